@@ -0,0 +1,74 @@
+// Command schooner-agent runs on a remote server and deploys the apps a
+// Schooner instance assigns to it on the server's Docker daemon.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"schooner/internal/agent"
+	"schooner/internal/docker"
+	"schooner/internal/version"
+)
+
+const usage = `Usage: schooner-agent --url URL --token TOKEN
+
+Connects to a Schooner server, runs the apps it assigns to this server on the
+local Docker daemon and reports their status. Create the agent and its token
+under Settings → Agents. The URL and token can also be set with SCHOONER_URL
+and SCHOONER_AGENT_TOKEN.
+`
+
+func main() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
+	slog.SetDefault(logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("schooner-agent", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	urlFlag := flags.String("url", os.Getenv("SCHOONER_URL"), "Schooner server URL")
+	tokenFlag := flags.String("token", os.Getenv("SCHOONER_AGENT_TOKEN"), "agent token")
+	versionFlag := flags.Bool("version", false, "print the agent version")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *versionFlag {
+		fmt.Println("schooner-agent", version.GetShortCommit())
+		return nil
+	}
+	if *urlFlag == "" || *tokenFlag == "" {
+		flags.Usage()
+		return errors.New("server URL and token are required")
+	}
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return err
+	}
+	defer dockerClient.Close()
+
+	if err := dockerClient.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to reach Docker daemon: %w", err)
+	}
+
+	slog.Info("starting schooner-agent", "server", *urlFlag, "version", version.GetShortCommit())
+	return agent.New(*urlFlag, *tokenFlag, version.GetShortCommit(), dockerClient).Run(ctx)
+}
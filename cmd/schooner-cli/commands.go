@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"schooner/internal/cli"
+	"schooner/internal/models"
+)
+
+// runApps handles `apps list`
+func runApps(ctx context.Context, client *cli.Client, args []string) error {
+	if len(args) == 0 || args[0] != "list" {
+		return errors.New("usage: schooner-cli apps list")
+	}
+
+	apps, err := client.ListApps(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tID\tBRANCH\tSTRATEGY\tAUTO DEPLOY")
+	for _, app := range apps {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%t\n", app.Name, app.ID, app.Branch, app.BuildStrategy, app.AutoDeploy)
+	}
+	return tw.Flush()
+}
+
+// runDeploy handles `deploy <app> [-f]`
+func runDeploy(ctx context.Context, client *cli.Client, args []string) error {
+	fs := flag.NewFlagSet("deploy", flag.ContinueOnError)
+	follow := fs.Bool("f", false, "follow the build log")
+	appArg, err := parseWithArg(fs, args, "usage: schooner-cli deploy <app> [-f]")
+	if err != nil {
+		return err
+	}
+
+	app, err := client.FindApp(ctx, appArg)
+	if err != nil {
+		return err
+	}
+
+	buildID, err := client.Deploy(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Build %s queued for %s\n", buildID, app.Name)
+
+	if !*follow {
+		return nil
+	}
+	return tailBuild(ctx, client, buildID)
+}
+
+// runBuilds handles `builds list` and `builds tail <id>`
+func runBuilds(ctx context.Context, client *cli.Client, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: schooner-cli builds list|tail")
+	}
+
+	switch args[0] {
+	case "list":
+		return listBuilds(ctx, client, args[1:])
+	case "tail":
+		if len(args) != 2 {
+			return errors.New("usage: schooner-cli builds tail <build-id>")
+		}
+		return tailBuild(ctx, client, args[1])
+	}
+	return fmt.Errorf("unknown builds command %q", args[0])
+}
+
+func listBuilds(ctx context.Context, client *cli.Client, args []string) error {
+	fs := flag.NewFlagSet("builds list", flag.ContinueOnError)
+	appArg := fs.String("app", "", "only show builds for this app")
+	limit := fs.Int("limit", 20, "number of builds to show")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var appID string
+	if *appArg != "" {
+		app, err := client.FindApp(ctx, *appArg)
+		if err != nil {
+			return err
+		}
+		appID = app.ID
+	}
+
+	builds, err := client.ListBuilds(ctx, appID, *limit)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tAPP\tSTATUS\tCOMMIT\tCREATED")
+	for _, build := range builds {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", build.ID, build.AppName, build.Status, build.GetShortSHA(), build.CreatedAt.Local().Format(time.DateTime))
+	}
+	return tw.Flush()
+}
+
+// tailBuild streams a build's logs and fails if the build did not succeed
+func tailBuild(ctx context.Context, client *cli.Client, buildID string) error {
+	status, err := client.TailBuild(ctx, buildID, func(log models.BuildLog) {
+		fmt.Printf("%s %s\n", log.Timestamp.Local().Format(time.TimeOnly), log.Message)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Build %s finished: %s\n", buildID, status)
+	if status != models.BuildStatusSuccess {
+		return fmt.Errorf("build %s", status)
+	}
+	return nil
+}
+
+// runLogs handles `logs <app> [-f] [--since 1h]`
+func runLogs(ctx context.Context, client *cli.Client, args []string) error {
+	fs := flag.NewFlagSet("logs", flag.ContinueOnError)
+	follow := fs.Bool("f", false, "follow new log lines")
+	since := fs.Duration("since", time.Hour, "how far back to show logs")
+	limit := fs.Int("limit", 1000, "maximum number of lines")
+	appArg, err := parseWithArg(fs, args, "usage: schooner-cli logs <app> [-f] [--since 1h]")
+	if err != nil {
+		return err
+	}
+
+	app, err := client.FindApp(ctx, appArg)
+	if err != nil {
+		return err
+	}
+
+	printLine := func(entry cli.LogEntry) {
+		fmt.Printf("%s %s\n", entry.Timestamp.Local().Format(time.DateTime), entry.Message)
+	}
+
+	if *follow {
+		return client.FollowAppLogs(ctx, app.ID, printLine)
+	}
+
+	entries, err := client.AppLogs(ctx, app.ID, time.Now().Add(-*since), *limit)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		printLine(entry)
+	}
+	return nil
+}
+
+// parseWithArg parses flags around a single positional argument, so both
+// `deploy web -f` and `deploy -f web` work
+func parseWithArg(fs *flag.FlagSet, args []string, usage string) (string, error) {
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+
+	var positional []string
+	for fs.NArg() > 0 {
+		positional = append(positional, fs.Arg(0))
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return "", err
+		}
+	}
+
+	if len(positional) != 1 {
+		return "", errors.New(usage)
+	}
+	return positional[0], nil
+}
@@ -0,0 +1,131 @@
+// Command schooner-cli manages a Schooner server from the terminal using a
+// personal API token.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"schooner/internal/cli"
+	"schooner/internal/version"
+)
+
+const usage = `Usage: schooner-cli [--url URL] [--token TOKEN] <command> [args]
+
+Commands:
+  login --url URL --token TOKEN   Save server and API token
+  apps list                       List apps
+  deploy <app> [-f]               Deploy an app, optionally following the build
+  builds list [--app APP]         List recent builds
+  builds tail <build-id>          Stream a build's logs until it finishes
+  logs <app> [-f] [--since 1h]    Show container logs, optionally following
+  version                         Print the CLI version
+
+Apps can be given by name or ID. The server URL and token can also be set
+with SCHOONER_URL and SCHOONER_TOKEN.
+`
+
+// command is a subcommand that runs against the API
+type command func(ctx context.Context, client *cli.Client, args []string) error
+
+var commands = map[string]command{
+	"apps":   runApps,
+	"deploy": runDeploy,
+	"builds": runBuilds,
+	"logs":   runLogs,
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	global := flag.NewFlagSet("schooner-cli", flag.ContinueOnError)
+	global.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	urlFlag := global.String("url", "", "Schooner server URL")
+	tokenFlag := global.String("token", "", "personal API token")
+	if err := global.Parse(args); err != nil {
+		return err
+	}
+
+	args = global.Args()
+	if len(args) == 0 {
+		global.Usage()
+		return errors.New("no command given")
+	}
+
+	configPath, err := cli.DefaultConfigPath()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "version":
+		fmt.Println("schooner-cli", version.GetShortCommit())
+		return nil
+	case "login":
+		return runLogin(ctx, configPath, args[1:])
+	case "help", "-h", "--help":
+		global.Usage()
+		return nil
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		global.Usage()
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+
+	cfg, err := cli.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	if *urlFlag != "" {
+		cfg.URL = *urlFlag
+	}
+	if *tokenFlag != "" {
+		cfg.Token = *tokenFlag
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	return cmd(ctx, cli.NewClient(cfg.URL, cfg.Token), args[1:])
+}
+
+// runLogin verifies a server URL and token, then saves them
+func runLogin(ctx context.Context, configPath string, args []string) error {
+	fs := flag.NewFlagSet("login", flag.ContinueOnError)
+	urlFlag := fs.String("url", "", "Schooner server URL")
+	tokenFlag := fs.String("token", "", "personal API token")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := &cli.Config{URL: *urlFlag, Token: *tokenFlag}
+	if err := cfg.Validate(); err != nil {
+		return errors.New("login requires --url and --token")
+	}
+
+	if _, err := cli.NewClient(cfg.URL, cfg.Token).ListApps(ctx); err != nil {
+		return fmt.Errorf("could not authenticate: %w", err)
+	}
+
+	if err := cfg.Save(configPath); err != nil {
+		return err
+	}
+
+	fmt.Println("Logged in to", cfg.URL)
+	return nil
+}
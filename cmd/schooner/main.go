@@ -11,17 +11,24 @@ import (
 	"time"
 
 	"schooner/internal/api"
+	"schooner/internal/backup"
 	"schooner/internal/config"
 	"schooner/internal/database"
+	"schooner/internal/logging"
+	"schooner/internal/tracing"
 )
 
 var version = "dev"
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "validate-config" {
+		os.Exit(validateConfig(os.Args[2:]))
+	}
+
 	// Setup structured logging
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+	logger := slog.New(logging.NewContextHandler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
-	}))
+	})))
 	slog.SetDefault(logger)
 
 	// Load configuration
@@ -31,8 +38,27 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Export traces of webhooks and builds if a collector is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, version)
+	if err != nil {
+		slog.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
 	// Initialize database
-	db, err := database.New(cfg.Database.Path)
+	var db *database.DB
+	if cfg.Database.Driver == "postgres" {
+		db, err = database.NewPostgres(cfg.Database.URL)
+	} else {
+		// Swap in a system backup staged for restore before opening the database
+		if restored, err := backup.ApplyPendingRestore(cfg.Database.Path); err != nil {
+			slog.Error("failed to restore system backup", "error", err)
+			os.Exit(1)
+		} else if restored {
+			slog.Info("restored database from system backup", "path", cfg.Database.Path)
+		}
+		db, err = database.New(cfg.Database.Path)
+	}
 	if err != nil {
 		slog.Error("failed to initialize database", "error", err)
 		os.Exit(1)
@@ -69,6 +95,21 @@ func main() {
 		}
 	}()
 
+	// Reload the config file on SIGHUP
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			result, err := cfg.Reload()
+			if err != nil {
+				slog.Error("failed to reload config", "file", cfg.File(), "error", err)
+				continue
+			}
+			slog.Info("reloaded config", "file", cfg.File(),
+				"changed", result.Changed, "restart_required", result.RestartRequired)
+		}
+	}()
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -83,6 +124,9 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		slog.Error("server forced to shutdown", "error", err)
 	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Warn("failed to flush traces", "error", err)
+	}
 
 	slog.Info("server stopped")
 }
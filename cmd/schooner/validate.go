@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"schooner/internal/config"
+)
+
+const validateUsage = `Usage: schooner validate-config [FILE]
+
+Checks a config file without starting Schooner, listing every problem found,
+unknown settings included. Without FILE, the file Schooner would load is
+checked. Exits with status 1 if the config is invalid.
+`
+
+// validateConfig checks a config file and returns the exit status
+func validateConfig(args []string) int {
+	if len(args) > 1 || (len(args) == 1 && strings.HasPrefix(args[0], "-")) {
+		fmt.Fprint(os.Stderr, validateUsage)
+		return 2
+	}
+	path := ""
+	if len(args) == 1 {
+		path = args[0]
+	}
+
+	cfg, err := config.Check(path)
+	name := path
+	if cfg != nil {
+		name = cfg.File()
+	}
+	if name == "" {
+		name = "config (no file found, defaults and environment only)"
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s is invalid:\n", name)
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "  - %s\n", line)
+		}
+		return 1
+	}
+	fmt.Printf("%s is valid\n", name)
+	return 0
+}
@@ -0,0 +1,183 @@
+package addons
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+// passwordBytes is the entropy of generated add-on passwords
+const passwordBytes = 24
+
+// AddonLister lists the add-ons provisioned for an app
+type AddonLister interface {
+	ListByAppID(ctx context.Context, appID string) ([]*models.Addon, error)
+}
+
+// Manager runs the add-on containers of apps
+type Manager struct {
+	dockerClient *docker.Client
+	addons       AddonLister
+}
+
+// NewManager creates a new add-on manager
+func NewManager(dockerClient *docker.Client, addons AddonLister) *Manager {
+	return &Manager{
+		dockerClient: dockerClient,
+		addons:       addons,
+	}
+}
+
+// GeneratePassword returns a random password for a new add-on
+func GeneratePassword() (string, error) {
+	b := make([]byte, passwordBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// containerConfig returns the container an add-on runs in. Its data lives
+// in a named volume so it survives the container being recreated.
+func containerConfig(app *models.App, addon *models.Addon) docker.ContainerConfig {
+	return docker.ContainerConfig{
+		Name:  addon.ContainerName,
+		Image: addon.Image,
+		Cmd:   addon.ContainerCmd(),
+		Env:   addon.ContainerEnv(),
+		// Not labelled schooner.app, which would match the app's own
+		// containers in status lookups
+		Labels: map[string]string{
+			"schooner.managed":      "true",
+			"schooner.service":      "addon",
+			"schooner.addon":        addon.Name,
+			"schooner.addon-id":     addon.ID,
+			"schooner.addon-app-id": app.ID,
+		},
+		RestartPolicy: "unless-stopped",
+		Volumes: map[string]string{
+			addon.VolumeName(): addon.DataPath(),
+		},
+		Networks: []string{app.Network()},
+	}
+}
+
+// Provision starts an add-on's container on the app's add-on network,
+// replacing any existing container of the same name
+func (m *Manager) Provision(ctx context.Context, app *models.App, addon *models.Addon) error {
+	if err := m.dockerClient.EnsureNetwork(ctx, app.Network()); err != nil {
+		return err
+	}
+
+	_ = m.dockerClient.StopContainer(ctx, addon.ContainerName, 10)
+	_ = m.dockerClient.RemoveContainer(ctx, addon.ContainerName)
+
+	containerID, err := m.dockerClient.CreateAndStartContainer(ctx, containerConfig(app, addon))
+	if err != nil {
+		return fmt.Errorf("failed to start %s add-on: %w", addon.Type, err)
+	}
+
+	slog.Info("add-on started", "app", app.Name, "addon", addon.Name, "type", addon.Type, "container_id", containerID[:12])
+	return nil
+}
+
+// Remove stops and removes an add-on's container, and its data volume if
+// deleteData is set
+func (m *Manager) Remove(ctx context.Context, addon *models.Addon, deleteData bool) error {
+	_ = m.dockerClient.StopContainer(ctx, addon.ContainerName, 10)
+	if err := m.dockerClient.RemoveContainer(ctx, addon.ContainerName); err != nil {
+		slog.Warn("failed to remove add-on container", "addon", addon.Name, "error", err)
+	}
+
+	if deleteData {
+		if err := m.dockerClient.RemoveVolume(ctx, addon.VolumeName()); err != nil {
+			return fmt.Errorf("failed to remove add-on data: %w", err)
+		}
+	}
+	return nil
+}
+
+// RemoveApp removes the containers of all an app's add-ons before it is
+// deleted. Their data volumes are kept.
+func (m *Manager) RemoveApp(ctx context.Context, app *models.App) error {
+	addons, err := m.addons.ListByAppID(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+	for _, addon := range addons {
+		if err := m.Remove(ctx, addon, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Status returns the state of an add-on's container, nil if it is missing
+func (m *Manager) Status(ctx context.Context, addon *models.Addon) *docker.ContainerStatus {
+	status, err := m.dockerClient.GetContainerStatus(ctx, addon.ContainerName)
+	if err != nil {
+		return nil
+	}
+	return status
+}
+
+// AddonEnv returns the connection URLs of an app's add-ons, keyed by the env
+// var each is injected as
+func (m *Manager) AddonEnv(ctx context.Context, app *models.App) (map[string]string, error) {
+	addons, err := m.addons.ListByAppID(ctx, app.ID)
+	if err != nil {
+		return nil, err
+	}
+	return Env(addons), nil
+}
+
+// Env maps add-ons to their connection URLs, keyed by env var
+func Env(addons []*models.Addon) map[string]string {
+	env := make(map[string]string, len(addons))
+	for _, addon := range addons {
+		env[addon.EnvVar] = addon.ConnectionURL()
+	}
+	return env
+}
+
+// Ensure starts the add-on containers of an app that stopped and recreates
+// the ones that are gone, keeping their data. It returns what it fixed.
+func (m *Manager) Ensure(ctx context.Context, app *models.App) ([]string, error) {
+	addons, err := m.addons.ListByAppID(ctx, app.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixed []string
+	var errs []error
+	for _, addon := range addons {
+		status, err := m.dockerClient.GetContainerStatus(ctx, addon.ContainerName)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		switch status.State {
+		case "running", "restarting", "paused":
+			continue
+		case "not_found":
+			if err := m.Provision(ctx, app, addon); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			fixed = append(fixed, fmt.Sprintf("recreated add-on %s", addon.Name))
+		default:
+			if err := m.dockerClient.StartContainer(ctx, addon.ContainerName); err != nil {
+				errs = append(errs, fmt.Errorf("failed to start %s add-on: %w", addon.Type, err))
+				continue
+			}
+			fixed = append(fixed, fmt.Sprintf("started add-on %s", addon.Name))
+		}
+	}
+	return fixed, errors.Join(errs...)
+}
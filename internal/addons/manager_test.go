@@ -0,0 +1,48 @@
+package addons
+
+import (
+	"strings"
+	"testing"
+
+	"schooner/internal/models"
+)
+
+func TestContainerConfig(t *testing.T) {
+	app := &models.App{ID: "0f8c2a9e-1111", Name: "blog"}
+	addon := models.NewAddon(app, models.AddonRedis, "cache")
+	addon.ID = "addon-1"
+	addon.Password = "secret"
+
+	cfg := containerConfig(app, addon)
+	if cfg.Name != "schooner-blog-cache" || cfg.Image != "redis:7-alpine" {
+		t.Errorf("Name, Image = %q, %q", cfg.Name, cfg.Image)
+	}
+	if got := strings.Join(cfg.Cmd, " "); got != "redis-server --requirepass secret --appendonly yes" {
+		t.Errorf("Cmd = %q", got)
+	}
+	if cfg.Volumes["schooner-blog-cache-data"] != "/data" {
+		t.Errorf("Volumes = %v, want the data volume", cfg.Volumes)
+	}
+	if len(cfg.Networks) != 1 || cfg.Networks[0] != "schooner-addons-0f8c2a9e" {
+		t.Errorf("Networks = %v", cfg.Networks)
+	}
+	if _, ok := cfg.Labels["schooner.app"]; ok {
+		t.Error("add-on containers must not carry the app's schooner.app label")
+	}
+}
+
+func TestEnv(t *testing.T) {
+	app := &models.App{ID: "app", Name: "blog"}
+	db := models.NewAddon(app, models.AddonPostgres, "db")
+	db.Password = "pw"
+	cache := models.NewAddon(app, models.AddonRedis, "cache")
+	cache.Password = "pw"
+
+	env := Env([]*models.Addon{db, cache})
+	if env["DATABASE_URL"] != "postgres://schooner:pw@schooner-blog-db:5432/db" {
+		t.Errorf("DATABASE_URL = %q", env["DATABASE_URL"])
+	}
+	if env["REDIS_URL"] != "redis://:pw@schooner-blog-cache:6379/0" {
+		t.Errorf("REDIS_URL = %q", env["REDIS_URL"])
+	}
+}
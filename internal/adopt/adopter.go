@@ -0,0 +1,72 @@
+package adopt
+
+import (
+	"context"
+	"fmt"
+
+	"schooner/internal/docker"
+)
+
+// Adopter inspects existing containers and plans the apps that adopt them
+type Adopter struct {
+	dockerClient *docker.Client
+}
+
+// NewAdopter creates a new adopter
+func NewAdopter(dockerClient *docker.Client) *Adopter {
+	return &Adopter{dockerClient: dockerClient}
+}
+
+// Plan inspects a container, along with the rest of its compose project if
+// it belongs to one, and plans the app adopting them
+func (a *Adopter) Plan(ctx context.Context, containerID string) (*Plan, error) {
+	info, err := a.dockerClient.InspectContainer(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	if info.Config.Labels["schooner.managed"] == "true" {
+		return nil, fmt.Errorf("container %s is already managed by Schooner", containerName(info))
+	}
+
+	ids := []string{info.ID}
+	if project := info.Config.Labels[composeProjectLabel]; project != "" {
+		containers, err := a.dockerClient.ListContainers(ctx, true, map[string]string{composeProjectLabel: project})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list compose project containers: %w", err)
+		}
+		ids = ids[:0]
+		for _, c := range containers {
+			if c.Labels["schooner.managed"] == "true" {
+				return nil, fmt.Errorf("compose project %s is already managed by Schooner", project)
+			}
+			ids = append(ids, c.ID)
+		}
+	}
+
+	sources := make([]Source, 0, len(ids))
+	for _, id := range ids {
+		info, err := a.dockerClient.InspectContainer(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		image, err := a.dockerClient.ImageConfig(ctx, info.Image)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, Source{Container: info, Image: image})
+	}
+
+	return NewPlan(sources)
+}
+
+// Release removes the standalone containers a plan replaces, freeing their
+// name and ports for the first deploy. Their volumes are kept. Compose
+// projects are left running, compose recreates their containers itself.
+func (a *Adopter) Release(ctx context.Context, plan *Plan) error {
+	for _, id := range plan.Replace {
+		if err := a.dockerClient.StopAndRemove(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
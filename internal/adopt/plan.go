@@ -0,0 +1,370 @@
+// Package adopt turns containers started outside Schooner, on their own or
+// as a compose project, into apps Schooner deploys and restarts.
+//
+// Adoption reads each container's image, env, ports, volumes and networks
+// and writes them out as a compose file stored on the app. The first deploy
+// recreates the containers from that file under Schooner's labels. Volumes
+// and networks are referenced as external so their data carries over.
+package adopt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	"gopkg.in/yaml.v3"
+
+	"schooner/internal/models"
+)
+
+const (
+	composeProjectLabel = "com.docker.compose.project"
+	composeServiceLabel = "com.docker.compose.service"
+)
+
+// Source is an existing container along with the defaults of its image,
+// which are left out of the generated compose file
+type Source struct {
+	Container types.ContainerJSON
+	Image     *container.Config
+}
+
+// Plan is the app that adopting a set of containers creates
+type Plan struct {
+	Name       string            `json:"name"`        // Suggested app name
+	Project    string            `json:"project"`     // Compose project the app runs as
+	Services   []string          `json:"services"`    // Services in the compose file
+	Compose    string            `json:"compose"`     // Generated compose file
+	EnvVars    map[string]string `json:"env_vars"`    // Env moved into the app's env vars
+	PublicPort int               `json:"public_port"` // First published TCP port, 0 if none
+	Replace    []string          `json:"-"`           // Standalone containers the first deploy replaces
+}
+
+type composeFile struct {
+	Name     string                 `yaml:"name"`
+	Services map[string]*service    `yaml:"services"`
+	Volumes  map[string]externalRef `yaml:"volumes,omitempty"`
+	Networks map[string]externalRef `yaml:"networks,omitempty"`
+}
+
+type service struct {
+	Image       string            `yaml:"image"`
+	Restart     string            `yaml:"restart,omitempty"`
+	Entrypoint  []string          `yaml:"entrypoint,omitempty"`
+	Command     []string          `yaml:"command,omitempty"`
+	User        string            `yaml:"user,omitempty"`
+	WorkingDir  string            `yaml:"working_dir,omitempty"`
+	Environment []string          `yaml:"environment,omitempty"`
+	Ports       []string          `yaml:"ports,omitempty"`
+	Volumes     []string          `yaml:"volumes,omitempty"`
+	NetworkMode string            `yaml:"network_mode,omitempty"`
+	Networks    []string          `yaml:"networks,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+
+	env map[string]string
+}
+
+// externalRef points the compose file at a volume or network that already
+// exists, so compose neither renames nor recreates it
+type externalRef struct {
+	Name     string `yaml:"name"`
+	External bool   `yaml:"external"`
+}
+
+// NewPlan builds the plan for adopting containers. They must be a single
+// standalone container or the containers of one compose project.
+func NewPlan(sources []Source) (*Plan, error) {
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no containers to adopt")
+	}
+
+	project := sources[0].Container.Config.Labels[composeProjectLabel]
+	if project == "" && len(sources) > 1 {
+		return nil, fmt.Errorf("only containers of a compose project can be adopted together")
+	}
+
+	plan := &Plan{EnvVars: make(map[string]string)}
+	if project != "" {
+		plan.Name = project
+		plan.Project = project
+	} else {
+		plan.Name = containerName(sources[0].Container)
+		plan.Project = models.ComposeProjectName(plan.Name)
+		plan.Replace = []string{sources[0].Container.ID}
+	}
+
+	file := composeFile{
+		Name:     plan.Project,
+		Services: make(map[string]*service),
+		Volumes:  make(map[string]externalRef),
+		Networks: make(map[string]externalRef),
+	}
+
+	for _, src := range sources {
+		if src.Container.Config.Labels[composeProjectLabel] != project {
+			return nil, fmt.Errorf("container %s is not part of compose project %s", containerName(src.Container), project)
+		}
+
+		name := src.Container.Config.Labels[composeServiceLabel]
+		if name == "" {
+			name = models.ComposeProjectName(containerName(src.Container))
+		}
+		if _, ok := file.Services[name]; ok {
+			// Scaled services run several identical containers
+			continue
+		}
+
+		svc := newService(src, &file, project)
+		file.Services[name] = svc
+		plan.Services = append(plan.Services, name)
+	}
+	sort.Strings(plan.Services)
+
+	// Env vars that mean the same to every service move into the app, where
+	// they can be edited; conflicting values stay in their service
+	shared := sharedEnv(file.Services)
+	for _, name := range plan.Services {
+		svc := file.Services[name]
+		for _, key := range sortedKeys(svc.env) {
+			if value, ok := shared[key]; ok {
+				plan.EnvVars[key] = value
+				svc.Environment = append(svc.Environment, key)
+			} else {
+				svc.Environment = append(svc.Environment, key+"="+escape(svc.env[key]))
+			}
+		}
+
+		if plan.PublicPort == 0 {
+			plan.PublicPort = firstTCPPort(svc.Ports)
+		}
+	}
+
+	out, err := yaml.Marshal(&file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate compose file: %w", err)
+	}
+	plan.Compose = string(out)
+	return plan, nil
+}
+
+// newService converts a container into a compose service, registering the
+// volumes and networks it uses with the compose file
+func newService(src Source, file *composeFile, project string) *service {
+	cfg := src.Container.Config
+	hostCfg := src.Container.HostConfig
+	image := src.Image
+	if image == nil {
+		image = &container.Config{}
+	}
+
+	svc := &service{
+		Image: cfg.Image,
+		env:   make(map[string]string),
+	}
+
+	if hostCfg != nil {
+		svc.Restart = restartPolicy(hostCfg.RestartPolicy)
+		svc.Ports = portSpecs(hostCfg.PortBindings)
+
+		mode := string(hostCfg.NetworkMode)
+		if mode == "host" || mode == "none" || strings.HasPrefix(mode, "container:") {
+			svc.NetworkMode = mode
+		}
+	}
+
+	if !equalStrings(cfg.Entrypoint, image.Entrypoint) {
+		svc.Entrypoint = escapeAll(cfg.Entrypoint)
+	}
+	if !equalStrings(cfg.Cmd, image.Cmd) {
+		svc.Command = escapeAll(cfg.Cmd)
+	}
+	if cfg.User != image.User {
+		svc.User = cfg.User
+	}
+	if cfg.WorkingDir != image.WorkingDir {
+		svc.WorkingDir = cfg.WorkingDir
+	}
+
+	imageEnv := make(map[string]bool, len(image.Env))
+	for _, e := range image.Env {
+		imageEnv[e] = true
+	}
+	for _, e := range cfg.Env {
+		if imageEnv[e] {
+			continue
+		}
+		key, value, _ := strings.Cut(e, "=")
+		svc.env[key] = value
+	}
+
+	for _, m := range sortedMounts(src.Container.Mounts) {
+		mode := ""
+		if !m.RW {
+			mode = ":ro"
+		}
+		switch m.Type {
+		case "bind":
+			svc.Volumes = append(svc.Volumes, escape(m.Source)+":"+m.Destination+mode)
+		case "volume":
+			svc.Volumes = append(svc.Volumes, m.Name+":"+m.Destination+mode)
+			file.Volumes[m.Name] = externalRef{Name: m.Name, External: true}
+		}
+	}
+
+	if svc.NetworkMode == "" && src.Container.NetworkSettings != nil {
+		var networks []string
+		joinsDefault := false
+		for name := range src.Container.NetworkSettings.Networks {
+			switch {
+			case name == "bridge":
+			case project != "" && name == project+"_default":
+				joinsDefault = true
+			default:
+				networks = append(networks, name)
+				file.Networks[name] = externalRef{Name: name, External: true}
+			}
+		}
+		if len(networks) > 0 {
+			if joinsDefault {
+				networks = append(networks, "default")
+			}
+			sort.Strings(networks)
+			svc.Networks = networks
+		}
+	}
+
+	for k, v := range cfg.Labels {
+		if strings.HasPrefix(k, "com.docker.compose.") || strings.HasPrefix(k, "schooner.") {
+			continue
+		}
+		if imageValue, ok := image.Labels[k]; ok && imageValue == v {
+			continue
+		}
+		if svc.Labels == nil {
+			svc.Labels = make(map[string]string)
+		}
+		svc.Labels[k] = escape(v)
+	}
+
+	return svc
+}
+
+// sharedEnv returns the env vars whose value is the same in every service
+// that sets them
+func sharedEnv(services map[string]*service) map[string]string {
+	shared := make(map[string]string)
+	conflicting := make(map[string]bool)
+	for _, svc := range services {
+		for k, v := range svc.env {
+			if existing, ok := shared[k]; ok && existing != v {
+				conflicting[k] = true
+			}
+			shared[k] = v
+		}
+	}
+	for k := range conflicting {
+		delete(shared, k)
+	}
+	return shared
+}
+
+// restartPolicy formats a container's restart policy for compose
+func restartPolicy(policy container.RestartPolicy) string {
+	switch policy.Name {
+	case "", "no":
+		return ""
+	case "on-failure":
+		if policy.MaximumRetryCount > 0 {
+			return fmt.Sprintf("on-failure:%d", policy.MaximumRetryCount)
+		}
+	}
+	return string(policy.Name)
+}
+
+// portSpecs formats a container's port bindings as "[IP:]HOST:CONTAINER[/udp]"
+func portSpecs(bindings nat.PortMap) []string {
+	var specs []string
+	for port, hostBindings := range bindings {
+		suffix := ""
+		if port.Proto() != "tcp" {
+			suffix = "/" + port.Proto()
+		}
+		for _, b := range hostBindings {
+			if b.HostPort == "" {
+				continue
+			}
+			spec := b.HostPort + ":" + port.Port() + suffix
+			if b.HostIP != "" && b.HostIP != "0.0.0.0" && b.HostIP != "::" {
+				spec = b.HostIP + ":" + spec
+			}
+			specs = append(specs, spec)
+		}
+	}
+	sort.Strings(specs)
+	return specs
+}
+
+// firstTCPPort returns the host port of the first TCP port spec, 0 if none
+func firstTCPPort(specs []string) int {
+	for _, spec := range specs {
+		if strings.Contains(spec, "/") {
+			continue
+		}
+		parts := strings.Split(spec, ":")
+		var port int
+		if _, err := fmt.Sscanf(parts[len(parts)-2], "%d", &port); err == nil && port > 0 {
+			return port
+		}
+	}
+	return 0
+}
+
+// containerName returns a container's name without the leading slash
+func containerName(info types.ContainerJSON) string {
+	return strings.TrimPrefix(info.Name, "/")
+}
+
+func sortedMounts(mounts []types.MountPoint) []types.MountPoint {
+	sorted := append([]types.MountPoint(nil), mounts...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Destination < sorted[j].Destination
+	})
+	return sorted
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// escape protects a literal value from compose's variable interpolation
+func escape(s string) string {
+	return strings.ReplaceAll(s, "$", "$$")
+}
+
+func escapeAll(values []string) []string {
+	escaped := make([]string, len(values))
+	for i, v := range values {
+		escaped[i] = escape(v)
+	}
+	return escaped
+}
@@ -0,0 +1,196 @@
+package adopt
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	"gopkg.in/yaml.v3"
+)
+
+func testContainer(name string, labels map[string]string, env []string) types.ContainerJSON {
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:         name + "-id",
+			Name:       "/" + name,
+			HostConfig: &container.HostConfig{},
+		},
+		Config: &container.Config{
+			Image:  "example/" + name + ":1",
+			Env:    env,
+			Labels: labels,
+		},
+		NetworkSettings: &types.NetworkSettings{},
+	}
+}
+
+func parseCompose(t *testing.T, plan *Plan) composeFile {
+	t.Helper()
+	var file composeFile
+	if err := yaml.Unmarshal([]byte(plan.Compose), &file); err != nil {
+		t.Fatalf("generated compose doesn't parse: %v\n%s", err, plan.Compose)
+	}
+	return file
+}
+
+func TestNewPlanStandalone(t *testing.T) {
+	c := testContainer("My Blog", map[string]string{
+		"maintainer":           "image",
+		"traefik.enable":       "true",
+		"com.docker.compose.x": "skip",
+	}, []string{"PATH=/usr/bin", "DB_PASSWORD=pa$$word", "MODE=prod"})
+	c.HostConfig.RestartPolicy = container.RestartPolicy{Name: "unless-stopped"}
+	c.HostConfig.PortBindings = nat.PortMap{
+		"2368/tcp": {{HostPort: "8080"}},
+		"53/udp":   {{HostIP: "127.0.0.1", HostPort: "5353"}},
+	}
+	c.HostConfig.NetworkMode = "bridge"
+	c.Config.Cmd = []string{"node", "index.js", "--port=$PORT"}
+	c.Mounts = []types.MountPoint{
+		{Type: "volume", Name: "blog-content", Destination: "/var/lib/ghost/content", RW: true},
+		{Type: "bind", Source: "/srv/config.json", Destination: "/config.json", RW: false},
+	}
+	c.NetworkSettings.Networks = map[string]*network.EndpointSettings{"bridge": {}}
+
+	image := &container.Config{
+		Env:    []string{"PATH=/usr/bin"},
+		Cmd:    []string{"node", "current/index.js"},
+		Labels: map[string]string{"maintainer": "image"},
+	}
+
+	plan, err := NewPlan([]Source{{Container: c, Image: image}})
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+
+	if plan.Name != "My Blog" || plan.Project != "my-blog" {
+		t.Errorf("name, project = %q, %q", plan.Name, plan.Project)
+	}
+	if !reflect.DeepEqual(plan.Replace, []string{"My Blog-id"}) {
+		t.Errorf("Replace = %v", plan.Replace)
+	}
+	if plan.PublicPort != 8080 {
+		t.Errorf("PublicPort = %d, want 8080", plan.PublicPort)
+	}
+	wantEnv := map[string]string{"DB_PASSWORD": "pa$$word", "MODE": "prod"}
+	if !reflect.DeepEqual(plan.EnvVars, wantEnv) {
+		t.Errorf("EnvVars = %v, want %v", plan.EnvVars, wantEnv)
+	}
+
+	file := parseCompose(t, plan)
+	if file.Name != "my-blog" {
+		t.Errorf("compose name = %q", file.Name)
+	}
+	svc := file.Services["my-blog"]
+	if svc == nil {
+		t.Fatalf("service my-blog missing: %v", file.Services)
+	}
+
+	want := &service{
+		Image:       "example/My Blog:1",
+		Restart:     "unless-stopped",
+		Command:     []string{"node", "index.js", "--port=$$PORT"},
+		Environment: []string{"DB_PASSWORD", "MODE"},
+		Ports:       []string{"127.0.0.1:5353:53/udp", "8080:2368"},
+		Volumes:     []string{"/srv/config.json:/config.json:ro", "blog-content:/var/lib/ghost/content"},
+		Labels:      map[string]string{"traefik.enable": "true"},
+	}
+	if !reflect.DeepEqual(svc, want) {
+		t.Errorf("service = %+v\nwant %+v", svc, want)
+	}
+	if ref := file.Volumes["blog-content"]; !ref.External || ref.Name != "blog-content" {
+		t.Errorf("volume = %+v, want external blog-content", ref)
+	}
+	if len(file.Networks) != 0 {
+		t.Errorf("networks = %v, want none", file.Networks)
+	}
+}
+
+func TestNewPlanComposeProject(t *testing.T) {
+	labels := func(svc string) map[string]string {
+		return map[string]string{composeProjectLabel: "shop", composeServiceLabel: svc}
+	}
+
+	web := testContainer("shop-web-1", labels("web"), []string{"DB_HOST=db", "ROLE=web"})
+	web.HostConfig.PortBindings = nat.PortMap{"3000/tcp": {{HostPort: "3000"}}}
+	web.NetworkSettings.Networks = map[string]*network.EndpointSettings{"shop_default": {}, "proxy": {}}
+
+	web2 := testContainer("shop-web-2", labels("web"), nil)
+
+	db := testContainer("shop-db-1", labels("db"), []string{"DB_HOST=db", "ROLE=db"})
+	db.NetworkSettings.Networks = map[string]*network.EndpointSettings{"shop_default": {}}
+
+	plan, err := NewPlan([]Source{{Container: web}, {Container: web2}, {Container: db}})
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+
+	if plan.Name != "shop" || plan.Project != "shop" {
+		t.Errorf("name, project = %q, %q", plan.Name, plan.Project)
+	}
+	if len(plan.Replace) != 0 {
+		t.Errorf("Replace = %v, want none for compose projects", plan.Replace)
+	}
+	if !reflect.DeepEqual(plan.Services, []string{"db", "web"}) {
+		t.Errorf("Services = %v", plan.Services)
+	}
+	if plan.PublicPort != 3000 {
+		t.Errorf("PublicPort = %d, want 3000", plan.PublicPort)
+	}
+	if !reflect.DeepEqual(plan.EnvVars, map[string]string{"DB_HOST": "db"}) {
+		t.Errorf("EnvVars = %v, want only the shared DB_HOST", plan.EnvVars)
+	}
+
+	file := parseCompose(t, plan)
+	if got := file.Services["web"].Environment; !reflect.DeepEqual(got, []string{"DB_HOST", "ROLE=web"}) {
+		t.Errorf("web environment = %v", got)
+	}
+	if got := file.Services["web"].Networks; !reflect.DeepEqual(got, []string{"default", "proxy"}) {
+		t.Errorf("web networks = %v", got)
+	}
+	if got := file.Services["db"].Networks; got != nil {
+		t.Errorf("db networks = %v, want default only", got)
+	}
+	if ref := file.Networks["proxy"]; !ref.External {
+		t.Errorf("proxy network = %+v, want external", ref)
+	}
+}
+
+func TestNewPlanRejectsMixedContainers(t *testing.T) {
+	a := testContainer("a", nil, nil)
+	b := testContainer("b", nil, nil)
+	if _, err := NewPlan([]Source{{Container: a}, {Container: b}}); err == nil {
+		t.Error("NewPlan() with two standalone containers error = nil")
+	}
+
+	c := testContainer("c", map[string]string{composeProjectLabel: "one"}, nil)
+	d := testContainer("d", map[string]string{composeProjectLabel: "two"}, nil)
+	if _, err := NewPlan([]Source{{Container: c}, {Container: d}}); err == nil {
+		t.Error("NewPlan() across compose projects error = nil")
+	}
+
+	if _, err := NewPlan(nil); err == nil {
+		t.Error("NewPlan(nil) error = nil")
+	}
+}
+
+func TestRestartPolicy(t *testing.T) {
+	tests := []struct {
+		policy container.RestartPolicy
+		want   string
+	}{
+		{container.RestartPolicy{}, ""},
+		{container.RestartPolicy{Name: "no"}, ""},
+		{container.RestartPolicy{Name: "always"}, "always"},
+		{container.RestartPolicy{Name: "on-failure"}, "on-failure"},
+		{container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3}, "on-failure:3"},
+	}
+	for _, tt := range tests {
+		if got := restartPolicy(tt.policy); got != tt.want {
+			t.Errorf("restartPolicy(%+v) = %q, want %q", tt.policy, got, tt.want)
+		}
+	}
+}
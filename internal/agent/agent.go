@@ -0,0 +1,278 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"schooner/internal/docker"
+)
+
+// errUnauthorized means Schooner rejected the agent's token
+var errUnauthorized = errors.New("token rejected by server, was the agent removed?")
+
+// retryDelay is how long the agent waits after failing to reach Schooner
+const retryDelay = 5 * time.Second
+
+// stopTimeout is how long containers get to shut down on stop and restart
+const stopTimeout = 10 * time.Second
+
+// Agent runs on a remote server, carrying out Schooner's tasks on the local
+// Docker daemon and reporting its containers
+type Agent struct {
+	serverURL  string
+	token      string
+	version    string
+	docker     *docker.Client
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// New creates an agent that talks to the Schooner server at serverURL
+func New(serverURL, token, version string, dockerClient *docker.Client) *Agent {
+	return &Agent{
+		serverURL:  strings.TrimRight(serverURL, "/"),
+		token:      token,
+		version:    version,
+		docker:     dockerClient,
+		httpClient: &http.Client{},
+		logger:     slog.Default(),
+	}
+}
+
+// Run reports and polls for tasks until the context is cancelled or the
+// server rejects the token
+func (a *Agent) Run(ctx context.Context) error {
+	// Report once up front so Schooner sees the agent online right away
+	if err := a.report(ctx); err != nil {
+		if errors.Is(err, errUnauthorized) {
+			return err
+		}
+		a.logger.Warn("failed to report to server", "error", err)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	go func() {
+		ticker := time.NewTicker(ReportInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := a.report(ctx); err != nil {
+					if errors.Is(err, errUnauthorized) {
+						cancel(err)
+						return
+					}
+					a.logger.Warn("failed to report to server", "error", err)
+				}
+			}
+		}
+	}()
+
+	for {
+		tasks, err := a.poll(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			if errors.Is(err, errUnauthorized) {
+				return err
+			}
+			a.logger.Warn("failed to poll for tasks", "error", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+
+		for _, task := range tasks {
+			a.handle(ctx, task)
+		}
+	}
+
+	if cause := context.Cause(ctx); errors.Is(cause, errUnauthorized) {
+		return cause
+	}
+	return nil
+}
+
+// handle runs a task and sends its result back
+func (a *Agent) handle(ctx context.Context, task Task) {
+	a.logger.Info("running task", "type", task.Type, "container", task.Container)
+
+	var result TaskResult
+	containerID, err := a.execute(ctx, task)
+	if err != nil {
+		a.logger.Error("task failed", "type", task.Type, "container", task.Container, "error", err)
+		result.Error = err.Error()
+	}
+	result.ContainerID = containerID
+
+	if err := a.send(ctx, http.MethodPost, TasksPath+"/"+task.ID, result, nil); err != nil {
+		a.logger.Warn("failed to send task result", "type", task.Type, "error", err)
+	}
+}
+
+// execute carries out a task on the local daemon
+func (a *Agent) execute(ctx context.Context, task Task) (string, error) {
+	switch task.Type {
+	case TaskDeploy:
+		if task.Config == nil {
+			return "", fmt.Errorf("deploy task without container config")
+		}
+		if err := a.ensureImage(ctx, task.Config.Image); err != nil {
+			return "", err
+		}
+		return a.docker.RunContainer(ctx, *task.Config)
+	case TaskStart:
+		return "", a.docker.StartContainer(ctx, task.Container)
+	case TaskStop:
+		return "", a.docker.StopContainer(ctx, task.Container, stopTimeout)
+	case TaskRestart:
+		return "", a.docker.RestartContainer(ctx, task.Container, stopTimeout)
+	default:
+		return "", fmt.Errorf("unknown task type %q", task.Type)
+	}
+}
+
+// ensureImage downloads an image built by Schooner unless the daemon
+// already has it
+func (a *Agent) ensureImage(ctx context.Context, ref string) error {
+	ok, err := a.docker.HasImage(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("failed to inspect image: %w", err)
+	}
+	if ok {
+		return nil
+	}
+
+	a.logger.Info("downloading image", "image", ref)
+	req, err := a.newRequest(ctx, http.MethodGet, ImagePath+"?ref="+url.QueryEscape(ref), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download image: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return fmt.Errorf("failed to download image: %w", err)
+	}
+
+	return a.docker.LoadImage(ctx, resp.Body)
+}
+
+// report sends the status and stats of the Schooner-managed containers
+func (a *Agent) report(ctx context.Context) error {
+	containers, err := a.docker.ListContainers(ctx, true, map[string]string{"schooner.managed": "true"})
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+	report := Report{
+		Hostname:   hostname,
+		Version:    a.version,
+		Containers: []ContainerReport{},
+	}
+	for _, c := range containers {
+		status, err := a.docker.GetContainerStatus(ctx, c.ID)
+		if err != nil {
+			continue
+		}
+		cr := ContainerReport{Status: *status}
+		if status.State == "running" {
+			if stats, err := a.docker.GetContainerStats(ctx, c.ID); err == nil {
+				cr.Stats = stats
+			}
+		}
+		report.Containers = append(report.Containers, cr)
+	}
+
+	return a.send(ctx, http.MethodPost, ReportPath, report, nil)
+}
+
+// poll waits for the next batch of tasks
+func (a *Agent) poll(ctx context.Context) ([]Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, PollWait+30*time.Second)
+	defer cancel()
+
+	var tasks []Task
+	if err := a.send(ctx, http.MethodGet, TasksPath, nil, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// send makes an API request, encoding in as the body and decoding the
+// response into out when given
+func (a *Agent) send(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := a.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+func (a *Agent) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, a.serverURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	req.Header.Set("User-Agent", "schooner-agent/"+a.version)
+	return req, nil
+}
+
+// checkResponse turns error statuses into errors
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode == http.StatusUnauthorized {
+		return errUnauthorized
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("server returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
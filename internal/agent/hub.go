@@ -0,0 +1,272 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"schooner/internal/docker"
+)
+
+// ErrUnknownTask is returned for results of tasks nobody is waiting on
+var ErrUnknownTask = errors.New("unknown task")
+
+// defaultTaskTimeout bounds how long a dispatched task may take, including
+// an agent downloading the image of a deploy
+const defaultTaskTimeout = 10 * time.Minute
+
+// Hub is Schooner's side of the agent connections. It queues tasks for
+// agents, hands them out when agents poll and keeps each agent's latest
+// container report. State lives in memory; agents re-report after a restart.
+type Hub struct {
+	taskTimeout time.Duration
+	now         func() time.Time
+
+	mu     sync.Mutex
+	agents map[string]*agentState
+}
+
+// agentState is what the hub knows about one agent
+type agentState struct {
+	lastSeen time.Time
+	report   *Report
+	queue    []Task
+	wake     chan struct{} // signalled when a task is queued
+	pending  map[string]*pendingTask
+}
+
+// pendingTask is a dispatched task awaiting its result
+type pendingTask struct {
+	task Task
+	done chan TaskResult
+}
+
+// NewHub creates an empty hub
+func NewHub() *Hub {
+	return &Hub{
+		taskTimeout: defaultTaskTimeout,
+		now:         time.Now,
+		agents:      make(map[string]*agentState),
+	}
+}
+
+// state returns an agent's state, creating it on first use. Callers hold mu.
+func (h *Hub) state(agentID string) *agentState {
+	s, ok := h.agents[agentID]
+	if !ok {
+		s = &agentState{
+			wake:    make(chan struct{}, 1),
+			pending: make(map[string]*pendingTask),
+		}
+		h.agents[agentID] = s
+	}
+	return s
+}
+
+// Online reports whether an agent has been heard from recently
+func (h *Hub) Online(agentID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.online(agentID)
+}
+
+func (h *Hub) online(agentID string) bool {
+	s, ok := h.agents[agentID]
+	return ok && h.now().Sub(s.lastSeen) < offlineAfter
+}
+
+// Report stores an agent's container report
+func (h *Hub) Report(agentID string, report *Report) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.state(agentID)
+	s.report = report
+	s.lastSeen = h.now()
+}
+
+// Poll returns the tasks queued for an agent, waiting up to wait for one to
+// arrive when the queue is empty
+func (h *Hub) Poll(ctx context.Context, agentID string, wait time.Duration) []Task {
+	h.mu.Lock()
+	s := h.state(agentID)
+	s.lastSeen = h.now()
+	if tasks := s.take(); len(tasks) > 0 {
+		h.mu.Unlock()
+		return tasks
+	}
+	wake := s.wake
+	h.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-wake:
+	case <-timer.C:
+	case <-ctx.Done():
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.state(agentID).take()
+}
+
+// take empties the queue. Callers hold mu.
+func (s *agentState) take() []Task {
+	tasks := s.queue
+	s.queue = nil
+	return tasks
+}
+
+// Dispatch queues a task for an agent and waits for its result. Tasks for
+// offline agents fail straight away rather than waiting for them to return.
+func (h *Hub) Dispatch(ctx context.Context, agentID string, task Task) (*TaskResult, error) {
+	h.mu.Lock()
+	if !h.online(agentID) {
+		h.mu.Unlock()
+		return nil, fmt.Errorf("agent is offline")
+	}
+	task.ID = uuid.New().String()
+	p := &pendingTask{task: task, done: make(chan TaskResult, 1)}
+	s := h.state(agentID)
+	s.pending[task.ID] = p
+	s.queue = append(s.queue, task)
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	h.mu.Unlock()
+
+	defer h.forget(agentID, task.ID)
+
+	timer := time.NewTimer(h.taskTimeout)
+	defer timer.Stop()
+
+	select {
+	case result := <-p.done:
+		if result.Error != "" {
+			return &result, errors.New(result.Error)
+		}
+		return &result, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("agent did not finish %s within %s", task.Type, h.taskTimeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// forget drops a task that is finished or no longer waited on
+func (h *Hub) forget(agentID, taskID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.state(agentID)
+	delete(s.pending, taskID)
+	for i, t := range s.queue {
+		if t.ID == taskID {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			break
+		}
+	}
+}
+
+// Complete hands an agent's result to whoever dispatched the task
+func (h *Hub) Complete(agentID, taskID string, result TaskResult) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.state(agentID)
+	p, ok := s.pending[taskID]
+	if !ok {
+		return ErrUnknownTask
+	}
+	delete(s.pending, taskID)
+	p.done <- result
+	return nil
+}
+
+// ImageAllowed reports whether an agent is deploying an image, and so may
+// download it
+func (h *Hub) ImageAllowed(agentID, ref string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.agents[agentID]
+	if !ok {
+		return false
+	}
+	for _, p := range s.pending {
+		if p.task.Type == TaskDeploy && p.task.Config != nil && p.task.Config.Image == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// LastReport returns an agent's latest report, or nil if it is offline
+func (h *Hub) LastReport(agentID string) *Report {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.online(agentID) {
+		return nil
+	}
+	return h.agents[agentID].report
+}
+
+// Forget drops everything known about a removed agent
+func (h *Hub) Forget(agentID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.agents, agentID)
+}
+
+// container finds a container in an agent's latest report
+func (h *Hub) container(agentID, name string) (*ContainerReport, error) {
+	report := h.LastReport(agentID)
+	if report == nil {
+		return nil, fmt.Errorf("agent is offline")
+	}
+	for i := range report.Containers {
+		c := &report.Containers[i]
+		if strings.TrimPrefix(c.Status.Name, "/") == name {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
+// ContainerStatus returns a container's status from an agent's latest
+// report, with state "not_found" if the agent does not run it
+func (h *Hub) ContainerStatus(agentID, name string) (*docker.ContainerStatus, error) {
+	c, err := h.container(agentID, name)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return &docker.ContainerStatus{Name: name, State: "not_found"}, nil
+	}
+	status := c.Status
+	return &status, nil
+}
+
+// ContainerStats returns a container's resource usage from an agent's
+// latest report
+func (h *Hub) ContainerStats(agentID, name string) (*docker.ContainerStats, error) {
+	c, err := h.container(agentID, name)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil || c.Stats == nil {
+		return nil, fmt.Errorf("no stats for container %s", name)
+	}
+	stats := *c.Stats
+	return &stats, nil
+}
@@ -0,0 +1,182 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"schooner/internal/docker"
+)
+
+func TestHubDispatch(t *testing.T) {
+	hub := NewHub()
+	hub.Report("a1", &Report{})
+
+	type outcome struct {
+		result *TaskResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := hub.Dispatch(context.Background(), "a1", Task{
+			Type:      TaskDeploy,
+			Container: "web",
+			Config:    &docker.ContainerConfig{Name: "web", Image: "schooner/web:abc123"},
+		})
+		done <- outcome{result, err}
+	}()
+
+	tasks := hub.Poll(context.Background(), "a1", time.Second)
+	if len(tasks) != 1 || tasks[0].Type != TaskDeploy || tasks[0].ID == "" {
+		t.Fatalf("Poll() = %+v, want one deploy task", tasks)
+	}
+
+	if !hub.ImageAllowed("a1", "schooner/web:abc123") {
+		t.Error("ImageAllowed() = false for the image being deployed")
+	}
+	if hub.ImageAllowed("a1", "postgres:16") || hub.ImageAllowed("a2", "schooner/web:abc123") {
+		t.Error("ImageAllowed() = true for an image not being deployed to the agent")
+	}
+
+	if err := hub.Complete("a1", tasks[0].ID, TaskResult{ContainerID: "c0ffee"}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	got := <-done
+	if got.err != nil || got.result.ContainerID != "c0ffee" {
+		t.Errorf("Dispatch() = %+v, %v, want container c0ffee", got.result, got.err)
+	}
+
+	if err := hub.Complete("a1", tasks[0].ID, TaskResult{}); !errors.Is(err, ErrUnknownTask) {
+		t.Errorf("Complete() twice error = %v, want ErrUnknownTask", err)
+	}
+	if hub.ImageAllowed("a1", "schooner/web:abc123") {
+		t.Error("ImageAllowed() = true after the deploy finished")
+	}
+}
+
+func TestHubDispatchError(t *testing.T) {
+	hub := NewHub()
+	hub.Report("a1", &Report{})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := hub.Dispatch(context.Background(), "a1", Task{Type: TaskStart, Container: "web"})
+		done <- err
+	}()
+
+	tasks := hub.Poll(context.Background(), "a1", time.Second)
+	if len(tasks) != 1 {
+		t.Fatalf("Poll() returned %d tasks, want 1", len(tasks))
+	}
+	hub.Complete("a1", tasks[0].ID, TaskResult{Error: "no such container"})
+
+	if err := <-done; err == nil || err.Error() != "no such container" {
+		t.Errorf("Dispatch() error = %v, want the agent's error", err)
+	}
+}
+
+func TestHubDispatchOffline(t *testing.T) {
+	hub := NewHub()
+	if _, err := hub.Dispatch(context.Background(), "a1", Task{Type: TaskStop}); err == nil {
+		t.Error("Dispatch() to an unknown agent error = nil")
+	}
+
+	now := time.Now()
+	hub.now = func() time.Time { return now }
+	hub.Report("a1", &Report{})
+	now = now.Add(offlineAfter)
+
+	if hub.Online("a1") {
+		t.Error("Online() = true after the agent went quiet")
+	}
+	if _, err := hub.Dispatch(context.Background(), "a1", Task{Type: TaskStop}); err == nil {
+		t.Error("Dispatch() to an offline agent error = nil")
+	}
+	if _, err := hub.ContainerStatus("a1", "web"); err == nil {
+		t.Error("ContainerStatus() of an offline agent error = nil")
+	}
+}
+
+func TestHubDispatchTimeout(t *testing.T) {
+	hub := NewHub()
+	hub.taskTimeout = 10 * time.Millisecond
+	hub.Report("a1", &Report{})
+
+	_, err := hub.Dispatch(context.Background(), "a1", Task{Type: TaskRestart, Container: "web"})
+	if err == nil || !strings.Contains(err.Error(), "did not finish") {
+		t.Errorf("Dispatch() error = %v, want a timeout", err)
+	}
+
+	// The abandoned task is not handed out any more
+	if tasks := hub.Poll(context.Background(), "a1", 0); len(tasks) != 0 {
+		t.Errorf("Poll() = %+v, want no tasks", tasks)
+	}
+}
+
+func TestHubPollWaits(t *testing.T) {
+	hub := NewHub()
+
+	start := time.Now()
+	if tasks := hub.Poll(context.Background(), "a1", 20*time.Millisecond); len(tasks) != 0 {
+		t.Errorf("Poll() = %+v, want no tasks", tasks)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Error("Poll() returned before the wait elapsed")
+	}
+	if !hub.Online("a1") {
+		t.Error("Online() = false after polling")
+	}
+}
+
+func TestHubContainerStatus(t *testing.T) {
+	hub := NewHub()
+	hub.Report("a1", &Report{Containers: []ContainerReport{
+		{
+			Status: docker.ContainerStatus{Name: "/web", State: "running"},
+			Stats:  &docker.ContainerStats{CPUPercent: 12.5},
+		},
+		{Status: docker.ContainerStatus{Name: "/worker", State: "exited"}},
+	}})
+
+	tests := []struct {
+		name      string
+		wantState string
+		wantStats bool
+	}{
+		{"web", "running", true},
+		{"worker", "exited", false},
+		{"api", "not_found", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := hub.ContainerStatus("a1", tt.name)
+			if err != nil {
+				t.Fatalf("ContainerStatus() error = %v", err)
+			}
+			if status.State != tt.wantState {
+				t.Errorf("State = %q, want %q", status.State, tt.wantState)
+			}
+
+			stats, err := hub.ContainerStats("a1", tt.name)
+			if (err == nil) != tt.wantStats {
+				t.Errorf("ContainerStats() = %+v, %v, want stats %v", stats, err, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestGenerateToken(t *testing.T) {
+	token, prefix, hash, err := GenerateToken()
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	if !strings.HasPrefix(token, tokenPrefix) || !strings.HasPrefix(token, prefix) {
+		t.Errorf("token %q does not start with %q and %q", token, tokenPrefix, prefix)
+	}
+	if hash != HashToken(token) || hash == token {
+		t.Errorf("hash = %q, want HashToken(token)", hash)
+	}
+}
@@ -0,0 +1,91 @@
+// Package agent connects Schooner to servers running schooner-agent. Agents
+// dial out to Schooner over HTTPS, so they need no open ports: they poll for
+// tasks, run them on their local Docker daemon and report their containers
+// back on a fixed interval.
+package agent
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"schooner/internal/auth"
+	"schooner/internal/docker"
+)
+
+// API paths served by Schooner for agents, authenticated with the agent's
+// token as a bearer token
+const (
+	ReportPath = "/agent/v1/report"
+	TasksPath  = "/agent/v1/tasks"
+	ImagePath  = "/agent/v1/image"
+)
+
+const (
+	// ReportInterval is how often agents report their containers
+	ReportInterval = 15 * time.Second
+
+	// PollWait is how long a task poll is held open when nothing is queued.
+	// It stays under the server's write timeout.
+	PollWait = 10 * time.Second
+
+	// offlineAfter is how long an agent may stay silent before it is offline
+	offlineAfter = 3 * ReportInterval
+)
+
+// Task types
+const (
+	TaskDeploy  = "deploy"
+	TaskStart   = "start"
+	TaskStop    = "stop"
+	TaskRestart = "restart"
+)
+
+// Task is an instruction for an agent
+type Task struct {
+	ID        string                  `json:"id"`
+	Type      string                  `json:"type"`
+	Container string                  `json:"container"`        // container the task acts on
+	Config    *docker.ContainerConfig `json:"config,omitempty"` // deploy only
+}
+
+// TaskResult is an agent's answer to a task
+type TaskResult struct {
+	ContainerID string `json:"container_id,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Report is what an agent sends every ReportInterval
+type Report struct {
+	Hostname   string            `json:"hostname"`
+	Version    string            `json:"version"`
+	Containers []ContainerReport `json:"containers"`
+}
+
+// ContainerReport describes one Schooner-managed container on an agent
+type ContainerReport struct {
+	Status docker.ContainerStatus `json:"status"`
+	Stats  *docker.ContainerStats `json:"stats,omitempty"` // running containers only
+}
+
+// tokenPrefix marks agent tokens so they are not mistaken for API tokens
+const tokenPrefix = "sca_"
+
+// tokenDisplayLen is how much of a token is kept to identify it
+const tokenDisplayLen = 12
+
+// GenerateToken creates a new agent token, returning the plaintext, the
+// prefix shown in listings and the hash to store
+func GenerateToken() (token, prefix, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	token = tokenPrefix + hex.EncodeToString(b)
+	return token, token[:tokenDisplayLen], HashToken(token), nil
+}
+
+// HashToken returns the stored hash of a plaintext agent token
+func HashToken(token string) string {
+	return auth.HashAPIToken(token)
+}
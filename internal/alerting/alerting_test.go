@@ -0,0 +1,170 @@
+package alerting
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"schooner/internal/models"
+)
+
+func TestAlertState_Update(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	duration := 2 * time.Minute
+	cooldown := 10 * time.Minute
+
+	steps := []struct {
+		offset   time.Duration
+		breached bool
+		want     action
+	}{
+		{offset: 0, breached: true, want: actionNone},                // breach starts
+		{offset: time.Minute, breached: true, want: actionNone},      // not held long enough
+		{offset: 2 * time.Minute, breached: true, want: actionFire},  // duration reached
+		{offset: 5 * time.Minute, breached: true, want: actionNone},  // within cooldown
+		{offset: 12 * time.Minute, breached: true, want: actionFire}, // cooldown elapsed
+		{offset: 13 * time.Minute, breached: false, want: actionResolve},
+		{offset: 14 * time.Minute, breached: false, want: actionNone},
+		{offset: 15 * time.Minute, breached: true, want: actionNone},  // new breach restarts duration
+		{offset: 16 * time.Minute, breached: false, want: actionNone}, // cleared before firing
+	}
+
+	var s alertState
+	for i, step := range steps {
+		got := s.update(step.breached, start.Add(step.offset), duration, cooldown)
+		if got != step.want {
+			t.Errorf("step %d: update(%v) = %v, want %v", i, step.breached, got, step.want)
+		}
+	}
+}
+
+func TestAlertState_ZeroDurationFiresImmediately(t *testing.T) {
+	var s alertState
+	if got := s.update(true, time.Now(), 0, time.Hour); got != actionFire {
+		t.Errorf("update() = %v, want actionFire", got)
+	}
+}
+
+type fakeRuleStore struct {
+	rules []*models.AlertRule
+	fired []string
+}
+
+func (f *fakeRuleStore) ListEnabled(ctx context.Context) ([]*models.AlertRule, error) {
+	return f.rules, nil
+}
+
+func (f *fakeRuleStore) MarkFired(ctx context.Context, id string, firedAt time.Time) error {
+	f.fired = append(f.fired, id)
+	return nil
+}
+
+type fakeBuilds struct {
+	builds []*models.Build
+}
+
+func (f *fakeBuilds) ListRecentFinishedByAppID(ctx context.Context, appID string, limit int) ([]*models.Build, error) {
+	if len(f.builds) > limit {
+		return f.builds[:limit], nil
+	}
+	return f.builds, nil
+}
+
+type fakeApps struct {
+	apps []*models.App
+}
+
+func (f *fakeApps) GetByID(ctx context.Context, id string) (*models.App, error) {
+	for _, a := range f.apps {
+		if a.ID == id {
+			return a, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeApps) ListEnabled(ctx context.Context) ([]*models.App, error) {
+	return f.apps, nil
+}
+
+func TestEvaluator_BuildFailures(t *testing.T) {
+	rules := &fakeRuleStore{rules: []*models.AlertRule{{
+		ID:        "r1",
+		Name:      "failing builds",
+		Type:      models.AlertBuildFailures,
+		Threshold: 3,
+		Enabled:   true,
+	}}}
+	builds := &fakeBuilds{builds: []*models.Build{
+		{Status: models.BuildStatusFailed},
+		{Status: models.BuildStatusFailed},
+		{Status: models.BuildStatusSuccess},
+	}}
+	apps := &fakeApps{apps: []*models.App{{ID: "a1", Name: "web"}}}
+
+	e := NewEvaluator(rules, apps, builds, nil, nil, time.Minute)
+	now := time.Now()
+
+	e.evaluate(context.Background(), now)
+	if len(rules.fired) != 0 || len(e.Active()) != 0 {
+		t.Fatalf("expected no alert with 2 consecutive failures, got fired=%v", rules.fired)
+	}
+
+	builds.builds = append([]*models.Build{{Status: models.BuildStatusFailed}}, builds.builds...)
+	e.evaluate(context.Background(), now.Add(time.Minute))
+	if len(rules.fired) != 1 {
+		t.Fatalf("expected alert to fire once, got %d", len(rules.fired))
+	}
+	active := e.Active()
+	if len(active) != 1 || active[0].Subject != "web" {
+		t.Errorf("Active() = %+v, want one alert for web", active)
+	}
+}
+
+func TestEvaluator_DiskHigh(t *testing.T) {
+	rules := &fakeRuleStore{rules: []*models.AlertRule{{
+		ID:        "r1",
+		Name:      "disk",
+		Type:      models.AlertDiskHigh,
+		Threshold: 90,
+		Enabled:   true,
+	}}}
+
+	e := NewEvaluator(rules, &fakeApps{}, &fakeBuilds{}, nil, nil, time.Minute)
+	usage := 95.0
+	e.diskUsage = func() (float64, error) { return usage, nil }
+
+	now := time.Now()
+	e.evaluate(context.Background(), now)
+	if len(rules.fired) != 1 {
+		t.Fatalf("expected disk alert to fire, got %d", len(rules.fired))
+	}
+
+	usage = 50
+	e.evaluate(context.Background(), now.Add(time.Minute))
+	if len(e.Active()) != 0 {
+		t.Errorf("expected alert to resolve, got %+v", e.Active())
+	}
+}
+
+func TestEvaluator_ForgetsDeletedRules(t *testing.T) {
+	rules := &fakeRuleStore{rules: []*models.AlertRule{{
+		ID:        "r1",
+		Name:      "disk",
+		Type:      models.AlertDiskHigh,
+		Threshold: 90,
+		AppID:     sql.NullString{},
+		Enabled:   true,
+	}}}
+
+	e := NewEvaluator(rules, &fakeApps{}, &fakeBuilds{}, nil, nil, time.Minute)
+	e.diskUsage = func() (float64, error) { return 99, nil }
+	e.evaluate(context.Background(), time.Now())
+
+	rules.rules = nil
+	e.evaluate(context.Background(), time.Now())
+	if len(e.states) != 0 || len(e.Active()) != 0 {
+		t.Errorf("expected state to be cleared after rule removal")
+	}
+}
@@ -0,0 +1,140 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+
+	"schooner/internal/models"
+)
+
+// observation is the result of checking a rule against one subject
+type observation struct {
+	subject  string // app name, or "host" for host-level rules
+	appID    string
+	breached bool
+	value    float64
+	detail   string
+}
+
+// observe evaluates a rule and returns one observation per subject
+func (e *Evaluator) observe(ctx context.Context, rule *models.AlertRule) ([]observation, error) {
+	if rule.Type == models.AlertDiskHigh {
+		return e.observeDisk(rule)
+	}
+
+	apps, err := e.targetApps(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	var observations []observation
+	for _, app := range apps {
+		var obs *observation
+		switch rule.Type {
+		case models.AlertContainerDown:
+			obs = e.observeContainerDown(ctx, app)
+		case models.AlertCPUHigh:
+			obs = e.observeCPU(ctx, rule, app)
+		case models.AlertBuildFailures:
+			obs = e.observeBuildFailures(ctx, rule, app)
+		}
+		if obs != nil {
+			observations = append(observations, *obs)
+		}
+	}
+
+	return observations, nil
+}
+
+// targetApps returns the app a rule is scoped to, or all enabled apps
+func (e *Evaluator) targetApps(ctx context.Context, rule *models.AlertRule) ([]*models.App, error) {
+	if appID := rule.GetAppID(); appID != "" {
+		app, err := e.appQueries.GetByID(ctx, appID)
+		if err != nil || app == nil {
+			return nil, err
+		}
+		return []*models.App{app}, nil
+	}
+	return e.appQueries.ListEnabled(ctx)
+}
+
+func (e *Evaluator) observeContainerDown(ctx context.Context, app *models.App) *observation {
+	dockerClient, err := e.inspector(ctx, app)
+	if err != nil {
+		e.logger.Debug("alerting failed to reach Docker host", "app", app.Name, "error", err)
+		return nil
+	}
+
+	status, err := dockerClient.GetContainerStatus(ctx, app.GetContainerName())
+	if err != nil {
+		e.logger.Debug("alerting failed to get container status", "app", app.Name, "error", err)
+		return nil
+	}
+
+	return &observation{
+		subject:  app.Name,
+		appID:    app.ID,
+		breached: status.State != "running",
+		detail:   fmt.Sprintf("container is %s", status.State),
+	}
+}
+
+func (e *Evaluator) observeCPU(ctx context.Context, rule *models.AlertRule, app *models.App) *observation {
+	dockerClient, err := e.inspector(ctx, app)
+	if err != nil {
+		return nil
+	}
+
+	stats, err := dockerClient.GetContainerStats(ctx, app.GetContainerName())
+	if err != nil {
+		// Stopped containers have no stats; container_down rules cover that case
+		return nil
+	}
+
+	return &observation{
+		subject:  app.Name,
+		appID:    app.ID,
+		breached: stats.CPUPercent > rule.Threshold,
+		value:    stats.CPUPercent,
+		detail:   fmt.Sprintf("CPU at %.1f%% (threshold %.0f%%)", stats.CPUPercent, rule.Threshold),
+	}
+}
+
+func (e *Evaluator) observeBuildFailures(ctx context.Context, rule *models.AlertRule, app *models.App) *observation {
+	limit := int(rule.Threshold)
+	builds, err := e.buildQueries.ListRecentFinishedByAppID(ctx, app.ID, limit)
+	if err != nil {
+		e.logger.Debug("alerting failed to list builds", "app", app.Name, "error", err)
+		return nil
+	}
+
+	failed := 0
+	for _, b := range builds {
+		if b.Status != models.BuildStatusFailed {
+			break
+		}
+		failed++
+	}
+
+	return &observation{
+		subject:  app.Name,
+		appID:    app.ID,
+		breached: failed >= limit,
+		value:    float64(failed),
+		detail:   fmt.Sprintf("%d consecutive failed builds", failed),
+	}
+}
+
+func (e *Evaluator) observeDisk(rule *models.AlertRule) ([]observation, error) {
+	used, err := e.diskUsage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disk usage: %w", err)
+	}
+
+	return []observation{{
+		subject:  "host",
+		breached: used > rule.Threshold,
+		value:    used,
+		detail:   fmt.Sprintf("disk at %.1f%% (threshold %.0f%%)", used, rule.Threshold),
+	}}, nil
+}
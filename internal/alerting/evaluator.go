@@ -0,0 +1,291 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"schooner/internal/docker"
+	"schooner/internal/health"
+	"schooner/internal/models"
+	"schooner/internal/notify"
+)
+
+// RuleStore interface for loading alert rules
+type RuleStore interface {
+	ListEnabled(ctx context.Context) ([]*models.AlertRule, error)
+	MarkFired(ctx context.Context, id string, firedAt time.Time) error
+}
+
+// AppGetter interface for looking up apps
+type AppGetter interface {
+	GetByID(ctx context.Context, id string) (*models.App, error)
+	ListEnabled(ctx context.Context) ([]*models.App, error)
+}
+
+// BuildLister interface for reading recent build results
+type BuildLister interface {
+	ListRecentFinishedByAppID(ctx context.Context, appID string, limit int) ([]*models.Build, error)
+}
+
+// ContainerInspector interface for reading container state and usage
+type ContainerInspector interface {
+	GetContainerStatus(ctx context.Context, nameOrID string) (*docker.ContainerStatus, error)
+	GetContainerStats(ctx context.Context, nameOrID string) (*docker.ContainerStats, error)
+}
+
+// DockerHostResolver returns the Docker client for the host an app runs on
+type DockerHostResolver interface {
+	ForApp(ctx context.Context, app *models.App) (*docker.Client, error)
+}
+
+// AgentContainers reports the containers Schooner agents last saw
+type AgentContainers interface {
+	ContainerStatus(agentID, name string) (*docker.ContainerStatus, error)
+	ContainerStats(agentID, name string) (*docker.ContainerStats, error)
+}
+
+// agentInspector reads container status and stats from one agent's reports
+type agentInspector struct {
+	agents  AgentContainers
+	agentID string
+}
+
+func (i agentInspector) GetContainerStatus(ctx context.Context, name string) (*docker.ContainerStatus, error) {
+	return i.agents.ContainerStatus(i.agentID, name)
+}
+
+func (i agentInspector) GetContainerStats(ctx context.Context, name string) (*docker.ContainerStats, error) {
+	return i.agents.ContainerStats(i.agentID, name)
+}
+
+// ActiveAlert describes a rule that is currently firing for a subject
+type ActiveAlert struct {
+	RuleID   string    `json:"rule_id"`
+	RuleName string    `json:"rule_name"`
+	Type     string    `json:"type"`
+	Subject  string    `json:"subject"`
+	AppID    string    `json:"app_id,omitempty"`
+	Value    float64   `json:"value"`
+	Detail   string    `json:"detail"`
+	Since    time.Time `json:"since"`
+}
+
+// Evaluator periodically checks alert rules and sends notifications
+type Evaluator struct {
+	ruleStore    RuleStore
+	appQueries   AppGetter
+	buildQueries BuildLister
+	dockerClient ContainerInspector
+	dockerHosts  DockerHostResolver
+	agents       AgentContainers
+	dispatcher   *notify.Dispatcher
+	diskUsage    func() (float64, error)
+	interval     time.Duration
+	logger       *slog.Logger
+
+	mu     sync.Mutex
+	states map[string]*alertState
+	active map[string]ActiveAlert
+}
+
+// NewEvaluator creates a new alert evaluator
+func NewEvaluator(ruleStore RuleStore, appQueries AppGetter, buildQueries BuildLister, dockerClient ContainerInspector, dispatcher *notify.Dispatcher, interval time.Duration) *Evaluator {
+	return &Evaluator{
+		ruleStore:    ruleStore,
+		appQueries:   appQueries,
+		buildQueries: buildQueries,
+		dockerClient: dockerClient,
+		dispatcher:   dispatcher,
+		diskUsage:    hostDiskUsage,
+		interval:     interval,
+		logger:       slog.Default(),
+		states:       make(map[string]*alertState),
+		active:       make(map[string]ActiveAlert),
+	}
+}
+
+// SetDockerHosts makes container rules check apps on the Docker host they
+// deploy to rather than the local daemon
+func (e *Evaluator) SetDockerHosts(hosts DockerHostResolver) {
+	e.dockerHosts = hosts
+}
+
+// SetAgents makes container rules check apps on agents from the agents'
+// reports
+func (e *Evaluator) SetAgents(agents AgentContainers) {
+	e.agents = agents
+}
+
+// inspector returns the Docker client for an app's host, or its agent
+func (e *Evaluator) inspector(ctx context.Context, app *models.App) (ContainerInspector, error) {
+	if app.GetAgent() != "" {
+		if e.agents == nil {
+			return nil, fmt.Errorf("agents are not available")
+		}
+		return agentInspector{agents: e.agents, agentID: app.GetAgent()}, nil
+	}
+	if e.dockerHosts == nil || app.GetDockerHost() == "" {
+		return e.dockerClient, nil
+	}
+	return e.dockerHosts.ForApp(ctx, app)
+}
+
+// hostDiskUsage returns the used percentage of the host's root disk
+func hostDiskUsage() (float64, error) {
+	h, err := health.GetSystemHealth()
+	if err != nil {
+		return 0, err
+	}
+	return h.Disk.UsedPercent, nil
+}
+
+// Start runs the evaluator until the context is cancelled
+func (e *Evaluator) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.evaluate(ctx, time.Now())
+			}
+		}
+	}()
+}
+
+// Active returns the alerts that are currently firing
+func (e *Evaluator) Active() []ActiveAlert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alerts := make([]ActiveAlert, 0, len(e.active))
+	for _, a := range e.active {
+		alerts = append(alerts, a)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Since.Before(alerts[j].Since)
+	})
+	return alerts
+}
+
+// evaluate checks every enabled rule once
+func (e *Evaluator) evaluate(ctx context.Context, now time.Time) {
+	rules, err := e.ruleStore.ListEnabled(ctx)
+	if err != nil {
+		e.logger.Warn("alerting failed to list rules", "error", err)
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		observations, err := e.observe(ctx, rule)
+		if err != nil {
+			e.logger.Warn("alerting failed to evaluate rule", "rule", rule.Name, "error", err)
+			continue
+		}
+
+		for _, obs := range observations {
+			key := rule.ID + "/" + obs.subject
+			seen[key] = true
+			e.apply(ctx, rule, key, obs, now)
+		}
+	}
+
+	e.forget(seen)
+}
+
+// apply feeds an observation into the rule's state and notifies on transitions
+func (e *Evaluator) apply(ctx context.Context, rule *models.AlertRule, key string, obs observation, now time.Time) {
+	e.mu.Lock()
+	state, ok := e.states[key]
+	if !ok {
+		state = &alertState{}
+		e.states[key] = state
+	}
+	act := state.update(obs.breached, now, rule.Duration(), rule.Cooldown())
+	if state.firing {
+		e.active[key] = ActiveAlert{
+			RuleID:   rule.ID,
+			RuleName: rule.Name,
+			Type:     string(rule.Type),
+			Subject:  obs.subject,
+			AppID:    obs.appID,
+			Value:    obs.value,
+			Detail:   obs.detail,
+			Since:    state.breachedSince,
+		}
+	} else {
+		delete(e.active, key)
+	}
+	e.mu.Unlock()
+
+	switch act {
+	case actionFire:
+		e.notify(ctx, notify.Event{
+			Type:     notify.EventAlertFiring,
+			Title:    fmt.Sprintf("[%s] %s", rule.Name, obs.subject),
+			Message:  obs.detail,
+			AppName:  appName(obs),
+			URL:      e.alertURL(obs),
+			Priority: notify.PriorityHigh,
+		})
+		if err := e.ruleStore.MarkFired(ctx, rule.ID, now); err != nil {
+			e.logger.Warn("alerting failed to record fired rule", "rule", rule.Name, "error", err)
+		}
+		e.logger.Info("alert firing", "rule", rule.Name, "subject", obs.subject, "detail", obs.detail)
+	case actionResolve:
+		e.notify(ctx, notify.Event{
+			Type:     notify.EventAlertResolved,
+			Title:    fmt.Sprintf("[%s] %s resolved", rule.Name, obs.subject),
+			Message:  obs.detail,
+			AppName:  appName(obs),
+			URL:      e.alertURL(obs),
+			Priority: notify.PriorityDefault,
+		})
+		e.logger.Info("alert resolved", "rule", rule.Name, "subject", obs.subject)
+	}
+}
+
+// forget drops state for rules or apps that no longer exist
+func (e *Evaluator) forget(seen map[string]bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for key := range e.states {
+		if !seen[key] {
+			delete(e.states, key)
+			delete(e.active, key)
+		}
+	}
+}
+
+func (e *Evaluator) notify(ctx context.Context, event notify.Event) {
+	if e.dispatcher == nil {
+		return
+	}
+	e.dispatcher.Notify(ctx, event)
+}
+
+func (e *Evaluator) alertURL(obs observation) string {
+	if e.dispatcher == nil {
+		return ""
+	}
+	if obs.appID != "" {
+		return e.dispatcher.BaseURL() + "/apps/" + obs.appID
+	}
+	return e.dispatcher.BaseURL() + "/settings"
+}
+
+func appName(obs observation) string {
+	if obs.appID == "" {
+		return ""
+	}
+	return obs.subject
+}
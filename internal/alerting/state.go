@@ -0,0 +1,57 @@
+package alerting
+
+import "time"
+
+// action is what the evaluator should do after updating an alert's state
+type action int
+
+const (
+	actionNone action = iota
+	actionFire
+	actionResolve
+)
+
+// alertState tracks one (rule, subject) pair across evaluations
+type alertState struct {
+	breachedSince time.Time
+	firing        bool
+	lastNotified  time.Time
+	value         float64
+	detail        string
+}
+
+// update records whether the condition is breached at now and returns the
+// resulting action. A rule fires once the condition has held for duration,
+// re-notifies at most once per cooldown while firing, and resolves when the
+// condition clears.
+func (s *alertState) update(breached bool, now time.Time, duration, cooldown time.Duration) action {
+	if !breached {
+		wasFiring := s.firing
+		s.breachedSince = time.Time{}
+		s.firing = false
+		if wasFiring {
+			return actionResolve
+		}
+		return actionNone
+	}
+
+	if s.breachedSince.IsZero() {
+		s.breachedSince = now
+	}
+	if now.Sub(s.breachedSince) < duration {
+		return actionNone
+	}
+
+	if !s.firing {
+		s.firing = true
+		s.lastNotified = now
+		return actionFire
+	}
+
+	if cooldown > 0 && now.Sub(s.lastNotified) >= cooldown {
+		s.lastNotified = now
+		return actionFire
+	}
+
+	return actionNone
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"schooner/internal/auth"
+	"schooner/internal/events"
+	"schooner/internal/models"
+)
+
+// recordSettingsChanges adds each settings change that succeeds to the
+// activity feed, with who made it
+func recordSettingsChanges(bus *events.Bus) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			if status := ww.Status(); status != 0 && status >= 300 {
+				return
+			}
+			bus.Record(r.Context(), models.ActivitySettings, "", "",
+				"%s changed settings: %s %s", auth.Requester(r.Context()), r.Method, settingName(r.URL.Path))
+		})
+	}
+}
+
+// settingName is the part of a settings route after /settings/, such as
+// "tunnel/start"
+func settingName(path string) string {
+	if _, name, ok := strings.Cut(path, "/settings/"); ok {
+		return name
+	}
+	return path
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"schooner/internal/auth"
+	"schooner/internal/events"
+	"schooner/internal/models"
+)
+
+type fakeActivityStore struct {
+	created []*models.Activity
+}
+
+func (f *fakeActivityStore) Create(ctx context.Context, activity *models.Activity) error {
+	f.created = append(f.created, activity)
+	return nil
+}
+
+func TestRecordSettingsChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		status  int
+		message string
+	}{
+		{name: "change", method: "POST", path: "/api/settings/tunnel/start", status: 200, message: "owner changed settings: POST tunnel/start"},
+		{name: "versioned", method: "DELETE", path: "/api/v1/settings/banner", status: 204, message: "owner changed settings: DELETE banner"},
+		{name: "read", method: "GET", path: "/api/settings/banner", status: 200},
+		{name: "rejected", method: "POST", path: "/api/settings/build-workers", status: 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeActivityStore{}
+			bus := events.NewBus()
+			bus.SetActivityStore(store)
+			handler := recordSettingsChanges(bus)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req = req.WithContext(context.WithValue(req.Context(), auth.SessionKey, &auth.Session{Username: "owner"}))
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if tt.message == "" {
+				if len(store.created) != 0 {
+					t.Errorf("recorded %q, want nothing", store.created[0].Message)
+				}
+				return
+			}
+			if len(store.created) != 1 || store.created[0].Message != tt.message {
+				t.Fatalf("recorded %+v, want %q", store.created, tt.message)
+			}
+			if store.created[0].Kind != models.ActivitySettings {
+				t.Errorf("recorded kind %q, want %q", store.created[0].Kind, models.ActivitySettings)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// staticCacheControl lets browsers reuse static files for five minutes
+// before revalidating them, short enough that an upgrade's assets show up
+// soon after it
+const staticCacheControl = "public, max-age=300"
+
+// revalidateCacheControl lets browsers keep a response but check its ETag
+// before every use
+const revalidateCacheControl = "private, no-cache"
+
+// detectContentType sets the Content-Type of responses written without
+// one before their headers go out. Compress decides from the Content-Type
+// whether to compress, and pages written with fmt never set it.
+func detectContentType(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&sniffWriter{ResponseWriter: w}, r)
+	})
+}
+
+// sniffWriter detects the Content-Type of the first write
+type sniffWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (s *sniffWriter) WriteHeader(status int) {
+	s.wroteHeader = true
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *sniffWriter) Write(b []byte) (int, error) {
+	if !s.wroteHeader {
+		sniff(s.Header(), b)
+		s.WriteHeader(http.StatusOK)
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// Flush keeps server-sent event handlers working through the wrapper
+func (s *sniffWriter) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// sniff sets a Content-Type from the body if the handler didn't set one
+func sniff(h http.Header, b []byte) {
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", http.DetectContentType(b))
+	}
+}
+
+// etags lets browsers revalidate GET responses of JSON and HTML instead of
+// downloading them again. Bodies are hashed into an ETag, and requests
+// that already have the current one get 304 Not Modified. Other responses,
+// including event streams, pass straight through.
+func etags(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ew := &etagWriter{ResponseWriter: w}
+		next.ServeHTTP(ew, r)
+		ew.finish(r)
+	})
+}
+
+// etagWriter holds back successful JSON and HTML responses until their
+// ETag is known
+type etagWriter struct {
+	http.ResponseWriter
+	status    int
+	buffering bool
+	body      bytes.Buffer
+}
+
+func (e *etagWriter) WriteHeader(status int) {
+	if e.status != 0 {
+		return
+	}
+	e.status = status
+
+	contentType, _, _ := strings.Cut(e.Header().Get("Content-Type"), ";")
+	e.buffering = status == http.StatusOK && (contentType == "application/json" || contentType == "text/html")
+	if !e.buffering {
+		e.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (e *etagWriter) Write(b []byte) (int, error) {
+	if e.status == 0 {
+		sniff(e.Header(), b)
+		e.WriteHeader(http.StatusOK)
+	}
+	if e.buffering {
+		return e.body.Write(b)
+	}
+	return e.ResponseWriter.Write(b)
+}
+
+// Flush sends what's been held back and stops buffering, since a handler
+// that flushes is streaming
+func (e *etagWriter) Flush() {
+	if e.buffering {
+		e.buffering = false
+		e.ResponseWriter.WriteHeader(e.status)
+		e.ResponseWriter.Write(e.body.Bytes())
+		e.body.Reset()
+	}
+	if flusher, ok := e.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// finish writes the held back response, or 304 Not Modified if the
+// browser already has it
+func (e *etagWriter) finish(r *http.Request) {
+	if !e.buffering {
+		return
+	}
+
+	etag := weakETag(e.body.Bytes())
+	h := e.Header()
+	h.Set("ETag", etag)
+	if cc := h.Get("Cache-Control"); cc == "" || cc == "no-store" {
+		h.Set("Cache-Control", revalidateCacheControl)
+	}
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		h.Del("Content-Type")
+		h.Del("Content-Length")
+		e.ResponseWriter.WriteHeader(http.StatusNotModified)
+		return
+	}
+	e.ResponseWriter.WriteHeader(e.status)
+	e.ResponseWriter.Write(e.body.Bytes())
+}
+
+// weakETag hashes a body. ETags are weak because Compress changes the
+// bytes sent while the content stays the same.
+func weakETag(b []byte) string {
+	sum := sha256.Sum256(b)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header lists an ETag,
+// comparing weakly
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// staticFiles serves embedded static files with an ETag of their content,
+// hashed once at startup, so browsers revalidate them cheaply once they
+// expire
+func staticFiles(files fs.FS) (http.Handler, error) {
+	tags := make(map[string]string)
+	err := fs.WalkDir(files, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(files, path)
+		if err != nil {
+			return err
+		}
+		tags[path] = weakETag(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fileServer := http.FileServer(http.FS(files))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if etag, ok := tags[strings.TrimPrefix(r.URL.Path, "/")]; ok {
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Cache-Control", staticCacheControl)
+		}
+		fileServer.ServeHTTP(w, r)
+	}), nil
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestDetectContentTypeCompressesPages(t *testing.T) {
+	page := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "\n<div class=\"p-4\">Apps</div>")
+	})
+	handler := middleware.Compress(5)(detectContentType(page))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if got := rr.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("expected HTML content type, got %q", got)
+	}
+	if got := rr.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Errorf("expected gzip encoding, got %q", got)
+	}
+}
+
+func TestETags(t *testing.T) {
+	json := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"apps":[]}`)
+	})
+	etag := weakETag([]byte(`{"apps":[]}`))
+
+	tests := []struct {
+		name        string
+		method      string
+		handler     http.HandlerFunc
+		ifNoneMatch string
+		status      int
+		etag        string
+		body        string
+	}{
+		{name: "JSON", method: "GET", handler: json, status: 200, etag: etag, body: `{"apps":[]}`},
+		{name: "unchanged", method: "GET", handler: json, ifNoneMatch: etag, status: 304, etag: etag},
+		{name: "unchanged strong", method: "GET", handler: json, ifNoneMatch: `"other", ` + etag[2:], status: 304, etag: etag},
+		{name: "changed", method: "GET", handler: json, ifNoneMatch: `W/"stale"`, status: 200, etag: etag, body: `{"apps":[]}`},
+		{name: "POST", method: "POST", handler: json, ifNoneMatch: etag, status: 200, body: `{"apps":[]}`},
+		{
+			name:   "HTML partial",
+			method: "GET",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, "<div>queue</div>")
+			},
+			status: 200,
+			etag:   weakETag([]byte("<div>queue</div>")),
+			body:   "<div>queue</div>",
+		},
+		{
+			name:   "error",
+			method: "GET",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "not found", http.StatusNotFound)
+			},
+			status: 404,
+			body:   "not found\n",
+		},
+		{
+			name:   "event stream",
+			method: "GET",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				io.WriteString(w, "data: {}\n\n")
+			},
+			status: 200,
+			body:   "data: {}\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/apps", nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			rr := httptest.NewRecorder()
+			etags(tt.handler).ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rr.Code)
+			}
+			if got := rr.Header().Get("ETag"); got != tt.etag {
+				t.Errorf("expected ETag %q, got %q", tt.etag, got)
+			}
+			if tt.etag != "" && rr.Header().Get("Cache-Control") != revalidateCacheControl {
+				t.Errorf("expected Cache-Control %q, got %q", revalidateCacheControl, rr.Header().Get("Cache-Control"))
+			}
+			if got := rr.Body.String(); got != tt.body {
+				t.Errorf("expected body %q, got %q", tt.body, got)
+			}
+		})
+	}
+}
+
+func TestETagsFlushStreams(t *testing.T) {
+	handler := etags(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, "{}")
+		w.(http.Flusher).Flush()
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/apps", nil))
+
+	if !rr.Flushed || rr.Body.String() != "{}" {
+		t.Errorf("expected the body flushed, got flushed=%v body=%q", rr.Flushed, rr.Body.String())
+	}
+	if rr.Header().Get("ETag") != "" {
+		t.Error("expected no ETag on a flushed response")
+	}
+}
+
+func TestStaticFiles(t *testing.T) {
+	files := fstest.MapFS{"css/app.css": {Data: []byte("body{}")}}
+	handler, err := staticFiles(files)
+	if err != nil {
+		t.Fatalf("staticFiles() error = %v", err)
+	}
+	etag := weakETag([]byte("body{}"))
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		status      int
+	}{
+		{name: "first load", status: 200},
+		{name: "revalidated", ifNoneMatch: etag, status: 304},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/css/app.css", nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rr.Code)
+			}
+			if rr.Header().Get("ETag") != etag || rr.Header().Get("Cache-Control") != staticCacheControl {
+				t.Errorf("expected ETag and Cache-Control, got %v", rr.Header())
+			}
+		})
+	}
+}
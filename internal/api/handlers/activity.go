@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+// activityShown is how many entries of the activity feed the dashboard
+// shows
+const activityShown = 25
+
+// SetActivity shows the activity feed on the dashboard
+func (h *PageHandler) SetActivity(activityQueries *queries.ActivityQueries) {
+	h.activityQueries = activityQueries
+}
+
+// renderActivity writes the dashboard's activity panel
+func (h *PageHandler) renderActivity(w http.ResponseWriter, ctx context.Context) {
+	if h.activityQueries == nil {
+		return
+	}
+	activity, err := h.activityQueries.ListRecent(ctx, activityShown)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list activity", "error", err)
+	}
+	renderTemplate(w, "dashboard-activity", activity)
+}
+
+// ActivityPartial handles GET /partials/activity
+func (h *PageHandler) ActivityPartial(w http.ResponseWriter, r *http.Request) {
+	if h.activityQueries == nil {
+		http.Error(w, "activity feed not available", http.StatusServiceUnavailable)
+		return
+	}
+	activity, err := h.activityQueries.ListRecent(r.Context(), activityShown)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list activity", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	renderTemplate(w, "activity", activity)
+}
+
+// Activity handles GET /api/activity - the newest entries of the activity
+// feed, up to limit
+func (h *PageHandler) Activity(w http.ResponseWriter, r *http.Request) {
+	if h.activityQueries == nil {
+		http.Error(w, "activity feed not available", http.StatusServiceUnavailable)
+		return
+	}
+	limit := activityShown
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageLimit {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxPageLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	activity, err := h.activityQueries.ListRecent(r.Context(), limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list activity", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if activity == nil {
+		activity = []*models.Activity{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(activity)
+}
+
+// activityColor is the class of the dot marking an entry of the activity
+// feed
+func activityColor(kind models.ActivityKind) string {
+	switch kind {
+	case models.ActivityBuildStarted:
+		return "bg-blue-500"
+	case models.ActivityBuildFinished:
+		return "bg-red-500"
+	case models.ActivityDeploy:
+		return "bg-green-500"
+	case models.ActivityContainer:
+		return "bg-yellow-500"
+	case models.ActivityWebhook:
+		return "bg-purple-500"
+	}
+	return "bg-gray-400"
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+// newActivityHandler returns a PageHandler whose activity feed has a
+// deploy of api and a settings change
+func newActivityHandler(t *testing.T) *PageHandler {
+	t.Helper()
+	db := newTestDB(t)
+
+	activityQueries := queries.NewActivityQueries(db.DB)
+	for _, activity := range []*models.Activity{
+		{Kind: models.ActivityDeploy, AppID: "api", BuildID: "b1", Message: "Deployed api at 1a2b3c4d", CreatedAt: time.Now()},
+		{Kind: models.ActivitySettings, Message: "owner changed settings: POST banner", CreatedAt: time.Now()},
+	} {
+		if err := activityQueries.Create(context.Background(), activity); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := NewPageHandler(nil, nil, nil, nil, nil, nil, nil, nil)
+	h.SetActivity(activityQueries)
+	return h
+}
+
+func TestActivityPartial(t *testing.T) {
+	h := newActivityHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.ActivityPartial(rec, httptest.NewRequest(http.MethodGet, "/partials/activity", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `data-fragment="activity"`) {
+		t.Error("partial isn't marked as the activity fragment")
+	}
+	if !strings.Contains(body, `href="/builds/b1"`) {
+		t.Error("deploy doesn't link to its build")
+	}
+	if i, j := strings.Index(body, "changed settings"), strings.Index(body, "Deployed api"); i < 0 || j < 0 || i > j {
+		t.Error("partial doesn't list the newest entry first")
+	}
+}
+
+func TestActivity(t *testing.T) {
+	h := newActivityHandler(t)
+
+	tests := []struct {
+		name    string
+		query   string
+		status  int
+		entries int
+	}{
+		{name: "default", status: http.StatusOK, entries: 2},
+		{name: "limited", query: "?limit=1", status: http.StatusOK, entries: 1},
+		{name: "bad limit", query: "?limit=0", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.Activity(rec, httptest.NewRequest(http.MethodGet, "/api/activity"+tt.query, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var activity []models.Activity
+			if err := json.NewDecoder(rec.Body).Decode(&activity); err != nil {
+				t.Fatal(err)
+			}
+			if len(activity) != tt.entries {
+				t.Errorf("got %d entries, want %d", len(activity), tt.entries)
+			}
+		})
+	}
+}
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"schooner/internal/addons"
+	"schooner/internal/database/queries"
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+// AddonHandler handles the managed database and cache add-ons of apps
+type AddonHandler struct {
+	addonQueries *queries.AddonQueries
+	appQueries   *queries.AppQueries
+	addonManager *addons.Manager
+}
+
+// NewAddonHandler creates a new AddonHandler
+func NewAddonHandler(addonQueries *queries.AddonQueries, appQueries *queries.AppQueries, addonManager *addons.Manager) *AddonHandler {
+	return &AddonHandler{
+		addonQueries: addonQueries,
+		appQueries:   appQueries,
+		addonManager: addonManager,
+	}
+}
+
+// AddonRequest is the request body for provisioning an add-on
+type AddonRequest struct {
+	Type   models.AddonType `json:"type"`
+	Name   string           `json:"name"`
+	EnvVar string           `json:"env_var"` // Defaults to DATABASE_URL or REDIS_URL
+}
+
+// addonResponse is an add-on with the state of its container
+type addonResponse struct {
+	*models.Addon
+	Status *docker.ContainerStatus `json:"status,omitempty"`
+}
+
+// List handles GET /api/apps/{appID}/addons
+func (h *AddonHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID := chi.URLParam(r, "appID")
+
+	list, err := h.addonQueries.ListByAppID(ctx, appID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list add-ons", "appID", appID, "error", err)
+		http.Error(w, "failed to list add-ons", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]addonResponse, 0, len(list))
+	for _, addon := range list {
+		item := addonResponse{Addon: addon}
+		if h.addonManager != nil {
+			item.Status = h.addonManager.Status(ctx, addon)
+		}
+		response = append(response, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Create handles POST /api/apps/{appID}/addons
+func (h *AddonHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID := chi.URLParam(r, "appID")
+
+	if h.addonManager == nil {
+		http.Error(w, "Docker is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	app, err := h.appQueries.GetByID(ctx, appID)
+	if err != nil {
+		http.Error(w, "failed to get app", http.StatusInternalServerError)
+		return
+	}
+	if app == nil {
+		http.Error(w, "app not found", http.StatusNotFound)
+		return
+	}
+
+	var req AddonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	addon := models.NewAddon(app, req.Type, strings.TrimSpace(req.Name))
+	if envVar := strings.TrimSpace(req.EnvVar); envVar != "" {
+		addon.EnvVar = envVar
+	}
+	if err := addon.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	existing, err := h.addonQueries.ListByAppID(ctx, appID)
+	if err != nil {
+		http.Error(w, "failed to list add-ons", http.StatusInternalServerError)
+		return
+	}
+	for _, other := range existing {
+		if other.Name == addon.Name {
+			http.Error(w, fmt.Sprintf("add-on %q already exists", addon.Name), http.StatusConflict)
+			return
+		}
+		if other.EnvVar == addon.EnvVar {
+			http.Error(w, fmt.Sprintf("%s is already set by add-on %q", addon.EnvVar, other.Name), http.StatusConflict)
+			return
+		}
+	}
+
+	addon.ID = uuid.New().String()
+	addon.CreatedAt = time.Now()
+	if addon.Password, err = addons.GeneratePassword(); err != nil {
+		slog.ErrorContext(ctx, "failed to generate add-on password", "error", err)
+		http.Error(w, "failed to generate credentials", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.addonQueries.Create(ctx, addon); err != nil {
+		slog.ErrorContext(ctx, "failed to create add-on", "app", app.Name, "error", err)
+		http.Error(w, "failed to create add-on", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.addonManager.Provision(ctx, app, addon); err != nil {
+		slog.ErrorContext(ctx, "failed to provision add-on", "app", app.Name, "addon", addon.Name, "error", err)
+		if delErr := h.addonQueries.Delete(ctx, addon.ID); delErr != nil {
+			slog.ErrorContext(ctx, "failed to delete add-on", "id", addon.ID, "error", delErr)
+		}
+		http.Error(w, "failed to provision add-on: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "add-on created", "app", app.Name, "addon", addon.Name, "type", addon.Type)
+
+	message := fmt.Sprintf("Add-on created. Redeploy %s to set %s.", app.Name, addon.EnvVar)
+	if _, ok := app.EnvVars[addon.EnvVar]; ok {
+		message = fmt.Sprintf("Add-on created, but %s is set in the app's environment and takes precedence.", addon.EnvVar)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": message,
+		"addon":   addon,
+	})
+}
+
+// Delete handles DELETE /api/apps/{appID}/addons/{addonID}. The data volume
+// is kept unless delete_data=true is given.
+func (h *AddonHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID := chi.URLParam(r, "appID")
+	addonID := chi.URLParam(r, "addonID")
+
+	addon, err := h.addonQueries.GetByID(ctx, addonID)
+	if err != nil {
+		http.Error(w, "failed to get add-on", http.StatusInternalServerError)
+		return
+	}
+	if addon == nil || addon.AppID != appID {
+		http.Error(w, "add-on not found", http.StatusNotFound)
+		return
+	}
+
+	if h.addonManager != nil {
+		deleteData := r.URL.Query().Get("delete_data") == "true"
+		if err := h.addonManager.Remove(ctx, addon, deleteData); err != nil {
+			slog.ErrorContext(ctx, "failed to remove add-on", "addon", addon.Name, "error", err)
+			http.Error(w, "failed to remove add-on: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := h.addonQueries.Delete(ctx, addon.ID); err != nil {
+		slog.ErrorContext(ctx, "failed to delete add-on", "id", addon.ID, "error", err)
+		http.Error(w, "failed to delete add-on", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "add-on deleted", "addon", addon.Name, "container", addon.ContainerName)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Add-on removed",
+	})
+}
+
+func (h *PageHandler) renderAppAddons(w http.ResponseWriter, app *models.App) {
+	fmt.Fprintf(w, `
+        <h2 class="text-xl font-bold mb-4">Add-ons</h2>
+        <div class="bg-white shadow-sm rounded-lg p-6 border border-gray-200 mb-8" id="addons-section" data-app-id="%s">
+            <table class="w-full mb-6">
+                <thead>
+                    <tr class="text-left text-sm text-gray-500">
+                        <th class="pb-2">Name</th>
+                        <th class="pb-2">Type</th>
+                        <th class="pb-2">Env var</th>
+                        <th class="pb-2">Status</th>
+                        <th class="pb-2"></th>
+                    </tr>
+                </thead>
+                <tbody id="addons-list">
+                    <tr><td colspan="5" class="text-sm text-gray-500">Loading...</td></tr>
+                </tbody>
+            </table>
+            <form onsubmit="createAddon(event)">
+                <div class="grid grid-cols-1 md:grid-cols-3 gap-4 mb-4">
+                    <div>
+                        <label class="block text-sm text-gray-500 mb-1">Type</label>
+                        <select id="addon-type-input"
+                            class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                            <option value="postgres">PostgreSQL</option>
+                            <option value="mysql">MySQL</option>
+                            <option value="redis">Redis</option>
+                        </select>
+                    </div>
+                    <div>
+                        <label class="block text-sm text-gray-500 mb-1">Name</label>
+                        <input type="text" id="addon-name-input" placeholder="db" required
+                            class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                    </div>
+                    <div>
+                        <label class="block text-sm text-gray-500 mb-1">Env var</label>
+                        <input type="text" id="addon-env-input" placeholder="DATABASE_URL / REDIS_URL"
+                            class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                    </div>
+                </div>
+                <p class="text-sm text-gray-500 mb-4">The connection URL is injected into the app's environment on its next deploy.</p>
+                <button type="submit" class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white">Add</button>
+            </form>
+        </div>
+        <script>
+            (function() {
+                const appID = document.getElementById('addons-section').dataset.appId;
+
+                function escapeText(text) {
+                    const div = document.createElement('div');
+                    div.textContent = text;
+                    return div.innerHTML;
+                }
+
+                function loadAddons() {
+                    fetch('/api/apps/' + appID + '/addons')
+                        .then(response => response.json())
+                        .then(addons => {
+                            const list = document.getElementById('addons-list');
+                            if (addons.length === 0) {
+                                list.innerHTML = '<tr><td colspan="5" class="text-sm text-gray-500">No add-ons</td></tr>';
+                                return;
+                            }
+                            list.innerHTML = addons.map(addon => {
+                                const state = addon.status ? addon.status.state : 'unknown';
+                                const color = state === 'running' ? 'text-green-600' : 'text-red-600';
+                                return '<tr class="border-t border-gray-200 text-sm">' +
+                                    '<td class="py-2 font-mono">' + escapeText(addon.name) + '</td>' +
+                                    '<td class="py-2">' + escapeText(addon.type) + '</td>' +
+                                    '<td class="py-2 font-mono">' + escapeText(addon.env_var) + '</td>' +
+                                    '<td class="py-2 ' + color + '">' + escapeText(state) + '</td>' +
+                                    '<td class="py-2 text-right"><button class="text-red-600 hover:text-red-700" ' +
+                                    'onclick="deleteAddon(\'' + addon.id + '\', \'' + escapeText(addon.name) + '\')">Remove</button></td>' +
+                                    '</tr>';
+                            }).join('');
+                        });
+                }
+
+                window.createAddon = function(event) {
+                    event.preventDefault();
+                    const data = {
+                        type: document.getElementById('addon-type-input').value,
+                        name: document.getElementById('addon-name-input').value.trim(),
+                        env_var: document.getElementById('addon-env-input').value.trim()
+                    };
+
+                    fetch('/api/apps/' + appID + '/addons', {
+                        method: 'POST',
+                        headers: { 'Content-Type': 'application/json' },
+                        body: JSON.stringify(data)
+                    })
+                    .then(response => {
+                        if (response.ok) {
+                            response.json().then(result => showToast(result.message, 'success'));
+                            document.getElementById('addon-name-input').value = '';
+                            document.getElementById('addon-env-input').value = '';
+                            loadAddons();
+                        } else {
+                            response.text().then(text => alert('Failed to add: ' + text));
+                        }
+                    });
+                };
+
+                window.deleteAddon = function(id, name) {
+                    if (!confirm('Remove add-on ' + name + '?')) {
+                        return;
+                    }
+                    const deleteData = confirm('Also delete its data? This cannot be undone.');
+                    fetch('/api/apps/' + appID + '/addons/' + id + '?delete_data=' + deleteData, { method: 'DELETE' })
+                        .then(response => {
+                            if (response.ok) {
+                                showToast('Add-on removed', 'success');
+                                loadAddons();
+                            } else {
+                                response.text().then(text => alert('Failed to remove: ' + text));
+                            }
+                        });
+                };
+
+                loadAddons();
+            })();
+        </script>`,
+		html.EscapeString(app.ID))
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"schooner/internal/adopt"
+	"schooner/internal/build"
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+	"schooner/internal/proxy"
+)
+
+// AdoptHandler turns containers started outside Schooner into apps
+type AdoptHandler struct {
+	adopter      *adopt.Adopter
+	appQueries   *queries.AppQueries
+	proxyRouter  *proxy.Router
+	orchestrator *build.Orchestrator
+}
+
+// NewAdoptHandler creates a new AdoptHandler
+func NewAdoptHandler(adopter *adopt.Adopter, appQueries *queries.AppQueries, proxyRouter *proxy.Router, orchestrator *build.Orchestrator) *AdoptHandler {
+	return &AdoptHandler{
+		adopter:      adopter,
+		appQueries:   appQueries,
+		proxyRouter:  proxyRouter,
+		orchestrator: orchestrator,
+	}
+}
+
+// AdoptRequest is the request body for adopting a container
+type AdoptRequest struct {
+	Name       string `json:"name"`
+	Subdomain  string `json:"subdomain"`
+	PublicPort int    `json:"public_port"`
+}
+
+// Preview handles GET /api/containers/{containerID}/adopt
+func (h *AdoptHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	if h.adopter == nil {
+		http.Error(w, "Docker client not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	plan, err := h.adopter.Plan(r.Context(), chi.URLParam(r, "containerID"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
+
+// Adopt handles POST /api/containers/{containerID}/adopt
+func (h *AdoptHandler) Adopt(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if h.adopter == nil || h.orchestrator == nil {
+		http.Error(w, "build orchestrator not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req AdoptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.PublicPort < 0 || req.PublicPort > 65535 {
+		http.Error(w, "public_port must be between 1 and 65535", http.StatusBadRequest)
+		return
+	}
+
+	plan, err := h.adopter.Plan(ctx, chi.URLParam(r, "containerID"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = plan.Name
+	}
+	port := req.PublicPort
+	if port == 0 {
+		port = plan.PublicPort
+	}
+	subdomain := strings.TrimSpace(req.Subdomain)
+
+	app := &models.App{
+		ID:             uuid.New().String(),
+		Name:           name,
+		BuildStrategy:  models.BuildStrategyCompose,
+		DockerfilePath: "Dockerfile",
+		ComposeFile:    "docker-compose.yml",
+		BuildContext:   ".",
+		EnvVars:        plan.EnvVars,
+		Enabled:        true,
+		Subdomain:      sql.NullString{String: subdomain, Valid: subdomain != ""},
+		PublicPort:     sql.NullInt64{Int64: int64(port), Valid: port > 0},
+		ComposeSpec:    sql.NullString{String: plan.Compose, Valid: true},
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	if err := app.SaveEnvVars(); err != nil {
+		slog.ErrorContext(ctx, "failed to save env vars", "error", err)
+		http.Error(w, "failed to save env vars", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.appQueries.Create(ctx, app); err != nil {
+		if portConflict(w, err) {
+			return
+		}
+		slog.ErrorContext(ctx, "failed to create adopted app", "project", plan.Project, "error", err)
+		http.Error(w, "failed to create app: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Standalone containers make way for the app's first deploy
+	if err := h.adopter.Release(ctx, plan); err != nil {
+		slog.ErrorContext(ctx, "failed to release adopted containers", "app", app.Name, "error", err)
+		http.Error(w, "app created but the original container could not be removed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if h.proxyRouter != nil && h.proxyRouter.IsConfigured() && app.GetSubdomain() != "" && app.GetPublicPort() != 0 {
+		if err := h.proxyRouter.Reload(ctx); err != nil {
+			slog.WarnContext(ctx, "failed to reload proxy routes", "app", app.Name, "error", err)
+		}
+	}
+
+	b, err := h.orchestrator.TriggerManualBuild(ctx, app.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to trigger adopted app deploy", "app", app.Name, "error", err)
+		http.Error(w, "app created but deploy failed to start: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "containers adopted", "id", app.ID, "name", app.Name, "project", plan.Project, "services", plan.Services)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"app":   app,
+		"build": b,
+	})
+}
@@ -0,0 +1,412 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"schooner/internal/agent"
+	"schooner/internal/database/queries"
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+// agentKey is the context key for the agent a request authenticated as
+type agentKey struct{}
+
+// AgentHandler handles agent management and the API agents connect to
+type AgentHandler struct {
+	agentQueries *queries.AgentQueries
+	hub          *agent.Hub
+	dockerClient *docker.Client
+}
+
+// NewAgentHandler creates a new AgentHandler. Images built on dockerClient
+// are served to agents deploying them.
+func NewAgentHandler(agentQueries *queries.AgentQueries, hub *agent.Hub, dockerClient *docker.Client) *AgentHandler {
+	return &AgentHandler{
+		agentQueries: agentQueries,
+		hub:          hub,
+		dockerClient: dockerClient,
+	}
+}
+
+// AgentRequest is the request body for creating an agent
+type AgentRequest struct {
+	Name string `json:"name"`
+}
+
+// agentResponse is an agent as returned by the API
+type agentResponse struct {
+	*models.Agent
+	Online     bool   `json:"online"`
+	Containers int    `json:"containers"`
+	Token      string `json:"token,omitempty"` // plaintext, only on create
+}
+
+func (h *AgentHandler) newAgentResponse(a *models.Agent) agentResponse {
+	response := agentResponse{Agent: a, Online: h.hub.Online(a.ID)}
+	if report := h.hub.LastReport(a.ID); report != nil {
+		response.Containers = len(report.Containers)
+	}
+	return response
+}
+
+// List handles GET /api/agents
+func (h *AgentHandler) List(w http.ResponseWriter, r *http.Request) {
+	agents, err := h.agentQueries.List(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list agents", "error", err)
+		http.Error(w, "failed to list agents", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]agentResponse, len(agents))
+	for i, a := range agents {
+		response[i] = h.newAgentResponse(a)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Create handles POST /api/agents
+func (h *AgentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req AgentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	plaintext, prefix, hash, err := agent.GenerateToken()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to generate agent token", "error", err)
+		http.Error(w, "failed to create agent", http.StatusInternalServerError)
+		return
+	}
+
+	a := &models.Agent{
+		ID:          uuid.New().String(),
+		Name:        strings.TrimSpace(req.Name),
+		TokenPrefix: prefix,
+		TokenHash:   hash,
+		CreatedAt:   time.Now(),
+	}
+	if err := a.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.agentQueries.Create(r.Context(), a); err != nil {
+		slog.ErrorContext(r.Context(), "failed to create agent", "error", err)
+		http.Error(w, "failed to create agent: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "agent created", "id", a.ID, "name", a.Name)
+
+	response := h.newAgentResponse(a)
+	response.Token = plaintext
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Delete handles DELETE /api/agents/{agentID}
+func (h *AgentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	agentID := chi.URLParam(r, "agentID")
+
+	count, err := h.agentQueries.CountApps(ctx, agentID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to count apps on agent", "id", agentID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if count > 0 {
+		http.Error(w, fmt.Sprintf("%d app(s) still run on this agent, move them first", count), http.StatusConflict)
+		return
+	}
+
+	if err := h.agentQueries.Delete(ctx, agentID); err != nil {
+		slog.ErrorContext(ctx, "failed to delete agent", "id", agentID, "error", err)
+		http.Error(w, "failed to delete agent", http.StatusInternalServerError)
+		return
+	}
+	h.hub.Forget(agentID)
+
+	slog.InfoContext(ctx, "agent removed", "id", agentID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RequireAgent authenticates agents by their bearer token
+func (h *AgentHandler) RequireAgent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		a, err := h.agentQueries.GetByTokenHash(r.Context(), agent.HashToken(token))
+		if err != nil {
+			slog.Error("failed to look up agent", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		if a == nil {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), agentKey{}, a)))
+	})
+}
+
+// requestAgent returns the agent a request authenticated as
+func requestAgent(r *http.Request) *models.Agent {
+	a, _ := r.Context().Value(agentKey{}).(*models.Agent)
+	return a
+}
+
+// Report handles POST /agent/v1/report
+func (h *AgentHandler) Report(w http.ResponseWriter, r *http.Request) {
+	a := requestAgent(r)
+
+	var report agent.Report
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !h.hub.Online(a.ID) {
+		slog.InfoContext(r.Context(), "agent connected", "name", a.Name, "hostname", report.Hostname, "version", report.Version)
+	}
+	h.hub.Report(a.ID, &report)
+
+	if err := h.agentQueries.Touch(r.Context(), a.ID, report.Hostname, report.Version, time.Now()); err != nil {
+		slog.WarnContext(r.Context(), "failed to record agent report", "name", a.Name, "error", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Tasks handles GET /agent/v1/tasks, holding the request open until a task
+// is queued or agent.PollWait passes
+func (h *AgentHandler) Tasks(w http.ResponseWriter, r *http.Request) {
+	tasks := h.hub.Poll(r.Context(), requestAgent(r).ID, agent.PollWait)
+	if tasks == nil {
+		tasks = []agent.Task{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tasks)
+}
+
+// TaskResult handles POST /agent/v1/tasks/{taskID}
+func (h *AgentHandler) TaskResult(w http.ResponseWriter, r *http.Request) {
+	var result agent.TaskResult
+	if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.hub.Complete(requestAgent(r).ID, chi.URLParam(r, "taskID"), result); err != nil {
+		if errors.Is(err, agent.ErrUnknownTask) {
+			// The dispatcher gave up waiting, nothing to hand the result to
+			http.Error(w, err.Error(), http.StatusGone)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Image handles GET /agent/v1/image?ref=..., streaming an image the agent
+// is deploying as a docker save archive
+func (h *AgentHandler) Image(w http.ResponseWriter, r *http.Request) {
+	a := requestAgent(r)
+	ref := r.URL.Query().Get("ref")
+
+	if !h.hub.ImageAllowed(a.ID, ref) {
+		http.Error(w, "image is not being deployed to this agent", http.StatusForbidden)
+		return
+	}
+	if h.dockerClient == nil {
+		http.Error(w, "Docker client not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Images take longer to send than the server's write timeout and the
+	// request timeout allow
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		slog.DebugContext(r.Context(), "failed to lift write deadline for image download", "error", err)
+	}
+	archive, err := h.dockerClient.SaveImage(context.WithoutCancel(r.Context()), ref)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to export image for agent", "agent", a.Name, "image", ref, "error", err)
+		http.Error(w, "failed to export image", http.StatusInternalServerError)
+		return
+	}
+	defer archive.Close()
+
+	slog.InfoContext(r.Context(), "sending image to agent", "agent", a.Name, "image", ref)
+	w.Header().Set("Content-Type", "application/x-tar")
+	if _, err := io.Copy(w, archive); err != nil {
+		slog.WarnContext(r.Context(), "failed to send image to agent", "agent", a.Name, "image", ref, "error", err)
+	}
+}
+
+// agentSelect renders the agent picker of the app forms
+func agentSelect(agents []*models.Agent, selectedID string) string {
+	var b strings.Builder
+	b.WriteString(`<select name="agent_id" class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">`)
+	fmt.Fprintf(&b, `<option value="" %s>None, run on this server</option>`, selected(selectedID == ""))
+	for _, a := range agents {
+		fmt.Fprintf(&b, `<option value="%s" %s>%s</option>`,
+			html.EscapeString(a.ID), selected(a.ID == selectedID), html.EscapeString(a.Name))
+	}
+	b.WriteString(`</select>`)
+	return b.String()
+}
+
+func (h *PageHandler) renderAgentSettings(w http.ResponseWriter) {
+	if h.agentQueries == nil {
+		return
+	}
+
+	fmt.Fprint(w, `
+        <div class="mt-8">
+            <h2 class="text-xl font-bold mb-4">Agents</h2>
+            <div class="bg-white shadow-sm rounded-lg p-6 border border-gray-200">
+                <p class="text-gray-500 mb-4">Servers running <code class="bg-gray-100 px-1 rounded">schooner-agent</code>. Agents connect out to this server, so they need no open ports. Dockerfile apps assigned to an agent are built here, copied to the agent and run there.</p>
+                <div id="new-agent" class="hidden mb-4 px-3 py-2 rounded bg-green-50 border border-green-200 text-sm">
+                    <p class="text-green-700 mb-1">Start the agent on the server with this command. The token won't be shown again.</p>
+                    <code id="new-agent-command" class="font-mono break-all"></code>
+                </div>
+                <table class="w-full text-sm mb-6">
+                    <thead>
+                        <tr class="text-left text-gray-500 border-b border-gray-200">
+                            <th class="py-2">Name</th>
+                            <th class="py-2">Status</th>
+                            <th class="py-2">Host</th>
+                            <th class="py-2">Containers</th>
+                            <th class="py-2"></th>
+                        </tr>
+                    </thead>
+                    <tbody id="agents-body">
+                        <tr><td colspan="5" class="py-2 text-gray-400">Loading...</td></tr>
+                    </tbody>
+                </table>
+                <form onsubmit="submitAgent(event)" class="flex space-x-4 items-end">
+                    <div class="flex-1">
+                        <label class="block text-sm text-gray-500 mb-1">Name</label>
+                        <input type="text" name="name" required placeholder="edge-1"
+                            class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                    </div>
+                    <button type="submit" class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white">Add Agent</button>
+                </form>
+            </div>
+        </div>
+        <script>
+            function escapeAgentText(s) {
+                const div = document.createElement('div');
+                div.textContent = s == null ? '' : String(s);
+                return div.innerHTML;
+            }
+
+            function loadAgents() {
+                fetch('/api/agents')
+                    .then(response => response.json())
+                    .then(agents => {
+                        const body = document.getElementById('agents-body');
+                        if (agents.length === 0) {
+                            body.innerHTML = '<tr><td colspan="5" class="py-2 text-gray-400">No agents</td></tr>';
+                            return;
+                        }
+                        body.innerHTML = agents.map(agent => {
+                            const lastSeen = agent.last_seen_at && agent.last_seen_at.Valid ? 'last seen ' + new Date(agent.last_seen_at.Time).toLocaleString() : 'never connected';
+                            const status = agent.online
+                                ? '<span class="text-green-600">Online</span>'
+                                : '<span class="text-gray-400">Offline, ' + escapeAgentText(lastSeen) + '</span>';
+                            const host = agent.hostname ? escapeAgentText(agent.hostname) + ' <span class="text-gray-400">' + escapeAgentText(agent.version) + '</span>' : '-';
+                            return '<tr class="border-b border-gray-100">' +
+                                '<td class="py-2">' + escapeAgentText(agent.name) + '</td>' +
+                                '<td class="py-2">' + status + '</td>' +
+                                '<td class="py-2">' + host + '</td>' +
+                                '<td class="py-2">' + (agent.online ? agent.containers : '-') + '</td>' +
+                                '<td class="py-2 text-right"><button onclick="deleteAgent(\'' + agent.id + '\')" class="text-red-600 hover:text-red-700">Remove</button></td>' +
+                                '</tr>';
+                        }).join('');
+                    });
+            }
+
+            function submitAgent(event) {
+                event.preventDefault();
+                const form = event.target;
+
+                fetch('/api/agents', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ name: form.querySelector('input[name="name"]').value })
+                })
+                .then(response => {
+                    if (response.ok) {
+                        response.json().then(agent => {
+                            form.reset();
+                            document.getElementById('new-agent-command').textContent =
+                                'schooner-agent --url ' + window.location.origin + ' --token ' + agent.token;
+                            document.getElementById('new-agent').classList.remove('hidden');
+                            loadAgents();
+                        });
+                    } else {
+                        response.text().then(text => alert('Failed to add agent: ' + text));
+                    }
+                });
+            }
+
+            function deleteAgent(id) {
+                if (!confirm('Remove this agent? Its token stops working and containers already running on it are left alone.')) {
+                    return;
+                }
+                fetch('/api/agents/' + id, { method: 'DELETE' })
+                    .then(response => {
+                        if (response.ok) {
+                            showToast('Agent removed', 'success');
+                            loadAgents();
+                        } else {
+                            response.text().then(text => alert('Failed to remove agent: ' + text));
+                        }
+                    });
+            }
+
+            loadAgents();
+            setInterval(loadAgents, 15000);
+        </script>`)
+}
+
+// listAgents lists the agents for the app forms
+func (h *PageHandler) listAgents(ctx context.Context) []*models.Agent {
+	if h.agentQueries == nil {
+		return nil
+	}
+	agents, err := h.agentQueries.List(ctx)
+	if err != nil {
+		slog.Warn("failed to list agents", "error", err)
+	}
+	return agents
+}
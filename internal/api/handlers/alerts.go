@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"schooner/internal/alerting"
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+// AlertHandler handles alert rule management
+type AlertHandler struct {
+	alertQueries *queries.AlertQueries
+	evaluator    *alerting.Evaluator
+}
+
+// NewAlertHandler creates a new AlertHandler
+func NewAlertHandler(alertQueries *queries.AlertQueries, evaluator *alerting.Evaluator) *AlertHandler {
+	return &AlertHandler{
+		alertQueries: alertQueries,
+		evaluator:    evaluator,
+	}
+}
+
+// AlertRuleRequest is the request body for creating or updating an alert rule
+type AlertRuleRequest struct {
+	Name            string  `json:"name"`
+	Type            string  `json:"type"`
+	AppID           string  `json:"app_id"`
+	Threshold       float64 `json:"threshold"`
+	DurationSeconds int     `json:"duration_seconds"`
+	CooldownSeconds *int    `json:"cooldown_seconds"`
+	Enabled         *bool   `json:"enabled"`
+}
+
+// apply copies the request fields onto a rule
+func (req *AlertRuleRequest) apply(rule *models.AlertRule) {
+	rule.Name = strings.TrimSpace(req.Name)
+	rule.Type = models.AlertRuleType(req.Type)
+	rule.AppID = sql.NullString{String: req.AppID, Valid: req.AppID != ""}
+	rule.Threshold = req.Threshold
+	rule.DurationSeconds = req.DurationSeconds
+	if req.CooldownSeconds != nil {
+		rule.CooldownSeconds = *req.CooldownSeconds
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+}
+
+// ListRules handles GET /api/alerts/rules
+func (h *AlertHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.alertQueries.List(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list alert rules", "error", err)
+		http.Error(w, "failed to list alert rules", http.StatusInternalServerError)
+		return
+	}
+
+	if rules == nil {
+		rules = []*models.AlertRule{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+// CreateRule handles POST /api/alerts/rules
+func (h *AlertHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	var req AlertRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	rule := &models.AlertRule{
+		ID:              uuid.New().String(),
+		CooldownSeconds: 3600,
+		Enabled:         true,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	req.apply(rule)
+
+	if err := rule.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.alertQueries.Create(r.Context(), rule); err != nil {
+		slog.ErrorContext(r.Context(), "failed to create alert rule", "error", err)
+		http.Error(w, "failed to create alert rule", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "alert rule created", "id", rule.ID, "name", rule.Name, "type", rule.Type)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// UpdateRule handles PUT /api/alerts/rules/{ruleID}
+func (h *AlertHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ruleID := chi.URLParam(r, "ruleID")
+
+	rule, err := h.alertQueries.GetByID(ctx, ruleID)
+	if err != nil {
+		http.Error(w, "failed to get alert rule", http.StatusInternalServerError)
+		return
+	}
+	if rule == nil {
+		http.Error(w, "alert rule not found", http.StatusNotFound)
+		return
+	}
+
+	var req AlertRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.apply(rule)
+
+	if err := rule.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.alertQueries.Update(ctx, rule); err != nil {
+		slog.ErrorContext(ctx, "failed to update alert rule", "id", ruleID, "error", err)
+		http.Error(w, "failed to update alert rule", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// DeleteRule handles DELETE /api/alerts/rules/{ruleID}
+func (h *AlertHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	ruleID := chi.URLParam(r, "ruleID")
+
+	if err := h.alertQueries.Delete(r.Context(), ruleID); err != nil {
+		slog.ErrorContext(r.Context(), "failed to delete alert rule", "id", ruleID, "error", err)
+		http.Error(w, "failed to delete alert rule", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Alert rule deleted",
+	})
+}
+
+// ListActive handles GET /api/alerts/active
+func (h *AlertHandler) ListActive(w http.ResponseWriter, r *http.Request) {
+	active := []alerting.ActiveAlert{}
+	if h.evaluator != nil {
+		active = h.evaluator.Active()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(active)
+}
+
+func (h *PageHandler) renderAlertSettings(w http.ResponseWriter) {
+	fmt.Fprint(w, `
+        <div class="mt-8">
+            <h2 class="text-xl font-bold mb-4">Alerts</h2>
+            <div class="bg-white shadow-sm rounded-lg p-6 border border-gray-200">
+                <p class="text-gray-500 mb-4">Rules are checked every 30 seconds and delivered through the notification channels above. A firing rule re-notifies at most once per cooldown.</p>
+                <div id="active-alerts" class="mb-4"></div>
+                <table class="w-full text-sm mb-6">
+                    <thead>
+                        <tr class="text-left text-gray-500 border-b border-gray-200">
+                            <th class="py-2">Name</th>
+                            <th class="py-2">Condition</th>
+                            <th class="py-2">Scope</th>
+                            <th class="py-2">Last fired</th>
+                            <th class="py-2"></th>
+                        </tr>
+                    </thead>
+                    <tbody id="alert-rules-body">
+                        <tr><td colspan="5" class="py-2 text-gray-400">Loading...</td></tr>
+                    </tbody>
+                </table>
+                <form onsubmit="submitAlertRule(event)">
+                    <div class="grid grid-cols-1 md:grid-cols-3 gap-4 mb-4">
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Name</label>
+                            <input type="text" name="name" required placeholder="CPU pegged"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Type</label>
+                            <select name="type" class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                                <option value="container_down">Container down</option>
+                                <option value="cpu_high">CPU above %</option>
+                                <option value="disk_high">Host disk above %</option>
+                                <option value="build_failures">Consecutive failed builds</option>
+                            </select>
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">App ID (optional)</label>
+                            <input type="text" name="app_id" placeholder="All apps"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Threshold</label>
+                            <input type="number" step="any" name="threshold" value="90"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">For (seconds)</label>
+                            <input type="number" name="duration_seconds" value="300" min="0"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Cooldown (seconds)</label>
+                            <input type="number" name="cooldown_seconds" value="3600" min="0"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        </div>
+                    </div>
+                    <button type="submit" class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white">Add Rule</button>
+                </form>
+            </div>
+        </div>
+        <script>
+            function escapeAlertText(s) {
+                const div = document.createElement('div');
+                div.textContent = s == null ? '' : String(s);
+                return div.innerHTML;
+            }
+
+            function describeAlertRule(rule) {
+                const forText = rule.duration_seconds > 0 ? ' for ' + rule.duration_seconds + 's' : '';
+                switch (rule.type) {
+                    case 'container_down': return 'Container down' + forText;
+                    case 'cpu_high': return 'CPU > ' + rule.threshold + '%' + forText;
+                    case 'disk_high': return 'Disk > ' + rule.threshold + '%' + forText;
+                    case 'build_failures': return rule.threshold + ' failed builds in a row';
+                    default: return rule.type;
+                }
+            }
+
+            function loadAlertRules() {
+                fetch('/api/alerts/rules')
+                    .then(response => response.json())
+                    .then(rules => {
+                        const body = document.getElementById('alert-rules-body');
+                        if (rules.length === 0) {
+                            body.innerHTML = '<tr><td colspan="5" class="py-2 text-gray-400">No alert rules configured</td></tr>';
+                            return;
+                        }
+                        body.innerHTML = rules.map(rule => {
+                            const scope = rule.app_id && rule.app_id.Valid ? rule.app_id.String : 'All apps';
+                            const lastFired = rule.last_fired_at && rule.last_fired_at.Valid ? new Date(rule.last_fired_at.Time).toLocaleString() : 'Never';
+                            return '<tr class="border-b border-gray-100">' +
+                                '<td class="py-2">' + escapeAlertText(rule.name) + (rule.enabled ? '' : ' <span class="text-gray-400">(disabled)</span>') + '</td>' +
+                                '<td class="py-2">' + escapeAlertText(describeAlertRule(rule)) + '</td>' +
+                                '<td class="py-2 font-mono text-xs">' + escapeAlertText(scope) + '</td>' +
+                                '<td class="py-2 text-gray-500">' + escapeAlertText(lastFired) + '</td>' +
+                                '<td class="py-2 text-right"><button onclick="deleteAlertRule(\'' + rule.id + '\')" class="text-red-600 hover:text-red-700">Delete</button></td>' +
+                                '</tr>';
+                        }).join('');
+                    });
+
+                fetch('/api/alerts/active')
+                    .then(response => response.json())
+                    .then(alerts => {
+                        const el = document.getElementById('active-alerts');
+                        if (alerts.length === 0) {
+                            el.innerHTML = '';
+                            return;
+                        }
+                        el.innerHTML = alerts.map(a =>
+                            '<div class="mb-2 px-3 py-2 rounded bg-red-50 border border-red-200 text-red-700 text-sm">' +
+                            '<strong>' + escapeAlertText(a.rule_name) + '</strong> on ' + escapeAlertText(a.subject) + ': ' + escapeAlertText(a.detail) +
+                            '</div>'
+                        ).join('');
+                    });
+            }
+
+            function submitAlertRule(event) {
+                event.preventDefault();
+                const form = event.target;
+                const data = {
+                    name: form.querySelector('input[name="name"]').value,
+                    type: form.querySelector('select[name="type"]').value,
+                    app_id: form.querySelector('input[name="app_id"]').value.trim(),
+                    threshold: parseFloat(form.querySelector('input[name="threshold"]').value) || 0,
+                    duration_seconds: parseInt(form.querySelector('input[name="duration_seconds"]').value, 10) || 0,
+                    cooldown_seconds: parseInt(form.querySelector('input[name="cooldown_seconds"]').value, 10) || 0
+                };
+
+                fetch('/api/alerts/rules', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify(data)
+                })
+                .then(response => {
+                    if (response.ok) {
+                        form.reset();
+                        showToast('Alert rule added', 'success');
+                        loadAlertRules();
+                    } else {
+                        response.text().then(text => alert('Failed to add rule: ' + text));
+                    }
+                });
+            }
+
+            function deleteAlertRule(id) {
+                if (!confirm('Delete this alert rule?')) {
+                    return;
+                }
+                fetch('/api/alerts/rules/' + id, { method: 'DELETE' })
+                    .then(response => {
+                        if (response.ok) {
+                            loadAlertRules();
+                        } else {
+                            response.text().then(text => alert('Failed to delete: ' + text));
+                        }
+                    });
+            }
+
+            loadAlertRules();
+        </script>`)
+}
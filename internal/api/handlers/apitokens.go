@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"schooner/internal/auth"
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+// APITokenHandler handles personal API token management
+type APITokenHandler struct {
+	tokenQueries *queries.APITokenQueries
+}
+
+// NewAPITokenHandler creates a new APITokenHandler
+func NewAPITokenHandler(tokenQueries *queries.APITokenQueries) *APITokenHandler {
+	return &APITokenHandler{
+		tokenQueries: tokenQueries,
+	}
+}
+
+// APITokenRequest is the request body for creating an API token
+type APITokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"` // 0 means never
+}
+
+// apiTokenResponse is an API token as returned by the API
+type apiTokenResponse struct {
+	*models.APIToken
+	ScopeList []models.APITokenScope `json:"scopes"`
+	Token     string                 `json:"token,omitempty"` // plaintext, only on create
+}
+
+func newAPITokenResponse(token *models.APIToken) apiTokenResponse {
+	return apiTokenResponse{APIToken: token, ScopeList: token.ScopeList()}
+}
+
+// List handles GET /api/tokens
+func (h *APITokenHandler) List(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.tokenQueries.List(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list API tokens", "error", err)
+		http.Error(w, "failed to list API tokens", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]apiTokenResponse, len(tokens))
+	for i, token := range tokens {
+		response[i] = newAPITokenResponse(token)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Create handles POST /api/tokens
+func (h *APITokenHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req APITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.ExpiresInDays < 0 {
+		http.Error(w, "expires_in_days must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	plaintext, prefix, hash, err := auth.GenerateAPIToken()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to generate API token", "error", err)
+		http.Error(w, "failed to create API token", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	token := &models.APIToken{
+		ID:        uuid.New().String(),
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    prefix,
+		TokenHash: hash,
+		Scopes:    models.JoinScopes(req.Scopes),
+		CreatedAt: now,
+	}
+	if req.ExpiresInDays > 0 {
+		token.ExpiresAt = sql.NullTime{Time: now.AddDate(0, 0, req.ExpiresInDays), Valid: true}
+	}
+	if session := auth.GetSession(r.Context()); session != nil {
+		token.CreatedBy = session.Username
+	}
+
+	if err := token.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.tokenQueries.Create(r.Context(), token); err != nil {
+		slog.ErrorContext(r.Context(), "failed to create API token", "error", err)
+		http.Error(w, "failed to create API token", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "API token created", "id", token.ID, "name", token.Name, "scopes", token.Scopes, "created_by", token.CreatedBy)
+
+	response := newAPITokenResponse(token)
+	response.Token = plaintext
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Delete handles DELETE /api/tokens/{tokenID}
+func (h *APITokenHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	tokenID := chi.URLParam(r, "tokenID")
+
+	if err := h.tokenQueries.Delete(r.Context(), tokenID); err != nil {
+		slog.ErrorContext(r.Context(), "failed to revoke API token", "id", tokenID, "error", err)
+		http.Error(w, "failed to revoke API token", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "API token revoked", "id", tokenID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "API token revoked",
+	})
+}
+
+func (h *PageHandler) renderAPITokenSettings(w http.ResponseWriter) {
+	fmt.Fprint(w, `
+        <div class="mt-8">
+            <h2 class="text-xl font-bold mb-4">API Tokens</h2>
+            <div class="bg-white shadow-sm rounded-lg p-6 border border-gray-200">
+                <p class="text-gray-500 mb-4">Personal tokens authenticate scripts against <code class="bg-gray-100 px-1 rounded">/api/*</code> with an <code class="bg-gray-100 px-1 rounded">Authorization: Bearer</code> header. Tokens without scopes have full access.</p>
+                <div id="new-api-token" class="hidden mb-4 px-3 py-2 rounded bg-green-50 border border-green-200 text-sm">
+                    <p class="text-green-700 mb-1">Copy this token now. It won't be shown again.</p>
+                    <code id="new-api-token-value" class="font-mono break-all"></code>
+                </div>
+                <table class="w-full text-sm mb-6">
+                    <thead>
+                        <tr class="text-left text-gray-500 border-b border-gray-200">
+                            <th class="py-2">Name</th>
+                            <th class="py-2">Token</th>
+                            <th class="py-2">Scopes</th>
+                            <th class="py-2">Last used</th>
+                            <th class="py-2">Expires</th>
+                            <th class="py-2"></th>
+                        </tr>
+                    </thead>
+                    <tbody id="api-tokens-body">
+                        <tr><td colspan="6" class="py-2 text-gray-400">Loading...</td></tr>
+                    </tbody>
+                </table>
+                <form onsubmit="submitAPIToken(event)">
+                    <div class="grid grid-cols-1 md:grid-cols-3 gap-4 mb-4">
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Name</label>
+                            <input type="text" name="name" required placeholder="CI deploys"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Scopes</label>
+                            <div class="flex space-x-4 py-2 text-sm text-gray-700">
+                                <label><input type="checkbox" name="scope" value="read" class="mr-1">read</label>
+                                <label><input type="checkbox" name="scope" value="deploy" class="mr-1">deploy</label>
+                                <label><input type="checkbox" name="scope" value="admin" class="mr-1">admin</label>
+                            </div>
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Expires</label>
+                            <select name="expires_in_days" class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                                <option value="30">In 30 days</option>
+                                <option value="90">In 90 days</option>
+                                <option value="365">In 1 year</option>
+                                <option value="0">Never</option>
+                            </select>
+                        </div>
+                    </div>
+                    <button type="submit" class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white">Create Token</button>
+                </form>
+            </div>
+        </div>
+        <script>
+            function escapeTokenText(s) {
+                const div = document.createElement('div');
+                div.textContent = s == null ? '' : String(s);
+                return div.innerHTML;
+            }
+
+            function loadAPITokens() {
+                fetch('/api/tokens')
+                    .then(response => response.json())
+                    .then(tokens => {
+                        const body = document.getElementById('api-tokens-body');
+                        if (tokens.length === 0) {
+                            body.innerHTML = '<tr><td colspan="6" class="py-2 text-gray-400">No API tokens</td></tr>';
+                            return;
+                        }
+                        body.innerHTML = tokens.map(token => {
+                            const lastUsed = token.last_used_at && token.last_used_at.Valid ? new Date(token.last_used_at.Time).toLocaleString() : 'Never';
+                            const expires = token.expires_at && token.expires_at.Valid ? new Date(token.expires_at.Time).toLocaleDateString() : 'Never';
+                            return '<tr class="border-b border-gray-100">' +
+                                '<td class="py-2">' + escapeTokenText(token.name) + '</td>' +
+                                '<td class="py-2 font-mono text-xs">' + escapeTokenText(token.prefix) + '...</td>' +
+                                '<td class="py-2">' + escapeTokenText(token.scopes.join(', ')) + '</td>' +
+                                '<td class="py-2 text-gray-500">' + escapeTokenText(lastUsed) + '</td>' +
+                                '<td class="py-2 text-gray-500">' + escapeTokenText(expires) + '</td>' +
+                                '<td class="py-2 text-right"><button onclick="revokeAPIToken(\'' + token.id + '\')" class="text-red-600 hover:text-red-700">Revoke</button></td>' +
+                                '</tr>';
+                        }).join('');
+                    });
+            }
+
+            function submitAPIToken(event) {
+                event.preventDefault();
+                const form = event.target;
+                const data = {
+                    name: form.querySelector('input[name="name"]').value,
+                    scopes: Array.from(form.querySelectorAll('input[name="scope"]:checked')).map(el => el.value),
+                    expires_in_days: parseInt(form.querySelector('select[name="expires_in_days"]').value, 10) || 0
+                };
+
+                fetch('/api/tokens', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify(data)
+                })
+                .then(response => {
+                    if (response.ok) {
+                        response.json().then(token => {
+                            form.reset();
+                            document.getElementById('new-api-token-value').textContent = token.token;
+                            document.getElementById('new-api-token').classList.remove('hidden');
+                            loadAPITokens();
+                        });
+                    } else {
+                        response.text().then(text => alert('Failed to create token: ' + text));
+                    }
+                });
+            }
+
+            function revokeAPIToken(id) {
+                if (!confirm('Revoke this token? Scripts using it will stop working.')) {
+                    return;
+                }
+                fetch('/api/tokens/' + id, { method: 'DELETE' })
+                    .then(response => {
+                        if (response.ok) {
+                            showToast('Token revoked', 'success');
+                            loadAPITokens();
+                        } else {
+                            response.text().then(text => alert('Failed to revoke token: ' + text));
+                        }
+                    });
+            }
+
+            loadAPITokens();
+        </script>`)
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+// APIv1Handler serves the /api/v1 list endpoints that differ from their
+// unversioned counterparts by being paginated. Every other /api/v1 route is
+// the unversioned handler.
+type APIv1Handler struct {
+	appQueries   *queries.AppQueries
+	buildQueries *queries.BuildQueries
+	logQueries   *queries.LogQueries
+}
+
+// NewAPIv1Handler creates a new APIv1Handler
+func NewAPIv1Handler(appQueries *queries.AppQueries, buildQueries *queries.BuildQueries, logQueries *queries.LogQueries) *APIv1Handler {
+	return &APIv1Handler{
+		appQueries:   appQueries,
+		buildQueries: buildQueries,
+		logQueries:   logQueries,
+	}
+}
+
+// ListApps handles GET /api/v1/apps, optionally filtered like GET /api/apps
+func (h *APIv1Handler) ListApps(w http.ResponseWriter, r *http.Request) {
+	limit, after, err := pageParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	apps, err := h.appQueries.ListPage(r.Context(), parseAppFilter(r), after, limit+1)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list apps", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	writePage(w, apps, limit, func(app *models.App) string { return app.ID })
+}
+
+// ListBuilds handles GET /api/v1/builds, optionally filtered like
+// GET /api/builds
+func (h *APIv1Handler) ListBuilds(w http.ResponseWriter, r *http.Request) {
+	limit, before, err := pageParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := parseBuildFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	builds, err := h.buildQueries.ListPage(r.Context(), filter, before, limit+1)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list builds", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	writePage(w, builds, limit, func(build *models.Build) string { return build.ID })
+}
+
+// ListBuildLogs handles GET /api/v1/builds/{buildID}/logs
+func (h *APIv1Handler) ListBuildLogs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	buildID := chi.URLParam(r, "buildID")
+
+	limit, after, err := pageParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var afterID int64
+	if after != "" {
+		if afterID, err = strconv.ParseInt(after, 10, 64); err != nil {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+	}
+
+	build, err := h.buildQueries.GetByID(ctx, buildID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get build", "buildID", buildID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if build == nil {
+		http.Error(w, "build not found", http.StatusNotFound)
+		return
+	}
+
+	logs, err := h.logQueries.GetPageByBuildID(ctx, buildID, afterID, limit+1)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get build logs", "buildID", buildID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	writePage(w, logs, limit, func(log *models.BuildLog) string { return strconv.FormatInt(log.ID, 10) })
+}
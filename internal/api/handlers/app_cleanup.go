@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"schooner/internal/docker"
+	"schooner/internal/github"
+	"schooner/internal/models"
+)
+
+// CleanupOptions are what deleting an app also removes besides its record,
+// checkout and routes
+type CleanupOptions struct {
+	Containers bool // Stop and remove its containers or Swarm service
+	Images     bool // Delete the images built for it
+	Volumes    bool // Remove the named volumes its containers mounted
+	DNS        bool // Delete the Cloudflare DNS record of its hostname
+	Webhook    bool // Delete the GitHub webhook Schooner installed for it
+}
+
+// CleanupResult is how removing one thing a deleted app left behind went
+type CleanupResult struct {
+	Step  string `json:"step"` // containers, images, volumes, dns or webhook
+	Error string `json:"error,omitempty"`
+}
+
+// newCleanupResult records a cleanup step and logs it if it failed
+func newCleanupResult(app *models.App, step string, err error) CleanupResult {
+	if err == nil {
+		return CleanupResult{Step: step}
+	}
+	slog.Warn("failed to clean up deleted app", "app", app.Name, "step", step, "error", err)
+	return CleanupResult{Step: step, Error: err.Error()}
+}
+
+// parseCleanupOptions reads the containers, images, volumes, dns and webhook
+// flags of an app deletion. Images and volumes can't be removed while
+// containers use them, so they remove the containers too.
+func parseCleanupOptions(r *http.Request) (CleanupOptions, error) {
+	var opts CleanupOptions
+	params := r.URL.Query()
+	for name, flag := range map[string]*bool{
+		"containers": &opts.Containers,
+		"images":     &opts.Images,
+		"volumes":    &opts.Volumes,
+		"dns":        &opts.DNS,
+		"webhook":    &opts.Webhook,
+	} {
+		value := params.Get(name)
+		if value == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("invalid %s flag %q", name, value)
+		}
+		*flag = b
+	}
+	if opts.Images || opts.Volumes {
+		opts.Containers = true
+	}
+	return opts, nil
+}
+
+// removeAppContainers removes an app's containers and, if asked to, its
+// images and volumes, with a result for each step
+func (h *AppHandler) removeAppContainers(ctx context.Context, app *models.App, opts CleanupOptions) []CleanupResult {
+	steps := []string{"containers"}
+	if opts.Images {
+		steps = append(steps, "images")
+	}
+	if opts.Volumes {
+		steps = append(steps, "volumes")
+	}
+	// everyStep gives each step the same outcome
+	everyStep := func(err error) []CleanupResult {
+		var results []CleanupResult
+		for _, step := range steps {
+			results = append(results, newCleanupResult(app, step, err))
+		}
+		return results
+	}
+
+	if app.GetAgent() != "" {
+		return everyStep(fmt.Errorf("containers on agents are not removed"))
+	}
+	dockerClient, err := h.dockerFor(ctx, app)
+	if err != nil {
+		return everyStep(err)
+	}
+	if dockerClient == nil {
+		return everyStep(fmt.Errorf("Docker client %w", errNotAvailable))
+	}
+	defer h.containerChanged(app.ID)
+
+	// Compose takes down the stack's containers, the images it built and
+	// the volumes in the compose file in one go
+	if app.BuildStrategy == models.BuildStrategyCompose {
+		args := []string{"down", "--remove-orphans"}
+		if opts.Volumes {
+			args = append(args, "--volumes")
+		}
+		if opts.Images {
+			args = append(args, "--rmi", "local")
+		}
+		err := h.runComposeCommand(ctx, app, dockerClient, args...)
+		if err == nil {
+			removeAppNetwork(ctx, dockerClient, app)
+		}
+		return everyStep(err)
+	}
+
+	var volumes []string
+	deployConfig, _ := app.GetDeployConfig()
+	swarm := deployConfig != nil && deployConfig.IsSwarm()
+	if swarm {
+		err = dockerClient.RemoveService(ctx, app.GetContainerName())
+	} else {
+		volumes, err = dockerClient.RemoveAppContainers(ctx, app.GetContainerName(), app.ID)
+	}
+	if err != nil {
+		return everyStep(err)
+	}
+	results := []CleanupResult{newCleanupResult(app, "containers", nil)}
+	if !swarm {
+		removeAppNetwork(ctx, dockerClient, app)
+	}
+
+	if opts.Images {
+		_, _, err := dockerClient.CleanupOldImages(ctx, app.GetImageName(), 0)
+		results = append(results, newCleanupResult(app, "images", err))
+	}
+	if opts.Volumes && swarm {
+		// Each node has its own copy of a service's volumes
+		results = append(results, newCleanupResult(app, "volumes", fmt.Errorf("volumes of Swarm services are not removed")))
+	} else if opts.Volumes {
+		var errs []error
+		for _, volume := range volumes {
+			if err := dockerClient.RemoveVolume(ctx, volume); err != nil {
+				errs = append(errs, fmt.Errorf("volume %s: %w", volume, err))
+			}
+		}
+		results = append(results, newCleanupResult(app, "volumes", errors.Join(errs...)))
+	}
+	return results
+}
+
+// removeAppNetwork removes the private network of a deleted app once its
+// containers and add-ons are gone, as housekeeping keeps Schooner's
+// networks. It stays while other containers are still attached.
+func removeAppNetwork(ctx context.Context, dockerClient *docker.Client, app *models.App) {
+	if err := dockerClient.RemoveNetwork(ctx, app.Network()); err != nil {
+		slog.DebugContext(ctx, "app network not removed", "app", app.Name, "error", err)
+	}
+}
+
+// deleteAppDNS deletes the Cloudflare DNS record of a deleted app's hostname
+func (h *AppHandler) deleteAppDNS(ctx context.Context, app *models.App) CleanupResult {
+	if h.tunnels == nil {
+		return newCleanupResult(app, "dns", fmt.Errorf("Cloudflare tunnel is not configured"))
+	}
+	return newCleanupResult(app, "dns", h.tunnels.DeleteAppRecord(ctx, app))
+}
+
+// deleteAppWebhook deletes the GitHub webhook Schooner installed for a
+// deleted app. Webhooks pointing at the shared endpoint are kept, as other
+// apps of the repository may rely on them.
+func (h *AppHandler) deleteAppWebhook(ctx context.Context, app *models.App) CleanupResult {
+	if h.githubClient == nil || !h.githubClient.HasToken() {
+		return newCleanupResult(app, "webhook", fmt.Errorf("GitHub token not configured"))
+	}
+	owner, repo, err := github.ParseRepoURL(app.RepoURL)
+	if err != nil {
+		return newCleanupResult(app, "webhook", err)
+	}
+
+	webhooks, err := h.githubClient.ListWebhooks(ctx, owner, repo)
+	if err != nil {
+		return newCleanupResult(app, "webhook", err)
+	}
+	webhookURL := h.cfg.BaseURL() + "/webhook/github/" + app.ID
+	for _, wh := range webhooks {
+		if wh.Config.URL != webhookURL {
+			continue
+		}
+		if err := h.githubClient.DeleteWebhook(ctx, owner, repo, wh.ID); err != nil {
+			return newCleanupResult(app, "webhook", err)
+		}
+		slog.Info("webhook deleted", "app", app.Name, "webhookID", wh.ID)
+	}
+	return newCleanupResult(app, "webhook", nil)
+}
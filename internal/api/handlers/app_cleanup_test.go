@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+func TestParseCleanupOptions(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    CleanupOptions
+		wantErr bool
+	}{
+		{query: "", want: CleanupOptions{}},
+		{query: "containers=true&dns=1", want: CleanupOptions{Containers: true, DNS: true}},
+		{query: "images=true", want: CleanupOptions{Containers: true, Images: true}},
+		{query: "volumes=1&containers=false", want: CleanupOptions{Containers: true, Volumes: true}},
+		{query: "webhook=yes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodDelete, "/api/apps/a1?"+tt.query, nil)
+			got, err := parseCleanupOptions(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCleanupOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseCleanupOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppHandler_DeleteCleanup(t *testing.T) {
+	db := newTestDB(t)
+
+	ctx := context.Background()
+	appQueries := queries.NewAppQueries(db.DB)
+	for _, name := range []string{"api", "web"} {
+		app := &models.App{
+			ID: name, Name: name, RepoURL: "https://github.com/example/" + name + ".git", Branch: "main",
+			BuildStrategy: models.BuildStrategyDockerfile, CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		}
+		if err := appQueries.Create(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := NewAppHandler(nil, appQueries, nil, nil, nil, nil, nil, nil)
+	router := chi.NewRouter()
+	router.Delete("/api/apps/{appID}", h.Delete)
+
+	// Without options nothing else is touched
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/apps/web", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+
+	// Cleanup that can't be done is reported without failing the delete
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/apps/api?images=true&dns=true&webhook=true", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	var resp struct {
+		Cleanup []CleanupResult `json:"cleanup"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	steps := []string{"containers", "images", "dns", "webhook"}
+	if len(resp.Cleanup) != len(steps) {
+		t.Fatalf("cleanup = %+v, want steps %v", resp.Cleanup, steps)
+	}
+	for i, result := range resp.Cleanup {
+		if result.Step != steps[i] || result.Error == "" {
+			t.Errorf("cleanup step %d = %+v, want %s to fail", i, result, steps[i])
+		}
+	}
+
+	apps, err := appQueries.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(apps) != 0 {
+		t.Errorf("%d apps left, want both deleted", len(apps))
+	}
+}
@@ -6,80 +6,473 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/docker/docker/api/types"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"schooner/internal/addons"
+	"schooner/internal/agent"
 	"schooner/internal/build"
 	"schooner/internal/build/strategies"
 	"schooner/internal/cloudflare"
 	"schooner/internal/config"
 	"schooner/internal/database/queries"
 	"schooner/internal/docker"
-	"schooner/internal/git"
+	"schooner/internal/events"
 	"schooner/internal/github"
 	"schooner/internal/models"
+	"schooner/internal/proxy"
 )
 
 // AppHandler handles app-related requests
 type AppHandler struct {
-	cfg           *config.Config
-	appQueries    *queries.AppQueries
-	buildQueries  *queries.BuildQueries
-	dockerClient  *docker.Client
-	tunnelManager *cloudflare.Manager
-	orchestrator  *build.Orchestrator
-	githubClient  *github.Client
+	cfg             *config.Config
+	appQueries      *queries.AppQueries
+	buildQueries    *queries.BuildQueries
+	dockerClient    *docker.Client
+	proxyRouter     *proxy.Router
+	orchestrator    *build.Orchestrator
+	githubClient    *github.Client
+	addonManager    *addons.Manager
+	dockerHosts     *docker.Hosts
+	hostQueries     *queries.DockerHostQueries
+	agents          *agent.Hub
+	agentQueries    *queries.AgentQueries
+	statuses        *ContainerStatusCache
+	stats           *docker.StatsCollector
+	events          *events.Bus
+	tunnels         *cloudflare.Manager
+	configRevisions *queries.ConfigRevisionQueries
+	dependencies    *queries.DependencyQueries
+	ports           *PortAllocator
 }
 
 // NewAppHandler creates a new AppHandler
-func NewAppHandler(cfg *config.Config, appQueries *queries.AppQueries, buildQueries *queries.BuildQueries, dockerClient *docker.Client, tunnelManager *cloudflare.Manager, orchestrator *build.Orchestrator, githubClient *github.Client) *AppHandler {
+func NewAppHandler(cfg *config.Config, appQueries *queries.AppQueries, buildQueries *queries.BuildQueries, dockerClient *docker.Client, proxyRouter *proxy.Router, orchestrator *build.Orchestrator, githubClient *github.Client, addonManager *addons.Manager) *AppHandler {
 	return &AppHandler{
-		cfg:           cfg,
-		appQueries:    appQueries,
-		buildQueries:  buildQueries,
-		dockerClient:  dockerClient,
-		tunnelManager: tunnelManager,
-		orchestrator:  orchestrator,
-		githubClient:  githubClient,
+		cfg:          cfg,
+		appQueries:   appQueries,
+		buildQueries: buildQueries,
+		dockerClient: dockerClient,
+		proxyRouter:  proxyRouter,
+		orchestrator: orchestrator,
+		githubClient: githubClient,
+		addonManager: addonManager,
 	}
 }
 
+// SetDockerHosts lets apps be deployed to and managed on remote Docker hosts
+func (h *AppHandler) SetDockerHosts(hosts *docker.Hosts, hostQueries *queries.DockerHostQueries) {
+	h.dockerHosts = hosts
+	h.hostQueries = hostQueries
+}
+
+// SetAgents lets apps run on and be managed through Schooner agents
+func (h *AppHandler) SetAgents(agents *agent.Hub, agentQueries *queries.AgentQueries) {
+	h.agents = agents
+	h.agentQueries = agentQueries
+}
+
+// SetStatusCache sets the cache of app container statuses, which starting,
+// stopping and restarting an app clears
+func (h *AppHandler) SetStatusCache(cache *ContainerStatusCache) {
+	h.statuses = cache
+}
+
+// SetStatsCollector sets where container resource usage is read from
+func (h *AppHandler) SetStatsCollector(stats *docker.StatsCollector) {
+	h.stats = stats
+}
+
+// SetPorts lets apps saved with auto_port get a free host port
+func (h *AppHandler) SetPorts(ports *PortAllocator) {
+	h.ports = ports
+}
+
+// SetEvents sets where changes to apps and their containers are published
+func (h *AppHandler) SetEvents(bus *events.Bus) {
+	h.events = bus
+}
+
+// SetTunnelManager lets deleting an app also delete its Cloudflare DNS record
+func (h *AppHandler) SetTunnelManager(tunnels *cloudflare.Manager) {
+	h.tunnels = tunnels
+}
+
+// containerChanged drops the cached status of an app whose container was
+// just started, stopped or restarted, and tells the pages showing it
+func (h *AppHandler) containerChanged(appID string) {
+	h.statuses.Forget(appID)
+	h.events.Publish(events.Event{Kind: events.KindContainer, AppID: appID})
+}
+
+// dockerFor returns the Docker client for the host an app runs on
+func (h *AppHandler) dockerFor(ctx context.Context, app *models.App) (*docker.Client, error) {
+	if h.dockerHosts == nil {
+		return h.dockerClient, nil
+	}
+	return h.dockerHosts.ForApp(ctx, app)
+}
+
+// containerStatus returns the status of an app's container, from its agent's
+// latest report or its Docker host
+func (h *AppHandler) containerStatus(ctx context.Context, app *models.App) *docker.ContainerStatus {
+	if app.GetAgent() != "" {
+		if h.agents == nil {
+			return nil
+		}
+		status, _ := h.agents.ContainerStatus(app.GetAgent(), app.GetContainerName())
+		return status
+	}
+
+	dockerClient, err := h.dockerFor(ctx, app)
+	if err != nil || dockerClient == nil {
+		return nil
+	}
+	status, _ := dockerClient.GetContainerStatus(ctx, app.GetContainerName())
+	return status
+}
+
+// containerStatuses is what each container action reports once it's done
+var containerStatuses = map[string]string{
+	agent.TaskStart:   "started",
+	agent.TaskStop:    "stopped",
+	agent.TaskRestart: "restarted",
+}
+
+// errNotAvailable means there's no agent hub or Docker client to reach an
+// app's container through
+var errNotAvailable = errors.New("not available")
+
+// controlContainer starts, stops or restarts an app's container and records
+// whether it was stopped on purpose, so the reconciler leaves it down
+func (h *AppHandler) controlContainer(ctx context.Context, app *models.App, action string) error {
+	if err := h.runContainerTask(ctx, app, action); err != nil {
+		return err
+	}
+
+	stopped := action == agent.TaskStop
+	if app.Stopped != stopped {
+		if err := h.appQueries.SetStopped(ctx, app.ID, stopped); err != nil {
+			slog.WarnContext(ctx, "failed to record app stopped", "app", app.Name, "error", err)
+		}
+		app.Stopped = stopped
+	}
+	h.events.Record(ctx, models.ActivityContainer, app.ID, "",
+		"Container of %s %s by %s", app.Name, containerStatuses[action], requesterOf(ctx))
+	return nil
+}
+
+// runContainerTask starts, stops or restarts an app's container on its agent
+// or Docker host
+func (h *AppHandler) runContainerTask(ctx context.Context, app *models.App, action string) error {
+	defer h.containerChanged(app.ID)
+
+	if app.GetAgent() != "" {
+		if h.agents == nil {
+			return fmt.Errorf("agents are %w", errNotAvailable)
+		}
+		_, err := h.agents.Dispatch(ctx, app.GetAgent(), agent.Task{
+			Type:      action,
+			Container: app.GetContainerName(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to %s container on agent: %w", action, err)
+		}
+		return nil
+	}
+
+	dockerClient, err := h.dockerFor(ctx, app)
+	if err != nil {
+		return err
+	}
+	if dockerClient == nil {
+		return fmt.Errorf("Docker client %w", errNotAvailable)
+	}
+
+	deployConfig, _ := app.GetDeployConfig()
+	swarm := deployConfig != nil && deployConfig.IsSwarm()
+
+	switch action {
+	case agent.TaskStop:
+		// For compose apps, use docker compose down
+		if app.BuildStrategy == models.BuildStrategyCompose {
+			if err := h.stopComposeApp(ctx, app, dockerClient); err != nil {
+				return fmt.Errorf("failed to stop compose app: %w", err)
+			}
+		} else if swarm {
+			// Scaling to zero keeps the service for the next start
+			if err := dockerClient.ScaleService(ctx, app.GetContainerName(), 0); err != nil {
+				return fmt.Errorf("failed to stop service: %w", err)
+			}
+		} else if err := dockerClient.StopContainer(ctx, app.GetContainerName(), 30*time.Second); err != nil {
+			return fmt.Errorf("failed to stop container: %w", err)
+		}
+	case agent.TaskStart:
+		if swarm {
+			if err := dockerClient.ScaleService(ctx, app.GetContainerName(), deployConfig.GetReplicas()); err != nil {
+				return fmt.Errorf("failed to start service: %w", err)
+			}
+		} else if err := dockerClient.StartContainer(ctx, app.GetContainerName()); err != nil {
+			return fmt.Errorf("failed to start container: %w", err)
+		}
+	case agent.TaskRestart:
+		if swarm {
+			if err := dockerClient.RestartService(ctx, app.GetContainerName()); err != nil {
+				return fmt.Errorf("failed to restart service: %w", err)
+			}
+		} else if err := dockerClient.RestartContainer(ctx, app.GetContainerName(), 30*time.Second); err != nil {
+			return fmt.Errorf("failed to restart container: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown container action %q", action)
+	}
+	return nil
+}
+
+// runContainerAction starts, stops or restarts the container of the app in
+// the URL and writes the response
+func (h *AppHandler) runContainerAction(w http.ResponseWriter, r *http.Request, action string) {
+	ctx := r.Context()
+	appID := chi.URLParam(r, "appID")
+
+	app, err := h.appQueries.GetByID(ctx, appID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get app", "appID", appID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if app == nil {
+		http.Error(w, "app not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.controlContainer(ctx, app, action); err != nil {
+		slog.ErrorContext(ctx, "container action failed", "app", app.Name, "action", action, "error", err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, errNotAvailable) {
+			status = http.StatusServiceUnavailable
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	status := containerStatuses[action]
+	slog.InfoContext(ctx, "container "+status, "app", app.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  status,
+		"message": "Container " + status + " successfully",
+	})
+}
+
 // AppCreateRequest represents the request body for creating an app
 type AppCreateRequest struct {
-	Name           string            `json:"name"`
-	Description    string            `json:"description"`
-	RepoURL        string            `json:"repo_url"`
-	Branch         string            `json:"branch"`
-	WebhookSecret  string            `json:"webhook_secret"`
-	BuildStrategy  string            `json:"build_strategy"`
-	DockerfilePath string            `json:"dockerfile_path"`
-	ComposeFile    string            `json:"compose_file"`
-	BuildContext   string            `json:"build_context"`
-	ContainerName  string            `json:"container_name"`
-	ImageName      string            `json:"image_name"`
-	EnvVars        map[string]string `json:"env_vars"`
-	AutoDeploy     bool              `json:"auto_deploy"`
-	Enabled        bool              `json:"enabled"`
-	Subdomain      string            `json:"subdomain"`
-	PublicPort     int               `json:"public_port"`
-}
-
-// List handles GET /api/apps
+	Name            string                 `json:"name"`
+	Description     string                 `json:"description"`
+	RepoURL         string                 `json:"repo_url"`
+	Branch          string                 `json:"branch"`
+	WebhookSecret   string                 `json:"webhook_secret"`
+	BuildStrategy   string                 `json:"build_strategy"`
+	DockerfilePath  string                 `json:"dockerfile_path"`
+	ComposeFile     string                 `json:"compose_file"`
+	BuildContext    string                 `json:"build_context"`
+	ContainerName   string                 `json:"container_name"`
+	ImageName       string                 `json:"image_name"`
+	EnvVars         map[string]string      `json:"env_vars"`
+	AutoDeploy      bool                   `json:"auto_deploy"`
+	Enabled         bool                   `json:"enabled"`
+	Subdomain       string                 `json:"subdomain"`
+	PublicPort      int                    `json:"public_port"`
+	AutoPort        bool                   `json:"auto_port"` // Assign a free host port when public_port is blank
+	RoutePath       string                 `json:"route_path"`
+	Protected       bool                   `json:"protected"`
+	AccessAllow     string                 `json:"access_allow"`
+	Tunnel          string                 `json:"tunnel"`
+	BasicAuthUser   string                 `json:"basic_auth_user"`     // Blank clears basic auth
+	BasicAuthPass   string                 `json:"basic_auth_password"` // Blank keeps the current password
+	DockerHost      string                 `json:"docker_host"`         // Remote Docker host ID, blank for the local daemon
+	Agent           string                 `json:"agent_id"`            // Agent that runs the app, blank to run it here
+	Submodules      bool                   `json:"submodules"`
+	LFS             bool                   `json:"lfs"`
+	IsolatedBuild   bool                   `json:"isolated_build"`     // Build in a disposable builder container
+	RequireApproval bool                   `json:"require_approval"`   // Webhook builds wait for approval before deploying
+	DebounceSeconds int                    `json:"debounce_seconds"`   // Quick pushes only build the latest, 0 builds each
+	TagPattern      string                 `json:"tag_pattern"`        // Blank builds the branch
+	ReleaseEvents   bool                   `json:"release_events"`     // Build published GitHub releases instead of pushes
+	AllowedBranches []string               `json:"allowed_branches"`   // Branch globs webhooks may deploy, empty for any
+	ImageRefresh    string                 `json:"image_refresh"`      // Cron schedule refreshing images tracking :latest, blank for never
+	ImageTags       string                 `json:"image_tag_strategy"` // commit, branch, semver or date; blank for the git tag or build ID
+	ComposeProfiles []string               `json:"compose_profiles"`
+	TestCommand     string                 `json:"test_command"`    // Blank deploys without testing
+	ArtifactPaths   []string               `json:"artifact_paths"`  // Paths copied out of each built image
+	BuildCaches     []string               `json:"build_caches"`    // Package caches kept between builds, e.g. go or npm
+	DeployConfig    *models.DeployConfig   `json:"deploy_config"`   // Omitted keeps the current settings
+	DeploySchedule  *models.DeploySchedule `json:"deploy_schedule"` // Omitted keeps the current windows
+	Project         string                 `json:"project"`         // Dashboard group, blank for none
+	Tags            []string               `json:"tags"`
+}
+
+// applyDeploySchedule sets the app's deploy windows and freezes, if the
+// request has them
+func (req *AppCreateRequest) applyDeploySchedule(app *models.App) error {
+	if req.DeploySchedule == nil {
+		return nil
+	}
+	return app.SetDeploySchedule(req.DeploySchedule)
+}
+
+// validateTagPattern trims and checks the release tag pattern
+func (req *AppCreateRequest) validateTagPattern() error {
+	req.TagPattern = strings.TrimSpace(req.TagPattern)
+	return models.ValidateTagPattern(req.TagPattern)
+}
+
+// maxDebounceSeconds caps how long a push waits for newer ones
+const maxDebounceSeconds = 3600
+
+// validateDebounce checks the debounce window
+func (req *AppCreateRequest) validateDebounce() error {
+	if req.DebounceSeconds < 0 || req.DebounceSeconds > maxDebounceSeconds {
+		return fmt.Errorf("debounce_seconds must be between 0 and %d", maxDebounceSeconds)
+	}
+	return nil
+}
+
+// validateAccess trims and checks the Cloudflare Access allow list
+func (req *AppCreateRequest) validateAccess() error {
+	req.AccessAllow = strings.TrimSpace(req.AccessAllow)
+	_, err := cloudflare.ParseAccessRules(req.AccessAllow)
+	return err
+}
+
+// applyProtection sets the app's basic auth credentials from the request and
+// checks a protected app can be guarded by Access or basic auth
+func (req *AppCreateRequest) applyProtection(app *models.App) error {
+	user := strings.TrimSpace(req.BasicAuthUser)
+	if user == "" {
+		app.ClearBasicAuth()
+	} else if err := app.SetBasicAuth(user, req.BasicAuthPass); err != nil {
+		return err
+	}
+	return app.ValidateProtection()
+}
+
+// validateTunnel trims and checks the name of the tunnel the app routes
+// through, which is empty for the default tunnel
+func (req *AppCreateRequest) validateTunnel() error {
+	req.Tunnel = strings.TrimSpace(req.Tunnel)
+	if req.Tunnel != "" && !cloudflare.ValidTunnelName(req.Tunnel) {
+		return fmt.Errorf("tunnel name must be lowercase letters, digits and dashes")
+	}
+	return nil
+}
+
+// validateDockerHost checks the app's Docker host exists
+func (h *AppHandler) validateDockerHost(ctx context.Context, req *AppCreateRequest) error {
+	req.DockerHost = strings.TrimSpace(req.DockerHost)
+	if req.DockerHost == "" {
+		return nil
+	}
+	if h.hostQueries == nil {
+		return fmt.Errorf("remote Docker hosts are not available")
+	}
+	host, err := h.hostQueries.GetByID(ctx, req.DockerHost)
+	if err != nil {
+		return err
+	}
+	if host == nil {
+		return fmt.Errorf("unknown Docker host %q", req.DockerHost)
+	}
+	return nil
+}
+
+// validateAgent checks the app's agent exists and can run the app. Agents
+// run single containers, so compose apps and remote Docker hosts are out.
+func (h *AppHandler) validateAgent(ctx context.Context, req *AppCreateRequest, strategy models.BuildStrategy) error {
+	req.Agent = strings.TrimSpace(req.Agent)
+	if req.Agent == "" {
+		return nil
+	}
+	if h.agentQueries == nil {
+		return fmt.Errorf("agents are not available")
+	}
+	if req.DockerHost != "" {
+		return fmt.Errorf("an app runs either on a Docker host or an agent, not both")
+	}
+	if strategy == models.BuildStrategyCompose {
+		return fmt.Errorf("agents only run Dockerfile apps")
+	}
+	a, err := h.agentQueries.GetByID(ctx, req.Agent)
+	if err != nil {
+		return err
+	}
+	if a == nil {
+		return fmt.Errorf("unknown agent %q", req.Agent)
+	}
+	return nil
+}
+
+// validateDeployConfig checks the requested deploy settings, or the app's
+// current ones when the request leaves them out, and returns them. Swarm
+// services are created on a Docker host from a single image.
+func (req *AppCreateRequest) validateDeployConfig(current *models.DeployConfig, strategy models.BuildStrategy) (*models.DeployConfig, error) {
+	cfg := req.DeployConfig
+	if cfg == nil {
+		cfg = current
+	}
+	if cfg == nil {
+		return &models.DeployConfig{}, nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.IsSwarm() {
+		if req.Agent != "" {
+			return nil, fmt.Errorf("agents cannot run Swarm services")
+		}
+		if strategy == models.BuildStrategyCompose {
+			return nil, fmt.Errorf("only Dockerfile apps can deploy to Swarm")
+		}
+		if len(cfg.Devices) > 0 || cfg.ShmSize != "" || cfg.GPUs != "" || cfg.Runtime != "" {
+			return nil, fmt.Errorf("devices, shm size, GPUs and runtimes are not available to Swarm services")
+		}
+	}
+	if cfg.HasAdvanced() && strategy == models.BuildStrategyCompose {
+		return nil, fmt.Errorf("set devices, capabilities, sysctls, shm size and GPUs of Compose apps in the compose file")
+	}
+	return cfg, nil
+}
+
+// parseAppFilter reads the q, tag and project filters of an app list
+func parseAppFilter(r *http.Request) queries.AppFilter {
+	params := r.URL.Query()
+	return queries.AppFilter{
+		Query:   strings.TrimSpace(params.Get("q")),
+		Tag:     strings.TrimSpace(params.Get("tag")),
+		Project: strings.TrimSpace(params.Get("project")),
+	}
+}
+
+// List handles GET /api/apps, optionally filtered by ?q=, ?tag= and
+// ?project=
 func (h *AppHandler) List(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	apps, err := h.appQueries.List(ctx)
+	apps, err := h.appQueries.Search(ctx, parseAppFilter(r))
 	if err != nil {
-		slog.Error("failed to list apps", "error", err)
+		slog.ErrorContext(ctx, "failed to list apps", "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -95,7 +488,7 @@ func (h *AppHandler) Get(w http.ResponseWriter, r *http.Request) {
 
 	app, err := h.appQueries.GetByID(ctx, appID)
 	if err != nil {
-		slog.Error("failed to get app", "appID", appID, "error", err)
+		slog.ErrorContext(ctx, "failed to get app", "appID", appID, "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -128,6 +521,45 @@ func (h *AppHandler) Create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "repo_url is required", http.StatusBadRequest)
 		return
 	}
+	routePath, err := models.NormalizeRoutePath(req.RoutePath)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.validateAccess(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.validateTagPattern(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.validateDebounce(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.validateTunnel(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.validateDockerHost(ctx, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.validateAgent(ctx, &req, models.BuildStrategy(req.BuildStrategy)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	deployConfig, err := req.validateDeployConfig(nil, models.BuildStrategy(req.BuildStrategy))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	imageTagStrategy, err := models.ParseImageTagStrategy(req.ImageTags)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Set defaults
 	if req.Branch == "" {
@@ -145,47 +577,107 @@ func (h *AppHandler) Create(w http.ResponseWriter, r *http.Request) {
 	if req.BuildContext == "" {
 		req.BuildContext = "."
 	}
+	testCommand := strings.TrimSpace(req.TestCommand)
 
 	// Create app
 	app := &models.App{
-		ID:             uuid.New().String(),
-		Name:           req.Name,
-		Description:    sql.NullString{String: req.Description, Valid: req.Description != ""},
-		RepoURL:        req.RepoURL,
-		Branch:         req.Branch,
-		WebhookSecret:  sql.NullString{String: req.WebhookSecret, Valid: req.WebhookSecret != ""},
-		BuildStrategy:  models.BuildStrategy(req.BuildStrategy),
-		DockerfilePath: req.DockerfilePath,
-		ComposeFile:    req.ComposeFile,
-		BuildContext:   req.BuildContext,
-		ContainerName:  sql.NullString{String: req.ContainerName, Valid: req.ContainerName != ""},
-		ImageName:      sql.NullString{String: req.ImageName, Valid: req.ImageName != ""},
-		EnvVars:        req.EnvVars,
-		AutoDeploy:     req.AutoDeploy,
-		Enabled:        req.Enabled,
-		Subdomain:      sql.NullString{String: req.Subdomain, Valid: req.Subdomain != ""},
-		PublicPort:     sql.NullInt64{Int64: int64(req.PublicPort), Valid: req.PublicPort > 0},
-		CreatedAt:      time.Now(),
-		UpdatedAt:      time.Now(),
+		ID:              uuid.New().String(),
+		Name:            req.Name,
+		Description:     sql.NullString{String: req.Description, Valid: req.Description != ""},
+		RepoURL:         req.RepoURL,
+		Branch:          req.Branch,
+		WebhookSecret:   sql.NullString{String: req.WebhookSecret, Valid: req.WebhookSecret != ""},
+		BuildStrategy:   models.BuildStrategy(req.BuildStrategy),
+		DockerfilePath:  req.DockerfilePath,
+		ComposeFile:     req.ComposeFile,
+		BuildContext:    req.BuildContext,
+		ContainerName:   sql.NullString{String: req.ContainerName, Valid: req.ContainerName != ""},
+		ImageName:       sql.NullString{String: req.ImageName, Valid: req.ImageName != ""},
+		EnvVars:         req.EnvVars,
+		AutoDeploy:      req.AutoDeploy,
+		Enabled:         req.Enabled,
+		Subdomain:       sql.NullString{String: req.Subdomain, Valid: req.Subdomain != ""},
+		PublicPort:      sql.NullInt64{Int64: int64(req.PublicPort), Valid: req.PublicPort > 0},
+		RoutePath:       sql.NullString{String: routePath, Valid: routePath != ""},
+		Protected:       req.Protected,
+		AccessAllow:     sql.NullString{String: req.AccessAllow, Valid: req.AccessAllow != ""},
+		Tunnel:          sql.NullString{String: req.Tunnel, Valid: req.Tunnel != ""},
+		DockerHost:      sql.NullString{String: req.DockerHost, Valid: req.DockerHost != ""},
+		Agent:           sql.NullString{String: req.Agent, Valid: req.Agent != ""},
+		Submodules:      req.Submodules,
+		LFS:             req.LFS,
+		IsolatedBuild:   req.IsolatedBuild,
+		RequireApproval: req.RequireApproval,
+		DebounceSeconds: req.DebounceSeconds,
+		TagPattern:      sql.NullString{String: req.TagPattern, Valid: req.TagPattern != ""},
+		ReleaseEvents:   req.ReleaseEvents,
+		TestCommand:     sql.NullString{String: testCommand, Valid: testCommand != ""},
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+	app.ImageTagStrategy = imageTagStrategy
+	if err := req.applyProtection(app); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := app.SetDeployConfig(deployConfig); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := app.SetComposeProfiles(req.ComposeProfiles); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := app.SetArtifactPaths(req.ArtifactPaths); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := app.SetBuildCaches(req.BuildCaches); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := app.SetAllowedBranches(req.AllowedBranches); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := app.SetImageRefresh(req.ImageRefresh); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := app.SetTags(req.Tags); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	project := strings.TrimSpace(req.Project)
+	app.Project = sql.NullString{String: project, Valid: project != ""}
+	if err := req.applyDeploySchedule(app); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !h.autoPort(ctx, w, app, &req) {
+		return
 	}
 
 	// Save env vars
 	if err := app.SaveEnvVars(); err != nil {
-		slog.Error("failed to save env vars", "error", err)
+		slog.ErrorContext(ctx, "failed to save env vars", "error", err)
 		http.Error(w, "failed to save env vars", http.StatusInternalServerError)
 		return
 	}
 
 	if err := h.appQueries.Create(ctx, app); err != nil {
-		slog.Error("failed to create app", "error", err)
+		if portConflict(w, err) {
+			return
+		}
+		slog.ErrorContext(ctx, "failed to create app", "error", err)
 		http.Error(w, "failed to create app: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Update tunnel routes if app has subdomain/port configured
-	if h.tunnelManager != nil && h.tunnelManager.IsConfigured() && app.GetSubdomain() != "" && app.GetPublicPort() != 0 {
-		if err := h.tunnelManager.Reload(ctx); err != nil {
-			slog.Warn("failed to reload tunnel routes", "app", app.Name, "error", err)
+	// Update proxy routes if app has subdomain/port configured
+	if h.proxyRouter != nil && h.proxyRouter.IsConfigured() && app.GetSubdomain() != "" && app.GetPublicPort() != 0 {
+		if err := h.proxyRouter.Reload(ctx); err != nil {
+			slog.WarnContext(ctx, "failed to reload proxy routes", "app", app.Name, "error", err)
 		}
 	}
 
@@ -195,7 +687,8 @@ func (h *AppHandler) Create(w http.ResponseWriter, r *http.Request) {
 		webhookInstalled = h.installWebhook(ctx, app)
 	}
 
-	slog.Info("app created", "id", app.ID, "name", app.Name, "webhookInstalled", webhookInstalled)
+	slog.InfoContext(ctx, "app created", "id", app.ID, "name", app.Name, "webhookInstalled", webhookInstalled)
+	h.events.Publish(events.Event{Kind: events.KindApp, AppID: app.ID, Status: events.AppCreated})
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -227,7 +720,7 @@ func (h *AppHandler) installWebhook(ctx context.Context, app *models.App) bool {
 	}
 
 	// Build webhook URL
-	webhookURL := h.cfg.Server.BaseURL + "/webhook/github/" + app.ID
+	webhookURL := h.cfg.BaseURL() + "/webhook/github/" + app.ID
 
 	// Ensure webhook exists
 	webhook, created, err := h.githubClient.EnsureWebhook(ctx, owner, repo, webhookURL, secret)
@@ -262,7 +755,7 @@ func (h *AppHandler) Update(w http.ResponseWriter, r *http.Request) {
 	// Get existing app
 	app, err := h.appQueries.GetByID(ctx, appID)
 	if err != nil {
-		slog.Error("failed to get app", "appID", appID, "error", err)
+		slog.ErrorContext(ctx, "failed to get app", "appID", appID, "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -277,6 +770,51 @@ func (h *AppHandler) Update(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid request body", http.StatusBadRequest)
 		return
 	}
+	routePath, err := models.NormalizeRoutePath(req.RoutePath)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.validateAccess(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.validateTagPattern(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.validateDebounce(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.validateTunnel(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.validateDockerHost(ctx, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	strategy := app.BuildStrategy
+	if req.BuildStrategy != "" {
+		strategy = models.BuildStrategy(req.BuildStrategy)
+	}
+	if err := h.validateAgent(ctx, &req, strategy); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	before := app.ConfigSnapshot()
+	currentDeploy, _ := app.GetDeployConfig() // Unreadable settings are replaced
+	deployConfig, err := req.validateDeployConfig(currentDeploy, strategy)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	imageTagStrategy, err := models.ParseImageTagStrategy(req.ImageTags)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Update fields
 	if req.Name != "" {
@@ -309,42 +847,111 @@ func (h *AppHandler) Update(w http.ResponseWriter, r *http.Request) {
 	app.Enabled = req.Enabled
 	app.Subdomain = sql.NullString{String: req.Subdomain, Valid: req.Subdomain != ""}
 	app.PublicPort = sql.NullInt64{Int64: int64(req.PublicPort), Valid: req.PublicPort > 0}
+	app.RoutePath = sql.NullString{String: routePath, Valid: routePath != ""}
+	app.Protected = req.Protected
+	app.AccessAllow = sql.NullString{String: req.AccessAllow, Valid: req.AccessAllow != ""}
+	app.Tunnel = sql.NullString{String: req.Tunnel, Valid: req.Tunnel != ""}
+	app.DockerHost = sql.NullString{String: req.DockerHost, Valid: req.DockerHost != ""}
+	app.Agent = sql.NullString{String: req.Agent, Valid: req.Agent != ""}
+	app.Submodules = req.Submodules
+	app.LFS = req.LFS
+	app.IsolatedBuild = req.IsolatedBuild
+	app.RequireApproval = req.RequireApproval
+	app.DebounceSeconds = req.DebounceSeconds
+	app.TagPattern = sql.NullString{String: req.TagPattern, Valid: req.TagPattern != ""}
+	app.ReleaseEvents = req.ReleaseEvents
+	app.ImageTagStrategy = imageTagStrategy
+	testCommand := strings.TrimSpace(req.TestCommand)
+	app.TestCommand = sql.NullString{String: testCommand, Valid: testCommand != ""}
+	if err := req.applyProtection(app); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := app.SetDeployConfig(deployConfig); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := app.SetComposeProfiles(req.ComposeProfiles); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := app.SetArtifactPaths(req.ArtifactPaths); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := app.SetBuildCaches(req.BuildCaches); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := app.SetAllowedBranches(req.AllowedBranches); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := app.SetImageRefresh(req.ImageRefresh); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := app.SetTags(req.Tags); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	project := strings.TrimSpace(req.Project)
+	app.Project = sql.NullString{String: project, Valid: project != ""}
+	if err := req.applyDeploySchedule(app); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !h.autoPort(ctx, w, app, &req) {
+		return
+	}
 
 	// Save env vars
 	if err := app.SaveEnvVars(); err != nil {
-		slog.Error("failed to save env vars", "error", err)
+		slog.ErrorContext(ctx, "failed to save env vars", "error", err)
 		http.Error(w, "failed to save env vars", http.StatusInternalServerError)
 		return
 	}
 
 	if err := h.appQueries.Update(ctx, app); err != nil {
-		slog.Error("failed to update app", "error", err)
+		if portConflict(w, err) {
+			return
+		}
+		slog.ErrorContext(ctx, "failed to update app", "error", err)
 		http.Error(w, "failed to update app: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	h.recordConfigRevision(ctx, app, before, requester(r))
 
-	// Update tunnel routes if configured (reload all routes when app changes)
-	if h.tunnelManager != nil && h.tunnelManager.IsConfigured() {
-		if err := h.tunnelManager.Reload(ctx); err != nil {
-			slog.Warn("failed to reload tunnel routes", "app", app.Name, "error", err)
+	// Update proxy routes if configured (reload all routes when app changes)
+	if h.proxyRouter != nil && h.proxyRouter.IsConfigured() {
+		if err := h.proxyRouter.Reload(ctx); err != nil {
+			slog.WarnContext(ctx, "failed to reload proxy routes", "app", app.Name, "error", err)
 		}
 	}
 
-	slog.Info("app updated", "id", app.ID, "name", app.Name)
+	slog.InfoContext(ctx, "app updated", "id", app.ID, "name", app.Name)
+	h.events.Publish(events.Event{Kind: events.KindApp, AppID: app.ID, Status: events.AppUpdated})
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(app)
 }
 
-// Delete handles DELETE /api/apps/{appID}
+// Delete handles DELETE /api/apps/{appID}. Query flags also remove what
+// the app left behind, see parseCleanupOptions.
 func (h *AppHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	appID := chi.URLParam(r, "appID")
 
+	cleanup, err := parseCleanupOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Check if app exists
 	app, err := h.appQueries.GetByID(ctx, appID)
 	if err != nil {
-		slog.Error("failed to get app", "appID", appID, "error", err)
+		slog.ErrorContext(ctx, "failed to get app", "appID", appID, "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -354,22 +961,61 @@ func (h *AppHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Add-on records are deleted with the app, so stop their containers first
+	if h.addonManager != nil {
+		if err := h.addonManager.RemoveApp(ctx, app); err != nil {
+			slog.WarnContext(ctx, "failed to remove add-ons", "app", app.Name, "error", err)
+		}
+	}
+
+	// Containers are removed while the checkout still has the compose file
+	var results []CleanupResult
+	if cleanup.Containers {
+		results = h.removeAppContainers(ctx, app, cleanup)
+	}
+
 	if err := h.appQueries.Delete(ctx, appID); err != nil {
-		slog.Error("failed to delete app", "appID", appID, "error", err)
+		slog.ErrorContext(ctx, "failed to delete app", "appID", appID, "error", err)
 		http.Error(w, "failed to delete app", http.StatusInternalServerError)
 		return
 	}
 
-	// Reload tunnel routes after app deletion
-	if h.tunnelManager != nil && h.tunnelManager.IsConfigured() {
-		if err := h.tunnelManager.Reload(ctx); err != nil {
-			slog.Warn("failed to reload tunnel routes after delete", "app", app.Name, "error", err)
+	// Its checkout is of no use to any other app
+	if h.orchestrator != nil && app.HasRepo() {
+		if err := h.orchestrator.ClearCheckout(app); err != nil {
+			slog.WarnContext(ctx, "failed to remove checkout", "app", app.Name, "error", err)
+		}
+	}
+	// Nor are its build caches
+	if h.orchestrator != nil && len(app.GetBuildCaches()) > 0 {
+		if _, err := h.orchestrator.ClearBuildCache(ctx, app); err != nil {
+			slog.WarnContext(ctx, "failed to remove build caches", "app", app.Name, "error", err)
 		}
 	}
 
-	slog.Info("app deleted", "id", appID, "name", app.Name)
+	// Reload proxy routes after app deletion
+	if h.proxyRouter != nil && h.proxyRouter.IsConfigured() {
+		if err := h.proxyRouter.Reload(ctx); err != nil {
+			slog.WarnContext(ctx, "failed to reload proxy routes after delete", "app", app.Name, "error", err)
+		}
+	}
 
-	w.WriteHeader(http.StatusNoContent)
+	if cleanup.DNS {
+		results = append(results, h.deleteAppDNS(ctx, app))
+	}
+	if cleanup.Webhook {
+		results = append(results, h.deleteAppWebhook(ctx, app))
+	}
+
+	slog.InfoContext(ctx, "app deleted", "id", appID, "name", app.Name)
+	h.events.Publish(events.Event{Kind: events.KindApp, AppID: app.ID, Status: events.AppDeleted})
+
+	if results == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]CleanupResult{"cleanup": results})
 }
 
 // Status handles GET /api/apps/{appID}/status - returns container status
@@ -379,7 +1025,7 @@ func (h *AppHandler) Status(w http.ResponseWriter, r *http.Request) {
 
 	app, err := h.appQueries.GetByID(ctx, appID)
 	if err != nil {
-		slog.Error("failed to get app", "appID", appID, "error", err)
+		slog.ErrorContext(ctx, "failed to get app", "appID", appID, "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -393,10 +1039,7 @@ func (h *AppHandler) Status(w http.ResponseWriter, r *http.Request) {
 	latestBuild, _ := h.buildQueries.GetLatestByAppID(ctx, appID)
 
 	// Get container status from Docker
-	var containerStatus *docker.ContainerStatus
-	if h.dockerClient != nil {
-		containerStatus, _ = h.dockerClient.GetContainerStatus(ctx, app.GetContainerName())
-	}
+	containerStatus := h.containerStatus(ctx, app)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -413,7 +1056,7 @@ func (h *AppHandler) TriggerDeploy(w http.ResponseWriter, r *http.Request) {
 
 	app, err := h.appQueries.GetByID(ctx, appID)
 	if err != nil {
-		slog.Error("failed to get app", "appID", appID, "error", err)
+		slog.ErrorContext(ctx, "failed to get app", "appID", appID, "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -431,12 +1074,12 @@ func (h *AppHandler) TriggerDeploy(w http.ResponseWriter, r *http.Request) {
 	// Trigger build via orchestrator
 	build, err := h.orchestrator.TriggerManualBuild(ctx, appID)
 	if err != nil {
-		slog.Error("failed to trigger build", "appID", appID, "error", err)
+		slog.ErrorContext(ctx, "failed to trigger build", "appID", appID, "error", err)
 		http.Error(w, "failed to trigger build: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	slog.Info("build triggered", "appID", appID, "buildID", build.ID)
+	slog.InfoContext(ctx, "build triggered", "appID", appID, "buildID", build.ID)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
@@ -446,14 +1089,16 @@ func (h *AppHandler) TriggerDeploy(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// Stop handles POST /api/apps/{appID}/stop
-func (h *AppHandler) Stop(w http.ResponseWriter, r *http.Request) {
+// PreviewDeploy handles POST /api/apps/{appID}/deploy/preview - updates a
+// compose app's checkout and shows how deploying it would change the
+// running stack, without deploying
+func (h *AppHandler) PreviewDeploy(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	appID := chi.URLParam(r, "appID")
 
 	app, err := h.appQueries.GetByID(ctx, appID)
 	if err != nil {
-		slog.Error("failed to get app", "appID", appID, "error", err)
+		slog.ErrorContext(ctx, "failed to get app", "appID", appID, "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -463,67 +1108,38 @@ func (h *AppHandler) Stop(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.dockerClient == nil {
-		http.Error(w, "Docker client not available", http.StatusServiceUnavailable)
+	if h.orchestrator == nil {
+		http.Error(w, "build orchestrator not available", http.StatusServiceUnavailable)
 		return
 	}
 
-	// For compose apps, use docker compose down
-	if app.BuildStrategy == models.BuildStrategyCompose {
-		if err := h.stopComposeApp(ctx, app); err != nil {
-			slog.Error("failed to stop compose app", "app", app.Name, "error", err)
-			http.Error(w, "failed to stop compose app: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-	} else {
-		if err := h.dockerClient.StopContainer(ctx, app.GetContainerName(), 30*time.Second); err != nil {
-			slog.Error("failed to stop container", "app", app.Name, "error", err)
-			http.Error(w, "failed to stop container: "+err.Error(), http.StatusInternalServerError)
-			return
+	preview, err := h.orchestrator.PreviewDeploy(ctx, app)
+	if err != nil {
+		switch {
+		case errors.Is(err, build.ErrBuildRunning):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, build.ErrNotCompose):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			slog.ErrorContext(ctx, "failed to preview deploy", "app", app.Name, "error", err)
+			http.Error(w, "failed to preview deploy: "+err.Error(), http.StatusInternalServerError)
 		}
+		return
 	}
 
-	slog.Info("container stopped", "app", app.Name)
-
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"status":  "stopped",
-		"message": "Container stopped successfully",
-	})
-}
-
-// stopComposeApp stops a compose-based app using docker compose down
-func (h *AppHandler) stopComposeApp(ctx context.Context, app *models.App) error {
-	// Get the repo path
-	repoPath := git.RepoPath(h.cfg.Git.WorkDir, app.RepoURL)
-
-	// Find the compose file
-	composeFile := strategies.FindComposeFile(repoPath, app.ComposeFile)
-	if composeFile == "" {
-		return fmt.Errorf("compose file not found in %s", repoPath)
-	}
-
-	composePath := filepath.Join(repoPath, composeFile)
-
-	cmd := exec.CommandContext(ctx, "docker", "compose", "-f", composePath, "down")
-	cmd.Dir = repoPath
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("docker compose down failed: %w, output: %s", err, string(output))
-	}
-
-	return nil
+	json.NewEncoder(w).Encode(preview)
 }
 
-// Start handles POST /api/apps/{appID}/start
-func (h *AppHandler) Start(w http.ResponseWriter, r *http.Request) {
+// Validate handles POST /api/apps/{appID}/validate - updates the app's
+// checkout and checks its compose file or Dockerfile
+func (h *AppHandler) Validate(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	appID := chi.URLParam(r, "appID")
 
 	app, err := h.appQueries.GetByID(ctx, appID)
 	if err != nil {
-		slog.Error("failed to get app", "appID", appID, "error", err)
+		slog.ErrorContext(ctx, "failed to get app", "appID", appID, "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -533,34 +1149,35 @@ func (h *AppHandler) Start(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.dockerClient == nil {
-		http.Error(w, "Docker client not available", http.StatusServiceUnavailable)
+	if h.orchestrator == nil {
+		http.Error(w, "build orchestrator not available", http.StatusServiceUnavailable)
 		return
 	}
 
-	if err := h.dockerClient.StartContainer(ctx, app.GetContainerName()); err != nil {
-		slog.Error("failed to start container", "app", app.Name, "error", err)
-		http.Error(w, "failed to start container: "+err.Error(), http.StatusInternalServerError)
+	result, err := h.orchestrator.ValidateApp(ctx, app)
+	if err != nil {
+		if errors.Is(err, build.ErrBuildRunning) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		slog.ErrorContext(ctx, "failed to validate app", "app", app.Name, "error", err)
+		http.Error(w, "failed to validate app: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	slog.Info("container started", "app", app.Name)
-
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"status":  "started",
-		"message": "Container started successfully",
-	})
+	json.NewEncoder(w).Encode(result)
 }
 
-// Restart handles POST /api/apps/{appID}/restart
-func (h *AppHandler) Restart(w http.ResponseWriter, r *http.Request) {
+// ClearCheckout handles DELETE /api/apps/{appID}/checkout, removing the
+// app's cloned repository so the next build clones it afresh
+func (h *AppHandler) ClearCheckout(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	appID := chi.URLParam(r, "appID")
 
 	app, err := h.appQueries.GetByID(ctx, appID)
 	if err != nil {
-		slog.Error("failed to get app", "appID", appID, "error", err)
+		slog.ErrorContext(ctx, "failed to get app", "appID", appID, "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -570,26 +1187,78 @@ func (h *AppHandler) Restart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.dockerClient == nil {
-		http.Error(w, "Docker client not available", http.StatusServiceUnavailable)
+	if !app.HasRepo() {
+		http.Error(w, "app has no repository", http.StatusBadRequest)
+		return
+	}
+
+	if h.orchestrator == nil {
+		http.Error(w, "build orchestrator not available", http.StatusServiceUnavailable)
 		return
 	}
 
-	if err := h.dockerClient.RestartContainer(ctx, app.GetContainerName(), 30*time.Second); err != nil {
-		slog.Error("failed to restart container", "app", app.Name, "error", err)
-		http.Error(w, "failed to restart container: "+err.Error(), http.StatusInternalServerError)
+	if err := h.orchestrator.ClearCheckout(app); err != nil {
+		if errors.Is(err, build.ErrBuildRunning) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		slog.ErrorContext(ctx, "failed to clear checkout", "app", app.Name, "error", err)
+		http.Error(w, "failed to clear checkout", http.StatusInternalServerError)
 		return
 	}
 
-	slog.Info("container restarted", "app", app.Name)
+	slog.InfoContext(ctx, "checkout cleared", "app", app.Name)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
-		"status":  "restarted",
-		"message": "Container restarted successfully",
+		"status":  "cleared",
+		"message": "Checkout cleared, the next build clones the repository again",
 	})
 }
 
+// Stop handles POST /api/apps/{appID}/stop
+func (h *AppHandler) Stop(w http.ResponseWriter, r *http.Request) {
+	h.runContainerAction(w, r, agent.TaskStop)
+}
+
+// stopComposeApp stops a compose-based app using docker compose down
+func (h *AppHandler) stopComposeApp(ctx context.Context, app *models.App, dockerClient *docker.Client) error {
+	// Get the app's checkout, or the directory its compose file is written
+	// to when it has no repository
+	repoPath := build.CheckoutPath(h.cfg.Git.WorkDir, app)
+
+	// Find the compose file
+	composeFile := strategies.FindComposeFile(repoPath, app.ComposeFile)
+	if composeFile == "" {
+		return fmt.Errorf("compose file not found in %s", repoPath)
+	}
+
+	composePath := filepath.Join(repoPath, composeFile)
+
+	// Services in the app's profiles are only taken down when enabled
+	args := append([]string{"compose", "-f", composePath}, strategies.ProfileArgs(app.GetComposeProfiles())...)
+	cmd := exec.CommandContext(ctx, "docker", append(args, "down")...)
+	cmd.Dir = repoPath
+	cmd.Env = append(os.Environ(), dockerClient.Env()...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("docker compose down failed: %w, output: %s", err, string(output))
+	}
+
+	return nil
+}
+
+// Start handles POST /api/apps/{appID}/start
+func (h *AppHandler) Start(w http.ResponseWriter, r *http.Request) {
+	h.runContainerAction(w, r, agent.TaskStart)
+}
+
+// Restart handles POST /api/apps/{appID}/restart
+func (h *AppHandler) Restart(w http.ResponseWriter, r *http.Request) {
+	h.runContainerAction(w, r, agent.TaskRestart)
+}
+
 // ConfigureWebhook handles POST /api/apps/{appID}/webhook - sets up GitHub webhook
 func (h *AppHandler) ConfigureWebhook(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -597,7 +1266,7 @@ func (h *AppHandler) ConfigureWebhook(w http.ResponseWriter, r *http.Request) {
 
 	app, err := h.appQueries.GetByID(ctx, appID)
 	if err != nil {
-		slog.Error("failed to get app", "appID", appID, "error", err)
+		slog.ErrorContext(ctx, "failed to get app", "appID", appID, "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -615,7 +1284,7 @@ func (h *AppHandler) ConfigureWebhook(w http.ResponseWriter, r *http.Request) {
 	// Parse repo URL to get owner/repo
 	owner, repo, err := github.ParseRepoURL(app.RepoURL)
 	if err != nil {
-		slog.Error("failed to parse repo URL", "url", app.RepoURL, "error", err)
+		slog.ErrorContext(ctx, "failed to parse repo URL", "url", app.RepoURL, "error", err)
 		http.Error(w, "invalid repository URL: "+err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -625,7 +1294,7 @@ func (h *AppHandler) ConfigureWebhook(w http.ResponseWriter, r *http.Request) {
 	if webhookSecret == "" {
 		secretBytes := make([]byte, 32)
 		if _, err := rand.Read(secretBytes); err != nil {
-			slog.Error("failed to generate webhook secret", "error", err)
+			slog.ErrorContext(ctx, "failed to generate webhook secret", "error", err)
 			http.Error(w, "failed to generate secret", http.StatusInternalServerError)
 			return
 		}
@@ -634,27 +1303,27 @@ func (h *AppHandler) ConfigureWebhook(w http.ResponseWriter, r *http.Request) {
 		// Save the secret to the app
 		app.SetWebhookSecret(webhookSecret)
 		if err := h.appQueries.Update(ctx, app); err != nil {
-			slog.Error("failed to save webhook secret", "error", err)
+			slog.ErrorContext(ctx, "failed to save webhook secret", "error", err)
 			http.Error(w, "failed to save webhook secret", http.StatusInternalServerError)
 			return
 		}
 	}
 
 	// Build webhook URL
-	webhookURL := fmt.Sprintf("%s/webhook/github/%s", h.cfg.Server.BaseURL, app.ID)
+	webhookURL := fmt.Sprintf("%s/webhook/github/%s", h.cfg.BaseURL(), app.ID)
 
 	// Create or ensure webhook exists
 	webhook, created, err := h.githubClient.EnsureWebhook(ctx, owner, repo, webhookURL, webhookSecret)
 	if err != nil {
-		slog.Error("failed to configure webhook", "error", err)
+		slog.ErrorContext(ctx, "failed to configure webhook", "error", err)
 		http.Error(w, "failed to configure webhook: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	if created {
-		slog.Info("webhook created", "app", app.Name, "repo", fmt.Sprintf("%s/%s", owner, repo), "webhook_id", webhook.ID)
+		slog.InfoContext(ctx, "webhook created", "app", app.Name, "repo", fmt.Sprintf("%s/%s", owner, repo), "webhook_id", webhook.ID)
 	} else {
-		slog.Info("webhook already exists", "app", app.Name, "repo", fmt.Sprintf("%s/%s", owner, repo), "webhook_id", webhook.ID)
+		slog.InfoContext(ctx, "webhook already exists", "app", app.Name, "repo", fmt.Sprintf("%s/%s", owner, repo), "webhook_id", webhook.ID)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -673,7 +1342,7 @@ func (h *AppHandler) AllStatuses(w http.ResponseWriter, r *http.Request) {
 
 	apps, err := h.appQueries.List(ctx)
 	if err != nil {
-		slog.Error("failed to list apps", "error", err)
+		slog.ErrorContext(ctx, "failed to list apps", "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -684,15 +1353,13 @@ func (h *AppHandler) AllStatuses(w http.ResponseWriter, r *http.Request) {
 		ContainerStatus *docker.ContainerStatus `json:"container_status"`
 	}
 
+	containers := h.statuses.lookup(ctx, apps, h.containerStatus)
 	statuses := make([]AppStatus, 0, len(apps))
 	for _, app := range apps {
 		status := AppStatus{
-			AppID:   app.ID,
-			AppName: app.Name,
-		}
-
-		if h.dockerClient != nil {
-			status.ContainerStatus, _ = h.dockerClient.GetContainerStatus(ctx, app.GetContainerName())
+			AppID:           app.ID,
+			AppName:         app.Name,
+			ContainerStatus: containers[app.ID],
 		}
 
 		statuses = append(statuses, status)
@@ -702,86 +1369,87 @@ func (h *AppHandler) AllStatuses(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(statuses)
 }
 
-// ContainerStats handles GET /api/containers/stats - returns stats for all running containers
+// containerStat is a running container's resource usage as the dashboard
+// shows it
+type containerStat struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	CPUPercent    float64 `json:"cpu_percent"`
+	MemoryUsage   uint64  `json:"memory_usage"`
+	MemoryPercent float64 `json:"memory_percent"`
+	MemoryDisplay string  `json:"memory_display"`
+}
+
+// containerStats converts the collector's latest samples for the dashboard
+func containerStats(samples []docker.ContainerSample) []containerStat {
+	stats := make([]containerStat, 0, len(samples))
+	for _, s := range samples {
+		stats = append(stats, containerStat{
+			ID:            s.ID,
+			Name:          s.Name,
+			CPUPercent:    s.Stats.CPUPercent,
+			MemoryUsage:   s.Stats.MemoryUsage,
+			MemoryPercent: s.Stats.MemoryPercent,
+			MemoryDisplay: formatBytes(s.Stats.MemoryUsage),
+		})
+	}
+	return stats
+}
+
+// ContainerStats handles GET /api/containers/stats - returns the latest stats
+// of all running containers
 func (h *AppHandler) ContainerStats(w http.ResponseWriter, r *http.Request) {
-	if h.dockerClient == nil {
+	if h.stats == nil {
 		http.Error(w, "Docker client not available", http.StatusServiceUnavailable)
 		return
 	}
 
-	// Use a short timeout for the stats collection
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(containerStats(h.stats.Latest()))
+}
 
-	containers, err := h.dockerClient.ListContainers(ctx, false, nil)
-	if err != nil {
-		slog.Error("failed to list containers", "error", err)
-		http.Error(w, "internal error", http.StatusInternalServerError)
+// StreamContainerStats handles GET /api/containers/stats/stream - sends the
+// stats of all running containers each time they're sampled
+func (h *AppHandler) StreamContainerStats(w http.ResponseWriter, r *http.Request) {
+	if h.stats == nil {
+		http.Error(w, "Docker client not available", http.StatusServiceUnavailable)
 		return
 	}
 
-	type ContainerStat struct {
-		ID            string  `json:"id"`
-		Name          string  `json:"name"`
-		CPUPercent    float64 `json:"cpu_percent"`
-		MemoryUsage   uint64  `json:"memory_usage"`
-		MemoryPercent float64 `json:"memory_percent"`
-		MemoryDisplay string  `json:"memory_display"`
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "SSE not supported", http.StatusInternalServerError)
+		return
 	}
 
-	// Fetch stats concurrently
-	type result struct {
-		stat ContainerStat
-		ok   bool
+	// The stream stays open for as long as the page does
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		slog.DebugContext(r.Context(), "failed to lift write deadline for stats stream", "error", err)
 	}
-	results := make(chan result, len(containers))
 
-	for _, c := range containers {
-		go func(c types.Container) {
-			name := ""
-			if len(c.Names) > 0 {
-				name = c.Names[0]
-				if len(name) > 0 && name[0] == '/' {
-					name = name[1:]
-				}
-			}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
 
-			containerStats, err := h.dockerClient.GetContainerStats(ctx, c.ID)
-			if err != nil {
-				slog.Debug("failed to get container stats", "container", name, "error", err)
-				results <- result{ok: false}
-				return
-			}
+	updates, unsubscribe := h.stats.Subscribe()
+	defer unsubscribe()
 
-			results <- result{
-				stat: ContainerStat{
-					ID:            c.ID[:12],
-					Name:          name,
-					CPUPercent:    containerStats.CPUPercent,
-					MemoryUsage:   containerStats.MemoryUsage,
-					MemoryPercent: containerStats.MemoryPercent,
-					MemoryDisplay: formatBytes(containerStats.MemoryUsage),
-				},
-				ok: true,
-			}
-		}(c)
+	send := func(samples []docker.ContainerSample) {
+		data, _ := json.Marshal(containerStats(samples))
+		fmt.Fprintf(w, "event: stats\ndata: %s\n\n", data)
+		flusher.Flush()
 	}
+	send(h.stats.Latest())
 
-	// Collect results
-	stats := make([]ContainerStat, 0, len(containers))
-	for i := 0; i < len(containers); i++ {
+	for {
 		select {
-		case r := <-results:
-			if r.ok {
-				stats = append(stats, r.stat)
-			}
-		case <-ctx.Done():
-			break
+		case <-r.Context().Done():
+			return
+		case samples := <-updates:
+			send(samples)
 		}
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(stats)
 }
 
 // formatBytes formats bytes to human readable string
@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"schooner/internal/models"
 )
 
 func TestAppCreateRequest_Validation(t *testing.T) {
@@ -106,7 +108,7 @@ func TestAppCreateRequest_JSONMarshal(t *testing.T) {
 }
 
 func TestNewAppHandler(t *testing.T) {
-	handler := NewAppHandler(nil, nil, nil, nil, nil, nil, nil)
+	handler := NewAppHandler(nil, nil, nil, nil, nil, nil, nil, nil)
 	if handler == nil {
 		t.Error("Expected non-nil handler")
 	}
@@ -119,13 +121,13 @@ func TestNewAppHandler(t *testing.T) {
 	if handler.dockerClient != nil {
 		t.Error("Expected nil dockerClient")
 	}
-	if handler.tunnelManager != nil {
-		t.Error("Expected nil tunnelManager")
+	if handler.proxyRouter != nil {
+		t.Error("Expected nil proxyRouter")
 	}
 }
 
 func TestAppHandler_List_NoQueries(t *testing.T) {
-	handler := NewAppHandler(nil, nil, nil, nil, nil, nil, nil)
+	handler := NewAppHandler(nil, nil, nil, nil, nil, nil, nil, nil)
 
 	req := httptest.NewRequest("GET", "/api/apps", nil)
 	w := httptest.NewRecorder()
@@ -276,3 +278,30 @@ func TestHTTPStatusCodes(t *testing.T) {
 		t.Errorf("StatusInternalServerError = %v, want 500", http.StatusInternalServerError)
 	}
 }
+
+func TestAppCreateRequest_ValidateDeployConfig(t *testing.T) {
+	advanced := &models.DeployConfig{CapAdd: []string{"NET_ADMIN"}, Devices: []string{"/dev/dri"}}
+	tests := []struct {
+		name     string
+		cfg      *models.DeployConfig
+		strategy models.BuildStrategy
+		wantErr  bool
+	}{
+		{name: "container", cfg: advanced, strategy: models.BuildStrategyDockerfile},
+		{name: "compose", cfg: advanced, strategy: models.BuildStrategyCompose, wantErr: true},
+		{name: "swarm capabilities", cfg: &models.DeployConfig{Mode: models.DeployModeSwarm, CapAdd: []string{"NET_ADMIN"}}, strategy: models.BuildStrategyDockerfile},
+		{name: "swarm devices", cfg: &models.DeployConfig{Mode: models.DeployModeSwarm, Devices: []string{"/dev/dri"}}, strategy: models.BuildStrategyDockerfile, wantErr: true},
+		{name: "swarm shm size", cfg: &models.DeployConfig{Mode: models.DeployModeSwarm, ShmSize: "1g"}, strategy: models.BuildStrategyDockerfile, wantErr: true},
+		{name: "gpus", cfg: &models.DeployConfig{GPUs: "all", Runtime: "nvidia"}, strategy: models.BuildStrategyDockerfile},
+		{name: "swarm gpus", cfg: &models.DeployConfig{Mode: models.DeployModeSwarm, GPUs: "all"}, strategy: models.BuildStrategyDockerfile, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &AppCreateRequest{DeployConfig: tt.cfg}
+			if _, err := req.validateDeployConfig(nil, tt.strategy); (err != nil) != tt.wantErr {
+				t.Errorf("validateDeployConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/build"
+	"schooner/internal/models"
+)
+
+// SetArtifactsDir sets where the artifacts of builds are kept
+func (h *BuildHandler) SetArtifactsDir(dir string) {
+	h.artifactsDir = dir
+}
+
+// buildForArtifacts looks up the build of a request, writing an error and
+// returning nil if it can't
+func (h *BuildHandler) buildForArtifacts(w http.ResponseWriter, r *http.Request) *models.Build {
+	ctx := r.Context()
+	buildID := chi.URLParam(r, "buildID")
+
+	if h.artifactsDir == "" {
+		http.Error(w, "artifacts not available", http.StatusServiceUnavailable)
+		return nil
+	}
+	b, err := h.buildQueries.GetByID(ctx, buildID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get build", "buildID", buildID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return nil
+	}
+	if b == nil {
+		http.Error(w, "build not found", http.StatusNotFound)
+		return nil
+	}
+	return b
+}
+
+// ListArtifacts handles GET /api/builds/{buildID}/artifacts
+func (h *BuildHandler) ListArtifacts(w http.ResponseWriter, r *http.Request) {
+	b := h.buildForArtifacts(w, r)
+	if b == nil {
+		return
+	}
+
+	artifacts, err := build.ListArtifacts(h.artifactsDir, b)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list artifacts", "buildID", b.ID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if artifacts == nil {
+		artifacts = []build.Artifact{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(artifacts)
+}
+
+// DownloadArtifact handles GET /api/builds/{buildID}/artifacts/{name}
+func (h *BuildHandler) DownloadArtifact(w http.ResponseWriter, r *http.Request) {
+	b := h.buildForArtifacts(w, r)
+	if b == nil {
+		return
+	}
+
+	name := chi.URLParam(r, "name")
+	file, err := build.ArtifactFile(h.artifactsDir, b, name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		http.Error(w, "artifact not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to open artifact", "buildID", b.ID, "name", name, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to open artifact", "buildID", b.ID, "name", name, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s-%s"`, b.AppName, b.ID[:8], name))
+	http.ServeContent(w, r, name, info.ModTime(), f)
+}
+
+// renderArtifacts lists the files copied out of a build's image, with
+// links to download them
+func (h *PageHandler) renderArtifacts(ctx context.Context, w http.ResponseWriter, b *models.Build) {
+	artifacts, err := build.ListArtifacts(h.cfg.Docker.ArtifactsDir, b)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list artifacts", "buildID", b.ID, "error", err)
+		return
+	}
+	if len(artifacts) == 0 {
+		return
+	}
+
+	var rows strings.Builder
+	for _, artifact := range artifacts {
+		fmt.Fprintf(&rows, `
+                    <tr class="border-t border-gray-200">
+                        <td class="px-6 py-2 font-mono"><a href="/api/builds/%s/artifacts/%s" class="text-blue-600 hover:underline">%s</a></td>
+                        <td class="px-6 py-2 text-gray-500">%s</td>
+                    </tr>`,
+			html.EscapeString(b.ID), html.EscapeString(artifact.Name), html.EscapeString(artifact.Name),
+			formatBytes(uint64(artifact.Size)))
+	}
+
+	fmt.Fprintf(w, `
+        <h2 class="text-xl font-bold mt-8 mb-4">Artifacts</h2>
+        <div class="bg-white shadow-sm rounded-lg border border-gray-200 overflow-hidden">
+            <table class="w-full text-sm">
+                <thead class="bg-gray-50 text-left text-gray-500">
+                    <tr><th class="px-6 py-2">Archive</th><th class="px-6 py-2">Size</th></tr>
+                </thead>
+                <tbody>%s
+                </tbody>
+            </table>
+        </div>`, rows.String())
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// GetAutoDeployPause handles GET /api/settings/auto-deploy
+func (h *SettingsHandler) GetAutoDeployPause(w http.ResponseWriter, r *http.Request) {
+	paused, err := h.settingsQueries.IsAutoDeployPaused(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to get auto-deploy pause", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"paused": paused})
+}
+
+// SetAutoDeployPause handles POST /api/settings/auto-deploy
+func (h *SettingsHandler) SetAutoDeployPause(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Paused *bool `json:"paused"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Paused == nil {
+		http.Error(w, "paused (true or false) is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.settingsQueries.SetAutoDeployPaused(r.Context(), *req.Paused); err != nil {
+		slog.ErrorContext(r.Context(), "failed to save auto-deploy pause", "error", err)
+		http.Error(w, "failed to save auto-deploy pause", http.StatusInternalServerError)
+		return
+	}
+
+	message := "Auto-deploys resumed"
+	if *req.Paused {
+		message = "Auto-deploys paused"
+	}
+	slog.InfoContext(r.Context(), message)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"paused":  *req.Paused,
+		"message": message,
+	})
+}
+
+// renderAutoDeployPaused writes a notice on every page while auto-deploys
+// are paused
+func (h *PageHandler) renderAutoDeployPaused(w http.ResponseWriter, ctx context.Context) {
+	if h.settingsQueries == nil {
+		return
+	}
+	paused, err := h.settingsQueries.IsAutoDeployPaused(ctx)
+	if err != nil || !paused {
+		return
+	}
+
+	renderTemplate(w, "auto-deploy-paused", nil)
+}
+
+// renderAutoDeploySettings writes the switch pausing auto-deploys on the
+// settings page
+func (h *PageHandler) renderAutoDeploySettings(w http.ResponseWriter, ctx context.Context) {
+	if h.settingsQueries == nil {
+		return
+	}
+	paused, err := h.settingsQueries.IsAutoDeployPaused(ctx)
+	if err != nil {
+		slog.Warn("failed to load auto-deploy pause", "error", err)
+	}
+
+	renderTemplate(w, "auto-deploy-settings", paused)
+}
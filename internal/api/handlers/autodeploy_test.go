@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/build"
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+func TestAutoDeployPause(t *testing.T) {
+	db := newTestDB(t)
+
+	ctx := context.Background()
+	appQueries := queries.NewAppQueries(db.DB)
+	buildQueries := queries.NewBuildQueries(db.DB)
+	settingsQueries := queries.NewSettingsQueries(db.DB)
+	app := &models.App{
+		ID: "web", Name: "web", RepoURL: "https://github.com/example/web.git", Branch: "main",
+		BuildStrategy: models.BuildStrategyDockerfile, AutoDeploy: true, Enabled: true,
+		CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}
+	if err := appQueries.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	// The orchestrator isn't started, so builds queued for it stay queued
+	orchestrator := build.NewOrchestrator(nil, nil, appQueries, buildQueries, queries.NewLogQueries(db.DB))
+	webhooks := NewWebhookHandler(nil, appQueries, buildQueries, nil, orchestrator)
+	webhooks.SetSettingsQueries(settingsQueries)
+	settings := NewSettingsHandler(settingsQueries, nil, nil, nil, nil)
+	pages := &PageHandler{settingsQueries: settingsQueries}
+
+	router := chi.NewRouter()
+	router.Get("/api/settings/auto-deploy", settings.GetAutoDeployPause)
+	router.Post("/api/settings/auto-deploy", settings.SetAutoDeployPause)
+	router.Post("/webhooks/github/{appID}", webhooks.HandleGitHubForApp)
+
+	setPaused := func(body string) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/settings/auto-deploy", strings.NewReader(body)))
+		return rec.Code
+	}
+	isPaused := func() bool {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/settings/auto-deploy", nil))
+		var resp struct {
+			Paused bool `json:"paused"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("GetAutoDeployPause() response: %v", err)
+		}
+		return resp.Paused
+	}
+	push := func() (string, string) {
+		body := `{"ref": "refs/heads/main", "after": "abc1234def", "head_commit": {"id": "abc1234def", "message": "Fix", "author": {"name": "dev"}}}`
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/github/web", strings.NewReader(body))
+		req.Header.Set("X-GitHub-Event", "push")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		var resp struct {
+			Status   string   `json:"status"`
+			BuildIDs []string `json:"build_ids"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || len(resp.BuildIDs) != 1 {
+			t.Fatalf("webhook response = %d %v, %v, want one build", rec.Code, resp, err)
+		}
+		return resp.Status, resp.BuildIDs[0]
+	}
+	// render returns the banner every page shows and the settings section
+	render := func() (string, string) {
+		banner, section := httptest.NewRecorder(), httptest.NewRecorder()
+		pages.renderAutoDeployPaused(banner, ctx)
+		pages.renderAutoDeploySettings(section, ctx)
+		return banner.Body.String(), section.Body.String()
+	}
+
+	if got := setPaused(`{}`); got != http.StatusBadRequest {
+		t.Errorf("SetAutoDeployPause() without paused status = %d, want %d", got, http.StatusBadRequest)
+	}
+	if isPaused() {
+		t.Error("auto-deploys paused before pausing them")
+	}
+
+	if got := setPaused(`{"paused": true}`); got != http.StatusOK {
+		t.Fatalf("SetAutoDeployPause(true) status = %d", got)
+	}
+	if !isPaused() {
+		t.Error("auto-deploys not paused after pausing them")
+	}
+	if banner, section := render(); !strings.Contains(banner, `id="auto-deploy-paused"`) || !strings.Contains(section, "setAutoDeployPaused(false)") {
+		t.Errorf("pages while paused show %q and %q, want the banner and a resume button", banner, section)
+	}
+
+	// A push while paused is in the history as a cancelled build
+	status, buildID := push()
+	if status != "paused" {
+		t.Errorf("webhook status while paused = %q, want paused", status)
+	}
+	recorded, err := buildQueries.GetByID(ctx, buildID)
+	if err != nil || recorded == nil {
+		t.Fatalf("GetByID() = %v, %v", recorded, err)
+	}
+	if recorded.Status != models.BuildStatusCancelled || recorded.GetCommitSHA() != "abc1234def" || recorded.ErrorMessage.String != "Auto-deploys are paused" {
+		t.Errorf("build while paused = %s %q %q, want a cancelled build of the commit", recorded.Status, recorded.GetCommitSHA(), recorded.ErrorMessage.String)
+	}
+	if got := orchestrator.QueueDepth(); got != 0 {
+		t.Errorf("queued builds while paused = %d, want 0", got)
+	}
+
+	if got := setPaused(`{"paused": false}`); got != http.StatusOK {
+		t.Fatalf("SetAutoDeployPause(false) status = %d", got)
+	}
+	if isPaused() {
+		t.Error("auto-deploys still paused after resuming them")
+	}
+	if banner, section := render(); banner != "" || !strings.Contains(section, "setAutoDeployPaused(true)") {
+		t.Errorf("pages after resuming show %q and %q, want no banner and a pause button", banner, section)
+	}
+
+	status, buildID = push()
+	if status != "accepted" {
+		t.Errorf("webhook status after resuming = %q, want accepted", status)
+	}
+	if queued, err := buildQueries.GetByID(ctx, buildID); err != nil || queued.Status != models.BuildStatusPending {
+		t.Errorf("build after resuming = %v, %v, want it pending", queued, err)
+	}
+	if got := orchestrator.QueueDepth(); got != 1 {
+		t.Errorf("queued builds after resuming = %d, want 1", got)
+	}
+}
@@ -0,0 +1,394 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"schooner/internal/backup"
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+// BackupHandler handles volume backup jobs, archives and restores
+type BackupHandler struct {
+	backupQueries   *queries.BackupQueries
+	settingsQueries *queries.SettingsQueries
+	backupManager   *backup.Manager
+}
+
+// NewBackupHandler creates a new BackupHandler
+func NewBackupHandler(backupQueries *queries.BackupQueries, settingsQueries *queries.SettingsQueries, backupManager *backup.Manager) *BackupHandler {
+	return &BackupHandler{
+		backupQueries:   backupQueries,
+		settingsQueries: settingsQueries,
+		backupManager:   backupManager,
+	}
+}
+
+// BackupJobRequest is the request body for creating or updating a backup job
+type BackupJobRequest struct {
+	Name     string              `json:"name"`
+	Volumes  []string            `json:"volumes"`
+	Schedule string              `json:"schedule"` // Cron expression, empty for manual runs only
+	KeepLast int                 `json:"keep_last"`
+	Target   models.BackupTarget `json:"target"`
+	Enabled  bool                `json:"enabled"`
+}
+
+// apply copies the request onto a job
+func (req *BackupJobRequest) apply(job *models.BackupJob) {
+	job.Name = strings.TrimSpace(req.Name)
+	job.SetVolumes(req.Volumes)
+	job.Schedule = strings.TrimSpace(req.Schedule)
+	job.KeepLast = req.KeepLast
+	job.Target = req.Target
+	job.Enabled = req.Enabled
+}
+
+// backupJobResponse is a job with when it next runs
+type backupJobResponse struct {
+	*models.BackupJob
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+}
+
+// ListJobs handles GET /api/backups/jobs
+func (h *BackupHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.backupQueries.ListJobs(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list backup jobs", "error", err)
+		http.Error(w, "failed to list backup jobs", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	response := make([]backupJobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		item := backupJobResponse{BackupJob: job}
+		if next := job.NextRun(now); job.Enabled && !next.IsZero() {
+			item.NextRunAt = &next
+		}
+		response = append(response, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateJob handles POST /api/backups/jobs
+func (h *BackupHandler) CreateJob(w http.ResponseWriter, r *http.Request) {
+	var req BackupJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	job := &models.BackupJob{
+		ID:        uuid.New().String(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	req.apply(job)
+	if err := job.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.backupQueries.CreateJob(r.Context(), job); err != nil {
+		slog.ErrorContext(r.Context(), "failed to create backup job", "error", err)
+		http.Error(w, "failed to create backup job", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "backup job created", "name", job.Name, "volumes", job.Volumes, "schedule", job.Schedule)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(job)
+}
+
+// UpdateJob handles PUT /api/backups/jobs/{jobID}
+func (h *BackupHandler) UpdateJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.getJob(w, r)
+	if !ok {
+		return
+	}
+
+	var req BackupJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.apply(job)
+	if err := job.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.backupQueries.UpdateJob(r.Context(), job); err != nil {
+		slog.ErrorContext(r.Context(), "failed to update backup job", "error", err)
+		http.Error(w, "failed to update backup job", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// DeleteJob handles DELETE /api/backups/jobs/{jobID}. Its backups are kept.
+func (h *BackupHandler) DeleteJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.getJob(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.backupQueries.DeleteJob(r.Context(), job.ID); err != nil {
+		slog.ErrorContext(r.Context(), "failed to delete backup job", "error", err)
+		http.Error(w, "failed to delete backup job", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Backup job deleted",
+	})
+}
+
+// RunJob handles POST /api/backups/jobs/{jobID}/run. The backup runs in the
+// background.
+func (h *BackupHandler) RunJob(w http.ResponseWriter, r *http.Request) {
+	if h.backupManager == nil {
+		http.Error(w, "Docker is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	job, ok := h.getJob(w, r)
+	if !ok {
+		return
+	}
+
+	go func() {
+		if err := h.backupManager.RunJob(context.Background(), job); err != nil {
+			slog.ErrorContext(r.Context(), "backup failed", "job", job.Name, "error", err)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Backup started",
+	})
+}
+
+// List handles GET /api/backups, optionally filtered by ?volume=
+func (h *BackupHandler) List(w http.ResponseWriter, r *http.Request) {
+	backups, err := h.backupQueries.ListBackups(r.Context(), r.URL.Query().Get("volume"), 200)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list backups", "error", err)
+		http.Error(w, "failed to list backups", http.StatusInternalServerError)
+		return
+	}
+	if backups == nil {
+		backups = []*models.Backup{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(backups)
+}
+
+// Restore handles POST /api/backups/{backupID}/restore. Containers using the
+// volume are stopped while it is restored in the background.
+func (h *BackupHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	if h.backupManager == nil {
+		http.Error(w, "Docker is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	b, ok := h.getBackup(w, r)
+	if !ok {
+		return
+	}
+	if b.Status != models.BackupSuccess {
+		http.Error(w, "only successful backups can be restored", http.StatusBadRequest)
+		return
+	}
+
+	go func() {
+		if err := h.backupManager.Restore(context.Background(), b); err != nil {
+			slog.ErrorContext(r.Context(), "restore failed", "volume", b.Volume, "backup", b.ID, "error", err)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Restoring %s from %s", b.Volume, b.CreatedAt.Format("2006-01-02 15:04")),
+	})
+}
+
+// Delete handles DELETE /api/backups/{backupID}
+func (h *BackupHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if h.backupManager == nil {
+		http.Error(w, "Docker is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	b, ok := h.getBackup(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.backupManager.Delete(r.Context(), b); err != nil {
+		slog.ErrorContext(r.Context(), "failed to delete backup", "backup", b.ID, "error", err)
+		http.Error(w, "failed to delete backup: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Backup deleted",
+	})
+}
+
+// Volumes handles GET /api/backups/volumes
+func (h *BackupHandler) Volumes(w http.ResponseWriter, r *http.Request) {
+	if h.backupManager == nil {
+		http.Error(w, "Docker is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	volumes, err := h.backupManager.Volumes(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list volumes", "error", err)
+		http.Error(w, "failed to list volumes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(volumes)
+}
+
+// BackupStorageConfig is the request and response body for backup storage
+// settings
+type BackupStorageConfig struct {
+	Dir         string          `json:"dir"`
+	S3          backup.S3Config `json:"s3"`
+	S3SecretKey string          `json:"s3_secret_key,omitempty"` // Blank keeps the stored key
+	S3SecretSet bool            `json:"s3_secret_set"`
+}
+
+// GetStorageConfig handles GET /api/settings/backups
+func (h *BackupHandler) GetStorageConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	dir, _ := h.settingsQueries.Get(ctx, backup.SettingDir)
+	s3, err := backup.LoadS3Config(ctx, h.settingsQueries)
+	if err != nil {
+		http.Error(w, "failed to load backup settings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(BackupStorageConfig{
+		Dir:         dir,
+		S3:          s3,
+		S3SecretSet: s3.SecretKey != "",
+	})
+}
+
+// SetStorageConfig handles POST /api/settings/backups
+func (h *BackupHandler) SetStorageConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req BackupStorageConfig
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	s3 := req.S3
+	s3.SecretKey = req.S3SecretKey
+	if s3.SecretKey == "" {
+		existing, err := backup.LoadS3Config(ctx, h.settingsQueries)
+		if err != nil {
+			http.Error(w, "failed to load backup settings", http.StatusInternalServerError)
+			return
+		}
+		s3.SecretKey = existing.SecretKey
+	}
+
+	// S3 is optional, but once any of it is given it must be complete
+	if s3.Endpoint != "" || s3.Bucket != "" || s3.AccessKey != "" {
+		if err := s3.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.settingsQueries.Set(ctx, backup.SettingDir, strings.TrimSpace(req.Dir)); err != nil {
+		http.Error(w, "failed to save backup settings", http.StatusInternalServerError)
+		return
+	}
+	if err := backup.SaveS3Config(ctx, h.settingsQueries, s3); err != nil {
+		slog.ErrorContext(ctx, "failed to save S3 backup settings", "error", err)
+		http.Error(w, "failed to save backup settings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Backup storage saved",
+	})
+}
+
+// getJob loads the job named in the URL, writing an error response if it
+// can't
+func (h *BackupHandler) getJob(w http.ResponseWriter, r *http.Request) (*models.BackupJob, bool) {
+	job, err := h.backupQueries.GetJob(r.Context(), chi.URLParam(r, "jobID"))
+	if err != nil {
+		http.Error(w, "failed to get backup job", http.StatusInternalServerError)
+		return nil, false
+	}
+	if job == nil {
+		http.Error(w, "backup job not found", http.StatusNotFound)
+		return nil, false
+	}
+	return job, true
+}
+
+// getBackup loads the backup named in the URL, writing an error response if
+// it can't
+func (h *BackupHandler) getBackup(w http.ResponseWriter, r *http.Request) (*models.Backup, bool) {
+	b, err := h.backupQueries.GetBackup(r.Context(), chi.URLParam(r, "backupID"))
+	if err != nil {
+		http.Error(w, "failed to get backup", http.StatusInternalServerError)
+		return nil, false
+	}
+	if b == nil {
+		http.Error(w, "backup not found", http.StatusNotFound)
+		return nil, false
+	}
+	return b, true
+}
+
+// Backups renders the volume backups page
+func (h *PageHandler) Backups(w http.ResponseWriter, r *http.Request) {
+	h.writeHeader(w, r, "Backups")
+	renderTemplate(w, "backups", nil)
+	h.renderSystemBackups(w)
+	h.writeFooter(w)
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"schooner/internal/models"
+)
+
+// GetBanner handles GET /api/settings/banner
+func (h *SettingsHandler) GetBanner(w http.ResponseWriter, r *http.Request) {
+	banner, err := h.settingsQueries.GetBanner(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to get banner", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"active": banner.IsActive(time.Now()),
+	}
+	if banner != nil {
+		response["banner"] = banner
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetBanner handles POST /api/settings/banner
+func (h *SettingsHandler) SetBanner(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Message   string `json:"message"`
+		Level     string `json:"level"`
+		ExpiresAt string `json:"expires_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	banner, err := parseBannerRequest(req.Message, req.Level, req.ExpiresAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.settingsQueries.SetBanner(r.Context(), banner); err != nil {
+		slog.ErrorContext(r.Context(), "failed to save banner", "error", err)
+		http.Error(w, "failed to save banner", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "maintenance banner set", "level", banner.Level, "expires_at", banner.ExpiresAt)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Banner saved",
+	})
+}
+
+// ClearBanner handles DELETE /api/settings/banner
+func (h *SettingsHandler) ClearBanner(w http.ResponseWriter, r *http.Request) {
+	if err := h.settingsQueries.ClearBanner(r.Context()); err != nil {
+		slog.ErrorContext(r.Context(), "failed to clear banner", "error", err)
+		http.Error(w, "failed to clear banner", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "maintenance banner cleared")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Banner cleared",
+	})
+}
+
+// parseBannerRequest validates banner input and builds a Banner
+func parseBannerRequest(message, level, expiresAt string) (*models.Banner, error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return nil, fmt.Errorf("message is required")
+	}
+	if len(message) > 500 {
+		return nil, fmt.Errorf("message must be 500 characters or fewer")
+	}
+
+	banner := &models.Banner{Message: message, Level: models.BannerLevel(level)}
+	if level == "" {
+		banner.Level = models.BannerLevelInfo
+	}
+	if !banner.Level.IsValid() {
+		return nil, fmt.Errorf("level must be 'info' or 'warning'")
+	}
+
+	if expiresAt != "" {
+		t, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("expires_at must be an RFC3339 timestamp")
+		}
+		if !t.After(time.Now()) {
+			return nil, fmt.Errorf("expires_at must be in the future")
+		}
+		banner.ExpiresAt = &t
+	}
+
+	return banner, nil
+}
+
+// renderBanner writes the maintenance banner if one is active
+func (h *PageHandler) renderBanner(w http.ResponseWriter, ctx context.Context) {
+	if h.settingsQueries == nil {
+		return
+	}
+
+	banner, err := h.settingsQueries.GetBanner(ctx)
+	if err != nil {
+		slog.Warn("failed to load banner", "error", err)
+		return
+	}
+	if !banner.IsActive(time.Now()) {
+		return
+	}
+
+	classes := "bg-blue-50 border-blue-200 text-blue-800"
+	if banner.Level == models.BannerLevelWarning {
+		classes = "bg-yellow-50 border-yellow-300 text-yellow-800"
+	}
+
+	expiry := ""
+	if banner.ExpiresAt != nil {
+		expiry = fmt.Sprintf(`<span class="text-xs opacity-75 ml-2">until %s</span>`,
+			html.EscapeString(banner.ExpiresAt.Local().Format("Jan 2 15:04")))
+	}
+
+	fmt.Fprintf(w, `
+        <div id="maintenance-banner" class="mb-6 px-4 py-3 rounded-lg border %s">
+            <span class="text-sm font-medium">%s</span>%s
+        </div>`, classes, html.EscapeString(banner.Message), expiry)
+}
+
+func (h *PageHandler) renderBannerSettings(w http.ResponseWriter) {
+	fmt.Fprint(w, `
+        <div class="mt-8">
+            <h2 class="text-xl font-bold mb-4">Maintenance Banner</h2>
+            <div class="bg-white shadow-sm rounded-lg p-6 border border-gray-200">
+                <p class="text-gray-500 mb-4">Show an announcement at the top of every page, e.g. planned host maintenance.</p>
+                <form onsubmit="submitBanner(event)">
+                    <div class="grid grid-cols-1 md:grid-cols-2 gap-4 mb-4">
+                        <div class="md:col-span-2">
+                            <label class="block text-sm text-gray-500 mb-1">Message</label>
+                            <input type="text" name="message" id="banner-message-input" maxlength="500"
+                                placeholder="Builds paused for host maintenance tonight"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Level</label>
+                            <select name="level" id="banner-level-input" class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                                <option value="info">Info</option>
+                                <option value="warning">Warning</option>
+                            </select>
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Expires (optional)</label>
+                            <input type="datetime-local" name="expires_at" id="banner-expires-input"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        </div>
+                    </div>
+                    <div class="flex space-x-2">
+                        <button type="submit" class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white">Save Banner</button>
+                        <button type="button" onclick="clearBanner()" class="px-4 py-2 bg-gray-50 hover:bg-gray-100 rounded">Clear</button>
+                    </div>
+                </form>
+            </div>
+        </div>
+        <script>
+            fetch('/api/settings/banner')
+                .then(response => response.json())
+                .then(data => {
+                    if (!data.banner) return;
+                    document.getElementById('banner-message-input').value = data.banner.message;
+                    document.getElementById('banner-level-input').value = data.banner.level;
+                });
+
+            function submitBanner(event) {
+                event.preventDefault();
+                const form = event.target;
+                const expires = form.querySelector('input[name="expires_at"]').value;
+                const data = {
+                    message: form.querySelector('input[name="message"]').value,
+                    level: form.querySelector('select[name="level"]').value,
+                    expires_at: expires ? new Date(expires).toISOString() : ''
+                };
+
+                fetch('/api/settings/banner', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify(data)
+                })
+                .then(response => {
+                    if (response.ok) {
+                        window.location.reload();
+                    } else {
+                        response.text().then(text => alert('Failed to save banner: ' + text));
+                    }
+                });
+            }
+
+            function clearBanner() {
+                fetch('/api/settings/banner', { method: 'DELETE' })
+                    .then(response => {
+                        if (response.ok) {
+                            window.location.reload();
+                        } else {
+                            response.text().then(text => alert('Failed to clear banner: ' + text));
+                        }
+                    });
+            }
+        </script>`)
+}
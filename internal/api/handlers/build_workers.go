@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"schooner/internal/build"
+	"schooner/internal/database/queries"
+)
+
+// BuildWorkersStatus is how many builds can run at once and how busy the
+// workers running them are
+type BuildWorkersStatus struct {
+	Workers int `json:"workers"`
+	Busy    int `json:"busy"`   // Workers running a build
+	Queued  int `json:"queued"` // Builds waiting for a worker
+	Max     int `json:"max"`
+}
+
+// SetOrchestrator lets the build worker count be changed without a restart
+func (h *SettingsHandler) SetOrchestrator(orchestrator *build.Orchestrator) {
+	h.orchestrator = orchestrator
+}
+
+// buildWorkersStatus reports the orchestrator's workers, or the stored
+// count when builds aren't running here
+func buildWorkersStatus(ctx context.Context, orchestrator *build.Orchestrator, settingsQueries *queries.SettingsQueries) (BuildWorkersStatus, error) {
+	status := BuildWorkersStatus{Max: build.MaxWorkers}
+	if orchestrator != nil {
+		status.Workers = orchestrator.Workers()
+		status.Busy = orchestrator.BusyWorkers()
+		status.Queued = orchestrator.QueueDepth()
+		return status, nil
+	}
+
+	workers, err := settingsQueries.GetBuildWorkers(ctx)
+	if err != nil {
+		return status, err
+	}
+	if workers == 0 {
+		workers = build.DefaultWorkers
+	}
+	status.Workers = workers
+	return status, nil
+}
+
+// GetBuildWorkers handles GET /api/settings/build-workers
+func (h *SettingsHandler) GetBuildWorkers(w http.ResponseWriter, r *http.Request) {
+	status, err := buildWorkersStatus(r.Context(), h.orchestrator, h.settingsQueries)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to get build workers", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// SetBuildWorkers handles POST /api/settings/build-workers - stores how many
+// builds run at once and resizes the running worker pool to match
+func (h *SettingsHandler) SetBuildWorkers(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Workers int `json:"workers"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Workers < 1 || req.Workers > build.MaxWorkers {
+		http.Error(w, fmt.Sprintf("workers must be between 1 and %d", build.MaxWorkers), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.settingsQueries.SetBuildWorkers(r.Context(), req.Workers); err != nil {
+		slog.ErrorContext(r.Context(), "failed to save build workers", "error", err)
+		http.Error(w, "failed to save build workers", http.StatusInternalServerError)
+		return
+	}
+	if h.orchestrator != nil {
+		h.orchestrator.SetWorkers(req.Workers)
+	}
+	slog.InfoContext(r.Context(), "build workers set", "workers", req.Workers)
+
+	h.GetBuildWorkers(w, r)
+}
+
+// SetOrchestrator shows on the dashboard how many builds are running and
+// waiting for a worker
+func (h *PageHandler) SetOrchestrator(orchestrator *build.Orchestrator) {
+	h.orchestrator = orchestrator
+}
+
+// buildQueue returns the dashboard's build queue indicator, or nil when
+// builds don't run here
+func (h *PageHandler) buildQueue() *BuildWorkersStatus {
+	if h.orchestrator == nil {
+		return nil
+	}
+	status, _ := buildWorkersStatus(context.Background(), h.orchestrator, h.settingsQueries)
+	return &status
+}
+
+// BuildQueuePartial handles GET /partials/builds/queue
+func (h *PageHandler) BuildQueuePartial(w http.ResponseWriter, r *http.Request) {
+	status := h.buildQueue()
+	if status == nil {
+		http.Error(w, "builds are not running", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	renderTemplate(w, "build-queue", status)
+}
+
+// renderBuildWorkerSettings writes the control for how many builds run at
+// once on the settings page
+func (h *PageHandler) renderBuildWorkerSettings(w http.ResponseWriter, ctx context.Context) {
+	if h.settingsQueries == nil {
+		return
+	}
+	status, err := buildWorkersStatus(ctx, h.orchestrator, h.settingsQueries)
+	if err != nil {
+		slog.Warn("failed to load build workers", "error", err)
+	}
+
+	renderTemplate(w, "build-worker-settings", status)
+}
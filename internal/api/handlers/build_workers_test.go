@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"schooner/internal/build"
+	"schooner/internal/database/queries"
+)
+
+func TestSettingsHandler_SetBuildWorkers(t *testing.T) {
+	db := newTestDB(t)
+
+	settingsQueries := queries.NewSettingsQueries(db.DB)
+	orchestrator := build.NewOrchestrator(nil, nil, queries.NewAppQueries(db.DB), queries.NewBuildQueries(db.DB), queries.NewLogQueries(db.DB))
+	orchestrator.Start(build.DefaultWorkers)
+	defer orchestrator.Stop()
+
+	h := NewSettingsHandler(settingsQueries, nil, nil, nil, nil)
+	h.SetOrchestrator(orchestrator)
+
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantWorkers int
+	}{
+		{name: "scale up", body: `{"workers": 5}`, wantStatus: http.StatusOK, wantWorkers: 5},
+		{name: "scale down", body: `{"workers": 1}`, wantStatus: http.StatusOK, wantWorkers: 1},
+		{name: "zero", body: `{"workers": 0}`, wantStatus: http.StatusBadRequest, wantWorkers: 1},
+		{name: "above max", body: `{"workers": 17}`, wantStatus: http.StatusBadRequest, wantWorkers: 1},
+		{name: "invalid body", body: `five`, wantStatus: http.StatusBadRequest, wantWorkers: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.SetBuildWorkers(rec, httptest.NewRequest(http.MethodPost, "/api/settings/build-workers", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := orchestrator.Workers(); got != tt.wantWorkers {
+				t.Errorf("orchestrator workers = %d, want %d", got, tt.wantWorkers)
+			}
+			stored, err := settingsQueries.GetBuildWorkers(context.Background())
+			if err != nil || stored != tt.wantWorkers {
+				t.Errorf("GetBuildWorkers() = %d, %v, want %d", stored, err, tt.wantWorkers)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var status BuildWorkersStatus
+			if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+				t.Fatal(err)
+			}
+			if status.Workers != tt.wantWorkers || status.Max != build.MaxWorkers {
+				t.Errorf("status = %+v, want %d workers", status, tt.wantWorkers)
+			}
+		})
+	}
+}
+
+func TestBuildQueuePartial(t *testing.T) {
+	h := newDashboardHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.BuildQueuePartial(rec, httptest.NewRequest(http.MethodGet, "/partials/builds/queue", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status without an orchestrator = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	orchestrator := build.NewOrchestrator(nil, nil, h.appQueries, h.buildQueries, nil)
+	orchestrator.Start(3)
+	defer orchestrator.Stop()
+	h.SetOrchestrator(orchestrator)
+
+	rec = httptest.NewRecorder()
+	h.BuildQueuePartial(rec, httptest.NewRequest(http.MethodGet, "/partials/builds/queue", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{`data-fragment="build-queue"`, "0/3 workers busy"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("partial is missing %s:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "waiting") {
+		t.Errorf("partial shows builds waiting on an empty queue:\n%s", body)
+	}
+}
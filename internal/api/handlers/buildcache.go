@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/build"
+)
+
+// ClearBuildCache handles DELETE /api/apps/{appID}/build-cache, removing
+// the volumes that keep the app's package caches between builds
+func (h *AppHandler) ClearBuildCache(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID := chi.URLParam(r, "appID")
+
+	app, err := h.appQueries.GetByID(ctx, appID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get app", "appID", appID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if app == nil {
+		http.Error(w, "app not found", http.StatusNotFound)
+		return
+	}
+
+	if h.orchestrator == nil {
+		http.Error(w, "build orchestrator not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	removed, err := h.orchestrator.ClearBuildCache(ctx, app)
+	if errors.Is(err, build.ErrBuildRunning) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to clear build cache", "app", app.Name, "error", err)
+		http.Error(w, "failed to clear build cache: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "build cache cleared", "app", app.Name, "volumes", len(removed))
+
+	if removed == nil {
+		removed = []string{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "cleared",
+		"volumes": removed,
+	})
+}
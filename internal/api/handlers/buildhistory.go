@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"schooner/internal/models"
+)
+
+// buildHistoryPageSize is the number of builds on each page of /builds
+const buildHistoryPageSize = 25
+
+// buildHistoryView is a page of the build history and the filters it was
+// picked with
+type buildHistoryView struct {
+	Apps                                         []*models.App
+	AppID, Status, Trigger, Author, Since, Until string
+	Statuses                                     []models.BuildStatus
+	Triggers                                     []models.BuildTrigger
+	Sort                                         string
+	Ascending                                    bool
+	Problem                                      string
+	Builds                                       []buildHistoryRow
+	Total, First, Last                           int
+	PrevURL, NextURL                             string
+}
+
+// buildHistoryRow is a build and how long it took
+type buildHistoryRow struct {
+	*models.Build
+	Elapsed string
+}
+
+// BuildHistory renders the filterable build history at /builds
+func (h *PageHandler) BuildHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := r.URL.Query()
+
+	apps, err := h.appQueries.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list apps", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	filter, filterErr := parseBuildFilter(r)
+	sort, ascending, sortErr := parseBuildSort(r)
+	page, _ := strconv.Atoi(params.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	var builds []*models.Build
+	var total int
+	problem := ""
+	switch {
+	case filterErr != nil:
+		problem = filterErr.Error()
+	case sortErr != nil:
+		problem = sortErr.Error()
+	default:
+		builds, total, err = h.buildQueries.Search(ctx, filter, sort, ascending, buildHistoryPageSize, (page-1)*buildHistoryPageSize)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to search builds", "error", err)
+			problem = "failed to load builds"
+		}
+	}
+
+	view := buildHistoryView{
+		Apps:      apps,
+		AppID:     filter.AppID,
+		Status:    params.Get("status"),
+		Trigger:   string(filter.Trigger),
+		Author:    params.Get("author"),
+		Since:     params.Get("since"),
+		Until:     params.Get("until"),
+		Statuses:  buildStatuses,
+		Triggers:  []models.BuildTrigger{models.TriggerWebhook, models.TriggerManual, models.TriggerRollback, models.TriggerConfig, models.TriggerReconcile, models.TriggerRefresh},
+		Sort:      string(sort),
+		Ascending: ascending,
+		Problem:   problem,
+		Total:     total,
+	}
+	for _, build := range builds {
+		row := buildHistoryRow{Build: build, Elapsed: "-"}
+		if build.StartedAt.Valid {
+			row.Elapsed = build.Duration().Round(time.Second).String()
+		}
+		view.Builds = append(view.Builds, row)
+	}
+	if len(builds) > 0 {
+		view.First = (page-1)*buildHistoryPageSize + 1
+		view.Last = view.First + len(builds) - 1
+	}
+	if page > 1 {
+		view.PrevURL = buildHistoryPageURL(params, page-1)
+	}
+	if page*buildHistoryPageSize < total {
+		view.NextURL = buildHistoryPageURL(params, page+1)
+	}
+
+	h.writeHeader(w, r, "Builds")
+	renderTemplate(w, "build-history", view)
+	h.writeFooter(w)
+}
+
+// buildHistoryPageURL links to another page of the build history with the
+// same filters
+func buildHistoryPageURL(params url.Values, page int) string {
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	query.Set("page", strconv.Itoa(page))
+	return "/builds?" + query.Encode()
+}
@@ -1,15 +1,21 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"schooner/internal/auth"
+	"schooner/internal/build"
 	"schooner/internal/database/queries"
 	"schooner/internal/models"
 )
@@ -18,6 +24,9 @@ import (
 type BuildHandler struct {
 	buildQueries *queries.BuildQueries
 	logQueries   *queries.LogQueries
+	orchestrator *build.Orchestrator
+	stepQueries  *queries.BuildStepQueries
+	artifactsDir string
 }
 
 // NewBuildHandler creates a new BuildHandler
@@ -28,43 +37,150 @@ func NewBuildHandler(buildQueries *queries.BuildQueries, logQueries *queries.Log
 	}
 }
 
+// SetOrchestrator sets the orchestrator that queues redeploys
+func (h *BuildHandler) SetOrchestrator(orchestrator *build.Orchestrator) {
+	h.orchestrator = orchestrator
+}
+
+// SetStepQueries sets where the pipeline steps of builds are read from
+func (h *BuildHandler) SetStepQueries(stepQueries *queries.BuildStepQueries) {
+	h.stepQueries = stepQueries
+}
+
+// BuildList is the response body of the build history
+type BuildList struct {
+	Data   []*models.Build `json:"data"`
+	Total  int             `json:"total"` // Builds matching the filters on all pages
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
 // List handles GET /api/builds
 func (h *BuildHandler) List(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	filter, err := parseBuildFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sort, ascending, err := parseBuildSort(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, offset, err := parseOffsetPage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	// Parse query params
-	limit := 50
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
-			limit = parsed
+	builds, total, err := h.buildQueries.Search(r.Context(), filter, sort, ascending, limit, offset)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list builds", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(BuildList{Data: builds, Total: total, Limit: limit, Offset: offset})
+}
+
+// buildStatuses are the statuses ?status can filter on
+var buildStatuses = []models.BuildStatus{
+	models.BuildStatusPending, models.BuildStatusCloning, models.BuildStatusBuilding,
+	models.BuildStatusPushing, models.BuildStatusDeploying, models.BuildStatusWaitingApproval,
+	models.BuildStatusScheduled, models.BuildStatusSuccess, models.BuildStatusFailed,
+	models.BuildStatusCancelled,
+}
+
+// parseBuildFilter parses the build history filters: ?app_id, ?status (comma
+// separated), ?trigger, ?since and ?until (dates or RFC3339 times; a date
+// in until includes that whole day) and ?author
+func parseBuildFilter(r *http.Request) (queries.BuildFilter, error) {
+	params := r.URL.Query()
+	filter := queries.BuildFilter{
+		AppID:   params.Get("app_id"),
+		Trigger: models.BuildTrigger(params.Get("trigger")),
+		Author:  strings.TrimSpace(params.Get("author")),
+	}
+
+	if statuses := params.Get("status"); statuses != "" {
+		for _, status := range strings.Split(statuses, ",") {
+			status := models.BuildStatus(strings.TrimSpace(status))
+			if !slices.Contains(buildStatuses, status) {
+				return filter, fmt.Errorf("unknown status %q", status)
+			}
+			filter.Statuses = append(filter.Statuses, status)
 		}
 	}
 
-	appID := r.URL.Query().Get("app_id")
+	switch filter.Trigger {
+	case "", models.TriggerWebhook, models.TriggerManual, models.TriggerRollback, models.TriggerConfig, models.TriggerReconcile, models.TriggerRefresh:
+	default:
+		return filter, fmt.Errorf("trigger must be webhook, manual, rollback or config")
+	}
 
-	var builds interface{}
 	var err error
+	if filter.Since, err = parseFilterTime(params.Get("since"), false); err != nil {
+		return filter, fmt.Errorf("since must be a date (2006-01-02) or an RFC3339 time")
+	}
+	if filter.Until, err = parseFilterTime(params.Get("until"), true); err != nil {
+		return filter, fmt.Errorf("until must be a date (2006-01-02) or an RFC3339 time")
+	}
 
-	if appID != "" {
-		offset := 0
-		if o := r.URL.Query().Get("offset"); o != "" {
-			if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
-				offset = parsed
-			}
+	return filter, nil
+}
+
+// parseFilterTime parses a date or RFC3339 time. A date ending a range
+// stands for the end of that day.
+func parseFilterTime(s string, end bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if day, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		if end {
+			day = day.AddDate(0, 0, 1)
 		}
-		builds, err = h.buildQueries.ListByAppID(ctx, appID, limit, offset)
-	} else {
-		builds, err = h.buildQueries.ListRecent(ctx, limit)
+		return day, nil
 	}
+	return time.Parse(time.RFC3339, s)
+}
 
-	if err != nil {
-		slog.Error("failed to list builds", "error", err)
-		http.Error(w, "internal error", http.StatusInternalServerError)
-		return
+// parseBuildSort parses ?sort (created_at, duration, app or status) and
+// ?order (asc or desc, newest first by default)
+func parseBuildSort(r *http.Request) (queries.BuildSort, bool, error) {
+	sort := queries.BuildSort(r.URL.Query().Get("sort"))
+	if sort == "" {
+		sort = queries.BuildSortCreated
+	}
+	if !sort.IsValid() {
+		return "", false, fmt.Errorf("sort must be created_at, duration, app or status")
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(builds)
+	switch r.URL.Query().Get("order") {
+	case "", "desc":
+		return sort, false, nil
+	case "asc":
+		return sort, true, nil
+	}
+	return "", false, fmt.Errorf("order must be asc or desc")
+}
+
+// parseOffsetPage parses ?limit and ?offset of numbered pages
+func parseOffsetPage(r *http.Request) (limit, offset int, err error) {
+	limit = defaultPageLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+	}
+	if o := r.URL.Query().Get("offset"); o != "" {
+		offset, err = strconv.Atoi(o)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("offset must not be negative")
+		}
+	}
+	return limit, offset, nil
 }
 
 // Get handles GET /api/builds/{buildID}
@@ -74,7 +190,7 @@ func (h *BuildHandler) Get(w http.ResponseWriter, r *http.Request) {
 
 	build, err := h.buildQueries.GetByID(ctx, buildID)
 	if err != nil {
-		slog.Error("failed to get build", "buildID", buildID, "error", err)
+		slog.ErrorContext(ctx, "failed to get build", "buildID", buildID, "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -100,6 +216,133 @@ func (h *BuildHandler) Retry(w http.ResponseWriter, r *http.Request) {
 	http.Error(w, "not implemented", http.StatusNotImplemented)
 }
 
+// Redeploy handles POST /api/builds/{buildID}/redeploy - queues a build of
+// the same commit that runs the images the build pinned by digest
+func (h *BuildHandler) Redeploy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	buildID := chi.URLParam(r, "buildID")
+
+	source, err := h.buildQueries.GetByID(ctx, buildID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get build", "buildID", buildID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if source == nil {
+		http.Error(w, "build not found", http.StatusNotFound)
+		return
+	}
+
+	if h.orchestrator == nil {
+		http.Error(w, "build orchestrator not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	redeploy, err := h.orchestrator.RedeployBuild(ctx, source)
+	if err != nil {
+		if errors.Is(err, build.ErrNoImageDigests) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		slog.ErrorContext(ctx, "failed to redeploy build", "buildID", buildID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(redeploy)
+}
+
+// Approve handles POST /api/builds/{buildID}/approve - lets a build waiting
+// for approval deploy
+func (h *BuildHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.orchestrator.ApproveBuild)
+}
+
+// Reject handles POST /api/builds/{buildID}/reject - cancels a build waiting
+// for approval
+func (h *BuildHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.orchestrator.RejectBuild)
+}
+
+// decide approves or rejects a build waiting for approval in the name of
+// whoever made the request
+func (h *BuildHandler) decide(w http.ResponseWriter, r *http.Request, decision func(ctx context.Context, buildID, by string) (*models.Build, error)) {
+	ctx := r.Context()
+	buildID := chi.URLParam(r, "buildID")
+
+	existing, err := h.buildQueries.GetByID(ctx, buildID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get build", "buildID", buildID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if existing == nil {
+		http.Error(w, "build not found", http.StatusNotFound)
+		return
+	}
+
+	if h.orchestrator == nil {
+		http.Error(w, "build orchestrator not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	decided, err := decision(ctx, buildID, requester(r))
+	if err != nil {
+		if errors.Is(err, build.ErrNotWaitingApproval) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		slog.ErrorContext(ctx, "failed to decide on build approval", "buildID", buildID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(decided)
+}
+
+// requester names who made a request: the signed-in user, or the API token
+// used
+func requester(r *http.Request) string {
+	return requesterOf(r.Context())
+}
+
+// requesterOf names who made the request of a context
+func requesterOf(ctx context.Context) string {
+	if name := auth.Requester(ctx); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+// GetSteps handles GET /api/builds/{buildID}/steps
+func (h *BuildHandler) GetSteps(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	buildID := chi.URLParam(r, "buildID")
+
+	if h.stepQueries == nil {
+		http.Error(w, "build steps not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	steps, err := h.stepQueries.ListByBuildID(ctx, buildID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list build steps", "buildID", buildID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if steps == nil {
+		steps = []*models.BuildStep{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(steps)
+}
+
 // GetLogs handles GET /api/builds/{buildID}/logs
 func (h *BuildHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -108,7 +351,7 @@ func (h *BuildHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
 	// Check if build exists
 	build, err := h.buildQueries.GetByID(ctx, buildID)
 	if err != nil {
-		slog.Error("failed to get build", "buildID", buildID, "error", err)
+		slog.ErrorContext(ctx, "failed to get build", "buildID", buildID, "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -121,7 +364,7 @@ func (h *BuildHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
 	// Get logs
 	logs, err := h.logQueries.GetByBuildID(ctx, buildID)
 	if err != nil {
-		slog.Error("failed to get logs", "buildID", buildID, "error", err)
+		slog.ErrorContext(ctx, "failed to get logs", "buildID", buildID, "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -138,7 +381,7 @@ func (h *BuildHandler) StreamLogs(w http.ResponseWriter, r *http.Request) {
 	// Check if build exists
 	build, err := h.buildQueries.GetByID(ctx, buildID)
 	if err != nil {
-		slog.Error("failed to get build", "buildID", buildID, "error", err)
+		slog.ErrorContext(ctx, "failed to get build", "buildID", buildID, "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -160,50 +403,93 @@ func (h *BuildHandler) StreamLogs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Send existing logs first
-	existingLogs, _ := h.logQueries.GetByBuildID(ctx, buildID)
-	for _, log := range existingLogs {
+	// Send existing logs first. While the build runs here, new lines arrive
+	// as they're written; after that they're polled from the database.
+	var existingLogs []*models.BuildLog
+	var live <-chan models.BuildLog
+	if h.orchestrator != nil {
+		var unsubscribe func()
+		existingLogs, live, unsubscribe, _ = h.orchestrator.SubscribeLogs(ctx, buildID)
+		defer unsubscribe()
+	} else {
+		existingLogs, _ = h.logQueries.GetByBuildID(ctx, buildID)
+	}
+
+	// Each line's event ID is its position in the log, so a reconnecting
+	// client's Last-Event-ID says how many lines it already has
+	resumeAfter, _ := strconv.Atoi(r.Header.Get("Last-Event-ID"))
+	seq := 0
+	sendLog := func(log *models.BuildLog) {
+		seq++
+		if seq <= resumeAfter {
+			return
+		}
 		data, _ := json.Marshal(log)
-		fmt.Fprintf(w, "event: log\ndata: %s\n\n", data)
+		fmt.Fprintf(w, "id: %d\nevent: log\ndata: %s\n\n", seq, data)
+	}
+
+	// Track the last log ID for polling new logs. Lines sent before they
+	// reached the database are skipped when polling finds them.
+	var lastLogID int64
+	skip := 0
+	for _, log := range existingLogs {
+		sendLog(log)
+		if log.ID > 0 {
+			lastLogID = log.ID
+		} else {
+			skip++
+		}
 	}
 	flusher.Flush()
 
 	// If build is complete, close connection
-	if build.IsComplete() {
+	if build.IsComplete() && live == nil {
 		fmt.Fprintf(w, "event: complete\ndata: %s\n\n", buildCompleteJSON(build))
 		flusher.Flush()
 		return
 	}
 
-	// Track last log ID for polling new logs
-	var lastLogID int64
-	if len(existingLogs) > 0 {
-		lastLogID = existingLogs[len(existingLogs)-1].ID
-	}
-
-	// Poll for new logs every 500ms
+	// Poll for new logs every 500ms once the live lines stop
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
 
+	finishing := false
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case log, ok := <-live:
+			if !ok {
+				live = nil
+				continue
+			}
+			sendLog(&log)
+			flusher.Flush()
+			skip++
 		case <-ticker.C:
+			if live != nil {
+				continue
+			}
+
 			// Get new logs since last ID
 			newLogs, err := h.logQueries.GetByBuildIDAfterID(ctx, buildID, lastLogID)
 			if err != nil {
-				slog.Error("failed to get new logs", "buildID", buildID, "error", err)
+				slog.ErrorContext(ctx, "failed to get new logs", "buildID", buildID, "error", err)
 				continue
 			}
 
+			sent := false
 			for _, log := range newLogs {
-				data, _ := json.Marshal(log)
-				fmt.Fprintf(w, "event: log\ndata: %s\n\n", data)
 				lastLogID = log.ID
+				if skip > 0 {
+					skip--
+					continue
+				}
+				sendLog(log)
+				sent = true
 			}
 
-			if len(newLogs) > 0 {
+			if sent {
 				flusher.Flush()
 			}
 
@@ -213,11 +499,14 @@ func (h *BuildHandler) StreamLogs(w http.ResponseWriter, r *http.Request) {
 				continue
 			}
 
-			if build.IsComplete() {
+			// Give the last lines sent live one more poll to reach the
+			// database before finishing
+			if build.IsComplete() && (skip == 0 || finishing) {
 				fmt.Fprintf(w, "event: complete\ndata: %s\n\n", buildCompleteJSON(build))
 				flusher.Flush()
 				return
 			}
+			finishing = build.IsComplete()
 		}
 	}
 }
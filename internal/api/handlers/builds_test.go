@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+func TestParseBuildFilter(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+		return d
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected queries.BuildFilter
+		wantErr  bool
+	}{
+		{name: "no filters", query: ""},
+		{
+			name:  "all filters",
+			query: "?app_id=app-1&status=failed,cancelled&trigger=webhook&since=2024-06-01&until=2024-06-10&author=%20ada%20",
+			expected: queries.BuildFilter{
+				AppID:    "app-1",
+				Statuses: []models.BuildStatus{models.BuildStatusFailed, models.BuildStatusCancelled},
+				Trigger:  models.TriggerWebhook,
+				Since:    day("2024-06-01"),
+				Until:    day("2024-06-11"),
+				Author:   "ada",
+			},
+		},
+		{
+			name:     "RFC3339 until",
+			query:    "?until=2024-06-10T12:00:00Z",
+			expected: queries.BuildFilter{Until: time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)},
+		},
+		{name: "unknown status", query: "?status=done", wantErr: true},
+		{name: "unknown trigger", query: "?trigger=cron", wantErr: true},
+		{name: "bad date", query: "?since=yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := parseBuildFilter(httptest.NewRequest("GET", "/api/builds"+tt.query, nil))
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseBuildFilter() error = %v", err)
+			}
+			if !filter.Since.Equal(tt.expected.Since) || !filter.Until.Equal(tt.expected.Until) {
+				t.Errorf("expected range %v - %v, got %v - %v", tt.expected.Since, tt.expected.Until, filter.Since, filter.Until)
+			}
+			filter.Since, filter.Until = tt.expected.Since, tt.expected.Until
+			if !reflect.DeepEqual(filter, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, filter)
+			}
+		})
+	}
+}
+
+func TestParseBuildSort(t *testing.T) {
+	tests := []struct {
+		query        string
+		expectedSort queries.BuildSort
+		expectedAsc  bool
+		wantErr      bool
+	}{
+		{query: "", expectedSort: queries.BuildSortCreated},
+		{query: "?sort=duration&order=asc", expectedSort: queries.BuildSortDuration, expectedAsc: true},
+		{query: "?sort=app&order=desc", expectedSort: queries.BuildSortApp},
+		{query: "?sort=commit", wantErr: true},
+		{query: "?order=up", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			sort, ascending, err := parseBuildSort(httptest.NewRequest("GET", "/api/builds"+tt.query, nil))
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseBuildSort() error = %v", err)
+			}
+			if sort != tt.expectedSort || ascending != tt.expectedAsc {
+				t.Errorf("expected (%s, %v), got (%s, %v)", tt.expectedSort, tt.expectedAsc, sort, ascending)
+			}
+		})
+	}
+}
+
+func TestStreamLogsResume(t *testing.T) {
+	db := newTestDB(t)
+
+	ctx := context.Background()
+	app := &models.App{
+		ID: "app1", Name: "web", RepoURL: "https://github.com/example/web.git", Branch: "main",
+		BuildStrategy: models.BuildStrategyDockerfile, CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}
+	if err := queries.NewAppQueries(db.DB).Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	buildQueries := queries.NewBuildQueries(db.DB)
+	build := &models.Build{
+		ID: "build1", AppID: app.ID, Status: models.BuildStatusSuccess, Trigger: models.TriggerManual,
+		CreatedAt: time.Now(),
+	}
+	if err := buildQueries.Create(ctx, build); err != nil {
+		t.Fatal(err)
+	}
+	logQueries := queries.NewLogQueries(db.DB)
+	for _, message := range []string{"one", "two", "three"} {
+		if err := logQueries.Append(ctx, &models.BuildLog{BuildID: build.ID, Level: models.LogLevelInfo, Message: message, Source: models.LogSourceDocker}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	router := chi.NewRouter()
+	router.Get("/api/builds/{buildID}/logs/stream", NewBuildHandler(buildQueries, logQueries).StreamLogs)
+
+	tests := []struct {
+		lastEventID string
+		expectedIDs []string
+	}{
+		{lastEventID: "", expectedIDs: []string{"1", "2", "3"}},
+		{lastEventID: "2", expectedIDs: []string{"3"}},
+		{lastEventID: "3", expectedIDs: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run("after "+tt.lastEventID, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/builds/build1/logs/stream", nil)
+			if tt.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tt.lastEventID)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			var ids []string
+			for _, line := range strings.Split(rec.Body.String(), "\n") {
+				if id, ok := strings.CutPrefix(line, "id: "); ok {
+					ids = append(ids, id)
+				}
+			}
+			if !reflect.DeepEqual(ids, tt.expectedIDs) {
+				t.Errorf("event IDs = %v, want %v", ids, tt.expectedIDs)
+			}
+			if !strings.Contains(rec.Body.String(), "event: complete") {
+				t.Error("expected a complete event")
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+const (
+	// bulkActionDeploy queues a build of each app in a bulk action
+	bulkActionDeploy = "deploy"
+
+	// bulkWorkers is how many apps a bulk action works on at once
+	bulkWorkers = 4
+)
+
+// BulkActionRequest is the request body for acting on several apps at once.
+// The apps are picked by ID or by tag.
+type BulkActionRequest struct {
+	AppIDs []string `json:"app_ids"`
+	Tag    string   `json:"tag"` // Every app with the tag
+}
+
+// BulkActionResult is how a bulk action went for one app
+type BulkActionResult struct {
+	AppID   string `json:"app_id"`
+	Name    string `json:"name,omitempty"`
+	Status  string `json:"status,omitempty"` // queued, waiting (on a dependency), started, stopped or restarted
+	BuildID string `json:"build_id,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkActionResponse is the response to a bulk action
+type BulkActionResponse struct {
+	Action    string             `json:"action"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Results   []BulkActionResult `json:"results"`
+}
+
+// Bulk handles POST /api/apps/bulk/{action} - deploys, starts, stops or
+// restarts each of a set of apps and reports how it went for each. Apps are
+// deployed and started after the apps they depend on, and stopped before.
+func (h *AppHandler) Bulk(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	action := chi.URLParam(r, "action")
+
+	if _, ok := containerStatuses[action]; !ok && action != bulkActionDeploy {
+		http.Error(w, "unknown action, use deploy, start, stop or restart", http.StatusBadRequest)
+		return
+	}
+	if action == bulkActionDeploy && h.orchestrator == nil {
+		http.Error(w, "build orchestrator not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req BulkActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Tag = strings.ToLower(strings.TrimSpace(req.Tag))
+	if (len(req.AppIDs) == 0) == (req.Tag == "") {
+		http.Error(w, "give either app_ids or tag", http.StatusBadRequest)
+		return
+	}
+
+	results, apps, err := h.bulkApps(ctx, req)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to look up apps for bulk action", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	// Results are filled in by app, in the order the apps were given
+	resultOf := make(map[*models.App]*BulkActionResult, len(apps))
+	for i, app := range apps {
+		if app != nil {
+			resultOf[app] = &results[i]
+		}
+	}
+
+	if action == bulkActionDeploy {
+		h.bulkDeploy(ctx, apps, resultOf)
+	} else {
+		for _, wave := range h.bulkWaves(ctx, apps, action) {
+			h.runBulkWave(ctx, wave, action, resultOf)
+		}
+	}
+
+	resp := BulkActionResponse{Action: action, Results: results}
+	for _, result := range results {
+		if result.Error != "" {
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+	}
+	slog.InfoContext(ctx, "bulk action finished", "action", action, "succeeded", resp.Succeeded, "failed", resp.Failed)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// bulkApps looks up the apps a bulk action is for, with a result for each.
+// Apps that weren't found are nil, with their results already failed.
+func (h *AppHandler) bulkApps(ctx context.Context, req BulkActionRequest) ([]BulkActionResult, []*models.App, error) {
+	if req.Tag != "" {
+		apps, err := h.appQueries.Search(ctx, queries.AppFilter{Tag: req.Tag})
+		if err != nil {
+			return nil, nil, err
+		}
+		results := make([]BulkActionResult, len(apps))
+		for i, app := range apps {
+			results[i] = BulkActionResult{AppID: app.ID, Name: app.Name}
+		}
+		return results, apps, nil
+	}
+
+	var results []BulkActionResult
+	var apps []*models.App
+	seen := make(map[string]bool)
+	for _, id := range req.AppIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		app, err := h.appQueries.GetByID(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if app == nil {
+			results = append(results, BulkActionResult{AppID: id, Error: "app not found"})
+		} else {
+			results = append(results, BulkActionResult{AppID: app.ID, Name: app.Name})
+		}
+		apps = append(apps, app)
+	}
+	return results, apps, nil
+}
+
+// bulkDeploy queues a build of each app, deploying apps after the apps they
+// depend on
+func (h *AppHandler) bulkDeploy(ctx context.Context, apps []*models.App, resultOf map[*models.App]*BulkActionResult) {
+	var ids []string
+	for _, app := range apps {
+		if app != nil {
+			ids = append(ids, app.ID)
+		}
+	}
+
+	graph, err := h.dependencyGraph(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "deploying without dependency order", "error", err)
+		graph = models.DependencyGraph{}
+	}
+	builds, waves, failed, err := h.orchestrator.TriggerOrderedBuilds(ctx, graph, ids)
+	if err != nil {
+		slog.WarnContext(ctx, "deploying without dependency order", "error", err)
+		// Without dependencies every app is in the first wave, which can't fail
+		builds, waves, failed, _ = h.orchestrator.TriggerOrderedBuilds(ctx, models.DependencyGraph{}, ids)
+	}
+
+	wave := make(map[string]int)
+	for i, waveIDs := range waves {
+		for _, id := range waveIDs {
+			wave[id] = i
+		}
+	}
+	for app, result := range resultOf {
+		if failed := failed[app.ID]; failed != nil {
+			slog.Warn("bulk deploy failed", "app", app.Name, "error", failed)
+			result.Error = failed.Error()
+			continue
+		}
+		result.BuildID = builds[app.ID].ID
+		result.Status = "queued"
+		if wave[app.ID] > 0 {
+			result.Status = "waiting"
+		}
+	}
+}
+
+// runBulkWave runs a container action on the apps of one wave at once
+func (h *AppHandler) runBulkWave(ctx context.Context, wave []*models.App, action string, resultOf map[*models.App]*BulkActionResult) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, bulkWorkers)
+	for _, app := range wave {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(result *BulkActionResult, app *models.App) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			h.runBulkAction(ctx, app, action, result)
+		}(resultOf[app], app)
+	}
+	wg.Wait()
+}
+
+// runBulkAction starts, stops or restarts one app and records how it went
+func (h *AppHandler) runBulkAction(ctx context.Context, app *models.App, action string, result *BulkActionResult) {
+	if err := h.controlContainer(ctx, app, action); err != nil {
+		slog.Warn("bulk container action failed", "app", app.Name, "action", action, "error", err)
+		result.Error = err.Error()
+		return
+	}
+	result.Status = containerStatuses[action]
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+func TestAppHandler_Bulk(t *testing.T) {
+	db := newTestDB(t)
+
+	ctx := context.Background()
+	appQueries := queries.NewAppQueries(db.DB)
+	for _, a := range []struct{ name, tags string }{{"api", "prod"}, {"web", "prod"}, {"docs", ""}} {
+		app := &models.App{
+			ID: a.name, Name: a.name, RepoURL: "https://github.com/example/" + a.name + ".git", Branch: "main",
+			BuildStrategy: models.BuildStrategyDockerfile, CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		}
+		if a.tags != "" {
+			if err := app.SetTags([]string{a.tags}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := appQueries.Create(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Without Docker every container action fails, so each app reports why
+	h := NewAppHandler(nil, appQueries, nil, nil, nil, nil, nil, nil)
+	router := chi.NewRouter()
+	router.Post("/api/apps/bulk/{action}", h.Bulk)
+
+	tests := []struct {
+		name       string
+		action     string
+		body       string
+		wantStatus int
+		wantApps   []string
+		wantErrors []string
+	}{
+		{
+			name: "by tag", action: "restart", body: `{"tag": "Prod"}`, wantStatus: http.StatusOK,
+			wantApps: []string{"api", "web"}, wantErrors: []string{"Docker client not available", "Docker client not available"},
+		},
+		{
+			name: "by ID", action: "stop", body: `{"app_ids": ["docs", "missing", "docs"]}`, wantStatus: http.StatusOK,
+			wantApps: []string{"docs", "missing"}, wantErrors: []string{"Docker client not available", "app not found"},
+		},
+		{name: "tag without apps", action: "start", body: `{"tag": "staging"}`, wantStatus: http.StatusOK},
+		{name: "unknown action", action: "delete", body: `{"tag": "prod"}`, wantStatus: http.StatusBadRequest},
+		{name: "no apps given", action: "restart", body: `{}`, wantStatus: http.StatusBadRequest},
+		{name: "IDs and tag", action: "restart", body: `{"app_ids": ["api"], "tag": "prod"}`, wantStatus: http.StatusBadRequest},
+		{name: "deploy without orchestrator", action: "deploy", body: `{"tag": "prod"}`, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/apps/bulk/"+tt.action, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var resp BulkActionResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Results) != len(tt.wantApps) {
+				t.Fatalf("got %d results, want %d: %+v", len(resp.Results), len(tt.wantApps), resp.Results)
+			}
+			for i, result := range resp.Results {
+				if result.AppID != tt.wantApps[i] || result.Error != tt.wantErrors[i] {
+					t.Errorf("result %d = %+v, want app %s failing with %q", i, result, tt.wantApps[i], tt.wantErrors[i])
+				}
+			}
+			if resp.Action != tt.action || resp.Failed != len(tt.wantErrors) || resp.Succeeded != 0 {
+				t.Errorf("response = %+v, want %d failed", resp, len(tt.wantErrors))
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/build"
+	"schooner/internal/database"
+	"schooner/internal/database/queries"
+	"schooner/internal/git"
+	"schooner/internal/models"
+)
+
+func TestAppHandler_ClearCheckout(t *testing.T) {
+	db := newTestDB(t)
+
+	// A Git server that holds the clone of the running build until released
+	cloning := make(chan struct{}, 1)
+	release := make(chan struct{})
+	gitServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case cloning <- struct{}{}:
+		default:
+		}
+		<-release
+		http.NotFound(w, r)
+	}))
+	defer gitServer.Close()
+
+	ctx := context.Background()
+	appQueries := queries.NewAppQueries(db.DB)
+	buildQueries := queries.NewBuildQueries(db.DB)
+	for _, app := range []*models.App{
+		{ID: "web", Name: "web", RepoURL: gitServer.URL + "/web.git", Branch: "main"},
+		{ID: "kuma", Name: "kuma", Template: database.NullString("uptime-kuma")},
+	} {
+		app.BuildStrategy = models.BuildStrategyDockerfile
+		app.CreatedAt, app.UpdatedAt = time.Now(), time.Now()
+		if err := appQueries.Create(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gitClient, err := git.NewClient(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	orchestrator := build.NewOrchestrator(gitClient, nil, appQueries, buildQueries, queries.NewLogQueries(db.DB))
+	orchestrator.Start(1)
+	defer orchestrator.Stop()
+
+	h := NewAppHandler(nil, appQueries, buildQueries, nil, nil, orchestrator, nil, nil)
+	router := chi.NewRouter()
+	router.Delete("/api/apps/{appID}/checkout", h.ClearCheckout)
+	clear := func(appID string) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/apps/"+appID+"/checkout", nil))
+		return rec.Code
+	}
+
+	if got := clear("missing"); got != http.StatusNotFound {
+		t.Errorf("ClearCheckout() of a missing app status = %d, want %d", got, http.StatusNotFound)
+	}
+	if got := clear("kuma"); got != http.StatusBadRequest {
+		t.Errorf("ClearCheckout() of a template app status = %d, want %d", got, http.StatusBadRequest)
+	}
+
+	running, err := orchestrator.TriggerManualBuild(ctx, "web")
+	if err != nil {
+		t.Fatalf("TriggerManualBuild() error = %v", err)
+	}
+	select {
+	case <-cloning:
+	case <-time.After(5 * time.Second):
+		t.Fatal("build never started cloning")
+	}
+	if got := clear("web"); got != http.StatusConflict {
+		t.Errorf("ClearCheckout() while building status = %d, want %d", got, http.StatusConflict)
+	}
+	close(release)
+
+	// Once the build failed to clone, the app is free again
+	deadline := time.Now().Add(5 * time.Second)
+	got := clear("web")
+	for got == http.StatusConflict && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		got = clear("web")
+	}
+	if got != http.StatusOK {
+		t.Errorf("ClearCheckout() after the build status = %d, want %d", got, http.StatusOK)
+	}
+	if b, err := buildQueries.GetByID(ctx, running.ID); err != nil || b.Status != models.BuildStatusFailed {
+		t.Errorf("build = %v, %v, want it failed to clone", b, err)
+	}
+}
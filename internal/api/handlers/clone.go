@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"schooner/internal/events"
+	"schooner/internal/models"
+)
+
+// AppCloneRequest is the request body for cloning an app
+type AppCloneRequest struct {
+	Name           string `json:"name"`
+	Subdomain      string `json:"subdomain"`       // Blank leaves the copy without a route
+	PublicPort     int    `json:"public_port"`     // Blank leaves the copy unpublished, two apps can't share a port
+	Branch         string `json:"branch"`          // Blank builds the same branch
+	IncludeSecrets bool   `json:"include_secrets"` // Copy env vars that look like secrets too
+}
+
+// AppCloneResponse is the app a clone created, with the env vars left out
+// of it for looking like secrets
+type AppCloneResponse struct {
+	App             *models.App `json:"app"`
+	ExcludedEnvVars []string    `json:"excluded_env_vars,omitempty"`
+}
+
+// Clone handles POST /api/apps/{appID}/clone - creates a new app with the
+// same repository and build settings, for a second environment of it
+func (h *AppHandler) Clone(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID := chi.URLParam(r, "appID")
+
+	source, err := h.appQueries.GetByID(ctx, appID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get app", "appID", appID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if source == nil {
+		http.Error(w, "app not found", http.StatusNotFound)
+		return
+	}
+
+	var req AppCloneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	if req.PublicPort < 0 || req.PublicPort > 65535 {
+		http.Error(w, "port must be between 1 and 65535", http.StatusBadRequest)
+		return
+	}
+	existing, err := h.appQueries.GetByName(ctx, req.Name)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get app", "name", req.Name, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if existing != nil {
+		http.Error(w, "an app named "+req.Name+" already exists", http.StatusConflict)
+		return
+	}
+
+	app, excluded := cloneApp(source, req)
+	if err := app.SaveEnvVars(); err != nil {
+		slog.ErrorContext(ctx, "failed to save env vars", "error", err)
+		http.Error(w, "failed to save env vars", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.appQueries.Create(ctx, app); err != nil {
+		if portConflict(w, err) {
+			return
+		}
+		slog.ErrorContext(ctx, "failed to clone app", "source", source.Name, "error", err)
+		http.Error(w, "failed to create app: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if h.proxyRouter != nil && h.proxyRouter.IsConfigured() && app.GetSubdomain() != "" && app.GetPublicPort() != 0 {
+		if err := h.proxyRouter.Reload(ctx); err != nil {
+			slog.WarnContext(ctx, "failed to reload proxy routes", "app", app.Name, "error", err)
+		}
+	}
+
+	if h.githubClient != nil && h.githubClient.HasToken() && strings.Contains(app.RepoURL, "github.com") {
+		h.installWebhook(ctx, app)
+	}
+
+	slog.InfoContext(ctx, "app cloned", "id", app.ID, "name", app.Name, "source", source.Name, "excludedEnvVars", len(excluded))
+	h.events.Publish(events.Event{Kind: events.KindApp, AppID: app.ID, Status: events.AppCreated})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(AppCloneResponse{App: app, ExcludedEnvVars: excluded})
+}
+
+// cloneApp copies an app's settings under the request's name and route.
+// The copy gets its own container, image and webhook secret, and env vars
+// that look like secrets are left out unless the request includes them.
+func cloneApp(source *models.App, req AppCloneRequest) (*models.App, []string) {
+	app := *source
+	app.ID = uuid.New().String()
+	app.Name = req.Name
+	app.WebhookSecret = sql.NullString{}
+	app.ContainerName = sql.NullString{}
+	app.ImageName = sql.NullString{}
+
+	subdomain := strings.TrimSpace(req.Subdomain)
+	app.Subdomain = sql.NullString{String: subdomain, Valid: subdomain != ""}
+	app.PublicPort = sql.NullInt64{Int64: int64(req.PublicPort), Valid: req.PublicPort > 0}
+	if branch := strings.TrimSpace(req.Branch); branch != "" {
+		app.Branch = branch
+	}
+
+	var excluded []string
+	app.EnvVars = make(map[string]string, len(source.EnvVars))
+	for name, value := range source.EnvVars {
+		if !req.IncludeSecrets && models.IsSecretEnvVar(name) {
+			excluded = append(excluded, name)
+			continue
+		}
+		app.EnvVars[name] = value
+	}
+	sort.Strings(excluded)
+
+	app.CreatedAt = time.Now()
+	app.UpdatedAt = time.Now()
+	return &app, excluded
+}
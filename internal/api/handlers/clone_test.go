@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+func TestAppHandler_Clone(t *testing.T) {
+	db := newTestDB(t)
+
+	ctx := context.Background()
+	appQueries := queries.NewAppQueries(db.DB)
+	source := &models.App{
+		ID: "api", Name: "api", RepoURL: "https://example.com/api.git", Branch: "main",
+		BuildStrategy: models.BuildStrategyCompose, ComposeFile: "compose.yaml",
+		ContainerName: sql.NullString{String: "api-prod", Valid: true},
+		WebhookSecret: sql.NullString{String: "hook", Valid: true},
+		Subdomain:     sql.NullString{String: "api", Valid: true},
+		PublicPort:    sql.NullInt64{Int64: 8080, Valid: true},
+		EnvVars:       map[string]string{"LOG_LEVEL": "info", "DB_PASSWORD": "hunter2"},
+		Project:       sql.NullString{String: "shop", Valid: true},
+		CreatedAt:     time.Now(), UpdatedAt: time.Now(),
+	}
+	if err := source.SaveEnvVars(); err != nil {
+		t.Fatal(err)
+	}
+	if err := appQueries.Create(ctx, source); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewAppHandler(nil, appQueries, nil, nil, nil, nil, nil, nil)
+	router := chi.NewRouter()
+	router.Post("/api/apps/{appID}/clone", h.Clone)
+
+	tests := []struct {
+		name         string
+		appID        string
+		body         string
+		wantStatus   int
+		wantEnv      map[string]string
+		wantExcluded []string
+	}{
+		{
+			name: "without secrets", appID: "api", body: `{"name": "api-staging", "subdomain": "api-staging", "public_port": 8081, "branch": "develop"}`,
+			wantStatus: http.StatusCreated, wantEnv: map[string]string{"LOG_LEVEL": "info"}, wantExcluded: []string{"DB_PASSWORD"},
+		},
+		{
+			name: "with secrets", appID: "api", body: `{"name": "api-dev", "include_secrets": true}`,
+			wantStatus: http.StatusCreated, wantEnv: map[string]string{"LOG_LEVEL": "info", "DB_PASSWORD": "hunter2"},
+		},
+		{name: "name taken", appID: "api", body: `{"name": "api"}`, wantStatus: http.StatusConflict},
+		{name: "port taken", appID: "api", body: `{"name": "api-copy", "public_port": 8080}`, wantStatus: http.StatusConflict},
+		{name: "no name", appID: "api", body: `{"name": " "}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown app", appID: "missing", body: `{"name": "copy"}`, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/apps/"+tt.appID+"/clone", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if rec.Code != http.StatusCreated {
+				return
+			}
+
+			var resp AppCloneResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(resp.ExcludedEnvVars, ",") != strings.Join(tt.wantExcluded, ",") {
+				t.Errorf("excluded env vars = %v, want %v", resp.ExcludedEnvVars, tt.wantExcluded)
+			}
+
+			clone, err := appQueries.GetByID(ctx, resp.App.ID)
+			if err != nil || clone == nil {
+				t.Fatalf("GetByID() = %v, %v", clone, err)
+			}
+			if clone.ID == source.ID || clone.RepoURL != source.RepoURL || clone.ComposeFile != "compose.yaml" || clone.GetProject() != "shop" {
+				t.Errorf("clone = %+v, want the source's repository and build settings", clone)
+			}
+			if clone.GetContainerName() != clone.Name || clone.GetWebhookSecret() != "" {
+				t.Errorf("clone container %q and webhook secret %q, want its own", clone.GetContainerName(), clone.GetWebhookSecret())
+			}
+			if len(clone.EnvVars) != len(tt.wantEnv) {
+				t.Errorf("env vars = %v, want %v", clone.EnvVars, tt.wantEnv)
+			}
+			for k, v := range tt.wantEnv {
+				if clone.EnvVars[k] != v {
+					t.Errorf("env var %s = %q, want %q", k, clone.EnvVars[k], v)
+				}
+			}
+		})
+	}
+
+	staging, err := appQueries.GetByName(ctx, "api-staging")
+	if err != nil || staging == nil {
+		t.Fatalf("GetByName() = %v, %v", staging, err)
+	}
+	if staging.Branch != "develop" || staging.GetSubdomain() != "api-staging" || staging.GetPublicPort() != 8081 {
+		t.Errorf("staging = %+v, want the requested branch and route", staging)
+	}
+}
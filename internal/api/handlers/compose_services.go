@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/docker/docker/api/types"
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/build"
+	"schooner/internal/build/strategies"
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+// composeServiceLabel is the label docker compose puts the service name in
+const composeServiceLabel = "com.docker.compose.service"
+
+// ComposeServiceStatus is a compose app's service and its container
+type ComposeServiceStatus struct {
+	Name        string   `json:"name"`
+	Image       string   `json:"image"`
+	Ports       []string `json:"ports"`
+	Profiles    []string `json:"profiles,omitempty"`
+	ContainerID string   `json:"container_id,omitempty"`
+	State       string   `json:"state"`
+	Status      string   `json:"status,omitempty"`
+}
+
+// composeServices returns the services of a compose app: those declared in
+// its compose file and any other container labelled with the app, e.g. from
+// a service since removed from the file
+func (h *AppHandler) composeServices(ctx context.Context, app *models.App, dockerClient *docker.Client) ([]ComposeServiceStatus, error) {
+	containers, err := dockerClient.ListContainers(ctx, true, map[string]string{"schooner.app-id": app.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	// The checkout is gone until the next build after it's cleared
+	declared, err := strategies.ComposeServices(build.CheckoutPath(h.cfg.Git.WorkDir, app), app.ComposeFile)
+	if err != nil {
+		slog.Debug("failed to read compose services", "app", app.Name, "error", err)
+	}
+
+	return mergeComposeServices(declared, containers, app.GetComposeProfiles()), nil
+}
+
+// mergeComposeServices pairs declared services with their containers.
+// Services whose profiles aren't enabled aren't deployed.
+func mergeComposeServices(declared []strategies.ComposeService, containers []types.Container, profiles []string) []ComposeServiceStatus {
+	byService := make(map[string]types.Container)
+	for _, c := range containers {
+		if name := c.Labels[composeServiceLabel]; name != "" {
+			byService[name] = c
+		}
+	}
+
+	services := make([]ComposeServiceStatus, 0, len(declared))
+	seen := make(map[string]bool)
+	for _, d := range declared {
+		service := ComposeServiceStatus{Name: d.Name, Image: d.Image, Ports: d.Ports, Profiles: d.Profiles, State: "not created"}
+		if !d.Enabled(profiles) {
+			service.State = "profile disabled"
+		}
+		if c, ok := byService[d.Name]; ok {
+			service.withContainer(c)
+		}
+		services = append(services, service)
+		seen[d.Name] = true
+	}
+
+	for _, c := range containers {
+		name := c.Labels[composeServiceLabel]
+		if name == "" || seen[name] {
+			continue
+		}
+		service := ComposeServiceStatus{Name: name}
+		service.withContainer(c)
+		services = append(services, service)
+		seen[name] = true
+	}
+
+	return services
+}
+
+// withContainer fills in a service's details from its running container
+func (s *ComposeServiceStatus) withContainer(c types.Container) {
+	s.ContainerID = c.ID
+	s.Image = c.Image
+	s.State = c.State
+	s.Status = c.Status
+
+	var ports []string
+	for _, p := range c.Ports {
+		if p.PublicPort > 0 {
+			ports = append(ports, fmt.Sprintf("%d:%d", p.PublicPort, p.PrivatePort))
+		}
+	}
+	if len(ports) > 0 {
+		s.Ports = ports
+	}
+}
+
+// composeAppDocker loads a compose app and the Docker client for its host,
+// writing an error response and returning nil if either fails
+func (h *AppHandler) composeAppDocker(w http.ResponseWriter, r *http.Request) (*models.App, *docker.Client) {
+	ctx := r.Context()
+	appID := chi.URLParam(r, "appID")
+
+	app, err := h.appQueries.GetByID(ctx, appID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get app", "appID", appID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return nil, nil
+	}
+	if app == nil {
+		http.Error(w, "app not found", http.StatusNotFound)
+		return nil, nil
+	}
+	if app.BuildStrategy != models.BuildStrategyCompose {
+		http.Error(w, "only compose apps have services", http.StatusBadRequest)
+		return nil, nil
+	}
+
+	dockerClient, err := h.dockerFor(ctx, app)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to connect to Docker host", "app", app.Name, "error", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return nil, nil
+	}
+	if dockerClient == nil {
+		http.Error(w, "Docker client not available", http.StatusServiceUnavailable)
+		return nil, nil
+	}
+
+	return app, dockerClient
+}
+
+// findComposeService returns the named service of a compose app, writing a
+// not found response and returning nil if there is none
+func findComposeService(w http.ResponseWriter, services []ComposeServiceStatus, name string) *ComposeServiceStatus {
+	for i := range services {
+		if services[i].Name == name {
+			return &services[i]
+		}
+	}
+	http.Error(w, "service not found", http.StatusNotFound)
+	return nil
+}
+
+// serviceContainer finds the container of a compose app's service, writing a
+// not found response and returning "" if it has none
+func serviceContainer(w http.ResponseWriter, services []ComposeServiceStatus, name string) string {
+	for _, service := range services {
+		if service.Name == name && service.ContainerID != "" {
+			return service.ContainerID
+		}
+	}
+	http.Error(w, "service has no container, deploy the app first", http.StatusNotFound)
+	return ""
+}
+
+// Services handles GET /api/apps/{appID}/services
+func (h *AppHandler) Services(w http.ResponseWriter, r *http.Request) {
+	app, dockerClient := h.composeAppDocker(w, r)
+	if app == nil {
+		return
+	}
+
+	services, err := h.composeServices(r.Context(), app, dockerClient)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to get compose services", "app", app.Name, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(services)
+}
+
+// ServiceAction handles POST /api/apps/{appID}/services/{service}/{action},
+// where action is start, stop or restart. It runs docker compose on the one
+// service, so the rest of the stack keeps running.
+func (h *AppHandler) ServiceAction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := chi.URLParam(r, "service")
+	action := chi.URLParam(r, "action")
+	if action != "start" && action != "stop" && action != "restart" {
+		http.Error(w, "unknown action, use start, stop or restart", http.StatusBadRequest)
+		return
+	}
+	defer h.statuses.Forget(chi.URLParam(r, "appID"))
+
+	app, dockerClient := h.composeAppDocker(w, r)
+	if app == nil {
+		return
+	}
+
+	services, err := h.composeServices(ctx, app, dockerClient)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get compose services", "app", app.Name, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	service := findComposeService(w, services, name)
+	if service == nil {
+		return
+	}
+
+	// A service without a container, e.g. one added since the last deploy,
+	// has to be created to be started
+	args := []string{action, name}
+	if action == "start" && service.ContainerID == "" {
+		args = []string{"up", "-d", "--no-deps", name}
+	}
+	if err := h.runComposeCommand(ctx, app, dockerClient, args...); err != nil {
+		slog.ErrorContext(ctx, "compose service action failed", "app", app.Name, "service", name, "action", action, "error", err)
+		http.Error(w, "failed to "+action+" service: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "compose service "+action, "app", app.Name, "service", name)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  action,
+		"message": fmt.Sprintf("Service %s: %s done", name, action),
+	})
+}
+
+// runComposeCommand runs docker compose in a compose app's checkout
+func (h *AppHandler) runComposeCommand(ctx context.Context, app *models.App, dockerClient *docker.Client, args ...string) error {
+	repoPath := build.CheckoutPath(h.cfg.Git.WorkDir, app)
+	fileArgs, err := strategies.ComposeFileArgs(repoPath, app.ComposeFile)
+	if err != nil {
+		return err
+	}
+
+	composeArgs := append(append([]string{"compose"}, fileArgs...), strategies.ProfileArgs(app.GetComposeProfiles())...)
+	cmd := exec.CommandContext(ctx, "docker", append(composeArgs, args...)...)
+	cmd.Dir = repoPath
+	// Same environment as the deploy, for variables the compose file uses
+	cmd.Env = os.Environ()
+	for k, v := range app.EnvVars {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Env = append(cmd.Env, dockerClient.Env()...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("docker compose %s failed: %w, output: %s", args[0], err, string(output))
+	}
+	return nil
+}
+
+// ServiceLogs handles GET /api/apps/{appID}/services/{service}/logs - the
+// last lines (?tail=, default 200) of a service's container output
+func (h *AppHandler) ServiceLogs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := chi.URLParam(r, "service")
+
+	tail := "200"
+	if n, err := strconv.Atoi(r.URL.Query().Get("tail")); err == nil && n > 0 {
+		tail = strconv.Itoa(n)
+	}
+
+	app, dockerClient := h.composeAppDocker(w, r)
+	if app == nil {
+		return
+	}
+
+	services, err := h.composeServices(ctx, app, dockerClient)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get compose services", "app", app.Name, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	containerID := serviceContainer(w, services, name)
+	if containerID == "" {
+		return
+	}
+
+	logs, err := dockerClient.ReadContainerLogs(ctx, containerID, tail)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to read service logs", "app", app.Name, "service", name, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(logs)
+}
+
+// renderComposeServices lists a compose app's services on its page, each
+// with its own actions
+func (h *PageHandler) renderComposeServices(w http.ResponseWriter, app *models.App) {
+	if app.BuildStrategy != models.BuildStrategyCompose {
+		return
+	}
+	renderTemplate(w, "compose-services", app)
+}
+
+// renderDeployPreview renders the panel showing what deploying a compose app
+// would change
+func (h *PageHandler) renderDeployPreview(w http.ResponseWriter, app *models.App) {
+	if app.BuildStrategy != models.BuildStrategyCompose {
+		return
+	}
+	renderTemplate(w, "deploy-preview", app)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+
+	"schooner/internal/build/strategies"
+)
+
+func TestMergeComposeServices(t *testing.T) {
+	declared := []strategies.ComposeService{
+		{Name: "db", Image: "postgres:16"},
+		{Name: "web", Ports: []string{"8080:80"}},
+		{Name: "debug", Image: "busybox", Profiles: []string{"debug"}},
+		{Name: "worker", Image: "app-worker", Profiles: []string{"workers"}},
+	}
+	containers := []types.Container{
+		{
+			ID:     "abc",
+			Image:  "myapp-web",
+			State:  "running",
+			Status: "Up 2 hours",
+			Labels: map[string]string{composeServiceLabel: "web"},
+			Ports:  []types.Port{{PrivatePort: 80, PublicPort: 8080}, {PrivatePort: 9000}},
+		},
+		{
+			ID:     "def",
+			Image:  "myapp-old",
+			State:  "exited",
+			Labels: map[string]string{composeServiceLabel: "old"},
+		},
+	}
+
+	got := mergeComposeServices(declared, containers, []string{"workers"})
+	want := []ComposeServiceStatus{
+		{Name: "db", Image: "postgres:16", State: "not created"},
+		{Name: "web", Image: "myapp-web", Ports: []string{"8080:80"}, ContainerID: "abc", State: "running", Status: "Up 2 hours"},
+		{Name: "debug", Image: "busybox", Profiles: []string{"debug"}, State: "profile disabled"},
+		{Name: "worker", Image: "app-worker", Profiles: []string{"workers"}, State: "not created"},
+		{Name: "old", Image: "myapp-old", ContainerID: "def", State: "exited"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeComposeServices() =\n%+v\nwant\n%+v", got, want)
+	}
+}
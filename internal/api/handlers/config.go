@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"schooner/internal/config"
+)
+
+// ConfigHandler handles reloading the config file
+type ConfigHandler struct {
+	cfg *config.Config
+}
+
+// NewConfigHandler creates a new ConfigHandler
+func NewConfigHandler(cfg *config.Config) *ConfigHandler {
+	return &ConfigHandler{cfg: cfg}
+}
+
+// Reload handles POST /api/settings/config/reload - re-reads the config file
+// and applies the settings that can change without a restart
+func (h *ConfigHandler) Reload(w http.ResponseWriter, r *http.Request) {
+	result, err := h.cfg.Reload()
+	if err != nil {
+		slog.WarnContext(r.Context(), "failed to reload config", "file", h.cfg.File(), "error", err)
+		http.Error(w, "config not reloaded: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	slog.InfoContext(r.Context(), "reloaded config", "file", h.cfg.File(),
+		"changed", result.Changed, "restart_required", result.RestartRequired)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
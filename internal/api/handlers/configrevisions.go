@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/build"
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+// configHistoryLimit is how many config revisions the history returns by
+// default
+const configHistoryLimit = 20
+
+// ConfigRevisionView is a config revision with what changed from the one
+// before it
+type ConfigRevisionView struct {
+	*models.ConfigRevision
+	Deployed bool                  `json:"deployed"` // The running deployment uses this revision
+	Changes  []models.ConfigChange `json:"changes"`
+}
+
+// ConfigHistory is an app's config revisions and which one is deployed
+type ConfigHistory struct {
+	Current   int                   `json:"current"`
+	Deployed  int                   `json:"deployed"`
+	Changed   bool                  `json:"changed"`           // The configuration changed since the last deploy
+	Pending   []models.ConfigChange `json:"pending,omitempty"` // What the next deploy changes
+	Revisions []ConfigRevisionView  `json:"revisions"`
+}
+
+// SetConfigRevisions keeps a history of each app's configuration changes
+func (h *AppHandler) SetConfigRevisions(revisions *queries.ConfigRevisionQueries) {
+	h.configRevisions = revisions
+}
+
+// recordConfigRevision saves the app's configuration as a new revision when
+// the update changed it
+func (h *AppHandler) recordConfigRevision(ctx context.Context, app *models.App, before map[string]string, changedBy string) {
+	if h.configRevisions == nil {
+		return
+	}
+	after := app.ConfigSnapshot()
+	if maps.Equal(before, after) {
+		return
+	}
+
+	// Apps changed for the first time start their history with the
+	// configuration they had, which is what's running if they were deployed
+	if app.ConfigRevision == 0 {
+		baseline, err := h.configRevisions.Record(ctx, app.ID, before, "")
+		if err != nil {
+			slog.WarnContext(ctx, "failed to record config revision", "app", app.Name, "error", err)
+			return
+		}
+		if deployed, _ := h.buildQueries.GetLatestSuccessfulByAppID(ctx, app.ID); deployed != nil {
+			if err := h.configRevisions.MarkDeployed(ctx, app.ID, baseline.Revision); err == nil {
+				app.DeployedConfigRevision = baseline.Revision
+			}
+		}
+	}
+
+	rev, err := h.configRevisions.Record(ctx, app.ID, after, changedBy)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to record config revision", "app", app.Name, "error", err)
+		return
+	}
+	app.ConfigRevision = rev.Revision
+	slog.InfoContext(ctx, "app config changed", "app", app.Name, "revision", rev.Revision, "changes", len(models.DiffConfig(before, after)))
+}
+
+// ConfigHistory handles GET /api/apps/{appID}/config/revisions - the app's
+// config revisions, newest first, each with what it changed. ?limit= sets
+// how many are returned.
+func (h *AppHandler) ConfigHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID := chi.URLParam(r, "appID")
+
+	limit := configHistoryLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	app, err := h.appQueries.GetByID(ctx, appID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get app", "appID", appID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if app == nil {
+		http.Error(w, "app not found", http.StatusNotFound)
+		return
+	}
+
+	if h.configRevisions == nil {
+		http.Error(w, "config history not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	// One more than shown, to diff the oldest against
+	revisions, err := h.configRevisions.ListByAppID(ctx, appID, limit+1)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list config revisions", "appID", appID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	history, err := newConfigHistory(app, revisions, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to read config revisions", "appID", appID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// newConfigHistory diffs each revision, newest first, against the one
+// before it, and what's deployed against the current configuration
+func newConfigHistory(app *models.App, revisions []*models.ConfigRevision, limit int) (*ConfigHistory, error) {
+	history := &ConfigHistory{
+		Current:   app.ConfigRevision,
+		Deployed:  app.DeployedConfigRevision,
+		Changed:   app.ConfigChanged(),
+		Revisions: []ConfigRevisionView{},
+	}
+
+	snapshots := make([]map[string]string, len(revisions))
+	for i, rev := range revisions {
+		snapshot, err := rev.GetSnapshot()
+		if err != nil {
+			return nil, fmt.Errorf("revision %d: %w", rev.Revision, err)
+		}
+		snapshots[i] = snapshot
+	}
+
+	for i, rev := range revisions {
+		if i == limit {
+			break
+		}
+		var previous map[string]string
+		if i+1 < len(revisions) {
+			previous = snapshots[i+1]
+		}
+		// The first revision is where the history starts, not a change
+		var changes []models.ConfigChange
+		if rev.Revision > 1 {
+			changes = models.DiffConfig(previous, snapshots[i])
+		}
+		history.Revisions = append(history.Revisions, ConfigRevisionView{
+			ConfigRevision: rev,
+			Deployed:       rev.Revision == app.DeployedConfigRevision,
+			Changes:        changes,
+		})
+	}
+
+	if history.Changed {
+		for i, rev := range revisions {
+			if rev.Revision == app.DeployedConfigRevision {
+				history.Pending = models.DiffConfig(snapshots[i], app.ConfigSnapshot())
+				break
+			}
+		}
+	}
+
+	return history, nil
+}
+
+// ApplyConfig handles POST /api/apps/{appID}/config/apply - redeploys the
+// commit the app is running with its current configuration, restarting it
+func (h *AppHandler) ApplyConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID := chi.URLParam(r, "appID")
+
+	app, err := h.appQueries.GetByID(ctx, appID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get app", "appID", appID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if app == nil {
+		http.Error(w, "app not found", http.StatusNotFound)
+		return
+	}
+
+	if h.orchestrator == nil {
+		http.Error(w, "build orchestrator not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	b, err := h.orchestrator.ApplyConfig(ctx, app)
+	if err != nil {
+		if errors.Is(err, build.ErrNotDeployed) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		slog.ErrorContext(ctx, "failed to apply config", "appID", appID, "error", err)
+		http.Error(w, "failed to apply config: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "config apply queued", "appID", appID, "buildID", b.ID, "revision", app.ConfigRevision)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":   "queued",
+		"build_id": b.ID,
+		"message":  "Redeploying with the current configuration",
+	})
+}
+
+// renderConfigRevisions renders the app's configuration history, with a
+// notice and an apply button while it has changes that aren't deployed
+func (h *PageHandler) renderConfigRevisions(w http.ResponseWriter, app *models.App) {
+	if app.ConfigRevision == 0 {
+		return
+	}
+	renderTemplate(w, "config-revisions", app)
+}
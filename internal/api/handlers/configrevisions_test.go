@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+func TestConfigHistory(t *testing.T) {
+	db := newTestDB(t)
+
+	ctx := context.Background()
+	appQueries := queries.NewAppQueries(db.DB)
+	buildQueries := queries.NewBuildQueries(db.DB)
+	app := &models.App{
+		ID: "api", Name: "api", RepoURL: "https://github.com/example/api.git", Branch: "main",
+		BuildStrategy: models.BuildStrategyDockerfile, CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}
+	if err := appQueries.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	build := &models.Build{ID: "b1", AppID: "api", Status: models.BuildStatusSuccess, Trigger: models.TriggerManual, CreatedAt: time.Now()}
+	if err := buildQueries.Create(ctx, build); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewAppHandler(nil, appQueries, buildQueries, nil, nil, nil, nil, nil)
+	h.SetConfigRevisions(queries.NewConfigRevisionQueries(db.DB))
+
+	r := chi.NewRouter()
+	r.Put("/api/apps/{appID}", h.Update)
+	r.Get("/api/apps/{appID}/config/revisions", h.ConfigHistory)
+
+	update := func(body string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/apps/api", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Update() = %d %s", rec.Code, rec.Body)
+		}
+	}
+	update(`{"enabled":true,"env_vars":{"PORT":"8080"}}`)
+	update(`{"enabled":true,"env_vars":{"PORT":"8080"}}`) // No change, no revision
+	update(`{"enabled":true,"env_vars":{"PORT":"9090","DB_PASSWORD":"hunter2"}}`)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/apps/api/config/revisions", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ConfigHistory() = %d %s", rec.Code, rec.Body)
+	}
+	var history ConfigHistory
+	if err := json.NewDecoder(rec.Body).Decode(&history); err != nil {
+		t.Fatal(err)
+	}
+
+	// The app was deployed before its first change, so that's revision 1
+	if history.Current != 3 || history.Deployed != 1 || !history.Changed {
+		t.Errorf("current %d, deployed %d, changed %v, want 3, 1 and changed", history.Current, history.Deployed, history.Changed)
+	}
+	if len(history.Revisions) != 3 || history.Revisions[0].Revision != 3 || !history.Revisions[2].Deployed {
+		t.Fatalf("revisions = %+v, want 3 newest first", history.Revisions)
+	}
+	latest := history.Revisions[0].Changes
+	if len(latest) != 2 || latest[0].Key != "env.DB_PASSWORD" || latest[0].New == "hunter2" || latest[1].Old != "8080" || latest[1].New != "9090" {
+		t.Errorf("latest changes = %+v, want the password added masked and PORT changed", latest)
+	}
+	if len(history.Pending) != 2 {
+		t.Errorf("pending = %+v, want both env vars", history.Pending)
+	}
+
+	app, err := appQueries.GetByID(ctx, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !app.ConfigChanged() {
+		t.Error("app not marked as changed since its deploy")
+	}
+}
+
+func TestRenderConfigRevisions(t *testing.T) {
+	h := &PageHandler{}
+
+	rec := httptest.NewRecorder()
+	h.renderConfigRevisions(rec, &models.App{ID: "api"})
+	if rec.Body.Len() != 0 {
+		t.Errorf("an app that was never changed shows its history:\n%s", rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.renderConfigRevisions(rec, &models.App{ID: "api", ConfigRevision: 3, DeployedConfigRevision: 2})
+	for _, want := range []string{
+		`id="config-section" data-app-id="api"`, "Revision 2 is running, revision 3 takes effect",
+		`id="apply-config-button"`, "/static/js/config-revisions.js",
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("history is missing %s:\n%s", want, rec.Body)
+		}
+	}
+}
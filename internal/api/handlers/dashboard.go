@@ -0,0 +1,499 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/docker"
+	"schooner/internal/models"
+	"schooner/internal/notify"
+)
+
+// Container statuses are looked up a few at a time, each with a deadline so
+// an unreachable Docker host doesn't hold up the dashboard, and reused for
+// a few seconds across page loads
+const (
+	statusLookupWorkers = 8
+	statusLookupTimeout = 3 * time.Second
+	statusCacheTTL      = 5 * time.Second
+)
+
+// ContainerStatusCache keeps the container status of apps for a short
+// while. A nil cache looks every status up.
+type ContainerStatusCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedContainerStatus
+}
+
+type cachedContainerStatus struct {
+	status  *docker.ContainerStatus
+	fetched time.Time
+}
+
+// NewContainerStatusCache creates an empty ContainerStatusCache
+func NewContainerStatusCache() *ContainerStatusCache {
+	return &ContainerStatusCache{entries: make(map[string]cachedContainerStatus)}
+}
+
+// Forget drops the cached status of an app whose container changed
+func (c *ContainerStatusCache) Forget(appID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	delete(c.entries, appID)
+	c.mu.Unlock()
+}
+
+// lookup returns the container status of each app by app ID, fetching
+// those not cached in parallel
+func (c *ContainerStatusCache) lookup(ctx context.Context, apps []*models.App, fetch func(context.Context, *models.App) *docker.ContainerStatus) map[string]*docker.ContainerStatus {
+	statuses := make(map[string]*docker.ContainerStatus, len(apps))
+	missing := apps
+
+	if c != nil {
+		missing = nil
+		c.mu.Lock()
+		for _, app := range apps {
+			if entry, ok := c.entries[app.ID]; ok && time.Since(entry.fetched) < statusCacheTTL {
+				statuses[app.ID] = entry.status
+			} else {
+				missing = append(missing, app)
+			}
+		}
+		c.mu.Unlock()
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, statusLookupWorkers)
+	for _, app := range missing {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(app *models.App) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			lookupCtx, cancel := context.WithTimeout(ctx, statusLookupTimeout)
+			defer cancel()
+			status := fetch(lookupCtx, app)
+
+			mu.Lock()
+			statuses[app.ID] = status
+			mu.Unlock()
+		}(app)
+	}
+	wg.Wait()
+
+	if c == nil {
+		return statuses
+	}
+	c.mu.Lock()
+	now := time.Now()
+	for _, app := range missing {
+		c.entries[app.ID] = cachedContainerStatus{status: statuses[app.ID], fetched: now}
+	}
+	for id, entry := range c.entries {
+		if now.Sub(entry.fetched) >= statusCacheTTL {
+			delete(c.entries, id)
+		}
+	}
+	c.mu.Unlock()
+
+	return statuses
+}
+
+// DashboardApp is an app on the dashboard with its latest build and how
+// it's running
+type DashboardApp struct {
+	App         *models.App             `json:"app"`
+	LatestBuild *models.Build           `json:"latest_build,omitempty"`
+	Container   *docker.ContainerStatus `json:"container,omitempty"`
+	Uptime      *models.UptimeCheck     `json:"uptime,omitempty"`
+	Health      *models.ContainerHealth `json:"health,omitempty"`     // How its container's health check behaved
+	CrashLoop   *notify.CrashLoop       `json:"crash_loop,omitempty"` // Set while its containers keep dying
+}
+
+// Dashboard is everything the dashboard shows about apps and builds
+type Dashboard struct {
+	Apps         []DashboardApp  `json:"apps"`
+	RecentBuilds []*models.Build `json:"recent_builds"`
+	Tags         []string        `json:"tags"` // Every tag in use, to filter by
+}
+
+// loadDashboard gathers the dashboard's data for the apps matching filter
+// with one query per kind of record and the container lookups running in
+// parallel
+func (h *PageHandler) loadDashboard(ctx context.Context, filter queries.AppFilter) (*Dashboard, error) {
+	apps, err := h.appQueries.Search(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Containers are looked up while the database is read
+	var statuses map[string]*docker.ContainerStatus
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		statuses = h.statuses.lookup(ctx, apps, h.appContainerStatus)
+	}()
+
+	latest, err := h.buildQueries.ListLatestPerApp(ctx)
+	if err != nil {
+		slog.Error("failed to list latest builds", "error", err)
+	}
+	uptimeChecks := make(map[string]*models.UptimeCheck)
+	if checks, err := h.uptimeQueries.List(ctx); err == nil {
+		for _, check := range checks {
+			uptimeChecks[check.AppID] = check
+		}
+	}
+	health := make(map[string]*models.ContainerHealth)
+	if h.healthQueries != nil {
+		if records, err := h.healthQueries.List(ctx); err == nil {
+			for _, record := range records {
+				health[record.AppID] = record
+			}
+		}
+	}
+	recent, err := h.buildQueries.ListRecent(ctx, 10)
+	if err != nil {
+		slog.Error("failed to list builds", "error", err)
+	}
+	tags, err := h.appQueries.ListTags(ctx)
+	if err != nil {
+		slog.Error("failed to list tags", "error", err)
+	}
+
+	var crashLoops map[string]notify.CrashLoop
+	if h.crashLoops != nil {
+		crashLoops = h.crashLoops.CrashLoops()
+	}
+
+	<-done
+
+	dashboard := &Dashboard{
+		Apps:         make([]DashboardApp, 0, len(apps)),
+		RecentBuilds: recent,
+		Tags:         tags,
+	}
+	if dashboard.RecentBuilds == nil {
+		dashboard.RecentBuilds = []*models.Build{}
+	}
+	if dashboard.Tags == nil {
+		dashboard.Tags = []string{}
+	}
+	for _, app := range apps {
+		dashboard.Apps = append(dashboard.Apps, DashboardApp{
+			App:         app,
+			LatestBuild: latest[app.ID],
+			Container:   statuses[app.ID],
+			Uptime:      uptimeChecks[app.ID],
+			Health:      health[app.ID],
+		})
+		if loop, ok := crashLoops[app.ID]; ok {
+			dashboard.Apps[len(dashboard.Apps)-1].CrashLoop = &loop
+		}
+	}
+	return dashboard, nil
+}
+
+// DashboardSummary handles GET /api/dashboard, with its apps optionally
+// filtered like GET /api/apps
+func (h *PageHandler) DashboardSummary(w http.ResponseWriter, r *http.Request) {
+	dashboard, err := h.loadDashboard(r.Context(), parseAppFilter(r))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to load dashboard", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dashboard)
+}
+
+// appCardView is an app card on the dashboard, with the classes its status
+// is shown in worked out ahead of the template
+type appCardView struct {
+	DashboardApp
+	BuildStatus    string
+	BuildClass     string
+	CircleClass    string
+	ContainerLabel string
+	ContainerClass string
+	ShowControls   bool
+}
+
+// newAppCardView works out how an app card shows its latest build and
+// container. Start, stop and restart are only offered when controls is set.
+func newAppCardView(app DashboardApp, controls bool) appCardView {
+	view := appCardView{
+		DashboardApp: app,
+		BuildStatus:  "no builds",
+		BuildClass:   "bg-gray-50",
+		CircleClass:  "bg-gray-300",
+		ShowControls: controls && app.Container != nil,
+	}
+
+	if build := app.LatestBuild; build != nil {
+		view.BuildStatus = string(build.Status)
+		switch build.Status {
+		case models.BuildStatusSuccess:
+			view.BuildClass, view.CircleClass = "bg-green-100 text-green-700", "bg-green-500"
+		case models.BuildStatusFailed:
+			view.BuildClass, view.CircleClass = "bg-red-100 text-red-700", "bg-red-500"
+		case models.BuildStatusBuilding, models.BuildStatusCloning, models.BuildStatusDeploying:
+			view.BuildClass, view.CircleClass = "bg-blue-100 text-blue-700", "bg-blue-500 animate-pulse"
+		case models.BuildStatusWaitingApproval, models.BuildStatusScheduled:
+			view.BuildClass, view.CircleClass = "bg-yellow-100 text-yellow-700", "bg-yellow-500"
+		}
+	}
+
+	// The status circle follows the container when there is one
+	if container := app.Container; container != nil {
+		switch container.State {
+		case "running":
+			view.CircleClass = "bg-green-500"
+			view.ContainerLabel, view.ContainerClass = "Running", "bg-green-100 text-green-700"
+			// A health check, when the image has one, says more than running
+			switch container.Health {
+			case "healthy":
+				view.ContainerLabel = "Healthy"
+			case "unhealthy":
+				view.CircleClass = "bg-red-500"
+				view.ContainerLabel, view.ContainerClass = "Unhealthy", "bg-red-100 text-red-700"
+			case "starting":
+				view.CircleClass = "bg-yellow-500 animate-pulse"
+				view.ContainerLabel, view.ContainerClass = "Starting", "bg-yellow-100 text-yellow-700"
+			}
+		case "exited":
+			view.CircleClass = "bg-gray-400"
+			view.ContainerLabel, view.ContainerClass = "Stopped", "bg-gray-100 text-gray-700"
+		case "paused":
+			view.CircleClass = "bg-gray-400"
+			view.ContainerLabel, view.ContainerClass = "Paused", "bg-yellow-100 text-yellow-700"
+		case "restarting":
+			view.CircleClass = "bg-yellow-500 animate-pulse"
+			view.ContainerLabel, view.ContainerClass = "Restarting", "bg-blue-100 text-blue-700"
+		default:
+			view.CircleClass = "bg-gray-400"
+			view.ContainerLabel, view.ContainerClass = container.State, "bg-gray-100 text-gray-700"
+		}
+	}
+
+	// A crash-looping container is only briefly not running
+	if app.CrashLoop != nil {
+		view.CircleClass = "bg-red-500 animate-pulse"
+	}
+
+	return view
+}
+
+// appGroupView is the apps of one project on the dashboard. Apps without a
+// project have an empty Name.
+type appGroupView struct {
+	Name string
+	Apps []appCardView
+}
+
+// dashboardView is what the dashboard's apps and recent builds template
+// shows
+type dashboardView struct {
+	Filter       queries.AppFilter
+	Tags         []string
+	Groups       []appGroupView
+	RecentBuilds []*models.Build
+	BuildQueue   *BuildWorkersStatus // Nil when builds don't run here
+}
+
+// Grouped reports whether any app is in a project, and so whether the apps
+// are shown under collapsible groups rather than in one grid
+func (v dashboardView) Grouped() bool {
+	return len(v.Groups) > 1 || (len(v.Groups) == 1 && v.Groups[0].Name != "")
+}
+
+// Filtered reports whether the apps shown are narrowed down
+func (v dashboardView) Filtered() bool {
+	return v.Filter != queries.AppFilter{}
+}
+
+// newDashboardView prepares the loaded dashboard for its template, with the
+// apps grouped by project. Groups are sorted by name with the apps in no
+// project last.
+func (h *PageHandler) newDashboardView(dashboard *Dashboard, filter queries.AppFilter) dashboardView {
+	view := dashboardView{Filter: filter, Tags: dashboard.Tags, RecentBuilds: dashboard.RecentBuilds, BuildQueue: h.buildQueue()}
+
+	groups := make(map[string]int)
+	for _, app := range dashboard.Apps {
+		project := app.App.GetProject()
+		i, ok := groups[project]
+		if !ok {
+			i = len(view.Groups)
+			groups[project] = i
+			view.Groups = append(view.Groups, appGroupView{Name: project})
+		}
+		view.Groups[i].Apps = append(view.Groups[i].Apps, newAppCardView(app, h.dockerClient != nil))
+	}
+	sort.SliceStable(view.Groups, func(i, j int) bool {
+		a, b := view.Groups[i].Name, view.Groups[j].Name
+		if a == "" || b == "" {
+			return b == "" && a != ""
+		}
+		return strings.ToLower(a) < strings.ToLower(b)
+	})
+	return view
+}
+
+// AppCardPartial handles GET /partials/apps/{appID}/card
+func (h *PageHandler) AppCardPartial(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID := chi.URLParam(r, "appID")
+
+	app, err := h.appQueries.GetByID(ctx, appID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get app", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if app == nil {
+		http.Error(w, "app not found", http.StatusNotFound)
+		return
+	}
+
+	card := DashboardApp{App: app}
+	card.Container = h.statuses.lookup(ctx, []*models.App{app}, h.appContainerStatus)[app.ID]
+	if card.LatestBuild, err = h.buildQueries.GetLatestByAppID(ctx, app.ID); err != nil {
+		slog.ErrorContext(ctx, "failed to get latest build", "app", app.Name, "error", err)
+	}
+	if card.Uptime, err = h.uptimeQueries.GetByAppID(ctx, app.ID); err != nil {
+		slog.ErrorContext(ctx, "failed to get uptime check", "app", app.Name, "error", err)
+	}
+	if h.crashLoops != nil {
+		if loop, ok := h.crashLoops.CrashLoops()[app.ID]; ok {
+			card.CrashLoop = &loop
+		}
+	}
+	if h.healthQueries != nil {
+		if card.Health, err = h.healthQueries.GetByAppID(ctx, app.ID); err != nil {
+			slog.ErrorContext(ctx, "failed to get container health", "app", app.Name, "error", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	renderTemplate(w, "app-card", newAppCardView(card, h.dockerClient != nil))
+}
+
+// RecentBuildsPartial handles GET /partials/builds/recent
+func (h *PageHandler) RecentBuildsPartial(w http.ResponseWriter, r *http.Request) {
+	builds, err := h.buildQueries.ListRecent(r.Context(), 10)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list builds", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	renderTemplate(w, "recent-builds", builds)
+}
+
+// appBuildsView is the build history on an app's page
+type appBuildsView struct {
+	AppID  string
+	Builds []*models.Build
+}
+
+// AppBuildsPartial handles GET /partials/apps/{appID}/builds
+func (h *PageHandler) AppBuildsPartial(w http.ResponseWriter, r *http.Request) {
+	appID := chi.URLParam(r, "appID")
+
+	builds, err := h.buildQueries.ListByAppID(r.Context(), appID, 10, 0)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list builds", "appID", appID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	renderTemplate(w, "app-builds", appBuildsView{AppID: appID, Builds: builds})
+}
+
+// AppsPartial handles GET /partials/apps - the dashboard's apps matching the
+// q and tag filters
+func (h *PageHandler) AppsPartial(w http.ResponseWriter, r *http.Request) {
+	filter := parseAppFilter(r)
+	dashboard, err := h.loadDashboard(r.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to load dashboard", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	// The address bar follows the filter, so a reload or link keeps it
+	url := "/"
+	if query := r.URL.RawQuery; query != "" {
+		url += "?" + query
+	}
+	w.Header().Set("HX-Replace-Url", url)
+	w.Header().Set("Content-Type", "text/html")
+	renderTemplate(w, "app-list", h.newDashboardView(dashboard, filter))
+}
+
+// containerRow is a container in the dashboard's table
+type containerRow struct {
+	ID, Name, Image, State, Ports string
+	// Adoptable is set for containers Schooner doesn't manage
+	Adoptable bool
+}
+
+func (h *PageHandler) renderDockerContainers(w http.ResponseWriter, ctx context.Context) {
+	if h.dockerClient == nil {
+		return
+	}
+
+	containers, err := h.dockerClient.ListContainers(ctx, true, nil)
+	if err != nil {
+		slog.Error("failed to list containers", "error", err)
+		return
+	}
+
+	rows := make([]containerRow, 0, len(containers))
+	for _, c := range containers {
+		rows = append(rows, newContainerRow(c))
+	}
+	renderTemplate(w, "docker-containers", rows)
+}
+
+func newContainerRow(c types.Container) containerRow {
+	row := containerRow{ID: c.ID, Image: c.Image, State: c.State, Adoptable: c.Labels["schooner.managed"] != "true"}
+	if len(c.Names) > 0 {
+		row.Name = strings.TrimPrefix(c.Names[0], "/")
+	}
+
+	var ports []string
+	for _, p := range c.Ports {
+		if p.PublicPort > 0 {
+			ports = append(ports, fmt.Sprintf("%d:%d", p.PublicPort, p.PrivatePort))
+		}
+	}
+	row.Ports = strings.Join(ports, ", ")
+	if row.Ports == "" {
+		row.Ports = "-"
+	}
+
+	// Truncate image name if too long
+	if len(row.Image) > 35 {
+		row.Image = row.Image[:32] + "..."
+	}
+	return row
+}
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/database"
+	"schooner/internal/database/queries"
+	"schooner/internal/docker"
+	"schooner/internal/models"
+	"schooner/internal/notify"
+)
+
+func TestContainerStatusCache(t *testing.T) {
+	apps := []*models.App{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	var fetches atomic.Int32
+	fetch := func(ctx context.Context, app *models.App) *docker.ContainerStatus {
+		fetches.Add(1)
+		return &docker.ContainerStatus{Name: app.ID, State: "running"}
+	}
+
+	cache := NewContainerStatusCache()
+	statuses := cache.lookup(context.Background(), apps, fetch)
+	if len(statuses) != 3 || statuses["b"].Name != "b" {
+		t.Fatalf("statuses = %v, want one per app", statuses)
+	}
+
+	cache.lookup(context.Background(), apps, fetch)
+	if n := fetches.Load(); n != 3 {
+		t.Errorf("fetched %d times after a cached lookup, want 3", n)
+	}
+
+	cache.Forget("b")
+	cache.lookup(context.Background(), apps, fetch)
+	if n := fetches.Load(); n != 4 {
+		t.Errorf("fetched %d times after forgetting one app, want 4", n)
+	}
+
+	var nilCache *ContainerStatusCache
+	nilCache.lookup(context.Background(), apps, fetch)
+	if n := fetches.Load(); n != 7 {
+		t.Errorf("fetched %d times without a cache, want 7", n)
+	}
+}
+
+// newTestDB returns a migrated database in a temporary directory, keeping
+// the encryption key it creates there too
+func newTestDB(t *testing.T) *database.DB {
+	t.Helper()
+	t.Setenv("SCHOONER_KEY_PATH", filepath.Join(t.TempDir(), ".encryption_key"))
+	db, err := database.New(filepath.Join(t.TempDir(), "schooner.db"))
+	if err != nil {
+		t.Fatalf("database.New() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	return db
+}
+
+// newDashboardHandler returns a PageHandler over apps api, web and docs,
+// with builds b1 to b5 alternating between api and web
+func newDashboardHandler(t *testing.T) *PageHandler {
+	t.Helper()
+	db := newTestDB(t)
+
+	ctx := context.Background()
+	appQueries := queries.NewAppQueries(db.DB)
+	buildQueries := queries.NewBuildQueries(db.DB)
+	for _, name := range []string{"api", "web", "docs"} {
+		app := &models.App{
+			ID: name, Name: name, RepoURL: "https://github.com/example/" + name + ".git", Branch: "main",
+			BuildStrategy: models.BuildStrategyDockerfile, CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		}
+		if err := appQueries.Create(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now().Add(-time.Hour)
+	for i, b := range []struct{ id, app string }{{"b1", "api"}, {"b2", "web"}, {"b3", "api"}, {"b4", "web"}, {"b5", "api"}} {
+		build := &models.Build{
+			ID: b.id, AppID: b.app, Status: models.BuildStatusSuccess, Trigger: models.TriggerManual,
+			CreatedAt: start.Add(time.Duration(i) * time.Minute),
+		}
+		if err := buildQueries.Create(ctx, build); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return NewPageHandler(nil, appQueries, buildQueries, queries.NewSettingsQueries(db.DB), queries.NewUptimeQueries(db.DB), nil, nil, nil)
+}
+
+func TestLoadDashboard(t *testing.T) {
+	ctx := context.Background()
+	h := newDashboardHandler(t)
+	dashboard, err := h.loadDashboard(ctx, queries.AppFilter{})
+	if err != nil {
+		t.Fatalf("loadDashboard() error = %v", err)
+	}
+
+	latest := make(map[string]string)
+	for _, app := range dashboard.Apps {
+		if app.LatestBuild != nil {
+			latest[app.App.ID] = app.LatestBuild.ID
+		}
+	}
+	if len(latest) != 2 || latest["api"] != "b5" || latest["web"] != "b4" {
+		t.Errorf("latest builds = %v, want api b5 and web b4", latest)
+	}
+	if len(dashboard.RecentBuilds) != 5 || dashboard.RecentBuilds[0].ID != "b5" {
+		t.Errorf("recent builds = %d starting with %s, want 5 starting with b5", len(dashboard.RecentBuilds), dashboard.RecentBuilds[0].ID)
+	}
+}
+
+func TestNewAppCardView(t *testing.T) {
+	app := &models.App{ID: "api", Name: "api", Enabled: true}
+	tests := []struct {
+		name         string
+		card         DashboardApp
+		controls     bool
+		wantStatus   string
+		wantCircle   string
+		wantLabel    string
+		wantControls bool
+	}{
+		{
+			name:       "never built",
+			card:       DashboardApp{App: app},
+			wantStatus: "no builds", wantCircle: "bg-gray-300",
+		},
+		{
+			name:       "failed build without a container",
+			card:       DashboardApp{App: app, LatestBuild: &models.Build{Status: models.BuildStatusFailed}},
+			controls:   true,
+			wantStatus: "failed", wantCircle: "bg-red-500",
+		},
+		{
+			name: "running container",
+			card: DashboardApp{
+				App:         app,
+				LatestBuild: &models.Build{Status: models.BuildStatusFailed},
+				Container:   &docker.ContainerStatus{State: "running"},
+			},
+			controls:   true,
+			wantStatus: "failed", wantCircle: "bg-green-500", wantLabel: "Running", wantControls: true,
+		},
+		{
+			name:       "unhealthy container",
+			card:       DashboardApp{App: app, Container: &docker.ContainerStatus{State: "running", Health: "unhealthy"}},
+			controls:   true,
+			wantStatus: "no builds", wantCircle: "bg-red-500", wantLabel: "Unhealthy", wantControls: true,
+		},
+		{
+			name:       "healthy container",
+			card:       DashboardApp{App: app, Container: &docker.ContainerStatus{State: "running", Health: "healthy"}},
+			wantStatus: "no builds", wantCircle: "bg-green-500", wantLabel: "Healthy",
+		},
+		{
+			name: "crash looping",
+			card: DashboardApp{
+				App:       app,
+				Container: &docker.ContainerStatus{State: "running"},
+				CrashLoop: &notify.CrashLoop{AppID: "api", Deaths: 5},
+			},
+			wantStatus: "no builds", wantCircle: "bg-red-500 animate-pulse", wantLabel: "Running",
+		},
+		{
+			name:       "no Docker client",
+			card:       DashboardApp{App: app, Container: &docker.ContainerStatus{State: "exited"}},
+			wantStatus: "no builds", wantCircle: "bg-gray-400", wantLabel: "Stopped",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			view := newAppCardView(tt.card, tt.controls)
+			if view.BuildStatus != tt.wantStatus || view.CircleClass != tt.wantCircle || view.ContainerLabel != tt.wantLabel || view.ShowControls != tt.wantControls {
+				t.Errorf("newAppCardView() = %q %q %q %v, want %q %q %q %v",
+					view.BuildStatus, view.CircleClass, view.ContainerLabel, view.ShowControls,
+					tt.wantStatus, tt.wantCircle, tt.wantLabel, tt.wantControls)
+			}
+		})
+	}
+}
+
+func TestAppCardPartial(t *testing.T) {
+	h := newDashboardHandler(t)
+	r := chi.NewRouter()
+	r.Get("/partials/apps/{appID}/card", h.AppCardPartial)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/partials/apps/api/card", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{`data-fragment="app-card"`, `hx-get="/partials/apps/api/card"`, `hx-post="/api/apps/api/deploy"`, ">success</span>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("card is missing %s:\n%s", want, body)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/partials/apps/missing/card", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status for a missing app = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestRecentBuildsPartial(t *testing.T) {
+	h := newDashboardHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.RecentBuildsPartial(rec, httptest.NewRequest(http.MethodGet, "/partials/builds/recent", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	body := rec.Body.String()
+	if n := strings.Count(body, `href="/builds/b`); n != 5 {
+		t.Errorf("partial links %d builds, want 5", n)
+	}
+	if !strings.Contains(body, `data-fragment="recent-builds"`) {
+		t.Error("partial isn't marked as the recent-builds fragment")
+	}
+}
+
+func TestAppBuildsPartial(t *testing.T) {
+	h := newDashboardHandler(t)
+	r := chi.NewRouter()
+	r.Get("/partials/apps/{appID}/builds", h.AppBuildsPartial)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/partials/apps/web/builds", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `data-app-id="web"`) {
+		t.Error("partial isn't marked with its app")
+	}
+	for _, id := range []string{"b2", "b4"} {
+		if !strings.Contains(body, `href="/builds/`+id+`"`) {
+			t.Errorf("partial is missing build %s", id)
+		}
+	}
+	if strings.Contains(body, `href="/builds/b1"`) {
+		t.Error("partial lists another app's build")
+	}
+}
+
+func TestAppsPartial(t *testing.T) {
+	h := newDashboardHandler(t)
+	ctx := context.Background()
+	for _, a := range []struct {
+		id, project string
+		tags        []string
+	}{{"api", "core", []string{"backend", "public"}}, {"web", "core", []string{"public"}}} {
+		app, err := h.appQueries.GetByID(ctx, a.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		app.Project = sql.NullString{String: a.project, Valid: true}
+		if err := app.SetTags(a.tags); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.appQueries.Update(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query      string
+		wantApps   []string
+		wantGroups []string
+	}{
+		{query: "", wantApps: []string{"api", "docs", "web"}, wantGroups: []string{`data-group="core"`, `data-group=""`}},
+		{query: "?tag=PUBLIC", wantApps: []string{"api", "web"}, wantGroups: []string{`data-group="core"`}},
+		{query: "?tag=back", wantApps: nil},
+		{query: "?q=doc", wantApps: []string{"docs"}},
+		{query: "?q=end", wantApps: []string{"api"}}, // matches a tag
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.AppsPartial(rec, httptest.NewRequest(http.MethodGet, "/partials/apps"+tt.query, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			if got := rec.Header().Get("HX-Replace-Url"); got != "/"+tt.query {
+				t.Errorf("HX-Replace-Url = %q, want %q", got, "/"+tt.query)
+			}
+
+			body := rec.Body.String()
+			var apps []string
+			for _, id := range []string{"api", "docs", "web"} {
+				if strings.Contains(body, `data-app-id="`+id+`"`) {
+					apps = append(apps, id)
+				}
+			}
+			if strings.Join(apps, ",") != strings.Join(tt.wantApps, ",") {
+				t.Errorf("apps = %v, want %v", apps, tt.wantApps)
+			}
+			for _, group := range tt.wantGroups {
+				if !strings.Contains(body, group) {
+					t.Errorf("missing group %s", group)
+				}
+			}
+			if len(tt.wantApps) == 0 && !strings.Contains(body, "No applications match") {
+				t.Error("expected the no match message")
+			}
+		})
+	}
+}
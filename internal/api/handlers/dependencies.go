@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+// AppRef names an app in dependency listings
+type AppRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// AppDependencies is what an app depends on and what depends on it
+type AppDependencies struct {
+	DependsOn []AppRef `json:"depends_on"`
+	NeededBy  []AppRef `json:"needed_by"`
+}
+
+// DependencyGraphNode is an app and the IDs of the apps it depends on
+type DependencyGraphNode struct {
+	AppRef
+	DependsOn []string `json:"depends_on"`
+}
+
+// DependencyGraphView is the dependency graph of every app, with the waves
+// they start in
+type DependencyGraphView struct {
+	Apps  []DependencyGraphNode `json:"apps"`
+	Waves [][]AppRef            `json:"waves"`
+}
+
+// SetDependenciesRequest is the request body for replacing what an app
+// depends on. Apps are given by ID or name.
+type SetDependenciesRequest struct {
+	DependsOn []string `json:"depends_on"`
+}
+
+// SetDependencies lets apps depend on other apps, so bulk deploys and starts
+// run in dependency order
+func (h *AppHandler) SetDependencies(dependencies *queries.DependencyQueries) {
+	h.dependencies = dependencies
+}
+
+// dependencyGraph returns the dependencies of every app, or an empty graph
+// when apps can't have any
+func (h *AppHandler) dependencyGraph(ctx context.Context) (models.DependencyGraph, error) {
+	if h.dependencies == nil {
+		return models.DependencyGraph{}, nil
+	}
+	return h.dependencies.Graph(ctx)
+}
+
+// DependencyGraph handles GET /api/apps/dependencies - returns which apps
+// depend on which and the order they start in
+func (h *AppHandler) DependencyGraph(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	apps, err := h.appQueries.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list apps", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	graph, err := h.dependencyGraph(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load app dependencies", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	names := make(map[string]string, len(apps))
+	ids := make([]string, len(apps))
+	view := DependencyGraphView{Apps: make([]DependencyGraphNode, len(apps)), Waves: [][]AppRef{}}
+	for i, app := range apps {
+		names[app.ID] = app.Name
+		ids[i] = app.ID
+		dependsOn := graph[app.ID]
+		if dependsOn == nil {
+			dependsOn = []string{}
+		}
+		view.Apps[i] = DependencyGraphNode{AppRef: AppRef{ID: app.ID, Name: app.Name}, DependsOn: dependsOn}
+	}
+
+	waves, err := graph.Order(ids)
+	if err != nil {
+		// Saving dependencies rejects cycles, so one means the database was
+		// edited by hand
+		slog.WarnContext(ctx, "app dependencies loop", "error", err)
+	}
+	for _, wave := range waves {
+		refs := make([]AppRef, len(wave))
+		for i, id := range wave {
+			refs[i] = AppRef{ID: id, Name: names[id]}
+		}
+		view.Waves = append(view.Waves, refs)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
+// GetDependencies handles GET /api/apps/{appID}/dependencies - returns what
+// an app depends on and what depends on it
+func (h *AppHandler) GetDependencies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID := chi.URLParam(r, "appID")
+
+	app, err := h.appQueries.GetByID(ctx, appID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get app", "appID", appID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if app == nil {
+		http.Error(w, "app not found", http.StatusNotFound)
+		return
+	}
+
+	deps, err := h.appDependencies(ctx, app.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load app dependencies", "appID", appID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deps)
+}
+
+// appDependencies looks up what an app depends on and what depends on it
+func (h *AppHandler) appDependencies(ctx context.Context, appID string) (*AppDependencies, error) {
+	graph, err := h.dependencyGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deps := &AppDependencies{DependsOn: []AppRef{}, NeededBy: []AppRef{}}
+	for _, id := range graph[appID] {
+		ref, err := h.appRef(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		deps.DependsOn = append(deps.DependsOn, ref)
+	}
+	for _, id := range graph.Dependents(appID) {
+		ref, err := h.appRef(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		deps.NeededBy = append(deps.NeededBy, ref)
+	}
+	return deps, nil
+}
+
+// appRef looks up the name of an app by ID
+func (h *AppHandler) appRef(ctx context.Context, id string) (AppRef, error) {
+	app, err := h.appQueries.GetByID(ctx, id)
+	if err != nil {
+		return AppRef{}, err
+	}
+	if app == nil {
+		return AppRef{ID: id, Name: id}, nil
+	}
+	return AppRef{ID: app.ID, Name: app.Name}, nil
+}
+
+// UpdateDependencies handles PUT /api/apps/{appID}/dependencies - replaces
+// the apps an app depends on, refusing dependencies that loop
+func (h *AppHandler) UpdateDependencies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID := chi.URLParam(r, "appID")
+
+	if h.dependencies == nil {
+		http.Error(w, "app dependencies not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	app, err := h.appQueries.GetByID(ctx, appID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get app", "appID", appID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if app == nil {
+		http.Error(w, "app not found", http.StatusNotFound)
+		return
+	}
+
+	var req SetDependenciesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	apps, err := h.appQueries.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list apps", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	dependsOn, err := resolveDependencies(app, apps, req.DependsOn)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	graph, err := h.dependencies.Graph(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load app dependencies", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if cycle := graph.With(app.ID, dependsOn).FindCycle(); cycle != nil {
+		names := make(map[string]string, len(apps))
+		for _, a := range apps {
+			names[a.ID] = a.Name
+		}
+		http.Error(w, cycle.NameCycle(names).Error(), http.StatusConflict)
+		return
+	}
+
+	if err := h.dependencies.SetDependencies(ctx, app.ID, dependsOn); err != nil {
+		slog.ErrorContext(ctx, "failed to save app dependencies", "appID", appID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(ctx, "app dependencies updated", "app", app.Name, "dependsOn", len(dependsOn))
+
+	deps, err := h.appDependencies(ctx, app.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load app dependencies", "appID", appID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deps)
+}
+
+// resolveDependencies turns the apps an app should depend on, given by ID or
+// name, into their IDs without duplicates
+func resolveDependencies(app *models.App, apps []*models.App, given []string) ([]string, error) {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, ref := range given {
+		ref = strings.TrimSpace(ref)
+		var found *models.App
+		for _, a := range apps {
+			if a.ID == ref || a.Name == ref {
+				found = a
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("no app %q", ref)
+		}
+		if found.ID == app.ID {
+			return nil, errors.New("an app can't depend on itself")
+		}
+		if seen[found.ID] {
+			continue
+		}
+		seen[found.ID] = true
+		ids = append(ids, found.ID)
+	}
+	return ids, nil
+}
+
+// bulkWaves splits the apps of a bulk action into the waves to run it in, so
+// apps start after the apps they depend on. Apps stop in the reverse order.
+// If the apps can't be ordered they all run in one wave.
+func (h *AppHandler) bulkWaves(ctx context.Context, apps []*models.App, action string) [][]*models.App {
+	byID := make(map[string]*models.App, len(apps))
+	var ids []string
+	for _, app := range apps {
+		if app == nil {
+			continue
+		}
+		byID[app.ID] = app
+		ids = append(ids, app.ID)
+	}
+
+	graph, err := h.dependencyGraph(ctx)
+	if err == nil {
+		var waves [][]string
+		waves, err = graph.Order(ids)
+		if err == nil {
+			ordered := make([][]*models.App, len(waves))
+			for i, wave := range waves {
+				for _, id := range wave {
+					ordered[i] = append(ordered[i], byID[id])
+				}
+			}
+			if action == "stop" {
+				for i, j := 0, len(ordered)-1; i < j; i, j = i+1, j-1 {
+					ordered[i], ordered[j] = ordered[j], ordered[i]
+				}
+			}
+			return ordered
+		}
+	}
+
+	slog.WarnContext(ctx, "running bulk action without dependency order", "action", action, "error", err)
+	unordered := make([]*models.App, 0, len(ids))
+	for _, id := range ids {
+		unordered = append(unordered, byID[id])
+	}
+	return [][]*models.App{unordered}
+}
+
+// renderDependencies renders what the app depends on, what depends on it and
+// the order every app starts in, with an editor for the app's dependencies
+func (h *PageHandler) renderDependencies(w http.ResponseWriter, app *models.App) {
+	renderTemplate(w, "dependencies", app)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+func TestAppHandler_UpdateDependencies(t *testing.T) {
+	db := newTestDB(t)
+
+	ctx := context.Background()
+	appQueries := queries.NewAppQueries(db.DB)
+	for _, name := range []string{"db", "api", "web"} {
+		app := &models.App{
+			ID: name + "-id", Name: name, RepoURL: "https://github.com/example/" + name + ".git", Branch: "main",
+			BuildStrategy: models.BuildStrategyDockerfile, CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		}
+		if err := appQueries.Create(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := NewAppHandler(nil, appQueries, nil, nil, nil, nil, nil, nil)
+	h.SetDependencies(queries.NewDependencyQueries(db.DB))
+	router := chi.NewRouter()
+	router.Get("/api/apps/dependencies", h.DependencyGraph)
+	router.Put("/api/apps/{appID}/dependencies", h.UpdateDependencies)
+
+	tests := []struct {
+		name       string
+		appID      string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "by name", appID: "api-id", body: `{"depends_on": ["db"]}`, wantStatus: http.StatusOK},
+		{name: "by ID", appID: "web-id", body: `{"depends_on": ["api-id", "api"]}`, wantStatus: http.StatusOK},
+		{name: "cycle", appID: "db-id", body: `{"depends_on": ["web"]}`, wantStatus: http.StatusConflict, wantBody: "dependency cycle: api -> db -> web -> api"},
+		{name: "itself", appID: "db-id", body: `{"depends_on": ["db"]}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown app", appID: "db-id", body: `{"depends_on": ["cache"]}`, wantStatus: http.StatusBadRequest},
+		{name: "missing app", appID: "cache-id", body: `{"depends_on": []}`, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/apps/"+tt.appID+"/dependencies", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantBody != "" && strings.TrimSpace(rec.Body.String()) != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body, tt.wantBody)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/apps/dependencies", nil))
+	var graph DependencyGraphView
+	if err := json.NewDecoder(rec.Body).Decode(&graph); err != nil {
+		t.Fatal(err)
+	}
+	var waves [][]string
+	for _, wave := range graph.Waves {
+		var names []string
+		for _, app := range wave {
+			names = append(names, app.Name)
+		}
+		waves = append(waves, names)
+	}
+	if got, want := len(waves), 3; got != want || waves[0][0] != "db" || waves[1][0] != "api" || waves[2][0] != "web" {
+		t.Errorf("waves = %v, want db, then api, then web", waves)
+	}
+}
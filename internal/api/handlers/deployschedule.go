@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"schooner/internal/models"
+)
+
+// GetDeploySchedule handles GET /api/settings/deploy-schedule
+func (h *SettingsHandler) GetDeploySchedule(w http.ResponseWriter, r *http.Request) {
+	schedule, err := h.settingsQueries.GetDeploySchedule(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to get deploy schedule", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if schedule == nil {
+		schedule = &models.DeploySchedule{}
+	}
+
+	reason, next := models.DeployHold(time.Now(), schedule, nil)
+	response := map[string]interface{}{
+		"schedule":   schedule,
+		"can_deploy": reason == "",
+	}
+	if reason != "" {
+		response["reason"] = reason
+		if !next.IsZero() {
+			response["next_window"] = next
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetDeploySchedule handles POST /api/settings/deploy-schedule
+func (h *SettingsHandler) SetDeploySchedule(w http.ResponseWriter, r *http.Request) {
+	var schedule models.DeploySchedule
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := schedule.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.settingsQueries.SetDeploySchedule(r.Context(), &schedule); err != nil {
+		slog.ErrorContext(r.Context(), "failed to save deploy schedule", "error", err)
+		http.Error(w, "failed to save deploy schedule", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "global deploy schedule set", "windows", len(schedule.Windows), "freezes", len(schedule.Freezes))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Deploy schedule saved",
+	})
+}
+
+func (h *PageHandler) renderDeployScheduleSettings(w http.ResponseWriter, ctx context.Context) {
+	if h.settingsQueries == nil {
+		return
+	}
+	schedule, err := h.settingsQueries.GetDeploySchedule(ctx)
+	if err != nil {
+		slog.Warn("failed to load deploy schedule", "error", err)
+	}
+
+	fmt.Fprint(w, `
+        <div class="mt-8">
+            <h2 class="text-xl font-bold mb-4">Deploy Windows</h2>
+            <div class="bg-white shadow-sm rounded-lg p-6 border border-gray-200">
+                <p class="text-gray-500 mb-4">Limit when builds triggered by a push deploy, for every app. Builds outside a window are held and deploy once it opens.</p>
+                <form onsubmit="submitDeploySchedule(event)">
+                    <div class="grid grid-cols-2 gap-4 mb-4">`)
+	renderTemplate(w, "schedule-fields", newScheduleFieldsView(schedule, "Global"))
+	fmt.Fprint(w, `
+                    </div>
+                    <button type="submit" class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white">Save Deploy Windows</button>
+                </form>
+            </div>
+        </div>
+        <script>
+            function submitDeploySchedule(event) {
+                event.preventDefault();
+                fetch('/api/settings/deploy-schedule', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify(scheduleFromForm(new FormData(event.target)))
+                })
+                .then(response => {
+                    if (response.ok) {
+                        window.location.reload();
+                    } else {
+                        response.text().then(text => alert('Failed to save deploy windows: ' + text));
+                    }
+                });
+            }
+        </script>`)
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+
+	"schooner/internal/build"
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+// AppDiskUsage is the space an app takes up, in bytes. Add-on containers and
+// their volumes count towards the app they belong to.
+type AppDiskUsage struct {
+	AppID      string `json:"app_id"`
+	AppName    string `json:"app_name"`
+	Images     int64  `json:"images"`
+	Containers int64  `json:"containers"` // Writable layers
+	Volumes    int64  `json:"volumes"`
+	Repo       int64  `json:"repo"` // Cloned repository or compose spec directory
+	Total      int64  `json:"total"`
+}
+
+// DiskUsageTotals is space no app accounts for, in bytes
+type DiskUsageTotals struct {
+	Images     int64 `json:"images"`
+	Containers int64 `json:"containers"`
+	Volumes    int64 `json:"volumes"`
+	BuildCache int64 `json:"build_cache"`
+}
+
+// DiskUsageReport is the response body of the disk usage breakdown
+type DiskUsageReport struct {
+	Apps         []*AppDiskUsage `json:"apps"`
+	Unattributed DiskUsageTotals `json:"unattributed"`
+	Errors       []string        `json:"errors,omitempty"` // Docker hosts that couldn't be sized
+}
+
+// DiskUsage handles GET /api/disk-usage
+func (h *AppHandler) DiskUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	apps, err := h.appQueries.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list apps", "error", err)
+		http.Error(w, "failed to list apps", http.StatusInternalServerError)
+		return
+	}
+
+	report := &DiskUsageReport{Apps: []*AppDiskUsage{}}
+	usage := make(map[string]*AppDiskUsage, len(apps))
+	for _, app := range apps {
+		usage[app.ID] = &AppDiskUsage{AppID: app.ID, AppName: app.Name}
+		report.Apps = append(report.Apps, usage[app.ID])
+	}
+
+	// Size each Docker host once, with the apps deployed to it. Apps on
+	// agents are built here, so their images are local.
+	var clients []*docker.Client
+	hostApps := make(map[*docker.Client][]*models.App)
+	for _, app := range apps {
+		client := h.dockerClient
+		if app.GetAgent() == "" {
+			client, err = h.dockerFor(ctx, app)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %s", app.Name, err))
+				continue
+			}
+		}
+		if client == nil {
+			continue
+		}
+		if _, ok := hostApps[client]; !ok {
+			clients = append(clients, client)
+		}
+		hostApps[client] = append(hostApps[client], app)
+	}
+
+	for _, client := range clients {
+		du, err := client.DiskUsage(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to get docker disk usage", "error", err)
+			report.Errors = append(report.Errors, fmt.Sprintf("failed to get disk usage: %s", err))
+			continue
+		}
+		other := attributeDiskUsage(hostApps[client], du, usage)
+		report.Unattributed.Images += other.Images
+		report.Unattributed.Containers += other.Containers
+		report.Unattributed.Volumes += other.Volumes
+		report.Unattributed.BuildCache += other.BuildCache
+	}
+
+	for _, app := range apps {
+		u := usage[app.ID]
+		u.Repo = dirSize(build.CheckoutPath(h.cfg.Git.WorkDir, app))
+		u.Total = u.Images + u.Containers + u.Volumes + u.Repo
+	}
+	sort.SliceStable(report.Apps, func(i, j int) bool {
+		return report.Apps[i].Total > report.Apps[j].Total
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// attributeDiskUsage adds the images, containers and volumes of one Docker
+// host to the apps they belong to and returns what's left over. Containers
+// are matched by their Schooner labels; images by the containers using them
+// or by the app's image name; volumes by the containers mounting them.
+func attributeDiskUsage(apps []*models.App, du types.DiskUsage, usage map[string]*AppDiskUsage) DiskUsageTotals {
+	var other DiskUsageTotals
+
+	byName := make(map[string]string, len(apps))
+	byImage := make(map[string]string, len(apps))
+	for _, app := range apps {
+		byName[app.Name] = app.ID
+		byImage[app.GetImageName()] = app.ID
+	}
+
+	imageOwner := make(map[string]string)
+	volumeOwner := make(map[string]string)
+	for _, c := range du.Containers {
+		appID := c.Labels["schooner.app-id"]
+		if appID == "" {
+			appID = c.Labels["schooner.addon-app-id"]
+		}
+		if appID == "" {
+			appID = byName[c.Labels["schooner.app"]]
+		}
+		u, ok := usage[appID]
+		if !ok || appID == "" {
+			other.Containers += c.SizeRw
+			continue
+		}
+
+		u.Containers += c.SizeRw
+		if _, taken := imageOwner[c.ImageID]; !taken {
+			imageOwner[c.ImageID] = appID
+		}
+		for _, m := range c.Mounts {
+			if _, taken := volumeOwner[m.Name]; m.Type == mount.TypeVolume && !taken {
+				volumeOwner[m.Name] = appID
+			}
+		}
+	}
+
+	for _, img := range du.Images {
+		appID, ok := imageOwner[img.ID]
+		if !ok {
+			for _, tag := range img.RepoTags {
+				if id, found := byImage[imageRepo(tag)]; found {
+					appID, ok = id, true
+					break
+				}
+			}
+		}
+		if u := usage[appID]; ok && u != nil {
+			u.Images += img.Size
+		} else {
+			other.Images += img.Size
+		}
+	}
+
+	for _, v := range du.Volumes {
+		// A size of -1 means the daemon didn't compute it
+		if v.UsageData == nil || v.UsageData.Size < 0 {
+			continue
+		}
+		if u := usage[volumeOwner[v.Name]]; u != nil {
+			u.Volumes += v.UsageData.Size
+		} else {
+			other.Volumes += v.UsageData.Size
+		}
+	}
+
+	for _, cache := range du.BuildCache {
+		other.BuildCache += cache.Size
+	}
+
+	return other
+}
+
+// imageRepo strips the tag from an image reference, leaving registry ports
+// alone
+func imageRepo(ref string) string {
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i]
+	}
+	return ref
+}
+
+// dirSize adds up the size of the files under a directory, skipping what it
+// can't read. A missing directory is empty.
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+func (h *PageHandler) renderDiskUsageSettings(w http.ResponseWriter) {
+	if h.dockerClient == nil {
+		return
+	}
+
+	fmt.Fprint(w, `
+        <div class="mt-8">
+            <h2 class="text-xl font-bold mb-4">Disk Usage</h2>
+            <div class="bg-white shadow-sm rounded-lg p-6 border border-gray-200">
+                <p class="text-gray-500 mb-4">Space taken by each app's images, container layers, named volumes and cloned repository. Add-ons count towards their app.
+                    Images that share layers are each counted in full.</p>
+                <button type="button" onclick="loadDiskUsage()" class="px-4 py-2 bg-gray-100 hover:bg-gray-200 rounded text-gray-900 mb-4">Calculate</button>
+                <div id="disk-usage-errors" class="hidden mb-4 px-3 py-2 rounded bg-yellow-50 border border-yellow-200 text-sm text-yellow-700"></div>
+                <table class="w-full text-sm">
+                    <thead>
+                        <tr class="text-left text-gray-500 border-b border-gray-200">
+                            <th class="py-2">App</th>
+                            <th class="py-2 text-right">Images</th>
+                            <th class="py-2 text-right">Containers</th>
+                            <th class="py-2 text-right">Volumes</th>
+                            <th class="py-2 text-right">Repo</th>
+                            <th class="py-2 text-right">Total</th>
+                        </tr>
+                    </thead>
+                    <tbody id="disk-usage-apps">
+                        <tr><td colspan="6" class="py-2 text-gray-400">Sizing volumes can take a while, so this runs on request.</td></tr>
+                    </tbody>
+                </table>
+            </div>
+        </div>
+        <script>
+            function escapeDiskUsageText(text) {
+                const div = document.createElement('div');
+                div.textContent = text;
+                return div.innerHTML;
+            }
+
+            function formatDiskUsage(bytes) {
+                const units = ['B', 'KB', 'MB', 'GB', 'TB'];
+                let i = 0;
+                while (bytes >= 1024 && i < units.length - 1) {
+                    bytes /= 1024;
+                    i++;
+                }
+                return (i === 0 ? bytes : bytes.toFixed(1)) + ' ' + units[i];
+            }
+
+            function loadDiskUsage() {
+                const body = document.getElementById('disk-usage-apps');
+                body.innerHTML = '<tr><td colspan="6" class="py-2 text-gray-400">Calculating...</td></tr>';
+                fetch('/api/disk-usage')
+                    .then(response => {
+                        if (!response.ok) {
+                            return response.text().then(text => { throw new Error(text); });
+                        }
+                        return response.json();
+                    })
+                    .then(report => {
+                        const cell = (value, bold) => '<td class="py-2 text-right' + (bold ? ' font-semibold' : '') + '">' + formatDiskUsage(value) + '</td>';
+                        const rows = report.apps.map(app => '<tr class="border-b border-gray-100">' +
+                            '<td class="py-2">' + escapeDiskUsageText(app.app_name) + '</td>' +
+                            cell(app.images) + cell(app.containers) + cell(app.volumes) + cell(app.repo) + cell(app.total, true) +
+                            '</tr>');
+                        const other = report.unattributed;
+                        rows.push('<tr class="text-gray-500">' +
+                            '<td class="py-2">Not from an app (build cache ' + formatDiskUsage(other.build_cache) + ')</td>' +
+                            cell(other.images) + cell(other.containers) + cell(other.volumes) + '<td></td>' +
+                            cell(other.images + other.containers + other.volumes + other.build_cache, true) +
+                            '</tr>');
+                        body.innerHTML = rows.join('');
+
+                        const errors = document.getElementById('disk-usage-errors');
+                        errors.classList.toggle('hidden', !report.errors);
+                        errors.innerHTML = (report.errors || []).map(escapeDiskUsageText).join('<br>');
+                    })
+                    .catch(err => {
+                        body.innerHTML = '<tr><td colspan="6" class="py-2 text-red-600">' + escapeDiskUsageText(err.message) + '</td></tr>';
+                    });
+            }
+        </script>`)
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/volume"
+
+	"schooner/internal/models"
+)
+
+func TestAttributeDiskUsage(t *testing.T) {
+	apps := []*models.App{
+		{ID: "app-1", Name: "blog"},
+		{ID: "app-2", Name: "wiki"},
+	}
+	usage := map[string]*AppDiskUsage{
+		"app-1": {AppID: "app-1"},
+		"app-2": {AppID: "app-2"},
+	}
+
+	du := types.DiskUsage{
+		Containers: []*types.Container{
+			{
+				ImageID: "sha256:blog",
+				SizeRw:  10,
+				Labels:  map[string]string{"schooner.app-id": "app-1"},
+			},
+			{
+				ImageID: "sha256:postgres",
+				SizeRw:  5,
+				Labels:  map[string]string{"schooner.addon-app-id": "app-1"},
+				Mounts:  []types.MountPoint{{Type: "volume", Name: "blog-db"}},
+			},
+			{
+				ImageID: "sha256:wiki",
+				SizeRw:  7,
+				Labels:  map[string]string{"schooner.app": "wiki"},
+			},
+			{ImageID: "sha256:other", SizeRw: 3},
+		},
+		Images: []*image.Summary{
+			{ID: "sha256:blog", Size: 100},
+			{ID: "sha256:postgres", Size: 200},
+			{ID: "sha256:wiki", Size: 50},
+			{ID: "sha256:blog-old", Size: 90, RepoTags: []string{apps[0].GetImageName() + ":abc123"}},
+			{ID: "sha256:other", Size: 40},
+		},
+		Volumes: []*volume.Volume{
+			{Name: "blog-db", UsageData: &volume.UsageData{Size: 1000}},
+			{Name: "stray", UsageData: &volume.UsageData{Size: 60}},
+			{Name: "unsized", UsageData: &volume.UsageData{Size: -1}},
+		},
+		BuildCache: []*types.BuildCache{{Size: 70}, {Size: 30}},
+	}
+
+	other := attributeDiskUsage(apps, du, usage)
+
+	blog := usage["app-1"]
+	if blog.Containers != 15 || blog.Images != 390 || blog.Volumes != 1000 {
+		t.Errorf("blog = %+v, want containers 15, images 390, volumes 1000", blog)
+	}
+	wiki := usage["app-2"]
+	if wiki.Containers != 7 || wiki.Images != 50 || wiki.Volumes != 0 {
+		t.Errorf("wiki = %+v, want containers 7, images 50, volumes 0", wiki)
+	}
+	want := DiskUsageTotals{Images: 40, Containers: 3, Volumes: 60, BuildCache: 100}
+	if other != want {
+		t.Errorf("unattributed = %+v, want %+v", other, want)
+	}
+}
+
+func TestImageRepo(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"schooner/blog:abc123", "schooner/blog"},
+		{"schooner/blog", "schooner/blog"},
+		{"registry.local:5000/blog:latest", "registry.local:5000/blog"},
+		{"registry.local:5000/blog", "registry.local:5000/blog"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			if got := imageRepo(tt.ref); got != tt.want {
+				t.Errorf("imageRepo(%q) = %q, want %q", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDirSize(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a"), make([]byte, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "b"), make([]byte, 50), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := dirSize(dir); got != 150 {
+		t.Errorf("dirSize() = %d, want 150", got)
+	}
+	if got := dirSize(filepath.Join(dir, "missing")); got != 0 {
+		t.Errorf("dirSize() of a missing directory = %d, want 0", got)
+	}
+}
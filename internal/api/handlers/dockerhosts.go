@@ -0,0 +1,363 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+// dockerHostTestTimeout bounds connecting to and pinging a Docker host
+const dockerHostTestTimeout = 15 * time.Second
+
+// DockerHostHandler handles remote Docker host management
+type DockerHostHandler struct {
+	hostQueries *queries.DockerHostQueries
+	hosts       *docker.Hosts
+}
+
+// NewDockerHostHandler creates a new DockerHostHandler
+func NewDockerHostHandler(hostQueries *queries.DockerHostQueries, hosts *docker.Hosts) *DockerHostHandler {
+	return &DockerHostHandler{
+		hostQueries: hostQueries,
+		hosts:       hosts,
+	}
+}
+
+// DockerHostRequest is the request body for creating or updating a Docker host
+type DockerHostRequest struct {
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	TLSCertPath string `json:"tls_cert_path"`
+	SSHKeyPath  string `json:"ssh_key_path"`
+	SSHHostKey  string `json:"ssh_host_key"`
+}
+
+// apply copies the request onto a host and validates it
+func (req *DockerHostRequest) apply(host *models.DockerHost) error {
+	host.Name = strings.TrimSpace(req.Name)
+	host.URL = strings.TrimSpace(req.URL)
+	host.TLSCertPath = strings.TrimSpace(req.TLSCertPath)
+	host.SSHKeyPath = strings.TrimSpace(req.SSHKeyPath)
+	host.SSHHostKey = strings.TrimSpace(req.SSHHostKey)
+	return host.Validate()
+}
+
+// List handles GET /api/docker-hosts
+func (h *DockerHostHandler) List(w http.ResponseWriter, r *http.Request) {
+	hosts, err := h.hostQueries.List(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list Docker hosts", "error", err)
+		http.Error(w, "failed to list Docker hosts", http.StatusInternalServerError)
+		return
+	}
+	if hosts == nil {
+		hosts = []*models.DockerHost{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hosts)
+}
+
+// Create handles POST /api/docker-hosts
+func (h *DockerHostHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req DockerHostRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	host := &models.DockerHost{
+		ID:        uuid.New().String(),
+		CreatedAt: time.Now(),
+	}
+	if err := req.apply(host); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.hostQueries.Create(r.Context(), host); err != nil {
+		slog.ErrorContext(r.Context(), "failed to create Docker host", "error", err)
+		http.Error(w, "failed to create Docker host: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Docker host added", "id", host.ID, "name", host.Name, "url", host.URL)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(host)
+}
+
+// Update handles PUT /api/docker-hosts/{hostID}
+func (h *DockerHostHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	hostID := chi.URLParam(r, "hostID")
+
+	host, err := h.hostQueries.GetByID(ctx, hostID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get Docker host", "id", hostID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if host == nil {
+		http.Error(w, "Docker host not found", http.StatusNotFound)
+		return
+	}
+
+	var req DockerHostRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.apply(host); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.hostQueries.Update(ctx, host); err != nil {
+		slog.ErrorContext(ctx, "failed to update Docker host", "id", hostID, "error", err)
+		http.Error(w, "failed to update Docker host: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Reconnect with the new settings on next use
+	h.hosts.Forget(host.ID)
+
+	slog.InfoContext(ctx, "Docker host updated", "id", host.ID, "name", host.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(host)
+}
+
+// Delete handles DELETE /api/docker-hosts/{hostID}
+func (h *DockerHostHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	hostID := chi.URLParam(r, "hostID")
+
+	count, err := h.hostQueries.CountApps(ctx, hostID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to count apps on Docker host", "id", hostID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if count > 0 {
+		http.Error(w, fmt.Sprintf("%d app(s) still deploy to this host, move them first", count), http.StatusConflict)
+		return
+	}
+
+	if err := h.hostQueries.Delete(ctx, hostID); err != nil {
+		slog.ErrorContext(ctx, "failed to delete Docker host", "id", hostID, "error", err)
+		http.Error(w, "failed to delete Docker host", http.StatusInternalServerError)
+		return
+	}
+	h.hosts.Forget(hostID)
+
+	slog.InfoContext(ctx, "Docker host removed", "id", hostID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Test handles POST /api/docker-hosts/{hostID}/test
+func (h *DockerHostHandler) Test(w http.ResponseWriter, r *http.Request) {
+	hostID := chi.URLParam(r, "hostID")
+
+	host, err := h.hostQueries.GetByID(r.Context(), hostID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to get Docker host", "id", hostID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if host == nil {
+		http.Error(w, "Docker host not found", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), dockerHostTestTimeout)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := h.hosts.Test(ctx, host); err != nil {
+		slog.WarnContext(r.Context(), "Docker host test failed", "name", host.Name, "error", err)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Connected to " + host.Name,
+	})
+}
+
+// dockerHostSelect renders the Docker host picker of the app forms
+func dockerHostSelect(hosts []*models.DockerHost, selectedID string) string {
+	var b strings.Builder
+	b.WriteString(`<select name="docker_host" class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">`)
+	fmt.Fprintf(&b, `<option value="" %s>Local</option>`, selected(selectedID == ""))
+	for _, host := range hosts {
+		fmt.Fprintf(&b, `<option value="%s" %s>%s</option>`,
+			html.EscapeString(host.ID), selected(host.ID == selectedID), html.EscapeString(host.Name))
+	}
+	b.WriteString(`</select>`)
+	return b.String()
+}
+
+func (h *PageHandler) renderDockerHostSettings(w http.ResponseWriter) {
+	if h.hostQueries == nil {
+		return
+	}
+
+	fmt.Fprint(w, `
+        <div class="mt-8">
+            <h2 class="text-xl font-bold mb-4">Docker Hosts</h2>
+            <div class="bg-white shadow-sm rounded-lg p-6 border border-gray-200">
+                <p class="text-gray-500 mb-4">Remote Docker daemons apps can build and deploy on instead of the local one. Pick the host in an app's settings. Use <code class="bg-gray-100 px-1 rounded">tcp://host:2376</code> with a directory holding <code class="bg-gray-100 px-1 rounded">ca.pem</code>, <code class="bg-gray-100 px-1 rounded">cert.pem</code> and <code class="bg-gray-100 px-1 rounded">key.pem</code>, or <code class="bg-gray-100 px-1 rounded">ssh://user@host</code> with a private key.</p>
+                <table class="w-full text-sm mb-6">
+                    <thead>
+                        <tr class="text-left text-gray-500 border-b border-gray-200">
+                            <th class="py-2">Name</th>
+                            <th class="py-2">URL</th>
+                            <th class="py-2"></th>
+                        </tr>
+                    </thead>
+                    <tbody id="docker-hosts-body">
+                        <tr><td colspan="3" class="py-2 text-gray-400">Loading...</td></tr>
+                    </tbody>
+                </table>
+                <form onsubmit="submitDockerHost(event)">
+                    <div class="grid grid-cols-1 md:grid-cols-2 gap-4 mb-4">
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Name</label>
+                            <input type="text" name="name" required placeholder="builder"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">URL</label>
+                            <input type="text" name="url" required placeholder="ssh://deploy@vps.example.com"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">TLS Certificate Directory</label>
+                            <input type="text" name="tls_cert_path" placeholder="/data/certs/builder"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                            <p class="text-xs text-gray-400 mt-1">For tcp:// hosts</p>
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">SSH Private Key</label>
+                            <input type="text" name="ssh_key_path" placeholder="/data/ssh/id_ed25519"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                            <p class="text-xs text-gray-400 mt-1">For ssh:// hosts</p>
+                        </div>
+                        <div class="md:col-span-2">
+                            <label class="block text-sm text-gray-500 mb-1">SSH Host Key</label>
+                            <input type="text" name="ssh_host_key" placeholder="ssh-ed25519 AAAA..."
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900 font-mono text-sm">
+                            <p class="text-xs text-gray-400 mt-1">Output of <code>ssh-keyscan</code> without the host name; blank to check ~/.ssh/known_hosts</p>
+                        </div>
+                    </div>
+                    <button type="submit" class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white">Add Host</button>
+                </form>
+            </div>
+        </div>
+        <script>
+            function escapeHostText(s) {
+                const div = document.createElement('div');
+                div.textContent = s == null ? '' : String(s);
+                return div.innerHTML;
+            }
+
+            function loadDockerHosts() {
+                fetch('/api/docker-hosts')
+                    .then(response => response.json())
+                    .then(hosts => {
+                        const body = document.getElementById('docker-hosts-body');
+                        if (hosts.length === 0) {
+                            body.innerHTML = '<tr><td colspan="3" class="py-2 text-gray-400">No remote hosts, apps deploy locally</td></tr>';
+                            return;
+                        }
+                        body.innerHTML = hosts.map(host =>
+                            '<tr class="border-b border-gray-100">' +
+                            '<td class="py-2">' + escapeHostText(host.name) + '</td>' +
+                            '<td class="py-2 font-mono text-xs">' + escapeHostText(host.url) + '</td>' +
+                            '<td class="py-2 text-right space-x-2">' +
+                            '<button onclick="testDockerHost(\'' + host.id + '\')" class="text-blue-600 hover:text-blue-700">Test</button>' +
+                            '<button onclick="deleteDockerHost(\'' + host.id + '\')" class="text-red-600 hover:text-red-700">Remove</button>' +
+                            '</td></tr>'
+                        ).join('');
+                    });
+            }
+
+            function submitDockerHost(event) {
+                event.preventDefault();
+                const form = event.target;
+                const data = {};
+                ['name', 'url', 'tls_cert_path', 'ssh_key_path', 'ssh_host_key'].forEach(field => {
+                    data[field] = form.querySelector('input[name="' + field + '"]').value;
+                });
+
+                fetch('/api/docker-hosts', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify(data)
+                })
+                .then(response => {
+                    if (response.ok) {
+                        form.reset();
+                        showToast('Docker host added', 'success');
+                        loadDockerHosts();
+                    } else {
+                        response.text().then(text => alert('Failed to add host: ' + text));
+                    }
+                });
+            }
+
+            function testDockerHost(id) {
+                fetch('/api/docker-hosts/' + id + '/test', { method: 'POST' })
+                    .then(response => response.json())
+                    .then(result => showToast(result.message, result.success ? 'success' : 'error'));
+            }
+
+            function deleteDockerHost(id) {
+                if (!confirm('Remove this Docker host? Containers already running on it are left alone.')) {
+                    return;
+                }
+                fetch('/api/docker-hosts/' + id, { method: 'DELETE' })
+                    .then(response => {
+                        if (response.ok) {
+                            showToast('Docker host removed', 'success');
+                            loadDockerHosts();
+                        } else {
+                            response.text().then(text => alert('Failed to remove host: ' + text));
+                        }
+                    });
+            }
+
+            loadDockerHosts();
+        </script>`)
+}
+
+// listDockerHosts lists the remote Docker hosts for the app forms
+func (h *PageHandler) listDockerHosts(ctx context.Context) []*models.DockerHost {
+	if h.hostQueries == nil {
+		return nil
+	}
+	hosts, err := h.hostQueries.List(ctx)
+	if err != nil {
+		slog.Warn("failed to list Docker hosts", "error", err)
+	}
+	return hosts
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"schooner/internal/events"
+)
+
+func TestEventsStream(t *testing.T) {
+	bus := events.NewBus()
+	server := httptest.NewServer(http.HandlerFunc(NewEventsHandler(bus).Stream))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	// The stream subscribes before its first write
+	lines := bufio.NewScanner(resp.Body)
+	if !lines.Scan() || lines.Text() != ": connected" {
+		t.Fatalf("first line = %q, want the connected comment", lines.Text())
+	}
+
+	bus.Publish(events.Event{Kind: events.KindBuild, AppID: "api", BuildID: "b1", Status: "success"})
+	var event, data string
+	for lines.Scan() && data == "" {
+		line := lines.Text()
+		if v, ok := strings.CutPrefix(line, "event: "); ok {
+			event = v
+		}
+		if v, ok := strings.CutPrefix(line, "data: "); ok {
+			data = v
+		}
+	}
+	if event != "change" {
+		t.Errorf("event = %q, want change", event)
+	}
+	var got events.Event
+	if err := json.Unmarshal([]byte(data), &got); err != nil {
+		t.Fatalf("data %q: %v", data, err)
+	}
+	if got.Kind != events.KindBuild || got.AppID != "api" || got.BuildID != "b1" || got.Status != "success" {
+		t.Errorf("received %+v, want the published build change", got)
+	}
+}
@@ -1,16 +1,34 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"time"
 
+	"schooner/internal/build"
+	"schooner/internal/cloudflare"
+	"schooner/internal/database"
+	"schooner/internal/database/queries"
+	"schooner/internal/docker"
 	"schooner/internal/health"
+	"schooner/internal/models"
 )
 
+// diskTrendWindow is how much history the disk full estimate is fitted to
+const diskTrendWindow = 7 * 24 * time.Hour
+
 // HealthHandler handles health check requests
 type HealthHandler struct {
 	startTime time.Time
+	history   *queries.SystemHealthQueries
+	db        *database.DB
+
+	// Only needed by readiness checks
+	dockerClient  *docker.Client
+	orchestrator  *build.Orchestrator
+	tunnelManager *cloudflare.Manager
 }
 
 // NewHealthHandler creates a new HealthHandler
@@ -20,21 +38,46 @@ func NewHealthHandler() *HealthHandler {
 	}
 }
 
+// SetHistory sets where recorded host health is read from
+func (h *HealthHandler) SetHistory(history *queries.SystemHealthQueries) {
+	h.history = history
+}
+
+// SetDatabase sets the database whose health the check reports
+func (h *HealthHandler) SetDatabase(db *database.DB) {
+	h.db = db
+}
+
 // HealthResponse represents the health check response
 type HealthResponse struct {
-	Status  string `json:"status"`
-	Uptime  string `json:"uptime"`
-	Version string `json:"version,omitempty"`
+	Status   string           `json:"status"`
+	Uptime   string           `json:"uptime"`
+	Version  string           `json:"version,omitempty"`
+	Database *database.Health `json:"database,omitempty"`
 }
 
-// Check handles GET /health
+// Check handles GET /health and GET /healthz - fails with 503 when the
+// database can't be used
 func (h *HealthHandler) Check(w http.ResponseWriter, r *http.Request) {
 	response := HealthResponse{
 		Status: "ok",
 		Uptime: time.Since(h.startTime).Round(time.Second).String(),
 	}
 
+	status := http.StatusOK
+	if h.db != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+		dbHealth := h.db.Health(ctx)
+		response.Database = &dbHealth
+		if dbHealth.Status != "ok" {
+			response.Status = "degraded"
+			status = http.StatusServiceUnavailable
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(response)
 }
 
@@ -83,6 +126,69 @@ func (h *HealthHandler) GetSystemHealth(w http.ResponseWriter, r *http.Request)
 		response["uptime_display"] = health.FormatDuration(systemHealth.Uptime)
 	}
 
+	gpu := map[string]interface{}{
+		"count":  systemHealth.GPU.Count,
+		"models": systemHealth.GPU.Models,
+	}
+	if h.dockerClient != nil {
+		nvidia, err := h.dockerClient.HasRuntime(r.Context(), "nvidia")
+		if err != nil {
+			slog.WarnContext(r.Context(), "failed to check for the nvidia runtime", "error", err)
+		}
+		gpu["nvidia_runtime"] = nvidia
+	}
+	response["gpu"] = gpu
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSystemHealthHistory handles GET /api/health/system/history?range=24h
+func (h *HealthHandler) GetSystemHealthHistory(w http.ResponseWriter, r *http.Request) {
+	if h.history == nil {
+		http.Error(w, "system health history not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	name := r.URL.Query().Get("range")
+	if name == "" {
+		name = "24h"
+	}
+	rng, ok := resourceRanges[name]
+	if !ok {
+		http.Error(w, "range must be one of 1h, 6h, 24h, 7d or 30d", http.StatusBadRequest)
+		return
+	}
+
+	// The disk trend always looks at the last week, whatever the range
+	now := time.Now()
+	since := now.Add(-max(rng.span, diskTrendWindow))
+	samples, err := h.history.ListSince(r.Context(), since)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list system samples", "error", err)
+		http.Error(w, "failed to get system health history", http.StatusInternalServerError)
+		return
+	}
+
+	var trend, inRange []*models.SystemSample
+	for _, s := range samples {
+		if now.Sub(s.SampledAt) <= diskTrendWindow {
+			trend = append(trend, s)
+		}
+		if now.Sub(s.SampledAt) <= rng.span {
+			inRange = append(inRange, s)
+		}
+	}
+
+	response := map[string]interface{}{
+		"range":        name,
+		"step_seconds": int(rng.step.Seconds()),
+		"samples":      models.DownsampleSystem(inRange, rng.step),
+	}
+	if remaining, ok := models.PredictDiskFull(trend); ok {
+		response["disk_full_in_days"] = remaining.Hours() / 24
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
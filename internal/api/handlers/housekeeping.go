@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/maintenance"
+	"schooner/internal/models"
+)
+
+// housekeepingReports is how many housekeeping runs are listed
+const housekeepingReports = 20
+
+// HousekeepingHandler handles scheduled Docker image, volume and network
+// cleanup
+type HousekeepingHandler struct {
+	maintenanceQueries *queries.MaintenanceQueries
+	settingsQueries    *queries.SettingsQueries
+	manager            *maintenance.Manager
+}
+
+// NewHousekeepingHandler creates a new HousekeepingHandler
+func NewHousekeepingHandler(maintenanceQueries *queries.MaintenanceQueries, settingsQueries *queries.SettingsQueries, manager *maintenance.Manager) *HousekeepingHandler {
+	return &HousekeepingHandler{
+		maintenanceQueries: maintenanceQueries,
+		settingsQueries:    settingsQueries,
+		manager:            manager,
+	}
+}
+
+// GetConfig handles GET /api/settings/housekeeping
+func (h *HousekeepingHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	cfg, err := maintenance.LoadConfig(r.Context(), h.settingsQueries)
+	if err != nil {
+		http.Error(w, "failed to load housekeeping settings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cfg)
+}
+
+// SetConfig handles POST /api/settings/housekeeping
+func (h *HousekeepingHandler) SetConfig(w http.ResponseWriter, r *http.Request) {
+	var cfg maintenance.Config
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	cfg.PruneSchedule = strings.TrimSpace(cfg.PruneSchedule)
+	if err := cfg.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := maintenance.SaveConfig(r.Context(), h.settingsQueries, cfg); err != nil {
+		slog.ErrorContext(r.Context(), "failed to save housekeeping settings", "error", err)
+		http.Error(w, "failed to save housekeeping settings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Housekeeping settings saved",
+	})
+}
+
+// List handles GET /api/housekeeping, the latest run reports
+func (h *HousekeepingHandler) List(w http.ResponseWriter, r *http.Request) {
+	runs, err := h.maintenanceQueries.ListRuns(r.Context(), housekeepingReports)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list housekeeping runs", "error", err)
+		http.Error(w, "failed to list housekeeping runs", http.StatusInternalServerError)
+		return
+	}
+	if runs == nil {
+		runs = []*models.MaintenanceRun{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runs)
+}
+
+// Run handles POST /api/housekeeping. The cleanup runs in the background.
+func (h *HousekeepingHandler) Run(w http.ResponseWriter, r *http.Request) {
+	if h.manager == nil {
+		http.Error(w, "Docker client not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	go func() {
+		if _, err := h.manager.Run(context.Background(), models.MaintenanceManual); err != nil {
+			if errors.Is(err, maintenance.ErrRunning) {
+				return
+			}
+			slog.ErrorContext(r.Context(), "housekeeping failed", "error", err)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Cleanup started",
+	})
+}
+
+func (h *PageHandler) renderHousekeepingSettings(w http.ResponseWriter) {
+	if h.dockerClient == nil {
+		return
+	}
+
+	fmt.Fprint(w, `
+        <div class="mt-8">
+            <h2 class="text-xl font-bold mb-4">Docker Housekeeping</h2>
+            <div class="bg-white shadow-sm rounded-lg p-6 border border-gray-200">
+                <p class="text-gray-500 mb-4">Removes old app images, dangling images, anonymous volumes and networks nothing uses.
+                    Named volumes, such as add-on data, are never removed. When the disk passes the threshold an emergency cleanup also keeps just one image per app and clears the build cache.</p>
+                <form onsubmit="saveHousekeepingConfig(event)" class="mb-6">
+                    <div class="grid grid-cols-1 md:grid-cols-3 gap-4 mb-4">
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Images kept per app</label>
+                            <input type="number" id="housekeeping-keep" min="0"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                            <p class="text-xs text-gray-400 mt-1">0 keeps all; older images are what rollbacks use</p>
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Prune schedule (cron)</label>
+                            <input type="text" id="housekeeping-schedule" placeholder="Blank to disable"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900 font-mono">
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Emergency cleanup at disk use (%)</label>
+                            <input type="number" id="housekeeping-threshold" min="0" max="99"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                            <p class="text-xs text-gray-400 mt-1">0 disables</p>
+                        </div>
+                    </div>
+                    <button type="submit" class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white">Save</button>
+                    <button type="button" onclick="runHousekeeping()" class="px-4 py-2 bg-gray-100 hover:bg-gray-200 rounded text-gray-900 ml-2">Clean up now</button>
+                </form>
+                <table class="w-full text-sm">
+                    <thead>
+                        <tr class="text-left text-gray-500 border-b border-gray-200">
+                            <th class="py-2">Ran</th>
+                            <th class="py-2">Trigger</th>
+                            <th class="py-2">Removed</th>
+                            <th class="py-2">Reclaimed</th>
+                            <th class="py-2">Status</th>
+                        </tr>
+                    </thead>
+                    <tbody id="housekeeping-runs">
+                        <tr><td colspan="5" class="py-2 text-gray-400">Loading...</td></tr>
+                    </tbody>
+                </table>
+            </div>
+        </div>
+        <script>
+            function escapeHousekeepingText(text) {
+                const div = document.createElement('div');
+                div.textContent = text;
+                return div.innerHTML;
+            }
+
+            function formatReclaimed(bytes) {
+                const units = ['B', 'KB', 'MB', 'GB', 'TB'];
+                let i = 0;
+                while (bytes >= 1024 && i < units.length - 1) {
+                    bytes /= 1024;
+                    i++;
+                }
+                return (i === 0 ? bytes : bytes.toFixed(1)) + ' ' + units[i];
+            }
+
+            function loadHousekeepingConfig() {
+                fetch('/api/settings/housekeeping')
+                    .then(response => response.json())
+                    .then(config => {
+                        document.getElementById('housekeeping-keep').value = config.keep_images;
+                        document.getElementById('housekeeping-schedule').value = config.prune_schedule || '';
+                        document.getElementById('housekeeping-threshold').value = config.disk_threshold;
+                    });
+            }
+
+            function loadHousekeepingRuns() {
+                fetch('/api/housekeeping')
+                    .then(response => response.json())
+                    .then(runs => {
+                        const body = document.getElementById('housekeeping-runs');
+                        if (runs.length === 0) {
+                            body.innerHTML = '<tr><td colspan="5" class="py-2 text-gray-400">No cleanups yet</td></tr>';
+                            return;
+                        }
+                        const triggers = { scheduled: 'Schedule', manual: 'Manual', disk_pressure: 'Disk pressure' };
+                        const colors = { success: 'text-green-600', failed: 'text-red-600', running: 'text-yellow-600' };
+                        body.innerHTML = runs.map(run => {
+                            const removed = run.images_removed + ' images, ' + run.volumes_removed + ' volumes, ' + run.networks_removed + ' networks';
+                            return '<tr class="border-b border-gray-100">' +
+                                '<td class="py-2 text-gray-500">' + escapeHousekeepingText(new Date(run.started_at).toLocaleString()) + '</td>' +
+                                '<td class="py-2">' + escapeHousekeepingText(triggers[run.trigger] || run.trigger) + '</td>' +
+                                '<td class="py-2">' + removed + '</td>' +
+                                '<td class="py-2">' + formatReclaimed(run.space_reclaimed) + '</td>' +
+                                '<td class="py-2 ' + (colors[run.status] || '') + '" title="' + escapeHousekeepingText(run.error || '') + '">' + escapeHousekeepingText(run.status) + '</td>' +
+                                '</tr>';
+                        }).join('');
+                    });
+            }
+
+            function saveHousekeepingConfig(event) {
+                event.preventDefault();
+                const data = {
+                    keep_images: parseInt(document.getElementById('housekeeping-keep').value, 10) || 0,
+                    prune_schedule: document.getElementById('housekeeping-schedule').value.trim(),
+                    disk_threshold: parseInt(document.getElementById('housekeeping-threshold').value, 10) || 0
+                };
+                fetch('/api/settings/housekeeping', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify(data)
+                })
+                .then(response => {
+                    if (response.ok) {
+                        showToast('Housekeeping settings saved', 'success');
+                    } else {
+                        response.text().then(text => alert('Failed to save: ' + text));
+                    }
+                });
+            }
+
+            function runHousekeeping() {
+                fetch('/api/housekeeping', { method: 'POST' })
+                    .then(response => {
+                        if (response.ok) {
+                            showToast('Cleanup started', 'success');
+                            setTimeout(loadHousekeepingRuns, 3000);
+                        } else {
+                            response.text().then(text => alert('Failed to start cleanup: ' + text));
+                        }
+                    });
+            }
+
+            loadHousekeepingConfig();
+            loadHousekeepingRuns();
+        </script>`)
+}
@@ -6,8 +6,10 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -20,6 +22,9 @@ import (
 	"schooner/internal/models"
 )
 
+// maxGitHubPerPage is the largest page GitHub returns
+const maxGitHubPerPage = 100
+
 // ImportHandler handles GitHub import requests
 type ImportHandler struct {
 	cfg          *config.Config
@@ -36,7 +41,30 @@ func NewImportHandler(cfg *config.Config, githubClient *github.Client, appQuerie
 	}
 }
 
-// ListRepos handles GET /api/github/repos - lists user's GitHub repositories
+// ListOrgs handles GET /api/github/orgs - lists the user's GitHub organizations
+func (h *ImportHandler) ListOrgs(w http.ResponseWriter, r *http.Request) {
+	if !h.githubClient.HasToken() {
+		http.Error(w, "GitHub token not configured", http.StatusBadRequest)
+		return
+	}
+
+	orgs, err := h.githubClient.ListOrgs(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list GitHub organizations", "error", err)
+		http.Error(w, "failed to list organizations: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if orgs == nil {
+		orgs = []github.Organization{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orgs)
+}
+
+// ListRepos handles GET /api/github/repos - lists GitHub repositories, the
+// user's or an organization's (?owner=), optionally searched by name (?q=).
+// A Link header with rel="next" points to the next page.
 func (h *ImportHandler) ListRepos(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -54,10 +82,20 @@ func (h *ImportHandler) ListRepos(w http.ResponseWriter, r *http.Request) {
 	if perPage <= 0 {
 		perPage = 30
 	}
+	if perPage > maxGitHubPerPage {
+		perPage = maxGitHubPerPage
+	}
 
-	repos, err := h.githubClient.ListUserRepos(ctx, page, perPage)
+	owner := strings.TrimSpace(r.URL.Query().Get("owner"))
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	repos, hasNext, err := h.githubClient.ListRepos(ctx, github.RepoListOptions{
+		Owner:   owner,
+		Query:   query,
+		Page:    page,
+		PerPage: perPage,
+	})
 	if err != nil {
-		slog.Error("failed to list GitHub repos", "error", err)
+		slog.ErrorContext(ctx, "failed to list GitHub repos", "error", err)
 		http.Error(w, "failed to list repositories: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -65,7 +103,7 @@ func (h *ImportHandler) ListRepos(w http.ResponseWriter, r *http.Request) {
 	// Get existing apps to mark which repos are already imported
 	existingApps, err := h.appQueries.List(ctx)
 	if err != nil {
-		slog.Error("failed to list apps", "error", err)
+		slog.ErrorContext(ctx, "failed to list apps", "error", err)
 	}
 
 	// Create a map of repo URLs to check for duplicates
@@ -79,10 +117,12 @@ func (h *ImportHandler) ListRepos(w http.ResponseWriter, r *http.Request) {
 	// Enhance repo info with import status
 	type RepoWithStatus struct {
 		github.Repository
-		AlreadyImported bool   `json:"already_imported"`
-		HasDockerfile   bool   `json:"has_dockerfile"`
-		HasCompose      bool   `json:"has_compose"`
-		ComposeFile     string `json:"compose_file,omitempty"`
+		AlreadyImported bool     `json:"already_imported"`
+		HasDockerfile   bool     `json:"has_dockerfile"`
+		HasCompose      bool     `json:"has_compose"`
+		ComposeFile     string   `json:"compose_file,omitempty"`
+		ExposedPorts    []int    `json:"exposed_ports,omitempty"`
+		EnvKeys         []string `json:"env_keys,omitempty"`
 	}
 
 	result := make([]RepoWithStatus, len(repos))
@@ -93,14 +133,31 @@ func (h *ImportHandler) ListRepos(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Check for Dockerfile and docker-compose (do this in parallel for better performance in future)
-		if hasDockerfile, _ := h.githubClient.CheckRepoHasDockerfile(ctx, strings.Split(repo.FullName, "/")[0], strings.Split(repo.FullName, "/")[1]); hasDockerfile {
+		repoOwner, repoName, _ := strings.Cut(repo.FullName, "/")
+		if dockerfile, _ := h.githubClient.GetFileContent(ctx, repoOwner, repoName, "Dockerfile"); dockerfile != nil {
 			result[i].HasDockerfile = true
+			result[i].ExposedPorts = github.ExposedPorts(dockerfile)
 		}
 
-		if hasCompose, composeFile, _ := h.githubClient.CheckRepoHasDockerCompose(ctx, strings.Split(repo.FullName, "/")[0], strings.Split(repo.FullName, "/")[1]); hasCompose {
+		if hasCompose, composeFile, _ := h.githubClient.CheckRepoHasDockerCompose(ctx, repoOwner, repoName); hasCompose {
 			result[i].HasCompose = true
 			result[i].ComposeFile = composeFile
 		}
+
+		// Variables the app expects, to prefill the import form
+		if envExample, _ := h.githubClient.GetFileContent(ctx, repoOwner, repoName, ".env.example"); envExample != nil {
+			result[i].EnvKeys = github.EnvExampleKeys(envExample)
+		}
+	}
+
+	if hasNext {
+		next := url.Values{
+			"owner":    {owner},
+			"q":        {query},
+			"page":     {strconv.Itoa(page + 1)},
+			"per_page": {strconv.Itoa(perPage)},
+		}
+		w.Header().Add("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, next.Encode()))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -112,10 +169,12 @@ func (h *ImportHandler) ImportRepo(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var req struct {
-		RepoFullName  string `json:"repo_full_name"` // e.g., "owner/repo"
-		BuildStrategy string `json:"build_strategy"` // dockerfile, compose
-		AutoDeploy    bool   `json:"auto_deploy"`
-		Branch        string `json:"branch"`
+		RepoFullName  string            `json:"repo_full_name"` // e.g., "owner/repo"
+		BuildStrategy string            `json:"build_strategy"` // dockerfile, compose
+		AutoDeploy    bool              `json:"auto_deploy"`
+		Branch        string            `json:"branch"`
+		PublicPort    int               `json:"public_port"`
+		EnvVars       map[string]string `json:"env_vars"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid request body", http.StatusBadRequest)
@@ -138,7 +197,7 @@ func (h *ImportHandler) ImportRepo(w http.ResponseWriter, r *http.Request) {
 	// Fetch repo details from GitHub
 	repo, err := h.githubClient.GetRepo(ctx, owner, repoName)
 	if err != nil {
-		slog.Error("failed to get repo from GitHub", "repo", req.RepoFullName, "error", err)
+		slog.ErrorContext(ctx, "failed to get repo from GitHub", "repo", req.RepoFullName, "error", err)
 		http.Error(w, "failed to get repository: "+err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -188,14 +247,25 @@ func (h *ImportHandler) ImportRepo(w http.ResponseWriter, r *http.Request) {
 		BuildContext:   ".",
 		ContainerName:  sql.NullString{String: repo.Name, Valid: true},
 		ImageName:      sql.NullString{String: repo.Name, Valid: true},
+		EnvVars:        req.EnvVars,
+		PublicPort:     sql.NullInt64{Int64: int64(req.PublicPort), Valid: req.PublicPort > 0},
 		AutoDeploy:     req.AutoDeploy,
 		Enabled:        true,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}
 
+	if err := app.SaveEnvVars(); err != nil {
+		slog.ErrorContext(ctx, "failed to save env vars", "error", err)
+		http.Error(w, "failed to save env vars", http.StatusInternalServerError)
+		return
+	}
+
 	if err := h.appQueries.Create(ctx, app); err != nil {
-		slog.Error("failed to create app from import", "error", err)
+		if portConflict(w, err) {
+			return
+		}
+		slog.ErrorContext(ctx, "failed to create app from import", "error", err)
 		http.Error(w, "failed to create app: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -203,15 +273,15 @@ func (h *ImportHandler) ImportRepo(w http.ResponseWriter, r *http.Request) {
 	// Auto-install GitHub webhook
 	webhookInstalled := false
 	hasToken := h.githubClient.HasToken()
-	baseURL := h.cfg.Server.BaseURL
-	slog.Info("webhook install check", "hasToken", hasToken, "baseURL", baseURL)
+	baseURL := h.cfg.BaseURL()
+	slog.InfoContext(ctx, "webhook install check", "hasToken", hasToken, "baseURL", baseURL)
 	if hasToken && baseURL != "" {
 		webhookInstalled = h.installWebhook(ctx, app, owner, repoName)
 	} else {
-		slog.Warn("skipping webhook install", "hasToken", hasToken, "hasBaseURL", baseURL != "")
+		slog.WarnContext(ctx, "skipping webhook install", "hasToken", hasToken, "hasBaseURL", baseURL != "")
 	}
 
-	slog.Info("app imported from GitHub", "id", app.ID, "name", app.Name, "repo", req.RepoFullName, "webhookInstalled", webhookInstalled)
+	slog.InfoContext(ctx, "app imported from GitHub", "id", app.ID, "name", app.Name, "repo", req.RepoFullName, "webhookInstalled", webhookInstalled)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -235,7 +305,7 @@ func (h *ImportHandler) installWebhook(ctx context.Context, app *models.App, own
 	}
 
 	// Build webhook URL
-	webhookURL := h.cfg.Server.BaseURL + "/webhook/github/" + app.ID
+	webhookURL := h.cfg.BaseURL() + "/webhook/github/" + app.ID
 
 	// Create webhook
 	webhook, created, err := h.githubClient.EnsureWebhook(ctx, owner, repo, webhookURL, secret)
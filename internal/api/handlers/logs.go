@@ -3,10 +3,12 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"html"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -36,6 +38,24 @@ type LogSource struct {
 	Type string `json:"type"` // "app" or "service"
 }
 
+// infraLogSources are the schooner-managed service containers whose logs can be queried
+var infraLogSources = []LogSource{
+	{ID: "schooner-loki", Name: "Loki", Type: "service"},
+	{ID: "schooner-promtail", Name: "Promtail", Type: "service"},
+	{ID: "schooner-grafana", Name: "Grafana", Type: "service"},
+	{ID: "schooner-cloudflared", Name: "Cloudflare Tunnel", Type: "service"},
+}
+
+// isInfraLogSource returns true if id is a known infrastructure container
+func isInfraLogSource(id string) bool {
+	for _, s := range infraLogSources {
+		if s.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
 // ListSources handles GET /api/logs - lists available log sources
 func (h *LogsHandler) ListSources(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -48,7 +68,7 @@ func (h *LogsHandler) ListSources(w http.ResponseWriter, r *http.Request) {
 	// Get all apps
 	apps, err := h.appQueries.List(ctx)
 	if err != nil {
-		slog.Error("failed to list apps", "error", err)
+		slog.ErrorContext(ctx, "failed to list apps", "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -63,13 +83,7 @@ func (h *LogsHandler) ListSources(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Add infrastructure services
-	infraServices := []LogSource{
-		{ID: "schooner-loki", Name: "Loki", Type: "service"},
-		{ID: "schooner-promtail", Name: "Promtail", Type: "service"},
-		{ID: "schooner-grafana", Name: "Grafana", Type: "service"},
-		{ID: "schooner-cloudflared", Name: "Cloudflare Tunnel", Type: "service"},
-	}
-	sources = append(sources, infraServices...)
+	sources = append(sources, infraLogSources...)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(sources)
@@ -103,7 +117,7 @@ func (h *LogsHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
 
 	// Build Loki query
 	var query string
-	if appID == "schooner-loki" || appID == "schooner-promtail" || appID == "schooner-grafana" || appID == "schooner-cloudflared" {
+	if isInfraLogSource(appID) {
 		// Infrastructure service - query by container name
 		query = fmt.Sprintf(`{container="%s"}`, appID)
 	} else {
@@ -123,7 +137,7 @@ func (h *LogsHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := http.Get(queryURL)
 	if err != nil {
-		slog.Error("failed to query Loki", "error", err, "url", queryURL)
+		slog.ErrorContext(ctx, "failed to query Loki", "error", err, "url", queryURL)
 		http.Error(w, "failed to query logs", http.StatusInternalServerError)
 		return
 	}
@@ -135,6 +149,112 @@ func (h *LogsHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
 	io.Copy(w, resp.Body)
 }
 
+// Search handles GET /api/logs/search - searches logs across apps via Loki.
+// Filters are validated server-side and compiled to LogQL, so callers can't
+// query arbitrary label sets.
+func (h *LogsHandler) Search(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if h.observabilityManager == nil || !h.observabilityManager.IsEnabled(ctx) {
+		http.Error(w, "observability not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	search, err := h.parseSearch(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.observabilityManager.SearchLogs(ctx, search)
+	if err != nil {
+		slog.ErrorContext(ctx, "log search failed", "query", search.LogQL(), "error", err)
+		http.Error(w, "failed to query logs", http.StatusBadGateway)
+		return
+	}
+
+	if entries == nil {
+		entries = []observability.LogEntry{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"query":   search.LogQL(),
+		"start":   search.Start,
+		"end":     search.End,
+		"entries": entries,
+	})
+}
+
+// parseSearch builds a LogSearch from query parameters, checking that every
+// app exists and every service is a known infrastructure container
+func (h *LogsHandler) parseSearch(r *http.Request, now time.Time) (*observability.LogSearch, error) {
+	q := r.URL.Query()
+
+	search := &observability.LogSearch{
+		Contains: q.Get("q"),
+		Regex:    q.Get("regex"),
+		Forward:  q.Get("direction") == "forward",
+	}
+
+	for _, appID := range q["app"] {
+		app, err := h.appQueries.GetByID(r.Context(), appID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up app %s", appID)
+		}
+		if app == nil {
+			return nil, fmt.Errorf("unknown app %s", appID)
+		}
+		search.AppIDs = append(search.AppIDs, app.ID)
+	}
+
+	for _, service := range q["service"] {
+		if !isInfraLogSource(service) {
+			return nil, fmt.Errorf("unknown service %s", service)
+		}
+		search.Containers = append(search.Containers, service)
+	}
+
+	if since := q.Get("since"); since != "" {
+		d, err := time.ParseDuration(since)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid since duration %q", since)
+		}
+		search.End = now
+		search.Start = now.Add(-d)
+	} else {
+		var err error
+		if search.Start, err = parseSearchTime(q.Get("start")); err != nil {
+			return nil, fmt.Errorf("invalid start: %w", err)
+		}
+		if search.End, err = parseSearchTime(q.Get("end")); err != nil {
+			return nil, fmt.Errorf("invalid end: %w", err)
+		}
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid limit %q", limit)
+		}
+		search.Limit = n
+	}
+
+	if err := search.Validate(now); err != nil {
+		return nil, err
+	}
+
+	return search, nil
+}
+
+// parseSearchTime parses an RFC3339 timestamp, returning zero for empty input
+func parseSearchTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
 // StreamLogs handles GET /api/logs/{appID}/stream - SSE stream of logs
 func (h *LogsHandler) StreamLogs(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -159,7 +279,7 @@ func (h *LogsHandler) StreamLogs(w http.ResponseWriter, r *http.Request) {
 
 	// Build Loki query
 	var query string
-	if appID == "schooner-loki" || appID == "schooner-promtail" || appID == "schooner-grafana" || appID == "schooner-cloudflared" {
+	if isInfraLogSource(appID) {
 		query = fmt.Sprintf(`{container="%s"}`, appID)
 	} else {
 		query = fmt.Sprintf(`{app_id="%s"}`, appID)
@@ -191,7 +311,7 @@ func (h *LogsHandler) StreamLogs(w http.ResponseWriter, r *http.Request) {
 
 			resp, err := http.Get(queryURL)
 			if err != nil {
-				slog.Debug("failed to query Loki", "error", err)
+				slog.DebugContext(ctx, "failed to query Loki", "error", err)
 				continue
 			}
 
@@ -249,3 +369,135 @@ func parseNanoTimestamp(s string) (int64, error) {
 	_, err := fmt.Sscanf(s, "%d", &ts)
 	return ts, err
 }
+
+// LogSearch handles GET /logs - the cross-app log search page
+func (h *PageHandler) LogSearch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	apps, err := h.appQueries.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list apps", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeHeader(w, r, "Logs")
+
+	fmt.Fprint(w, `<h1 class="text-2xl font-bold mb-6">Log Search</h1>`)
+
+	if h.observabilityManager == nil || !h.observabilityManager.IsEnabled(ctx) {
+		fmt.Fprint(w, `
+        <div class="bg-white shadow-sm rounded-lg p-8 border border-gray-200 text-center">
+            <p class="text-gray-500 mb-4">Log search needs the observability stack (Loki) to be enabled.</p>
+            <a href="/settings" class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded inline-block text-white">Open Settings</a>
+        </div>`)
+		h.writeFooter(w)
+		return
+	}
+
+	fmt.Fprint(w, `
+        <form id="log-search-form" class="bg-white shadow-sm rounded-lg p-6 border border-gray-200 mb-6" onsubmit="searchLogs(event)">
+            <div class="grid grid-cols-1 md:grid-cols-4 gap-4 mb-4">
+                <div class="md:col-span-2">
+                    <label class="block text-sm text-gray-500 mb-1">Search</label>
+                    <input type="text" name="q" placeholder="error" autofocus
+                        class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900 font-mono">
+                    <label class="inline-flex items-center text-sm text-gray-500 mt-1">
+                        <input type="checkbox" name="regex" class="mr-1"> Regex
+                    </label>
+                </div>
+                <div>
+                    <label class="block text-sm text-gray-500 mb-1">Source</label>
+                    <select name="source" class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        <option value="">All apps and services</option>
+                        <optgroup label="Apps">`)
+
+	for _, app := range apps {
+		fmt.Fprintf(w, `<option value="app:%s">%s</option>`, html.EscapeString(app.ID), html.EscapeString(app.Name))
+	}
+
+	fmt.Fprint(w, `</optgroup><optgroup label="Services">`)
+
+	for _, s := range infraLogSources {
+		fmt.Fprintf(w, `<option value="service:%s">%s</option>`, html.EscapeString(s.ID), html.EscapeString(s.Name))
+	}
+
+	fmt.Fprint(w, `
+                        </optgroup>
+                    </select>
+                </div>
+                <div>
+                    <label class="block text-sm text-gray-500 mb-1">Time range</label>
+                    <select name="since" class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        <option value="15m">Last 15 minutes</option>
+                        <option value="1h" selected>Last hour</option>
+                        <option value="6h">Last 6 hours</option>
+                        <option value="24h">Last 24 hours</option>
+                        <option value="168h">Last 7 days</option>
+                    </select>
+                </div>
+            </div>
+            <div class="flex items-center justify-between">
+                <button type="submit" class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white">Search</button>
+                <code id="log-search-query" class="text-xs text-gray-400"></code>
+            </div>
+        </form>
+        <div class="bg-white shadow-sm rounded-lg border border-gray-200 overflow-hidden">
+            <div id="log-search-status" class="px-4 py-2 text-sm text-gray-500 border-b border-gray-200">Enter a search to begin.</div>
+            <div id="log-search-results" class="font-mono text-xs overflow-x-auto max-h-[70vh] overflow-y-auto"></div>
+        </div>
+        <script>
+            function searchLogs(event) {
+                event.preventDefault();
+                const form = event.target;
+                const params = new URLSearchParams();
+                const text = form.querySelector('input[name="q"]').value;
+                if (text) {
+                    params.set(form.querySelector('input[name="regex"]').checked ? 'regex' : 'q', text);
+                }
+                const source = form.querySelector('select[name="source"]').value;
+                if (source.startsWith('app:')) {
+                    params.set('app', source.slice(4));
+                } else if (source.startsWith('service:')) {
+                    params.set('service', source.slice(8));
+                }
+                params.set('since', form.querySelector('select[name="since"]').value);
+
+                const status = document.getElementById('log-search-status');
+                const results = document.getElementById('log-search-results');
+                status.textContent = 'Searching...';
+                results.innerHTML = '';
+
+                fetch('/api/logs/search?' + params.toString())
+                    .then(response => {
+                        if (!response.ok) {
+                            return response.text().then(text => { throw new Error(text); });
+                        }
+                        return response.json();
+                    })
+                    .then(data => {
+                        document.getElementById('log-search-query').textContent = data.query;
+                        status.textContent = data.entries.length + ' lines';
+                        data.entries.forEach(entry => {
+                            const row = document.createElement('div');
+                            row.className = 'px-4 py-1 border-b border-gray-100 whitespace-pre-wrap hover:bg-gray-50';
+                            const ts = document.createElement('span');
+                            ts.className = 'text-gray-400 mr-3';
+                            ts.textContent = new Date(entry.timestamp).toLocaleString();
+                            const src = document.createElement('span');
+                            src.className = 'text-purple-600 mr-3';
+                            src.textContent = entry.labels.app || entry.labels.container || '';
+                            const line = document.createElement('span');
+                            line.textContent = entry.line;
+                            row.append(ts, src, line);
+                            results.appendChild(row);
+                        });
+                    })
+                    .catch(err => {
+                        status.textContent = 'Search failed: ' + err.message;
+                    });
+            }
+        </script>`)
+
+	h.writeFooter(w)
+}
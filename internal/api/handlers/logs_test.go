@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLogsHandler_Search_NotEnabled(t *testing.T) {
+	h := NewLogsHandler(nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/logs/search?q=error", nil)
+	rr := httptest.NewRecorder()
+	h.Search(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+}
+
+func TestLogsHandler_ParseSearch(t *testing.T) {
+	h := NewLogsHandler(nil, nil)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{name: "defaults", query: ""},
+		{name: "known service", query: "service=schooner-loki&q=error"},
+		{name: "unknown service", query: "service=postgres", wantErr: true},
+		{name: "since", query: "since=15m"},
+		{name: "bad since", query: "since=-1h", wantErr: true},
+		{name: "since too wide", query: "since=720h", wantErr: true},
+		{name: "explicit range", query: "start=2024-01-01T10:00:00Z&end=2024-01-01T11:00:00Z"},
+		{name: "bad start", query: "start=yesterday", wantErr: true},
+		{name: "bad limit", query: "limit=abc", wantErr: true},
+		{name: "bad regex", query: "regex=(", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/logs/search?"+tt.query, nil)
+			_, err := h.parseSearch(req, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseSearch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
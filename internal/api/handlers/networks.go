@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/docker"
+)
+
+// networkNamePattern matches the network names Docker accepts
+var networkNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,62}$`)
+
+// NetworkHandler handles listing, creating and removing Docker networks and
+// attaching containers to them
+type NetworkHandler struct {
+	dockerClient *docker.Client
+	appQueries   *queries.AppQueries
+}
+
+// NewNetworkHandler creates a new NetworkHandler
+func NewNetworkHandler(dockerClient *docker.Client, appQueries *queries.AppQueries) *NetworkHandler {
+	return &NetworkHandler{
+		dockerClient: dockerClient,
+		appQueries:   appQueries,
+	}
+}
+
+// networkResponse is a network as returned by the API, with the app it
+// belongs to if it's an app's private network
+type networkResponse struct {
+	docker.Network
+	AppID   string `json:"app_id,omitempty"`
+	AppName string `json:"app_name,omitempty"`
+}
+
+// NetworkRequest is the body for creating a network
+type NetworkRequest struct {
+	Name     string `json:"name"`
+	Internal bool   `json:"internal"` // No route out, containers only reach each other
+}
+
+// NetworkAttachRequest is the body for attaching a container to a network
+// or detaching it
+type NetworkAttachRequest struct {
+	Container string `json:"container"` // Container name or ID
+}
+
+// List handles GET /api/networks
+func (h *NetworkHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if h.dockerClient == nil {
+		http.Error(w, "Docker client not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	networks, err := h.dockerClient.ListNetworks(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list networks", "error", err)
+		http.Error(w, "failed to list networks", http.StatusInternalServerError)
+		return
+	}
+	owners, err := h.networkOwners(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list apps", "error", err)
+		http.Error(w, "failed to list networks", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]networkResponse, len(networks))
+	for i, network := range networks {
+		response[i] = networkResponse{Network: network}
+		if owner, ok := owners[network.Name]; ok {
+			response[i].AppID, response[i].AppName = owner[0], owner[1]
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// networkOwners maps the private network of each app to the app's ID and
+// name
+func (h *NetworkHandler) networkOwners(ctx context.Context) (map[string][2]string, error) {
+	apps, err := h.appQueries.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	owners := make(map[string][2]string, len(apps))
+	for _, app := range apps {
+		owners[app.Network()] = [2]string{app.ID, app.Name}
+	}
+	return owners, nil
+}
+
+// Create handles POST /api/networks
+func (h *NetworkHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if h.dockerClient == nil {
+		http.Error(w, "Docker client not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req NetworkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !networkNamePattern.MatchString(req.Name) {
+		http.Error(w, "network names start with a letter or digit and contain only letters, digits, '_', '.' and '-'", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.dockerClient.CreateNetwork(ctx, req.Name, req.Internal); err != nil {
+		slog.ErrorContext(ctx, "failed to create network", "network", req.Name, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(ctx, "network created", "network", req.Name, "internal", req.Internal)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Network %s created", req.Name),
+	})
+}
+
+// Remove handles DELETE /api/networks/{network}
+func (h *NetworkHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if h.dockerClient == nil {
+		http.Error(w, "Docker client not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	name := chi.URLParam(r, "network")
+	if docker.IsBuiltinNetwork(name) {
+		http.Error(w, fmt.Sprintf("%s is one of Docker's own networks and can't be removed", name), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.dockerClient.RemoveNetwork(ctx, name); err != nil {
+		slog.ErrorContext(ctx, "failed to remove network", "network", name, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(ctx, "network removed", "network", name)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Network %s removed", name),
+	})
+}
+
+// Attach handles POST /api/networks/{network}/attach
+func (h *NetworkHandler) Attach(w http.ResponseWriter, r *http.Request) {
+	h.changeAttachment(w, r, true)
+}
+
+// Detach handles POST /api/networks/{network}/detach
+func (h *NetworkHandler) Detach(w http.ResponseWriter, r *http.Request) {
+	h.changeAttachment(w, r, false)
+}
+
+// changeAttachment attaches a container to a network or detaches it
+func (h *NetworkHandler) changeAttachment(w http.ResponseWriter, r *http.Request, attach bool) {
+	ctx := r.Context()
+	if h.dockerClient == nil {
+		http.Error(w, "Docker client not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	name := chi.URLParam(r, "network")
+	var req NetworkAttachRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Container == "" {
+		http.Error(w, "container is required", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	action := "attached to"
+	if attach {
+		err = h.dockerClient.ConnectToNetwork(ctx, req.Container, name)
+	} else {
+		action = "detached from"
+		err = h.dockerClient.DisconnectFromNetwork(ctx, req.Container, name)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to change network attachment", "network", name, "container", req.Container, "attach", attach, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(ctx, "container "+action+" network", "network", name, "container", req.Container)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("%s %s %s", req.Container, action, name),
+	})
+}
+
+// Networks handles GET /networks
+func (h *PageHandler) Networks(w http.ResponseWriter, r *http.Request) {
+	h.writeHeader(w, r, "Networks")
+	renderTemplate(w, "networks", nil)
+	h.writeFooter(w)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/docker"
+)
+
+func TestNetworkHandler_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		docker  *docker.Client
+		handler func(*NetworkHandler) http.HandlerFunc
+		network string
+		body    string
+		status  int
+	}{
+		{name: "no Docker", handler: func(h *NetworkHandler) http.HandlerFunc { return h.List }, status: http.StatusServiceUnavailable},
+		{name: "invalid body", docker: &docker.Client{}, handler: func(h *NetworkHandler) http.HandlerFunc { return h.Create }, body: "{", status: http.StatusBadRequest},
+		{name: "blank name", docker: &docker.Client{}, handler: func(h *NetworkHandler) http.HandlerFunc { return h.Create }, body: `{"name":""}`, status: http.StatusBadRequest},
+		{name: "name with spaces", docker: &docker.Client{}, handler: func(h *NetworkHandler) http.HandlerFunc { return h.Create }, body: `{"name":"my net"}`, status: http.StatusBadRequest},
+		{name: "remove bridge", docker: &docker.Client{}, handler: func(h *NetworkHandler) http.HandlerFunc { return h.Remove }, network: "bridge", status: http.StatusBadRequest},
+		{name: "attach without container", docker: &docker.Client{}, handler: func(h *NetworkHandler) http.HandlerFunc { return h.Attach }, network: "shared", body: `{}`, status: http.StatusBadRequest},
+		{name: "detach without container", docker: &docker.Client{}, handler: func(h *NetworkHandler) http.HandlerFunc { return h.Detach }, network: "shared", body: `{"container":""}`, status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewNetworkHandler(tt.docker, nil)
+			req := httptest.NewRequest(http.MethodPost, "/api/networks", strings.NewReader(tt.body))
+			routeCtx := chi.NewRouteContext()
+			routeCtx.URLParams.Add("network", tt.network)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+
+			rec := httptest.NewRecorder()
+			tt.handler(h)(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+		})
+	}
+}
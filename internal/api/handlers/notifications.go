@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/notify"
+)
+
+// NotificationHandler handles notification channel settings
+type NotificationHandler struct {
+	settingsQueries *queries.SettingsQueries
+	dispatcher      *notify.Dispatcher
+}
+
+// NewNotificationHandler creates a new NotificationHandler
+func NewNotificationHandler(settingsQueries *queries.SettingsQueries, dispatcher *notify.Dispatcher) *NotificationHandler {
+	return &NotificationHandler{
+		settingsQueries: settingsQueries,
+		dispatcher:      dispatcher,
+	}
+}
+
+// GetConfig handles GET /api/settings/notifications
+func (h *NotificationHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	server, _ := h.settingsQueries.Get(ctx, notify.NtfyServerKey)
+	topic, _ := h.settingsQueries.Get(ctx, notify.NtfyTopicKey)
+	token, _ := h.settingsQueries.Get(ctx, notify.NtfyTokenKey)
+
+	if server == "" {
+		server = notify.DefaultNtfyServer
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"ntfy": map[string]interface{}{
+			"configured": topic != "",
+			"server_url": server,
+			"topic":      topic,
+			"has_token":  token != "",
+		},
+	})
+}
+
+// SetNtfyConfig handles POST /api/settings/notifications/ntfy
+func (h *NotificationHandler) SetNtfyConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req struct {
+		ServerURL string `json:"server_url"`
+		Topic     string `json:"topic"`
+		Token     string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.ServerURL = strings.TrimSpace(req.ServerURL)
+	req.Topic = strings.TrimSpace(req.Topic)
+
+	if req.ServerURL != "" && !isHTTPURL(req.ServerURL) {
+		http.Error(w, "server_url must be an http(s) URL", http.StatusBadRequest)
+		return
+	}
+	if strings.ContainsAny(req.Topic, "/ ") {
+		http.Error(w, "topic must not contain slashes or spaces", http.StatusBadRequest)
+		return
+	}
+
+	settings := map[string]string{
+		notify.NtfyServerKey: req.ServerURL,
+		notify.NtfyTopicKey:  req.Topic,
+	}
+	// Only overwrite the token when a new one is provided
+	if req.Token != "" {
+		settings[notify.NtfyTokenKey] = req.Token
+	}
+
+	if err := h.settingsQueries.SetMultiple(ctx, settings); err != nil {
+		slog.ErrorContext(ctx, "failed to save ntfy settings", "error", err)
+		http.Error(w, "failed to save settings", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "ntfy notification settings saved", "server", req.ServerURL, "topic", req.Topic)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "ntfy settings saved",
+	})
+}
+
+// DeleteNtfyConfig handles DELETE /api/settings/notifications/ntfy
+func (h *NotificationHandler) DeleteNtfyConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	for _, key := range []string{notify.NtfyServerKey, notify.NtfyTopicKey, notify.NtfyTokenKey} {
+		if err := h.settingsQueries.Delete(ctx, key); err != nil {
+			slog.ErrorContext(ctx, "failed to delete ntfy setting", "key", key, "error", err)
+			http.Error(w, "failed to delete settings", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "ntfy disabled",
+	})
+}
+
+// SendTest handles POST /api/settings/notifications/test
+func (h *NotificationHandler) SendTest(w http.ResponseWriter, r *http.Request) {
+	if h.dispatcher == nil {
+		http.Error(w, "notifications not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := h.dispatcher.SendTest(r.Context()); err != nil {
+		slog.WarnContext(r.Context(), "test notification failed", "error", err)
+		http.Error(w, "failed to send test notification: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Test notification sent",
+	})
+}
+
+// isHTTPURL returns true if s parses as an absolute http or https URL
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func (h *PageHandler) renderNotificationSettings(w http.ResponseWriter) {
+	fmt.Fprint(w, `
+        <div class="mt-8">
+            <h2 class="text-xl font-bold mb-4">Notifications</h2>
+            <div class="bg-white shadow-sm rounded-lg p-6 border border-gray-200">
+                <p class="text-gray-500 mb-4">Send push alerts via <a href="https://ntfy.sh" target="_blank" class="text-purple-600 hover:text-purple-700">ntfy</a> when builds fail or app containers go down.</p>
+                <form onsubmit="submitNtfyConfig(event)">
+                    <div class="grid grid-cols-1 md:grid-cols-2 gap-4 mb-4">
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Server URL</label>
+                            <input type="text" name="server_url" id="ntfy-server-input"
+                                placeholder="https://ntfy.sh"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Topic</label>
+                            <input type="text" name="topic" id="ntfy-topic-input"
+                                placeholder="my-schooner-alerts"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        </div>
+                        <div class="md:col-span-2">
+                            <label class="block text-sm text-gray-500 mb-1">Access Token (optional)</label>
+                            <input type="password" name="token" id="ntfy-token-input"
+                                placeholder="tk_..."
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                            <p class="text-xs text-gray-400 mt-1">Only needed for protected topics. Leave blank to keep the current token.</p>
+                        </div>
+                    </div>
+                    <div class="flex space-x-2">
+                        <button type="submit" class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white">Save</button>
+                        <button type="button" onclick="testNotification()" class="px-4 py-2 bg-gray-50 hover:bg-gray-100 rounded">Send Test</button>
+                        <button type="button" onclick="disableNtfy()" class="px-4 py-2 bg-gray-50 hover:bg-gray-100 rounded">Disable</button>
+                    </div>
+                </form>
+            </div>
+        </div>
+        <script>
+            fetch('/api/settings/notifications')
+                .then(response => response.json())
+                .then(data => {
+                    document.getElementById('ntfy-server-input').value = data.ntfy.server_url || '';
+                    document.getElementById('ntfy-topic-input').value = data.ntfy.topic || '';
+                    if (data.ntfy.has_token) {
+                        document.getElementById('ntfy-token-input').placeholder = '********';
+                    }
+                });
+
+            function submitNtfyConfig(event) {
+                event.preventDefault();
+                const form = event.target;
+                const data = {
+                    server_url: form.querySelector('input[name="server_url"]').value,
+                    topic: form.querySelector('input[name="topic"]').value,
+                    token: form.querySelector('input[name="token"]').value
+                };
+
+                fetch('/api/settings/notifications/ntfy', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify(data)
+                })
+                .then(response => {
+                    if (response.ok) {
+                        showToast('Notification settings saved', 'success');
+                    } else {
+                        response.text().then(text => alert('Failed to save: ' + text));
+                    }
+                });
+            }
+
+            function testNotification() {
+                fetch('/api/settings/notifications/test', { method: 'POST' })
+                    .then(response => {
+                        if (response.ok) {
+                            showToast('Test notification sent', 'success');
+                        } else {
+                            response.text().then(text => alert(text));
+                        }
+                    });
+            }
+
+            function disableNtfy() {
+                fetch('/api/settings/notifications/ntfy', { method: 'DELETE' })
+                    .then(response => {
+                        if (response.ok) {
+                            window.location.reload();
+                        } else {
+                            response.text().then(text => alert('Failed to disable: ' + text));
+                        }
+                    });
+            }
+        </script>`)
+}
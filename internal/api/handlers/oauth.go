@@ -50,14 +50,15 @@ func generateState() (string, error) {
 
 // Login handles GET /oauth/github/login - redirects to GitHub OAuth
 func (h *OAuthHandler) Login(w http.ResponseWriter, r *http.Request) {
-	if h.cfg.GitHubOAuth.ClientID == "" {
+	oauth := h.cfg.OAuth()
+	if oauth.ClientID == "" {
 		http.Error(w, "GitHub OAuth not configured. Please set github_oauth.client_id and github_oauth.client_secret in config.", http.StatusBadRequest)
 		return
 	}
 
 	state, err := generateState()
 	if err != nil {
-		slog.Error("failed to generate state", "error", err)
+		slog.ErrorContext(r.Context(), "failed to generate state", "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -74,9 +75,9 @@ func (h *OAuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	// Build GitHub OAuth URL
 	params := url.Values{
-		"client_id":    {h.cfg.GitHubOAuth.ClientID},
-		"redirect_uri": {h.cfg.Server.BaseURL + "/oauth/github/callback"},
-		"scope":        {"repo read:user"},
+		"client_id":    {oauth.ClientID},
+		"redirect_uri": {h.cfg.BaseURL() + "/oauth/github/callback"},
+		"scope":        {"repo read:user read:org"},
 		"state":        {state},
 	}
 
@@ -112,7 +113,7 @@ func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
 	// Check for errors from GitHub
 	if errMsg := r.URL.Query().Get("error"); errMsg != "" {
 		errDesc := r.URL.Query().Get("error_description")
-		slog.Error("GitHub OAuth error", "error", errMsg, "description", errDesc)
+		slog.ErrorContext(ctx, "GitHub OAuth error", "error", errMsg, "description", errDesc)
 		http.Redirect(w, r, "/settings?error="+url.QueryEscape(errDesc), http.StatusTemporaryRedirect)
 		return
 	}
@@ -127,7 +128,7 @@ func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
 	// Exchange code for access token
 	tokenResp, err := h.exchangeCodeForToken(code)
 	if err != nil {
-		slog.Error("failed to exchange code for token", "error", err)
+		slog.ErrorContext(ctx, "failed to exchange code for token", "error", err)
 		http.Redirect(w, r, "/settings?error="+url.QueryEscape("Failed to authenticate with GitHub"), http.StatusTemporaryRedirect)
 		return
 	}
@@ -136,7 +137,7 @@ func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
 	h.githubClient.SetToken(tokenResp.AccessToken)
 	user, err := h.githubClient.GetUserFull(ctx)
 	if err != nil {
-		slog.Error("failed to get GitHub user", "error", err)
+		slog.ErrorContext(ctx, "failed to get GitHub user", "error", err)
 		http.Redirect(w, r, "/settings?error="+url.QueryEscape("Failed to verify GitHub token"), http.StatusTemporaryRedirect)
 		return
 	}
@@ -144,7 +145,7 @@ func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
 	// First user wins: check if an owner is already registered
 	ownerGitHubID, err := h.settingsQueries.Get(ctx, "owner_github_id")
 	if err != nil {
-		slog.Error("failed to check owner", "error", err)
+		slog.ErrorContext(ctx, "failed to check owner", "error", err)
 		http.Redirect(w, r, "/settings?error="+url.QueryEscape("Failed to verify ownership"), http.StatusTemporaryRedirect)
 		return
 	}
@@ -152,19 +153,19 @@ func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
 	if ownerGitHubID == "" {
 		// First user wins - register as owner
 		if err := h.settingsQueries.Set(ctx, "owner_github_id", strconv.FormatInt(user.ID, 10)); err != nil {
-			slog.Error("failed to set owner GitHub ID", "error", err)
+			slog.ErrorContext(ctx, "failed to set owner GitHub ID", "error", err)
 			http.Redirect(w, r, "/settings?error="+url.QueryEscape("Failed to register owner"), http.StatusTemporaryRedirect)
 			return
 		}
 		if err := h.settingsQueries.Set(ctx, "owner_username", user.Login); err != nil {
-			slog.Error("failed to set owner username", "error", err)
+			slog.ErrorContext(ctx, "failed to set owner username", "error", err)
 			// Non-fatal, continue
 		}
-		slog.Info("first user registered as owner", "github_id", user.ID, "username", user.Login)
+		slog.InfoContext(ctx, "first user registered as owner", "github_id", user.ID, "username", user.Login)
 	} else {
 		// Verify this is the owner
 		if ownerGitHubID != strconv.FormatInt(user.ID, 10) {
-			slog.Warn("unauthorized login attempt", "github_id", user.ID, "username", user.Login, "owner_github_id", ownerGitHubID)
+			slog.WarnContext(ctx, "unauthorized login attempt", "github_id", user.ID, "username", user.Login, "owner_github_id", ownerGitHubID)
 			h.githubClient.SetToken("") // Clear the token
 			http.Redirect(w, r, "/oauth/github/login?error="+url.QueryEscape("You are not the owner of this instance"), http.StatusTemporaryRedirect)
 			return
@@ -173,7 +174,7 @@ func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
 		// Update username if changed (GitHub allows username changes)
 		if currentUsername, _ := h.settingsQueries.Get(ctx, "owner_username"); currentUsername != user.Login {
 			h.settingsQueries.Set(ctx, "owner_username", user.Login)
-			slog.Info("owner username updated", "old", currentUsername, "new", user.Login)
+			slog.InfoContext(ctx, "owner username updated", "old", currentUsername, "new", user.Login)
 		}
 	}
 
@@ -181,7 +182,7 @@ func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
 
 	// Save the token to settings (for API access)
 	if err := h.settingsQueries.Set(ctx, "github_token", tokenResp.AccessToken); err != nil {
-		slog.Error("failed to save GitHub token", "error", err)
+		slog.ErrorContext(ctx, "failed to save GitHub token", "error", err)
 		http.Redirect(w, r, "/settings?error="+url.QueryEscape("Failed to save token"), http.StatusTemporaryRedirect)
 		return
 	}
@@ -189,23 +190,43 @@ func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
 	// Update git client auth for cloning private repos
 	if h.gitClient != nil {
 		h.gitClient.SetHTTPAuth("x-access-token", tokenResp.AccessToken)
-		slog.Info("git client auth updated after OAuth")
+		slog.InfoContext(ctx, "git client auth updated after OAuth")
 	}
 
 	// Create session for the user
-	session, err := h.sessionStore.Create(username, user.AvatarURL, tokenResp.AccessToken)
+	session, sessionToken, err := h.sessionStore.Create(username, user.AvatarURL, r)
 	if err != nil {
-		slog.Error("failed to create session", "error", err)
+		slog.ErrorContext(ctx, "failed to create session", "error", err)
 		http.Redirect(w, r, "/settings?error="+url.QueryEscape("Failed to create session"), http.StatusTemporaryRedirect)
 		return
 	}
 
 	// Set session cookie (24 hours)
 	// Use secure cookies if base URL is HTTPS
-	secure := strings.HasPrefix(h.cfg.Server.BaseURL, "https://")
-	auth.SetSessionCookie(w, session.ID, 86400, secure)
+	secure := strings.HasPrefix(h.cfg.BaseURL(), "https://")
+	auth.SetSessionCookie(w, sessionToken, 86400, secure)
 
-	slog.Info("GitHub OAuth completed", "username", username)
+	slog.InfoContext(ctx, "GitHub OAuth completed", "username", username)
+
+	// Hold the session until the second factor is verified or enrolled
+	twoFactor, err := h.settingsQueries.GetTwoFactorConfig(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get two-factor config", "error", err)
+		h.sessionStore.Delete(session.ID)
+		auth.ClearSessionCookie(w)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	switch {
+	case twoFactor.Enabled:
+		h.sessionStore.SetMFAState(session.ID, auth.MFAVerify)
+		http.Redirect(w, r, "/login/2fa", http.StatusTemporaryRedirect)
+		return
+	case twoFactor.Required:
+		h.sessionStore.SetMFAState(session.ID, auth.MFASetup)
+		http.Redirect(w, r, "/login/2fa/setup", http.StatusTemporaryRedirect)
+		return
+	}
 
 	// Redirect to dashboard
 	http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
@@ -218,9 +239,10 @@ type tokenResponse struct {
 }
 
 func (h *OAuthHandler) exchangeCodeForToken(code string) (*tokenResponse, error) {
+	oauth := h.cfg.OAuth()
 	data := url.Values{
-		"client_id":     {h.cfg.GitHubOAuth.ClientID},
-		"client_secret": {h.cfg.GitHubOAuth.ClientSecret},
+		"client_id":     {oauth.ClientID},
+		"client_secret": {oauth.ClientSecret},
 		"code":          {code},
 	}
 
@@ -257,9 +279,10 @@ func (h *OAuthHandler) exchangeCodeForToken(code string) (*tokenResponse, error)
 
 // Status handles GET /oauth/github/status - returns OAuth configuration status
 func (h *OAuthHandler) Status(w http.ResponseWriter, r *http.Request) {
+	oauth := h.cfg.OAuth()
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"oauth_configured": h.cfg.GitHubOAuth.ClientID != "" && h.cfg.GitHubOAuth.ClientSecret != "",
+		"oauth_configured": oauth.ClientID != "" && oauth.ClientSecret != "",
 	})
 }
 
@@ -269,13 +292,13 @@ func (h *OAuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie(auth.CookieName)
 	if err == nil {
 		// Delete session
-		h.sessionStore.Delete(cookie.Value)
+		h.sessionStore.DeleteByToken(cookie.Value)
 	}
 
 	// Clear cookie
 	auth.ClearSessionCookie(w)
 
-	slog.Info("user logged out")
+	slog.InfoContext(r.Context(), "user logged out")
 
 	// Redirect to login
 	http.Redirect(w, r, "/oauth/github/login", http.StatusTemporaryRedirect)
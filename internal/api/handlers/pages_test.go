@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/config"
+	"schooner/internal/models"
+)
+
+func TestPages(t *testing.T) {
+	h := newDashboardHandler(t)
+	h.cfg = &config.Config{Docker: config.DockerConfig{ArtifactsDir: t.TempDir()}}
+	ctx := context.Background()
+
+	web, err := h.appQueries.GetByID(ctx, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	web.BuildStrategy = models.BuildStrategyCompose
+	web.AllowedBranches = sql.NullString{String: "release/*", Valid: true}
+	web.Enabled = true
+	if err := web.SetDeployConfig(&models.DeployConfig{Replicas: 3, Sysctls: map[string]string{"net.core.somaxconn": "1024"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.appQueries.Update(ctx, web); err != nil {
+		t.Fatal(err)
+	}
+
+	build := &models.Build{
+		ID: "0123456789abcdef", AppID: "web", Status: models.BuildStatusWaitingApproval, Trigger: models.TriggerWebhook,
+		StartedAt: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}, CreatedAt: time.Now(),
+		ReleaseName:  sql.NullString{String: "Spring <release>", Valid: true},
+		ReleaseURL:   sql.NullString{String: "javascript:alert(1)", Valid: true},
+		ReleaseNotes: sql.NullString{String: "Fixes", Valid: true},
+		CommitSHA:    sql.NullString{String: "fedcba9876543210", Valid: true},
+		Tag:          sql.NullString{String: "v1.2.0", Valid: true},
+	}
+	build.SetImageDigests(map[string]string{"worker": "redis@sha256:bbb", "app": "web@sha256:aaa"})
+	if err := h.buildQueries.Create(ctx, build); err != nil {
+		t.Fatal(err)
+	}
+
+	r := chi.NewRouter()
+	r.Get("/apps/{appID}", h.AppDetail)
+	r.Get("/builds/{buildID}", h.BuildDetail)
+	r.Get("/settings", h.Settings)
+	r.Get("/builds", h.BuildHistory)
+	r.Get("/networks", h.Networks)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{
+			path: "/apps/web",
+			want: []string{
+				`<h1 class="text-2xl font-bold">web</h1>`, `onclick="validateApp()"`, `onclick="previewDeploy()"`,
+				`hx-post="/api/apps/web/deploy"`, `id="compose-services" data-app-id="web"`, `/static/js/deploy-preview.js`,
+				"Build History", `href="/builds/0123456789abcdef"`,
+				`id="dependencies-section" data-app-id="web"`, `id="save-dependencies"`, "/static/js/dependencies.js",
+			},
+		},
+		{
+			path: "/builds/0123456789abcdef",
+			want: []string{
+				"Build 01234567</h1>", `data-build-id="0123456789abcdef"`, `data-running="false"`,
+				`onclick="decideBuild('approve')"`, "/static/js/build-detail.js",
+				"<td class=\"px-6 py-2 font-medium\">app</td>", "redis@sha256:bbb",
+				`<span class="font-medium">Spring &lt;release&gt;</span>`, ">Fixes</pre>",
+			},
+		},
+		{
+			path: "/settings",
+			want: []string{
+				`id="add-app-form"`, `<option value="" selected>Git tag or build ID</option>`, `id="edit-form-web"`,
+				`<option value="compose" selected>Docker Compose</option>`, `name="replicas" value="3"`,
+				"net.core.somaxconn=1024", "Pushes to main aren't allowed", "App Deploy Windows", "Global Deploy Windows",
+				`onclick="configureWebhook('api', 'api')"`, `id="tunnel-status-display"`, "/static/js/tunnel.js",
+				"/static/js/observability.js", `id="import-modal"`, `<form id="build-workers-form"`,
+				"workers busy, 0 builds waiting.", "/static/js/build-workers.js",
+			},
+		},
+		{
+			path: "/builds?app_id=web",
+			want: []string{
+				`<option value="web" selected>web</option>`, "waiting_approval</span>",
+				`/commit/fedcba9876543210" target="_blank" class="text-purple-600 hover:text-purple-700 hover:underline">fedcba98</a>`,
+				`<a href="/builds/0123456789abcdef"`, "1–3 of 3 builds",
+			},
+		},
+		{
+			path: "/networks",
+			want: []string{`<tbody id="networks-body">`, `<form id="create-network"`, "/static/js/networks.js"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			body := rec.Body.String()
+			if !strings.HasSuffix(strings.TrimSpace(body), "</html>") {
+				t.Errorf("page stops short:\n%s", body)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(body, want) {
+					t.Errorf("page is missing %s", want)
+				}
+			}
+		})
+	}
+}
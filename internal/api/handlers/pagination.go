@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// defaultPageLimit is the page size when ?limit is not given
+	defaultPageLimit = 50
+	// maxPageLimit caps ?limit so a single request stays cheap
+	maxPageLimit = 200
+)
+
+// Page is the envelope for paginated /api/v1 list responses. Pass
+// next_cursor back as ?cursor to fetch the following page; it is omitted on
+// the last page.
+type Page struct {
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// pageParams parses ?limit and ?cursor. The cursor is opaque to clients and
+// decodes to the key of the last item on the previous page.
+func pageParams(r *http.Request) (limit int, after string, err error) {
+	limit = defaultPageLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return 0, "", fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+	}
+
+	if c := r.URL.Query().Get("cursor"); c != "" {
+		key, err := base64.RawURLEncoding.DecodeString(c)
+		if err != nil || len(key) == 0 {
+			return 0, "", fmt.Errorf("invalid cursor")
+		}
+		after = string(key)
+	}
+
+	return limit, after, nil
+}
+
+// encodeCursor makes an opaque cursor from the key of a page's last item
+func encodeCursor(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// writePage writes a page of results. Callers fetch limit+1 rows; the extra
+// row only signals that another page exists and is not returned.
+func writePage[T any](w http.ResponseWriter, items []T, limit int, key func(T) string) {
+	page := Page{Data: items}
+	if len(items) > limit {
+		items = items[:limit]
+		page.Data = items
+		page.NextCursor = encodeCursor(key(items[limit-1]))
+	}
+	if items == nil {
+		page.Data = []T{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPageParams(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		expectedLimit int
+		expectedAfter string
+		wantErr       bool
+	}{
+		{name: "defaults", query: "", expectedLimit: defaultPageLimit},
+		{name: "limit", query: "?limit=10", expectedLimit: 10},
+		{name: "cursor", query: "?cursor=" + encodeCursor("app-1"), expectedLimit: defaultPageLimit, expectedAfter: "app-1"},
+		{name: "limit too large", query: "?limit=1000", wantErr: true},
+		{name: "limit zero", query: "?limit=0", wantErr: true},
+		{name: "bad cursor", query: "?cursor=***", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, after, err := pageParams(httptest.NewRequest("GET", "/api/v1/apps"+tt.query, nil))
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("pageParams() error = %v", err)
+			}
+			if limit != tt.expectedLimit || after != tt.expectedAfter {
+				t.Errorf("expected (%d, %q), got (%d, %q)", tt.expectedLimit, tt.expectedAfter, limit, after)
+			}
+		})
+	}
+}
+
+func TestWritePage(t *testing.T) {
+	key := func(s string) string { return s }
+
+	tests := []struct {
+		name           string
+		items          []string
+		limit          int
+		expectedData   []string
+		expectedCursor string
+	}{
+		{name: "empty", items: nil, limit: 2, expectedData: []string{}},
+		{name: "last page", items: []string{"a", "b"}, limit: 2, expectedData: []string{"a", "b"}},
+		{name: "more pages", items: []string{"a", "b", "c"}, limit: 2, expectedData: []string{"a", "b"}, expectedCursor: encodeCursor("b")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			writePage(rr, tt.items, tt.limit, key)
+
+			var page struct {
+				Data       []string `json:"data"`
+				NextCursor string   `json:"next_cursor"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if page.Data == nil || len(page.Data) != len(tt.expectedData) {
+				t.Fatalf("expected data %v, got %v", tt.expectedData, page.Data)
+			}
+			for i := range tt.expectedData {
+				if page.Data[i] != tt.expectedData[i] {
+					t.Errorf("expected data %v, got %v", tt.expectedData, page.Data)
+				}
+			}
+			if page.NextCursor != tt.expectedCursor {
+				t.Errorf("expected cursor %q, got %q", tt.expectedCursor, page.NextCursor)
+			}
+		})
+	}
+}
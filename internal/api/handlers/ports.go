@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+// PortAllocator gives apps saved without a host port a free one from the
+// configured range
+type PortAllocator struct {
+	ports       *queries.PortQueries
+	first, last int
+}
+
+// NewPortAllocator creates a PortAllocator handing out ports first to last
+func NewPortAllocator(ports *queries.PortQueries, first, last int) *PortAllocator {
+	return &PortAllocator{ports: ports, first: first, last: last}
+}
+
+// Assign sets the app's public port to preferred if no other app on its
+// machine uses it, or else to the first free port of the range
+func (a *PortAllocator) Assign(ctx context.Context, app *models.App, preferred int) error {
+	port := preferred
+	if port > 0 {
+		owner, err := a.ports.Owner(ctx, app.PortHost(), port, app.ID)
+		if err != nil {
+			return err
+		}
+		if owner != "" {
+			port = 0
+		}
+	}
+	if port == 0 {
+		free, err := a.ports.NextFree(ctx, app.PortHost(), a.first, a.last, app.ID)
+		if err != nil {
+			return fmt.Errorf("failed to assign a host port: %w", err)
+		}
+		port = free
+	}
+
+	app.PublicPort = sql.NullInt64{Int64: int64(port), Valid: true}
+	return nil
+}
+
+// autoPort gives an app saved with auto_port and no port a free one,
+// answering the request itself and returning false if that fails
+func (h *AppHandler) autoPort(ctx context.Context, w http.ResponseWriter, app *models.App, req *AppCreateRequest) bool {
+	if !req.AutoPort || app.GetPublicPort() != 0 || h.ports == nil {
+		return true
+	}
+	if err := h.ports.Assign(ctx, app, 0); err != nil {
+		slog.ErrorContext(ctx, "failed to assign host port", "app", app.Name, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// portConflict answers 409 Conflict if saving an app failed for a host port
+// another app uses, reporting whether it did
+func portConflict(w http.ResponseWriter, err error) bool {
+	var conflict *queries.PortConflictError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+	http.Error(w, conflict.Error(), http.StatusConflict)
+	return true
+}
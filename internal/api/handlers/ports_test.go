@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+func portTestApp(id string, port int, dockerHost string) *models.App {
+	return &models.App{
+		ID: id, Name: id, RepoURL: "https://example.com/" + id + ".git", Branch: "main",
+		BuildStrategy: models.BuildStrategyDockerfile,
+		PublicPort:    sql.NullInt64{Int64: int64(port), Valid: port > 0},
+		DockerHost:    sql.NullString{String: dockerHost, Valid: dockerHost != ""},
+		CreatedAt:     time.Now(), UpdatedAt: time.Now(),
+	}
+}
+
+func TestPortAllocation(t *testing.T) {
+	db := newTestDB(t)
+
+	ctx := context.Background()
+	appQueries := queries.NewAppQueries(db.DB)
+	for _, app := range []*models.App{portTestApp("web", 8080, ""), portTestApp("api", 10000, "")} {
+		if err := appQueries.Create(ctx, app); err != nil {
+			t.Fatalf("Create(%s) error = %v", app.Name, err)
+		}
+	}
+
+	var conflict *queries.PortConflictError
+	err := appQueries.Create(ctx, portTestApp("blog", 8080, ""))
+	if !errors.As(err, &conflict) || conflict.App != "web" {
+		t.Errorf("Create() on a used port error = %v, want a conflict with web", err)
+	}
+	if err := appQueries.Create(ctx, portTestApp("remote", 8080, "host-2")); err != nil {
+		t.Errorf("Create() on another host's port error = %v", err)
+	}
+
+	api, _ := appQueries.GetByID(ctx, "api")
+	if err := appQueries.Update(ctx, api); err != nil {
+		t.Errorf("Update() keeping the port error = %v", err)
+	}
+	api.PublicPort = sql.NullInt64{Int64: 8080, Valid: true}
+	if err := appQueries.Update(ctx, api); !errors.As(err, &conflict) {
+		t.Errorf("Update() to a used port error = %v, want a conflict", err)
+	}
+
+	allocator := NewPortAllocator(queries.NewPortQueries(db.DB), 10000, 10002)
+	tests := []struct {
+		name      string
+		app       *models.App
+		preferred int
+		want      int
+	}{
+		{name: "preferred free", app: portTestApp("new", 0, ""), preferred: 9000, want: 9000},
+		{name: "preferred taken", app: portTestApp("new", 0, ""), preferred: 8080, want: 10001},
+		{name: "from range", app: portTestApp("new", 0, ""), want: 10001},
+		{name: "own port", app: portTestApp("api", 0, ""), want: 10000},
+		{name: "other host", app: portTestApp("new", 0, "host-2"), preferred: 8081, want: 8081},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := allocator.Assign(ctx, tt.app, tt.preferred); err != nil {
+				t.Fatalf("Assign() error = %v", err)
+			}
+			if got := tt.app.GetPublicPort(); got != tt.want {
+				t.Errorf("Assign() port = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if err := appQueries.Delete(ctx, "web"); err != nil {
+		t.Fatal(err)
+	}
+	if err := appQueries.Create(ctx, portTestApp("blog", 8080, "")); err != nil {
+		t.Errorf("Create() on a deleted app's port error = %v", err)
+	}
+}
+
+func TestPortAllocationBackfill(t *testing.T) {
+	db := newTestDB(t)
+
+	// Apps saved sharing a port before allocations were tracked
+	for i, id := range []string{"first", "second"} {
+		query := `INSERT INTO apps (id, name, repo_url, build_strategy, public_port, created_at, updated_at) VALUES (?, ?, '', 'dockerfile', 8080, ?, ?)`
+		created := time.Now().Add(time.Duration(i) * time.Minute)
+		if _, err := db.Exec(query, id, id, created, created); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	var owner string
+	if err := db.Get(&owner, `SELECT app_id FROM port_allocations WHERE port = 8080`); err != nil || owner != "first" {
+		t.Errorf("port 8080 allocated to %q, %v, want first", owner, err)
+	}
+
+	ctx := context.Background()
+	appQueries := queries.NewAppQueries(db.DB)
+	second, _ := appQueries.GetByID(ctx, "second")
+	if err := appQueries.Update(ctx, second); err != nil {
+		t.Errorf("Update() of an app already sharing its port error = %v", err)
+	}
+}
+
+func TestPortConflict(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if !portConflict(rec, &queries.PortConflictError{Port: 8080, App: "web"}) {
+		t.Fatal("portConflict() = false for a conflict")
+	}
+	if rec.Code != http.StatusConflict || rec.Body.String() != "host port 8080 is already used by app web\n" {
+		t.Errorf("response = %d %q", rec.Code, rec.Body)
+	}
+	if portConflict(httptest.NewRecorder(), errors.New("disk full")) {
+		t.Error("portConflict() = true for another error")
+	}
+}
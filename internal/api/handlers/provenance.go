@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"schooner/internal/models"
+)
+
+// shortDigest shortens an image digest for display, keeping the algorithm
+func shortDigest(digest string) string {
+	algorithm, hex, ok := strings.Cut(digest, ":")
+	if !ok || len(hex) <= 12 {
+		return digest
+	}
+	return algorithm + ":" + hex[:12]
+}
+
+// describeDeploy says how a build came to be deployed, e.g. "webhook by
+// bob, approved by alice"
+func describeDeploy(b *models.Build) string {
+	how := b.DescribeTrigger()
+	if b.ApprovedBy.Valid && b.ApprovedBy.String != "" {
+		how += ", approved by " + b.ApprovedBy.String
+	}
+	return how
+}
+
+// deployedView is the build running an app, or none when it never deployed
+type deployedView struct {
+	Build               *models.Build
+	Number              int
+	Digest, ShortDigest string
+	DeployedAt          time.Time
+	How                 string
+	Details             []deployDetail
+}
+
+// deployDetail is a fact about how a deployed image was made
+type deployDetail struct {
+	Label, Value string
+}
+
+// renderDeployed writes which build is running the app: its number, the
+// exact image it deployed, when and how, and how that image was made
+func (h *PageHandler) renderDeployed(ctx context.Context, w http.ResponseWriter, app *models.App) {
+	deployed, err := h.buildQueries.GetLatestSuccessfulByAppID(ctx, app.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get deployed build", "appID", app.ID, "error", err)
+		return
+	}
+	if deployed == nil {
+		renderTemplate(w, "deployed", deployedView{})
+		return
+	}
+	number, err := h.buildQueries.Number(ctx, deployed)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to number build", "buildID", deployed.ID, "error", err)
+	}
+
+	view := deployedView{Build: deployed, Number: number, DeployedAt: deployed.CreatedAt, How: describeDeploy(deployed)}
+	if deployed.FinishedAt.Valid {
+		view.DeployedAt = deployed.FinishedAt.Time
+	}
+	if d := deployed.Digest(); d != "" {
+		view.Digest, view.ShortDigest = d, shortDigest(d)
+	}
+
+	detail := func(label, value string) {
+		if value != "" {
+			view.Details = append(view.Details, deployDetail{Label: label, Value: value})
+		}
+	}
+	detail("Image", deployed.GetImageTag())
+	detail("Commit", deployed.GetShortSHA())
+	detail("Base image", deployed.BaseImage.String)
+	if deployed.BuildSeconds > 0 {
+		detail("Build time", (time.Duration(deployed.BuildSeconds) * time.Second).String())
+	}
+	detail("Built by", deployed.Builder.String)
+	digests := deployed.GetImageDigests()
+	for _, service := range slices.Sorted(maps.Keys(digests)) {
+		detail(service, digests[service])
+	}
+	renderTemplate(w, "deployed", view)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+func TestRenderDeployed(t *testing.T) {
+	db := newTestDB(t)
+
+	ctx := context.Background()
+	appQueries := queries.NewAppQueries(db.DB)
+	buildQueries := queries.NewBuildQueries(db.DB)
+	app := &models.App{
+		ID: "web", Name: "web", RepoURL: "https://github.com/example/web.git", Branch: "main",
+		BuildStrategy: models.BuildStrategyDockerfile, CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}
+	if err := appQueries.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	h := &PageHandler{buildQueries: buildQueries}
+
+	rec := httptest.NewRecorder()
+	h.renderDeployed(ctx, rec, app)
+	if !strings.Contains(rec.Body.String(), "Not deployed yet") {
+		t.Errorf("before any build got %q, want not deployed", rec.Body)
+	}
+
+	// The second build is running; the third failed
+	start := time.Now().Add(-3 * time.Hour)
+	for i, status := range []models.BuildStatus{models.BuildStatusSuccess, models.BuildStatusSuccess, models.BuildStatusFailed} {
+		b := &models.Build{
+			ID: "build-" + string(rune('a'+i)), AppID: "web", Status: status, Trigger: models.TriggerWebhook,
+			CreatedAt: start.Add(time.Duration(i) * time.Minute),
+		}
+		if i == 1 {
+			b.ApprovedBy = sql.NullString{String: "alice", Valid: true}
+		}
+		if err := buildQueries.Create(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+		if i != 1 {
+			continue
+		}
+		b.ImageTag = sql.NullString{String: "web:abc12345", Valid: true}
+		b.ImageID = sql.NullString{String: "sha256:0123456789abcdef0123", Valid: true}
+		b.BaseImage = sql.NullString{String: "node:22-alpine", Valid: true}
+		b.Builder = sql.NullString{String: "schooner 1a2b3c4d, dockerfile on Docker 27.3.1", Valid: true}
+		b.BuildSeconds = 95
+		if err := buildQueries.Update(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	h.renderDeployed(ctx, rec, app)
+	for _, want := range []string{"build #2", "sha256:0123456789ab<", "by webhook, approved by alice", "node:22-alpine", "1m35s", "Docker 27.3.1"} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("rendered %q, want it to contain %q", rec.Body, want)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/proxy"
+)
+
+// ProxyHandler handles reverse proxy selection and Caddy management
+type ProxyHandler struct {
+	settingsQueries *queries.SettingsQueries
+	proxyRouter     *proxy.Router
+	caddyManager    *proxy.CaddyManager
+}
+
+// NewProxyHandler creates a new ProxyHandler
+func NewProxyHandler(settingsQueries *queries.SettingsQueries, proxyRouter *proxy.Router, caddyManager *proxy.CaddyManager) *ProxyHandler {
+	return &ProxyHandler{
+		settingsQueries: settingsQueries,
+		proxyRouter:     proxyRouter,
+		caddyManager:    caddyManager,
+	}
+}
+
+// GetConfig handles GET /api/settings/proxy
+func (h *ProxyHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	provider := h.proxyRouter.ActiveName(ctx)
+	response := map[string]interface{}{
+		"provider":         provider,
+		"caddy_available":  h.caddyManager != nil,
+		"caddy_configured": false,
+		"caddy_running":    false,
+	}
+	for key := range (ProxyConfigRequest{}).settings() {
+		if key != proxy.ProviderSettingKey {
+			response[key], _ = h.settingsQueries.Get(ctx, key)
+		}
+	}
+
+	if h.caddyManager != nil {
+		response["caddy_configured"] = h.caddyManager.IsConfigured()
+		if status, err := h.caddyManager.GetStatus(ctx); err == nil && status != nil {
+			response["caddy_running"] = status.State == "running"
+			response["caddy_container_status"] = status.State
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ProxyConfigRequest is the request body for saving proxy settings
+type ProxyConfigRequest struct {
+	Provider            string `json:"provider"`
+	CaddyDomain         string `json:"caddy_domain"`
+	CaddyEmail          string `json:"caddy_email"`
+	TraefikDomain       string `json:"traefik_domain"`
+	TraefikNetwork      string `json:"traefik_network"`
+	TraefikEntryPoint   string `json:"traefik_entrypoint"`
+	TraefikCertResolver string `json:"traefik_certresolver"`
+}
+
+// settings returns the request as settings keys and trimmed values
+func (req ProxyConfigRequest) settings() map[string]string {
+	return map[string]string{
+		proxy.ProviderSettingKey: req.Provider,
+		"caddy_domain":           strings.TrimSpace(req.CaddyDomain),
+		"caddy_email":            strings.TrimSpace(req.CaddyEmail),
+		"traefik_domain":         strings.TrimSpace(req.TraefikDomain),
+		"traefik_network":        strings.TrimSpace(req.TraefikNetwork),
+		"traefik_entrypoint":     strings.TrimSpace(req.TraefikEntryPoint),
+		"traefik_certresolver":   strings.TrimSpace(req.TraefikCertResolver),
+	}
+}
+
+// SetConfig handles POST /api/settings/proxy
+func (h *ProxyHandler) SetConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req ProxyConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	switch req.Provider {
+	case proxy.ProviderCloudflare, proxy.ProviderCaddy, proxy.ProviderTraefik:
+	default:
+		http.Error(w, "provider must be cloudflare, caddy or traefik", http.StatusBadRequest)
+		return
+	}
+
+	settings := req.settings()
+	for key, value := range settings {
+		// Values are written into the Caddyfile and container labels, so
+		// they must be single tokens
+		if strings.ContainsAny(value, " \t\r\n{}`\"") {
+			http.Error(w, key+" must not contain spaces, quotes or braces", http.StatusBadRequest)
+			return
+		}
+	}
+
+	for key, value := range settings {
+		if err := h.settingsQueries.Set(ctx, key, value); err != nil {
+			slog.ErrorContext(ctx, "failed to save proxy setting", "key", key, "error", err)
+			http.Error(w, "failed to save proxy settings", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	slog.InfoContext(ctx, "proxy settings saved", "provider", req.Provider)
+
+	// Publish the current apps through the newly selected provider
+	if h.proxyRouter.IsConfigured() {
+		if err := h.proxyRouter.Reload(ctx); err != nil {
+			slog.WarnContext(ctx, "failed to reload proxy routes", "provider", req.Provider, "error", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Proxy settings saved",
+	})
+}
+
+// StartCaddy handles POST /api/settings/proxy/caddy/start
+func (h *ProxyHandler) StartCaddy(w http.ResponseWriter, r *http.Request) {
+	if h.caddyManager == nil {
+		http.Error(w, "caddy manager not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := h.caddyManager.Start(r.Context()); err != nil {
+		slog.ErrorContext(r.Context(), "failed to start caddy", "error", err)
+		http.Error(w, "failed to start caddy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Caddy started",
+	})
+}
+
+// StopCaddy handles POST /api/settings/proxy/caddy/stop
+func (h *ProxyHandler) StopCaddy(w http.ResponseWriter, r *http.Request) {
+	if h.caddyManager == nil {
+		http.Error(w, "caddy manager not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := h.caddyManager.Stop(r.Context()); err != nil {
+		slog.ErrorContext(r.Context(), "failed to stop caddy", "error", err)
+		http.Error(w, "failed to stop caddy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Caddy stopped",
+	})
+}
+
+func (h *PageHandler) renderProxySettings(w http.ResponseWriter) {
+	fmt.Fprint(w, `
+        <div class="mt-8">
+            <h2 class="text-xl font-bold mb-4">Reverse Proxy</h2>
+            <div class="bg-white shadow-sm rounded-lg p-6 border border-gray-200">
+                <p class="text-gray-500 mb-4">Choose how apps are exposed. Caddy runs on ports 80 and 443 of this host and obtains Let's Encrypt certificates automatically; DNS for each subdomain must point at this server. In Traefik mode Schooner only labels app containers for your existing Traefik, and route changes apply on the next deploy.</p>
+
+                <div id="caddy-status-display" class="mb-4 hidden">
+                    <div class="flex items-center justify-between p-3 bg-gray-50 rounded">
+                        <div class="flex items-center">
+                            <span id="caddy-status-indicator" class="w-3 h-3 rounded-full mr-3"></span>
+                            <span id="caddy-status-text" class="text-sm"></span>
+                        </div>
+                        <div class="flex space-x-2">
+                            <button id="caddy-start-btn" onclick="startCaddy()" class="hidden px-3 py-1 bg-green-600 hover:bg-green-700 rounded text-sm text-white">Start</button>
+                            <button id="caddy-stop-btn" onclick="stopCaddy()" class="hidden px-3 py-1 bg-red-600 hover:bg-red-700 rounded text-sm text-white">Stop</button>
+                        </div>
+                    </div>
+                </div>
+
+                <form onsubmit="submitProxyConfig(event)">
+                    <div class="grid grid-cols-1 md:grid-cols-2 gap-4 mb-4">
+                        <div class="md:col-span-2">
+                            <label class="block text-sm text-gray-500 mb-1">Provider</label>
+                            <select name="provider" id="proxy-provider-input" class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                                <option value="cloudflare">Cloudflare Tunnel</option>
+                                <option value="caddy">Caddy (Let's Encrypt)</option>
+                                <option value="traefik">Existing Traefik (container labels)</option>
+                            </select>
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Caddy Domain</label>
+                            <input type="text" name="caddy_domain" id="caddy-domain-input"
+                                placeholder="example.com"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                            <p class="text-xs text-gray-400 mt-1">Apps are served at subdomain.example.com</p>
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Let's Encrypt Email</label>
+                            <input type="email" name="caddy_email" id="caddy-email-input"
+                                placeholder="admin@example.com"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                            <p class="text-xs text-gray-400 mt-1">Used for certificate expiry notices</p>
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Traefik Domain</label>
+                            <input type="text" name="traefik_domain" id="traefik-domain-input"
+                                placeholder="example.com"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                            <p class="text-xs text-gray-400 mt-1">Host rules use subdomain.example.com</p>
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Traefik Network</label>
+                            <input type="text" name="traefik_network" id="traefik-network-input"
+                                placeholder="traefik"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                            <p class="text-xs text-gray-400 mt-1">Existing Docker network shared with Traefik</p>
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Traefik Entrypoint (optional)</label>
+                            <input type="text" name="traefik_entrypoint" id="traefik-entrypoint-input"
+                                placeholder="websecure"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Traefik Cert Resolver (optional)</label>
+                            <input type="text" name="traefik_certresolver" id="traefik-certresolver-input"
+                                placeholder="letsencrypt"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                            <p class="text-xs text-gray-400 mt-1">Enables TLS on generated routers</p>
+                        </div>
+                    </div>
+                    <button type="submit" class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white">Save Proxy Config</button>
+                </form>
+            </div>
+        </div>
+        <script>
+            fetch('/api/settings/proxy')
+                .then(response => response.json())
+                .then(data => {
+                    document.getElementById('proxy-provider-input').value = data.provider;
+                    document.getElementById('caddy-domain-input').value = data.caddy_domain || '';
+                    document.getElementById('caddy-email-input').value = data.caddy_email || '';
+                    document.getElementById('traefik-domain-input').value = data.traefik_domain || '';
+                    document.getElementById('traefik-network-input').value = data.traefik_network || '';
+                    document.getElementById('traefik-entrypoint-input').value = data.traefik_entrypoint || '';
+                    document.getElementById('traefik-certresolver-input').value = data.traefik_certresolver || '';
+
+                    if (!data.caddy_configured) {
+                        return;
+                    }
+                    document.getElementById('caddy-status-display').classList.remove('hidden');
+                    const indicator = document.getElementById('caddy-status-indicator');
+                    const statusText = document.getElementById('caddy-status-text');
+                    if (data.caddy_running) {
+                        indicator.className = 'w-3 h-3 rounded-full mr-3 bg-green-500';
+                        statusText.textContent = 'Caddy is running';
+                        document.getElementById('caddy-stop-btn').classList.remove('hidden');
+                    } else {
+                        indicator.className = 'w-3 h-3 rounded-full mr-3 bg-gray-400';
+                        statusText.textContent = 'Caddy is stopped';
+                        document.getElementById('caddy-start-btn').classList.remove('hidden');
+                    }
+                });
+
+            function submitProxyConfig(event) {
+                event.preventDefault();
+                const form = event.target;
+                const data = {
+                    provider: form.querySelector('select[name="provider"]').value,
+                    caddy_domain: form.querySelector('input[name="caddy_domain"]').value,
+                    caddy_email: form.querySelector('input[name="caddy_email"]').value,
+                    traefik_domain: form.querySelector('input[name="traefik_domain"]').value,
+                    traefik_network: form.querySelector('input[name="traefik_network"]').value,
+                    traefik_entrypoint: form.querySelector('input[name="traefik_entrypoint"]').value,
+                    traefik_certresolver: form.querySelector('input[name="traefik_certresolver"]').value
+                };
+
+                fetch('/api/settings/proxy', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify(data)
+                })
+                .then(response => {
+                    if (response.ok) {
+                        alert('Proxy configuration saved');
+                        window.location.reload();
+                    } else {
+                        response.text().then(text => alert('Failed to save: ' + text));
+                    }
+                });
+            }
+
+            function startCaddy() {
+                fetch('/api/settings/proxy/caddy/start', { method: 'POST' })
+                    .then(response => {
+                        if (response.ok) {
+                            window.location.reload();
+                        } else {
+                            response.text().then(text => alert('Failed to start Caddy: ' + text));
+                        }
+                    });
+            }
+
+            function stopCaddy() {
+                fetch('/api/settings/proxy/caddy/stop', { method: 'POST' })
+                    .then(response => {
+                        if (response.ok) {
+                            window.location.reload();
+                        } else {
+                            response.text().then(text => alert('Failed to stop Caddy: ' + text));
+                        }
+                    });
+            }
+        </script>`)
+}
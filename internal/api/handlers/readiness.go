@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"schooner/internal/build"
+	"schooner/internal/cloudflare"
+	"schooner/internal/docker"
+)
+
+// ReadinessCheck is how one of the things Schooner depends on is doing
+type ReadinessCheck struct {
+	Name   string `json:"name"`   // database, docker, build_workers or tunnels
+	Status string `json:"status"` // ok, error or disabled
+	Detail string `json:"detail,omitempty"`
+}
+
+// ReadinessResponse is the readiness check response
+type ReadinessResponse struct {
+	Status string           `json:"status"` // ready or not_ready
+	Checks []ReadinessCheck `json:"checks"`
+}
+
+// SetDocker sets the Docker daemon whose reachability readiness reports
+func (h *HealthHandler) SetDocker(dockerClient *docker.Client) {
+	h.dockerClient = dockerClient
+}
+
+// SetOrchestrator sets the build workers readiness reports
+func (h *HealthHandler) SetOrchestrator(orchestrator *build.Orchestrator) {
+	h.orchestrator = orchestrator
+}
+
+// SetTunnelManager sets the Cloudflare tunnels readiness reports
+func (h *HealthHandler) SetTunnelManager(tunnelManager *cloudflare.Manager) {
+	h.tunnelManager = tunnelManager
+}
+
+// Ready handles GET /readyz - checks the database, the Docker daemon, the
+// build workers and the Cloudflare tunnels, failing with 503 if any of them
+// is in trouble. Tunnels that aren't set up are reported as disabled.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	response := ReadinessResponse{
+		Status: "ready",
+		Checks: []ReadinessCheck{
+			h.checkDatabase(ctx),
+			h.checkDocker(ctx),
+			h.checkBuildWorkers(),
+			h.checkTunnels(ctx),
+		},
+	}
+	status := http.StatusOK
+	for _, check := range response.Checks {
+		if check.Status == "error" {
+			response.Status = "not_ready"
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *HealthHandler) checkDatabase(ctx context.Context) ReadinessCheck {
+	check := ReadinessCheck{Name: "database", Status: "ok"}
+	if h.db == nil {
+		check.Status = "error"
+		check.Detail = "database not available"
+		return check
+	}
+	dbHealth := h.db.Health(ctx)
+	if dbHealth.Status != "ok" {
+		check.Status = "error"
+		check.Detail = dbHealth.Error
+		return check
+	}
+	check.Detail = fmt.Sprintf("%s, ping %dms", dbHealth.Driver, dbHealth.PingMS)
+	return check
+}
+
+func (h *HealthHandler) checkDocker(ctx context.Context) ReadinessCheck {
+	check := ReadinessCheck{Name: "docker", Status: "ok"}
+	if h.dockerClient == nil {
+		check.Status = "error"
+		check.Detail = "Docker client not available"
+		return check
+	}
+	if err := h.dockerClient.Ping(ctx); err != nil {
+		check.Status = "error"
+		check.Detail = err.Error()
+	}
+	return check
+}
+
+func (h *HealthHandler) checkBuildWorkers() ReadinessCheck {
+	check := ReadinessCheck{Name: "build_workers", Status: "ok"}
+	if h.orchestrator == nil {
+		check.Status = "error"
+		check.Detail = "builds are not running"
+		return check
+	}
+	workers := h.orchestrator.Workers()
+	if workers == 0 {
+		check.Status = "error"
+		check.Detail = "build workers are stopped"
+		return check
+	}
+	check.Detail = fmt.Sprintf("%d workers, %d busy, %d queued", workers, h.orchestrator.BusyWorkers(), h.orchestrator.QueueDepth())
+	return check
+}
+
+func (h *HealthHandler) checkTunnels(ctx context.Context) ReadinessCheck {
+	check := ReadinessCheck{Name: "tunnels", Status: "ok"}
+	if h.tunnelManager == nil || !h.tunnelManager.IsConfigured() {
+		check.Status = "disabled"
+		return check
+	}
+
+	states := h.tunnelManager.TunnelStates(ctx)
+	var details []string
+	for name, state := range states {
+		if state != "running" {
+			check.Status = "error"
+		}
+		details = append(details, name+": "+state)
+	}
+	sort.Strings(details)
+	check.Detail = strings.Join(details, ", ")
+	return check
+}
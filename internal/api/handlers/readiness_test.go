@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"schooner/internal/build"
+)
+
+func TestHealthHandler_Ready(t *testing.T) {
+	db := newTestDB(t)
+
+	h := NewHealthHandler()
+	h.SetDatabase(db)
+	h.SetOrchestrator(build.NewOrchestrator(nil, nil, nil, nil, nil))
+
+	rec := httptest.NewRecorder()
+	h.Ready(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	var resp ReadinessResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"database":      "ok",
+		"docker":        "error",
+		"build_workers": "error", // Never started
+		"tunnels":       "disabled",
+	}
+	if resp.Status != "not_ready" || len(resp.Checks) != len(want) {
+		t.Fatalf("response = %+v, want not_ready with %d checks", resp, len(want))
+	}
+	for _, check := range resp.Checks {
+		if check.Status != want[check.Name] {
+			t.Errorf("check %s = %+v, want %s", check.Name, check, want[check.Name])
+		}
+	}
+
+	// Liveness only depends on the database
+	rec = httptest.NewRecorder()
+	h.Check(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("/healthz status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"schooner/internal/reconcile"
+)
+
+// ReconcileHandler reports on and runs the reconciler that brings apps,
+// add-ons and services back in line with what should be running
+type ReconcileHandler struct {
+	reconciler *reconcile.Reconciler
+}
+
+// NewReconcileHandler creates a new ReconcileHandler
+func NewReconcileHandler(reconciler *reconcile.Reconciler) *ReconcileHandler {
+	return &ReconcileHandler{reconciler: reconciler}
+}
+
+// Last handles GET /api/reconcile - what the latest pass fixed, or null
+// before the first one
+func (h *ReconcileHandler) Last(w http.ResponseWriter, r *http.Request) {
+	if h.reconciler == nil {
+		http.Error(w, "Docker is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.reconciler.Last())
+}
+
+// Run handles POST /api/reconcile - reconciles now and returns what was
+// fixed
+func (h *ReconcileHandler) Run(w http.ResponseWriter, r *http.Request) {
+	if h.reconciler == nil {
+		http.Error(w, "Docker is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	result := h.reconciler.Run(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
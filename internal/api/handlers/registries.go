@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/models"
+)
+
+// GetRegistries handles GET /api/settings/registries - lists the registries
+// images are pushed to, without their passwords
+func (h *SettingsHandler) GetRegistries(w http.ResponseWriter, r *http.Request) {
+	credentials, err := h.settingsQueries.GetRegistryCredentials(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to get registry credentials", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	registries := make([]models.RegistryCredential, 0, len(credentials))
+	for _, c := range credentials {
+		registries = append(registries, models.RegistryCredential{Registry: c.Registry, Username: c.Username})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(registries)
+}
+
+// SetRegistry handles POST /api/settings/registries - stores the login for a
+// registry, replacing any it had
+func (h *SettingsHandler) SetRegistry(w http.ResponseWriter, r *http.Request) {
+	var req models.RegistryCredential
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.settingsQueries.SetRegistryCredential(r.Context(), req); err != nil {
+		slog.ErrorContext(r.Context(), "failed to save registry credentials", "registry", req.Registry, "error", err)
+		http.Error(w, "failed to save registry credentials", http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "registry credentials saved", "registry", req.Registry)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"registry": req.Registry,
+	})
+}
+
+// DeleteRegistry handles DELETE /api/settings/registries/{registry}
+func (h *SettingsHandler) DeleteRegistry(w http.ResponseWriter, r *http.Request) {
+	registry := chi.URLParam(r, "registry")
+
+	found, err := h.settingsQueries.DeleteRegistryCredential(r.Context(), registry)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to delete registry credentials", "registry", registry, "error", err)
+		http.Error(w, "failed to delete registry credentials", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "registry not found", http.StatusNotFound)
+		return
+	}
+	slog.InfoContext(r.Context(), "registry credentials deleted", "registry", registry)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+func TestSettingsHandler_Registries(t *testing.T) {
+	db := newTestDB(t)
+
+	settingsQueries := queries.NewSettingsQueries(db.DB)
+	h := NewSettingsHandler(settingsQueries, nil, nil, nil, nil)
+	router := chi.NewRouter()
+	router.Get("/api/settings/registries", h.GetRegistries)
+	router.Post("/api/settings/registries", h.SetRegistry)
+	router.Delete("/api/settings/registries/{registry}", h.DeleteRegistry)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "add", method: http.MethodPost, path: "/api/settings/registries", body: `{"registry": "ghcr.io", "username": "bot", "password": "old"}`, wantStatus: http.StatusOK},
+		{name: "replace", method: http.MethodPost, path: "/api/settings/registries", body: `{"registry": " ghcr.io ", "username": "bot", "password": "new"}`, wantStatus: http.StatusOK},
+		{name: "add another", method: http.MethodPost, path: "/api/settings/registries", body: `{"registry": "registry.example.com:5000", "username": "ci", "password": "pw"}`, wantStatus: http.StatusOK},
+		{name: "no password", method: http.MethodPost, path: "/api/settings/registries", body: `{"registry": "docker.io", "username": "bot"}`, wantStatus: http.StatusBadRequest},
+		{name: "path as registry", method: http.MethodPost, path: "/api/settings/registries", body: `{"registry": "ghcr.io/org", "username": "bot", "password": "pw"}`, wantStatus: http.StatusBadRequest},
+		{name: "delete", method: http.MethodDelete, path: "/api/settings/registries/registry.example.com:5000", wantStatus: http.StatusNoContent},
+		{name: "delete unknown", method: http.MethodDelete, path: "/api/settings/registries/docker.io", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/settings/registries", nil))
+	if strings.Contains(rec.Body.String(), "new") {
+		t.Errorf("registries = %s, want passwords left out", rec.Body)
+	}
+	var listed []models.RegistryCredential
+	if err := json.NewDecoder(rec.Body).Decode(&listed); err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 || listed[0].Registry != "ghcr.io" || listed[0].Username != "bot" {
+		t.Errorf("registries = %+v, want only ghcr.io", listed)
+	}
+
+	stored, err := settingsQueries.GetRegistryCredentials(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || stored[0].Password != "new" {
+		t.Errorf("stored credentials = %+v, want the replaced password", stored)
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"schooner/internal/models"
+	"schooner/ui"
+)
+
+// templateFuncs expose the HTML helpers pages written with fmt share to
+// the templates. Their output is escaped where it's built.
+var templateFuncs = template.FuncMap{
+	"uptimeBadge": func(check *models.UptimeCheck) template.HTML {
+		return template.HTML(uptimeBadge(check))
+	},
+	"dockerHostSelect": func(hosts []*models.DockerHost, selectedID string) template.HTML {
+		return template.HTML(dockerHostSelect(hosts, selectedID))
+	},
+	"agentSelect": func(agents []*models.Agent, selectedID string) template.HTML {
+		return template.HTML(agentSelect(agents, selectedID))
+	},
+	"buildStatusColors": buildStatusColors,
+	"commitURL":         commitURL,
+	"formatBuildTime":   formatBuildTime,
+	"activityColor":     activityColor,
+	"trackedRef":        trackedRef,
+	"join":              strings.Join,
+	"truncate": func(s string, n int) string {
+		if len(s) > n {
+			return s[:n] + "..."
+		}
+		return s
+	},
+}
+
+// pageTemplates are the templates under ui/pages and ui/components, parsed
+// once at startup
+var pageTemplates = template.Must(template.New("").Funcs(templateFuncs).ParseFS(ui.Templates, "components/*.html", "pages/*.html"))
+
+// renderTemplate writes the named template. The response has usually been
+// started by the time one fails, so failures are only logged.
+func renderTemplate(w io.Writer, name string, data any) {
+	if err := pageTemplates.ExecuteTemplate(w, name, data); err != nil {
+		slog.Error("failed to render template", "template", name, "error", err)
+	}
+}
+
+// formatBuildTime formats a build timestamp for display
+// If < 24 hours ago: shows relative time like "2h 30m ago"
+// If >= 24 hours ago: shows datetime like "Jan 2, 15:04"
+func formatBuildTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	now := time.Now()
+	diff := now.Sub(t)
+
+	if diff < 24*time.Hour {
+		hours := int(diff.Hours())
+		minutes := int(diff.Minutes()) % 60
+
+		if hours > 0 {
+			return fmt.Sprintf("%dh %dm ago", hours, minutes)
+		}
+		if minutes > 0 {
+			return fmt.Sprintf("%dm ago", minutes)
+		}
+		return "just now"
+	}
+
+	// More than 24 hours ago - show date and time
+	return t.Format("Jan 2, 15:04")
+}
+
+// commitURL links to a commit on GitHub:
+// https://github.com/user/repo.git -> https://github.com/user/repo/commit/SHA
+func commitURL(repoURL, sha string) string {
+	webURL := strings.TrimSuffix(repoURL, ".git")
+	webURL = strings.Replace(webURL, "git@github.com:", "https://github.com/", 1)
+	if !strings.HasPrefix(webURL, "https://") {
+		webURL = "https://" + webURL
+	}
+	return webURL + "/commit/" + sha
+}
+
+// trackedRef describes what an app builds: its branch, its release tags or
+// its published releases
+func trackedRef(app *models.App) string {
+	switch {
+	case app.ReleaseEvents && app.GetTagPattern() != "":
+		return app.GetTagPattern() + " releases"
+	case app.ReleaseEvents:
+		return "GitHub releases"
+	case app.BuildsOnTags():
+		return app.GetTagPattern() + " tags"
+	}
+	return app.Branch
+}
+
+// buildStatusColors picks the colors of a build's status badge
+func buildStatusColors(status models.BuildStatus) string {
+	switch status {
+	case models.BuildStatusSuccess:
+		return "bg-green-100 text-green-700"
+	case models.BuildStatusFailed:
+		return "bg-red-100 text-red-700"
+	case models.BuildStatusBuilding, models.BuildStatusCloning, models.BuildStatusDeploying:
+		return "bg-blue-100 text-blue-700"
+	case models.BuildStatusPending, models.BuildStatusWaitingApproval, models.BuildStatusScheduled:
+		return "bg-yellow-100 text-yellow-700"
+	}
+	return "bg-gray-100 text-gray-700"
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+// resourceRange is a span of resource history and the step its samples
+// are averaged over, keeping charts to a few hundred points
+type resourceRange struct {
+	span time.Duration
+	step time.Duration
+}
+
+// resourceRanges are the spans /api/apps/{appID}/metrics accepts. History
+// older than a day is kept hourly, so longer spans can't step finer.
+var resourceRanges = map[string]resourceRange{
+	"1h":  {span: time.Hour, step: time.Minute},
+	"6h":  {span: 6 * time.Hour, step: time.Minute},
+	"24h": {span: 24 * time.Hour, step: 5 * time.Minute},
+	"7d":  {span: 7 * 24 * time.Hour, step: time.Hour},
+	"30d": {span: 30 * 24 * time.Hour, step: 6 * time.Hour},
+}
+
+// ResourceHandler handles per-app resource usage history
+type ResourceHandler struct {
+	resourceQueries *queries.ResourceQueries
+	appQueries      *queries.AppQueries
+}
+
+// NewResourceHandler creates a new ResourceHandler
+func NewResourceHandler(resourceQueries *queries.ResourceQueries, appQueries *queries.AppQueries) *ResourceHandler {
+	return &ResourceHandler{
+		resourceQueries: resourceQueries,
+		appQueries:      appQueries,
+	}
+}
+
+// Metrics handles GET /api/apps/{appID}/metrics?range=24h
+func (h *ResourceHandler) Metrics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID := chi.URLParam(r, "appID")
+
+	name := r.URL.Query().Get("range")
+	if name == "" {
+		name = "24h"
+	}
+	rng, ok := resourceRanges[name]
+	if !ok {
+		http.Error(w, "range must be one of 1h, 6h, 24h, 7d or 30d", http.StatusBadRequest)
+		return
+	}
+
+	app, err := h.appQueries.GetByID(ctx, appID)
+	if err != nil {
+		http.Error(w, "failed to get app", http.StatusInternalServerError)
+		return
+	}
+	if app == nil {
+		http.Error(w, "app not found", http.StatusNotFound)
+		return
+	}
+
+	samples, err := h.resourceQueries.ListSince(ctx, appID, time.Now().Add(-rng.span))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list resource samples", "appID", appID, "error", err)
+		http.Error(w, "failed to get resource usage", http.StatusInternalServerError)
+		return
+	}
+	samples = models.DownsampleResources(samples, rng.step)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"range":        name,
+		"step_seconds": int(rng.step.Seconds()),
+		"samples":      samples,
+	})
+}
+
+func (h *PageHandler) renderAppResources(w http.ResponseWriter, app *models.App) {
+	fmt.Fprintf(w, `
+        <div class="flex items-center justify-between mb-4">
+            <h2 class="text-xl font-bold">Resource Usage</h2>
+            <select id="resources-range" class="bg-gray-50 border border-gray-200 rounded px-2 py-1 text-sm">
+                <option value="1h">Last hour</option>
+                <option value="6h">Last 6 hours</option>
+                <option value="24h" selected>Last 24 hours</option>
+                <option value="7d">Last 7 days</option>
+                <option value="30d">Last 30 days</option>
+            </select>
+        </div>
+        <div class="bg-white shadow-sm rounded-lg p-6 border border-gray-200 mb-8" id="resources-section" data-app-id="%s">
+            <p id="resources-empty" class="hidden text-sm text-gray-500">No usage recorded in this range. Usage is recorded every minute while the app's containers run on this server.</p>
+            <div id="resources-charts" class="grid grid-cols-1 md:grid-cols-2 gap-6">
+                <div>
+                    <div class="flex justify-between text-sm mb-1">
+                        <span class="text-gray-500">CPU</span>
+                        <span id="resources-cpu-peak" class="text-gray-400"></span>
+                    </div>
+                    <svg id="resources-cpu" class="w-full h-24 bg-gray-50 rounded" viewBox="0 0 300 100" preserveAspectRatio="none"></svg>
+                </div>
+                <div>
+                    <div class="flex justify-between text-sm mb-1">
+                        <span class="text-gray-500">Memory</span>
+                        <span id="resources-mem-peak" class="text-gray-400"></span>
+                    </div>
+                    <svg id="resources-mem" class="w-full h-24 bg-gray-50 rounded" viewBox="0 0 300 100" preserveAspectRatio="none"></svg>
+                </div>
+            </div>
+            <div class="text-sm text-gray-500 mt-3"><span id="resources-restarts">0</span> restarts in this range</div>
+        </div>
+        <script>
+            (function() {
+                const appID = document.getElementById('resources-section').dataset.appId;
+                const rangeSelect = document.getElementById('resources-range');
+                const megabytes = bytes => (bytes / 1048576).toFixed(bytes < 10485760 ? 1 : 0) + ' MB';
+
+                function plot(id, values, max) {
+                    const svg = document.getElementById(id);
+                    if (values.length < 2) {
+                        svg.innerHTML = '';
+                        return;
+                    }
+                    const points = values.map((v, i) =>
+                        (i / (values.length - 1) * 300).toFixed(1) + ',' + (100 - v / max * 95).toFixed(1)).join(' ');
+                    svg.innerHTML = '<polyline fill="none" stroke="#2563eb" stroke-width="1.5" vector-effect="non-scaling-stroke" points="' + points + '"/>';
+                }
+
+                function loadResources() {
+                    fetch('/api/apps/' + appID + '/metrics?range=' + rangeSelect.value)
+                        .then(response => response.json())
+                        .then(data => {
+                            const samples = data.samples;
+                            document.getElementById('resources-empty').classList.toggle('hidden', samples.length > 0);
+                            document.getElementById('resources-charts').classList.toggle('hidden', samples.length === 0);
+
+                            const cpu = samples.map(s => s.cpu_percent);
+                            const cpuPeak = Math.max(0, ...cpu);
+                            plot('resources-cpu', cpu, Math.max(1, cpuPeak));
+                            document.getElementById('resources-cpu-peak').textContent = 'peak ' + cpuPeak.toFixed(1) + '%%';
+
+                            const mem = samples.map(s => s.memory_usage);
+                            const memPeak = Math.max(0, ...mem);
+                            plot('resources-mem', mem, Math.max(1, memPeak));
+                            document.getElementById('resources-mem-peak').textContent = 'peak ' + megabytes(memPeak);
+
+                            // Restart counts are per container and start over
+                            // when a deploy replaces it
+                            let restarts = 0;
+                            for (let i = 1; i < samples.length; i++) {
+                                restarts += Math.max(0, samples[i].restart_count - samples[i - 1].restart_count);
+                            }
+                            document.getElementById('resources-restarts').textContent = restarts;
+                        });
+                }
+
+                rangeSelect.addEventListener('change', loadResources);
+                loadResources();
+            })();
+        </script>`,
+		html.EscapeString(app.ID))
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"schooner/internal/selfupdate"
+)
+
+// UpdateHandler handles checking for and applying Schooner releases
+type UpdateHandler struct {
+	updater *selfupdate.Manager
+}
+
+// NewUpdateHandler creates a new UpdateHandler
+func NewUpdateHandler(updater *selfupdate.Manager) *UpdateHandler {
+	return &UpdateHandler{updater: updater}
+}
+
+// Status handles GET /api/update - the latest release and whether Schooner
+// can update to it. ?check=true looks for a release now.
+func (h *UpdateHandler) Status(w http.ResponseWriter, r *http.Request) {
+	status := h.updater.Status()
+	if r.URL.Query().Get("check") == "true" {
+		var err error
+		if status, err = h.updater.Check(r.Context()); err != nil {
+			slog.WarnContext(r.Context(), "failed to check for Schooner updates", "error", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// Apply handles POST /api/update - updates Schooner to the latest release.
+// Schooner restarts on the new image shortly after this returns.
+func (h *UpdateHandler) Apply(w http.ResponseWriter, r *http.Request) {
+	if err := h.updater.Update(r.Context()); err != nil {
+		switch {
+		case errors.Is(err, selfupdate.ErrNoUpdate),
+			errors.Is(err, selfupdate.ErrNoImage),
+			errors.Is(err, selfupdate.ErrNotInContainer),
+			errors.Is(err, selfupdate.ErrBuildsRunning),
+			errors.Is(err, selfupdate.ErrUpdating):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			slog.ErrorContext(r.Context(), "failed to update Schooner", "error", err)
+			http.Error(w, "failed to update Schooner", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	status := h.updater.Status()
+	slog.InfoContext(r.Context(), "Schooner update requested", "image", status.Image)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"image":   status.Image,
+		"message": "Updating Schooner, it will restart shortly",
+	})
+}
+
+// SetUpdater shows a banner on every page when a Schooner release is
+// available
+func (h *PageHandler) SetUpdater(updater *selfupdate.Manager) {
+	h.updater = updater
+}
+
+// renderUpdateBanner writes a notice on every page while a newer Schooner
+// release is available, with a button updating to it
+func (h *PageHandler) renderUpdateBanner(w http.ResponseWriter, ctx context.Context) {
+	if h.updater == nil {
+		return
+	}
+	status := h.updater.Status()
+	if !status.Available || status.Latest == nil {
+		return
+	}
+
+	renderTemplate(w, "update-available", status)
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"schooner/internal/config"
+	"schooner/internal/github"
+	"schooner/internal/selfupdate"
+)
+
+type stubReleases struct{}
+
+func (stubReleases) GetLatestRelease(ctx context.Context, owner, repo string) (*github.Release, error) {
+	return &github.Release{TagName: "v2.0.0", HTMLURL: "https://github.com/bas-slats/schooner/releases/tag/v2.0.0"}, nil
+}
+
+func (stubReleases) CompareCommits(ctx context.Context, owner, repo, base, head string) (string, error) {
+	return "ahead", nil
+}
+
+func TestUpdateHandler(t *testing.T) {
+	updater := selfupdate.NewManager(config.UpdateConfig{Repo: "bas-slats/schooner", Image: "ghcr.io/bas-slats/schooner"}, "abc123", stubReleases{})
+	h := NewUpdateHandler(updater)
+	pages := &PageHandler{}
+	pages.SetUpdater(updater)
+
+	// Nothing to update to before a check
+	rec := httptest.NewRecorder()
+	h.Apply(rec, httptest.NewRequest(http.MethodPost, "/api/update", nil))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Apply() before a check status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	banner := httptest.NewRecorder()
+	pages.renderUpdateBanner(banner, context.Background())
+	if banner.Body.Len() != 0 {
+		t.Errorf("banner shown before a release was found: %s", banner.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Status(rec, httptest.NewRequest(http.MethodGet, "/api/update?check=true", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"available":true`) {
+		t.Fatalf("Status() = %d %s, want the release available", rec.Code, rec.Body)
+	}
+	banner = httptest.NewRecorder()
+	pages.renderUpdateBanner(banner, context.Background())
+	for _, want := range []string{
+		"Schooner v2.0.0 is available.", `href="https://github.com/bas-slats/schooner/releases/tag/v2.0.0"`,
+		`id="update-button" data-tag="v2.0.0"`, "/static/js/update.js",
+	} {
+		if !strings.Contains(banner.Body.String(), want) {
+			t.Errorf("banner = %s, want %s", banner.Body, want)
+		}
+	}
+
+	// Not running in a container Docker can swap
+	rec = httptest.NewRecorder()
+	h.Apply(rec, httptest.NewRequest(http.MethodPost, "/api/update", nil))
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "not running in a container") {
+		t.Errorf("Apply() = %d %s, want %d", rec.Code, rec.Body, http.StatusConflict)
+	}
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/auth"
+)
+
+// SessionHandler handles listing and revoking dashboard sessions
+type SessionHandler struct {
+	sessionStore *auth.SessionStore
+}
+
+// NewSessionHandler creates a new SessionHandler
+func NewSessionHandler(sessionStore *auth.SessionStore) *SessionHandler {
+	return &SessionHandler{
+		sessionStore: sessionStore,
+	}
+}
+
+// sessionResponse is a session as returned by the API
+type sessionResponse struct {
+	auth.Session
+	Current bool `json:"current"`
+}
+
+// List handles GET /api/sessions
+func (h *SessionHandler) List(w http.ResponseWriter, r *http.Request) {
+	var currentID string
+	if current := auth.GetSession(r.Context()); current != nil {
+		currentID = current.ID
+	}
+
+	sessions := h.sessionStore.List()
+	response := make([]sessionResponse, len(sessions))
+	for i, session := range sessions {
+		response[i] = sessionResponse{Session: session, Current: session.ID == currentID}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Revoke handles DELETE /api/sessions/{sessionID}
+func (h *SessionHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionID")
+
+	h.sessionStore.Delete(sessionID)
+	slog.InfoContext(r.Context(), "session revoked", "id", sessionID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Session revoked",
+	})
+}
+
+// RevokeAll handles POST /api/sessions/revoke-all, signing out every
+// session including the current one
+func (h *SessionHandler) RevokeAll(w http.ResponseWriter, r *http.Request) {
+	count := h.sessionStore.DeleteAllExcept("")
+	auth.ClearSessionCookie(w)
+
+	slog.InfoContext(r.Context(), "all sessions revoked", "count", count)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Signed out %d sessions", count),
+	})
+}
+
+// Sessions handles GET /sessions
+func (h *PageHandler) Sessions(w http.ResponseWriter, r *http.Request) {
+	h.writeHeader(w, r, "Sessions")
+
+	fmt.Fprint(w, `
+        <div class="flex items-center justify-between mb-6">
+            <h1 class="text-2xl font-bold">Sessions</h1>
+            <button onclick="revokeAllSessions()" class="px-4 py-2 bg-red-600 hover:bg-red-700 rounded text-white">Sign out everywhere</button>
+        </div>
+        <div class="bg-white shadow-sm rounded-lg p-6 border border-gray-200">
+            <p class="text-gray-500 mb-4">Browsers currently signed in to this dashboard. Revoke any you don't recognize.</p>
+            <table class="w-full text-sm">
+                <thead>
+                    <tr class="text-left text-gray-500 border-b border-gray-200">
+                        <th class="py-2">Device</th>
+                        <th class="py-2">IP address</th>
+                        <th class="py-2">Signed in</th>
+                        <th class="py-2">Last seen</th>
+                        <th class="py-2"></th>
+                    </tr>
+                </thead>
+                <tbody id="sessions-body">
+                    <tr><td colspan="5" class="py-2 text-gray-400">Loading...</td></tr>
+                </tbody>
+            </table>
+        </div>
+        <script>
+            function escapeSessionText(s) {
+                const div = document.createElement('div');
+                div.textContent = s == null ? '' : String(s);
+                return div.innerHTML;
+            }
+
+            function loadSessions() {
+                fetch('/api/sessions')
+                    .then(response => response.json())
+                    .then(sessions => {
+                        const body = document.getElementById('sessions-body');
+                        if (sessions.length === 0) {
+                            body.innerHTML = '<tr><td colspan="5" class="py-2 text-gray-400">No active sessions</td></tr>';
+                            return;
+                        }
+                        body.innerHTML = sessions.map(session => {
+                            const action = session.current
+                                ? '<span class="text-gray-400">This browser</span>'
+                                : '<button onclick="revokeSession(\'' + session.id + '\')" class="text-red-600 hover:text-red-700">Revoke</button>';
+                            return '<tr class="border-b border-gray-100">' +
+                                '<td class="py-2 pr-4 break-all">' + escapeSessionText(session.user_agent || 'Unknown') +
+                                    '<div class="text-xs text-gray-400">' + escapeSessionText(session.username) + '</div></td>' +
+                                '<td class="py-2 font-mono text-xs">' + escapeSessionText(session.ip) + '</td>' +
+                                '<td class="py-2 text-gray-500">' + escapeSessionText(new Date(session.created_at).toLocaleString()) + '</td>' +
+                                '<td class="py-2 text-gray-500">' + escapeSessionText(new Date(session.last_seen_at).toLocaleString()) + '</td>' +
+                                '<td class="py-2 text-right">' + action + '</td>' +
+                                '</tr>';
+                        }).join('');
+                    });
+            }
+
+            function revokeSession(id) {
+                if (!confirm('Sign out this session?')) {
+                    return;
+                }
+                fetch('/api/sessions/' + id, { method: 'DELETE' })
+                    .then(response => {
+                        if (response.ok) {
+                            showToast('Session revoked', 'success');
+                            loadSessions();
+                        } else {
+                            response.text().then(text => alert('Failed to revoke session: ' + text));
+                        }
+                    });
+            }
+
+            function revokeAllSessions() {
+                if (!confirm('Sign out every session, including this one?')) {
+                    return;
+                }
+                fetch('/api/sessions/revoke-all', { method: 'POST' })
+                    .then(response => {
+                        if (response.ok) {
+                            window.location.href = '/';
+                        } else {
+                            response.text().then(text => alert('Failed to sign out: ' + text));
+                        }
+                    });
+            }
+
+            loadSessions();
+        </script>`)
+
+	h.writeFooter(w)
+}
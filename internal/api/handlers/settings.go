@@ -6,8 +6,11 @@ import (
 	"log/slog"
 	"net/http"
 
+	"schooner/internal/build"
 	"schooner/internal/cloudflare"
+	"schooner/internal/crypto"
 	"schooner/internal/database/queries"
+	"schooner/internal/docker"
 	"schooner/internal/git"
 	"schooner/internal/github"
 	"schooner/internal/observability"
@@ -20,6 +23,7 @@ type SettingsHandler struct {
 	gitClient            *git.Client
 	tunnelManager        *cloudflare.Manager
 	observabilityManager *observability.Manager
+	orchestrator         *build.Orchestrator
 }
 
 // NewSettingsHandler creates a new SettingsHandler
@@ -39,14 +43,16 @@ func (h *SettingsHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 
 	settings, err := h.settingsQueries.GetAll(ctx)
 	if err != nil {
-		slog.Error("failed to get settings", "error", err)
+		slog.ErrorContext(ctx, "failed to get settings", "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
 
 	// Mask sensitive values
-	if _, ok := settings["github_token"]; ok {
-		settings["github_token"] = "********"
+	for key := range settings {
+		if crypto.IsSensitiveKey(key) {
+			settings[key] = "********"
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -74,14 +80,14 @@ func (h *SettingsHandler) SetGitHubToken(w http.ResponseWriter, r *http.Request)
 	testClient := github.NewClient(req.Token)
 	username, err := testClient.GetUser(ctx)
 	if err != nil {
-		slog.Error("invalid GitHub token", "error", err)
+		slog.ErrorContext(ctx, "invalid GitHub token", "error", err)
 		http.Error(w, "invalid GitHub token: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Save the token
 	if err := h.settingsQueries.Set(ctx, "github_token", req.Token); err != nil {
-		slog.Error("failed to save GitHub token", "error", err)
+		slog.ErrorContext(ctx, "failed to save GitHub token", "error", err)
 		http.Error(w, "failed to save token", http.StatusInternalServerError)
 		return
 	}
@@ -92,7 +98,7 @@ func (h *SettingsHandler) SetGitHubToken(w http.ResponseWriter, r *http.Request)
 		h.gitClient.SetHTTPAuth("x-access-token", req.Token)
 	}
 
-	slog.Info("GitHub token configured", "username", username)
+	slog.InfoContext(ctx, "GitHub token configured", "username", username)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -107,7 +113,7 @@ func (h *SettingsHandler) DeleteGitHubToken(w http.ResponseWriter, r *http.Reque
 	ctx := r.Context()
 
 	if err := h.settingsQueries.Delete(ctx, "github_token"); err != nil {
-		slog.Error("failed to delete GitHub token", "error", err)
+		slog.ErrorContext(ctx, "failed to delete GitHub token", "error", err)
 		http.Error(w, "failed to delete token", http.StatusInternalServerError)
 		return
 	}
@@ -118,7 +124,7 @@ func (h *SettingsHandler) DeleteGitHubToken(w http.ResponseWriter, r *http.Reque
 		h.gitClient.SetHTTPAuth("", "")
 	}
 
-	slog.Info("GitHub token removed")
+	slog.InfoContext(ctx, "GitHub token removed")
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -129,7 +135,7 @@ func (h *SettingsHandler) GetGitHubStatus(w http.ResponseWriter, r *http.Request
 
 	token, err := h.settingsQueries.Get(ctx, "github_token")
 	if err != nil {
-		slog.Error("failed to get GitHub token", "error", err)
+		slog.ErrorContext(ctx, "failed to get GitHub token", "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -156,7 +162,7 @@ func (h *SettingsHandler) GetCloneDirectory(w http.ResponseWriter, r *http.Reque
 
 	cloneDir, err := h.settingsQueries.Get(ctx, "clone_directory")
 	if err != nil {
-		slog.Error("failed to get clone directory", "error", err)
+		slog.ErrorContext(ctx, "failed to get clone directory", "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -191,12 +197,12 @@ func (h *SettingsHandler) SetCloneDirectory(w http.ResponseWriter, r *http.Reque
 
 	// Save the setting
 	if err := h.settingsQueries.Set(ctx, "clone_directory", req.CloneDirectory); err != nil {
-		slog.Error("failed to save clone directory", "error", err)
+		slog.ErrorContext(ctx, "failed to save clone directory", "error", err)
 		http.Error(w, "failed to save clone directory", http.StatusInternalServerError)
 		return
 	}
 
-	slog.Info("clone directory configured", "path", req.CloneDirectory)
+	slog.InfoContext(ctx, "clone directory configured", "path", req.CloneDirectory)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -261,7 +267,7 @@ func (h *SettingsHandler) SetTunnelConfig(w http.ResponseWriter, r *http.Request
 	// Save settings
 	if req.TunnelToken != "" {
 		if err := h.settingsQueries.Set(ctx, "cloudflare_tunnel_token", req.TunnelToken); err != nil {
-			slog.Error("failed to save tunnel token", "error", err)
+			slog.ErrorContext(ctx, "failed to save tunnel token", "error", err)
 			http.Error(w, "failed to save tunnel token", http.StatusInternalServerError)
 			return
 		}
@@ -269,7 +275,7 @@ func (h *SettingsHandler) SetTunnelConfig(w http.ResponseWriter, r *http.Request
 
 	if req.TunnelID != "" {
 		if err := h.settingsQueries.Set(ctx, "cloudflare_tunnel_id", req.TunnelID); err != nil {
-			slog.Error("failed to save tunnel ID", "error", err)
+			slog.ErrorContext(ctx, "failed to save tunnel ID", "error", err)
 			http.Error(w, "failed to save tunnel ID", http.StatusInternalServerError)
 			return
 		}
@@ -277,7 +283,7 @@ func (h *SettingsHandler) SetTunnelConfig(w http.ResponseWriter, r *http.Request
 
 	if req.Domain != "" {
 		if err := h.settingsQueries.Set(ctx, "cloudflare_domain", req.Domain); err != nil {
-			slog.Error("failed to save domain", "error", err)
+			slog.ErrorContext(ctx, "failed to save domain", "error", err)
 			http.Error(w, "failed to save domain", http.StatusInternalServerError)
 			return
 		}
@@ -285,13 +291,13 @@ func (h *SettingsHandler) SetTunnelConfig(w http.ResponseWriter, r *http.Request
 
 	if req.APIToken != "" {
 		if err := h.settingsQueries.Set(ctx, "cloudflare_api_token", req.APIToken); err != nil {
-			slog.Error("failed to save API token", "error", err)
+			slog.ErrorContext(ctx, "failed to save API token", "error", err)
 			http.Error(w, "failed to save API token", http.StatusInternalServerError)
 			return
 		}
 	}
 
-	slog.Info("cloudflare tunnel settings saved", "domain", req.Domain, "has_api_token", req.APIToken != "")
+	slog.InfoContext(ctx, "cloudflare tunnel settings saved", "domain", req.Domain, "has_api_token", req.APIToken != "")
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -310,7 +316,7 @@ func (h *SettingsHandler) StartTunnel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.tunnelManager.Start(ctx); err != nil {
-		slog.Error("failed to start tunnel", "error", err)
+		slog.ErrorContext(ctx, "failed to start tunnel", "error", err)
 		http.Error(w, "failed to start tunnel: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -332,7 +338,7 @@ func (h *SettingsHandler) StopTunnel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.tunnelManager.Stop(ctx); err != nil {
-		slog.Error("failed to stop tunnel", "error", err)
+		slog.ErrorContext(ctx, "failed to stop tunnel", "error", err)
 		http.Error(w, "failed to stop tunnel: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -359,7 +365,7 @@ func (h *SettingsHandler) GetObservabilityStatus(w http.ResponseWriter, r *http.
 
 	status, err := h.observabilityManager.GetStatus(ctx)
 	if err != nil {
-		slog.Error("failed to get observability status", "error", err)
+		slog.ErrorContext(ctx, "failed to get observability status", "error", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -368,6 +374,7 @@ func (h *SettingsHandler) GetObservabilityStatus(w http.ResponseWriter, r *http.
 		"available":   true,
 		"enabled":     status.Enabled,
 		"grafana_url": status.GrafanaURL,
+		"embed_mode":  h.observabilityManager.GetEmbedMode(ctx),
 	}
 
 	if status.LokiStatus != nil {
@@ -381,9 +388,20 @@ func (h *SettingsHandler) GetObservabilityStatus(w http.ResponseWriter, r *http.
 	}
 
 	// Check if all services are running
-	running := status.LokiStatus != nil && status.LokiStatus.State == "running" &&
-		status.PromtailStatus != nil && status.PromtailStatus.State == "running" &&
-		status.GrafanaStatus != nil && status.GrafanaStatus.State == "running"
+	running := isContainerRunning(status.LokiStatus) &&
+		isContainerRunning(status.PromtailStatus) &&
+		isContainerRunning(status.GrafanaStatus)
+
+	response["metrics_enabled"] = status.MetricsEnabled
+	if status.MetricsEnabled {
+		response["prometheus_status"] = containerState(status.PrometheusStatus)
+		response["node_exporter_status"] = containerState(status.NodeExporterStatus)
+		response["cadvisor_status"] = containerState(status.CadvisorStatus)
+		running = running &&
+			isContainerRunning(status.PrometheusStatus) &&
+			isContainerRunning(status.NodeExporterStatus) &&
+			isContainerRunning(status.CadvisorStatus)
+	}
 	response["running"] = running
 
 	w.Header().Set("Content-Type", "application/json")
@@ -395,25 +413,33 @@ func (h *SettingsHandler) SetObservabilityConfig(w http.ResponseWriter, r *http.
 	ctx := r.Context()
 
 	var req struct {
-		Enabled       bool   `json:"enabled"`
-		GrafanaPort   int    `json:"grafana_port"`
-		LokiRetention string `json:"loki_retention"`
+		Enabled             bool   `json:"enabled"`
+		GrafanaPort         int    `json:"grafana_port"`
+		LokiRetention       string `json:"loki_retention"`
+		MetricsEnabled      *bool  `json:"metrics_enabled"`
+		PrometheusRetention string `json:"prometheus_retention"`
+		EmbedMode           string `json:"embed_mode"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid request body", http.StatusBadRequest)
 		return
 	}
 
+	if req.EmbedMode != "" && req.EmbedMode != observability.EmbedModeAnonymous && req.EmbedMode != observability.EmbedModeSigned {
+		http.Error(w, "embed_mode must be anonymous or signed", http.StatusBadRequest)
+		return
+	}
+
 	// Save settings
 	if err := h.settingsQueries.Set(ctx, "observability_enabled", fmt.Sprintf("%t", req.Enabled)); err != nil {
-		slog.Error("failed to save observability enabled", "error", err)
+		slog.ErrorContext(ctx, "failed to save observability enabled", "error", err)
 		http.Error(w, "failed to save settings", http.StatusInternalServerError)
 		return
 	}
 
 	if req.GrafanaPort > 0 {
 		if err := h.settingsQueries.Set(ctx, "observability_grafana_port", fmt.Sprintf("%d", req.GrafanaPort)); err != nil {
-			slog.Error("failed to save Grafana port", "error", err)
+			slog.ErrorContext(ctx, "failed to save Grafana port", "error", err)
 			http.Error(w, "failed to save settings", http.StatusInternalServerError)
 			return
 		}
@@ -421,13 +447,37 @@ func (h *SettingsHandler) SetObservabilityConfig(w http.ResponseWriter, r *http.
 
 	if req.LokiRetention != "" {
 		if err := h.settingsQueries.Set(ctx, "observability_loki_retention", req.LokiRetention); err != nil {
-			slog.Error("failed to save Loki retention", "error", err)
+			slog.ErrorContext(ctx, "failed to save Loki retention", "error", err)
 			http.Error(w, "failed to save settings", http.StatusInternalServerError)
 			return
 		}
 	}
 
-	slog.Info("observability settings saved", "enabled", req.Enabled, "grafana_port", req.GrafanaPort, "retention", req.LokiRetention)
+	if req.MetricsEnabled != nil {
+		if err := h.settingsQueries.Set(ctx, "observability_metrics_enabled", fmt.Sprintf("%t", *req.MetricsEnabled)); err != nil {
+			slog.ErrorContext(ctx, "failed to save metrics enabled", "error", err)
+			http.Error(w, "failed to save settings", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if req.PrometheusRetention != "" {
+		if err := h.settingsQueries.Set(ctx, "observability_prometheus_retention", req.PrometheusRetention); err != nil {
+			slog.ErrorContext(ctx, "failed to save Prometheus retention", "error", err)
+			http.Error(w, "failed to save settings", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if req.EmbedMode != "" {
+		if err := h.settingsQueries.Set(ctx, "observability_grafana_embed_mode", req.EmbedMode); err != nil {
+			slog.ErrorContext(ctx, "failed to save Grafana embed mode", "error", err)
+			http.Error(w, "failed to save settings", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	slog.InfoContext(ctx, "observability settings saved", "enabled", req.Enabled, "grafana_port", req.GrafanaPort, "retention", req.LokiRetention)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -447,13 +497,13 @@ func (h *SettingsHandler) StartObservability(w http.ResponseWriter, r *http.Requ
 
 	// Enable observability before starting
 	if err := h.settingsQueries.Set(ctx, "observability_enabled", "true"); err != nil {
-		slog.Error("failed to enable observability", "error", err)
+		slog.ErrorContext(ctx, "failed to enable observability", "error", err)
 		http.Error(w, "failed to enable observability", http.StatusInternalServerError)
 		return
 	}
 
 	if err := h.observabilityManager.Start(ctx); err != nil {
-		slog.Error("failed to start observability stack", "error", err)
+		slog.ErrorContext(ctx, "failed to start observability stack", "error", err)
 		http.Error(w, "failed to start observability: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -476,14 +526,14 @@ func (h *SettingsHandler) StopObservability(w http.ResponseWriter, r *http.Reque
 	}
 
 	if err := h.observabilityManager.Stop(ctx); err != nil {
-		slog.Error("failed to stop observability stack", "error", err)
+		slog.ErrorContext(ctx, "failed to stop observability stack", "error", err)
 		http.Error(w, "failed to stop observability: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Disable observability after stopping
 	if err := h.settingsQueries.Set(ctx, "observability_enabled", "false"); err != nil {
-		slog.Warn("failed to disable observability setting", "error", err)
+		slog.WarnContext(ctx, "failed to disable observability setting", "error", err)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -492,3 +542,16 @@ func (h *SettingsHandler) StopObservability(w http.ResponseWriter, r *http.Reque
 		"message": "Observability stack stopped",
 	})
 }
+
+// isContainerRunning returns true if the container status reports running
+func isContainerRunning(status *docker.ContainerStatus) bool {
+	return status != nil && status.State == "running"
+}
+
+// containerState returns the container state or "-" if unknown
+func containerState(status *docker.ContainerStatus) string {
+	if status == nil {
+		return "-"
+	}
+	return status.State
+}
@@ -6,6 +6,9 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+
+	"schooner/internal/models"
 )
 
 func TestNewSettingsHandler(t *testing.T) {
@@ -132,3 +135,49 @@ func TestSettingsHandler_SetGitHubToken_InvalidBody(t *testing.T) {
 		t.Errorf("Status = %v, want %v", w.Code, http.StatusBadRequest)
 	}
 }
+
+func TestSettingsHandler_SetBanner_InvalidBody(t *testing.T) {
+	handler := NewSettingsHandler(nil, nil, nil, nil, nil)
+
+	req := httptest.NewRequest("POST", "/api/settings/banner", strings.NewReader("invalid json"))
+	w := httptest.NewRecorder()
+
+	handler.SetBanner(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Status = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestParseBannerRequest(t *testing.T) {
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name      string
+		message   string
+		level     string
+		expiresAt string
+		wantErr   bool
+		wantLevel models.BannerLevel
+	}{
+		{name: "defaults to info", message: "maintenance tonight", wantLevel: models.BannerLevelInfo},
+		{name: "warning with expiry", message: "down", level: "warning", expiresAt: future, wantLevel: models.BannerLevelWarning},
+		{name: "empty message", message: "  ", wantErr: true},
+		{name: "unknown level", message: "hi", level: "critical", wantErr: true},
+		{name: "bad expiry", message: "hi", expiresAt: "tomorrow", wantErr: true},
+		{name: "past expiry", message: "hi", expiresAt: past, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			banner, err := parseBannerRequest(tt.message, tt.level, tt.expiresAt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseBannerRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && banner.Level != tt.wantLevel {
+				t.Errorf("Level = %v, want %v", banner.Level, tt.wantLevel)
+			}
+		})
+	}
+}
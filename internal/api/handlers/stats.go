@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"schooner/internal/models"
+)
+
+// Build statistics cover 30 days unless ?days= asks for up to a year
+const (
+	defaultStatsDays = 30
+	maxStatsDays     = 365
+)
+
+// Stats handles GET /api/stats
+func (h *BuildHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	days := defaultStatsDays
+	if d := r.URL.Query().Get("days"); d != "" {
+		parsed, err := strconv.Atoi(d)
+		if err != nil || parsed < 1 || parsed > maxStatsDays {
+			http.Error(w, fmt.Sprintf("days must be between 1 and %d", maxStatsDays), http.StatusBadRequest)
+			return
+		}
+		days = parsed
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	builds, err := h.buildQueries.ListCompletedSince(r.Context(), today.AddDate(0, 0, -(days-1)))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list builds for stats", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.ComputeBuildStats(builds, days, now))
+}
+
+// renderBuildStats writes the build charts of the dashboard, filled in from
+// /api/stats
+func (h *PageHandler) renderBuildStats(w http.ResponseWriter) {
+	fmt.Fprint(w, `
+        <div class="flex items-center justify-between mt-10 mb-4">
+            <h2 class="text-xl font-bold">Build Statistics</h2>
+            <span class="text-sm text-gray-500">Last 14 days</span>
+        </div>
+        <div id="build-stats" class="grid grid-cols-1 lg:grid-cols-3 gap-4">
+            <div class="bg-white shadow-sm rounded-lg p-4 border border-gray-200 lg:col-span-2">
+                <div class="flex items-center justify-between mb-2">
+                    <span class="text-gray-500 text-sm">Builds per day</span>
+                    <span class="text-sm"><span id="stats-success-rate" class="font-bold">--</span> <span class="text-gray-400">success rate</span></span>
+                </div>
+                <div id="stats-daily" class="flex items-end h-32 gap-1"></div>
+                <div class="flex justify-between text-xs text-gray-400 mt-1">
+                    <span id="stats-first-day"></span>
+                    <span>
+                        <span class="inline-block w-2 h-2 bg-green-400 rounded-sm"></span> succeeded
+                        <span class="inline-block w-2 h-2 bg-red-400 rounded-sm ml-2"></span> failed
+                        <span class="inline-block w-2 h-2 bg-gray-300 rounded-sm ml-2"></span> cancelled
+                    </span>
+                    <span>Today</span>
+                </div>
+            </div>
+            <div class="bg-white shadow-sm rounded-lg p-4 border border-gray-200">
+                <span class="text-gray-500 text-sm">Failure reasons</span>
+                <ul id="stats-failures" class="mt-2 space-y-1 text-sm"></ul>
+            </div>
+            <div class="bg-white shadow-sm rounded-lg border border-gray-200 overflow-hidden lg:col-span-3">
+                <table class="w-full">
+                    <thead class="bg-gray-50">
+                        <tr>
+                            <th class="px-4 py-2 text-left text-sm">App</th>
+                            <th class="px-4 py-2 text-right text-sm">Builds</th>
+                            <th class="px-4 py-2 text-right text-sm">Success</th>
+                            <th class="px-4 py-2 text-right text-sm">Avg</th>
+                            <th class="px-4 py-2 text-right text-sm">p50</th>
+                            <th class="px-4 py-2 text-right text-sm">p95</th>
+                        </tr>
+                    </thead>
+                    <tbody id="stats-apps"></tbody>
+                </table>
+            </div>
+        </div>
+        <script>
+            (function() {
+                const duration = s => s >= 60 ? Math.floor(s / 60) + 'm ' + Math.round(s % 60) + 's' : Math.round(s) + 's';
+                const cell = (text, right) => {
+                    const td = document.createElement('td');
+                    td.className = 'px-4 py-2 text-sm' + (right ? ' text-right' : '');
+                    td.textContent = text;
+                    return td;
+                };
+
+                fetch('/api/stats?days=14')
+                    .then(response => response.json())
+                    .then(stats => {
+                        document.getElementById('stats-success-rate').textContent =
+                            stats.succeeded + stats.failed > 0 ? stats.success_rate + '%' : '--';
+
+                        const daily = document.getElementById('stats-daily');
+                        const max = Math.max(1, ...stats.daily.map(d => d.succeeded + d.failed + d.cancelled));
+                        stats.daily.forEach(d => {
+                            const column = document.createElement('div');
+                            column.className = 'flex-1 flex flex-col justify-end h-full';
+                            column.title = d.date + ': ' + d.succeeded + ' succeeded, ' + d.failed + ' failed, ' + d.cancelled + ' cancelled';
+                            [['cancelled', 'bg-gray-300'], ['failed', 'bg-red-400'], ['succeeded', 'bg-green-400']].forEach(([key, color]) => {
+                                if (!d[key]) return;
+                                const bar = document.createElement('div');
+                                bar.className = color;
+                                bar.style.height = (d[key] / max * 100) + '%';
+                                column.appendChild(bar);
+                            });
+                            daily.appendChild(column);
+                        });
+                        if (stats.daily.length) {
+                            document.getElementById('stats-first-day').textContent = stats.daily[0].date;
+                        }
+
+                        const failures = document.getElementById('stats-failures');
+                        if (!stats.failure_reasons.length) {
+                            failures.innerHTML = '<li class="text-gray-400">No failed builds</li>';
+                        }
+                        stats.failure_reasons.forEach(f => {
+                            const li = document.createElement('li');
+                            li.className = 'flex justify-between';
+                            const reason = document.createElement('span');
+                            reason.className = 'truncate text-gray-700 mr-2';
+                            reason.textContent = f.reason;
+                            const count = document.createElement('span');
+                            count.className = 'text-red-600 font-medium';
+                            count.textContent = f.count;
+                            li.append(reason, count);
+                            failures.appendChild(li);
+                        });
+
+                        const apps = document.getElementById('stats-apps');
+                        if (!stats.apps.length) {
+                            apps.innerHTML = '<tr><td colspan="6" class="px-4 py-4 text-center text-sm text-gray-500">No finished builds</td></tr>';
+                        }
+                        stats.apps.forEach(a => {
+                            const tr = document.createElement('tr');
+                            tr.className = 'border-t border-gray-200';
+                            const ran = a.succeeded + a.failed > 0;
+                            tr.append(
+                                cell(a.app_name),
+                                cell(a.total, true),
+                                cell(ran ? a.success_rate + '%' : '-', true),
+                                cell(a.avg_duration ? duration(a.avg_duration) : '-', true),
+                                cell(a.p50_duration ? duration(a.p50_duration) : '-', true),
+                                cell(a.p95_duration ? duration(a.p95_duration) : '-', true)
+                            );
+                            apps.appendChild(tr);
+                        });
+                    })
+                    .catch(() => {
+                        document.getElementById('build-stats').innerHTML = '<p class="text-gray-500 text-sm">Build statistics are unavailable.</p>';
+                    });
+            })();
+        </script>`)
+}
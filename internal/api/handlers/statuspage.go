@@ -0,0 +1,500 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+	"schooner/internal/proxy"
+	"schooner/internal/uptime"
+)
+
+const (
+	// statusPageDays is how many days of history the status page shows
+	statusPageDays = 7
+
+	// statusPageCacheTTL limits how often the public page hits the database
+	statusPageCacheTTL = 30 * time.Second
+
+	// statusPageMaxIncidents caps the incident list
+	statusPageMaxIncidents = 10
+)
+
+// StatusPageHandler serves the public status page and its settings
+type StatusPageHandler struct {
+	settingsQueries *queries.SettingsQueries
+	uptimeQueries   *queries.UptimeQueries
+	proxyRouter     *proxy.Router
+
+	mu         sync.RWMutex
+	config     models.StatusPageConfig
+	snapshot   *statusSnapshot
+	snapshotAt time.Time
+}
+
+// statusSnapshot is the data rendered on the public status page
+type statusSnapshot struct {
+	Title       string           `json:"title"`
+	Overall     string           `json:"overall"` // operational, degraded or outage
+	Apps        []statusApp      `json:"apps"`
+	Incidents   []statusIncident `json:"incidents"`
+	GeneratedAt time.Time        `json:"generated_at"`
+}
+
+type statusApp struct {
+	Name          string              `json:"name"`
+	Status        models.UptimeStatus `json:"status"`
+	UptimePercent float64             `json:"uptime_percent"`
+	Days          []uptime.DaySummary `json:"days"`
+}
+
+type statusIncident struct {
+	App      string     `json:"app"`
+	Start    time.Time  `json:"start"`
+	End      *time.Time `json:"end,omitempty"`
+	Duration string     `json:"duration"`
+}
+
+// NewStatusPageHandler creates a new StatusPageHandler
+func NewStatusPageHandler(settingsQueries *queries.SettingsQueries, uptimeQueries *queries.UptimeQueries, proxyRouter *proxy.Router) *StatusPageHandler {
+	h := &StatusPageHandler{
+		settingsQueries: settingsQueries,
+		uptimeQueries:   uptimeQueries,
+		proxyRouter:     proxyRouter,
+	}
+
+	if settingsQueries != nil {
+		if cfg, err := settingsQueries.GetStatusPageConfig(context.Background()); err != nil {
+			slog.Warn("failed to load status page settings", "error", err)
+		} else {
+			h.config = *cfg
+		}
+	}
+
+	return h
+}
+
+func (h *StatusPageHandler) currentConfig() models.StatusPageConfig {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.config
+}
+
+// Middleware serves the status page at its configured path or subdomain,
+// ahead of authentication. Other paths on the status subdomain return 404 so
+// the dashboard is not exposed there.
+func (h *StatusPageHandler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := h.currentConfig()
+		if !cfg.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		path := r.URL.Path
+		onHost := cfg.MatchesHost(r.Host)
+		isRead := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+		switch {
+		case isRead && (path == cfg.GetPath() || (onHost && path == "/")):
+			h.servePage(w, r, cfg)
+		case isRead && (path == cfg.GetPath()+".json" || (onHost && path == "/status.json")):
+			h.serveJSON(w, r, cfg)
+		case onHost && !strings.HasPrefix(path, "/static/"):
+			http.NotFound(w, r)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// loadSnapshot returns a cached snapshot, rebuilding it when stale
+func (h *StatusPageHandler) loadSnapshot(ctx context.Context, cfg models.StatusPageConfig) (*statusSnapshot, error) {
+	h.mu.RLock()
+	if h.snapshot != nil && time.Since(h.snapshotAt) < statusPageCacheTTL {
+		s := h.snapshot
+		h.mu.RUnlock()
+		return s, nil
+	}
+	h.mu.RUnlock()
+
+	s, err := h.buildSnapshot(ctx, cfg, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	h.snapshot = s
+	h.snapshotAt = time.Now()
+	h.mu.Unlock()
+
+	return s, nil
+}
+
+// buildSnapshot computes per-app status and recent incidents from uptime results
+func (h *StatusPageHandler) buildSnapshot(ctx context.Context, cfg models.StatusPageConfig, now time.Time) (*statusSnapshot, error) {
+	checks, err := h.uptimeQueries.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &statusSnapshot{
+		Title:       cfg.GetTitle(),
+		Overall:     "operational",
+		Apps:        []statusApp{},
+		Incidents:   []statusIncident{},
+		GeneratedAt: now,
+	}
+
+	since := now.AddDate(0, 0, -statusPageDays)
+	down := 0
+	for _, check := range checks {
+		if !check.Enabled {
+			continue
+		}
+
+		results, err := h.uptimeQueries.ListResultsSince(ctx, check.ID, since)
+		if err != nil {
+			return nil, err
+		}
+
+		upCount := 0
+		for _, r := range results {
+			if r.Up {
+				upCount++
+			}
+		}
+		stats := models.UptimeStats{Checks: len(results), UpChecks: upCount}
+
+		snapshot.Apps = append(snapshot.Apps, statusApp{
+			Name:          check.AppName,
+			Status:        check.LastStatus,
+			UptimePercent: stats.UptimePercent(),
+			Days:          uptime.DailySummary(results, statusPageDays, now),
+		})
+		if check.LastStatus == models.UptimeDown {
+			down++
+		}
+
+		for _, inc := range uptime.Incidents(results) {
+			snapshot.Incidents = append(snapshot.Incidents, statusIncident{
+				App:      check.AppName,
+				Start:    inc.Start,
+				End:      inc.End,
+				Duration: formatIncidentDuration(inc.Duration(now)),
+			})
+		}
+	}
+
+	sort.Slice(snapshot.Incidents, func(i, j int) bool {
+		return snapshot.Incidents[i].Start.After(snapshot.Incidents[j].Start)
+	})
+	if len(snapshot.Incidents) > statusPageMaxIncidents {
+		snapshot.Incidents = snapshot.Incidents[:statusPageMaxIncidents]
+	}
+
+	switch {
+	case down > 0 && down == len(snapshot.Apps):
+		snapshot.Overall = "outage"
+	case down > 0:
+		snapshot.Overall = "degraded"
+	}
+
+	return snapshot, nil
+}
+
+// formatIncidentDuration renders a duration rounded to the minute
+func formatIncidentDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "under a minute"
+	}
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	if h == 0 {
+		return fmt.Sprintf("%dm", m)
+	}
+	return fmt.Sprintf("%dh %dm", h, m)
+}
+
+func (h *StatusPageHandler) serveJSON(w http.ResponseWriter, r *http.Request, cfg models.StatusPageConfig) {
+	snapshot, err := h.loadSnapshot(r.Context(), cfg)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to build status page", "error", err)
+		http.Error(w, "status unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=30")
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+func (h *StatusPageHandler) servePage(w http.ResponseWriter, r *http.Request, cfg models.StatusPageConfig) {
+	snapshot, err := h.loadSnapshot(r.Context(), cfg)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to build status page", "error", err)
+		http.Error(w, "status unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	overallText := "All systems operational"
+	overallClass := "bg-green-500"
+	switch snapshot.Overall {
+	case "degraded":
+		overallText = "Some systems are experiencing issues"
+		overallClass = "bg-yellow-500"
+	case "outage":
+		overallText = "Major outage"
+		overallClass = "bg-red-500"
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.Header().Set("Cache-Control", "public, max-age=30")
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <meta http-equiv="refresh" content="60">
+    <title>%s</title>
+    <link href="/static/css/tailwind.css" rel="stylesheet">
+</head>
+<body class="bg-gray-50 text-gray-900">
+    <main class="max-w-3xl mx-auto px-4 py-10">
+        <h1 class="text-3xl font-bold mb-6">%s</h1>
+        <div class="%s text-white rounded-lg px-6 py-4 mb-8 font-semibold">%s</div>
+        <div class="bg-white shadow-sm rounded-lg border border-gray-200 divide-y divide-gray-200 mb-10">`,
+		html.EscapeString(snapshot.Title),
+		html.EscapeString(snapshot.Title),
+		overallClass,
+		overallText)
+
+	if len(snapshot.Apps) == 0 {
+		fmt.Fprint(w, `<div class="px-6 py-4 text-gray-500">No services are being monitored.</div>`)
+	}
+
+	for _, app := range snapshot.Apps {
+		statusText, statusClass := "Operational", "text-green-600"
+		switch app.Status {
+		case models.UptimeDown:
+			statusText, statusClass = "Down", "text-red-600"
+		case models.UptimeUnknown:
+			statusText, statusClass = "Unknown", "text-gray-500"
+		}
+
+		fmt.Fprintf(w, `
+            <div class="px-6 py-4">
+                <div class="flex justify-between mb-2">
+                    <span class="font-medium">%s</span>
+                    <span class="%s font-medium">%s</span>
+                </div>
+                <div class="flex space-x-1 mb-1">`,
+			html.EscapeString(app.Name), statusClass, statusText)
+
+		for _, day := range app.Days {
+			barClass := "bg-green-400"
+			switch {
+			case day.Checks == 0:
+				barClass = "bg-gray-200"
+			case day.UptimePercent < 95:
+				barClass = "bg-red-400"
+			case day.UptimePercent < 100:
+				barClass = "bg-yellow-400"
+			}
+			fmt.Fprintf(w, `<div class="flex-1 h-8 rounded-sm %s" title="%s: %.2f%%"></div>`,
+				barClass, day.Date, day.UptimePercent)
+		}
+
+		fmt.Fprintf(w, `
+                </div>
+                <div class="flex justify-between text-xs text-gray-400">
+                    <span>%d days ago</span>
+                    <span>%.2f%% uptime</span>
+                    <span>Today</span>
+                </div>
+            </div>`, statusPageDays, app.UptimePercent)
+	}
+
+	fmt.Fprint(w, `
+        </div>
+        <h2 class="text-xl font-bold mb-4">Recent incidents</h2>
+        <div class="bg-white shadow-sm rounded-lg border border-gray-200 divide-y divide-gray-200">`)
+
+	if len(snapshot.Incidents) == 0 {
+		fmt.Fprintf(w, `<div class="px-6 py-4 text-gray-500">No incidents in the last %d days.</div>`, statusPageDays)
+	}
+
+	for _, inc := range snapshot.Incidents {
+		state := "Resolved"
+		if inc.End == nil {
+			state = `<span class="text-red-600">Ongoing</span>`
+		}
+		fmt.Fprintf(w, `
+            <div class="px-6 py-4 flex justify-between text-sm">
+                <div>
+                    <div class="font-medium">%s unavailable</div>
+                    <div class="text-gray-500">%s &middot; %s</div>
+                </div>
+                <div class="text-gray-500">%s</div>
+            </div>`,
+			html.EscapeString(inc.App),
+			inc.Start.UTC().Format("Jan 2, 15:04 MST"),
+			html.EscapeString(inc.Duration),
+			state)
+	}
+
+	fmt.Fprintf(w, `
+        </div>
+        <p class="text-xs text-gray-400 mt-8 text-center">Updated %s</p>
+    </main>
+</body>
+</html>`, snapshot.GeneratedAt.UTC().Format("Jan 2, 15:04:05 MST"))
+}
+
+// GetConfig handles GET /api/settings/status-page
+func (h *StatusPageHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	cfg := h.currentConfig()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"enabled":   cfg.Enabled,
+		"title":     cfg.Title,
+		"path":      cfg.GetPath(),
+		"subdomain": cfg.Subdomain,
+	})
+}
+
+// SetConfig handles POST /api/settings/status-page
+func (h *StatusPageHandler) SetConfig(w http.ResponseWriter, r *http.Request) {
+	var req models.StatusPageConfig
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Title = strings.TrimSpace(req.Title)
+	req.Path = strings.TrimSpace(req.Path)
+	req.Subdomain = strings.ToLower(strings.TrimSpace(req.Subdomain))
+
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.settingsQueries.SetStatusPageConfig(r.Context(), &req); err != nil {
+		slog.ErrorContext(r.Context(), "failed to save status page settings", "error", err)
+		http.Error(w, "failed to save settings", http.StatusInternalServerError)
+		return
+	}
+
+	h.mu.Lock()
+	previous := h.config
+	h.config = req
+	h.snapshot = nil
+	h.mu.Unlock()
+
+	slog.InfoContext(r.Context(), "status page settings saved", "enabled", req.Enabled, "path", req.GetPath(), "subdomain", req.Subdomain)
+
+	// Route the status subdomain through the active proxy
+	if req.Subdomain != previous.Subdomain && h.proxyRouter != nil && h.proxyRouter.IsConfigured() {
+		go func() {
+			if err := h.proxyRouter.Reload(context.Background()); err != nil {
+				slog.ErrorContext(r.Context(), "failed to reload proxy routes for status page", "error", err)
+			}
+		}()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Status page settings saved",
+	})
+}
+
+func (h *PageHandler) renderStatusPageSettings(w http.ResponseWriter) {
+	fmt.Fprint(w, `
+        <div class="mt-8">
+            <h2 class="text-xl font-bold mb-4">Public Status Page</h2>
+            <div class="bg-white shadow-sm rounded-lg p-6 border border-gray-200">
+                <p class="text-gray-500 mb-4">A read-only page showing up/down status and recent incidents for apps with uptime checks. No login required.</p>
+                <form onsubmit="submitStatusPageConfig(event)">
+                    <label class="flex items-center mb-4">
+                        <input type="checkbox" name="enabled" id="status-page-enabled-input" class="mr-2">
+                        <span>Enable public status page</span>
+                    </label>
+                    <div class="grid grid-cols-1 md:grid-cols-3 gap-4 mb-4">
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Title</label>
+                            <input type="text" name="title" id="status-page-title-input" placeholder="Service Status"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Path</label>
+                            <input type="text" name="path" id="status-page-path-input" placeholder="/status"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Subdomain (optional)</label>
+                            <input type="text" name="subdomain" id="status-page-subdomain-input" placeholder="status"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                            <p class="text-xs text-gray-400 mt-1">Routed through the Cloudflare tunnel when configured</p>
+                        </div>
+                    </div>
+                    <div class="flex items-center space-x-4">
+                        <button type="submit" class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white">Save</button>
+                        <a href="/status" id="status-page-link" target="_blank" class="text-purple-600 hover:text-purple-700 hidden">View status page</a>
+                    </div>
+                </form>
+            </div>
+        </div>
+        <script>
+            fetch('/api/settings/status-page')
+                .then(response => response.json())
+                .then(data => {
+                    document.getElementById('status-page-enabled-input').checked = data.enabled;
+                    document.getElementById('status-page-title-input').value = data.title || '';
+                    document.getElementById('status-page-path-input').value = data.path || '';
+                    document.getElementById('status-page-subdomain-input').value = data.subdomain || '';
+                    const link = document.getElementById('status-page-link');
+                    link.href = data.path;
+                    link.classList.toggle('hidden', !data.enabled);
+                });
+
+            function submitStatusPageConfig(event) {
+                event.preventDefault();
+                const form = event.target;
+                const data = {
+                    enabled: form.querySelector('input[name="enabled"]').checked,
+                    title: form.querySelector('input[name="title"]').value,
+                    path: form.querySelector('input[name="path"]').value,
+                    subdomain: form.querySelector('input[name="subdomain"]').value
+                };
+
+                fetch('/api/settings/status-page', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify(data)
+                })
+                .then(response => {
+                    if (response.ok) {
+                        showToast('Status page settings saved', 'success');
+                        const link = document.getElementById('status-page-link');
+                        link.href = data.path || '/status';
+                        link.classList.toggle('hidden', !data.enabled);
+                    } else {
+                        response.text().then(text => alert('Failed to save: ' + text));
+                    }
+                });
+            }
+        </script>`)
+}
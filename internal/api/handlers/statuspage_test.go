@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"schooner/internal/models"
+)
+
+func TestStatusPageHandler_Middleware_Passthrough(t *testing.T) {
+	h := NewStatusPageHandler(nil, nil, nil)
+	h.config = models.StatusPageConfig{Enabled: true, Path: "/status", Subdomain: "status"}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := h.Middleware(next)
+
+	tests := []struct {
+		name     string
+		host     string
+		path     string
+		expected int
+	}{
+		{name: "dashboard host passes through", host: "schooner.example.com", path: "/settings", expected: http.StatusTeapot},
+		{name: "static on status host passes through", host: "status.example.com", path: "/static/img/logo.svg", expected: http.StatusTeapot},
+		{name: "dashboard routes hidden on status host", host: "status.example.com", path: "/settings", expected: http.StatusNotFound},
+		{name: "api hidden on status host", host: "status.example.com:8080", path: "/api/apps", expected: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Host = tt.host
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expected {
+				t.Errorf("status = %d, want %d", rr.Code, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStatusPageHandler_Middleware_Disabled(t *testing.T) {
+	h := NewStatusPageHandler(nil, nil, nil)
+	h.config = models.StatusPageConfig{Enabled: false, Subdomain: "status"}
+
+	called := false
+	handler := h.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	req.Host = "status.example.com"
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !called {
+		t.Error("expected request to pass through when the status page is disabled")
+	}
+}
+
+func TestStatusPageHandler_SetConfig_InvalidPath(t *testing.T) {
+	h := NewStatusPageHandler(nil, nil, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/settings/status-page", strings.NewReader(`{"enabled":true,"path":"/api/status"}`))
+	rr := httptest.NewRecorder()
+
+	h.SetConfig(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+}
+
+func TestFormatIncidentDuration(t *testing.T) {
+	tests := []struct {
+		d        time.Duration
+		expected string
+	}{
+		{d: 20 * time.Second, expected: "under a minute"},
+		{d: 5 * time.Minute, expected: "5m"},
+		{d: 90 * time.Minute, expected: "1h 30m"},
+	}
+
+	for _, tt := range tests {
+		if got := formatIncidentDuration(tt.d); got != tt.expected {
+			t.Errorf("formatIncidentDuration(%v) = %q, want %q", tt.d, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,470 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/backup"
+	"schooner/internal/crypto"
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+// maxSystemBackupUpload caps the size of an uploaded system backup
+const maxSystemBackupUpload = 1 << 30
+
+// SystemBackupHandler handles backups of Schooner's own database and settings
+type SystemBackupHandler struct {
+	backupQueries   *queries.BackupQueries
+	settingsQueries *queries.SettingsQueries
+	systemManager   *backup.SystemManager
+}
+
+// NewSystemBackupHandler creates a new SystemBackupHandler
+func NewSystemBackupHandler(backupQueries *queries.BackupQueries, settingsQueries *queries.SettingsQueries, systemManager *backup.SystemManager) *SystemBackupHandler {
+	return &SystemBackupHandler{
+		backupQueries:   backupQueries,
+		settingsQueries: settingsQueries,
+		systemManager:   systemManager,
+	}
+}
+
+// SystemBackupConfig is the request and response body for system backup
+// settings
+type SystemBackupConfig struct {
+	Schedule      string              `json:"schedule"`
+	KeepLast      int                 `json:"keep_last"`
+	Target        models.BackupTarget `json:"target"`
+	Passphrase    string              `json:"passphrase,omitempty"` // Blank keeps the stored passphrase
+	PassphraseSet bool                `json:"passphrase_set"`
+}
+
+// GetConfig handles GET /api/settings/system-backup
+func (h *SystemBackupHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	cfg, err := backup.LoadSystemConfig(r.Context(), h.settingsQueries)
+	if err != nil {
+		http.Error(w, "failed to load system backup settings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SystemBackupConfig{
+		Schedule:      cfg.Schedule,
+		KeepLast:      cfg.KeepLast,
+		Target:        cfg.Target,
+		PassphraseSet: cfg.Passphrase != "",
+	})
+}
+
+// SetConfig handles POST /api/settings/system-backup
+func (h *SystemBackupHandler) SetConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req SystemBackupConfig
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	existing, err := backup.LoadSystemConfig(ctx, h.settingsQueries)
+	if err != nil {
+		http.Error(w, "failed to load system backup settings", http.StatusInternalServerError)
+		return
+	}
+
+	cfg := backup.SystemConfig{
+		Schedule:   strings.TrimSpace(req.Schedule),
+		KeepLast:   req.KeepLast,
+		Target:     req.Target,
+		Passphrase: req.Passphrase,
+	}
+	if cfg.Passphrase == "" {
+		cfg.Passphrase = existing.Passphrase
+	}
+	if err := cfg.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if cfg.Schedule != "" && cfg.Passphrase == "" {
+		http.Error(w, "a passphrase is required to schedule backups", http.StatusBadRequest)
+		return
+	}
+
+	if err := backup.SaveSystemConfig(ctx, h.settingsQueries, cfg); err != nil {
+		slog.ErrorContext(ctx, "failed to save system backup settings", "error", err)
+		http.Error(w, "failed to save system backup settings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "System backup settings saved",
+	})
+}
+
+// List handles GET /api/backups/system
+func (h *SystemBackupHandler) List(w http.ResponseWriter, r *http.Request) {
+	backups, err := h.backupQueries.ListSystemBackups(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list system backups", "error", err)
+		http.Error(w, "failed to list system backups", http.StatusInternalServerError)
+		return
+	}
+	if backups == nil {
+		backups = []*models.SystemBackup{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(backups)
+}
+
+// Run handles POST /api/backups/system. The backup runs in the background.
+func (h *SystemBackupHandler) Run(w http.ResponseWriter, r *http.Request) {
+	cfg, err := backup.LoadSystemConfig(r.Context(), h.settingsQueries)
+	if err != nil {
+		http.Error(w, "failed to load system backup settings", http.StatusInternalServerError)
+		return
+	}
+	if cfg.Passphrase == "" {
+		http.Error(w, "set a passphrase before backing up Schooner", http.StatusBadRequest)
+		return
+	}
+
+	go func() {
+		if _, err := h.systemManager.Run(context.Background()); err != nil {
+			slog.ErrorContext(r.Context(), "system backup failed", "error", err)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Backup started",
+	})
+}
+
+// Download handles GET /api/backups/system/{backupID}/download. The file
+// stays encrypted with the backup passphrase.
+func (h *SystemBackupHandler) Download(w http.ResponseWriter, r *http.Request) {
+	b, ok := h.getBackup(w, r)
+	if !ok {
+		return
+	}
+	if b.Status != models.BackupSuccess {
+		http.Error(w, "backup did not complete", http.StatusBadRequest)
+		return
+	}
+
+	archive, err := h.systemManager.Open(r.Context(), b)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to open system backup", "key", b.Key, "error", err)
+		http.Error(w, "failed to open backup", http.StatusInternalServerError)
+		return
+	}
+	defer archive.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="schooner-%s"`, path.Base(b.Key)))
+	if b.SizeBytes > 0 {
+		w.Header().Set("Content-Length", fmt.Sprint(b.SizeBytes))
+	}
+	io.Copy(w, archive)
+}
+
+// RestoreRequest is the request body for restoring a stored system backup
+type RestoreRequest struct {
+	Passphrase string `json:"passphrase"`
+}
+
+// Restore handles POST /api/backups/system/{backupID}/restore
+func (h *SystemBackupHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	b, ok := h.getBackup(w, r)
+	if !ok {
+		return
+	}
+	if b.Status != models.BackupSuccess {
+		http.Error(w, "backup did not complete", http.StatusBadRequest)
+		return
+	}
+
+	var req RestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	archive, err := h.systemManager.Open(r.Context(), b)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to open system backup", "key", b.Key, "error", err)
+		http.Error(w, "failed to open backup", http.StatusInternalServerError)
+		return
+	}
+	defer archive.Close()
+
+	h.stage(w, r, archive, req.Passphrase)
+}
+
+// Upload handles POST /api/backups/system/restore, restoring a downloaded
+// backup sent as the multipart "file" field with its "passphrase"
+func (h *SystemBackupHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxSystemBackupUpload)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "backup file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	h.stage(w, r, file, r.FormValue("passphrase"))
+}
+
+// stage decrypts a backup and stages it to replace the database on restart
+func (h *SystemBackupHandler) stage(w http.ResponseWriter, r *http.Request, archive io.Reader, passphrase string) {
+	manifest, err := h.systemManager.Restore(r.Context(), archive, passphrase)
+	if err != nil {
+		if errors.Is(err, crypto.ErrWrongPassphrase) || errors.Is(err, crypto.ErrInvalidData) {
+			http.Error(w, "wrong passphrase or not a Schooner backup", http.StatusBadRequest)
+			return
+		}
+		slog.ErrorContext(r.Context(), "failed to stage system backup", "error", err)
+		http.Error(w, "failed to restore backup: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Backup from %s staged. Restart Schooner to finish restoring it.", manifest.CreatedAt.Format("2006-01-02 15:04")),
+	})
+}
+
+// Delete handles DELETE /api/backups/system/{backupID}
+func (h *SystemBackupHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	b, ok := h.getBackup(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.systemManager.Delete(r.Context(), b); err != nil {
+		slog.ErrorContext(r.Context(), "failed to delete system backup", "backup", b.ID, "error", err)
+		http.Error(w, "failed to delete backup: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Backup deleted",
+	})
+}
+
+// getBackup loads the system backup named in the URL, writing an error
+// response if it can't
+func (h *SystemBackupHandler) getBackup(w http.ResponseWriter, r *http.Request) (*models.SystemBackup, bool) {
+	b, err := h.backupQueries.GetSystemBackup(r.Context(), chi.URLParam(r, "backupID"))
+	if err != nil {
+		http.Error(w, "failed to get backup", http.StatusInternalServerError)
+		return nil, false
+	}
+	if b == nil {
+		http.Error(w, "backup not found", http.StatusNotFound)
+		return nil, false
+	}
+	return b, true
+}
+
+func (h *PageHandler) renderSystemBackups(w http.ResponseWriter) {
+	fmt.Fprint(w, `
+        <h2 class="text-xl font-bold mt-8 mb-4">Schooner</h2>
+        <div class="bg-white shadow-sm rounded-lg p-6 border border-gray-200">
+            <p class="text-sm text-gray-500 mb-4">Dumps of Schooner's database (apps, webhooks, settings) and its encryption key, encrypted with your passphrase.
+                Keep the passphrase somewhere safe: without it a backup can't be restored.</p>
+            <form onsubmit="saveSystemBackupConfig(event)" class="mb-6">
+                <div class="grid grid-cols-1 md:grid-cols-4 gap-4 mb-4">
+                    <div>
+                        <label class="block text-sm text-gray-500 mb-1">Schedule (cron)</label>
+                        <input type="text" id="system-schedule" placeholder="0 4 * * *"
+                            class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900 font-mono">
+                    </div>
+                    <div>
+                        <label class="block text-sm text-gray-500 mb-1">Keep last</label>
+                        <input type="number" id="system-keep" min="0"
+                            class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                    </div>
+                    <div>
+                        <label class="block text-sm text-gray-500 mb-1">Target</label>
+                        <select id="system-target"
+                            class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                            <option value="local">Local</option>
+                            <option value="s3">S3</option>
+                        </select>
+                    </div>
+                    <div>
+                        <label class="block text-sm text-gray-500 mb-1">Passphrase</label>
+                        <input type="password" id="system-passphrase" placeholder="Passphrase"
+                            class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                    </div>
+                </div>
+                <button type="submit" class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white">Save</button>
+                <button type="button" onclick="runSystemBackup()" class="px-4 py-2 bg-gray-100 hover:bg-gray-200 rounded text-gray-900 ml-2">Back up now</button>
+            </form>
+            <table class="w-full text-sm mb-6">
+                <thead>
+                    <tr class="text-left text-gray-500 border-b border-gray-200">
+                        <th class="py-2">Taken</th>
+                        <th class="py-2">Size</th>
+                        <th class="py-2">Target</th>
+                        <th class="py-2">Status</th>
+                        <th class="py-2"></th>
+                    </tr>
+                </thead>
+                <tbody id="system-backups">
+                    <tr><td colspan="5" class="py-2 text-gray-400">Loading...</td></tr>
+                </tbody>
+            </table>
+            <form onsubmit="uploadSystemBackup(event)">
+                <label class="block text-sm text-gray-500 mb-1">Restore from a downloaded backup</label>
+                <div class="flex flex-wrap gap-2">
+                    <input type="file" id="system-restore-file" required class="text-sm">
+                    <input type="password" id="system-restore-passphrase" placeholder="Passphrase" required
+                        class="bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                    <button type="submit" class="px-4 py-2 bg-red-600 hover:bg-red-700 rounded text-white">Restore</button>
+                </div>
+            </form>
+        </div>
+        <script>
+            function loadSystemBackupConfig() {
+                fetch('/api/settings/system-backup')
+                    .then(response => response.json())
+                    .then(config => {
+                        document.getElementById('system-schedule').value = config.schedule || '';
+                        document.getElementById('system-keep').value = config.keep_last;
+                        document.getElementById('system-target').value = config.target;
+                        document.getElementById('system-passphrase').placeholder = config.passphrase_set ? 'Passphrase (unchanged)' : 'Passphrase';
+                    });
+            }
+
+            function loadSystemBackups() {
+                fetch('/api/backups/system')
+                    .then(response => response.json())
+                    .then(backups => {
+                        const body = document.getElementById('system-backups');
+                        if (backups.length === 0) {
+                            body.innerHTML = '<tr><td colspan="5" class="py-2 text-gray-400">No backups yet</td></tr>';
+                            return;
+                        }
+                        body.innerHTML = backups.map(b => {
+                            const colors = { success: 'text-green-600', failed: 'text-red-600', running: 'text-yellow-600' };
+                            const actions = b.status === 'success'
+                                ? '<a href="/api/backups/system/' + b.id + '/download" class="text-blue-600 hover:text-blue-700 mr-3">Download</a>' +
+                                  '<button onclick="restoreSystemBackup(\'' + b.id + '\')" class="text-blue-600 hover:text-blue-700 mr-3">Restore</button>'
+                                : '';
+                            return '<tr class="border-b border-gray-100">' +
+                                '<td class="py-2 text-gray-500">' + escapeBackupText(new Date(b.created_at).toLocaleString()) + '</td>' +
+                                '<td class="py-2">' + (b.status === 'success' ? formatBytes(b.size_bytes) : '') + '</td>' +
+                                '<td class="py-2">' + escapeBackupText(b.target) + '</td>' +
+                                '<td class="py-2 ' + (colors[b.status] || '') + '" title="' + escapeBackupText(b.error || '') + '">' + escapeBackupText(b.status) + '</td>' +
+                                '<td class="py-2 text-right whitespace-nowrap">' + actions +
+                                    '<button onclick="deleteSystemBackup(\'' + b.id + '\')" class="text-red-600 hover:text-red-700">Delete</button>' +
+                                '</td></tr>';
+                        }).join('');
+                    });
+            }
+
+            function saveSystemBackupConfig(event) {
+                event.preventDefault();
+                const data = {
+                    schedule: document.getElementById('system-schedule').value.trim(),
+                    keep_last: parseInt(document.getElementById('system-keep').value, 10) || 0,
+                    target: document.getElementById('system-target').value,
+                    passphrase: document.getElementById('system-passphrase').value
+                };
+                fetch('/api/settings/system-backup', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify(data)
+                })
+                .then(response => {
+                    if (response.ok) {
+                        showToast('System backup settings saved', 'success');
+                        document.getElementById('system-passphrase').value = '';
+                        loadSystemBackupConfig();
+                    } else {
+                        response.text().then(text => alert('Failed to save: ' + text));
+                    }
+                });
+            }
+
+            function runSystemBackup() {
+                fetch('/api/backups/system', { method: 'POST' })
+                    .then(response => {
+                        if (response.ok) {
+                            showToast('Backup started', 'success');
+                            setTimeout(loadSystemBackups, 2000);
+                        } else {
+                            response.text().then(text => alert('Failed to start backup: ' + text));
+                        }
+                    });
+            }
+
+            function stagedRestore(response) {
+                if (response.ok) {
+                    response.json().then(result => alert(result.message));
+                } else {
+                    response.text().then(text => alert('Failed to restore: ' + text));
+                }
+            }
+
+            function restoreSystemBackup(id) {
+                const passphrase = prompt('Passphrase for this backup. Schooner\'s current database is replaced on restart.');
+                if (!passphrase) {
+                    return;
+                }
+                fetch('/api/backups/system/' + id + '/restore', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ passphrase: passphrase })
+                }).then(stagedRestore);
+            }
+
+            function uploadSystemBackup(event) {
+                event.preventDefault();
+                if (!confirm('Restore this backup? Schooner\'s current database is replaced on restart.')) {
+                    return;
+                }
+                const form = new FormData();
+                form.append('file', document.getElementById('system-restore-file').files[0]);
+                form.append('passphrase', document.getElementById('system-restore-passphrase').value);
+                fetch('/api/backups/system/restore', { method: 'POST', body: form }).then(stagedRestore);
+            }
+
+            function deleteSystemBackup(id) {
+                if (!confirm('Delete this backup? This cannot be undone.')) {
+                    return;
+                }
+                fetch('/api/backups/system/' + id, { method: 'DELETE' })
+                    .then(response => {
+                        if (response.ok) {
+                            showToast('Backup deleted', 'success');
+                            loadSystemBackups();
+                        } else {
+                            response.text().then(text => alert('Failed to delete backup: ' + text));
+                        }
+                    });
+            }
+
+            loadSystemBackupConfig();
+            loadSystemBackups();
+        </script>`)
+}
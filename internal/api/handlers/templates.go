@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"schooner/internal/build"
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+	"schooner/internal/proxy"
+	"schooner/internal/templates"
+)
+
+// TemplateHandler handles the catalog of one-click app templates
+type TemplateHandler struct {
+	catalog      *templates.Catalog
+	appQueries   *queries.AppQueries
+	proxyRouter  *proxy.Router
+	orchestrator *build.Orchestrator
+	ports        *PortAllocator
+}
+
+// NewTemplateHandler creates a new TemplateHandler
+func NewTemplateHandler(catalog *templates.Catalog, appQueries *queries.AppQueries, proxyRouter *proxy.Router, orchestrator *build.Orchestrator) *TemplateHandler {
+	return &TemplateHandler{
+		catalog:      catalog,
+		appQueries:   appQueries,
+		proxyRouter:  proxyRouter,
+		orchestrator: orchestrator,
+	}
+}
+
+// SetPorts lets templates deployed without a port get a free one when
+// their default port is taken
+func (h *TemplateHandler) SetPorts(ports *PortAllocator) {
+	h.ports = ports
+}
+
+// TemplateDeployRequest is the request body for deploying a template
+type TemplateDeployRequest struct {
+	Name      string            `json:"name"`
+	Port      int               `json:"port"` // Blank uses the template's port, or a free one if another app has it
+	Subdomain string            `json:"subdomain"`
+	Values    map[string]string `json:"values"`
+}
+
+// List handles GET /api/templates
+func (h *TemplateHandler) List(w http.ResponseWriter, r *http.Request) {
+	list := []*templates.Template{}
+	if h.catalog != nil {
+		list = h.catalog.List()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// Deploy handles POST /api/templates/{templateID}/deploy
+func (h *TemplateHandler) Deploy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if h.catalog == nil {
+		http.Error(w, "template catalog is not available", http.StatusServiceUnavailable)
+		return
+	}
+	if h.orchestrator == nil {
+		http.Error(w, "build orchestrator not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	tmpl := h.catalog.Get(chi.URLParam(r, "templateID"))
+	if tmpl == nil {
+		http.Error(w, "template not found", http.StatusNotFound)
+		return
+	}
+
+	var req TemplateDeployRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		req.Name = tmpl.ID
+	}
+	if req.Port < 0 || req.Port > 65535 {
+		http.Error(w, "port must be between 1 and 65535", http.StatusBadRequest)
+		return
+	}
+
+	values, err := tmpl.Values(req.Values)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	subdomain := strings.TrimSpace(req.Subdomain)
+	app := &models.App{
+		ID:             uuid.New().String(),
+		Name:           req.Name,
+		Description:    sql.NullString{String: tmpl.Description, Valid: tmpl.Description != ""},
+		BuildStrategy:  models.BuildStrategyCompose,
+		DockerfilePath: "Dockerfile",
+		ComposeFile:    "docker-compose.yml",
+		BuildContext:   ".",
+		EnvVars:        values,
+		Enabled:        true,
+		Subdomain:      sql.NullString{String: subdomain, Valid: subdomain != ""},
+		PublicPort:     sql.NullInt64{Int64: int64(req.Port), Valid: req.Port > 0},
+		Template:       sql.NullString{String: tmpl.ID, Valid: true},
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	if req.Port == 0 {
+		app.PublicPort = sql.NullInt64{Int64: int64(tmpl.Port), Valid: true}
+		if h.ports != nil {
+			if err := h.ports.Assign(ctx, app, tmpl.Port); err != nil {
+				slog.ErrorContext(ctx, "failed to assign host port", "template", tmpl.ID, "error", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	if err := app.SaveEnvVars(); err != nil {
+		slog.ErrorContext(ctx, "failed to save env vars", "error", err)
+		http.Error(w, "failed to save env vars", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.appQueries.Create(ctx, app); err != nil {
+		if portConflict(w, err) {
+			return
+		}
+		slog.ErrorContext(ctx, "failed to create app from template", "template", tmpl.ID, "error", err)
+		http.Error(w, "failed to create app: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if h.proxyRouter != nil && h.proxyRouter.IsConfigured() && app.GetSubdomain() != "" {
+		if err := h.proxyRouter.Reload(ctx); err != nil {
+			slog.WarnContext(ctx, "failed to reload proxy routes", "app", app.Name, "error", err)
+		}
+	}
+
+	b, err := h.orchestrator.TriggerManualBuild(ctx, app.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to trigger template deploy", "app", app.Name, "error", err)
+		http.Error(w, "app created but deploy failed to start: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "app deployed from template", "id", app.ID, "name", app.Name, "template", tmpl.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"app":   app,
+		"build": b,
+	})
+}
+
+// Templates renders the catalog of one-click apps
+func (h *PageHandler) Templates(w http.ResponseWriter, r *http.Request) {
+	h.writeHeader(w, r, "Templates")
+
+	fmt.Fprint(w, `
+        <h1 class="text-2xl font-bold mb-2">Templates</h1>
+        <p class="text-gray-500 mb-6">Deploy popular self-hosted apps in one click, no repository needed.</p>
+
+        <div id="template-grid" class="grid grid-cols-1 md:grid-cols-2 lg:grid-cols-3 gap-4 mb-8">
+            <p class="text-gray-400">Loading...</p>
+        </div>
+
+        <div id="template-deploy" class="hidden bg-white shadow-sm rounded-lg p-6 border border-gray-200">
+            <h2 id="deploy-title" class="text-xl font-bold mb-4"></h2>
+            <form onsubmit="deployTemplate(event)">
+                <div class="grid grid-cols-1 md:grid-cols-3 gap-4 mb-4">
+                    <div>
+                        <label class="block text-sm text-gray-500 mb-1">App name</label>
+                        <input type="text" id="deploy-name" required
+                            class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                    </div>
+                    <div>
+                        <label class="block text-sm text-gray-500 mb-1">Host port (optional)</label>
+                        <input type="number" id="deploy-port" min="1" max="65535"
+                            class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        <p class="text-xs text-gray-400 mt-1">Blank uses the template's port, or a free one if another app has it</p>
+                    </div>
+                    <div>
+                        <label class="block text-sm text-gray-500 mb-1">Subdomain (optional)</label>
+                        <input type="text" id="deploy-subdomain"
+                            class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                    </div>
+                </div>
+                <div id="deploy-params" class="grid grid-cols-1 md:grid-cols-2 gap-4 mb-4"></div>
+                <button type="submit" class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white">Deploy</button>
+                <button type="button" onclick="closeDeploy()" class="px-4 py-2 text-gray-600 hover:text-gray-900">Cancel</button>
+            </form>
+        </div>
+
+        <script>
+            let templates = [];
+            let selectedTemplate = null;
+
+            function escapeTemplateText(s) {
+                const div = document.createElement('div');
+                div.textContent = s == null ? '' : String(s);
+                return div.innerHTML;
+            }
+
+            function loadTemplates() {
+                fetch('/api/templates')
+                    .then(response => response.json())
+                    .then(list => {
+                        templates = list;
+                        const grid = document.getElementById('template-grid');
+                        if (list.length === 0) {
+                            grid.innerHTML = '<p class="text-gray-400">No templates available</p>';
+                            return;
+                        }
+                        grid.innerHTML = list.map(t =>
+                            '<div class="bg-white shadow-sm rounded-lg p-5 border border-gray-200 flex flex-col">' +
+                                '<div class="flex items-center justify-between mb-2">' +
+                                    '<h3 class="font-semibold">' + escapeTemplateText(t.name) + '</h3>' +
+                                    '<span class="text-xs text-gray-500 bg-gray-100 rounded px-2 py-0.5">' + escapeTemplateText(t.category) + '</span>' +
+                                '</div>' +
+                                '<p class="text-sm text-gray-600 mb-4 flex-1">' + escapeTemplateText(t.description) + '</p>' +
+                                '<div class="flex items-center justify-between">' +
+                                    (t.website ? '<a href="' + escapeTemplateText(t.website) + '" target="_blank" class="text-sm text-gray-500 hover:text-gray-700">Website</a>' : '<span></span>') +
+                                    '<button onclick="openDeploy(\'' + t.id + '\')" class="px-3 py-1 bg-blue-600 hover:bg-blue-700 rounded text-white text-sm">Deploy</button>' +
+                                '</div>' +
+                            '</div>').join('');
+                    });
+            }
+
+            function openDeploy(id) {
+                selectedTemplate = templates.find(t => t.id === id);
+                if (!selectedTemplate) return;
+
+                document.getElementById('deploy-title').textContent = 'Deploy ' + selectedTemplate.name;
+                document.getElementById('deploy-name').value = selectedTemplate.id;
+                document.getElementById('deploy-port').value = '';
+                document.getElementById('deploy-port').placeholder = selectedTemplate.port;
+                document.getElementById('deploy-subdomain').value = selectedTemplate.id;
+                document.getElementById('deploy-params').innerHTML = (selectedTemplate.params || []).map(p => {
+                    let hint = p.description || '';
+                    if (p.secret && !hint) hint = 'Generated if left blank';
+                    return '<div>' +
+                        '<label class="block text-sm text-gray-500 mb-1">' + escapeTemplateText(p.label || p.name) + (p.required ? ' *' : '') + '</label>' +
+                        '<input type="' + (p.secret ? 'password' : 'text') + '" data-param="' + escapeTemplateText(p.name) + '"' +
+                            ' value="' + escapeTemplateText(p.default) + '"' + (p.required ? ' required' : '') +
+                            ' class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">' +
+                        (hint ? '<p class="text-xs text-gray-400 mt-1">' + escapeTemplateText(hint) + '</p>' : '') +
+                    '</div>';
+                }).join('');
+
+                const panel = document.getElementById('template-deploy');
+                panel.classList.remove('hidden');
+                panel.scrollIntoView({ behavior: 'smooth' });
+            }
+
+            function closeDeploy() {
+                selectedTemplate = null;
+                document.getElementById('template-deploy').classList.add('hidden');
+            }
+
+            function deployTemplate(e) {
+                e.preventDefault();
+                if (!selectedTemplate) return;
+
+                const values = {};
+                document.querySelectorAll('#deploy-params [data-param]').forEach(input => {
+                    values[input.dataset.param] = input.value;
+                });
+
+                fetch('/api/templates/' + selectedTemplate.id + '/deploy', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({
+                        name: document.getElementById('deploy-name').value,
+                        port: parseInt(document.getElementById('deploy-port').value, 10) || 0,
+                        subdomain: document.getElementById('deploy-subdomain').value,
+                        values: values
+                    })
+                })
+                .then(response => {
+                    if (!response.ok) return response.text().then(text => { throw new Error(text); });
+                    return response.json();
+                })
+                .then(data => {
+                    window.location.href = '/builds/' + data.build.id;
+                })
+                .catch(err => alert('Deploy failed: ' + err.message));
+            }
+
+            loadTemplates();
+        </script>
+`)
+
+	h.writeFooter(w)
+}
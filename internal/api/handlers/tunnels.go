@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"schooner/internal/cloudflare"
+	"schooner/internal/database/queries"
+)
+
+// TunnelsHandler manages the additional named Cloudflare tunnels apps can
+// route through alongside the default tunnel
+type TunnelsHandler struct {
+	settingsQueries *queries.SettingsQueries
+	tunnelManager   *cloudflare.Manager
+}
+
+// NewTunnelsHandler creates a new TunnelsHandler
+func NewTunnelsHandler(settingsQueries *queries.SettingsQueries, tunnelManager *cloudflare.Manager) *TunnelsHandler {
+	return &TunnelsHandler{
+		settingsQueries: settingsQueries,
+		tunnelManager:   tunnelManager,
+	}
+}
+
+// TunnelRequest is the request body for adding or updating a named tunnel
+type TunnelRequest struct {
+	Name        string `json:"name"`
+	TunnelToken string `json:"tunnel_token"` // Blank keeps the current token
+	Domain      string `json:"domain"`
+	APIToken    string `json:"api_token"` // Blank keeps the current token
+}
+
+// tunnelResponse is a named tunnel as returned by the API, without secrets
+type tunnelResponse struct {
+	Name        string `json:"name"`
+	Domain      string `json:"domain"`
+	HasAPIToken bool   `json:"has_api_token"`
+	State       string `json:"state"`
+}
+
+// List handles GET /api/settings/tunnels
+func (h *TunnelsHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if h.tunnelManager == nil {
+		http.Error(w, "tunnel manager not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	tunnels, err := h.tunnelManager.NamedTunnels(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load tunnels", "error", err)
+		http.Error(w, "failed to load tunnels: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]tunnelResponse, len(tunnels))
+	for i, t := range tunnels {
+		response[i] = tunnelResponse{Name: t.Name, Domain: t.Domain, HasAPIToken: t.APIToken != "", State: "stopped"}
+		if status, err := h.tunnelManager.TunnelStatus(ctx, t.Name); err == nil && status != nil {
+			response[i].State = status.State
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Save handles POST /api/settings/tunnels
+func (h *TunnelsHandler) Save(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if h.tunnelManager == nil {
+		http.Error(w, "tunnel manager not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req TunnelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tunnels, err := h.tunnelManager.NamedTunnels(ctx)
+	if err != nil {
+		http.Error(w, "failed to load tunnels: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tunnel := cloudflare.Tunnel{
+		Name:     strings.TrimSpace(req.Name),
+		Token:    strings.TrimSpace(req.TunnelToken),
+		Domain:   strings.TrimSpace(req.Domain),
+		APIToken: strings.TrimSpace(req.APIToken),
+	}
+	tunnels = upsertTunnel(tunnels, tunnel)
+
+	if err := h.saveTunnels(ctx, tunnels); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Recreate the tunnel's container so it picks up the new token and routes
+	_ = h.tunnelManager.StopTunnel(ctx, tunnel.Name)
+	if err := h.tunnelManager.Start(ctx); err != nil {
+		slog.ErrorContext(ctx, "failed to start tunnels", "error", err)
+		http.Error(w, "tunnel saved but failed to start: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "cloudflare tunnel saved", "name", tunnel.Name, "domain", tunnel.Domain)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Tunnel %s saved", tunnel.Name),
+	})
+}
+
+// Delete handles DELETE /api/settings/tunnels/{name}
+func (h *TunnelsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := chi.URLParam(r, "name")
+
+	if h.tunnelManager == nil {
+		http.Error(w, "tunnel manager not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	tunnels, err := h.tunnelManager.NamedTunnels(ctx)
+	if err != nil {
+		http.Error(w, "failed to load tunnels: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	remaining := make([]cloudflare.Tunnel, 0, len(tunnels))
+	for _, t := range tunnels {
+		if t.Name != name {
+			remaining = append(remaining, t)
+		}
+	}
+	if len(remaining) == len(tunnels) {
+		http.Error(w, "tunnel not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.saveTunnels(ctx, remaining); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.tunnelManager.StopTunnel(ctx, name); err != nil {
+		slog.WarnContext(ctx, "failed to stop removed tunnel", "name", name, "error", err)
+	}
+
+	slog.InfoContext(ctx, "cloudflare tunnel removed", "name", name)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Tunnel %s removed", name),
+	})
+}
+
+// upsertTunnel replaces the tunnel with the same name, keeping its tokens
+// where the update leaves them blank, or appends it
+func upsertTunnel(tunnels []cloudflare.Tunnel, tunnel cloudflare.Tunnel) []cloudflare.Tunnel {
+	for i, t := range tunnels {
+		if t.Name != tunnel.Name {
+			continue
+		}
+		if tunnel.Token == "" {
+			tunnel.Token = t.Token
+		}
+		if tunnel.APIToken == "" {
+			tunnel.APIToken = t.APIToken
+		}
+		tunnels[i] = tunnel
+		return tunnels
+	}
+	return append(tunnels, tunnel)
+}
+
+// saveTunnels validates and stores the named tunnels
+func (h *TunnelsHandler) saveTunnels(ctx context.Context, tunnels []cloudflare.Tunnel) error {
+	if err := cloudflare.ValidateTunnels(tunnels); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(tunnels)
+	if err != nil {
+		return fmt.Errorf("failed to encode tunnels: %w", err)
+	}
+	if err := h.settingsQueries.Set(ctx, cloudflare.TunnelsSettingKey, string(data)); err != nil {
+		slog.Error("failed to save tunnels", "error", err)
+		return fmt.Errorf("failed to save tunnels")
+	}
+	return nil
+}
+
+func (h *PageHandler) renderNamedTunnelSettings(w http.ResponseWriter) {
+	fmt.Fprint(w, `
+        <div class="mt-8">
+            <h2 class="text-xl font-bold mb-4">Additional Tunnels</h2>
+            <div class="bg-white shadow-sm rounded-lg p-6 border border-gray-200">
+                <p class="text-gray-500 mb-4">Run extra Cloudflare tunnels for other domains, e.g. an internal domain alongside a public one. Set an app's Tunnel field to a name below to route it through that tunnel.</p>
+                <table class="w-full text-sm mb-6">
+                    <thead>
+                        <tr class="text-left text-gray-500 border-b border-gray-200">
+                            <th class="py-2">Name</th>
+                            <th class="py-2">Domain</th>
+                            <th class="py-2">DNS</th>
+                            <th class="py-2">Status</th>
+                            <th class="py-2"></th>
+                        </tr>
+                    </thead>
+                    <tbody id="named-tunnels-body">
+                        <tr><td colspan="5" class="py-2 text-gray-400">Loading...</td></tr>
+                    </tbody>
+                </table>
+                <form onsubmit="submitNamedTunnel(event)">
+                    <div class="grid grid-cols-1 md:grid-cols-2 gap-4 mb-4">
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Name</label>
+                            <input type="text" name="name" required placeholder="internal" pattern="[a-z0-9][a-z0-9-]*"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Domain</label>
+                            <input type="text" name="domain" required placeholder="internal.example.com"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">Tunnel Token</label>
+                            <input type="password" name="tunnel_token" placeholder="Leave blank to keep the current token"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        </div>
+                        <div>
+                            <label class="block text-sm text-gray-500 mb-1">API Token (optional)</label>
+                            <input type="password" name="api_token" placeholder="Defaults to the main API token"
+                                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                        </div>
+                    </div>
+                    <button type="submit" class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white">Save Tunnel</button>
+                </form>
+            </div>
+        </div>
+        <script>
+            function escapeTunnelText(s) {
+                const div = document.createElement('div');
+                div.textContent = s == null ? '' : String(s);
+                return div.innerHTML;
+            }
+
+            function loadNamedTunnels() {
+                fetch('/api/settings/tunnels')
+                    .then(response => response.ok ? response.json() : [])
+                    .then(tunnels => {
+                        const body = document.getElementById('named-tunnels-body');
+                        if (tunnels.length === 0) {
+                            body.innerHTML = '<tr><td colspan="5" class="py-2 text-gray-400">No additional tunnels</td></tr>';
+                            return;
+                        }
+                        body.innerHTML = tunnels.map(tunnel => '<tr class="border-b border-gray-100">' +
+                            '<td class="py-2 font-mono">' + escapeTunnelText(tunnel.name) + '</td>' +
+                            '<td class="py-2">' + escapeTunnelText(tunnel.domain) + '</td>' +
+                            '<td class="py-2 text-gray-500">' + (tunnel.has_api_token ? 'Managed' : 'Manual') + '</td>' +
+                            '<td class="py-2 text-gray-500">' + escapeTunnelText(tunnel.state) + '</td>' +
+                            '<td class="py-2 text-right"><button onclick="deleteNamedTunnel(\'' + escapeTunnelText(tunnel.name) + '\')" class="text-red-600 hover:text-red-700">Remove</button></td>' +
+                            '</tr>').join('');
+                    });
+            }
+
+            function submitNamedTunnel(event) {
+                event.preventDefault();
+                const form = event.target;
+                const data = {
+                    name: form.querySelector('input[name="name"]').value,
+                    domain: form.querySelector('input[name="domain"]').value,
+                    tunnel_token: form.querySelector('input[name="tunnel_token"]').value,
+                    api_token: form.querySelector('input[name="api_token"]').value
+                };
+
+                fetch('/api/settings/tunnels', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify(data)
+                })
+                .then(response => {
+                    if (response.ok) {
+                        form.reset();
+                        showToast('Tunnel saved', 'success');
+                    } else {
+                        response.text().then(text => alert('Failed to save tunnel: ' + text));
+                    }
+                    loadNamedTunnels();
+                });
+            }
+
+            function deleteNamedTunnel(name) {
+                if (!confirm('Remove tunnel ' + name + '? Apps routed through it will no longer be reachable.')) {
+                    return;
+                }
+                fetch('/api/settings/tunnels/' + encodeURIComponent(name), { method: 'DELETE' })
+                    .then(response => {
+                        if (response.ok) {
+                            showToast('Tunnel removed', 'success');
+                            loadNamedTunnels();
+                        } else {
+                            response.text().then(text => alert('Failed to remove tunnel: ' + text));
+                        }
+                    });
+            }
+
+            loadNamedTunnels();
+        </script>`)
+}
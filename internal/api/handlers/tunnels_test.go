@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"schooner/internal/cloudflare"
+)
+
+func TestUpsertTunnel(t *testing.T) {
+	tunnels := []cloudflare.Tunnel{{Name: "internal", Token: "old-token", Domain: "lan.example.com", APIToken: "old-api"}}
+
+	updated := upsertTunnel(tunnels, cloudflare.Tunnel{Name: "internal", Domain: "intra.example.com"})
+	if len(updated) != 1 {
+		t.Fatalf("upsertTunnel() returned %d tunnels, want 1", len(updated))
+	}
+	if got := updated[0]; got.Domain != "intra.example.com" || got.Token != "old-token" || got.APIToken != "old-api" {
+		t.Errorf("updated tunnel = %+v, want new domain and kept tokens", got)
+	}
+
+	added := upsertTunnel(updated, cloudflare.Tunnel{Name: "public", Token: "t", Domain: "example.com"})
+	if len(added) != 2 || added[1].Name != "public" {
+		t.Errorf("upsertTunnel() = %+v, want the new tunnel appended", added)
+	}
+}
+
+func TestTunnelsHandler_List_NoManager(t *testing.T) {
+	handler := NewTunnelsHandler(nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/settings/tunnels", nil)
+	rr := httptest.NewRecorder()
+	handler.List(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("List() status = %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"schooner/internal/auth"
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+// TwoFactorHandler handles TOTP enrollment and login verification
+type TwoFactorHandler struct {
+	settingsQueries *queries.SettingsQueries
+	sessionStore    *auth.SessionStore
+
+	mu       sync.Mutex
+	failures map[string]int // session ID -> failed verification attempts
+}
+
+// NewTwoFactorHandler creates a new TwoFactorHandler
+func NewTwoFactorHandler(settingsQueries *queries.SettingsQueries, sessionStore *auth.SessionStore) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		settingsQueries: settingsQueries,
+		sessionStore:    sessionStore,
+		failures:        make(map[string]int),
+	}
+}
+
+// twoFactorCodeRequest is the request body for actions confirmed with a code
+type twoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// verifyCode checks a TOTP or recovery code and persists the consumed
+// counter or recovery code
+func (h *TwoFactorHandler) verifyCode(ctx context.Context, cfg *models.TwoFactorConfig, code string) (bool, error) {
+	if counter, ok := auth.ValidateTOTP(cfg.Secret, code, time.Now(), cfg.LastCounter); ok {
+		cfg.LastCounter = counter
+		return true, h.settingsQueries.SetTwoFactorConfig(ctx, cfg)
+	}
+
+	if remaining, ok := auth.UseRecoveryCode(cfg.RecoveryCodeHashes, code); ok {
+		cfg.RecoveryCodeHashes = remaining
+		slog.Warn("two-factor recovery code used", "remaining", len(remaining))
+		return true, h.settingsQueries.SetTwoFactorConfig(ctx, cfg)
+	}
+
+	return false, nil
+}
+
+// Status handles GET /api/2fa
+func (h *TwoFactorHandler) Status(w http.ResponseWriter, r *http.Request) {
+	cfg, err := h.settingsQueries.GetTwoFactorConfig(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to get two-factor config", "error", err)
+		http.Error(w, "failed to get two-factor settings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"enabled":                  cfg.Enabled,
+		"required":                 cfg.Required,
+		"recovery_codes_remaining": cfg.RecoveryCodesRemaining(),
+	})
+}
+
+// Disable handles POST /api/2fa/disable
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req twoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	cfg, err := h.settingsQueries.GetTwoFactorConfig(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get two-factor config", "error", err)
+		http.Error(w, "failed to disable two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+	if !cfg.Enabled {
+		http.Error(w, "two-factor authentication is not enabled", http.StatusBadRequest)
+		return
+	}
+	if cfg.Required {
+		http.Error(w, "two-factor authentication is required; turn off enforcement first", http.StatusBadRequest)
+		return
+	}
+
+	ok, err := h.verifyCode(ctx, cfg, req.Code)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to verify two-factor code", "error", err)
+		http.Error(w, "failed to disable two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "invalid code", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.settingsQueries.SetTwoFactorConfig(ctx, &models.TwoFactorConfig{}); err != nil {
+		slog.ErrorContext(ctx, "failed to save two-factor config", "error", err)
+		http.Error(w, "failed to disable two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "two-factor authentication disabled")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// SetRequired handles PUT /api/2fa/required
+func (h *TwoFactorHandler) SetRequired(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req struct {
+		Required bool   `json:"required"`
+		Code     string `json:"code"` // needed to lift enforcement once enrolled
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	cfg, err := h.settingsQueries.GetTwoFactorConfig(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get two-factor config", "error", err)
+		http.Error(w, "failed to save two-factor settings", http.StatusInternalServerError)
+		return
+	}
+
+	// Otherwise a hijacked session could drop enforcement and then disable 2FA
+	if cfg.Required && !req.Required && cfg.Enabled {
+		ok, err := h.verifyCode(ctx, cfg, req.Code)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to verify two-factor code", "error", err)
+			http.Error(w, "failed to save two-factor settings", http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			http.Error(w, "invalid code", http.StatusBadRequest)
+			return
+		}
+	}
+
+	cfg.Required = req.Required
+	if err := h.settingsQueries.SetTwoFactorConfig(ctx, cfg); err != nil {
+		slog.ErrorContext(ctx, "failed to save two-factor config", "error", err)
+		http.Error(w, "failed to save two-factor settings", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "two-factor enforcement updated", "required", req.Required)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Two-factor settings saved",
+	})
+}
+
+func (h *PageHandler) renderTwoFactorSettings(w http.ResponseWriter, r *http.Request) {
+	cfg, err := h.settingsQueries.GetTwoFactorConfig(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to get two-factor config", "error", err)
+		cfg = &models.TwoFactorConfig{}
+	}
+
+	requiredChecked := ""
+	if cfg.Required {
+		requiredChecked = "checked"
+	}
+
+	fmt.Fprint(w, `
+        <div class="mt-8">
+            <h2 class="text-xl font-bold mb-4">Two-Factor Authentication</h2>
+            <div class="bg-white shadow-sm rounded-lg p-6 border border-gray-200">`)
+
+	if cfg.Enabled {
+		fmt.Fprintf(w, `
+                <div class="flex items-center justify-between mb-4">
+                    <div class="flex items-center space-x-2">
+                        <span class="w-3 h-3 rounded-full bg-green-500"></span>
+                        <span class="text-sm">Enabled - %d recovery codes remaining</span>
+                    </div>
+                    <div class="space-x-2">
+                        <button onclick="regenerateRecoveryCodes()" class="px-3 py-1 bg-gray-100 hover:bg-gray-200 rounded text-sm">New recovery codes</button>
+                        <button onclick="disableTwoFactor()" class="px-3 py-1 bg-red-600 hover:bg-red-700 rounded text-sm text-white">Disable</button>
+                    </div>
+                </div>
+                <pre id="totp-new-recovery-codes" class="hidden bg-gray-50 border border-gray-200 rounded p-3 font-mono text-sm mb-4"></pre>`, cfg.RecoveryCodesRemaining())
+	} else {
+		fmt.Fprint(w, `
+                <p class="text-gray-500 mb-4">Require a code from an authenticator app after signing in with GitHub, so a leaked session or GitHub account alone can't reach this server.</p>
+                <button id="totp-setup-btn" onclick="document.getElementById('totp-setup').classList.remove('hidden'); this.classList.add('hidden'); startTwoFactorSetup();"
+                    class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white mb-4">Set Up</button>
+                <div id="totp-setup" class="hidden max-w-sm mb-4">`)
+		renderTwoFactorEnrollment(w, "", false)
+		fmt.Fprint(w, `
+                </div>`)
+	}
+
+	fmt.Fprintf(w, `
+                <label class="flex items-center text-sm text-gray-700 pt-4 border-t border-gray-200">
+                    <input type="checkbox" id="totp-required-input" class="mr-2" %s onchange="setTwoFactorRequired(this.checked)">
+                    Require two-factor authentication (sign-in forces enrollment and 2FA can't be disabled)
+                </label>
+            </div>
+        </div>
+        <script>
+            function setTwoFactorRequired(required) {
+                let code = '';
+                if (!required && document.getElementById('totp-setup-btn') === null) {
+                    code = prompt('Enter an authentication code to stop requiring two-factor authentication');
+                    if (!code) {
+                        document.getElementById('totp-required-input').checked = true;
+                        return;
+                    }
+                }
+                fetch('/api/2fa/required', {
+                    method: 'PUT',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ required: required, code: code })
+                })
+                .then(response => {
+                    if (response.ok) {
+                        showToast('Two-factor settings saved', 'success');
+                    } else {
+                        document.getElementById('totp-required-input').checked = !required;
+                        response.text().then(text => alert('Failed to save: ' + text));
+                    }
+                });
+            }
+
+            function disableTwoFactor() {
+                const code = prompt('Enter an authentication or recovery code to disable two-factor authentication');
+                if (!code) {
+                    return;
+                }
+                fetch('/api/2fa/disable', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ code: code })
+                })
+                .then(response => {
+                    if (response.ok) {
+                        window.location.reload();
+                    } else {
+                        response.text().then(text => alert('Failed to disable: ' + text));
+                    }
+                });
+            }
+
+            function regenerateRecoveryCodes() {
+                const code = prompt('Enter an authentication code to replace your recovery codes');
+                if (!code) {
+                    return;
+                }
+                fetch('/api/2fa/recovery-codes', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ code: code })
+                })
+                .then(response => {
+                    if (response.ok) {
+                        response.json().then(data => {
+                            const el = document.getElementById('totp-new-recovery-codes');
+                            el.textContent = data.recovery_codes.join('\n');
+                            el.classList.remove('hidden');
+                            showToast('Recovery codes regenerated', 'success');
+                        });
+                    } else {
+                        response.text().then(text => alert('Failed to regenerate: ' + text));
+                    }
+                });
+            }
+        </script>`, requiredChecked)
+}
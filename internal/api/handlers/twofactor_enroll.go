@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"schooner/internal/auth"
+	"schooner/internal/database/queries"
+)
+
+// Setup handles POST /api/2fa/setup - generates a secret to enroll
+func (h *TwoFactorHandler) Setup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cfg, err := h.settingsQueries.GetTwoFactorConfig(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get two-factor config", "error", err)
+		http.Error(w, "failed to get two-factor settings", http.StatusInternalServerError)
+		return
+	}
+	if cfg.Enabled {
+		http.Error(w, "two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to generate TOTP secret", "error", err)
+		http.Error(w, "failed to start setup", http.StatusInternalServerError)
+		return
+	}
+	if err := h.settingsQueries.Set(ctx, queries.TOTPPendingSecretKey, secret); err != nil {
+		slog.ErrorContext(ctx, "failed to save pending TOTP secret", "error", err)
+		http.Error(w, "failed to start setup", http.StatusInternalServerError)
+		return
+	}
+
+	account := "owner"
+	if session := auth.GetSession(ctx); session != nil {
+		account = session.Username
+	}
+
+	otpauthURL := auth.TOTPURL("Schooner", account, secret)
+	qrCode, err := auth.QRCodeSVG(otpauthURL, 180)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to draw TOTP QR code", "error", err)
+		http.Error(w, "failed to start setup", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"secret":      secret,
+		"otpauth_url": otpauthURL,
+		"qr_svg":      qrCode,
+	})
+}
+
+// Enable handles POST /api/2fa/enable - confirms setup with a first code
+func (h *TwoFactorHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req twoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	secret, err := h.settingsQueries.Get(ctx, queries.TOTPPendingSecretKey)
+	if err != nil || secret == "" {
+		http.Error(w, "start setup first", http.StatusBadRequest)
+		return
+	}
+
+	counter, ok := auth.ValidateTOTP(secret, req.Code, time.Now(), 0)
+	if !ok {
+		http.Error(w, "invalid code", http.StatusBadRequest)
+		return
+	}
+
+	cfg, err := h.settingsQueries.GetTwoFactorConfig(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get two-factor config", "error", err)
+		http.Error(w, "failed to enable two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	codes, hashes, err := auth.GenerateRecoveryCodes()
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to generate recovery codes", "error", err)
+		http.Error(w, "failed to enable two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	cfg.Enabled = true
+	cfg.Secret = secret
+	cfg.RecoveryCodeHashes = hashes
+	cfg.LastCounter = counter
+	if err := h.settingsQueries.SetTwoFactorConfig(ctx, cfg); err != nil {
+		slog.ErrorContext(ctx, "failed to save two-factor config", "error", err)
+		http.Error(w, "failed to enable two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+	_ = h.settingsQueries.Delete(ctx, queries.TOTPPendingSecretKey)
+
+	// The code just entered proves possession, so this session is verified
+	if session := auth.GetSession(ctx); session != nil {
+		h.sessionStore.SetMFAState(session.ID, auth.MFAComplete)
+	}
+
+	slog.InfoContext(ctx, "two-factor authentication enabled")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":        true,
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// SetupPage handles GET /login/2fa/setup - enrollment when 2FA is required
+func (h *TwoFactorHandler) SetupPage(w http.ResponseWriter, r *http.Request) {
+	writeAuthPageHeader(w, r, "Set up two-factor authentication")
+	fmt.Fprint(w, `
+        <p class="text-gray-500 mb-4 text-sm">Two-factor authentication is required on this instance. Scan the code with an authenticator app, then enter the 6-digit code it shows.</p>`)
+	renderTwoFactorEnrollment(w, "/", true)
+	writeAuthPageFooter(w)
+}
+
+// renderTwoFactorEnrollment renders the QR code, confirmation form and
+// recovery code display. After enrollment the user continues to next, or the
+// page reloads when next is empty. Without autoStart, setup begins when
+// startTwoFactorSetup() is called.
+func renderTwoFactorEnrollment(w http.ResponseWriter, next string, autoStart bool) {
+	fmt.Fprintf(w, `
+        <div id="totp-enroll" data-next="%s" data-autostart="%t">
+            <div id="totp-qr" class="flex justify-center mb-2"></div>
+            <p class="text-xs text-gray-400 text-center mb-4">Or enter the key manually: <code id="totp-secret" class="font-mono"></code></p>
+            <form onsubmit="enableTwoFactor(event)" class="flex space-x-2">
+                <input type="text" name="code" required placeholder="123456" autocomplete="one-time-code"
+                    class="flex-1 bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900 font-mono">
+                <button type="submit" class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white">Enable</button>
+            </form>
+        </div>
+        <div id="totp-recovery" class="hidden">
+            <p class="text-sm text-gray-700 mb-2">Save these recovery codes somewhere safe. Each can be used once if you lose your authenticator.</p>
+            <pre id="totp-recovery-codes" class="bg-gray-50 border border-gray-200 rounded p-3 font-mono text-sm mb-4"></pre>
+            <button onclick="finishTwoFactorSetup()" class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white">I've saved them</button>
+        </div>
+        <script>
+            function startTwoFactorSetup() {
+                fetch('/api/2fa/setup', { method: 'POST' })
+                    .then(response => {
+                        if (!response.ok) {
+                            return response.text().then(text => { throw new Error(text); });
+                        }
+                        return response.json();
+                    })
+                    .then(data => {
+                        document.getElementById('totp-qr').innerHTML = data.qr_svg;
+                        document.getElementById('totp-secret').textContent = data.secret;
+                    })
+                    .catch(err => alert('Failed to start setup: ' + err.message));
+            }
+
+            function enableTwoFactor(event) {
+                event.preventDefault();
+                const code = event.target.querySelector('input[name="code"]').value;
+                fetch('/api/2fa/enable', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ code: code })
+                })
+                .then(response => {
+                    if (response.ok) {
+                        response.json().then(data => {
+                            document.getElementById('totp-enroll').classList.add('hidden');
+                            document.getElementById('totp-recovery-codes').textContent = data.recovery_codes.join('\n');
+                            document.getElementById('totp-recovery').classList.remove('hidden');
+                        });
+                    } else {
+                        response.text().then(text => alert('Failed to enable: ' + text));
+                    }
+                });
+            }
+
+            function finishTwoFactorSetup() {
+                const next = document.getElementById('totp-enroll').dataset.next;
+                if (next) {
+                    window.location.href = next;
+                } else {
+                    window.location.reload();
+                }
+            }
+
+            if (document.getElementById('totp-enroll').dataset.autostart === 'true') {
+                startTwoFactorSetup();
+            }
+        </script>`, html.EscapeString(next), autoStart)
+}
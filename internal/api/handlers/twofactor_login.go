@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+
+	"schooner/internal/auth"
+)
+
+// maxMFAFailures is how many wrong codes a session may enter before it is
+// discarded and the user has to sign in with GitHub again
+const maxMFAFailures = 5
+
+// VerifyPage handles GET /login/2fa
+func (h *TwoFactorHandler) VerifyPage(w http.ResponseWriter, r *http.Request) {
+	h.renderVerifyPage(w, r, "")
+}
+
+// Verify handles POST /login/2fa - completes login with a code
+func (h *TwoFactorHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	session := auth.GetSession(ctx)
+	if session == nil {
+		http.Redirect(w, r, "/oauth/github/login", http.StatusSeeOther)
+		return
+	}
+
+	cfg, err := h.settingsQueries.GetTwoFactorConfig(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get two-factor config", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	ok, err := h.verifyCode(ctx, cfg, r.FormValue("code"))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to verify two-factor code", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if !ok {
+		if h.recordFailure(session.ID) >= maxMFAFailures {
+			slog.WarnContext(ctx, "too many two-factor failures, ending session", "username", session.Username)
+			h.sessionStore.Delete(session.ID)
+			auth.ClearSessionCookie(w)
+			http.Redirect(w, r, "/oauth/github/login", http.StatusSeeOther)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		h.renderVerifyPage(w, r, "Invalid code. Try again.")
+		return
+	}
+
+	h.clearFailures(session.ID)
+	h.sessionStore.SetMFAState(session.ID, auth.MFAComplete)
+	slog.InfoContext(ctx, "two-factor verification completed", "username", session.Username)
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (h *TwoFactorHandler) recordFailure(sessionID string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures[sessionID]++
+	return h.failures[sessionID]
+}
+
+func (h *TwoFactorHandler) clearFailures(sessionID string) {
+	h.mu.Lock()
+	delete(h.failures, sessionID)
+	h.mu.Unlock()
+}
+
+func (h *TwoFactorHandler) renderVerifyPage(w http.ResponseWriter, r *http.Request, errMsg string) {
+	writeAuthPageHeader(w, r, "Two-factor authentication")
+	if errMsg != "" {
+		fmt.Fprintf(w, `
+        <div class="mb-4 px-3 py-2 rounded bg-red-50 border border-red-200 text-red-700 text-sm">%s</div>`, html.EscapeString(errMsg))
+	}
+	fmt.Fprintf(w, `
+        <form method="POST" action="/login/2fa">
+            <input type="hidden" name="%s" value="%s">
+            <label class="block text-sm text-gray-500 mb-1">Authentication or recovery code</label>
+            <input type="text" name="code" required autofocus autocomplete="one-time-code"
+                class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900 font-mono mb-4">
+            <button type="submit" class="w-full px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white">Verify</button>
+        </form>
+        <p class="text-xs text-gray-400 mt-4 text-center"><a href="/logout" class="hover:text-gray-600">Sign out</a></p>`,
+		auth.CSRFFormField, html.EscapeString(auth.CSRFToken(r.Context())))
+	writeAuthPageFooter(w)
+}
+
+// writeAuthPageHeader starts a standalone page for login steps that run
+// before the dashboard layout is available
+func writeAuthPageHeader(w http.ResponseWriter, r *http.Request, title string) {
+	w.Header().Set("Content-Type", "text/html")
+	w.Header().Set("Cache-Control", "no-store")
+	renderTemplate(w, "auth-header", struct {
+		Title, CSRFToken string
+	}{title, auth.CSRFToken(r.Context())})
+}
+
+func writeAuthPageFooter(w http.ResponseWriter) {
+	renderTemplate(w, "auth-footer", nil)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"schooner/internal/auth"
+)
+
+// RegenerateRecoveryCodes handles POST /api/2fa/recovery-codes
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req twoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	cfg, err := h.settingsQueries.GetTwoFactorConfig(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get two-factor config", "error", err)
+		http.Error(w, "failed to regenerate recovery codes", http.StatusInternalServerError)
+		return
+	}
+	if !cfg.Enabled {
+		http.Error(w, "two-factor authentication is not enabled", http.StatusBadRequest)
+		return
+	}
+
+	ok, err := h.verifyCode(ctx, cfg, req.Code)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to verify two-factor code", "error", err)
+		http.Error(w, "failed to regenerate recovery codes", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "invalid code", http.StatusBadRequest)
+		return
+	}
+
+	codes, hashes, err := auth.GenerateRecoveryCodes()
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to generate recovery codes", "error", err)
+		http.Error(w, "failed to regenerate recovery codes", http.StatusInternalServerError)
+		return
+	}
+	cfg.RecoveryCodeHashes = hashes
+	if err := h.settingsQueries.SetTwoFactorConfig(ctx, cfg); err != nil {
+		slog.ErrorContext(ctx, "failed to save two-factor config", "error", err)
+		http.Error(w, "failed to regenerate recovery codes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":        true,
+		"message":        "Recovery codes regenerated",
+		"recovery_codes": codes,
+	})
+}
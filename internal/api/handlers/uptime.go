@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+	"schooner/internal/proxy"
+)
+
+// uptimeHistoryLimit is the number of recent results returned for charts
+const uptimeHistoryLimit = 90
+
+// UptimeHandler handles per-app uptime check configuration and history
+type UptimeHandler struct {
+	uptimeQueries *queries.UptimeQueries
+	appQueries    *queries.AppQueries
+	proxyRouter   *proxy.Router
+}
+
+// NewUptimeHandler creates a new UptimeHandler
+func NewUptimeHandler(uptimeQueries *queries.UptimeQueries, appQueries *queries.AppQueries, proxyRouter *proxy.Router) *UptimeHandler {
+	return &UptimeHandler{
+		uptimeQueries: uptimeQueries,
+		appQueries:    appQueries,
+		proxyRouter:   proxyRouter,
+	}
+}
+
+// UptimeCheckRequest is the request body for configuring an uptime check
+type UptimeCheckRequest struct {
+	URL             string `json:"url"`
+	IntervalSeconds int    `json:"interval_seconds"`
+	TimeoutSeconds  int    `json:"timeout_seconds"`
+	ExpectedStatus  int    `json:"expected_status"`
+	Enabled         *bool  `json:"enabled"`
+}
+
+// Get handles GET /api/apps/{appID}/uptime
+func (h *UptimeHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID := chi.URLParam(r, "appID")
+
+	app, err := h.appQueries.GetByID(ctx, appID)
+	if err != nil {
+		http.Error(w, "failed to get app", http.StatusInternalServerError)
+		return
+	}
+	if app == nil {
+		http.Error(w, "app not found", http.StatusNotFound)
+		return
+	}
+
+	defaultURL := ""
+	if h.proxyRouter != nil {
+		defaultURL = h.proxyRouter.PublicURL(ctx, app)
+	}
+
+	check, err := h.uptimeQueries.GetByAppID(ctx, appID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get uptime check", "appID", appID, "error", err)
+		http.Error(w, "failed to get uptime check", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"configured":  check != nil,
+		"default_url": defaultURL,
+	}
+
+	if check != nil {
+		results, err := h.uptimeQueries.ListResults(ctx, check.ID, uptimeHistoryLimit)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to list uptime results", "appID", appID, "error", err)
+		}
+		if results == nil {
+			results = []*models.UptimeResult{}
+		}
+
+		stats, err := h.uptimeQueries.GetStats(ctx, check.ID, time.Now().Add(-24*time.Hour))
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to get uptime stats", "appID", appID, "error", err)
+			stats = &models.UptimeStats{}
+		}
+
+		response["check"] = check
+		response["results"] = results
+		response["stats_24h"] = map[string]interface{}{
+			"checks":          stats.Checks,
+			"up_checks":       stats.UpChecks,
+			"uptime_percent":  stats.UptimePercent(),
+			"avg_response_ms": stats.AvgResponseMs,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Save handles PUT /api/apps/{appID}/uptime
+func (h *UptimeHandler) Save(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	appID := chi.URLParam(r, "appID")
+
+	app, err := h.appQueries.GetByID(ctx, appID)
+	if err != nil {
+		http.Error(w, "failed to get app", http.StatusInternalServerError)
+		return
+	}
+	if app == nil {
+		http.Error(w, "app not found", http.StatusNotFound)
+		return
+	}
+
+	var req UptimeCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.URL = strings.TrimSpace(req.URL)
+	if req.URL != "" && !isHTTPURL(req.URL) {
+		http.Error(w, "url must be an http(s) URL", http.StatusBadRequest)
+		return
+	}
+
+	check, err := h.uptimeQueries.GetByAppID(ctx, appID)
+	if err != nil {
+		http.Error(w, "failed to get uptime check", http.StatusInternalServerError)
+		return
+	}
+	if check == nil {
+		check = &models.UptimeCheck{
+			ID:        uuid.New().String(),
+			AppID:     appID,
+			Enabled:   true,
+			CreatedAt: time.Now(),
+		}
+	}
+
+	check.URL = sql.NullString{String: req.URL, Valid: req.URL != ""}
+	check.IntervalSeconds = req.IntervalSeconds
+	check.TimeoutSeconds = req.TimeoutSeconds
+	check.ExpectedStatus = req.ExpectedStatus
+	if req.Enabled != nil {
+		check.Enabled = *req.Enabled
+	}
+	if check.IntervalSeconds == 0 {
+		check.IntervalSeconds = 60
+	}
+	if check.TimeoutSeconds == 0 {
+		check.TimeoutSeconds = 10
+	}
+
+	if err := check.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if check.GetURL() == "" && (h.proxyRouter == nil || h.proxyRouter.PublicURL(ctx, app) == "") {
+		http.Error(w, "url is required when the app has no public tunnel URL", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.uptimeQueries.Save(ctx, check); err != nil {
+		slog.ErrorContext(ctx, "failed to save uptime check", "appID", appID, "error", err)
+		http.Error(w, "failed to save uptime check", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "uptime check saved", "app", app.Name, "url", check.GetURL(), "interval", check.IntervalSeconds)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Uptime check saved",
+	})
+}
+
+// Delete handles DELETE /api/apps/{appID}/uptime
+func (h *UptimeHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	appID := chi.URLParam(r, "appID")
+
+	if err := h.uptimeQueries.DeleteByAppID(r.Context(), appID); err != nil {
+		slog.ErrorContext(r.Context(), "failed to delete uptime check", "appID", appID, "error", err)
+		http.Error(w, "failed to delete uptime check", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Uptime check removed",
+	})
+}
+
+// uptimeBadge renders the dashboard badge for an app's uptime check
+func uptimeBadge(check *models.UptimeCheck) string {
+	if check == nil || !check.Enabled {
+		return ""
+	}
+
+	switch check.LastStatus {
+	case models.UptimeUp:
+		label := "Up"
+		if check.LastResponseMs.Valid {
+			label = fmt.Sprintf("Up &middot; %dms", check.LastResponseMs.Int64)
+		}
+		return `<span class="px-2 py-1 text-xs rounded-full bg-green-100 text-green-700 ml-2">` + label + `</span>`
+	case models.UptimeDown:
+		return `<span class="px-2 py-1 text-xs rounded-full bg-red-100 text-red-700 ml-2">Down</span>`
+	default:
+		return `<span class="px-2 py-1 text-xs rounded-full bg-gray-100 text-gray-700 ml-2">Checking</span>`
+	}
+}
+
+func (h *PageHandler) renderAppUptime(w http.ResponseWriter, app *models.App) {
+	fmt.Fprintf(w, `
+        <h2 class="text-xl font-bold mb-4">Uptime</h2>
+        <div class="bg-white shadow-sm rounded-lg p-6 border border-gray-200 mb-8" id="uptime-section" data-app-id="%s">
+            <div class="grid grid-cols-3 gap-4 mb-4 text-center">
+                <div>
+                    <div class="text-sm text-gray-500">Status</div>
+                    <div class="text-lg font-semibold" id="uptime-status">-</div>
+                </div>
+                <div>
+                    <div class="text-sm text-gray-500">Uptime (24h)</div>
+                    <div class="text-lg font-semibold" id="uptime-percent">-</div>
+                </div>
+                <div>
+                    <div class="text-sm text-gray-500">Avg response (24h)</div>
+                    <div class="text-lg font-semibold" id="uptime-avg">-</div>
+                </div>
+            </div>
+            <div class="flex items-end h-12 space-x-px mb-6" id="uptime-history"></div>
+            <form onsubmit="saveUptimeCheck(event)">
+                <div class="grid grid-cols-1 md:grid-cols-4 gap-4 mb-4">
+                    <div class="md:col-span-2">
+                        <label class="block text-sm text-gray-500 mb-1">URL</label>
+                        <input type="text" name="url" id="uptime-url-input"
+                            class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                    </div>
+                    <div>
+                        <label class="block text-sm text-gray-500 mb-1">Interval (s)</label>
+                        <input type="number" name="interval_seconds" id="uptime-interval-input" value="60" min="10"
+                            class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                    </div>
+                    <div>
+                        <label class="block text-sm text-gray-500 mb-1">Expected status</label>
+                        <input type="number" name="expected_status" id="uptime-status-input" placeholder="Any 2xx/3xx"
+                            class="w-full bg-gray-50 border border-gray-200 rounded px-3 py-2 text-gray-900">
+                    </div>
+                </div>
+                <div class="flex space-x-2">
+                    <button type="submit" class="px-4 py-2 bg-blue-600 hover:bg-blue-700 rounded text-white">Save</button>
+                    <button type="button" onclick="deleteUptimeCheck()" class="px-4 py-2 bg-gray-50 hover:bg-gray-100 rounded">Remove</button>
+                </div>
+            </form>
+        </div>
+        <script>
+            (function() {
+                const appID = document.getElementById('uptime-section').dataset.appId;
+
+                function loadUptime() {
+                    fetch('/api/apps/' + appID + '/uptime')
+                        .then(response => response.json())
+                        .then(data => {
+                            const urlInput = document.getElementById('uptime-url-input');
+                            urlInput.placeholder = data.default_url || 'https://example.com/health';
+                            if (!data.configured) {
+                                document.getElementById('uptime-status').textContent = 'Not monitored';
+                                return;
+                            }
+
+                            const check = data.check;
+                            urlInput.value = check.url && check.url.Valid ? check.url.String : '';
+                            document.getElementById('uptime-interval-input').value = check.interval_seconds;
+                            document.getElementById('uptime-status-input').value = check.expected_status || '';
+
+                            const status = document.getElementById('uptime-status');
+                            status.textContent = check.last_status;
+                            status.className = 'text-lg font-semibold ' +
+                                (check.last_status === 'up' ? 'text-green-600' : check.last_status === 'down' ? 'text-red-600' : 'text-gray-500');
+                            document.getElementById('uptime-percent').textContent = data.stats_24h.uptime_percent.toFixed(2) + '%%';
+                            document.getElementById('uptime-avg').textContent = Math.round(data.stats_24h.avg_response_ms) + 'ms';
+
+                            const history = document.getElementById('uptime-history');
+                            const max = Math.max(1, ...data.results.map(r => r.response_ms));
+                            history.innerHTML = '';
+                            data.results.slice().reverse().forEach(r => {
+                                const bar = document.createElement('div');
+                                bar.className = 'flex-1 ' + (r.up ? 'bg-green-400' : 'bg-red-400');
+                                bar.style.height = r.up ? Math.max(8, r.response_ms / max * 100) + '%%' : '100%%';
+                                bar.title = new Date(r.checked_at).toLocaleString() + ': ' +
+                                    (r.up ? r.response_ms + 'ms' : (r.error && r.error.Valid ? r.error.String : 'down'));
+                                history.appendChild(bar);
+                            });
+                        });
+                }
+
+                window.saveUptimeCheck = function(event) {
+                    event.preventDefault();
+                    const data = {
+                        url: document.getElementById('uptime-url-input').value.trim(),
+                        interval_seconds: parseInt(document.getElementById('uptime-interval-input').value, 10) || 60,
+                        expected_status: parseInt(document.getElementById('uptime-status-input').value, 10) || 0
+                    };
+
+                    fetch('/api/apps/' + appID + '/uptime', {
+                        method: 'PUT',
+                        headers: { 'Content-Type': 'application/json' },
+                        body: JSON.stringify(data)
+                    })
+                    .then(response => {
+                        if (response.ok) {
+                            showToast('Uptime check saved', 'success');
+                            loadUptime();
+                        } else {
+                            response.text().then(text => alert('Failed to save: ' + text));
+                        }
+                    });
+                };
+
+                window.deleteUptimeCheck = function() {
+                    fetch('/api/apps/' + appID + '/uptime', { method: 'DELETE' })
+                        .then(response => {
+                            if (response.ok) {
+                                window.location.reload();
+                            } else {
+                                response.text().then(text => alert('Failed to remove: ' + text));
+                            }
+                        });
+                };
+
+                loadUptime();
+            })();
+        </script>`,
+		html.EscapeString(app.ID))
+}
@@ -1,24 +1,33 @@
 package handlers
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
+	"database/sql"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 
 	"schooner/internal/build"
 	"schooner/internal/config"
 	"schooner/internal/database"
 	"schooner/internal/database/queries"
+	"schooner/internal/events"
+	"schooner/internal/metrics"
 	"schooner/internal/models"
+	"schooner/internal/tracing"
 )
 
 // WebhookHandler handles GitHub webhook requests
@@ -28,6 +37,9 @@ type WebhookHandler struct {
 	buildQueries *queries.BuildQueries
 	logQueries   *queries.LogQueries
 	orchestrator *build.Orchestrator
+
+	settingsQueries *queries.SettingsQueries
+	events          *events.Bus
 }
 
 // NewWebhookHandler creates a new WebhookHandler
@@ -41,9 +53,29 @@ func NewWebhookHandler(cfg *config.Config, appQueries *queries.AppQueries, build
 	}
 }
 
+// SetSettingsQueries sets where the auto-deploy pause switch is read from
+func (h *WebhookHandler) SetSettingsQueries(settingsQueries *queries.SettingsQueries) {
+	h.settingsQueries = settingsQueries
+}
+
+// autoDeployPaused reports whether pushes should be recorded without
+// building them
+func (h *WebhookHandler) autoDeployPaused(ctx context.Context) bool {
+	if h.settingsQueries == nil {
+		return false
+	}
+	paused, err := h.settingsQueries.IsAutoDeployPaused(ctx)
+	if err != nil {
+		slog.Warn("failed to read auto-deploy pause", "error", err)
+		return false
+	}
+	return paused
+}
+
 // GitHubPushEvent represents a GitHub push webhook payload
 type GitHubPushEvent struct {
 	Ref        string              `json:"ref"`
+	Deleted    bool                `json:"deleted"`
 	Before     string              `json:"before"`
 	After      string              `json:"after"`
 	Repository GitHubRepository    `json:"repository"`
@@ -77,7 +109,7 @@ type GitHubAuthor struct {
 	Username string `json:"username"`
 }
 
-// GitHubPusher represents pusher info in webhook
+// GitHubPusher represents pusher info in webhook, Name being their login
 type GitHubPusher struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
@@ -85,20 +117,65 @@ type GitHubPusher struct {
 
 // HandleGitHub handles GitHub webhooks for any matching app
 func (h *WebhookHandler) HandleGitHub(w http.ResponseWriter, r *http.Request) {
-	h.handleWebhook(w, r, "")
+	ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+	r, endSpan := traceWebhook(r)
+	h.handleWebhook(ww, r, "")
+	recordWebhookMetric(r, ww.Status())
+	endSpan(ww.Status())
 }
 
 // HandleGitHubForApp handles GitHub webhooks for a specific app
 func (h *WebhookHandler) HandleGitHubForApp(w http.ResponseWriter, r *http.Request) {
 	appID := chi.URLParam(r, "appID")
-	h.handleWebhook(w, r, appID)
+	ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+	r, endSpan := traceWebhook(r)
+	h.handleWebhook(ww, r, appID)
+	recordWebhookMetric(r, ww.Status())
+	endSpan(ww.Status())
+}
+
+// traceWebhook starts the span of a webhook delivery, which the builds it
+// queues join. The returned function ends it with the response status.
+func traceWebhook(r *http.Request) (*http.Request, func(status int)) {
+	ctx, span := tracing.Start(r.Context(), "webhook.github",
+		attribute.String("github.event", r.Header.Get("X-GitHub-Event")),
+		attribute.String("github.delivery", r.Header.Get("X-GitHub-Delivery")),
+	)
+	return r.WithContext(ctx), func(status int) {
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		var err error
+		if status >= 500 {
+			err = fmt.Errorf("webhook failed with status %d", status)
+		}
+		tracing.End(span, err)
+	}
+}
+
+// recordWebhookMetric counts a webhook by GitHub event type and outcome
+func recordWebhookMetric(r *http.Request, status int) {
+	event := r.Header.Get("X-GitHub-Event")
+	if event == "" {
+		event = "unknown"
+	}
+
+	result := "ok"
+	switch {
+	case status == http.StatusUnauthorized:
+		result = "unauthorized"
+	case status >= 500:
+		result = "error"
+	case status >= 400:
+		result = "rejected"
+	}
+
+	metrics.WebhooksTotal.Inc(event, result)
 }
 
 func (h *WebhookHandler) handleWebhook(w http.ResponseWriter, r *http.Request, appID string) {
 	// Read body
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		slog.Error("failed to read webhook body", "error", err)
+		slog.ErrorContext(r.Context(), "failed to read webhook body", "error", err)
 		http.Error(w, "failed to read body", http.StatusBadRequest)
 		return
 	}
@@ -110,24 +187,40 @@ func (h *WebhookHandler) handleWebhook(w http.ResponseWriter, r *http.Request, a
 		return
 	}
 
-	// Only handle push events
+	// Only handle push and release events
+	if eventType == "release" {
+		h.handleRelease(w, r, body, appID)
+		return
+	}
 	if eventType != "push" {
-		slog.Debug("ignoring non-push event", "event", eventType)
+		slog.DebugContext(r.Context(), "ignoring non-push event", "event", eventType)
 		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(map[string]string{"status": "ignored", "reason": "not a push event"})
+		json.NewEncoder(w).Encode(map[string]string{"status": "ignored", "reason": "not a push or release event"})
 		return
 	}
 
 	// Parse push event
 	var event GitHubPushEvent
 	if err := json.Unmarshal(body, &event); err != nil {
-		slog.Error("failed to parse webhook payload", "error", err)
+		slog.ErrorContext(r.Context(), "failed to parse webhook payload", "error", err)
 		http.Error(w, "invalid payload", http.StatusBadRequest)
 		return
 	}
 
-	// Extract branch from ref (refs/heads/main -> main)
+	// A deleted branch or tag has nothing to build
+	if event.Deleted {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"status": "ignored", "reason": "ref deleted"})
+		return
+	}
+
+	// Extract branch or tag from ref (refs/heads/main -> main,
+	// refs/tags/v1.2.0 -> v1.2.0)
+	tag, isTag := strings.CutPrefix(event.Ref, "refs/tags/")
 	branch := strings.TrimPrefix(event.Ref, "refs/heads/")
+	if isTag {
+		branch = ""
+	}
 
 	// Find matching apps
 	var apps []*models.App
@@ -135,32 +228,21 @@ func (h *WebhookHandler) handleWebhook(w http.ResponseWriter, r *http.Request, a
 
 	if appID != "" {
 		// Specific app requested
-		app, err := h.appQueries.GetByID(ctx, appID)
-		if err != nil {
-			slog.Error("failed to get app", "appID", appID, "error", err)
-			http.Error(w, "internal error", http.StatusInternalServerError)
-			return
-		}
+		app := h.webhookApp(w, r, body, appID)
 		if app == nil {
-			http.Error(w, "app not found", http.StatusNotFound)
 			return
 		}
 
-		// Verify signature for this specific app
-		signature := r.Header.Get("X-Hub-Signature-256")
-		if app.GetWebhookSecret() != "" {
-			if err := verifySignature(body, signature, app.GetWebhookSecret()); err != nil {
-				slog.Warn("webhook signature verification failed", "appID", appID, "error", err)
-				http.Error(w, "invalid signature", http.StatusUnauthorized)
-				return
-			}
+		// Check the push is a branch or tag the app builds
+		if reason := pushMismatch(app, branch, tag, isTag); reason != "" {
+			slog.DebugContext(ctx, reason, "app", app.Name, "ref", event.Ref)
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(map[string]string{"status": "ignored", "reason": reason})
+			return
 		}
-
-		// Check if branch matches
-		if app.Branch != branch {
-			slog.Debug("branch mismatch", "app", app.Name, "expected", app.Branch, "got", branch)
+		if !isTag && !branchAllowed(ctx, app, branch) {
 			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode(map[string]string{"status": "ignored", "reason": "branch mismatch"})
+			json.NewEncoder(w).Encode(map[string]string{"status": "ignored", "reason": "branch not allowed"})
 			return
 		}
 
@@ -168,42 +250,32 @@ func (h *WebhookHandler) handleWebhook(w http.ResponseWriter, r *http.Request, a
 	} else {
 		// Find all matching apps
 		var err error
-		apps, err = h.appQueries.FindByRepoAndBranch(ctx, event.Repository.CloneURL, branch)
+		if isTag {
+			apps, err = h.findTagApps(ctx, event.Repository, tag)
+		} else {
+			apps, err = h.appQueries.FindByRepoAndBranch(ctx, event.Repository.CloneURL, branch)
+
+			// Also try SSH URL
+			if err == nil && len(apps) == 0 {
+				apps, err = h.appQueries.FindByRepoAndBranch(ctx, event.Repository.SSHURL, branch)
+			}
+		}
 		if err != nil {
-			slog.Error("failed to find matching apps", "error", err)
+			slog.ErrorContext(ctx, "failed to find matching apps", "error", err)
 			http.Error(w, "internal error", http.StatusInternalServerError)
 			return
 		}
-
-		// Also try SSH URL
-		if len(apps) == 0 {
-			apps, err = h.appQueries.FindByRepoAndBranch(ctx, event.Repository.SSHURL, branch)
-			if err != nil {
-				slog.Error("failed to find matching apps", "error", err)
-				http.Error(w, "internal error", http.StatusInternalServerError)
-				return
-			}
+		if !isTag {
+			apps = slices.DeleteFunc(apps, func(app *models.App) bool {
+				return !branchAllowed(ctx, app, branch)
+			})
 		}
 
-		// Verify signature for each app and filter
-		signature := r.Header.Get("X-Hub-Signature-256")
-		var validApps []*models.App
-		for _, app := range apps {
-			if app.GetWebhookSecret() == "" {
-				validApps = append(validApps, app)
-				continue
-			}
-			if err := verifySignature(body, signature, app.GetWebhookSecret()); err == nil {
-				validApps = append(validApps, app)
-			} else {
-				slog.Warn("webhook signature verification failed for app", "app", app.Name)
-			}
-		}
-		apps = validApps
+		apps = signedApps(r, body, apps)
 	}
 
 	if len(apps) == 0 {
-		slog.Debug("no matching apps found", "repo", event.Repository.FullName, "branch", branch)
+		slog.DebugContext(ctx, "no matching apps found", "repo", event.Repository.FullName, "ref", event.Ref)
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(map[string]string{"status": "ignored", "reason": "no matching apps"})
 		return
@@ -223,48 +295,174 @@ func (h *WebhookHandler) handleWebhook(w http.ResponseWriter, r *http.Request, a
 		commitSHA = event.After
 	}
 
+	h.queueBuilds(w, r, apps, models.Build{
+		CommitSHA:     database.NullString(commitSHA),
+		CommitMessage: database.NullString(commitMessage),
+		CommitAuthor:  database.NullString(commitAuthor),
+		Branch:        database.NullString(branch),
+		Tag:           database.NullString(tag),
+		TriggeredBy:   database.NullString(event.Pusher.Name),
+	})
+}
+
+// webhookApp looks up the app a webhook was sent to and checks its
+// signature, writing an error and returning nil if either fails
+func (h *WebhookHandler) webhookApp(w http.ResponseWriter, r *http.Request, body []byte, appID string) *models.App {
+	ctx := r.Context()
+	app, err := h.appQueries.GetByID(ctx, appID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get app", "appID", appID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return nil
+	}
+	if app == nil {
+		http.Error(w, "app not found", http.StatusNotFound)
+		return nil
+	}
+
+	signature := r.Header.Get("X-Hub-Signature-256")
+	if app.GetWebhookSecret() != "" {
+		if err := verifySignature(body, signature, app.GetWebhookSecret()); err != nil {
+			slog.WarnContext(ctx, "webhook signature verification failed", "appID", appID, "error", err)
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return nil
+		}
+	}
+	return app
+}
+
+// signedApps leaves out the apps whose secret the webhook's signature
+// doesn't match
+func signedApps(r *http.Request, body []byte, apps []*models.App) []*models.App {
+	signature := r.Header.Get("X-Hub-Signature-256")
+	var validApps []*models.App
+	for _, app := range apps {
+		if app.GetWebhookSecret() == "" {
+			validApps = append(validApps, app)
+			continue
+		}
+		if err := verifySignature(body, signature, app.GetWebhookSecret()); err == nil {
+			validApps = append(validApps, app)
+		} else {
+			slog.WarnContext(r.Context(), "webhook signature verification failed for app", "app", app.Name)
+		}
+	}
+	return validApps
+}
+
+// queueBuilds records a build of each app from the commit, branch, tag and
+// release of ref, queues them and writes the response
+func (h *WebhookHandler) queueBuilds(w http.ResponseWriter, r *http.Request, apps []*models.App, ref models.Build) {
+	ctx := r.Context()
+
+	// While auto-deploys are paused, pushes are recorded as cancelled builds
+	// so they show up in the history without deploying
+	paused := h.autoDeployPaused(ctx)
+
 	// Queue builds for each matching app
 	var buildIDs []string
 	for _, app := range apps {
 		if !app.Enabled || !app.AutoDeploy {
-			slog.Debug("skipping disabled/no-auto-deploy app", "app", app.Name)
+			slog.DebugContext(ctx, "skipping disabled/no-auto-deploy app", "app", app.Name)
 			continue
 		}
 
-		build := &models.Build{
-			ID:            uuid.New().String(),
-			AppID:         app.ID,
-			Status:        models.BuildStatusPending,
-			Trigger:       models.TriggerWebhook,
-			CommitSHA:     database.NullString(commitSHA),
-			CommitMessage: database.NullString(commitMessage),
-			CommitAuthor:  database.NullString(commitAuthor),
-			Branch:        database.NullString(branch),
-			CreatedAt:     time.Now(),
+		build := ref
+		build.ID = uuid.New().String()
+		build.AppID = app.ID
+		build.Status = models.BuildStatusPending
+		build.Trigger = models.TriggerWebhook
+		build.CreatedAt = time.Now()
+		if paused {
+			build.Status = models.BuildStatusCancelled
+			build.ErrorMessage = database.NullString("Auto-deploys are paused")
+			build.FinishedAt = sql.NullTime{Time: build.CreatedAt, Valid: true}
 		}
 
-		if err := h.buildQueries.Create(ctx, build); err != nil {
-			slog.Error("failed to create build", "app", app.Name, "error", err)
+		if err := h.buildQueries.Create(ctx, &build); err != nil {
+			slog.ErrorContext(ctx, "failed to create build", "app", app.Name, "error", err)
 			continue
 		}
 
-		slog.Info("build queued", "app", app.Name, "buildID", build.ID, "commit", commitSHA[:8])
+		h.recordWebhook(ctx, app, &build, paused)
+		if paused {
+			slog.InfoContext(ctx, "auto-deploys paused, push recorded", "app", app.Name, "buildID", build.ID, "commit", build.GetShortSHA(), "tag", build.GetTag())
+			buildIDs = append(buildIDs, build.ID)
+			continue
+		}
+
+		slog.InfoContext(ctx, "build queued", "app", app.Name, "buildID", build.ID, "commit", build.GetShortSHA(), "tag", build.GetTag())
 		buildIDs = append(buildIDs, build.ID)
 
-		// Trigger build execution via orchestrator
+		// Trigger build execution via orchestrator, waiting out the app's
+		// debounce window
 		if h.orchestrator != nil {
-			h.orchestrator.QueueBuild(build.ID)
+			h.orchestrator.QueueDebounced(ctx, app, &build)
 		}
 	}
 
+	status := "accepted"
+	if paused {
+		status = "paused"
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":    "accepted",
+		"status":    status,
 		"builds":    len(buildIDs),
 		"build_ids": buildIDs,
 	})
 }
 
+// findTagApps returns the apps of a repository whose release tag pattern
+// matches a pushed tag
+func (h *WebhookHandler) findTagApps(ctx context.Context, repo GitHubRepository, tag string) ([]*models.App, error) {
+	var apps []*models.App
+	for _, url := range []string{repo.CloneURL, repo.SSHURL} {
+		candidates, err := h.appQueries.FindByRepoWithTags(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		for _, app := range candidates {
+			if app.MatchesTag(tag) {
+				apps = append(apps, app)
+			}
+		}
+		if len(apps) > 0 {
+			break
+		}
+	}
+	return apps, nil
+}
+
+// pushMismatch returns why an app doesn't build a pushed branch or tag, or
+// an empty string if it does
+func pushMismatch(app *models.App, branch, tag string, isTag bool) string {
+	switch {
+	case app.ReleaseEvents:
+		return "app builds published releases"
+	case app.BuildsOnTags() && !isTag:
+		return "app builds release tags"
+	case app.BuildsOnTags() && !app.MatchesTag(tag):
+		return "tag mismatch"
+	case !app.BuildsOnTags() && isTag:
+		return "app builds its branch"
+	case !app.BuildsOnTags() && app.Branch != branch:
+		return "branch mismatch"
+	}
+	return ""
+}
+
+// branchAllowed reports whether an app's webhook deploys may build a push to
+// branch, logging the pushes they ignore so a misconfigured branch shows up
+func branchAllowed(ctx context.Context, app *models.App, branch string) bool {
+	if app.AllowsBranch(branch) {
+		return true
+	}
+	slog.WarnContext(ctx, "ignoring push to a branch the app doesn't allow", "app", app.Name, "branch", branch, "allowed", app.AllowedBranches.String)
+	return false
+}
+
 // verifySignature validates GitHub webhook HMAC-SHA256 signature
 func verifySignature(payload []byte, signature, secret string) error {
 	if signature == "" {
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"context"
+
+	"schooner/internal/events"
+	"schooner/internal/models"
+)
+
+// SetEvents sets where received webhooks are added to the activity feed
+func (h *WebhookHandler) SetEvents(bus *events.Bus) {
+	h.events = bus
+}
+
+// recordWebhook adds a webhook that asked for a build of an app to the
+// activity feed
+func (h *WebhookHandler) recordWebhook(ctx context.Context, app *models.App, build *models.Build, paused bool) {
+	ref := "branch " + build.GetBranch()
+	if tag := build.GetTag(); tag != "" {
+		ref = "tag " + tag
+	}
+	if sha := build.GetShortSHA(); sha != "" {
+		ref += " at " + sha
+	}
+	if by := build.GetTriggeredBy(); by != "" {
+		ref += ", by " + by
+	}
+
+	if paused {
+		h.events.Record(ctx, models.ActivityWebhook, app.ID, build.ID,
+			"Webhook for %s, %s, not deployed while auto-deploys are paused", app.Name, ref)
+		return
+	}
+	h.events.Record(ctx, models.ActivityWebhook, app.ID, build.ID, "Webhook received for %s, %s", app.Name, ref)
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"schooner/internal/database"
+	"schooner/internal/models"
+)
+
+// GitHubReleaseEvent represents a GitHub release webhook payload
+type GitHubReleaseEvent struct {
+	Action     string           `json:"action"`
+	Release    GitHubRelease    `json:"release"`
+	Repository GitHubRepository `json:"repository"`
+	Sender     struct {
+		Login string `json:"login"`
+	} `json:"sender"` // Who published the release
+}
+
+// GitHubRelease represents release info in webhook
+type GitHubRelease struct {
+	TagName string `json:"tag_name"`
+	Name    string `json:"name"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+	Draft   bool   `json:"draft"`
+	Author  struct {
+		Login string `json:"login"`
+	} `json:"author"`
+}
+
+// handleRelease queues builds of a published GitHub release for the apps
+// that build releases
+func (h *WebhookHandler) handleRelease(w http.ResponseWriter, r *http.Request, body []byte, appID string) {
+	var event GitHubReleaseEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		slog.ErrorContext(r.Context(), "failed to parse webhook payload", "error", err)
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	// Drafts, edits and deletions have nothing new to build
+	if event.Action != "published" || event.Release.Draft || event.Release.TagName == "" {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"status": "ignored", "reason": "release not published"})
+		return
+	}
+	tag := event.Release.TagName
+
+	var apps []*models.App
+	ctx := r.Context()
+
+	if appID != "" {
+		app := h.webhookApp(w, r, body, appID)
+		if app == nil {
+			return
+		}
+
+		if reason := releaseMismatch(app, tag); reason != "" {
+			slog.DebugContext(ctx, reason, "app", app.Name, "tag", tag)
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(map[string]string{"status": "ignored", "reason": reason})
+			return
+		}
+
+		apps = []*models.App{app}
+	} else {
+		var err error
+		apps, err = h.findReleaseApps(ctx, event.Repository, tag)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to find matching apps", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		apps = signedApps(r, body, apps)
+	}
+
+	if len(apps) == 0 {
+		slog.DebugContext(ctx, "no matching apps found", "repo", event.Repository.FullName, "tag", tag)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"status": "ignored", "reason": "no matching apps"})
+		return
+	}
+
+	// The release doesn't say which commit its tag points at, so the build
+	// finds out when it checks the tag out
+	title := event.Release.Name
+	if title == "" {
+		title = tag
+	}
+	h.queueBuilds(w, r, apps, models.Build{
+		CommitMessage: database.NullString("Release " + title),
+		CommitAuthor:  database.NullString(event.Release.Author.Login),
+		Tag:           database.NullString(tag),
+		ReleaseName:   database.NullString(event.Release.Name),
+		ReleaseNotes:  database.NullString(event.Release.Body),
+		ReleaseURL:    database.NullString(event.Release.HTMLURL),
+		TriggeredBy:   database.NullString(event.Sender.Login),
+	})
+}
+
+// findReleaseApps returns the apps of a repository that build published
+// releases of a tag
+func (h *WebhookHandler) findReleaseApps(ctx context.Context, repo GitHubRepository, tag string) ([]*models.App, error) {
+	var apps []*models.App
+	for _, url := range []string{repo.CloneURL, repo.SSHURL} {
+		candidates, err := h.appQueries.FindByRepoWithReleases(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		for _, app := range candidates {
+			if releaseMismatch(app, tag) == "" {
+				apps = append(apps, app)
+			}
+		}
+		if len(apps) > 0 {
+			break
+		}
+	}
+	return apps, nil
+}
+
+// releaseMismatch returns why an app doesn't build a published release, or
+// an empty string if it does
+func releaseMismatch(app *models.App, tag string) string {
+	switch {
+	case !app.ReleaseEvents:
+		return "app builds pushes"
+	case !app.MatchesTag(tag):
+		return "tag mismatch"
+	}
+	return ""
+}
+
+// releaseView is the GitHub release a build deployed
+type releaseView struct {
+	Title, URL, Notes string
+}
+
+// renderRelease shows the title and notes of the GitHub release a build
+// deployed
+func (h *PageHandler) renderRelease(w http.ResponseWriter, b *models.Build) {
+	if !b.ReleaseURL.Valid && !b.ReleaseNotes.Valid {
+		return
+	}
+	title := b.ReleaseName.String
+	if title == "" {
+		title = b.GetTag()
+	}
+
+	// Unsigned webhooks could send any URL, so only web pages are linked
+	view := releaseView{Title: title, Notes: b.ReleaseNotes.String}
+	if strings.HasPrefix(b.ReleaseURL.String, "https://") {
+		view.URL = b.ReleaseURL.String
+	}
+	renderTemplate(w, "release", view)
+}
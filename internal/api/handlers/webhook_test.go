@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"database/sql"
+	"testing"
+
+	"schooner/internal/models"
+)
+
+func TestPushMismatch(t *testing.T) {
+	branchApp := &models.App{Branch: "main"}
+	tagApp := &models.App{Branch: "main", TagPattern: sql.NullString{String: "v*.*.*", Valid: true}}
+	releaseApp := &models.App{Branch: "main", ReleaseEvents: true}
+
+	tests := []struct {
+		name   string
+		app    *models.App
+		branch string
+		tag    string
+		isTag  bool
+		want   string
+	}{
+		{"branch push", branchApp, "main", "", false, ""},
+		{"other branch", branchApp, "dev", "", false, "branch mismatch"},
+		{"tag to branch app", branchApp, "", "v1.0.0", true, "app builds its branch"},
+		{"release tag", tagApp, "", "v1.0.0", true, ""},
+		{"other tag", tagApp, "", "nightly", true, "tag mismatch"},
+		{"branch push to tag app", tagApp, "main", "", false, "app builds release tags"},
+		{"branch push to release app", releaseApp, "main", "", false, "app builds published releases"},
+		{"tag push to release app", releaseApp, "", "v1.0.0", true, "app builds published releases"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pushMismatch(tt.app, tt.branch, tt.tag, tt.isTag); got != tt.want {
+				t.Errorf("pushMismatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReleaseMismatch(t *testing.T) {
+	branchApp := &models.App{Branch: "main"}
+	releaseApp := &models.App{Branch: "main", ReleaseEvents: true}
+	stableApp := &models.App{Branch: "main", ReleaseEvents: true, TagPattern: sql.NullString{String: "v*.*.*", Valid: true}}
+
+	tests := []struct {
+		name string
+		app  *models.App
+		tag  string
+		want string
+	}{
+		{"any release", releaseApp, "nightly-42", ""},
+		{"matching release", stableApp, "v1.2.0", ""},
+		{"other release", stableApp, "nightly-42", "tag mismatch"},
+		{"branch app", branchApp, "v1.2.0", "app builds pushes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := releaseMismatch(tt.app, tt.tag); got != tt.want {
+				t.Errorf("releaseMismatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"schooner/internal/logging"
+)
+
+// requestIDHeader returns the request ID to clients, to quote when
+// reporting a failure
+const requestIDHeader = "X-Request-ID"
+
+// requestLogger writes a structured log line for every request once it's
+// served, and ties the log lines written while serving it to its request ID.
+// It runs after middleware.RequestID, which reuses an X-Request-Id sent by a
+// proxy in front of Schooner.
+func requestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		requestID := middleware.GetReqID(r.Context())
+		ctx := logging.WithRequest(r.Context(), requestID)
+		w.Header().Set(requestIDHeader, requestID)
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		slog.LogAttrs(ctx, requestLogLevel(r.URL.Path, status), "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+			slog.Int("bytes", ww.BytesWritten()),
+			slog.String("remote", r.RemoteAddr),
+		)
+	})
+}
+
+// requestLogLevel logs failures louder, and health probes and static files
+// only when debugging
+func requestLogLevel(path string, status int) slog.Level {
+	switch {
+	case status >= 500:
+		return slog.LevelError
+	case status >= 400:
+		return slog.LevelWarn
+	case path == "/health" || path == "/healthz" || path == "/readyz" || strings.HasPrefix(path, "/static/"):
+		return slog.LevelDebug
+	}
+	return slog.LevelInfo
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"schooner/internal/logging"
+)
+
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(logging.NewContextHandler(slog.NewTextHandler(&buf, nil))))
+
+	handler := middleware.RequestID(requestLogger(apiVersioning(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logging.SetUser(r.Context(), "octocat")
+		slog.ErrorContext(r.Context(), "failed to get app")
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}))))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/apps/a1", nil)
+	req.Header.Set("X-Request-Id", "proxy-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(requestIDHeader); got != "proxy-123" {
+		t.Errorf("%s = %q, want the proxy's ID", requestIDHeader, got)
+	}
+	var body apiError
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Error.RequestID != "proxy-123" {
+		t.Errorf("error request_id = %q, want proxy-123", body.Error.RequestID)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines, want the handler's and the request's: %s", len(lines), buf.String())
+	}
+	for _, want := range []string{"request_id=proxy-123", "user=octocat"} {
+		if !strings.Contains(lines[0], want) || !strings.Contains(lines[1], want) {
+			t.Errorf("log lines %q missing %s", lines, want)
+		}
+	}
+	for _, want := range []string{"level=ERROR", "msg=request", "method=GET", "path=/api/v1/apps/a1", "status=500", "duration_ms="} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("request log line %q missing %s", lines[1], want)
+		}
+	}
+}
+
+func TestRequestLogLevel(t *testing.T) {
+	tests := []struct {
+		path   string
+		status int
+		want   slog.Level
+	}{
+		{path: "/api/apps", status: 200, want: slog.LevelInfo},
+		{path: "/api/apps", status: 404, want: slog.LevelWarn},
+		{path: "/api/apps", status: 502, want: slog.LevelError},
+		{path: "/healthz", status: 200, want: slog.LevelDebug},
+		{path: "/readyz", status: 503, want: slog.LevelError},
+		{path: "/static/js/app.js", status: 200, want: slog.LevelDebug},
+	}
+
+	for _, tt := range tests {
+		if got := requestLogLevel(tt.path, tt.status); got != tt.want {
+			t.Errorf("requestLogLevel(%q, %d) = %v, want %v", tt.path, tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+
+	"schooner/internal/agent"
+	"schooner/internal/build"
+	"schooner/internal/database/queries"
+	"schooner/internal/docker"
+	"schooner/internal/metrics"
+)
+
+// registerRuntimeMetrics registers gauges that are computed at scrape time
+func registerRuntimeMetrics(orchestrator *build.Orchestrator, dockerClient *docker.Client, dockerHosts *docker.Hosts, agents *agent.Hub, appQueries *queries.AppQueries) {
+	metrics.Default.Register(metrics.NewGaugeFunc(
+		"schooner_build_queue_depth",
+		"Number of builds waiting in the queue.",
+		nil,
+		func(ctx context.Context) []metrics.Sample {
+			if orchestrator == nil {
+				return []metrics.Sample{{Value: 0}}
+			}
+			return []metrics.Sample{{Value: float64(orchestrator.QueueDepth())}}
+		},
+	))
+
+	metrics.Default.Register(metrics.NewGaugeFunc(
+		"schooner_build_workers_busy",
+		"Number of build workers running a build.",
+		nil,
+		func(ctx context.Context) []metrics.Sample {
+			if orchestrator == nil {
+				return []metrics.Sample{{Value: 0}}
+			}
+			return []metrics.Sample{{Value: float64(orchestrator.BusyWorkers())}}
+		},
+	))
+
+	if dockerClient == nil {
+		return
+	}
+
+	metrics.Default.Register(metrics.NewGaugeFunc(
+		"schooner_app_container_up",
+		"Whether the app's container is running (1) or not (0).",
+		[]string{"app"},
+		func(ctx context.Context) []metrics.Sample {
+			apps, err := appQueries.ListEnabled(ctx)
+			if err != nil {
+				slog.Warn("failed to list apps for metrics", "error", err)
+				return nil
+			}
+
+			samples := make([]metrics.Sample, 0, len(apps))
+			for _, app := range apps {
+				up := 0.0
+				if app.GetAgent() != "" {
+					if status, err := agents.ContainerStatus(app.GetAgent(), app.GetContainerName()); err == nil && status.State == "running" {
+						up = 1
+					}
+					samples = append(samples, metrics.Sample{Labels: []string{app.Name}, Value: up})
+					continue
+				}
+				appClient, err := dockerHosts.ForApp(ctx, app)
+				if err != nil {
+					samples = append(samples, metrics.Sample{Labels: []string{app.Name}, Value: up})
+					continue
+				}
+				if status, err := appClient.GetContainerStatus(ctx, app.GetContainerName()); err == nil && status.State == "running" {
+					up = 1
+				}
+				samples = append(samples, metrics.Sample{Labels: []string{app.Name}, Value: up})
+			}
+			return samples
+		},
+	))
+}
@@ -2,25 +2,48 @@ package api
 
 import (
 	"context"
+	"io/fs"
 	"log/slog"
 	"net/http"
+	"path/filepath"
+	"slices"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
+	"schooner/internal/addons"
+	"schooner/internal/adopt"
+	"schooner/internal/agent"
+	"schooner/internal/alerting"
 	"schooner/internal/api/handlers"
 	"schooner/internal/auth"
+	"schooner/internal/backup"
 	"schooner/internal/build"
 	"schooner/internal/build/strategies"
 	"schooner/internal/cloudflare"
 	"schooner/internal/config"
 	"schooner/internal/database"
 	"schooner/internal/database/queries"
+	"schooner/internal/deploy"
 	"schooner/internal/docker"
+	"schooner/internal/events"
 	"schooner/internal/git"
 	"schooner/internal/github"
+	"schooner/internal/health"
+	"schooner/internal/maintenance"
+	"schooner/internal/metrics"
+	"schooner/internal/notify"
 	"schooner/internal/observability"
+	"schooner/internal/proxy"
+	"schooner/internal/reconcile"
+	"schooner/internal/refresh"
+	"schooner/internal/selfupdate"
+	"schooner/internal/templates"
+	"schooner/internal/uptime"
+	"schooner/internal/usage"
+	"schooner/internal/version"
+	"schooner/ui"
 )
 
 // NewRouter creates and configures the HTTP router
@@ -30,23 +53,50 @@ func NewRouter(cfg *config.Config, db *database.DB) *chi.Mux {
 	// Middleware stack
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)
+	r.Use(requestLogger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(60 * time.Second))
 	r.Use(middleware.Compress(5))
-	r.Use(securityHeaders)
+	r.Use(detectContentType)
+	r.Use(forceHTTPS(cfg.Server))
+	r.Use(securityHeaders(cfg.Server))
+	if cfg.Metrics.Enabled {
+		r.Use(metrics.Middleware)
+	}
 
 	// Initialize queries
 	appQueries := queries.NewAppQueries(db.DB)
 	buildQueries := queries.NewBuildQueries(db.DB)
 	logQueries := queries.NewLogQueries(db.DB)
+	stepQueries := queries.NewBuildStepQueries(db.DB)
 	settingsQueries := queries.NewSettingsQueries(db.DB)
+	alertQueries := queries.NewAlertQueries(db.DB)
+	uptimeQueries := queries.NewUptimeQueries(db.DB)
+	containerHealthQueries := queries.NewContainerHealthQueries(db.DB)
+	configRevisionQueries := queries.NewConfigRevisionQueries(db.DB)
+	dependencyQueries := queries.NewDependencyQueries(db.DB)
+	portQueries := queries.NewPortQueries(db.DB)
+	resourceQueries := queries.NewResourceQueries(db.DB)
+	systemHealthQueries := queries.NewSystemHealthQueries(db.DB)
+	apiTokenQueries := queries.NewAPITokenQueries(db.DB)
+	sessionQueries := queries.NewSessionQueries(db.DB)
+	dnsRecordQueries := queries.NewDNSRecordQueries(db.DB)
+	addonQueries := queries.NewAddonQueries(db.DB)
+	backupQueries := queries.NewBackupQueries(db.DB)
+	dockerHostQueries := queries.NewDockerHostQueries(db.DB)
+	agentQueries := queries.NewAgentQueries(db.DB)
+	maintenanceQueries := queries.NewMaintenanceQueries(db.DB)
+	activityQueries := queries.NewActivityQueries(db.DB)
 
 	// Initialize session store (24 hour TTL)
 	sessionStore := auth.NewSessionStore(24 * time.Hour)
+	if err := sessionStore.SetPersister(context.Background(), sessionQueries); err != nil {
+		slog.Warn("failed to load persisted sessions", "error", err)
+	}
 
 	// Initialize auth middleware
 	authMiddleware := auth.NewMiddleware(sessionStore, "/oauth/github/login")
+	authMiddleware.SetTokenStore(apiTokenQueries)
 
 	// Initialize GitHub client and load token from settings if available
 	githubClient := github.NewClient("")
@@ -61,6 +111,13 @@ func NewRouter(cfg *config.Config, db *database.DB) *chi.Mux {
 		slog.Warn("failed to create Docker client, container management disabled", "error", err)
 	}
 
+	// Remote Docker hosts apps can deploy to, next to the local daemon. SSH
+	// hosts are forwarded through sockets beside the repos.
+	dockerHosts := docker.NewHosts(dockerClient, dockerHostQueries, filepath.Join(filepath.Dir(cfg.Git.WorkDir), "docker-hosts"))
+
+	// Servers running schooner-agent, which poll for tasks and report back
+	agentHub := agent.NewHub()
+
 	// Initialize Git client
 	var gitOpts []git.ClientOption
 	if cfg.Git.SSHKeyPath != "" {
@@ -76,28 +133,88 @@ func NewRouter(cfg *config.Config, db *database.DB) *chi.Mux {
 		slog.Warn("failed to create Git client", "error", err)
 	}
 
-	// Cancel any stale builds from previous run
+	// Cancel any stale builds from previous run. This assumes no other
+	// instance shares the database, as its running builds would fail too.
 	if cancelled, err := buildQueries.CancelStaleBuilds(context.Background()); err != nil {
 		slog.Error("failed to cancel stale builds", "error", err)
 	} else if cancelled > 0 {
 		slog.Info("cancelled stale builds from previous run", "count", cancelled)
 	}
 
+	// Initialize notification dispatcher
+	notifier := notify.NewDispatcher(settingsQueries, cfg.BaseURL())
+
+	// Changes to apps, builds and containers, streamed to the pages showing
+	// them, and the activity feed on the dashboard
+	eventBus := events.NewBus()
+	eventBus.SetActivityStore(activityQueries)
+
+	// Initialize add-on manager (managed Postgres, MySQL and Redis)
+	var addonManager *addons.Manager
+	if dockerClient != nil {
+		addonManager = addons.NewManager(dockerClient, addonQueries)
+	}
+
+	// Initialize adopter for containers started outside Schooner
+	var adopter *adopt.Adopter
+	if dockerClient != nil {
+		adopter = adopt.NewAdopter(dockerClient)
+	}
+
+	// Load the one-click app template catalog
+	templateCatalog, err := templates.Load()
+	if err != nil {
+		slog.Error("failed to load app templates", "error", err)
+	}
+
 	// Initialize build orchestrator
 	var orchestrator *build.Orchestrator
 	if gitClient != nil && dockerClient != nil {
 		orchestrator = build.NewOrchestrator(gitClient, dockerClient, appQueries, buildQueries, logQueries)
+		orchestrator.SetNotifier(notifier)
+		orchestrator.SetEvents(eventBus)
+		orchestrator.SetStepQueries(stepQueries)
+		orchestrator.SetAddonProvider(addonManager)
+		orchestrator.SetAddonFinder(addonQueries)
+		orchestrator.SetDockerHosts(dockerHosts)
+		orchestrator.SetAgents(agentHub)
+		orchestrator.SetDeploySchedules(settingsQueries)
+		orchestrator.SetConfigRevisions(configRevisionQueries)
+		orchestrator.SetArtifactsDir(cfg.Docker.ArtifactsDir)
+		if templateCatalog != nil {
+			orchestrator.SetTemplateRenderer(templateCatalog)
+		}
 		orchestrator.RegisterStrategy(strategies.NewDockerfileStrategy(dockerClient))
+		orchestrator.RegisterStrategy(strategies.NewKanikoStrategy(dockerClient, settingsQueries))
 		orchestrator.RegisterStrategy(strategies.NewComposeStrategy(dockerClient))
-		orchestrator.Start(2) // 2 concurrent build workers
+
+		workers := cfg.BuildWorkers()
+		if workers == 0 {
+			if workers, err = settingsQueries.GetBuildWorkers(context.Background()); err != nil {
+				slog.Warn("failed to load build workers, using the default", "error", err)
+			}
+		}
+		if workers == 0 {
+			workers = build.DefaultWorkers
+		}
+		orchestrator.Start(workers)
 	}
 
+	// Apply settings changed by reloading the config file
+	cfg.OnReload(func(changed []string) {
+		notifier.SetBaseURL(cfg.BaseURL())
+		if workers := cfg.BuildWorkers(); orchestrator != nil && workers > 0 && slices.Contains(changed, "docker.build_workers") {
+			orchestrator.SetWorkers(workers)
+		}
+	})
+
 	// Initialize Cloudflare tunnel manager
 	var tunnelManager *cloudflare.Manager
 	if dockerClient != nil {
 		tunnelManager = cloudflare.NewManager(cfg, dockerClient)
 		tunnelManager.SetSettingsQueries(settingsQueries)
 		tunnelManager.SetAppQueries(appQueries)
+		tunnelManager.SetRecordStore(dnsRecordQueries)
 
 		// Auto-start tunnel if configured
 		if tunnelManager.IsConfigured() {
@@ -109,6 +226,126 @@ func NewRouter(cfg *config.Config, db *database.DB) *chi.Mux {
 		}
 	}
 
+	// Route app hostnames through the provider selected in settings
+	proxyRouter := proxy.NewRouter(settingsQueries)
+	traefikLabeler := proxy.NewTraefikLabeler(cfg)
+	traefikLabeler.SetSettingsQueries(settingsQueries)
+	proxyRouter.Register(proxy.ProviderTraefik, traefikLabeler)
+	if orchestrator != nil {
+		orchestrator.SetRouteLabeler(proxyRouter)
+		orchestrator.SetGitHubDeployments(githubClient, proxyRouter)
+	}
+
+	var caddyManager *proxy.CaddyManager
+	if dockerClient != nil {
+		proxyRouter.Register(proxy.ProviderCloudflare, tunnelManager)
+
+		caddyManager = proxy.NewCaddyManager(cfg, dockerClient)
+		caddyManager.SetSettingsQueries(settingsQueries)
+		caddyManager.SetAppQueries(appQueries)
+		proxyRouter.Register(proxy.ProviderCaddy, caddyManager)
+
+		// Auto-start Caddy if it is the active, configured provider
+		if proxyRouter.ActiveName(context.Background()) == proxy.ProviderCaddy && caddyManager.IsConfigured() {
+			go func() {
+				if err := caddyManager.Start(context.Background()); err != nil {
+					slog.Error("failed to auto-start caddy", "error", err)
+				}
+			}()
+		}
+	}
+
+	// Watch app containers and notify when they go down
+	if dockerClient != nil {
+		containerMonitor := notify.NewContainerMonitor(notifier, dockerClient, appQueries, 30*time.Second)
+		containerMonitor.SetDockerHosts(dockerHosts)
+		containerMonitor.SetAgents(agentHub)
+		containerMonitor.SetEvents(eventBus)
+		containerMonitor.SetHealthRecorder(containerHealthQueries)
+		containerMonitor.Start(context.Background())
+	}
+
+	// After a reboot, restart apps Docker started before the apps they
+	// depend on were up
+	if dockerClient != nil {
+		bootOrder := deploy.NewBootOrder(appQueries, dependencyQueries, dockerClient)
+		bootOrder.SetDockerHosts(dockerHosts)
+		go func() {
+			if err := bootOrder.Run(context.Background()); err != nil {
+				slog.Warn("failed to check app start order", "error", err)
+			}
+		}()
+	}
+
+	// Notify when an app's containers keep dying
+	var crashLoops *notify.CrashLoopDetector
+	if dockerClient != nil {
+		crashLoops = notify.NewCrashLoopDetector(notifier, dockerClient)
+		crashLoops.SetEvents(eventBus)
+		crashLoops.Start(context.Background())
+	}
+
+	// Evaluate user-defined alert rules
+	var alertEvaluator *alerting.Evaluator
+	if dockerClient != nil {
+		alertEvaluator = alerting.NewEvaluator(alertQueries, appQueries, buildQueries, dockerClient, notifier, 30*time.Second)
+		alertEvaluator.SetDockerHosts(dockerHosts)
+		alertEvaluator.SetAgents(agentHub)
+		alertEvaluator.Start(context.Background())
+	}
+
+	// Sample container resource usage for the dashboard
+	var statsCollector *docker.StatsCollector
+	if dockerClient != nil {
+		statsCollector = docker.NewStatsCollector(dockerClient, 5*time.Second)
+		statsCollector.Start(context.Background())
+
+		// Keep a history of each app's usage for its charts
+		usageRecorder := usage.NewRecorder(statsCollector, resourceQueries, appQueries, dockerClient)
+		usageRecorder.Start(context.Background())
+	}
+
+	// Keep a history of the host's health for its charts
+	healthRecorder := health.NewRecorder(systemHealthQueries)
+	healthRecorder.Start(context.Background())
+
+	// Probe app URLs for uptime
+	prober := uptime.NewProber(uptimeQueries, appQueries, notifier)
+	prober.SetURLResolver(proxyRouter)
+	prober.Start(context.Background())
+
+	// Back up named volumes on their schedules
+	var backupManager *backup.Manager
+	if dockerClient != nil {
+		backupManager = backup.NewManager(dockerClient, backupQueries, settingsQueries)
+		backupManager.SetNotifier(notifier)
+		backupManager.Start(context.Background())
+	}
+
+	// Back up Schooner's own database on its schedule
+	systemBackupManager := backup.NewSystemManager(db, cfg.Database.Path, backupQueries, settingsQueries)
+	systemBackupManager.SetNotifier(notifier)
+	systemBackupManager.Start(context.Background())
+
+	// Prune old images, volumes and networks on schedule and under disk pressure
+	var maintenanceManager *maintenance.Manager
+	if dockerClient != nil {
+		maintenanceManager = maintenance.NewManager(dockerClient, appQueries, maintenanceQueries, settingsQueries)
+		maintenanceManager.SetDockerHosts(dockerHosts)
+		maintenanceManager.SetNotifier(notifier)
+		maintenanceManager.Start(context.Background())
+	}
+
+	// Check for Schooner releases and update it in place
+	updater := selfupdate.NewManager(cfg.Update, version.Commit, githubClient)
+	if dockerClient != nil {
+		updater.SetDocker(dockerClient)
+	}
+	if orchestrator != nil {
+		updater.SetBuilds(orchestrator)
+	}
+	updater.Start(context.Background())
+
 	// Initialize observability manager (Loki + Grafana)
 	var observabilityManager *observability.Manager
 	if dockerClient != nil {
@@ -116,28 +353,145 @@ func NewRouter(cfg *config.Config, db *database.DB) *chi.Mux {
 		observabilityManager.SetSettingsQueries(settingsQueries)
 	}
 
+	// Bring stopped containers, missing add-ons, tunnels and the proxy back
+	// in line with what should be running, at startup and periodically
+	var reconciler *reconcile.Reconciler
+	if dockerClient != nil {
+		reconciler = reconcile.NewReconciler(appQueries, buildQueries, dockerClient)
+		reconciler.SetDockerHosts(dockerHosts)
+		if orchestrator != nil {
+			reconciler.SetRedeployer(orchestrator)
+		}
+		reconciler.SetAddons(addonManager)
+		reconciler.AddService("tunnel", tunnelManager)
+		reconciler.AddService("caddy", reconcile.ServiceFunc(func(ctx context.Context) ([]string, error) {
+			if proxyRouter.ActiveName(ctx) != proxy.ProviderCaddy {
+				return nil, nil
+			}
+			return caddyManager.Reconcile(ctx)
+		}))
+		reconciler.AddService("observability", observabilityManager)
+		reconciler.Start(context.Background(), cfg.Docker.ReconcileInterval)
+	}
+
+	// Pull images that track :latest on each app's schedule, redeploying
+	// apps whose images changed
+	if dockerClient != nil && orchestrator != nil {
+		refresher := refresh.NewRefresher(appQueries, buildQueries, dockerClient, orchestrator)
+		refresher.SetDockerHosts(dockerHosts)
+		refresher.Start(context.Background())
+	}
+
 	// Initialize handlers
 	healthHandler := handlers.NewHealthHandler()
+	healthHandler.SetHistory(systemHealthQueries)
+	healthHandler.SetDatabase(db)
+	healthHandler.SetDocker(dockerClient)
+	healthHandler.SetOrchestrator(orchestrator)
+	healthHandler.SetTunnelManager(tunnelManager)
 	webhookHandler := handlers.NewWebhookHandler(cfg, appQueries, buildQueries, logQueries, orchestrator)
-	appHandler := handlers.NewAppHandler(cfg, appQueries, buildQueries, dockerClient, tunnelManager, orchestrator, githubClient)
+	webhookHandler.SetSettingsQueries(settingsQueries)
+	webhookHandler.SetEvents(eventBus)
+	appHandler := handlers.NewAppHandler(cfg, appQueries, buildQueries, dockerClient, proxyRouter, orchestrator, githubClient, addonManager)
+	appHandler.SetDockerHosts(dockerHosts, dockerHostQueries)
+	appHandler.SetAgents(agentHub, agentQueries)
+	appHandler.SetStatsCollector(statsCollector)
+	appHandler.SetEvents(eventBus)
+	appHandler.SetTunnelManager(tunnelManager)
+	appHandler.SetConfigRevisions(configRevisionQueries)
+	appHandler.SetDependencies(dependencyQueries)
+	// The range was validated when the config was loaded
+	firstPort, lastPort, _ := cfg.Docker.Ports()
+	portAllocator := handlers.NewPortAllocator(portQueries, firstPort, lastPort)
+	appHandler.SetPorts(portAllocator)
+	eventsHandler := handlers.NewEventsHandler(eventBus)
 	buildHandler := handlers.NewBuildHandler(buildQueries, logQueries)
-	pageHandler := handlers.NewPageHandler(cfg, appQueries, buildQueries, dockerClient, tunnelManager, observabilityManager)
+	buildHandler.SetOrchestrator(orchestrator)
+	buildHandler.SetStepQueries(stepQueries)
+	buildHandler.SetArtifactsDir(cfg.Docker.ArtifactsDir)
+	apiV1Handler := handlers.NewAPIv1Handler(appQueries, buildQueries, logQueries)
+	pageHandler := handlers.NewPageHandler(cfg, appQueries, buildQueries, settingsQueries, uptimeQueries, dockerClient, tunnelManager, observabilityManager)
+	pageHandler.SetDockerHosts(dockerHosts, dockerHostQueries)
+	pageHandler.SetAgents(agentHub, agentQueries)
+
+	// The dashboard reuses container statuses for a few seconds, until an
+	// app is started or stopped
+	statusCache := handlers.NewContainerStatusCache()
+	appHandler.SetStatusCache(statusCache)
+	pageHandler.SetStatusCache(statusCache)
+	pageHandler.SetOrchestrator(orchestrator)
+	pageHandler.SetUpdater(updater)
+	pageHandler.SetContainerHealth(containerHealthQueries)
+	pageHandler.SetCrashLoops(crashLoops)
+	pageHandler.SetActivity(activityQueries)
+
 	settingsHandler := handlers.NewSettingsHandler(settingsQueries, githubClient, gitClient, tunnelManager, observabilityManager)
+	settingsHandler.SetOrchestrator(orchestrator)
 	logsHandler := handlers.NewLogsHandler(observabilityManager, appQueries)
 	importHandler := handlers.NewImportHandler(cfg, githubClient, appQueries)
+	notificationHandler := handlers.NewNotificationHandler(settingsQueries, notifier)
+	alertHandler := handlers.NewAlertHandler(alertQueries, alertEvaluator)
+	uptimeHandler := handlers.NewUptimeHandler(uptimeQueries, appQueries, proxyRouter)
+	resourceHandler := handlers.NewResourceHandler(resourceQueries, appQueries)
+	addonHandler := handlers.NewAddonHandler(addonQueries, appQueries, addonManager)
+	backupHandler := handlers.NewBackupHandler(backupQueries, settingsQueries, backupManager)
+	systemBackupHandler := handlers.NewSystemBackupHandler(backupQueries, settingsQueries, systemBackupManager)
+	housekeepingHandler := handlers.NewHousekeepingHandler(maintenanceQueries, settingsQueries, maintenanceManager)
+	adoptHandler := handlers.NewAdoptHandler(adopter, appQueries, proxyRouter, orchestrator)
+	templateHandler := handlers.NewTemplateHandler(templateCatalog, appQueries, proxyRouter, orchestrator)
+	templateHandler.SetPorts(portAllocator)
+	proxyHandler := handlers.NewProxyHandler(settingsQueries, proxyRouter, caddyManager)
+	tunnelsHandler := handlers.NewTunnelsHandler(settingsQueries, tunnelManager)
+	apiTokenHandler := handlers.NewAPITokenHandler(apiTokenQueries)
+	dockerHostHandler := handlers.NewDockerHostHandler(dockerHostQueries, dockerHosts)
+	agentHandler := handlers.NewAgentHandler(agentQueries, agentHub, dockerClient)
+	twoFactorHandler := handlers.NewTwoFactorHandler(settingsQueries, sessionStore)
+	sessionHandler := handlers.NewSessionHandler(sessionStore)
+	networkHandler := handlers.NewNetworkHandler(dockerClient, appQueries)
+	statusPageHandler := handlers.NewStatusPageHandler(settingsQueries, uptimeQueries, proxyRouter)
 	oauthHandler := handlers.NewOAuthHandler(cfg, settingsQueries, githubClient, gitClient, sessionStore)
+	updateHandler := handlers.NewUpdateHandler(updater)
+	reconcileHandler := handlers.NewReconcileHandler(reconciler)
+	configHandler := handlers.NewConfigHandler(cfg)
 
-	// Static files (public)
-	fileServer := http.FileServer(http.Dir("ui/static"))
-	r.Handle("/static/*", http.StripPrefix("/static/", fileServer))
+	// Public status page (configurable path or subdomain, no auth)
+	r.Use(statusPageHandler.Middleware)
+
+	// Static files (public), built into the binary
+	static, err := fs.Sub(ui.Static, "static")
+	if err != nil {
+		panic(err)
+	}
+	staticHandler, err := staticFiles(static)
+	if err != nil {
+		panic(err)
+	}
+	r.Handle("/static/*", http.StripPrefix("/static/", staticHandler))
 
 	// Health check (public)
 	r.Get("/health", healthHandler.Check)
+	r.Get("/healthz", healthHandler.Check)
+	r.Get("/readyz", healthHandler.Ready)
+
+	// Prometheus metrics (public, optionally protected by bearer token)
+	if cfg.Metrics.Enabled {
+		registerRuntimeMetrics(orchestrator, dockerClient, dockerHosts, agentHub, appQueries)
+		r.Handle("/metrics", metrics.Handler(metrics.Default, cfg.Metrics.Token))
+	}
 
 	// Webhook endpoints (public - uses signature verification)
 	r.Post("/webhook/github", webhookHandler.HandleGitHub)
 	r.Post("/webhook/github/{appID}", webhookHandler.HandleGitHubForApp)
 
+	// Agent endpoints (authenticated with the agent's token)
+	r.Group(func(r chi.Router) {
+		r.Use(agentHandler.RequireAgent)
+		r.Post(agent.ReportPath, agentHandler.Report)
+		r.Get(agent.TasksPath, agentHandler.Tasks)
+		r.Post(agent.TasksPath+"/{taskID}", agentHandler.TaskResult)
+		r.Get(agent.ImagePath, agentHandler.Image)
+	})
+
 	// OAuth endpoints (public)
 	r.Get("/oauth/github/login", oauthHandler.Login)
 	r.Get("/oauth/github/callback", oauthHandler.Callback)
@@ -153,18 +507,44 @@ func NewRouter(cfg *config.Config, db *database.DB) *chi.Mux {
 		// UI Pages (HTML responses)
 		r.Get("/", pageHandler.Dashboard)
 		r.Get("/apps/{appID}", pageHandler.AppDetail)
+		r.Get("/builds", pageHandler.BuildHistory)
 		r.Get("/builds/{buildID}", pageHandler.BuildDetail)
 		r.Get("/settings", pageHandler.Settings)
+		r.Get("/logs", pageHandler.LogSearch)
+		r.Get("/backups", pageHandler.Backups)
+		r.Get("/templates", pageHandler.Templates)
+		r.Get("/sessions", pageHandler.Sessions)
+		r.Get("/networks", pageHandler.Networks)
+
+		// Page fragments htmx swaps in when what they show changes
+		r.Group(func(r chi.Router) {
+			// htmx polls these, mostly getting the same HTML back
+			r.Use(etags)
+			r.Get("/partials/apps", pageHandler.AppsPartial)
+			r.Get("/partials/apps/{appID}/card", pageHandler.AppCardPartial)
+			r.Get("/partials/apps/{appID}/builds", pageHandler.AppBuildsPartial)
+			r.Get("/partials/builds/recent", pageHandler.RecentBuildsPartial)
+			r.Get("/partials/builds/queue", pageHandler.BuildQueuePartial)
+			r.Get("/partials/activity", pageHandler.ActivityPartial)
+		})
+
+		// Two-factor login steps (reachable before 2FA completes, see auth.mfaAllowedPaths)
+		r.Get("/login/2fa", twoFactorHandler.VerifyPage)
+		r.Post("/login/2fa", twoFactorHandler.Verify)
+		r.Get("/login/2fa/setup", twoFactorHandler.SetupPage)
 	})
 
-	// API Routes (JSON/HTMX responses) - protected
-	r.Route("/api", func(r chi.Router) {
-		r.Use(authMiddleware.RequireAuth)
+	// API Routes (JSON/HTMX responses) - protected. The same routes are
+	// served under /api/v1, with paginated list endpoints and JSON errors.
+	apiRoutes := chi.NewRouter()
+	apiRoutes.Group(func(r chi.Router) {
 		// Apps
 		r.Route("/apps", func(r chi.Router) {
 			r.Get("/", appHandler.List)
 			r.Post("/", appHandler.Create)
 			r.Get("/statuses", appHandler.AllStatuses)
+			r.Post("/bulk/{action}", appHandler.Bulk)
+			r.Get("/dependencies", appHandler.DependencyGraph)
 			r.Get("/{appID}", appHandler.Get)
 			r.Put("/{appID}", appHandler.Update)
 			r.Delete("/{appID}", appHandler.Delete)
@@ -172,10 +552,33 @@ func NewRouter(cfg *config.Config, db *database.DB) *chi.Mux {
 			// App-specific actions
 			r.Get("/{appID}/status", appHandler.Status)
 			r.Post("/{appID}/deploy", appHandler.TriggerDeploy)
+			r.Post("/{appID}/deploy/preview", appHandler.PreviewDeploy)
+			r.Post("/{appID}/validate", appHandler.Validate)
+			r.Post("/{appID}/clone", appHandler.Clone)
+			r.Delete("/{appID}/checkout", appHandler.ClearCheckout)
+			r.Delete("/{appID}/build-cache", appHandler.ClearBuildCache)
 			r.Post("/{appID}/stop", appHandler.Stop)
 			r.Post("/{appID}/start", appHandler.Start)
 			r.Post("/{appID}/restart", appHandler.Restart)
+			r.Get("/{appID}/services", appHandler.Services)
+			r.Post("/{appID}/services/{service}/{action}", appHandler.ServiceAction)
+			r.Get("/{appID}/services/{service}/logs", appHandler.ServiceLogs)
 			r.Post("/{appID}/webhook", appHandler.ConfigureWebhook)
+			r.Get("/{appID}/config/revisions", appHandler.ConfigHistory)
+			r.Post("/{appID}/config/apply", appHandler.ApplyConfig)
+			r.Get("/{appID}/dependencies", appHandler.GetDependencies)
+			r.Put("/{appID}/dependencies", appHandler.UpdateDependencies)
+
+			// Uptime monitoring
+			r.Get("/{appID}/uptime", uptimeHandler.Get)
+			r.Put("/{appID}/uptime", uptimeHandler.Save)
+			r.Delete("/{appID}/uptime", uptimeHandler.Delete)
+			r.Get("/{appID}/metrics", resourceHandler.Metrics)
+
+			// Managed databases and caches
+			r.Get("/{appID}/addons", addonHandler.List)
+			r.Post("/{appID}/addons", addonHandler.Create)
+			r.Delete("/{appID}/addons/{addonID}", addonHandler.Delete)
 		})
 
 		// Builds
@@ -184,14 +587,21 @@ func NewRouter(cfg *config.Config, db *database.DB) *chi.Mux {
 			r.Get("/{buildID}", buildHandler.Get)
 			r.Post("/{buildID}/cancel", buildHandler.Cancel)
 			r.Post("/{buildID}/retry", buildHandler.Retry)
+			r.Post("/{buildID}/redeploy", buildHandler.Redeploy)
+			r.Post("/{buildID}/approve", buildHandler.Approve)
+			r.Post("/{buildID}/reject", buildHandler.Reject)
+			r.Get("/{buildID}/artifacts", buildHandler.ListArtifacts)
+			r.Get("/{buildID}/artifacts/{name}", buildHandler.DownloadArtifact)
 
 			// Build logs
+			r.Get("/{buildID}/steps", buildHandler.GetSteps)
 			r.Get("/{buildID}/logs", buildHandler.GetLogs)
 			r.Get("/{buildID}/logs/stream", buildHandler.StreamLogs)
 		})
 
 		// Settings
 		r.Route("/settings", func(r chi.Router) {
+			r.Use(recordSettingsChanges(eventBus))
 			r.Get("/", settingsHandler.GetAll)
 			r.Post("/github-token", settingsHandler.SetGitHubToken)
 			r.Delete("/github-token", settingsHandler.DeleteGitHubToken)
@@ -204,51 +614,215 @@ func NewRouter(cfg *config.Config, db *database.DB) *chi.Mux {
 			r.Post("/tunnel", settingsHandler.SetTunnelConfig)
 			r.Post("/tunnel/start", settingsHandler.StartTunnel)
 			r.Post("/tunnel/stop", settingsHandler.StopTunnel)
+			r.Get("/tunnels", tunnelsHandler.List)
+			r.Post("/tunnels", tunnelsHandler.Save)
+			r.Delete("/tunnels/{name}", tunnelsHandler.Delete)
+			r.Get("/proxy", proxyHandler.GetConfig)
+			r.Post("/proxy", proxyHandler.SetConfig)
+			r.Post("/proxy/caddy/start", proxyHandler.StartCaddy)
+			r.Post("/proxy/caddy/stop", proxyHandler.StopCaddy)
 
 			// Observability (Loki + Grafana)
 			r.Get("/observability-status", settingsHandler.GetObservabilityStatus)
 			r.Post("/observability", settingsHandler.SetObservabilityConfig)
 			r.Post("/observability/start", settingsHandler.StartObservability)
 			r.Post("/observability/stop", settingsHandler.StopObservability)
+
+			// Notifications
+			r.Get("/notifications", notificationHandler.GetConfig)
+			r.Post("/notifications/ntfy", notificationHandler.SetNtfyConfig)
+			r.Delete("/notifications/ntfy", notificationHandler.DeleteNtfyConfig)
+			r.Post("/notifications/test", notificationHandler.SendTest)
+
+			// Backup storage
+			r.Get("/backups", backupHandler.GetStorageConfig)
+			r.Post("/backups", backupHandler.SetStorageConfig)
+			r.Get("/system-backup", systemBackupHandler.GetConfig)
+			r.Post("/system-backup", systemBackupHandler.SetConfig)
+
+			// Docker housekeeping
+			r.Get("/housekeeping", housekeepingHandler.GetConfig)
+			r.Post("/housekeeping", housekeepingHandler.SetConfig)
+
+			// Public status page
+			r.Get("/status-page", statusPageHandler.GetConfig)
+			r.Post("/status-page", statusPageHandler.SetConfig)
+
+			// Maintenance banner
+			r.Get("/banner", settingsHandler.GetBanner)
+			r.Post("/banner", settingsHandler.SetBanner)
+			r.Delete("/banner", settingsHandler.ClearBanner)
+
+			// Auto-deploy pause
+			r.Get("/auto-deploy", settingsHandler.GetAutoDeployPause)
+			r.Post("/auto-deploy", settingsHandler.SetAutoDeployPause)
+
+			// Logins kaniko builds push images with
+			r.Get("/registries", settingsHandler.GetRegistries)
+			r.Post("/registries", settingsHandler.SetRegistry)
+			r.Delete("/registries/{registry}", settingsHandler.DeleteRegistry)
+
+			// Concurrent builds
+			r.Get("/build-workers", settingsHandler.GetBuildWorkers)
+			r.Post("/build-workers", settingsHandler.SetBuildWorkers)
+
+			// Deploy windows and freezes
+			r.Get("/deploy-schedule", settingsHandler.GetDeploySchedule)
+			r.Post("/deploy-schedule", settingsHandler.SetDeploySchedule)
+
+			// Re-read the config file
+			r.Post("/config/reload", configHandler.Reload)
+		})
+
+		// Alerts
+		r.Route("/alerts", func(r chi.Router) {
+			r.Get("/rules", alertHandler.ListRules)
+			r.Post("/rules", alertHandler.CreateRule)
+			r.Put("/rules/{ruleID}", alertHandler.UpdateRule)
+			r.Delete("/rules/{ruleID}", alertHandler.DeleteRule)
+			r.Get("/active", alertHandler.ListActive)
+		})
+
+		// One-click app templates
+		r.Get("/templates", templateHandler.List)
+		r.Post("/templates/{templateID}/deploy", templateHandler.Deploy)
+
+		// Volume backups
+		r.Route("/backups", func(r chi.Router) {
+			r.Get("/", backupHandler.List)
+			r.Get("/volumes", backupHandler.Volumes)
+			r.Get("/jobs", backupHandler.ListJobs)
+			r.Post("/jobs", backupHandler.CreateJob)
+			r.Put("/jobs/{jobID}", backupHandler.UpdateJob)
+			r.Delete("/jobs/{jobID}", backupHandler.DeleteJob)
+			r.Post("/jobs/{jobID}/run", backupHandler.RunJob)
+			r.Post("/{backupID}/restore", backupHandler.Restore)
+			r.Delete("/{backupID}", backupHandler.Delete)
+
+			// Schooner's own database and settings
+			r.Get("/system", systemBackupHandler.List)
+			r.Post("/system", systemBackupHandler.Run)
+			r.Post("/system/restore", systemBackupHandler.Upload)
+			r.Get("/system/{backupID}/download", systemBackupHandler.Download)
+			r.Post("/system/{backupID}/restore", systemBackupHandler.Restore)
+			r.Delete("/system/{backupID}", systemBackupHandler.Delete)
+		})
+
+		// Docker housekeeping reports
+		r.Get("/housekeeping", housekeepingHandler.List)
+		r.Post("/housekeeping", housekeepingHandler.Run)
+
+		// Disk used by each app
+		r.Get("/disk-usage", appHandler.DiskUsage)
+
+		// Build statistics
+		r.Get("/stats", buildHandler.Stats)
+
+		// Everything on the dashboard in one request
+		r.Get("/dashboard", pageHandler.DashboardSummary)
+
+		// What Schooner has been doing, newest first
+		r.Get("/activity", pageHandler.Activity)
+
+		// Schooner releases and self-update
+		r.Get("/update", updateHandler.Status)
+		r.Post("/update", updateHandler.Apply)
+
+		// Drift between what should be running and what is
+		r.Get("/reconcile", reconcileHandler.Last)
+		r.Post("/reconcile", reconcileHandler.Run)
+
+		// Two-factor authentication (session only, see auth.sessionOnlyPrefixes)
+		r.Route("/2fa", func(r chi.Router) {
+			r.Get("/", twoFactorHandler.Status)
+			r.Post("/setup", twoFactorHandler.Setup)
+			r.Post("/enable", twoFactorHandler.Enable)
+			r.Post("/disable", twoFactorHandler.Disable)
+			r.Post("/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+			r.Put("/required", twoFactorHandler.SetRequired)
+		})
+
+		// Dashboard sessions (session only, see auth.sessionOnlyPrefixes)
+		r.Route("/sessions", func(r chi.Router) {
+			r.Get("/", sessionHandler.List)
+			r.Post("/revoke-all", sessionHandler.RevokeAll)
+			r.Delete("/{sessionID}", sessionHandler.Revoke)
+		})
+
+		// Personal API tokens (session only, see auth.sessionOnlyPrefixes)
+		r.Route("/tokens", func(r chi.Router) {
+			r.Get("/", apiTokenHandler.List)
+			r.Post("/", apiTokenHandler.Create)
+			r.Delete("/{tokenID}", apiTokenHandler.Delete)
+		})
+
+		// Docker networks and the containers attached to them
+		r.Route("/networks", func(r chi.Router) {
+			r.Get("/", networkHandler.List)
+			r.Post("/", networkHandler.Create)
+			r.Delete("/{network}", networkHandler.Remove)
+			r.Post("/{network}/attach", networkHandler.Attach)
+			r.Post("/{network}/detach", networkHandler.Detach)
+		})
+
+		// Remote Docker hosts apps can deploy to
+		r.Route("/docker-hosts", func(r chi.Router) {
+			r.Get("/", dockerHostHandler.List)
+			r.Post("/", dockerHostHandler.Create)
+			r.Put("/{hostID}", dockerHostHandler.Update)
+			r.Delete("/{hostID}", dockerHostHandler.Delete)
+			r.Post("/{hostID}/test", dockerHostHandler.Test)
+		})
+
+		// Servers running schooner-agent (session only, see auth.sessionOnlyPrefixes)
+		r.Route("/agents", func(r chi.Router) {
+			r.Get("/", agentHandler.List)
+			r.Post("/", agentHandler.Create)
+			r.Delete("/{agentID}", agentHandler.Delete)
 		})
 
 		// Container logs (via Loki)
 		r.Route("/logs", func(r chi.Router) {
 			r.Get("/", logsHandler.ListSources)
+			r.Get("/search", logsHandler.Search)
 			r.Get("/{appID}", logsHandler.GetLogs)
 			r.Get("/{appID}/stream", logsHandler.StreamLogs)
 		})
 
 		// GitHub import
 		r.Route("/github", func(r chi.Router) {
+			r.Get("/orgs", importHandler.ListOrgs)
 			r.Get("/repos", importHandler.ListRepos)
 			r.Post("/import", importHandler.ImportRepo)
 		})
 
 		// System health
 		r.Get("/health/system", healthHandler.GetSystemHealth)
+		r.Get("/health/system/history", healthHandler.GetSystemHealthHistory)
 
 		// Container stats
 		r.Get("/containers/stats", appHandler.ContainerStats)
+		r.Get("/containers/stats/stream", appHandler.StreamContainerStats)
+		r.Get("/containers/{containerID}/adopt", adoptHandler.Preview)
+		r.Post("/containers/{containerID}/adopt", adoptHandler.Adopt)
+
+		// Live changes to apps, builds and containers
+		r.Get("/events", eventsHandler.Stream)
 	})
 
-	return r
-}
+	r.Route("/api", func(r chi.Router) {
+		r.Use(apiVersioning)
+		r.Use(authMiddleware.RequireAuth)
+		r.Use(etags)
 
-// securityHeaders adds security-related HTTP headers to all responses
-func securityHeaders(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Prevent MIME type sniffing
-		w.Header().Set("X-Content-Type-Options", "nosniff")
-		// Prevent clickjacking
-		w.Header().Set("X-Frame-Options", "DENY")
-		// Enable XSS filter in browsers
-		w.Header().Set("X-XSS-Protection", "1; mode=block")
-		// Prevent caching of sensitive data
-		w.Header().Set("Cache-Control", "no-store")
-		// Referrer policy
-		w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
-
-		next.ServeHTTP(w, r)
+		r.Route("/v1", func(r chi.Router) {
+			r.Get("/apps", apiV1Handler.ListApps)
+			r.Get("/builds", apiV1Handler.ListBuilds)
+			r.Get("/builds/{buildID}/logs", apiV1Handler.ListBuildLogs)
+			r.Mount("/", apiRoutes)
+		})
+		r.Mount("/", apiRoutes)
 	})
+
+	return r
 }
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"schooner/internal/config"
+)
+
+func TestForceHTTPS(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name     string
+		force    bool
+		method   string
+		path     string
+		proto    string
+		tls      bool
+		status   int
+		location string
+	}{
+		{name: "off", force: false, method: "GET", path: "/apps", status: 200},
+		{name: "plain HTTP", force: true, method: "GET", path: "/apps?tab=logs", status: 308, location: "https://schooner.lan/apps?tab=logs"},
+		{name: "webhook keeps method", force: true, method: "POST", path: "/webhook/github", status: 308, location: "https://schooner.lan/webhook/github"},
+		{name: "behind TLS proxy", force: true, method: "GET", path: "/apps", proto: "https", status: 200},
+		{name: "direct TLS", force: true, method: "GET", path: "/apps", tls: true, status: 200},
+		{name: "health check", force: true, method: "GET", path: "/healthz", status: 200},
+		{name: "metrics", force: true, method: "GET", path: "/metrics", status: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := forceHTTPS(config.ServerConfig{ForceHTTPS: tt.force})(ok)
+			req := httptest.NewRequest(tt.method, "http://schooner.lan"+tt.path, nil)
+			if tt.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rr.Code)
+			}
+			if got := rr.Header().Get("Location"); got != tt.location {
+				t.Errorf("expected Location %q, got %q", tt.location, got)
+			}
+		})
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name    string
+		cfg     config.ServerConfig
+		proto   string
+		hsts    bool
+		anyImgs bool // Images from any HTTPS origin, not just GitHub avatars
+	}{
+		{name: "default", cfg: config.ServerConfig{}, proto: "https", anyImgs: true},
+		{name: "forced HTTPS", cfg: config.ServerConfig{ForceHTTPS: true}, proto: "https", hsts: true, anyImgs: true},
+		{name: "no HSTS over HTTP", cfg: config.ServerConfig{ForceHTTPS: true}, anyImgs: true},
+		{name: "strict", cfg: config.ServerConfig{StrictCSP: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			rr := httptest.NewRecorder()
+			securityHeaders(tt.cfg)(ok).ServeHTTP(rr, req)
+
+			if got := rr.Header().Get("Strict-Transport-Security") != ""; got != tt.hsts {
+				t.Errorf("expected HSTS=%v, got %q", tt.hsts, rr.Header().Get("Strict-Transport-Security"))
+			}
+			if rr.Header().Get("X-Content-Type-Options") != "nosniff" {
+				t.Error("expected X-Content-Type-Options: nosniff")
+			}
+			csp := rr.Header().Get("Content-Security-Policy")
+			if !strings.Contains(csp, "frame-ancestors 'none'") {
+				t.Errorf("expected CSP to forbid framing, got %q", csp)
+			}
+			if strings.Contains(csp, "cdn") {
+				t.Errorf("expected no CDN in the CSP, got %q", csp)
+			}
+			if got := strings.Contains(csp, "img-src 'self' data: https:;"); got != tt.anyImgs {
+				t.Errorf("expected remote images allowed=%v, got %q", tt.anyImgs, csp)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"schooner/internal/logging"
+)
+
+// apiV1Prefix is the current versioned API. Unversioned /api routes remain
+// for the dashboard and older scripts but are marked deprecated.
+const apiV1Prefix = "/api/v1"
+
+// apiError is the error envelope returned by /api/v1
+type apiError struct {
+	Error apiErrorBody `json:"error"`
+}
+
+type apiErrorBody struct {
+	Status    int    `json:"status"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"` // Finds the failure's log lines
+}
+
+// apiVersioning wraps /api/v1 errors in a JSON envelope and adds deprecation
+// headers to unversioned /api responses pointing at their v1 successor
+func apiVersioning(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if path != apiV1Prefix && !strings.HasPrefix(path, apiV1Prefix+"/") {
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Link", `<`+apiV1Prefix+strings.TrimPrefix(path, "/api")+`>; rel="successor-version"`)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ew := &errorEnvelopeWriter{ResponseWriter: w, requestID: logging.RequestID(r.Context())}
+		next.ServeHTTP(ew, r)
+		ew.finish()
+	})
+}
+
+// errorEnvelopeWriter captures plain-text error responses written with
+// http.Error so they can be re-encoded as an apiError. Other responses,
+// including event streams, pass straight through.
+type errorEnvelopeWriter struct {
+	http.ResponseWriter
+	requestID string
+	status    int
+	body      bytes.Buffer
+}
+
+func (e *errorEnvelopeWriter) WriteHeader(status int) {
+	if status >= 400 && strings.HasPrefix(e.Header().Get("Content-Type"), "text/plain") {
+		e.status = status
+		return
+	}
+	e.ResponseWriter.WriteHeader(status)
+}
+
+func (e *errorEnvelopeWriter) Write(b []byte) (int, error) {
+	if e.status != 0 {
+		return e.body.Write(b)
+	}
+	return e.ResponseWriter.Write(b)
+}
+
+// Flush keeps server-sent event handlers working through the wrapper
+func (e *errorEnvelopeWriter) Flush() {
+	if flusher, ok := e.ResponseWriter.(http.Flusher); ok && e.status == 0 {
+		flusher.Flush()
+	}
+}
+
+// finish writes the captured error, if any, as an apiError
+func (e *errorEnvelopeWriter) finish() {
+	if e.status == 0 {
+		return
+	}
+
+	e.Header().Set("Content-Type", "application/json")
+	e.Header().Del("Content-Length")
+	e.ResponseWriter.WriteHeader(e.status)
+	json.NewEncoder(e.ResponseWriter).Encode(apiError{Error: apiErrorBody{
+		Status:    e.status,
+		Code:      strings.ToLower(strings.ReplaceAll(http.StatusText(e.status), " ", "_")),
+		Message:   strings.TrimSpace(e.body.String()),
+		RequestID: e.requestID,
+	}})
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIVersioning(t *testing.T) {
+	handler := apiVersioning(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			http.Error(w, "app not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		deprecated  bool
+		link        string
+		errEnvelope bool
+	}{
+		{name: "v1 success", path: "/api/v1/apps", status: 200},
+		{name: "v1 error is wrapped", path: "/api/v1/apps/x?fail=1", status: 404, errEnvelope: true},
+		{name: "unversioned is deprecated", path: "/api/apps", status: 200, deprecated: true, link: `</api/v1/apps>; rel="successor-version"`},
+		{name: "unversioned error stays plain", path: "/api/apps/x?fail=1", status: 404, deprecated: true, link: `</api/v1/apps/x>; rel="successor-version"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+
+			if rr.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rr.Code)
+			}
+			if got := rr.Header().Get("Deprecation") == "true"; got != tt.deprecated {
+				t.Errorf("expected deprecated=%v, got %v", tt.deprecated, got)
+			}
+			if got := rr.Header().Get("Link"); got != tt.link {
+				t.Errorf("expected Link %q, got %q", tt.link, got)
+			}
+
+			if !tt.errEnvelope {
+				return
+			}
+			var body apiError
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatalf("expected JSON error envelope, got %q", rr.Body.String())
+			}
+			expected := apiErrorBody{Status: 404, Code: "not_found", Message: "app not found"}
+			if body.Error != expected {
+				t.Errorf("expected %+v, got %+v", expected, body.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"schooner/internal/logging"
+	"schooner/internal/models"
+)
+
+const (
+	// APITokenKey is the context key for the API token used to authenticate
+	APITokenKey ContextKey = "api_token"
+
+	// apiTokenPrefix marks Schooner tokens so they are easy to spot in leaks
+	apiTokenPrefix = "sch_"
+
+	// apiTokenDisplayLen is how much of a token is kept for identification
+	apiTokenDisplayLen = 12
+
+	// lastUsedResolution limits how often last-used times are written
+	lastUsedResolution = time.Minute
+)
+
+// sessionOnlyPrefixes are API routes that cannot be called with a token, so a
+// leaked token cannot mint others or agent tokens, turn off two-factor auth,
+// revoke sessions or download system backups, which hold every secret
+var sessionOnlyPrefixes = []string{"/api/tokens", "/api/agents", "/api/2fa", "/api/sessions", "/api/backups/system"}
+
+// APITokenStore looks up API tokens
+type APITokenStore interface {
+	GetByHash(ctx context.Context, hash string) (*models.APIToken, error)
+	TouchLastUsed(ctx context.Context, id string, at time.Time) error
+}
+
+// GenerateAPIToken creates a new random token, returning the plaintext, the
+// prefix shown in listings and the hash to store
+func GenerateAPIToken() (token, prefix, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	token = apiTokenPrefix + hex.EncodeToString(b)
+	return token, token[:apiTokenDisplayLen], HashAPIToken(token), nil
+}
+
+// HashAPIToken returns the stored hash of a plaintext token. Tokens are
+// high-entropy, so a fast hash is sufficient.
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetAPIToken retrieves the API token from context, or nil for session auth
+func GetAPIToken(ctx context.Context) *models.APIToken {
+	token, ok := ctx.Value(APITokenKey).(*models.APIToken)
+	if !ok {
+		return nil
+	}
+	return token
+}
+
+// Requester names who made a request: the signed-in user, or the API token
+// used. It's empty for work Schooner started itself.
+func Requester(ctx context.Context) string {
+	if session := GetSession(ctx); session != nil {
+		return session.Username
+	}
+	if token := GetAPIToken(ctx); token != nil {
+		return "API token " + token.Name
+	}
+	return ""
+}
+
+// unversionedAPIPath maps /api/v1/... to the equivalent /api/... route, so
+// scope and session-only checks apply to both
+func unversionedAPIPath(path string) string {
+	if rest, ok := strings.CutPrefix(path, "/api/v1/"); ok {
+		return "/api/" + rest
+	}
+	return path
+}
+
+// bearerToken extracts the token from an Authorization: Bearer header
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(header[7:])
+}
+
+// authenticateToken validates a bearer token for an API request and, if it
+// is allowed, serves the request with the token in context
+func (m *Middleware) authenticateToken(w http.ResponseWriter, r *http.Request, next http.Handler, plaintext string) {
+	ctx := r.Context()
+	path := unversionedAPIPath(r.URL.Path)
+
+	for _, prefix := range sessionOnlyPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			http.Error(w, "this endpoint requires a browser session", http.StatusForbidden)
+			return
+		}
+	}
+
+	token, err := m.tokens.GetByHash(ctx, HashAPIToken(plaintext))
+	if err != nil {
+		slog.Error("failed to look up API token", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	if token == nil || token.IsExpired(now) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if scope := models.RequiredScope(r.Method, path); !token.HasScope(scope) {
+		http.Error(w, "token lacks the "+string(scope)+" scope", http.StatusForbidden)
+		return
+	}
+
+	if !token.LastUsedAt.Valid || now.Sub(token.LastUsedAt.Time) >= lastUsedResolution {
+		if err := m.tokens.TouchLastUsed(ctx, token.ID, now); err != nil {
+			slog.Warn("failed to record API token use", "token_id", token.ID, "error", err)
+		}
+	}
+
+	logging.SetUser(ctx, "token:"+token.Name)
+	next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, APITokenKey, token)))
+}
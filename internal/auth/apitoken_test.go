@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"schooner/internal/models"
+)
+
+type fakeTokenStore struct {
+	tokens  map[string]*models.APIToken
+	touched int
+}
+
+func (f *fakeTokenStore) GetByHash(ctx context.Context, hash string) (*models.APIToken, error) {
+	return f.tokens[hash], nil
+}
+
+func (f *fakeTokenStore) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	f.touched++
+	return nil
+}
+
+func TestRequireAuth_APIToken(t *testing.T) {
+	readToken, _, readHash, err := GenerateAPIToken()
+	if err != nil {
+		t.Fatalf("GenerateAPIToken() error = %v", err)
+	}
+	expiredToken, _, expiredHash, _ := GenerateAPIToken()
+	deployToken, _, deployHash, _ := GenerateAPIToken()
+
+	store := &fakeTokenStore{tokens: map[string]*models.APIToken{
+		readHash:    {ID: "t1", Name: "read", Scopes: "read"},
+		deployHash:  {ID: "t3", Name: "deploy", Scopes: "deploy"},
+		expiredHash: {ID: "t2", Name: "old", ExpiresAt: sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true}},
+	}}
+
+	m := NewMiddleware(NewSessionStore(time.Hour), "/login")
+	m.SetTokenStore(store)
+
+	handler := m.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if GetAPIToken(r.Context()) == nil {
+			t.Error("expected API token in context")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		token    string
+		expected int
+	}{
+		{name: "read allowed", method: http.MethodGet, path: "/api/apps", token: readToken, expected: http.StatusOK},
+		{name: "deploy needs scope", method: http.MethodPost, path: "/api/apps/a1/deploy", token: readToken, expected: http.StatusForbidden},
+		{name: "unknown token", method: http.MethodGet, path: "/api/apps", token: "sch_nope", expected: http.StatusUnauthorized},
+		{name: "expired token", method: http.MethodGet, path: "/api/apps", token: expiredToken, expected: http.StatusUnauthorized},
+		{name: "token management is session only", method: http.MethodGet, path: "/api/tokens", token: readToken, expected: http.StatusForbidden},
+		{name: "versioned token management is session only", method: http.MethodGet, path: "/api/v1/tokens", token: readToken, expected: http.StatusForbidden},
+		{name: "system backup download is session only", method: http.MethodGet, path: "/api/backups/system/b1/download", token: readToken, expected: http.StatusForbidden},
+		{name: "agent management is session only", method: http.MethodPost, path: "/api/agents", token: readToken, expected: http.StatusForbidden},
+		{name: "versioned deploy allowed", method: http.MethodPost, path: "/api/v1/apps/a1/deploy", token: deployToken, expected: http.StatusOK},
+		{name: "pages ignore tokens", method: http.MethodGet, path: "/settings", token: readToken, expected: http.StatusTemporaryRedirect},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expected {
+				t.Errorf("expected status %d, got %d", tt.expected, rr.Code)
+			}
+		})
+	}
+
+	if store.touched == 0 {
+		t.Error("expected last used time to be recorded")
+	}
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"schooner/internal/models"
+)
+
+func TestRequireAuth_CSRF(t *testing.T) {
+	store := NewSessionStore(time.Hour)
+	m := NewMiddleware(store, "/login")
+	handler := m.RequireAuth(okHandler())
+
+	session, sessionToken, err := store.Create("owner", "", httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	apiToken, _, apiHash, _ := GenerateAPIToken()
+	m.SetTokenStore(&fakeTokenStore{tokens: map[string]*models.APIToken{
+		apiHash: {ID: "t1", Name: "ci"},
+	}})
+
+	tests := []struct {
+		name     string
+		method   string
+		header   string
+		form     string
+		bearer   string
+		expected int
+	}{
+		{name: "get needs no token", method: http.MethodGet, expected: http.StatusOK},
+		{name: "post without token", method: http.MethodPost, expected: http.StatusForbidden},
+		{name: "post with wrong token", method: http.MethodPost, header: "nope", expected: http.StatusForbidden},
+		{name: "post with header token", method: http.MethodPost, header: session.CSRFToken, expected: http.StatusOK},
+		{name: "delete with header token", method: http.MethodDelete, header: session.CSRFToken, expected: http.StatusOK},
+		{name: "post with form token", method: http.MethodPost, form: session.CSRFToken, expected: http.StatusOK},
+		{name: "bearer token is exempt", method: http.MethodPost, bearer: apiToken, expected: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req *http.Request
+			if tt.form != "" {
+				body := url.Values{CSRFFormField: {tt.form}}.Encode()
+				req = httptest.NewRequest(tt.method, "/api/apps", strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			} else {
+				req = httptest.NewRequest(tt.method, "/api/apps", nil)
+			}
+			if tt.header != "" {
+				req.Header.Set(CSRFHeader, tt.header)
+			}
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			} else {
+				req.AddCookie(&http.Cookie{Name: CookieName, Value: sessionToken})
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expected {
+				t.Errorf("expected status %d, got %d", tt.expected, rr.Code)
+			}
+		})
+	}
+}
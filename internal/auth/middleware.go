@@ -3,6 +3,9 @@ package auth
 import (
 	"context"
 	"net/http"
+	"strings"
+
+	"schooner/internal/logging"
 )
 
 // ContextKey is a custom type for context keys
@@ -15,9 +18,16 @@ const (
 	CookieName = "schooner_session"
 )
 
+// mfaAllowedPaths are the routes a session may use before finishing 2FA
+var mfaAllowedPaths = map[MFAState][]string{
+	MFAVerify: {"/login/2fa"},
+	MFASetup:  {"/login/2fa/setup", "/api/2fa/setup", "/api/2fa/enable"},
+}
+
 // Middleware provides authentication middleware
 type Middleware struct {
 	store        *SessionStore
+	tokens       APITokenStore
 	loginURL     string
 	publicPaths  map[string]bool
 	publicPrefix []string
@@ -30,6 +40,8 @@ func NewMiddleware(store *SessionStore, loginURL string) *Middleware {
 		loginURL: loginURL,
 		publicPaths: map[string]bool{
 			"/health":                true,
+			"/healthz":               true,
+			"/readyz":                true,
 			"/logout":                true,
 			"/oauth/github/login":    true,
 			"/oauth/github/callback": true,
@@ -42,6 +54,11 @@ func NewMiddleware(store *SessionStore, loginURL string) *Middleware {
 	}
 }
 
+// SetTokenStore enables Authorization: Bearer authentication for API routes
+func (m *Middleware) SetTokenStore(tokens APITokenStore) {
+	m.tokens = tokens
+}
+
 // RequireAuth returns middleware that requires authentication
 func (m *Middleware) RequireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -51,6 +68,14 @@ func (m *Middleware) RequireAuth(next http.Handler) http.Handler {
 			return
 		}
 
+		// API requests may authenticate with a personal token instead
+		if m.tokens != nil && strings.HasPrefix(r.URL.Path, "/api/") {
+			if token := bearerToken(r); token != "" {
+				m.authenticateToken(w, r, next, token)
+				return
+			}
+		}
+
 		// Get session from cookie
 		cookie, err := r.Cookie(CookieName)
 		if err != nil {
@@ -74,10 +99,23 @@ func (m *Middleware) RequireAuth(next http.Handler) http.Handler {
 			return
 		}
 
+		// Hold back sessions that still need to complete two-factor auth
+		if mfa := m.store.GetMFAState(session.ID); mfa != MFAComplete && !isMFAAllowedPath(mfa, r.URL.Path) {
+			m.redirectToMFA(w, r, mfa)
+			return
+		}
+
+		// State-changing requests must prove they came from our own pages
+		if !validCSRF(r, session) {
+			http.Error(w, "invalid CSRF token", http.StatusForbidden)
+			return
+		}
+
 		// Refresh session on each request
 		m.store.Refresh(session.ID)
 
 		// Add session to context
+		logging.SetUser(r.Context(), session.Username)
 		ctx := context.WithValue(r.Context(), SessionKey, session)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -112,6 +150,27 @@ func (m *Middleware) redirectToLogin(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, m.loginURL, http.StatusTemporaryRedirect)
 }
 
+// isMFAAllowedPath checks if a path is usable before 2FA is complete
+func isMFAAllowedPath(state MFAState, path string) bool {
+	for _, allowed := range mfaAllowedPaths[state] {
+		if path == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// redirectToMFA sends a session to the page that completes its 2FA step
+func (m *Middleware) redirectToMFA(w http.ResponseWriter, r *http.Request, state MFAState) {
+	if isAPIRequest(r) {
+		http.Error(w, "two-factor authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	target := mfaAllowedPaths[state][0]
+	http.Redirect(w, r, target, http.StatusTemporaryRedirect)
+}
+
 // isAPIRequest checks if the request is an API request
 func isAPIRequest(r *http.Request) bool {
 	// Check if path starts with /api/
@@ -134,10 +193,10 @@ func GetSession(ctx context.Context) *Session {
 }
 
 // SetSessionCookie sets the session cookie
-func SetSessionCookie(w http.ResponseWriter, sessionID string, maxAge int, secure bool) {
+func SetSessionCookie(w http.ResponseWriter, token string, maxAge int, secure bool) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     CookieName,
-		Value:    sessionID,
+		Value:    token,
 		Path:     "/",
 		MaxAge:   maxAge,
 		HttpOnly: true,
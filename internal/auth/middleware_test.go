@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func serve(handler http.Handler, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.AddCookie(&http.Cookie{Name: CookieName, Value: token})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestRequireAuth_MFA(t *testing.T) {
+	store := NewSessionStore(time.Hour)
+	m := NewMiddleware(store, "/login")
+	handler := m.RequireAuth(okHandler())
+
+	session, token, err := store.Create("owner", "", httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		state    MFAState
+		path     string
+		expected int
+	}{
+		{name: "complete session", state: MFAComplete, path: "/settings", expected: 200},
+		{name: "verify redirects pages", state: MFAVerify, path: "/settings", expected: 307},
+		{name: "verify blocks api", state: MFAVerify, path: "/api/apps", expected: 401},
+		{name: "verify page allowed", state: MFAVerify, path: "/login/2fa", expected: 200},
+		{name: "setup api allowed", state: MFASetup, path: "/api/2fa/enable", expected: 200},
+		{name: "setup blocks other api", state: MFASetup, path: "/api/2fa/disable", expected: 401},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store.SetMFAState(session.ID, tt.state)
+			rr := serve(handler, "GET", tt.path, token)
+			if rr.Code != tt.expected {
+				t.Errorf("expected status %d, got %d", tt.expected, rr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRSRemainder(t *testing.T) {
+	// "HELLO WORLD" as version 1-M, from the worked example of the spec
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	if got := rsRemainder(data, rsDivisor(10)); !bytes.Equal(got, want) {
+		t.Errorf("rsRemainder() = %v, want %v", got, want)
+	}
+}
+
+func TestQRFormatAndVersionBits(t *testing.T) {
+	tests := []struct {
+		name string
+		got  int
+		want int
+	}{
+		{"format M mask 0", qrFormatBits(0), 0b101010000010010},
+		{"format M mask 5", qrFormatBits(5), 0b100000011001110},
+		{"version 7", qrVersionBits(7), 0x07C94},
+		{"version 10", qrVersionBits(10), 0x0A4D3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %015b, want %015b", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+// readQR undoes the mask named by the format bits and reads the codewords
+// back in drawing order
+func readQR(t *testing.T, qr *qrCode) []byte {
+	t.Helper()
+	format := 0
+	for i := 0; i <= 5; i++ {
+		if qr.modules[i][8] {
+			format |= 1 << i
+		}
+	}
+	for i, xy := range [][2]int{{8, 7}, {8, 8}, {7, 8}} {
+		if qr.modules[xy[1]][xy[0]] {
+			format |= 1 << (6 + i)
+		}
+	}
+	for i := 9; i < 15; i++ {
+		if qr.modules[8][14-i] {
+			format |= 1 << i
+		}
+	}
+	mask := -1
+	for m := range qrMasks {
+		if qrFormatBits(m) == format {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("format bits %015b match no mask", format)
+	}
+
+	var out []byte
+	var b byte
+	n := 0
+	for right := qr.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < qr.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = qr.size - 1 - vert
+				}
+				if qr.function[y][x] {
+					continue
+				}
+				b <<= 1
+				if qr.modules[y][x] != qrMasks[mask](x, y) {
+					b |= 1
+				}
+				if n++; n%8 == 0 {
+					out = append(out, b)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func TestEncodeQR(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		version int
+	}{
+		{"short", "schooner", 1},
+		{"otpauth", TOTPURL("Schooner", "owner", strings.Repeat("A", 32)), 6},
+		{"two block groups", strings.Repeat("x", 150), 8},
+		{"largest", strings.Repeat("x", 213), 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qr, err := encodeQR([]byte(tt.text))
+			if err != nil {
+				t.Fatalf("encodeQR() error = %v", err)
+			}
+			if want := tt.version*4 + 17; qr.size != want {
+				t.Fatalf("size = %d, want %d", qr.size, want)
+			}
+
+			v := qrVersions[tt.version-1]
+			capacity := 0
+			for _, n := range v.blocks {
+				capacity += n
+			}
+			countBits := 8
+			if tt.version >= 10 {
+				countBits = 16
+			}
+			want := qrInterleave(qrDataCodewords([]byte(tt.text), countBits, capacity), v)
+			if got := readQR(t, qr); !bytes.HasPrefix(got, want) {
+				t.Errorf("codewords read back don't match those encoded")
+			}
+		})
+	}
+}
+
+func TestQRCodeSVG(t *testing.T) {
+	svg, err := QRCodeSVG(TOTPURL("Schooner", "owner", "JBSWY3DPEHPK3PXP"), 180)
+	if err != nil {
+		t.Fatalf("QRCodeSVG() error = %v", err)
+	}
+	if !strings.HasPrefix(svg, "<svg") || !strings.Contains(svg, `width="180"`) {
+		t.Errorf("QRCodeSVG() = %.60s..., want an SVG 180 pixels wide", svg)
+	}
+
+	if _, err := QRCodeSVG(strings.Repeat("x", 300), 180); err == nil {
+		t.Error("QRCodeSVG() of 300 bytes succeeded, want an error")
+	}
+}
@@ -1,27 +1,64 @@
 package auth
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
 	"sync"
 	"time"
 )
 
+// lastSeenResolution limits how often session activity is written to storage
+const lastSeenResolution = time.Minute
+
+// MFAState tracks whether a session still has to complete two-factor auth
+type MFAState int
+
+const (
+	// MFAComplete sessions have full access
+	MFAComplete MFAState = iota
+	// MFAVerify sessions must enter a TOTP or recovery code
+	MFAVerify
+	// MFASetup sessions must enroll in 2FA because it is required
+	MFASetup
+)
+
 // Session represents a user session
 type Session struct {
-	ID        string
-	Username  string
-	AvatarURL string
-	Token     string // GitHub access token
-	CreatedAt time.Time
-	ExpiresAt time.Time
+	ID         string    `db:"id" json:"id"` // SHA-256 of the cookie token
+	Username   string    `db:"username" json:"username"`
+	AvatarURL  string    `db:"avatar_url" json:"avatar_url"`
+	UserAgent  string    `db:"user_agent" json:"user_agent"`
+	IP         string    `db:"ip" json:"ip"`
+	MFA        MFAState  `db:"mfa_state" json:"-"`
+	CSRFToken  string    `db:"csrf_token" json:"-"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	LastSeenAt time.Time `db:"last_seen_at" json:"last_seen_at"`
+	ExpiresAt  time.Time `db:"expires_at" json:"expires_at"`
+
+	persistedAt time.Time // when LastSeenAt was last written to storage
 }
 
-// SessionStore manages user sessions
+// SessionPersister stores sessions so they survive restarts
+type SessionPersister interface {
+	SaveSession(ctx context.Context, session *Session) error
+	ListSessions(ctx context.Context) ([]*Session, error)
+	DeleteSession(ctx context.Context, id string) error
+}
+
+// SessionStore manages user sessions in memory, writing through to an
+// optional persister
 type SessionStore struct {
-	sessions map[string]*Session
-	mu       sync.RWMutex
-	ttl      time.Duration
+	sessions  map[string]*Session
+	mu        sync.RWMutex
+	ttl       time.Duration
+	persister SessionPersister
 }
 
 // NewSessionStore creates a new session store
@@ -37,31 +74,81 @@ func NewSessionStore(ttl time.Duration) *SessionStore {
 	return store
 }
 
-// Create creates a new session
-func (s *SessionStore) Create(username, avatarURL, token string) (*Session, error) {
-	id, err := generateSessionID()
+// SetPersister enables persisted sessions and loads the unexpired ones
+func (s *SessionStore) SetPersister(ctx context.Context, persister SessionPersister) error {
+	sessions, err := persister.ListSessions(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var upgraded []*Session
+	s.mu.Lock()
+	s.persister = persister
+	for _, session := range sessions {
+		if now.After(session.ExpiresAt) {
+			continue
+		}
+		if session.CSRFToken == "" {
+			// Stored before CSRF tokens existed
+			if session.CSRFToken, err = generateSessionToken(); err != nil {
+				s.mu.Unlock()
+				return err
+			}
+			upgraded = append(upgraded, session)
+		}
+		session.persistedAt = session.LastSeenAt
+		s.sessions[session.ID] = session
+	}
+	s.mu.Unlock()
+
+	// Keep the new CSRF tokens so pages open before a restart still work
+	for _, session := range upgraded {
+		s.persist(session)
+	}
+
+	return nil
+}
+
+// Create creates a new session for the request, returning the session and
+// the token to store in the cookie
+func (s *SessionStore) Create(username, avatarURL string, r *http.Request) (*Session, string, error) {
+	token, err := generateSessionToken()
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	csrfToken, err := generateSessionToken()
+	if err != nil {
+		return nil, "", err
 	}
 
+	now := time.Now()
 	session := &Session{
-		ID:        id,
-		Username:  username,
-		AvatarURL: avatarURL,
-		Token:     token,
-		CreatedAt: time.Now(),
-		ExpiresAt: time.Now().Add(s.ttl),
+		ID:          hashSessionToken(token),
+		CSRFToken:   csrfToken,
+		Username:    username,
+		AvatarURL:   avatarURL,
+		UserAgent:   r.UserAgent(),
+		IP:          clientIP(r),
+		CreatedAt:   now,
+		LastSeenAt:  now,
+		ExpiresAt:   now.Add(s.ttl),
+		persistedAt: now,
 	}
 
 	s.mu.Lock()
-	s.sessions[id] = session
+	s.sessions[session.ID] = session
 	s.mu.Unlock()
 
-	return session, nil
+	s.persist(session)
+
+	return session, token, nil
 }
 
-// Get retrieves a session by ID
-func (s *SessionStore) Get(id string) *Session {
+// Get retrieves a session by its cookie token
+func (s *SessionStore) Get(token string) *Session {
+	id := hashSessionToken(token)
+
 	s.mu.RLock()
 	session, ok := s.sessions[id]
 	s.mu.RUnlock()
@@ -79,20 +166,122 @@ func (s *SessionStore) Get(id string) *Session {
 	return session
 }
 
-// Delete removes a session
+// List returns copies of all unexpired sessions, most recently active first
+func (s *SessionStore) List() []Session {
+	now := time.Now()
+
+	s.mu.RLock()
+	sessions := make([]Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		if now.Before(session.ExpiresAt) {
+			sessions = append(sessions, *session)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions
+}
+
+// Delete removes a session by ID
 func (s *SessionStore) Delete(id string) {
 	s.mu.Lock()
 	delete(s.sessions, id)
 	s.mu.Unlock()
+
+	if s.persister != nil {
+		if err := s.persister.DeleteSession(context.Background(), id); err != nil {
+			slog.Error("failed to delete persisted session", "error", err)
+		}
+	}
+}
+
+// DeleteByToken removes the session a cookie token belongs to
+func (s *SessionStore) DeleteByToken(token string) {
+	s.Delete(hashSessionToken(token))
 }
 
-// Refresh extends the session expiry
+// DeleteAllExcept removes every session other than keepID, returning how
+// many were removed
+func (s *SessionStore) DeleteAllExcept(keepID string) int {
+	s.mu.RLock()
+	var ids []string
+	for id := range s.sessions {
+		if id != keepID {
+			ids = append(ids, id)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, id := range ids {
+		s.Delete(id)
+	}
+	return len(ids)
+}
+
+// Refresh extends the session expiry and records activity
 func (s *SessionStore) Refresh(id string) {
+	now := time.Now()
+
 	s.mu.Lock()
-	if session, ok := s.sessions[id]; ok {
-		session.ExpiresAt = time.Now().Add(s.ttl)
+	session, ok := s.sessions[id]
+	var persist bool
+	if ok {
+		session.ExpiresAt = now.Add(s.ttl)
+		session.LastSeenAt = now
+		persist = now.Sub(session.persistedAt) >= lastSeenResolution
+		if persist {
+			session.persistedAt = now
+		}
 	}
 	s.mu.Unlock()
+
+	if persist {
+		s.persist(session)
+	}
+}
+
+// SetMFAState updates a session's two-factor state
+func (s *SessionStore) SetMFAState(id string, state MFAState) {
+	s.mu.Lock()
+	session, ok := s.sessions[id]
+	if ok {
+		session.MFA = state
+	}
+	s.mu.Unlock()
+
+	if ok {
+		s.persist(session)
+	}
+}
+
+// GetMFAState returns a session's two-factor state. A session that is gone
+// counts as still needing verification.
+func (s *SessionStore) GetMFAState(id string) MFAState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if session, ok := s.sessions[id]; ok {
+		return session.MFA
+	}
+	return MFAVerify
+}
+
+// persist writes a session to storage, if configured
+func (s *SessionStore) persist(session *Session) {
+	if s.persister == nil {
+		return
+	}
+
+	s.mu.RLock()
+	snapshot := *session
+	s.mu.RUnlock()
+
+	if err := s.persister.SaveSession(context.Background(), &snapshot); err != nil {
+		slog.Error("failed to persist session", "error", err)
+	}
 }
 
 // cleanup periodically removes expired sessions
@@ -101,22 +290,44 @@ func (s *SessionStore) cleanup() {
 	defer ticker.Stop()
 
 	for range ticker.C {
+		var expired []string
+
 		s.mu.Lock()
 		now := time.Now()
 		for id, session := range s.sessions {
 			if now.After(session.ExpiresAt) {
 				delete(s.sessions, id)
+				expired = append(expired, id)
 			}
 		}
 		s.mu.Unlock()
+
+		for _, id := range expired {
+			s.Delete(id)
+		}
 	}
 }
 
-// generateSessionID creates a cryptographically secure session ID
-func generateSessionID() (string, error) {
+// generateSessionToken creates a cryptographically secure session token
+func generateSessionToken() (string, error) {
 	b := make([]byte, 32)
 	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
 	return base64.URLEncoding.EncodeToString(b), nil
 }
+
+// hashSessionToken derives the session ID from its cookie token, so stored
+// and displayed IDs can't be replayed as cookies
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// clientIP returns the request's client address without the port
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// memoryPersister is a SessionPersister backed by a map
+type memoryPersister struct {
+	sessions map[string]Session
+}
+
+func newMemoryPersister() *memoryPersister {
+	return &memoryPersister{sessions: make(map[string]Session)}
+}
+
+func (p *memoryPersister) SaveSession(ctx context.Context, session *Session) error {
+	p.sessions[session.ID] = *session
+	return nil
+}
+
+func (p *memoryPersister) ListSessions(ctx context.Context) ([]*Session, error) {
+	var sessions []*Session
+	for _, session := range p.sessions {
+		session := session
+		sessions = append(sessions, &session)
+	}
+	return sessions, nil
+}
+
+func (p *memoryPersister) DeleteSession(ctx context.Context, id string) error {
+	delete(p.sessions, id)
+	return nil
+}
+
+func TestSessionStore_CreateAndGet(t *testing.T) {
+	store := NewSessionStore(time.Hour)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("User-Agent", "test-browser")
+	req.RemoteAddr = "192.0.2.10:54321"
+
+	session, token, err := store.Create("owner", "", req)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if session.ID == token {
+		t.Error("session ID must not equal the cookie token")
+	}
+	if session.UserAgent != "test-browser" {
+		t.Errorf("expected user agent test-browser, got %q", session.UserAgent)
+	}
+	if session.IP != "192.0.2.10" {
+		t.Errorf("expected IP 192.0.2.10, got %q", session.IP)
+	}
+
+	if got := store.Get(token); got == nil || got.ID != session.ID {
+		t.Error("expected Get(token) to return the session")
+	}
+	if got := store.Get(session.ID); got != nil {
+		t.Error("expected the session ID not to work as a token")
+	}
+}
+
+func TestSessionStore_Persistence(t *testing.T) {
+	persister := newMemoryPersister()
+
+	store := NewSessionStore(time.Hour)
+	if err := store.SetPersister(context.Background(), persister); err != nil {
+		t.Fatalf("SetPersister() error = %v", err)
+	}
+
+	session, token, err := store.Create("owner", "", httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	store.SetMFAState(session.ID, MFAVerify)
+
+	persister.sessions["expired"] = Session{ID: "expired", ExpiresAt: time.Now().Add(-time.Minute)}
+
+	// A new store, as after a restart, picks up the unexpired session
+	restarted := NewSessionStore(time.Hour)
+	if err := restarted.SetPersister(context.Background(), persister); err != nil {
+		t.Fatalf("SetPersister() error = %v", err)
+	}
+
+	got := restarted.Get(token)
+	if got == nil {
+		t.Fatal("expected session to survive a restart")
+	}
+	if got.MFA != MFAVerify {
+		t.Errorf("expected MFA state %d, got %d", MFAVerify, got.MFA)
+	}
+	if n := len(restarted.List()); n != 1 {
+		t.Errorf("expected 1 session after reload, got %d", n)
+	}
+
+	restarted.DeleteByToken(token)
+	if _, ok := persister.sessions[session.ID]; ok {
+		t.Error("expected delete to remove the persisted session")
+	}
+}
+
+func TestSessionStore_LegacyCSRF(t *testing.T) {
+	persister := newMemoryPersister()
+	persister.sessions["legacy"] = Session{ID: "legacy", Username: "owner", ExpiresAt: time.Now().Add(time.Hour)}
+
+	store := NewSessionStore(time.Hour)
+	if err := store.SetPersister(context.Background(), persister); err != nil {
+		t.Fatalf("SetPersister() error = %v", err)
+	}
+
+	saved := persister.sessions["legacy"].CSRFToken
+	if saved == "" {
+		t.Fatal("expected the new CSRF token to be persisted")
+	}
+	if got := store.List()[0].CSRFToken; got != saved {
+		t.Errorf("persisted CSRF token %q, session has %q", saved, got)
+	}
+}
+
+func TestSessionStore_DeleteAllExcept(t *testing.T) {
+	persister := newMemoryPersister()
+	store := NewSessionStore(time.Hour)
+	if err := store.SetPersister(context.Background(), persister); err != nil {
+		t.Fatalf("SetPersister() error = %v", err)
+	}
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		session, _, err := store.Create("owner", "", httptest.NewRequest("GET", "/", nil))
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		ids = append(ids, session.ID)
+	}
+
+	if n := store.DeleteAllExcept(ids[0]); n != 2 {
+		t.Errorf("expected 2 sessions removed, got %d", n)
+	}
+
+	sessions := store.List()
+	if len(sessions) != 1 || sessions[0].ID != ids[0] {
+		t.Errorf("expected only %s to remain, got %v", ids[0], sessions)
+	}
+	if len(persister.sessions) != 1 {
+		t.Errorf("expected 1 persisted session, got %d", len(persister.sessions))
+	}
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 seed from RFC 6238 appendix B
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestValidateTOTP_RFCVectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if _, ok := ValidateTOTP(rfcSecret, tt.code, time.Unix(tt.unix, 0), 0); !ok {
+				t.Errorf("ValidateTOTP(%s at %d) = false, want true", tt.code, tt.unix)
+			}
+		})
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	counter := now.Unix() / 30
+
+	tests := []struct {
+		name        string
+		code        string
+		at          time.Time
+		lastCounter int64
+		want        bool
+	}{
+		{name: "current", code: "005924", at: now, want: true},
+		{name: "spaces ignored", code: "005 924", at: now, want: true},
+		{name: "previous step within skew", code: "005924", at: now.Add(30 * time.Second), want: true},
+		{name: "outside skew", code: "005924", at: now.Add(90 * time.Second), want: false},
+		{name: "replayed", code: "005924", at: now, lastCounter: counter, want: false},
+		{name: "wrong code", code: "123456", at: now, want: false},
+		{name: "wrong length", code: "05924", at: now, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(rfcSecret, tt.code, tt.at, tt.lastCounter); ok != tt.want {
+				t.Errorf("ValidateTOTP() = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+	if len(codes) != recoveryCodeCount || len(hashes) != recoveryCodeCount {
+		t.Fatalf("expected %d codes, got %d", recoveryCodeCount, len(codes))
+	}
+
+	remaining, ok := UseRecoveryCode(hashes, codes[3])
+	if !ok {
+		t.Fatal("expected recovery code to be accepted")
+	}
+	if len(remaining) != recoveryCodeCount-1 {
+		t.Errorf("expected %d codes remaining, got %d", recoveryCodeCount-1, len(remaining))
+	}
+
+	if _, ok := UseRecoveryCode(remaining, codes[3]); ok {
+		t.Error("expected used recovery code to be rejected")
+	}
+	if _, ok := UseRecoveryCode(remaining, codes[4]); !ok {
+		t.Error("expected unused recovery code to be accepted")
+	}
+}
@@ -0,0 +1,104 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Files in a system backup
+const (
+	dumpManifest = "manifest.json"
+	dumpDatabase = "schooner.db"
+	dumpKey      = "encryption.key"
+)
+
+// dumpFormat is the version of the system backup layout
+const dumpFormat = 1
+
+// sqliteHeader starts every SQLite database file
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+// Manifest describes a system backup
+type Manifest struct {
+	Format    int       `json:"format"`
+	CreatedAt time.Time `json:"created_at"`
+	Commit    string    `json:"commit"`
+}
+
+// writeDump returns a gzipped tar of the named files
+func writeDump(files map[string][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	// Manifest first so it can be read without unpacking the rest
+	for _, name := range []string{dumpManifest, dumpDatabase, dumpKey} {
+		data := files[name]
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0600,
+			Size:    int64(len(data)),
+			ModTime: time.Now(),
+		}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readDump unpacks and checks a dump written by writeDump
+func readDump(dump []byte) (map[string][]byte, *Manifest, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(dump))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid backup: %w", err)
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid backup: %w", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid backup: %w", err)
+		}
+		files[header.Name] = data
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(files[dumpManifest], &manifest); err != nil {
+		return nil, nil, fmt.Errorf("invalid backup manifest: %w", err)
+	}
+	if manifest.Format != dumpFormat {
+		return nil, nil, fmt.Errorf("unsupported backup format %d", manifest.Format)
+	}
+	if !bytes.HasPrefix(files[dumpDatabase], sqliteHeader) {
+		return nil, nil, fmt.Errorf("backup does not contain a database")
+	}
+	if len(files[dumpKey]) == 0 {
+		return nil, nil, fmt.Errorf("backup does not contain an encryption key")
+	}
+	return files, &manifest, nil
+}
@@ -0,0 +1,442 @@
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/google/uuid"
+
+	"schooner/internal/docker"
+	"schooner/internal/models"
+	"schooner/internal/notify"
+)
+
+const (
+	// helperImage runs the containers that mount volumes for backup and
+	// restore
+	helperImage = "alpine:3.20"
+
+	// volumeMount is where helper containers mount the volume. Archives hold
+	// its contents under "volume/".
+	volumeMount = "/volume"
+
+	defaultDir = "./data/backups"
+)
+
+// Settings keys for backup storage
+const (
+	SettingDir         = "backup_dir"
+	SettingS3Endpoint  = "backup_s3_endpoint"
+	SettingS3Region    = "backup_s3_region"
+	SettingS3Bucket    = "backup_s3_bucket"
+	SettingS3Prefix    = "backup_s3_prefix"
+	SettingS3AccessKey = "backup_s3_access_key"
+	SettingS3SecretKey = "backup_s3_secret_key"
+)
+
+// SettingsStore interface for reading and writing settings in the database
+type SettingsStore interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key, value string) error
+}
+
+// BackupStore interface for backup jobs and archive records
+type BackupStore interface {
+	ListJobs(ctx context.Context) ([]*models.BackupJob, error)
+	RecordJobRun(ctx context.Context, id string, ranAt time.Time, runErr string) error
+	CreateBackup(ctx context.Context, backup *models.Backup) error
+	FinishBackup(ctx context.Context, backup *models.Backup) error
+	ListJobBackups(ctx context.Context, jobID, volume string) ([]*models.Backup, error)
+	DeleteBackup(ctx context.Context, id string) error
+	FailRunningBackups(ctx context.Context) (int64, error)
+}
+
+// Manager snapshots named volumes into archives and restores them
+type Manager struct {
+	dockerClient *docker.Client
+	backups      BackupStore
+	settings     SettingsStore
+	dispatcher   *notify.Dispatcher
+	logger       *slog.Logger
+
+	mu      sync.Mutex
+	running map[string]bool // Job IDs being run
+	volumes map[string]bool // Volumes being backed up or restored
+}
+
+// NewManager creates a new backup manager
+func NewManager(dockerClient *docker.Client, backups BackupStore, settings SettingsStore) *Manager {
+	return &Manager{
+		dockerClient: dockerClient,
+		backups:      backups,
+		settings:     settings,
+		logger:       slog.Default(),
+		running:      make(map[string]bool),
+		volumes:      make(map[string]bool),
+	}
+}
+
+// SetNotifier sets the dispatcher used for backup failure notifications
+func (m *Manager) SetNotifier(dispatcher *notify.Dispatcher) {
+	m.dispatcher = dispatcher
+}
+
+// Dir returns the directory local backups are kept in
+func (m *Manager) Dir(ctx context.Context) string {
+	return localDir(ctx, m.settings)
+}
+
+// localDir returns the directory local backups are kept in
+func localDir(ctx context.Context, settings SettingsStore) string {
+	if dir, err := settings.Get(ctx, SettingDir); err == nil && dir != "" {
+		return dir
+	}
+	return defaultDir
+}
+
+// s3Settings maps the S3 settings keys to their config fields
+func s3Settings(cfg *S3Config) map[string]*string {
+	return map[string]*string{
+		SettingS3Endpoint:  &cfg.Endpoint,
+		SettingS3Region:    &cfg.Region,
+		SettingS3Bucket:    &cfg.Bucket,
+		SettingS3Prefix:    &cfg.Prefix,
+		SettingS3AccessKey: &cfg.AccessKey,
+		SettingS3SecretKey: &cfg.SecretKey,
+	}
+}
+
+// LoadS3Config reads the S3 target from settings
+func LoadS3Config(ctx context.Context, settings SettingsStore) (S3Config, error) {
+	var cfg S3Config
+	for key, dest := range s3Settings(&cfg) {
+		value, err := settings.Get(ctx, key)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to load %s: %w", key, err)
+		}
+		*dest = value
+	}
+	return cfg, nil
+}
+
+// SaveS3Config writes the S3 target to settings
+func SaveS3Config(ctx context.Context, settings SettingsStore, cfg S3Config) error {
+	for key, value := range s3Settings(&cfg) {
+		if err := settings.Set(ctx, key, strings.TrimSpace(*value)); err != nil {
+			return fmt.Errorf("failed to save %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Volumes returns the names of the volumes that can be backed up
+func (m *Manager) Volumes(ctx context.Context) ([]string, error) {
+	return m.dockerClient.ListVolumes(ctx)
+}
+
+// Store returns the store for a backup target
+func (m *Manager) Store(ctx context.Context, target models.BackupTarget) (Store, error) {
+	return OpenStore(ctx, m.settings, target)
+}
+
+// OpenStore returns the store for a backup target, as configured in settings
+func OpenStore(ctx context.Context, settings SettingsStore, target models.BackupTarget) (Store, error) {
+	if target != models.BackupTargetS3 {
+		return NewLocalStore(localDir(ctx, settings)), nil
+	}
+
+	cfg, err := LoadS3Config(ctx, settings)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.IsConfigured() {
+		return nil, fmt.Errorf("S3 backup storage is not configured")
+	}
+	return NewS3Store(cfg), nil
+}
+
+// RunJob backs up each of a job's volumes, then prunes backups beyond its
+// retention. It fails if the job is already running.
+func (m *Manager) RunJob(ctx context.Context, job *models.BackupJob) error {
+	m.mu.Lock()
+	if m.running[job.ID] {
+		m.mu.Unlock()
+		return fmt.Errorf("backup job %q is already running", job.Name)
+	}
+	m.running[job.ID] = true
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.running, job.ID)
+		m.mu.Unlock()
+	}()
+
+	startedAt := time.Now()
+	var errs []error
+	for _, volume := range job.VolumeList() {
+		if _, err := m.BackupVolume(ctx, job, volume); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", volume, err))
+			continue
+		}
+		m.prune(ctx, job, volume)
+	}
+
+	runErr := errors.Join(errs...)
+	errText := ""
+	if runErr != nil {
+		errText = runErr.Error()
+		m.notifyFailure(ctx, fmt.Sprintf("Backup %s failed", job.Name), errText)
+	}
+	if err := m.backups.RecordJobRun(ctx, job.ID, startedAt, errText); err != nil {
+		m.logger.Error("failed to record backup run", "job", job.Name, "error", err)
+	}
+	return runErr
+}
+
+// BackupVolume archives one volume into the job's target
+func (m *Manager) BackupVolume(ctx context.Context, job *models.BackupJob, volume string) (*models.Backup, error) {
+	if err := m.lockVolume(volume); err != nil {
+		return nil, err
+	}
+	defer m.unlockVolume(volume)
+
+	now := time.Now()
+	backup := &models.Backup{
+		ID:        uuid.New().String(),
+		JobID:     sql.NullString{String: job.ID, Valid: true},
+		Volume:    volume,
+		Target:    job.Target,
+		Key:       fmt.Sprintf("%s/%s.tar.gz", volume, now.UTC().Format("20060102T150405Z")),
+		Status:    models.BackupRunning,
+		CreatedAt: now,
+	}
+	if err := m.backups.CreateBackup(ctx, backup); err != nil {
+		return nil, err
+	}
+
+	size, err := m.archive(ctx, backup)
+	backup.SizeBytes = size
+	backup.FinishedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	backup.Status = models.BackupSuccess
+	if err != nil {
+		backup.Status = models.BackupFailed
+		backup.Error = sql.NullString{String: err.Error(), Valid: true}
+	}
+	if ferr := m.backups.FinishBackup(ctx, backup); ferr != nil {
+		m.logger.Error("failed to record backup", "volume", volume, "error", ferr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m.logger.Info("volume backed up", "volume", volume, "target", backup.Target, "key", backup.Key, "size", size)
+	return backup, nil
+}
+
+// archive writes a gzipped tar of a volume to a staging file and moves it
+// into the backup's store
+func (m *Manager) archive(ctx context.Context, backup *models.Backup) (int64, error) {
+	store, err := m.Store(ctx, backup.Target)
+	if err != nil {
+		return 0, err
+	}
+
+	stagingDir := filepath.Join(m.Dir(ctx), ".staging")
+	if err := os.MkdirAll(stagingDir, 0700); err != nil {
+		return 0, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	staging := filepath.Join(stagingDir, backup.ID+".tar.gz")
+	defer os.Remove(staging)
+
+	size, err := m.writeArchive(ctx, backup.Volume, staging)
+	if err != nil {
+		return 0, err
+	}
+	if err := store.Put(ctx, backup.Key, staging); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// writeArchive copies a volume's contents out of a helper container that
+// mounts it read-only, compressing them into path
+func (m *Manager) writeArchive(ctx context.Context, volume, path string) (int64, error) {
+	id, err := m.dockerClient.CreateContainer(ctx, helperConfig(volume, true, nil))
+	if err != nil {
+		return 0, err
+	}
+	defer m.dockerClient.RemoveContainer(context.Background(), id)
+
+	archive, err := m.dockerClient.CopyFromContainer(ctx, id, volumeMount)
+	if err != nil {
+		return 0, err
+	}
+	defer archive.Close()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	if _, err := io.Copy(gz, archive); err != nil {
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), f.Close()
+}
+
+// Restore replaces a volume's contents with a backup. Running containers
+// using the volume are stopped for the restore and started again after.
+func (m *Manager) Restore(ctx context.Context, backup *models.Backup) error {
+	if err := m.restore(ctx, backup); err != nil {
+		m.notifyFailure(ctx, fmt.Sprintf("Restore of %s failed", backup.Volume), err.Error())
+		return err
+	}
+	return nil
+}
+
+func (m *Manager) restore(ctx context.Context, backup *models.Backup) error {
+	if backup.Status != models.BackupSuccess {
+		return fmt.Errorf("only successful backups can be restored")
+	}
+	if err := m.lockVolume(backup.Volume); err != nil {
+		return err
+	}
+	defer m.unlockVolume(backup.Volume)
+
+	store, err := m.Store(ctx, backup.Target)
+	if err != nil {
+		return err
+	}
+	archive, err := store.Get(ctx, backup.Key)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	gz, err := gzip.NewReader(archive)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+
+	users, err := m.dockerClient.ContainersUsingVolume(ctx, backup.Volume)
+	if err != nil {
+		return err
+	}
+	for _, id := range users {
+		if err := m.dockerClient.StopContainer(ctx, id, 30*time.Second); err != nil {
+			return fmt.Errorf("failed to stop container %s: %w", id[:12], err)
+		}
+	}
+	defer func() {
+		for _, id := range users {
+			if err := m.dockerClient.StartContainer(context.Background(), id); err != nil {
+				m.logger.Error("failed to restart container after restore", "container", id[:12], "error", err)
+			}
+		}
+	}()
+
+	// Empty the volume so files created after the backup don't survive it
+	id, err := m.dockerClient.CreateAndStartContainer(ctx, helperConfig(backup.Volume, false,
+		[]string{"find", volumeMount, "-mindepth", "1", "-delete"}))
+	if err != nil {
+		return err
+	}
+	defer m.dockerClient.RemoveContainer(context.Background(), id)
+
+	if err := m.dockerClient.WaitForContainer(ctx, id, container.WaitConditionNotRunning); err != nil {
+		return fmt.Errorf("failed to clear volume: %w", err)
+	}
+	if err := m.dockerClient.CopyToContainer(ctx, id, "/", gz); err != nil {
+		return err
+	}
+
+	m.logger.Info("volume restored", "volume", backup.Volume, "backup", backup.ID, "created_at", backup.CreatedAt)
+	return nil
+}
+
+// Delete removes a backup's archive and its record
+func (m *Manager) Delete(ctx context.Context, backup *models.Backup) error {
+	if backup.Status == models.BackupSuccess {
+		store, err := m.Store(ctx, backup.Target)
+		if err != nil {
+			return err
+		}
+		if err := store.Delete(ctx, backup.Key); err != nil {
+			return err
+		}
+	}
+	return m.backups.DeleteBackup(ctx, backup.ID)
+}
+
+// lockVolume claims a volume so it isn't backed up and restored at once
+func (m *Manager) lockVolume(volume string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.volumes[volume] {
+		return fmt.Errorf("volume %s is already being backed up or restored", volume)
+	}
+	m.volumes[volume] = true
+	return nil
+}
+
+func (m *Manager) unlockVolume(volume string) {
+	m.mu.Lock()
+	delete(m.volumes, volume)
+	m.mu.Unlock()
+}
+
+// helperConfig returns a helper container that mounts a volume
+func helperConfig(volume string, readOnly bool, cmd []string) docker.ContainerConfig {
+	mount := volumeMount
+	if readOnly {
+		mount += ":ro"
+	}
+	return docker.ContainerConfig{
+		Name:  "schooner-backup-" + uuid.New().String()[:8],
+		Image: helperImage,
+		Cmd:   cmd,
+		Labels: map[string]string{
+			"schooner.managed": "true",
+			"schooner.service": "backup",
+		},
+		Volumes: map[string]string{
+			volume: mount,
+		},
+	}
+}
+
+// notifyFailure sends a backup failure notification
+func (m *Manager) notifyFailure(ctx context.Context, title, message string) {
+	if m.dispatcher == nil {
+		return
+	}
+	m.dispatcher.Notify(ctx, notify.Event{
+		Type:     notify.EventBackupFailed,
+		Title:    title,
+		Message:  message,
+		URL:      m.dispatcher.BaseURL() + "/backups",
+		Priority: notify.PriorityHigh,
+	})
+}
@@ -0,0 +1,59 @@
+package backup
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"schooner/internal/models"
+)
+
+func TestIsDue(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		job     *models.BackupJob
+		now     time.Time
+		wantDue bool
+	}{
+		{"manual", &models.BackupJob{CreatedAt: created}, created.Add(48 * time.Hour), false},
+		{"before first run", &models.BackupJob{Schedule: "0 3 * * *", CreatedAt: created}, created.Add(time.Hour), false},
+		{"first run", &models.BackupJob{Schedule: "0 3 * * *", CreatedAt: created}, created.Add(15 * time.Hour), true},
+		{
+			"already ran",
+			&models.BackupJob{Schedule: "0 3 * * *", CreatedAt: created, LastRunAt: sql.NullTime{Time: created.Add(15 * time.Hour), Valid: true}},
+			created.Add(16 * time.Hour),
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isDue(tt.job, tt.now); got != tt.wantDue {
+				t.Errorf("isDue() = %v, want %v", got, tt.wantDue)
+			}
+		})
+	}
+}
+
+func TestExpired(t *testing.T) {
+	backups := []*models.Backup{{ID: "3"}, {ID: "2"}, {ID: "1"}}
+
+	if got := expired(backups, 5); len(got) != 0 {
+		t.Errorf("expired(5) = %d backups, want 0", len(got))
+	}
+	if got := expired(backups, 2); len(got) != 1 || got[0].ID != "1" {
+		t.Errorf("expired(2) should return the oldest backup, got %v", got)
+	}
+}
+
+func TestHelperConfig(t *testing.T) {
+	cfg := helperConfig("app-data", true, nil)
+	if cfg.Volumes["app-data"] != "/volume:ro" {
+		t.Errorf("Volumes = %v", cfg.Volumes)
+	}
+	if cfg.Image != helperImage || cfg.Labels["schooner.service"] != "backup" {
+		t.Errorf("unexpected helper config %+v", cfg)
+	}
+}
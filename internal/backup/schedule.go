@@ -0,0 +1,91 @@
+package backup
+
+import (
+	"context"
+	"time"
+
+	"schooner/internal/models"
+)
+
+// tick is how often the scheduler looks for jobs that are due
+const tick = time.Minute
+
+// Start runs scheduled jobs until the context is cancelled
+func (m *Manager) Start(ctx context.Context) {
+	if n, err := m.backups.FailRunningBackups(ctx); err != nil {
+		m.logger.Error("failed to clean up interrupted backups", "error", err)
+	} else if n > 0 {
+		m.logger.Warn("marked interrupted backups as failed", "count", n)
+	}
+
+	go func() {
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				m.runDue(ctx, now)
+			}
+		}
+	}()
+}
+
+// runDue starts every enabled job whose schedule has come round since it
+// last ran
+func (m *Manager) runDue(ctx context.Context, now time.Time) {
+	jobs, err := m.backups.ListJobs(ctx)
+	if err != nil {
+		m.logger.Error("failed to list backup jobs", "error", err)
+		return
+	}
+
+	for _, job := range jobs {
+		if !job.Enabled || !isDue(job, now) {
+			continue
+		}
+		go func(job *models.BackupJob) {
+			if err := m.RunJob(ctx, job); err != nil {
+				m.logger.Error("scheduled backup failed", "job", job.Name, "error", err)
+			}
+		}(job)
+	}
+}
+
+// isDue reports whether a scheduled job should run at now
+func isDue(job *models.BackupJob, now time.Time) bool {
+	last := job.CreatedAt
+	if job.LastRunAt.Valid {
+		last = job.LastRunAt.Time
+	}
+	next := job.NextRun(last)
+	return !next.IsZero() && !next.After(now)
+}
+
+// prune deletes a job's oldest backups of a volume beyond its retention
+func (m *Manager) prune(ctx context.Context, job *models.BackupJob, volume string) {
+	if job.KeepLast <= 0 {
+		return
+	}
+
+	backups, err := m.backups.ListJobBackups(ctx, job.ID, volume)
+	if err != nil {
+		m.logger.Error("failed to list backups for retention", "job", job.Name, "error", err)
+		return
+	}
+	for _, old := range expired(backups, job.KeepLast) {
+		if err := m.Delete(ctx, old); err != nil {
+			m.logger.Warn("failed to prune backup", "volume", volume, "key", old.Key, "error", err)
+		}
+	}
+}
+
+// expired returns the backups beyond the newest keep, given newest first
+func expired(backups []*models.Backup, keep int) []*models.Backup {
+	if len(backups) <= keep {
+		return nil
+	}
+	return backups[keep:]
+}
@@ -0,0 +1,48 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := NewLocalStore(filepath.Join(dir, "backups"))
+
+	staging := filepath.Join(dir, "staging.tar.gz")
+	if err := os.WriteFile(staging, []byte("archive"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	key := "app-data/20240501T030000Z.tar.gz"
+	if err := store.Put(ctx, key, staging); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if _, err := os.Stat(staging); !os.IsNotExist(err) {
+		t.Error("Put() should move the staging file")
+	}
+
+	r, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "archive" {
+		t.Errorf("Get() = %q", data)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("Delete() of a missing archive error = %v", err)
+	}
+	if _, err := store.Get(ctx, key); err == nil {
+		t.Error("Get() of a deleted archive should fail")
+	}
+}
@@ -0,0 +1,428 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+
+	"schooner/internal/crypto"
+	"schooner/internal/models"
+	"schooner/internal/notify"
+	"schooner/internal/version"
+)
+
+// Settings keys for system backups
+const (
+	SettingSystemSchedule   = "system_backup_schedule"
+	SettingSystemKeepLast   = "system_backup_keep_last"
+	SettingSystemTarget     = "system_backup_target"
+	SettingSystemPassphrase = "system_backup_passphrase"
+)
+
+// pendingSuffix marks files staged by a restore, swapped in on next start
+const pendingSuffix = ".restore"
+
+// Snapshotter writes a consistent copy of the database to a file
+type Snapshotter interface {
+	Snapshot(ctx context.Context, path string) error
+}
+
+// SystemBackupStore interface for system backup records
+type SystemBackupStore interface {
+	CreateSystemBackup(ctx context.Context, backup *models.SystemBackup) error
+	FinishSystemBackup(ctx context.Context, backup *models.SystemBackup) error
+	ListSystemBackups(ctx context.Context) ([]*models.SystemBackup, error)
+	DeleteSystemBackup(ctx context.Context, id string) error
+	FailRunningBackups(ctx context.Context) (int64, error)
+}
+
+// SystemConfig holds the schedule and destination of system backups
+type SystemConfig struct {
+	Schedule   string              `json:"schedule"` // Cron expression, empty for manual runs only
+	KeepLast   int                 `json:"keep_last"`
+	Target     models.BackupTarget `json:"target"`
+	Passphrase string              `json:"-"`
+}
+
+// Validate checks the schedule, retention and target
+func (c SystemConfig) Validate() error {
+	if c.Schedule != "" {
+		if _, err := cron.ParseStandard(c.Schedule); err != nil {
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+	}
+	if c.KeepLast < 0 {
+		return fmt.Errorf("keep_last must not be negative")
+	}
+	if c.Target != models.BackupTargetLocal && c.Target != models.BackupTargetS3 {
+		return fmt.Errorf("unknown backup target %q", c.Target)
+	}
+	return nil
+}
+
+// LoadSystemConfig reads the system backup config from settings
+func LoadSystemConfig(ctx context.Context, settings SettingsStore) (SystemConfig, error) {
+	cfg := SystemConfig{KeepLast: 7, Target: models.BackupTargetLocal}
+
+	values := make(map[string]string)
+	for _, key := range []string{SettingSystemSchedule, SettingSystemKeepLast, SettingSystemTarget, SettingSystemPassphrase} {
+		value, err := settings.Get(ctx, key)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to load %s: %w", key, err)
+		}
+		values[key] = value
+	}
+
+	cfg.Schedule = values[SettingSystemSchedule]
+	cfg.Passphrase = values[SettingSystemPassphrase]
+	if n, err := strconv.Atoi(values[SettingSystemKeepLast]); err == nil {
+		cfg.KeepLast = n
+	}
+	if target := values[SettingSystemTarget]; target != "" {
+		cfg.Target = models.BackupTarget(target)
+	}
+	return cfg, nil
+}
+
+// SaveSystemConfig writes the system backup config to settings
+func SaveSystemConfig(ctx context.Context, settings SettingsStore, cfg SystemConfig) error {
+	values := map[string]string{
+		SettingSystemSchedule:   cfg.Schedule,
+		SettingSystemKeepLast:   strconv.Itoa(cfg.KeepLast),
+		SettingSystemTarget:     string(cfg.Target),
+		SettingSystemPassphrase: cfg.Passphrase,
+	}
+	for key, value := range values {
+		if err := settings.Set(ctx, key, value); err != nil {
+			return fmt.Errorf("failed to save %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// SystemManager dumps Schooner's database and encryption key into
+// passphrase-encrypted archives and stages them for restore
+type SystemManager struct {
+	db         Snapshotter
+	dbPath     string
+	backups    SystemBackupStore
+	settings   SettingsStore
+	dispatcher *notify.Dispatcher
+	logger     *slog.Logger
+
+	mu sync.Mutex // Held while a backup runs
+}
+
+// NewSystemManager creates a new system backup manager
+func NewSystemManager(db Snapshotter, dbPath string, backups SystemBackupStore, settings SettingsStore) *SystemManager {
+	return &SystemManager{
+		db:       db,
+		dbPath:   dbPath,
+		backups:  backups,
+		settings: settings,
+		logger:   slog.Default(),
+	}
+}
+
+// SetNotifier sets the dispatcher used for backup failure notifications
+func (m *SystemManager) SetNotifier(dispatcher *notify.Dispatcher) {
+	m.dispatcher = dispatcher
+}
+
+// Start runs scheduled system backups until the context is cancelled
+func (m *SystemManager) Start(ctx context.Context) {
+	if _, err := m.backups.FailRunningBackups(ctx); err != nil {
+		m.logger.Error("failed to clean up interrupted backups", "error", err)
+	}
+
+	startedAt := time.Now()
+	go func() {
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if m.isDue(ctx, startedAt, now) {
+					if _, err := m.Run(ctx); err != nil {
+						m.logger.Error("scheduled system backup failed", "error", err)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// isDue reports whether the schedule has come round since the last backup,
+// or since startup if there is none
+func (m *SystemManager) isDue(ctx context.Context, startedAt, now time.Time) bool {
+	cfg, err := LoadSystemConfig(ctx, m.settings)
+	if err != nil || cfg.Schedule == "" || cfg.Passphrase == "" {
+		return false
+	}
+	schedule, err := cron.ParseStandard(cfg.Schedule)
+	if err != nil {
+		return false
+	}
+
+	last := startedAt
+	backups, err := m.backups.ListSystemBackups(ctx)
+	if err != nil {
+		m.logger.Error("failed to list system backups", "error", err)
+		return false
+	}
+	if len(backups) > 0 && backups[0].CreatedAt.After(last) {
+		last = backups[0].CreatedAt
+	}
+	return !schedule.Next(last).After(now)
+}
+
+// Run dumps the database to the configured target, then prunes old dumps
+func (m *SystemManager) Run(ctx context.Context) (*models.SystemBackup, error) {
+	if !m.mu.TryLock() {
+		return nil, fmt.Errorf("a system backup is already running")
+	}
+	defer m.mu.Unlock()
+
+	cfg, err := LoadSystemConfig(ctx, m.settings)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Passphrase == "" {
+		return nil, fmt.Errorf("set a passphrase before backing up Schooner")
+	}
+
+	now := time.Now()
+	id := uuid.New().String()
+	backup := &models.SystemBackup{
+		ID:        id,
+		Target:    cfg.Target,
+		Key:       fmt.Sprintf("schooner/%s-%s.sbk", now.UTC().Format("20060102T150405Z"), id[:8]),
+		Status:    models.BackupRunning,
+		CreatedAt: now,
+	}
+	if err := m.backups.CreateSystemBackup(ctx, backup); err != nil {
+		return nil, err
+	}
+
+	size, err := m.write(ctx, cfg, backup)
+	backup.SizeBytes = size
+	backup.FinishedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	backup.Status = models.BackupSuccess
+	if err != nil {
+		backup.Status = models.BackupFailed
+		backup.Error = sql.NullString{String: err.Error(), Valid: true}
+	}
+	if ferr := m.backups.FinishSystemBackup(ctx, backup); ferr != nil {
+		m.logger.Error("failed to record system backup", "error", ferr)
+	}
+	if err != nil {
+		m.notifyFailure(ctx, err)
+		return nil, err
+	}
+
+	m.logger.Info("Schooner backed up", "target", backup.Target, "key", backup.Key, "size", size)
+	m.prune(ctx, cfg.KeepLast)
+	return backup, nil
+}
+
+// write seals a dump into a staging file and moves it into the target store
+func (m *SystemManager) write(ctx context.Context, cfg SystemConfig, backup *models.SystemBackup) (int64, error) {
+	store, err := OpenStore(ctx, m.settings, cfg.Target)
+	if err != nil {
+		return 0, err
+	}
+
+	stagingDir := filepath.Join(localDir(ctx, m.settings), ".staging")
+	if err := os.MkdirAll(stagingDir, 0700); err != nil {
+		return 0, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	dump, err := m.dump(ctx, filepath.Join(stagingDir, backup.ID+".db"))
+	if err != nil {
+		return 0, err
+	}
+	sealed, err := crypto.SealWithPassphrase(dump, cfg.Passphrase)
+	if err != nil {
+		return 0, err
+	}
+
+	staging := filepath.Join(stagingDir, backup.ID+".sbk")
+	defer os.Remove(staging)
+	if err := os.WriteFile(staging, sealed, 0600); err != nil {
+		return 0, fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := store.Put(ctx, backup.Key, staging); err != nil {
+		return 0, err
+	}
+	return int64(len(sealed)), nil
+}
+
+// dump snapshots the database to snapshotPath and returns a gzipped tar of
+// it, the encryption key and a manifest
+func (m *SystemManager) dump(ctx context.Context, snapshotPath string) ([]byte, error) {
+	defer os.Remove(snapshotPath)
+	if err := m.db.Snapshot(ctx, snapshotPath); err != nil {
+		return nil, err
+	}
+	database, err := os.ReadFile(snapshotPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	key, err := crypto.ExportKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read encryption key: %w", err)
+	}
+	manifest, err := json.Marshal(Manifest{
+		Format:    dumpFormat,
+		CreatedAt: time.Now().UTC(),
+		Commit:    version.Commit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return writeDump(map[string][]byte{
+		dumpManifest: manifest,
+		dumpDatabase: database,
+		dumpKey:      []byte(key),
+	})
+}
+
+// Open returns a stored system backup, still encrypted
+func (m *SystemManager) Open(ctx context.Context, backup *models.SystemBackup) (io.ReadCloser, error) {
+	store, err := OpenStore(ctx, m.settings, backup.Target)
+	if err != nil {
+		return nil, err
+	}
+	return store.Get(ctx, backup.Key)
+}
+
+// Restore decrypts a system backup and stages its database and encryption
+// key. They replace the current ones when Schooner next starts.
+func (m *SystemManager) Restore(ctx context.Context, sealed io.Reader, passphrase string) (*Manifest, error) {
+	data, err := io.ReadAll(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	dump, err := crypto.OpenWithPassphrase(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	files, manifest, err := readDump(dump)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(m.dbPath+pendingSuffix, files[dumpDatabase], 0600); err != nil {
+		return nil, fmt.Errorf("failed to stage database: %w", err)
+	}
+	if err := os.WriteFile(crypto.KeyPath()+pendingSuffix, files[dumpKey], 0600); err != nil {
+		os.Remove(m.dbPath + pendingSuffix)
+		return nil, fmt.Errorf("failed to stage encryption key: %w", err)
+	}
+
+	m.logger.Warn("system backup staged, restart Schooner to restore it", "created_at", manifest.CreatedAt)
+	return manifest, nil
+}
+
+// Delete removes a system backup and its record
+func (m *SystemManager) Delete(ctx context.Context, backup *models.SystemBackup) error {
+	if backup.Status == models.BackupSuccess {
+		store, err := OpenStore(ctx, m.settings, backup.Target)
+		if err != nil {
+			return err
+		}
+		if err := store.Delete(ctx, backup.Key); err != nil {
+			return err
+		}
+	}
+	return m.backups.DeleteSystemBackup(ctx, backup.ID)
+}
+
+// prune deletes successful system backups beyond the newest keep
+func (m *SystemManager) prune(ctx context.Context, keep int) {
+	if keep <= 0 {
+		return
+	}
+
+	backups, err := m.backups.ListSystemBackups(ctx)
+	if err != nil {
+		m.logger.Error("failed to list system backups for retention", "error", err)
+		return
+	}
+
+	kept := 0
+	for _, backup := range backups {
+		if backup.Status != models.BackupSuccess {
+			continue
+		}
+		if kept++; kept <= keep {
+			continue
+		}
+		if err := m.Delete(ctx, backup); err != nil {
+			m.logger.Warn("failed to prune system backup", "key", backup.Key, "error", err)
+		}
+	}
+}
+
+// notifyFailure sends a system backup failure notification
+func (m *SystemManager) notifyFailure(ctx context.Context, err error) {
+	if m.dispatcher == nil {
+		return
+	}
+	m.dispatcher.Notify(ctx, notify.Event{
+		Type:     notify.EventBackupFailed,
+		Title:    "Schooner backup failed",
+		Message:  err.Error(),
+		URL:      m.dispatcher.BaseURL() + "/backups",
+		Priority: notify.PriorityHigh,
+	})
+}
+
+// ApplyPendingRestore swaps in a database and encryption key staged by
+// Restore. It must run before the database is opened and reports whether
+// there was anything to restore.
+func ApplyPendingRestore(dbPath string) (bool, error) {
+	pending := dbPath + pendingSuffix
+	if _, err := os.Stat(pending); os.IsNotExist(err) {
+		return false, nil
+	}
+
+	// Drop the old write-ahead log so it isn't replayed onto the restored
+	// database
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !os.IsNotExist(err) {
+			return false, fmt.Errorf("failed to remove %s: %w", dbPath+suffix, err)
+		}
+	}
+	if err := os.Rename(pending, dbPath); err != nil {
+		return false, fmt.Errorf("failed to restore database: %w", err)
+	}
+
+	keyPath := crypto.KeyPath()
+	if _, err := os.Stat(keyPath + pendingSuffix); err == nil {
+		if err := os.Rename(keyPath+pendingSuffix, keyPath); err != nil {
+			return true, fmt.Errorf("failed to restore encryption key: %w", err)
+		}
+		if os.Getenv("SCHOONER_ENCRYPTION_KEY") != "" {
+			slog.Warn("SCHOONER_ENCRYPTION_KEY overrides the restored key file; set it to the restored key if secrets fail to decrypt", "path", keyPath)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,209 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"schooner/internal/crypto"
+	"schooner/internal/models"
+)
+
+// fakeSettings is an in-memory SettingsStore
+type fakeSettings map[string]string
+
+func (s fakeSettings) Get(ctx context.Context, key string) (string, error) { return s[key], nil }
+
+func (s fakeSettings) Set(ctx context.Context, key, value string) error {
+	s[key] = value
+	return nil
+}
+
+// fakeSystemBackups is an in-memory SystemBackupStore
+type fakeSystemBackups struct {
+	backups []*models.SystemBackup
+}
+
+func (f *fakeSystemBackups) CreateSystemBackup(ctx context.Context, b *models.SystemBackup) error {
+	f.backups = append([]*models.SystemBackup{b}, f.backups...)
+	return nil
+}
+
+func (f *fakeSystemBackups) FinishSystemBackup(ctx context.Context, b *models.SystemBackup) error {
+	return nil
+}
+
+func (f *fakeSystemBackups) ListSystemBackups(ctx context.Context) ([]*models.SystemBackup, error) {
+	return f.backups, nil
+}
+
+func (f *fakeSystemBackups) DeleteSystemBackup(ctx context.Context, id string) error {
+	for i, b := range f.backups {
+		if b.ID == id {
+			f.backups = append(f.backups[:i], f.backups[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeSystemBackups) FailRunningBackups(ctx context.Context) (int64, error) { return 0, nil }
+
+// fakeSnapshotter writes fixed database contents
+type fakeSnapshotter []byte
+
+func (f fakeSnapshotter) Snapshot(ctx context.Context, path string) error {
+	return os.WriteFile(path, f, 0600)
+}
+
+func TestSystemConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     SystemConfig
+		wantErr bool
+	}{
+		{"valid", SystemConfig{Schedule: "0 4 * * *", KeepLast: 7, Target: models.BackupTargetLocal}, false},
+		{"manual only", SystemConfig{Target: models.BackupTargetS3}, false},
+		{"bad schedule", SystemConfig{Schedule: "daily", Target: models.BackupTargetLocal}, true},
+		{"negative retention", SystemConfig{KeepLast: -1, Target: models.BackupTargetLocal}, true},
+		{"unknown target", SystemConfig{Target: "ftp"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadSystemConfig_Defaults(t *testing.T) {
+	cfg, err := LoadSystemConfig(context.Background(), fakeSettings{})
+	if err != nil {
+		t.Fatalf("LoadSystemConfig() error = %v", err)
+	}
+	if cfg.KeepLast != 7 || cfg.Target != models.BackupTargetLocal || cfg.Schedule != "" {
+		t.Errorf("defaults = %+v", cfg)
+	}
+}
+
+func TestReadDump(t *testing.T) {
+	database := append([]byte(nil), sqliteHeader...)
+	manifest := []byte(`{"format":1,"created_at":"2024-05-01T04:00:00Z"}`)
+
+	tests := []struct {
+		name    string
+		files   map[string][]byte
+		wantErr bool
+	}{
+		{"valid", map[string][]byte{dumpManifest: manifest, dumpDatabase: database, dumpKey: []byte("key")}, false},
+		{"newer format", map[string][]byte{dumpManifest: []byte(`{"format":2}`), dumpDatabase: database, dumpKey: []byte("key")}, true},
+		{"not a database", map[string][]byte{dumpManifest: manifest, dumpDatabase: []byte("nope"), dumpKey: []byte("key")}, true},
+		{"missing key", map[string][]byte{dumpManifest: manifest, dumpDatabase: database}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dump, err := writeDump(tt.files)
+			if err != nil {
+				t.Fatalf("writeDump() error = %v", err)
+			}
+			if _, _, err := readDump(dump); (err != nil) != tt.wantErr {
+				t.Errorf("readDump() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSystemManager_BackupAndRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	t.Setenv("SCHOONER_ENCRYPTION_KEY", "MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE=")
+	t.Setenv("SCHOONER_KEY_PATH", filepath.Join(dir, ".encryption_key"))
+
+	settings := fakeSettings{
+		SettingDir:              filepath.Join(dir, "backups"),
+		SettingSystemKeepLast:   "1",
+		SettingSystemPassphrase: "correct horse",
+	}
+	records := &fakeSystemBackups{}
+	database := append(append([]byte(nil), sqliteHeader...), "apps and settings"...)
+	dbPath := filepath.Join(dir, "schooner.db")
+	manager := NewSystemManager(fakeSnapshotter(database), dbPath, records, settings)
+
+	first, err := manager.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if first.Status != models.BackupSuccess || first.SizeBytes == 0 {
+		t.Errorf("backup = %+v", first)
+	}
+
+	// Retention keeps only the newest
+	if _, err := manager.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(records.backups) != 1 {
+		t.Fatalf("kept %d backups, want 1", len(records.backups))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "backups", filepath.FromSlash(first.Key))); !os.IsNotExist(err) {
+		t.Error("pruned backup should be deleted from the store")
+	}
+
+	sealed, err := manager.Open(ctx, records.backups[0])
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	data := new(bytes.Buffer)
+	data.ReadFrom(sealed)
+	sealed.Close()
+	if bytes.Contains(data.Bytes(), []byte("apps and settings")) {
+		t.Error("stored backup should be encrypted")
+	}
+
+	if _, err := manager.Restore(ctx, bytes.NewReader(data.Bytes()), "wrong"); !errors.Is(err, crypto.ErrWrongPassphrase) {
+		t.Fatalf("Restore() with wrong passphrase error = %v", err)
+	}
+	if _, err := manager.Restore(ctx, bytes.NewReader(data.Bytes()), "correct horse"); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	// The current database stays in place until the next start
+	if err := os.WriteFile(dbPath, []byte("current"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dbPath+"-wal", []byte("wal"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := ApplyPendingRestore(dbPath)
+	if err != nil || !restored {
+		t.Fatalf("ApplyPendingRestore() = %v, %v", restored, err)
+	}
+	got, _ := os.ReadFile(dbPath)
+	if !bytes.Equal(got, database) {
+		t.Errorf("restored database = %q", got)
+	}
+	if _, err := os.Stat(dbPath + "-wal"); !os.IsNotExist(err) {
+		t.Error("old write-ahead log should be removed")
+	}
+	key, _ := os.ReadFile(crypto.KeyPath())
+	if string(key) != "MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE=" {
+		t.Errorf("restored key = %q", key)
+	}
+
+	if restored, err := ApplyPendingRestore(dbPath); err != nil || restored {
+		t.Errorf("second ApplyPendingRestore() = %v, %v", restored, err)
+	}
+}
+
+func TestSystemManager_RunRequiresPassphrase(t *testing.T) {
+	manager := NewSystemManager(fakeSnapshotter(sqliteHeader), "schooner.db", &fakeSystemBackups{}, fakeSettings{})
+	if _, err := manager.Run(context.Background()); err == nil {
+		t.Error("Run() without a passphrase should fail")
+	}
+}
@@ -0,0 +1,28 @@
+package build
+
+import (
+	"context"
+
+	"schooner/internal/models"
+)
+
+// recordStarted adds a build starting to the activity feed
+func (o *Orchestrator) recordStarted(ctx context.Context, app *models.App, build *models.Build) {
+	o.events.Record(ctx, models.ActivityBuildStarted, app.ID, build.ID,
+		"Build of %s started (%s)", app.Name, build.DescribeTrigger())
+}
+
+// recordDeployed adds a successful build's deploy to the activity feed
+func (o *Orchestrator) recordDeployed(ctx context.Context, app *models.App, build *models.Build) {
+	if sha := build.GetShortSHA(); sha != "" {
+		o.events.Record(ctx, models.ActivityDeploy, app.ID, build.ID, "Deployed %s at %s", app.Name, sha)
+		return
+	}
+	o.events.Record(ctx, models.ActivityDeploy, app.ID, build.ID, "Deployed %s", app.Name)
+}
+
+// recordFailed adds a failed build to the activity feed
+func (o *Orchestrator) recordFailed(ctx context.Context, build *models.Build, message string) {
+	o.events.Record(ctx, models.ActivityBuildFinished, build.AppID, build.ID,
+		"Build of %s failed: %s", build.AppName, message)
+}
@@ -0,0 +1,38 @@
+package build
+
+import (
+	"context"
+	"fmt"
+
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+// AddonProvider supplies the connection env vars of an app's add-ons
+type AddonProvider interface {
+	AddonEnv(ctx context.Context, app *models.App) (map[string]string, error)
+}
+
+// SetAddonProvider sets the source of add-on env vars for deployments
+func (o *Orchestrator) SetAddonProvider(provider AddonProvider) {
+	o.addonProvider = provider
+}
+
+// addonEnv returns the env vars pointing an app at its add-ons, empty if it
+// has none
+func (o *Orchestrator) addonEnv(ctx context.Context, app *models.App) (map[string]string, error) {
+	if o.addonProvider == nil {
+		return nil, nil
+	}
+	return o.addonProvider.AddonEnv(ctx, app)
+}
+
+// ensureAppNetwork creates the private network an app's containers and
+// add-ons share, if it doesn't exist yet, and returns its name
+func ensureAppNetwork(ctx context.Context, dockerClient *docker.Client, app *models.App) (string, error) {
+	network := app.Network()
+	if err := dockerClient.EnsureNetwork(ctx, network); err != nil {
+		return "", fmt.Errorf("failed to create app network: %w", err)
+	}
+	return network, nil
+}
@@ -0,0 +1,50 @@
+package build
+
+import (
+	"context"
+
+	"schooner/internal/agent"
+	"schooner/internal/docker"
+)
+
+// AgentDispatcher sends deploy tasks to Schooner agents and knows what their
+// containers are running
+type AgentDispatcher interface {
+	Dispatch(ctx context.Context, agentID string, task agent.Task) (*agent.TaskResult, error)
+	ContainerStatus(agentID, name string) (*docker.ContainerStatus, error)
+}
+
+// SetAgents lets apps assigned to an agent be deployed through it. Without
+// it such apps fail to deploy.
+func (o *Orchestrator) SetAgents(agents AgentDispatcher) {
+	o.agents = agents
+}
+
+// containerRunner runs an app's container, on a Docker daemon or an agent
+type containerRunner interface {
+	GetContainerStatus(ctx context.Context, nameOrID string) (*docker.ContainerStatus, error)
+	RunContainer(ctx context.Context, cfg docker.ContainerConfig) (string, error)
+}
+
+// agentRunner runs containers through an agent, which downloads the image
+// from Schooner as part of the deploy
+type agentRunner struct {
+	agents  AgentDispatcher
+	agentID string
+}
+
+func (r agentRunner) GetContainerStatus(ctx context.Context, name string) (*docker.ContainerStatus, error) {
+	return r.agents.ContainerStatus(r.agentID, name)
+}
+
+func (r agentRunner) RunContainer(ctx context.Context, cfg docker.ContainerConfig) (string, error) {
+	result, err := r.agents.Dispatch(ctx, r.agentID, agent.Task{
+		Type:      agent.TaskDeploy,
+		Container: cfg.Name,
+		Config:    &cfg,
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.ContainerID, nil
+}
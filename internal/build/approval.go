@@ -0,0 +1,111 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"schooner/internal/models"
+	"schooner/internal/notify"
+)
+
+// ErrNotWaitingApproval is returned when approving or rejecting a build that
+// isn't waiting for approval
+var ErrNotWaitingApproval = errors.New("build is not waiting for approval")
+
+// needsApproval reports whether a build waits for approval before its deploy
+// step
+func needsApproval(app *models.App, build *models.Build) bool {
+	return app.RequireApproval && build.Trigger == models.TriggerWebhook
+}
+
+// awaitApproval pauses a built build until someone approves its deploy. The
+// build gives up its worker; approving it queues it again.
+func (o *Orchestrator) awaitApproval(ctx context.Context, app *models.App, build *models.Build, logWriter io.Writer, logger *slog.Logger) {
+	build.Status = models.BuildStatusWaitingApproval
+	o.saveBuild(ctx, build)
+
+	fmt.Fprintf(logWriter, "\n--- Waiting for Approval ---\n")
+	fmt.Fprintf(logWriter, "%s requires approval before deploying\n", app.Name)
+	logger.Info("build waiting for approval")
+
+	if o.notifier != nil {
+		o.notifier.Notify(context.Background(), notify.Event{
+			Type:     notify.EventApprovalRequired,
+			Title:    fmt.Sprintf("Deploy waiting for approval: %s", app.Name),
+			Message:  fmt.Sprintf("Build %s of %s (%s) is ready to deploy", build.ID[:8], build.GetShortSHA(), build.DescribeTrigger()),
+			AppName:  app.Name,
+			URL:      o.notifier.BaseURL() + "/builds/" + build.ID,
+			Priority: notify.PriorityDefault,
+		})
+	}
+}
+
+// ApproveBuild approves the deploy of a build waiting for approval and
+// queues it to carry on from its deploy step
+func (o *Orchestrator) ApproveBuild(ctx context.Context, buildID, approvedBy string) (*models.Build, error) {
+	ok, err := o.buildQueries.Approve(ctx, buildID, approvedBy)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotWaitingApproval
+	}
+
+	o.appendSystemLog(ctx, buildID, fmt.Sprintf("Deploy approved by %s", approvedBy))
+	o.QueueBuild(buildID)
+
+	return o.buildQueries.GetByID(ctx, buildID)
+}
+
+// RejectBuild cancels a build waiting for approval without deploying it
+func (o *Orchestrator) RejectBuild(ctx context.Context, buildID, rejectedBy string) (*models.Build, error) {
+	ok, err := o.buildQueries.Reject(ctx, buildID, rejectedBy)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotWaitingApproval
+	}
+
+	o.appendSystemLog(ctx, buildID, fmt.Sprintf("Deploy rejected by %s", rejectedBy))
+
+	build, err := o.buildQueries.GetByID(ctx, buildID)
+	if err != nil || build == nil {
+		return build, err
+	}
+	o.publishBuild(build)
+
+	// Settle the approval step and the steps that never ran
+	steps := newStepRecorder(o.stepQueries, buildID)
+	if err := steps.load(ctx); err != nil {
+		return nil, err
+	}
+	for _, step := range steps.loaded {
+		steps.add(ctx, step.Name, step.GetCommand())
+	}
+	steps.abort(build)
+
+	return build, nil
+}
+
+// appendSystemLog adds a system message to a build's log
+func (o *Orchestrator) appendSystemLog(ctx context.Context, buildID, message string) {
+	log := &models.BuildLog{
+		BuildID:   buildID,
+		Level:     models.LogLevelInfo,
+		Message:   message,
+		Source:    models.LogSourceSystem,
+		Timestamp: time.Now(),
+	}
+
+	// A running build's log goes through its writer to stay in order
+	if w := o.logWriter(buildID); w != nil {
+		w.Append(log)
+		return
+	}
+	o.logQueries.Append(ctx, log)
+}
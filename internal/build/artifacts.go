@@ -0,0 +1,205 @@
+package build
+
+import (
+	"cmp"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+// StepArtifacts copies the app's artifact paths out of the built image
+const StepArtifacts = "artifacts"
+
+// keepArtifactBuilds is how many of an app's builds keep their artifacts
+const keepArtifactBuilds = 10
+
+// Artifact is an archive of a path copied out of a build's image
+type Artifact struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// SetArtifactsDir sets where the artifacts of builds are kept, enabling
+// the artifacts step of apps with artifact paths
+func (o *Orchestrator) SetArtifactsDir(dir string) {
+	o.artifactsDir = dir
+}
+
+// withArtifacts adds the artifacts step right after a pipeline's build step
+func withArtifacts(pipeline []PipelineStep) []PipelineStep {
+	steps := make([]PipelineStep, 0, len(pipeline)+1)
+	for _, step := range pipeline {
+		steps = append(steps, step)
+		if step.Name == StepBuild {
+			steps = append(steps, PipelineStep{Name: StepArtifacts})
+		}
+	}
+	return steps
+}
+
+// ArtifactsDir is the directory the artifacts of a build are kept in
+func ArtifactsDir(root string, build *models.Build) string {
+	return filepath.Join(root, build.AppID, build.ID)
+}
+
+// ListArtifacts returns the artifacts kept for a build, none if it has none
+func ListArtifacts(root string, build *models.Build) ([]Artifact, error) {
+	entries, err := os.ReadDir(ArtifactsDir(root, build))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+
+	var artifacts []Artifact
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), ".tar.gz") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		artifacts = append(artifacts, Artifact{Name: entry.Name(), Size: info.Size()})
+	}
+	return artifacts, nil
+}
+
+// ArtifactFile returns the file a build's artifact is kept in
+func ArtifactFile(root string, build *models.Build, name string) (string, error) {
+	if name != filepath.Base(name) || !strings.HasSuffix(name, ".tar.gz") || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid artifact name %q", name)
+	}
+	return filepath.Join(ArtifactsDir(root, build), name), nil
+}
+
+// extractArtifacts copies each of the app's artifact paths out of the image
+// the build produced into a gzipped tar archive in the build's artifacts
+// directory
+func (o *Orchestrator) extractArtifacts(ctx context.Context, d *deployment) error {
+	logWriter := d.opts.LogWriter
+	fmt.Fprintf(logWriter, "\n--- Step: %s ---\n\n", StepArtifacts)
+
+	if d.buildStrategy == models.BuildStrategyCompose {
+		fmt.Fprintf(logWriter, "Compose apps build several images, skipping artifacts\n")
+		return nil
+	}
+	dockerClient := d.opts.Docker
+	if dockerClient == nil {
+		dockerClient = o.dockerClient
+	}
+	if dockerClient == nil {
+		return fmt.Errorf("artifacts failed: docker is not available")
+	}
+
+	id, err := dockerClient.CreateContainer(ctx, docker.ContainerConfig{
+		Name:  "schooner-artifacts-" + uuid.New().String()[:8],
+		Image: d.result.ImageTag,
+		Cmd:   []string{"true"}, // Never started, but images without a command can't be created
+		Labels: map[string]string{
+			"schooner.managed": "true",
+			"schooner.service": "artifacts",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("artifacts failed: %w", err)
+	}
+	defer dockerClient.RemoveContainer(context.Background(), id)
+
+	dir := ArtifactsDir(o.artifactsDir, d.build)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("artifacts failed: %w", err)
+	}
+	for _, imagePath := range d.app.GetArtifactPaths() {
+		size, err := writeArtifact(ctx, dockerClient, id, imagePath, filepath.Join(dir, models.ArtifactName(imagePath)))
+		if err != nil {
+			fmt.Fprintf(logWriter, "ERROR: Failed to copy %s: %s\n", imagePath, err)
+			return fmt.Errorf("artifacts failed: %s: %w", imagePath, err)
+		}
+		fmt.Fprintf(logWriter, "Copied %s (%d bytes compressed)\n", imagePath, size)
+	}
+
+	if err := pruneArtifacts(filepath.Dir(dir), keepArtifactBuilds); err != nil {
+		d.logger.Warn("failed to prune artifacts", "error", err)
+	}
+	return nil
+}
+
+// writeArtifact compresses the tar archive of a path in a container into
+// file, returning its size
+func writeArtifact(ctx context.Context, dockerClient *docker.Client, containerID, imagePath, file string) (int64, error) {
+	archive, err := dockerClient.CopyFromContainer(ctx, containerID, imagePath)
+	if err != nil {
+		return 0, err
+	}
+	defer archive.Close()
+
+	f, err := os.Create(file)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	if _, err := io.Copy(gz, archive); err != nil {
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), f.Close()
+}
+
+// pruneArtifacts removes the artifacts of all but the newest keep builds in
+// an app's artifacts directory
+func pruneArtifacts(appDir string, keep int) error {
+	entries, err := os.ReadDir(appDir)
+	if err != nil {
+		return fmt.Errorf("failed to list artifacts: %w", err)
+	}
+
+	type buildDir struct {
+		path    string
+		modTime int64
+	}
+	var dirs []buildDir
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		dirs = append(dirs, buildDir{filepath.Join(appDir, entry.Name()), info.ModTime().UnixNano()})
+	}
+	if len(dirs) <= keep {
+		return nil
+	}
+
+	slices.SortFunc(dirs, func(a, b buildDir) int { return cmp.Compare(b.modTime, a.modTime) })
+	var errs []error
+	for _, dir := range dirs[keep:] {
+		if err := os.RemoveAll(dir.path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
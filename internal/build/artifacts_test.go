@@ -0,0 +1,95 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"schooner/internal/models"
+)
+
+func TestWithArtifacts(t *testing.T) {
+	pipeline := []PipelineStep{{Name: StepBuild}, {Name: "lint", Run: "make lint"}, {Name: StepDeploy}}
+	want := []PipelineStep{{Name: StepBuild}, {Name: StepArtifacts}, {Name: "lint", Run: "make lint"}, {Name: StepDeploy}}
+	if got := withArtifacts(pipeline); !reflect.DeepEqual(got, want) {
+		t.Errorf("withArtifacts() = %+v, want %+v", got, want)
+	}
+}
+
+func TestListArtifacts(t *testing.T) {
+	root := t.TempDir()
+	b := &models.Build{ID: "b1", AppID: "a1"}
+
+	if artifacts, err := ListArtifacts(root, b); err != nil || artifacts != nil {
+		t.Fatalf("ListArtifacts() of a build without artifacts = %v, %v", artifacts, err)
+	}
+
+	dir := ArtifactsDir(root, b)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "app-dist.tar.gz"), []byte("dist"), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0644)
+
+	artifacts, err := ListArtifacts(root, b)
+	if err != nil {
+		t.Fatalf("ListArtifacts() error = %v", err)
+	}
+	want := []Artifact{{Name: "app-dist.tar.gz", Size: 4}}
+	if !reflect.DeepEqual(artifacts, want) {
+		t.Errorf("ListArtifacts() = %+v, want %+v", artifacts, want)
+	}
+}
+
+func TestArtifactFile(t *testing.T) {
+	b := &models.Build{ID: "b1", AppID: "a1"}
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"app-dist.tar.gz", false},
+		{"../a2/b2/app-dist.tar.gz", true},
+		{"secrets.txt", true},
+		{".tar.gz", true},
+	}
+
+	for _, tt := range tests {
+		file, err := ArtifactFile("/data/artifacts", b, tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ArtifactFile(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && file != "/data/artifacts/a1/b1/"+tt.name {
+			t.Errorf("ArtifactFile(%q) = %q", tt.name, file)
+		}
+	}
+}
+
+func TestPruneArtifacts(t *testing.T) {
+	appDir := t.TempDir()
+	now := time.Now()
+	for i, id := range []string{"oldest", "older", "newest"} {
+		dir := filepath.Join(appDir, id)
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		modTime := now.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(dir, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := pruneArtifacts(appDir, 2); err != nil {
+		t.Fatalf("pruneArtifacts() error = %v", err)
+	}
+	entries, _ := os.ReadDir(appDir)
+	var kept []string
+	for _, entry := range entries {
+		kept = append(kept, entry.Name())
+	}
+	if want := []string{"newest", "older"}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("kept %v, want %v", kept, want)
+	}
+}
@@ -0,0 +1,48 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"schooner/internal/models"
+)
+
+// ClearBuildCache removes an app's build cache volumes, so its next build
+// starts with empty caches, and returns the volumes removed. It fails with
+// ErrBuildRunning while the app builds.
+func (o *Orchestrator) ClearBuildCache(ctx context.Context, app *models.App) ([]string, error) {
+	appLock := o.getAppLock(app.ID)
+	if !appLock.TryLock() {
+		return nil, ErrBuildRunning
+	}
+	defer appLock.Unlock()
+
+	dockerClient, err := o.dockerFor(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	if dockerClient == nil {
+		return nil, fmt.Errorf("Docker client not available")
+	}
+
+	// Caches the app no longer keeps are cleared too
+	volumes, err := dockerClient.ListVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	var errs []error
+	for _, volume := range volumes {
+		if !strings.HasPrefix(volume, app.BuildCacheVolumePrefix()) {
+			continue
+		}
+		if err := dockerClient.RemoveVolume(ctx, volume); err != nil {
+			errs = append(errs, fmt.Errorf("volume %s: %w", volume, err))
+			continue
+		}
+		removed = append(removed, volume)
+	}
+	return removed, errors.Join(errs...)
+}
@@ -0,0 +1,68 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"schooner/internal/git"
+	"schooner/internal/models"
+)
+
+// ErrBuildRunning is returned when an app's checkout is cleared while one of
+// its builds is using it
+var ErrBuildRunning = errors.New("a build is running for this app")
+
+// CheckoutPath returns the directory an app's repository is checked out
+// into, given the git work directory. Every app has its own, so apps
+// tracking different branches of one repository don't trample each other's
+// files. Apps without a repository get their compose file directory.
+func CheckoutPath(workDir string, app *models.App) string {
+	if !app.HasRepo() {
+		return ComposeSpecPath(workDir, app.ID)
+	}
+	return filepath.Join(workDir, "checkouts", app.ID)
+}
+
+// adoptSharedClone moves the clone apps of the same repository used to share
+// into the app's own checkout, the first time it builds, so files the app
+// bind mounts from it come along
+func (o *Orchestrator) adoptSharedClone(app *models.App, checkout string) {
+	if _, err := os.Stat(checkout); !os.IsNotExist(err) {
+		return
+	}
+	shared := git.RepoPath(o.gitClient.WorkDir(), app.RepoURL)
+	if _, err := os.Stat(filepath.Join(shared, ".git")); err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(checkout), 0755); err != nil {
+		o.logger.Warn("failed to create checkouts directory", "error", err)
+		return
+	}
+	if err := os.Rename(shared, checkout); err != nil {
+		o.logger.Warn("failed to adopt shared clone", "app", app.Name, "error", err)
+		return
+	}
+	o.logger.Info("adopted shared clone", "app", app.Name, "path", checkout)
+}
+
+// ClearCheckout removes an app's checkout, so its next build clones the
+// repository afresh. It fails with ErrBuildRunning while the app builds.
+func (o *Orchestrator) ClearCheckout(app *models.App) error {
+	if !app.HasRepo() {
+		return fmt.Errorf("app has no repository")
+	}
+
+	appLock := o.getAppLock(app.ID)
+	if !appLock.TryLock() {
+		return ErrBuildRunning
+	}
+	defer appLock.Unlock()
+
+	if err := os.RemoveAll(CheckoutPath(o.gitClient.WorkDir(), app)); err != nil {
+		return fmt.Errorf("failed to remove checkout: %w", err)
+	}
+	return nil
+}
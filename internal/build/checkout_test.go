@@ -0,0 +1,86 @@
+package build
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"schooner/internal/git"
+	"schooner/internal/models"
+)
+
+func TestCheckoutPath(t *testing.T) {
+	tests := []struct {
+		name string
+		app  *models.App
+		want string
+	}{
+		{
+			name: "repository",
+			app:  &models.App{ID: "a1", RepoURL: "https://github.com/example/web.git"},
+			want: "/work/checkouts/a1",
+		},
+		{
+			name: "apps of one repository apart",
+			app:  &models.App{ID: "a2", RepoURL: "https://github.com/example/web.git"},
+			want: "/work/checkouts/a2",
+		},
+		{
+			name: "template",
+			app:  &models.App{ID: "a3", Template: sql.NullString{String: "uptime-kuma", Valid: true}},
+			want: "/work/apps/a3",
+		},
+		{
+			name: "adopted compose file",
+			app:  &models.App{ID: "a4", ComposeSpec: sql.NullString{String: "services: {}", Valid: true}},
+			want: "/work/apps/a4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckoutPath("/work", tt.app); got != tt.want {
+				t.Errorf("CheckoutPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClearCheckout(t *testing.T) {
+	gitClient, err := git.NewClient(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewOrchestrator(gitClient, nil, nil, nil, nil)
+
+	app := &models.App{ID: "a1", Name: "web", RepoURL: "https://github.com/example/web.git"}
+	checkout := CheckoutPath(gitClient.WorkDir(), app)
+	if err := os.MkdirAll(filepath.Join(checkout, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// A running build holds the app's lock
+	lock := o.getAppLock(app.ID)
+	lock.Lock()
+	if err := o.ClearCheckout(app); !errors.Is(err, ErrBuildRunning) {
+		t.Errorf("ClearCheckout() while building error = %v, want ErrBuildRunning", err)
+	}
+	if _, err := os.Stat(checkout); err != nil {
+		t.Errorf("checkout removed while building: %v", err)
+	}
+	lock.Unlock()
+
+	if err := o.ClearCheckout(app); err != nil {
+		t.Fatalf("ClearCheckout() error = %v", err)
+	}
+	if _, err := os.Stat(checkout); !os.IsNotExist(err) {
+		t.Errorf("checkout still there after ClearCheckout(): %v", err)
+	}
+
+	template := &models.App{ID: "a2", Template: sql.NullString{String: "uptime-kuma", Valid: true}}
+	if err := o.ClearCheckout(template); err == nil {
+		t.Error("ClearCheckout() of an app without repository error = nil")
+	}
+}
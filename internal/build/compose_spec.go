@@ -0,0 +1,62 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"schooner/internal/models"
+)
+
+// composeSpecFile is the name compose files of apps without a repository
+// are written to
+const composeSpecFile = "docker-compose.yml"
+
+// TemplateRenderer renders the compose file of apps deployed from the
+// template catalog
+type TemplateRenderer interface {
+	RenderCompose(app *models.App) (string, error)
+}
+
+// SetTemplateRenderer sets the renderer for apps deployed from templates
+func (o *Orchestrator) SetTemplateRenderer(renderer TemplateRenderer) {
+	o.templateRenderer = renderer
+}
+
+// prepareComposeSpec writes the compose file of an app without a repository
+// to its own directory under the git work dir and returns that directory,
+// which stands in for the repository. Adopted apps carry their compose file,
+// template apps have it rendered from the catalog.
+func (o *Orchestrator) prepareComposeSpec(app *models.App) (string, error) {
+	var compose string
+	if app.ComposeSpec.Valid && app.ComposeSpec.String != "" {
+		compose = app.ComposeSpec.String
+	} else {
+		if o.templateRenderer == nil {
+			return "", fmt.Errorf("template catalog is not available")
+		}
+		var err error
+		compose, err = o.templateRenderer.RenderCompose(app)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	dir := ComposeSpecPath(o.gitClient.WorkDir(), app.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create compose directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, composeSpecFile), []byte(compose), 0644); err != nil {
+		return "", fmt.Errorf("failed to write compose file: %w", err)
+	}
+
+	app.BuildStrategy = models.BuildStrategyCompose
+	app.ComposeFile = composeSpecFile
+	return dir, nil
+}
+
+// ComposeSpecPath returns the directory the compose file of an app without a
+// repository is written to, given the git work directory
+func ComposeSpecPath(workDir, appID string) string {
+	return filepath.Join(workDir, "apps", appID)
+}
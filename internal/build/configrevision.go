@@ -0,0 +1,107 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"schooner/internal/auth"
+	"schooner/internal/database"
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+// ErrNotDeployed is returned when redeploying the running commit of an app
+// that has never been deployed
+var ErrNotDeployed = errors.New("app has not been deployed yet")
+
+// SetConfigRevisions sets where the config revision a deploy ran with is
+// recorded
+func (o *Orchestrator) SetConfigRevisions(revisions *queries.ConfigRevisionQueries) {
+	o.configRevisions = revisions
+}
+
+// ApplyConfig queues a build that redeploys the commit an app is running
+// with its current configuration, so configuration changes take effect
+// without picking up new commits
+func (o *Orchestrator) ApplyConfig(ctx context.Context, app *models.App) (*models.Build, error) {
+	return o.redeployDeployed(ctx, app, models.TriggerConfig, func(deployed *models.Build) string {
+		return fmt.Sprintf("Applying configuration revision %d to build %s", app.ConfigRevision, deployed.ID[:8])
+	})
+}
+
+// RecreateContainer queues a build that redeploys the commit an app is
+// running, for when its container went missing
+func (o *Orchestrator) RecreateContainer(ctx context.Context, app *models.App) (*models.Build, error) {
+	return o.redeployDeployed(ctx, app, models.TriggerReconcile, func(deployed *models.Build) string {
+		return fmt.Sprintf("Container missing, recreating it from build %s", deployed.ID[:8])
+	})
+}
+
+// RefreshImages redeploys an app's latest successful build so its
+// containers run images a scheduled pull updated
+func (o *Orchestrator) RefreshImages(ctx context.Context, app *models.App, images []string) (*models.Build, error) {
+	return o.redeployDeployed(ctx, app, models.TriggerRefresh, func(deployed *models.Build) string {
+		return fmt.Sprintf("Updated images %s, redeploying build %s", strings.Join(images, ", "), deployed.ID[:8])
+	})
+}
+
+// redeployDeployed queues a build of the commit of an app's latest
+// successful build, logging why it was queued
+func (o *Orchestrator) redeployDeployed(ctx context.Context, app *models.App, trigger models.BuildTrigger, message func(deployed *models.Build) string) (*models.Build, error) {
+	deployed, err := o.buildQueries.GetLatestSuccessfulByAppID(ctx, app.ID)
+	if err != nil {
+		return nil, err
+	}
+	if deployed == nil {
+		return nil, ErrNotDeployed
+	}
+
+	build := &models.Build{
+		ID:            uuid.New().String(),
+		AppID:         app.ID,
+		Status:        models.BuildStatusPending,
+		Trigger:       trigger,
+		TriggeredBy:   database.NullString(auth.Requester(ctx)),
+		CommitSHA:     deployed.CommitSHA,
+		CommitMessage: deployed.CommitMessage,
+		CommitAuthor:  deployed.CommitAuthor,
+		Branch:        deployed.Branch,
+		Tag:           deployed.Tag,
+		CreatedAt:     time.Now(),
+	}
+	if err := o.buildQueries.Create(ctx, build); err != nil {
+		return nil, err
+	}
+	o.publishBuild(build)
+
+	log := &models.BuildLog{
+		BuildID:   build.ID,
+		Level:     models.LogLevelInfo,
+		Message:   message(deployed),
+		Source:    models.LogSourceSystem,
+		Timestamp: time.Now(),
+	}
+	o.logQueries.Append(ctx, log)
+
+	o.linkTrace(ctx, build.ID)
+	o.QueueBuild(build.ID)
+
+	return build, nil
+}
+
+// markConfigDeployed records that the app is running the config revision
+// its build started with
+func (o *Orchestrator) markConfigDeployed(ctx context.Context, app *models.App, logger *slog.Logger) {
+	if o.configRevisions == nil || app.ConfigRevision == app.DeployedConfigRevision {
+		return
+	}
+	if err := o.configRevisions.MarkDeployed(ctx, app.ID, app.ConfigRevision); err != nil {
+		logger.Warn("failed to record deployed config revision", "error", err)
+	}
+}
@@ -0,0 +1,97 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"schooner/internal/events"
+	"schooner/internal/models"
+)
+
+// debouncedBuild is the latest webhook build of an app, queued once no newer
+// push arrives for the app's debounce window
+type debouncedBuild struct {
+	buildID string
+	timer   *time.Timer
+}
+
+// QueueDebounced queues a build triggered by a push. Apps with a debounce
+// window only build the latest of several quick pushes: the build waits out
+// the window and is cancelled as superseded if a newer push arrives first.
+// The build's spans join the trace of ctx.
+func (o *Orchestrator) QueueDebounced(ctx context.Context, app *models.App, build *models.Build) {
+	o.publishBuild(build)
+	o.linkTrace(ctx, build.ID)
+
+	if app.DebounceSeconds <= 0 {
+		o.QueueBuild(build.ID)
+		return
+	}
+
+	o.debouncedMu.Lock()
+	defer o.debouncedMu.Unlock()
+
+	if o.ctx.Err() != nil {
+		return
+	}
+
+	if previous, ok := o.debounced[app.ID]; ok {
+		previous.timer.Stop()
+		o.supersede(previous.buildID, build)
+	}
+
+	delay := time.Duration(app.DebounceSeconds) * time.Second
+	entry := &debouncedBuild{buildID: build.ID}
+	entry.timer = time.AfterFunc(delay, func() { o.releaseDebounced(app.ID, entry) })
+	o.debounced[app.ID] = entry
+
+	o.appendSystemLog(o.ctx, build.ID, fmt.Sprintf("Waiting %s for newer pushes before building", delay))
+	o.logger.Info("build debounced", "app", app.Name, "buildID", build.ID, "delay", delay)
+}
+
+// releaseDebounced queues a debounced build once its window passes without
+// a newer push
+func (o *Orchestrator) releaseDebounced(appID string, entry *debouncedBuild) {
+	o.debouncedMu.Lock()
+	defer o.debouncedMu.Unlock()
+
+	if o.debounced[appID] != entry || o.ctx.Err() != nil {
+		return
+	}
+	delete(o.debounced, appID)
+	o.QueueBuild(entry.buildID)
+}
+
+// supersede cancels a debounced or held build that a newer push replaced
+func (o *Orchestrator) supersede(buildID string, newer *models.Build) {
+	ctx, cancel := context.WithTimeout(o.ctx, 10*time.Second)
+	defer cancel()
+
+	o.unlinkTrace(buildID)
+	reason := fmt.Sprintf("Superseded by a newer push (%s)", newer.GetShortSHA())
+	ok, err := o.buildQueries.Supersede(ctx, buildID, reason)
+	if err != nil {
+		o.logger.Error("failed to supersede build", "buildID", buildID, "error", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	o.appendSystemLog(ctx, buildID, reason)
+	o.events.Publish(events.Event{Kind: events.KindBuild, AppID: newer.AppID, BuildID: buildID, Status: string(models.BuildStatusCancelled)})
+	o.logger.Info("build superseded", "buildID", buildID, "by", newer.ID)
+}
+
+// stopDebounced drops the debounce timers so none queue a build while the
+// orchestrator shuts down. Their builds are cancelled as stale on the next
+// start.
+func (o *Orchestrator) stopDebounced() {
+	o.debouncedMu.Lock()
+	defer o.debouncedMu.Unlock()
+
+	for appID, entry := range o.debounced {
+		entry.timer.Stop()
+		delete(o.debounced, appID)
+	}
+}
@@ -0,0 +1,67 @@
+package build
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"schooner/internal/database"
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+func TestQueueDebounced(t *testing.T) {
+	db := newTestDB(t)
+
+	ctx := context.Background()
+	appQueries := queries.NewAppQueries(db.DB)
+	buildQueries := queries.NewBuildQueries(db.DB)
+	o := NewOrchestrator(nil, nil, appQueries, buildQueries, queries.NewLogQueries(db.DB))
+	defer o.cancel()
+
+	app := &models.App{
+		ID: "app1", Name: "web", RepoURL: "https://github.com/example/web.git", Branch: "main",
+		BuildStrategy: models.BuildStrategyDockerfile, DebounceSeconds: 1,
+		CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}
+	if err := appQueries.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	push := func(id, sha string) *models.Build {
+		b := &models.Build{
+			ID: id, AppID: app.ID, Status: models.BuildStatusPending, Trigger: models.TriggerWebhook,
+			CommitSHA: database.NullString(sha), CreatedAt: time.Now(),
+		}
+		if err := buildQueries.Create(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+		o.QueueDebounced(ctx, app, b)
+		return b
+	}
+	push("first", "1111111111")
+	push("second", "2222222222")
+
+	select {
+	case id := <-o.buildQueue:
+		if id != "second" {
+			t.Errorf("queued build = %q, want the latest push", id)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("debounced build was never queued")
+	}
+
+	first, err := buildQueries.GetByID(ctx, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Status != models.BuildStatusCancelled || first.GetErrorMessage() != "Superseded by a newer push (22222222)" {
+		t.Errorf("superseded build = %s %q", first.Status, first.GetErrorMessage())
+	}
+
+	select {
+	case id := <-o.buildQueue:
+		t.Errorf("superseded build %q was queued too", id)
+	default:
+	}
+}
@@ -0,0 +1,162 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"schooner/internal/events"
+	"schooner/internal/models"
+)
+
+// How often the builds an ordered deploy waits on are checked
+const waveCheckInterval = 5 * time.Second
+
+// TriggerOrderedBuilds creates a manual build of each app and queues them in
+// waves, so apps deploy after the apps they depend on. The first wave is
+// queued right away and each later one once the wave before it finished.
+// Builds of apps depending on an app whose build didn't succeed are
+// cancelled. It returns the builds by app ID, the waves they run in, and
+// why apps that got no build didn't.
+func (o *Orchestrator) TriggerOrderedBuilds(ctx context.Context, graph models.DependencyGraph, appIDs []string) (map[string]*models.Build, [][]string, map[string]error, error) {
+	waves, err := graph.Order(appIDs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	builds := make(map[string]*models.Build)
+	failed := make(map[string]error)
+	var buildWaves [][]*models.Build
+	for i, wave := range waves {
+		message := "Build triggered manually"
+		if i > 0 {
+			message = "Build triggered manually, waiting for the apps it depends on to deploy"
+		}
+
+		var created []*models.Build
+		for _, appID := range wave {
+			build, err := o.createManualBuild(ctx, appID, message)
+			if err != nil {
+				failed[appID] = err
+				continue
+			}
+			o.linkTrace(ctx, build.ID)
+			builds[appID] = build
+			created = append(created, build)
+		}
+		if len(created) > 0 {
+			buildWaves = append(buildWaves, created)
+		}
+	}
+
+	if len(buildWaves) > 0 {
+		for _, build := range buildWaves[0] {
+			o.QueueBuild(build.ID)
+		}
+		// Waves still waiting when the orchestrator stops are cancelled as
+		// stale on the next start
+		go o.queueWaves(graph, buildWaves[1:], buildWaves[0])
+	}
+	return builds, waves, failed, nil
+}
+
+// queueWaves queues each wave of an ordered deploy once the builds of the
+// wave before it finished, cancelling the builds that depend on an app whose
+// build didn't succeed
+func (o *Orchestrator) queueWaves(graph models.DependencyGraph, waves [][]*models.Build, running []*models.Build) {
+	undeployed := make(map[string]string) // App IDs to names
+	for _, wave := range waves {
+		finished, ok := o.awaitBuilds(running)
+		if !ok {
+			return
+		}
+		for _, build := range finished {
+			if build.Status != models.BuildStatusSuccess {
+				undeployed[build.AppID] = build.AppName
+			}
+		}
+
+		running = nil
+		for _, build := range wave {
+			if missing := missingDependency(graph, build.AppID, undeployed); missing != "" {
+				o.cancelDependent(build, missing)
+				undeployed[build.AppID] = build.AppName
+				continue
+			}
+			o.appendSystemLog(o.ctx, build.ID, "The apps it depends on deployed, starting build")
+			o.QueueBuild(build.ID)
+			running = append(running, build)
+		}
+	}
+}
+
+// missingDependency returns the name of an app that appID depends on which
+// didn't deploy, or an empty string if there is none
+func missingDependency(graph models.DependencyGraph, appID string, undeployed map[string]string) string {
+	for id := range graph.Requires(appID) {
+		if name, ok := undeployed[id]; ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// awaitBuilds waits until every one of the builds finished and returns them
+// as they ended. It reports false if the orchestrator stopped first.
+func (o *Orchestrator) awaitBuilds(builds []*models.Build) ([]*models.Build, bool) {
+	ticker := time.NewTicker(waveCheckInterval)
+	defer ticker.Stop()
+
+	pending := builds
+	var finished []*models.Build
+	for len(pending) > 0 {
+		select {
+		case <-o.ctx.Done():
+			return nil, false
+		case <-ticker.C:
+		}
+
+		var still []*models.Build
+		for _, build := range pending {
+			current, err := o.buildQueries.GetByID(o.ctx, build.ID)
+			if err != nil {
+				still = append(still, build)
+				continue
+			}
+			if current == nil {
+				// Deleted with its app, which won't deploy then
+				build.Status = models.BuildStatusCancelled
+				finished = append(finished, build)
+				continue
+			}
+			if !current.IsComplete() {
+				still = append(still, current)
+				continue
+			}
+			finished = append(finished, current)
+		}
+		pending = still
+	}
+	return finished, true
+}
+
+// cancelDependent cancels the waiting build of an app that depends on an app
+// which didn't deploy
+func (o *Orchestrator) cancelDependent(build *models.Build, dependency string) {
+	ctx, cancel := context.WithTimeout(o.ctx, 10*time.Second)
+	defer cancel()
+
+	o.unlinkTrace(build.ID)
+	reason := fmt.Sprintf("Cancelled: %s, which it depends on, did not deploy", dependency)
+	ok, err := o.buildQueries.Supersede(ctx, build.ID, reason)
+	if err != nil {
+		o.logger.Error("failed to cancel dependent build", "buildID", build.ID, "error", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	o.appendSystemLog(ctx, build.ID, reason)
+	o.events.Publish(events.Event{Kind: events.KindBuild, AppID: build.AppID, BuildID: build.ID, Status: string(models.BuildStatusCancelled)})
+	o.logger.Info("dependent build cancelled", "buildID", build.ID, "app", build.AppName, "dependency", dependency)
+}
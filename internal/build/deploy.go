@@ -0,0 +1,194 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"schooner/internal/database"
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+// deployment is what the deploy step of a build works with
+type deployment struct {
+	app           *models.App
+	build         *models.Build
+	strategy      Strategy
+	buildStrategy models.BuildStrategy
+	opts          BuildOptions
+	result        *BuildResult
+	deployConfig  *models.DeployConfig
+	runner        containerRunner
+	remote        bool
+	onAgent       bool
+	logger        *slog.Logger
+	github        *githubDeployment // Set while the deploy is mirrored to GitHub
+}
+
+// deploy runs the image a build produced, or brings up its compose project.
+// It reports whether the deploy replaces Schooner itself, in which case the
+// build is already marked successful.
+func (o *Orchestrator) deploy(ctx context.Context, d *deployment) (bool, error) {
+	app, build, logWriter, logger := d.app, d.build, d.opts.LogWriter, d.logger
+
+	// Update status to deploying
+	build.Status = models.BuildStatusDeploying
+	o.saveBuild(ctx, build)
+	fmt.Fprintf(logWriter, "\n--- Deploying ---\n\n")
+
+	// Capture previous image for potential rollback (Dockerfile strategy only)
+	var previousImage string
+	if d.buildStrategy != models.BuildStrategyCompose {
+		if status, err := d.runner.GetContainerStatus(ctx, app.GetContainerName()); err == nil && status != nil {
+			previousImage = status.Image
+			fmt.Fprintf(logWriter, "Previous image: %s (for rollback)\n", previousImage)
+		}
+	}
+
+	// Check for self-deployment, which can only happen on our own daemon
+	isSelfDeploy := !d.remote && !d.onAgent && !d.deployConfig.IsSwarm() && o.isSelfDeploy(app.GetContainerName())
+	if isSelfDeploy {
+		fmt.Fprintf(logWriter, "⚠️  Self-deployment detected - using fire-and-forget deploy\n")
+	}
+
+	// Deploy based on strategy
+	if d.buildStrategy == models.BuildStrategyCompose {
+		// For compose, run docker compose up
+		composeStrategy := d.strategy.(composeStrategyWrapper)
+
+		var err error
+		if isSelfDeploy {
+			err = composeStrategy.UpSelfDeploy(ctx, d.opts)
+			if err == nil {
+				o.completeSelfDeploy(build, logWriter, logger)
+				return true, nil
+			}
+		} else {
+			err = composeStrategy.Up(ctx, d.opts)
+		}
+
+		if err != nil {
+			logger.Error("deploy failed", "error", err)
+			fmt.Fprintf(logWriter, "ERROR: Deploy failed: %s\n", err)
+			return false, fmt.Errorf("deploy failed: %w", err)
+		}
+
+		o.recordImageDigests(ctx, d.strategy, d.opts, build, logWriter)
+		return false, nil
+	}
+
+	if isSelfDeploy {
+		// Dockerfile self-deployment: use helper container
+		fmt.Fprintf(logWriter, "Self-deployment via helper container...\n")
+
+		if err := o.selfDeployDockerfile(ctx, app, d.result.ImageTag, logWriter); err != nil {
+			logger.Error("self-deploy failed", "error", err)
+			fmt.Fprintf(logWriter, "ERROR: Self-deploy failed: %s\n", err)
+			return false, fmt.Errorf("self-deploy failed: %w", err)
+		}
+
+		o.completeSelfDeploy(build, logWriter, logger)
+		return true, nil
+	}
+
+	// For other strategies, run container
+	fmt.Fprintf(logWriter, "Deploying container: %s\n", app.GetContainerName())
+
+	containerConfig := docker.ContainerConfig{
+		Name:          app.GetContainerName(),
+		Image:         d.result.ImageTag,
+		Env:           envMapToSlice(d.opts.EnvVars),
+		RestartPolicy: "unless-stopped",
+		Labels: map[string]string{
+			"schooner.managed":  "true",
+			"schooner.app":      app.Name,
+			"schooner.app-id":   app.ID,
+			"schooner.build-id": build.ID,
+		},
+		Devices: d.deployConfig.Devices,
+		CapAdd:  d.deployConfig.CapAdd,
+		CapDrop: d.deployConfig.CapDrop,
+		Sysctls: d.deployConfig.Sysctls,
+		ShmSize: d.deployConfig.GetShmSize(),
+		Runtime: d.deployConfig.Runtime,
+	}
+	// Validated when the app was saved
+	containerConfig.GPUCount, containerConfig.GPUIDs, _ = d.deployConfig.GPURequest()
+	if containerConfig.GPUCount != 0 || len(containerConfig.GPUIDs) > 0 {
+		fmt.Fprintf(logWriter, "Requesting GPUs: %s\n", d.deployConfig.GPUs)
+	}
+	applyRouting(&containerConfig, d.opts.Routing)
+	if d.opts.AppNetwork != "" {
+		containerConfig.Networks = append(containerConfig.Networks, d.opts.AppNetwork)
+	}
+	containerConfig.Networks = append(containerConfig.Networks, d.opts.Networks...)
+
+	var containerID string
+	var err error
+	if d.deployConfig.IsSwarm() {
+		containerID, err = d.opts.Docker.DeployService(ctx, docker.ServiceConfig{
+			ContainerConfig:   containerConfig,
+			Replicas:          d.deployConfig.GetReplicas(),
+			UpdateParallelism: d.deployConfig.GetUpdateParallelism(),
+			UpdateDelay:       d.deployConfig.GetUpdateDelay(),
+			UpdateOrder:       d.deployConfig.GetUpdateOrder(),
+		})
+		if err != nil {
+			// Swarm rolls failed updates back on its own
+			logger.Error("deploy failed", "error", err)
+			fmt.Fprintf(logWriter, "ERROR: Deploy failed: %s\n", err)
+			return false, fmt.Errorf("deploy failed: %w", err)
+		}
+		fmt.Fprintf(logWriter, "Service updated: %s (%d replicas)\n", containerID[:12], d.deployConfig.GetReplicas())
+		return false, nil
+	}
+
+	containerID, err = d.runner.RunContainer(ctx, containerConfig)
+	if err != nil {
+		logger.Error("deploy failed", "error", err)
+		fmt.Fprintf(logWriter, "ERROR: Deploy failed: %s\n", err)
+
+		// Attempt rollback if we have a previous image
+		if previousImage != "" {
+			fmt.Fprintf(logWriter, "\n--- Attempting Rollback ---\n")
+			fmt.Fprintf(logWriter, "Restoring previous image: %s\n", previousImage)
+
+			rollbackConfig := containerConfig
+			rollbackConfig.Image = previousImage
+			delete(rollbackConfig.Labels, "schooner.build-id") // Don't associate with failed build
+
+			if rollbackID, rollbackErr := d.runner.RunContainer(ctx, rollbackConfig); rollbackErr == nil {
+				fmt.Fprintf(logWriter, "✓ Rollback successful: %s\n", rollbackID[:12])
+				logger.Info("rollback successful", "previousImage", previousImage)
+			} else {
+				fmt.Fprintf(logWriter, "✗ Rollback failed: %s\n", rollbackErr)
+				logger.Error("rollback failed", "error", rollbackErr)
+			}
+		}
+
+		return false, fmt.Errorf("deploy failed: %w", err)
+	}
+
+	fmt.Fprintf(logWriter, "Container started: %s\n", containerID[:12])
+	return false, nil
+}
+
+// completeSelfDeploy marks a build that replaces Schooner successful right
+// away, since Schooner is about to be stopped
+func (o *Orchestrator) completeSelfDeploy(build *models.Build, logWriter io.Writer, logger *slog.Logger) {
+	build.Status = models.BuildStatusSuccess
+	build.FinishedAt = database.NullTime(time.Now())
+	o.saveBuild(context.Background(), build)
+
+	recordBuildMetrics(build)
+	duration := build.Duration()
+	fmt.Fprintf(logWriter, "\n--- Build Complete (self-deploy) ---\n")
+	fmt.Fprintf(logWriter, "Duration: %s\n", duration.Round(time.Second))
+	fmt.Fprintf(logWriter, "Status: SUCCESS\n")
+	fmt.Fprintf(logWriter, "\nContainer will restart momentarily...\n")
+
+	logger.Info("self-deploy initiated", "duration", duration)
+}
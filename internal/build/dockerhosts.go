@@ -0,0 +1,27 @@
+package build
+
+import (
+	"context"
+
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+// DockerHostResolver returns the Docker client for the host an app deploys to
+type DockerHostResolver interface {
+	ForApp(ctx context.Context, app *models.App) (*docker.Client, error)
+}
+
+// SetDockerHosts sets how apps on remote Docker hosts are reached. Without
+// it every app builds and deploys on the local daemon.
+func (o *Orchestrator) SetDockerHosts(hosts DockerHostResolver) {
+	o.dockerHosts = hosts
+}
+
+// dockerFor returns the Docker client an app builds and deploys with
+func (o *Orchestrator) dockerFor(ctx context.Context, app *models.App) (*docker.Client, error) {
+	if o.dockerHosts == nil {
+		return o.dockerClient, nil
+	}
+	return o.dockerHosts.ForApp(ctx, app)
+}
@@ -0,0 +1,102 @@
+package build
+
+import (
+	"context"
+	"fmt"
+
+	"schooner/internal/github"
+	"schooner/internal/models"
+)
+
+// GitHubDeployer records deploys in a GitHub repository's deployment history
+type GitHubDeployer interface {
+	HasToken() bool
+	CreateDeployment(ctx context.Context, owner, repo, ref, environment, description string) (int64, error)
+	CreateDeploymentStatus(ctx context.Context, owner, repo string, deploymentID int64, status github.DeploymentStatus) error
+}
+
+// AppURLResolver finds the URL an app is served at, or empty if it has none
+type AppURLResolver interface {
+	PublicURL(ctx context.Context, app *models.App) string
+}
+
+// SetGitHubDeployments mirrors deploys of apps built from GitHub
+// repositories as GitHub Deployments, linking each to the app's URL
+func (o *Orchestrator) SetGitHubDeployments(deployer GitHubDeployer, urls AppURLResolver) {
+	o.githubDeployer = deployer
+	o.appURLs = urls
+}
+
+// githubDeployment is a deploy being mirrored to GitHub
+type githubDeployment struct {
+	owner string
+	repo  string
+	id    int64
+}
+
+// startGitHubDeployment creates a GitHub Deployment of the build's commit to
+// an environment named after the app and marks it in progress. Deploys go
+// ahead regardless of whether GitHub accepts it.
+func (o *Orchestrator) startGitHubDeployment(ctx context.Context, d *deployment) {
+	if o.githubDeployer == nil || !o.githubDeployer.HasToken() || d.build.GetCommitSHA() == "" {
+		return
+	}
+	owner, repo, err := github.ParseRepoURL(d.app.RepoURL)
+	if err != nil {
+		return
+	}
+
+	description := fmt.Sprintf("Schooner %s build", d.build.Trigger)
+	id, err := o.githubDeployer.CreateDeployment(ctx, owner, repo, d.build.GetCommitSHA(), d.app.Name, description)
+	if err != nil {
+		d.logger.Warn("failed to create GitHub deployment", "error", err)
+		return
+	}
+	d.github = &githubDeployment{owner: owner, repo: repo, id: id}
+	o.setGitHubDeploymentStatus(ctx, d, github.DeploymentInProgress, "Deploying")
+}
+
+// finishGitHubDeployment reports how the deploy a GitHub Deployment mirrors
+// ended: successful if err is nil, failed otherwise
+func (o *Orchestrator) finishGitHubDeployment(d *deployment, err error) {
+	if d.github == nil {
+		return
+	}
+	// The build's context may be cancelled by now
+	ctx := context.Background()
+	if err != nil {
+		o.setGitHubDeploymentStatus(ctx, d, github.DeploymentFailure, err.Error())
+		return
+	}
+	o.setGitHubDeploymentStatus(ctx, d, github.DeploymentSuccess, "Deployed")
+}
+
+// setGitHubDeploymentStatus updates a mirrored deploy's state, linking to
+// the app and the build's log
+func (o *Orchestrator) setGitHubDeploymentStatus(ctx context.Context, d *deployment, state, description string) {
+	status := github.DeploymentStatus{
+		State:       state,
+		Description: truncateDescription(description),
+	}
+	if o.appURLs != nil {
+		status.EnvironmentURL = o.appURLs.PublicURL(ctx, d.app)
+	}
+	if o.notifier != nil {
+		status.LogURL = o.notifier.BaseURL() + "/builds/" + d.build.ID
+	}
+
+	if err := o.githubDeployer.CreateDeploymentStatus(ctx, d.github.owner, d.github.repo, d.github.id, status); err != nil {
+		d.logger.Warn("failed to update GitHub deployment", "state", state, "error", err)
+	}
+}
+
+// truncateDescription shortens a description to the 140 characters GitHub
+// accepts
+func truncateDescription(description string) string {
+	const maxLength = 140
+	runes := []rune(description)
+	if len(runes) <= maxLength {
+		return description
+	}
+	return string(runes[:maxLength-1]) + "…"
+}
@@ -0,0 +1,111 @@
+package build
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"schooner/internal/github"
+	"schooner/internal/models"
+)
+
+type fakeGitHubDeployer struct {
+	token    bool
+	created  []string
+	statuses []github.DeploymentStatus
+}
+
+func (f *fakeGitHubDeployer) HasToken() bool { return f.token }
+
+func (f *fakeGitHubDeployer) CreateDeployment(ctx context.Context, owner, repo, ref, environment, description string) (int64, error) {
+	f.created = append(f.created, strings.Join([]string{owner, repo, ref, environment}, " "))
+	return 42, nil
+}
+
+func (f *fakeGitHubDeployer) CreateDeploymentStatus(ctx context.Context, owner, repo string, deploymentID int64, status github.DeploymentStatus) error {
+	f.statuses = append(f.statuses, status)
+	return nil
+}
+
+type fakeAppURLs map[string]string
+
+func (f fakeAppURLs) PublicURL(ctx context.Context, app *models.App) string { return f[app.ID] }
+
+func TestGitHubDeployment(t *testing.T) {
+	tests := []struct {
+		name      string
+		token     bool
+		repoURL   string
+		deployErr error
+		created   []string
+		states    []string
+	}{
+		{
+			name:    "success",
+			token:   true,
+			repoURL: "https://github.com/acme/web.git",
+			created: []string{"acme web 0123abcd web"},
+			states:  []string{github.DeploymentInProgress, github.DeploymentSuccess},
+		},
+		{
+			name:      "failure",
+			token:     true,
+			repoURL:   "git@github.com:acme/web.git",
+			deployErr: errors.New("container exited"),
+			created:   []string{"acme web 0123abcd web"},
+			states:    []string{github.DeploymentInProgress, github.DeploymentFailure},
+		},
+		{
+			name:    "no token",
+			repoURL: "https://github.com/acme/web.git",
+		},
+		{
+			name:    "not on GitHub",
+			token:   true,
+			repoURL: "https://gitea.local/acme/web.git",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployer := &fakeGitHubDeployer{token: tt.token}
+			o := NewOrchestrator(nil, nil, nil, nil, nil)
+			o.SetGitHubDeployments(deployer, fakeAppURLs{"a1": "https://web.example.com"})
+
+			d := &deployment{
+				app:    &models.App{ID: "a1", Name: "web", RepoURL: tt.repoURL},
+				build:  &models.Build{ID: "b1", Trigger: models.TriggerWebhook, CommitSHA: sql.NullString{String: "0123abcd", Valid: true}},
+				logger: slog.Default(),
+			}
+			o.startGitHubDeployment(context.Background(), d)
+			o.finishGitHubDeployment(d, tt.deployErr)
+
+			if !reflect.DeepEqual(deployer.created, tt.created) {
+				t.Errorf("created = %v, want %v", deployer.created, tt.created)
+			}
+			var states []string
+			for _, status := range deployer.statuses {
+				states = append(states, status.State)
+				if status.EnvironmentURL != "https://web.example.com" {
+					t.Errorf("environment URL = %q, want the app's URL", status.EnvironmentURL)
+				}
+			}
+			if !reflect.DeepEqual(states, tt.states) {
+				t.Errorf("states = %v, want %v", states, tt.states)
+			}
+		})
+	}
+}
+
+func TestTruncateDescription(t *testing.T) {
+	if got := truncateDescription("Deployed"); got != "Deployed" {
+		t.Errorf("truncateDescription() = %q, want it unchanged", got)
+	}
+	if got := []rune(truncateDescription(strings.Repeat("x", 200))); len(got) != 140 || got[139] != '…' {
+		t.Errorf("truncateDescription() = %q, want 140 characters ending in an ellipsis", string(got))
+	}
+}
@@ -0,0 +1,58 @@
+package build
+
+import (
+	"net/url"
+	"time"
+
+	"schooner/internal/models"
+)
+
+// buildArgs are the build args every Dockerfile build gets, for images to
+// bake in the version they were built from
+func buildArgs(version, commitSHA string, build *models.Build) map[string]string {
+	args := map[string]string{
+		"VERSION":    version,
+		"BUILD_DATE": build.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if commitSHA != "" {
+		args["GIT_SHA"] = commitSHA
+	}
+	return args
+}
+
+// imageLabels are the standard OCI annotations describing the build that
+// made an image
+func imageLabels(app *models.App, build *models.Build, version, commitSHA string) map[string]string {
+	labels := map[string]string{
+		"org.opencontainers.image.title":   app.Name,
+		"org.opencontainers.image.version": version,
+		"org.opencontainers.image.created": build.CreatedAt.UTC().Format(time.RFC3339),
+		"schooner.build-id":                build.ID,
+	}
+	if commitSHA != "" {
+		labels["org.opencontainers.image.revision"] = commitSHA
+	}
+	if description := app.GetDescription(); description != "" {
+		labels["org.opencontainers.image.description"] = description
+	}
+	if source := sourceURL(app.RepoURL); source != "" {
+		labels["org.opencontainers.image.source"] = source
+	}
+	if ref := build.GetTag(); ref != "" {
+		labels["org.opencontainers.image.ref.name"] = ref
+	} else if ref := build.GetBranch(); ref != "" {
+		labels["org.opencontainers.image.ref.name"] = ref
+	}
+	return labels
+}
+
+// sourceURL returns a repo URL without any credentials in it, empty for
+// local paths
+func sourceURL(repoURL string) string {
+	u, err := url.Parse(repoURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	u.User = nil
+	return u.String()
+}
@@ -0,0 +1,307 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"schooner/internal/database"
+	"schooner/internal/models"
+)
+
+// Build output is written to the database in batches, so chatty builds
+// don't insert a row per line. Log streams get each line straight away.
+const (
+	logFlushLines       = 100
+	logFlushInterval    = 500 * time.Millisecond
+	logRetryLines       = 10 * logFlushLines // Lines held back while the database is locked
+	logSubscriberBuffer = 1000
+)
+
+// buildLogWriter writes the output of a running build to the database and
+// to anyone streaming its log
+type buildLogWriter struct {
+	buildID string
+	o       *Orchestrator
+
+	flushMu       sync.Mutex // Held while lines are inserted; taken before mu
+	mu            sync.Mutex
+	partial       []byte             // Output after the last newline
+	stderrPartial []byte             // Same for stderr
+	pending       []*models.BuildLog // Lines not yet in the database
+	timer         *time.Timer        // Flushes pending lines that didn't fill a batch
+	subscribers   map[chan models.BuildLog]struct{}
+	closed        bool
+}
+
+// newBuildLogWriter creates the log writer of a build and makes its output
+// available to SubscribeLogs until it's closed
+func (o *Orchestrator) newBuildLogWriter(buildID string) *buildLogWriter {
+	w := &buildLogWriter{
+		buildID:     buildID,
+		o:           o,
+		subscribers: make(map[chan models.BuildLog]struct{}),
+	}
+
+	o.logWritersMu.Lock()
+	o.logWriters[buildID] = w
+	o.logWritersMu.Unlock()
+
+	return w
+}
+
+// Write takes build output from stdout
+func (w *buildLogWriter) Write(p []byte) (n int, err error) {
+	return w.write(p, &w.partial, models.LogStreamStdout)
+}
+
+// Stderr returns a writer for the build's output on stderr
+func (w *buildLogWriter) Stderr() io.Writer {
+	return stderrLogWriter{w}
+}
+
+// stderrLogWriter is the stderr side of a buildLogWriter
+type stderrLogWriter struct {
+	w *buildLogWriter
+}
+
+func (s stderrLogWriter) Write(p []byte) (n int, err error) {
+	return s.w.write(p, &s.w.stderrPartial, models.LogStreamStderr)
+}
+
+func (w *buildLogWriter) write(p []byte, partial *[]byte, stream models.LogStream) (n int, err error) {
+	w.mu.Lock()
+	*partial = append(*partial, p...)
+
+	// Process complete lines
+	for {
+		idx := bytes.IndexByte(*partial, '\n')
+		if idx == -1 {
+			break
+		}
+		line := string((*partial)[:idx])
+		*partial = (*partial)[idx+1:]
+		w.addLineLocked(line, stream)
+	}
+	full := w.scheduleFlushLocked()
+	w.mu.Unlock()
+
+	if full {
+		w.Flush()
+	}
+	return len(p), nil
+}
+
+// addLineLocked queues a line of build output, with its terminal colors
+// turned into styles and its level guessed from what it says
+func (w *buildLogWriter) addLineLocked(line string, stream models.LogStream) {
+	message, styles := models.ParseANSI(line)
+	if strings.TrimSpace(message) == "" {
+		return
+	}
+
+	w.addLocked(&models.BuildLog{
+		BuildID:   w.buildID,
+		Level:     models.DetectLogLevel(message),
+		Message:   message,
+		Source:    models.LogSourceDocker,
+		Stream:    stream,
+		Styles:    styles,
+		Progress:  models.LogProgressKey(message),
+		Timestamp: time.Now(),
+	})
+}
+
+// Append queues a log entry that isn't build output, keeping it in order
+// with the lines around it
+func (w *buildLogWriter) Append(log *models.BuildLog) {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		w.o.logQueries.Append(ctx, log)
+		return
+	}
+	w.addLocked(log)
+	full := w.scheduleFlushLocked()
+	w.mu.Unlock()
+
+	if full {
+		w.Flush()
+	}
+}
+
+// scheduleFlushLocked reports whether a full batch is waiting to be written,
+// or otherwise makes sure a smaller one is written soon
+func (w *buildLogWriter) scheduleFlushLocked() bool {
+	if len(w.pending) >= logFlushLines {
+		return true
+	}
+	if len(w.pending) > 0 && w.timer == nil {
+		w.timer = time.AfterFunc(logFlushInterval, w.Flush)
+	}
+	return false
+}
+
+// addLocked queues a log entry for the database and sends it to the log
+// streams
+func (w *buildLogWriter) addLocked(log *models.BuildLog) {
+	w.pending = append(w.pending, log)
+
+	for ch := range w.subscribers {
+		select {
+		case ch <- *log:
+		default:
+			// A stream that can't keep up carries on from the database
+			delete(w.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Flush writes the pending lines to the database. The lines are taken off
+// pending first, so the build's output isn't held up by the insert.
+func (w *buildLogWriter) Flush() {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	batch := w.takePendingLocked()
+	w.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+
+	err := w.insert(batch)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if database.IsBusy(err) && len(batch)+len(w.pending) < logRetryLines && !w.closed {
+		// Another process has the database locked, try again with the next
+		// batch rather than lose these lines
+		slog.Debug("database locked, retrying build logs", "buildID", w.buildID, "lines", len(batch))
+		w.pending = append(batch, w.pending...)
+		if w.timer == nil {
+			w.timer = time.AfterFunc(logFlushInterval, w.Flush)
+		}
+		return
+	}
+	if err != nil {
+		slog.Warn("failed to write build logs", "buildID", w.buildID, "lines", len(batch), "error", err)
+	}
+}
+
+// takePendingLocked stops the flush timer and hands over the pending lines
+func (w *buildLogWriter) takePendingLocked() []*models.BuildLog {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	batch := w.pending
+	w.pending = nil
+	return batch
+}
+
+// insert writes a batch of lines to the database
+func (w *buildLogWriter) insert(batch []*models.BuildLog) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return w.o.logQueries.AppendBatch(ctx, batch)
+}
+
+// Close writes out what's left of the build's output and ends its log
+// streams
+func (w *buildLogWriter) Close() {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	if len(w.partial) > 0 {
+		w.addLineLocked(string(w.partial), models.LogStreamStdout)
+		w.partial = nil
+	}
+	if len(w.stderrPartial) > 0 {
+		w.addLineLocked(string(w.stderrPartial), models.LogStreamStderr)
+		w.stderrPartial = nil
+	}
+	batch := w.takePendingLocked()
+	w.closed = true
+	w.mu.Unlock()
+
+	if len(batch) > 0 {
+		if err := w.insert(batch); err != nil {
+			slog.Warn("failed to write build logs", "buildID", w.buildID, "lines", len(batch), "error", err)
+		}
+	}
+
+	// Streams end only once their lines are in the database
+	w.mu.Lock()
+	for ch := range w.subscribers {
+		delete(w.subscribers, ch)
+		close(ch)
+	}
+	w.mu.Unlock()
+
+	w.o.logWritersMu.Lock()
+	if w.o.logWriters[w.buildID] == w {
+		delete(w.o.logWriters, w.buildID)
+	}
+	w.o.logWritersMu.Unlock()
+}
+
+// logWriter returns the log writer of a running build, if any
+func (o *Orchestrator) logWriter(buildID string) *buildLogWriter {
+	o.logWritersMu.Lock()
+	defer o.logWritersMu.Unlock()
+	return o.logWriters[buildID]
+}
+
+// SubscribeLogs returns a build's log so far and, while the build runs, a
+// channel of the lines it writes after that. The channel is closed once the
+// build stops or if the reader falls behind; the lines it carried are in the
+// database by the time the build stops. Call unsubscribe when done.
+func (o *Orchestrator) SubscribeLogs(ctx context.Context, buildID string) (logs []*models.BuildLog, live <-chan models.BuildLog, unsubscribe func(), err error) {
+	unsubscribe = func() {}
+
+	w := o.logWriter(buildID)
+	if w == nil {
+		logs, err = o.logQueries.GetByBuildID(ctx, buildID)
+		return logs, nil, unsubscribe, err
+	}
+
+	// Holding the writer's locks keeps lines from moving from pending to the
+	// database while the history is read, so none are missed or repeated
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	logs, err = o.logQueries.GetByBuildID(ctx, buildID)
+	if err != nil {
+		return nil, nil, unsubscribe, err
+	}
+	for _, log := range w.pending {
+		copied := *log
+		logs = append(logs, &copied)
+	}
+	if w.closed {
+		return logs, nil, unsubscribe, nil
+	}
+
+	ch := make(chan models.BuildLog, logSubscriberBuffer)
+	w.subscribers[ch] = struct{}{}
+	unsubscribe = func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if _, ok := w.subscribers[ch]; ok {
+			delete(w.subscribers, ch)
+			close(ch)
+		}
+	}
+	return logs, ch, unsubscribe, nil
+}
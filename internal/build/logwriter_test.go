@@ -0,0 +1,116 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+func TestBuildLogWriter(t *testing.T) {
+	db := newTestDB(t)
+
+	ctx := context.Background()
+	appQueries := queries.NewAppQueries(db.DB)
+	buildQueries := queries.NewBuildQueries(db.DB)
+	logQueries := queries.NewLogQueries(db.DB)
+	o := NewOrchestrator(nil, nil, appQueries, buildQueries, logQueries)
+	defer o.cancel()
+
+	app := &models.App{
+		ID: "app1", Name: "web", RepoURL: "https://github.com/example/web.git", Branch: "main",
+		BuildStrategy: models.BuildStrategyDockerfile, CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}
+	if err := appQueries.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	build := &models.Build{
+		ID: "build1", AppID: app.ID, Status: models.BuildStatusBuilding, Trigger: models.TriggerManual,
+		CreatedAt: time.Now(),
+	}
+	if err := buildQueries.Create(ctx, build); err != nil {
+		t.Fatal(err)
+	}
+
+	stored := func() []*models.BuildLog {
+		logs, err := logQueries.GetByBuildID(ctx, build.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return logs
+	}
+
+	w := o.newBuildLogWriter(build.ID)
+
+	// A partial batch waits in memory but is already visible to streams
+	fmt.Fprint(w, "one\ntwo\n\nthr")
+	if logs := stored(); len(logs) != 0 {
+		t.Errorf("stored %d lines before a flush, want 0", len(logs))
+	}
+	history, live, unsubscribe, err := o.SubscribeLogs(ctx, build.ID)
+	if err != nil {
+		t.Fatalf("SubscribeLogs() error = %v", err)
+	}
+	defer unsubscribe()
+	if len(history) != 2 || history[0].Message != "one" || history[1].Message != "two" {
+		t.Errorf("history = %v, want one and two", history)
+	}
+
+	// Lines written afterwards arrive live, system messages included
+	fmt.Fprint(w, "ee\n")
+	o.appendSystemLog(ctx, build.ID, "Deploy approved by ada")
+	for _, want := range []string{"three", "Deploy approved by ada"} {
+		select {
+		case log := <-live:
+			if log.Message != want {
+				t.Errorf("live line = %q, want %q", log.Message, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%q never arrived live", want)
+		}
+	}
+
+	// A full batch goes straight to the database
+	fmt.Fprint(w, strings.Repeat("line\n", logFlushLines))
+	if logs := stored(); len(logs) != logFlushLines+4 {
+		t.Errorf("stored %d lines after a full batch, want %d", len(logs), logFlushLines+4)
+	}
+
+	// Lines on stderr are kept apart and errors are picked out
+	fmt.Fprint(w.Stderr(), "warning: cache miss\nERROR: build failed\n")
+
+	// Whatever is left is written on close, and the streams end
+	fmt.Fprint(w, "last\nunterminated")
+	w.Close()
+	logs := stored()
+	if len(logs) != logFlushLines+8 || logs[len(logs)-1].Message != "unterminated" {
+		t.Fatalf("stored %d lines ending in %q after close, want %d ending in unterminated",
+			len(logs), logs[len(logs)-1].Message, logFlushLines+8)
+	}
+	for _, log := range logs[len(logs)-4:] {
+		switch log.Message {
+		case "warning: cache miss":
+			if log.Stream != models.LogStreamStderr || log.Level != models.LogLevelWarn {
+				t.Errorf("%q = %s %s, want stderr warn", log.Message, log.Stream, log.Level)
+			}
+		case "ERROR: build failed":
+			if log.Stream != models.LogStreamStderr || log.Level != models.LogLevelError {
+				t.Errorf("%q = %s %s, want stderr error", log.Message, log.Stream, log.Level)
+			}
+		default:
+			if log.Stream != models.LogStreamStdout || log.Level != models.LogLevelInfo {
+				t.Errorf("%q = %s %s, want stdout info", log.Message, log.Stream, log.Level)
+			}
+		}
+	}
+	for range live {
+	}
+
+	if _, live, _, _ := o.SubscribeLogs(ctx, build.ID); live != nil {
+		t.Error("expected no live lines once the build stopped")
+	}
+}
@@ -2,33 +2,54 @@ package build
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
+	"schooner/internal/auth"
 	"schooner/internal/database"
 	"schooner/internal/database/queries"
 	"schooner/internal/docker"
+	"schooner/internal/events"
 	"schooner/internal/git"
+	"schooner/internal/metrics"
 	"schooner/internal/models"
+	"schooner/internal/notify"
+	"schooner/internal/tracing"
 )
 
 // Orchestrator coordinates build execution
 type Orchestrator struct {
-	strategies   map[models.BuildStrategy]Strategy
-	gitClient    *git.Client
-	dockerClient *docker.Client
-	appQueries   *queries.AppQueries
-	buildQueries *queries.BuildQueries
-	logQueries   *queries.LogQueries
-	logger       *slog.Logger
+	strategies       map[models.BuildStrategy]Strategy
+	gitClient        *git.Client
+	dockerClient     *docker.Client
+	appQueries       *queries.AppQueries
+	buildQueries     *queries.BuildQueries
+	logQueries       *queries.LogQueries
+	stepQueries      *queries.BuildStepQueries
+	notifier         *notify.Dispatcher
+	routeLabeler     RouteLabeler
+	addonProvider    AddonProvider
+	addonFinder      AddonFinder
+	templateRenderer TemplateRenderer
+	dockerHosts      DockerHostResolver
+	agents           AgentDispatcher
+	deploySchedules  DeployScheduleSource
+	configRevisions  *queries.ConfigRevisionQueries
+	githubDeployer   GitHubDeployer
+	appURLs          AppURLResolver
+	artifactsDir     string
+	events           *events.Bus
+	logger           *slog.Logger
 
 	// Build queue
 	buildQueue chan string
@@ -36,9 +57,25 @@ type Orchestrator struct {
 	ctx        context.Context
 	cancel     context.CancelFunc
 
+	// Goroutines running builds, resizable at runtime
+	workers   workerPool
+	workersMu sync.Mutex
+
 	// Per-app locks to prevent concurrent builds for the same app
 	appLocks   map[string]*sync.Mutex
 	appLocksMu sync.Mutex
+
+	// Log writers of running builds, for live log streaming
+	logWriters   map[string]*buildLogWriter
+	logWritersMu sync.Mutex
+
+	// Webhook builds waiting out their app's debounce window, by app ID
+	debounced   map[string]*debouncedBuild
+	debouncedMu sync.Mutex
+
+	// Spans builds were queued under, by build ID, until a worker runs them
+	traces   map[string]trace.SpanContext
+	tracesMu sync.Mutex
 }
 
 // NewOrchestrator creates a new build orchestrator
@@ -63,6 +100,9 @@ func NewOrchestrator(
 		ctx:          ctx,
 		cancel:       cancel,
 		appLocks:     make(map[string]*sync.Mutex),
+		logWriters:   make(map[string]*buildLogWriter),
+		debounced:    make(map[string]*debouncedBuild),
+		traces:       make(map[string]trace.SpanContext),
 	}
 
 	return o
@@ -73,20 +113,59 @@ func (o *Orchestrator) RegisterStrategy(strategy Strategy) {
 	o.strategies[strategy.Name()] = strategy
 }
 
+// SetStepQueries sets where the status of each pipeline step is recorded
+func (o *Orchestrator) SetStepQueries(stepQueries *queries.BuildStepQueries) {
+	o.stepQueries = stepQueries
+}
+
+// SetNotifier sets the dispatcher used for build failure notifications
+func (o *Orchestrator) SetNotifier(notifier *notify.Dispatcher) {
+	o.notifier = notifier
+}
+
+// SetEvents sets where build status changes are published
+func (o *Orchestrator) SetEvents(bus *events.Bus) {
+	o.events = bus
+}
+
+// saveBuild stores a build's progress and publishes its status
+func (o *Orchestrator) saveBuild(ctx context.Context, build *models.Build) {
+	if err := o.buildQueries.Update(ctx, build); err != nil {
+		o.logger.Warn("failed to save build", "buildID", build.ID, "error", err)
+	}
+	o.publishBuild(build)
+}
+
+// publishBuild tells the pages showing a build its status changed
+func (o *Orchestrator) publishBuild(build *models.Build) {
+	o.events.Publish(events.Event{
+		Kind:    events.KindBuild,
+		AppID:   build.AppID,
+		BuildID: build.ID,
+		Status:  string(build.Status),
+	})
+}
+
 // Start begins processing builds
 func (o *Orchestrator) Start(workers int) {
 	o.logger.Info("starting build orchestrator", "workers", workers)
 
-	for i := 0; i < workers; i++ {
-		o.wg.Add(1)
-		go o.worker(i)
-	}
+	o.SetWorkers(workers)
+
+	o.wg.Add(1)
+	go o.releaseScheduled()
 }
 
 // Stop gracefully stops the orchestrator
 func (o *Orchestrator) Stop() {
 	o.logger.Info("stopping build orchestrator")
+
+	// Hold the pool so no worker is added while the workers are waited on
+	o.workersMu.Lock()
 	o.cancel()
+	o.workersMu.Unlock()
+
+	o.stopDebounced()
 	close(o.buildQueue)
 	o.wg.Wait()
 }
@@ -97,6 +176,7 @@ func (o *Orchestrator) QueueBuild(buildID string) {
 	case o.buildQueue <- buildID:
 		o.logger.Debug("build queued", "buildID", buildID)
 	default:
+		o.unlinkTrace(buildID)
 		o.logger.Warn("build queue full, dropping build", "buildID", buildID)
 	}
 }
@@ -115,23 +195,6 @@ func (o *Orchestrator) getAppLock(appID string) *sync.Mutex {
 	return lock
 }
 
-// worker processes builds from the queue
-func (o *Orchestrator) worker(id int) {
-	defer o.wg.Done()
-
-	for {
-		select {
-		case <-o.ctx.Done():
-			return
-		case buildID, ok := <-o.buildQueue:
-			if !ok {
-				return
-			}
-			o.processBuild(buildID)
-		}
-	}
-}
-
 // Build timeout (1 hour)
 const buildTimeout = 1 * time.Hour
 
@@ -150,6 +213,9 @@ func (o *Orchestrator) processBuild(buildID string) {
 		return
 	}
 
+	ctx, span := o.traceBuild(ctx, build)
+	defer span.End()
+
 	// Acquire per-app lock to prevent concurrent builds for the same app
 	appLock := o.getAppLock(build.AppID)
 	appLock.Lock()
@@ -165,48 +231,154 @@ func (o *Orchestrator) processBuild(buildID string) {
 
 	logger = logger.With("app", app.Name)
 	logger.Info("starting build (app locked)")
+	span.SetAttributes(attribute.String("app.name", app.Name))
 
 	// Create log writer
-	logWriter := newBuildLogWriter(build.ID, o.logQueries)
+	logWriter := o.newBuildLogWriter(build.ID)
+	defer logWriter.Close()
+
+	// Steps still running when the build stops failed with it
+	steps := newStepRecorder(o.stepQueries, build.ID)
+	defer steps.abort(build)
+
+	// Builds that waited to deploy carry on from where they stopped, on the
+	// commit they built
+	resuming := build.StartedAt.Valid
+	if resuming {
+		if err := steps.load(ctx); err != nil {
+			logger.Warn("failed to load build steps", "error", err)
+		}
+		fmt.Fprintf(logWriter, "\n--- Resuming Build ---\n")
+	}
 
-	// Update build status to cloning
-	build.Status = models.BuildStatusCloning
-	build.StartedAt = database.NullTime(time.Now())
-	o.buildQueries.Update(ctx, build)
-
-	// Clone/pull repository
-	fmt.Fprintf(logWriter, "Cloning repository: %s\n", app.RepoURL)
-	fmt.Fprintf(logWriter, "Branch: %s\n", app.Branch)
-
-	repo, err := o.gitClient.CloneOrPull(ctx, git.CloneOptions{
-		URL:      app.RepoURL,
-		Branch:   app.Branch,
-		Depth:    1,
-		Progress: logWriter,
-	})
+	// Redeploys check out the commit of the build they pin to and run its
+	// images
+	pinnedImages, err := o.pinnedImages(ctx, build)
 	if err != nil {
-		logger.Error("clone failed", "error", err)
-		fmt.Fprintf(logWriter, "\nERROR: Failed to clone repository: %s\n", err)
-		o.failBuild(ctx, build, fmt.Sprintf("clone failed: %v", err))
+		logger.Error("failed to load pinned build", "error", err)
+		fmt.Fprintf(logWriter, "ERROR: Failed to load pinned build: %s\n", err)
+		o.failBuild(ctx, build, fmt.Sprintf("failed to load pinned build: %v", err))
 		return
 	}
+	pinnedCommit := ""
+	if build.GetPinnedBuildID() != "" {
+		pinnedCommit = build.GetCommitSHA()
+		fmt.Fprintf(logWriter, "Redeploying build %s with %d pinned images\n", build.GetPinnedBuildID()[:8], len(pinnedImages))
+	}
+	if resuming || build.Trigger == models.TriggerConfig {
+		pinnedCommit = build.GetCommitSHA()
+	}
+
+	// Update build status to cloning
+	cloneStep := steps.add(ctx, StepClone, "")
+	cloneCtx := steps.start(ctx, cloneStep)
+	build.Status = models.BuildStatusCloning
+	if !resuming {
+		build.StartedAt = database.NullTime(time.Now())
+	}
+	o.saveBuild(ctx, build)
+	if !resuming {
+		o.recordStarted(ctx, app, build)
+	}
+
+	var repoPath string
+	if !app.HasRepo() {
+		// Template and adopted apps have no repository, only a compose file
+		if app.Template.Valid {
+			fmt.Fprintf(logWriter, "Rendering template: %s\n", app.Template.String)
+		} else {
+			fmt.Fprintf(logWriter, "Using adopted compose file\n")
+		}
+
+		repoPath, err = o.prepareComposeSpec(app)
+		if err != nil {
+			logger.Error("compose file preparation failed", "error", err)
+			fmt.Fprintf(logWriter, "\nERROR: Failed to prepare compose file: %s\n", err)
+			o.failBuild(ctx, build, fmt.Sprintf("compose file preparation failed: %v", err))
+			return
+		}
+	} else {
+		// Clone/pull repository
+		fmt.Fprintf(logWriter, "Cloning repository: %s\n", app.RepoURL)
+		if tag := build.GetTag(); tag != "" {
+			fmt.Fprintf(logWriter, "Tag: %s\n", tag)
+		} else {
+			fmt.Fprintf(logWriter, "Branch: %s\n", app.Branch)
+		}
+
+		repoPath = CheckoutPath(o.gitClient.WorkDir(), app)
+		o.adoptSharedClone(app, repoPath)
+
+		repo, err := o.gitClient.CloneOrPull(cloneCtx, git.CloneOptions{
+			URL:        app.RepoURL,
+			Branch:     app.Branch,
+			Depth:      1,
+			Progress:   logWriter,
+			Path:       repoPath,
+			Submodules: app.Submodules,
+			LFS:        app.LFS,
+			Tag:        build.GetTag(),
+			Commit:     pinnedCommit,
+		})
+		if err != nil {
+			logger.Error("clone failed", "error", err)
+			fmt.Fprintf(logWriter, "\nERROR: Failed to clone repository: %s\n", err)
+			o.failBuild(ctx, build, fmt.Sprintf("clone failed: %v", err))
+			return
+		}
+
+		// Get commit info
+		commit, err := o.gitClient.GetHeadCommit(repo)
+		if err == nil {
+			build.CommitSHA = database.NullString(commit.Hash.String())
+			build.CommitMessage = database.NullString(commit.Message)
+			build.CommitAuthor = database.NullString(commit.Author.Name)
+			o.saveBuild(ctx, build)
+			span.SetAttributes(attribute.String("build.commit", commit.Hash.String()))
+
+			fmt.Fprintf(logWriter, "\nCommit: %s\n", commit.Hash.String()[:8])
+			fmt.Fprintf(logWriter, "Author: %s\n", commit.Author.Name)
+			fmt.Fprintf(logWriter, "Message: %s\n", commit.Message)
+		}
+	}
 
-	// Get commit info
-	commit, err := o.gitClient.GetHeadCommit(repo)
-	if err == nil {
-		build.CommitSHA = database.NullString(commit.Hash.String())
-		build.CommitMessage = database.NullString(commit.Message)
-		build.CommitAuthor = database.NullString(commit.Author.Name)
-		o.buildQueries.Update(ctx, build)
+	steps.finish(ctx, cloneStep)
 
-		fmt.Fprintf(logWriter, "\nCommit: %s\n", commit.Hash.String()[:8])
-		fmt.Fprintf(logWriter, "Author: %s\n", commit.Author.Name)
-		fmt.Fprintf(logWriter, "Message: %s\n", commit.Message)
+	// The repository's schooner.yaml can replace the default build, test
+	// and deploy pipeline
+	pipeline, pipelineFile, err := LoadPipeline(repoPath, app.GetTestCommand())
+	if err != nil {
+		logger.Error("invalid pipeline", "error", err)
+		fmt.Fprintf(logWriter, "\nERROR: %s\n", err)
+		o.failBuild(ctx, build, err.Error())
+		return
+	}
+	if pipelineFile != "" {
+		fmt.Fprintf(logWriter, "\nPipeline from %s: %s\n", pipelineFile, pipelineSummary(pipeline))
+	}
+	if (o.artifactsDir != "" && len(app.GetArtifactPaths()) > 0) || steps.recorded(StepArtifacts) {
+		pipeline = withArtifacts(pipeline)
+	}
+	// Builds may wait for approval, then for their deploy window, before
+	// deploying
+	if needsApproval(app, build) || steps.recorded(StepApproval) {
+		pipeline = withGate(pipeline, StepApproval)
+	}
+	if o.needsWindow(ctx, app, build) || steps.recorded(StepWindow) {
+		pipeline = withGate(pipeline, StepWindow)
+	}
+	// Builds that waited before this step existed don't validate again
+	var validateStep *models.BuildStep
+	if !resuming || steps.recorded(StepValidate) {
+		validateStep = steps.add(ctx, StepValidate, "")
+	}
+	pipelineSteps := make([]*models.BuildStep, len(pipeline))
+	for i, step := range pipeline {
+		pipelineSteps[i] = steps.add(ctx, step.Name, step.Run)
 	}
 
 	// Determine build strategy (autodetect if needed)
 	buildStrategy := app.BuildStrategy
-	repoPath := o.gitClient.RepoPath(app.RepoURL)
 
 	if buildStrategy == models.BuildStrategyAutodetect {
 		detected, composeFile := o.detectBuildStrategy(repoPath)
@@ -230,15 +402,21 @@ func (o *Orchestrator) processBuild(buildID string) {
 		o.failBuild(ctx, build, fmt.Sprintf("unknown build strategy: %s", buildStrategy))
 		return
 	}
+	span.SetAttributes(attribute.String("build.strategy", string(buildStrategy)))
 
 	// Prepare build options
-	// Use commit SHA for version, fall back to build ID
+	// Use commit SHA for version, fall back to build ID, unless the app
+	// chose how its images are tagged
 	version := build.ID[:8]
 	commitSHA := ""
 	if len(build.CommitSHA.String) >= 8 {
 		version = build.CommitSHA.String[:8]
 		commitSHA = build.CommitSHA.String
 	}
+	imageTag, tagAlias := app.ImageTagStrategy.Tags(build)
+	if app.ImageTagStrategy != models.ImageTagDefault {
+		version = imageTag
+	}
 
 	// Create env vars with git info injected
 	envVars := make(map[string]string)
@@ -251,181 +429,225 @@ func (o *Orchestrator) processBuild(buildID string) {
 		envVars["GIT_COMMIT"] = commitSHA
 	}
 	envVars["VERSION"] = version
+	// Builds of release tags see the release they deploy
+	for k, v := range build.ReleaseEnv() {
+		envVars[k] = v
+	}
 
-	buildOpts := BuildOptions{
-		AppID:        app.ID,
-		AppName:      app.Name,
-		BuildID:      build.ID,
-		RepoPath:     repoPath,
-		ImageName:    app.GetImageName(),
-		Tag:          build.ID[:8],
-		BuildContext: app.BuildContext,
-		Dockerfile:   app.DockerfilePath,
-		ComposeFile:  app.ComposeFile,
-		EnvVars:      envVars,
-		BuildArgs: map[string]string{
-			"VERSION": version,
-		},
-		LogWriter: logWriter,
-	}
-
-	// Validate
-	fmt.Fprintf(logWriter, "\nValidating build configuration...\n")
-	if err := strategy.Validate(ctx, buildOpts); err != nil {
-		logger.Error("validation failed", "error", err)
-		fmt.Fprintf(logWriter, "ERROR: Validation failed: %s\n", err)
-		o.failBuild(ctx, build, fmt.Sprintf("validation failed: %v", err))
+	// Build and deploy on the app's Docker host
+	dockerClient, err := o.dockerFor(ctx, app)
+	if err != nil {
+		logger.Error("failed to connect to Docker host", "error", err)
+		fmt.Fprintf(logWriter, "ERROR: Failed to connect to Docker host: %s\n", err)
+		o.failBuild(ctx, build, fmt.Sprintf("failed to connect to Docker host: %v", err))
 		return
 	}
+	remote := dockerClient.IsRemote()
 
-	// Update status to building
-	build.Status = models.BuildStatusBuilding
-	o.buildQueries.Update(ctx, build)
-	fmt.Fprintf(logWriter, "\n--- Starting Build ---\n\n")
+	// Apps on an agent are built here and their container runs there
+	var runner containerRunner = dockerClient
+	onAgent := app.GetAgent() != ""
+	if onAgent {
+		if o.agents == nil {
+			fmt.Fprintf(logWriter, "ERROR: Agents are not available\n")
+			o.failBuild(ctx, build, "agents are not available")
+			return
+		}
+		if buildStrategy == models.BuildStrategyCompose {
+			fmt.Fprintf(logWriter, "ERROR: Agents only run Dockerfile apps\n")
+			o.failBuild(ctx, build, "agents only run Dockerfile apps")
+			return
+		}
+		runner = agentRunner{agents: o.agents, agentID: app.GetAgent()}
+	}
 
-	// Execute build
-	result, err := strategy.Build(ctx, buildOpts)
+	// Swarm apps run as a service on the host's Swarm instead of a container
+	deployConfig, err := app.GetDeployConfig()
 	if err != nil {
-		logger.Error("build failed", "error", err)
-		fmt.Fprintf(logWriter, "\nERROR: Build failed: %s\n", err)
-		o.failBuild(ctx, build, fmt.Sprintf("build failed: %v", err))
+		fmt.Fprintf(logWriter, "ERROR: %s\n", err)
+		o.failBuild(ctx, build, err.Error())
 		return
 	}
+	if deployConfig.IsSwarm() {
+		if onAgent || buildStrategy == models.BuildStrategyCompose {
+			fmt.Fprintf(logWriter, "ERROR: Swarm deploys need a Dockerfile app on a Docker host\n")
+			o.failBuild(ctx, build, "swarm deploys need a Dockerfile app on a Docker host")
+			return
+		}
+		manager, err := dockerClient.IsSwarmManager(ctx)
+		if err != nil || !manager {
+			fmt.Fprintf(logWriter, "ERROR: Docker host is not a Swarm manager\n")
+			o.failBuild(ctx, build, "docker host is not a swarm manager")
+			return
+		}
+	}
 
-	build.ImageTag = database.NullString(result.ImageTag)
-
-	// Update status to deploying
-	build.Status = models.BuildStatusDeploying
-	o.buildQueries.Update(ctx, build)
-	fmt.Fprintf(logWriter, "\n--- Deploying ---\n\n")
+	// Point the app at its add-ons, unless it sets the variables itself.
+	// Add-ons and the proxy run on the local daemon, out of reach of apps
+	// on remote hosts and agents.
+	var routing *RouteOptions
+	if onAgent {
+		fmt.Fprintf(logWriter, "Deploying to agent, skipping add-ons and proxy labels\n")
+	} else if deployConfig.IsSwarm() {
+		fmt.Fprintf(logWriter, "Deploying to Swarm, skipping add-ons and proxy labels\n")
+	} else if remote {
+		fmt.Fprintf(logWriter, "Deploying to remote Docker host, skipping add-ons and proxy labels\n")
+	} else {
+		addonEnv, err := o.addonEnv(ctx, app)
+		if err != nil {
+			logger.Error("failed to load add-ons", "error", err)
+			fmt.Fprintf(logWriter, "ERROR: Failed to load add-ons: %s\n", err)
+			o.failBuild(ctx, build, fmt.Sprintf("failed to load add-ons: %v", err))
+			return
+		}
+		for k, v := range addonEnv {
+			if _, ok := envVars[k]; !ok {
+				envVars[k] = v
+				fmt.Fprintf(logWriter, "Injected %s from add-on\n", k)
+			}
+		}
+		routing = o.routeOptions(ctx, app)
+	}
 
-	// Capture previous image for potential rollback (Dockerfile strategy only)
-	var previousImage string
-	if buildStrategy != models.BuildStrategyCompose {
-		if status, err := o.dockerClient.GetContainerStatus(ctx, app.GetContainerName()); err == nil && status != nil {
-			previousImage = status.Image
-			fmt.Fprintf(logWriter, "Previous image: %s (for rollback)\n", previousImage)
+	// Keep the app off the default bridge, on a network of its own
+	var appNetwork string
+	if !onAgent && !deployConfig.IsSwarm() {
+		appNetwork, err = ensureAppNetwork(ctx, dockerClient, app)
+		if err != nil {
+			logger.Error("failed to create app network", "error", err)
+			fmt.Fprintf(logWriter, "ERROR: %s\n", err)
+			o.failBuild(ctx, build, err.Error())
+			return
 		}
+		fmt.Fprintf(logWriter, "Joining app network %s\n", appNetwork)
 	}
 
-	// Check for self-deployment
-	isSelfDeploy := o.isSelfDeploy(app.GetContainerName())
-	if isSelfDeploy {
-		fmt.Fprintf(logWriter, "⚠️  Self-deployment detected - using fire-and-forget deploy\n")
+	// Fill in values env vars reference from other apps and add-ons. Other
+	// apps' add-ons are only reachable from this daemon.
+	referenceNetworks, err := o.resolveEnvReferences(ctx, app, envVars)
+	if err != nil {
+		logger.Error("failed to resolve env var references", "error", err)
+		fmt.Fprintf(logWriter, "ERROR: Failed to resolve env var references: %s\n", err)
+		o.failBuild(ctx, build, fmt.Sprintf("failed to resolve env var references: %v", err))
+		return
+	}
+	if onAgent || deployConfig.IsSwarm() || remote {
+		referenceNetworks = nil
+	}
+	for _, network := range referenceNetworks {
+		fmt.Fprintf(logWriter, "Joining network %s of a referenced add-on\n", network)
 	}
 
-	// Deploy based on strategy
-	if buildStrategy == models.BuildStrategyCompose {
-		// For compose, run docker compose up
-		composeStrategy := strategy.(composeStrategyWrapper)
+	buildOpts := BuildOptions{
+		AppID:        app.ID,
+		AppName:      app.Name,
+		BuildID:      build.ID,
+		RepoPath:     repoPath,
+		ImageName:    app.GetImageName(),
+		Tag:          imageTag,
+		TagAlias:     tagAlias,
+		BuildContext: app.BuildContext,
+		Dockerfile:   app.DockerfilePath,
+		ComposeFile:  app.ComposeFile,
+		Profiles:     app.GetComposeProfiles(),
+		EnvVars:      envVars,
+		BuildArgs:    buildArgs(version, commitSHA, build),
+		Labels:       imageLabels(app, build, version, commitSHA),
+		LogWriter:    logWriter,
+		ErrWriter:    logWriter.Stderr(),
+		Routing:      routing,
+		AppNetwork:   appNetwork,
+		Networks:     referenceNetworks,
+		Docker:       dockerClient,
+		PinnedImages: pinnedImages,
+		Isolated:     app.IsolatedBuild,
+		Caches:       app.BuildCacheVolumes(),
+	}
+
+	d := &deployment{
+		app:           app,
+		build:         build,
+		strategy:      strategy,
+		buildStrategy: buildStrategy,
+		opts:          buildOpts,
+		deployConfig:  deployConfig,
+		runner:        runner,
+		remote:        remote,
+		onAgent:       onAgent,
+		logger:        logger,
+	}
+	if resuming {
+		d.result = &BuildResult{ImageTag: build.ImageTag.String}
+	}
+	if validateStep != nil && validateStep.Status != models.StepStatusSuccess {
+		validateCtx := steps.start(ctx, validateStep)
+		if err := o.validateBuild(validateCtx, strategy, buildOpts, logger); err != nil {
+			o.failBuild(ctx, build, err.Error())
+			return
+		}
+		steps.finish(ctx, validateStep)
+	}
+	for i, step := range pipeline {
+		// Steps that passed before the build waited don't run again
+		if pipelineSteps[i].Status == models.StepStatusSuccess {
+			continue
+		}
+		stepCtx := steps.start(ctx, pipelineSteps[i])
 
 		var err error
-		if isSelfDeploy {
-			err = composeStrategy.UpSelfDeploy(ctx, buildOpts)
-			if err == nil {
-				// Mark as success immediately - we're about to be killed
-				build.Status = models.BuildStatusSuccess
-				build.FinishedAt = database.NullTime(time.Now())
-				o.buildQueries.Update(context.Background(), build)
-
-				duration := build.Duration()
-				fmt.Fprintf(logWriter, "\n--- Build Complete (self-deploy) ---\n")
-				fmt.Fprintf(logWriter, "Duration: %s\n", duration.Round(time.Second))
-				fmt.Fprintf(logWriter, "Status: SUCCESS\n")
-				fmt.Fprintf(logWriter, "\nContainer will restart momentarily...\n")
-
-				logger.Info("self-deploy initiated", "duration", duration)
+		selfDeployed := false
+		switch step.Name {
+		case StepBuild:
+			d.result, err = o.buildImage(stepCtx, strategy, buildOpts, build, logger)
+		case StepArtifacts:
+			err = o.extractArtifacts(stepCtx, d)
+		case StepApproval:
+			if !build.IsApproved() {
+				o.awaitApproval(stepCtx, app, build, logWriter, logger)
 				return
 			}
-		} else {
-			err = composeStrategy.Up(ctx, buildOpts)
+			fmt.Fprintf(logWriter, "\nDeploy approved by %s\n", build.ApprovedBy.String)
+		case StepWindow:
+			var reason string
+			var next time.Time
+			if reason, next, err = o.deployHold(stepCtx, app); err == nil && reason != "" {
+				o.holdForWindow(stepCtx, build, reason, next, logWriter, logger)
+				return
+			}
+		case StepDeploy:
+			o.startGitHubDeployment(stepCtx, d)
+			selfDeployed, err = o.deploy(stepCtx, d)
+		default:
+			err = o.runStep(stepCtx, strategy, buildOpts, d.result, step, logger)
 		}
-
 		if err != nil {
-			logger.Error("deploy failed", "error", err)
-			fmt.Fprintf(logWriter, "ERROR: Deploy failed: %s\n", err)
-			o.failBuild(ctx, build, fmt.Sprintf("deploy failed: %v", err))
-			return
-		}
-	} else if isSelfDeploy {
-		// Dockerfile self-deployment: use helper container
-		fmt.Fprintf(logWriter, "Self-deployment via helper container...\n")
-
-		if err := o.selfDeployDockerfile(ctx, app, result.ImageTag, logWriter); err != nil {
-			logger.Error("self-deploy failed", "error", err)
-			fmt.Fprintf(logWriter, "ERROR: Self-deploy failed: %s\n", err)
-			o.failBuild(ctx, build, fmt.Sprintf("self-deploy failed: %v", err))
+			o.finishGitHubDeployment(d, err)
+			o.failBuild(ctx, build, err.Error())
 			return
 		}
 
-		// Mark as success - we're about to be killed
-		build.Status = models.BuildStatusSuccess
-		build.FinishedAt = database.NullTime(time.Now())
-		o.buildQueries.Update(context.Background(), build)
-
-		duration := build.Duration()
-		fmt.Fprintf(logWriter, "\n--- Build Complete (self-deploy) ---\n")
-		fmt.Fprintf(logWriter, "Duration: %s\n", duration.Round(time.Second))
-		fmt.Fprintf(logWriter, "Status: SUCCESS\n")
-		fmt.Fprintf(logWriter, "\nContainer will restart momentarily...\n")
-
-		logger.Info("self-deploy initiated", "duration", duration)
-		return
-	} else {
-		// For other strategies, run container
-		fmt.Fprintf(logWriter, "Deploying container: %s\n", app.GetContainerName())
-
-		containerConfig := docker.ContainerConfig{
-			Name:          app.GetContainerName(),
-			Image:         result.ImageTag,
-			Env:           envMapToSlice(envVars),
-			RestartPolicy: "unless-stopped",
-			Labels: map[string]string{
-				"schooner.managed":  "true",
-				"schooner.app":      app.Name,
-				"schooner.app-id":   app.ID,
-				"schooner.build-id": build.ID,
-			},
-		}
-
-		// Parse deploy config for ports/volumes if set
-		// TODO: Parse app.DeployConfig for additional settings
-
-		containerID, err := o.dockerClient.RunContainer(ctx, containerConfig)
-		if err != nil {
-			logger.Error("deploy failed", "error", err)
-			fmt.Fprintf(logWriter, "ERROR: Deploy failed: %s\n", err)
-
-			// Attempt rollback if we have a previous image
-			if previousImage != "" {
-				fmt.Fprintf(logWriter, "\n--- Attempting Rollback ---\n")
-				fmt.Fprintf(logWriter, "Restoring previous image: %s\n", previousImage)
-
-				rollbackConfig := containerConfig
-				rollbackConfig.Image = previousImage
-				delete(rollbackConfig.Labels, "schooner.build-id") // Don't associate with failed build
-
-				if rollbackID, rollbackErr := o.dockerClient.RunContainer(ctx, rollbackConfig); rollbackErr == nil {
-					fmt.Fprintf(logWriter, "✓ Rollback successful: %s\n", rollbackID[:12])
-					logger.Info("rollback successful", "previousImage", previousImage)
-				} else {
-					fmt.Fprintf(logWriter, "✗ Rollback failed: %s\n", rollbackErr)
-					logger.Error("rollback failed", "error", rollbackErr)
-				}
-			}
-
-			o.failBuild(ctx, build, fmt.Sprintf("deploy failed: %v", err))
+		steps.finish(ctx, pipelineSteps[i])
+		if selfDeployed {
+			o.finishGitHubDeployment(d, nil)
 			return
 		}
-
-		fmt.Fprintf(logWriter, "Container started: %s\n", containerID[:12])
 	}
+	o.finishGitHubDeployment(d, nil)
 
 	// Build succeeded
 	build.Status = models.BuildStatusSuccess
 	build.FinishedAt = database.NullTime(time.Now())
-	o.buildQueries.Update(ctx, build)
+	o.saveBuild(ctx, build)
+	o.recordDeployed(ctx, app, build)
+	o.markConfigDeployed(ctx, app, logger)
+	if app.Stopped {
+		// Deploying starts a stopped app again
+		if err := o.appQueries.SetStopped(ctx, app.ID, false); err != nil {
+			logger.Warn("failed to record app started", "error", err)
+		}
+	}
 
+	recordBuildMetrics(build)
 	duration := build.Duration()
 	fmt.Fprintf(logWriter, "\n--- Build Complete ---\n")
 	fmt.Fprintf(logWriter, "Duration: %s\n", duration.Round(time.Second))
@@ -444,13 +666,52 @@ func (o *Orchestrator) failBuild(ctx context.Context, build *models.Build, messa
 	build.Status = models.BuildStatusFailed
 	build.ErrorMessage = database.NullString(message)
 	build.FinishedAt = database.NullTime(time.Now())
+	tracing.Fail(trace.SpanFromContext(ctx), errors.New(message))
 
 	// Use background context for the update since the original context may be cancelled
-	o.buildQueries.Update(context.Background(), build)
+	o.saveBuild(context.Background(), build)
+	o.recordFailed(context.Background(), build, message)
+	recordBuildMetrics(build)
+
+	if o.notifier != nil {
+		o.notifier.Notify(context.Background(), notify.Event{
+			Type:     notify.EventBuildFailed,
+			Title:    fmt.Sprintf("Build failed: %s", build.AppName),
+			Message:  fmt.Sprintf("%s (%s)", message, build.DescribeTrigger()),
+			AppName:  build.AppName,
+			URL:      o.notifier.BaseURL() + "/builds/" + build.ID,
+			Priority: notify.PriorityHigh,
+		})
+	}
+}
+
+// recordBuildMetrics records the final status and duration of a build
+func recordBuildMetrics(build *models.Build) {
+	metrics.BuildsTotal.Inc(string(build.Status))
+	metrics.BuildDuration.Observe(build.Duration().Seconds(), string(build.Status))
+}
+
+// QueueDepth returns the number of builds waiting in the queue
+func (o *Orchestrator) QueueDepth() int {
+	return len(o.buildQueue)
 }
 
 // TriggerManualBuild creates and queues a manual build
 func (o *Orchestrator) TriggerManualBuild(ctx context.Context, appID string) (*models.Build, error) {
+	build, err := o.createManualBuild(ctx, appID, "Build triggered manually")
+	if err != nil {
+		return nil, err
+	}
+
+	o.linkTrace(ctx, build.ID)
+	o.QueueBuild(build.ID)
+
+	return build, nil
+}
+
+// createManualBuild creates a pending manual build of an app, starting its
+// log with message, without queueing it
+func (o *Orchestrator) createManualBuild(ctx context.Context, appID, message string) (*models.Build, error) {
 	app, err := o.appQueries.GetByID(ctx, appID)
 	if err != nil {
 		return nil, err
@@ -460,97 +721,47 @@ func (o *Orchestrator) TriggerManualBuild(ctx context.Context, appID string) (*m
 	}
 
 	build := &models.Build{
-		ID:        uuid.New().String(),
-		AppID:     app.ID,
-		Status:    models.BuildStatusPending,
-		Trigger:   models.TriggerManual,
-		Branch:    database.NullString(app.Branch),
-		CreatedAt: time.Now(),
+		ID:          uuid.New().String(),
+		AppID:       app.ID,
+		AppName:     app.Name,
+		Status:      models.BuildStatusPending,
+		Trigger:     models.TriggerManual,
+		TriggeredBy: database.NullString(auth.Requester(ctx)),
+		Branch:      database.NullString(app.Branch),
+		CreatedAt:   time.Now(),
+	}
+
+	// Apps building release tags redeploy their latest release rather than
+	// the branch head
+	if app.BuildsOnTags() {
+		tag, err := o.buildQueries.GetLatestTag(ctx, app.ID)
+		if err != nil {
+			return nil, err
+		}
+		if tag != "" {
+			build.Branch = database.NullString("")
+			build.Tag = database.NullString(tag)
+		}
 	}
 
 	if err := o.buildQueries.Create(ctx, build); err != nil {
 		return nil, err
 	}
+	o.publishBuild(build)
 
 	// Add initial log
 	log := &models.BuildLog{
 		BuildID:   build.ID,
 		Level:     models.LogLevelInfo,
-		Message:   "Build triggered manually",
+		Message:   message,
 		Source:    models.LogSourceSystem,
 		Timestamp: time.Now(),
 	}
 	o.logQueries.Append(ctx, log)
 
-	o.QueueBuild(build.ID)
-
 	return build, nil
 }
 
-// buildLogWriter writes logs to the database
-type buildLogWriter struct {
-	buildID    string
-	logQueries *queries.LogQueries
-	buffer     []byte
-}
-
-func newBuildLogWriter(buildID string, logQueries *queries.LogQueries) *buildLogWriter {
-	return &buildLogWriter{
-		buildID:    buildID,
-		logQueries: logQueries,
-	}
-}
-
-func (w *buildLogWriter) Write(p []byte) (n int, err error) {
-	w.buffer = append(w.buffer, p...)
-
-	// Process complete lines
-	for {
-		idx := -1
-		for i, b := range w.buffer {
-			if b == '\n' {
-				idx = i
-				break
-			}
-		}
-		if idx == -1 {
-			break
-		}
-
-		line := string(w.buffer[:idx])
-		w.buffer = w.buffer[idx+1:]
-
-		if line == "" {
-			continue
-		}
-
-		log := &models.BuildLog{
-			BuildID:   w.buildID,
-			Level:     models.LogLevelInfo,
-			Message:   line,
-			Source:    models.LogSourceDocker,
-			Timestamp: time.Now(),
-		}
-		w.logQueries.Append(context.Background(), log)
-	}
-
-	return len(p), nil
-}
-
-func (w *buildLogWriter) Flush() {
-	if len(w.buffer) > 0 {
-		log := &models.BuildLog{
-			BuildID:   w.buildID,
-			Level:     models.LogLevelInfo,
-			Message:   string(w.buffer),
-			Source:    models.LogSourceDocker,
-			Timestamp: time.Now(),
-		}
-		w.logQueries.Append(context.Background(), log)
-		w.buffer = nil
-	}
-}
-
 // detectBuildStrategy examines the repo to determine the best build strategy.
 // Returns the detected strategy and the compose file path (if compose is detected).
 func (o *Orchestrator) detectBuildStrategy(repoPath string) (models.BuildStrategy, string) {
@@ -602,40 +813,11 @@ var _ io.Writer = (*buildLogWriter)(nil)
 // isSelfDeploy checks if we're trying to deploy the container we're running in.
 // This would kill us mid-deployment, so we need to skip deployment in this case.
 func (o *Orchestrator) isSelfDeploy(targetContainerName string) bool {
-	// Check if we're running in a Docker container
-	if _, err := os.Stat("/.dockerenv"); os.IsNotExist(err) {
-		return false // Not in Docker, safe to deploy anything
-	}
-
-	// Get our hostname (in Docker, this is typically the container ID or name)
-	hostname, err := os.Hostname()
-	if err != nil {
-		return false
-	}
-
-	// Check if our hostname matches the target container name
-	// Docker sets hostname to container ID by default, but it can be set to the container name
-	if hostname == targetContainerName {
-		return true
-	}
-
-	// Also check by querying Docker for our container's name
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	status, err := o.dockerClient.GetContainerStatus(ctx, hostname)
-	if err != nil || status == nil {
-		return false
-	}
-
-	// Check if our container's name matches the target
-	// Container names from Docker API may include leading slash
-	containerName := status.Name
-	if len(containerName) > 0 && containerName[0] == '/' {
-		containerName = containerName[1:]
-	}
-
-	return containerName == targetContainerName
+	self, err := o.dockerClient.SelfContainer(ctx)
+	return err == nil && self != nil && self.Name == targetContainerName
 }
 
 // selfDeployDockerfile handles self-deployment for Dockerfile strategy using a helper container.
@@ -649,54 +831,15 @@ func (o *Orchestrator) selfDeployDockerfile(ctx context.Context, app *models.App
 		return fmt.Errorf("could not get current container status: %w", err)
 	}
 
-	// Get container run arguments to recreate with same configuration
-	runArgs, err := o.dockerClient.GetContainerRunArgs(ctx, containerName)
-	if err != nil {
-		return fmt.Errorf("could not get container configuration: %w", err)
-	}
-
 	fmt.Fprintf(logWriter, "Current container ID: %s\n", status.ID[:12])
 	fmt.Fprintf(logWriter, "New image: %s\n", newImageTag)
-
-	// Build run args string for the script
-	runArgsStr := strings.Join(runArgs, " ")
-
-	// Build the helper script that will do the swap
-	// The script waits 2 seconds (to let us finish), then stops old and starts new
-	helperScript := fmt.Sprintf(`
-		sleep 2
-		echo "Stopping old container: %s"
-		docker stop %s --time 30 || true
-		docker rm %s || true
-		echo "Starting new container with image: %s"
-		docker run -d --name %s \
-			--label schooner.managed=true \
-			--label "schooner.app=%s" \
-			--label "schooner.app-id=%s" \
-			%s \
-			%s
-		echo "Self-deployment complete"
-	`, containerName, containerName, containerName, newImageTag, containerName, app.Name, app.ID, runArgsStr, newImageTag)
-
-	// Create and start helper container
-	helperConfig := docker.ContainerConfig{
-		Name:  "schooner-deploy-helper",
-		Image: "docker:cli",
-		Cmd:   []string{"sh", "-c", helperScript},
-		Volumes: map[string]string{
-			"/var/run/docker.sock": "/var/run/docker.sock",
-		},
-		Labels: map[string]string{
-			"schooner.helper": "true",
-		},
-	}
-
 	fmt.Fprintf(logWriter, "Starting deployment helper container...\n")
 
-	// Remove any existing helper container
-	_ = o.dockerClient.StopAndRemove(ctx, "schooner-deploy-helper")
-
-	helperID, err := o.dockerClient.RunContainer(ctx, helperConfig)
+	helperID, err := o.dockerClient.SwapContainer(ctx, containerName, newImageTag, map[string]string{
+		"schooner.managed": "true",
+		"schooner.app":     app.Name,
+		"schooner.app-id":  app.ID,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to start helper container: %w", err)
 	}
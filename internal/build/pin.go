@@ -0,0 +1,107 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"schooner/internal/auth"
+	"schooner/internal/database"
+	"schooner/internal/models"
+)
+
+// ErrNoImageDigests is returned when redeploying a build that recorded no
+// pinned images
+var ErrNoImageDigests = errors.New("build has no pinned images to redeploy")
+
+// imageDigester resolves the images a compose deploy runs to their digests
+type imageDigester interface {
+	ImageDigests(ctx context.Context, opts BuildOptions) (map[string]string, error)
+}
+
+// RedeployBuild queues a build that checks out the commit of an earlier
+// compose build and runs exactly the images it did, rather than whatever
+// their tags point to now
+func (o *Orchestrator) RedeployBuild(ctx context.Context, source *models.Build) (*models.Build, error) {
+	if len(source.GetImageDigests()) == 0 {
+		return nil, ErrNoImageDigests
+	}
+
+	build := &models.Build{
+		ID:            uuid.New().String(),
+		AppID:         source.AppID,
+		Status:        models.BuildStatusPending,
+		Trigger:       models.TriggerRollback,
+		TriggeredBy:   database.NullString(auth.Requester(ctx)),
+		CommitSHA:     source.CommitSHA,
+		CommitMessage: source.CommitMessage,
+		CommitAuthor:  source.CommitAuthor,
+		Branch:        source.Branch,
+		Tag:           source.Tag,
+		PinnedBuildID: database.NullString(source.ID),
+		CreatedAt:     time.Now(),
+	}
+	if err := o.buildQueries.Create(ctx, build); err != nil {
+		return nil, err
+	}
+	o.publishBuild(build)
+
+	log := &models.BuildLog{
+		BuildID:   build.ID,
+		Level:     models.LogLevelInfo,
+		Message:   fmt.Sprintf("Redeploy of build %s with its pinned images", source.ID[:8]),
+		Source:    models.LogSourceSystem,
+		Timestamp: time.Now(),
+	}
+	o.logQueries.Append(ctx, log)
+
+	o.QueueBuild(build.ID)
+
+	return build, nil
+}
+
+// pinnedImages returns the images a redeploy pins its services to, or nil
+// for builds that aren't redeploys
+func (o *Orchestrator) pinnedImages(ctx context.Context, build *models.Build) (map[string]string, error) {
+	pinnedID := build.GetPinnedBuildID()
+	if pinnedID == "" {
+		return nil, nil
+	}
+	pinned, err := o.buildQueries.GetByID(ctx, pinnedID)
+	if err != nil {
+		return nil, err
+	}
+	if pinned == nil {
+		return nil, fmt.Errorf("build %s not found", pinnedID)
+	}
+	return pinned.GetImageDigests(), nil
+}
+
+// recordImageDigests records the digest of each image a compose deploy
+// runs on its build, so it can be redeployed exactly
+func (o *Orchestrator) recordImageDigests(ctx context.Context, strategy Strategy, opts BuildOptions, build *models.Build, logWriter io.Writer) {
+	digester, ok := strategy.(imageDigester)
+	if !ok {
+		return
+	}
+	digests, err := digester.ImageDigests(ctx, opts)
+	if err != nil {
+		fmt.Fprintf(logWriter, "Warning: failed to resolve image digests: %v\n", err)
+		return
+	}
+	build.SetImageDigests(digests)
+
+	services := make([]string, 0, len(digests))
+	for service := range digests {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	for _, service := range services {
+		fmt.Fprintf(logWriter, "Pinned %s: %s\n", service, digests[service])
+	}
+}
@@ -0,0 +1,323 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/yaml.v3"
+
+	"schooner/internal/database"
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+	"schooner/internal/tracing"
+)
+
+// Built-in pipeline steps
+const (
+	StepClone    = "clone"
+	StepValidate = "validate"
+	StepBuild    = "build"
+	StepApproval = "approval"
+	StepWindow   = "window"
+	StepDeploy   = "deploy"
+)
+
+// PipelineStep is a step of an app's build pipeline: the built-in build or
+// deploy step, or a shell command run in the image the build produced
+type PipelineStep struct {
+	Name    string `yaml:"name" json:"name"`
+	Run     string `yaml:"run" json:"run,omitempty"`
+	Service string `yaml:"service" json:"service,omitempty"` // Compose service the command runs in, empty for the first one built from source
+}
+
+// pipelineFileNames are the files a repository defines its pipeline in
+var pipelineFileNames = []string{"schooner.yaml", "schooner.yml"}
+
+// stepName is what a pipeline step may be called
+var stepName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// DefaultPipeline is the pipeline of apps without a schooner.yaml: build,
+// run the app's test command if it has one, and deploy
+func DefaultPipeline(testCommand string) []PipelineStep {
+	steps := []PipelineStep{{Name: StepBuild}}
+	if testCommand != "" {
+		steps = append(steps, PipelineStep{Name: "test", Run: testCommand})
+	}
+	return append(steps, PipelineStep{Name: StepDeploy})
+}
+
+// LoadPipeline reads the pipeline a checkout defines in its schooner.yaml,
+// returning the file it came from. Without one it returns the default
+// pipeline and an empty file name.
+func LoadPipeline(repoPath, testCommand string) ([]PipelineStep, string, error) {
+	for _, name := range pipelineFileNames {
+		data, err := os.ReadFile(filepath.Join(repoPath, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		var file struct {
+			Pipeline []PipelineStep `yaml:"pipeline"`
+		}
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, "", fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		if len(file.Pipeline) == 0 {
+			break
+		}
+		if err := validatePipeline(file.Pipeline); err != nil {
+			return nil, "", fmt.Errorf("invalid pipeline in %s: %w", name, err)
+		}
+		return file.Pipeline, name, nil
+	}
+	return DefaultPipeline(testCommand), "", nil
+}
+
+// validatePipeline checks a pipeline builds once, deploys once after that,
+// and only runs commands once there's an image to run them in
+func validatePipeline(steps []PipelineStep) error {
+	seen := make(map[string]bool)
+	built, deployed := false, false
+	for i, step := range steps {
+		if !stepName.MatchString(step.Name) {
+			return fmt.Errorf("step %d: invalid name %q", i+1, step.Name)
+		}
+		if seen[step.Name] {
+			return fmt.Errorf("step %q appears twice", step.Name)
+		}
+		seen[step.Name] = true
+
+		switch step.Name {
+		case StepClone, StepValidate, StepArtifacts, StepApproval, StepWindow:
+			return fmt.Errorf("the %s step is added by Schooner and can't be listed", step.Name)
+		case StepBuild, StepDeploy:
+			if step.Run != "" || step.Service != "" {
+				return fmt.Errorf("the built-in %s step takes no run or service", step.Name)
+			}
+			if step.Name == StepDeploy && !built {
+				return fmt.Errorf("the deploy step must come after the build step")
+			}
+			built = built || step.Name == StepBuild
+			deployed = deployed || step.Name == StepDeploy
+		default:
+			if step.Run == "" {
+				return fmt.Errorf("step %q needs a run command", step.Name)
+			}
+			if !built {
+				return fmt.Errorf("step %q runs in the built image and must come after the build step", step.Name)
+			}
+		}
+	}
+	if !built || !deployed {
+		return fmt.Errorf("the pipeline needs a build and a deploy step")
+	}
+	return nil
+}
+
+// withGate adds a step a build may wait in, such as approval, right in
+// front of a pipeline's deploy step
+func withGate(pipeline []PipelineStep, name string) []PipelineStep {
+	steps := make([]PipelineStep, 0, len(pipeline)+1)
+	for _, step := range pipeline {
+		if step.Name == StepDeploy {
+			steps = append(steps, PipelineStep{Name: name})
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// stepRecorder records the status and duration of a build's steps. Without
+// queries it only keeps them in memory.
+type stepRecorder struct {
+	queries *queries.BuildStepQueries
+	buildID string
+	steps   []*models.BuildStep
+	loaded  []*models.BuildStep // Steps recorded before the build was resumed
+	spans   map[*models.BuildStep]trace.Span
+}
+
+func newStepRecorder(stepQueries *queries.BuildStepQueries, buildID string) *stepRecorder {
+	return &stepRecorder{queries: stepQueries, buildID: buildID, spans: make(map[*models.BuildStep]trace.Span)}
+}
+
+// load reads the steps recorded by an earlier run of the build, which add
+// picks up again instead of recording them twice
+func (r *stepRecorder) load(ctx context.Context) error {
+	if r.queries == nil {
+		return nil
+	}
+	steps, err := r.queries.ListByBuildID(ctx, r.buildID)
+	if err != nil {
+		return err
+	}
+	r.loaded = steps
+	return nil
+}
+
+// recorded reports whether an earlier run of the build recorded a step
+func (r *stepRecorder) recorded(name string) bool {
+	for _, step := range r.loaded {
+		if step.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// add records a step as pending
+func (r *stepRecorder) add(ctx context.Context, name, command string) *models.BuildStep {
+	for _, step := range r.loaded {
+		if step.Name == name {
+			r.steps = append(r.steps, step)
+			return step
+		}
+	}
+
+	step := &models.BuildStep{
+		BuildID:  r.buildID,
+		Position: len(r.steps),
+		Name:     name,
+		Command:  database.NullString(command),
+		Status:   models.StepStatusPending,
+	}
+	r.steps = append(r.steps, step)
+	if r.queries != nil {
+		r.queries.Create(ctx, step)
+	}
+	return step
+}
+
+// start marks a step as running, returning a context that traces the work
+// done for it. The step a build waited in is still running when the build
+// resumes, and keeps the time it started.
+func (r *stepRecorder) start(ctx context.Context, step *models.BuildStep) context.Context {
+	if step.Status != models.StepStatusRunning {
+		step.Status = models.StepStatusRunning
+		step.StartedAt = database.NullTime(time.Now())
+	}
+	r.save(ctx, step)
+
+	stepCtx, span := tracing.Start(ctx, "pipeline."+step.Name, attribute.String("build.step", step.Name))
+	r.spans[step] = span
+	return stepCtx
+}
+
+// finish marks a running step as succeeded
+func (r *stepRecorder) finish(ctx context.Context, step *models.BuildStep) {
+	step.Status = models.StepStatusSuccess
+	step.FinishedAt = database.NullTime(time.Now())
+	r.save(ctx, step)
+	r.endSpan(step, nil)
+}
+
+// endSpan ends the span of a step started by this run of the build
+func (r *stepRecorder) endSpan(step *models.BuildStep, err error) {
+	if span, ok := r.spans[step]; ok {
+		tracing.End(span, err)
+		delete(r.spans, step)
+	}
+}
+
+// abort settles the steps of a build that stopped early: a step still
+// running failed with the build's error, and those never reached are
+// skipped. A build waiting to deploy leaves them as they are.
+func (r *stepRecorder) abort(build *models.Build) {
+	if build.IsWaiting() {
+		// The step it waits in gets a new span once the build resumes
+		for step := range r.spans {
+			r.endSpan(step, nil)
+		}
+		return
+	}
+	ctx := context.Background()
+	for _, step := range r.steps {
+		switch step.Status {
+		case models.StepStatusRunning:
+			step.Status = models.StepStatusFailed
+			step.ErrorMessage = build.ErrorMessage
+			step.FinishedAt = database.NullTime(time.Now())
+			r.save(ctx, step)
+			r.endSpan(step, errors.New(build.GetErrorMessage()))
+		case models.StepStatusPending:
+			step.Status = models.StepStatusSkipped
+			r.save(ctx, step)
+		}
+	}
+}
+
+func (r *stepRecorder) save(ctx context.Context, step *models.BuildStep) {
+	if r.queries == nil || step.ID == 0 {
+		return
+	}
+	r.queries.Update(ctx, step)
+}
+
+// pipelineSummary lists the names of a pipeline's steps
+func pipelineSummary(pipeline []PipelineStep) string {
+	names := make([]string, len(pipeline))
+	for i, step := range pipeline {
+		names[i] = step.Name
+	}
+	return strings.Join(names, " → ")
+}
+
+// validateBuild checks the build configuration before anything is built
+func (o *Orchestrator) validateBuild(ctx context.Context, strategy Strategy, opts BuildOptions, logger *slog.Logger) error {
+	fmt.Fprintf(opts.LogWriter, "\nValidating build configuration...\n")
+	if err := strategy.Validate(ctx, opts); err != nil {
+		logger.Error("validation failed", "error", err)
+		fmt.Fprintf(opts.LogWriter, "ERROR: Validation failed: %s\n", err)
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	return nil
+}
+
+// buildImage runs the strategy's build
+func (o *Orchestrator) buildImage(ctx context.Context, strategy Strategy, opts BuildOptions, build *models.Build, logger *slog.Logger) (*BuildResult, error) {
+	logWriter := opts.LogWriter
+
+	// Update status to building
+	build.Status = models.BuildStatusBuilding
+	o.saveBuild(ctx, build)
+	fmt.Fprintf(logWriter, "\n--- Starting Build ---\n\n")
+
+	started := time.Now()
+	result, err := strategy.Build(ctx, opts)
+	if err != nil {
+		logger.Error("build failed", "error", err)
+		fmt.Fprintf(logWriter, "\nERROR: Build failed: %s\n", err)
+		return nil, fmt.Errorf("build failed: %w", err)
+	}
+
+	build.ImageTag = database.NullString(result.ImageTag)
+	o.recordProvenance(ctx, strategy.Name(), opts, build, result, time.Since(started))
+	return result, nil
+}
+
+// runStep runs the command of a pipeline step in the image the build step
+// produced
+func (o *Orchestrator) runStep(ctx context.Context, strategy Strategy, opts BuildOptions, result *BuildResult, step PipelineStep, logger *slog.Logger) error {
+	fmt.Fprintf(opts.LogWriter, "\n--- Step: %s ---\n\n", step.Name)
+
+	opts.TestService = step.Service
+	if err := runTests(ctx, strategy, opts, result, step.Run); err != nil {
+		logger.Error("step failed", "step", step.Name, "error", err)
+		fmt.Fprintf(opts.LogWriter, "\nERROR: Step %s failed: %s\n", step.Name, err)
+		return fmt.Errorf("step %s failed: %w", step.Name, err)
+	}
+	fmt.Fprintf(opts.LogWriter, "\nStep %s passed\n", step.Name)
+	return nil
+}
@@ -0,0 +1,149 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadPipeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string // Contents of schooner.yaml, empty for none
+		want     []PipelineStep
+		wantFile string
+		wantErr  string
+	}{
+		{
+			name: "default",
+			want: []PipelineStep{{Name: StepBuild}, {Name: "test", Run: "make test"}, {Name: StepDeploy}},
+		},
+		{
+			name: "no pipeline key",
+			file: "other: true\n",
+			want: []PipelineStep{{Name: StepBuild}, {Name: "test", Run: "make test"}, {Name: StepDeploy}},
+		},
+		{
+			name: "custom",
+			file: `pipeline:
+  - name: build
+  - name: lint
+    run: make lint
+  - name: migrate
+    run: ./migrate up
+    service: api
+  - name: deploy
+  - name: smoke
+    run: ./smoke.sh
+`,
+			want: []PipelineStep{
+				{Name: StepBuild},
+				{Name: "lint", Run: "make lint"},
+				{Name: "migrate", Run: "./migrate up", Service: "api"},
+				{Name: StepDeploy},
+				{Name: "smoke", Run: "./smoke.sh"},
+			},
+			wantFile: "schooner.yaml",
+		},
+		{
+			name:    "command before build",
+			file:    "pipeline:\n  - name: lint\n    run: make lint\n  - name: build\n  - name: deploy\n",
+			wantErr: "must come after the build step",
+		},
+		{
+			name:    "deploy before build",
+			file:    "pipeline:\n  - name: deploy\n  - name: build\n",
+			wantErr: "deploy step must come after the build step",
+		},
+		{
+			name:    "no deploy",
+			file:    "pipeline:\n  - name: build\n",
+			wantErr: "needs a build and a deploy step",
+		},
+		{
+			name:    "missing command",
+			file:    "pipeline:\n  - name: build\n  - name: lint\n  - name: deploy\n",
+			wantErr: `step "lint" needs a run command`,
+		},
+		{
+			name:    "duplicate",
+			file:    "pipeline:\n  - name: build\n  - name: build\n  - name: deploy\n",
+			wantErr: "appears twice",
+		},
+		{
+			name:    "command on built-in step",
+			file:    "pipeline:\n  - name: build\n    run: make\n  - name: deploy\n",
+			wantErr: "takes no run or service",
+		},
+		{
+			name:    "approval listed",
+			file:    "pipeline:\n  - name: build\n  - name: approval\n  - name: deploy\n",
+			wantErr: "approval step is added by Schooner",
+		},
+		{
+			name:    "window listed",
+			file:    "pipeline:\n  - name: build\n  - name: window\n  - name: deploy\n",
+			wantErr: "window step is added by Schooner",
+		},
+		{
+			name:    "artifacts listed",
+			file:    "pipeline:\n  - name: build\n  - name: artifacts\n  - name: deploy\n",
+			wantErr: "artifacts step is added by Schooner",
+		},
+		{
+			name:    "validate listed",
+			file:    "pipeline:\n  - name: validate\n  - name: build\n  - name: deploy\n",
+			wantErr: "validate step is added by Schooner",
+		},
+		{
+			name:    "clone listed",
+			file:    "pipeline:\n  - name: clone\n  - name: build\n  - name: deploy\n",
+			wantErr: "clone step",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.file != "" {
+				if err := os.WriteFile(filepath.Join(dir, "schooner.yaml"), []byte(tt.file), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, file, err := LoadPipeline(dir, "make test")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadPipeline() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadPipeline() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadPipeline() = %+v, want %+v", got, tt.want)
+			}
+			if file != tt.wantFile {
+				t.Errorf("LoadPipeline() file = %q, want %q", file, tt.wantFile)
+			}
+		})
+	}
+}
+
+func TestDefaultPipelineWithoutTests(t *testing.T) {
+	want := []PipelineStep{{Name: StepBuild}, {Name: StepDeploy}}
+	if got := DefaultPipeline(""); !reflect.DeepEqual(got, want) {
+		t.Errorf("DefaultPipeline(\"\") = %+v, want %+v", got, want)
+	}
+}
+
+func TestWithGate(t *testing.T) {
+	pipeline := []PipelineStep{{Name: StepBuild}, {Name: "lint", Run: "make lint"}, {Name: StepDeploy}, {Name: "smoke", Run: "./smoke.sh"}}
+	want := []PipelineStep{{Name: StepBuild}, {Name: "lint", Run: "make lint"}, {Name: StepApproval}, {Name: StepDeploy}, {Name: "smoke", Run: "./smoke.sh"}}
+	if got := withGate(pipeline, StepApproval); !reflect.DeepEqual(got, want) {
+		t.Errorf("withGate() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,185 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"schooner/internal/git"
+	"schooner/internal/models"
+)
+
+// ErrNotCompose is returned when previewing the deploy of an app that isn't
+// built with docker compose
+var ErrNotCompose = errors.New("only compose apps can be previewed")
+
+// ComposePreview is what deploying a compose app would change
+type ComposePreview struct {
+	Commit   string           `json:"commit,omitempty"`
+	Config   string           `json:"config"` // Output of docker compose config
+	Services []ServicePreview `json:"services"`
+}
+
+// ServicePreview is how deploying changes one compose service
+type ServicePreview struct {
+	Name    string         `json:"name"`
+	Action  string         `json:"action"`  // create, update, remove or unchanged
+	Rebuild bool           `json:"rebuild"` // Built from source, so its image is replaced regardless
+	Changes []ConfigChange `json:"changes,omitempty"`
+}
+
+// Service preview actions
+const (
+	PreviewCreate    = "create"
+	PreviewUpdate    = "update"
+	PreviewRemove    = "remove"
+	PreviewUnchanged = "unchanged"
+)
+
+// ConfigChange is a setting of a service that differs from its running
+// container
+type ConfigChange struct {
+	Field string `json:"field"`
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+}
+
+// composePreviewer renders a compose deploy without running it
+type composePreviewer interface {
+	Preview(ctx context.Context, opts BuildOptions) (*ComposePreview, error)
+}
+
+// PreviewDeploy updates a compose app's checkout to what the next deploy
+// would build and compares the resulting compose config against the running
+// stack. It fails with ErrBuildRunning while the app builds.
+func (o *Orchestrator) PreviewDeploy(ctx context.Context, app *models.App) (*ComposePreview, error) {
+	appLock := o.getAppLock(app.ID)
+	if !appLock.TryLock() {
+		return nil, ErrBuildRunning
+	}
+	defer appLock.Unlock()
+
+	repoPath, commitSHA, err := o.refreshCheckout(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	buildStrategy := app.BuildStrategy
+	if buildStrategy == models.BuildStrategyAutodetect {
+		var composeFile string
+		buildStrategy, composeFile = o.detectBuildStrategy(repoPath)
+		if composeFile != "" {
+			app.ComposeFile = composeFile
+		}
+	}
+	if buildStrategy != models.BuildStrategyCompose {
+		return nil, ErrNotCompose
+	}
+	previewer, ok := o.strategies[buildStrategy].(composePreviewer)
+	if !ok {
+		return nil, fmt.Errorf("compose strategy not available")
+	}
+
+	dockerClient, err := o.dockerFor(ctx, app)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Docker host: %w", err)
+	}
+
+	// The same environment processBuild deploys with
+	version := "preview"
+	envVars := make(map[string]string)
+	for k, v := range app.EnvVars {
+		envVars[k] = v
+	}
+	if commitSHA != "" {
+		version = commitSHA[:8]
+		envVars["GIT_SHA"] = commitSHA
+		envVars["GIT_COMMIT"] = commitSHA
+	}
+	envVars["VERSION"] = version
+
+	var routing *RouteOptions
+	if !dockerClient.IsRemote() {
+		addonEnv, err := o.addonEnv(ctx, app)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load add-ons: %w", err)
+		}
+		for k, v := range addonEnv {
+			if _, ok := envVars[k]; !ok {
+				envVars[k] = v
+			}
+		}
+		routing = o.routeOptions(ctx, app)
+	}
+	referenceNetworks, err := o.resolveEnvReferences(ctx, app, envVars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve env var references: %w", err)
+	}
+	if dockerClient.IsRemote() {
+		referenceNetworks = nil
+	}
+
+	preview, err := previewer.Preview(ctx, BuildOptions{
+		AppID:       app.ID,
+		AppName:     app.Name,
+		RepoPath:    repoPath,
+		ComposeFile: app.ComposeFile,
+		Profiles:    app.GetComposeProfiles(),
+		EnvVars:     envVars,
+		LogWriter:   io.Discard,
+		Routing:     routing,
+		AppNetwork:  app.Network(),
+		Networks:    referenceNetworks,
+		Docker:      dockerClient,
+	})
+	if err != nil {
+		return nil, err
+	}
+	preview.Commit = commitSHA
+	return preview, nil
+}
+
+// refreshCheckout brings an app's checkout up to the commit its next deploy
+// builds and returns it with the commit's SHA, empty for apps without a
+// repository
+func (o *Orchestrator) refreshCheckout(ctx context.Context, app *models.App) (string, string, error) {
+	if !app.HasRepo() {
+		repoPath, err := o.prepareComposeSpec(app)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to prepare compose file: %w", err)
+		}
+		return repoPath, "", nil
+	}
+
+	// Apps building release tags deploy their latest release
+	var tag string
+	if app.BuildsOnTags() {
+		var err error
+		if tag, err = o.buildQueries.GetLatestTag(ctx, app.ID); err != nil {
+			return "", "", err
+		}
+	}
+
+	repoPath := CheckoutPath(o.gitClient.WorkDir(), app)
+	o.adoptSharedClone(app, repoPath)
+
+	repo, err := o.gitClient.CloneOrPull(ctx, git.CloneOptions{
+		URL:        app.RepoURL,
+		Branch:     app.Branch,
+		Depth:      1,
+		Path:       repoPath,
+		Submodules: app.Submodules,
+		LFS:        app.LFS,
+		Tag:        tag,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to update checkout: %w", err)
+	}
+
+	commit, err := o.gitClient.GetHeadCommit(repo)
+	if err != nil {
+		return repoPath, "", nil
+	}
+	return repoPath, commit.Hash.String(), nil
+}
@@ -0,0 +1,91 @@
+package build
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"schooner/internal/database"
+	"schooner/internal/models"
+	"schooner/internal/version"
+)
+
+// recordProvenance records on a build exactly which image it made and how:
+// the image's ID and registry digest, the image it's based on, and what
+// built it. Compose builds record the images of their services as pinned
+// digests instead.
+func (o *Orchestrator) recordProvenance(ctx context.Context, strategy models.BuildStrategy, opts BuildOptions, build *models.Build, result *BuildResult, took time.Duration) {
+	build.BuildSeconds = int(took.Round(time.Second).Seconds())
+
+	builder := fmt.Sprintf("schooner %s, %s", version.GetShortCommit(), strategy)
+	dockerClient := opts.Docker
+	if dockerClient == nil {
+		dockerClient = o.dockerClient
+	}
+	if dockerClient != nil {
+		if v, err := dockerClient.ServerVersion(ctx); err == nil {
+			builder += " on Docker " + v
+		}
+	}
+	build.Builder = database.NullString(builder)
+
+	if strategy == models.BuildStrategyCompose || dockerClient == nil {
+		return
+	}
+	if id, digest, err := dockerClient.ImageID(ctx, result.ImageTag); err == nil {
+		build.ImageID = database.NullString(id)
+		build.ImageDigest = database.NullString(digest)
+	} else {
+		o.logger.Warn("failed to inspect built image", "image", result.ImageTag, "error", err)
+	}
+
+	contextPath, err := SafePath(opts.RepoPath, opts.BuildContext)
+	if err != nil {
+		return
+	}
+	dockerfile, err := SafePath(contextPath, opts.Dockerfile)
+	if err != nil {
+		return
+	}
+	if data, err := os.ReadFile(dockerfile); err == nil {
+		build.BaseImage = database.NullString(BaseImage(data))
+	}
+}
+
+// BaseImage returns the image the final stage of a Dockerfile is built
+// FROM, following stages built from earlier ones, or an empty string if
+// there's none
+func BaseImage(dockerfile []byte) string {
+	stages := make(map[string]string) // Stage name to its base image
+	var base string
+
+	scanner := bufio.NewScanner(bytes.NewReader(dockerfile))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "FROM") {
+			continue
+		}
+
+		// FROM [--platform=...] image [AS name]
+		args := fields[1:]
+		for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			continue
+		}
+		base = args[0]
+		if earlier, ok := stages[strings.ToLower(base)]; ok {
+			base = earlier
+		}
+		if len(args) == 3 && strings.EqualFold(args[1], "AS") {
+			stages[strings.ToLower(args[2])] = base
+		}
+	}
+	return base
+}
@@ -0,0 +1,32 @@
+package build
+
+import "testing"
+
+func TestBaseImage(t *testing.T) {
+	tests := []struct {
+		name       string
+		dockerfile string
+		want       string
+	}{
+		{"single stage", "FROM nginx:1.27-alpine\nCOPY . /usr/share/nginx/html\n", "nginx:1.27-alpine"},
+		{
+			name:       "final stage from image",
+			dockerfile: "FROM golang:1.24 AS build\nRUN go build -o /app\n\nFROM gcr.io/distroless/static\nCOPY --from=build /app /app\n",
+			want:       "gcr.io/distroless/static",
+		},
+		{
+			name:       "final stage from earlier stage",
+			dockerfile: "FROM --platform=$BUILDPLATFORM node:22 AS base\nFROM base AS deps\nRUN npm ci\nfrom deps\nCMD [\"npm\", \"start\"]\n",
+			want:       "node:22",
+		},
+		{"no FROM", "RUN echo hi\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BaseImage([]byte(tt.dockerfile)); got != tt.want {
+				t.Errorf("BaseImage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
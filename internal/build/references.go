@@ -0,0 +1,227 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"schooner/internal/models"
+)
+
+// envReference matches references to other apps and add-ons in env var
+// values, ${apps.<app>.<KEY>} and ${addons.<add-on>.<KEY>}. A reference
+// written $${...} is kept as a literal ${...}.
+var envReference = regexp.MustCompile(`\$?\$\{(apps|addons)\.([^}]*)\}`)
+
+// maxReferenceDepth bounds how deep references to env vars of other apps,
+// which may reference others in turn, are followed
+const maxReferenceDepth = 8
+
+// AppFinder looks up the apps env var references point to
+type AppFinder interface {
+	GetByName(ctx context.Context, name string) (*models.App, error)
+}
+
+// AddonFinder looks up the add-ons env var references point to
+type AddonFinder interface {
+	ListByAppID(ctx context.Context, appID string) ([]*models.Addon, error)
+	ListByName(ctx context.Context, name string) ([]*models.Addon, error)
+}
+
+// SetAddonFinder lets env vars reference add-ons by name. Without one,
+// only references to apps resolve.
+func (o *Orchestrator) SetAddonFinder(finder AddonFinder) {
+	o.addonFinder = finder
+}
+
+// referenceResolver resolves the references in the env vars of one deploy
+type referenceResolver struct {
+	apps   AppFinder
+	addons AddonFinder
+	app    *models.App
+
+	// Add-on networks of other apps the deploy must join to reach the
+	// add-ons it references
+	networks []string
+}
+
+// resolveEnvReferences replaces references in the app's env vars with the
+// values they point to, and returns the networks of other apps' add-ons
+// the deployment needs to join to reach them
+func (o *Orchestrator) resolveEnvReferences(ctx context.Context, app *models.App, env map[string]string) ([]string, error) {
+	r := &referenceResolver{apps: o.appQueries, addons: o.addonFinder, app: app}
+	if err := r.resolveAll(ctx, env); err != nil {
+		return nil, err
+	}
+	return r.networks, nil
+}
+
+// resolveAll replaces the references in each value of env
+func (r *referenceResolver) resolveAll(ctx context.Context, env map[string]string) error {
+	for name, value := range env {
+		resolved, err := r.resolve(ctx, r.app, value, []string{r.app.Name + "." + name})
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		env[name] = resolved
+	}
+	return nil
+}
+
+// resolve replaces the references in a value of an env var of app. path is
+// the chain of env vars being resolved, to catch references that loop.
+func (r *referenceResolver) resolve(ctx context.Context, app *models.App, value string, path []string) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+
+	var firstErr error
+	resolved := envReference.ReplaceAllStringFunc(value, func(ref string) string {
+		if firstErr != nil {
+			return ref
+		}
+		if strings.HasPrefix(ref, "$$") {
+			return ref[1:]
+		}
+		match := envReference.FindStringSubmatch(ref)
+		var v string
+		var err error
+		switch match[1] {
+		case "apps":
+			v, err = r.appValue(ctx, match[2], path)
+		case "addons":
+			v, err = r.addonValue(ctx, app, match[2])
+		}
+		if err != nil {
+			firstErr = fmt.Errorf("%s: %w", ref, err)
+		}
+		return v
+	})
+	return resolved, firstErr
+}
+
+// splitReference splits the target and key of a reference, e.g.
+// "postgres.PORT"
+func splitReference(ref string) (string, string, error) {
+	i := strings.LastIndex(ref, ".")
+	if i <= 0 || i == len(ref)-1 {
+		return "", "", fmt.Errorf("must be <name>.<KEY>")
+	}
+	return ref[:i], ref[i+1:], nil
+}
+
+// appValue looks up what ${apps.<app>.<KEY>} refers to: one of the app's
+// env vars, or failing that its HOST, PORT or URL
+func (r *referenceResolver) appValue(ctx context.Context, ref string, path []string) (string, error) {
+	name, key, err := splitReference(ref)
+	if err != nil {
+		return "", err
+	}
+	target, err := r.apps.GetByName(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if target == nil {
+		return "", fmt.Errorf("no app named %q", name)
+	}
+
+	if value, ok := target.EnvVars[key]; ok {
+		step := target.Name + "." + key
+		if slices.Contains(path, step) {
+			return "", fmt.Errorf("references loop through %s", strings.Join(append(path, step), " -> "))
+		}
+		if len(path) >= maxReferenceDepth {
+			return "", fmt.Errorf("references nested more than %d deep", maxReferenceDepth)
+		}
+		return r.resolve(ctx, target, value, append(slices.Clone(path), step))
+	}
+
+	host := target.GetContainerName()
+	port := target.GetPublicPort()
+	switch key {
+	case "HOST":
+		return host, nil
+	case "PORT":
+		if port == 0 {
+			return "", fmt.Errorf("app %s has no port", target.Name)
+		}
+		return strconv.Itoa(port), nil
+	case "URL":
+		if port == 0 {
+			return "http://" + host, nil
+		}
+		return fmt.Sprintf("http://%s:%d", host, port), nil
+	}
+	return "", fmt.Errorf("app %s has no env var %s", target.Name, key)
+}
+
+// addonValue looks up what ${addons.<add-on>.<KEY>} refers to. The add-on
+// is one of app's own, or else the only add-on of that name.
+func (r *referenceResolver) addonValue(ctx context.Context, app *models.App, ref string) (string, error) {
+	name, key, err := splitReference(ref)
+	if err != nil {
+		return "", err
+	}
+	if r.addons == nil {
+		return "", fmt.Errorf("add-ons can't be referenced")
+	}
+	addon, err := r.findAddon(ctx, app, name)
+	if err != nil {
+		return "", err
+	}
+
+	if addon.AppID != r.app.ID {
+		network := (&models.App{ID: addon.AppID}).Network()
+		if !slices.Contains(r.networks, network) {
+			r.networks = append(r.networks, network)
+		}
+	}
+
+	switch key {
+	case "URL":
+		return addon.ConnectionURL(), nil
+	case "HOST":
+		return addon.ContainerName, nil
+	case "PORT":
+		return strconv.Itoa(addon.Port()), nil
+	case "USER":
+		return addon.Username, nil
+	case "PASSWORD":
+		return addon.Password, nil
+	case "DATABASE":
+		if addon.Type == models.AddonRedis {
+			return "0", nil
+		}
+		return addon.Name, nil
+	}
+	return "", fmt.Errorf("unknown add-on key %s, use URL, HOST, PORT, USER, PASSWORD or DATABASE", key)
+}
+
+// findAddon returns the add-on of app with the given name, or else the one
+// add-on with that name among all apps
+func (r *referenceResolver) findAddon(ctx context.Context, app *models.App, name string) (*models.Addon, error) {
+	own, err := r.addons.ListByAppID(ctx, app.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, addon := range own {
+		if addon.Name == name {
+			return addon, nil
+		}
+	}
+
+	found, err := r.addons.ListByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("no add-on named %q", name)
+	case 1:
+		return found[0], nil
+	}
+	return nil, fmt.Errorf("%d apps have an add-on named %q, reference it through its app's env var", len(found), name)
+}
@@ -0,0 +1,63 @@
+package build
+
+import (
+	"context"
+	"strconv"
+
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+// RouteOptions describes reverse proxy labels to attach to the container that
+// serves an app's public port, for proxies that discover routes from labels
+type RouteOptions struct {
+	PublicPort int               // Host port the app is published on
+	Network    string            // Docker network the proxy reaches containers on
+	Labels     map[string]string // Router labels
+	PortLabel  string            // Label that receives the container port
+}
+
+// LabelsFor returns the route labels for a container listening on port
+func (r *RouteOptions) LabelsFor(containerPort int) map[string]string {
+	labels := make(map[string]string, len(r.Labels)+1)
+	for k, v := range r.Labels {
+		labels[k] = v
+	}
+	if r.PortLabel != "" {
+		labels[r.PortLabel] = strconv.Itoa(containerPort)
+	}
+	return labels
+}
+
+// RouteLabeler supplies route labels for an app, or nil when the active
+// proxy does not route by labels
+type RouteLabeler interface {
+	RouteOptions(ctx context.Context, app *models.App) *RouteOptions
+}
+
+// SetRouteLabeler sets the source of reverse proxy labels for deployments
+func (o *Orchestrator) SetRouteLabeler(labeler RouteLabeler) {
+	o.routeLabeler = labeler
+}
+
+// routeOptions returns the route labels for an app, if any
+func (o *Orchestrator) routeOptions(ctx context.Context, app *models.App) *RouteOptions {
+	if o.routeLabeler == nil {
+		return nil
+	}
+	return o.routeLabeler.RouteOptions(ctx, app)
+}
+
+// applyRouting adds route labels and the proxy network to a container. Apps
+// deployed as a single container listen on their public port.
+func applyRouting(cfg *docker.ContainerConfig, routing *RouteOptions) {
+	if routing == nil {
+		return
+	}
+	for k, v := range routing.LabelsFor(routing.PublicPort) {
+		cfg.Labels[k] = v
+	}
+	if routing.Network != "" {
+		cfg.Networks = append(cfg.Networks, routing.Network)
+	}
+}
@@ -0,0 +1,146 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"schooner/internal/models"
+)
+
+// How often builds held for their deploy window are checked
+const scheduleCheckInterval = time.Minute
+
+// DeployScheduleSource provides the deploy windows and freezes of all apps
+type DeployScheduleSource interface {
+	GetDeploySchedule(ctx context.Context) (*models.DeploySchedule, error)
+}
+
+// SetDeploySchedules sets where the global deploy windows and freezes are
+// read from
+func (o *Orchestrator) SetDeploySchedules(source DeployScheduleSource) {
+	o.deploySchedules = source
+}
+
+// deployHold returns why an app can't deploy right now, or an empty string
+// if it can, and when it next can
+func (o *Orchestrator) deployHold(ctx context.Context, app *models.App) (string, time.Time, error) {
+	var global *models.DeploySchedule
+	if o.deploySchedules != nil {
+		var err error
+		if global, err = o.deploySchedules.GetDeploySchedule(ctx); err != nil {
+			return "", time.Time{}, err
+		}
+	}
+	schedule, err := app.GetDeploySchedule()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	reason, next := models.DeployHold(time.Now(), global, schedule)
+	return reason, next, nil
+}
+
+// hasDeploySchedule reports whether an app's deploys are limited to windows
+// or held by freezes
+func (o *Orchestrator) hasDeploySchedule(ctx context.Context, app *models.App) bool {
+	if schedule, err := app.GetDeploySchedule(); err == nil && !schedule.IsEmpty() {
+		return true
+	}
+	if o.deploySchedules == nil {
+		return false
+	}
+	global, err := o.deploySchedules.GetDeploySchedule(ctx)
+	return err == nil && !global.IsEmpty()
+}
+
+// needsWindow reports whether a build waits for its deploy window before its
+// deploy step
+func (o *Orchestrator) needsWindow(ctx context.Context, app *models.App, build *models.Build) bool {
+	return build.Trigger == models.TriggerWebhook && o.hasDeploySchedule(ctx, app)
+}
+
+// holdForWindow pauses a built build until deploys are allowed again. The
+// build gives up its worker and is queued again once its window opens.
+func (o *Orchestrator) holdForWindow(ctx context.Context, build *models.Build, reason string, next time.Time, logWriter io.Writer, logger *slog.Logger) {
+	build.Status = models.BuildStatusScheduled
+	o.saveBuild(ctx, build)
+
+	fmt.Fprintf(logWriter, "\n--- Deploy Scheduled ---\n")
+	fmt.Fprintf(logWriter, "Not deploying now: %s\n", reason)
+	if !next.IsZero() {
+		fmt.Fprintf(logWriter, "Deploying when the window opens: %s\n", next.Format("Mon Jan 2 15:04 MST"))
+	}
+	logger.Info("deploy held for window", "reason", reason, "next", next)
+}
+
+// releaseScheduled queues the builds held for their deploy window once it
+// opens
+func (o *Orchestrator) releaseScheduled() {
+	defer o.wg.Done()
+
+	ticker := time.NewTicker(scheduleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-o.ctx.Done():
+			return
+		case <-ticker.C:
+			o.releaseOpenWindows(o.ctx)
+		}
+	}
+}
+
+// releaseOpenWindows queues the newest held build of every app that may
+// deploy now. The app's older held builds are cancelled as superseded rather
+// than deployed one after another.
+func (o *Orchestrator) releaseOpenWindows(ctx context.Context) {
+	builds, err := o.buildQueries.ListScheduled(ctx)
+	if err != nil {
+		o.logger.Error("failed to list scheduled builds", "error", err)
+		return
+	}
+
+	// Scheduled builds are listed oldest first
+	newest := make(map[string]*models.Build)
+	for _, build := range builds {
+		newest[build.AppID] = build
+	}
+
+	for _, build := range builds {
+		if ctx.Err() != nil {
+			return
+		}
+		if newest[build.AppID] != build {
+			continue
+		}
+		app, err := o.appQueries.GetByID(ctx, build.AppID)
+		if err != nil || app == nil {
+			continue
+		}
+		reason, _, err := o.deployHold(ctx, app)
+		if err != nil {
+			o.logger.Warn("failed to check deploy window", "app", app.Name, "error", err)
+			continue
+		}
+		if reason != "" {
+			continue
+		}
+
+		for _, older := range builds {
+			if older.AppID == build.AppID && older != build {
+				o.supersede(older.ID, build)
+			}
+		}
+
+		released, err := o.buildQueries.Release(ctx, build.ID)
+		if err != nil || !released {
+			continue
+		}
+		o.appendSystemLog(ctx, build.ID, "Deploy window open, resuming build")
+		o.logger.Info("deploy window open, resuming build", "buildID", build.ID, "app", app.Name)
+		o.QueueBuild(build.ID)
+	}
+}
@@ -0,0 +1,72 @@
+package build
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"schooner/internal/database"
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+func TestReleaseOpenWindows(t *testing.T) {
+	db := newTestDB(t)
+
+	ctx := context.Background()
+	appQueries := queries.NewAppQueries(db.DB)
+	buildQueries := queries.NewBuildQueries(db.DB)
+	o := NewOrchestrator(nil, nil, appQueries, buildQueries, queries.NewLogQueries(db.DB))
+	defer o.cancel()
+
+	app := &models.App{
+		ID: "app1", Name: "web", RepoURL: "https://github.com/example/web.git", Branch: "main",
+		BuildStrategy: models.BuildStrategyDockerfile, CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}
+	if err := appQueries.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	held := func(id, sha string, created time.Time) {
+		b := &models.Build{
+			ID: id, AppID: app.ID, Status: models.BuildStatusScheduled, Trigger: models.TriggerWebhook,
+			CommitSHA: database.NullString(sha), CreatedAt: created,
+		}
+		if err := buildQueries.Create(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	held("older", "1111111111", time.Now().Add(-time.Hour))
+	held("newer", "2222222222", time.Now())
+
+	o.releaseOpenWindows(ctx)
+
+	select {
+	case id := <-o.buildQueue:
+		if id != "newer" {
+			t.Errorf("queued build = %q, want the newest held build", id)
+		}
+	default:
+		t.Fatal("no held build was queued")
+	}
+	select {
+	case id := <-o.buildQueue:
+		t.Errorf("superseded build %q was queued too", id)
+	default:
+	}
+
+	older, err := buildQueries.GetByID(ctx, "older")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if older.Status != models.BuildStatusCancelled || older.GetErrorMessage() != "Superseded by a newer push (22222222)" {
+		t.Errorf("older build = %s %q", older.Status, older.GetErrorMessage())
+	}
+	newer, err := buildQueries.GetByID(ctx, "newer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newer.Status != models.BuildStatusPending {
+		t.Errorf("newer build status = %s, want pending", newer.Status)
+	}
+}
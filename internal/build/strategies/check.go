@@ -0,0 +1,230 @@
+package strategies
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"schooner/internal/build"
+)
+
+// Check runs docker compose config on the app's compose file, which parses
+// it, validates it against the compose schema and interpolates variables
+func (s *ComposeStrategy) Check(ctx context.Context, opts build.BuildOptions) ([]build.ValidationError, error) {
+	composeFile := FindComposeFile(opts.RepoPath, opts.ComposeFile)
+	if composeFile == "" {
+		return []build.ValidationError{{Message: "compose file not found"}}, nil
+	}
+	composePath := filepath.Join(opts.RepoPath, composeFile)
+
+	args := append([]string{"compose", "-f", composePath}, ProfileArgs(opts.Profiles)...)
+	cmd := exec.CommandContext(ctx, "docker", append(args, "config", "--quiet")...)
+	cmd.Dir = opts.RepoPath
+	cmd.Env = composeEnv(opts)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return nil, fmt.Errorf("failed to run docker compose config: %w", err)
+		}
+		return composeErrors(stderr.String(), composePath, composeFile), nil
+	}
+	return nil, nil
+}
+
+// composeLinePattern finds the line number in YAML parse errors
+var composeLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// composeErrors turns the output of a failed docker compose config into
+// validation errors, with paths relative to the checkout
+func composeErrors(output, composePath, composeFile string) []build.ValidationError {
+	var problems []build.ValidationError
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		problem := build.ValidationError{
+			File:    composeFile,
+			Message: strings.ReplaceAll(line, composePath, composeFile),
+		}
+		if m := composeLinePattern.FindStringSubmatch(line); m != nil {
+			problem.Line, _ = strconv.Atoi(m[1])
+		}
+		problems = append(problems, problem)
+	}
+	if len(problems) == 0 {
+		problems = append(problems, build.ValidationError{File: composeFile, Message: "docker compose config failed"})
+	}
+	return problems
+}
+
+// Check parses the app's Dockerfile and reports syntax errors
+func (s *DockerfileStrategy) Check(ctx context.Context, opts build.BuildOptions) ([]build.ValidationError, error) {
+	contextPath, err := build.SafePath(opts.RepoPath, opts.BuildContext)
+	if err != nil {
+		return nil, err
+	}
+	dockerfilePath, err := build.SafePath(contextPath, opts.Dockerfile)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(dockerfilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Dockerfile: %w", err)
+	}
+
+	rel, err := filepath.Rel(opts.RepoPath, dockerfilePath)
+	if err != nil {
+		rel = opts.Dockerfile
+	}
+	return CheckDockerfile(data, rel), nil
+}
+
+// dockerfileInstructions are the instructions a Dockerfile may contain
+var dockerfileInstructions = map[string]bool{
+	"ADD": true, "ARG": true, "CMD": true, "COPY": true, "ENTRYPOINT": true,
+	"ENV": true, "EXPOSE": true, "FROM": true, "HEALTHCHECK": true, "LABEL": true,
+	"MAINTAINER": true, "ONBUILD": true, "RUN": true, "SHELL": true,
+	"STOPSIGNAL": true, "USER": true, "VOLUME": true, "WORKDIR": true,
+}
+
+var (
+	// escapeDirective sets the line continuation character
+	escapeDirective = regexp.MustCompile(`(?i)^#\s*escape\s*=\s*(\S)`)
+	// heredocPattern finds the heredoc delimiters of RUN, COPY and ADD
+	heredocPattern = regexp.MustCompile(`<<-?\s*["']?([A-Za-z_][A-Za-z0-9_]*)["']?`)
+)
+
+// CheckDockerfile reports the syntax errors in a Dockerfile: unknown or
+// empty instructions, instructions before the first FROM, unterminated
+// heredocs and invalid EXPOSE ports
+func CheckDockerfile(data []byte, file string) []build.ValidationError {
+	var problems []build.ValidationError
+	report := func(line int, format string, args ...interface{}) {
+		problems = append(problems, build.ValidationError{File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	escape := `\`
+	seenFrom := false
+	directives := true
+
+	var heredocs []string // Delimiters still to be closed
+	var current strings.Builder
+	startLine := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		raw := scanner.Text()
+
+		// Heredoc bodies are content, not instructions
+		if len(heredocs) > 0 {
+			if strings.TrimSpace(raw) == heredocs[0] {
+				heredocs = heredocs[1:]
+			}
+			continue
+		}
+
+		line := strings.TrimSpace(raw)
+		if strings.HasPrefix(line, "#") {
+			if directives {
+				if m := escapeDirective.FindStringSubmatch(line); m != nil {
+					escape = m[1]
+				}
+			}
+			continue
+		}
+		if line == "" {
+			if current.Len() == 0 {
+				directives = false
+			}
+			continue
+		}
+		directives = false
+
+		if current.Len() == 0 {
+			startLine = lineNo
+		}
+		if cont, ok := strings.CutSuffix(line, escape); ok {
+			current.WriteString(cont + " ")
+			continue
+		}
+		current.WriteString(line)
+		instruction := current.String()
+		current.Reset()
+
+		keyword, args, _ := strings.Cut(instruction, " ")
+		keyword = strings.ToUpper(keyword)
+		args = strings.TrimSpace(args)
+
+		switch {
+		case !dockerfileInstructions[keyword]:
+			report(startLine, "unknown instruction: %s", keyword)
+			continue
+		case args == "":
+			report(startLine, "%s requires at least one argument", keyword)
+			continue
+		case keyword == "FROM":
+			seenFrom = true
+		case keyword != "ARG" && !seenFrom:
+			report(startLine, "%s before the first FROM", keyword)
+		}
+
+		if keyword == "RUN" || keyword == "COPY" || keyword == "ADD" {
+			for _, m := range heredocPattern.FindAllStringSubmatch(args, -1) {
+				heredocs = append(heredocs, m[1])
+			}
+		}
+		if keyword == "EXPOSE" {
+			for _, port := range strings.Fields(args) {
+				number, _, _ := strings.Cut(port, "/")
+				if strings.Contains(number, "$") {
+					continue
+				}
+				if !validPortRange(number) {
+					report(startLine, "invalid port in EXPOSE: %s", port)
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		report(lineNo, "failed to read Dockerfile: %v", err)
+	}
+
+	if current.Len() > 0 {
+		report(startLine, "line continuation at the end of the file")
+	}
+	if len(heredocs) > 0 {
+		report(lineNo, "heredoc %s is never terminated", heredocs[0])
+	}
+	if !seenFrom {
+		report(0, "no FROM instruction")
+	}
+	return problems
+}
+
+// validPortRange reports whether s is a port or a port range like 8000-8010
+func validPortRange(s string) bool {
+	parts := []string{s}
+	if from, to, isRange := strings.Cut(s, "-"); isRange {
+		parts = []string{from, to}
+	}
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 1 || n > 65535 {
+			return false
+		}
+	}
+	return true
+}
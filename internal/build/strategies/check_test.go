@@ -0,0 +1,109 @@
+package strategies
+
+import (
+	"reflect"
+	"testing"
+
+	"schooner/internal/build"
+)
+
+func TestCheckDockerfile(t *testing.T) {
+	tests := []struct {
+		name       string
+		dockerfile string
+		want       []build.ValidationError
+	}{
+		{
+			name: "valid",
+			dockerfile: `# syntax=docker/dockerfile:1
+ARG GO_VERSION=1.24
+FROM golang:${GO_VERSION} AS build
+RUN apt-get update && \
+    apt-get install -y git
+EXPOSE 8080 9000-9010/udp ${PORT}
+CMD ["./app"]
+`,
+		},
+		{
+			name: "heredoc body is skipped",
+			dockerfile: `FROM alpine
+RUN <<EOF
+not an instruction
+EOF
+COPY <<-"CONF" /etc/app.conf
+  key = value
+  CONF
+`,
+		},
+		{
+			name:       "escape directive",
+			dockerfile: "# escape=`\nFROM mcr.microsoft.com/windows/servercore\nRUN dir `\n    c:\\\n",
+		},
+		{
+			name:       "unknown and empty instructions",
+			dockerfile: "FROM alpine\nRUNN echo hi\n\nWORKDIR\n",
+			want: []build.ValidationError{
+				{File: "Dockerfile", Line: 2, Message: "unknown instruction: RUNN"},
+				{File: "Dockerfile", Line: 4, Message: "WORKDIR requires at least one argument"},
+			},
+		},
+		{
+			name:       "instruction before FROM",
+			dockerfile: "ARG BASE=alpine\nRUN echo hi\nFROM ${BASE}\n",
+			want: []build.ValidationError{
+				{File: "Dockerfile", Line: 2, Message: "RUN before the first FROM"},
+			},
+		},
+		{
+			name:       "invalid ports",
+			dockerfile: "FROM alpine\nEXPOSE 80 http 70000 9000-x/tcp\n",
+			want: []build.ValidationError{
+				{File: "Dockerfile", Line: 2, Message: "invalid port in EXPOSE: http"},
+				{File: "Dockerfile", Line: 2, Message: "invalid port in EXPOSE: 70000"},
+				{File: "Dockerfile", Line: 2, Message: "invalid port in EXPOSE: 9000-x/tcp"},
+			},
+		},
+		{
+			name:       "unterminated heredoc",
+			dockerfile: "FROM alpine\nRUN <<EOF\necho hi\n",
+			want: []build.ValidationError{
+				{File: "Dockerfile", Line: 3, Message: "heredoc EOF is never terminated"},
+			},
+		},
+		{
+			name:       "no FROM",
+			dockerfile: "# just a comment\n",
+			want: []build.ValidationError{
+				{File: "Dockerfile", Message: "no FROM instruction"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CheckDockerfile([]byte(tt.dockerfile), "Dockerfile")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckDockerfile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposeErrors(t *testing.T) {
+	output := "yaml: line 4: did not find expected key\n" +
+		"/srv/checkouts/app/docker-compose.yml: services.web Additional property buld is not allowed\n"
+
+	got := composeErrors(output, "/srv/checkouts/app/docker-compose.yml", "docker-compose.yml")
+	want := []build.ValidationError{
+		{File: "docker-compose.yml", Line: 4, Message: "yaml: line 4: did not find expected key"},
+		{File: "docker-compose.yml", Message: "docker-compose.yml: services.web Additional property buld is not allowed"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("composeErrors() = %+v, want %+v", got, want)
+	}
+
+	got = composeErrors("", "/srv/docker-compose.yml", "docker-compose.yml")
+	if len(got) != 1 || got[0].Message != "docker compose config failed" {
+		t.Errorf("composeErrors() with no output = %+v", got)
+	}
+}
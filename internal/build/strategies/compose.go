@@ -91,18 +91,29 @@ func (s *ComposeStrategy) Build(ctx context.Context, opts build.BuildOptions) (*
 
 	fmt.Fprintf(opts.LogWriter, "Building with Docker Compose: %s\n", composePath)
 
-	// Build environment
-	env := os.Environ()
-	for k, v := range opts.EnvVars {
-		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	if opts.Isolated {
+		// The builder sees the checkout at the same path, so compose names
+		// the project and resolves build contexts as it does for up
+		dockerClient := opts.Docker
+		if dockerClient == nil {
+			dockerClient = s.dockerClient
+		}
+		args := append([]string{"compose", "-f", composePath}, ProfileArgs(opts.Profiles)...)
+		args = append(args, "build", "--pull")
+		if err := runIsolated(ctx, dockerClient, opts, opts.RepoPath, []string{".git"}, opts.EnvVars, args...); err != nil {
+			return nil, fmt.Errorf("docker compose build failed: %w", err)
+		}
+		fmt.Fprintf(opts.LogWriter, "\nDocker Compose build complete\n")
+		return &build.BuildResult{ImageTag: opts.AppName}, nil
 	}
 
+	// Build environment
+	env := composeEnv(opts)
+
 	// Run docker compose build
-	buildCmd := exec.CommandContext(ctx, "docker", "compose",
-		"-f", composePath,
-		"build",
-		"--pull",
-	)
+	buildArgs := append([]string{"compose", "-f", composePath}, ProfileArgs(opts.Profiles)...)
+	buildArgs = append(buildArgs, "build", "--pull")
+	buildCmd := exec.CommandContext(ctx, "docker", buildArgs...)
 	buildCmd.Dir = opts.RepoPath
 	buildCmd.Env = env
 
@@ -133,7 +144,7 @@ func (s *ComposeStrategy) Build(ctx context.Context, opts build.BuildOptions) (*
 	go func() {
 		scanner := bufio.NewScanner(stderr)
 		for scanner.Scan() {
-			fmt.Fprintf(opts.LogWriter, "%s\n", scanner.Text())
+			fmt.Fprintf(opts.Stderr(), "%s\n", scanner.Text())
 		}
 	}()
 
@@ -192,16 +203,14 @@ func (s *ComposeStrategy) upWithOptions(ctx context.Context, opts build.BuildOpt
 	}
 
 	// Build environment
-	env := os.Environ()
-	for k, v := range opts.EnvVars {
-		env = append(env, fmt.Sprintf("%s=%s", k, v))
-	}
+	env := composeEnv(opts)
 
 	// Build command args with both compose files
 	args := []string{"compose", "-f", composePath}
 	if overridePath != "" {
 		args = append(args, "-f", overridePath)
 	}
+	args = append(args, ProfileArgs(opts.Profiles)...)
 	args = append(args, "up", "-d", "--force-recreate", "--remove-orphans")
 	if !selfDeploy {
 		args = append(args, "--wait")
@@ -218,6 +227,9 @@ func (s *ComposeStrategy) upWithOptions(ctx context.Context, opts build.BuildOpt
 		if overridePath != "" {
 			composeCmd += fmt.Sprintf(" -f %s", overridePath)
 		}
+		for _, arg := range ProfileArgs(opts.Profiles) {
+			composeCmd += " " + arg
+		}
 		composeCmd += " up -d --force-recreate --remove-orphans"
 
 		script := fmt.Sprintf(`
@@ -269,7 +281,7 @@ func (s *ComposeStrategy) upWithOptions(ctx context.Context, opts build.BuildOpt
 	go func() {
 		scanner := bufio.NewScanner(stderr)
 		for scanner.Scan() {
-			fmt.Fprintf(opts.LogWriter, "%s\n", scanner.Text())
+			fmt.Fprintf(opts.Stderr(), "%s\n", scanner.Text())
 		}
 	}()
 
@@ -281,6 +293,28 @@ func (s *ComposeStrategy) upWithOptions(ctx context.Context, opts build.BuildOpt
 	return nil
 }
 
+// ProfileArgs returns the --profile flags that enable compose profiles
+func ProfileArgs(profiles []string) []string {
+	var args []string
+	for _, p := range profiles {
+		args = append(args, "--profile", p)
+	}
+	return args
+}
+
+// composeEnv returns the environment docker compose runs with: ours, the
+// app's env vars and, for remote hosts, the daemon to talk to
+func composeEnv(opts build.BuildOptions) []string {
+	env := os.Environ()
+	for k, v := range opts.EnvVars {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+	if opts.Docker != nil {
+		env = append(env, opts.Docker.Env()...)
+	}
+	return env
+}
+
 // writeEnvFile writes environment variables to a .env file
 func writeEnvFile(path string, envVars map[string]string) error {
 	f, err := os.Create(path)
@@ -304,21 +338,31 @@ func writeEnvFile(path string, envVars map[string]string) error {
 // generateLabelOverride creates an override file that adds schooner labels to all services
 // and converts relative bind mounts to volume mounts (for containerized Schooner deployments)
 func generateLabelOverride(composePath string, opts build.BuildOptions) (string, error) {
+	overridePath := filepath.Join(filepath.Dir(composePath), schoonerOverrideFile)
+	if err := writeLabelOverride(composePath, overridePath, opts); err != nil {
+		return "", err
+	}
+	return overridePath, nil
+}
+
+// writeLabelOverride writes the override generateLabelOverride creates to
+// overridePath
+func writeLabelOverride(composePath, overridePath string, opts build.BuildOptions) error {
 	// Read the original compose file
 	data, err := os.ReadFile(composePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to read compose file: %w", err)
+		return fmt.Errorf("failed to read compose file: %w", err)
 	}
 
 	// Parse to extract service names and volumes
 	var compose map[string]interface{}
 	if err := yaml.Unmarshal(data, &compose); err != nil {
-		return "", fmt.Errorf("failed to parse compose file: %w", err)
+		return fmt.Errorf("failed to parse compose file: %w", err)
 	}
 
 	services, ok := compose["services"].(map[string]interface{})
 	if !ok {
-		return "", fmt.Errorf("no services found in compose file")
+		return fmt.Errorf("no services found in compose file")
 	}
 
 	// Build override structure with labels for each service
@@ -332,8 +376,9 @@ func generateLabelOverride(composePath string, opts build.BuildOptions) (string,
 	}
 
 	// Check if we're running in a container with the schooner-data volume
-	// by checking if /data is a mount point (the volume path)
-	needsVolumeConversion := isRunningInContainer()
+	// by checking if /data is a mount point (the volume path). The volume
+	// only exists on the local daemon.
+	needsVolumeConversion := isRunningInContainer() && (opts.Docker == nil || !opts.Docker.IsRemote())
 
 	overrideServices := make(map[string]interface{})
 	hasBindMounts := false
@@ -351,6 +396,12 @@ func generateLabelOverride(composePath string, opts build.BuildOptions) (string,
 			}
 		}
 
+		// Redeploys run the exact images an earlier deploy did. Services
+		// built from source are rebuilt from the pinned commit instead.
+		if pinned, ok := opts.PinnedImages[serviceName]; ok && !hasBuild(serviceConfig) {
+			serviceOverride["image"] = pinned
+		}
+
 		overrideServices[serviceName] = serviceOverride
 	}
 
@@ -358,6 +409,40 @@ func generateLabelOverride(composePath string, opts build.BuildOptions) (string,
 		"services": overrideServices,
 	}
 
+	networks := make(map[string]interface{})
+
+	// Route the service publishing the app's public port through the proxy
+	if opts.Routing != nil {
+		if routeComposeService(overrideServices, services, labels, opts.Routing) {
+			if opts.Routing.Network != "" {
+				externalNetwork(networks, opts.Routing.Network)
+			}
+			fmt.Fprintf(opts.LogWriter, "Added proxy route labels for %s\n", describeRouting(opts.Routing))
+		} else {
+			fmt.Fprintf(opts.LogWriter, "Warning: no service publishes port %d, skipping proxy route labels\n", opts.Routing.PublicPort)
+		}
+	}
+
+	// Put every service on the app's private network, where its add-ons are
+	if opts.AppNetwork != "" {
+		for serviceName, serviceConfig := range services {
+			joinNetwork(overrideServices[serviceName].(map[string]interface{}), serviceConfig, opts.AppNetwork)
+		}
+		externalNetwork(networks, opts.AppNetwork)
+	}
+
+	// and the add-ons of other apps their env vars reference
+	for _, network := range opts.Networks {
+		for serviceName, serviceConfig := range services {
+			joinNetwork(overrideServices[serviceName].(map[string]interface{}), serviceConfig, network)
+		}
+		externalNetwork(networks, network)
+	}
+
+	if len(networks) > 0 {
+		override["networks"] = networks
+	}
+
 	// Add external volume definition if we converted any bind mounts
 	if hasBindMounts {
 		override["volumes"] = map[string]interface{}{
@@ -370,15 +455,14 @@ func generateLabelOverride(composePath string, opts build.BuildOptions) (string,
 	// Write override file
 	overrideData, err := yaml.Marshal(override)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal override: %w", err)
+		return fmt.Errorf("failed to marshal override: %w", err)
 	}
 
-	overridePath := filepath.Join(filepath.Dir(composePath), schoonerOverrideFile)
 	if err := os.WriteFile(overridePath, overrideData, 0644); err != nil {
-		return "", fmt.Errorf("failed to write override file: %w", err)
+		return fmt.Errorf("failed to write override file: %w", err)
 	}
 
-	return overridePath, nil
+	return nil
 }
 
 // isRunningInContainer checks if Schooner is running inside a Docker container
@@ -0,0 +1,83 @@
+package strategies
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"schooner/internal/build"
+)
+
+// ImageDigests returns the image each of the app's compose services runs,
+// pinned by digest. Services running images built locally have no digest
+// and are left out.
+func (s *ComposeStrategy) ImageDigests(ctx context.Context, opts build.BuildOptions) (map[string]string, error) {
+	dockerClient := opts.Docker
+	if dockerClient == nil {
+		dockerClient = s.dockerClient
+	}
+	if dockerClient == nil {
+		return nil, fmt.Errorf("Docker client not available")
+	}
+
+	containers, err := dockerClient.ListContainers(ctx, false, map[string]string{"schooner.app-id": opts.AppID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	digests := make(map[string]string)
+	for _, c := range containers {
+		service := c.Labels["com.docker.compose.service"]
+		if service == "" {
+			continue
+		}
+		info, err := dockerClient.InspectContainer(ctx, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		repoDigests, err := dockerClient.ImageRepoDigests(ctx, info.Image)
+		if err != nil {
+			return nil, err
+		}
+		if pinned := pinnedReference(info.Config.Image, repoDigests); pinned != "" {
+			digests[service] = pinned
+		}
+	}
+	return digests, nil
+}
+
+// pinnedReference picks the repo digest of an image that belongs to the
+// repository it was referenced by, falling back to its first digest
+func pinnedReference(ref string, repoDigests []string) string {
+	repository := normalizeRepository(ref)
+	for _, digest := range repoDigests {
+		if normalizeRepository(digest) == repository {
+			return digest
+		}
+	}
+	if len(repoDigests) > 0 {
+		return repoDigests[0]
+	}
+	return ""
+}
+
+// normalizeRepository strips the tag or digest of an image reference, and
+// the Docker Hub prefixes Docker omits from repo digests
+func normalizeRepository(ref string) string {
+	ref, _, _ = strings.Cut(ref, "@")
+	if slash := strings.LastIndex(ref, "/"); strings.LastIndex(ref, ":") > slash {
+		ref = ref[:strings.LastIndex(ref, ":")]
+	}
+	ref = strings.TrimPrefix(ref, "docker.io/")
+	return strings.TrimPrefix(ref, "library/")
+}
+
+// hasBuild reports whether a compose service is built from source
+func hasBuild(serviceConfig interface{}) bool {
+	config, ok := serviceConfig.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = config["build"]
+	return ok
+}
@@ -0,0 +1,83 @@
+package strategies
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"schooner/internal/build"
+)
+
+func TestPinnedReference(t *testing.T) {
+	tests := []struct {
+		name        string
+		ref         string
+		repoDigests []string
+		want        string
+	}{
+		{"docker hub", "postgres:16", []string{"postgres@sha256:aaa"}, "postgres@sha256:aaa"},
+		{"docker hub long form", "docker.io/library/redis", []string{"redis@sha256:bbb"}, "redis@sha256:bbb"},
+		{
+			"matching repository",
+			"ghcr.io/acme/api:latest",
+			[]string{"acme/api@sha256:ccc", "ghcr.io/acme/api@sha256:ddd"},
+			"ghcr.io/acme/api@sha256:ddd",
+		},
+		{"registry port", "localhost:5000/app", []string{"localhost:5000/app@sha256:eee"}, "localhost:5000/app@sha256:eee"},
+		{"already pinned", "nginx@sha256:fff", []string{"nginx@sha256:fff"}, "nginx@sha256:fff"},
+		{"other repository", "app:1", []string{"mirror/app@sha256:ggg"}, "mirror/app@sha256:ggg"},
+		{"built locally", "myapp-web", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pinnedReference(tt.ref, tt.repoDigests); got != tt.want {
+				t.Errorf("pinnedReference(%q) = %q, want %q", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteLabelOverridePinsImages(t *testing.T) {
+	dir := t.TempDir()
+	composePath := filepath.Join(dir, "docker-compose.yml")
+	compose := "services:\n  web:\n    build: .\n  db:\n    image: postgres:16\n"
+	if err := os.WriteFile(composePath, []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	overridePath := filepath.Join(dir, "override.yml")
+	err := writeLabelOverride(composePath, overridePath, build.BuildOptions{
+		AppID:    "app-1",
+		AppName:  "app",
+		RepoPath: dir,
+		PinnedImages: map[string]string{
+			"web": "app-web@sha256:aaa",
+			"db":  "postgres@sha256:bbb",
+		},
+	})
+	if err != nil {
+		t.Fatalf("writeLabelOverride() error = %v", err)
+	}
+
+	data, err := os.ReadFile(overridePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var override struct {
+		Services map[string]struct {
+			Image string `yaml:"image"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &override); err != nil {
+		t.Fatal(err)
+	}
+	if got := override.Services["db"].Image; got != "postgres@sha256:bbb" {
+		t.Errorf("db image = %q, want postgres@sha256:bbb", got)
+	}
+	if got := override.Services["web"].Image; got != "" {
+		t.Errorf("web image = %q, want none for a service built from source", got)
+	}
+}
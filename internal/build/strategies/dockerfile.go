@@ -16,6 +16,9 @@ import (
 	"schooner/internal/models"
 )
 
+// buildContextExcludes are left out of the build context sent to the daemon
+var buildContextExcludes = []string{".git", "node_modules", ".env*", "*.log"}
+
 // DockerfileStrategy builds images using a Dockerfile
 type DockerfileStrategy struct {
 	dockerClient *docker.Client
@@ -61,20 +64,35 @@ func (s *DockerfileStrategy) Build(ctx context.Context, opts build.BuildOptions)
 		return nil, fmt.Errorf("invalid build context: %w", err)
 	}
 
+	// Prepare image tag
+	imageTag := fmt.Sprintf("%s:%s", opts.ImageName, opts.Tag)
+
+	dockerClient := s.dockerClient
+	if opts.Docker != nil {
+		dockerClient = opts.Docker
+	}
+
+	if opts.Isolated {
+		fmt.Fprintf(opts.LogWriter, "Building image: %s\n", imageTag)
+		fmt.Fprintf(opts.LogWriter, "Dockerfile: %s\n", opts.Dockerfile)
+		if err := runIsolated(ctx, dockerClient, opts, contextPath, buildContextExcludes, nil, isolatedBuildArgs(opts, imageTag)...); err != nil {
+			return nil, fmt.Errorf("docker build failed: %w", err)
+		}
+		fmt.Fprintf(opts.LogWriter, "\nBuild complete: %s\n", imageTag)
+		return &build.BuildResult{ImageTag: imageTag}, nil
+	}
+
 	// Create tar archive of build context
 	fmt.Fprintf(opts.LogWriter, "Creating build context from %s\n", contextPath)
 
 	buildContext, err := archive.TarWithOptions(contextPath, &archive.TarOptions{
-		ExcludePatterns: []string{".git", "node_modules", ".env*", "*.log"},
+		ExcludePatterns: buildContextExcludes,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create build context: %w", err)
 	}
 	defer buildContext.Close()
 
-	// Prepare image tag
-	imageTag := fmt.Sprintf("%s:%s", opts.ImageName, opts.Tag)
-
 	fmt.Fprintf(opts.LogWriter, "Building image: %s\n", imageTag)
 	fmt.Fprintf(opts.LogWriter, "Dockerfile: %s\n", opts.Dockerfile)
 
@@ -85,20 +103,30 @@ func (s *DockerfileStrategy) Build(ctx context.Context, opts build.BuildOptions)
 		buildArgs[k] = &val
 	}
 
+	// Tag the image with its moving tag too, and label it with the build
+	tags := []string{imageTag}
+	if alias := aliasTag(opts); alias != "" {
+		tags = append(tags, alias)
+	}
+	labels := map[string]string{
+		"schooner.app":    opts.AppName,
+		"schooner.app-id": opts.AppID,
+	}
+	for k, v := range opts.Labels {
+		labels[k] = v
+	}
+
 	// Build options
 	buildOpts := types.ImageBuildOptions{
-		Tags:       []string{imageTag},
+		Tags:       tags,
 		Dockerfile: opts.Dockerfile,
 		Remove:     true,
 		BuildArgs:  buildArgs,
-		Labels: map[string]string{
-			"schooner.app":    opts.AppName,
-			"schooner.app-id": opts.AppID,
-		},
+		Labels:     labels,
 	}
 
 	// Execute build
-	resp, err := s.dockerClient.BuildImage(ctx, buildContext, buildOpts)
+	resp, err := dockerClient.BuildImage(ctx, buildContext, buildOpts)
 	if err != nil {
 		return nil, fmt.Errorf("docker build failed: %w", err)
 	}
@@ -118,6 +146,15 @@ func (s *DockerfileStrategy) Build(ctx context.Context, opts build.BuildOptions)
 	}, nil
 }
 
+// aliasTag returns the moving tag the image also gets, such as
+// web:main-latest, or an empty string if it gets none
+func aliasTag(opts build.BuildOptions) string {
+	if opts.TagAlias == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%s", opts.ImageName, opts.TagAlias)
+}
+
 // streamBuildOutput streams Docker build output and extracts the image ID
 func streamBuildOutput(reader io.Reader, writer io.Writer) (string, error) {
 	var imageID string
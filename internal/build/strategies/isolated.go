@@ -0,0 +1,74 @@
+package strategies
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/pkg/archive"
+
+	"schooner/internal/build"
+	"schooner/internal/docker"
+)
+
+// builderImage runs isolated builds: the docker CLI with the compose and
+// buildx plugins
+const builderImage = "docker:cli"
+
+// runIsolated runs a docker command in a disposable builder container on
+// the build's daemon. The builder gets a copy of dir at the same path and
+// only the env given, never Schooner's own files or environment.
+func runIsolated(ctx context.Context, dockerClient *docker.Client, opts build.BuildOptions, dir string, exclude []string, env map[string]string, args ...string) error {
+	if dockerClient == nil {
+		return fmt.Errorf("Docker client not available")
+	}
+
+	files, err := archive.TarWithOptions(dir, &archive.TarOptions{ExcludePatterns: exclude})
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", dir, err)
+	}
+	defer files.Close()
+
+	fmt.Fprintf(opts.LogWriter, "Running in isolated builder container (%s)\n", builderImage)
+
+	exitCode, err := dockerClient.RunBuilder(ctx, docker.BuilderConfig{
+		Name:    "schooner-builder-" + opts.BuildID,
+		Image:   builderImage,
+		Cmd:     append([]string{"docker"}, args...),
+		Env:     sortedEnv(env),
+		WorkDir: dir,
+		Files:   files,
+		Labels:  map[string]string{"schooner.builder": opts.BuildID},
+		Socket:  true,
+		// The builder only runs the docker CLI while the daemon does the
+		// heavy lifting
+		Memory:    512 * 1024 * 1024,
+		PidsLimit: 256,
+	}, opts.LogWriter, opts.Stderr())
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("builder exited with code %d", exitCode)
+	}
+	return nil
+}
+
+// isolatedBuildArgs are the docker build arguments of a Dockerfile build run
+// in a builder container
+func isolatedBuildArgs(opts build.BuildOptions, imageTag string) []string {
+	args := []string{"build", "--progress", "plain", "-t", imageTag}
+	if alias := aliasTag(opts); alias != "" {
+		args = append(args, "-t", alias)
+	}
+	args = append(args, "-f", opts.Dockerfile,
+		"--label", "schooner.app="+opts.AppName,
+		"--label", "schooner.app-id="+opts.AppID,
+	)
+	for _, label := range sortedEnv(opts.Labels) {
+		args = append(args, "--label", label)
+	}
+	for _, arg := range sortedEnv(opts.BuildArgs) {
+		args = append(args, "--build-arg", arg)
+	}
+	return append(args, ".")
+}
@@ -0,0 +1,57 @@
+package strategies
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"schooner/internal/build"
+)
+
+func TestIsolatedBuildArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		opts build.BuildOptions
+		want string
+	}{
+		{
+			name: "build args sorted",
+			opts: build.BuildOptions{
+				AppID: "a1", AppName: "web", Dockerfile: "Dockerfile",
+				BuildArgs: map[string]string{"VERSION": "abc123", "NODE_ENV": "production"},
+			},
+			want: "build --progress plain -t web:abc123 -f Dockerfile --label schooner.app=web --label schooner.app-id=a1 --build-arg NODE_ENV=production --build-arg VERSION=abc123 .",
+		},
+		{
+			name: "nested Dockerfile",
+			opts: build.BuildOptions{AppID: "a2", AppName: "api", Dockerfile: "docker/api.Dockerfile"},
+			want: "build --progress plain -t web:abc123 -f docker/api.Dockerfile --label schooner.app=api --label schooner.app-id=a2 .",
+		},
+		{
+			name: "moving tag and OCI labels",
+			opts: build.BuildOptions{
+				AppID: "a3", AppName: "web", ImageName: "web", TagAlias: "main-latest", Dockerfile: "Dockerfile",
+				Labels: map[string]string{"org.opencontainers.image.version": "main-abc123"},
+			},
+			want: "build --progress plain -t web:abc123 -t web:main-latest -f Dockerfile --label schooner.app=web --label schooner.app-id=a3 --label org.opencontainers.image.version=main-abc123 .",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(isolatedBuildArgs(tt.opts, "web:abc123"), " ")
+			if got != tt.want {
+				t.Errorf("isolatedBuildArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunIsolatedWithoutDocker(t *testing.T) {
+	opts := build.BuildOptions{BuildID: "b1", LogWriter: io.Discard}
+	err := runIsolated(context.Background(), nil, opts, t.TempDir(), nil, nil, "build", ".")
+	if err == nil || !strings.Contains(err.Error(), "not available") {
+		t.Errorf("runIsolated() error = %v, want Docker not available", err)
+	}
+}
@@ -0,0 +1,161 @@
+package strategies
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/pkg/archive"
+
+	"schooner/internal/build"
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+// kanikoImage runs kaniko builds
+const kanikoImage = "gcr.io/kaniko-project/executor:v1.23.2"
+
+// kanikoCapabilities are what kaniko needs to unpack base images and run
+// RUN steps as root inside its own container, which Docker grants
+// unprivileged containers by default too
+var kanikoCapabilities = []string{
+	"CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID", "SETUID", "SETGID", "SETFCAP",
+	"MKNOD", "KILL", "SYS_CHROOT", "AUDIT_WRITE", "NET_BIND_SERVICE",
+}
+
+// RegistryCredentials provides the logins kaniko pushes and pulls with
+type RegistryCredentials interface {
+	GetRegistryCredentials(ctx context.Context) ([]models.RegistryCredential, error)
+}
+
+// KanikoStrategy builds Dockerfiles with kaniko in an unprivileged
+// container, without access to the Docker socket. Images are pushed when
+// their name includes a registry and always loaded locally to deploy.
+type KanikoStrategy struct {
+	*DockerfileStrategy
+	credentials RegistryCredentials
+}
+
+// NewKanikoStrategy creates a new kaniko build strategy
+func NewKanikoStrategy(dockerClient *docker.Client, credentials RegistryCredentials) *KanikoStrategy {
+	return &KanikoStrategy{
+		DockerfileStrategy: NewDockerfileStrategy(dockerClient),
+		credentials:        credentials,
+	}
+}
+
+// Name returns the strategy name
+func (s *KanikoStrategy) Name() models.BuildStrategy {
+	return models.BuildStrategyKaniko
+}
+
+// Build executes the build
+func (s *KanikoStrategy) Build(ctx context.Context, opts build.BuildOptions) (*build.BuildResult, error) {
+	contextPath, err := build.SafePath(opts.RepoPath, opts.BuildContext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid build context: %w", err)
+	}
+
+	imageTag := fmt.Sprintf("%s:%s", opts.ImageName, opts.Tag)
+
+	dockerClient := s.dockerClient
+	if opts.Docker != nil {
+		dockerClient = opts.Docker
+	}
+	if dockerClient == nil {
+		return nil, fmt.Errorf("Docker client not available")
+	}
+
+	var creds []models.RegistryCredential
+	if s.credentials != nil {
+		creds, err = s.credentials.GetRegistryCredentials(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get registry credentials: %w", err)
+		}
+	}
+	secrets := map[string][]byte{}
+	if len(creds) > 0 {
+		config, err := models.DockerConfig(creds)
+		if err != nil {
+			return nil, err
+		}
+		secrets["/kaniko/.docker/config.json"] = config
+	}
+
+	files, err := archive.TarWithOptions(contextPath, &archive.TarOptions{ExcludePatterns: buildContextExcludes})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create build context: %w", err)
+	}
+	defer files.Close()
+
+	push := models.ImageRegistry(opts.ImageName) != ""
+	fmt.Fprintf(opts.LogWriter, "Building image with kaniko: %s\n", imageTag)
+	fmt.Fprintf(opts.LogWriter, "Dockerfile: %s\n", opts.Dockerfile)
+	if !push {
+		fmt.Fprintf(opts.LogWriter, "Image name has no registry, not pushing\n")
+	}
+
+	exitCode, err := dockerClient.RunBuilder(ctx, docker.BuilderConfig{
+		Name:    "schooner-builder-" + opts.BuildID,
+		Image:   kanikoImage,
+		Cmd:     append([]string{"/kaniko/executor"}, kanikoArgs(opts, imageTag, push)...),
+		WorkDir: "/workspace",
+		Files:   files,
+		Secrets: secrets,
+		Labels:  map[string]string{"schooner.builder": opts.BuildID},
+		Network: "bridge",
+		CapAdd:  kanikoCapabilities,
+		Volumes: opts.Caches, // Kaniko leaves mounts out of the image
+		Output:  "/kaniko/image.tar",
+		Collect: func(output io.Reader) error {
+			return loadKanikoImage(ctx, dockerClient, output)
+		},
+	}, opts.LogWriter, opts.Stderr())
+	if err != nil {
+		return nil, fmt.Errorf("kaniko build failed: %w", err)
+	}
+	if exitCode != 0 {
+		return nil, fmt.Errorf("kaniko build failed: builder exited with code %d", exitCode)
+	}
+
+	fmt.Fprintf(opts.LogWriter, "\nBuild complete: %s\n", imageTag)
+	return &build.BuildResult{ImageTag: imageTag}, nil
+}
+
+// kanikoArgs are the executor arguments of a build. The image is always
+// written to a tarball for the daemon to load, and pushed only if asked to.
+func kanikoArgs(opts build.BuildOptions, imageTag string, push bool) []string {
+	args := []string{
+		"--context", "dir:///workspace",
+		"--dockerfile", opts.Dockerfile,
+		"--destination", imageTag,
+	}
+	if alias := aliasTag(opts); alias != "" {
+		args = append(args, "--destination", alias)
+	}
+	args = append(args, "--tar-path", "/kaniko/image.tar",
+		"--label", "schooner.app="+opts.AppName,
+		"--label", "schooner.app-id="+opts.AppID,
+	)
+	for _, label := range sortedEnv(opts.Labels) {
+		args = append(args, "--label", label)
+	}
+	for _, arg := range sortedEnv(opts.BuildArgs) {
+		args = append(args, "--build-arg", arg)
+	}
+	if !push {
+		args = append(args, "--no-push")
+	}
+	return args
+}
+
+// loadKanikoImage loads the image tarball kaniko wrote, which arrives
+// wrapped in the tar archive of the file copied out of the builder
+func loadKanikoImage(ctx context.Context, dockerClient *docker.Client, output io.Reader) error {
+	tr := tar.NewReader(output)
+	if _, err := tr.Next(); err != nil {
+		return fmt.Errorf("failed to read kaniko image: %w", err)
+	}
+	return dockerClient.LoadImage(ctx, tr)
+}
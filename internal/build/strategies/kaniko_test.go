@@ -0,0 +1,49 @@
+package strategies
+
+import (
+	"strings"
+	"testing"
+
+	"schooner/internal/build"
+)
+
+func TestKanikoArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		opts build.BuildOptions
+		push bool
+		want string
+	}{
+		{
+			name: "local image",
+			opts: build.BuildOptions{AppID: "a1", AppName: "web", Dockerfile: "Dockerfile"},
+			want: "--context dir:///workspace --dockerfile Dockerfile --destination web:abc123 --tar-path /kaniko/image.tar --label schooner.app=web --label schooner.app-id=a1 --no-push",
+		},
+		{
+			name: "pushed with build args sorted",
+			opts: build.BuildOptions{
+				AppID: "a2", AppName: "api", Dockerfile: "docker/api.Dockerfile",
+				BuildArgs: map[string]string{"VERSION": "abc123", "NODE_ENV": "production"},
+			},
+			push: true,
+			want: "--context dir:///workspace --dockerfile docker/api.Dockerfile --destination web:abc123 --tar-path /kaniko/image.tar --label schooner.app=api --label schooner.app-id=a2 --build-arg NODE_ENV=production --build-arg VERSION=abc123",
+		},
+		{
+			name: "moving tag and OCI labels",
+			opts: build.BuildOptions{
+				AppID: "a3", AppName: "web", ImageName: "web", TagAlias: "1.4", Dockerfile: "Dockerfile",
+				Labels: map[string]string{"org.opencontainers.image.version": "1.4.2"},
+			},
+			want: "--context dir:///workspace --dockerfile Dockerfile --destination web:abc123 --destination web:1.4 --tar-path /kaniko/image.tar --label schooner.app=web --label schooner.app-id=a3 --label org.opencontainers.image.version=1.4.2 --no-push",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(kanikoArgs(tt.opts, "web:abc123", tt.push), " ")
+			if got != tt.want {
+				t.Errorf("kanikoArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
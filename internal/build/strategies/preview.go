@@ -0,0 +1,370 @@
+package strategies
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+
+	"schooner/internal/build"
+)
+
+// composeConfig is the part of `docker compose config --format json` a
+// preview compares
+type composeConfig struct {
+	Name     string                          `json:"name"`
+	Services map[string]composeServiceConfig `json:"services"`
+	Networks map[string]struct {
+		Name string `json:"name"`
+	} `json:"networks"`
+}
+
+type composeServiceConfig struct {
+	Image       string                     `json:"image"`
+	Build       json.RawMessage            `json:"build"`
+	Environment map[string]*string         `json:"environment"`
+	Labels      map[string]string          `json:"labels"`
+	Ports       []composePortConfig        `json:"ports"`
+	Volumes     []composeVolumeConfig      `json:"volumes"`
+	Networks    map[string]json.RawMessage `json:"networks"`
+}
+
+type composePortConfig struct {
+	Target    int         `json:"target"`
+	Published interface{} `json:"published"`
+	Protocol  string      `json:"protocol"`
+	HostIP    string      `json:"host_ip"`
+}
+
+type composeVolumeConfig struct {
+	Type   string `json:"type"`
+	Target string `json:"target"`
+}
+
+// serviceState is the comparable configuration of a service, as declared
+// or as its container runs
+type serviceState struct {
+	Image    string
+	Rebuild  bool
+	Env      map[string]string
+	Labels   map[string]string
+	Ports    []string
+	Mounts   []string
+	Networks []string
+}
+
+// Preview renders the compose config a deploy would run, with the override
+// Schooner generates, and compares it against the app's running containers
+func (s *ComposeStrategy) Preview(ctx context.Context, opts build.BuildOptions) (*build.ComposePreview, error) {
+	composeFile := FindComposeFile(opts.RepoPath, opts.ComposeFile)
+	if composeFile == "" {
+		return nil, fmt.Errorf("compose file not found")
+	}
+	composePath := filepath.Join(opts.RepoPath, composeFile)
+
+	// The override goes to a temporary file so the deployed one stays
+	// untouched
+	override, err := os.CreateTemp("", "schooner-preview-*.yml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create override file: %w", err)
+	}
+	override.Close()
+	defer os.Remove(override.Name())
+	if err := writeLabelOverride(composePath, override.Name(), opts); err != nil {
+		return nil, err
+	}
+
+	args := append([]string{"compose", "-f", composePath, "-f", override.Name()}, ProfileArgs(opts.Profiles)...)
+	args = append(args, "config")
+	rendered, err := s.composeOutput(ctx, opts, args...)
+	if err != nil {
+		return nil, err
+	}
+	configJSON, err := s.composeOutput(ctx, opts, append(args, "--format", "json")...)
+	if err != nil {
+		return nil, err
+	}
+	var cfg composeConfig
+	if err := json.Unmarshal(configJSON, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse compose config: %w", err)
+	}
+
+	running, err := s.runningServices(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Containers of services in profiles that aren't enabled are left alone
+	declared := make(map[string]bool)
+	if services, err := ComposeServices(opts.RepoPath, opts.ComposeFile); err == nil {
+		for _, service := range services {
+			declared[service.Name] = true
+		}
+	}
+
+	return &build.ComposePreview{
+		Config:   string(rendered),
+		Services: diffServices(desiredServices(cfg), running, declared),
+	}, nil
+}
+
+// composeOutput runs docker compose in the app's checkout and returns its
+// output
+func (s *ComposeStrategy) composeOutput(ctx context.Context, opts build.BuildOptions, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Dir = opts.RepoPath
+	cmd.Env = composeEnv(opts)
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("docker compose config failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+// runningServices returns the state of the app's containers by service
+func (s *ComposeStrategy) runningServices(ctx context.Context, opts build.BuildOptions) (map[string]serviceState, error) {
+	dockerClient := opts.Docker
+	if dockerClient == nil {
+		dockerClient = s.dockerClient
+	}
+	if dockerClient == nil {
+		return nil, fmt.Errorf("Docker client not available")
+	}
+
+	containers, err := dockerClient.ListContainers(ctx, true, map[string]string{"schooner.app-id": opts.AppID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	running := make(map[string]serviceState)
+	for _, c := range containers {
+		name := c.Labels["com.docker.compose.service"]
+		if name == "" {
+			continue
+		}
+		info, err := dockerClient.InspectContainer(ctx, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		// Without the image's defaults, only what the compose file sets
+		// is compared
+		imageConfig, err := dockerClient.ImageConfig(ctx, info.Image)
+		if err != nil {
+			imageConfig = &container.Config{}
+		}
+		running[name] = runningService(info, imageConfig)
+	}
+	return running, nil
+}
+
+// desiredServices returns the state of each service in a compose config
+func desiredServices(cfg composeConfig) map[string]serviceState {
+	services := make(map[string]serviceState, len(cfg.Services))
+	for name, svc := range cfg.Services {
+		state := serviceState{
+			Image:   svc.Image,
+			Rebuild: len(svc.Build) > 0 && string(svc.Build) != "null",
+			Env:     make(map[string]string),
+			Labels:  make(map[string]string),
+		}
+		if state.Image == "" {
+			// Compose names the images it builds after project and service
+			state.Image = cfg.Name + "-" + name
+		}
+		for k, v := range svc.Environment {
+			if v != nil {
+				state.Env[k] = *v
+			}
+		}
+		for k, v := range svc.Labels {
+			if comparedLabel(k) {
+				state.Labels[k] = v
+			}
+		}
+		for _, p := range svc.Ports {
+			published := ""
+			if p.Published != nil {
+				published = fmt.Sprint(p.Published)
+			}
+			state.Ports = append(state.Ports, formatPort(p.HostIP, published, p.Target, p.Protocol))
+		}
+		for _, v := range svc.Volumes {
+			state.Mounts = append(state.Mounts, v.Type+":"+v.Target)
+		}
+		for network := range svc.Networks {
+			full := cfg.Networks[network].Name
+			if full == "" {
+				full = cfg.Name + "_" + network
+			}
+			state.Networks = append(state.Networks, full)
+		}
+		sortState(&state)
+		services[name] = state
+	}
+	return services
+}
+
+// runningService returns the state of a service's container, leaving out the
+// env and labels it inherits from its image
+func runningService(info types.ContainerJSON, image *container.Config) serviceState {
+	state := serviceState{
+		Env:    make(map[string]string),
+		Labels: make(map[string]string),
+	}
+	if info.Config != nil {
+		state.Image = info.Config.Image
+
+		imageEnv := make(map[string]bool)
+		for _, e := range image.Env {
+			imageEnv[e] = true
+		}
+		for _, e := range info.Config.Env {
+			if imageEnv[e] {
+				continue
+			}
+			k, v, _ := strings.Cut(e, "=")
+			state.Env[k] = v
+		}
+
+		for k, v := range info.Config.Labels {
+			if iv, ok := image.Labels[k]; (ok && iv == v) || !comparedLabel(k) {
+				continue
+			}
+			state.Labels[k] = v
+		}
+	}
+
+	if info.HostConfig != nil {
+		for port, bindings := range info.HostConfig.PortBindings {
+			for _, b := range bindings {
+				state.Ports = append(state.Ports, formatPort(b.HostIP, b.HostPort, port.Int(), port.Proto()))
+			}
+		}
+	}
+	for _, m := range info.Mounts {
+		state.Mounts = append(state.Mounts, string(m.Type)+":"+m.Destination)
+	}
+	if info.NetworkSettings != nil {
+		for network := range info.NetworkSettings.Networks {
+			state.Networks = append(state.Networks, network)
+		}
+	}
+	sortState(&state)
+	return state
+}
+
+// comparedLabel reports whether a label is part of a service's config,
+// rather than set by compose or for a single build
+func comparedLabel(key string) bool {
+	return !strings.HasPrefix(key, "com.docker.compose.") && key != "schooner.build-id"
+}
+
+// formatPort formats a port mapping the same way for compose configs and
+// containers
+func formatPort(hostIP, published string, target int, protocol string) string {
+	if protocol == "" {
+		protocol = "tcp"
+	}
+	port := fmt.Sprintf("%d/%s", target, protocol)
+	if published == "" {
+		return port
+	}
+	if hostIP != "" && hostIP != "0.0.0.0" {
+		return hostIP + ":" + published + ":" + port
+	}
+	return published + ":" + port
+}
+
+func sortState(state *serviceState) {
+	sort.Strings(state.Ports)
+	sort.Strings(state.Mounts)
+	sort.Strings(state.Networks)
+}
+
+// diffServices compares the desired services against the running ones.
+// Running services that are declared but not rendered, i.e. in a disabled
+// profile, aren't removed by a deploy and are left out.
+func diffServices(desired, running map[string]serviceState, declared map[string]bool) []build.ServicePreview {
+	var previews []build.ServicePreview
+	for name, want := range desired {
+		preview := build.ServicePreview{Name: name, Rebuild: want.Rebuild}
+		have, ok := running[name]
+		switch {
+		case !ok:
+			preview.Action = build.PreviewCreate
+		default:
+			preview.Changes = diffState(have, want)
+			preview.Action = build.PreviewUnchanged
+			if len(preview.Changes) > 0 {
+				preview.Action = build.PreviewUpdate
+			}
+		}
+		previews = append(previews, preview)
+	}
+	for name := range running {
+		if _, ok := desired[name]; !ok && !declared[name] {
+			previews = append(previews, build.ServicePreview{Name: name, Action: build.PreviewRemove})
+		}
+	}
+
+	sort.Slice(previews, func(i, j int) bool { return previews[i].Name < previews[j].Name })
+	return previews
+}
+
+// diffState lists the settings that differ between a running and a desired
+// service
+func diffState(have, want serviceState) []build.ConfigChange {
+	var changes []build.ConfigChange
+	if have.Image != want.Image {
+		changes = append(changes, build.ConfigChange{Field: "image", From: have.Image, To: want.Image})
+	}
+	changes = append(changes, diffMap("env ", have.Env, want.Env)...)
+	changes = append(changes, diffMap("label ", have.Labels, want.Labels)...)
+	changes = append(changes, diffList("ports", have.Ports, want.Ports)...)
+	changes = append(changes, diffList("volumes", have.Mounts, want.Mounts)...)
+	changes = append(changes, diffList("networks", have.Networks, want.Networks)...)
+	return changes
+}
+
+func diffMap(prefix string, have, want map[string]string) []build.ConfigChange {
+	keys := make(map[string]bool)
+	for k := range have {
+		keys[k] = true
+	}
+	for k := range want {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var changes []build.ConfigChange
+	for _, k := range sorted {
+		from, hadIt := have[k]
+		to, wantIt := want[k]
+		if hadIt == wantIt && from == to {
+			continue
+		}
+		changes = append(changes, build.ConfigChange{Field: prefix + k, From: from, To: to})
+	}
+	return changes
+}
+
+func diffList(field string, have, want []string) []build.ConfigChange {
+	if strings.Join(have, ",") == strings.Join(want, ",") {
+		return nil
+	}
+	return []build.ConfigChange{{Field: field, From: strings.Join(have, ", "), To: strings.Join(want, ", ")}}
+}
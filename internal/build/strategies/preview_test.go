@@ -0,0 +1,139 @@
+package strategies
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+
+	"schooner/internal/build"
+)
+
+const previewConfigJSON = `{
+  "name": "shop",
+  "services": {
+    "web": {
+      "build": {"context": "."},
+      "environment": {"MODE": "production", "UNSET": null},
+      "labels": {"schooner.app-id": "app-1"},
+      "ports": [{"target": 80, "published": "8080", "protocol": "tcp"}],
+      "volumes": [{"type": "volume", "source": "data", "target": "/data"}],
+      "networks": {"default": null}
+    },
+    "cache": {
+      "image": "redis:7",
+      "networks": {"default": null}
+    }
+  },
+  "networks": {"default": {"name": "shop_default"}}
+}`
+
+func TestDesiredServices(t *testing.T) {
+	var cfg composeConfig
+	if err := json.Unmarshal([]byte(previewConfigJSON), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	got := desiredServices(cfg)
+	want := map[string]serviceState{
+		"web": {
+			Image:    "shop-web",
+			Rebuild:  true,
+			Env:      map[string]string{"MODE": "production"},
+			Labels:   map[string]string{"schooner.app-id": "app-1"},
+			Ports:    []string{"8080:80/tcp"},
+			Mounts:   []string{"volume:/data"},
+			Networks: []string{"shop_default"},
+		},
+		"cache": {
+			Image:    "redis:7",
+			Env:      map[string]string{},
+			Labels:   map[string]string{},
+			Networks: []string{"shop_default"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("desiredServices() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestRunningService(t *testing.T) {
+	info := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			HostConfig: &container.HostConfig{
+				PortBindings: nat.PortMap{"80/tcp": {{HostIP: "0.0.0.0", HostPort: "8080"}}},
+			},
+		},
+		Config: &container.Config{
+			Image: "shop-web",
+			Env:   []string{"PATH=/usr/bin", "MODE=staging"},
+			Labels: map[string]string{
+				"maintainer":                 "shop",
+				"schooner.app-id":            "app-1",
+				"schooner.build-id":          "b-1",
+				"com.docker.compose.project": "shop",
+			},
+		},
+		Mounts: []types.MountPoint{{Type: mount.TypeVolume, Destination: "/data"}},
+		NetworkSettings: &types.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{"shop_default": {}},
+		},
+	}
+	image := &container.Config{
+		Env:    []string{"PATH=/usr/bin"},
+		Labels: map[string]string{"maintainer": "shop"},
+	}
+
+	got := runningService(info, image)
+	want := serviceState{
+		Image:    "shop-web",
+		Env:      map[string]string{"MODE": "staging"},
+		Labels:   map[string]string{"schooner.app-id": "app-1"},
+		Ports:    []string{"8080:80/tcp"},
+		Mounts:   []string{"volume:/data"},
+		Networks: []string{"shop_default"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("runningService() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestDiffServices(t *testing.T) {
+	web := serviceState{
+		Image:   "shop-web",
+		Rebuild: true,
+		Env:     map[string]string{"MODE": "production"},
+		Ports:   []string{"8080:80/tcp"},
+	}
+	desired := map[string]serviceState{
+		"web":    web,
+		"worker": {Image: "shop-worker"},
+		"cache":  {Image: "redis:7"},
+	}
+	running := map[string]serviceState{
+		"web":    {Image: "shop-web", Env: map[string]string{"MODE": "staging", "OLD": "1"}, Ports: []string{"8080:80/tcp"}},
+		"cache":  {Image: "redis:7"},
+		"legacy": {Image: "shop-legacy"},
+		"debug":  {Image: "busybox"},
+	}
+	declared := map[string]bool{"web": true, "worker": true, "cache": true, "debug": true}
+
+	got := diffServices(desired, running, declared)
+	want := []build.ServicePreview{
+		{Name: "cache", Action: build.PreviewUnchanged},
+		{Name: "legacy", Action: build.PreviewRemove},
+		{Name: "web", Action: build.PreviewUpdate, Rebuild: true, Changes: []build.ConfigChange{
+			{Field: "env MODE", From: "staging", To: "production"},
+			{Field: "env OLD", From: "1"},
+		}},
+		{Name: "worker", Action: build.PreviewCreate},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffServices() =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,157 @@
+package strategies
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"schooner/internal/build"
+)
+
+// routeComposeService adds proxy route labels to the service that publishes
+// the app's public port and attaches it to the proxy network. It returns
+// false if no service could be routed.
+func routeComposeService(overrideServices, services map[string]interface{}, labels map[string]string, routing *build.RouteOptions) bool {
+	name, containerPort := routedService(services, routing.PublicPort)
+	if name == "" {
+		return false
+	}
+
+	serviceLabels := routing.LabelsFor(containerPort)
+	for k, v := range serviceLabels {
+		// Compose interpolates $ in the override file, e.g. in bcrypt hashes
+		serviceLabels[k] = strings.ReplaceAll(v, "$", "$$")
+	}
+	for k, v := range labels {
+		serviceLabels[k] = v
+	}
+
+	serviceOverride := overrideServices[name].(map[string]interface{})
+	serviceOverride["labels"] = serviceLabels
+
+	if routing.Network != "" {
+		joinNetwork(serviceOverride, services[name], routing.Network)
+	}
+	return true
+}
+
+// routedService finds the service that publishes publicPort on the host and
+// the container port it maps to. A lone service without a matching mapping is
+// assumed to listen on publicPort.
+func routedService(services map[string]interface{}, publicPort int) (string, int) {
+	for name, serviceConfig := range services {
+		for _, mapping := range publishedPorts(serviceConfig) {
+			if mapping[0] == publicPort {
+				return name, mapping[1]
+			}
+		}
+	}
+
+	if len(services) == 1 {
+		for name := range services {
+			return name, publicPort
+		}
+	}
+	return "", 0
+}
+
+// publishedPorts returns the [host, container] port pairs a service publishes
+func publishedPorts(serviceConfig interface{}) [][2]int {
+	cfg, ok := serviceConfig.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	ports, ok := cfg["ports"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var mappings [][2]int
+	for _, port := range ports {
+		var host, target int
+		var ok bool
+		switch p := port.(type) {
+		case string:
+			host, target, ok = parseShortPort(p)
+		case map[string]interface{}:
+			host, ok = portNumber(p["published"])
+			target, _ = portNumber(p["target"])
+			ok = ok && target != 0
+		}
+		if ok {
+			mappings = append(mappings, [2]int{host, target})
+		}
+	}
+	return mappings
+}
+
+// parseShortPort parses "[IP:]HOST:CONTAINER[/PROTOCOL]" port syntax. Ports
+// without a host part, and port ranges, are not published on a fixed port.
+func parseShortPort(spec string) (host, target int, ok bool) {
+	spec, _, _ = strings.Cut(spec, "/")
+	sep := strings.LastIndex(spec, ":")
+	if sep < 0 {
+		return 0, 0, false
+	}
+
+	target, err := strconv.Atoi(spec[sep+1:])
+	if err != nil {
+		return 0, 0, false
+	}
+	hostPart := spec[:sep]
+	if i := strings.LastIndex(hostPart, ":"); i >= 0 {
+		hostPart = hostPart[i+1:]
+	}
+	host, err = strconv.Atoi(hostPart)
+	if err != nil {
+		return 0, 0, false
+	}
+	return host, target, true
+}
+
+// portNumber reads a port from a YAML value, which may be a number or string
+func portNumber(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case string:
+		port, err := strconv.Atoi(n)
+		return port, err == nil
+	}
+	return 0, false
+}
+
+// joinNetwork adds a network to a service's override. Services on the
+// implicit default network keep it; services with a custom network_mode
+// can't join other networks and are left alone.
+func joinNetwork(serviceOverride map[string]interface{}, serviceConfig interface{}, network string) {
+	cfg, _ := serviceConfig.(map[string]interface{})
+	if _, ok := cfg["network_mode"]; ok {
+		return
+	}
+
+	networks, ok := serviceOverride["networks"].(map[string]interface{})
+	if !ok {
+		networks = make(map[string]interface{})
+		if _, ok := cfg["networks"]; !ok {
+			networks["default"] = map[string]interface{}{}
+		}
+		serviceOverride["networks"] = networks
+	}
+	networks[network] = map[string]interface{}{}
+}
+
+// externalNetwork declares a pre-existing network in a compose override
+func externalNetwork(networks map[string]interface{}, network string) {
+	networks[network] = map[string]interface{}{
+		"external": true,
+	}
+}
+
+// describeRouting summarizes route labels for the build log
+func describeRouting(routing *build.RouteOptions) string {
+	if routing.Network == "" {
+		return fmt.Sprintf("port %d", routing.PublicPort)
+	}
+	return fmt.Sprintf("port %d on network %s", routing.PublicPort, routing.Network)
+}
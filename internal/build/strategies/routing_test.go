@@ -0,0 +1,143 @@
+package strategies
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"schooner/internal/build"
+)
+
+func TestParseShortPort(t *testing.T) {
+	tests := []struct {
+		spec   string
+		host   int
+		target int
+		ok     bool
+	}{
+		{"8080:80", 8080, 80, true},
+		{"127.0.0.1:8080:80", 8080, 80, true},
+		{"8080:80/tcp", 8080, 80, true},
+		{"[::1]:8080:80", 8080, 80, true},
+		{"80", 0, 0, false},
+		{"8000-8010:8000-8010", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		host, target, ok := parseShortPort(tt.spec)
+		if host != tt.host || target != tt.target || ok != tt.ok {
+			t.Errorf("parseShortPort(%q) = %d, %d, %v; want %d, %d, %v", tt.spec, host, target, ok, tt.host, tt.target, tt.ok)
+		}
+	}
+}
+
+func TestRouteComposeService(t *testing.T) {
+	compose := `
+services:
+  db:
+    image: postgres
+  web:
+    image: nginx
+    ports:
+      - "8082:80"
+  worker:
+    image: worker
+    network_mode: host
+    ports:
+      - target: 9000
+        published: "9090"
+`
+	var parsed map[string]map[string]interface{}
+	if err := yaml.Unmarshal([]byte(compose), &parsed); err != nil {
+		t.Fatal(err)
+	}
+	services := parsed["services"]
+
+	tests := []struct {
+		name        string
+		publicPort  int
+		wantService string
+		wantPort    string
+		wantNetwork bool
+	}{
+		{"short syntax", 8082, "web", "80", true},
+		{"long syntax with network_mode", 9090, "worker", "9000", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overrideServices := make(map[string]interface{})
+			for name := range services {
+				overrideServices[name] = map[string]interface{}{"labels": map[string]string{"schooner.managed": "true"}}
+			}
+			routing := &build.RouteOptions{
+				PublicPort: tt.publicPort,
+				Network:    "traefik",
+				Labels:     map[string]string{"traefik.enable": "true", "users": "admin:$2a$10$hash"},
+				PortLabel:  "port",
+			}
+
+			if !routeComposeService(overrideServices, services, map[string]string{"schooner.managed": "true"}, routing) {
+				t.Fatal("expected a service to be routed")
+			}
+
+			override := overrideServices[tt.wantService].(map[string]interface{})
+			labels := override["labels"].(map[string]string)
+			if labels["port"] != tt.wantPort || labels["traefik.enable"] != "true" || labels["schooner.managed"] != "true" {
+				t.Errorf("labels = %v", labels)
+			}
+			if labels["users"] != "admin:$$2a$$10$$hash" {
+				t.Errorf("users label = %q, want $ escaped for compose", labels["users"])
+			}
+			if _, ok := override["networks"]; ok != tt.wantNetwork {
+				t.Errorf("networks set = %v, want %v", ok, tt.wantNetwork)
+			}
+
+			db := overrideServices["db"].(map[string]interface{})
+			if _, ok := db["labels"].(map[string]string)["traefik.enable"]; ok {
+				t.Error("unrouted service should not get proxy labels")
+			}
+		})
+	}
+}
+
+func TestRoutedService_SingleService(t *testing.T) {
+	services := map[string]interface{}{"app": map[string]interface{}{"image": "app"}}
+	name, port := routedService(services, 3000)
+	if name != "app" || port != 3000 {
+		t.Errorf("routedService() = %q, %d; want app, 3000", name, port)
+	}
+
+	services["other"] = map[string]interface{}{"image": "other"}
+	if name, _ := routedService(services, 3000); name != "" {
+		t.Errorf("routedService() = %q, want no match", name)
+	}
+}
+
+func TestJoinNetwork(t *testing.T) {
+	override := map[string]interface{}{}
+	joinNetwork(override, map[string]interface{}{"image": "app"}, "traefik")
+	joinNetwork(override, map[string]interface{}{"image": "app"}, "schooner-addons-1234")
+	networks := override["networks"].(map[string]interface{})
+	for _, name := range []string{"default", "traefik", "schooner-addons-1234"} {
+		if _, ok := networks[name]; !ok {
+			t.Errorf("networks = %v, missing %s", networks, name)
+		}
+	}
+
+	override = map[string]interface{}{}
+	joinNetwork(override, map[string]interface{}{"networks": []interface{}{"backend"}}, "traefik")
+	networks = override["networks"].(map[string]interface{})
+	if _, ok := networks["default"]; ok {
+		t.Error("services with explicit networks should not gain default")
+	}
+	if _, ok := networks["traefik"]; !ok {
+		t.Error("expected proxy network")
+	}
+
+	override = map[string]interface{}{}
+	joinNetwork(override, map[string]interface{}{"network_mode": "host"}, "traefik")
+	if _, ok := override["networks"]; ok {
+		t.Error("services with network_mode should not join networks")
+	}
+}
@@ -0,0 +1,98 @@
+package strategies
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ComposeService is a service declared in an app's compose file
+type ComposeService struct {
+	Name  string
+	Image string
+	// Ports are the "host:container" ports the service publishes
+	Ports []string
+	// Profiles the service belongs to, empty if it always runs
+	Profiles []string
+}
+
+// Enabled reports whether the service runs with the given profiles enabled
+func (s ComposeService) Enabled(profiles []string) bool {
+	if len(s.Profiles) == 0 {
+		return true
+	}
+	for _, p := range s.Profiles {
+		for _, enabled := range profiles {
+			if p == enabled {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ComposeServices returns the services declared in the compose file of a
+// checkout, sorted by name
+func ComposeServices(repoPath, configuredFile string) ([]ComposeService, error) {
+	composeFile := FindComposeFile(repoPath, configuredFile)
+	if composeFile == "" {
+		return nil, fmt.Errorf("compose file not found in %s", repoPath)
+	}
+
+	data, err := os.ReadFile(filepath.Join(repoPath, composeFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compose file: %w", err)
+	}
+	return parseComposeServices(data)
+}
+
+// ComposeFileArgs returns the -f flags that select a checkout's compose file
+// and, once the app has been deployed, the override Schooner generated for
+// it, so commands on single services see the same project as the deploy
+func ComposeFileArgs(repoPath, configuredFile string) ([]string, error) {
+	composeFile := FindComposeFile(repoPath, configuredFile)
+	if composeFile == "" {
+		return nil, fmt.Errorf("compose file not found in %s", repoPath)
+	}
+
+	composePath := filepath.Join(repoPath, composeFile)
+	args := []string{"-f", composePath}
+	overridePath := filepath.Join(filepath.Dir(composePath), schoonerOverrideFile)
+	if _, err := os.Stat(overridePath); err == nil {
+		args = append(args, "-f", overridePath)
+	}
+	return args, nil
+}
+
+// parseComposeServices parses the services of a compose file
+func parseComposeServices(data []byte) ([]ComposeService, error) {
+	var compose map[string]interface{}
+	if err := yaml.Unmarshal(data, &compose); err != nil {
+		return nil, fmt.Errorf("failed to parse compose file: %w", err)
+	}
+
+	services, _ := compose["services"].(map[string]interface{})
+	result := make([]ComposeService, 0, len(services))
+	for name, serviceConfig := range services {
+		service := ComposeService{Name: name}
+		if cfg, ok := serviceConfig.(map[string]interface{}); ok {
+			service.Image, _ = cfg["image"].(string)
+			profiles, _ := cfg["profiles"].([]interface{})
+			for _, p := range profiles {
+				if name, ok := p.(string); ok {
+					service.Profiles = append(service.Profiles, name)
+				}
+			}
+		}
+		for _, mapping := range publishedPorts(serviceConfig) {
+			service.Ports = append(service.Ports, fmt.Sprintf("%d:%d", mapping[0], mapping[1]))
+		}
+		result = append(result, service)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
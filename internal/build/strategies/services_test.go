@@ -0,0 +1,109 @@
+package strategies
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseComposeServices(t *testing.T) {
+	data := []byte(`
+services:
+  web:
+    build: .
+    ports:
+      - "8080:80"
+      - target: 443
+        published: 8443
+  db:
+    image: postgres:16
+  worker:
+    image: app-worker
+    profiles: [workers]
+    ports:
+      - "9000"
+`)
+
+	got, err := parseComposeServices(data)
+	if err != nil {
+		t.Fatalf("parseComposeServices() error = %v", err)
+	}
+
+	want := []ComposeService{
+		{Name: "db", Image: "postgres:16"},
+		{Name: "web", Ports: []string{"8080:80", "8443:443"}},
+		{Name: "worker", Image: "app-worker", Profiles: []string{"workers"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseComposeServices() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseComposeServices_Invalid(t *testing.T) {
+	if _, err := parseComposeServices([]byte("services: [")); err == nil {
+		t.Error("parseComposeServices() error = nil, want parse error")
+	}
+}
+
+func TestComposeFileArgs(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := ComposeFileArgs(dir, ""); err == nil {
+		t.Error("ComposeFileArgs() error = nil, want compose file not found")
+	}
+
+	composePath := filepath.Join(dir, "compose.yaml")
+	if err := os.WriteFile(composePath, []byte("services: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ComposeFileArgs(dir, "")
+	if err != nil {
+		t.Fatalf("ComposeFileArgs() error = %v", err)
+	}
+	if want := []string{"-f", composePath}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ComposeFileArgs() = %v, want %v", got, want)
+	}
+
+	overridePath := filepath.Join(dir, schoonerOverrideFile)
+	if err := os.WriteFile(overridePath, []byte("services: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err = ComposeFileArgs(dir, "")
+	if err != nil {
+		t.Fatalf("ComposeFileArgs() error = %v", err)
+	}
+	if want := []string{"-f", composePath, "-f", overridePath}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ComposeFileArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestComposeService_Enabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		service  ComposeService
+		profiles []string
+		want     bool
+	}{
+		{"no profiles", ComposeService{Name: "web"}, nil, true},
+		{"profile disabled", ComposeService{Name: "debug", Profiles: []string{"debug"}}, []string{"workers"}, false},
+		{"profile enabled", ComposeService{Name: "worker", Profiles: []string{"workers", "all"}}, []string{"all"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.service.Enabled(tt.profiles); got != tt.want {
+				t.Errorf("Enabled(%v) = %v, want %v", tt.profiles, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileArgs(t *testing.T) {
+	if got := ProfileArgs(nil); got != nil {
+		t.Errorf("ProfileArgs(nil) = %v, want nil", got)
+	}
+	want := []string{"--profile", "workers", "--profile", "debug"}
+	if got := ProfileArgs([]string{"workers", "debug"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("ProfileArgs() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,150 @@
+package strategies
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"schooner/internal/build"
+	"schooner/internal/docker"
+)
+
+// Test runs the app's test command in a throwaway container of the image
+// just built
+func (s *DockerfileStrategy) Test(ctx context.Context, opts build.BuildOptions, result *build.BuildResult, command string) error {
+	dockerClient := opts.Docker
+	if dockerClient == nil {
+		dockerClient = s.dockerClient
+	}
+	if dockerClient == nil {
+		return fmt.Errorf("Docker client not available")
+	}
+
+	cfg := docker.ContainerConfig{
+		Image: result.ImageTag,
+		Cmd:   []string{"sh", "-c", command},
+		Env:   sortedEnv(opts.EnvVars),
+	}
+	if len(opts.Caches) > 0 {
+		cfg.Volumes = opts.Caches
+	}
+	if opts.AppNetwork != "" {
+		cfg.Networks = []string{opts.AppNetwork}
+	}
+
+	exitCode, err := dockerClient.RunToCompletion(ctx, cfg, opts.LogWriter, opts.Stderr())
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("test command exited with code %d", exitCode)
+	}
+	return nil
+}
+
+// Test runs the app's test command with docker compose run in the service
+// the step names or the first one built from source, without starting its
+// dependencies
+func (s *ComposeStrategy) Test(ctx context.Context, opts build.BuildOptions, result *build.BuildResult, command string) error {
+	composeFile := FindComposeFile(opts.RepoPath, opts.ComposeFile)
+	if composeFile == "" {
+		return fmt.Errorf("compose file not found")
+	}
+	composePath := filepath.Join(opts.RepoPath, composeFile)
+
+	service := opts.TestService
+	if service == "" {
+		var err error
+		if service, err = testService(composePath); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(opts.LogWriter, "Running tests in service %s\n", service)
+
+	args := append([]string{"compose", "-f", composePath}, ProfileArgs(opts.Profiles)...)
+	args = append(args, "run", "--rm", "--no-deps", "-T")
+	args = append(args, cacheVolumeArgs(opts.Caches)...)
+	args = append(args, service, "sh", "-c", command)
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Dir = opts.RepoPath
+	cmd.Env = composeEnv(opts)
+
+	output, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+	cmd.Stderr = opts.Stderr()
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start docker compose run: %w", err)
+	}
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		fmt.Fprintf(opts.LogWriter, "%s\n", scanner.Text())
+	}
+
+	if err := cmd.Wait(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("test command exited with code %d", exitErr.ExitCode())
+		}
+		return fmt.Errorf("docker compose run failed: %w", err)
+	}
+	return nil
+}
+
+// testService picks the compose service tests run in: the first service,
+// by name, built from source, or the first service if none are
+func testService(composePath string) (string, error) {
+	data, err := os.ReadFile(composePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read compose file: %w", err)
+	}
+	var compose struct {
+		Services map[string]interface{} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &compose); err != nil {
+		return "", fmt.Errorf("failed to parse compose file: %w", err)
+	}
+	if len(compose.Services) == 0 {
+		return "", fmt.Errorf("no services found in compose file")
+	}
+
+	names := make([]string, 0, len(compose.Services))
+	for name := range compose.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if hasBuild(compose.Services[name]) {
+			return name, nil
+		}
+	}
+	return names[0], nil
+}
+
+// cacheVolumeArgs are the docker -v arguments mounting build caches, sorted
+// by volume
+func cacheVolumeArgs(caches map[string]string) []string {
+	var args []string
+	for _, volume := range slices.Sorted(maps.Keys(caches)) {
+		args = append(args, "-v", volume+":"+caches[volume])
+	}
+	return args
+}
+
+// sortedEnv formats env vars as KEY=value, sorted by key
+func sortedEnv(vars map[string]string) []string {
+	env := make([]string, 0, len(vars))
+	for k, v := range vars {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	return env
+}
@@ -0,0 +1,63 @@
+package strategies
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTestService(t *testing.T) {
+	tests := []struct {
+		name    string
+		compose string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "first built service",
+			compose: "services:\n  worker:\n    build: ./worker\n  db:\n    image: postgres\n  api:\n    build: .\n",
+			want:    "api",
+		},
+		{
+			name:    "no built services",
+			compose: "services:\n  web:\n    image: nginx\n  cache:\n    image: redis\n",
+			want:    "cache",
+		},
+		{
+			name:    "no services",
+			compose: "volumes:\n  data: {}\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "compose.yml")
+			if err := os.WriteFile(path, []byte(tt.compose), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := testService(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("testService() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("testService() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheVolumeArgs(t *testing.T) {
+	caches := map[string]string{
+		"schooner-cache-a1-npm":    "/root/.npm",
+		"schooner-cache-a1-go-mod": "/go/pkg/mod",
+	}
+	want := []string{"-v", "schooner-cache-a1-go-mod:/go/pkg/mod", "-v", "schooner-cache-a1-npm:/root/.npm"}
+	if got := cacheVolumeArgs(caches); !reflect.DeepEqual(got, want) {
+		t.Errorf("cacheVolumeArgs() = %v, want %v", got, want)
+	}
+	if got := cacheVolumeArgs(nil); got != nil {
+		t.Errorf("cacheVolumeArgs(nil) = %v, want none", got)
+	}
+}
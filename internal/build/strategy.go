@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"schooner/internal/docker"
 	"schooner/internal/models"
 )
 
@@ -30,12 +31,32 @@ type BuildOptions struct {
 	RepoPath     string
 	ImageName    string
 	Tag          string
+	TagAlias     string // Moving tag also pointing at the image, such as main-latest, empty for none
 	BuildContext string
 	Dockerfile   string
 	ComposeFile  string
+	Profiles     []string // Compose profiles to enable
 	EnvVars      map[string]string
 	BuildArgs    map[string]string
+	Labels       map[string]string // OCI labels describing the build, added to Dockerfile images
 	LogWriter    io.Writer
+	ErrWriter    io.Writer         // Where commands' stderr goes, LogWriter if nil
+	Routing      *RouteOptions     // Reverse proxy labels, nil unless routing by labels
+	AppNetwork   string            // Private network of the app and its add-ons, empty for agents and Swarm
+	Networks     []string          // Add-on networks of other apps, whose add-ons env vars reference
+	Docker       *docker.Client    // Daemon to build and deploy on, nil for the strategy's own client
+	PinnedImages map[string]string // Compose service to image pinned by digest, for redeploys
+	TestService  string            // Compose service a step's command runs in, empty for the first one built from source
+	Isolated     bool              // Build in a disposable builder container instead of with Schooner's own access
+	Caches       map[string]string // Named volume to the path kaniko builds and pipeline steps mount it at
+}
+
+// Stderr returns where commands' stderr goes
+func (o BuildOptions) Stderr() io.Writer {
+	if o.ErrWriter != nil {
+		return o.ErrWriter
+	}
+	return o.LogWriter
 }
 
 // BuildResult contains the result of a build
@@ -0,0 +1,21 @@
+package build
+
+import (
+	"context"
+	"fmt"
+)
+
+// testRunner runs an app's test command against what a build produced
+type testRunner interface {
+	Test(ctx context.Context, opts BuildOptions, result *BuildResult, command string) error
+}
+
+// runTests runs the app's test command with the strategy that built it
+func runTests(ctx context.Context, strategy Strategy, opts BuildOptions, result *BuildResult, command string) error {
+	tester, ok := strategy.(testRunner)
+	if !ok {
+		return fmt.Errorf("the %s strategy can't run tests", strategy.Name())
+	}
+	fmt.Fprintf(opts.LogWriter, "$ %s\n", command)
+	return tester.Test(ctx, opts, result, command)
+}
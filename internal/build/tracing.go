@@ -0,0 +1,57 @@
+package build
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"schooner/internal/models"
+	"schooner/internal/tracing"
+)
+
+// linkTrace remembers the span a build was queued under, such as the
+// webhook's, so the build's spans join that trace once a worker picks the
+// build up
+func (o *Orchestrator) linkTrace(ctx context.Context, buildID string) {
+	parent := trace.SpanContextFromContext(ctx)
+	if !parent.IsValid() {
+		return
+	}
+
+	o.tracesMu.Lock()
+	defer o.tracesMu.Unlock()
+	o.traces[buildID] = parent
+}
+
+// unlinkTrace returns and forgets the span a build was queued under
+func (o *Orchestrator) unlinkTrace(buildID string) trace.SpanContext {
+	o.tracesMu.Lock()
+	defer o.tracesMu.Unlock()
+
+	parent := o.traces[buildID]
+	delete(o.traces, buildID)
+	return parent
+}
+
+// traceBuild starts the span of a build run. The time it spent waiting for
+// its debounce window and a free worker is recorded first as a queue span.
+// Resumed builds waited for approval or a deploy window instead, which is
+// left out.
+func (o *Orchestrator) traceBuild(ctx context.Context, build *models.Build) (context.Context, trace.Span) {
+	if parent := o.unlinkTrace(build.ID); parent.IsValid() {
+		ctx = trace.ContextWithSpanContext(ctx, parent)
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("build.id", build.ID),
+		attribute.String("build.trigger", string(build.Trigger)),
+		attribute.String("app.id", build.AppID),
+	}
+
+	if !build.StartedAt.Valid {
+		_, queued := tracing.StartAt(ctx, "build.queue", build.CreatedAt, attrs...)
+		queued.End()
+	}
+
+	return tracing.Start(ctx, "build", attrs...)
+}
@@ -0,0 +1,74 @@
+package build
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"schooner/internal/database"
+	"schooner/internal/models"
+	"schooner/internal/tracing"
+)
+
+func TestTraceBuild(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	o := NewOrchestrator(nil, nil, nil, nil, nil)
+	defer o.cancel()
+
+	tests := []struct {
+		name      string
+		build     *models.Build
+		wantSpans []string
+	}{
+		{
+			name:      "queued",
+			build:     &models.Build{ID: "b1", AppID: "app1", CreatedAt: time.Now().Add(-time.Minute)},
+			wantSpans: []string{"build.queue", "build"},
+		},
+		{
+			name:      "resumed",
+			build:     &models.Build{ID: "b2", AppID: "app1", CreatedAt: time.Now().Add(-time.Hour), StartedAt: database.NullTime(time.Now())},
+			wantSpans: []string{"build"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			webhookCtx, webhook := tracing.Start(context.Background(), "webhook.github")
+			o.linkTrace(webhookCtx, tt.build.ID)
+			webhook.End()
+
+			_, span := o.traceBuild(context.Background(), tt.build)
+			span.End()
+
+			spans := exporter.GetSpans()[1:]
+			if len(spans) != len(tt.wantSpans) {
+				t.Fatalf("got %d build spans, want %v", len(spans), tt.wantSpans)
+			}
+			for i, s := range spans {
+				if s.Name != tt.wantSpans[i] {
+					t.Errorf("span %d = %q, want %q", i, s.Name, tt.wantSpans[i])
+				}
+				if s.Parent.SpanID() != webhook.SpanContext().SpanID() {
+					t.Errorf("span %q isn't a child of the webhook span", s.Name)
+				}
+			}
+			if spans[0].Name == "build.queue" && !spans[0].StartTime.Equal(tt.build.CreatedAt) {
+				t.Errorf("queue span started at %s, want when the build was created %s", spans[0].StartTime, tt.build.CreatedAt)
+			}
+			if _, ok := o.traces[tt.build.ID]; ok {
+				t.Errorf("trace of build %s still linked", tt.build.ID)
+			}
+		})
+	}
+}
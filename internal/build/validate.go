@@ -0,0 +1,88 @@
+package build
+
+import (
+	"context"
+	"io"
+
+	"schooner/internal/models"
+)
+
+// ValidationResult is the outcome of checking an app's build files
+type ValidationResult struct {
+	Valid    bool                 `json:"valid"`
+	Strategy models.BuildStrategy `json:"strategy"`
+	Commit   string               `json:"commit,omitempty"`
+	Errors   []ValidationError    `json:"errors"`
+}
+
+// ValidationError is a problem found in a build file
+type ValidationError struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// configChecker checks a strategy's build files without building
+type configChecker interface {
+	Check(ctx context.Context, opts BuildOptions) ([]ValidationError, error)
+}
+
+// ValidateApp updates an app's checkout to what its next build would use and
+// checks its compose file or Dockerfile, so mistakes show up before a build
+// fails on them. It fails with ErrBuildRunning while the app builds.
+func (o *Orchestrator) ValidateApp(ctx context.Context, app *models.App) (*ValidationResult, error) {
+	appLock := o.getAppLock(app.ID)
+	if !appLock.TryLock() {
+		return nil, ErrBuildRunning
+	}
+	defer appLock.Unlock()
+
+	repoPath, commitSHA, err := o.refreshCheckout(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	buildStrategy := app.BuildStrategy
+	if buildStrategy == models.BuildStrategyAutodetect {
+		var composeFile string
+		buildStrategy, composeFile = o.detectBuildStrategy(repoPath)
+		if composeFile != "" {
+			app.ComposeFile = composeFile
+		}
+	}
+
+	result := &ValidationResult{Strategy: buildStrategy, Commit: commitSHA, Errors: []ValidationError{}}
+	strategy, ok := o.strategies[buildStrategy]
+	if !ok {
+		result.Errors = append(result.Errors, ValidationError{Message: "unknown build strategy: " + string(buildStrategy)})
+		return result, nil
+	}
+
+	opts := BuildOptions{
+		AppID:        app.ID,
+		AppName:      app.Name,
+		RepoPath:     repoPath,
+		BuildContext: app.BuildContext,
+		Dockerfile:   app.DockerfilePath,
+		ComposeFile:  app.ComposeFile,
+		Profiles:     app.GetComposeProfiles(),
+		EnvVars:      app.EnvVars,
+		LogWriter:    io.Discard,
+	}
+	// Missing files are reported before their contents are checked
+	if err := strategy.Validate(ctx, opts); err != nil {
+		result.Errors = append(result.Errors, ValidationError{Message: err.Error()})
+		return result, nil
+	}
+
+	if checker, ok := strategy.(configChecker); ok {
+		problems, err := checker.Check(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		result.Errors = append(result.Errors, problems...)
+	}
+
+	result.Valid = len(result.Errors) == 0
+	return result, nil
+}
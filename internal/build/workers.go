@@ -0,0 +1,93 @@
+package build
+
+import "sync/atomic"
+
+const (
+	// DefaultWorkers is how many builds run at once unless set otherwise
+	DefaultWorkers = 2
+
+	// MaxWorkers caps the worker pool, as every build holds a checkout and
+	// a Docker build
+	MaxWorkers = 16
+)
+
+// workerPool tracks the goroutines taking builds off the queue
+type workerPool struct {
+	stops []chan struct{} // Closing one stops its worker once it is idle
+	busy  atomic.Int32    // Workers in the middle of a build
+}
+
+// SetWorkers scales the number of builds run at once up or down, clamped to
+// 1..MaxWorkers, and returns the new count. Removed workers finish the build
+// they are running before they exit.
+func (o *Orchestrator) SetWorkers(n int) int {
+	n = max(1, min(n, MaxWorkers))
+
+	o.workersMu.Lock()
+	defer o.workersMu.Unlock()
+
+	if o.ctx.Err() != nil {
+		return len(o.workers.stops)
+	}
+
+	before := len(o.workers.stops)
+	for len(o.workers.stops) < n {
+		stop := make(chan struct{})
+		o.workers.stops = append(o.workers.stops, stop)
+		o.wg.Add(1)
+		go o.worker(len(o.workers.stops)-1, stop)
+	}
+	for len(o.workers.stops) > n {
+		last := len(o.workers.stops) - 1
+		close(o.workers.stops[last])
+		o.workers.stops = o.workers.stops[:last]
+	}
+
+	if before != n {
+		o.logger.Info("build workers changed", "from", before, "to", n)
+	}
+	return n
+}
+
+// Workers returns the number of builds that can run at once
+func (o *Orchestrator) Workers() int {
+	o.workersMu.Lock()
+	defer o.workersMu.Unlock()
+	return len(o.workers.stops)
+}
+
+// BusyWorkers returns the number of builds running right now
+func (o *Orchestrator) BusyWorkers() int {
+	return int(o.workers.busy.Load())
+}
+
+// worker processes builds from the queue until the orchestrator stops or
+// the pool shrinks past it
+func (o *Orchestrator) worker(id int, stop <-chan struct{}) {
+	defer o.wg.Done()
+
+	for {
+		// Leave once scaled down rather than picking up another build
+		select {
+		case <-stop:
+			o.logger.Debug("build worker stopped", "worker", id)
+			return
+		default:
+		}
+
+		select {
+		case <-o.ctx.Done():
+			return
+		case <-stop:
+			o.logger.Debug("build worker stopped", "worker", id)
+			return
+		case buildID, ok := <-o.buildQueue:
+			if !ok {
+				return
+			}
+			o.workers.busy.Add(1)
+			o.processBuild(buildID)
+			o.workers.busy.Add(-1)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package build
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"schooner/internal/database"
+	"schooner/internal/database/queries"
+)
+
+func TestSetWorkers(t *testing.T) {
+	db := newTestDB(t)
+
+	o := NewOrchestrator(nil, nil, queries.NewAppQueries(db.DB), queries.NewBuildQueries(db.DB), queries.NewLogQueries(db.DB))
+	o.Start(DefaultWorkers)
+
+	tests := []struct {
+		set  int
+		want int
+	}{
+		{set: 4, want: 4},
+		{set: 1, want: 1},
+		{set: 0, want: 1},
+		{set: 100, want: MaxWorkers},
+		{set: 3, want: 3},
+	}
+	for _, tt := range tests {
+		if got := o.SetWorkers(tt.set); got != tt.want {
+			t.Errorf("SetWorkers(%d) = %d, want %d", tt.set, got, tt.want)
+		}
+		if got := o.Workers(); got != tt.want {
+			t.Errorf("Workers() after SetWorkers(%d) = %d, want %d", tt.set, got, tt.want)
+		}
+	}
+
+	// The remaining workers still drain the queue; unknown builds are skipped
+	for _, id := range []string{"b1", "b2", "b3", "b4", "b5"} {
+		o.QueueBuild(id)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for o.QueueDepth() > 0 || o.BusyWorkers() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("queue depth %d, busy workers %d, want the queue drained", o.QueueDepth(), o.BusyWorkers())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	o.Stop()
+	if got := o.SetWorkers(2); got != 3 {
+		t.Errorf("SetWorkers() after Stop() = %d, want the pool left at 3", got)
+	}
+}
+
+// newTestDB returns a migrated database in a temporary directory, keeping
+// the encryption key it creates there too
+func newTestDB(t *testing.T) *database.DB {
+	t.Helper()
+	t.Setenv("SCHOONER_KEY_PATH", filepath.Join(t.TempDir(), ".encryption_key"))
+	db, err := database.New(filepath.Join(t.TempDir(), "schooner.db"))
+	if err != nil {
+		t.Fatalf("database.New() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	return db
+}
@@ -0,0 +1,263 @@
+// Package cli implements the HTTP client behind the schooner-cli binary.
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"schooner/internal/models"
+)
+
+// Client talks to the Schooner HTTP API with a personal API token
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a new API client
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{},
+	}
+}
+
+// APIError is a non-2xx response from the server
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("server returned %d", e.StatusCode)
+	}
+	return fmt.Sprintf("server returned %d: %s", e.StatusCode, e.Message)
+}
+
+// newAPIError reads an /api/v1 error envelope, falling back to the raw body
+func newAPIError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	var envelope struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Error.Message != "" {
+		return &APIError{StatusCode: resp.StatusCode, Message: envelope.Error.Message}
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+}
+
+// LogEntry is a single container log line
+type LogEntry struct {
+	Timestamp time.Time
+	Message   string
+}
+
+// page is a paginated /api/v1 list response
+type page[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// ListApps returns all apps, following pagination
+func (c *Client) ListApps(ctx context.Context) ([]models.App, error) {
+	var apps []models.App
+	cursor := ""
+	for {
+		params := url.Values{}
+		params.Set("limit", "200")
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+
+		var resp page[models.App]
+		if err := c.getJSON(ctx, "/api/v1/apps?"+params.Encode(), &resp); err != nil {
+			return nil, fmt.Errorf("failed to list apps: %w", err)
+		}
+		apps = append(apps, resp.Data...)
+
+		if resp.NextCursor == "" {
+			return apps, nil
+		}
+		cursor = resp.NextCursor
+	}
+}
+
+// FindApp resolves an app by ID or name
+func (c *Client) FindApp(ctx context.Context, nameOrID string) (*models.App, error) {
+	apps, err := c.ListApps(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range apps {
+		if apps[i].ID == nameOrID || apps[i].Name == nameOrID {
+			return &apps[i], nil
+		}
+	}
+	return nil, fmt.Errorf("app %q not found", nameOrID)
+}
+
+// Deploy queues a build for an app and returns the build ID
+func (c *Client) Deploy(ctx context.Context, appID string) (string, error) {
+	var resp struct {
+		BuildID string `json:"build_id"`
+	}
+	path := "/api/v1/apps/" + url.PathEscape(appID) + "/deploy"
+	if err := c.doJSON(ctx, http.MethodPost, path, nil, &resp); err != nil {
+		return "", fmt.Errorf("failed to trigger deploy: %w", err)
+	}
+	return resp.BuildID, nil
+}
+
+// ListBuilds returns recent builds, optionally for a single app
+func (c *Client) ListBuilds(ctx context.Context, appID string, limit int) ([]models.Build, error) {
+	params := url.Values{}
+	params.Set("limit", fmt.Sprintf("%d", limit))
+	if appID != "" {
+		params.Set("app_id", appID)
+	}
+
+	var resp page[models.Build]
+	if err := c.getJSON(ctx, "/api/v1/builds?"+params.Encode(), &resp); err != nil {
+		return nil, fmt.Errorf("failed to list builds: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// TailBuild streams a build's logs to fn until the build completes, then
+// returns its final status
+func (c *Client) TailBuild(ctx context.Context, buildID string, fn func(models.BuildLog)) (models.BuildStatus, error) {
+	var status models.BuildStatus
+	path := "/api/v1/builds/" + url.PathEscape(buildID) + "/logs/stream"
+
+	err := c.stream(ctx, path, func(event, data string) error {
+		switch event {
+		case "log":
+			var log models.BuildLog
+			if err := json.Unmarshal([]byte(data), &log); err != nil {
+				return fmt.Errorf("failed to decode build log: %w", err)
+			}
+			fn(log)
+		case "complete":
+			var complete struct {
+				Status models.BuildStatus `json:"status"`
+			}
+			if err := json.Unmarshal([]byte(data), &complete); err != nil {
+				return fmt.Errorf("failed to decode build status: %w", err)
+			}
+			status = complete.Status
+			return errStreamDone
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to stream build logs: %w", err)
+	}
+	return status, nil
+}
+
+// AppLogs returns an app's container logs since the given time, oldest first
+func (c *Client) AppLogs(ctx context.Context, appID string, since time.Time, limit int) ([]LogEntry, error) {
+	params := url.Values{}
+	params.Set("start", fmt.Sprintf("%d", since.UnixNano()))
+	params.Set("limit", fmt.Sprintf("%d", limit))
+
+	var resp lokiResponse
+	path := "/api/v1/logs/" + url.PathEscape(appID) + "?" + params.Encode()
+	if err := c.getJSON(ctx, path, &resp); err != nil {
+		return nil, fmt.Errorf("failed to fetch logs: %w", err)
+	}
+	return resp.entries(), nil
+}
+
+// FollowAppLogs streams an app's container logs to fn until ctx is done.
+// The server starts the stream a few minutes in the past.
+func (c *Client) FollowAppLogs(ctx context.Context, appID string, fn func(LogEntry)) error {
+	path := "/api/v1/logs/" + url.PathEscape(appID) + "/stream"
+
+	err := c.stream(ctx, path, func(event, data string) error {
+		if event != "log" {
+			return nil
+		}
+		var line struct {
+			Timestamp string `json:"timestamp"`
+			Message   string `json:"message"`
+		}
+		if err := json.Unmarshal([]byte(data), &line); err != nil {
+			return fmt.Errorf("failed to decode log line: %w", err)
+		}
+		fn(LogEntry{Timestamp: parseNano(line.Timestamp), Message: line.Message})
+		return nil
+	})
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to stream logs: %w", err)
+	}
+	return nil
+}
+
+// getJSON performs a GET request and decodes the JSON response into out
+func (c *Client) getJSON(ctx context.Context, path string, out interface{}) error {
+	return c.doJSON(ctx, http.MethodGet, path, nil, out)
+}
+
+// doJSON performs a request with an optional JSON body and decodes the JSON
+// response into out, if non-nil
+func (c *Client) doJSON(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	resp, err := c.do(ctx, method, path, reader)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// do sends an authenticated request, turning non-2xx responses into APIErrors
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, newAPIError(resp)
+	}
+
+	return resp, nil
+}
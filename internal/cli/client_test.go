@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"schooner/internal/models"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/apps", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cursor") == "" {
+			fmt.Fprint(w, `{"data":[{"id":"a1","name":"web"}],"next_cursor":"next"}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":[{"id":"a2","name":"api"}]}`)
+	})
+	mux.HandleFunc("GET /api/v1/apps/{appID}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":{"status":404,"code":"not_found","message":"app not found"}}`)
+	})
+	mux.HandleFunc("POST /api/v1/apps/{appID}/deploy", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"status":"queued","build_id":"b-%s"}`, r.PathValue("appID"))
+	})
+	mux.HandleFunc("GET /api/v1/builds/{buildID}/logs/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: log\ndata: {\"id\":1,\"message\":\"step 1\"}\n\n")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "event: log\ndata: {\"id\":2,\"message\":\"step 2\"}\n\n")
+		fmt.Fprint(w, "event: complete\ndata: {\"status\":\"failed\"}\n\n")
+	})
+	mux.HandleFunc("GET /api/v1/logs/{appID}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"result":[
+			{"values":[["3000","third"],["1000","first"]]},
+			{"values":[["2000","second"]]}
+		]}}`)
+	})
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sch_test" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+}
+
+func TestClient_FindApp(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+	client := NewClient(server.URL+"/", "sch_test")
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		wantErr  bool
+	}{
+		{name: "by name", query: "api", expected: "a2"},
+		{name: "by id", query: "a1", expected: "a1"},
+		{name: "unknown", query: "nope", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, err := client.FindApp(context.Background(), tt.query)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FindApp() error = %v", err)
+			}
+			if app.ID != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, app.ID)
+			}
+		})
+	}
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	_, err := NewClient(server.URL, "sch_wrong").ListApps(context.Background())
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 APIError, got %v", err)
+	}
+}
+
+func TestClient_ErrorEnvelope(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	err := NewClient(server.URL, "sch_test").getJSON(context.Background(), "/api/v1/apps/nope", nil)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "app not found" {
+		t.Errorf("expected envelope message, got %v", err)
+	}
+}
+
+func TestClient_Deploy(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	buildID, err := NewClient(server.URL, "sch_test").Deploy(context.Background(), "a1")
+	if err != nil {
+		t.Fatalf("Deploy() error = %v", err)
+	}
+	if buildID != "b-a1" {
+		t.Errorf("expected build b-a1, got %s", buildID)
+	}
+}
+
+func TestClient_TailBuild(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	var messages []string
+	status, err := NewClient(server.URL, "sch_test").TailBuild(context.Background(), "b1", func(log models.BuildLog) {
+		messages = append(messages, log.Message)
+	})
+	if err != nil {
+		t.Fatalf("TailBuild() error = %v", err)
+	}
+
+	if status != models.BuildStatusFailed {
+		t.Errorf("expected status failed, got %s", status)
+	}
+	if strings.Join(messages, ",") != "step 1,step 2" {
+		t.Errorf("unexpected log lines: %v", messages)
+	}
+}
+
+func TestClient_AppLogs(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	entries, err := NewClient(server.URL, "sch_test").AppLogs(context.Background(), "a1", time.Now().Add(-time.Hour), 100)
+	if err != nil {
+		t.Fatalf("AppLogs() error = %v", err)
+	}
+
+	var messages []string
+	for _, entry := range entries {
+		messages = append(messages, entry.Message)
+	}
+	if strings.Join(messages, ",") != "first,second,third" {
+		t.Errorf("expected logs ordered by time, got %v", messages)
+	}
+}
+
+func TestReadEvents_MultilineData(t *testing.T) {
+	input := "event: log\ndata: line one\ndata: line two\n\ndata: no event\n\n"
+
+	var got []string
+	err := readEvents(strings.NewReader(input), func(event, data string) error {
+		got = append(got, event+"|"+data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("readEvents() error = %v", err)
+	}
+
+	expected := []string{"log|line one\nline two", "|no event"}
+	if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
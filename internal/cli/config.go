@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Config holds the server the CLI talks to and the token it uses
+type Config struct {
+	URL   string `json:"url"`
+	Token string `json:"token"`
+}
+
+// DefaultConfigPath returns the per-user config file location
+func DefaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find config directory: %w", err)
+	}
+	return filepath.Join(dir, "schooner", "cli.json"), nil
+}
+
+// LoadConfig reads the config file, returning an empty config if it does
+// not exist. SCHOONER_URL and SCHOONER_TOKEN override the file.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+		}
+	}
+
+	if url := os.Getenv("SCHOONER_URL"); url != "" {
+		cfg.URL = url
+	}
+	if token := os.Getenv("SCHOONER_TOKEN"); token != "" {
+		cfg.Token = token
+	}
+
+	return cfg, nil
+}
+
+// Save writes the config file, readable only by the current user since it
+// holds the API token
+func (c *Config) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// Validate checks that a server and token are configured
+func (c *Config) Validate() error {
+	if c.URL == "" || c.Token == "" {
+		return errors.New("not logged in: run `schooner-cli login --url <url> --token <token>` or set SCHOONER_URL and SCHOONER_TOKEN")
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errStreamDone stops a stream without reporting an error
+var errStreamDone = errors.New("stream done")
+
+// stream opens a server-sent events endpoint and calls fn for each event
+// until the server closes the connection or fn returns an error
+func (c *Client) stream(ctx context.Context, path string, fn func(event, data string) error) error {
+	resp, err := c.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	err = readEvents(resp.Body, fn)
+	if errors.Is(err, errStreamDone) {
+		return nil
+	}
+	return err
+}
+
+// readEvents parses a server-sent events stream, calling fn for each
+// complete event. Multi-line data fields are joined with newlines.
+func readEvents(r io.Reader, fn func(event, data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				if err := fn(event, strings.Join(data, "\n")); err != nil {
+					return err
+				}
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, ":"):
+			// Comment / keep-alive
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+
+	return scanner.Err()
+}
+
+// lokiResponse is the Loki query_range response forwarded by /api/logs
+type lokiResponse struct {
+	Data struct {
+		Result []struct {
+			Values [][]string `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// entries flattens all streams into log entries ordered by time
+func (r *lokiResponse) entries() []LogEntry {
+	var entries []LogEntry
+	for _, stream := range r.Data.Result {
+		for _, value := range stream.Values {
+			if len(value) < 2 {
+				continue
+			}
+			entries = append(entries, LogEntry{Timestamp: parseNano(value[0]), Message: value[1]})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	return entries
+}
+
+// parseNano parses a Unix nanosecond timestamp, returning the zero time if
+// it is malformed
+func parseNano(s string) time.Time {
+	ns, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
@@ -0,0 +1,257 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"schooner/internal/models"
+)
+
+const (
+	// accessAppPrefix marks Access applications Schooner manages, so it never
+	// touches ones created by hand
+	accessAppPrefix    = "Schooner: "
+	accessPolicyName   = "Schooner allow list"
+	accessSessionLimit = "24h"
+)
+
+// AccessApp represents a Cloudflare Access application
+type AccessApp struct {
+	ID              string `json:"id,omitempty"`
+	Name            string `json:"name"`
+	Domain          string `json:"domain"`
+	Type            string `json:"type"`
+	SessionDuration string `json:"session_duration,omitempty"`
+}
+
+// AccessRule is a single Access include rule, e.g. {"email": {"email": "a@b.c"}}
+type AccessRule map[string]map[string]string
+
+// AccessPolicy represents a Cloudflare Access policy
+type AccessPolicy struct {
+	ID         string       `json:"id,omitempty"`
+	Name       string       `json:"name"`
+	Decision   string       `json:"decision"`
+	Include    []AccessRule `json:"include"`
+	Precedence int          `json:"precedence,omitempty"`
+}
+
+// ParseAccessRules converts an allow list into Access include rules. Entries
+// are separated by commas or whitespace and may be an email address, an
+// "@example.com" email domain or "group:<id>" for an Access group.
+func ParseAccessRules(allow string) ([]AccessRule, error) {
+	entries := strings.FieldsFunc(allow, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\r' || r == '\t'
+	})
+
+	var rules []AccessRule
+	for _, entry := range entries {
+		switch {
+		case strings.HasPrefix(entry, "group:"):
+			id := strings.TrimPrefix(entry, "group:")
+			if id == "" {
+				return nil, fmt.Errorf("missing group ID in %q", entry)
+			}
+			rules = append(rules, AccessRule{"group": {"id": id}})
+		case strings.HasPrefix(entry, "@"):
+			if len(entry) == 1 || strings.Contains(entry[1:], "@") {
+				return nil, fmt.Errorf("invalid email domain %q", entry)
+			}
+			rules = append(rules, AccessRule{"email_domain": {"domain": entry[1:]}})
+		case strings.Count(entry, "@") == 1 && !strings.HasSuffix(entry, "@"):
+			rules = append(rules, AccessRule{"email": {"email": entry}})
+		default:
+			return nil, fmt.Errorf("invalid access entry %q: expected an email, @domain or group:<id>", entry)
+		}
+	}
+	return rules, nil
+}
+
+// ListAccessApps lists the Access applications in a zone
+func (c *DNSClient) ListAccessApps(ctx context.Context, zoneID string) ([]AccessApp, error) {
+	resp, err := c.doRequest(ctx, "GET", fmt.Sprintf("/zones/%s/access/apps", zoneID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var apps []AccessApp
+	if err := json.Unmarshal(resp.Result, &apps); err != nil {
+		return nil, fmt.Errorf("failed to parse access apps: %w", err)
+	}
+	return apps, nil
+}
+
+// CreateAccessApp creates an Access application in a zone
+func (c *DNSClient) CreateAccessApp(ctx context.Context, zoneID string, app AccessApp) (*AccessApp, error) {
+	resp, err := c.doRequest(ctx, "POST", fmt.Sprintf("/zones/%s/access/apps", zoneID), app)
+	if err != nil {
+		return nil, err
+	}
+
+	var created AccessApp
+	if err := json.Unmarshal(resp.Result, &created); err != nil {
+		return nil, fmt.Errorf("failed to parse created access app: %w", err)
+	}
+	return &created, nil
+}
+
+// DeleteAccessApp deletes an Access application
+func (c *DNSClient) DeleteAccessApp(ctx context.Context, zoneID, appID string) error {
+	_, err := c.doRequest(ctx, "DELETE", fmt.Sprintf("/zones/%s/access/apps/%s", zoneID, appID), nil)
+	return err
+}
+
+// ListAccessPolicies lists the policies of an Access application
+func (c *DNSClient) ListAccessPolicies(ctx context.Context, zoneID, appID string) ([]AccessPolicy, error) {
+	resp, err := c.doRequest(ctx, "GET", fmt.Sprintf("/zones/%s/access/apps/%s/policies", zoneID, appID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var policies []AccessPolicy
+	if err := json.Unmarshal(resp.Result, &policies); err != nil {
+		return nil, fmt.Errorf("failed to parse access policies: %w", err)
+	}
+	return policies, nil
+}
+
+// SaveAccessPolicy creates a policy, or updates it if it has an ID
+func (c *DNSClient) SaveAccessPolicy(ctx context.Context, zoneID, appID string, policy AccessPolicy) error {
+	path := fmt.Sprintf("/zones/%s/access/apps/%s/policies", zoneID, appID)
+	method := "POST"
+	if policy.ID != "" {
+		path += "/" + policy.ID
+		method = "PUT"
+	}
+	_, err := c.doRequest(ctx, method, path, policy)
+	return err
+}
+
+// EnsureAccessApp makes sure domain (a hostname with optional path) is
+// guarded by a Schooner-managed Access application whose policy allows
+// exactly the given rules
+func (c *DNSClient) EnsureAccessApp(ctx context.Context, domain string, rules []AccessRule) error {
+	zone, err := c.zoneForHostname(ctx, domain)
+	if err != nil {
+		return err
+	}
+
+	app, err := c.findManagedAccessApp(ctx, zone.ID, domain)
+	if err != nil {
+		return err
+	}
+	if app == nil {
+		slog.Info("creating Cloudflare Access application", "domain", domain)
+		app, err = c.CreateAccessApp(ctx, zone.ID, AccessApp{
+			Name:            accessAppPrefix + domain,
+			Domain:          domain,
+			Type:            "self_hosted",
+			SessionDuration: accessSessionLimit,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create access app: %w", err)
+		}
+	}
+
+	policies, err := c.ListAccessPolicies(ctx, zone.ID, app.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list access policies: %w", err)
+	}
+
+	policy := AccessPolicy{Name: accessPolicyName, Decision: "allow", Include: rules, Precedence: 1}
+	for _, existing := range policies {
+		if existing.Name == accessPolicyName {
+			policy.ID = existing.ID
+			policy.Precedence = existing.Precedence
+		}
+	}
+
+	if err := c.SaveAccessPolicy(ctx, zone.ID, app.ID, policy); err != nil {
+		return fmt.Errorf("failed to save access policy: %w", err)
+	}
+	return nil
+}
+
+// RemoveAccessApp deletes the Schooner-managed Access application for
+// domain, if there is one
+func (c *DNSClient) RemoveAccessApp(ctx context.Context, domain string) error {
+	zone, err := c.zoneForHostname(ctx, domain)
+	if err != nil {
+		return err
+	}
+
+	app, err := c.findManagedAccessApp(ctx, zone.ID, domain)
+	if err != nil || app == nil {
+		return err
+	}
+
+	slog.Info("removing Cloudflare Access application", "domain", domain)
+	return c.DeleteAccessApp(ctx, zone.ID, app.ID)
+}
+
+// findManagedAccessApp returns the Schooner-managed Access app for domain
+func (c *DNSClient) findManagedAccessApp(ctx context.Context, zoneID, domain string) (*AccessApp, error) {
+	apps, err := c.ListAccessApps(ctx, zoneID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access apps: %w", err)
+	}
+
+	for _, app := range apps {
+		if app.Domain == domain && strings.HasPrefix(app.Name, accessAppPrefix) {
+			return &app, nil
+		}
+	}
+	return nil, nil
+}
+
+// protectApps sets up Cloudflare Access for protected apps and removes it
+// from apps that are no longer protected. It returns the apps that may be
+// routed: a protected app is left out unless Access guards it, so a missing
+// API token or a failed API call never exposes it.
+func (m *Manager) protectApps(ctx context.Context, dnsClient *DNSClient, apps []*models.App, domain string) []*models.App {
+	routed := make([]*models.App, 0, len(apps))
+	for _, app := range apps {
+		if !app.Enabled || app.GetSubdomain() == "" || app.GetPublicPort() == 0 {
+			routed = append(routed, app)
+			continue
+		}
+		accessDomain := fmt.Sprintf("%s.%s%s", app.GetSubdomain(), domain, app.GetRoutePath())
+
+		if !app.Protected {
+			if dnsClient != nil {
+				if err := dnsClient.RemoveAccessApp(ctx, accessDomain); err != nil {
+					slog.Warn("failed to remove Cloudflare Access app", "app", app.Name, "error", err)
+				}
+			}
+			routed = append(routed, app)
+			continue
+		}
+
+		if err := ensureAccess(ctx, dnsClient, app, accessDomain); err != nil {
+			slog.Error("not routing protected app: Cloudflare Access setup failed", "app", app.Name, "error", err)
+			continue
+		}
+		routed = append(routed, app)
+	}
+	return routed
+}
+
+// ensureAccess guards an app's domain with its Access allow list
+func ensureAccess(ctx context.Context, dnsClient *DNSClient, app *models.App, domain string) error {
+	if dnsClient == nil {
+		return fmt.Errorf("a Cloudflare API token is required for Access")
+	}
+
+	rules, err := ParseAccessRules(app.GetAccessAllow())
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return fmt.Errorf("no allowed emails or groups configured")
+	}
+
+	return dnsClient.EnsureAccessApp(ctx, domain, rules)
+}
@@ -0,0 +1,70 @@
+package cloudflare
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"schooner/internal/config"
+	"schooner/internal/models"
+)
+
+func TestParseAccessRules(t *testing.T) {
+	rules, err := ParseAccessRules("me@example.com, @corp.example\ngroup:abc123")
+	if err != nil {
+		t.Fatalf("ParseAccessRules() error = %v", err)
+	}
+
+	want := []AccessRule{
+		{"email": {"email": "me@example.com"}},
+		{"email_domain": {"domain": "corp.example"}},
+		{"group": {"id": "abc123"}},
+	}
+	if len(rules) != len(want) {
+		t.Fatalf("got %d rules, want %d: %v", len(rules), len(want), rules)
+	}
+	for i := range want {
+		for kind, fields := range want[i] {
+			for k, v := range fields {
+				if rules[i][kind][k] != v {
+					t.Errorf("rule %d = %v, want %v", i, rules[i], want[i])
+				}
+			}
+		}
+	}
+}
+
+func TestParseAccessRules_Invalid(t *testing.T) {
+	for _, allow := range []string{"not-an-email", "group:", "@", "a@b@c", "me@"} {
+		if _, err := ParseAccessRules(allow); err == nil {
+			t.Errorf("ParseAccessRules(%q) expected error", allow)
+		}
+	}
+
+	rules, err := ParseAccessRules("  ")
+	if err != nil || len(rules) != 0 {
+		t.Errorf("ParseAccessRules(blank) = %v, %v; want no rules", rules, err)
+	}
+}
+
+func TestManager_ProtectApps_WithoutAPIToken(t *testing.T) {
+	m := NewManager(&config.Config{}, nil)
+
+	routedApp := func(name string, protected bool) *models.App {
+		return &models.App{
+			Name:        name,
+			Enabled:     true,
+			Subdomain:   sql.NullString{String: name, Valid: true},
+			PublicPort:  sql.NullInt64{Int64: 8080, Valid: true},
+			Protected:   protected,
+			AccessAllow: sql.NullString{String: "me@example.com", Valid: true},
+		}
+	}
+
+	apps := []*models.App{routedApp("public", false), routedApp("internal", true)}
+	routed := m.protectApps(context.Background(), nil, apps, "example.com")
+
+	if len(routed) != 1 || routed[0].Name != "public" {
+		t.Errorf("routed = %v, want only the unprotected app", routed)
+	}
+}
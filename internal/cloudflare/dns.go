@@ -9,6 +9,7 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 )
 
 const (
@@ -179,19 +180,31 @@ func (c *DNSClient) DeleteDNSRecord(ctx context.Context, zoneID, recordID string
 	return err
 }
 
-// EnsureTunnelCNAME creates or updates a CNAME record pointing to the tunnel
-func (c *DNSClient) EnsureTunnelCNAME(ctx context.Context, hostname, tunnelID string) error {
+// zoneForHostname finds the zone a hostname belongs to. Any path after the
+// hostname is ignored.
+func (c *DNSClient) zoneForHostname(ctx context.Context, hostname string) (*Zone, error) {
+	hostname, _, _ = strings.Cut(hostname, "/")
+
 	// Extract the zone name (e.g., "slats.dev" from "schooner.slats.dev")
 	parts := strings.Split(hostname, ".")
 	if len(parts) < 2 {
-		return fmt.Errorf("invalid hostname: %s", hostname)
+		return nil, fmt.Errorf("invalid hostname: %s", hostname)
 	}
 	zoneName := strings.Join(parts[len(parts)-2:], ".")
 
-	// Get the zone ID
 	zone, err := c.GetZoneByName(ctx, zoneName)
 	if err != nil {
-		return fmt.Errorf("failed to get zone: %w", err)
+		return nil, fmt.Errorf("failed to get zone: %w", err)
+	}
+	return zone, nil
+}
+
+// EnsureTunnelCNAME creates or updates a CNAME record pointing to the tunnel.
+// It returns the record when it was created, so the caller can track it.
+func (c *DNSClient) EnsureTunnelCNAME(ctx context.Context, hostname, tunnelID string) (*ManagedRecord, error) {
+	zone, err := c.zoneForHostname(ctx, hostname)
+	if err != nil {
+		return nil, err
 	}
 
 	tunnelTarget := fmt.Sprintf("%s.cfargotunnel.com", tunnelID)
@@ -199,7 +212,7 @@ func (c *DNSClient) EnsureTunnelCNAME(ctx context.Context, hostname, tunnelID st
 	// Check if record exists
 	existing, err := c.GetDNSRecord(ctx, zone.ID, "CNAME", hostname)
 	if err != nil {
-		return fmt.Errorf("failed to check existing record: %w", err)
+		return nil, fmt.Errorf("failed to check existing record: %w", err)
 	}
 
 	record := DNSRecord{
@@ -216,19 +229,44 @@ func (c *DNSClient) EnsureTunnelCNAME(ctx context.Context, hostname, tunnelID st
 			slog.Info("updating DNS record", "hostname", hostname, "tunnel", tunnelTarget)
 			_, err = c.UpdateDNSRecord(ctx, zone.ID, existing.ID, record)
 			if err != nil {
-				return fmt.Errorf("failed to update record: %w", err)
+				return nil, fmt.Errorf("failed to update record: %w", err)
 			}
 		} else {
 			slog.Debug("DNS record already correct", "hostname", hostname)
 		}
-	} else {
-		// Create new record
-		slog.Info("creating DNS record", "hostname", hostname, "tunnel", tunnelTarget)
-		_, err = c.CreateDNSRecord(ctx, zone.ID, record)
-		if err != nil {
-			return fmt.Errorf("failed to create record: %w", err)
-		}
+		return nil, nil
+	}
+
+	// Create new record
+	slog.Info("creating DNS record", "hostname", hostname, "tunnel", tunnelTarget)
+	created, err := c.CreateDNSRecord(ctx, zone.ID, record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create record: %w", err)
+	}
+
+	return &ManagedRecord{
+		Hostname:  hostname,
+		ZoneID:    zone.ID,
+		RecordID:  created.ID,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// DeleteTunnelCNAME deletes a record Schooner created, unless it has since
+// been removed or repointed away from a tunnel outside of Schooner
+func (c *DNSClient) DeleteTunnelCNAME(ctx context.Context, record *ManagedRecord) error {
+	existing, err := c.GetDNSRecord(ctx, record.ZoneID, "CNAME", record.Hostname)
+	if err != nil {
+		return fmt.Errorf("failed to check existing record: %w", err)
+	}
+	if existing == nil || existing.ID != record.RecordID || !strings.HasSuffix(existing.Content, ".cfargotunnel.com") {
+		slog.Debug("DNS record no longer managed by schooner", "hostname", record.Hostname)
+		return nil
 	}
 
+	slog.Info("deleting DNS record", "hostname", record.Hostname)
+	if err := c.DeleteDNSRecord(ctx, record.ZoneID, record.RecordID); err != nil {
+		return fmt.Errorf("failed to delete record: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,86 @@
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+// Reconcile starts the cloudflared container of each tunnel that isn't
+// running and brings the routes, DNS records and Access apps of the running
+// ones in line with the enabled apps. cloudflared is only restarted when its
+// routes changed. Tunnels stopped from the settings stay down. It returns
+// what it fixed.
+func (m *Manager) Reconcile(ctx context.Context) ([]string, error) {
+	tunnels := m.tunnels(ctx)
+	if len(tunnels) == 0 || m.appQueries == nil {
+		return nil, nil
+	}
+	apps, err := m.appQueries.ListEnabled(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list apps: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopped {
+		return nil, nil
+	}
+
+	var fixed []string
+	var errs []error
+	for _, t := range tunnels {
+		status, _ := m.dockerClient.GetContainerStatus(ctx, t.containerName())
+		if status == nil || status.State != "running" {
+			if err := m.startTunnel(ctx, t, apps); err != nil {
+				errs = append(errs, fmt.Errorf("tunnel %s: %w", t.label(), err))
+				continue
+			}
+			fixed = append(fixed, fmt.Sprintf("started tunnel %s", t.label()))
+			continue
+		}
+
+		changed, err := m.reconcileRoutes(ctx, t, apps, status)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tunnel %s: %w", t.label(), err))
+			continue
+		}
+		if changed {
+			fixed = append(fixed, fmt.Sprintf("updated routes of tunnel %s", t.label()))
+		}
+	}
+	m.pruneOrphanedRecords(ctx)
+	return fixed, errors.Join(errs...)
+}
+
+// reconcileRoutes rewrites a running tunnel's config and DNS records, and
+// reports whether its routes had drifted from the apps
+func (m *Manager) reconcileRoutes(ctx context.Context, t Tunnel, apps []*models.App, status *docker.ContainerStatus) (bool, error) {
+	payload, err := decodeToken(t.Token)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode token: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Join(m.configDir, t.Name), 0755); err != nil {
+		return false, fmt.Errorf("failed to create config dir: %w", err)
+	}
+
+	configPath := filepath.Join(m.configDir, t.Name, "config.yml")
+	before, _ := os.ReadFile(configPath)
+	_, remote, err := m.applyRoutes(ctx, t, payload, apps)
+	if err != nil {
+		return false, err
+	}
+	after, _ := os.ReadFile(configPath)
+
+	runningRemote := status.Labels[tunnelConfigLabel] == tunnelConfigRemote
+	if bytes.Equal(before, after) && runningRemote == remote {
+		return false, nil
+	}
+	return true, m.applyToRunning(ctx, t, payload, remote, status)
+}
@@ -0,0 +1,191 @@
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"schooner/internal/models"
+)
+
+// ManagedRecord is a DNS record Schooner created, tracked so it can be
+// deleted once nothing routes through its hostname
+type ManagedRecord struct {
+	Hostname  string    `db:"hostname"`
+	ZoneID    string    `db:"zone_id"`
+	RecordID  string    `db:"record_id"`
+	Tunnel    string    `db:"tunnel"` // Name of the tunnel it points at, empty for the default
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// RecordStore stores the DNS records Schooner created
+type RecordStore interface {
+	SaveRecord(ctx context.Context, record *ManagedRecord) error
+	ListRecords(ctx context.Context) ([]*ManagedRecord, error)
+	DeleteRecord(ctx context.Context, hostname string) error
+}
+
+// SetRecordStore enables tracking and cleanup of created DNS records
+func (m *Manager) SetRecordStore(rs RecordStore) {
+	m.recordStore = rs
+}
+
+// ensureRecord points a hostname at a tunnel, tracking the record if it was
+// created
+func (m *Manager) ensureRecord(ctx context.Context, dnsClient *DNSClient, t Tunnel, hostname, tunnelID string) error {
+	created, err := dnsClient.EnsureTunnelCNAME(ctx, hostname, tunnelID)
+	if err != nil || created == nil || m.recordStore == nil {
+		return err
+	}
+
+	created.Tunnel = t.Name
+	if err := m.recordStore.SaveRecord(ctx, created); err != nil {
+		return fmt.Errorf("failed to track DNS record: %w", err)
+	}
+	return nil
+}
+
+// pruneRecords deletes the tracked records keep rejects
+func (m *Manager) pruneRecords(ctx context.Context, dnsClient *DNSClient, keep func(*ManagedRecord) bool) {
+	if m.recordStore == nil || dnsClient == nil {
+		return
+	}
+
+	records, err := m.recordStore.ListRecords(ctx)
+	if err != nil {
+		slog.Warn("failed to list tracked DNS records", "error", err)
+		return
+	}
+
+	for _, record := range records {
+		if keep(record) {
+			continue
+		}
+		if err := m.removeRecord(ctx, dnsClient, record); err != nil {
+			slog.Warn("failed to remove DNS record", "hostname", record.Hostname, "error", err)
+		}
+	}
+}
+
+// removeRecord deletes a tracked record from Cloudflare and stops tracking it
+func (m *Manager) removeRecord(ctx context.Context, dnsClient *DNSClient, record *ManagedRecord) error {
+	if err := dnsClient.DeleteTunnelCNAME(ctx, record); err != nil {
+		return err
+	}
+	return m.recordStore.DeleteRecord(ctx, record.Hostname)
+}
+
+// pruneTunnelRecords deletes a tunnel's tracked records for hostnames it no
+// longer routes
+func (m *Manager) pruneTunnelRecords(ctx context.Context, dnsClient *DNSClient, t Tunnel, hostnames []string) {
+	routed := make(map[string]bool, len(hostnames))
+	for _, hostname := range hostnames {
+		routed[hostname] = true
+	}
+	m.pruneRecords(ctx, dnsClient, func(record *ManagedRecord) bool {
+		return record.Tunnel != t.Name || routed[record.Hostname]
+	})
+}
+
+// pruneOrphanedRecords deletes tracked records of named tunnels that were
+// removed, using the default API token
+func (m *Manager) pruneOrphanedRecords(ctx context.Context) {
+	_, _, _, apiToken := m.getTunnelConfig(ctx)
+	named, err := m.NamedTunnels(ctx)
+	if apiToken == "" || err != nil {
+		return
+	}
+
+	configured := map[string]bool{"": true}
+	for _, t := range named {
+		configured[t.Name] = true
+	}
+	m.pruneRecords(ctx, NewDNSClient(apiToken), func(record *ManagedRecord) bool {
+		return configured[record.Tunnel]
+	})
+}
+
+// appHostname returns an app's hostname and the DNS client of the tunnel it
+// routes through. The client is nil when the app has no hostname, its
+// tunnel has no API token, or another enabled app still routes through the
+// hostname.
+func (m *Manager) appHostname(ctx context.Context, app *models.App) (*DNSClient, Tunnel, string, error) {
+	t, ok := m.tunnelFor(ctx, app)
+	subdomain := app.GetSubdomain()
+	if !ok || subdomain == "" {
+		return nil, t, "", nil
+	}
+	dnsClient := t.dnsClient()
+	if dnsClient == nil {
+		return nil, t, "", nil
+	}
+	hostname := fmt.Sprintf("%s.%s", subdomain, t.Domain)
+
+	if m.appQueries != nil {
+		apps, err := m.appQueries.ListEnabled(ctx)
+		if err != nil {
+			return nil, t, "", fmt.Errorf("failed to list apps: %w", err)
+		}
+		for _, other := range appsForTunnel(apps, t.Name) {
+			if other.ID != app.ID && other.GetSubdomain() == subdomain {
+				return nil, t, "", nil
+			}
+		}
+	}
+	return dnsClient, t, hostname, nil
+}
+
+// removeAppRecords deletes the tracked record for an app's hostname, unless
+// another enabled app still routes through it
+func (m *Manager) removeAppRecords(ctx context.Context, app *models.App) error {
+	if m.recordStore == nil {
+		return nil
+	}
+	dnsClient, t, hostname, err := m.appHostname(ctx, app)
+	if err != nil || dnsClient == nil {
+		return err
+	}
+
+	records, err := m.recordStore.ListRecords(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list tracked DNS records: %w", err)
+	}
+	for _, record := range records {
+		if record.Hostname == hostname && record.Tunnel == t.Name {
+			return m.removeRecord(ctx, dnsClient, record)
+		}
+	}
+	return nil
+}
+
+// DeleteAppRecord deletes the CNAME pointing a deleted app's hostname at a
+// tunnel, including one created outside of Schooner, unless another enabled
+// app still routes through the hostname. Records pointing anywhere else are
+// left alone.
+func (m *Manager) DeleteAppRecord(ctx context.Context, app *models.App) error {
+	dnsClient, _, hostname, err := m.appHostname(ctx, app)
+	if err != nil || dnsClient == nil {
+		return err
+	}
+
+	zone, err := dnsClient.zoneForHostname(ctx, hostname)
+	if err != nil {
+		return err
+	}
+	existing, err := dnsClient.GetDNSRecord(ctx, zone.ID, "CNAME", hostname)
+	if err != nil {
+		return fmt.Errorf("failed to check existing record: %w", err)
+	}
+	if existing != nil {
+		record := &ManagedRecord{Hostname: hostname, ZoneID: zone.ID, RecordID: existing.ID}
+		if err := dnsClient.DeleteTunnelCNAME(ctx, record); err != nil {
+			return err
+		}
+	}
+
+	if m.recordStore != nil {
+		return m.recordStore.DeleteRecord(ctx, hostname)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"schooner/internal/config"
+)
+
+type fakeRecordStore map[string]*ManagedRecord
+
+func (f fakeRecordStore) SaveRecord(ctx context.Context, record *ManagedRecord) error {
+	f[record.Hostname] = record
+	return nil
+}
+
+func (f fakeRecordStore) ListRecords(ctx context.Context) ([]*ManagedRecord, error) {
+	var records []*ManagedRecord
+	for _, record := range f {
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (f fakeRecordStore) DeleteRecord(ctx context.Context, hostname string) error {
+	delete(f, hostname)
+	return nil
+}
+
+// fakeZone serves the DNS record endpoints for one zone and records deletes
+type fakeZone struct {
+	records map[string]DNSRecord // by hostname
+	deleted []string
+}
+
+func (z *fakeZone) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var result interface{}
+	switch {
+	case r.Method == http.MethodGet:
+		var found []DNSRecord
+		if record, ok := z.records[r.URL.Query().Get("name")]; ok {
+			found = append(found, record)
+		}
+		result = found
+	case r.Method == http.MethodDelete:
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		z.deleted = append(z.deleted, id)
+		result = map[string]string{"id": id}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "result": result})
+}
+
+// rewriteTransport sends API requests to a test server
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestManager_PruneTunnelRecords(t *testing.T) {
+	zone := &fakeZone{records: map[string]DNSRecord{
+		"blog.example.com": {ID: "rec-blog", Name: "blog.example.com", Content: "tid.cfargotunnel.com"},
+		"old.example.com":  {ID: "rec-old", Name: "old.example.com", Content: "tid.cfargotunnel.com"},
+		"moved.example.com": {
+			ID: "rec-moved", Name: "moved.example.com", Content: "somewhere-else.example.net",
+		},
+	}}
+	server := httptest.NewServer(zone)
+	defer server.Close()
+	target, _ := url.Parse(server.URL)
+	dnsClient := &DNSClient{apiToken: "token", httpClient: &http.Client{Transport: rewriteTransport{target}}}
+
+	store := fakeRecordStore{
+		"blog.example.com":  {Hostname: "blog.example.com", ZoneID: "zone", RecordID: "rec-blog"},
+		"old.example.com":   {Hostname: "old.example.com", ZoneID: "zone", RecordID: "rec-old"},
+		"moved.example.com": {Hostname: "moved.example.com", ZoneID: "zone", RecordID: "rec-moved"},
+		"wiki.lan.com":      {Hostname: "wiki.lan.com", ZoneID: "zone", RecordID: "rec-wiki", Tunnel: "internal"},
+	}
+	m := NewManager(&config.Config{}, nil)
+	m.SetRecordStore(store)
+
+	m.pruneTunnelRecords(context.Background(), dnsClient, Tunnel{Domain: "example.com"}, []string{"blog.example.com"})
+
+	if len(zone.deleted) != 1 || zone.deleted[0] != "rec-old" {
+		t.Errorf("deleted records = %v, want only rec-old", zone.deleted)
+	}
+	for hostname, want := range map[string]bool{
+		"blog.example.com":  true,  // still routed
+		"old.example.com":   false, // no longer routed
+		"moved.example.com": false, // repointed outside schooner: untracked, not deleted
+		"wiki.lan.com":      true,  // belongs to another tunnel
+	} {
+		if _, ok := store[hostname]; ok != want {
+			t.Errorf("%s tracked = %v, want %v", hostname, ok, want)
+		}
+	}
+}
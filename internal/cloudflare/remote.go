@@ -0,0 +1,106 @@
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+
+	"schooner/internal/docker"
+)
+
+// tunnelConfigLabel records on the cloudflared container where it reads its
+// ingress rules from
+const tunnelConfigLabel = "schooner.tunnel-config"
+
+const (
+	// tunnelConfigRemote containers run with the tunnel token and receive
+	// ingress rules from Cloudflare, applying changes without a restart
+	tunnelConfigRemote = "remote"
+	// tunnelConfigLocal containers read config.yml, which they only load on
+	// start
+	tunnelConfigLocal = "local"
+)
+
+// remoteTunnelConfig is the body of a tunnel configuration update
+type remoteTunnelConfig struct {
+	Config struct {
+		Ingress []IngressRule `json:"ingress"`
+	} `json:"config"`
+}
+
+// PutTunnelConfiguration replaces the ingress rules of a remotely-managed
+// tunnel. Running cloudflared connectors apply them without restarting.
+func (c *DNSClient) PutTunnelConfiguration(ctx context.Context, accountID, tunnelID string, rules []IngressRule) error {
+	var body remoteTunnelConfig
+	body.Config.Ingress = rules
+
+	path := fmt.Sprintf("/accounts/%s/cfd_tunnel/%s/configurations", accountID, tunnelID)
+	_, err := c.doRequest(ctx, "PUT", path, body)
+	return err
+}
+
+// pushRemoteConfig pushes a tunnel's ingress rules to Cloudflare, reporting
+// whether the tunnel can run remotely managed. Without an API token, or when
+// the token lacks Cloudflare Tunnel Edit, the local config file is used.
+func (m *Manager) pushRemoteConfig(ctx context.Context, dnsClient *DNSClient, t Tunnel, payload *tunnelTokenPayload, rules []IngressRule) bool {
+	if dnsClient == nil || payload.AccountTag == "" {
+		return false
+	}
+
+	if err := dnsClient.PutTunnelConfiguration(ctx, payload.AccountTag, payload.TunnelID, rules); err != nil {
+		slog.Warn("failed to push remote tunnel config, using local config", "tunnel", t.label(), "error", err)
+		return false
+	}
+	return true
+}
+
+// tunnelContainerConfig returns the cloudflared container for a tunnel,
+// running remotely managed or from its local config file
+func tunnelContainerConfig(t Tunnel, payload *tunnelTokenPayload, remote bool) docker.ContainerConfig {
+	cfg := docker.ContainerConfig{
+		Name:  t.containerName(),
+		Image: cloudflaredImage,
+		Labels: map[string]string{
+			"schooner.managed": "true",
+			"schooner.service": "cloudflared",
+			tunnelConfigLabel:  tunnelConfigLocal,
+		},
+		RestartPolicy: "unless-stopped",
+		Volumes: map[string]string{
+			cloudflaredVolume: "/data",
+		},
+	}
+
+	if remote {
+		// The token is passed in the environment to keep it out of the
+		// container's command line
+		cfg.Cmd = []string{"tunnel", "--no-autoupdate", "run"}
+		cfg.Env = []string{"TUNNEL_TOKEN=" + t.Token}
+		cfg.Labels[tunnelConfigLabel] = tunnelConfigRemote
+		return cfg
+	}
+
+	cfg.Cmd = []string{
+		"tunnel",
+		"--no-autoupdate",
+		"--config", path.Join("/data/cloudflared", t.Name, "config.yml"),
+		"run", payload.TunnelID,
+	}
+	return cfg
+}
+
+// runTunnelContainer replaces a tunnel's cloudflared container
+func (m *Manager) runTunnelContainer(ctx context.Context, t Tunnel, payload *tunnelTokenPayload, remote bool) error {
+	// Stop existing container if any
+	_ = m.dockerClient.StopContainer(ctx, t.containerName(), 10)
+	_ = m.dockerClient.RemoveContainer(ctx, t.containerName())
+
+	containerID, err := m.dockerClient.CreateAndStartContainer(ctx, tunnelContainerConfig(t, payload, remote))
+	if err != nil {
+		return fmt.Errorf("failed to start cloudflared: %w", err)
+	}
+
+	slog.Info("cloudflared started", "tunnel", t.label(), "container_id", containerID[:12], "remote_config", remote)
+	return nil
+}
@@ -0,0 +1,66 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDNSClient_PutTunnelConfiguration(t *testing.T) {
+	var gotPath string
+	var gotBody remoteTunnelConfig
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.Method + " " + r.URL.Path
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.Write([]byte(`{"success":true,"result":{}}`))
+	}))
+	defer server.Close()
+	target, _ := url.Parse(server.URL)
+	client := &DNSClient{apiToken: "token", httpClient: &http.Client{Transport: rewriteTransport{target}}}
+
+	rules := []IngressRule{
+		{Hostname: "blog.example.com", Service: "http://host.docker.internal:8080"},
+		{Service: "http_status:404"},
+	}
+	if err := client.PutTunnelConfiguration(context.Background(), "acct", "tid", rules); err != nil {
+		t.Fatalf("PutTunnelConfiguration() error = %v", err)
+	}
+
+	if want := "PUT /client/v4/accounts/acct/cfd_tunnel/tid/configurations"; gotPath != want {
+		t.Errorf("request = %q, want %q", gotPath, want)
+	}
+	if len(gotBody.Config.Ingress) != 2 || gotBody.Config.Ingress[0].Hostname != "blog.example.com" {
+		t.Errorf("ingress = %+v, want the pushed rules", gotBody.Config.Ingress)
+	}
+}
+
+func TestTunnelContainerConfig(t *testing.T) {
+	tunnel := Tunnel{Name: "internal", Token: "secret-token"}
+	payload := &tunnelTokenPayload{TunnelID: "tid"}
+
+	remote := tunnelContainerConfig(tunnel, payload, true)
+	if remote.Labels[tunnelConfigLabel] != tunnelConfigRemote {
+		t.Errorf("remote label = %q", remote.Labels[tunnelConfigLabel])
+	}
+	if strings.Contains(strings.Join(remote.Cmd, " "), "secret-token") {
+		t.Errorf("remote command %v exposes the tunnel token", remote.Cmd)
+	}
+	if len(remote.Env) != 1 || remote.Env[0] != "TUNNEL_TOKEN=secret-token" {
+		t.Errorf("remote env = %v, want the tunnel token", remote.Env)
+	}
+
+	local := tunnelContainerConfig(tunnel, payload, false)
+	if local.Labels[tunnelConfigLabel] != tunnelConfigLocal {
+		t.Errorf("local label = %q", local.Labels[tunnelConfigLabel])
+	}
+	if got := strings.Join(local.Cmd, " "); got != "tunnel --no-autoupdate --config /data/cloudflared/internal/config.yml run tid" {
+		t.Errorf("local command = %q", got)
+	}
+	if local.Name != "schooner-cloudflared-internal" {
+		t.Errorf("container name = %q", local.Name)
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"sync"
 	"time"
 
@@ -35,8 +38,9 @@ type tunnelTokenPayload struct {
 
 // IngressRule represents a Cloudflare tunnel ingress rule
 type IngressRule struct {
-	Hostname string `yaml:"hostname,omitempty"`
-	Service  string `yaml:"service"`
+	Hostname string `yaml:"hostname,omitempty" json:"hostname,omitempty"`
+	Path     string `yaml:"path,omitempty" json:"path,omitempty"` // Regex matched against the request path
+	Service  string `yaml:"service" json:"service"`
 }
 
 // TunnelConfig represents the cloudflared config.yml structure
@@ -57,9 +61,10 @@ type Manager struct {
 	dockerClient    *docker.Client
 	settingsQueries SettingsGetter
 	appQueries      AppGetter
+	recordStore     RecordStore
 	mu              sync.Mutex
 	configDir       string
-	dnsClient       *DNSClient
+	stopped         bool // Stopped on purpose, so Reconcile leaves the tunnels down
 }
 
 // AppGetter interface for getting apps from the database
@@ -157,44 +162,55 @@ func (m *Manager) getTunnelConfig(ctx context.Context) (token, tunnelID, domain,
 	return
 }
 
-// IsConfigured returns true if Cloudflare tunnel is configured
+// IsConfigured returns true if at least one Cloudflare tunnel is configured
 func (m *Manager) IsConfigured() bool {
-	token, _, domain, _ := m.getTunnelConfig(context.Background())
-	return token != "" && domain != ""
+	return len(m.tunnels(context.Background())) > 0
 }
 
-// Start starts the cloudflared container
+// Start starts cloudflared for every configured tunnel
 func (m *Manager) Start(ctx context.Context) error {
-	token, _, domain, apiToken := m.getTunnelConfig(ctx)
-
-	if token == "" || domain == "" {
-		slog.Info("Cloudflare tunnel not configured, skipping", "has_token", token != "", "has_domain", domain != "")
+	tunnels := m.tunnels(ctx)
+	if len(tunnels) == 0 {
+		slog.Info("Cloudflare tunnel not configured, skipping")
 		return fmt.Errorf("tunnel not configured: token and domain are required")
 	}
 
-	// Decode token to get tunnel credentials
-	payload, err := decodeToken(token)
-	if err != nil {
-		return fmt.Errorf("failed to decode tunnel token: %w", err)
-	}
-
-	// Initialize DNS client if we have an API token
-	if apiToken != "" {
-		m.dnsClient = NewDNSClient(apiToken)
+	// Load apps to create the initial config with routes
+	var apps []*models.App
+	if m.appQueries != nil {
+		apps, _ = m.appQueries.ListEnabled(ctx)
 	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.stopped = false
+
+	var errs []error
+	for _, t := range tunnels {
+		if err := m.startTunnel(ctx, t, apps); err != nil {
+			errs = append(errs, fmt.Errorf("tunnel %s: %w", t.label(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// startTunnel writes a tunnel's config and starts its cloudflared container
+func (m *Manager) startTunnel(ctx context.Context, t Tunnel, apps []*models.App) error {
+	// Decode token to get tunnel credentials
+	payload, err := decodeToken(t.Token)
+	if err != nil {
+		return fmt.Errorf("failed to decode tunnel token: %w", err)
+	}
 
 	// Check if already running
-	status, _ := m.dockerClient.GetContainerStatus(ctx, cloudflaredContainer)
+	status, _ := m.dockerClient.GetContainerStatus(ctx, t.containerName())
 	if status != nil && status.State == "running" {
-		slog.Info("cloudflared already running")
+		slog.Info("cloudflared already running", "tunnel", t.label())
 		return nil
 	}
 
 	// Ensure config directory exists
-	if err := os.MkdirAll(m.configDir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Join(m.configDir, t.Name), 0755); err != nil {
 		return fmt.Errorf("failed to create config dir: %w", err)
 	}
 
@@ -203,64 +219,52 @@ func (m *Manager) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to write credentials: %w", err)
 	}
 
-	// Load apps and create initial config with routes
-	var apps []*models.App
-	if m.appQueries != nil {
-		apps, _ = m.appQueries.ListEnabled(ctx)
+	routeCount, remote, err := m.applyRoutes(ctx, t, payload, apps)
+	if err != nil {
+		return err
 	}
-	if err := m.writeConfigForApps(apps, payload.TunnelID, domain); err != nil {
-		return fmt.Errorf("failed to write config: %w", err)
+
+	slog.Info("starting cloudflared tunnel", "tunnel", t.label(), "domain", t.Domain, "tunnel_id", payload.TunnelID, "app_count", routeCount, "remote_config", remote)
+	return m.runTunnelContainer(ctx, t, payload, remote)
+}
+
+// applyRoutes sets up Access, writes the ingress config and configures DNS
+// for the apps routed through a tunnel. It returns how many apps are routed
+// and whether the ingress rules were also pushed as remote config.
+func (m *Manager) applyRoutes(ctx context.Context, t Tunnel, payload *tunnelTokenPayload, apps []*models.App) (int, bool, error) {
+	dnsClient := t.dnsClient()
+
+	apps = m.protectApps(ctx, dnsClient, appsForTunnel(apps, t.Name), t.Domain)
+	rules := m.ingressRules(ctx, t, apps)
+
+	// The local config is always written so cloudflared can fall back to it
+	if err := m.writeTunnelConfig(t, rules, payload.TunnelID); err != nil {
+		return 0, false, fmt.Errorf("failed to write config: %w", err)
 	}
+	remote := m.pushRemoteConfig(ctx, dnsClient, t, payload, rules)
 
 	// Configure DNS records if we have an API token
-	if m.dnsClient != nil {
-		m.configureDNSRecords(ctx, apps, payload.TunnelID, domain)
-	}
-
-	// Stop existing container if any
-	_ = m.dockerClient.StopContainer(ctx, cloudflaredContainer, 10)
-	_ = m.dockerClient.RemoveContainer(ctx, cloudflaredContainer)
-
-	slog.Info("starting cloudflared tunnel", "domain", domain, "tunnel_id", payload.TunnelID, "app_count", len(apps))
-
-	// Start cloudflared container with config mode (not token mode)
-	// This allows us to control ingress via the config file
-	containerConfig := docker.ContainerConfig{
-		Name:  cloudflaredContainer,
-		Image: cloudflaredImage,
-		Cmd: []string{
-			"tunnel",
-			"--no-autoupdate",
-			"--config", "/data/cloudflared/config.yml",
-			"run", payload.TunnelID,
-		},
-		Labels: map[string]string{
-			"schooner.managed": "true",
-			"schooner.service": "cloudflared",
-		},
-		RestartPolicy: "unless-stopped",
-		Volumes: map[string]string{
-			cloudflaredVolume: "/data",
-		},
-	}
-
-	containerID, err := m.dockerClient.CreateAndStartContainer(ctx, containerConfig)
-	if err != nil {
-		return fmt.Errorf("failed to start cloudflared: %w", err)
+	if dnsClient != nil {
+		m.configureDNSRecords(ctx, dnsClient, t, apps, payload.TunnelID)
 	}
 
-	slog.Info("cloudflared started", "container_id", containerID[:12])
-	return nil
+	routeCount := 0
+	for _, app := range apps {
+		if app.Enabled && app.GetSubdomain() != "" && app.GetPublicPort() != 0 {
+			routeCount++
+		}
+	}
+	return routeCount, remote, nil
 }
 
-// configureDNSRecords sets up DNS CNAME records for tunnel hostnames
-func (m *Manager) configureDNSRecords(ctx context.Context, apps []*models.App, tunnelID, domain string) {
-	// Configure schooner's own hostname
-	if m.cfg.Server.BaseURL != "" {
-		if parsed, err := url.Parse(m.cfg.Server.BaseURL); err == nil && parsed.Host != "" {
-			if err := m.dnsClient.EnsureTunnelCNAME(ctx, parsed.Host, tunnelID); err != nil {
-				slog.Warn("failed to configure DNS for schooner", "hostname", parsed.Host, "error", err)
-			}
+// configureDNSRecords sets up DNS CNAME records for tunnel hostnames and
+// removes the ones Schooner created for hostnames no longer routed
+func (m *Manager) configureDNSRecords(ctx context.Context, dnsClient *DNSClient, t Tunnel, apps []*models.App, tunnelID string) {
+	// Configure schooner's own hostnames
+	hostnames := m.schoonerHostnames(ctx, t)
+	for _, hostname := range hostnames {
+		if err := m.ensureRecord(ctx, dnsClient, t, hostname, tunnelID); err != nil {
+			slog.Warn("failed to configure DNS for schooner", "hostname", hostname, "error", err)
 		}
 	}
 
@@ -273,35 +277,62 @@ func (m *Manager) configureDNSRecords(ctx context.Context, apps []*models.App, t
 		if subdomain == "" {
 			continue
 		}
-		hostname := fmt.Sprintf("%s.%s", subdomain, domain)
-		if err := m.dnsClient.EnsureTunnelCNAME(ctx, hostname, tunnelID); err != nil {
+		hostname := fmt.Sprintf("%s.%s", subdomain, t.Domain)
+		hostnames = append(hostnames, hostname)
+		if err := m.ensureRecord(ctx, dnsClient, t, hostname, tunnelID); err != nil {
 			slog.Warn("failed to configure DNS for app", "app", app.Name, "hostname", hostname, "error", err)
 		}
 	}
+
+	m.pruneTunnelRecords(ctx, dnsClient, t, hostnames)
 }
 
-// writeConfigForApps writes the tunnel config with routes for the given apps
-func (m *Manager) writeConfigForApps(apps []*models.App, tunnelID, domain string) error {
-	var rules []IngressRule
+// schoonerHostnames returns the hostnames routed to schooner itself: the
+// dashboard host from base_url and the public status page subdomain, if set.
+// They are only served by the default tunnel.
+func (m *Manager) schoonerHostnames(ctx context.Context, t Tunnel) []string {
+	if t.Name != "" {
+		return nil
+	}
+
+	var hostnames []string
 
-	// Add schooner's own route first (from base_url config)
-	if m.cfg.Server.BaseURL != "" {
-		if parsed, err := url.Parse(m.cfg.Server.BaseURL); err == nil && parsed.Host != "" {
-			// Use service_port if set, otherwise fall back to server port
-			port := m.cfg.Cloudflare.ServicePort
-			if port == 0 {
-				port = m.cfg.Server.Port
-			}
-			schoonerService := fmt.Sprintf("http://host.docker.internal:%d", port)
-			rules = append(rules, IngressRule{
-				Hostname: parsed.Host,
-				Service:  schoonerService,
-			})
-			slog.Debug("added schooner tunnel route", "hostname", parsed.Host, "service", schoonerService)
+	if m.cfg.BaseURL() != "" {
+		if parsed, err := url.Parse(m.cfg.BaseURL()); err == nil && parsed.Host != "" {
+			hostnames = append(hostnames, parsed.Host)
 		}
 	}
 
+	if m.settingsQueries != nil && t.Domain != "" {
+		if sub, err := m.settingsQueries.Get(ctx, "status_page_subdomain"); err == nil && sub != "" {
+			hostnames = append(hostnames, fmt.Sprintf("%s.%s", sub, t.Domain))
+		}
+	}
+
+	return hostnames
+}
+
+// ingressRules returns a tunnel's ingress rules for the given apps
+func (m *Manager) ingressRules(ctx context.Context, t Tunnel, apps []*models.App) []IngressRule {
+	var rules []IngressRule
+
+	// Add schooner's own routes first (base_url host and status page)
+	// Use service_port if set, otherwise fall back to server port
+	port := m.cfg.Cloudflare.ServicePort
+	if port == 0 {
+		port = m.cfg.Server.Port
+	}
+	schoonerService := fmt.Sprintf("http://host.docker.internal:%d", port)
+	for _, hostname := range m.schoonerHostnames(ctx, t) {
+		rules = append(rules, IngressRule{
+			Hostname: hostname,
+			Service:  schoonerService,
+		})
+		slog.Debug("added schooner tunnel route", "hostname", hostname, "service", schoonerService)
+	}
+
 	// Add app routes
+	var appRules []IngressRule
 	for _, app := range apps {
 		if !app.Enabled {
 			continue
@@ -314,94 +345,127 @@ func (m *Manager) writeConfigForApps(apps []*models.App, tunnelID, domain string
 			continue
 		}
 
-		hostname := fmt.Sprintf("%s.%s", subdomain, domain)
+		hostname := fmt.Sprintf("%s.%s", subdomain, t.Domain)
 		service := fmt.Sprintf("http://host.docker.internal:%d", port)
 
-		rules = append(rules, IngressRule{
+		appRules = append(appRules, IngressRule{
 			Hostname: hostname,
+			Path:     pathPattern(app.GetRoutePath()),
 			Service:  service,
 		})
 
-		slog.Debug("added tunnel route", "hostname", hostname, "service", service)
+		slog.Debug("added tunnel route", "tunnel", t.label(), "hostname", hostname, "path", app.GetRoutePath(), "service", service)
 	}
+	rules = append(rules, sortIngressRules(appRules)...)
 
 	// Always add catch-all 404 at the end
 	rules = append(rules, IngressRule{
 		Service: "http_status:404",
 	})
 
-	return m.writeConfigWithTunnelID(rules, tunnelID)
+	return rules
 }
 
-// Stop stops the cloudflared container
+// Stop stops the cloudflared containers of all configured tunnels
 func (m *Manager) Stop(ctx context.Context) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.stopped = true
 
-	if err := m.dockerClient.StopContainer(ctx, cloudflaredContainer, 30); err != nil {
-		return fmt.Errorf("failed to stop cloudflared: %w", err)
+	tunnels := m.tunnels(ctx)
+	if len(tunnels) == 0 {
+		tunnels = []Tunnel{{}}
+	}
+
+	var errs []error
+	for _, t := range tunnels {
+		if err := m.dockerClient.StopContainer(ctx, t.containerName(), 30); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop cloudflared for tunnel %s: %w", t.label(), err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
 	}
 
 	slog.Info("cloudflared stopped")
 	return nil
 }
 
-// UpdateRoutes updates the tunnel ingress rules based on apps and restarts if needed
+// StopTunnel stops and removes the cloudflared container of a named tunnel,
+// for when the tunnel is deleted
+func (m *Manager) StopTunnel(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.dockerClient.StopAndRemove(ctx, Tunnel{Name: name}.containerName())
+}
+
+// UpdateRoutes updates every tunnel's ingress rules based on apps and
+// restarts running tunnels to apply them
 func (m *Manager) UpdateRoutes(ctx context.Context, apps []*models.App) error {
-	if !m.IsConfigured() {
+	tunnels := m.tunnels(ctx)
+	if len(tunnels) == 0 {
 		return nil
 	}
 
-	token, _, domain, apiToken := m.getTunnelConfig(ctx)
-	if domain == "" {
-		return fmt.Errorf("domain not configured")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var errs []error
+	for _, t := range tunnels {
+		if err := m.updateTunnelRoutes(ctx, t, apps); err != nil {
+			errs = append(errs, fmt.Errorf("tunnel %s: %w", t.label(), err))
+		}
 	}
+	m.pruneOrphanedRecords(ctx)
+	return errors.Join(errs...)
+}
 
+// updateTunnelRoutes rewrites one tunnel's config and restarts it if running
+func (m *Manager) updateTunnelRoutes(ctx context.Context, t Tunnel, apps []*models.App) error {
 	// Decode token for tunnel ID
-	payload, err := decodeToken(token)
+	payload, err := decodeToken(t.Token)
 	if err != nil {
 		return fmt.Errorf("failed to decode token: %w", err)
 	}
 
-	// Initialize DNS client if we have an API token
-	if apiToken != "" && m.dnsClient == nil {
-		m.dnsClient = NewDNSClient(apiToken)
+	if err := os.MkdirAll(filepath.Join(m.configDir, t.Name), 0755); err != nil {
+		return fmt.Errorf("failed to create config dir: %w", err)
 	}
 
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	// Write new config
-	if err := m.writeConfigForApps(apps, payload.TunnelID, domain); err != nil {
-		return fmt.Errorf("failed to write config: %w", err)
+	routeCount, remote, err := m.applyRoutes(ctx, t, payload, apps)
+	if err != nil {
+		return err
 	}
 
-	// Configure DNS records if we have an API token
-	if m.dnsClient != nil {
-		m.configureDNSRecords(ctx, apps, payload.TunnelID, domain)
-	}
+	slog.Info("tunnel routes updated", "tunnel", t.label(), "count", routeCount, "remote_config", remote)
 
-	// Count valid routes
-	routeCount := 0
-	for _, app := range apps {
-		if app.Enabled && app.GetSubdomain() != "" && app.GetPublicPort() != 0 {
-			routeCount++
-		}
+	status, _ := m.dockerClient.GetContainerStatus(ctx, t.containerName())
+	if status == nil || status.State != "running" {
+		return nil
 	}
+	return m.applyToRunning(ctx, t, payload, remote, status)
+}
 
-	slog.Info("tunnel routes updated", "count", routeCount)
-
-	// Restart tunnel to pick up new config
-	// cloudflared doesn't support hot reload, so we need to restart
-	status, _ := m.dockerClient.GetContainerStatus(ctx, cloudflaredContainer)
-	if status != nil && status.State == "running" {
-		slog.Info("restarting cloudflared to apply new routes")
-		if err := m.dockerClient.RestartContainer(ctx, cloudflaredContainer, 10*time.Second); err != nil {
+// applyToRunning makes a running cloudflared use a tunnel's new config,
+// restarting or recreating it when it can't pick the change up itself
+func (m *Manager) applyToRunning(ctx context.Context, t Tunnel, payload *tunnelTokenPayload, remote bool, status *docker.ContainerStatus) error {
+	switch running := status.Labels[tunnelConfigLabel]; {
+	case remote && running == tunnelConfigRemote:
+		// cloudflared picks up remote config changes without a restart
+		return nil
+	case !remote && running != tunnelConfigRemote:
+		// A locally-managed tunnel only reads its config file on start
+		slog.Info("restarting cloudflared to apply new routes", "tunnel", t.label())
+		if err := m.dockerClient.RestartContainer(ctx, t.containerName(), 10*time.Second); err != nil {
 			return fmt.Errorf("failed to restart cloudflared: %w", err)
 		}
+		return nil
+	default:
+		// Switching between remote and local config needs a new container
+		slog.Info("recreating cloudflared to switch config source", "tunnel", t.label(), "remote_config", remote)
+		return m.runTunnelContainer(ctx, t, payload, remote)
 	}
-
-	return nil
 }
 
 // Reload reloads the tunnel configuration from the database
@@ -434,13 +498,16 @@ func (m *Manager) AddRoute(ctx context.Context, app *models.App) error {
 	return m.Reload(ctx)
 }
 
-// RemoveRoute removes a route for an app by reloading all routes
+// RemoveRoute removes an app's DNS record and reloads all routes
 func (m *Manager) RemoveRoute(ctx context.Context, app *models.App) error {
+	if err := m.removeAppRecords(ctx, app); err != nil {
+		slog.Warn("failed to remove DNS record for app", "app", app.Name, "error", err)
+	}
 	return m.Reload(ctx)
 }
 
-// writeConfigWithTunnelID writes the cloudflared config.yml file with a specific tunnel ID
-func (m *Manager) writeConfigWithTunnelID(rules []IngressRule, tunnelID string) error {
+// writeTunnelConfig writes a tunnel's cloudflared config.yml file
+func (m *Manager) writeTunnelConfig(t Tunnel, rules []IngressRule, tunnelID string) error {
 	cfg := TunnelConfig{
 		Tunnel:          tunnelID,
 		CredentialsFile: fmt.Sprintf("/data/cloudflared/%s.json", tunnelID),
@@ -452,14 +519,53 @@ func (m *Manager) writeConfigWithTunnelID(rules []IngressRule, tunnelID string)
 		return err
 	}
 
-	configPath := filepath.Join(m.configDir, "config.yml")
+	configPath := filepath.Join(m.configDir, t.Name, "config.yml")
 	return os.WriteFile(configPath, data, 0644)
 }
 
-// GetStatus returns the current tunnel status
+// GetStatus returns the current status of the default tunnel
 func (m *Manager) GetStatus(ctx context.Context) (*docker.ContainerStatus, error) {
 	if !m.IsConfigured() {
 		return nil, nil
 	}
 	return m.dockerClient.GetContainerStatus(ctx, cloudflaredContainer)
 }
+
+// TunnelStatus returns the container status of a named tunnel
+func (m *Manager) TunnelStatus(ctx context.Context, name string) (*docker.ContainerStatus, error) {
+	return m.dockerClient.GetContainerStatus(ctx, Tunnel{Name: name}.containerName())
+}
+
+// PublicURL returns the URL an app is exposed at through its tunnel, or
+// empty if the app has no subdomain or its tunnel is not configured
+func (m *Manager) PublicURL(ctx context.Context, app *models.App) string {
+	t, ok := m.tunnelFor(ctx, app)
+	subdomain := app.GetSubdomain()
+	if !ok || subdomain == "" || app.GetPublicPort() == 0 {
+		return ""
+	}
+	return fmt.Sprintf("https://%s.%s%s", subdomain, t.Domain, app.GetRoutePath())
+}
+
+// pathPattern converts a route path prefix like "/api" into the regex
+// cloudflared matches paths against, so "/api" and "/api/..." match but
+// "/apis" does not
+func pathPattern(prefix string) string {
+	if prefix == "" {
+		return ""
+	}
+	return "^" + regexp.QuoteMeta(prefix) + "(/|$)"
+}
+
+// sortIngressRules groups rules by hostname and, within a hostname, puts
+// longer path prefixes before shorter ones and the whole-host rule last.
+// cloudflared uses the first matching rule.
+func sortIngressRules(rules []IngressRule) []IngressRule {
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Hostname != rules[j].Hostname {
+			return rules[i].Hostname < rules[j].Hostname
+		}
+		return len(rules[i].Path) > len(rules[j].Path)
+	})
+	return rules
+}
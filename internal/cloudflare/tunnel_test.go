@@ -1,9 +1,14 @@
 package cloudflare
 
 import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
 	"testing"
 
 	"schooner/internal/config"
+	"schooner/internal/models"
 )
 
 func TestManager_IsConfigured(t *testing.T) {
@@ -64,6 +69,91 @@ func TestManager_IsConfigured(t *testing.T) {
 	}
 }
 
+func TestManager_PublicURL(t *testing.T) {
+	cfg := &config.Config{Cloudflare: config.CloudflareConfig{Domain: "example.com"}}
+	m := NewManager(cfg, nil)
+
+	tests := []struct {
+		name     string
+		app      *models.App
+		expected string
+	}{
+		{
+			name: "routed app",
+			app: &models.App{
+				Subdomain:  sql.NullString{String: "blog", Valid: true},
+				PublicPort: sql.NullInt64{Int64: 8080, Valid: true},
+			},
+			expected: "https://blog.example.com",
+		},
+		{
+			name: "routed app with path",
+			app: &models.App{
+				Subdomain:  sql.NullString{String: "blog", Valid: true},
+				PublicPort: sql.NullInt64{Int64: 8080, Valid: true},
+				RoutePath:  sql.NullString{String: "/api", Valid: true},
+			},
+			expected: "https://blog.example.com/api",
+		},
+		{
+			name:     "no subdomain",
+			app:      &models.App{PublicPort: sql.NullInt64{Int64: 8080, Valid: true}},
+			expected: "",
+		},
+		{
+			name:     "no public port",
+			app:      &models.App{Subdomain: sql.NullString{String: "blog", Valid: true}},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.PublicURL(context.Background(), tt.app); got != tt.expected {
+				t.Errorf("PublicURL() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPathPattern(t *testing.T) {
+	if got := pathPattern(""); got != "" {
+		t.Errorf("pathPattern(\"\") = %q, want empty", got)
+	}
+
+	pattern := regexp.MustCompile(pathPattern("/api.v1"))
+	for path, want := range map[string]bool{
+		"/api.v1":      true,
+		"/api.v1/":     true,
+		"/api.v1/apps": true,
+		"/api.v1s":     false,
+		"/apixv1":      false,
+		"/":            false,
+	} {
+		if got := pattern.MatchString(path); got != want {
+			t.Errorf("pattern %q matches %q = %v, want %v", pattern, path, got, want)
+		}
+	}
+}
+
+func TestSortIngressRules(t *testing.T) {
+	rules := sortIngressRules([]IngressRule{
+		{Hostname: "b.example.com", Service: "b"},
+		{Hostname: "a.example.com", Service: "a-root"},
+		{Hostname: "a.example.com", Path: pathPattern("/api"), Service: "a-api"},
+		{Hostname: "a.example.com", Path: pathPattern("/api/admin"), Service: "a-admin"},
+	})
+
+	var order []string
+	for _, rule := range rules {
+		order = append(order, rule.Service)
+	}
+	want := []string{"a-admin", "a-api", "a-root", "b"}
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
+
 func TestIngressRule(t *testing.T) {
 	rule := IngressRule{
 		Hostname: "app.example.com",
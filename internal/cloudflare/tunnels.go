@@ -0,0 +1,198 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"schooner/internal/models"
+)
+
+// TunnelsSettingKey stores the additional named tunnels as a JSON array
+const TunnelsSettingKey = "cloudflare_tunnels"
+
+// tunnelNamePattern restricts tunnel names to what is safe in container
+// names and config paths
+var tunnelNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Tunnel is a Cloudflare tunnel serving one domain. The default tunnel has
+// an empty name; apps select another tunnel by its name.
+type Tunnel struct {
+	Name     string `json:"name"`
+	Token    string `json:"tunnel_token"`
+	Domain   string `json:"domain"`
+	APIToken string `json:"api_token,omitempty"` // Defaults to the main API token
+}
+
+// ValidTunnelName reports whether name can name a tunnel
+func ValidTunnelName(name string) bool {
+	return tunnelNamePattern.MatchString(name)
+}
+
+// Validate checks a named tunnel is complete
+func (t Tunnel) Validate() error {
+	if !ValidTunnelName(t.Name) {
+		return fmt.Errorf("tunnel name %q must be lowercase letters, digits and dashes", t.Name)
+	}
+	if t.Token == "" {
+		return fmt.Errorf("tunnel %s: token is required", t.Name)
+	}
+	if t.Domain == "" {
+		return fmt.Errorf("tunnel %s: domain is required", t.Name)
+	}
+	if _, err := decodeToken(t.Token); err != nil {
+		return fmt.Errorf("tunnel %s: %w", t.Name, err)
+	}
+	return nil
+}
+
+// label names the tunnel in logs and errors
+func (t Tunnel) label() string {
+	if t.Name == "" {
+		return "default"
+	}
+	return t.Name
+}
+
+// containerName returns the cloudflared container running this tunnel
+func (t Tunnel) containerName() string {
+	if t.Name == "" {
+		return cloudflaredContainer
+	}
+	return cloudflaredContainer + "-" + t.Name
+}
+
+// dnsClient returns a DNS client for the tunnel's zone, or nil without an
+// API token
+func (t Tunnel) dnsClient() *DNSClient {
+	if t.APIToken == "" {
+		return nil
+	}
+	return NewDNSClient(t.APIToken)
+}
+
+// ParseTunnels decodes and validates the stored list of named tunnels
+func ParseTunnels(raw string) ([]Tunnel, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var tunnels []Tunnel
+	if err := json.Unmarshal([]byte(raw), &tunnels); err != nil {
+		return nil, fmt.Errorf("failed to parse tunnels: %w", err)
+	}
+	if err := ValidateTunnels(tunnels); err != nil {
+		return nil, err
+	}
+	return tunnels, nil
+}
+
+// ValidateTunnels checks every named tunnel is complete and uniquely named
+func ValidateTunnels(tunnels []Tunnel) error {
+	seen := make(map[string]bool, len(tunnels))
+	for _, t := range tunnels {
+		if err := t.Validate(); err != nil {
+			return err
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("duplicate tunnel name %q", t.Name)
+		}
+		seen[t.Name] = true
+	}
+	return nil
+}
+
+// NamedTunnels returns the additional tunnels from the database, falling
+// back to the config file
+func (m *Manager) NamedTunnels(ctx context.Context) ([]Tunnel, error) {
+	if m.settingsQueries != nil {
+		if raw, err := m.settingsQueries.Get(ctx, TunnelsSettingKey); err == nil && raw != "" {
+			return ParseTunnels(raw)
+		}
+	}
+
+	tunnels := make([]Tunnel, 0, len(m.cfg.Cloudflare.Tunnels))
+	for _, t := range m.cfg.Cloudflare.Tunnels {
+		tunnels = append(tunnels, Tunnel{
+			Name:     t.Name,
+			Token:    t.TunnelToken,
+			Domain:   t.Domain,
+			APIToken: t.APIToken,
+		})
+	}
+	if err := ValidateTunnels(tunnels); err != nil {
+		return nil, err
+	}
+	return tunnels, nil
+}
+
+// TunnelStates returns the state of the cloudflared container of every
+// configured tunnel by name, "default" for the default one and "missing"
+// when its container doesn't exist
+func (m *Manager) TunnelStates(ctx context.Context) map[string]string {
+	states := make(map[string]string)
+	for _, t := range m.tunnels(ctx) {
+		status, err := m.dockerClient.GetContainerStatus(ctx, t.containerName())
+		switch {
+		case err != nil:
+			states[t.label()] = "unknown"
+		case status == nil:
+			states[t.label()] = "missing"
+		default:
+			states[t.label()] = status.State
+		}
+	}
+	return states
+}
+
+// tunnels returns every configured tunnel: the default one, if set up, then
+// the named ones. Named tunnels without an API token use the default's.
+func (m *Manager) tunnels(ctx context.Context) []Tunnel {
+	token, _, domain, apiToken := m.getTunnelConfig(ctx)
+
+	var tunnels []Tunnel
+	if token != "" && domain != "" {
+		tunnels = append(tunnels, Tunnel{Token: token, Domain: domain, APIToken: apiToken})
+	}
+
+	named, err := m.NamedTunnels(ctx)
+	if err != nil {
+		slog.Error("ignoring invalid Cloudflare tunnels", "error", err)
+		return tunnels
+	}
+	for _, t := range named {
+		if t.APIToken == "" {
+			t.APIToken = apiToken
+		}
+		tunnels = append(tunnels, t)
+	}
+	return tunnels
+}
+
+// tunnelFor returns the tunnel an app routes through
+func (m *Manager) tunnelFor(ctx context.Context, app *models.App) (Tunnel, bool) {
+	if app.GetTunnel() == "" {
+		token, _, domain, apiToken := m.getTunnelConfig(ctx)
+		return Tunnel{Token: token, Domain: domain, APIToken: apiToken}, domain != ""
+	}
+
+	for _, t := range m.tunnels(ctx) {
+		if t.Name == app.GetTunnel() {
+			return t, true
+		}
+	}
+	return Tunnel{}, false
+}
+
+// appsForTunnel returns the apps that route through the named tunnel
+func appsForTunnel(apps []*models.App, name string) []*models.App {
+	var routed []*models.App
+	for _, app := range apps {
+		if app.GetTunnel() == name {
+			routed = append(routed, app)
+		}
+	}
+	return routed
+}
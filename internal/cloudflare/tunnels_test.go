@@ -0,0 +1,134 @@
+package cloudflare
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"schooner/internal/config"
+	"schooner/internal/models"
+)
+
+// testToken builds a tunnel token for the given tunnel ID
+func testToken(tunnelID string) string {
+	return base64.StdEncoding.EncodeToString([]byte(`{"a":"account","s":"secret","t":"` + tunnelID + `"}`))
+}
+
+type fakeSettings map[string]string
+
+func (f fakeSettings) Get(ctx context.Context, key string) (string, error) {
+	return f[key], nil
+}
+
+func TestParseTunnels(t *testing.T) {
+	token := testToken("tunnel-1")
+
+	tests := []struct {
+		name    string
+		raw     string
+		want    int
+		wantErr bool
+	}{
+		{name: "empty", raw: "", want: 0},
+		{name: "valid", raw: `[{"name":"internal","tunnel_token":"` + token + `","domain":"lan.example.com"}]`, want: 1},
+		{name: "invalid json", raw: `{`, wantErr: true},
+		{name: "bad name", raw: `[{"name":"Internal Net","tunnel_token":"` + token + `","domain":"lan.example.com"}]`, wantErr: true},
+		{name: "missing domain", raw: `[{"name":"internal","tunnel_token":"` + token + `"}]`, wantErr: true},
+		{name: "bad token", raw: `[{"name":"internal","tunnel_token":"nope","domain":"lan.example.com"}]`, wantErr: true},
+		{
+			name:    "duplicate name",
+			raw:     `[{"name":"a","tunnel_token":"` + token + `","domain":"a.com"},{"name":"a","tunnel_token":"` + token + `","domain":"b.com"}]`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tunnels, err := ParseTunnels(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTunnels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(tunnels) != tt.want {
+				t.Errorf("ParseTunnels() returned %d tunnels, want %d", len(tunnels), tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_Tunnels(t *testing.T) {
+	cfg := &config.Config{Cloudflare: config.CloudflareConfig{
+		TunnelToken: testToken("default"),
+		Domain:      "example.com",
+		APIToken:    "main-api-token",
+	}}
+	m := NewManager(cfg, nil)
+	m.SetSettingsQueries(fakeSettings{
+		TunnelsSettingKey: `[{"name":"internal","tunnel_token":"` + testToken("internal") + `","domain":"lan.example.com"}]`,
+	})
+
+	tunnels := m.tunnels(context.Background())
+	if len(tunnels) != 2 {
+		t.Fatalf("tunnels() returned %d tunnels, want 2", len(tunnels))
+	}
+	if tunnels[0].Name != "" || tunnels[0].containerName() != "schooner-cloudflared" {
+		t.Errorf("first tunnel = %+v, want the default tunnel", tunnels[0])
+	}
+	internal := tunnels[1]
+	if internal.containerName() != "schooner-cloudflared-internal" {
+		t.Errorf("containerName() = %q", internal.containerName())
+	}
+	if internal.APIToken != "main-api-token" {
+		t.Errorf("APIToken = %q, want the main API token", internal.APIToken)
+	}
+}
+
+func TestManager_PublicURL_NamedTunnel(t *testing.T) {
+	m := NewManager(&config.Config{Cloudflare: config.CloudflareConfig{
+		Domain: "example.com",
+		Tunnels: []config.CloudflareTunnel{
+			{Name: "internal", TunnelToken: testToken("internal"), Domain: "lan.example.com"},
+		},
+	}}, nil)
+
+	app := &models.App{
+		Subdomain:  sql.NullString{String: "wiki", Valid: true},
+		PublicPort: sql.NullInt64{Int64: 8080, Valid: true},
+		Tunnel:     sql.NullString{String: "internal", Valid: true},
+	}
+	if got := m.PublicURL(context.Background(), app); got != "https://wiki.lan.example.com" {
+		t.Errorf("PublicURL() = %q, want https://wiki.lan.example.com", got)
+	}
+
+	app.Tunnel = sql.NullString{String: "missing", Valid: true}
+	if got := m.PublicURL(context.Background(), app); got != "" {
+		t.Errorf("PublicURL() with unknown tunnel = %q, want empty", got)
+	}
+}
+
+func TestManager_IngressRules_PerTunnel(t *testing.T) {
+	m := NewManager(&config.Config{Server: config.ServerConfig{Port: 7123, BaseURL: "https://schooner.example.com"}}, nil)
+
+	routed := func(name, tunnel string) *models.App {
+		return &models.App{
+			Name:       name,
+			Enabled:    true,
+			Subdomain:  sql.NullString{String: name, Valid: true},
+			PublicPort: sql.NullInt64{Int64: 8080, Valid: true},
+			Tunnel:     sql.NullString{String: tunnel, Valid: tunnel != ""},
+		}
+	}
+	apps := []*models.App{routed("blog", ""), routed("wiki", "internal")}
+
+	internal := Tunnel{Name: "internal", Domain: "lan.example.com"}
+	rules := m.ingressRules(context.Background(), internal, appsForTunnel(apps, internal.Name))
+
+	var hostnames []string
+	for _, rule := range rules {
+		hostnames = append(hostnames, rule.Hostname)
+	}
+	if got := strings.Join(hostnames, ","); got != "wiki.lan.example.com," {
+		t.Errorf("ingress hostnames = %q, want only the internal app and the catch-all", got)
+	}
+}
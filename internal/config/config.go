@@ -1,27 +1,80 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
 // Load reads configuration from file and environment variables
 func Load() (*Config, error) {
+	cfg, unknown, err := read("")
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range unknown {
+		slog.Warn("ignoring unknown config setting", "key", key, "file", cfg.file)
+	}
+
+	// Ensure directories exist
+	if err := ensureDirs(cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Check reads and validates a config file without using it, reporting every
+// problem found, unknown settings included. An empty path searches for the
+// file the way Load does.
+func Check(path string) (*Config, error) {
+	cfg, unknown, err := read(path)
+	if cfg == nil {
+		return nil, err
+	}
+
+	errs := []error{err}
+	for _, key := range unknown {
+		errs = append(errs, fmt.Errorf("%s: unknown setting", key))
+	}
+	return cfg, errors.Join(errs...)
+}
+
+// read loads and validates the config file at path, or the one found in the
+// search paths, returning the settings it has that Config doesn't. The
+// config is returned with its validation errors, or nil if it can't be read.
+func read(path string) (*Config, []string, error) {
 	v := viper.New()
 
 	// Set defaults
 	v.SetDefault("server.host", "0.0.0.0")
 	v.SetDefault("server.port", 8080)
+	v.SetDefault("server.force_https", false)
+	v.SetDefault("server.strict_csp", false)
+	v.SetDefault("database.driver", "sqlite")
 	v.SetDefault("database.path", "./data/schooner.db")
+	v.SetDefault("database.url", "")
 	v.SetDefault("git.work_dir", "./data/repos")
 	v.SetDefault("docker.cleanup_enabled", true)
 	v.SetDefault("docker.keep_image_count", 5)
 	v.SetDefault("docker.build_timeout", "30m")
+	v.SetDefault("docker.reconcile_interval", "5m")
+	v.SetDefault("docker.port_range", "10000-10999")
+	v.SetDefault("docker.artifacts_dir", "./data/artifacts")
+	v.SetDefault("metrics.enabled", false)
+	v.SetDefault("tracing.endpoint", "")
+	v.SetDefault("tracing.sample_ratio", 1.0)
+	v.SetDefault("update.repo", "bas-slats/schooner")
+	v.SetDefault("update.image", "ghcr.io/bas-slats/schooner")
+	v.SetDefault("update.check_interval", "12h")
 
 	// Config file settings
 	v.SetConfigName("config")
@@ -34,8 +87,11 @@ func Load() (*Config, error) {
 	v.AddConfigPath("/etc/homelab-cd")
 
 	// Also check HOMELAB_CD_CONFIG env var
-	if configPath := os.Getenv("HOMELAB_CD_CONFIG"); configPath != "" {
-		v.SetConfigFile(configPath)
+	if path == "" {
+		path = os.Getenv("HOMELAB_CD_CONFIG")
+	}
+	if path != "" {
+		v.SetConfigFile(path)
 	}
 
 	// Environment variable settings
@@ -46,21 +102,26 @@ func Load() (*Config, error) {
 	// Read config file (optional)
 	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, fmt.Errorf("failed to read config file: %w", err)
+			return nil, nil, fmt.Errorf("failed to read config file: %w", err)
 		}
 		// Config file not found is okay, we'll use defaults and env vars
 	}
 
 	// Parse config into struct
 	var cfg Config
-	if err := v.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	var metadata mapstructure.Metadata
+	if err := v.Unmarshal(&cfg, func(dc *mapstructure.DecoderConfig) { dc.Metadata = &metadata }); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	cfg.file = v.ConfigFileUsed()
 
 	// Expand environment variables in sensitive fields
 	cfg.Server.SecretKey = expandEnv(cfg.Server.SecretKey)
 	cfg.Git.Token = expandEnv(cfg.Git.Token)
+	cfg.Database.URL = expandEnv(cfg.Database.URL)
 	cfg.Git.SSHKeyPath = expandEnv(cfg.Git.SSHKeyPath)
+	cfg.Metrics.Token = expandEnv(cfg.Metrics.Token)
+	cfg.Tracing.Endpoint = expandEnv(cfg.Tracing.Endpoint)
 
 	for i := range cfg.Apps {
 		cfg.Apps[i].WebhookSecret = expandEnv(cfg.Apps[i].WebhookSecret)
@@ -74,7 +135,7 @@ func Load() (*Config, error) {
 		if timeout := v.GetString("docker.build_timeout"); timeout != "" {
 			d, err := time.ParseDuration(timeout)
 			if err != nil {
-				return nil, fmt.Errorf("invalid build_timeout: %w", err)
+				return nil, nil, fmt.Errorf("invalid build_timeout: %w", err)
 			}
 			cfg.Docker.BuildTimeout = d
 		}
@@ -100,16 +161,8 @@ func Load() (*Config, error) {
 	}
 
 	// Validate config
-	if err := validate(&cfg); err != nil {
-		return nil, err
-	}
-
-	// Ensure directories exist
-	if err := ensureDirs(&cfg); err != nil {
-		return nil, err
-	}
-
-	return &cfg, nil
+	sort.Strings(metadata.Unused)
+	return &cfg, metadata.Unused, validate(&cfg)
 }
 
 // expandEnv expands ${VAR} or $VAR in string
@@ -117,28 +170,62 @@ func expandEnv(s string) string {
 	return os.ExpandEnv(s)
 }
 
-// validate checks config for required fields and valid values
+// validate checks config for required fields and valid values, returning
+// every problem found
 func validate(cfg *Config) error {
+	var errs []error
+
 	if cfg.Server.Port < 1 || cfg.Server.Port > 65535 {
-		return fmt.Errorf("invalid server port: %d", cfg.Server.Port)
+		errs = append(errs, fmt.Errorf("invalid server port: %d", cfg.Server.Port))
+	}
+
+	switch cfg.Database.Driver {
+	case "sqlite":
+	case "postgres":
+		if cfg.Database.URL == "" {
+			errs = append(errs, fmt.Errorf("database url is required for postgres"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("invalid database driver %q", cfg.Database.Driver))
+	}
+
+	if cfg.Docker.BuildWorkers < 0 {
+		errs = append(errs, fmt.Errorf("invalid docker build_workers %d: must not be negative", cfg.Docker.BuildWorkers))
+	}
+	if cfg.Docker.ReconcileInterval < 0 {
+		errs = append(errs, fmt.Errorf("invalid docker reconcile_interval: must not be negative"))
+	}
+	if _, _, err := cfg.Docker.Ports(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid docker port_range: %w", err))
+	}
+
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("invalid tracing sample_ratio %v: must be between 0 and 1", cfg.Tracing.SampleRatio))
+	}
+
+	if cfg.Update.Repo != "" && strings.Count(cfg.Update.Repo, "/") != 1 {
+		errs = append(errs, fmt.Errorf("invalid update repo %q: must be owner/name", cfg.Update.Repo))
+	}
+	if cfg.Update.CheckInterval < 0 {
+		errs = append(errs, fmt.Errorf("invalid update check_interval: must not be negative"))
 	}
 
 	for i, app := range cfg.Apps {
 		if app.Name == "" {
-			return fmt.Errorf("app[%d]: name is required", i)
+			errs = append(errs, fmt.Errorf("app[%d]: name is required", i))
 		}
 		if app.RepoURL == "" {
-			return fmt.Errorf("app[%d] %q: repo_url is required", i, app.Name)
+			errs = append(errs, fmt.Errorf("app[%d] %q: repo_url is required", i, app.Name))
 		}
 		switch app.BuildStrategy {
-		case "dockerfile", "compose", "autodetect":
+		case "dockerfile", "kaniko", "compose", "autodetect":
 			// valid
 		default:
-			return fmt.Errorf("app[%d] %q: invalid build_strategy %q", i, app.Name, app.BuildStrategy)
+			errs = append(errs, fmt.Errorf("app[%d] %q: invalid build_strategy %q", i, app.Name, app.BuildStrategy))
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 // ensureDirs creates necessary directories
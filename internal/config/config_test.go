@@ -0,0 +1,111 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantErrs []string
+	}{
+		{
+			name:    "valid",
+			content: "server:\n  port: 8080\n",
+		},
+		{
+			name:     "every problem reported",
+			content:  "server:\n  port: 0\ndatabase:\n  driver: mysql\ntracing:\n  sample_ratio: 2\n",
+			wantErrs: []string{"invalid server port: 0", `invalid database driver "mysql"`, "invalid tracing sample_ratio 2"},
+		},
+		{
+			name:     "bad port range",
+			content:  "docker:\n  port_range: 9000-8000\n",
+			wantErrs: []string{"invalid docker port_range"},
+		},
+		{
+			name:     "unknown settings",
+			content:  "server:\n  prot: 8080\nupdates:\n  repo: x/y\n",
+			wantErrs: []string{"server.prot: unknown setting", "updates: unknown setting"},
+		},
+		{
+			name:     "wrong type",
+			content:  "server:\n  port: eighty\n",
+			wantErrs: []string{"server.port"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			writeConfig(t, path, tt.content)
+
+			_, err := Check(path)
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("Check() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Check() succeeded, want %v", tt.wantErrs)
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Check() error = %q, want it to mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, "server:\n  base_url: http://old.example.com\n  port: 8080\n")
+	cfg, err := Check(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var notified []string
+	cfg.OnReload(func(changed []string) { notified = changed })
+
+	writeConfig(t, path, "server:\n  base_url: https://new.example.com\n  port: 9090\ndocker:\n  build_workers: 4\n")
+	result, err := cfg.Reload()
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if want := []string{"server.base_url", "docker.build_workers"}; !slices.Equal(result.Changed, want) || !slices.Equal(notified, want) {
+		t.Errorf("changed = %v, notified %v, want %v", result.Changed, notified, want)
+	}
+	if !slices.Equal(result.RestartRequired, []string{"server.port"}) {
+		t.Errorf("restart required = %v, want [server.port]", result.RestartRequired)
+	}
+	if cfg.BaseURL() != "https://new.example.com" || cfg.BuildWorkers() != 4 {
+		t.Errorf("base URL %q, build workers %d not applied", cfg.BaseURL(), cfg.BuildWorkers())
+	}
+	if cfg.Server.Port != 8080 {
+		t.Errorf("port changed to %d without a restart", cfg.Server.Port)
+	}
+
+	// An invalid file leaves the config as it was
+	writeConfig(t, path, "server:\n  base_url: https://broken.example.com\n  port: 0\n")
+	if _, err := cfg.Reload(); err == nil {
+		t.Fatal("Reload() of an invalid file succeeded")
+	}
+	if cfg.BaseURL() != "https://new.example.com" {
+		t.Errorf("base URL = %q after a failed reload", cfg.BaseURL())
+	}
+}
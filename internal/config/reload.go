@@ -0,0 +1,112 @@
+package config
+
+import (
+	"reflect"
+)
+
+// ReloadResult is what reloading the config file changed
+type ReloadResult struct {
+	Changed         []string `json:"changed"`          // Settings now in effect
+	RestartRequired []string `json:"restart_required"` // Settings that changed but only apply after a restart
+}
+
+// File returns the config file that was read, empty if none was found
+func (c *Config) File() string {
+	return c.file
+}
+
+// BaseURL returns the public URL Schooner is reached at, which a reload can
+// change
+func (c *Config) BaseURL() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.BaseURL
+}
+
+// OAuth returns the GitHub OAuth app users log in with, which a reload can
+// change
+func (c *Config) OAuth() GitHubOAuthConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.GitHubOAuth
+}
+
+// BuildWorkers returns how many builds the config file runs at once, or 0
+// to use the count set in the UI. A reload can change it.
+func (c *Config) BuildWorkers() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Docker.BuildWorkers
+}
+
+// OnReload registers fn to be called with the settings a reload changed
+func (c *Config) OnReload(fn func(changed []string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onReload = append(c.onReload, fn)
+}
+
+// Reload re-reads the config file and applies the settings that can change
+// at runtime: the base URL, the GitHub OAuth app and the build workers.
+// Other settings that changed are reported as needing a restart. An invalid
+// file leaves the config as it was.
+func (c *Config) Reload() (*ReloadResult, error) {
+	next, _, err := read(c.file)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReloadResult{Changed: []string{}, RestartRequired: []string{}}
+	for _, s := range []struct {
+		key      string
+		old, new interface{}
+	}{
+		{"server.host", c.Server.Host, next.Server.Host},
+		{"server.port", c.Server.Port, next.Server.Port},
+		{"server.secret_key", c.Server.SecretKey, next.Server.SecretKey},
+		{"server.force_https", c.Server.ForceHTTPS, next.Server.ForceHTTPS},
+		{"server.strict_csp", c.Server.StrictCSP, next.Server.StrictCSP},
+		{"database", c.Database, next.Database},
+		{"git", c.Git, next.Git},
+		{"cloudflare", c.Cloudflare, next.Cloudflare},
+		{"caddy", c.Caddy, next.Caddy},
+		{"traefik", c.Traefik, next.Traefik},
+		{"observability", c.Observability, next.Observability},
+		{"docker.host", c.Docker.Host, next.Docker.Host},
+		{"docker.cleanup_enabled", c.Docker.CleanupEnabled, next.Docker.CleanupEnabled},
+		{"docker.keep_image_count", c.Docker.KeepImageCount, next.Docker.KeepImageCount},
+		{"docker.build_timeout", c.Docker.BuildTimeout, next.Docker.BuildTimeout},
+		{"docker.port_range", c.Docker.PortRange, next.Docker.PortRange},
+		{"metrics", c.Metrics, next.Metrics},
+		{"tracing", c.Tracing, next.Tracing},
+		{"update", c.Update, next.Update},
+		{"apps", c.Apps, next.Apps},
+	} {
+		if !reflect.DeepEqual(s.old, s.new) {
+			result.RestartRequired = append(result.RestartRequired, s.key)
+		}
+	}
+
+	c.mu.Lock()
+	if c.Server.BaseURL != next.Server.BaseURL {
+		c.Server.BaseURL = next.Server.BaseURL
+		result.Changed = append(result.Changed, "server.base_url")
+	}
+	if c.GitHubOAuth != next.GitHubOAuth {
+		c.GitHubOAuth = next.GitHubOAuth
+		result.Changed = append(result.Changed, "github_oauth")
+	}
+	if c.Docker.BuildWorkers != next.Docker.BuildWorkers {
+		c.Docker.BuildWorkers = next.Docker.BuildWorkers
+		result.Changed = append(result.Changed, "docker.build_workers")
+	}
+	hooks := c.onReload
+	c.mu.Unlock()
+
+	if len(result.Changed) > 0 {
+		for _, fn := range hooks {
+			fn(result.Changed)
+		}
+	}
+	return result, nil
+}
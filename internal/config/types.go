@@ -1,6 +1,12 @@
 package config
 
-import "time"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
 
 // Config represents the application configuration
 type Config struct {
@@ -9,22 +15,35 @@ type Config struct {
 	Git           GitConfig           `yaml:"git" mapstructure:"git"`
 	GitHubOAuth   GitHubOAuthConfig   `yaml:"github_oauth" mapstructure:"github_oauth"`
 	Cloudflare    CloudflareConfig    `yaml:"cloudflare" mapstructure:"cloudflare"`
+	Caddy         CaddyConfig         `yaml:"caddy" mapstructure:"caddy"`
+	Traefik       TraefikConfig       `yaml:"traefik" mapstructure:"traefik"`
 	Observability ObservabilityConfig `yaml:"observability" mapstructure:"observability"`
 	Docker        DockerConfig        `yaml:"docker" mapstructure:"docker"`
+	Metrics       MetricsConfig       `yaml:"metrics" mapstructure:"metrics"`
+	Tracing       TracingConfig       `yaml:"tracing" mapstructure:"tracing"`
+	Update        UpdateConfig        `yaml:"update" mapstructure:"update"`
 	Apps          []AppConfig         `yaml:"apps" mapstructure:"apps"`
+
+	file     string       // Config file read, empty if none was found
+	mu       sync.RWMutex // Guards the settings Reload changes
+	onReload []func(changed []string)
 }
 
 // ServerConfig holds HTTP server settings
 type ServerConfig struct {
-	Host      string `yaml:"host" mapstructure:"host"`
-	Port      int    `yaml:"port" mapstructure:"port"`
-	BaseURL   string `yaml:"base_url" mapstructure:"base_url"`
-	SecretKey string `yaml:"secret_key" mapstructure:"secret_key"`
+	Host       string `yaml:"host" mapstructure:"host"`
+	Port       int    `yaml:"port" mapstructure:"port"`
+	BaseURL    string `yaml:"base_url" mapstructure:"base_url"`
+	SecretKey  string `yaml:"secret_key" mapstructure:"secret_key"`
+	ForceHTTPS bool   `yaml:"force_https" mapstructure:"force_https"` // Redirect plain HTTP to HTTPS and send HSTS
+	StrictCSP  bool   `yaml:"strict_csp" mapstructure:"strict_csp"`   // Only load images from Schooner and GitHub avatars
 }
 
 // DatabaseConfig holds database settings
 type DatabaseConfig struct {
-	Path string `yaml:"path" mapstructure:"path"`
+	Driver string `yaml:"driver" mapstructure:"driver"` // sqlite (default) or postgres
+	Path   string `yaml:"path" mapstructure:"path"`     // SQLite database file
+	URL    string `yaml:"url" mapstructure:"url"`       // Postgres connection string
 }
 
 // GitConfig holds git client settings
@@ -43,11 +62,36 @@ type GitHubOAuthConfig struct {
 
 // CloudflareConfig holds Cloudflare Tunnel settings
 type CloudflareConfig struct {
+	TunnelToken string             `yaml:"tunnel_token" mapstructure:"tunnel_token"`
+	TunnelID    string             `yaml:"tunnel_id" mapstructure:"tunnel_id"`
+	Domain      string             `yaml:"domain" mapstructure:"domain"`             // e.g., "slats.dev"
+	ServicePort int                `yaml:"service_port" mapstructure:"service_port"` // External port for tunnel to reach schooner (defaults to server.port)
+	APIToken    string             `yaml:"api_token" mapstructure:"api_token"`       // Cloudflare API token for DNS management
+	Tunnels     []CloudflareTunnel `yaml:"tunnels" mapstructure:"tunnels"`           // Additional named tunnels apps can route through
+}
+
+// CloudflareTunnel holds an additional named tunnel serving its own domain
+type CloudflareTunnel struct {
+	Name        string `yaml:"name" mapstructure:"name"`
 	TunnelToken string `yaml:"tunnel_token" mapstructure:"tunnel_token"`
-	TunnelID    string `yaml:"tunnel_id" mapstructure:"tunnel_id"`
-	Domain      string `yaml:"domain" mapstructure:"domain"`             // e.g., "slats.dev"
-	ServicePort int    `yaml:"service_port" mapstructure:"service_port"` // External port for tunnel to reach schooner (defaults to server.port)
-	APIToken    string `yaml:"api_token" mapstructure:"api_token"`       // Cloudflare API token for DNS management
+	Domain      string `yaml:"domain" mapstructure:"domain"`
+	APIToken    string `yaml:"api_token" mapstructure:"api_token"` // Defaults to the main API token
+}
+
+// CaddyConfig holds settings for the Caddy reverse proxy, an alternative to
+// the Cloudflare Tunnel
+type CaddyConfig struct {
+	Domain string `yaml:"domain" mapstructure:"domain"` // Apps are served at subdomain.domain
+	Email  string `yaml:"email" mapstructure:"email"`   // Let's Encrypt account email
+}
+
+// TraefikConfig holds settings for routing apps through an existing Traefik
+// instance using container labels
+type TraefikConfig struct {
+	Domain       string `yaml:"domain" mapstructure:"domain"`             // Apps are served at subdomain.domain
+	Network      string `yaml:"network" mapstructure:"network"`           // Docker network Traefik reaches containers on
+	EntryPoint   string `yaml:"entrypoint" mapstructure:"entrypoint"`     // e.g., "websecure"
+	CertResolver string `yaml:"certresolver" mapstructure:"certresolver"` // Enables TLS with this resolver
 }
 
 // ObservabilityConfig holds Loki/Grafana log aggregation settings
@@ -56,6 +100,10 @@ type ObservabilityConfig struct {
 	GrafanaPort   int    `yaml:"grafana_port" mapstructure:"grafana_port"`     // Default: 3000
 	LokiRetention string `yaml:"loki_retention" mapstructure:"loki_retention"` // Default: "168h" (7 days)
 	DataDir       string `yaml:"data_dir" mapstructure:"data_dir"`             // Default: "/data/observability"
+
+	// Optional Prometheus + node-exporter + cAdvisor metrics stack
+	MetricsEnabled      bool   `yaml:"metrics_enabled" mapstructure:"metrics_enabled"`
+	PrometheusRetention string `yaml:"prometheus_retention" mapstructure:"prometheus_retention"` // Default: "15d"
 }
 
 // DockerConfig holds Docker client settings
@@ -64,6 +112,58 @@ type DockerConfig struct {
 	CleanupEnabled bool          `yaml:"cleanup_enabled" mapstructure:"cleanup_enabled"`
 	KeepImageCount int           `yaml:"keep_image_count" mapstructure:"keep_image_count"`
 	BuildTimeout   time.Duration `yaml:"build_timeout" mapstructure:"build_timeout"`
+	BuildWorkers   int           `yaml:"build_workers" mapstructure:"build_workers"` // Builds run at once, overriding the setting when non-zero
+	ArtifactsDir   string        `yaml:"artifacts_dir" mapstructure:"artifacts_dir"` // Where files copied out of built images are kept
+
+	// ReconcileInterval is how often apps, add-ons and services are checked
+	// against what should be running; 0 only checks at startup
+	ReconcileInterval time.Duration `yaml:"reconcile_interval" mapstructure:"reconcile_interval"`
+
+	// PortRange is where host ports are picked from for apps saved without
+	// one, as "first-last"
+	PortRange string `yaml:"port_range" mapstructure:"port_range"`
+}
+
+// Ports returns the first and last host port of PortRange
+func (d DockerConfig) Ports() (int, int, error) {
+	from, to, ok := strings.Cut(d.PortRange, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid port range %q: must be first-last", d.PortRange)
+	}
+	first, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port range %q: %w", d.PortRange, err)
+	}
+	last, err := strconv.Atoi(strings.TrimSpace(to))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port range %q: %w", d.PortRange, err)
+	}
+	if first < 1 || last > 65535 || first > last {
+		return 0, 0, fmt.Errorf("invalid port range %q: must be within 1-65535, first to last", d.PortRange)
+	}
+	return first, last, nil
+}
+
+// MetricsConfig holds Prometheus /metrics endpoint settings. The endpoint is
+// off by default as it lists apps and their status to anyone who can reach it.
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled" mapstructure:"enabled"`
+	Token   string `yaml:"token" mapstructure:"token"` // Optional bearer token required to scrape
+}
+
+// TracingConfig holds OpenTelemetry trace export settings. The standard
+// OTEL_EXPORTER_OTLP_* environment variables work too.
+type TracingConfig struct {
+	Endpoint    string  `yaml:"endpoint" mapstructure:"endpoint"`         // OTLP/HTTP collector URL, e.g. "http://tempo:4318"
+	SampleRatio float64 `yaml:"sample_ratio" mapstructure:"sample_ratio"` // Share of traces kept, 0 to 1
+}
+
+// UpdateConfig holds settings for updating Schooner itself to its latest
+// GitHub release
+type UpdateConfig struct {
+	Repo          string        `yaml:"repo" mapstructure:"repo"`                     // GitHub repository releases are checked in, "owner/name"
+	Image         string        `yaml:"image" mapstructure:"image"`                   // Image releases are published as, tagged with the release tag
+	CheckInterval time.Duration `yaml:"check_interval" mapstructure:"check_interval"` // 0 disables update checks
 }
 
 // AppConfig defines an application to deploy
@@ -94,7 +194,8 @@ func Default() *Config {
 			Port: 7123,
 		},
 		Database: DatabaseConfig{
-			Path: "./data/schooner.db",
+			Driver: "sqlite",
+			Path:   "./data/schooner.db",
 		},
 		Git: GitConfig{
 			WorkDir: "./data/repos",
@@ -103,6 +204,15 @@ func Default() *Config {
 			CleanupEnabled: true,
 			KeepImageCount: 5,
 			BuildTimeout:   30 * time.Minute,
+			PortRange:      "10000-10999",
+		},
+		Tracing: TracingConfig{
+			SampleRatio: 1,
+		},
+		Update: UpdateConfig{
+			Repo:          "bas-slats/schooner",
+			Image:         "ghcr.io/bas-slats/schooner",
+			CheckInterval: 12 * time.Hour,
 		},
 	}
 }
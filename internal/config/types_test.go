@@ -36,6 +36,9 @@ func TestDefault(t *testing.T) {
 	if cfg.Docker.BuildTimeout != 30*time.Minute {
 		t.Errorf("Docker.BuildTimeout = %v, want 30m", cfg.Docker.BuildTimeout)
 	}
+	if cfg.Metrics.Enabled {
+		t.Errorf("Metrics.Enabled = %v, want false", cfg.Metrics.Enabled)
+	}
 }
 
 func TestServerConfig(t *testing.T) {
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 )
 
 var (
@@ -96,7 +97,7 @@ func getOrCreateKey() ([]byte, error) {
 	}
 
 	// Try to read from key file
-	keyPath := getKeyPath()
+	keyPath := KeyPath()
 	if data, err := os.ReadFile(keyPath); err == nil {
 		key, err := base64.StdEncoding.DecodeString(string(data))
 		if err != nil {
@@ -114,7 +115,7 @@ func getOrCreateKey() ([]byte, error) {
 	}
 
 	// Save to file
-	if err := os.MkdirAll("./data", 0700); err != nil {
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
 		return nil, fmt.Errorf("failed to create data directory: %w", err)
 	}
 
@@ -126,8 +127,17 @@ func getOrCreateKey() ([]byte, error) {
 	return key, nil
 }
 
-// getKeyPath returns the path to the key file
-func getKeyPath() string {
+// ExportKey returns the base64-encoded encryption key, creating it if needed
+func ExportKey() (string, error) {
+	key, err := getOrCreateKey()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// KeyPath returns the path to the key file
+func KeyPath() string {
 	if path := os.Getenv("SCHOONER_KEY_PATH"); path != "" {
 		return path
 	}
@@ -137,8 +147,16 @@ func getKeyPath() string {
 // IsSensitiveKey returns true if the setting key contains sensitive data
 func IsSensitiveKey(key string) bool {
 	sensitiveKeys := map[string]bool{
-		"github_token":            true,
-		"cloudflare_tunnel_token": true,
+		"github_token":                    true,
+		"cloudflare_tunnel_token":         true,
+		"cloudflare_tunnels":              true,
+		"ntfy_token":                      true,
+		"observability_grafana_embed_key": true,
+		"totp_secret":                     true,
+		"totp_pending_secret":             true,
+		"backup_s3_secret_key":            true,
+		"system_backup_passphrase":        true,
+		"registry_credentials":            true,
 	}
 	return sensitiveKeys[key]
 }
@@ -122,6 +122,7 @@ func TestIsSensitiveKey(t *testing.T) {
 	}{
 		{"github_token", true},
 		{"cloudflare_tunnel_token", true},
+		{"ntfy_token", true},
 		{"clone_directory", false},
 		{"random_setting", false},
 		{"", false},
@@ -0,0 +1,94 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// sealedMagic prefixes data sealed with a passphrase
+var sealedMagic = []byte("SCHOONER1")
+
+const saltSize = 16
+
+var (
+	// ErrWrongPassphrase is returned when sealed data can't be opened with
+	// the given passphrase
+	ErrWrongPassphrase = errors.New("wrong passphrase or corrupted data")
+)
+
+// SealWithPassphrase encrypts data with a key derived from a passphrase. The
+// output holds everything but the passphrase needed to open it.
+func SealWithPassphrase(data []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase is required")
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	gcm, err := passphraseGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	out := make([]byte, 0, len(sealedMagic)+saltSize+len(nonce)+len(data)+gcm.Overhead())
+	out = append(out, sealedMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, data, sealedMagic), nil
+}
+
+// OpenWithPassphrase decrypts data sealed by SealWithPassphrase
+func OpenWithPassphrase(sealed []byte, passphrase string) ([]byte, error) {
+	if !bytes.HasPrefix(sealed, sealedMagic) {
+		return nil, ErrInvalidData
+	}
+	sealed = sealed[len(sealedMagic):]
+	if len(sealed) < saltSize {
+		return nil, ErrInvalidData
+	}
+	salt, sealed := sealed[:saltSize], sealed[saltSize:]
+
+	gcm, err := passphraseGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrInvalidData
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+
+	data, err := gcm.Open(nil, nonce, ciphertext, sealedMagic)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return data, nil
+}
+
+// passphraseGCM derives an AES-256-GCM cipher from a passphrase and salt
+func passphraseGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
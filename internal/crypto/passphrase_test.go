@@ -0,0 +1,52 @@
+package crypto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSealWithPassphraseRoundTrip(t *testing.T) {
+	data := []byte("SQLite format 3\x00 and more")
+
+	sealed, err := SealWithPassphrase(data, "correct horse")
+	if err != nil {
+		t.Fatalf("SealWithPassphrase() error = %v", err)
+	}
+	if bytes.Contains(sealed, data) {
+		t.Error("sealed data should not contain the plaintext")
+	}
+
+	opened, err := OpenWithPassphrase(sealed, "correct horse")
+	if err != nil {
+		t.Fatalf("OpenWithPassphrase() error = %v", err)
+	}
+	if !bytes.Equal(opened, data) {
+		t.Errorf("OpenWithPassphrase() = %q, want %q", opened, data)
+	}
+}
+
+func TestOpenWithPassphraseErrors(t *testing.T) {
+	sealed, err := SealWithPassphrase([]byte("secret"), "correct horse")
+	if err != nil {
+		t.Fatalf("SealWithPassphrase() error = %v", err)
+	}
+
+	if _, err := OpenWithPassphrase(sealed, "battery staple"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("wrong passphrase error = %v, want ErrWrongPassphrase", err)
+	}
+
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := OpenWithPassphrase(tampered, "correct horse"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("tampered data error = %v, want ErrWrongPassphrase", err)
+	}
+
+	if _, err := OpenWithPassphrase([]byte("not sealed"), "correct horse"); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("unsealed data error = %v, want ErrInvalidData", err)
+	}
+
+	if _, err := SealWithPassphrase([]byte("secret"), ""); err == nil {
+		t.Error("SealWithPassphrase() should require a passphrase")
+	}
+}
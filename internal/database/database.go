@@ -3,19 +3,22 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 // DB wraps sqlx.DB with additional functionality
 type DB struct {
 	*sqlx.DB
+	postgres bool
 }
 
 // New creates a new database connection
@@ -26,8 +29,10 @@ func New(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
 	}
 
-	// Build connection string with pragmas
-	connStr := fmt.Sprintf("%s?_journal_mode=WAL&_busy_timeout=5000&_synchronous=NORMAL&_cache_size=1000000000&_foreign_keys=ON", dbPath)
+	// Build connection string with pragmas. Transactions take the write lock
+	// when they begin, as a read lock upgraded later fails at once with
+	// "database is locked" instead of waiting out the busy timeout.
+	connStr := fmt.Sprintf("%s?_journal_mode=WAL&_busy_timeout=5000&_synchronous=NORMAL&_cache_size=1000000000&_foreign_keys=ON&_txlock=immediate", dbPath)
 
 	db, err := sqlx.Connect("sqlite3", connStr)
 	if err != nil {
@@ -35,7 +40,9 @@ func New(dbPath string) (*DB, error) {
 	}
 
 	// Configure connection pool for SQLite
-	// SQLite only supports one writer at a time
+	// SQLite only supports one writer at a time, so concurrent builds queue
+	// for the single connection rather than contend for the file's lock. The
+	// busy timeout covers other processes, such as the sqlite3 shell.
 	db.SetMaxOpenConns(1)
 	db.SetMaxIdleConns(1)
 	db.SetConnMaxLifetime(time.Hour)
@@ -47,6 +54,14 @@ func New(dbPath string) (*DB, error) {
 func (db *DB) Migrate() error {
 	slog.Info("running database migrations")
 
+	if db.postgres {
+		unlock, err := db.lockMigrations()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
 	// Initial schema - creates all tables
 	schema := `
 -- Enable WAL mode for better concurrency
@@ -63,7 +78,7 @@ CREATE TABLE IF NOT EXISTS apps (
     repo_url TEXT NOT NULL,
     branch TEXT NOT NULL DEFAULT 'main',
     webhook_secret TEXT,
-    build_strategy TEXT NOT NULL CHECK(build_strategy IN ('dockerfile', 'compose', 'autodetect')),
+    build_strategy TEXT NOT NULL CHECK(build_strategy IN ('dockerfile', 'kaniko', 'compose', 'autodetect')),
     dockerfile_path TEXT DEFAULT 'Dockerfile',
     compose_file TEXT DEFAULT 'docker-compose.yaml',
     build_context TEXT DEFAULT '.',
@@ -81,8 +96,8 @@ CREATE TABLE IF NOT EXISTS apps (
 CREATE TABLE IF NOT EXISTS builds (
     id TEXT PRIMARY KEY,
     app_id TEXT NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
-    status TEXT NOT NULL CHECK(status IN ('pending', 'cloning', 'building', 'pushing', 'deploying', 'success', 'failed', 'cancelled')),
-    trigger TEXT NOT NULL CHECK(trigger IN ('webhook', 'manual', 'rollback')),
+    status TEXT NOT NULL CHECK(status IN ('pending', 'cloning', 'building', 'pushing', 'deploying', 'waiting_approval', 'scheduled', 'success', 'failed', 'cancelled')),
+    trigger TEXT NOT NULL CHECK(trigger IN ('webhook', 'manual', 'config', 'reconcile', 'refresh', 'rollback')),
     commit_sha TEXT,
     commit_message TEXT,
     commit_author TEXT,
@@ -104,6 +119,19 @@ CREATE TABLE IF NOT EXISTS build_logs (
     source TEXT CHECK(source IN ('git', 'docker', 'deploy', 'system') OR source IS NULL)
 );
 
+-- Build steps table (pipeline steps of a build, in order)
+CREATE TABLE IF NOT EXISTS build_steps (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    build_id TEXT NOT NULL REFERENCES builds(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    command TEXT,
+    status TEXT NOT NULL CHECK(status IN ('pending', 'running', 'success', 'failed', 'skipped')),
+    error_message TEXT,
+    started_at DATETIME,
+    finished_at DATETIME
+);
+
 -- Deployments table
 CREATE TABLE IF NOT EXISTS deployments (
     id TEXT PRIMARY KEY,
@@ -125,16 +153,269 @@ CREATE TABLE IF NOT EXISTS settings (
     updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Alert rules (evaluated by the alerting worker)
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL CHECK(type IN ('container_down', 'cpu_high', 'disk_high', 'build_failures')),
+    app_id TEXT REFERENCES apps(id) ON DELETE CASCADE,
+    threshold REAL NOT NULL DEFAULT 0,
+    duration_seconds INTEGER NOT NULL DEFAULT 0,
+    cooldown_seconds INTEGER NOT NULL DEFAULT 3600,
+    enabled INTEGER NOT NULL DEFAULT 1,
+    last_fired_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Uptime checks (one HTTP probe per app)
+CREATE TABLE IF NOT EXISTS uptime_checks (
+    id TEXT PRIMARY KEY,
+    app_id TEXT NOT NULL UNIQUE REFERENCES apps(id) ON DELETE CASCADE,
+    url TEXT,
+    interval_seconds INTEGER NOT NULL DEFAULT 60,
+    timeout_seconds INTEGER NOT NULL DEFAULT 10,
+    expected_status INTEGER NOT NULL DEFAULT 0,
+    enabled INTEGER NOT NULL DEFAULT 1,
+    last_status TEXT NOT NULL DEFAULT 'unknown' CHECK(last_status IN ('unknown', 'up', 'down')),
+    last_checked_at DATETIME,
+    last_response_ms INTEGER,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Uptime check results (response-time history)
+CREATE TABLE IF NOT EXISTS uptime_results (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    check_id TEXT NOT NULL REFERENCES uptime_checks(id) ON DELETE CASCADE,
+    up INTEGER NOT NULL,
+    status_code INTEGER,
+    response_ms INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    checked_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Personal API tokens (only the SHA-256 hash is stored)
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at DATETIME,
+    expires_at DATETIME
+);
+
+-- Dashboard sessions (id is the SHA-256 of the cookie token)
+CREATE TABLE IF NOT EXISTS sessions (
+    id TEXT PRIMARY KEY,
+    username TEXT NOT NULL,
+    avatar_url TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    mfa_state INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL
+);
+
+-- DNS records created by Schooner, removed once no longer routed
+CREATE TABLE IF NOT EXISTS dns_records (
+    hostname TEXT PRIMARY KEY,
+    zone_id TEXT NOT NULL,
+    record_id TEXT NOT NULL,
+    tunnel TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Managed database and cache containers provisioned for apps
+CREATE TABLE IF NOT EXISTS addons (
+    id TEXT PRIMARY KEY,
+    app_id TEXT NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    type TEXT NOT NULL CHECK(type IN ('postgres', 'mysql', 'redis')),
+    name TEXT NOT NULL,
+    image TEXT NOT NULL,
+    container_name TEXT NOT NULL UNIQUE,
+    env_var TEXT NOT NULL,
+    username TEXT NOT NULL,
+    password TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(app_id, name),
+    UNIQUE(app_id, env_var)
+);
+
+-- Volume backup jobs (scheduled or on demand)
+CREATE TABLE IF NOT EXISTS backup_jobs (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    volumes TEXT NOT NULL,
+    schedule TEXT NOT NULL DEFAULT '',
+    keep_last INTEGER NOT NULL DEFAULT 7,
+    target TEXT NOT NULL DEFAULT 'local' CHECK(target IN ('local', 's3')),
+    enabled INTEGER NOT NULL DEFAULT 1,
+    last_run_at DATETIME,
+    last_error TEXT,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Volume backup archives
+CREATE TABLE IF NOT EXISTS backups (
+    id TEXT PRIMARY KEY,
+    job_id TEXT REFERENCES backup_jobs(id) ON DELETE SET NULL,
+    volume TEXT NOT NULL,
+    target TEXT NOT NULL CHECK(target IN ('local', 's3')),
+    key TEXT NOT NULL,
+    size_bytes INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'running' CHECK(status IN ('running', 'success', 'failed')),
+    error TEXT,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at DATETIME
+);
+
+-- Encrypted dumps of this database
+CREATE TABLE IF NOT EXISTS system_backups (
+    id TEXT PRIMARY KEY,
+    target TEXT NOT NULL CHECK(target IN ('local', 's3')),
+    key TEXT NOT NULL,
+    size_bytes INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'running' CHECK(status IN ('running', 'success', 'failed')),
+    error TEXT,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at DATETIME
+);
+
+-- Remote Docker daemons apps can be deployed to
+CREATE TABLE IF NOT EXISTS docker_hosts (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    url TEXT NOT NULL,
+    tls_cert_path TEXT NOT NULL DEFAULT '',
+    ssh_key_path TEXT NOT NULL DEFAULT '',
+    ssh_host_key TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Servers running schooner-agent that apps can be deployed to
+CREATE TABLE IF NOT EXISTS agents (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    token_prefix TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    hostname TEXT NOT NULL DEFAULT '',
+    version TEXT NOT NULL DEFAULT '',
+    last_seen_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Docker housekeeping runs and the space they reclaimed
+CREATE TABLE IF NOT EXISTS maintenance_runs (
+    id TEXT PRIMARY KEY,
+    triggered_by TEXT NOT NULL CHECK(triggered_by IN ('scheduled', 'manual', 'disk_pressure')),
+    status TEXT NOT NULL DEFAULT 'running' CHECK(status IN ('running', 'success', 'failed')),
+    images_removed INTEGER NOT NULL DEFAULT 0,
+    volumes_removed INTEGER NOT NULL DEFAULT 0,
+    networks_removed INTEGER NOT NULL DEFAULT 0,
+    space_reclaimed INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at DATETIME
+);
+
+-- Resource usage of app containers (minute samples are rolled up into
+-- hourly ones after a day)
+CREATE TABLE IF NOT EXISTS resource_samples (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    app_id TEXT NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    resolution TEXT NOT NULL DEFAULT 'minute' CHECK(resolution IN ('minute', 'hour')),
+    cpu_percent REAL NOT NULL DEFAULT 0,
+    memory_usage INTEGER NOT NULL DEFAULT 0,
+    memory_limit INTEGER NOT NULL DEFAULT 0,
+    restart_count INTEGER NOT NULL DEFAULT 0,
+    sampled_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Host CPU, memory and disk use, rolled up like resource_samples
+CREATE TABLE IF NOT EXISTS system_samples (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    resolution TEXT NOT NULL DEFAULT 'minute' CHECK(resolution IN ('minute', 'hour')),
+    cpu_percent REAL NOT NULL DEFAULT 0,
+    load_avg_1 REAL NOT NULL DEFAULT 0,
+    memory_used INTEGER NOT NULL DEFAULT 0,
+    memory_total INTEGER NOT NULL DEFAULT 0,
+    disk_used INTEGER NOT NULL DEFAULT 0,
+    disk_total INTEGER NOT NULL DEFAULT 0,
+    sampled_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Health check state of app containers, with how often it flapped and the
+-- watchdog restarted them
+CREATE TABLE IF NOT EXISTS container_health (
+    app_id TEXT PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    health TEXT NOT NULL DEFAULT '',
+    flaps INTEGER NOT NULL DEFAULT 0,
+    restarts INTEGER NOT NULL DEFAULT 0,
+    last_flap_at DATETIME,
+    last_restart_at DATETIME,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS app_config_revisions (
+    id TEXT PRIMARY KEY,
+    app_id TEXT NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    snapshot TEXT NOT NULL,
+    changed_by TEXT,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(app_id, revision)
+);
+
+-- Apps that must be running before another app starts
+CREATE TABLE IF NOT EXISTS app_dependencies (
+    app_id TEXT NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    depends_on_id TEXT NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    PRIMARY KEY (app_id, depends_on_id)
+);
+
+-- Host ports apps publish on, one app per port of each machine. host is
+-- "agent:" and the agent ID, a remote Docker host ID, or empty for the local
+-- daemon.
+CREATE TABLE IF NOT EXISTS port_allocations (
+    host TEXT NOT NULL DEFAULT '',
+    port INTEGER NOT NULL,
+    app_id TEXT NOT NULL UNIQUE REFERENCES apps(id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (host, port)
+);
+
+-- What Schooner has been doing, for the dashboard's activity feed. Entries
+-- outlive the apps and builds they mention.
+CREATE TABLE IF NOT EXISTS activity (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL,
+    app_id TEXT NOT NULL DEFAULT '',
+    build_id TEXT NOT NULL DEFAULT '',
+    message TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
 -- Indexes
 CREATE INDEX IF NOT EXISTS idx_builds_app_id ON builds(app_id);
 CREATE INDEX IF NOT EXISTS idx_builds_status ON builds(status);
 CREATE INDEX IF NOT EXISTS idx_builds_created_at ON builds(created_at DESC);
 CREATE INDEX IF NOT EXISTS idx_build_logs_build_id ON build_logs(build_id);
+CREATE INDEX IF NOT EXISTS idx_build_steps_build_id ON build_steps(build_id);
 CREATE INDEX IF NOT EXISTS idx_deployments_app_id ON deployments(app_id);
+CREATE INDEX IF NOT EXISTS idx_uptime_results_check_id ON uptime_results(check_id, checked_at DESC);
+CREATE INDEX IF NOT EXISTS idx_backups_volume ON backups(volume, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_resource_samples_app_id ON resource_samples(app_id, sampled_at);
+CREATE INDEX IF NOT EXISTS idx_system_samples_sampled_at ON system_samples(sampled_at);
 `
 
 	// Run migrations
-	_, err := db.Exec(schema)
+	_, err := db.Exec(db.ddl(schema))
 	if err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
@@ -143,16 +424,208 @@ CREATE INDEX IF NOT EXISTS idx_deployments_app_id ON deployments(app_id);
 	alterStatements := []string{
 		"ALTER TABLE apps ADD COLUMN subdomain TEXT",
 		"ALTER TABLE apps ADD COLUMN public_port INTEGER",
+		"ALTER TABLE apps ADD COLUMN route_path TEXT",
+		"ALTER TABLE apps ADD COLUMN protected BOOLEAN NOT NULL DEFAULT 0",
+		"ALTER TABLE apps ADD COLUMN access_allow TEXT",
+		"ALTER TABLE apps ADD COLUMN tunnel TEXT",
+		"ALTER TABLE apps ADD COLUMN basic_auth_user TEXT",
+		"ALTER TABLE apps ADD COLUMN basic_auth_hash TEXT",
+		"ALTER TABLE apps ADD COLUMN template TEXT",
+		"ALTER TABLE apps ADD COLUMN compose_spec TEXT",
+		"ALTER TABLE apps ADD COLUMN docker_host TEXT",
+		"ALTER TABLE apps ADD COLUMN agent_id TEXT",
+		"ALTER TABLE apps ADD COLUMN submodules BOOLEAN NOT NULL DEFAULT 0",
+		"ALTER TABLE apps ADD COLUMN lfs BOOLEAN NOT NULL DEFAULT 0",
+		"ALTER TABLE apps ADD COLUMN tag_pattern TEXT",
+		"ALTER TABLE apps ADD COLUMN compose_profiles TEXT",
+		"ALTER TABLE apps ADD COLUMN test_command TEXT",
+		"ALTER TABLE apps ADD COLUMN require_approval BOOLEAN NOT NULL DEFAULT 0",
+		"ALTER TABLE apps ADD COLUMN deploy_schedule TEXT",
+		"ALTER TABLE apps ADD COLUMN debounce_seconds INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE apps ADD COLUMN project TEXT",
+		"ALTER TABLE apps ADD COLUMN tags TEXT",
+		"ALTER TABLE apps ADD COLUMN isolated_build BOOLEAN NOT NULL DEFAULT 0",
+		"ALTER TABLE apps ADD COLUMN config_revision INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE apps ADD COLUMN deployed_config_revision INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE apps ADD COLUMN stopped BOOLEAN NOT NULL DEFAULT 0",
+		"ALTER TABLE apps ADD COLUMN image_tag_strategy TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE apps ADD COLUMN artifact_paths TEXT",
+		"ALTER TABLE apps ADD COLUMN build_caches TEXT",
+		"ALTER TABLE apps ADD COLUMN release_events BOOLEAN NOT NULL DEFAULT 0",
+		"ALTER TABLE apps ADD COLUMN allowed_branches TEXT",
+		"ALTER TABLE apps ADD COLUMN image_refresh TEXT",
+		"ALTER TABLE builds ADD COLUMN tag TEXT",
+		"ALTER TABLE builds ADD COLUMN image_digests TEXT",
+		"ALTER TABLE builds ADD COLUMN pinned_build_id TEXT",
+		"ALTER TABLE builds ADD COLUMN approved_by TEXT",
+		"ALTER TABLE builds ADD COLUMN approved_at DATETIME",
+		"ALTER TABLE builds ADD COLUMN image_id TEXT",
+		"ALTER TABLE builds ADD COLUMN image_digest TEXT",
+		"ALTER TABLE builds ADD COLUMN base_image TEXT",
+		"ALTER TABLE builds ADD COLUMN builder TEXT",
+		"ALTER TABLE builds ADD COLUMN build_seconds INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE builds ADD COLUMN release_name TEXT",
+		"ALTER TABLE builds ADD COLUMN release_notes TEXT",
+		"ALTER TABLE builds ADD COLUMN release_url TEXT",
+		"ALTER TABLE builds ADD COLUMN triggered_by TEXT",
+		"ALTER TABLE sessions ADD COLUMN csrf_token TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE build_logs ADD COLUMN styles TEXT",
+		"ALTER TABLE build_logs ADD COLUMN progress TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE build_logs ADD COLUMN stream TEXT NOT NULL DEFAULT ''",
 	}
 
 	for _, stmt := range alterStatements {
-		_, _ = db.Exec(stmt) // Ignore errors - column may already exist
+		_, _ = db.Exec(db.ddl(stmt)) // Ignore errors - column may already exist
+	}
+
+	// Allow new values in CHECK constraints of existing databases
+	if err := db.addCheckValue("builds", "deploying", "waiting_approval"); err != nil {
+		return err
+	}
+	if err := db.addCheckValue("builds", "waiting_approval", "scheduled"); err != nil {
+		return err
+	}
+	if err := db.addCheckValue("apps", "dockerfile", "kaniko"); err != nil {
+		return err
+	}
+	if err := db.addCheckValue("builds", "manual", "config"); err != nil {
+		return err
+	}
+	if err := db.addCheckValue("builds", "config", "reconcile"); err != nil {
+		return err
+	}
+	if err := db.addCheckValue("builds", "reconcile", "refresh"); err != nil {
+		return err
+	}
+
+	// Allocate the ports of apps saved before allocations were tracked. Of
+	// apps already sharing a port, the first keeps it.
+	backfill := `
+		INSERT INTO port_allocations (host, port, app_id)
+		SELECT CASE WHEN agent_id IS NOT NULL AND agent_id != '' THEN 'agent:' || agent_id ELSE COALESCE(docker_host, '') END,
+			public_port, id
+		FROM apps
+		WHERE public_port > 0 AND id NOT IN (SELECT app_id FROM port_allocations)
+		ORDER BY created_at
+		ON CONFLICT DO NOTHING`
+	if _, err := db.Exec(backfill); err != nil {
+		return fmt.Errorf("failed to allocate app ports: %w", err)
 	}
 
 	slog.Info("database migrations completed")
 	return nil
 }
 
+// addCheckValue adds value to the list a CHECK constraint of table allows,
+// right after the existing value after. SQLite can't alter constraints, so
+// the table is copied into a new one with the constraint widened.
+func (db *DB) addCheckValue(table, after, value string) error {
+	if db.postgres {
+		return db.addPostgresCheckValue(table, after, value)
+	}
+	ctx := context.Background()
+
+	var createSQL string
+	if err := db.GetContext(ctx, &createSQL, `SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?`, table); err != nil {
+		return fmt.Errorf("failed to read %s schema: %w", table, err)
+	}
+	if strings.Contains(createSQL, "'"+value+"'") {
+		return nil
+	}
+	old := "'" + after + "', "
+	if !strings.Contains(createSQL, old) {
+		return fmt.Errorf("failed to migrate %s: no CHECK value %q", table, after)
+	}
+	createSQL = strings.Replace(createSQL, old, old+"'"+value+"', ", 1)
+
+	var indexes []string
+	if err := db.SelectContext(ctx, &indexes, `SELECT sql FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL`, table); err != nil {
+		return fmt.Errorf("failed to read %s indexes: %w", table, err)
+	}
+
+	slog.Info("migrating table constraint", "table", table, "value", value)
+
+	// Dropping the old table mustn't cascade to the rows referencing it, and
+	// foreign keys can only be turned off outside a transaction
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return fmt.Errorf("failed to disable foreign keys: %w", err)
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	tmp := table + "_migrating"
+	stmts := []string{
+		strings.Replace(createSQL, table, tmp, 1),
+		fmt.Sprintf("INSERT INTO %s SELECT * FROM %s", tmp, table),
+		fmt.Sprintf("DROP TABLE %s", table),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", tmp, table),
+	}
+	for _, stmt := range append(stmts, indexes...) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to migrate %s: %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration of %s: %w", table, err)
+	}
+	return nil
+}
+
+// IsPostgres reports whether the database is Postgres rather than SQLite
+func (db *DB) IsPostgres() bool {
+	return db.postgres
+}
+
+// ddl translates schema statements written for SQLite to the database's
+// dialect
+func (db *DB) ddl(stmt string) string {
+	if db.postgres {
+		return postgresSchema(stmt)
+	}
+	return stmt
+}
+
+// lockMigrations waits until no other instance is migrating the database and
+// returns how to let the next one go ahead
+func (db *DB) lockMigrations() (func(), error) {
+	ctx := context.Background()
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock(?)`, migrationLock); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	return func() {
+		conn.ExecContext(ctx, `SELECT pg_advisory_unlock(?)`, migrationLock)
+		conn.Close()
+	}, nil
+}
+
+// Snapshot writes a consistent copy of the database to path, which must not
+// exist yet. Postgres databases are backed up with pg_dump instead.
+func (db *DB) Snapshot(ctx context.Context, path string) error {
+	if db.postgres {
+		return fmt.Errorf("failed to snapshot database: Postgres databases are backed up with pg_dump")
+	}
+	if _, err := db.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	return nil
+}
+
 // WithTx executes a function within a transaction
 func (db *DB) WithTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
 	tx, err := db.BeginTxx(ctx, nil)
@@ -174,6 +647,13 @@ func (db *DB) WithTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
 	return nil
 }
 
+// IsBusy reports whether err is SQLite giving up on a lock that another
+// process held for longer than the busy timeout
+func IsBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked)
+}
+
 // NullString creates a sql.NullString from a string
 func NullString(s string) sql.NullString {
 	if s == "" {
@@ -1,9 +1,15 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/mattn/go-sqlite3"
 )
 
 func TestNullString(t *testing.T) {
@@ -67,3 +73,124 @@ func TestNullTime(t *testing.T) {
 		})
 	}
 }
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	db, err := New(filepath.Join(dir, "schooner.db"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer db.Close()
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO settings (key, value) VALUES ('clone_directory', '/srv')`); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "snapshot.db")
+	if err := db.Snapshot(context.Background(), path); err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+
+	snapshot, err := New(path)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer snapshot.Close()
+
+	var value string
+	if err := snapshot.Get(&value, `SELECT value FROM settings WHERE key = 'clone_directory'`); err != nil || value != "/srv" {
+		t.Errorf("snapshot setting = %q, %v", value, err)
+	}
+}
+
+func TestAddCheckValue(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "schooner.db"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer db.Close()
+
+	setup := []string{
+		`CREATE TABLE widgets (id TEXT PRIMARY KEY, state TEXT NOT NULL CHECK(state IN ('new', 'done')))`,
+		`CREATE INDEX idx_widgets_state ON widgets(state)`,
+		`CREATE TABLE parts (id INTEGER PRIMARY KEY, widget_id TEXT NOT NULL REFERENCES widgets(id) ON DELETE CASCADE)`,
+		`ALTER TABLE widgets ADD COLUMN note TEXT`,
+		`INSERT INTO widgets (id, state, note) VALUES ('w1', 'done', 'kept')`,
+		`INSERT INTO parts (widget_id) VALUES ('w1')`,
+	}
+	for _, stmt := range setup {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := db.Exec(`INSERT INTO widgets (id, state) VALUES ('w2', 'held')`); err == nil {
+		t.Fatal("insert of new value succeeded before migration")
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := db.addCheckValue("widgets", "new", "held"); err != nil {
+			t.Fatalf("addCheckValue() error = %v", err)
+		}
+	}
+
+	if _, err := db.Exec(`INSERT INTO widgets (id, state) VALUES ('w2', 'held')`); err != nil {
+		t.Errorf("insert of new value after migration: %v", err)
+	}
+	var note string
+	if err := db.Get(&note, `SELECT note FROM widgets WHERE id = 'w1'`); err != nil || note != "kept" {
+		t.Errorf("migrated row note = %q, %v", note, err)
+	}
+	var parts int
+	if err := db.Get(&parts, `SELECT COUNT(*) FROM parts`); err != nil || parts != 1 {
+		t.Errorf("parts after migration = %d, %v", parts, err)
+	}
+	var indexes int
+	if err := db.Get(&indexes, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = 'idx_widgets_state'`); err != nil || indexes != 1 {
+		t.Errorf("index after migration = %d, %v", indexes, err)
+	}
+	if _, err := db.Exec(`INSERT INTO parts (widget_id) VALUES ('missing')`); err == nil {
+		t.Error("foreign keys not enforced after migration")
+	}
+}
+
+func TestHealth(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "schooner.db"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	h := db.Health(context.Background())
+	if h.Status != "ok" || h.Driver != "sqlite" || h.JournalMode != "wal" || h.OpenConnections != 1 {
+		t.Errorf("Health() = %+v, want ok in WAL mode", h)
+	}
+
+	db.Close()
+	if h := db.Health(context.Background()); h.Status != "error" || h.Error == "" {
+		t.Errorf("Health() of closed database = %+v, want error", h)
+	}
+}
+
+func TestIsBusy(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "busy", err: fmt.Errorf("failed to append logs: %w", sqlite3.Error{Code: sqlite3.ErrBusy}), want: true},
+		{name: "locked", err: sqlite3.Error{Code: sqlite3.ErrLocked}, want: true},
+		{name: "constraint", err: sqlite3.Error{Code: sqlite3.ErrConstraint}},
+		{name: "other", err: errors.New("database is locked")},
+		{name: "nil"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsBusy(tt.err); got != tt.want {
+				t.Errorf("IsBusy(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// Health is how the database connection is doing, for /healthz
+type Health struct {
+	Status          string `json:"status"` // ok or error
+	Driver          string `json:"driver"`
+	JournalMode     string `json:"journal_mode,omitempty"` // wal unless SQLite couldn't enable it
+	PingMS          int64  `json:"ping_ms"`
+	OpenConnections int    `json:"open_connections"`
+	InUse           int    `json:"in_use"`
+	WaitCount       int64  `json:"wait_count"` // Queries that queued for a connection since startup
+	WaitMS          int64  `json:"wait_ms"`    // Time they spent queued in total
+	Error           string `json:"error,omitempty"`
+}
+
+// Health pings the database and reports its connection pool. A SQLite
+// database that isn't in WAL mode counts as unhealthy, as readers then
+// block the writer.
+func (db *DB) Health(ctx context.Context) Health {
+	stats := db.Stats()
+	h := Health{
+		Status:          "ok",
+		Driver:          "sqlite",
+		OpenConnections: stats.OpenConnections,
+		InUse:           stats.InUse,
+		WaitCount:       stats.WaitCount,
+		WaitMS:          stats.WaitDuration.Milliseconds(),
+	}
+	if db.postgres {
+		h.Driver = "postgres"
+	}
+
+	start := time.Now()
+	if err := db.PingContext(ctx); err != nil {
+		h.Status = "error"
+		h.Error = err.Error()
+		return h
+	}
+	h.PingMS = time.Since(start).Milliseconds()
+
+	if db.postgres {
+		return h
+	}
+	if err := db.GetContext(ctx, &h.JournalMode, `PRAGMA journal_mode`); err != nil {
+		h.Status = "error"
+		h.Error = err.Error()
+		return h
+	}
+	if h.JournalMode != "wal" {
+		h.Status = "error"
+		h.Error = "database is not in WAL mode"
+	}
+	return h
+}
@@ -0,0 +1,203 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// postgresDriver is lib/pq behind the SQLite dialect the queries are
+// written in
+const postgresDriver = "schooner-postgres"
+
+// migrationLock keeps a restarting instance from migrating the database
+// while the one it replaces still is
+const migrationLock = 7_231_004
+
+func init() {
+	sql.Register(postgresDriver, postgresDialect{})
+}
+
+// NewPostgres connects to a Postgres database. Only one Schooner instance
+// may use it: startup cancels builds left running, sessions are kept in
+// memory and the background loops don't coordinate with other instances.
+func NewPostgres(url string) (*DB, error) {
+	db, err := sqlx.Connect(postgresDriver, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(time.Hour)
+
+	return &DB{DB: db, postgres: true}, nil
+}
+
+var (
+	pragmaPattern    = regexp.MustCompile(`(?m)^PRAGMA .*;\n`)
+	likePattern      = regexp.MustCompile(`\bLIKE\b`)
+	julianDayPattern = regexp.MustCompile(`julianday\(([^()]*)\)`)
+)
+
+// postgresSchema translates SQLite DDL. Booleans stay integers, as that's
+// what the queries compare them with.
+func postgresSchema(ddl string) string {
+	ddl = pragmaPattern.ReplaceAllString(ddl, "")
+	return strings.NewReplacer(
+		"INTEGER PRIMARY KEY AUTOINCREMENT", "BIGSERIAL PRIMARY KEY",
+		"INTEGER", "BIGINT",
+		"BOOLEAN", "BIGINT",
+		"DATETIME", "TIMESTAMPTZ",
+		"REAL", "DOUBLE PRECISION",
+		"ADD COLUMN", "ADD COLUMN IF NOT EXISTS",
+	).Replace(ddl)
+}
+
+// postgresQuery translates a query: ? placeholders outside string literals
+// are numbered, LIKE ignores case like SQLite's and julianday() differences
+// are computed from epochs
+func postgresQuery(query string) string {
+	query = likePattern.ReplaceAllString(query, "ILIKE")
+	query = julianDayPattern.ReplaceAllString(query, "(EXTRACT(EPOCH FROM $1) / 86400.0)")
+
+	var b strings.Builder
+	n, quoted := 0, false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == '?' && !quoted:
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// postgresArgs converts arguments to what the schema stores: booleans as 0
+// or 1 and bytes, such as JSON documents, as text
+func postgresArgs(args []driver.NamedValue) []driver.NamedValue {
+	converted := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		switch v := arg.Value.(type) {
+		case bool:
+			arg.Value = int64(0)
+			if v {
+				arg.Value = int64(1)
+			}
+		case []byte:
+			arg.Value = string(v)
+		}
+		converted[i] = arg
+	}
+	return converted
+}
+
+// postgresDialect opens lib/pq connections that translate each query
+type postgresDialect struct{}
+
+func (postgresDialect) Open(dsn string) (driver.Conn, error) {
+	conn, err := pq.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &postgresConn{Conn: conn}, nil
+}
+
+type postgresConn struct {
+	driver.Conn
+}
+
+func (c *postgresConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *postgresConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.(driver.ConnPrepareContext).PrepareContext(ctx, postgresQuery(query))
+	if err != nil {
+		return nil, err
+	}
+	return &postgresStmt{Stmt: stmt}, nil
+}
+
+func (c *postgresConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.Conn.(driver.ExecerContext).ExecContext(ctx, postgresQuery(query), postgresArgs(args))
+}
+
+func (c *postgresConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.Conn.(driver.QueryerContext).QueryContext(ctx, postgresQuery(query), postgresArgs(args))
+}
+
+func (c *postgresConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+func (c *postgresConn) Ping(ctx context.Context) error {
+	return c.Conn.(driver.Pinger).Ping(ctx)
+}
+
+func (c *postgresConn) ResetSession(ctx context.Context) error {
+	return c.Conn.(driver.SessionResetter).ResetSession(ctx)
+}
+
+func (c *postgresConn) IsValid() bool {
+	return c.Conn.(driver.Validator).IsValid()
+}
+
+type postgresStmt struct {
+	driver.Stmt
+}
+
+func (s *postgresStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.Stmt.(driver.StmtExecContext).ExecContext(ctx, postgresArgs(args))
+}
+
+func (s *postgresStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.Stmt.(driver.StmtQueryContext).QueryContext(ctx, postgresArgs(args))
+}
+
+// addPostgresCheckValue widens a CHECK constraint in place. Postgres lists
+// the values of an IN as 'value'::text.
+func (db *DB) addPostgresCheckValue(table, after, value string) error {
+	ctx := context.Background()
+
+	var checks []struct {
+		Name       string `db:"conname"`
+		Definition string `db:"definition"`
+	}
+	if err := db.SelectContext(ctx, &checks, `
+		SELECT conname, pg_get_constraintdef(oid) AS definition
+		FROM pg_constraint WHERE conrelid = ?::regclass AND contype = 'c'`, table); err != nil {
+		return fmt.Errorf("failed to read %s constraints: %w", table, err)
+	}
+
+	old := "'" + after + "'::text"
+	for _, check := range checks {
+		if !strings.Contains(check.Definition, old) {
+			continue
+		}
+		if strings.Contains(check.Definition, "'"+value+"'::text") {
+			return nil
+		}
+		slog.Info("migrating table constraint", "table", table, "value", value)
+		definition := strings.Replace(check.Definition, old, old+", '"+value+"'::text", 1)
+		if _, err := db.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s DROP CONSTRAINT %s, ADD CONSTRAINT %s %s`,
+			table, check.Name, check.Name, definition)); err != nil {
+			return fmt.Errorf("failed to migrate %s: %w", table, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("failed to migrate %s: no CHECK value %q", table, after)
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+func TestPostgresQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "placeholders numbered",
+			query: `SELECT * FROM builds WHERE app_id = ? AND status IN (?, ?)`,
+			want:  `SELECT * FROM builds WHERE app_id = $1 AND status IN ($2, $3)`,
+		},
+		{
+			name:  "question marks in literals kept",
+			query: `SELECT '?' AS q FROM apps WHERE name = ?`,
+			want:  `SELECT '?' AS q FROM apps WHERE name = $1`,
+		},
+		{
+			name:  "LIKE ignores case",
+			query: `SELECT id FROM apps WHERE name LIKE ? ESCAPE '\'`,
+			want:  `SELECT id FROM apps WHERE name ILIKE $1 ESCAPE '\'`,
+		},
+		{
+			name:  "julianday",
+			query: `ORDER BY julianday(b.finished_at) - julianday(b.started_at) DESC`,
+			want:  `ORDER BY (EXTRACT(EPOCH FROM b.finished_at) / 86400.0) - (EXTRACT(EPOCH FROM b.started_at) / 86400.0) DESC`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := postgresQuery(tt.query); got != tt.want {
+				t.Errorf("postgresQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostgresSchema(t *testing.T) {
+	tests := []struct {
+		name string
+		ddl  string
+		want string
+	}{
+		{
+			name: "pragmas dropped",
+			ddl:  "PRAGMA foreign_keys=ON;\nCREATE TABLE t (id TEXT PRIMARY KEY);\n",
+			want: "CREATE TABLE t (id TEXT PRIMARY KEY);\n",
+		},
+		{
+			name: "types",
+			ddl:  "CREATE TABLE t (id INTEGER PRIMARY KEY AUTOINCREMENT, n INTEGER NOT NULL, cpu REAL, at DATETIME)",
+			want: "CREATE TABLE t (id BIGSERIAL PRIMARY KEY, n BIGINT NOT NULL, cpu DOUBLE PRECISION, at TIMESTAMPTZ)",
+		},
+		{
+			name: "added columns",
+			ddl:  "ALTER TABLE apps ADD COLUMN lfs BOOLEAN NOT NULL DEFAULT 0",
+			want: "ALTER TABLE apps ADD COLUMN IF NOT EXISTS lfs BIGINT NOT NULL DEFAULT 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := postgresSchema(tt.ddl); got != tt.want {
+				t.Errorf("postgresSchema() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostgresArgs(t *testing.T) {
+	args := postgresArgs([]driver.NamedValue{
+		{Ordinal: 1, Value: true},
+		{Ordinal: 2, Value: false},
+		{Ordinal: 3, Value: []byte(`{"replicas":2}`)},
+		{Ordinal: 4, Value: "web"},
+	})
+	want := []driver.Value{int64(1), int64(0), `{"replicas":2}`, "web"}
+	for i, arg := range args {
+		if arg.Value != want[i] || arg.Ordinal != i+1 {
+			t.Errorf("arg %d = %#v, want %#v", i, arg, want[i])
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"schooner/internal/models"
+)
+
+// activityKept is how many entries the activity feed keeps, dropping the
+// oldest as new ones come in
+const activityKept = 1000
+
+// ActivityQueries provides database operations for the activity feed
+type ActivityQueries struct {
+	db *sqlx.DB
+}
+
+// NewActivityQueries creates a new ActivityQueries instance
+func NewActivityQueries(db *sqlx.DB) *ActivityQueries {
+	return &ActivityQueries{db: db}
+}
+
+// Create adds an entry to the activity feed and drops those that fell off
+// its end
+func (q *ActivityQueries) Create(ctx context.Context, activity *models.Activity) error {
+	query := `
+		INSERT INTO activity (kind, app_id, build_id, message, created_at)
+		VALUES (:kind, :app_id, :build_id, :message, :created_at)`
+
+	if _, err := q.db.NamedExecContext(ctx, query, activity); err != nil {
+		return fmt.Errorf("failed to create activity: %w", err)
+	}
+
+	prune := `DELETE FROM activity WHERE id <= (SELECT MAX(id) FROM activity) - ?`
+	if _, err := q.db.ExecContext(ctx, prune, activityKept); err != nil {
+		return fmt.Errorf("failed to prune activity: %w", err)
+	}
+	return nil
+}
+
+// ListRecent retrieves the newest entries of the activity feed, newest
+// first
+func (q *ActivityQueries) ListRecent(ctx context.Context, limit int) ([]*models.Activity, error) {
+	var activity []*models.Activity
+	query := `SELECT * FROM activity ORDER BY id DESC LIMIT ?`
+
+	if err := q.db.SelectContext(ctx, &activity, query, limit); err != nil {
+		return nil, fmt.Errorf("failed to list activity: %w", err)
+	}
+	return activity, nil
+}
@@ -0,0 +1,126 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"schooner/internal/crypto"
+	"schooner/internal/models"
+)
+
+// AddonQueries provides database operations for app add-ons
+type AddonQueries struct {
+	db        *sqlx.DB
+	encryptor *crypto.Encryptor
+}
+
+// NewAddonQueries creates a new AddonQueries instance
+func NewAddonQueries(db *sqlx.DB) *AddonQueries {
+	encryptor, err := crypto.NewEncryptor()
+	if err != nil {
+		// Log but continue - passwords are stored unencrypted
+		fmt.Printf("Warning: encryption not available: %v\n", err)
+	}
+	return &AddonQueries{db: db, encryptor: encryptor}
+}
+
+// Create inserts a new add-on, encrypting its password
+func (q *AddonQueries) Create(ctx context.Context, addon *models.Addon) error {
+	stored := *addon
+	if q.encryptor != nil {
+		encrypted, err := q.encryptor.Encrypt(addon.Password)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt password: %w", err)
+		}
+		stored.Password = encrypted
+	}
+
+	query := `
+		INSERT INTO addons (
+			id, app_id, type, name, image, container_name, env_var,
+			username, password, created_at
+		) VALUES (
+			:id, :app_id, :type, :name, :image, :container_name, :env_var,
+			:username, :password, :created_at
+		)`
+
+	_, err := q.db.NamedExecContext(ctx, query, &stored)
+	if err != nil {
+		return fmt.Errorf("failed to create add-on: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an add-on by ID
+func (q *AddonQueries) GetByID(ctx context.Context, id string) (*models.Addon, error) {
+	var addon models.Addon
+	query := `SELECT * FROM addons WHERE id = ?`
+
+	err := q.db.GetContext(ctx, &addon, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get add-on: %w", err)
+	}
+
+	q.decrypt(&addon)
+	return &addon, nil
+}
+
+// ListByAppID retrieves an app's add-ons
+func (q *AddonQueries) ListByAppID(ctx context.Context, appID string) ([]*models.Addon, error) {
+	var addons []*models.Addon
+	query := `SELECT * FROM addons WHERE app_id = ? ORDER BY name`
+
+	err := q.db.SelectContext(ctx, &addons, query, appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list add-ons: %w", err)
+	}
+
+	for _, addon := range addons {
+		q.decrypt(addon)
+	}
+	return addons, nil
+}
+
+// ListByName retrieves the add-ons of every app with the given name
+func (q *AddonQueries) ListByName(ctx context.Context, name string) ([]*models.Addon, error) {
+	var addons []*models.Addon
+	query := `SELECT * FROM addons WHERE name = ? ORDER BY created_at`
+
+	err := q.db.SelectContext(ctx, &addons, query, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list add-ons: %w", err)
+	}
+
+	for _, addon := range addons {
+		q.decrypt(addon)
+	}
+	return addons, nil
+}
+
+// Delete removes an add-on
+func (q *AddonQueries) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM addons WHERE id = ?`
+
+	_, err := q.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete add-on: %w", err)
+	}
+	return nil
+}
+
+// decrypt replaces an add-on's stored password with the plaintext
+func (q *AddonQueries) decrypt(addon *models.Addon) {
+	if q.encryptor == nil || addon.Password == "" {
+		return
+	}
+	// If decryption fails the password was stored without encryption
+	if password, err := q.encryptor.Decrypt(addon.Password); err == nil {
+		addon.Password = password
+	}
+}
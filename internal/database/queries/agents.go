@@ -0,0 +1,104 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"schooner/internal/models"
+)
+
+// AgentQueries provides database operations for Schooner agents
+type AgentQueries struct {
+	db *sqlx.DB
+}
+
+// NewAgentQueries creates a new AgentQueries instance
+func NewAgentQueries(db *sqlx.DB) *AgentQueries {
+	return &AgentQueries{db: db}
+}
+
+// Create inserts a new agent
+func (q *AgentQueries) Create(ctx context.Context, agent *models.Agent) error {
+	query := `
+		INSERT INTO agents (id, name, token_prefix, token_hash, created_at)
+		VALUES (:id, :name, :token_prefix, :token_hash, :created_at)`
+
+	_, err := q.db.NamedExecContext(ctx, query, agent)
+	if err != nil {
+		return fmt.Errorf("failed to create agent: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an agent by ID
+func (q *AgentQueries) GetByID(ctx context.Context, id string) (*models.Agent, error) {
+	var agent models.Agent
+	query := `SELECT * FROM agents WHERE id = ?`
+
+	err := q.db.GetContext(ctx, &agent, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get agent: %w", err)
+	}
+	return &agent, nil
+}
+
+// GetByTokenHash retrieves the agent a token belongs to
+func (q *AgentQueries) GetByTokenHash(ctx context.Context, hash string) (*models.Agent, error) {
+	var agent models.Agent
+	query := `SELECT * FROM agents WHERE token_hash = ?`
+
+	err := q.db.GetContext(ctx, &agent, query, hash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get agent: %w", err)
+	}
+	return &agent, nil
+}
+
+// List retrieves all agents ordered by name
+func (q *AgentQueries) List(ctx context.Context) ([]*models.Agent, error) {
+	var agents []*models.Agent
+	query := `SELECT * FROM agents ORDER BY name`
+
+	if err := q.db.SelectContext(ctx, &agents, query); err != nil {
+		return nil, fmt.Errorf("failed to list agents: %w", err)
+	}
+	return agents, nil
+}
+
+// Touch records that an agent reported in, along with what it runs on
+func (q *AgentQueries) Touch(ctx context.Context, id, hostname, version string, at time.Time) error {
+	query := `UPDATE agents SET hostname = ?, version = ?, last_seen_at = ? WHERE id = ?`
+
+	if _, err := q.db.ExecContext(ctx, query, hostname, version, at, id); err != nil {
+		return fmt.Errorf("failed to update agent: %w", err)
+	}
+	return nil
+}
+
+// Delete removes an agent, revoking its token
+func (q *AgentQueries) Delete(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, `DELETE FROM agents WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete agent: %w", err)
+	}
+	return nil
+}
+
+// CountApps returns how many apps run on an agent
+func (q *AgentQueries) CountApps(ctx context.Context, id string) (int, error) {
+	var count int
+	if err := q.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM apps WHERE agent_id = ?`, id); err != nil {
+		return 0, fmt.Errorf("failed to count apps: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,126 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"schooner/internal/models"
+)
+
+// AlertQueries provides database operations for alert rules
+type AlertQueries struct {
+	db *sqlx.DB
+}
+
+// NewAlertQueries creates a new AlertQueries instance
+func NewAlertQueries(db *sqlx.DB) *AlertQueries {
+	return &AlertQueries{db: db}
+}
+
+// Create inserts a new alert rule
+func (q *AlertQueries) Create(ctx context.Context, rule *models.AlertRule) error {
+	query := `
+		INSERT INTO alert_rules (
+			id, name, type, app_id, threshold, duration_seconds,
+			cooldown_seconds, enabled, created_at, updated_at
+		) VALUES (
+			:id, :name, :type, :app_id, :threshold, :duration_seconds,
+			:cooldown_seconds, :enabled, :created_at, :updated_at
+		)`
+
+	_, err := q.db.NamedExecContext(ctx, query, rule)
+	if err != nil {
+		return fmt.Errorf("failed to create alert rule: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an alert rule by ID
+func (q *AlertQueries) GetByID(ctx context.Context, id string) (*models.AlertRule, error) {
+	var rule models.AlertRule
+	query := `SELECT * FROM alert_rules WHERE id = ?`
+
+	err := q.db.GetContext(ctx, &rule, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get alert rule: %w", err)
+	}
+
+	return &rule, nil
+}
+
+// List retrieves all alert rules
+func (q *AlertQueries) List(ctx context.Context) ([]*models.AlertRule, error) {
+	var rules []*models.AlertRule
+	query := `SELECT * FROM alert_rules ORDER BY name`
+
+	err := q.db.SelectContext(ctx, &rules, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alert rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// ListEnabled retrieves all enabled alert rules
+func (q *AlertQueries) ListEnabled(ctx context.Context) ([]*models.AlertRule, error) {
+	var rules []*models.AlertRule
+	query := `SELECT * FROM alert_rules WHERE enabled = 1 ORDER BY name`
+
+	err := q.db.SelectContext(ctx, &rules, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list enabled alert rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// Update updates an alert rule
+func (q *AlertQueries) Update(ctx context.Context, rule *models.AlertRule) error {
+	rule.UpdatedAt = time.Now()
+	query := `
+		UPDATE alert_rules SET
+			name = :name,
+			type = :type,
+			app_id = :app_id,
+			threshold = :threshold,
+			duration_seconds = :duration_seconds,
+			cooldown_seconds = :cooldown_seconds,
+			enabled = :enabled,
+			updated_at = :updated_at
+		WHERE id = :id`
+
+	_, err := q.db.NamedExecContext(ctx, query, rule)
+	if err != nil {
+		return fmt.Errorf("failed to update alert rule: %w", err)
+	}
+	return nil
+}
+
+// Delete removes an alert rule
+func (q *AlertQueries) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM alert_rules WHERE id = ?`
+
+	_, err := q.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+	return nil
+}
+
+// MarkFired records when a rule last sent a notification
+func (q *AlertQueries) MarkFired(ctx context.Context, id string, firedAt time.Time) error {
+	query := `UPDATE alert_rules SET last_fired_at = ? WHERE id = ?`
+
+	_, err := q.db.ExecContext(ctx, query, firedAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark alert rule fired: %w", err)
+	}
+	return nil
+}
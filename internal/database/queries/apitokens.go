@@ -0,0 +1,89 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"schooner/internal/models"
+)
+
+// APITokenQueries provides database operations for API tokens
+type APITokenQueries struct {
+	db *sqlx.DB
+}
+
+// NewAPITokenQueries creates a new APITokenQueries instance
+func NewAPITokenQueries(db *sqlx.DB) *APITokenQueries {
+	return &APITokenQueries{db: db}
+}
+
+// Create inserts a new API token
+func (q *APITokenQueries) Create(ctx context.Context, token *models.APIToken) error {
+	query := `
+		INSERT INTO api_tokens (
+			id, name, prefix, token_hash, scopes, created_by, created_at, expires_at
+		) VALUES (
+			:id, :name, :prefix, :token_hash, :scopes, :created_by, :created_at, :expires_at
+		)`
+
+	_, err := q.db.NamedExecContext(ctx, query, token)
+	if err != nil {
+		return fmt.Errorf("failed to create API token: %w", err)
+	}
+	return nil
+}
+
+// List retrieves all API tokens, newest first
+func (q *APITokenQueries) List(ctx context.Context) ([]*models.APIToken, error) {
+	var tokens []*models.APIToken
+	query := `SELECT * FROM api_tokens ORDER BY created_at DESC`
+
+	err := q.db.SelectContext(ctx, &tokens, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// GetByHash retrieves an API token by the hash of its plaintext value
+func (q *APITokenQueries) GetByHash(ctx context.Context, hash string) (*models.APIToken, error) {
+	var token models.APIToken
+	query := `SELECT * FROM api_tokens WHERE token_hash = ?`
+
+	err := q.db.GetContext(ctx, &token, query, hash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get API token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// TouchLastUsed records when a token was last used
+func (q *APITokenQueries) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	query := `UPDATE api_tokens SET last_used_at = ? WHERE id = ?`
+
+	_, err := q.db.ExecContext(ctx, query, at, id)
+	if err != nil {
+		return fmt.Errorf("failed to update API token last used: %w", err)
+	}
+	return nil
+}
+
+// Delete revokes an API token
+func (q *APITokenQueries) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM api_tokens WHERE id = ?`
+
+	_, err := q.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete API token: %w", err)
+	}
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -28,18 +30,38 @@ func (q *AppQueries) Create(ctx context.Context, app *models.App) error {
 			id, name, description, repo_url, branch, webhook_secret,
 			build_strategy, dockerfile_path, compose_file, build_context,
 			container_name, image_name, deploy_config, env_vars,
-			auto_deploy, enabled, subdomain, public_port, created_at, updated_at
+			auto_deploy, enabled, subdomain, public_port, route_path,
+			protected, access_allow, tunnel, basic_auth_user, basic_auth_hash,
+			template, compose_spec, docker_host, agent_id, submodules, lfs,
+			tag_pattern, compose_profiles, test_command, require_approval, deploy_schedule,
+			debounce_seconds, project, tags, isolated_build, image_tag_strategy, artifact_paths, build_caches, release_events, allowed_branches, image_refresh, created_at, updated_at
 		) VALUES (
 			:id, :name, :description, :repo_url, :branch, :webhook_secret,
 			:build_strategy, :dockerfile_path, :compose_file, :build_context,
 			:container_name, :image_name, :deploy_config, :env_vars,
-			:auto_deploy, :enabled, :subdomain, :public_port, :created_at, :updated_at
+			:auto_deploy, :enabled, :subdomain, :public_port, :route_path,
+			:protected, :access_allow, :tunnel, :basic_auth_user, :basic_auth_hash,
+			:template, :compose_spec, :docker_host, :agent_id, :submodules, :lfs,
+			:tag_pattern, :compose_profiles, :test_command, :require_approval, :deploy_schedule,
+			:debounce_seconds, :project, :tags, :isolated_build, :image_tag_strategy, :artifact_paths, :build_caches, :release_events, :allowed_branches, :image_refresh, :created_at, :updated_at
 		)`
 
-	_, err := q.db.NamedExecContext(ctx, query, app)
+	tx, err := q.db.BeginTxx(ctx, nil)
 	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.NamedExecContext(ctx, query, app); err != nil {
 		return fmt.Errorf("failed to create app: %w", err)
 	}
+	if err := allocatePort(ctx, tx, app, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit app: %w", err)
+	}
 	return nil
 }
 
@@ -102,6 +124,107 @@ func (q *AppQueries) List(ctx context.Context) ([]*models.App, error) {
 	return apps, nil
 }
 
+// AppFilter narrows down a list of apps
+type AppFilter struct {
+	Query   string // Substring of the name, description, repository, project or a tag, ignoring case
+	Tag     string // Tag the app has, ignoring case
+	Project string // Project the app is in
+}
+
+// where returns the filter's conditions on apps, joined by AND, and their
+// arguments
+func (f AppFilter) where() (string, []interface{}) {
+	conditions := []string{"1 = 1"}
+	var args []interface{}
+	if f.Query != "" {
+		pattern := "%" + likeEscaper.Replace(f.Query) + "%"
+		conditions = append(conditions, `(name LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\'
+			OR repo_url LIKE ? ESCAPE '\' OR project LIKE ? ESCAPE '\' OR tags LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern, pattern, pattern, pattern)
+	}
+	if f.Tag != "" {
+		// Tags are stored lowercase between commas, so whole tags match
+		conditions = append(conditions, "',' || tags || ',' LIKE ? ESCAPE '\\'")
+		args = append(args, "%,"+likeEscaper.Replace(strings.ToLower(strings.TrimSpace(f.Tag)))+",%")
+	}
+	if f.Project != "" {
+		conditions = append(conditions, "project = ?")
+		args = append(args, f.Project)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// Search retrieves the apps matching filter, ordered by name
+func (q *AppQueries) Search(ctx context.Context, filter AppFilter) ([]*models.App, error) {
+	var apps []*models.App
+	where, args := filter.where()
+	query := `SELECT * FROM apps WHERE ` + where + ` ORDER BY name`
+
+	err := q.db.SelectContext(ctx, &apps, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search apps: %w", err)
+	}
+
+	for _, app := range apps {
+		if err := app.LoadEnvVars(); err != nil {
+			return nil, fmt.Errorf("failed to load env vars: %w", err)
+		}
+	}
+
+	return apps, nil
+}
+
+// ListTags retrieves every tag used by an app, sorted
+func (q *AppQueries) ListTags(ctx context.Context) ([]string, error) {
+	var rows []string
+	query := `SELECT tags FROM apps WHERE tags IS NOT NULL AND tags != ''`
+
+	if err := q.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var tags []string
+	for _, row := range rows {
+		for _, tag := range strings.Split(row, ",") {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// ListPage retrieves up to limit apps matching filter, ordered by name,
+// starting after the app with ID afterID (or from the start if afterID is
+// empty)
+func (q *AppQueries) ListPage(ctx context.Context, filter AppFilter, afterID string, limit int) ([]*models.App, error) {
+	var apps []*models.App
+	where, args := filter.where()
+	query := `
+		SELECT * FROM apps
+		WHERE ` + where + `
+		  AND (? = '' OR (name, id) > (SELECT name, id FROM apps WHERE id = ?))
+		ORDER BY name, id
+		LIMIT ?`
+
+	args = append(args, afterID, afterID, limit)
+	err := q.db.SelectContext(ctx, &apps, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list apps: %w", err)
+	}
+
+	for _, app := range apps {
+		if err := app.LoadEnvVars(); err != nil {
+			return nil, fmt.Errorf("failed to load env vars: %w", err)
+		}
+	}
+
+	return apps, nil
+}
+
 // ListEnabled retrieves all enabled apps
 func (q *AppQueries) ListEnabled(ctx context.Context) ([]*models.App, error) {
 	var apps []*models.App
@@ -121,7 +244,58 @@ func (q *AppQueries) ListEnabled(ctx context.Context) ([]*models.App, error) {
 	return apps, nil
 }
 
-// FindByRepoAndBranch finds apps matching a repo URL and branch
+// FindByRepoWithTags finds apps of a repo URL that build pushed release tags
+func (q *AppQueries) FindByRepoWithTags(ctx context.Context, repoURL string) ([]*models.App, error) {
+	var apps []*models.App
+	query := `
+		SELECT * FROM apps
+		WHERE enabled = 1
+		AND auto_deploy = 1
+		AND repo_url = ?
+		AND tag_pattern IS NOT NULL AND tag_pattern != ''
+		AND release_events = 0`
+
+	err := q.db.SelectContext(ctx, &apps, query, repoURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find apps: %w", err)
+	}
+
+	for _, app := range apps {
+		if err := app.LoadEnvVars(); err != nil {
+			return nil, fmt.Errorf("failed to load env vars: %w", err)
+		}
+	}
+
+	return apps, nil
+}
+
+// FindByRepoWithReleases finds apps of a repo URL that build published
+// GitHub releases
+func (q *AppQueries) FindByRepoWithReleases(ctx context.Context, repoURL string) ([]*models.App, error) {
+	var apps []*models.App
+	query := `
+		SELECT * FROM apps
+		WHERE enabled = 1
+		AND auto_deploy = 1
+		AND repo_url = ?
+		AND release_events = 1`
+
+	err := q.db.SelectContext(ctx, &apps, query, repoURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find apps: %w", err)
+	}
+
+	for _, app := range apps {
+		if err := app.LoadEnvVars(); err != nil {
+			return nil, fmt.Errorf("failed to load env vars: %w", err)
+		}
+	}
+
+	return apps, nil
+}
+
+// FindByRepoAndBranch finds apps matching a repo URL and branch, leaving out
+// apps that build release tags
 func (q *AppQueries) FindByRepoAndBranch(ctx context.Context, repoURL, branch string) ([]*models.App, error) {
 	var apps []*models.App
 	query := `
@@ -129,7 +303,9 @@ func (q *AppQueries) FindByRepoAndBranch(ctx context.Context, repoURL, branch st
 		WHERE enabled = 1
 		AND auto_deploy = 1
 		AND (repo_url = ? OR repo_url = ?)
-		AND branch = ?`
+		AND branch = ?
+		AND (tag_pattern IS NULL OR tag_pattern = '')
+		AND release_events = 0`
 
 	// Try both HTTPS and SSH URL formats
 	httpsURL := repoURL
@@ -172,19 +348,70 @@ func (q *AppQueries) Update(ctx context.Context, app *models.App) error {
 			enabled = :enabled,
 			subdomain = :subdomain,
 			public_port = :public_port,
+			route_path = :route_path,
+			protected = :protected,
+			access_allow = :access_allow,
+			tunnel = :tunnel,
+			basic_auth_user = :basic_auth_user,
+			basic_auth_hash = :basic_auth_hash,
+			template = :template,
+			compose_spec = :compose_spec,
+			docker_host = :docker_host,
+			agent_id = :agent_id,
+			submodules = :submodules,
+			lfs = :lfs,
+			tag_pattern = :tag_pattern,
+			compose_profiles = :compose_profiles,
+			test_command = :test_command,
+			require_approval = :require_approval,
+			deploy_schedule = :deploy_schedule,
+			debounce_seconds = :debounce_seconds,
+			project = :project,
+			tags = :tags,
+			isolated_build = :isolated_build,
+			image_tag_strategy = :image_tag_strategy,
+			artifact_paths = :artifact_paths,
+			build_caches = :build_caches,
+			release_events = :release_events,
+			allowed_branches = :allowed_branches,
+			image_refresh = :image_refresh,
 			updated_at = :updated_at
 		WHERE id = :id`
 
-	result, err := q.db.NamedExecContext(ctx, query, app)
+	tx, err := q.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to update app: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
+	var previous models.App
+	err = tx.GetContext(ctx, &previous, `SELECT public_port, docker_host, agent_id FROM apps WHERE id = ?`, app.ID)
+	if err == sql.ErrNoRows {
 		return fmt.Errorf("app not found: %s", app.ID)
 	}
+	if err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+
+	if _, err := tx.NamedExecContext(ctx, query, app); err != nil {
+		return fmt.Errorf("failed to update app: %w", err)
+	}
+	if err := allocatePort(ctx, tx, app, &previous); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit app: %w", err)
+	}
+	return nil
+}
 
+// SetStopped records whether an app was stopped on purpose
+func (q *AppQueries) SetStopped(ctx context.Context, id string, stopped bool) error {
+	query := `UPDATE apps SET stopped = ? WHERE id = ?`
+	if _, err := q.db.ExecContext(ctx, query, stopped, id); err != nil {
+		return fmt.Errorf("failed to update app stopped: %w", err)
+	}
 	return nil
 }
 
@@ -0,0 +1,302 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"schooner/internal/models"
+)
+
+// BackupQueries provides database operations for backup jobs and archives
+type BackupQueries struct {
+	db *sqlx.DB
+}
+
+// NewBackupQueries creates a new BackupQueries instance
+func NewBackupQueries(db *sqlx.DB) *BackupQueries {
+	return &BackupQueries{db: db}
+}
+
+// CreateJob inserts a new backup job
+func (q *BackupQueries) CreateJob(ctx context.Context, job *models.BackupJob) error {
+	query := `
+		INSERT INTO backup_jobs (
+			id, name, volumes, schedule, keep_last, target, enabled,
+			created_at, updated_at
+		) VALUES (
+			:id, :name, :volumes, :schedule, :keep_last, :target, :enabled,
+			:created_at, :updated_at
+		)`
+
+	_, err := q.db.NamedExecContext(ctx, query, job)
+	if err != nil {
+		return fmt.Errorf("failed to create backup job: %w", err)
+	}
+	return nil
+}
+
+// UpdateJob saves a backup job's settings
+func (q *BackupQueries) UpdateJob(ctx context.Context, job *models.BackupJob) error {
+	job.UpdatedAt = time.Now()
+	query := `
+		UPDATE backup_jobs SET
+			name = :name,
+			volumes = :volumes,
+			schedule = :schedule,
+			keep_last = :keep_last,
+			target = :target,
+			enabled = :enabled,
+			updated_at = :updated_at
+		WHERE id = :id`
+
+	_, err := q.db.NamedExecContext(ctx, query, job)
+	if err != nil {
+		return fmt.Errorf("failed to update backup job: %w", err)
+	}
+	return nil
+}
+
+// GetJob retrieves a backup job by ID
+func (q *BackupQueries) GetJob(ctx context.Context, id string) (*models.BackupJob, error) {
+	var job models.BackupJob
+	query := `SELECT * FROM backup_jobs WHERE id = ?`
+
+	err := q.db.GetContext(ctx, &job, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get backup job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// ListJobs retrieves all backup jobs
+func (q *BackupQueries) ListJobs(ctx context.Context) ([]*models.BackupJob, error) {
+	var jobs []*models.BackupJob
+	query := `SELECT * FROM backup_jobs ORDER BY name`
+
+	err := q.db.SelectContext(ctx, &jobs, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backup jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// DeleteJob removes a backup job. Its archives are kept.
+func (q *BackupQueries) DeleteJob(ctx context.Context, id string) error {
+	query := `DELETE FROM backup_jobs WHERE id = ?`
+
+	_, err := q.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete backup job: %w", err)
+	}
+	return nil
+}
+
+// RecordJobRun stores when a job last ran and its error, if any
+func (q *BackupQueries) RecordJobRun(ctx context.Context, id string, ranAt time.Time, runErr string) error {
+	query := `UPDATE backup_jobs SET last_run_at = ?, last_error = ? WHERE id = ?`
+
+	_, err := q.db.ExecContext(ctx, query, ranAt, sql.NullString{String: runErr, Valid: runErr != ""}, id)
+	if err != nil {
+		return fmt.Errorf("failed to record backup job run: %w", err)
+	}
+	return nil
+}
+
+// CreateBackup inserts a new backup archive record
+func (q *BackupQueries) CreateBackup(ctx context.Context, backup *models.Backup) error {
+	query := `
+		INSERT INTO backups (
+			id, job_id, volume, target, key, size_bytes, status, error,
+			created_at, finished_at
+		) VALUES (
+			:id, :job_id, :volume, :target, :key, :size_bytes, :status, :error,
+			:created_at, :finished_at
+		)`
+
+	_, err := q.db.NamedExecContext(ctx, query, backup)
+	if err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+	return nil
+}
+
+// FinishBackup stores the outcome of a backup
+func (q *BackupQueries) FinishBackup(ctx context.Context, backup *models.Backup) error {
+	query := `
+		UPDATE backups SET
+			size_bytes = :size_bytes,
+			status = :status,
+			error = :error,
+			finished_at = :finished_at
+		WHERE id = :id`
+
+	_, err := q.db.NamedExecContext(ctx, query, backup)
+	if err != nil {
+		return fmt.Errorf("failed to update backup: %w", err)
+	}
+	return nil
+}
+
+// GetBackup retrieves a backup by ID
+func (q *BackupQueries) GetBackup(ctx context.Context, id string) (*models.Backup, error) {
+	var backup models.Backup
+	query := `SELECT * FROM backups WHERE id = ?`
+
+	err := q.db.GetContext(ctx, &backup, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get backup: %w", err)
+	}
+
+	return &backup, nil
+}
+
+// ListBackups retrieves the most recent backups, newest first, optionally
+// only those of one volume
+func (q *BackupQueries) ListBackups(ctx context.Context, volume string, limit int) ([]*models.Backup, error) {
+	var backups []*models.Backup
+	query := `
+		SELECT * FROM backups
+		WHERE ? = '' OR volume = ?
+		ORDER BY created_at DESC
+		LIMIT ?`
+
+	err := q.db.SelectContext(ctx, &backups, query, volume, volume, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	return backups, nil
+}
+
+// ListJobBackups retrieves a job's successful backups of a volume, newest
+// first
+func (q *BackupQueries) ListJobBackups(ctx context.Context, jobID, volume string) ([]*models.Backup, error) {
+	var backups []*models.Backup
+	query := `
+		SELECT * FROM backups
+		WHERE job_id = ? AND volume = ? AND status = 'success'
+		ORDER BY created_at DESC`
+
+	err := q.db.SelectContext(ctx, &backups, query, jobID, volume)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job backups: %w", err)
+	}
+
+	return backups, nil
+}
+
+// DeleteBackup removes a backup record
+func (q *BackupQueries) DeleteBackup(ctx context.Context, id string) error {
+	query := `DELETE FROM backups WHERE id = ?`
+
+	_, err := q.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete backup: %w", err)
+	}
+	return nil
+}
+
+// FailRunningBackups marks volume and system backups interrupted by a
+// restart as failed
+func (q *BackupQueries) FailRunningBackups(ctx context.Context) (int64, error) {
+	var total int64
+	for _, table := range []string{"backups", "system_backups"} {
+		query := `
+			UPDATE ` + table + `
+			SET status = 'failed', error = 'interrupted by restart', finished_at = ?
+			WHERE status = 'running'`
+
+		result, err := q.db.ExecContext(ctx, query, time.Now())
+		if err != nil {
+			return 0, fmt.Errorf("failed to fail running backups: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		total += n
+	}
+	return total, nil
+}
+
+// CreateSystemBackup inserts a new system backup record
+func (q *BackupQueries) CreateSystemBackup(ctx context.Context, backup *models.SystemBackup) error {
+	query := `
+		INSERT INTO system_backups (
+			id, target, key, size_bytes, status, error, created_at, finished_at
+		) VALUES (
+			:id, :target, :key, :size_bytes, :status, :error, :created_at, :finished_at
+		)`
+
+	_, err := q.db.NamedExecContext(ctx, query, backup)
+	if err != nil {
+		return fmt.Errorf("failed to create system backup: %w", err)
+	}
+	return nil
+}
+
+// FinishSystemBackup stores the outcome of a system backup
+func (q *BackupQueries) FinishSystemBackup(ctx context.Context, backup *models.SystemBackup) error {
+	query := `
+		UPDATE system_backups SET
+			size_bytes = :size_bytes,
+			status = :status,
+			error = :error,
+			finished_at = :finished_at
+		WHERE id = :id`
+
+	_, err := q.db.NamedExecContext(ctx, query, backup)
+	if err != nil {
+		return fmt.Errorf("failed to update system backup: %w", err)
+	}
+	return nil
+}
+
+// GetSystemBackup retrieves a system backup by ID
+func (q *BackupQueries) GetSystemBackup(ctx context.Context, id string) (*models.SystemBackup, error) {
+	var backup models.SystemBackup
+	query := `SELECT * FROM system_backups WHERE id = ?`
+
+	err := q.db.GetContext(ctx, &backup, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get system backup: %w", err)
+	}
+
+	return &backup, nil
+}
+
+// ListSystemBackups retrieves system backups, newest first
+func (q *BackupQueries) ListSystemBackups(ctx context.Context) ([]*models.SystemBackup, error) {
+	var backups []*models.SystemBackup
+	query := `SELECT * FROM system_backups ORDER BY created_at DESC`
+
+	err := q.db.SelectContext(ctx, &backups, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list system backups: %w", err)
+	}
+
+	return backups, nil
+}
+
+// DeleteSystemBackup removes a system backup record
+func (q *BackupQueries) DeleteSystemBackup(ctx context.Context, id string) error {
+	query := `DELETE FROM system_backups WHERE id = ?`
+
+	_, err := q.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete system backup: %w", err)
+	}
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 
@@ -24,13 +26,15 @@ func NewBuildQueries(db *sqlx.DB) *BuildQueries {
 func (q *BuildQueries) Create(ctx context.Context, build *models.Build) error {
 	query := `
 		INSERT INTO builds (
-			id, app_id, status, trigger, commit_sha, commit_message,
-			commit_author, branch, image_tag, error_message,
-			started_at, finished_at, created_at
+			id, app_id, status, trigger, triggered_by, commit_sha, commit_message,
+			commit_author, branch, image_tag, tag, image_digests,
+			pinned_build_id, approved_by, approved_at, error_message, started_at,
+			finished_at, release_name, release_notes, release_url, created_at
 		) VALUES (
-			:id, :app_id, :status, :trigger, :commit_sha, :commit_message,
-			:commit_author, :branch, :image_tag, :error_message,
-			:started_at, :finished_at, :created_at
+			:id, :app_id, :status, :trigger, :triggered_by, :commit_sha, :commit_message,
+			:commit_author, :branch, :image_tag, :tag, :image_digests,
+			:pinned_build_id, :approved_by, :approved_at, :error_message, :started_at,
+			:finished_at, :release_name, :release_notes, :release_url, :created_at
 		)`
 
 	_, err := q.db.NamedExecContext(ctx, query, build)
@@ -79,6 +83,137 @@ func (q *BuildQueries) ListByAppID(ctx context.Context, appID string, limit, off
 	return builds, nil
 }
 
+// BuildFilter narrows a build listing. Zero fields don't filter.
+type BuildFilter struct {
+	AppID    string
+	Statuses []models.BuildStatus
+	Trigger  models.BuildTrigger
+	Since    time.Time // Created at or after
+	Until    time.Time // Created before
+	Author   string    // Substring of the commit author, ignoring case
+}
+
+// where returns the filter's conditions on builds b, joined by AND, and
+// their arguments
+func (f BuildFilter) where() (string, []interface{}) {
+	conditions := []string{"1 = 1"}
+	var args []interface{}
+	if f.AppID != "" {
+		conditions = append(conditions, "b.app_id = ?")
+		args = append(args, f.AppID)
+	}
+	if len(f.Statuses) > 0 {
+		conditions = append(conditions, "b.status IN (?"+strings.Repeat(", ?", len(f.Statuses)-1)+")")
+		for _, status := range f.Statuses {
+			args = append(args, status)
+		}
+	}
+	if f.Trigger != "" {
+		conditions = append(conditions, "b.trigger = ?")
+		args = append(args, f.Trigger)
+	}
+	if !f.Since.IsZero() {
+		conditions = append(conditions, "b.created_at >= ?")
+		args = append(args, f.Since)
+	}
+	if !f.Until.IsZero() {
+		conditions = append(conditions, "b.created_at < ?")
+		args = append(args, f.Until)
+	}
+	if f.Author != "" {
+		conditions = append(conditions, "b.commit_author LIKE ? ESCAPE '\\'")
+		args = append(args, "%"+likeEscaper.Replace(f.Author)+"%")
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListPage retrieves up to limit builds matching filter, newest first,
+// starting after the build with ID beforeID (or from the newest if empty)
+func (q *BuildQueries) ListPage(ctx context.Context, filter BuildFilter, beforeID string, limit int) ([]*models.Build, error) {
+	var builds []*models.Build
+	where, args := filter.where()
+	query := `
+		SELECT b.*, a.name as app_name, a.repo_url as app_repo_url
+		FROM builds b
+		JOIN apps a ON a.id = b.app_id
+		WHERE ` + where + `
+		  AND (? = '' OR (b.created_at, b.id) < (SELECT created_at, id FROM builds WHERE id = ?))
+		ORDER BY b.created_at DESC, b.id DESC
+		LIMIT ?`
+
+	args = append(args, beforeID, beforeID, limit)
+	err := q.db.SelectContext(ctx, &builds, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list builds: %w", err)
+	}
+
+	return builds, nil
+}
+
+// BuildSort orders a build search
+type BuildSort string
+
+const (
+	BuildSortCreated  BuildSort = "created_at"
+	BuildSortDuration BuildSort = "duration"
+	BuildSortApp      BuildSort = "app"
+	BuildSortStatus   BuildSort = "status"
+)
+
+// buildSortColumns maps each sort to what it orders by
+var buildSortColumns = map[BuildSort]string{
+	BuildSortCreated:  "b.created_at",
+	BuildSortDuration: "julianday(b.finished_at) - julianday(b.started_at)",
+	BuildSortApp:      "a.name",
+	BuildSortStatus:   "b.status",
+}
+
+// IsValid returns true if the sort is known
+func (s BuildSort) IsValid() bool {
+	_, ok := buildSortColumns[s]
+	return ok
+}
+
+// Search retrieves a page of builds matching filter, in the given order.
+// It also returns how many builds match in all.
+func (q *BuildQueries) Search(ctx context.Context, filter BuildFilter, sort BuildSort, ascending bool, limit, offset int) ([]*models.Build, int, error) {
+	column, ok := buildSortColumns[sort]
+	if !ok {
+		column = buildSortColumns[BuildSortCreated]
+	}
+	direction := "DESC"
+	if ascending {
+		direction = "ASC"
+	}
+	where, args := filter.where()
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM builds b JOIN apps a ON a.id = b.app_id WHERE ` + where
+	if err := q.db.GetContext(ctx, &total, countQuery, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count builds: %w", err)
+	}
+
+	// Builds without a duration sort last either way
+	builds := []*models.Build{}
+	query := `
+		SELECT b.*, a.name as app_name, a.repo_url as app_repo_url
+		FROM builds b
+		JOIN apps a ON a.id = b.app_id
+		WHERE ` + where + `
+		ORDER BY (` + column + `) IS NULL, ` + column + ` ` + direction + `, b.created_at DESC, b.id DESC
+		LIMIT ? OFFSET ?`
+
+	err := q.db.SelectContext(ctx, &builds, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search builds: %w", err)
+	}
+
+	return builds, total, nil
+}
+
 // ListRecent retrieves recent builds across all apps
 func (q *BuildQueries) ListRecent(ctx context.Context, limit int) ([]*models.Build, error) {
 	var builds []*models.Build
@@ -97,6 +232,65 @@ func (q *BuildQueries) ListRecent(ctx context.Context, limit int) ([]*models.Bui
 	return builds, nil
 }
 
+// ListRecentFinishedByAppID retrieves the most recent successful or failed builds for an app
+func (q *BuildQueries) ListRecentFinishedByAppID(ctx context.Context, appID string, limit int) ([]*models.Build, error) {
+	var builds []*models.Build
+	query := `
+		SELECT b.*, a.name as app_name, a.repo_url as app_repo_url
+		FROM builds b
+		JOIN apps a ON a.id = b.app_id
+		WHERE b.app_id = ? AND b.status IN ('success', 'failed')
+		ORDER BY b.created_at DESC
+		LIMIT ?`
+
+	err := q.db.SelectContext(ctx, &builds, query, appID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list finished builds: %w", err)
+	}
+
+	return builds, nil
+}
+
+// ListCompletedSince retrieves the finished builds of all apps created
+// since a time, for statistics
+func (q *BuildQueries) ListCompletedSince(ctx context.Context, since time.Time) ([]*models.Build, error) {
+	var builds []*models.Build
+	query := `
+		SELECT b.*, a.name as app_name, a.repo_url as app_repo_url
+		FROM builds b
+		JOIN apps a ON a.id = b.app_id
+		WHERE b.created_at >= ? AND b.status IN ('success', 'failed', 'cancelled')
+		ORDER BY b.created_at`
+
+	err := q.db.SelectContext(ctx, &builds, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list completed builds: %w", err)
+	}
+
+	return builds, nil
+}
+
+// GetLatestTag returns the git tag of an app's most recent tag build, or an
+// empty string when it has none
+func (q *BuildQueries) GetLatestTag(ctx context.Context, appID string) (string, error) {
+	var tag string
+	query := `
+		SELECT tag FROM builds
+		WHERE app_id = ? AND tag IS NOT NULL AND tag != ''
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	err := q.db.GetContext(ctx, &tag, query, appID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get latest tag: %w", err)
+	}
+
+	return tag, nil
+}
+
 // GetLatestByAppID retrieves the most recent build for an app
 func (q *BuildQueries) GetLatestByAppID(ctx context.Context, appID string) (*models.Build, error) {
 	var build models.Build
@@ -119,6 +313,32 @@ func (q *BuildQueries) GetLatestByAppID(ctx context.Context, appID string) (*mod
 	return &build, nil
 }
 
+// ListLatestPerApp retrieves the most recent build of every app that has
+// one, by app ID
+func (q *BuildQueries) ListLatestPerApp(ctx context.Context) (map[string]*models.Build, error) {
+	var builds []*models.Build
+	query := `
+		SELECT b.*, a.name as app_name, a.repo_url as app_repo_url
+		FROM builds b
+		JOIN apps a ON a.id = b.app_id
+		WHERE b.id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY app_id ORDER BY created_at DESC, id DESC) AS n
+				FROM builds
+			) ranked WHERE n = 1
+		)`
+
+	if err := q.db.SelectContext(ctx, &builds, query); err != nil {
+		return nil, fmt.Errorf("failed to list latest builds: %w", err)
+	}
+
+	latest := make(map[string]*models.Build, len(builds))
+	for _, build := range builds {
+		latest[build.AppID] = build
+	}
+	return latest, nil
+}
+
 // GetLatestSuccessfulByAppID retrieves the most recent successful build for an app
 func (q *BuildQueries) GetLatestSuccessfulByAppID(ctx context.Context, appID string) (*models.Build, error) {
 	var build models.Build
@@ -154,6 +374,20 @@ func (q *BuildQueries) CountByAppID(ctx context.Context, appID string) (int, err
 	return count, nil
 }
 
+// Number returns the build's place among its app's builds, counting from 1
+// for the first
+func (q *BuildQueries) Number(ctx context.Context, build *models.Build) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM builds WHERE app_id = ? AND created_at <= ?`
+
+	err := q.db.GetContext(ctx, &count, query, build.AppID, build.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count builds: %w", err)
+	}
+
+	return count, nil
+}
+
 // Update updates an existing build
 func (q *BuildQueries) Update(ctx context.Context, build *models.Build) error {
 	query := `
@@ -164,6 +398,13 @@ func (q *BuildQueries) Update(ctx context.Context, build *models.Build) error {
 			commit_author = :commit_author,
 			branch = :branch,
 			image_tag = :image_tag,
+			tag = :tag,
+			image_digests = :image_digests,
+			image_id = :image_id,
+			image_digest = :image_digest,
+			base_image = :base_image,
+			builder = :builder,
+			build_seconds = :build_seconds,
 			error_message = :error_message,
 			started_at = :started_at,
 			finished_at = :finished_at
@@ -235,3 +476,89 @@ func (q *BuildQueries) CancelStaleBuilds(ctx context.Context) (int64, error) {
 	return rows, nil
 }
 
+
+// Approve moves a build waiting for approval back to pending so it can be
+// queued again, recording who approved it. It reports false if the build
+// wasn't waiting for approval.
+func (q *BuildQueries) Approve(ctx context.Context, id, approvedBy string) (bool, error) {
+	query := `
+		UPDATE builds
+		SET status = 'pending',
+		    approved_by = ?,
+		    approved_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'waiting_approval'`
+
+	result, err := q.db.ExecContext(ctx, query, approvedBy, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to approve build: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// Reject cancels a build waiting for approval. It reports false if the
+// build wasn't waiting for approval.
+func (q *BuildQueries) Reject(ctx context.Context, id, rejectedBy string) (bool, error) {
+	query := `
+		UPDATE builds
+		SET status = 'cancelled',
+		    error_message = ?,
+		    finished_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'waiting_approval'`
+
+	result, err := q.db.ExecContext(ctx, query, "Deploy rejected by "+rejectedBy, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to reject build: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// Supersede cancels a pending or held build that a newer push replaced
+// before it deployed. It reports false if the build had already started.
+func (q *BuildQueries) Supersede(ctx context.Context, id, reason string) (bool, error) {
+	query := `
+		UPDATE builds
+		SET status = 'cancelled',
+		    error_message = ?,
+		    finished_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status IN ('pending', 'scheduled')`
+
+	result, err := q.db.ExecContext(ctx, query, reason, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to supersede build: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// ListScheduled retrieves the builds held for their deploy window
+func (q *BuildQueries) ListScheduled(ctx context.Context) ([]*models.Build, error) {
+	var builds []*models.Build
+	query := `
+		SELECT * FROM builds
+		WHERE status = 'scheduled'
+		ORDER BY created_at`
+
+	if err := q.db.SelectContext(ctx, &builds, query); err != nil {
+		return nil, fmt.Errorf("failed to list scheduled builds: %w", err)
+	}
+	return builds, nil
+}
+
+// Release moves a build held for its deploy window back to pending so it can
+// be queued again. It reports false if the build wasn't held.
+func (q *BuildQueries) Release(ctx context.Context, id string) (bool, error) {
+	query := `UPDATE builds SET status = 'pending' WHERE id = ? AND status = 'scheduled'`
+
+	result, err := q.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to release build: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
@@ -0,0 +1,72 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"schooner/internal/models"
+)
+
+// BuildStepQueries provides database operations for build pipeline steps
+type BuildStepQueries struct {
+	db *sqlx.DB
+}
+
+// NewBuildStepQueries creates a new BuildStepQueries instance
+func NewBuildStepQueries(db *sqlx.DB) *BuildStepQueries {
+	return &BuildStepQueries{db: db}
+}
+
+// Create inserts a new build step
+func (q *BuildStepQueries) Create(ctx context.Context, step *models.BuildStep) error {
+	query := `
+		INSERT INTO build_steps (
+			build_id, position, name, command, status, error_message,
+			started_at, finished_at
+		) VALUES (
+			:build_id, :position, :name, :command, :status, :error_message,
+			:started_at, :finished_at
+		)`
+
+	query, args, err := q.db.BindNamed(query+" RETURNING id", step)
+	if err != nil {
+		return fmt.Errorf("failed to create build step: %w", err)
+	}
+	if err := q.db.GetContext(ctx, &step.ID, query, args...); err != nil {
+		return fmt.Errorf("failed to create build step: %w", err)
+	}
+
+	return nil
+}
+
+// Update updates the status and timing of a build step
+func (q *BuildStepQueries) Update(ctx context.Context, step *models.BuildStep) error {
+	query := `
+		UPDATE build_steps SET
+			status = :status,
+			error_message = :error_message,
+			started_at = :started_at,
+			finished_at = :finished_at
+		WHERE id = :id`
+
+	if _, err := q.db.NamedExecContext(ctx, query, step); err != nil {
+		return fmt.Errorf("failed to update build step: %w", err)
+	}
+	return nil
+}
+
+// ListByBuildID retrieves the steps of a build in order
+func (q *BuildStepQueries) ListByBuildID(ctx context.Context, buildID string) ([]*models.BuildStep, error) {
+	var steps []*models.BuildStep
+	query := `
+		SELECT * FROM build_steps
+		WHERE build_id = ?
+		ORDER BY position, id`
+
+	if err := q.db.SelectContext(ctx, &steps, query, buildID); err != nil {
+		return nil, fmt.Errorf("failed to list build steps: %w", err)
+	}
+	return steps, nil
+}
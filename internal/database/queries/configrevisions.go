@@ -0,0 +1,102 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"schooner/internal/models"
+)
+
+// ConfigRevisionQueries keeps the history of app configuration changes
+type ConfigRevisionQueries struct {
+	db *sqlx.DB
+}
+
+// NewConfigRevisionQueries creates a new ConfigRevisionQueries instance
+func NewConfigRevisionQueries(db *sqlx.DB) *ConfigRevisionQueries {
+	return &ConfigRevisionQueries{db: db}
+}
+
+// Record saves a snapshot of an app's configuration as its next revision
+// and makes it the app's current revision
+func (q *ConfigRevisionQueries) Record(ctx context.Context, appID string, snapshot map[string]string, changedBy string) (*models.ConfigRevision, error) {
+	tx, err := q.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var latest int
+	if err := tx.GetContext(ctx, &latest, `SELECT COALESCE(MAX(revision), 0) FROM app_config_revisions WHERE app_id = ?`, appID); err != nil {
+		return nil, fmt.Errorf("failed to get latest config revision: %w", err)
+	}
+
+	rev := &models.ConfigRevision{
+		ID:        uuid.New().String(),
+		AppID:     appID,
+		Revision:  latest + 1,
+		ChangedBy: sql.NullString{String: changedBy, Valid: changedBy != ""},
+		CreatedAt: time.Now(),
+	}
+	if err := rev.SetSnapshot(snapshot); err != nil {
+		return nil, fmt.Errorf("failed to encode config snapshot: %w", err)
+	}
+
+	query := `
+		INSERT INTO app_config_revisions (id, app_id, revision, snapshot, changed_by, created_at)
+		VALUES (:id, :app_id, :revision, :snapshot, :changed_by, :created_at)`
+	if _, err := tx.NamedExecContext(ctx, query, rev); err != nil {
+		return nil, fmt.Errorf("failed to record config revision: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE apps SET config_revision = ? WHERE id = ?`, rev.Revision, appID); err != nil {
+		return nil, fmt.Errorf("failed to update config revision: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit config revision: %w", err)
+	}
+	return rev, nil
+}
+
+// GetLatest returns an app's latest config revision, or nil when its
+// configuration was never changed
+func (q *ConfigRevisionQueries) GetLatest(ctx context.Context, appID string) (*models.ConfigRevision, error) {
+	var rev models.ConfigRevision
+	query := `SELECT * FROM app_config_revisions WHERE app_id = ? ORDER BY revision DESC LIMIT 1`
+
+	err := q.db.GetContext(ctx, &rev, query, appID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config revision: %w", err)
+	}
+	return &rev, nil
+}
+
+// ListByAppID returns an app's config revisions, newest first
+func (q *ConfigRevisionQueries) ListByAppID(ctx context.Context, appID string, limit int) ([]*models.ConfigRevision, error) {
+	var revisions []*models.ConfigRevision
+	query := `SELECT * FROM app_config_revisions WHERE app_id = ? ORDER BY revision DESC LIMIT ?`
+
+	if err := q.db.SelectContext(ctx, &revisions, query, appID, limit); err != nil {
+		return nil, fmt.Errorf("failed to list config revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+// MarkDeployed records the config revision an app's running deployment
+// was deployed with
+func (q *ConfigRevisionQueries) MarkDeployed(ctx context.Context, appID string, revision int) error {
+	query := `UPDATE apps SET deployed_config_revision = ? WHERE id = ?`
+	if _, err := q.db.ExecContext(ctx, query, revision, appID); err != nil {
+		return fmt.Errorf("failed to mark config revision deployed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"schooner/internal/models"
+)
+
+// ContainerHealthQueries records the health check state of app containers
+type ContainerHealthQueries struct {
+	db *sqlx.DB
+}
+
+// NewContainerHealthQueries creates a new ContainerHealthQueries instance
+func NewContainerHealthQueries(db *sqlx.DB) *ContainerHealthQueries {
+	return &ContainerHealthQueries{db: db}
+}
+
+// SaveHealth records the health an app's container changed to, counting a
+// flap when it went from healthy to unhealthy
+func (q *ContainerHealthQueries) SaveHealth(ctx context.Context, appID, health string, flapped bool) error {
+	now := time.Now()
+	flaps := 0
+	var lastFlapAt sql.NullTime
+	if flapped {
+		flaps, lastFlapAt = 1, sql.NullTime{Time: now, Valid: true}
+	}
+
+	query := `
+		INSERT INTO container_health (app_id, health, flaps, last_flap_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(app_id) DO UPDATE SET
+			health = excluded.health,
+			flaps = container_health.flaps + excluded.flaps,
+			last_flap_at = COALESCE(excluded.last_flap_at, container_health.last_flap_at),
+			updated_at = excluded.updated_at`
+
+	_, err := q.db.ExecContext(ctx, query, appID, health, flaps, lastFlapAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to save container health: %w", err)
+	}
+	return nil
+}
+
+// RecordRestart counts a restart of an app's container by the watchdog
+func (q *ContainerHealthQueries) RecordRestart(ctx context.Context, appID string) error {
+	now := time.Now()
+	query := `
+		INSERT INTO container_health (app_id, health, restarts, last_restart_at, updated_at)
+		VALUES (?, 'unhealthy', 1, ?, ?)
+		ON CONFLICT(app_id) DO UPDATE SET
+			restarts = container_health.restarts + 1,
+			last_restart_at = excluded.last_restart_at,
+			updated_at = excluded.updated_at`
+
+	_, err := q.db.ExecContext(ctx, query, appID, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to record container restart: %w", err)
+	}
+	return nil
+}
+
+// GetByAppID retrieves the health of an app's container, or nil if it has
+// never reported any
+func (q *ContainerHealthQueries) GetByAppID(ctx context.Context, appID string) (*models.ContainerHealth, error) {
+	var health models.ContainerHealth
+	query := `SELECT * FROM container_health WHERE app_id = ?`
+
+	err := q.db.GetContext(ctx, &health, query, appID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get container health: %w", err)
+	}
+	return &health, nil
+}
+
+// List retrieves the health of every app's container that reported any
+func (q *ContainerHealthQueries) List(ctx context.Context) ([]*models.ContainerHealth, error) {
+	var health []*models.ContainerHealth
+	query := `SELECT * FROM container_health ORDER BY app_id`
+
+	err := q.db.SelectContext(ctx, &health, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list container health: %w", err)
+	}
+	return health, nil
+}
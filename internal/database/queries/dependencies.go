@@ -0,0 +1,55 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"schooner/internal/models"
+)
+
+// DependencyQueries stores which apps must be running before others start
+type DependencyQueries struct {
+	db *sqlx.DB
+}
+
+// NewDependencyQueries creates a new DependencyQueries instance
+func NewDependencyQueries(db *sqlx.DB) *DependencyQueries {
+	return &DependencyQueries{db: db}
+}
+
+// Graph retrieves the dependencies of every app
+func (q *DependencyQueries) Graph(ctx context.Context) (models.DependencyGraph, error) {
+	var deps []models.AppDependency
+	query := `SELECT app_id, depends_on_id FROM app_dependencies ORDER BY app_id, depends_on_id`
+
+	if err := q.db.SelectContext(ctx, &deps, query); err != nil {
+		return nil, fmt.Errorf("failed to list app dependencies: %w", err)
+	}
+	return models.NewDependencyGraph(deps), nil
+}
+
+// SetDependencies replaces the apps an app depends on
+func (q *DependencyQueries) SetDependencies(ctx context.Context, appID string, dependsOn []string) error {
+	tx, err := q.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM app_dependencies WHERE app_id = ?`, appID); err != nil {
+		return fmt.Errorf("failed to clear app dependencies: %w", err)
+	}
+	for _, id := range dependsOn {
+		query := `INSERT INTO app_dependencies (app_id, depends_on_id) VALUES (?, ?)`
+		if _, err := tx.ExecContext(ctx, query, appID, id); err != nil {
+			return fmt.Errorf("failed to save app dependency: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit app dependencies: %w", err)
+	}
+	return nil
+}
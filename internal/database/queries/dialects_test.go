@@ -0,0 +1,204 @@
+package queries_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"schooner/internal/database"
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+// postgresURLEnv names a Postgres database to run the queries against. The
+// test works in a schema of its own that it drops afterwards.
+const postgresURLEnv = "SCHOONER_TEST_POSTGRES_URL"
+
+func TestQueriesOnBothDialects(t *testing.T) {
+	t.Setenv("SCHOONER_KEY_PATH", filepath.Join(t.TempDir(), ".encryption_key"))
+
+	t.Run("sqlite", func(t *testing.T) {
+		runQueries(t, newTestDB(t))
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		dsn := os.Getenv(postgresURLEnv)
+		if dsn == "" {
+			t.Skipf("%s not set", postgresURLEnv)
+		}
+		db := postgresSchema(t, dsn)
+		defer db.Close()
+		if err := db.Migrate(); err != nil {
+			t.Fatalf("Migrate() error = %v", err)
+		}
+		runQueries(t, db)
+	})
+}
+
+// newTestDB returns a migrated SQLite database in a temporary directory,
+// keeping the encryption key it creates there too
+func newTestDB(t *testing.T) *database.DB {
+	t.Helper()
+	t.Setenv("SCHOONER_KEY_PATH", filepath.Join(t.TempDir(), ".encryption_key"))
+	db, err := database.New(filepath.Join(t.TempDir(), "schooner.db"))
+	if err != nil {
+		t.Fatalf("database.New() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	return db
+}
+
+// postgresSchema connects to a fresh schema of the database at dsn
+func postgresSchema(t *testing.T, dsn string) *database.DB {
+	t.Helper()
+
+	admin, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to connect to postgres: %v", err)
+	}
+	defer admin.Close()
+
+	schema := fmt.Sprintf("schooner_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		cleanup, err := sqlx.Connect("postgres", dsn)
+		if err != nil {
+			return
+		}
+		defer cleanup.Close()
+		cleanup.Exec("DROP SCHEMA " + schema + " CASCADE")
+	})
+
+	db, err := database.NewPostgres(withSearchPath(t, dsn, schema))
+	if err != nil {
+		t.Fatalf("database.NewPostgres() error = %v", err)
+	}
+	return db
+}
+
+// withSearchPath points a URL or key=value DSN at schema
+func withSearchPath(t *testing.T, dsn, schema string) string {
+	if !strings.Contains(dsn, "://") {
+		return dsn + " search_path=" + schema
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("invalid %s: %v", postgresURLEnv, err)
+	}
+	values := u.Query()
+	values.Set("search_path", schema)
+	u.RawQuery = values.Encode()
+	return u.String()
+}
+
+// runQueries goes through the queries the dashboard, the build pages and
+// the builder run most
+func runQueries(t *testing.T, db *database.DB) {
+	ctx := context.Background()
+	apps := queries.NewAppQueries(db.DB)
+	builds := queries.NewBuildQueries(db.DB)
+	logs := queries.NewLogQueries(db.DB)
+	settings := queries.NewSettingsQueries(db.DB)
+	activity := queries.NewActivityQueries(db.DB)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, name := range []string{"web", "api"} {
+		app := &models.App{
+			ID: name, Name: name, RepoURL: "https://github.com/example/" + name, Branch: "main",
+			BuildStrategy: models.BuildStrategyDockerfile, AutoDeploy: true, Enabled: true,
+			PublicPort: sql.NullInt64{Int64: int64(8080 + i), Valid: true},
+			CreatedAt:  now, UpdatedAt: now,
+		}
+		if err := apps.Create(ctx, app); err != nil {
+			t.Fatalf("apps.Create(%s) error = %v", name, err)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		build := &models.Build{
+			ID: fmt.Sprintf("web-%d", i), AppID: "web", Status: models.BuildStatusSuccess,
+			Trigger:    models.TriggerWebhook,
+			CommitSHA:  sql.NullString{String: fmt.Sprintf("sha%d", i), Valid: true},
+			StartedAt:  sql.NullTime{Time: now.Add(time.Duration(i) * time.Minute), Valid: true},
+			FinishedAt: sql.NullTime{Time: now.Add(time.Duration(i)*time.Minute + 30*time.Second), Valid: true},
+			CreatedAt:  now.Add(time.Duration(i) * time.Minute),
+		}
+		if err := builds.Create(ctx, build); err != nil {
+			t.Fatalf("builds.Create() error = %v", err)
+		}
+	}
+
+	latest, err := builds.ListLatestPerApp(ctx)
+	if err != nil {
+		t.Fatalf("ListLatestPerApp() error = %v", err)
+	}
+	if len(latest) != 1 || latest["web"] == nil || latest["web"].ID != "web-2" {
+		t.Errorf("ListLatestPerApp() = %v, want web-2 for web only", latest)
+	}
+
+	found, err := apps.Search(ctx, queries.AppFilter{Query: "WE"})
+	if err != nil || len(found) != 1 || found[0].Name != "web" {
+		t.Errorf("apps.Search() = %v, %v, want web", found, err)
+	}
+	app, err := apps.GetByName(ctx, "api")
+	if err != nil || app == nil {
+		t.Fatalf("apps.GetByName() = %v, %v", app, err)
+	}
+	app.Description = sql.NullString{String: "API", Valid: true}
+	if err := apps.Update(ctx, app); err != nil {
+		t.Errorf("apps.Update() error = %v", err)
+	}
+
+	page, total, err := builds.Search(ctx, queries.BuildFilter{AppID: "web"}, queries.BuildSortDuration, false, 10, 0)
+	if err != nil || total != 3 || len(page) != 3 {
+		t.Errorf("builds.Search() = %d builds of %d, %v, want 3", len(page), total, err)
+	}
+	if n, err := builds.Number(ctx, latest["web"]); err != nil || n != 3 {
+		t.Errorf("builds.Number() = %d, %v, want 3", n, err)
+	}
+
+	entries := []*models.BuildLog{
+		{BuildID: "web-2", Level: models.LogLevelInfo, Message: "one", Source: models.LogSourceDocker},
+		{BuildID: "web-2", Level: models.LogLevelInfo, Message: "two", Source: models.LogSourceDocker},
+	}
+	if err := logs.AppendBatch(ctx, entries); err != nil {
+		t.Fatalf("logs.AppendBatch() error = %v", err)
+	}
+	if got, err := logs.GetPageByBuildID(ctx, "web-2", 0, 10); err != nil || len(got) != 2 {
+		t.Errorf("logs.GetPageByBuildID() = %d entries, %v, want 2", len(got), err)
+	}
+
+	if err := settings.Set(ctx, "domain", "example.com"); err != nil {
+		t.Fatalf("settings.Set() error = %v", err)
+	}
+	if err := settings.Set(ctx, "domain", "example.org"); err != nil {
+		t.Fatalf("settings.Set() again error = %v", err)
+	}
+	if got, err := settings.Get(ctx, "domain"); err != nil || got != "example.org" {
+		t.Errorf("settings.Get() = %q, %v, want example.org", got, err)
+	}
+
+	if err := activity.Create(ctx, &models.Activity{Kind: models.ActivityDeploy, AppID: "web", Message: "deployed", CreatedAt: now}); err != nil {
+		t.Fatalf("activity.Create() error = %v", err)
+	}
+	if got, err := activity.ListRecent(ctx, 5); err != nil || len(got) != 1 {
+		t.Errorf("activity.ListRecent() = %d entries, %v, want 1", len(got), err)
+	}
+
+	if err := apps.Delete(ctx, "web"); err != nil {
+		t.Errorf("apps.Delete() error = %v", err)
+	}
+}
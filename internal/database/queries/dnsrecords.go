@@ -0,0 +1,65 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"schooner/internal/cloudflare"
+)
+
+// DNSRecordQueries tracks the Cloudflare DNS records Schooner created
+type DNSRecordQueries struct {
+	db *sqlx.DB
+}
+
+// NewDNSRecordQueries creates a new DNSRecordQueries instance
+func NewDNSRecordQueries(db *sqlx.DB) *DNSRecordQueries {
+	return &DNSRecordQueries{db: db}
+}
+
+// SaveRecord creates or replaces the tracked record for a hostname
+func (q *DNSRecordQueries) SaveRecord(ctx context.Context, record *cloudflare.ManagedRecord) error {
+	query := `
+		INSERT INTO dns_records (
+			hostname, zone_id, record_id, tunnel, created_at
+		) VALUES (
+			:hostname, :zone_id, :record_id, :tunnel, :created_at
+		)
+		ON CONFLICT(hostname) DO UPDATE SET
+			zone_id = excluded.zone_id,
+			record_id = excluded.record_id,
+			tunnel = excluded.tunnel,
+			created_at = excluded.created_at`
+
+	_, err := q.db.NamedExecContext(ctx, query, record)
+	if err != nil {
+		return fmt.Errorf("failed to save DNS record: %w", err)
+	}
+	return nil
+}
+
+// ListRecords retrieves all tracked records
+func (q *DNSRecordQueries) ListRecords(ctx context.Context) ([]*cloudflare.ManagedRecord, error) {
+	var records []*cloudflare.ManagedRecord
+	query := `SELECT * FROM dns_records ORDER BY hostname`
+
+	err := q.db.SelectContext(ctx, &records, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list DNS records: %w", err)
+	}
+
+	return records, nil
+}
+
+// DeleteRecord stops tracking the record for a hostname
+func (q *DNSRecordQueries) DeleteRecord(ctx context.Context, hostname string) error {
+	query := `DELETE FROM dns_records WHERE hostname = ?`
+
+	_, err := q.db.ExecContext(ctx, query, hostname)
+	if err != nil {
+		return fmt.Errorf("failed to delete DNS record: %w", err)
+	}
+	return nil
+}
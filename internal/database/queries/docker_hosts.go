@@ -0,0 +1,100 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"schooner/internal/models"
+)
+
+// DockerHostQueries provides database operations for remote Docker hosts
+type DockerHostQueries struct {
+	db *sqlx.DB
+}
+
+// NewDockerHostQueries creates a new DockerHostQueries instance
+func NewDockerHostQueries(db *sqlx.DB) *DockerHostQueries {
+	return &DockerHostQueries{db: db}
+}
+
+// Create inserts a new Docker host
+func (q *DockerHostQueries) Create(ctx context.Context, host *models.DockerHost) error {
+	query := `
+		INSERT INTO docker_hosts (id, name, url, tls_cert_path, ssh_key_path, ssh_host_key, created_at)
+		VALUES (:id, :name, :url, :tls_cert_path, :ssh_key_path, :ssh_host_key, :created_at)`
+
+	_, err := q.db.NamedExecContext(ctx, query, host)
+	if err != nil {
+		return fmt.Errorf("failed to create Docker host: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a Docker host by ID
+func (q *DockerHostQueries) GetByID(ctx context.Context, id string) (*models.DockerHost, error) {
+	var host models.DockerHost
+	query := `SELECT * FROM docker_hosts WHERE id = ?`
+
+	err := q.db.GetContext(ctx, &host, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get Docker host: %w", err)
+	}
+	return &host, nil
+}
+
+// List retrieves all Docker hosts ordered by name
+func (q *DockerHostQueries) List(ctx context.Context) ([]*models.DockerHost, error) {
+	var hosts []*models.DockerHost
+	query := `SELECT * FROM docker_hosts ORDER BY name`
+
+	if err := q.db.SelectContext(ctx, &hosts, query); err != nil {
+		return nil, fmt.Errorf("failed to list Docker hosts: %w", err)
+	}
+	return hosts, nil
+}
+
+// Update saves changes to a Docker host
+func (q *DockerHostQueries) Update(ctx context.Context, host *models.DockerHost) error {
+	query := `
+		UPDATE docker_hosts SET
+			name = :name,
+			url = :url,
+			tls_cert_path = :tls_cert_path,
+			ssh_key_path = :ssh_key_path,
+			ssh_host_key = :ssh_host_key
+		WHERE id = :id`
+
+	result, err := q.db.NamedExecContext(ctx, query, host)
+	if err != nil {
+		return fmt.Errorf("failed to update Docker host: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("Docker host not found")
+	}
+	return nil
+}
+
+// Delete removes a Docker host
+func (q *DockerHostQueries) Delete(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, `DELETE FROM docker_hosts WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete Docker host: %w", err)
+	}
+	return nil
+}
+
+// CountApps returns how many apps deploy to a Docker host
+func (q *DockerHostQueries) CountApps(ctx context.Context, id string) (int, error) {
+	var count int
+	if err := q.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM apps WHERE docker_host = ?`, id); err != nil {
+		return 0, fmt.Errorf("failed to count apps: %w", err)
+	}
+	return count, nil
+}
@@ -27,16 +27,17 @@ func (q *LogQueries) Append(ctx context.Context, log *models.BuildLog) error {
 	}
 
 	query := `
-		INSERT INTO build_logs (build_id, timestamp, level, message, source)
-		VALUES (:build_id, :timestamp, :level, :message, :source)`
+		INSERT INTO build_logs (build_id, timestamp, level, message, source, stream, styles, progress)
+		VALUES (:build_id, :timestamp, :level, :message, :source, :stream, :styles, :progress)`
 
-	result, err := q.db.NamedExecContext(ctx, query, log)
+	// RETURNING rather than LastInsertId, which Postgres doesn't support
+	query, args, err := q.db.BindNamed(query+" RETURNING id", log)
 	if err != nil {
 		return fmt.Errorf("failed to append log: %w", err)
 	}
-
-	id, _ := result.LastInsertId()
-	log.ID = id
+	if err := q.db.GetContext(ctx, &log.ID, query, args...); err != nil {
+		return fmt.Errorf("failed to append log: %w", err)
+	}
 
 	return nil
 }
@@ -48,8 +49,8 @@ func (q *LogQueries) AppendBatch(ctx context.Context, logs []*models.BuildLog) e
 	}
 
 	query := `
-		INSERT INTO build_logs (build_id, timestamp, level, message, source)
-		VALUES (:build_id, :timestamp, :level, :message, :source)`
+		INSERT INTO build_logs (build_id, timestamp, level, message, source, stream, styles, progress)
+		VALUES (:build_id, :timestamp, :level, :message, :source, :stream, :styles, :progress)`
 
 	_, err := q.db.NamedExecContext(ctx, query, logs)
 	if err != nil {
@@ -91,6 +92,24 @@ func (q *LogQueries) GetByBuildIDSince(ctx context.Context, buildID string, sinc
 	return logs, nil
 }
 
+// GetPageByBuildID retrieves up to limit logs for a build in order,
+// starting after the log with ID afterID
+func (q *LogQueries) GetPageByBuildID(ctx context.Context, buildID string, afterID int64, limit int) ([]*models.BuildLog, error) {
+	var logs []*models.BuildLog
+	query := `
+		SELECT * FROM build_logs
+		WHERE build_id = ? AND id > ?
+		ORDER BY id
+		LIMIT ?`
+
+	err := q.db.SelectContext(ctx, &logs, query, buildID, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logs: %w", err)
+	}
+
+	return logs, nil
+}
+
 // GetByBuildIDAfterID retrieves logs for a build after a specific log ID
 func (q *LogQueries) GetByBuildIDAfterID(ctx context.Context, buildID string, afterID int64) ([]*models.BuildLog, error) {
 	var logs []*models.BuildLog
@@ -0,0 +1,100 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"schooner/internal/models"
+)
+
+// MaintenanceQueries provides database operations for Docker housekeeping runs
+type MaintenanceQueries struct {
+	db *sqlx.DB
+}
+
+// NewMaintenanceQueries creates a new MaintenanceQueries instance
+func NewMaintenanceQueries(db *sqlx.DB) *MaintenanceQueries {
+	return &MaintenanceQueries{db: db}
+}
+
+// CreateRun inserts a new housekeeping run
+func (q *MaintenanceQueries) CreateRun(ctx context.Context, run *models.MaintenanceRun) error {
+	query := `
+		INSERT INTO maintenance_runs (
+			id, triggered_by, status, images_removed, volumes_removed,
+			networks_removed, space_reclaimed, error, started_at, finished_at
+		) VALUES (
+			:id, :triggered_by, :status, :images_removed, :volumes_removed,
+			:networks_removed, :space_reclaimed, :error, :started_at, :finished_at
+		)`
+
+	_, err := q.db.NamedExecContext(ctx, query, run)
+	if err != nil {
+		return fmt.Errorf("failed to create maintenance run: %w", err)
+	}
+	return nil
+}
+
+// FinishRun stores the outcome of a housekeeping run
+func (q *MaintenanceQueries) FinishRun(ctx context.Context, run *models.MaintenanceRun) error {
+	query := `
+		UPDATE maintenance_runs SET
+			status = :status,
+			images_removed = :images_removed,
+			volumes_removed = :volumes_removed,
+			networks_removed = :networks_removed,
+			space_reclaimed = :space_reclaimed,
+			error = :error,
+			finished_at = :finished_at
+		WHERE id = :id`
+
+	_, err := q.db.NamedExecContext(ctx, query, run)
+	if err != nil {
+		return fmt.Errorf("failed to update maintenance run: %w", err)
+	}
+	return nil
+}
+
+// ListRuns retrieves the latest housekeeping runs, newest first
+func (q *MaintenanceQueries) ListRuns(ctx context.Context, limit int) ([]*models.MaintenanceRun, error) {
+	var runs []*models.MaintenanceRun
+	query := `SELECT * FROM maintenance_runs ORDER BY started_at DESC LIMIT ?`
+
+	err := q.db.SelectContext(ctx, &runs, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list maintenance runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// PruneRuns deletes all but the newest keep runs
+func (q *MaintenanceQueries) PruneRuns(ctx context.Context, keep int) error {
+	query := `
+		DELETE FROM maintenance_runs WHERE id NOT IN (
+			SELECT id FROM maintenance_runs ORDER BY started_at DESC LIMIT ?
+		)`
+
+	_, err := q.db.ExecContext(ctx, query, keep)
+	if err != nil {
+		return fmt.Errorf("failed to prune maintenance runs: %w", err)
+	}
+	return nil
+}
+
+// FailRunningRuns marks runs interrupted by a restart as failed
+func (q *MaintenanceQueries) FailRunningRuns(ctx context.Context) (int64, error) {
+	query := `
+		UPDATE maintenance_runs
+		SET status = 'failed', error = 'interrupted by restart', finished_at = ?
+		WHERE status = 'running'`
+
+	result, err := q.db.ExecContext(ctx, query, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail running maintenance runs: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,108 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"schooner/internal/models"
+)
+
+// PortConflictError is returned when an app is saved with a host port
+// another app on the same machine already publishes on
+type PortConflictError struct {
+	Port int
+	App  string // Name of the app using the port
+}
+
+func (e *PortConflictError) Error() string {
+	return fmt.Sprintf("host port %d is already used by app %s", e.Port, e.App)
+}
+
+// PortQueries handles host port allocation queries
+type PortQueries struct {
+	db *sqlx.DB
+}
+
+// NewPortQueries creates a new PortQueries instance
+func NewPortQueries(db *sqlx.DB) *PortQueries {
+	return &PortQueries{db: db}
+}
+
+// NextFree returns the first port from first to last that no app other
+// than exceptAppID uses on host
+func (q *PortQueries) NextFree(ctx context.Context, host string, first, last int, exceptAppID string) (int, error) {
+	var used []int
+	query := `SELECT port FROM port_allocations WHERE host = ? AND port BETWEEN ? AND ? AND app_id != ?`
+	if err := q.db.SelectContext(ctx, &used, query, host, first, last, exceptAppID); err != nil {
+		return 0, fmt.Errorf("failed to list allocated ports: %w", err)
+	}
+
+	taken := make(map[int]bool, len(used))
+	for _, port := range used {
+		taken[port] = true
+	}
+	for port := first; port <= last; port++ {
+		if !taken[port] {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("no free host port left in %d-%d", first, last)
+}
+
+// Owner returns the name of the app other than exceptAppID using port on
+// host, or empty if it's free
+func (q *PortQueries) Owner(ctx context.Context, host string, port int, exceptAppID string) (string, error) {
+	return portOwner(ctx, q.db, host, port, exceptAppID)
+}
+
+// portOwner returns the name of the app other than exceptAppID using port
+// on host, or empty if it's free
+func portOwner(ctx context.Context, db sqlx.QueryerContext, host string, port int, exceptAppID string) (string, error) {
+	var name string
+	query := `
+		SELECT a.name FROM port_allocations p
+		JOIN apps a ON a.id = p.app_id
+		WHERE p.host = ? AND p.port = ? AND p.app_id != ?`
+	err := sqlx.GetContext(ctx, db, &name, query, host, port, exceptAppID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get port owner: %w", err)
+	}
+	return name, nil
+}
+
+// allocatePort records the host port an app publishes on, replacing the
+// port it had. previous is the app as it was saved, nil for a new app: apps
+// that shared a port before allocations were tracked keep sharing it until
+// one of them moves.
+func allocatePort(ctx context.Context, tx *sqlx.Tx, app, previous *models.App) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM port_allocations WHERE app_id = ?`, app.ID); err != nil {
+		return fmt.Errorf("failed to release port: %w", err)
+	}
+	port := app.GetPublicPort()
+	if port == 0 {
+		return nil
+	}
+
+	owner, err := portOwner(ctx, tx, app.PortHost(), port, app.ID)
+	if err != nil {
+		return err
+	}
+	if owner != "" {
+		if previous != nil && previous.GetPublicPort() == port && previous.PortHost() == app.PortHost() {
+			return nil
+		}
+		return &PortConflictError{Port: port, App: owner}
+	}
+
+	query := `INSERT INTO port_allocations (host, port, app_id) VALUES (?, ?, ?)`
+	if _, err := tx.ExecContext(ctx, query, app.PortHost(), port, app.ID); err != nil {
+		return fmt.Errorf("failed to allocate port: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"schooner/internal/models"
+)
+
+// ResourceQueries provides database operations for app resource usage history
+type ResourceQueries struct {
+	db *sqlx.DB
+}
+
+// NewResourceQueries creates a new ResourceQueries instance
+func NewResourceQueries(db *sqlx.DB) *ResourceQueries {
+	return &ResourceQueries{db: db}
+}
+
+const insertResourceSamples = `
+		INSERT INTO resource_samples (app_id, resolution, cpu_percent, memory_usage, memory_limit, restart_count, sampled_at)
+		VALUES (:app_id, :resolution, :cpu_percent, :memory_usage, :memory_limit, :restart_count, :sampled_at)`
+
+// RecordSamples stores a round of samples
+func (q *ResourceQueries) RecordSamples(ctx context.Context, samples []*models.ResourceSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	if _, err := q.db.NamedExecContext(ctx, insertResourceSamples, samples); err != nil {
+		return fmt.Errorf("failed to record resource samples: %w", err)
+	}
+	return nil
+}
+
+// ListSince retrieves an app's samples since the given time, oldest first
+func (q *ResourceQueries) ListSince(ctx context.Context, appID string, since time.Time) ([]*models.ResourceSample, error) {
+	var samples []*models.ResourceSample
+	query := `
+		SELECT * FROM resource_samples
+		WHERE app_id = ? AND sampled_at >= ?
+		ORDER BY sampled_at ASC, id ASC`
+
+	err := q.db.SelectContext(ctx, &samples, query, appID, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource samples: %w", err)
+	}
+
+	return samples, nil
+}
+
+// Downsample rolls the minute samples taken before the given time up into
+// hourly ones, returning how many minute samples were replaced
+func (q *ResourceQueries) Downsample(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := q.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var samples []*models.ResourceSample
+	err = tx.SelectContext(ctx, &samples, `
+		SELECT * FROM resource_samples
+		WHERE resolution = ? AND sampled_at < ?
+		ORDER BY sampled_at ASC, id ASC`, models.ResourceResolutionMinute, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to list resource samples: %w", err)
+	}
+	if len(samples) == 0 {
+		return 0, nil
+	}
+
+	hourly := models.DownsampleResources(samples, time.Hour)
+	for _, s := range hourly {
+		s.Resolution = models.ResourceResolutionHour
+	}
+	if _, err := tx.NamedExecContext(ctx, insertResourceSamples, hourly); err != nil {
+		return 0, fmt.Errorf("failed to record hourly resource samples: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM resource_samples WHERE resolution = ? AND sampled_at < ?`,
+		models.ResourceResolutionMinute, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete minute resource samples: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit downsampled resource samples: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// PruneSamples deletes samples older than the given time
+func (q *ResourceQueries) PruneSamples(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, `DELETE FROM resource_samples WHERE sampled_at < ?`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune resource samples: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,67 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"schooner/internal/auth"
+)
+
+// SessionQueries persists dashboard sessions
+type SessionQueries struct {
+	db *sqlx.DB
+}
+
+// NewSessionQueries creates a new SessionQueries instance
+func NewSessionQueries(db *sqlx.DB) *SessionQueries {
+	return &SessionQueries{db: db}
+}
+
+// SaveSession creates or updates a session
+func (q *SessionQueries) SaveSession(ctx context.Context, session *auth.Session) error {
+	query := `
+		INSERT INTO sessions (
+			id, username, avatar_url, user_agent, ip, mfa_state, csrf_token,
+			created_at, last_seen_at, expires_at
+		) VALUES (
+			:id, :username, :avatar_url, :user_agent, :ip, :mfa_state, :csrf_token,
+			:created_at, :last_seen_at, :expires_at
+		)
+		ON CONFLICT(id) DO UPDATE SET
+			mfa_state = excluded.mfa_state,
+			csrf_token = excluded.csrf_token,
+			last_seen_at = excluded.last_seen_at,
+			expires_at = excluded.expires_at`
+
+	_, err := q.db.NamedExecContext(ctx, query, session)
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// ListSessions retrieves all stored sessions
+func (q *SessionQueries) ListSessions(ctx context.Context) ([]*auth.Session, error) {
+	var sessions []*auth.Session
+	query := `SELECT * FROM sessions ORDER BY last_seen_at DESC`
+
+	err := q.db.SelectContext(ctx, &sessions, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// DeleteSession removes a stored session
+func (q *SessionQueries) DeleteSession(ctx context.Context, id string) error {
+	query := `DELETE FROM sessions WHERE id = ?`
+
+	_, err := q.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
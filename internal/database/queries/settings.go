@@ -3,12 +3,17 @@ package queries
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 
 	"schooner/internal/crypto"
+	"schooner/internal/models"
 )
 
 // Setting represents a key-value setting
@@ -147,3 +152,294 @@ func (q *SettingsQueries) SetMultiple(ctx context.Context, settings map[string]s
 
 	return nil
 }
+
+// Maintenance banner setting keys
+const (
+	bannerMessageKey   = "banner_message"
+	bannerLevelKey     = "banner_level"
+	bannerExpiresAtKey = "banner_expires_at"
+)
+
+// GetBanner retrieves the maintenance banner, or nil if none is set
+func (q *SettingsQueries) GetBanner(ctx context.Context) (*models.Banner, error) {
+	message, err := q.Get(ctx, bannerMessageKey)
+	if err != nil {
+		return nil, err
+	}
+	if message == "" {
+		return nil, nil
+	}
+
+	level, err := q.Get(ctx, bannerLevelKey)
+	if err != nil {
+		return nil, err
+	}
+
+	banner := &models.Banner{Message: message, Level: models.BannerLevel(level)}
+	if !banner.Level.IsValid() {
+		banner.Level = models.BannerLevelInfo
+	}
+
+	expiresAt, err := q.Get(ctx, bannerExpiresAtKey)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt != "" {
+		t, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse banner expiry: %w", err)
+		}
+		banner.ExpiresAt = &t
+	}
+
+	return banner, nil
+}
+
+// SetBanner stores the maintenance banner
+func (q *SettingsQueries) SetBanner(ctx context.Context, banner *models.Banner) error {
+	expiresAt := ""
+	if banner.ExpiresAt != nil {
+		expiresAt = banner.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+
+	return q.SetMultiple(ctx, map[string]string{
+		bannerMessageKey:   banner.Message,
+		bannerLevelKey:     string(banner.Level),
+		bannerExpiresAtKey: expiresAt,
+	})
+}
+
+// ClearBanner removes the maintenance banner
+func (q *SettingsQueries) ClearBanner(ctx context.Context) error {
+	for _, key := range []string{bannerMessageKey, bannerLevelKey, bannerExpiresAtKey} {
+		if err := q.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Status page setting keys
+const (
+	statusPageEnabledKey   = "status_page_enabled"
+	statusPageTitleKey     = "status_page_title"
+	statusPagePathKey      = "status_page_path"
+	statusPageSubdomainKey = "status_page_subdomain"
+)
+
+// GetStatusPageConfig retrieves the public status page settings
+func (q *SettingsQueries) GetStatusPageConfig(ctx context.Context) (*models.StatusPageConfig, error) {
+	values := make(map[string]string)
+	for _, key := range []string{statusPageEnabledKey, statusPageTitleKey, statusPagePathKey, statusPageSubdomainKey} {
+		v, err := q.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = v
+	}
+
+	return &models.StatusPageConfig{
+		Enabled:   values[statusPageEnabledKey] == "true",
+		Title:     values[statusPageTitleKey],
+		Path:      values[statusPagePathKey],
+		Subdomain: values[statusPageSubdomainKey],
+	}, nil
+}
+
+// SetStatusPageConfig stores the public status page settings
+func (q *SettingsQueries) SetStatusPageConfig(ctx context.Context, cfg *models.StatusPageConfig) error {
+	enabled := "false"
+	if cfg.Enabled {
+		enabled = "true"
+	}
+
+	return q.SetMultiple(ctx, map[string]string{
+		statusPageEnabledKey:   enabled,
+		statusPageTitleKey:     cfg.Title,
+		statusPagePathKey:      cfg.Path,
+		statusPageSubdomainKey: cfg.Subdomain,
+	})
+}
+
+// Two-factor authentication setting keys
+const (
+	totpEnabledKey       = "totp_enabled"
+	totpRequiredKey      = "totp_required"
+	totpSecretKey        = "totp_secret"
+	totpRecoveryCodesKey = "totp_recovery_codes"
+	totpLastCounterKey   = "totp_last_counter"
+
+	// TOTPPendingSecretKey holds a secret generated during setup until the
+	// first code confirms it
+	TOTPPendingSecretKey = "totp_pending_secret"
+)
+
+// GetTwoFactorConfig retrieves the owner's two-factor settings
+func (q *SettingsQueries) GetTwoFactorConfig(ctx context.Context) (*models.TwoFactorConfig, error) {
+	values := make(map[string]string)
+	for _, key := range []string{totpEnabledKey, totpRequiredKey, totpSecretKey, totpRecoveryCodesKey, totpLastCounterKey} {
+		v, err := q.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = v
+	}
+
+	cfg := &models.TwoFactorConfig{
+		Enabled:  values[totpEnabledKey] == "true" && values[totpSecretKey] != "",
+		Required: values[totpRequiredKey] == "true",
+		Secret:   values[totpSecretKey],
+	}
+	if values[totpRecoveryCodesKey] != "" {
+		cfg.RecoveryCodeHashes = strings.Split(values[totpRecoveryCodesKey], ",")
+	}
+	if values[totpLastCounterKey] != "" {
+		counter, err := strconv.ParseInt(values[totpLastCounterKey], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse TOTP counter: %w", err)
+		}
+		cfg.LastCounter = counter
+	}
+
+	return cfg, nil
+}
+
+// SetTwoFactorConfig stores the owner's two-factor settings
+func (q *SettingsQueries) SetTwoFactorConfig(ctx context.Context, cfg *models.TwoFactorConfig) error {
+	return q.SetMultiple(ctx, map[string]string{
+		totpEnabledKey:       strconv.FormatBool(cfg.Enabled),
+		totpRequiredKey:      strconv.FormatBool(cfg.Required),
+		totpSecretKey:        cfg.Secret,
+		totpRecoveryCodesKey: strings.Join(cfg.RecoveryCodeHashes, ","),
+		totpLastCounterKey:   strconv.FormatInt(cfg.LastCounter, 10),
+	})
+}
+
+// deployScheduleKey holds the global deploy windows and freezes as JSON
+const deployScheduleKey = "deploy_schedule"
+
+// GetDeploySchedule retrieves the deploy windows and freezes of all apps, or
+// nil if there are none
+func (q *SettingsQueries) GetDeploySchedule(ctx context.Context) (*models.DeploySchedule, error) {
+	value, err := q.Get(ctx, deployScheduleKey)
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		return nil, nil
+	}
+
+	schedule := &models.DeploySchedule{}
+	if err := json.Unmarshal([]byte(value), schedule); err != nil {
+		return nil, fmt.Errorf("failed to parse deploy schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+// SetDeploySchedule stores the deploy windows and freezes of all apps
+func (q *SettingsQueries) SetDeploySchedule(ctx context.Context, schedule *models.DeploySchedule) error {
+	if schedule.IsEmpty() {
+		return q.Delete(ctx, deployScheduleKey)
+	}
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return err
+	}
+	return q.Set(ctx, deployScheduleKey, string(data))
+}
+
+// autoDeployPausedKey is "true" while pushes are recorded without building
+const autoDeployPausedKey = "auto_deploy_paused"
+
+// IsAutoDeployPaused reports whether auto-deploys of all apps are paused
+func (q *SettingsQueries) IsAutoDeployPaused(ctx context.Context) (bool, error) {
+	value, err := q.Get(ctx, autoDeployPausedKey)
+	if err != nil {
+		return false, err
+	}
+	return value == "true", nil
+}
+
+// SetAutoDeployPaused pauses or resumes auto-deploys of all apps
+func (q *SettingsQueries) SetAutoDeployPaused(ctx context.Context, paused bool) error {
+	if !paused {
+		return q.Delete(ctx, autoDeployPausedKey)
+	}
+	return q.Set(ctx, autoDeployPausedKey, "true")
+}
+
+// buildWorkersKey is how many builds run at once
+const buildWorkersKey = "build_workers"
+
+// GetBuildWorkers returns how many builds run at once, or 0 if not set
+func (q *SettingsQueries) GetBuildWorkers(ctx context.Context) (int, error) {
+	value, err := q.Get(ctx, buildWorkersKey)
+	if err != nil || value == "" {
+		return 0, err
+	}
+	workers, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse build workers: %w", err)
+	}
+	return workers, nil
+}
+
+// SetBuildWorkers stores how many builds run at once
+func (q *SettingsQueries) SetBuildWorkers(ctx context.Context, workers int) error {
+	return q.Set(ctx, buildWorkersKey, strconv.Itoa(workers))
+}
+
+// registryCredentialsKey holds the logins images are pushed with, as JSON
+const registryCredentialsKey = "registry_credentials"
+
+// GetRegistryCredentials returns the registry logins images are pushed with
+func (q *SettingsQueries) GetRegistryCredentials(ctx context.Context) ([]models.RegistryCredential, error) {
+	value, err := q.Get(ctx, registryCredentialsKey)
+	if err != nil || value == "" {
+		return nil, err
+	}
+	var credentials []models.RegistryCredential
+	if err := json.Unmarshal([]byte(value), &credentials); err != nil {
+		return nil, fmt.Errorf("failed to parse registry credentials: %w", err)
+	}
+	return credentials, nil
+}
+
+// SetRegistryCredential adds the login for a registry, replacing any it had
+func (q *SettingsQueries) SetRegistryCredential(ctx context.Context, credential models.RegistryCredential) error {
+	credentials, err := q.GetRegistryCredentials(ctx)
+	if err != nil {
+		return err
+	}
+	credentials = slices.DeleteFunc(credentials, func(c models.RegistryCredential) bool {
+		return c.Registry == credential.Registry
+	})
+	return q.setRegistryCredentials(ctx, append(credentials, credential))
+}
+
+// DeleteRegistryCredential removes the login for a registry, reporting
+// whether there was one
+func (q *SettingsQueries) DeleteRegistryCredential(ctx context.Context, registry string) (bool, error) {
+	credentials, err := q.GetRegistryCredentials(ctx)
+	if err != nil {
+		return false, err
+	}
+	kept := slices.DeleteFunc(slices.Clone(credentials), func(c models.RegistryCredential) bool {
+		return c.Registry == registry
+	})
+	if len(kept) == len(credentials) {
+		return false, nil
+	}
+	return true, q.setRegistryCredentials(ctx, kept)
+}
+
+func (q *SettingsQueries) setRegistryCredentials(ctx context.Context, credentials []models.RegistryCredential) error {
+	if len(credentials) == 0 {
+		return q.Delete(ctx, registryCredentialsKey)
+	}
+	data, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+	return q.Set(ctx, registryCredentialsKey, string(data))
+}
@@ -0,0 +1,98 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"schooner/internal/models"
+)
+
+// SystemHealthQueries provides database operations for host health history
+type SystemHealthQueries struct {
+	db *sqlx.DB
+}
+
+// NewSystemHealthQueries creates a new SystemHealthQueries instance
+func NewSystemHealthQueries(db *sqlx.DB) *SystemHealthQueries {
+	return &SystemHealthQueries{db: db}
+}
+
+const insertSystemSamples = `
+		INSERT INTO system_samples (resolution, cpu_percent, load_avg_1, memory_used, memory_total, disk_used, disk_total, sampled_at)
+		VALUES (:resolution, :cpu_percent, :load_avg_1, :memory_used, :memory_total, :disk_used, :disk_total, :sampled_at)`
+
+// RecordSample stores a sample
+func (q *SystemHealthQueries) RecordSample(ctx context.Context, sample *models.SystemSample) error {
+	if _, err := q.db.NamedExecContext(ctx, insertSystemSamples, sample); err != nil {
+		return fmt.Errorf("failed to record system sample: %w", err)
+	}
+	return nil
+}
+
+// ListSince retrieves samples since the given time, oldest first
+func (q *SystemHealthQueries) ListSince(ctx context.Context, since time.Time) ([]*models.SystemSample, error) {
+	var samples []*models.SystemSample
+	query := `
+		SELECT * FROM system_samples
+		WHERE sampled_at >= ?
+		ORDER BY sampled_at ASC, id ASC`
+
+	err := q.db.SelectContext(ctx, &samples, query, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list system samples: %w", err)
+	}
+
+	return samples, nil
+}
+
+// Downsample rolls the minute samples taken before the given time up into
+// hourly ones, returning how many minute samples were replaced
+func (q *SystemHealthQueries) Downsample(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := q.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var samples []*models.SystemSample
+	err = tx.SelectContext(ctx, &samples, `
+		SELECT * FROM system_samples
+		WHERE resolution = ? AND sampled_at < ?
+		ORDER BY sampled_at ASC, id ASC`, models.ResourceResolutionMinute, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to list system samples: %w", err)
+	}
+	if len(samples) == 0 {
+		return 0, nil
+	}
+
+	hourly := models.DownsampleSystem(samples, time.Hour)
+	for _, s := range hourly {
+		s.Resolution = models.ResourceResolutionHour
+	}
+	if _, err := tx.NamedExecContext(ctx, insertSystemSamples, hourly); err != nil {
+		return 0, fmt.Errorf("failed to record hourly system samples: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM system_samples WHERE resolution = ? AND sampled_at < ?`,
+		models.ResourceResolutionMinute, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete minute system samples: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit downsampled system samples: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// PruneSamples deletes samples older than the given time
+func (q *SystemHealthQueries) PruneSamples(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, `DELETE FROM system_samples WHERE sampled_at < ?`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune system samples: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,201 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"schooner/internal/models"
+)
+
+// UptimeQueries provides database operations for uptime checks and results
+type UptimeQueries struct {
+	db *sqlx.DB
+}
+
+// NewUptimeQueries creates a new UptimeQueries instance
+func NewUptimeQueries(db *sqlx.DB) *UptimeQueries {
+	return &UptimeQueries{db: db}
+}
+
+// GetByAppID retrieves the uptime check for an app
+func (q *UptimeQueries) GetByAppID(ctx context.Context, appID string) (*models.UptimeCheck, error) {
+	var check models.UptimeCheck
+	query := `
+		SELECT c.*, a.name as app_name
+		FROM uptime_checks c
+		JOIN apps a ON c.app_id = a.id
+		WHERE c.app_id = ?`
+
+	err := q.db.GetContext(ctx, &check, query, appID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get uptime check: %w", err)
+	}
+
+	return &check, nil
+}
+
+// List retrieves all uptime checks
+func (q *UptimeQueries) List(ctx context.Context) ([]*models.UptimeCheck, error) {
+	var checks []*models.UptimeCheck
+	query := `
+		SELECT c.*, a.name as app_name
+		FROM uptime_checks c
+		JOIN apps a ON c.app_id = a.id
+		ORDER BY a.name`
+
+	err := q.db.SelectContext(ctx, &checks, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list uptime checks: %w", err)
+	}
+
+	return checks, nil
+}
+
+// ListEnabled retrieves enabled uptime checks for enabled apps
+func (q *UptimeQueries) ListEnabled(ctx context.Context) ([]*models.UptimeCheck, error) {
+	var checks []*models.UptimeCheck
+	query := `
+		SELECT c.*, a.name as app_name
+		FROM uptime_checks c
+		JOIN apps a ON c.app_id = a.id
+		WHERE c.enabled = 1 AND a.enabled = 1
+		ORDER BY a.name`
+
+	err := q.db.SelectContext(ctx, &checks, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list enabled uptime checks: %w", err)
+	}
+
+	return checks, nil
+}
+
+// Save creates or replaces the uptime check for an app
+func (q *UptimeQueries) Save(ctx context.Context, check *models.UptimeCheck) error {
+	check.UpdatedAt = time.Now()
+	query := `
+		INSERT INTO uptime_checks (
+			id, app_id, url, interval_seconds, timeout_seconds,
+			expected_status, enabled, created_at, updated_at
+		) VALUES (
+			:id, :app_id, :url, :interval_seconds, :timeout_seconds,
+			:expected_status, :enabled, :created_at, :updated_at
+		)
+		ON CONFLICT(app_id) DO UPDATE SET
+			url = excluded.url,
+			interval_seconds = excluded.interval_seconds,
+			timeout_seconds = excluded.timeout_seconds,
+			expected_status = excluded.expected_status,
+			enabled = excluded.enabled,
+			updated_at = excluded.updated_at`
+
+	_, err := q.db.NamedExecContext(ctx, query, check)
+	if err != nil {
+		return fmt.Errorf("failed to save uptime check: %w", err)
+	}
+	return nil
+}
+
+// DeleteByAppID removes an app's uptime check and its history
+func (q *UptimeQueries) DeleteByAppID(ctx context.Context, appID string) error {
+	query := `DELETE FROM uptime_checks WHERE app_id = ?`
+
+	_, err := q.db.ExecContext(ctx, query, appID)
+	if err != nil {
+		return fmt.Errorf("failed to delete uptime check: %w", err)
+	}
+	return nil
+}
+
+// RecordResult stores a probe result and updates the check's last known state
+func (q *UptimeQueries) RecordResult(ctx context.Context, result *models.UptimeResult, status models.UptimeStatus) error {
+	tx, err := q.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.NamedExecContext(ctx, `
+		INSERT INTO uptime_results (check_id, up, status_code, response_ms, error, checked_at)
+		VALUES (:check_id, :up, :status_code, :response_ms, :error, :checked_at)`, result)
+	if err != nil {
+		return fmt.Errorf("failed to insert uptime result: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE uptime_checks
+		SET last_status = ?, last_checked_at = ?, last_response_ms = ?
+		WHERE id = ?`, status, result.CheckedAt, result.ResponseMs, result.CheckID)
+	if err != nil {
+		return fmt.Errorf("failed to update uptime check: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// ListResults retrieves the most recent results for a check, newest first
+func (q *UptimeQueries) ListResults(ctx context.Context, checkID string, limit int) ([]*models.UptimeResult, error) {
+	var results []*models.UptimeResult
+	query := `
+		SELECT * FROM uptime_results
+		WHERE check_id = ?
+		ORDER BY checked_at DESC
+		LIMIT ?`
+
+	err := q.db.SelectContext(ctx, &results, query, checkID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list uptime results: %w", err)
+	}
+
+	return results, nil
+}
+
+// ListResultsSince retrieves a check's results since the given time, oldest first
+func (q *UptimeQueries) ListResultsSince(ctx context.Context, checkID string, since time.Time) ([]*models.UptimeResult, error) {
+	var results []*models.UptimeResult
+	query := `
+		SELECT * FROM uptime_results
+		WHERE check_id = ? AND checked_at >= ?
+		ORDER BY checked_at ASC`
+
+	err := q.db.SelectContext(ctx, &results, query, checkID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list uptime results: %w", err)
+	}
+
+	return results, nil
+}
+
+// GetStats summarizes a check's results since the given time
+func (q *UptimeQueries) GetStats(ctx context.Context, checkID string, since time.Time) (*models.UptimeStats, error) {
+	var stats models.UptimeStats
+	query := `
+		SELECT
+			COUNT(*) as checks,
+			COALESCE(SUM(up), 0) as up_checks,
+			COALESCE(AVG(CASE WHEN up = 1 THEN response_ms END), 0) as avg_response_ms
+		FROM uptime_results
+		WHERE check_id = ? AND checked_at >= ?`
+
+	err := q.db.GetContext(ctx, &stats, query, checkID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get uptime stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// PruneResults deletes results older than the given time
+func (q *UptimeQueries) PruneResults(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, `DELETE FROM uptime_results WHERE checked_at < ?`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune uptime results: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,204 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+const (
+	// bootWindow is how recently an app must have started for its start to
+	// count as part of the host booting
+	bootWindow = 10 * time.Minute
+
+	// bootReadyTimeout is how long an app gets to come up before the apps
+	// that depend on it are left alone
+	bootReadyTimeout = 3 * time.Minute
+)
+
+// AppLister lists the apps that should be running
+type AppLister interface {
+	ListEnabled(ctx context.Context) ([]*models.App, error)
+}
+
+// DependencyGraphs loads which apps depend on which
+type DependencyGraphs interface {
+	Graph(ctx context.Context) (models.DependencyGraph, error)
+}
+
+// Containers inspects and restarts app containers
+type Containers interface {
+	GetContainerStatus(ctx context.Context, nameOrID string) (*docker.ContainerStatus, error)
+	RestartContainer(ctx context.Context, nameOrID string, timeout time.Duration) error
+}
+
+// DockerHostResolver returns the Docker client for the host an app runs on
+type DockerHostResolver interface {
+	ForApp(ctx context.Context, app *models.App) (*docker.Client, error)
+}
+
+// BootOrder restarts apps that Docker brought back up after the host
+// rebooted before the apps they depend on, such as their database, were up.
+// Apps are checked in dependency order, each waiting until the apps it
+// depends on run and are healthy.
+type BootOrder struct {
+	apps         AppLister
+	dependencies DependencyGraphs
+	dockerClient Containers
+	dockerHosts  DockerHostResolver
+	interval     time.Duration
+	logger       *slog.Logger
+}
+
+// NewBootOrder creates a BootOrder
+func NewBootOrder(apps AppLister, dependencies DependencyGraphs, dockerClient Containers) *BootOrder {
+	return &BootOrder{
+		apps:         apps,
+		dependencies: dependencies,
+		dockerClient: dockerClient,
+		interval:     2 * time.Second,
+		logger:       slog.Default(),
+	}
+}
+
+// SetDockerHosts makes apps on remote Docker hosts be checked on their host
+func (b *BootOrder) SetDockerHosts(hosts DockerHostResolver) {
+	b.dockerHosts = hosts
+}
+
+// containersFor returns the Docker client for an app's host
+func (b *BootOrder) containersFor(ctx context.Context, app *models.App) (Containers, error) {
+	if b.dockerHosts == nil || app.GetDockerHost() == "" {
+		return b.dockerClient, nil
+	}
+	return b.dockerHosts.ForApp(ctx, app)
+}
+
+// Run checks the start order of every enabled app once
+func (b *BootOrder) Run(ctx context.Context) error {
+	graph, err := b.dependencies.Graph(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load app dependencies: %w", err)
+	}
+	if len(graph) == 0 {
+		return nil
+	}
+	apps, err := b.apps.ListEnabled(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list apps: %w", err)
+	}
+
+	// Agents, Swarm and Compose manage their own containers
+	byID := make(map[string]*models.App)
+	var ids []string
+	for _, app := range apps {
+		deployConfig, _ := app.GetDeployConfig()
+		if app.GetAgent() != "" || (deployConfig != nil && deployConfig.IsSwarm()) || app.BuildStrategy == models.BuildStrategyCompose {
+			continue
+		}
+		byID[app.ID] = app
+		ids = append(ids, app.ID)
+	}
+	waves, err := graph.Order(ids)
+	if err != nil {
+		return err
+	}
+
+	booted := time.Now().Add(-bootWindow)
+	ready := make(map[string]time.Time) // When each app that's up started
+	for _, wave := range waves {
+		for _, id := range wave {
+			app := byID[id]
+			containers, err := b.containersFor(ctx, app)
+			if err != nil {
+				b.logger.Warn("boot order failed to reach docker host", "app", app.Name, "error", err)
+				continue
+			}
+
+			var latest time.Time // When the last of the app's dependencies started
+			up := true
+			for _, dep := range graph[id] {
+				if _, ok := byID[dep]; !ok {
+					continue
+				}
+				started, ok := ready[dep]
+				if !ok {
+					up = false
+					break
+				}
+				if started.After(latest) {
+					latest = started
+				}
+			}
+			if !up {
+				b.logger.Warn("app's dependencies are not up, leaving it as it is", "app", app.Name)
+				continue
+			}
+			if latest.After(booted) {
+				b.restartIfEarly(ctx, containers, app, latest)
+			}
+
+			if started, ok := b.awaitReady(ctx, containers, app); ok {
+				ready[id] = started
+			}
+		}
+	}
+	return nil
+}
+
+// restartIfEarly restarts an app that started before the last of its
+// dependencies did, or that keeps failing while they came up
+func (b *BootOrder) restartIfEarly(ctx context.Context, containers Containers, app *models.App, dependenciesStarted time.Time) {
+	status, err := containers.GetContainerStatus(ctx, app.GetContainerName())
+	if err != nil || status == nil || status.ID == "" {
+		return
+	}
+	started, _ := time.Parse(time.RFC3339Nano, status.StartedAt)
+	early := status.State == "running" && started.Before(dependenciesStarted)
+	if !early && status.State != "restarting" {
+		return
+	}
+
+	b.logger.Info("restarting app that started before its dependencies", "app", app.Name, "state", status.State)
+	if err := containers.RestartContainer(ctx, status.ID, 30*time.Second); err != nil {
+		b.logger.Warn("failed to restart app in boot order", "app", app.Name, "error", err)
+	}
+}
+
+// awaitReady waits until an app runs and, if it has a health check, is
+// healthy, and returns when it started. It gives up on apps that aren't
+// coming up, such as ones that were stopped.
+func (b *BootOrder) awaitReady(ctx context.Context, containers Containers, app *models.App) (time.Time, bool) {
+	deadline := time.Now().Add(bootReadyTimeout)
+	for {
+		status, err := containers.GetContainerStatus(ctx, app.GetContainerName())
+		if err != nil || status == nil {
+			return time.Time{}, false
+		}
+		switch {
+		case status.State == "running" && (status.Health == "" || status.Health == "healthy"):
+			started, err := time.Parse(time.RFC3339Nano, status.StartedAt)
+			if err != nil {
+				started = time.Now()
+			}
+			return started, true
+		case status.State == "running" && status.Health == "starting", status.State == "restarting":
+		default:
+			return time.Time{}, false
+		}
+
+		if time.Now().After(deadline) {
+			b.logger.Warn("app did not come up in time for the apps depending on it", "app", app.Name)
+			return time.Time{}, false
+		}
+		select {
+		case <-ctx.Done():
+			return time.Time{}, false
+		case <-time.After(b.interval):
+		}
+	}
+}
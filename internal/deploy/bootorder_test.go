@@ -0,0 +1,100 @@
+package deploy
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+type fakeApps []*models.App
+
+func (f fakeApps) ListEnabled(ctx context.Context) ([]*models.App, error) {
+	return f, nil
+}
+
+type fakeGraph models.DependencyGraph
+
+func (f fakeGraph) Graph(ctx context.Context) (models.DependencyGraph, error) {
+	return models.DependencyGraph(f), nil
+}
+
+// fakeContainers serves container statuses by name and records restarts
+type fakeContainers struct {
+	statuses  map[string]*docker.ContainerStatus
+	restarted []string
+}
+
+func (f *fakeContainers) GetContainerStatus(ctx context.Context, name string) (*docker.ContainerStatus, error) {
+	if status, ok := f.statuses[name]; ok {
+		return status, nil
+	}
+	return &docker.ContainerStatus{Name: name, State: "not_found"}, nil
+}
+
+func (f *fakeContainers) RestartContainer(ctx context.Context, id string, timeout time.Duration) error {
+	f.restarted = append(f.restarted, id)
+	status := f.statuses[id]
+	status.State = "running"
+	status.StartedAt = time.Now().Format(time.RFC3339Nano)
+	return nil
+}
+
+func TestBootOrder_Run(t *testing.T) {
+	now := time.Now()
+	at := func(ago time.Duration) string { return now.Add(-ago).Format(time.RFC3339Nano) }
+	app := func(name string) *models.App {
+		return &models.App{ID: name, Name: name, BuildStrategy: models.BuildStrategyDockerfile}
+	}
+
+	// The database came up after the API and the worker, which were started
+	// by Docker at boot; the API keeps crashing without it. The report app
+	// started after the database, and the docs don't depend on anything.
+	containers := &fakeContainers{statuses: map[string]*docker.ContainerStatus{
+		"db":     {ID: "db", State: "running", Health: "healthy", StartedAt: at(time.Minute)},
+		"api":    {ID: "api", State: "restarting", StartedAt: at(2 * time.Minute)},
+		"worker": {ID: "worker", State: "running", StartedAt: at(2 * time.Minute)},
+		"report": {ID: "report", State: "running", StartedAt: at(30 * time.Second)},
+		"docs":   {ID: "docs", State: "running", StartedAt: at(2 * time.Minute)},
+		"web":    {ID: "web", State: "exited", StartedAt: at(2 * time.Minute)},
+		"admin":  {ID: "admin", State: "running", StartedAt: at(2 * time.Minute)},
+	}}
+	apps := fakeApps{app("db"), app("api"), app("worker"), app("report"), app("docs"), app("web"), app("admin")}
+	graph := fakeGraph{
+		"api":    {"db"},
+		"worker": {"db"},
+		"report": {"db"},
+		"admin":  {"web"}, // web was stopped, so admin is left alone
+	}
+
+	b := NewBootOrder(apps, graph, containers)
+	b.interval = time.Millisecond
+	if err := b.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if want := []string{"api", "worker"}; !reflect.DeepEqual(containers.restarted, want) {
+		t.Errorf("restarted %v, want %v", containers.restarted, want)
+	}
+}
+
+func TestBootOrder_Run_LongRunning(t *testing.T) {
+	// Apps that have been up since long before aren't restarted when only
+	// Schooner restarts
+	day := time.Now().Add(-24 * time.Hour)
+	containers := &fakeContainers{statuses: map[string]*docker.ContainerStatus{
+		"db":  {ID: "db", State: "running", StartedAt: day.Format(time.RFC3339Nano)},
+		"api": {ID: "api", State: "running", StartedAt: day.Add(-time.Hour).Format(time.RFC3339Nano)},
+	}}
+	apps := fakeApps{{ID: "db", Name: "db"}, {ID: "api", Name: "api"}}
+
+	b := NewBootOrder(apps, fakeGraph{"api": {"db"}}, containers)
+	if err := b.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(containers.restarted) != 0 {
+		t.Errorf("restarted %v, want none", containers.restarted)
+	}
+}
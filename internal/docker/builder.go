@@ -0,0 +1,169 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"schooner/internal/metrics"
+)
+
+// Socket is where a daemon listens on its own host, mounted into builder
+// containers that build with it
+const Socket = "/var/run/docker.sock"
+
+// BuilderConfig is a disposable container a build runs in. It only sees the
+// files copied into it, and the daemon's socket if it builds with it, never
+// Schooner's data.
+type BuilderConfig struct {
+	Name      string
+	Image     string
+	Cmd       []string // Program and its arguments, replacing the image's entrypoint
+	Env       []string
+	WorkDir   string            // Where Files are extracted and Cmd runs
+	Files     io.Reader         // Tar archive of the files the build needs
+	Secrets   map[string][]byte // Files by absolute path kept out of WorkDir, such as registry logins
+	Volumes   map[string]string // Named volume to the path it's mounted at, such as package caches
+	Labels    map[string]string
+	Socket    bool     // Mount the daemon's socket to build with it
+	Network   string   // Network to join, none if empty
+	CapAdd    []string // Capabilities kept, all others are dropped
+	Memory    int64    // Bytes, 0 for no limit
+	PidsLimit int64    // 0 for no limit
+
+	// Output is a file handed to Collect, as a tar archive, once the
+	// builder exits with 0
+	Output  string
+	Collect func(archive io.Reader) error
+}
+
+// RunBuilder runs a build in a disposable container with no capabilities
+// beyond those asked for, streams its output and returns its exit code. The
+// container is removed afterwards.
+func (c *Client) RunBuilder(ctx context.Context, cfg BuilderConfig, stdout, stderr io.Writer) (int64, error) {
+	defer metrics.ObserveDocker("run_builder", time.Now())
+
+	if len(cfg.Cmd) == 0 {
+		return -1, fmt.Errorf("builder has no command")
+	}
+	if err := c.ensureImage(ctx, cfg.Image); err != nil {
+		return -1, fmt.Errorf("failed to ensure builder image: %w", err)
+	}
+
+	hostConfig := &container.HostConfig{
+		NetworkMode: "none",
+		CapDrop:     []string{"ALL"},
+		CapAdd:      cfg.CapAdd,
+		SecurityOpt: []string{"no-new-privileges"},
+		Resources:   container.Resources{Memory: cfg.Memory},
+	}
+	if cfg.Socket {
+		hostConfig.Binds = []string{Socket + ":" + Socket}
+	}
+	hostConfig.Binds = append(hostConfig.Binds, toBinds(cfg.Volumes)...)
+	if cfg.Network != "" {
+		hostConfig.NetworkMode = container.NetworkMode(cfg.Network)
+	}
+	if cfg.PidsLimit > 0 {
+		hostConfig.Resources.PidsLimit = &cfg.PidsLimit
+	}
+
+	resp, err := c.cli.ContainerCreate(ctx, &container.Config{
+		Image:      cfg.Image,
+		Entrypoint: cfg.Cmd[:1],
+		Cmd:        cfg.Cmd[1:],
+		Env:        cfg.Env,
+		WorkingDir: cfg.WorkDir,
+		Labels:     cfg.Labels,
+	}, hostConfig, nil, nil, cfg.Name)
+	if err != nil {
+		return -1, fmt.Errorf("failed to create builder container: %w", err)
+	}
+	defer c.cli.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true, RemoveVolumes: true})
+
+	if cfg.Files != nil {
+		if err := c.CopyToContainer(ctx, resp.ID, cfg.WorkDir, cfg.Files); err != nil {
+			return -1, err
+		}
+	}
+	if len(cfg.Secrets) > 0 {
+		secrets, err := tarFiles(cfg.Secrets)
+		if err != nil {
+			return -1, err
+		}
+		if err := c.CopyToContainer(ctx, resp.ID, "/", secrets); err != nil {
+			return -1, err
+		}
+	}
+
+	// Wait before starting, so a builder that exits at once isn't missed
+	statusCh, errCh := c.cli.ContainerWait(ctx, resp.ID, container.WaitConditionNextExit)
+	if err := c.cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return -1, fmt.Errorf("failed to start builder container: %w", err)
+	}
+
+	logs, err := c.cli.ContainerLogs(ctx, resp.ID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+	})
+	if err == nil {
+		stdcopy.StdCopy(stdout, stderr, logs)
+		logs.Close()
+	}
+
+	var exitCode int64
+	select {
+	case err := <-errCh:
+		return -1, fmt.Errorf("failed to wait for builder container: %w", err)
+	case status := <-statusCh:
+		if status.Error != nil {
+			return -1, fmt.Errorf("builder container failed: %s", status.Error.Message)
+		}
+		exitCode = status.StatusCode
+	}
+
+	if exitCode == 0 && cfg.Output != "" && cfg.Collect != nil {
+		output, err := c.CopyFromContainer(ctx, resp.ID, cfg.Output)
+		if err != nil {
+			return -1, err
+		}
+		defer output.Close()
+		if err := cfg.Collect(output); err != nil {
+			return -1, err
+		}
+	}
+	return exitCode, nil
+}
+
+// tarFiles archives files by absolute path, readable only by their owner
+func tarFiles(files map[string][]byte) (io.Reader, error) {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, path := range paths {
+		data := files[path]
+		if err := tw.WriteHeader(&tar.Header{Name: path, Mode: 0600, Size: int64(len(data)), ModTime: time.Now()}); err != nil {
+			return nil, fmt.Errorf("failed to archive %s: %w", path, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to archive %s: %w", path, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
@@ -1,11 +1,14 @@
 package docker
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -13,17 +16,25 @@ import (
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/versions"
+	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
+
+	"schooner/internal/metrics"
 )
 
 // Client wraps the Docker client with additional functionality
 type Client struct {
 	cli    *client.Client
 	logger *slog.Logger
+	env    []string   // Points the docker CLI at a remote daemon, nil for local
+	tunnel *sshTunnel // Forwards to an SSH host's daemon, nil otherwise
 }
 
-// NewClient creates a new Docker client
+// NewClient creates a new Docker client. It traces its API calls through the
+// global OpenTelemetry tracer provider, under the span of the call's context.
 func NewClient() (*Client, error) {
 	cli, err := client.NewClientWithOpts(
 		client.FromEnv,
@@ -41,11 +52,26 @@ func NewClient() (*Client, error) {
 
 // Close closes the Docker client
 func (c *Client) Close() error {
+	if c.tunnel != nil {
+		c.tunnel.Close()
+	}
 	return c.cli.Close()
 }
 
+// Env returns the environment that points docker CLI commands, such as
+// docker compose, at this client's daemon. It is empty for the local daemon.
+func (c *Client) Env() []string {
+	return c.env
+}
+
+// IsRemote reports whether the client talks to a daemon on another machine
+func (c *Client) IsRemote() bool {
+	return c.env != nil
+}
+
 // Ping checks if Docker is responsive
 func (c *Client) Ping(ctx context.Context) error {
+	defer metrics.ObserveDocker("ping", time.Now())
 	_, err := c.cli.Ping(ctx)
 	return err
 }
@@ -60,6 +86,16 @@ type ContainerConfig struct {
 	Volumes       map[string]string // host:container
 	Networks      []string
 	NetworkMode   string // e.g., "host", "bridge"
+	PidMode       string // e.g., "host"
+	Privileged    bool
+	Devices       []string // host device paths, or host:container[:permissions]
+	CapAdd        []string
+	CapDrop       []string
+	Sysctls       map[string]string
+	ShmSize       int64    // bytes, 0 for Docker's default
+	GPUCount      int      // GPUs to give the container, -1 for all
+	GPUIDs        []string // IDs of the GPUs to give the container, instead of a count
+	Runtime       string   // e.g. "nvidia", empty for the daemon's default
 	RestartPolicy string
 	Labels        map[string]string
 }
@@ -75,10 +111,13 @@ type ContainerStatus struct {
 	Ports     map[string]string `json:"ports,omitempty"`
 	Image     string            `json:"image"`
 	CreatedAt string            `json:"created_at"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Restarts  int               `json:"restart_count"`
 }
 
 // RunContainer creates and starts a container
 func (c *Client) RunContainer(ctx context.Context, cfg ContainerConfig) (string, error) {
+	defer metrics.ObserveDocker("run", time.Now())
 	c.logger.Info("running container", "name", cfg.Name, "image", cfg.Image)
 
 	// Ensure image exists
@@ -103,6 +142,15 @@ func (c *Client) RunContainer(ctx context.Context, cfg ContainerConfig) (string,
 		RestartPolicy: container.RestartPolicy{
 			Name: container.RestartPolicyMode(cfg.RestartPolicy),
 		},
+		Resources: container.Resources{
+			Devices:        toDeviceMappings(cfg.Devices),
+			DeviceRequests: toDeviceRequests(cfg.GPUCount, cfg.GPUIDs),
+		},
+		CapAdd:  cfg.CapAdd,
+		CapDrop: cfg.CapDrop,
+		Sysctls: cfg.Sysctls,
+		ShmSize: cfg.ShmSize,
+		Runtime: cfg.Runtime,
 	}
 
 	// Build network config
@@ -129,8 +177,61 @@ func (c *Client) RunContainer(ctx context.Context, cfg ContainerConfig) (string,
 	return resp.ID, nil
 }
 
+// RunToCompletion runs a one-off container from an image that's already
+// present, streams its stdout and stderr and returns its exit code. The
+// container is removed afterwards.
+func (c *Client) RunToCompletion(ctx context.Context, cfg ContainerConfig, stdout, stderr io.Writer) (int64, error) {
+	defer metrics.ObserveDocker("run_once", time.Now())
+
+	networkConfig := &network.NetworkingConfig{}
+	if len(cfg.Networks) > 0 {
+		networkConfig.EndpointsConfig = make(map[string]*network.EndpointSettings)
+		for _, net := range cfg.Networks {
+			networkConfig.EndpointsConfig[net] = &network.EndpointSettings{}
+		}
+	}
+
+	resp, err := c.cli.ContainerCreate(ctx, &container.Config{
+		Image:  cfg.Image,
+		Cmd:    cfg.Cmd,
+		Env:    cfg.Env,
+		Labels: cfg.Labels,
+	}, &container.HostConfig{}, networkConfig, nil, cfg.Name)
+	if err != nil {
+		return -1, fmt.Errorf("failed to create container: %w", err)
+	}
+	defer c.cli.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
+
+	// Wait before starting, so a container that exits at once isn't missed
+	statusCh, errCh := c.cli.ContainerWait(ctx, resp.ID, container.WaitConditionNextExit)
+	if err := c.cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return -1, fmt.Errorf("failed to start container: %w", err)
+	}
+
+	logs, err := c.cli.ContainerLogs(ctx, resp.ID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+	})
+	if err == nil {
+		stdcopy.StdCopy(stdout, stderr, logs)
+		logs.Close()
+	}
+
+	select {
+	case err := <-errCh:
+		return -1, fmt.Errorf("failed to wait for container: %w", err)
+	case status := <-statusCh:
+		if status.Error != nil {
+			return -1, fmt.Errorf("container failed: %s", status.Error.Message)
+		}
+		return status.StatusCode, nil
+	}
+}
+
 // StopAndRemove stops and removes a container
 func (c *Client) StopAndRemove(ctx context.Context, nameOrID string) error {
+	defer metrics.ObserveDocker("stop_remove", time.Now())
 	timeout := 30
 	stopOptions := container.StopOptions{Timeout: &timeout}
 
@@ -149,8 +250,53 @@ func (c *Client) StopAndRemove(ctx context.Context, nameOrID string) error {
 	return nil
 }
 
+// RemoveAppContainers stops and removes the container called name and any
+// others labelled with the app's ID, and returns the named volumes they
+// mounted
+func (c *Client) RemoveAppContainers(ctx context.Context, name, appID string) ([]string, error) {
+	defer metrics.ObserveDocker("remove_app", time.Now())
+	seen := make(map[string]bool)
+	var ids []string
+	volumes := make(map[string]bool)
+	for _, filter := range []filters.KeyValuePair{
+		filters.Arg("name", "^/"+name+"$"),
+		filters.Arg("label", "schooner.app-id="+appID),
+	} {
+		containers, err := c.cli.ContainerList(ctx, container.ListOptions{All: true, Filters: filters.NewArgs(filter)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list containers: %w", err)
+		}
+		for _, ctr := range containers {
+			if seen[ctr.ID] {
+				continue
+			}
+			seen[ctr.ID] = true
+			ids = append(ids, ctr.ID)
+			for _, m := range ctr.Mounts {
+				if m.Type == "volume" && m.Name != "" {
+					volumes[m.Name] = true
+				}
+			}
+		}
+	}
+
+	for _, id := range ids {
+		if err := c.StopAndRemove(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	mounted := make([]string, 0, len(volumes))
+	for v := range volumes {
+		mounted = append(mounted, v)
+	}
+	sort.Strings(mounted)
+	return mounted, nil
+}
+
 // GetContainerStatus retrieves status of a container by name/ID, falling back to label lookup
 func (c *Client) GetContainerStatus(ctx context.Context, nameOrID string) (*ContainerStatus, error) {
+	defer metrics.ObserveDocker("inspect", time.Now())
 	info, err := c.cli.ContainerInspect(ctx, nameOrID)
 	if err != nil {
 		if client.IsErrNotFound(err) {
@@ -173,6 +319,11 @@ func (c *Client) getContainerStatusByLabel(ctx context.Context, appName string)
 	}
 
 	if len(containers) == 0 {
+		// Swarm apps may run all their tasks on other nodes. Daemons
+		// outside a Swarm fail the lookup, which is fine to ignore.
+		if status, err := c.getServiceStatus(ctx, appName); err == nil && status != nil {
+			return status, nil
+		}
 		return &ContainerStatus{Name: appName, State: "not_found"}, nil
 	}
 
@@ -196,6 +347,8 @@ func (c *Client) containerInfoToStatus(info types.ContainerJSON) *ContainerStatu
 		Image:     info.Config.Image,
 		CreatedAt: info.Created,
 		Ports:     extractPorts(info.NetworkSettings.Ports),
+		Labels:    info.Config.Labels,
+		Restarts:  info.RestartCount,
 	}
 
 	if info.State.Health != nil {
@@ -205,6 +358,61 @@ func (c *Client) containerInfoToStatus(info types.ContainerJSON) *ContainerStatu
 	return status
 }
 
+// InspectContainer returns the full configuration and state of a container
+func (c *Client) InspectContainer(ctx context.Context, nameOrID string) (types.ContainerJSON, error) {
+	defer metrics.ObserveDocker("inspect", time.Now())
+	info, err := c.cli.ContainerInspect(ctx, nameOrID)
+	if err != nil {
+		return types.ContainerJSON{}, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	return info, nil
+}
+
+// ImageConfig returns the defaults an image gives its containers, such as
+// its env, command and labels
+func (c *Client) ImageConfig(ctx context.Context, ref string) (*container.Config, error) {
+	info, _, err := c.cli.ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect image: %w", err)
+	}
+	if info.Config == nil {
+		return &container.Config{}, nil
+	}
+	return info.Config, nil
+}
+
+// ImageRepoDigests returns the registry digests of an image, empty for
+// images built locally and never pushed
+func (c *Client) ImageRepoDigests(ctx context.Context, ref string) ([]string, error) {
+	info, _, err := c.cli.ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect image: %w", err)
+	}
+	return info.RepoDigests, nil
+}
+
+// ImageID returns the content ID of an image, and its registry digest or
+// an empty string if it was never pushed
+func (c *Client) ImageID(ctx context.Context, ref string) (id, digest string, err error) {
+	info, _, err := c.cli.ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to inspect image: %w", err)
+	}
+	if len(info.RepoDigests) > 0 {
+		digest = info.RepoDigests[0]
+	}
+	return info.ID, digest, nil
+}
+
+// ServerVersion returns the version of the Docker daemon
+func (c *Client) ServerVersion(ctx context.Context) (string, error) {
+	version, err := c.cli.ServerVersion(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get docker version: %w", err)
+	}
+	return version.Version, nil
+}
+
 // GetContainerRunArgs returns the docker run arguments needed to recreate a container
 func (c *Client) GetContainerRunArgs(ctx context.Context, nameOrID string) ([]string, error) {
 	info, err := c.cli.ContainerInspect(ctx, nameOrID)
@@ -260,6 +468,7 @@ func (c *Client) GetContainerRunArgs(ctx context.Context, nameOrID string) ([]st
 
 // ListContainers lists all containers with optional filtering
 func (c *Client) ListContainers(ctx context.Context, all bool, filterLabels map[string]string) ([]types.Container, error) {
+	defer metrics.ObserveDocker("list", time.Now())
 	filterArgs := filters.NewArgs()
 	for k, v := range filterLabels {
 		filterArgs.Add("label", fmt.Sprintf("%s=%s", k, v))
@@ -281,6 +490,31 @@ func (c *Client) GetContainerLogs(ctx context.Context, nameOrID string, tail str
 	})
 }
 
+// ReadContainerLogs returns the last lines of a container's output, with
+// stdout and stderr interleaved
+func (c *Client) ReadContainerLogs(ctx context.Context, nameOrID string, tail string) ([]byte, error) {
+	info, err := c.cli.ContainerInspect(ctx, nameOrID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	logs, err := c.GetContainerLogs(ctx, nameOrID, tail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container logs: %w", err)
+	}
+	defer logs.Close()
+
+	// Without a TTY, Docker multiplexes both streams with frame headers
+	if info.Config != nil && info.Config.Tty {
+		return io.ReadAll(logs)
+	}
+	var buf bytes.Buffer
+	if _, err := stdcopy.StdCopy(&buf, &buf, logs); err != nil {
+		return nil, fmt.Errorf("failed to read container logs: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
 // ContainerStats holds container resource usage stats
 type ContainerStats struct {
 	CPUPercent    float64 `json:"cpu_percent"`
@@ -291,6 +525,7 @@ type ContainerStats struct {
 
 // GetContainerStats retrieves container resource usage statistics
 func (c *Client) GetContainerStats(ctx context.Context, nameOrID string) (*ContainerStats, error) {
+	defer metrics.ObserveDocker("stats", time.Now())
 	statsResponse, err := c.cli.ContainerStats(ctx, nameOrID, false)
 	if err != nil {
 		return nil, err
@@ -332,9 +567,41 @@ func (c *Client) BuildImage(ctx context.Context, buildContext io.Reader, options
 
 // PullImage pulls a Docker image
 func (c *Client) PullImage(ctx context.Context, refStr string) (io.ReadCloser, error) {
+	defer metrics.ObserveDocker("pull", time.Now())
 	return c.cli.ImagePull(ctx, refStr, image.PullOptions{})
 }
 
+// HasImage reports whether an image exists on the daemon
+func (c *Client) HasImage(ctx context.Context, ref string) (bool, error) {
+	_, _, err := c.cli.ImageInspectWithRaw(ctx, ref)
+	if err == nil {
+		return true, nil
+	}
+	if client.IsErrNotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// SaveImage exports an image as a tar archive that LoadImage can import
+func (c *Client) SaveImage(ctx context.Context, ref string) (io.ReadCloser, error) {
+	defer metrics.ObserveDocker("save", time.Now())
+	return c.cli.ImageSave(ctx, []string{ref})
+}
+
+// LoadImage imports a tar archive written by SaveImage
+func (c *Client) LoadImage(ctx context.Context, archive io.Reader) error {
+	defer metrics.ObserveDocker("load", time.Now())
+	resp, err := c.cli.ImageLoad(ctx, archive, true)
+	if err != nil {
+		return fmt.Errorf("failed to load image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
+
 // ensureImage ensures an image exists locally
 func (c *Client) ensureImage(ctx context.Context, imageName string) error {
 	_, _, err := c.cli.ImageInspectWithRaw(ctx, imageName)
@@ -359,39 +626,90 @@ func (c *Client) ensureImage(ctx context.Context, imageName string) error {
 	return err
 }
 
-// CleanupOldImages removes old images keeping the specified count
-func (c *Client) CleanupOldImages(ctx context.Context, imageName string, keepCount int) error {
+// CleanupOldImages removes all but the newest keepCount images of a
+// repository, returning how many were removed and their size. Images still
+// used by a container are skipped.
+func (c *Client) CleanupOldImages(ctx context.Context, imageName string, keepCount int) (int, int64, error) {
+	defer metrics.ObserveDocker("cleanup_images", time.Now())
 	images, err := c.cli.ImageList(ctx, image.ListOptions{
 		Filters: filters.NewArgs(filters.Arg("reference", imageName)),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to list images: %w", err)
+		return 0, 0, fmt.Errorf("failed to list images: %w", err)
 	}
 
 	if len(images) <= keepCount {
-		return nil
+		return 0, 0, nil
 	}
 
-	// Sort by creation time (images are already sorted newest first typically)
-	// Remove older images
-	for i := keepCount; i < len(images); i++ {
-		c.logger.Info("removing old image", "id", images[i].ID[:12])
-		_, err := c.cli.ImageRemove(ctx, images[i].ID, image.RemoveOptions{
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].Created > images[j].Created
+	})
+
+	removed := 0
+	var reclaimed int64
+	for _, img := range images[keepCount:] {
+		c.logger.Info("removing old image", "id", shortID(img.ID))
+		_, err := c.cli.ImageRemove(ctx, img.ID, image.RemoveOptions{
 			PruneChildren: true,
 		})
 		if err != nil {
-			c.logger.Warn("failed to remove image", "id", images[i].ID[:12], "error", err)
+			c.logger.Warn("failed to remove image", "id", shortID(img.ID), "error", err)
+			continue
 		}
+		removed++
+		reclaimed += img.Size
 	}
 
-	return nil
+	return removed, reclaimed, nil
 }
 
 // PruneImages removes dangling images
 func (c *Client) PruneImages(ctx context.Context) (image.PruneReport, error) {
+	defer metrics.ObserveDocker("prune_images", time.Now())
 	return c.cli.ImagesPrune(ctx, filters.NewArgs(filters.Arg("dangling", "true")))
 }
 
+// PruneVolumes removes anonymous volumes no container uses. Named volumes,
+// such as add-on data, are kept. Daemons older than API 1.42 would remove
+// named volumes too, so they are skipped.
+func (c *Client) PruneVolumes(ctx context.Context) (volume.PruneReport, error) {
+	defer metrics.ObserveDocker("prune_volumes", time.Now())
+	if versions.LessThan(c.cli.ClientVersion(), "1.42") {
+		c.logger.Warn("skipping volume prune on old Docker API", "version", c.cli.ClientVersion())
+		return volume.PruneReport{}, nil
+	}
+	return c.cli.VolumesPrune(ctx, filters.NewArgs())
+}
+
+// PruneNetworks removes unused networks, except those Schooner creates
+func (c *Client) PruneNetworks(ctx context.Context) (network.PruneReport, error) {
+	defer metrics.ObserveDocker("prune_networks", time.Now())
+	return c.cli.NetworksPrune(ctx, filters.NewArgs(filters.Arg("label!", "schooner.managed=true")))
+}
+
+// PruneBuildCache removes all build cache not used by a running build
+func (c *Client) PruneBuildCache(ctx context.Context) (*types.BuildCachePruneReport, error) {
+	defer metrics.ObserveDocker("prune_build_cache", time.Now())
+	return c.cli.BuildCachePrune(ctx, types.BuildCachePruneOptions{All: true})
+}
+
+// DiskUsage returns the space used by images, containers, volumes and the
+// build cache, as docker system df does. Sizing volumes can take a while.
+func (c *Client) DiskUsage(ctx context.Context) (types.DiskUsage, error) {
+	defer metrics.ObserveDocker("disk_usage", time.Now())
+	return c.cli.DiskUsage(ctx, types.DiskUsageOptions{})
+}
+
+// shortID shortens an image or container ID for logs
+func shortID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
 // toPortBindings converts port map to Docker port bindings
 func toPortBindings(ports map[string]string) nat.PortMap {
 	portMap := nat.PortMap{}
@@ -413,6 +731,27 @@ func toBinds(volumes map[string]string) []string {
 	return binds
 }
 
+// toDeviceMappings converts host device paths to Docker device mappings
+func toDeviceMappings(devices []string) []container.DeviceMapping {
+	var mappings []container.DeviceMapping
+	for _, device := range devices {
+		parts := strings.SplitN(device, ":", 3)
+		mapping := container.DeviceMapping{
+			PathOnHost:        parts[0],
+			PathInContainer:   parts[0],
+			CgroupPermissions: "rwm",
+		}
+		if len(parts) > 1 && parts[1] != "" {
+			mapping.PathInContainer = parts[1]
+		}
+		if len(parts) > 2 && parts[2] != "" {
+			mapping.CgroupPermissions = parts[2]
+		}
+		mappings = append(mappings, mapping)
+	}
+	return mappings
+}
+
 // extractPorts extracts port mappings from network settings
 func extractPorts(portMap nat.PortMap) map[string]string {
 	ports := make(map[string]string)
@@ -439,26 +778,92 @@ func (c *Client) WaitForContainer(ctx context.Context, nameOrID string, conditio
 
 // StartContainer starts a stopped container
 func (c *Client) StartContainer(ctx context.Context, nameOrID string) error {
+	defer metrics.ObserveDocker("start", time.Now())
 	return c.cli.ContainerStart(ctx, nameOrID, container.StartOptions{})
 }
 
 // StopContainer stops a running container
 func (c *Client) StopContainer(ctx context.Context, nameOrID string, timeout time.Duration) error {
+	defer metrics.ObserveDocker("stop", time.Now())
 	timeoutSecs := int(timeout.Seconds())
 	return c.cli.ContainerStop(ctx, nameOrID, container.StopOptions{Timeout: &timeoutSecs})
 }
 
 // RestartContainer restarts a container
 func (c *Client) RestartContainer(ctx context.Context, nameOrID string, timeout time.Duration) error {
+	defer metrics.ObserveDocker("restart", time.Now())
 	timeoutSecs := int(timeout.Seconds())
 	return c.cli.ContainerRestart(ctx, nameOrID, container.StopOptions{Timeout: &timeoutSecs})
 }
 
 // RemoveContainer removes a container
 func (c *Client) RemoveContainer(ctx context.Context, nameOrID string) error {
+	defer metrics.ObserveDocker("remove", time.Now())
 	return c.cli.ContainerRemove(ctx, nameOrID, container.RemoveOptions{Force: true})
 }
 
+// ListVolumes returns the names of all named volumes
+func (c *Client) ListVolumes(ctx context.Context) ([]string, error) {
+	defer metrics.ObserveDocker("volume_list", time.Now())
+	resp, err := c.cli.VolumeList(ctx, volume.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
+	}
+
+	names := make([]string, 0, len(resp.Volumes))
+	for _, v := range resp.Volumes {
+		names = append(names, v.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ContainersUsingVolume returns the IDs of running containers that mount a
+// volume
+func (c *Client) ContainersUsingVolume(ctx context.Context, name string) ([]string, error) {
+	containers, err := c.cli.ContainerList(ctx, container.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("volume", name)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	ids := make([]string, 0, len(containers))
+	for _, ctr := range containers {
+		ids = append(ids, ctr.ID)
+	}
+	return ids, nil
+}
+
+// CopyFromContainer returns a tar archive of a path in a container
+func (c *Client) CopyFromContainer(ctx context.Context, nameOrID, path string) (io.ReadCloser, error) {
+	defer metrics.ObserveDocker("copy_from", time.Now())
+	reader, _, err := c.cli.CopyFromContainer(ctx, nameOrID, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy from container: %w", err)
+	}
+	return reader, nil
+}
+
+// CopyToContainer extracts a tar archive into a path in a container,
+// keeping the owners recorded in the archive
+func (c *Client) CopyToContainer(ctx context.Context, nameOrID, path string, content io.Reader) error {
+	defer metrics.ObserveDocker("copy_to", time.Now())
+	err := c.cli.CopyToContainer(ctx, nameOrID, path, content, container.CopyToContainerOptions{
+		CopyUIDGID: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy to container: %w", err)
+	}
+	return nil
+}
+
+// RemoveVolume removes a named volume and the data in it
+func (c *Client) RemoveVolume(ctx context.Context, name string) error {
+	defer metrics.ObserveDocker("volume_remove", time.Now())
+	return c.cli.VolumeRemove(ctx, name, false)
+}
+
 // EnsureNetwork creates a network if it doesn't exist
 func (c *Client) EnsureNetwork(ctx context.Context, name string) error {
 	networks, err := c.cli.NetworkList(ctx, network.ListOptions{
@@ -498,6 +903,24 @@ func (c *Client) ConnectToNetwork(ctx context.Context, containerID, networkName
 
 // CreateAndStartContainer creates and starts a container with full config
 func (c *Client) CreateAndStartContainer(ctx context.Context, cfg ContainerConfig) (string, error) {
+	defer metrics.ObserveDocker("create_start", time.Now())
+
+	id, err := c.CreateContainer(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	// Start container
+	if err := c.cli.ContainerStart(ctx, id, container.StartOptions{}); err != nil {
+		return "", fmt.Errorf("failed to start container: %w", err)
+	}
+
+	c.logger.Info("container started", "id", id[:12], "name", cfg.Name)
+	return id, nil
+}
+
+// CreateContainer creates a container with full config without starting it
+func (c *Client) CreateContainer(ctx context.Context, cfg ContainerConfig) (string, error) {
 	c.logger.Info("creating container", "name", cfg.Name, "image", cfg.Image)
 
 	// Ensure image exists
@@ -525,6 +948,17 @@ func (c *Client) CreateAndStartContainer(ctx context.Context, cfg ContainerConfi
 	if cfg.NetworkMode != "" {
 		hostConfig.NetworkMode = container.NetworkMode(cfg.NetworkMode)
 	}
+	if cfg.PidMode != "" {
+		hostConfig.PidMode = container.PidMode(cfg.PidMode)
+	}
+	hostConfig.Privileged = cfg.Privileged
+	hostConfig.Devices = toDeviceMappings(cfg.Devices)
+	hostConfig.CapAdd = cfg.CapAdd
+	hostConfig.CapDrop = cfg.CapDrop
+	hostConfig.Sysctls = cfg.Sysctls
+	hostConfig.ShmSize = cfg.ShmSize
+	hostConfig.DeviceRequests = toDeviceRequests(cfg.GPUCount, cfg.GPUIDs)
+	hostConfig.Runtime = cfg.Runtime
 
 	// Build network config
 	networkConfig := &network.NetworkingConfig{}
@@ -541,11 +975,5 @@ func (c *Client) CreateAndStartContainer(ctx context.Context, cfg ContainerConfi
 		return "", fmt.Errorf("failed to create container: %w", err)
 	}
 
-	// Start container
-	if err := c.cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
-		return "", fmt.Errorf("failed to start container: %w", err)
-	}
-
-	c.logger.Info("container started", "id", resp.ID[:12], "name", cfg.Name)
 	return resp.ID, nil
 }
@@ -154,3 +154,26 @@ func TestToBindsEmpty(t *testing.T) {
 		t.Errorf("Expected empty binds, got %v", binds)
 	}
 }
+
+func TestToDeviceMappings(t *testing.T) {
+	mappings := toDeviceMappings([]string{"/dev/kmsg"})
+
+	if len(mappings) != 1 {
+		t.Fatalf("len(mappings) = %v, want 1", len(mappings))
+	}
+	if mappings[0].PathOnHost != "/dev/kmsg" || mappings[0].PathInContainer != "/dev/kmsg" {
+		t.Errorf("mapping = %+v, want /dev/kmsg on both sides", mappings[0])
+	}
+	if mappings[0].CgroupPermissions != "rwm" {
+		t.Errorf("CgroupPermissions = %v, want rwm", mappings[0].CgroupPermissions)
+	}
+
+	if got := toDeviceMappings(nil); len(got) != 0 {
+		t.Errorf("toDeviceMappings(nil) = %v, want empty", got)
+	}
+
+	mappings = toDeviceMappings([]string{"/dev/dri/renderD128:/dev/dri/card0:rw"})
+	if got := mappings[0]; got.PathOnHost != "/dev/dri/renderD128" || got.PathInContainer != "/dev/dri/card0" || got.CgroupPermissions != "rw" {
+		t.Errorf("mapping = %+v, want renderD128 as card0 with rw", got)
+	}
+}
@@ -0,0 +1,65 @@
+package docker
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// ContainerEvent is a change in the lifecycle of a container an app deployed
+type ContainerEvent struct {
+	ContainerID string
+	Name        string
+	AppID       string
+	AppName     string
+	Action      string // kill or die
+	ExitCode    int    // Set when it died
+	Time        time.Time
+}
+
+// ContainerEvents streams the kill and die events of app containers
+// until the context is cancelled. An error is sent when the stream breaks.
+func (c *Client) ContainerEvents(ctx context.Context) (<-chan ContainerEvent, <-chan error) {
+	messages, errs := c.cli.Events(ctx, events.ListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("type", string(events.ContainerEventType)),
+			filters.Arg("label", "schooner.app-id"),
+			filters.Arg("event", string(events.ActionKill)),
+			filters.Arg("event", string(events.ActionDie)),
+		),
+	})
+
+	out := make(chan ContainerEvent)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				event := ContainerEvent{
+					ContainerID: msg.Actor.ID,
+					Name:        msg.Actor.Attributes["name"],
+					AppID:       msg.Actor.Attributes["schooner.app-id"],
+					AppName:     msg.Actor.Attributes["schooner.app"],
+					Action:      string(msg.Action),
+					Time:        time.Unix(0, msg.TimeNano),
+				}
+				event.ExitCode, _ = strconv.Atoi(msg.Actor.Attributes["exitCode"])
+
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, errs
+}
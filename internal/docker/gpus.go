@@ -0,0 +1,36 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+
+	"schooner/internal/metrics"
+)
+
+// toDeviceRequests asks for GPUs the way docker run --gpus does: count
+// GPUs, -1 for all of them, or the ones with the given IDs
+func toDeviceRequests(count int, ids []string) []container.DeviceRequest {
+	if count == 0 && len(ids) == 0 {
+		return nil
+	}
+	return []container.DeviceRequest{{
+		Count:        count,
+		DeviceIDs:    ids,
+		Capabilities: [][]string{{"gpu"}},
+	}}
+}
+
+// HasRuntime reports whether the daemon can run containers with the named
+// runtime, such as nvidia
+func (c *Client) HasRuntime(ctx context.Context, name string) (bool, error) {
+	defer metrics.ObserveDocker("info", time.Now())
+	info, err := c.cli.Info(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get docker info: %w", err)
+	}
+	_, ok := info.Runtimes[name]
+	return ok, nil
+}
@@ -0,0 +1,19 @@
+package docker
+
+import "testing"
+
+func TestToDeviceRequests(t *testing.T) {
+	if got := toDeviceRequests(0, nil); got != nil {
+		t.Errorf("toDeviceRequests(0, nil) = %v, want none", got)
+	}
+
+	all := toDeviceRequests(-1, nil)
+	if len(all) != 1 || all[0].Count != -1 || all[0].Capabilities[0][0] != "gpu" {
+		t.Errorf("toDeviceRequests(-1, nil) = %+v, want every GPU", all)
+	}
+
+	byID := toDeviceRequests(0, []string{"0", "2"})
+	if len(byID) != 1 || byID[0].Count != 0 || len(byID[0].DeviceIDs) != 2 {
+		t.Errorf("toDeviceRequests(0, [0 2]) = %+v, want GPUs 0 and 2", byID)
+	}
+}
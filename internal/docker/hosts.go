@@ -0,0 +1,118 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"schooner/internal/models"
+)
+
+// HostStore looks up configured remote Docker hosts
+type HostStore interface {
+	GetByID(ctx context.Context, id string) (*models.DockerHost, error)
+}
+
+// Hosts hands out Docker clients for the local daemon and configured remote
+// hosts, connecting to each remote host once and reusing the client
+type Hosts struct {
+	local     *Client
+	store     HostStore
+	socketDir string
+
+	mu      sync.Mutex
+	clients map[string]*Client
+}
+
+// NewHosts creates a registry of Docker hosts. SSH hosts are forwarded
+// through sockets created in socketDir.
+func NewHosts(local *Client, store HostStore, socketDir string) *Hosts {
+	// The docker CLI resolves DOCKER_HOST from the compose file's directory
+	if abs, err := filepath.Abs(socketDir); err == nil {
+		socketDir = abs
+	}
+	return &Hosts{
+		local:     local,
+		store:     store,
+		socketDir: socketDir,
+		clients:   make(map[string]*Client),
+	}
+}
+
+// Local returns the client for the daemon Schooner runs next to
+func (h *Hosts) Local() *Client {
+	return h.local
+}
+
+// Client returns the client for a Docker host, the local daemon for an
+// empty ID
+func (h *Hosts) Client(ctx context.Context, hostID string) (*Client, error) {
+	if hostID == "" {
+		return h.local, nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if c, ok := h.clients[hostID]; ok {
+		return c, nil
+	}
+
+	host, err := h.store.GetByID(ctx, hostID)
+	if err != nil {
+		return nil, err
+	}
+	if host == nil {
+		return nil, fmt.Errorf("Docker host %s not found", hostID)
+	}
+
+	c, err := h.connect(host, hostID)
+	if err != nil {
+		return nil, err
+	}
+	h.clients[hostID] = c
+	return c, nil
+}
+
+// ForApp returns the client for the host an app deploys to
+func (h *Hosts) ForApp(ctx context.Context, app *models.App) (*Client, error) {
+	return h.Client(ctx, app.GetDockerHost())
+}
+
+// Forget closes the cached client of a host, so the next use reconnects with
+// its current settings
+func (h *Hosts) Forget(hostID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if c, ok := h.clients[hostID]; ok {
+		c.Close()
+		delete(h.clients, hostID)
+	}
+}
+
+// Test connects to a host and pings its daemon without caching the client
+func (h *Hosts) Test(ctx context.Context, host *models.DockerHost) error {
+	c, err := h.connect(host, "test-"+host.Name)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	return c.Ping(ctx)
+}
+
+func (h *Hosts) connect(host *models.DockerHost, socketName string) (*Client, error) {
+	c, err := NewRemoteClient(RemoteConfig{
+		URL:         host.URL,
+		TLSCertPath: host.TLSCertPath,
+		SSHKeyPath:  host.SSHKeyPath,
+		SSHHostKey:  host.SSHHostKey,
+		SocketPath:  filepath.Join(h.socketDir, socketName+".sock"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Docker host %s: %w", host.Name, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,117 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/network"
+
+	"schooner/internal/metrics"
+)
+
+// Network is a Docker network and the containers attached to it
+type Network struct {
+	ID         string             `json:"id"`
+	Name       string             `json:"name"`
+	Driver     string             `json:"driver"`
+	Scope      string             `json:"scope"`
+	Internal   bool               `json:"internal"` // No route out of the network
+	Managed    bool               `json:"managed"`  // Created by Schooner
+	Builtin    bool               `json:"builtin"`  // One of Docker's own networks, which can't be removed
+	Containers []NetworkContainer `json:"containers"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+// NetworkContainer is a container attached to a network
+type NetworkContainer struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Address string `json:"address,omitempty"`
+}
+
+// IsBuiltinNetwork reports whether a network is one Docker creates itself
+func IsBuiltinNetwork(name string) bool {
+	return name == "bridge" || name == "host" || name == "none"
+}
+
+// ListNetworks returns every network on the daemon with the containers
+// attached to it, sorted by name
+func (c *Client) ListNetworks(ctx context.Context) ([]Network, error) {
+	defer metrics.ObserveDocker("network_list", time.Now())
+	summaries, err := c.cli.NetworkList(ctx, network.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list networks: %w", err)
+	}
+
+	// Listing leaves out the containers, only inspecting fills them in
+	networks := make([]Network, 0, len(summaries))
+	for _, summary := range summaries {
+		info, err := c.cli.NetworkInspect(ctx, summary.ID, network.InspectOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect network %s: %w", summary.Name, err)
+		}
+		networks = append(networks, networkFromInspect(info))
+	}
+	sort.Slice(networks, func(i, j int) bool { return networks[i].Name < networks[j].Name })
+	return networks, nil
+}
+
+// networkFromInspect converts an inspected network, sorting its containers
+// by name
+func networkFromInspect(info network.Inspect) Network {
+	n := Network{
+		ID:         info.ID,
+		Name:       info.Name,
+		Driver:     info.Driver,
+		Scope:      info.Scope,
+		Internal:   info.Internal,
+		Managed:    info.Labels["schooner.managed"] == "true",
+		Builtin:    IsBuiltinNetwork(info.Name),
+		Containers: make([]NetworkContainer, 0, len(info.Containers)),
+		CreatedAt:  info.Created,
+	}
+	for id, endpoint := range info.Containers {
+		address, _, _ := strings.Cut(endpoint.IPv4Address, "/")
+		n.Containers = append(n.Containers, NetworkContainer{ID: id, Name: endpoint.Name, Address: address})
+	}
+	sort.Slice(n.Containers, func(i, j int) bool { return n.Containers[i].Name < n.Containers[j].Name })
+	return n
+}
+
+// CreateNetwork creates a bridge network labelled as Schooner's. Internal
+// networks have no route out, so their containers only reach each other.
+func (c *Client) CreateNetwork(ctx context.Context, name string, internal bool) error {
+	defer metrics.ObserveDocker("network_create", time.Now())
+	_, err := c.cli.NetworkCreate(ctx, name, network.CreateOptions{
+		Driver:   "bridge",
+		Internal: internal,
+		Labels: map[string]string{
+			"schooner.managed": "true",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create network: %w", err)
+	}
+	return nil
+}
+
+// RemoveNetwork removes a network, which fails while containers are
+// attached to it
+func (c *Client) RemoveNetwork(ctx context.Context, name string) error {
+	defer metrics.ObserveDocker("network_remove", time.Now())
+	if err := c.cli.NetworkRemove(ctx, name); err != nil {
+		return fmt.Errorf("failed to remove network: %w", err)
+	}
+	return nil
+}
+
+// DisconnectFromNetwork disconnects a container from a network
+func (c *Client) DisconnectFromNetwork(ctx context.Context, containerID, networkName string) error {
+	if err := c.cli.NetworkDisconnect(ctx, networkName, containerID, false); err != nil {
+		return fmt.Errorf("failed to disconnect from network: %w", err)
+	}
+	return nil
+}
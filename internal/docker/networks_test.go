@@ -0,0 +1,53 @@
+package docker
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/network"
+)
+
+func TestNetworkFromInspect(t *testing.T) {
+	tests := []struct {
+		name string
+		info network.Inspect
+		want Network
+	}{
+		{
+			name: "app network",
+			info: network.Inspect{
+				ID:     "abc",
+				Name:   "schooner-addons-0f8c2a9e",
+				Driver: "bridge",
+				Labels: map[string]string{"schooner.managed": "true"},
+				Containers: map[string]network.EndpointResource{
+					"2": {Name: "schooner-blog-db", IPv4Address: "172.20.0.3/16"},
+					"1": {Name: "schooner-blog", IPv4Address: "172.20.0.2/16"},
+				},
+			},
+			want: Network{
+				ID:      "abc",
+				Name:    "schooner-addons-0f8c2a9e",
+				Driver:  "bridge",
+				Managed: true,
+				Containers: []NetworkContainer{
+					{ID: "1", Name: "schooner-blog", Address: "172.20.0.2"},
+					{ID: "2", Name: "schooner-blog-db", Address: "172.20.0.3"},
+				},
+			},
+		},
+		{
+			name: "default bridge",
+			info: network.Inspect{ID: "def", Name: "bridge", Driver: "bridge"},
+			want: Network{ID: "def", Name: "bridge", Driver: "bridge", Builtin: true, Containers: []NetworkContainer{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := networkFromInspect(tt.info); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("networkFromInspect() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package docker
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// remoteDockerSocket is where the daemon listens on SSH hosts
+const remoteDockerSocket = "/var/run/docker.sock"
+
+// sshDialTimeout bounds connecting to an SSH host
+const sshDialTimeout = 10 * time.Second
+
+// RemoteConfig describes how to reach a remote Docker daemon
+type RemoteConfig struct {
+	URL         string // tcp://host:2376 or ssh://user@host[:port]
+	TLSCertPath string // Directory with ca.pem, cert.pem and key.pem, for tcp
+	SSHKeyPath  string // Private key file, for ssh
+	SSHHostKey  string // Pinned host key in authorized_keys format, empty to check known_hosts
+	SocketPath  string // Local socket SSH connections are forwarded through
+}
+
+// NewRemoteClient creates a client for a remote Docker daemon. TLS hosts are
+// dialed directly. SSH hosts are reached through a local socket forwarded to
+// the remote daemon's socket, which the docker CLI can use as well.
+func NewRemoteClient(cfg RemoteConfig) (*Client, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Docker host URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "tcp":
+		cli, err := client.NewClientWithOpts(
+			client.WithHost(cfg.URL),
+			client.WithTLSClientConfig(
+				filepath.Join(cfg.TLSCertPath, "ca.pem"),
+				filepath.Join(cfg.TLSCertPath, "cert.pem"),
+				filepath.Join(cfg.TLSCertPath, "key.pem"),
+			),
+			client.WithAPIVersionNegotiation(),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create docker client: %w", err)
+		}
+		return &Client{
+			cli:    cli,
+			logger: slog.Default().With("dockerHost", u.Host),
+			env:    tlsEnv(cfg),
+		}, nil
+
+	case "ssh":
+		tunnel, err := newSSHTunnel(u, cfg)
+		if err != nil {
+			return nil, err
+		}
+		host := "unix://" + cfg.SocketPath
+		cli, err := client.NewClientWithOpts(
+			client.WithHost(host),
+			client.WithAPIVersionNegotiation(),
+		)
+		if err != nil {
+			tunnel.Close()
+			return nil, fmt.Errorf("failed to create docker client: %w", err)
+		}
+		return &Client{
+			cli:    cli,
+			logger: slog.Default().With("dockerHost", u.Host),
+			env:    []string{"DOCKER_HOST=" + host},
+			tunnel: tunnel,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported Docker host scheme %q", u.Scheme)
+	}
+}
+
+// tlsEnv points the docker CLI at a TLS daemon
+func tlsEnv(cfg RemoteConfig) []string {
+	return []string{
+		"DOCKER_HOST=" + cfg.URL,
+		"DOCKER_TLS_VERIFY=1",
+		"DOCKER_CERT_PATH=" + cfg.TLSCertPath,
+	}
+}
+
+// sshTunnel forwards connections on a local socket to the Docker socket of
+// an SSH host, reconnecting when the SSH connection drops
+type sshTunnel struct {
+	addr       string
+	config     *ssh.ClientConfig
+	socketPath string
+	listener   net.Listener
+	logger     *slog.Logger
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+func newSSHTunnel(u *url.URL, cfg RemoteConfig) (*sshTunnel, error) {
+	key, err := os.ReadFile(cfg.SSHKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH key: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH key: %w", err)
+	}
+	hostKeyCallback, err := hostKeyCallback(cfg.SSHHostKey)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "22")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cfg.SocketPath), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	// A socket left behind by a previous run would make Listen fail
+	os.Remove(cfg.SocketPath)
+	listener, err := net.Listen("unix", cfg.SocketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", cfg.SocketPath, err)
+	}
+
+	t := &sshTunnel{
+		addr: addr,
+		config: &ssh.ClientConfig{
+			User:            u.User.Username(),
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         sshDialTimeout,
+		},
+		socketPath: cfg.SocketPath,
+		listener:   listener,
+		logger:     slog.Default().With("dockerHost", addr),
+	}
+	go t.serve()
+	return t, nil
+}
+
+// hostKeyCallback checks SSH host keys against a pinned key, or the user's
+// known_hosts file when none is pinned
+func hostKeyCallback(pinned string) (ssh.HostKeyCallback, error) {
+	if pinned != "" {
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(pinned))
+		if err != nil {
+			return nil, fmt.Errorf("invalid SSH host key: %w", err)
+		}
+		return ssh.FixedHostKey(key), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("no SSH host key pinned and no home directory for known_hosts: %w", err)
+	}
+	callback, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+	if err != nil {
+		return nil, fmt.Errorf("no SSH host key pinned and known_hosts unreadable: %w", err)
+	}
+	return callback, nil
+}
+
+func (t *sshTunnel) serve() {
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+		go t.forward(conn)
+	}
+}
+
+// forward copies a local connection to the remote Docker socket and back,
+// passing half-closes through so streamed requests finish cleanly
+func (t *sshTunnel) forward(local net.Conn) {
+	defer local.Close()
+
+	remote, err := t.dial()
+	if err != nil {
+		t.logger.Warn("failed to reach remote Docker socket", "error", err)
+		return
+	}
+	defer remote.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(remote, local)
+		closeWrite(remote)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(local, remote)
+		closeWrite(local)
+	}()
+	wg.Wait()
+}
+
+// dial opens a connection to the remote Docker socket, reconnecting over SSH
+// if the current connection is gone
+func (t *sshTunnel) dial() (net.Conn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client != nil {
+		conn, err := t.client.Dial("unix", remoteDockerSocket)
+		if err == nil {
+			return conn, nil
+		}
+		t.client.Close()
+		t.client = nil
+	}
+
+	sshClient, err := ssh.Dial("tcp", t.addr, t.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect over SSH: %w", err)
+	}
+	t.client = sshClient
+	return sshClient.Dial("unix", remoteDockerSocket)
+}
+
+// Close stops forwarding and disconnects from the SSH host
+func (t *sshTunnel) Close() error {
+	err := t.listener.Close()
+	t.mu.Lock()
+	if t.client != nil {
+		t.client.Close()
+		t.client = nil
+	}
+	t.mu.Unlock()
+	os.Remove(t.socketPath)
+	return err
+}
+
+func closeWrite(conn net.Conn) {
+	if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+		cw.CloseWrite()
+	}
+}
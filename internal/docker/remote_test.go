@@ -0,0 +1,84 @@
+package docker
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestHostKeyCallbackPinned(t *testing.T) {
+	pinned := newHostKey(t)
+	other := newHostKey(t)
+
+	callback, err := hostKeyCallback(string(ssh.MarshalAuthorizedKey(pinned)))
+	if err != nil {
+		t.Fatalf("hostKeyCallback() error = %v", err)
+	}
+
+	addr := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 22}
+	if err := callback("vps:22", addr, pinned); err != nil {
+		t.Errorf("pinned key rejected: %v", err)
+	}
+	if err := callback("vps:22", addr, other); err == nil {
+		t.Error("different host key accepted")
+	}
+
+	if _, err := hostKeyCallback("not a key"); err == nil {
+		t.Error("hostKeyCallback() with invalid key error = nil")
+	}
+}
+
+func TestNewRemoteClientTLS(t *testing.T) {
+	c, err := NewRemoteClient(RemoteConfig{URL: "tcp://builder:2376", TLSCertPath: t.TempDir()})
+	if err == nil {
+		c.Close()
+		t.Fatal("NewRemoteClient() with missing certificates error = nil")
+	}
+
+	env := tlsEnv(RemoteConfig{URL: "tcp://builder:2376", TLSCertPath: "/certs/builder"})
+	want := []string{"DOCKER_HOST=tcp://builder:2376", "DOCKER_TLS_VERIFY=1", "DOCKER_CERT_PATH=/certs/builder"}
+	if len(env) != len(want) {
+		t.Fatalf("tlsEnv() = %v, want %v", env, want)
+	}
+	for i := range want {
+		if env[i] != want[i] {
+			t.Errorf("tlsEnv()[%d] = %q, want %q", i, env[i], want[i])
+		}
+	}
+}
+
+func TestNewRemoteClientUnsupportedScheme(t *testing.T) {
+	if _, err := NewRemoteClient(RemoteConfig{URL: "http://builder:2375"}); err == nil {
+		t.Error("NewRemoteClient() with http:// error = nil")
+	}
+}
+
+func TestHostsLocal(t *testing.T) {
+	local := &Client{}
+	hosts := NewHosts(local, nil, t.TempDir())
+
+	c, err := hosts.Client(context.Background(), "")
+	if err != nil || c != local {
+		t.Errorf("Client(\"\") = %p, %v, want the local client", c, err)
+	}
+	if c.Env() != nil {
+		t.Errorf("local Env() = %v, want none", c.Env())
+	}
+}
+
+func newHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
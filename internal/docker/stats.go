@@ -0,0 +1,167 @@
+package docker
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+// Stats are read from a few containers at a time, and a round that takes
+// longer than the deadline keeps what it has so far
+const (
+	statsWorkers      = 8
+	statsRoundTimeout = 10 * time.Second
+)
+
+// StatsSource lists containers and reads their resource usage
+type StatsSource interface {
+	ListContainers(ctx context.Context, all bool, filterLabels map[string]string) ([]types.Container, error)
+	GetContainerStats(ctx context.Context, nameOrID string) (*ContainerStats, error)
+}
+
+// ContainerSample is the resource usage of a running container at one point
+type ContainerSample struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	AppID     string         `json:"app_id,omitempty"` // the app that deployed it, if any
+	Stats     ContainerStats `json:"stats"`
+	SampledAt time.Time      `json:"sampled_at"`
+}
+
+// StatsCollector samples the resource usage of running containers in the
+// background and keeps the latest sample of each in memory, so readers
+// never wait on the Docker stats API
+type StatsCollector struct {
+	source   StatsSource
+	interval time.Duration
+
+	mu          sync.Mutex
+	samples     []ContainerSample
+	subscribers map[chan []ContainerSample]struct{}
+}
+
+// NewStatsCollector creates a StatsCollector that samples every interval
+func NewStatsCollector(source StatsSource, interval time.Duration) *StatsCollector {
+	return &StatsCollector{
+		source:      source,
+		interval:    interval,
+		subscribers: make(map[chan []ContainerSample]struct{}),
+	}
+}
+
+// Start samples right away and then every interval until the context is
+// cancelled
+func (c *StatsCollector) Start(ctx context.Context) {
+	go func() {
+		c.sample(ctx)
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.sample(ctx)
+			}
+		}
+	}()
+}
+
+// Latest returns the most recent sample of each running container
+func (c *StatsCollector) Latest() []ContainerSample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.samples
+}
+
+// Subscribe returns a channel that receives every new round of samples.
+// A subscriber that falls behind only gets the newest round.
+func (c *StatsCollector) Subscribe() (<-chan []ContainerSample, func()) {
+	ch := make(chan []ContainerSample, 1)
+
+	c.mu.Lock()
+	c.subscribers[ch] = struct{}{}
+	c.mu.Unlock()
+
+	return ch, func() {
+		c.mu.Lock()
+		delete(c.subscribers, ch)
+		c.mu.Unlock()
+	}
+}
+
+// sample reads the stats of every running container once and publishes
+// them
+func (c *StatsCollector) sample(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, statsRoundTimeout)
+	defer cancel()
+
+	containers, err := c.source.ListContainers(ctx, false, nil)
+	if err != nil {
+		slog.Debug("stats collector failed to list containers", "error", err)
+		return
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	samples := make([]ContainerSample, 0, len(containers))
+	sem := make(chan struct{}, statsWorkers)
+	for _, ctr := range containers {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(ctr types.Container) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			name := ""
+			if len(ctr.Names) > 0 {
+				name = strings.TrimPrefix(ctr.Names[0], "/")
+			}
+			stats, err := c.source.GetContainerStats(ctx, ctr.ID)
+			if err != nil {
+				slog.Debug("stats collector failed to get container stats", "container", name, "error", err)
+				return
+			}
+
+			id := ctr.ID
+			if len(id) > 12 {
+				id = id[:12]
+			}
+			mu.Lock()
+			samples = append(samples, ContainerSample{
+				ID:        id,
+				Name:      name,
+				AppID:     ctr.Labels["schooner.app-id"],
+				Stats:     *stats,
+				SampledAt: time.Now(),
+			})
+			mu.Unlock()
+		}(ctr)
+	}
+	wg.Wait()
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Name < samples[j].Name })
+	c.publish(samples)
+}
+
+// publish replaces the latest samples and hands them to subscribers
+func (c *StatsCollector) publish(samples []ContainerSample) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.samples = samples
+	for ch := range c.subscribers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- samples
+	}
+}
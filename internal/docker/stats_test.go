@@ -0,0 +1,82 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+type fakeStatsSource struct {
+	containers []types.Container
+	calls      atomic.Int32
+}
+
+func (s *fakeStatsSource) ListContainers(ctx context.Context, all bool, filterLabels map[string]string) ([]types.Container, error) {
+	return s.containers, nil
+}
+
+func (s *fakeStatsSource) GetContainerStats(ctx context.Context, nameOrID string) (*ContainerStats, error) {
+	s.calls.Add(1)
+	if nameOrID == "broken0000000000" {
+		return nil, errors.New("no such container")
+	}
+	return &ContainerStats{CPUPercent: 12.5, MemoryUsage: 1024}, nil
+}
+
+func TestStatsCollector(t *testing.T) {
+	source := &fakeStatsSource{containers: []types.Container{
+		{ID: "web0000000000000", Names: []string{"/web"}},
+		{ID: "api0000000000000", Names: []string{"/api"}},
+		{ID: "broken0000000000", Names: []string{"/broken"}},
+	}}
+	c := NewStatsCollector(source, time.Hour)
+
+	if samples := c.Latest(); len(samples) != 0 {
+		t.Fatalf("Latest() before sampling = %v, want none", samples)
+	}
+
+	updates, unsubscribe := c.Subscribe()
+	defer unsubscribe()
+
+	c.sample(context.Background())
+	samples := c.Latest()
+	if len(samples) != 2 || samples[0].Name != "api" || samples[1].Name != "web" {
+		t.Fatalf("Latest() = %v, want api and web", samples)
+	}
+	if samples[0].ID != "api000000000" || samples[0].Stats.CPUPercent != 12.5 {
+		t.Errorf("sample = %+v, want short ID and stats", samples[0])
+	}
+
+	select {
+	case got := <-updates:
+		if len(got) != 2 {
+			t.Errorf("subscriber got %d samples, want 2", len(got))
+		}
+	default:
+		t.Fatal("subscriber got nothing")
+	}
+
+	// Reading the latest samples doesn't touch Docker
+	calls := source.calls.Load()
+	c.Latest()
+	c.Latest()
+	if n := source.calls.Load(); n != calls {
+		t.Errorf("stats fetched %d times after reads, want %d", n, calls)
+	}
+
+	// A slow subscriber is never blocked on and gets the newest round
+	c.sample(context.Background())
+	c.sample(context.Background())
+	if got := <-updates; len(got) != 2 {
+		t.Errorf("subscriber got %d samples, want 2", len(got))
+	}
+	select {
+	case <-updates:
+		t.Error("subscriber got a stale round")
+	default:
+	}
+}
@@ -0,0 +1,87 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// swapHelperName is the container that replaces Schooner's own container
+const swapHelperName = "schooner-deploy-helper"
+
+// SelfContainer returns the container Schooner runs in, or nil when it
+// doesn't run in a container on this daemon
+func (c *Client) SelfContainer(ctx context.Context) (*ContainerStatus, error) {
+	if _, err := os.Stat("/.dockerenv"); err != nil {
+		return nil, nil
+	}
+
+	// Docker sets the hostname to the container ID unless it's given one,
+	// usually the container name
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := c.GetContainerStatus(ctx, hostname)
+	if err != nil || status == nil || status.State == "not_found" {
+		return nil, err
+	}
+	status.Name = strings.TrimPrefix(status.Name, "/")
+	return status, nil
+}
+
+// SwapContainer replaces a container with one running image, keeping its
+// ports, mounts, env vars, network and restart policy. A helper container
+// does the swap a couple of seconds later, so a container can replace
+// itself: the caller is stopped along the way. The schooner.* labels of the
+// new container are replaced with labels. It returns the helper's ID.
+func (c *Client) SwapContainer(ctx context.Context, containerName, image string, labels map[string]string) (string, error) {
+	runArgs, err := c.GetContainerRunArgs(ctx, containerName)
+	if err != nil {
+		return "", fmt.Errorf("could not get container configuration: %w", err)
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var labelArgs []string
+	for _, k := range keys {
+		labelArgs = append(labelArgs, fmt.Sprintf(`--label "%s=%s"`, k, labels[k]))
+	}
+
+	// The script waits 2 seconds (to let the caller finish), then stops the
+	// old container and starts the new one
+	script := fmt.Sprintf(`
+		sleep 2
+		echo "Stopping old container: %s"
+		docker stop %s --time 30 || true
+		docker rm %s || true
+		echo "Starting new container with image: %s"
+		docker run -d --name %s \
+			%s \
+			%s \
+			%s
+		echo "Self-deployment complete"
+	`, containerName, containerName, containerName, image, containerName,
+		strings.Join(labelArgs, " "), strings.Join(runArgs, " "), image)
+
+	// Remove any existing helper container
+	_ = c.StopAndRemove(ctx, swapHelperName)
+
+	return c.RunContainer(ctx, ContainerConfig{
+		Name:  swapHelperName,
+		Image: "docker:cli",
+		Cmd:   []string{"sh", "-c", script},
+		Volumes: map[string]string{
+			"/var/run/docker.sock": "/var/run/docker.sock",
+		},
+		Labels: map[string]string{
+			"schooner.helper": "true",
+		},
+	})
+}
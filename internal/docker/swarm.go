@@ -0,0 +1,274 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+
+	"schooner/internal/metrics"
+)
+
+// serviceConvergeTimeout is how long DeployService waits for a rolling
+// update to finish
+const serviceConvergeTimeout = 5 * time.Minute
+
+// ServiceConfig holds configuration for running an app as a Swarm service
+type ServiceConfig struct {
+	ContainerConfig
+	Replicas          uint64
+	UpdateParallelism uint64
+	UpdateDelay       time.Duration
+	UpdateOrder       string // stop-first or start-first
+}
+
+// IsSwarmManager reports whether the daemon is a manager of a Swarm, which
+// is needed to create services
+func (c *Client) IsSwarmManager(ctx context.Context) (bool, error) {
+	info, err := c.cli.Info(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get docker info: %w", err)
+	}
+	return info.Swarm.ControlAvailable, nil
+}
+
+// DeployService creates the service or rolls it onto the new config, then
+// waits for the update to converge. Swarm rolls back a failed update by
+// itself, in which case an error is returned.
+func (c *Client) DeployService(ctx context.Context, cfg ServiceConfig) (string, error) {
+	defer metrics.ObserveDocker("service_deploy", time.Now())
+	c.logger.Info("deploying service", "name", cfg.Name, "image", cfg.Image, "replicas", cfg.Replicas)
+
+	// An app switching from a plain container would clash on ports
+	_ = c.StopAndRemove(ctx, cfg.Name)
+
+	spec := serviceSpec(cfg)
+	service, _, err := c.cli.ServiceInspectWithRaw(ctx, cfg.Name, types.ServiceInspectOptions{})
+	if err != nil {
+		if !client.IsErrNotFound(err) {
+			return "", fmt.Errorf("failed to inspect service: %w", err)
+		}
+		resp, err := c.cli.ServiceCreate(ctx, spec, types.ServiceCreateOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to create service: %w", err)
+		}
+		return resp.ID, c.waitForService(ctx, resp.ID, cfg.Replicas)
+	}
+
+	resp, err := c.cli.ServiceUpdate(ctx, service.ID, service.Version, spec, types.ServiceUpdateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to update service: %w", err)
+	}
+	for _, warning := range resp.Warnings {
+		c.logger.Warn("service update warning", "name", cfg.Name, "warning", warning)
+	}
+	return service.ID, c.waitForService(ctx, service.ID, cfg.Replicas)
+}
+
+// waitForService waits until a service's update completes and the desired
+// number of tasks is running
+func (c *Client) waitForService(ctx context.Context, serviceID string, replicas uint64) error {
+	ctx, cancel := context.WithTimeout(ctx, serviceConvergeTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("service did not converge: %w", ctx.Err())
+		case <-ticker.C:
+		}
+
+		service, _, err := c.cli.ServiceInspectWithRaw(ctx, serviceID, types.ServiceInspectOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to inspect service: %w", err)
+		}
+		if status := service.UpdateStatus; status != nil {
+			switch status.State {
+			case swarm.UpdateStateUpdating, swarm.UpdateStateRollbackStarted:
+				continue
+			case swarm.UpdateStatePaused, swarm.UpdateStateRollbackPaused, swarm.UpdateStateRollbackCompleted:
+				return fmt.Errorf("service update %s: %s", status.State, status.Message)
+			}
+		}
+
+		running, err := c.runningTasks(ctx, serviceID)
+		if err != nil {
+			return err
+		}
+		if running >= replicas {
+			return nil
+		}
+	}
+}
+
+// runningTasks counts a service's running tasks
+func (c *Client) runningTasks(ctx context.Context, serviceID string) (uint64, error) {
+	tasks, err := c.cli.TaskList(ctx, types.TaskListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("service", serviceID),
+			filters.Arg("desired-state", "running"),
+		),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	var running uint64
+	for _, task := range tasks {
+		if task.Status.State == swarm.TaskStateRunning {
+			running++
+		}
+	}
+	return running, nil
+}
+
+// ScaleService sets the number of tasks a service runs. Scaling to zero
+// stops the app without removing it.
+func (c *Client) ScaleService(ctx context.Context, name string, replicas uint64) error {
+	defer metrics.ObserveDocker("service_scale", time.Now())
+	service, _, err := c.cli.ServiceInspectWithRaw(ctx, name, types.ServiceInspectOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to inspect service: %w", err)
+	}
+
+	spec := service.Spec
+	spec.Mode.Replicated = &swarm.ReplicatedService{Replicas: &replicas}
+	if _, err := c.cli.ServiceUpdate(ctx, service.ID, service.Version, spec, types.ServiceUpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to scale service: %w", err)
+	}
+	return nil
+}
+
+// RestartService replaces a service's tasks, following its update config
+func (c *Client) RestartService(ctx context.Context, name string) error {
+	defer metrics.ObserveDocker("service_restart", time.Now())
+	service, _, err := c.cli.ServiceInspectWithRaw(ctx, name, types.ServiceInspectOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to inspect service: %w", err)
+	}
+
+	spec := service.Spec
+	spec.TaskTemplate.ForceUpdate++
+	if _, err := c.cli.ServiceUpdate(ctx, service.ID, service.Version, spec, types.ServiceUpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to restart service: %w", err)
+	}
+	return nil
+}
+
+// RemoveService removes a Swarm service and its tasks. A service that
+// doesn't exist is already removed.
+func (c *Client) RemoveService(ctx context.Context, name string) error {
+	defer metrics.ObserveDocker("service_remove", time.Now())
+	if err := c.cli.ServiceRemove(ctx, name); err != nil && !client.IsErrNotFound(err) {
+		return fmt.Errorf("failed to remove service: %w", err)
+	}
+	return nil
+}
+
+// getServiceStatus describes a service the way GetContainerStatus describes
+// a container, for Swarm apps whose tasks run on other nodes
+func (c *Client) getServiceStatus(ctx context.Context, name string) (*ContainerStatus, error) {
+	service, _, err := c.cli.ServiceInspectWithRaw(ctx, name, types.ServiceInspectOptions{})
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to inspect service: %w", err)
+	}
+
+	running, err := c.runningTasks(ctx, service.ID)
+	if err != nil {
+		return nil, err
+	}
+	var desired uint64
+	if mode := service.Spec.Mode.Replicated; mode != nil && mode.Replicas != nil {
+		desired = *mode.Replicas
+	}
+
+	state := "exited"
+	if running > 0 {
+		state = "running"
+	}
+	status := &ContainerStatus{
+		ID:        service.ID,
+		Name:      service.Spec.Name,
+		State:     state,
+		Status:    fmt.Sprintf("%d/%d replicas running", running, desired),
+		CreatedAt: service.CreatedAt.Format(time.RFC3339Nano),
+		Labels:    service.Spec.Labels,
+		Ports:     map[string]string{},
+	}
+	if spec := service.Spec.TaskTemplate.ContainerSpec; spec != nil {
+		status.Image = spec.Image
+	}
+	if service.Spec.EndpointSpec != nil {
+		for _, p := range service.Spec.EndpointSpec.Ports {
+			status.Ports[fmt.Sprintf("%d/%s", p.TargetPort, p.Protocol)] = strconv.FormatUint(uint64(p.PublishedPort), 10)
+		}
+	}
+	return status, nil
+}
+
+// serviceSpec converts a service config into a Swarm service spec
+func serviceSpec(cfg ServiceConfig) swarm.ServiceSpec {
+	replicas := cfg.Replicas
+	spec := swarm.ServiceSpec{
+		Annotations: swarm.Annotations{
+			Name:   cfg.Name,
+			Labels: cfg.Labels,
+		},
+		TaskTemplate: swarm.TaskSpec{
+			ContainerSpec: &swarm.ContainerSpec{
+				Image:          cfg.Image,
+				Args:           cfg.Cmd,
+				Env:            cfg.Env,
+				Labels:         cfg.Labels,
+				CapabilityAdd:  cfg.CapAdd,
+				CapabilityDrop: cfg.CapDrop,
+				Sysctls:        cfg.Sysctls,
+			},
+			RestartPolicy: &swarm.RestartPolicy{Condition: swarm.RestartPolicyConditionAny},
+		},
+		Mode: swarm.ServiceMode{
+			Replicated: &swarm.ReplicatedService{Replicas: &replicas},
+		},
+		UpdateConfig: &swarm.UpdateConfig{
+			Parallelism:   cfg.UpdateParallelism,
+			Delay:         cfg.UpdateDelay,
+			FailureAction: swarm.UpdateFailureActionRollback,
+			Order:         cfg.UpdateOrder,
+		},
+	}
+
+	for _, net := range cfg.Networks {
+		spec.TaskTemplate.Networks = append(spec.TaskTemplate.Networks, swarm.NetworkAttachmentConfig{Target: net})
+	}
+
+	if len(cfg.Ports) > 0 {
+		spec.EndpointSpec = &swarm.EndpointSpec{}
+		for containerPort, hostPort := range cfg.Ports {
+			target, err1 := strconv.ParseUint(strings.TrimSuffix(containerPort, "/tcp"), 10, 16)
+			published, err2 := strconv.ParseUint(hostPort, 10, 16)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			spec.EndpointSpec.Ports = append(spec.EndpointSpec.Ports, swarm.PortConfig{
+				Protocol:      swarm.PortConfigProtocolTCP,
+				TargetPort:    uint32(target),
+				PublishedPort: uint32(published),
+				PublishMode:   swarm.PortConfigPublishModeIngress,
+			})
+		}
+	}
+
+	return spec
+}
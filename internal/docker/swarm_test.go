@@ -0,0 +1,74 @@
+package docker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+func TestServiceSpec(t *testing.T) {
+	cfg := ServiceConfig{
+		ContainerConfig: ContainerConfig{
+			Name:     "schooner-web",
+			Image:    "schooner/web:abc123",
+			Env:      []string{"PORT=3000"},
+			Ports:    map[string]string{"3000": "8080", "bad": "1"},
+			Networks: []string{"overlay"},
+			Labels:   map[string]string{"schooner.app": "web"},
+			CapAdd:   []string{"NET_ADMIN"},
+			Sysctls:  map[string]string{"net.ipv4.ip_forward": "1"},
+		},
+		Replicas:          3,
+		UpdateParallelism: 1,
+		UpdateDelay:       10 * time.Second,
+		UpdateOrder:       "start-first",
+	}
+
+	spec := serviceSpec(cfg)
+
+	if spec.Name != "schooner-web" {
+		t.Errorf("Name = %v, want schooner-web", spec.Name)
+	}
+	if got := *spec.Mode.Replicated.Replicas; got != 3 {
+		t.Errorf("Replicas = %v, want 3", got)
+	}
+	if spec.TaskTemplate.ContainerSpec.Image != cfg.Image {
+		t.Errorf("Image = %v, want %v", spec.TaskTemplate.ContainerSpec.Image, cfg.Image)
+	}
+	if spec.TaskTemplate.ContainerSpec.Labels["schooner.app"] != "web" || spec.Labels["schooner.app"] != "web" {
+		t.Error("schooner.app label missing from service or container spec")
+	}
+	if c := spec.TaskTemplate.ContainerSpec; len(c.CapabilityAdd) != 1 || c.Sysctls["net.ipv4.ip_forward"] != "1" {
+		t.Errorf("capabilities %v and sysctls %v, want NET_ADMIN and ip_forward", c.CapabilityAdd, c.Sysctls)
+	}
+	if len(spec.TaskTemplate.Networks) != 1 || spec.TaskTemplate.Networks[0].Target != "overlay" {
+		t.Errorf("Networks = %+v, want overlay", spec.TaskTemplate.Networks)
+	}
+
+	update := spec.UpdateConfig
+	if update.Parallelism != 1 || update.Delay != 10*time.Second || update.Order != "start-first" {
+		t.Errorf("UpdateConfig = %+v", update)
+	}
+	if update.FailureAction != swarm.UpdateFailureActionRollback {
+		t.Errorf("FailureAction = %v, want rollback", update.FailureAction)
+	}
+
+	if spec.EndpointSpec == nil || len(spec.EndpointSpec.Ports) != 1 {
+		t.Fatalf("EndpointSpec = %+v, want one port", spec.EndpointSpec)
+	}
+	port := spec.EndpointSpec.Ports[0]
+	if port.TargetPort != 3000 || port.PublishedPort != 8080 {
+		t.Errorf("port = %d:%d, want 8080:3000", port.PublishedPort, port.TargetPort)
+	}
+}
+
+func TestServiceSpecNoPorts(t *testing.T) {
+	spec := serviceSpec(ServiceConfig{ContainerConfig: ContainerConfig{Name: "worker", Image: "worker:1"}, Replicas: 0})
+	if spec.EndpointSpec != nil {
+		t.Errorf("EndpointSpec = %+v, want nil", spec.EndpointSpec)
+	}
+	if got := *spec.Mode.Replicated.Replicas; got != 0 {
+		t.Errorf("Replicas = %v, want 0", got)
+	}
+}
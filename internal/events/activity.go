@@ -0,0 +1,50 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"schooner/internal/models"
+)
+
+// ActivityStore keeps the entries of the activity feed
+type ActivityStore interface {
+	Create(ctx context.Context, activity *models.Activity) error
+}
+
+// SetActivityStore sets where Record keeps the activity feed
+func (b *Bus) SetActivityStore(store ActivityStore) {
+	b.activity = store
+}
+
+// Record adds an entry to the activity feed and publishes it, so the
+// dashboard can show it as it happens. Failing to store it is only logged.
+func (b *Bus) Record(ctx context.Context, kind models.ActivityKind, appID, buildID, format string, args ...any) {
+	if b == nil {
+		return
+	}
+
+	activity := &models.Activity{
+		Kind:      kind,
+		AppID:     appID,
+		BuildID:   buildID,
+		Message:   fmt.Sprintf(format, args...),
+		CreatedAt: time.Now().UTC(),
+	}
+	if b.activity != nil {
+		if err := b.activity.Create(ctx, activity); err != nil {
+			slog.WarnContext(ctx, "failed to record activity", "kind", kind, "error", err)
+		}
+	}
+
+	b.Publish(Event{
+		Kind:    KindActivity,
+		AppID:   appID,
+		BuildID: buildID,
+		Status:  string(kind),
+		Message: activity.Message,
+		Time:    activity.CreatedAt,
+	})
+}
@@ -0,0 +1,88 @@
+// Package events passes changes to apps, builds and containers to the
+// pages showing them
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is how many events a subscriber can fall behind before
+// newer ones are dropped for it
+const subscriberBuffer = 64
+
+// Kind is what an event is about
+type Kind string
+
+const (
+	KindApp       Kind = "app"
+	KindBuild     Kind = "build"
+	KindContainer Kind = "container"
+	KindActivity  Kind = "activity"
+)
+
+// Statuses of app events. Build and container events carry the build status
+// or container state instead, and activity events the kind of entry.
+const (
+	AppCreated = "created"
+	AppUpdated = "updated"
+	AppDeleted = "deleted"
+)
+
+// Event is a change to an app, one of its builds or its container
+type Event struct {
+	Kind    Kind      `json:"kind"`
+	AppID   string    `json:"app_id"`
+	BuildID string    `json:"build_id,omitempty"`
+	Status  string    `json:"status,omitempty"`
+	Message string    `json:"message,omitempty"` // What happened, for activity events
+	Time    time.Time `json:"time"`
+}
+
+// Bus fans events out to every subscriber. A nil Bus drops them, so
+// publishers don't have to check whether anything is listening.
+type Bus struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+	activity    ActivityStore
+}
+
+// NewBus creates a Bus with no subscribers
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[chan Event]struct{})}
+}
+
+// Publish sends an event to every subscriber without waiting on them
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel that receives every event published from now
+// on, and a function that stops them
+func (b *Bus) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		b.mu.Unlock()
+	}
+}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"schooner/internal/models"
+)
+
+func TestBus(t *testing.T) {
+	var nilBus *Bus
+	nilBus.Publish(Event{Kind: KindApp}) // dropped, not a panic
+
+	bus := NewBus()
+	first, unsubscribe := bus.Subscribe()
+	second, _ := bus.Subscribe()
+
+	bus.Publish(Event{Kind: KindBuild, AppID: "api", BuildID: "b1", Status: "building"})
+	for _, ch := range []<-chan Event{first, second} {
+		event := <-ch
+		if event.BuildID != "b1" || event.Time.IsZero() {
+			t.Errorf("received %+v, want build b1 stamped with a time", event)
+		}
+	}
+
+	unsubscribe()
+	bus.Publish(Event{Kind: KindApp, AppID: "api", Status: AppUpdated})
+	if len(first) != 0 {
+		t.Error("received an event after unsubscribing")
+	}
+	if len(second) != 1 {
+		t.Error("other subscriber missed an event")
+	}
+
+	// A subscriber that stops reading doesn't block publishers
+	for i := 0; i < subscriberBuffer*2; i++ {
+		bus.Publish(Event{Kind: KindContainer, AppID: "api", Status: "running"})
+	}
+	if len(second) != subscriberBuffer {
+		t.Errorf("slow subscriber has %d events queued, want %d", len(second), subscriberBuffer)
+	}
+}
+
+type fakeActivityStore struct {
+	created []*models.Activity
+}
+
+func (f *fakeActivityStore) Create(ctx context.Context, activity *models.Activity) error {
+	f.created = append(f.created, activity)
+	return nil
+}
+
+func TestRecord(t *testing.T) {
+	var nilBus *Bus
+	nilBus.Record(context.Background(), models.ActivityDeploy, "api", "", "dropped") // not a panic
+
+	store := &fakeActivityStore{}
+	bus := NewBus()
+	bus.SetActivityStore(store)
+	ch, _ := bus.Subscribe()
+
+	bus.Record(context.Background(), models.ActivityDeploy, "api", "b1", "Deployed %s", "api")
+
+	if len(store.created) != 1 || store.created[0].Message != "Deployed api" || store.created[0].CreatedAt.IsZero() {
+		t.Fatalf("stored %+v, want one stamped deploy entry", store.created)
+	}
+	event := <-ch
+	if event.Kind != KindActivity || event.Status != string(models.ActivityDeploy) || event.Message != "Deployed api" {
+		t.Errorf("received %+v, want the deploy activity", event)
+	}
+}
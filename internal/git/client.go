@@ -3,11 +3,13 @@ package git
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 
@@ -22,9 +24,10 @@ import (
 
 // Client provides git operations
 type Client struct {
-	workDir string
-	auth    transport.AuthMethod
-	logger  *slog.Logger
+	workDir    string
+	auth       transport.AuthMethod
+	sshKeyPath string // Key behind SSH auth, for the git binary
+	logger     *slog.Logger
 }
 
 // ClientOption configures the git client
@@ -56,6 +59,7 @@ func WithSSHKey(keyPath string) ClientOption {
 			return
 		}
 		c.auth = auth
+		c.sshKeyPath = keyPath
 	}
 }
 
@@ -89,27 +93,53 @@ func (c *Client) SetHTTPAuth(username, token string) {
 
 // CloneOptions configures clone/pull operations
 type CloneOptions struct {
-	URL      string
-	Branch   string
-	Depth    int
-	Progress io.Writer
+	URL        string
+	Branch     string
+	Depth      int
+	Progress   io.Writer
+	Path       string // Checkout directory, RepoPath(URL) if empty
+	Submodules bool   // Check out submodules recursively
+	LFS        bool   // Replace Git LFS pointers with their files
+	Tag        string // Tag to check out instead of the branch head
+	Commit     string // Commit to check out instead of the branch or tag head
+}
+
+// targetRef returns the reference a checkout follows, the tag when one is
+// given and the branch otherwise
+func (o CloneOptions) targetRef() plumbing.ReferenceName {
+	if o.Tag != "" {
+		return plumbing.NewTagReferenceName(o.Tag)
+	}
+	return plumbing.NewBranchReferenceName(o.Branch)
 }
 
 // CloneOrPull clones a repository if it doesn't exist, or pulls updates
 func (c *Client) CloneOrPull(ctx context.Context, opts CloneOptions) (*git.Repository, error) {
-	repoPath := c.RepoPath(opts.URL)
+	repoPath := opts.Path
+	if repoPath == "" {
+		repoPath = c.RepoPath(opts.URL)
+	}
 
 	// Check if repo already exists
 	if _, err := os.Stat(filepath.Join(repoPath, ".git")); err == nil {
 		return c.pull(ctx, repoPath, opts)
 	}
 
+	// Clones start at the branch or tag head, so a pinned commit is
+	// checked out over a fresh clone
+	if opts.Commit != "" {
+		if _, err := c.clone(ctx, repoPath, opts); err != nil {
+			return nil, err
+		}
+		return c.pull(ctx, repoPath, opts)
+	}
+
 	return c.clone(ctx, repoPath, opts)
 }
 
 // clone clones a new repository
 func (c *Client) clone(ctx context.Context, path string, opts CloneOptions) (*git.Repository, error) {
-	c.logger.Info("cloning repository", "url", opts.URL, "branch", opts.Branch)
+	c.logger.Info("cloning repository", "url", opts.URL, "ref", opts.targetRef())
 
 	// Ensure parent directory exists
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
@@ -119,7 +149,7 @@ func (c *Client) clone(ctx context.Context, path string, opts CloneOptions) (*gi
 	cloneOpts := &git.CloneOptions{
 		URL:           opts.URL,
 		Auth:          c.auth,
-		ReferenceName: plumbing.NewBranchReferenceName(opts.Branch),
+		ReferenceName: opts.targetRef(),
 		SingleBranch:  true,
 		Progress:      opts.Progress,
 	}
@@ -133,13 +163,17 @@ func (c *Client) clone(ctx context.Context, path string, opts CloneOptions) (*gi
 		return nil, fmt.Errorf("failed to clone repository: %w", err)
 	}
 
+	if err := c.checkoutExtras(ctx, repo, path, opts); err != nil {
+		return nil, err
+	}
+
 	c.logger.Info("repository cloned", "path", path)
 	return repo, nil
 }
 
 // pull pulls updates for an existing repository
 func (c *Client) pull(ctx context.Context, path string, opts CloneOptions) (*git.Repository, error) {
-	c.logger.Info("pulling repository", "path", path, "branch", opts.Branch)
+	c.logger.Info("pulling repository", "path", path, "ref", opts.targetRef())
 
 	repo, err := git.PlainOpen(path)
 	if err != nil {
@@ -151,14 +185,29 @@ func (c *Client) pull(ctx context.Context, path string, opts CloneOptions) (*git
 		return c.clone(ctx, path, opts)
 	}
 
+	// A checkout of another repository, say after the app's URL changed,
+	// is replaced
+	if remoteURL, err := c.GetRemoteURL(path); err != nil || remoteURL != opts.URL {
+		c.logger.Info("repository URL changed, will re-clone", "path", path)
+		return c.reclone(ctx, path, opts)
+	}
+
 	w, err := repo.Worktree()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get worktree: %w", err)
 	}
 
-	// Fetch first to get latest refs
+	// Fetch first to get latest refs. Clones are single-branch, so the
+	// branch or tag is named in case it was never fetched.
+	branchRef := plumbing.NewBranchReferenceName(opts.Branch)
+	localRef := plumbing.NewRemoteReferenceName("origin", opts.Branch)
+	if opts.Tag != "" {
+		branchRef = opts.targetRef()
+		localRef = branchRef
+	}
 	fetchOpts := &git.FetchOptions{
 		RemoteName: "origin",
+		RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("+%s:%s", branchRef, localRef))},
 		Auth:       c.auth,
 		Progress:   opts.Progress,
 		Force:      true,
@@ -168,34 +217,152 @@ func (c *Client) pull(ctx context.Context, path string, opts CloneOptions) (*git
 		c.logger.Warn("fetch failed", "error", err)
 	}
 
-	// Checkout and reset to the target branch
-	branchRef := plumbing.NewBranchReferenceName(opts.Branch)
-	remoteRef := plumbing.NewRemoteReferenceName("origin", opts.Branch)
-
-	// Get remote ref
-	ref, err := repo.Reference(remoteRef, true)
+	// Resolve the commit to check out, peeling annotated tags
+	hash, err := repo.ResolveRevision(plumbing.Revision(localRef))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get remote reference: %w", err)
 	}
 
-	// Reset to remote HEAD
+	if opts.Commit != "" {
+		if hash, err = c.fetchCommit(ctx, repo, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	// Point HEAD at the target branch, so the reset moves it rather than
+	// the branch checked out before, creating the branch if the checkout
+	// never had it. Tags and commits are checked out detached.
+	head := plumbing.NewSymbolicReference(plumbing.HEAD, branchRef)
+	if opts.Tag != "" || opts.Commit != "" {
+		head = plumbing.NewHashReference(plumbing.HEAD, *hash)
+	} else if err := repo.Storer.SetReference(plumbing.NewHashReference(branchRef, *hash)); err != nil {
+		return nil, fmt.Errorf("failed to switch branch: %w", err)
+	}
+	if err := repo.Storer.SetReference(head); err != nil {
+		return nil, fmt.Errorf("failed to switch branch: %w", err)
+	}
+
+	// Reset to the target commit
 	if err := w.Reset(&git.ResetOptions{
-		Commit: ref.Hash(),
+		Commit: *hash,
 		Mode:   git.HardReset,
 	}); err != nil {
 		return nil, fmt.Errorf("failed to reset: %w", err)
 	}
 
-	// Update local branch reference
-	localRef := plumbing.NewHashReference(branchRef, ref.Hash())
-	if err := repo.Storer.SetReference(localRef); err != nil {
-		c.logger.Warn("failed to update local branch ref", "error", err)
+	if err := c.checkoutExtras(ctx, repo, path, opts); err != nil {
+		return nil, err
 	}
 
 	c.logger.Info("repository updated", "path", path)
 	return repo, nil
 }
 
+// fetchCommit fetches the commit a checkout is pinned to, unless the
+// repository already has it
+func (c *Client) fetchCommit(ctx context.Context, repo *git.Repository, opts CloneOptions) (*plumbing.Hash, error) {
+	hash := plumbing.NewHash(opts.Commit)
+	if _, err := repo.CommitObject(hash); err == nil {
+		return &hash, nil
+	}
+
+	err := repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: "origin",
+		RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("%s:refs/schooner/pinned", hash))},
+		Auth:       c.auth,
+		Progress:   opts.Progress,
+		Depth:      1,
+		Force:      true,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return nil, fmt.Errorf("failed to fetch commit %s: %w", opts.Commit, err)
+	}
+	if _, err := repo.CommitObject(hash); err != nil {
+		return nil, fmt.Errorf("commit %s not found: %w", opts.Commit, err)
+	}
+	return &hash, nil
+}
+
+// checkoutExtras brings in the submodules and LFS files of a checkout when
+// asked to
+func (c *Client) checkoutExtras(ctx context.Context, repo *git.Repository, path string, opts CloneOptions) error {
+	if opts.Submodules {
+		w, err := repo.Worktree()
+		if err != nil {
+			return fmt.Errorf("failed to get worktree: %w", err)
+		}
+		submodules, err := w.Submodules()
+		if err != nil {
+			return fmt.Errorf("failed to read submodules: %w", err)
+		}
+		if len(submodules) > 0 {
+			fmt.Fprintf(progressWriter(opts.Progress), "Updating %d submodule(s)\n", len(submodules))
+		}
+		if err := submodules.UpdateContext(ctx, &git.SubmoduleUpdateOptions{
+			Init:              true,
+			RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
+			Auth:              c.auth,
+		}); err != nil {
+			return fmt.Errorf("failed to update submodules: %w", err)
+		}
+	}
+
+	if opts.LFS {
+		// go-git can't smudge LFS pointers, so the git binary fetches them
+		// with the client's credentials
+		cmd := exec.CommandContext(ctx, "git", "lfs", "pull", "origin")
+		cmd.Dir = path
+		cmd.Env = append(os.Environ(), c.gitEnv()...)
+		cmd.Stdout = progressWriter(opts.Progress)
+		cmd.Stderr = progressWriter(opts.Progress)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to pull LFS files (is git-lfs installed?): %w", err)
+		}
+	}
+
+	return nil
+}
+
+// gitEnv returns the environment handing the client's credentials to the git
+// binary. HTTP credentials go in a config header through the environment
+// rather than the command line, where other processes could read them.
+func (c *Client) gitEnv() []string {
+	env := []string{"GIT_TERMINAL_PROMPT=0"}
+	switch auth := c.auth.(type) {
+	case *http.BasicAuth:
+		if auth.Password == "" {
+			break
+		}
+		creds := base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password))
+		env = append(env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+creds,
+		)
+	case *ssh.PublicKeys:
+		if c.sshKeyPath != "" {
+			env = append(env, "GIT_SSH_COMMAND=ssh -i '"+c.sshKeyPath+"' -o IdentitiesOnly=yes")
+		}
+	}
+	return env
+}
+
+// progressWriter returns w, or a writer discarding output when it's nil
+func progressWriter(w io.Writer) io.Writer {
+	if w == nil {
+		return io.Discard
+	}
+	return w
+}
+
+// reclone replaces a checkout with a fresh clone
+func (c *Client) reclone(ctx context.Context, path string, opts CloneOptions) (*git.Repository, error) {
+	if err := os.RemoveAll(path); err != nil {
+		return nil, fmt.Errorf("failed to remove checkout: %w", err)
+	}
+	return c.clone(ctx, path, opts)
+}
+
 // GetHeadCommit returns the HEAD commit
 func (c *Client) GetHeadCommit(repo *git.Repository) (*object.Commit, error) {
 	ref, err := repo.Head()
@@ -211,6 +378,11 @@ func (c *Client) GetHeadCommit(repo *git.Repository) (*object.Commit, error) {
 	return commit, nil
 }
 
+// WorkDir returns the directory repositories are cloned into
+func (c *Client) WorkDir() string {
+	return c.workDir
+}
+
 // RepoPath returns the local path for a repository URL
 func (c *Client) RepoPath(url string) string {
 	return RepoPath(c.workDir, url)
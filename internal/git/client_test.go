@@ -0,0 +1,248 @@
+package git
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+func TestGitEnv(t *testing.T) {
+	creds := base64.StdEncoding.EncodeToString([]byte("x-access-token:secret"))
+	tests := []struct {
+		name string
+		auth *http.BasicAuth
+		want []string
+	}{
+		{"no auth", nil, []string{"GIT_TERMINAL_PROMPT=0"}},
+		{"cleared token", &http.BasicAuth{}, []string{"GIT_TERMINAL_PROMPT=0"}},
+		{
+			"token",
+			&http.BasicAuth{Username: "x-access-token", Password: "secret"},
+			[]string{
+				"GIT_TERMINAL_PROMPT=0",
+				"GIT_CONFIG_COUNT=1",
+				"GIT_CONFIG_KEY_0=http.extraHeader",
+				"GIT_CONFIG_VALUE_0=Authorization: Basic " + creds,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{}
+			if tt.auth != nil {
+				c.auth = tt.auth
+			}
+			got := c.gitEnv()
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("gitEnv() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// newOrigin creates a repository with a commit on main tagged v1.0.0 and a
+// later commit, returning its path
+func newOrigin(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInitWithOptions(dir, &git.PlainInitOptions{
+		InitOptions: git.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName("main")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+	commit := func(content string) plumbing.Hash {
+		if err := os.WriteFile(filepath.Join(dir, "VERSION"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Add("VERSION"); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := w.Commit(content, &git.CommitOptions{Author: sig})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+
+	release := commit("1.0.0")
+	if _, err := repo.CreateTag("v1.0.0", release, &git.CreateTagOptions{Tagger: sig, Message: "v1.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+	commit("next")
+	return dir
+}
+
+func TestCloneOrPullTag(t *testing.T) {
+	origin := newOrigin(t)
+	c, err := NewClient(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	path := filepath.Join(c.WorkDir(), "checkout")
+
+	read := func() string {
+		data, err := os.ReadFile(filepath.Join(path, "VERSION"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	// Clone the branch, then move the checkout to the annotated tag and back
+	if _, err := c.CloneOrPull(ctx, CloneOptions{URL: origin, Branch: "main", Path: path}); err != nil {
+		t.Fatalf("clone branch: %v", err)
+	}
+	if got := read(); got != "next" {
+		t.Errorf("branch checkout VERSION = %q, want next", got)
+	}
+	if _, err := c.CloneOrPull(ctx, CloneOptions{URL: origin, Branch: "main", Tag: "v1.0.0", Path: path}); err != nil {
+		t.Fatalf("pull tag: %v", err)
+	}
+	if got := read(); got != "1.0.0" {
+		t.Errorf("tag checkout VERSION = %q, want 1.0.0", got)
+	}
+	if _, err := c.CloneOrPull(ctx, CloneOptions{URL: origin, Branch: "main", Path: path}); err != nil {
+		t.Fatalf("pull branch: %v", err)
+	}
+	if got := read(); got != "next" {
+		t.Errorf("branch checkout VERSION = %q, want next", got)
+	}
+
+	// A fresh clone of the tag
+	path = filepath.Join(c.WorkDir(), "tagged")
+	if _, err := c.CloneOrPull(ctx, CloneOptions{URL: origin, Branch: "main", Tag: "v1.0.0", Path: path}); err != nil {
+		t.Fatalf("clone tag: %v", err)
+	}
+	if got := read(); got != "1.0.0" {
+		t.Errorf("tag clone VERSION = %q, want 1.0.0", got)
+	}
+}
+
+func TestCloneOrPullCommit(t *testing.T) {
+	origin := newOrigin(t)
+	c, err := NewClient(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	repo, err := git.PlainOpen(origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release, err := repo.ResolveRevision("v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pinning a fresh clone and a checkout of the branch head, which a
+	// shallow clone doesn't have the older commit of
+	for _, name := range []string{"fresh", "existing"} {
+		path := filepath.Join(c.WorkDir(), name)
+		if name == "existing" {
+			if _, err := c.CloneOrPull(ctx, CloneOptions{URL: origin, Branch: "main", Depth: 1, Path: path}); err != nil {
+				t.Fatalf("clone branch: %v", err)
+			}
+		}
+
+		checkout, err := c.CloneOrPull(ctx, CloneOptions{URL: origin, Branch: "main", Depth: 1, Path: path, Commit: release.String()})
+		if err != nil {
+			t.Fatalf("%s: pin commit: %v", name, err)
+		}
+		head, err := checkout.Head()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if head.Hash() != *release {
+			t.Errorf("%s: HEAD = %s, want %s", name, head.Hash(), release)
+		}
+		data, err := os.ReadFile(filepath.Join(path, "VERSION"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "1.0.0" {
+			t.Errorf("%s: VERSION = %q, want 1.0.0", name, data)
+		}
+	}
+}
+
+func TestCloneOrPullBranchSwitch(t *testing.T) {
+	origin := newOrigin(t)
+	repo, err := git.PlainOpen(origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature"), Create: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(origin, "VERSION"), []byte("feature"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Add("VERSION"); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+	if _, err := w.Commit("feature", &git.CommitOptions{Author: sig}); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewClient(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	path := filepath.Join(c.WorkDir(), "checkout")
+
+	// A single-branch clone of main pulled after the app moved to another
+	// branch, and back
+	tests := []struct {
+		branch string
+		want   string
+	}{
+		{branch: "main", want: "next"},
+		{branch: "feature", want: "feature"},
+		{branch: "main", want: "next"},
+	}
+	for _, tt := range tests {
+		checkout, err := c.CloneOrPull(ctx, CloneOptions{URL: origin, Branch: tt.branch, Depth: 1, Path: path})
+		if err != nil {
+			t.Fatalf("%s: CloneOrPull() error = %v", tt.branch, err)
+		}
+		head, err := checkout.Head()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if head.Name() != plumbing.NewBranchReferenceName(tt.branch) {
+			t.Errorf("%s: HEAD = %s, want the branch checked out", tt.branch, head.Name())
+		}
+		data, err := os.ReadFile(filepath.Join(path, "VERSION"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("%s: VERSION = %q, want %q", tt.branch, data, tt.want)
+		}
+	}
+}
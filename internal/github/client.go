@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -65,24 +67,107 @@ func (c *Client) GetToken() string {
 	return c.token
 }
 
-// ListUserRepos lists repositories for the authenticated user
-func (c *Client) ListUserRepos(ctx context.Context, page, perPage int) ([]Repository, error) {
+// Organization represents a GitHub organization the user belongs to
+type Organization struct {
+	Login       string `json:"login"`
+	Description string `json:"description"`
+	AvatarURL   string `json:"avatar_url"`
+}
+
+// RepoListOptions selects a page of repositories
+type RepoListOptions struct {
+	Owner   string // Organization or user login, empty for the authenticated user's repos
+	Query   string // Search repository names, server-side
+	Page    int
+	PerPage int
+}
+
+// ListRepos lists a page of repositories, most recently pushed first, and
+// reports whether there's another page. Without an owner it lists every repo
+// the authenticated user can see; searches are limited to an owner, the user
+// themselves when none is given.
+func (c *Client) ListRepos(ctx context.Context, opts RepoListOptions) ([]Repository, bool, error) {
 	if c.token == "" {
-		return nil, fmt.Errorf("GitHub token not configured")
+		return nil, false, fmt.Errorf("GitHub token not configured")
 	}
 
-	if perPage <= 0 {
-		perPage = 30
+	if opts.PerPage <= 0 {
+		opts.PerPage = 30
 	}
-	if page <= 0 {
-		page = 1
+	if opts.Page <= 0 {
+		opts.Page = 1
 	}
 
-	url := fmt.Sprintf("https://api.github.com/user/repos?sort=pushed&direction=desc&per_page=%d&page=%d&affiliation=owner,collaborator,organization_member", perPage, page)
+	if opts.Query != "" {
+		owner := opts.Owner
+		if owner == "" {
+			login, err := c.GetUser(ctx)
+			if err != nil {
+				return nil, false, err
+			}
+			owner = login
+		}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		params := url.Values{
+			"q":        {searchQuery(opts.Query, owner)},
+			"sort":     {"updated"},
+			"per_page": {strconv.Itoa(opts.PerPage)},
+			"page":     {strconv.Itoa(opts.Page)},
+		}
+		var result struct {
+			Items []Repository `json:"items"`
+		}
+		hasNext, err := c.getPage(ctx, "https://api.github.com/search/repositories?"+params.Encode(), &result)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to search repos: %w", err)
+		}
+		return result.Items, hasNext, nil
+	}
+
+	endpoint := fmt.Sprintf("https://api.github.com/user/repos?sort=pushed&direction=desc&per_page=%d&page=%d&affiliation=owner,collaborator,organization_member", opts.PerPage, opts.Page)
+	if opts.Owner != "" {
+		endpoint = fmt.Sprintf("https://api.github.com/orgs/%s/repos?type=all&sort=pushed&direction=desc&per_page=%d&page=%d", url.PathEscape(opts.Owner), opts.PerPage, opts.Page)
+	}
+
+	var repos []Repository
+	hasNext, err := c.getPage(ctx, endpoint, &repos)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, false, fmt.Errorf("failed to fetch repos: %w", err)
+	}
+	return repos, hasNext, nil
+}
+
+// ListOrgs lists the organizations the authenticated user belongs to
+func (c *Client) ListOrgs(ctx context.Context) ([]Organization, error) {
+	if c.token == "" {
+		return nil, fmt.Errorf("GitHub token not configured")
+	}
+
+	var orgs []Organization
+	for page := 1; ; page++ {
+		var batch []Organization
+		hasNext, err := c.getPage(ctx, fmt.Sprintf("https://api.github.com/user/orgs?per_page=100&page=%d", page), &batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch organizations: %w", err)
+		}
+		orgs = append(orgs, batch...)
+		if !hasNext {
+			return orgs, nil
+		}
+	}
+}
+
+// searchQuery builds a repository search limited to an owner's repo names
+func searchQuery(query, owner string) string {
+	return fmt.Sprintf("%s in:name user:%s fork:true", strings.TrimSpace(query), owner)
+}
+
+// getPage fetches one page of a GitHub API list into v and reports whether
+// the Link header points to a next page
+func (c *Client) getPage(ctx context.Context, endpoint string, v interface{}) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.token)
@@ -91,21 +176,30 @@ func (c *Client) ListUserRepos(ctx context.Context, page, perPage int) ([]Reposi
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch repos: %w", err)
+		return false, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API error (status %d): %s", resp.StatusCode, string(body))
+		return false, fmt.Errorf("GitHub API error (status %d): %s", resp.StatusCode, string(body))
 	}
 
-	var repos []Repository
-	if err := json.NewDecoder(resp.Body).Decode(&repos); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return false, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return repos, nil
+	return hasNextPage(resp.Header.Get("Link")), nil
+}
+
+// hasNextPage reports whether a Link header has a rel="next" link
+func hasNextPage(link string) bool {
+	for _, part := range strings.Split(link, ",") {
+		if strings.Contains(part, `rel="next"`) {
+			return true
+		}
+	}
+	return false
 }
 
 // GetRepo fetches details for a specific repository
@@ -279,6 +373,41 @@ func (c *Client) CheckRepoHasDockerCompose(ctx context.Context, owner, repo stri
 	return false, "", nil
 }
 
+// GetFileContent fetches the raw content of a file on a repo's default
+// branch, or nil if the file doesn't exist
+func (c *Client) GetFileContent(ctx context.Context, owner, repo, path string) ([]byte, error) {
+	if c.token == "" {
+		return nil, fmt.Errorf("GitHub token not configured")
+	}
+
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s", owner, repo, path)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/vnd.github.raw+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GitHub API error: %s - %s", resp.Status, string(body))
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
 // Webhook represents a GitHub webhook
 type Webhook struct {
 	ID     int64    `json:"id"`
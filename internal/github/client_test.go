@@ -0,0 +1,40 @@
+package github
+
+import "testing"
+
+func TestHasNextPage(t *testing.T) {
+	tests := []struct {
+		name string
+		link string
+		want bool
+	}{
+		{"empty", "", false},
+		{"next and last", `<https://api.github.com/user/repos?page=2>; rel="next", <https://api.github.com/user/repos?page=5>; rel="last"`, true},
+		{"last page", `<https://api.github.com/user/repos?page=1>; rel="first", <https://api.github.com/user/repos?page=4>; rel="prev"`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasNextPage(tt.link); got != tt.want {
+				t.Errorf("hasNextPage(%q) = %v, want %v", tt.link, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		owner string
+		want  string
+	}{
+		{"api", "acme", "api in:name user:acme fork:true"},
+		{"  web app ", "octocat", "web app in:name user:octocat fork:true"},
+	}
+
+	for _, tt := range tests {
+		if got := searchQuery(tt.query, tt.owner); got != tt.want {
+			t.Errorf("searchQuery(%q, %q) = %q, want %q", tt.query, tt.owner, got, tt.want)
+		}
+	}
+}
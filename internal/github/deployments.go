@@ -0,0 +1,100 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Deployment states a deployment status can report
+const (
+	DeploymentInProgress = "in_progress"
+	DeploymentSuccess    = "success"
+	DeploymentFailure    = "failure"
+	DeploymentError      = "error"
+)
+
+// DeploymentStatus is a change in a deployment's state
+type DeploymentStatus struct {
+	State          string `json:"state"`
+	Description    string `json:"description,omitempty"`
+	EnvironmentURL string `json:"environment_url,omitempty"` // Where "View deployment" links
+	LogURL         string `json:"log_url,omitempty"`
+}
+
+// CreateDeployment records a deployment of ref to an environment of a
+// repository, creating the environment if it doesn't exist, and returns
+// its ID
+func (c *Client) CreateDeployment(ctx context.Context, owner, repo, ref, environment, description string) (int64, error) {
+	payload := map[string]interface{}{
+		"ref":               ref,
+		"environment":       environment,
+		"description":       description,
+		"auto_merge":        false,
+		"required_contexts": []string{}, // Schooner already decided to deploy
+	}
+
+	var deployment struct {
+		ID int64 `json:"id"`
+	}
+	endpoint := fmt.Sprintf("https://api.github.com/repos/%s/%s/deployments", owner, repo)
+	if err := c.post(ctx, endpoint, payload, &deployment); err != nil {
+		return 0, fmt.Errorf("failed to create deployment: %w", err)
+	}
+	return deployment.ID, nil
+}
+
+// CreateDeploymentStatus reports a deployment's state. GitHub marks earlier
+// deployments to the same environment inactive once one succeeds.
+func (c *Client) CreateDeploymentStatus(ctx context.Context, owner, repo string, deploymentID int64, status DeploymentStatus) error {
+	endpoint := fmt.Sprintf("https://api.github.com/repos/%s/%s/deployments/%d/statuses", owner, repo, deploymentID)
+	if err := c.post(ctx, endpoint, status, nil); err != nil {
+		return fmt.Errorf("failed to update deployment status: %w", err)
+	}
+	return nil
+}
+
+// post sends payload as JSON to endpoint and decodes the created resource
+// into v, if it's not nil
+func (c *Client) post(ctx context.Context, endpoint string, payload, v interface{}) error {
+	if c.token == "" {
+		return fmt.Errorf("GitHub token not configured")
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach GitHub: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("GitHub API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
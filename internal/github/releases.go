@@ -0,0 +1,85 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Release is a published GitHub release
+type Release struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	HTMLURL     string    `json:"html_url"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// GetLatestRelease fetches the latest published release of a repository,
+// or nil if it has none. Public repositories don't need a token.
+func (c *Client) GetLatestRelease(ctx context.Context, owner, repo string) (*Release, error) {
+	endpoint := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", owner, repo)
+
+	var release Release
+	found, err := c.getPublic(ctx, endpoint, &release)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// CompareCommits reports how head relates to base: "ahead" if head has
+// commits base lacks, "behind", "identical" or "diverged"
+func (c *Client) CompareCommits(ctx context.Context, owner, repo, base, head string) (string, error) {
+	endpoint := fmt.Sprintf("https://api.github.com/repos/%s/%s/compare/%s...%s",
+		owner, repo, url.PathEscape(base), url.PathEscape(head))
+
+	var comparison struct {
+		Status string `json:"status"`
+	}
+	found, err := c.getPublic(ctx, endpoint, &comparison)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("commit %s or %s not found", base, head)
+	}
+	return comparison.Status, nil
+}
+
+// getPublic decodes a GET of a public endpoint into v, using the token if
+// there is one for its higher rate limit. It reports false on a 404.
+func (c *Client) getPublic(ctx context.Context, endpoint string, v interface{}) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to reach GitHub: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return false, fmt.Errorf("GitHub API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return false, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return true, nil
+}
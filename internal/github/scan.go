@@ -0,0 +1,82 @@
+package github
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// ExposedPorts returns the ports a Dockerfile declares with EXPOSE, in order
+// and without duplicates. Ports given as build arguments are skipped.
+func ExposedPorts(dockerfile []byte) []int {
+	var ports []int
+	seen := make(map[int]bool)
+	for _, line := range dockerfileInstructions(dockerfile) {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "EXPOSE") {
+			continue
+		}
+		for _, field := range fields[1:] {
+			// 8080/tcp, 53/udp
+			field, _, _ = strings.Cut(field, "/")
+			port, err := strconv.Atoi(field)
+			if err != nil || port <= 0 || port > 65535 || seen[port] {
+				continue
+			}
+			seen[port] = true
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// dockerfileInstructions splits a Dockerfile into instructions, joining
+// backslash continuations and dropping comments
+func dockerfileInstructions(dockerfile []byte) []string {
+	var instructions []string
+	var current strings.Builder
+	scanner := bufio.NewScanner(bytes.NewReader(dockerfile))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if cont, ok := strings.CutSuffix(line, `\`); ok {
+			current.WriteString(cont + " ")
+			continue
+		}
+		current.WriteString(line)
+		if s := strings.TrimSpace(current.String()); s != "" {
+			instructions = append(instructions, s)
+		}
+		current.Reset()
+	}
+	if s := strings.TrimSpace(current.String()); s != "" {
+		instructions = append(instructions, s)
+	}
+	return instructions
+}
+
+// EnvExampleKeys returns the variable names defined in a .env.example file,
+// in order and without duplicates
+func EnvExampleKeys(content []byte) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, _, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,44 @@
+package github
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExposedPorts(t *testing.T) {
+	tests := []struct {
+		name       string
+		dockerfile string
+		want       []int
+	}{
+		{"none", "FROM alpine\nCMD [\"sh\"]\n", nil},
+		{"single", "FROM nginx\nEXPOSE 80\n", []int{80}},
+		{"protocols and duplicates", "FROM app\nexpose 8080/tcp 53/udp\nEXPOSE 8080\n", []int{8080, 53}},
+		{"continuation", "FROM app\nEXPOSE 3000 \\\n    3001\n", []int{3000, 3001}},
+		{"build arg skipped", "FROM app\nARG PORT=8000\nEXPOSE ${PORT} 9090\n", []int{9090}},
+		{"commented out", "FROM app\n# EXPOSE 22\n", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExposedPorts([]byte(tt.dockerfile)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExposedPorts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvExampleKeys(t *testing.T) {
+	content := `# Database
+DATABASE_URL=postgres://localhost/app
+export SECRET_KEY=
+  PORT = 8080
+
+DATABASE_URL=duplicate
+not a variable
+`
+	want := []string{"DATABASE_URL", "SECRET_KEY", "PORT"}
+	if got := EnvExampleKeys([]byte(content)); !reflect.DeepEqual(got, want) {
+		t.Errorf("EnvExampleKeys() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,56 @@
+package health
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// nvidiaGPUDir is where the NVIDIA driver lists the host's GPUs, one
+// directory per PCI address. /proc/driver isn't namespaced, so it reads the
+// same from inside a container.
+const nvidiaGPUDir = "/proc/driver/nvidia/gpus"
+
+// GPUHealth lists the GPUs found on the host
+type GPUHealth struct {
+	Count  int      `json:"count"`
+	Models []string `json:"models,omitempty"`
+}
+
+// getGPUs reads the GPUs of the NVIDIA driver in dir. Hosts without the
+// driver have none.
+func getGPUs(dir string) GPUHealth {
+	var gpus GPUHealth
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return gpus
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		gpus.Count++
+		if model := readGPUModel(filepath.Join(dir, entry.Name(), "information")); model != "" {
+			gpus.Models = append(gpus.Models, model)
+		}
+	}
+	return gpus
+}
+
+// readGPUModel returns the Model line of a GPU's information file
+func readGPUModel(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if model, ok := strings.CutPrefix(scanner.Text(), "Model:"); ok {
+			return strings.TrimSpace(model)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,32 @@
+package health
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetGPUs(t *testing.T) {
+	dir := t.TempDir()
+	for bus, info := range map[string]string{
+		"0000:01:00.0": "Model: \t\t NVIDIA GeForce RTX 3090\nIRQ:   \t\t 130\n",
+		"0000:02:00.0": "IRQ:   \t\t 131\n",
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, bus), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, bus, "information"), []byte(info), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gpus := getGPUs(dir)
+	if gpus.Count != 2 || strings.Join(gpus.Models, ",") != "NVIDIA GeForce RTX 3090" {
+		t.Errorf("getGPUs() = %+v, want 2 GPUs with one model known", gpus)
+	}
+
+	if gpus := getGPUs(filepath.Join(dir, "missing")); gpus.Count != 0 {
+		t.Errorf("getGPUs() without a driver = %+v, want none", gpus)
+	}
+}
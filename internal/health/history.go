@@ -0,0 +1,111 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"schooner/internal/models"
+)
+
+const (
+	// historyInterval is how often host health is recorded
+	historyInterval = time.Minute
+
+	// minuteRetention is how long minute samples are kept before they're
+	// rolled up into hourly ones
+	minuteRetention = 24 * time.Hour
+
+	// retention is how long hourly samples are kept
+	retention = 30 * 24 * time.Hour
+)
+
+// SampleStore interface for recording and compacting host health samples
+type SampleStore interface {
+	RecordSample(ctx context.Context, sample *models.SystemSample) error
+	Downsample(ctx context.Context, before time.Time) (int64, error)
+	PruneSamples(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Recorder keeps a history of the host's CPU, memory and disk use
+type Recorder struct {
+	store  SampleStore
+	read   func() (*SystemHealth, error)
+	logger *slog.Logger
+}
+
+// NewRecorder creates a new Recorder
+func NewRecorder(store SampleStore) *Recorder {
+	return &Recorder{
+		store:  store,
+		read:   GetSystemHealth,
+		logger: slog.Default(),
+	}
+}
+
+// Start records a sample right away and then every minute until the
+// context is cancelled
+func (r *Recorder) Start(ctx context.Context) {
+	go func() {
+		r.record(ctx, time.Now())
+
+		ticker := time.NewTicker(historyInterval)
+		defer ticker.Stop()
+
+		lastCompact := time.Time{}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				r.record(ctx, now)
+
+				if now.Sub(lastCompact) >= time.Hour {
+					lastCompact = now
+					r.compact(ctx, now)
+				}
+			}
+		}
+	}()
+}
+
+// record stores the host's current health
+func (r *Recorder) record(ctx context.Context, now time.Time) {
+	health, err := r.read()
+	if err != nil {
+		r.logger.Warn("failed to read system health", "error", err)
+		return
+	}
+
+	sample := &models.SystemSample{
+		Resolution:  models.ResourceResolutionMinute,
+		CPUPercent:  health.CPU.UsagePercent,
+		LoadAvg1:    health.CPU.LoadAvg1,
+		MemoryUsed:  health.Memory.Used,
+		MemoryTotal: health.Memory.Total,
+		DiskUsed:    health.Disk.Used,
+		DiskTotal:   health.Disk.Total,
+		SampledAt:   now.UTC().Truncate(historyInterval),
+	}
+	if err := r.store.RecordSample(ctx, sample); err != nil {
+		r.logger.Warn("failed to record system health", "error", err)
+	}
+}
+
+// compact rolls samples older than a day up into hourly ones and drops
+// those past retention
+func (r *Recorder) compact(ctx context.Context, now time.Time) {
+	// Only whole hours are rolled up, so no hour is split across two rows
+	before := now.UTC().Add(-minuteRetention).Truncate(time.Hour)
+	if n, err := r.store.Downsample(ctx, before); err != nil {
+		r.logger.Warn("failed to downsample system health", "error", err)
+	} else if n > 0 {
+		r.logger.Debug("downsampled system health", "count", n)
+	}
+
+	if n, err := r.store.PruneSamples(ctx, now.Add(-retention)); err != nil {
+		r.logger.Warn("failed to prune system health", "error", err)
+	} else if n > 0 {
+		r.logger.Debug("pruned system health", "count", n)
+	}
+}
@@ -0,0 +1,81 @@
+package health
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"schooner/internal/database"
+	"schooner/internal/database/queries"
+	"schooner/internal/models"
+)
+
+func TestRecorder(t *testing.T) {
+	db := newTestDB(t)
+
+	ctx := context.Background()
+	store := queries.NewSystemHealthQueries(db.DB)
+	r := NewRecorder(store)
+	used := uint64(0)
+	r.read = func() (*SystemHealth, error) {
+		used += 1 << 30
+		return &SystemHealth{
+			CPU:  CPUHealth{UsagePercent: 25},
+			Disk: DiskHealth{Used: used, Total: 1 << 40},
+		}, nil
+	}
+
+	now := time.Now()
+	start := now.Add(-48 * time.Hour).Truncate(time.Hour)
+	for at := start; at.Before(now); at = at.Add(15 * time.Minute) {
+		r.record(ctx, at)
+	}
+	r.compact(ctx, now)
+
+	samples, err := store.ListSince(ctx, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cutoff := now.UTC().Add(-minuteRetention).Truncate(time.Hour)
+	hours, minutes := 0, 0
+	for i, s := range samples {
+		if i > 0 && s.DiskUsed <= samples[i-1].DiskUsed {
+			t.Fatalf("disk use went from %d to %d, want it growing", samples[i-1].DiskUsed, s.DiskUsed)
+		}
+		switch {
+		case s.SampledAt.Before(cutoff) && s.Resolution == models.ResourceResolutionHour:
+			hours++
+		case !s.SampledAt.Before(cutoff) && s.Resolution == models.ResourceResolutionMinute:
+			minutes++
+		default:
+			t.Fatalf("sample at %v is %s", s.SampledAt, s.Resolution)
+		}
+	}
+	if want := int(cutoff.Sub(start.UTC()) / time.Hour); hours != want {
+		t.Errorf("rolled up into %d hourly samples, want %d", hours, want)
+	}
+	if minutes == 0 {
+		t.Error("expected minute samples for the last day")
+	}
+
+	if _, ok := models.PredictDiskFull(samples); !ok {
+		t.Error("expected a disk full estimate from steadily growing use")
+	}
+}
+
+// newTestDB returns a migrated database in a temporary directory, keeping
+// the encryption key it creates there too
+func newTestDB(t *testing.T) *database.DB {
+	t.Helper()
+	t.Setenv("SCHOONER_KEY_PATH", filepath.Join(t.TempDir(), ".encryption_key"))
+	db, err := database.New(filepath.Join(t.TempDir(), "schooner.db"))
+	if err != nil {
+		t.Fatalf("database.New() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	return db
+}
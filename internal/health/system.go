@@ -15,6 +15,7 @@ type SystemHealth struct {
 	CPU        CPUHealth    `json:"cpu"`
 	Memory     MemoryHealth `json:"memory"`
 	Disk       DiskHealth   `json:"disk"`
+	GPU        GPUHealth    `json:"gpu"`
 	Uptime     time.Duration `json:"uptime"`
 	Platform   string       `json:"platform"`
 	NumCPU     int          `json:"num_cpu"`
@@ -85,6 +86,8 @@ func GetSystemHealth() (*SystemHealth, error) {
 		health.Uptime = uptime
 	}
 
+	health.GPU = getGPUs(nvidiaGPUDir)
+
 	return health, nil
 }
 
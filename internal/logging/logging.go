@@ -0,0 +1,86 @@
+// Package logging ties log lines to the HTTP request they were written for
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+type contextKey struct{}
+
+// request is what's known about the request a context belongs to. The user
+// is only known once authentication, further down the middleware chain,
+// has run.
+type request struct {
+	id string
+
+	mu   sync.Mutex
+	user string
+}
+
+// WithRequest returns a context whose log lines carry the request ID
+func WithRequest(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, &request{id: requestID})
+}
+
+// RequestID returns the ID of the request ctx belongs to, if any
+func RequestID(ctx context.Context) string {
+	if req, ok := ctx.Value(contextKey{}).(*request); ok {
+		return req.id
+	}
+	return ""
+}
+
+// SetUser records who made the request ctx belongs to, including for the
+// middleware that logs it after the handlers have run
+func SetUser(ctx context.Context, user string) {
+	if req, ok := ctx.Value(contextKey{}).(*request); ok {
+		req.mu.Lock()
+		req.user = user
+		req.mu.Unlock()
+	}
+}
+
+// User returns who made the request ctx belongs to, if known
+func User(ctx context.Context) string {
+	req, ok := ctx.Value(contextKey{}).(*request)
+	if !ok {
+		return ""
+	}
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	return req.user
+}
+
+// ContextHandler adds the request ID and user to log lines written with
+// the context of a request, such as by slog.ErrorContext
+type ContextHandler struct {
+	slog.Handler
+}
+
+// NewContextHandler wraps a handler to add request details to its lines
+func NewContextHandler(handler slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: handler}
+}
+
+// Handle adds the request details of ctx, if any, to the record
+func (h *ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+		if user := User(ctx); user != "" {
+			record.AddAttrs(slog.String("user", user))
+		}
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs keeps request details on loggers with attributes
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup keeps request details on loggers with groups
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithGroup(name)}
+}
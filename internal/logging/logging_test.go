@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestContextHandler(t *testing.T) {
+	request := WithRequest(context.Background(), "req-1")
+	withUser := WithRequest(context.Background(), "req-2")
+	SetUser(withUser, "octocat")
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{name: "outside a request", ctx: context.Background(), want: "level=INFO msg=hello app=web\n"},
+		{name: "request", ctx: request, want: "level=INFO msg=hello app=web request_id=req-1\n"},
+		{name: "request with user", ctx: withUser, want: "level=INFO msg=hello app=web request_id=req-2 user=octocat\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(NewContextHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.TimeKey {
+						return slog.Attr{}
+					}
+					return a
+				},
+			})))
+
+			logger.With("app", "web").InfoContext(tt.ctx, "hello")
+			if got := buf.String(); got != tt.want {
+				t.Errorf("log line = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetUserOutsideRequest(t *testing.T) {
+	ctx := context.Background()
+	SetUser(ctx, "octocat")
+	if user, id := User(ctx), RequestID(ctx); user != "" || id != "" {
+		t.Errorf("User() = %q, RequestID() = %q outside a request, want neither", user, id)
+	}
+}
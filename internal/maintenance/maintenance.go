@@ -0,0 +1,414 @@
+// Package maintenance keeps the Docker daemon tidy: it trims old app images,
+// prunes dangling images, unused volumes and networks on a schedule, and
+// frees space early when the disk fills up.
+package maintenance
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+
+	"schooner/internal/docker"
+	"schooner/internal/health"
+	"schooner/internal/models"
+	"schooner/internal/notify"
+)
+
+// Settings keys for Docker housekeeping
+const (
+	SettingKeepImages    = "maintenance_keep_images"
+	SettingPruneSchedule = "maintenance_prune_schedule"
+	SettingDiskThreshold = "maintenance_disk_threshold"
+)
+
+const (
+	// tick is how often the scheduler checks the schedule and the disk
+	tick = time.Minute
+
+	// emergencyCooldown is the least time between disk pressure cleanups,
+	// so a disk that stays full isn't pruned every minute
+	emergencyCooldown = time.Hour
+
+	// keepRuns is how many run reports are kept
+	keepRuns = 50
+)
+
+// ErrRunning is returned when a run is requested while one is in progress
+var ErrRunning = errors.New("housekeeping is already running")
+
+// SettingsStore interface for reading and writing settings in the database
+type SettingsStore interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key, value string) error
+}
+
+// RunStore interface for housekeeping run reports
+type RunStore interface {
+	CreateRun(ctx context.Context, run *models.MaintenanceRun) error
+	FinishRun(ctx context.Context, run *models.MaintenanceRun) error
+	ListRuns(ctx context.Context, limit int) ([]*models.MaintenanceRun, error)
+	PruneRuns(ctx context.Context, keep int) error
+	FailRunningRuns(ctx context.Context) (int64, error)
+}
+
+// AppLister lists the apps whose old images are trimmed
+type AppLister interface {
+	List(ctx context.Context) ([]*models.App, error)
+}
+
+// DockerHostResolver returns the Docker client an app deploys with
+type DockerHostResolver interface {
+	ForApp(ctx context.Context, app *models.App) (*docker.Client, error)
+}
+
+// Config holds the housekeeping settings
+type Config struct {
+	KeepImages    int    `json:"keep_images"`    // Images kept per app, 0 keeps all
+	PruneSchedule string `json:"prune_schedule"` // Cron expression, empty disables scheduled runs
+	DiskThreshold int    `json:"disk_threshold"` // Disk use percentage that triggers a cleanup, 0 disables
+}
+
+// Validate checks the retention, schedule and threshold
+func (c Config) Validate() error {
+	if c.KeepImages < 0 {
+		return fmt.Errorf("keep_images must not be negative")
+	}
+	if c.PruneSchedule != "" {
+		if _, err := cron.ParseStandard(c.PruneSchedule); err != nil {
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+	}
+	if c.DiskThreshold < 0 || c.DiskThreshold > 99 {
+		return fmt.Errorf("disk_threshold must be between 0 and 99")
+	}
+	return nil
+}
+
+// LoadConfig reads the housekeeping settings, defaulting to keeping three
+// images per app, a weekly prune and a cleanup at 90% disk use
+func LoadConfig(ctx context.Context, settings SettingsStore) (Config, error) {
+	cfg := Config{KeepImages: 3, PruneSchedule: "0 4 * * 0", DiskThreshold: 90}
+
+	for key, field := range map[string]*int{
+		SettingKeepImages:    &cfg.KeepImages,
+		SettingDiskThreshold: &cfg.DiskThreshold,
+	} {
+		value, err := settings.Get(ctx, key)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to load %s: %w", key, err)
+		}
+		if n, err := strconv.Atoi(value); err == nil {
+			*field = n
+		}
+	}
+
+	value, err := settings.Get(ctx, SettingPruneSchedule)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to load %s: %w", SettingPruneSchedule, err)
+	}
+	if value == "off" {
+		cfg.PruneSchedule = ""
+	} else if value != "" {
+		cfg.PruneSchedule = value
+	}
+	return cfg, nil
+}
+
+// SaveConfig writes the housekeeping settings
+func SaveConfig(ctx context.Context, settings SettingsStore, cfg Config) error {
+	// An empty setting means the default, so a disabled schedule is stored
+	// as "off"
+	schedule := cfg.PruneSchedule
+	if schedule == "" {
+		schedule = "off"
+	}
+
+	values := map[string]string{
+		SettingKeepImages:    strconv.Itoa(cfg.KeepImages),
+		SettingPruneSchedule: schedule,
+		SettingDiskThreshold: strconv.Itoa(cfg.DiskThreshold),
+	}
+	for key, value := range values {
+		if err := settings.Set(ctx, key, value); err != nil {
+			return fmt.Errorf("failed to save %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Manager runs Docker housekeeping on the local daemon and trims app images
+// on the Docker host each app deploys to
+type Manager struct {
+	dockerClient *docker.Client
+	apps         AppLister
+	runs         RunStore
+	settings     SettingsStore
+	dockerHosts  DockerHostResolver
+	dispatcher   *notify.Dispatcher
+	diskUsage    func() (float64, error)
+	logger       *slog.Logger
+
+	mu            sync.Mutex // Held while a run is in progress
+	lastEmergency time.Time
+}
+
+// NewManager creates a new housekeeping manager
+func NewManager(dockerClient *docker.Client, apps AppLister, runs RunStore, settings SettingsStore) *Manager {
+	return &Manager{
+		dockerClient: dockerClient,
+		apps:         apps,
+		runs:         runs,
+		settings:     settings,
+		diskUsage:    hostDiskUsage,
+		logger:       slog.Default(),
+	}
+}
+
+// SetDockerHosts trims the images of apps on remote Docker hosts on those
+// hosts. Without it only the local daemon is cleaned.
+func (m *Manager) SetDockerHosts(hosts DockerHostResolver) {
+	m.dockerHosts = hosts
+}
+
+// SetNotifier sets the dispatcher used to report disk pressure cleanups
+func (m *Manager) SetNotifier(dispatcher *notify.Dispatcher) {
+	m.dispatcher = dispatcher
+}
+
+// hostDiskUsage returns the used percentage of the host's root disk
+func hostDiskUsage() (float64, error) {
+	h, err := health.GetSystemHealth()
+	if err != nil {
+		return 0, err
+	}
+	return h.Disk.UsedPercent, nil
+}
+
+// Start runs scheduled and disk pressure housekeeping until the context is
+// cancelled
+func (m *Manager) Start(ctx context.Context) {
+	if _, err := m.runs.FailRunningRuns(ctx); err != nil {
+		m.logger.Error("failed to clean up interrupted housekeeping runs", "error", err)
+	}
+
+	startedAt := time.Now()
+	go func() {
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				cfg, err := LoadConfig(ctx, m.settings)
+				if err != nil {
+					m.logger.Error("failed to load housekeeping settings", "error", err)
+					continue
+				}
+
+				trigger := models.MaintenanceTrigger("")
+				if m.underPressure(cfg, now) {
+					trigger = models.MaintenanceDiskPressure
+					m.lastEmergency = now
+				} else if m.isDue(ctx, cfg, startedAt, now) {
+					trigger = models.MaintenanceScheduled
+				}
+				if trigger == "" {
+					continue
+				}
+				if _, err := m.Run(ctx, trigger); err != nil && !errors.Is(err, ErrRunning) {
+					m.logger.Error("housekeeping failed", "trigger", trigger, "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// underPressure reports whether the disk is above the threshold and the
+// last emergency cleanup is long enough ago
+func (m *Manager) underPressure(cfg Config, now time.Time) bool {
+	if cfg.DiskThreshold == 0 || now.Sub(m.lastEmergency) < emergencyCooldown {
+		return false
+	}
+	used, err := m.diskUsage()
+	if err != nil {
+		m.logger.Warn("failed to get disk usage", "error", err)
+		return false
+	}
+	return used >= float64(cfg.DiskThreshold)
+}
+
+// isDue reports whether the prune schedule has come round since the last
+// run, or since startup if there is none
+func (m *Manager) isDue(ctx context.Context, cfg Config, startedAt, now time.Time) bool {
+	if cfg.PruneSchedule == "" {
+		return false
+	}
+	schedule, err := cron.ParseStandard(cfg.PruneSchedule)
+	if err != nil {
+		return false
+	}
+
+	last := startedAt
+	runs, err := m.runs.ListRuns(ctx, 1)
+	if err != nil {
+		m.logger.Error("failed to list housekeeping runs", "error", err)
+		return false
+	}
+	if len(runs) > 0 && runs[0].StartedAt.After(last) {
+		last = runs[0].StartedAt
+	}
+	return !schedule.Next(last).After(now)
+}
+
+// Run trims old app images and prunes dangling images, unused volumes and
+// networks. Disk pressure runs keep a single image per app and clear the
+// build cache too.
+func (m *Manager) Run(ctx context.Context, trigger models.MaintenanceTrigger) (*models.MaintenanceRun, error) {
+	if !m.mu.TryLock() {
+		return nil, ErrRunning
+	}
+	defer m.mu.Unlock()
+
+	cfg, err := LoadConfig(ctx, m.settings)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &models.MaintenanceRun{
+		ID:        uuid.New().String(),
+		Trigger:   trigger,
+		Status:    models.MaintenanceRunning,
+		StartedAt: time.Now(),
+	}
+	if err := m.runs.CreateRun(ctx, run); err != nil {
+		return nil, err
+	}
+
+	keep := cfg.KeepImages
+	if trigger == models.MaintenanceDiskPressure {
+		keep = 1
+	}
+	err = m.clean(ctx, run, keep, trigger == models.MaintenanceDiskPressure)
+
+	run.FinishedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	run.Status = models.MaintenanceSuccess
+	if err != nil {
+		run.Status = models.MaintenanceFailed
+		run.Error = sql.NullString{String: err.Error(), Valid: true}
+	}
+	if ferr := m.runs.FinishRun(ctx, run); ferr != nil {
+		m.logger.Error("failed to record housekeeping run", "error", ferr)
+	}
+	if perr := m.runs.PruneRuns(ctx, keepRuns); perr != nil {
+		m.logger.Warn("failed to prune housekeeping runs", "error", perr)
+	}
+
+	m.logger.Info("housekeeping finished", "trigger", trigger, "images", run.ImagesRemoved,
+		"volumes", run.VolumesRemoved, "networks", run.NetworksRemoved, "reclaimed", run.SpaceReclaimed)
+	if trigger == models.MaintenanceDiskPressure {
+		m.notifyDiskPressure(ctx, cfg, run)
+	}
+	return run, err
+}
+
+// clean does the work of a run, adding what it removes to the run. Failed
+// steps don't stop the rest; the first error is returned.
+func (m *Manager) clean(ctx context.Context, run *models.MaintenanceRun, keep int, emergency bool) error {
+	var errs []error
+
+	if keep > 0 {
+		apps, err := m.apps.List(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list apps: %w", err))
+		}
+		for _, app := range apps {
+			// Compose apps build an image per service, named by compose
+			if app.BuildStrategy == models.BuildStrategyCompose {
+				continue
+			}
+			client, err := m.dockerFor(ctx, app)
+			if err != nil {
+				m.logger.Warn("skipping image cleanup", "app", app.Name, "error", err)
+				continue
+			}
+			removed, size, err := client.CleanupOldImages(ctx, app.GetImageName(), keep)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to clean up images of %s: %w", app.Name, err))
+				continue
+			}
+			run.ImagesRemoved += removed
+			run.SpaceReclaimed += size
+		}
+	}
+
+	if report, err := m.dockerClient.PruneImages(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to prune images: %w", err))
+	} else {
+		run.ImagesRemoved += len(report.ImagesDeleted)
+		run.SpaceReclaimed += int64(report.SpaceReclaimed)
+	}
+
+	if report, err := m.dockerClient.PruneVolumes(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to prune volumes: %w", err))
+	} else {
+		run.VolumesRemoved += len(report.VolumesDeleted)
+		run.SpaceReclaimed += int64(report.SpaceReclaimed)
+	}
+
+	if report, err := m.dockerClient.PruneNetworks(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to prune networks: %w", err))
+	} else {
+		run.NetworksRemoved += len(report.NetworksDeleted)
+	}
+
+	if emergency {
+		if report, err := m.dockerClient.PruneBuildCache(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to prune build cache: %w", err))
+		} else {
+			run.SpaceReclaimed += int64(report.SpaceReclaimed)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// dockerFor returns the Docker client holding an app's images. Apps on
+// agents are built here, so their images are local.
+func (m *Manager) dockerFor(ctx context.Context, app *models.App) (*docker.Client, error) {
+	if m.dockerHosts == nil || app.GetAgent() != "" {
+		return m.dockerClient, nil
+	}
+	return m.dockerHosts.ForApp(ctx, app)
+}
+
+// notifyDiskPressure reports an emergency cleanup
+func (m *Manager) notifyDiskPressure(ctx context.Context, cfg Config, run *models.MaintenanceRun) {
+	if m.dispatcher == nil {
+		return
+	}
+	message := fmt.Sprintf("Disk use passed %d%%. Removed %d images, %d volumes and %d networks, freeing %s.",
+		cfg.DiskThreshold, run.ImagesRemoved, run.VolumesRemoved, run.NetworksRemoved, health.FormatBytes(uint64(run.SpaceReclaimed)))
+	if run.Error.Valid {
+		message += " Some steps failed: " + run.Error.String
+	}
+	m.dispatcher.Notify(ctx, notify.Event{
+		Type:     notify.EventDiskCleanup,
+		Title:    "Disk cleanup ran",
+		Message:  message,
+		URL:      m.dispatcher.BaseURL() + "/settings",
+		Priority: notify.PriorityHigh,
+	})
+}
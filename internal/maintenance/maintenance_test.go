@@ -0,0 +1,146 @@
+package maintenance
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"schooner/internal/models"
+)
+
+// fakeSettings is an in-memory SettingsStore
+type fakeSettings map[string]string
+
+func (s fakeSettings) Get(ctx context.Context, key string) (string, error) { return s[key], nil }
+
+func (s fakeSettings) Set(ctx context.Context, key, value string) error {
+	s[key] = value
+	return nil
+}
+
+// fakeRuns is an in-memory RunStore, newest run first
+type fakeRuns struct {
+	runs []*models.MaintenanceRun
+}
+
+func (f *fakeRuns) CreateRun(ctx context.Context, run *models.MaintenanceRun) error {
+	f.runs = append([]*models.MaintenanceRun{run}, f.runs...)
+	return nil
+}
+
+func (f *fakeRuns) FinishRun(ctx context.Context, run *models.MaintenanceRun) error { return nil }
+
+func (f *fakeRuns) ListRuns(ctx context.Context, limit int) ([]*models.MaintenanceRun, error) {
+	if len(f.runs) > limit {
+		return f.runs[:limit], nil
+	}
+	return f.runs, nil
+}
+
+func (f *fakeRuns) PruneRuns(ctx context.Context, keep int) error { return nil }
+
+func (f *fakeRuns) FailRunningRuns(ctx context.Context) (int64, error) { return 0, nil }
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"defaults", Config{KeepImages: 3, PruneSchedule: "0 4 * * 0", DiskThreshold: 90}, false},
+		{"all disabled", Config{}, false},
+		{"negative keep", Config{KeepImages: -1}, true},
+		{"bad schedule", Config{PruneSchedule: "weekly-ish"}, true},
+		{"threshold too high", Config{DiskThreshold: 100}, true},
+		{"negative threshold", Config{DiskThreshold: -5}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	cfg, err := LoadConfig(context.Background(), fakeSettings{})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.KeepImages != 3 || cfg.PruneSchedule != "0 4 * * 0" || cfg.DiskThreshold != 90 {
+		t.Errorf("defaults = %+v", cfg)
+	}
+}
+
+func TestSaveConfigRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	settings := fakeSettings{}
+
+	want := Config{KeepImages: 0, PruneSchedule: "", DiskThreshold: 0}
+	if err := SaveConfig(ctx, settings, want); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+	got, err := LoadConfig(ctx, settings)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("LoadConfig() = %+v, want %+v", got, want)
+	}
+}
+
+func TestUnderPressure(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name          string
+		threshold     int
+		used          float64
+		lastEmergency time.Time
+		want          bool
+	}{
+		{"below threshold", 90, 80, time.Time{}, false},
+		{"at threshold", 90, 90, time.Time{}, true},
+		{"disabled", 0, 99, time.Time{}, false},
+		{"cooling down", 90, 95, now.Add(-10 * time.Minute), false},
+		{"cooled down", 90, 95, now.Add(-2 * time.Hour), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Manager{
+				diskUsage:     func() (float64, error) { return tt.used, nil },
+				lastEmergency: tt.lastEmergency,
+				logger:        slog.Default(),
+			}
+			if got := m.underPressure(Config{DiskThreshold: tt.threshold}, now); got != tt.want {
+				t.Errorf("underPressure() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDue(t *testing.T) {
+	ctx := context.Background()
+	sunday := time.Date(2026, 3, 1, 4, 0, 30, 0, time.UTC) // A Sunday, just after 04:00
+	cfg := Config{PruneSchedule: "0 4 * * 0"}
+
+	m := &Manager{runs: &fakeRuns{}, logger: slog.Default()}
+	if !m.isDue(ctx, cfg, sunday.Add(-24*time.Hour), sunday) {
+		t.Error("isDue() = false on schedule, want true")
+	}
+	if m.isDue(ctx, cfg, sunday.Add(-time.Minute), sunday.Add(-40*time.Second)) {
+		t.Error("isDue() = true before the schedule, want false")
+	}
+	if m.isDue(ctx, Config{}, sunday.Add(-24*time.Hour), sunday) {
+		t.Error("isDue() = true with no schedule, want false")
+	}
+
+	m.runs.CreateRun(ctx, &models.MaintenanceRun{StartedAt: sunday.Add(-10 * time.Second)})
+	if m.isDue(ctx, cfg, sunday.Add(-24*time.Hour), sunday) {
+		t.Error("isDue() = true right after a run, want false")
+	}
+}
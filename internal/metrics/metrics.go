@@ -0,0 +1,279 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are histogram buckets (in seconds) suited to request latencies
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// BuildBuckets are histogram buckets (in seconds) suited to build durations
+var BuildBuckets = []float64{10, 30, 60, 120, 300, 600, 1200, 1800, 3600}
+
+// Collector writes metrics in the Prometheus text exposition format
+type Collector interface {
+	Write(ctx context.Context, w io.Writer)
+}
+
+// Sample is a single labelled value produced by a GaugeFunc
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// Registry holds a set of collectors in registration order
+type Registry struct {
+	mu         sync.Mutex
+	collectors []Collector
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds collectors to the registry
+func (r *Registry) Register(cs ...Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, cs...)
+}
+
+// WriteTo writes every registered collector to w
+func (r *Registry) WriteTo(ctx context.Context, w io.Writer) {
+	r.mu.Lock()
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	for _, c := range collectors {
+		c.Write(ctx, w)
+	}
+}
+
+// vec stores float values keyed by their label values
+type vec struct {
+	name   string
+	help   string
+	labels []string
+	mu     sync.Mutex
+	values map[string]float64
+	keys   map[string][]string
+}
+
+func newVec(name, help string, labels []string) vec {
+	return vec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]float64),
+		keys:   make(map[string][]string),
+	}
+}
+
+func (v *vec) add(delta float64, set bool, labelValues []string) {
+	key := strings.Join(labelValues, "\xff")
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if _, ok := v.keys[key]; !ok {
+		v.keys[key] = append([]string(nil), labelValues...)
+	}
+	if set {
+		v.values[key] = delta
+		return
+	}
+	v.values[key] += delta
+}
+
+func (v *vec) write(w io.Writer, typ string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	writeHeader(w, v.name, v.help, typ)
+	for _, key := range sortedKeys(v.values) {
+		fmt.Fprintf(w, "%s%s %s\n", v.name, formatLabels(v.labels, v.keys[key]), formatFloat(v.values[key]))
+	}
+}
+
+// CounterVec is a monotonically increasing counter partitioned by labels
+type CounterVec struct{ vec }
+
+// NewCounterVec creates a counter with the given label names
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{newVec(name, help, labels)}
+}
+
+// Inc increments the counter for the given label values
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.add(1, false, labelValues)
+}
+
+// Write implements Collector
+func (c *CounterVec) Write(ctx context.Context, w io.Writer) {
+	c.write(w, "counter")
+}
+
+// GaugeVec is a value that can go up and down, partitioned by labels
+type GaugeVec struct{ vec }
+
+// NewGaugeVec creates a gauge with the given label names
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{newVec(name, help, labels)}
+}
+
+// Set sets the gauge for the given label values
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.add(value, true, labelValues)
+}
+
+// Write implements Collector
+func (g *GaugeVec) Write(ctx context.Context, w io.Writer) {
+	g.write(w, "gauge")
+}
+
+// GaugeFunc is a gauge whose samples are computed at scrape time
+type GaugeFunc struct {
+	name    string
+	help    string
+	labels  []string
+	collect func(ctx context.Context) []Sample
+}
+
+// NewGaugeFunc creates a gauge computed by fn on every scrape
+func NewGaugeFunc(name, help string, labels []string, fn func(ctx context.Context) []Sample) *GaugeFunc {
+	return &GaugeFunc{name: name, help: help, labels: labels, collect: fn}
+}
+
+// Write implements Collector
+func (g *GaugeFunc) Write(ctx context.Context, w io.Writer) {
+	writeHeader(w, g.name, g.help, "gauge")
+	for _, s := range g.collect(ctx) {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, formatLabels(g.labels, s.Labels), formatFloat(s.Value))
+	}
+}
+
+// histogramData holds cumulative bucket counts for one label set
+type histogramData struct {
+	labelValues []string
+	counts      []uint64
+	sum         float64
+	count       uint64
+}
+
+// HistogramVec tracks value distributions partitioned by labels
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	mu      sync.Mutex
+	data    map[string]*histogramData
+}
+
+// NewHistogramVec creates a histogram with the given buckets and label names
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		data:    make(map[string]*histogramData),
+	}
+}
+
+// Observe records a value for the given label values
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	d, ok := h.data[key]
+	if !ok {
+		d = &histogramData{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.data[key] = d
+	}
+
+	for i, upper := range h.buckets {
+		if value <= upper {
+			d.counts[i]++
+		}
+	}
+	d.sum += value
+	d.count++
+}
+
+// Write implements Collector
+func (h *HistogramVec) Write(ctx context.Context, w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	writeHeader(w, h.name, h.help, "histogram")
+	for _, key := range sortedKeys(h.data) {
+		d := h.data[key]
+		bucketLabels := append(append([]string(nil), h.labels...), "le")
+		for i, upper := range h.buckets {
+			values := append(append([]string(nil), d.labelValues...), formatFloat(upper))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(bucketLabels, values), d.counts[i])
+		}
+		values := append(append([]string(nil), d.labelValues...), "+Inf")
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(bucketLabels, values), d.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, d.labelValues), formatFloat(d.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, d.labelValues), d.count)
+	}
+}
+
+func writeHeader(w io.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+}
+
+// formatLabels renders {name="value",...} or an empty string when there are no labels
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(names))
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, name, escapeLabel(value)))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCounterVec_Write(t *testing.T) {
+	c := NewCounterVec("test_total", "A test counter.", "status")
+	c.Inc("success")
+	c.Inc("success")
+	c.Inc("failed")
+
+	var buf bytes.Buffer
+	c.Write(context.Background(), &buf)
+	out := buf.String()
+
+	for _, want := range []string{
+		"# TYPE test_total counter",
+		`test_total{status="success"} 2`,
+		`test_total{status="failed"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestHistogramVec_Write(t *testing.T) {
+	h := NewHistogramVec("test_seconds", "A test histogram.", []float64{1, 5}, "op")
+	h.Observe(0.5, "inspect")
+	h.Observe(3, "inspect")
+	h.Observe(10, "inspect")
+
+	var buf bytes.Buffer
+	h.Write(context.Background(), &buf)
+	out := buf.String()
+
+	for _, want := range []string{
+		`test_seconds_bucket{op="inspect",le="1"} 1`,
+		`test_seconds_bucket{op="inspect",le="5"} 2`,
+		`test_seconds_bucket{op="inspect",le="+Inf"} 3`,
+		`test_seconds_sum{op="inspect"} 13.5`,
+		`test_seconds_count{op="inspect"} 3`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatLabels(t *testing.T) {
+	tests := []struct {
+		name     string
+		names    []string
+		values   []string
+		expected string
+	}{
+		{name: "no labels", expected: ""},
+		{name: "single", names: []string{"app"}, values: []string{"blog"}, expected: `{app="blog"}`},
+		{name: "escaped", names: []string{"app"}, values: []string{`a"b\c`}, expected: `{app="a\"b\\c"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatLabels(tt.names, tt.values); got != tt.expected {
+				t.Errorf("formatLabels() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHandler_Token(t *testing.T) {
+	reg := NewRegistry()
+	handler := Handler(reg, "secret")
+
+	tests := []struct {
+		name   string
+		auth   string
+		status int
+	}{
+		{name: "missing token", auth: "", status: http.StatusUnauthorized},
+		{name: "wrong token", auth: "Bearer nope", status: http.StatusUnauthorized},
+		{name: "valid token", auth: "Bearer secret", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/metrics", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("Status = %v, want %v", w.Code, tt.status)
+			}
+		})
+	}
+}
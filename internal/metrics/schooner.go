@@ -0,0 +1,49 @@
+package metrics
+
+import "time"
+
+// Schooner metrics. These are package-level so that any subsystem can record
+// into them without threading a registry through every constructor.
+var (
+	BuildsTotal = NewCounterVec(
+		"schooner_builds_total",
+		"Total number of finished builds by status.",
+		"status",
+	)
+	BuildDuration = NewHistogramVec(
+		"schooner_build_duration_seconds",
+		"Build duration in seconds by final status.",
+		BuildBuckets,
+		"status",
+	)
+	WebhooksTotal = NewCounterVec(
+		"schooner_webhooks_total",
+		"Total number of received webhooks by event and result.",
+		"event", "result",
+	)
+	DockerAPIDuration = NewHistogramVec(
+		"schooner_docker_api_duration_seconds",
+		"Latency of Docker API calls in seconds by operation.",
+		DefaultBuckets,
+		"operation",
+	)
+	HTTPRequestDuration = NewHistogramVec(
+		"schooner_http_request_duration_seconds",
+		"HTTP handler latency in seconds by method, route and status code.",
+		DefaultBuckets,
+		"method", "route", "code",
+	)
+)
+
+// Default is the registry served on /metrics
+var Default = NewRegistry()
+
+func init() {
+	Default.Register(BuildsTotal, BuildDuration, WebhooksTotal, DockerAPIDuration, HTTPRequestDuration)
+}
+
+// ObserveDocker records the latency of a Docker API call started at start.
+// Intended to be used as: defer metrics.ObserveDocker("inspect", time.Now())
+func ObserveDocker(operation string, start time.Time) {
+	DockerAPIDuration.Observe(time.Since(start).Seconds(), operation)
+}
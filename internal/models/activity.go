@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// ActivityKind is what an entry of the activity feed is about
+type ActivityKind string
+
+const (
+	ActivityBuildStarted  ActivityKind = "build_started"
+	ActivityBuildFinished ActivityKind = "build_finished"
+	ActivityDeploy        ActivityKind = "deploy"
+	ActivityContainer     ActivityKind = "container"
+	ActivitySettings      ActivityKind = "settings"
+	ActivityWebhook       ActivityKind = "webhook"
+)
+
+// Activity is an entry of the dashboard's activity feed, a chronological
+// view of what Schooner has been doing
+type Activity struct {
+	ID        int64        `db:"id" json:"id"`
+	Kind      ActivityKind `db:"kind" json:"kind"`
+	AppID     string       `db:"app_id" json:"app_id,omitempty"`
+	BuildID   string       `db:"build_id" json:"build_id,omitempty"`
+	Message   string       `db:"message" json:"message"`
+	CreatedAt time.Time    `db:"created_at" json:"created_at"`
+}
+
+// Link returns the page an entry is about: its build, else its app, else
+// nothing
+func (a *Activity) Link() string {
+	switch {
+	case a.BuildID != "":
+		return "/builds/" + a.BuildID
+	case a.AppID != "":
+		return "/apps/" + a.AppID
+	}
+	return ""
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// AddonType is the kind of managed service an add-on runs
+type AddonType string
+
+const (
+	AddonPostgres AddonType = "postgres"
+	AddonMySQL    AddonType = "mysql"
+	AddonRedis    AddonType = "redis"
+)
+
+// addonNamePattern keeps add-on names usable as database and container names
+var addonNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// envVarPattern matches valid environment variable names
+var envVarPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// containerNameUnsafe matches characters Docker rejects in container names
+var containerNameUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// addonSpec describes how a type of add-on is run
+type addonSpec struct {
+	image    string
+	port     int
+	dataPath string
+	envVar   string
+}
+
+var addonSpecs = map[AddonType]addonSpec{
+	AddonPostgres: {image: "postgres:17-alpine", port: 5432, dataPath: "/var/lib/postgresql/data", envVar: "DATABASE_URL"},
+	AddonMySQL:    {image: "mysql:8.4", port: 3306, dataPath: "/var/lib/mysql", envVar: "DATABASE_URL"},
+	AddonRedis:    {image: "redis:7-alpine", port: 6379, dataPath: "/data", envVar: "REDIS_URL"},
+}
+
+// Addon is a database or cache container provisioned for an app
+type Addon struct {
+	ID            string    `db:"id" json:"id"`
+	AppID         string    `db:"app_id" json:"app_id"`
+	Type          AddonType `db:"type" json:"type"`
+	Name          string    `db:"name" json:"name"`
+	Image         string    `db:"image" json:"image"`                   // Pinned at creation so upgrades are deliberate
+	ContainerName string    `db:"container_name" json:"container_name"` // Also the hostname the app connects to
+	EnvVar        string    `db:"env_var" json:"env_var"`               // App env var that receives the connection URL
+	Username      string    `db:"username" json:"username"`
+	Password      string    `db:"password" json:"-"` // Stored encrypted
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+}
+
+// NewAddon returns an add-on of the given type for an app with its default
+// image, container name and env var
+func NewAddon(app *App, addonType AddonType, name string) *Addon {
+	spec := addonSpecs[addonType]
+	return &Addon{
+		AppID:         app.ID,
+		Type:          addonType,
+		Name:          name,
+		Image:         spec.image,
+		ContainerName: fmt.Sprintf("schooner-%s-%s", containerNameUnsafe.ReplaceAllString(app.Name, "-"), name),
+		EnvVar:        spec.envVar,
+		Username:      "schooner",
+	}
+}
+
+// Validate checks the add-on type, name and env var
+func (a *Addon) Validate() error {
+	if _, ok := addonSpecs[a.Type]; !ok {
+		return fmt.Errorf("unknown add-on type %q", a.Type)
+	}
+	if !addonNamePattern.MatchString(a.Name) {
+		return fmt.Errorf("name must start with a letter and contain only lowercase letters, digits and underscores (max 32)")
+	}
+	if !envVarPattern.MatchString(a.EnvVar) {
+		return fmt.Errorf("invalid env var name %q", a.EnvVar)
+	}
+	return nil
+}
+
+// Port returns the port the service listens on inside its container
+func (a *Addon) Port() int {
+	return addonSpecs[a.Type].port
+}
+
+// DataPath returns where the service keeps its data inside the container
+func (a *Addon) DataPath() string {
+	return addonSpecs[a.Type].dataPath
+}
+
+// VolumeName returns the named volume holding the add-on's data
+func (a *Addon) VolumeName() string {
+	return a.ContainerName + "-data"
+}
+
+// ConnectionURL returns the URL the app uses to connect to the add-on
+func (a *Addon) ConnectionURL() string {
+	u := url.URL{
+		Scheme: string(a.Type),
+		Host:   fmt.Sprintf("%s:%d", a.ContainerName, a.Port()),
+	}
+
+	switch a.Type {
+	case AddonRedis:
+		u.User = url.UserPassword("", a.Password)
+		u.Path = "/0"
+	default:
+		u.User = url.UserPassword(a.Username, a.Password)
+		u.Path = "/" + a.Name
+	}
+	return u.String()
+}
+
+// ContainerEnv returns the environment that initializes the service with the
+// add-on's credentials
+func (a *Addon) ContainerEnv() []string {
+	switch a.Type {
+	case AddonPostgres:
+		return []string{
+			"POSTGRES_USER=" + a.Username,
+			"POSTGRES_PASSWORD=" + a.Password,
+			"POSTGRES_DB=" + a.Name,
+		}
+	case AddonMySQL:
+		return []string{
+			"MYSQL_USER=" + a.Username,
+			"MYSQL_PASSWORD=" + a.Password,
+			"MYSQL_DATABASE=" + a.Name,
+			"MYSQL_RANDOM_ROOT_PASSWORD=yes",
+		}
+	}
+	return nil
+}
+
+// ContainerCmd returns the command the service runs with, if it is configured
+// on the command line rather than through the environment
+func (a *Addon) ContainerCmd() []string {
+	if a.Type == AddonRedis {
+		return []string{"redis-server", "--requirepass", a.Password, "--appendonly", "yes"}
+	}
+	return nil
+}
+
+// Network returns the private Docker network an app's containers share with
+// its add-ons, keeping them off the default bridge. It keeps the name it had
+// when only add-ons used it, so existing add-on containers stay on it.
+func (a *App) Network() string {
+	id := a.ID
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return "schooner-addons-" + id
+}
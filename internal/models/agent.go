@@ -0,0 +1,33 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// agentNamePattern keeps agent names short and readable in the UI
+var agentNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// Agent is a server running schooner-agent. Agents connect to Schooner with
+// a token, run the apps assigned to them and report their containers back.
+// Only a hash of the token is stored; the plaintext is shown once.
+type Agent struct {
+	ID          string       `db:"id" json:"id"`
+	Name        string       `db:"name" json:"name"`
+	TokenPrefix string       `db:"token_prefix" json:"token_prefix"` // first characters, for identification
+	TokenHash   string       `db:"token_hash" json:"-"`
+	Hostname    string       `db:"hostname" json:"hostname"` // as reported by the agent
+	Version     string       `db:"version" json:"version"`   // as reported by the agent
+	LastSeenAt  sql.NullTime `db:"last_seen_at" json:"last_seen_at,omitempty"`
+	CreatedAt   time.Time    `db:"created_at" json:"created_at"`
+}
+
+// Validate checks the agent's name
+func (a *Agent) Validate() error {
+	if !agentNamePattern.MatchString(a.Name) {
+		return fmt.Errorf("name must be lowercase letters, digits and dashes (max 32)")
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// AlertRuleType identifies the condition an alert rule checks
+type AlertRuleType string
+
+const (
+	AlertContainerDown AlertRuleType = "container_down"
+	AlertCPUHigh       AlertRuleType = "cpu_high"
+	AlertDiskHigh      AlertRuleType = "disk_high"
+	AlertBuildFailures AlertRuleType = "build_failures"
+)
+
+// AlertRule is a user-defined condition evaluated by the alerting worker
+type AlertRule struct {
+	ID              string         `db:"id" json:"id"`
+	Name            string         `db:"name" json:"name"`
+	Type            AlertRuleType  `db:"type" json:"type"`
+	AppID           sql.NullString `db:"app_id" json:"app_id"`
+	Threshold       float64        `db:"threshold" json:"threshold"`
+	DurationSeconds int            `db:"duration_seconds" json:"duration_seconds"`
+	CooldownSeconds int            `db:"cooldown_seconds" json:"cooldown_seconds"`
+	Enabled         bool           `db:"enabled" json:"enabled"`
+	LastFiredAt     sql.NullTime   `db:"last_fired_at" json:"last_fired_at,omitempty"`
+	CreatedAt       time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// GetAppID returns the app ID the rule is scoped to, or empty for all apps
+func (r *AlertRule) GetAppID() string {
+	if r.AppID.Valid {
+		return r.AppID.String
+	}
+	return ""
+}
+
+// Duration returns how long a condition must hold before the rule fires
+func (r *AlertRule) Duration() time.Duration {
+	return time.Duration(r.DurationSeconds) * time.Second
+}
+
+// Cooldown returns the minimum time between repeated notifications
+func (r *AlertRule) Cooldown() time.Duration {
+	return time.Duration(r.CooldownSeconds) * time.Second
+}
+
+// Validate checks that the rule type and threshold are consistent
+func (r *AlertRule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if r.DurationSeconds < 0 || r.CooldownSeconds < 0 {
+		return fmt.Errorf("duration and cooldown must not be negative")
+	}
+
+	switch r.Type {
+	case AlertContainerDown:
+		return nil
+	case AlertCPUHigh, AlertDiskHigh:
+		if r.Threshold <= 0 || r.Threshold > 100 {
+			return fmt.Errorf("threshold must be a percentage between 0 and 100")
+		}
+		if r.Type == AlertDiskHigh && r.AppID.Valid {
+			return fmt.Errorf("disk alerts apply to the host and cannot be scoped to an app")
+		}
+		return nil
+	case AlertBuildFailures:
+		if r.Threshold < 1 {
+			return fmt.Errorf("threshold must be at least 1 failed build")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown alert type %q", r.Type)
+	}
+}
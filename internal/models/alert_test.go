@@ -0,0 +1,37 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestAlertRule_Validate(t *testing.T) {
+	appID := sql.NullString{String: "app-1", Valid: true}
+
+	tests := []struct {
+		name    string
+		rule    AlertRule
+		wantErr bool
+	}{
+		{name: "container down", rule: AlertRule{Name: "down", Type: AlertContainerDown, DurationSeconds: 120}, wantErr: false},
+		{name: "missing name", rule: AlertRule{Type: AlertContainerDown}, wantErr: true},
+		{name: "unknown type", rule: AlertRule{Name: "x", Type: "memory_high"}, wantErr: true},
+		{name: "cpu valid", rule: AlertRule{Name: "cpu", Type: AlertCPUHigh, Threshold: 90, AppID: appID}, wantErr: false},
+		{name: "cpu over 100", rule: AlertRule{Name: "cpu", Type: AlertCPUHigh, Threshold: 150}, wantErr: true},
+		{name: "cpu zero", rule: AlertRule{Name: "cpu", Type: AlertCPUHigh}, wantErr: true},
+		{name: "disk valid", rule: AlertRule{Name: "disk", Type: AlertDiskHigh, Threshold: 90}, wantErr: false},
+		{name: "disk scoped to app", rule: AlertRule{Name: "disk", Type: AlertDiskHigh, Threshold: 90, AppID: appID}, wantErr: true},
+		{name: "build failures valid", rule: AlertRule{Name: "builds", Type: AlertBuildFailures, Threshold: 3}, wantErr: false},
+		{name: "build failures zero", rule: AlertRule{Name: "builds", Type: AlertBuildFailures}, wantErr: true},
+		{name: "negative duration", rule: AlertRule{Name: "down", Type: AlertContainerDown, DurationSeconds: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ansiColors are the names of the 8 basic terminal colors, in SGR order
+var ansiColors = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// LogStyle is how a run of a log message was colored in the original
+// output. Start and End count characters, not bytes.
+type LogStyle struct {
+	Start     int    `json:"start"`
+	End       int    `json:"end"`
+	FG        string `json:"fg,omitempty"` // Color name, bright-<name> or #rrggbb
+	BG        string `json:"bg,omitempty"`
+	Bold      bool   `json:"bold,omitempty"`
+	Dim       bool   `json:"dim,omitempty"`
+	Italic    bool   `json:"italic,omitempty"`
+	Underline bool   `json:"underline,omitempty"`
+}
+
+// sameLook reports whether two styles differ only in where they apply
+func (s LogStyle) sameLook(o LogStyle) bool {
+	s.Start, s.End, o.Start, o.End = 0, 0, 0, 0
+	return s == o
+}
+
+// plain reports whether the style leaves the text as it is
+func (s LogStyle) plain() bool {
+	return s.sameLook(LogStyle{})
+}
+
+// LogStyles is the styling of a log message, stored as JSON
+type LogStyles []LogStyle
+
+// Scan implements the sql.Scanner interface
+func (s *LogStyles) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into LogStyles", value)
+	}
+	if len(data) == 0 {
+		*s = nil
+		return nil
+	}
+	return json.Unmarshal(data, s)
+}
+
+// Value implements the driver.Valuer interface
+func (s LogStyles) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// ParseANSI turns a line of terminal output into plain text and the styles
+// its color codes described. Carriage returns redraw the line, so only the
+// text after the last one is kept; cursor movement and other escape
+// sequences are dropped.
+func ParseANSI(line string) (string, LogStyles) {
+	line = strings.TrimRight(line, "\r")
+	if i := strings.LastIndexByte(line, '\r'); i >= 0 {
+		line = line[i+1:]
+	}
+	if !strings.ContainsFunc(line, isControl) {
+		return line, nil
+	}
+
+	var text strings.Builder
+	var styles LogStyles
+	var current LogStyle
+	chars := 0
+
+	// Close the current run before the style changes
+	closeRun := func() {
+		if chars == current.Start || current.plain() {
+			return
+		}
+		current.End = chars
+		if n := len(styles); n > 0 && styles[n-1].End == current.Start && styles[n-1].sameLook(current) {
+			styles[n-1].End = current.End
+			return
+		}
+		styles = append(styles, current)
+	}
+
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == 0x1b && i+1 < len(line) && line[i+1] == '[':
+			// CSI: parameters, then a final byte from @ to ~
+			j := i + 2
+			for j < len(line) && (line[j] < 0x40 || line[j] > 0x7e) {
+				j++
+			}
+			if j < len(line) && line[j] == 'm' {
+				closeRun()
+				current = applySGR(current, line[i+2:j])
+				current.Start = chars
+			}
+			i = j + 1
+		case c == 0x1b && i+1 < len(line) && line[i+1] == ']':
+			// OSC: ended by BEL or ESC \
+			j := i + 2
+			for j < len(line) && line[j] != 0x07 && line[j] != 0x1b {
+				j++
+			}
+			if j < len(line) && line[j] == 0x1b {
+				j++
+			}
+			i = j + 1
+		case c == 0x1b:
+			i += 2
+		case c != '\t' && isControl(rune(c)):
+			i++
+		default:
+			r, size := utf8.DecodeRuneInString(line[i:])
+			text.WriteRune(r)
+			chars++
+			i += size
+		}
+	}
+	closeRun()
+
+	return text.String(), styles
+}
+
+// isControl reports whether r is an ASCII control character other than tab
+func isControl(r rune) bool {
+	return (r < 0x20 && r != '\t') || r == 0x7f
+}
+
+// applySGR applies the parameters of a "select graphic rendition" sequence
+func applySGR(style LogStyle, params string) LogStyle {
+	if params == "" {
+		return LogStyle{}
+	}
+
+	codes := strings.FieldsFunc(params, func(r rune) bool { return r == ';' || r == ':' })
+	for i := 0; i < len(codes); i++ {
+		code, err := strconv.Atoi(codes[i])
+		if err != nil {
+			continue
+		}
+		switch {
+		case code == 0:
+			style = LogStyle{}
+		case code == 1:
+			style.Bold = true
+		case code == 2:
+			style.Dim = true
+		case code == 3:
+			style.Italic = true
+		case code == 4:
+			style.Underline = true
+		case code == 22:
+			style.Bold, style.Dim = false, false
+		case code == 23:
+			style.Italic = false
+		case code == 24:
+			style.Underline = false
+		case code >= 30 && code <= 37:
+			style.FG = ansiColors[code-30]
+		case code == 39:
+			style.FG = ""
+		case code >= 40 && code <= 47:
+			style.BG = ansiColors[code-40]
+		case code == 49:
+			style.BG = ""
+		case code >= 90 && code <= 97:
+			style.FG = "bright-" + ansiColors[code-90]
+		case code >= 100 && code <= 107:
+			style.BG = "bright-" + ansiColors[code-100]
+		case code == 38 || code == 48:
+			color, used := extendedColor(codes[i+1:])
+			i += used
+			if code == 38 {
+				style.FG = color
+			} else {
+				style.BG = color
+			}
+		}
+	}
+	return style
+}
+
+// extendedColor reads a 256-color (5;n) or true color (2;r;g;b) value,
+// returning it and how many parameters it took
+func extendedColor(params []string) (string, int) {
+	num := func(i int) int {
+		if i >= len(params) {
+			return 0
+		}
+		n, _ := strconv.Atoi(params[i])
+		return min(max(n, 0), 255)
+	}
+	if len(params) == 0 {
+		return "", 0
+	}
+
+	switch params[0] {
+	case "5":
+		n := num(1)
+		switch {
+		case n < 8:
+			return ansiColors[n], 2
+		case n < 16:
+			return "bright-" + ansiColors[n-8], 2
+		case n < 232:
+			// 6x6x6 color cube
+			levels := []int{0, 95, 135, 175, 215, 255}
+			n -= 16
+			return fmt.Sprintf("#%02x%02x%02x", levels[n/36], levels[n/6%6], levels[n%6]), 2
+		default:
+			gray := 8 + (n-232)*10
+			return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray), 2
+		}
+	case "2":
+		return fmt.Sprintf("#%02x%02x%02x", num(1), num(2), num(3)), 4
+	}
+	return "", 1
+}
+
+var (
+	// progressLayerPattern matches the per-layer progress of docker pull and push
+	progressLayerPattern = regexp.MustCompile(`^([0-9a-f]{12}): (Pulling fs layer|Waiting|Downloading|Verifying Checksum|Download complete|Extracting|Pull complete|Preparing|Pushing|Pushed|Layer already exists|Already exists)\b`)
+
+	// progressStepPattern matches BuildKit's transfer progress of a build step
+	progressStepPattern = regexp.MustCompile(`^(#\d+ (?:sha256:[0-9a-f]+|transferring [\w ]+|extracting sha256:[0-9a-f]+))\b`)
+
+	// progressHeaderPattern matches the summary line of compose progress
+	progressHeaderPattern = regexp.MustCompile(`^\[\+\] (\w+)`)
+)
+
+// LogProgressKey identifies lines of progress output that update the same
+// item, such as a layer being pulled or a compose service starting, so a
+// log view can show only the latest of them. Other lines have no key.
+func LogProgressKey(text string) string {
+	trimmed := strings.TrimSpace(text)
+
+	if m := progressLayerPattern.FindStringSubmatch(trimmed); m != nil {
+		return "layer:" + m[1]
+	}
+	if m := progressStepPattern.FindStringSubmatch(trimmed); m != nil {
+		return "step:" + m[1]
+	}
+	if m := progressHeaderPattern.FindStringSubmatch(trimmed); m != nil {
+		return "header:" + m[1]
+	}
+
+	// Compose spinners: "⠿ Container web-1  Started  0.5s"
+	r, size := utf8.DecodeRuneInString(trimmed)
+	if r < 0x2800 || r > 0x28ff {
+		return ""
+	}
+	fields := strings.Fields(trimmed[size:])
+	if len(fields) == 0 {
+		return ""
+	}
+	switch fields[0] {
+	case "Container", "Network", "Volume", "Image", "Service":
+		if len(fields) > 1 {
+			return "spinner:" + fields[0] + " " + fields[1]
+		}
+	}
+	return "spinner:" + fields[0]
+}
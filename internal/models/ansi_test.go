@@ -0,0 +1,104 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseANSI(t *testing.T) {
+	tests := []struct {
+		name           string
+		line           string
+		expectedText   string
+		expectedStyles LogStyles
+	}{
+		{
+			name:         "plain text",
+			line:         "Step 1/4 : FROM golang:1.24",
+			expectedText: "Step 1/4 : FROM golang:1.24",
+		},
+		{
+			name:           "basic color",
+			line:           "\x1b[31mERROR\x1b[0m: build failed",
+			expectedText:   "ERROR: build failed",
+			expectedStyles: LogStyles{{Start: 0, End: 5, FG: "red"}},
+		},
+		{
+			name:         "bold bright and background",
+			line:         "\x1b[1;92mok\x1b[22m \x1b[44mdone\x1b[m",
+			expectedText: "ok done",
+			expectedStyles: LogStyles{
+				{Start: 0, End: 2, FG: "bright-green", Bold: true},
+				{Start: 2, End: 3, FG: "bright-green"},
+				{Start: 3, End: 7, FG: "bright-green", BG: "blue"},
+			},
+		},
+		{
+			name:         "256 and true color",
+			line:         "\x1b[38;5;196mhot\x1b[38;2;0;128;255m cold\x1b[0m",
+			expectedText: "hot cold",
+			expectedStyles: LogStyles{
+				{Start: 0, End: 3, FG: "#ff0000"},
+				{Start: 3, End: 8, FG: "#0080ff"},
+			},
+		},
+		{
+			name:           "offsets count characters",
+			line:           "✓ \x1b[32mpassé\x1b[0m",
+			expectedText:   "✓ passé",
+			expectedStyles: LogStyles{{Start: 2, End: 7, FG: "green"}},
+		},
+		{
+			name:         "carriage returns keep the last redraw",
+			line:         "Downloading 10%\rDownloading 50%\rDownloading 100%\r",
+			expectedText: "Downloading 100%",
+		},
+		{
+			name:         "cursor movement and titles are dropped",
+			line:         "\x1b[1A\x1b[2K\x1b]0;title\x07#5 DONE 0.3s",
+			expectedText: "#5 DONE 0.3s",
+		},
+		{
+			name:         "style codes without text",
+			line:         "\x1b[33m\x1b[0m",
+			expectedText: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, styles := ParseANSI(tt.line)
+			if text != tt.expectedText {
+				t.Errorf("text = %q, want %q", text, tt.expectedText)
+			}
+			if !reflect.DeepEqual(styles, tt.expectedStyles) {
+				t.Errorf("styles = %+v, want %+v", styles, tt.expectedStyles)
+			}
+		})
+	}
+}
+
+func TestLogProgressKey(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{text: "a3ed95caeb02: Downloading [==>    ]  1.2MB/20MB", expected: "layer:a3ed95caeb02"},
+		{text: "a3ed95caeb02: Pull complete", expected: "layer:a3ed95caeb02"},
+		{text: "#7 sha256:4f4fb7 12.58MB / 29.15MB 0.9s", expected: "step:#7 sha256:4f4fb7"},
+		{text: "#7 transferring context: 2.1kB 0.1s done", expected: "step:#7 transferring context"},
+		{text: "[+] Building 12.3s (5/10)", expected: "header:Building"},
+		{text: " ⠿ Container web-1  Started   0.5s", expected: "spinner:Container web-1"},
+		{text: " ⠋ web Pulling", expected: "spinner:web"},
+		{text: "#7 [2/4] RUN go build ./...", expected: ""},
+		{text: "Step 1/4 : FROM golang:1.24", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if key := LogProgressKey(tt.text); key != tt.expected {
+				t.Errorf("LogProgressKey() = %q, want %q", key, tt.expected)
+			}
+		})
+	}
+}
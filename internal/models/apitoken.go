@@ -0,0 +1,109 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// APITokenScope limits what an API token may do
+type APITokenScope string
+
+const (
+	// ScopeRead allows GET requests to the API
+	ScopeRead APITokenScope = "read"
+	// ScopeDeploy allows deploying, starting, stopping and restarting apps
+	// and cancelling or retrying builds
+	ScopeDeploy APITokenScope = "deploy"
+	// ScopeAdmin allows every API request. Tokens without scopes are admin.
+	ScopeAdmin APITokenScope = "admin"
+)
+
+// deployActionPattern matches the API routes covered by the deploy scope
+var deployActionPattern = regexp.MustCompile(`^/api/(apps/[^/]+/(deploy|start|stop|restart)|builds/[^/]+/(cancel|retry))$`)
+
+// APIToken is a personal access token for the API. Only a hash of the token
+// is stored; the plaintext is shown once at creation.
+type APIToken struct {
+	ID         string       `db:"id" json:"id"`
+	Name       string       `db:"name" json:"name"`
+	Prefix     string       `db:"prefix" json:"prefix"` // first characters, for identification
+	TokenHash  string       `db:"token_hash" json:"-"`
+	Scopes     string       `db:"scopes" json:"-"` // comma-separated, empty means admin
+	CreatedBy  string       `db:"created_by" json:"created_by"`
+	CreatedAt  time.Time    `db:"created_at" json:"created_at"`
+	LastUsedAt sql.NullTime `db:"last_used_at" json:"last_used_at,omitempty"`
+	ExpiresAt  sql.NullTime `db:"expires_at" json:"expires_at,omitempty"`
+}
+
+// ScopeList returns the token's scopes
+func (t *APIToken) ScopeList() []APITokenScope {
+	if t.Scopes == "" {
+		return []APITokenScope{ScopeAdmin}
+	}
+	var scopes []APITokenScope
+	for _, s := range strings.Split(t.Scopes, ",") {
+		scopes = append(scopes, APITokenScope(s))
+	}
+	return scopes
+}
+
+// HasScope returns true if the token grants the scope
+func (t *APIToken) HasScope(scope APITokenScope) bool {
+	for _, s := range t.ScopeList() {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired returns true if the token has an expiry in the past
+func (t *APIToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt.Valid && !now.Before(t.ExpiresAt.Time)
+}
+
+// Validate checks the token name and scopes
+func (t *APIToken) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	for _, s := range t.ScopeList() {
+		switch s {
+		case ScopeRead, ScopeDeploy, ScopeAdmin:
+		default:
+			return fmt.Errorf("unknown scope %q", s)
+		}
+	}
+	return nil
+}
+
+// JoinScopes normalizes scopes into the stored comma-separated form
+func JoinScopes(scopes []string) string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, s := range scopes {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	return strings.Join(out, ",")
+}
+
+// RequiredScope returns the scope needed for an API request
+func RequiredScope(method, path string) APITokenScope {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ScopeRead
+	}
+	if method == http.MethodPost && deployActionPattern.MatchString(path) {
+		return ScopeDeploy
+	}
+	return ScopeAdmin
+}
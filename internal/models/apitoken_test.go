@@ -0,0 +1,100 @@
+package models
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		method   string
+		path     string
+		expected APITokenScope
+	}{
+		{http.MethodGet, "/api/apps", ScopeRead},
+		{http.MethodHead, "/api/builds/b1", ScopeRead},
+		{http.MethodPost, "/api/apps/a1/deploy", ScopeDeploy},
+		{http.MethodPost, "/api/apps/a1/restart", ScopeDeploy},
+		{http.MethodPost, "/api/apps/bulk/restart", ScopeDeploy},
+		{http.MethodPost, "/api/builds/b1/retry", ScopeDeploy},
+		{http.MethodPost, "/api/apps/a1/deploy/extra", ScopeAdmin},
+		{http.MethodPost, "/api/apps", ScopeAdmin},
+		{http.MethodDelete, "/api/apps/a1", ScopeAdmin},
+		{http.MethodPut, "/api/settings/banner", ScopeAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			if got := RequiredScope(tt.method, tt.path); got != tt.expected {
+				t.Errorf("RequiredScope() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAPIToken_HasScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		scopes   string
+		scope    APITokenScope
+		expected bool
+	}{
+		{name: "no scopes is admin", scopes: "", scope: ScopeAdmin, expected: true},
+		{name: "admin grants deploy", scopes: "admin", scope: ScopeDeploy, expected: true},
+		{name: "read only", scopes: "read", scope: ScopeDeploy, expected: false},
+		{name: "read and deploy", scopes: "read,deploy", scope: ScopeDeploy, expected: true},
+		{name: "deploy does not grant admin", scopes: "read,deploy", scope: ScopeAdmin, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := &APIToken{Scopes: tt.scopes}
+			if got := token.HasScope(tt.scope); got != tt.expected {
+				t.Errorf("HasScope(%s) = %v, want %v", tt.scope, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAPIToken_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   APIToken
+		wantErr bool
+	}{
+		{name: "valid", token: APIToken{Name: "ci", Scopes: "read,deploy"}},
+		{name: "missing name", token: APIToken{Name: " "}, wantErr: true},
+		{name: "unknown scope", token: APIToken{Name: "ci", Scopes: "write"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.token.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAPIToken_IsExpired(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	never := &APIToken{}
+	if never.IsExpired(now) {
+		t.Error("token without expiry should not expire")
+	}
+
+	past := &APIToken{ExpiresAt: sql.NullTime{Time: now.Add(-time.Second), Valid: true}}
+	if !past.IsExpired(now) {
+		t.Error("token with past expiry should be expired")
+	}
+}
+
+func TestJoinScopes(t *testing.T) {
+	if got := JoinScopes([]string{" Read", "deploy", "read", ""}); got != "read,deploy" {
+		t.Errorf("JoinScopes() = %q, want %q", got, "read,deploy")
+	}
+}
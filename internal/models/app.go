@@ -4,9 +4,24 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
 	"time"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
+// minBasicAuthPasswordLen is the shortest password accepted for basic auth
+const minBasicAuthPasswordLen = 8
+
+// routePathPattern matches a route path prefix without its leading slash
+var routePathPattern = regexp.MustCompile(`^[A-Za-z0-9._~-]+(/[A-Za-z0-9._~-]+)*$`)
+
+// composeProjectUnsafe matches characters compose doesn't allow in project names
+var composeProjectUnsafe = regexp.MustCompile(`[^a-z0-9_-]+`)
+
 // NullRawMessage is a json.RawMessage that handles NULL values from the database
 type NullRawMessage json.RawMessage
 
@@ -56,6 +71,7 @@ type BuildStrategy string
 
 const (
 	BuildStrategyDockerfile BuildStrategy = "dockerfile"
+	BuildStrategyKaniko     BuildStrategy = "kaniko" // Dockerfile built by kaniko, without the Docker socket
 	BuildStrategyCompose    BuildStrategy = "compose"
 	BuildStrategyAutodetect BuildStrategy = "autodetect"
 )
@@ -81,6 +97,36 @@ type App struct {
 	Enabled        bool              `db:"enabled" json:"enabled"`
 	Subdomain      sql.NullString    `db:"subdomain" json:"subdomain"`      // e.g., "myapp" for myapp.slats.dev
 	PublicPort     sql.NullInt64     `db:"public_port" json:"public_port"` // Port to expose via tunnel
+	RoutePath      sql.NullString    `db:"route_path" json:"route_path"`   // e.g., "/api" to route only that path prefix
+	Protected      bool              `db:"protected" json:"protected"`     // Require Cloudflare Access login
+	AccessAllow    sql.NullString    `db:"access_allow" json:"access_allow"` // Emails, @domains and group:<id> allowed through Access
+	Tunnel         sql.NullString    `db:"tunnel" json:"tunnel"`             // Named Cloudflare tunnel to route through, empty for the default
+	BasicAuthUser  sql.NullString    `db:"basic_auth_user" json:"basic_auth_user"` // Username Caddy and Traefik require for protected apps
+	BasicAuthHash  sql.NullString    `db:"basic_auth_hash" json:"-"`               // bcrypt hash of the basic auth password
+	Template       sql.NullString    `db:"template" json:"template"`               // Catalog template the app was deployed from, instead of a repo
+	ComposeSpec    sql.NullString    `db:"compose_spec" json:"-"`                  // Compose file of an app adopted from existing containers
+	DockerHost     sql.NullString    `db:"docker_host" json:"docker_host"`         // Remote Docker host ID to deploy to, empty for the local daemon
+	Agent          sql.NullString    `db:"agent_id" json:"agent_id"`                // Schooner agent that runs the app, empty to run it here
+	Submodules     bool              `db:"submodules" json:"submodules"`             // Check out git submodules recursively
+	LFS            bool              `db:"lfs" json:"lfs"`                           // Fetch Git LFS files
+	IsolatedBuild  bool              `db:"isolated_build" json:"isolated_build"`     // Build in a disposable builder container, away from Schooner's data
+	TagPattern     sql.NullString    `db:"tag_pattern" json:"tag_pattern"`           // Build pushed tags matching this glob, e.g. v*.*.*, instead of the branch
+	ReleaseEvents  bool              `db:"release_events" json:"release_events"`     // Build published GitHub releases instead of pushes
+	AllowedBranches sql.NullString   `db:"allowed_branches" json:"allowed_branches"` // Comma-separated globs of branches webhooks may deploy, empty for any
+	ImageRefresh   sql.NullString    `db:"image_refresh" json:"image_refresh"`       // Cron schedule pulling images that track :latest and redeploying when they changed
+	ImageTagStrategy ImageTagStrategy `db:"image_tag_strategy" json:"image_tag_strategy"` // How images are tagged, blank for the git tag or build ID
+	ComposeProfiles sql.NullString   `db:"compose_profiles" json:"compose_profiles"` // Comma-separated compose profiles to enable
+	TestCommand    sql.NullString    `db:"test_command" json:"test_command"`         // Shell command run in the built image before deploying
+	ArtifactPaths  sql.NullString    `db:"artifact_paths" json:"artifact_paths"`     // Comma-separated paths copied out of the built image
+	BuildCaches    sql.NullString    `db:"build_caches" json:"build_caches"`         // Comma-separated package caches kept between builds
+	RequireApproval bool             `db:"require_approval" json:"require_approval"` // Webhook builds wait for approval before deploying
+	DeploySchedule NullRawMessage    `db:"deploy_schedule" json:"deploy_schedule,omitempty"` // Deploy windows and freezes of webhook builds
+	DebounceSeconds int              `db:"debounce_seconds" json:"debounce_seconds"` // Webhook builds wait this long for newer pushes, 0 builds every push
+	Project        sql.NullString    `db:"project" json:"project"`                  // Group the app is shown in on the dashboard
+	Tags           sql.NullString    `db:"tags" json:"tags"`                        // Comma-separated lowercase tags to find and filter apps by
+	ConfigRevision int               `db:"config_revision" json:"config_revision"`                   // Latest saved revision of the app's configuration
+	DeployedConfigRevision int       `db:"deployed_config_revision" json:"deployed_config_revision"` // Revision the running deployment was deployed with
+	Stopped        bool              `db:"stopped" json:"stopped"`                                   // Stopped on purpose, so it isn't started again until started or deployed
 	CreatedAt      time.Time         `db:"created_at" json:"created_at"`
 	UpdatedAt      time.Time         `db:"updated_at" json:"updated_at"`
 }
@@ -138,6 +184,278 @@ func (a *App) GetPublicPort() int {
 	return 0
 }
 
+// GetRoutePath returns the route path prefix, or empty to route the whole host
+func (a *App) GetRoutePath() string {
+	if a.RoutePath.Valid {
+		return a.RoutePath.String
+	}
+	return ""
+}
+
+// GetAccessAllow returns the Cloudflare Access allow list or empty string
+func (a *App) GetAccessAllow() string {
+	if a.AccessAllow.Valid {
+		return a.AccessAllow.String
+	}
+	return ""
+}
+
+// GetTunnel returns the named tunnel the app routes through, or empty for the
+// default tunnel
+func (a *App) GetTunnel() string {
+	if a.Tunnel.Valid {
+		return a.Tunnel.String
+	}
+	return ""
+}
+
+// BasicAuth returns the basic auth username and password hash, or empty
+// strings if none are set
+func (a *App) BasicAuth() (user, hash string) {
+	if !a.BasicAuthUser.Valid || !a.BasicAuthHash.Valid {
+		return "", ""
+	}
+	return a.BasicAuthUser.String, a.BasicAuthHash.String
+}
+
+// HasBasicAuth reports whether basic auth credentials are set
+func (a *App) HasBasicAuth() bool {
+	user, hash := a.BasicAuth()
+	return user != "" && hash != ""
+}
+
+// SetBasicAuth sets the basic auth credentials. An empty password keeps the
+// current one, so the username can change without re-entering it.
+func (a *App) SetBasicAuth(user, password string) error {
+	if user == "" || strings.ContainsAny(user, ": \t\r\n{}\"") {
+		return fmt.Errorf("basic auth username must not be empty or contain spaces, ':', braces or quotes")
+	}
+
+	if password == "" {
+		if !a.BasicAuthHash.Valid || a.BasicAuthHash.String == "" {
+			return fmt.Errorf("basic auth password is required")
+		}
+		a.BasicAuthUser = sql.NullString{String: user, Valid: true}
+		return nil
+	}
+	if len(password) < minBasicAuthPasswordLen {
+		return fmt.Errorf("basic auth password must be at least %d characters", minBasicAuthPasswordLen)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	a.BasicAuthUser = sql.NullString{String: user, Valid: true}
+	a.BasicAuthHash = sql.NullString{String: string(hash), Valid: true}
+	return nil
+}
+
+// ClearBasicAuth removes the basic auth credentials
+func (a *App) ClearBasicAuth() {
+	a.BasicAuthUser = sql.NullString{}
+	a.BasicAuthHash = sql.NullString{}
+}
+
+// ValidateProtection checks a protected app can be guarded: Cloudflare
+// Access needs an allow list and Caddy and Traefik need basic auth
+func (a *App) ValidateProtection() error {
+	if a.Protected && a.GetAccessAllow() == "" && !a.HasBasicAuth() {
+		return fmt.Errorf("protected apps need an Access allow list or basic auth credentials")
+	}
+	return nil
+}
+
+// GetDockerHost returns the ID of the remote Docker host the app deploys
+// to, or empty for the local daemon
+func (a *App) GetDockerHost() string {
+	if a.DockerHost.Valid {
+		return a.DockerHost.String
+	}
+	return ""
+}
+
+// GetAgent returns the ID of the agent that runs the app, or empty string
+func (a *App) GetAgent() string {
+	if a.Agent.Valid {
+		return a.Agent.String
+	}
+	return ""
+}
+
+// PortHost names the machine whose host ports the app publishes on: its
+// agent, its remote Docker host, or empty for the local daemon. Apps on
+// different machines can use the same port.
+func (a *App) PortHost() string {
+	if agent := a.GetAgent(); agent != "" {
+		return "agent:" + agent
+	}
+	return a.GetDockerHost()
+}
+
+// GetTagPattern returns the release tag pattern or empty string
+func (a *App) GetTagPattern() string {
+	if a.TagPattern.Valid {
+		return a.TagPattern.String
+	}
+	return ""
+}
+
+// BuildsOnTags reports whether the app builds release tags, pushed or
+// published as GitHub releases, rather than pushes to its branch
+func (a *App) BuildsOnTags() bool {
+	return a.GetTagPattern() != "" || a.ReleaseEvents
+}
+
+// MatchesTag reports whether a tag is one of the app's releases. Apps
+// building GitHub releases without a tag pattern build every release.
+func (a *App) MatchesTag(tag string) bool {
+	if !a.BuildsOnTags() || tag == "" {
+		return false
+	}
+	if a.GetTagPattern() == "" {
+		return true
+	}
+	ok, err := path.Match(a.GetTagPattern(), tag)
+	return err == nil && ok
+}
+
+// ValidateTagPattern checks a release tag pattern is a valid glob
+func ValidateTagPattern(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid tag pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// GetComposeProfiles returns the compose profiles the app enables
+func (a *App) GetComposeProfiles() []string {
+	if !a.ComposeProfiles.Valid {
+		return nil
+	}
+	var profiles []string
+	for _, p := range strings.Split(a.ComposeProfiles.String, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			profiles = append(profiles, p)
+		}
+	}
+	return profiles
+}
+
+// composeProfileName is what docker compose accepts as a profile name
+var composeProfileName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// SetComposeProfiles checks and stores the compose profiles the app enables
+func (a *App) SetComposeProfiles(profiles []string) error {
+	var names []string
+	seen := make(map[string]bool)
+	for _, p := range profiles {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[p] {
+			continue
+		}
+		if !composeProfileName.MatchString(p) {
+			return fmt.Errorf("invalid compose profile %q", p)
+		}
+		seen[p] = true
+		names = append(names, p)
+	}
+	joined := strings.Join(names, ",")
+	a.ComposeProfiles = sql.NullString{String: joined, Valid: joined != ""}
+	return nil
+}
+
+// GetProject returns the project the app is grouped under, or empty string
+func (a *App) GetProject() string {
+	if a.Project.Valid {
+		return a.Project.String
+	}
+	return ""
+}
+
+// GetTags returns the app's tags
+func (a *App) GetTags() []string {
+	if !a.Tags.Valid || a.Tags.String == "" {
+		return nil
+	}
+	return strings.Split(a.Tags.String, ",")
+}
+
+// HasTag reports whether the app is tagged with tag, ignoring case
+func (a *App) HasTag(tag string) bool {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	for _, t := range a.GetTags() {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// tagName is what an app tag may look like once lowercased
+var tagName = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// SetTags checks and stores the app's tags, lowercased and without
+// duplicates
+func (a *App) SetTags(tags []string) error {
+	var names []string
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		if !tagName.MatchString(t) {
+			return fmt.Errorf("invalid tag %q: use letters, digits, '.', '_' and '-'", t)
+		}
+		seen[t] = true
+		names = append(names, t)
+	}
+	joined := strings.Join(names, ",")
+	a.Tags = sql.NullString{String: joined, Valid: joined != ""}
+	return nil
+}
+
+// GetTestCommand returns the command that gates deploys, or empty string
+func (a *App) GetTestCommand() string {
+	if a.TestCommand.Valid {
+		return a.TestCommand.String
+	}
+	return ""
+}
+
+// HasRepo reports whether the app is built from a git repository, rather
+// than deployed from a template or adopted from existing containers
+func (a *App) HasRepo() bool {
+	if a.Template.Valid && a.Template.String != "" {
+		return false
+	}
+	return !a.ComposeSpec.Valid || a.ComposeSpec.String == ""
+}
+
+// ComposeProjectName turns a name into a valid compose project name
+func ComposeProjectName(name string) string {
+	project := composeProjectUnsafe.ReplaceAllString(strings.ToLower(name), "-")
+	project = strings.Trim(project, "-_")
+	if project == "" {
+		return "app"
+	}
+	return project
+}
+
+// NormalizeRoutePath cleans a route path prefix to the form "/api", returning
+// empty for the whole host
+func NormalizeRoutePath(path string) (string, error) {
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	if path == "" {
+		return "", nil
+	}
+	if !routePathPattern.MatchString(path) {
+		return "", fmt.Errorf("route path may only contain letters, digits, '-', '_', '.', '~' and '/'")
+	}
+	return "/" + path, nil
+}
+
 // LoadEnvVars parses the JSON env vars into the map
 func (a *App) LoadEnvVars() error {
 	if !a.EnvVarsJSON.Valid || a.EnvVarsJSON.String == "" {
@@ -161,6 +479,26 @@ func (a *App) SaveEnvVars() error {
 	return nil
 }
 
+// secretEnvVarWords are the words of an env var name that mark its value
+// as a secret
+var secretEnvVarWords = map[string]bool{
+	"SECRET": true, "PASSWORD": true, "PASSWD": true, "PASS": true, "PWD": true,
+	"TOKEN": true, "KEY": true, "APIKEY": true, "CREDENTIALS": true, "PRIVATE": true,
+}
+
+// IsSecretEnvVar guesses from its name whether an env var holds a secret,
+// e.g. DB_PASSWORD or STRIPE_API_KEY
+func IsSecretEnvVar(name string) bool {
+	for _, word := range strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return r == '_' || r == '-' || r == '.'
+	}) {
+		if secretEnvVarWords[word] {
+			return true
+		}
+	}
+	return false
+}
+
 // GetEnvVarsAsString returns env vars as KEY=value lines
 func (a *App) GetEnvVarsAsString() string {
 	if len(a.EnvVars) == 0 {
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"encoding/json"
 	"testing"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestApp_GetDescription(t *testing.T) {
@@ -146,6 +148,98 @@ func TestApp_GetPublicPort(t *testing.T) {
 	}
 }
 
+func TestNormalizeRoutePath(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"", "", false},
+		{"/", "", false},
+		{"api", "/api", false},
+		{"/api/", "/api", false},
+		{" /api/v1 ", "/api/v1", false},
+		{"/api//v1", "", true},
+		{"/api?x=1", "", true},
+		{"/a b", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NormalizeRoutePath(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeRoutePath(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("NormalizeRoutePath(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApp_SetBasicAuth(t *testing.T) {
+	app := &App{}
+
+	if err := app.SetBasicAuth("admin", ""); err == nil {
+		t.Error("SetBasicAuth() without a password or current hash should fail")
+	}
+	if err := app.SetBasicAuth("ad:min", "correct horse"); err == nil {
+		t.Error("SetBasicAuth() should reject ':' in the username")
+	}
+	if err := app.SetBasicAuth("admin", "short"); err == nil {
+		t.Error("SetBasicAuth() should reject short passwords")
+	}
+
+	if err := app.SetBasicAuth("admin", "correct horse"); err != nil {
+		t.Fatalf("SetBasicAuth() error = %v", err)
+	}
+	user, hash := app.BasicAuth()
+	if user != "admin" || bcrypt.CompareHashAndPassword([]byte(hash), []byte("correct horse")) != nil {
+		t.Errorf("BasicAuth() = %q, %q; want admin and a hash of the password", user, hash)
+	}
+
+	// A blank password keeps the current hash
+	if err := app.SetBasicAuth("root", ""); err != nil {
+		t.Fatalf("SetBasicAuth() rename error = %v", err)
+	}
+	if user, kept := app.BasicAuth(); user != "root" || kept != hash {
+		t.Errorf("BasicAuth() after rename = %q, %q; want root and the same hash", user, kept)
+	}
+
+	app.ClearBasicAuth()
+	if app.HasBasicAuth() {
+		t.Error("HasBasicAuth() after ClearBasicAuth() = true")
+	}
+}
+
+func TestApp_ValidateProtection(t *testing.T) {
+	tests := []struct {
+		name    string
+		app     *App
+		wantErr bool
+	}{
+		{"unprotected", &App{}, false},
+		{"protected without guard", &App{Protected: true}, true},
+		{"protected by access", &App{Protected: true, AccessAllow: sql.NullString{String: "me@example.com", Valid: true}}, false},
+		{
+			name: "protected by basic auth",
+			app: &App{
+				Protected:     true,
+				BasicAuthUser: sql.NullString{String: "admin", Valid: true},
+				BasicAuthHash: sql.NullString{String: "$2a$10$hash", Valid: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.app.ValidateProtection(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateProtection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestApp_LoadSaveEnvVars(t *testing.T) {
 	app := &App{}
 
@@ -439,3 +533,159 @@ func TestNullRawMessage_UnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestComposeProjectName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"umami", "umami"},
+		{"My App", "my-app"},
+		{"n8n_prod", "n8n_prod"},
+		{"--x--", "x"},
+		{"!!!", "app"},
+	}
+	for _, tt := range tests {
+		if got := ComposeProjectName(tt.in); got != tt.want {
+			t.Errorf("ComposeProjectName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestApp_HasRepo(t *testing.T) {
+	tests := []struct {
+		name string
+		app  App
+		want bool
+	}{
+		{"repo", App{RepoURL: "https://github.com/user/repo.git"}, true},
+		{"template", App{Template: sql.NullString{String: "umami", Valid: true}}, false},
+		{"adopted", App{ComposeSpec: sql.NullString{String: "services: {}", Valid: true}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.app.HasRepo(); got != tt.want {
+				t.Errorf("HasRepo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApp_MatchesTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		releases bool
+		tag      string
+		want     bool
+	}{
+		{"semver", "v*.*.*", false, "v1.2.3", true},
+		{"prerelease", "v*.*.*", false, "v1.2.3-rc.1", true},
+		{"not a release", "v*.*.*", false, "nightly", false},
+		{"exact", "stable", false, "stable", true},
+		{"no pattern", "", false, "v1.2.3", false},
+		{"empty tag", "v*", false, "", false},
+		{"any release", "", true, "nightly", true},
+		{"release not matching", "v*.*.*", true, "nightly", false},
+		{"release without tag", "", true, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &App{TagPattern: sql.NullString{String: tt.pattern, Valid: tt.pattern != ""}, ReleaseEvents: tt.releases}
+			if got := app.MatchesTag(tt.tag); got != tt.want {
+				t.Errorf("MatchesTag(%q) = %v, want %v", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTagPattern(t *testing.T) {
+	for _, pattern := range []string{"", "v*.*.*", "release-[0-9]*"} {
+		if err := ValidateTagPattern(pattern); err != nil {
+			t.Errorf("ValidateTagPattern(%q) error = %v", pattern, err)
+		}
+	}
+	if err := ValidateTagPattern("v[1"); err == nil {
+		t.Error("ValidateTagPattern(\"v[1\") error = nil, want error")
+	}
+}
+
+func TestApp_SetComposeProfiles(t *testing.T) {
+	app := &App{}
+	if err := app.SetComposeProfiles([]string{" workers ", "debug", "workers", ""}); err != nil {
+		t.Fatalf("SetComposeProfiles() error = %v", err)
+	}
+	if app.ComposeProfiles.String != "workers,debug" {
+		t.Errorf("ComposeProfiles = %q, want %q", app.ComposeProfiles.String, "workers,debug")
+	}
+	if got := app.GetComposeProfiles(); len(got) != 2 || got[0] != "workers" || got[1] != "debug" {
+		t.Errorf("GetComposeProfiles() = %v, want [workers debug]", got)
+	}
+
+	if err := app.SetComposeProfiles(nil); err != nil {
+		t.Fatalf("SetComposeProfiles(nil) error = %v", err)
+	}
+	if app.ComposeProfiles.Valid || app.GetComposeProfiles() != nil {
+		t.Errorf("SetComposeProfiles(nil) left %v", app.ComposeProfiles)
+	}
+
+	if err := app.SetComposeProfiles([]string{"debug tools"}); err == nil {
+		t.Error("SetComposeProfiles(\"debug tools\") error = nil, want error")
+	}
+}
+
+func TestApp_SetTags(t *testing.T) {
+	app := &App{}
+	if err := app.SetTags([]string{" Media ", "home-lab", "media", ""}); err != nil {
+		t.Fatalf("SetTags() error = %v", err)
+	}
+	if app.Tags.String != "media,home-lab" {
+		t.Errorf("Tags = %q, want %q", app.Tags.String, "media,home-lab")
+	}
+	if got := app.GetTags(); len(got) != 2 || got[0] != "media" || got[1] != "home-lab" {
+		t.Errorf("GetTags() = %v, want [media home-lab]", got)
+	}
+	if !app.HasTag("MEDIA") || app.HasTag("med") {
+		t.Error("HasTag() should match whole tags ignoring case")
+	}
+
+	if err := app.SetTags(nil); err != nil {
+		t.Fatalf("SetTags(nil) error = %v", err)
+	}
+	if app.Tags.Valid || app.GetTags() != nil {
+		t.Errorf("SetTags(nil) left %v", app.Tags)
+	}
+
+	for _, tag := range []string{"two words", "a,b", "-lead"} {
+		if err := app.SetTags([]string{tag}); err == nil {
+			t.Errorf("SetTags(%q) error = nil, want error", tag)
+		}
+	}
+}
+
+func TestIsSecretEnvVar(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"DB_PASSWORD", true},
+		{"stripe_api_key", true},
+		{"GITHUB_TOKEN", true},
+		{"SECRET_KEY_BASE", true},
+		{"AWS_SECRET_ACCESS_KEY", true},
+		{"smtp-pass", true},
+		{"PORT", false},
+		{"DATABASE_HOST", false},
+		{"KEYCLOAK_URL", false},
+		{"PASSENGER_COUNT", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSecretEnvVar(tt.name); got != tt.want {
+				t.Errorf("IsSecretEnvVar(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
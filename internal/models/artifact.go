@@ -0,0 +1,58 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// GetArtifactPaths returns the paths copied out of the app's built image
+// after each build
+func (a *App) GetArtifactPaths() []string {
+	if !a.ArtifactPaths.Valid {
+		return nil
+	}
+	var paths []string
+	for _, p := range strings.Split(a.ArtifactPaths.String, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// SetArtifactPaths checks and stores the paths copied out of the app's built
+// image. They must be absolute and name distinct artifacts.
+func (a *App) SetArtifactPaths(paths []string) error {
+	var cleaned []string
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !path.IsAbs(p) || strings.Contains(p, ",") {
+			return fmt.Errorf("invalid artifact path %q: must be an absolute path in the image", p)
+		}
+		p = path.Clean(p)
+		if p == "/" {
+			return fmt.Errorf("invalid artifact path %q: copying the whole image isn't supported", p)
+		}
+		name := ArtifactName(p)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		cleaned = append(cleaned, p)
+	}
+	joined := strings.Join(cleaned, ",")
+	a.ArtifactPaths = sql.NullString{String: joined, Valid: joined != ""}
+	return nil
+}
+
+// ArtifactName is the file an artifact path of an image is archived as, e.g.
+// app-dist.tar.gz for /app/dist
+func ArtifactName(imagePath string) string {
+	return strings.ReplaceAll(strings.Trim(path.Clean(imagePath), "/"), "/", "-") + ".tar.gz"
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetArtifactPaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		paths   []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "none", paths: nil, want: nil},
+		{name: "cleaned", paths: []string{" /app/dist/ ", "/app/coverage", ""}, want: []string{"/app/dist", "/app/coverage"}},
+		{name: "same archive", paths: []string{"/app/dist", "/app/dist/."}, want: []string{"/app/dist"}},
+		{name: "relative", paths: []string{"dist"}, wantErr: true},
+		{name: "root", paths: []string{"/"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &App{}
+			err := app.SetArtifactPaths(tt.paths)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetArtifactPaths() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := app.GetArtifactPaths(); !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetArtifactPaths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArtifactName(t *testing.T) {
+	if got := ArtifactName("/app/dist/"); got != "app-dist.tar.gz" {
+		t.Errorf("ArtifactName() = %q, want app-dist.tar.gz", got)
+	}
+}
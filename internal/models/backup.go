@@ -0,0 +1,141 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// BackupTarget is where backup archives are stored
+type BackupTarget string
+
+const (
+	BackupTargetLocal BackupTarget = "local"
+	BackupTargetS3    BackupTarget = "s3"
+)
+
+// BackupStatus is the state of a single backup
+type BackupStatus string
+
+const (
+	BackupRunning BackupStatus = "running"
+	BackupSuccess BackupStatus = "success"
+	BackupFailed  BackupStatus = "failed"
+)
+
+// volumeNamePattern matches Docker volume names
+var volumeNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// BackupJob snapshots a set of named volumes, on a schedule or on demand
+type BackupJob struct {
+	ID        string         `db:"id" json:"id"`
+	Name      string         `db:"name" json:"name"`
+	Volumes   string         `db:"volumes" json:"volumes"`     // Comma-separated volume names
+	Schedule  string         `db:"schedule" json:"schedule"`   // Cron expression, empty for manual runs only
+	KeepLast  int            `db:"keep_last" json:"keep_last"` // Backups kept per volume, 0 keeps all
+	Target    BackupTarget   `db:"target" json:"target"`
+	Enabled   bool           `db:"enabled" json:"enabled"`
+	LastRunAt sql.NullTime   `db:"last_run_at" json:"last_run_at,omitempty"`
+	LastError sql.NullString `db:"last_error" json:"last_error,omitempty"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// VolumeList returns the job's volume names
+func (j *BackupJob) VolumeList() []string {
+	var volumes []string
+	for _, v := range strings.Split(j.Volumes, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			volumes = append(volumes, v)
+		}
+	}
+	return volumes
+}
+
+// SetVolumes stores the job's volume names
+func (j *BackupJob) SetVolumes(volumes []string) {
+	var cleaned []string
+	for _, v := range volumes {
+		if v = strings.TrimSpace(v); v != "" {
+			cleaned = append(cleaned, v)
+		}
+	}
+	j.Volumes = strings.Join(cleaned, ",")
+}
+
+// NextRun returns when a scheduled job is next due after the given time, or
+// the zero time if it only runs on demand
+func (j *BackupJob) NextRun(after time.Time) time.Time {
+	if j.Schedule == "" {
+		return time.Time{}
+	}
+	schedule, err := cron.ParseStandard(j.Schedule)
+	if err != nil {
+		return time.Time{}
+	}
+	return schedule.Next(after)
+}
+
+// Validate checks the job's volumes, schedule, retention and target
+func (j *BackupJob) Validate() error {
+	if j.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	volumes := j.VolumeList()
+	if len(volumes) == 0 {
+		return fmt.Errorf("at least one volume is required")
+	}
+	for _, v := range volumes {
+		if !volumeNamePattern.MatchString(v) {
+			return fmt.Errorf("invalid volume name %q", v)
+		}
+	}
+
+	if j.Schedule != "" {
+		if _, err := cron.ParseStandard(j.Schedule); err != nil {
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+	}
+	if j.KeepLast < 0 {
+		return fmt.Errorf("keep_last must not be negative")
+	}
+
+	switch j.Target {
+	case BackupTargetLocal, BackupTargetS3:
+		return nil
+	default:
+		return fmt.Errorf("unknown backup target %q", j.Target)
+	}
+}
+
+// Backup is one archive of one volume
+type Backup struct {
+	ID         string         `db:"id" json:"id"`
+	JobID      sql.NullString `db:"job_id" json:"job_id"` // Empty once the job is deleted
+	Volume     string         `db:"volume" json:"volume"`
+	Target     BackupTarget   `db:"target" json:"target"`
+	Key        string         `db:"key" json:"key"` // Path of the archive within the target
+	SizeBytes  int64          `db:"size_bytes" json:"size_bytes"`
+	Status     BackupStatus   `db:"status" json:"status"`
+	Error      sql.NullString `db:"error" json:"error,omitempty"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+	FinishedAt sql.NullTime   `db:"finished_at" json:"finished_at,omitempty"`
+}
+
+// SystemBackup is an encrypted dump of Schooner's own database and
+// encryption key
+type SystemBackup struct {
+	ID         string         `db:"id" json:"id"`
+	Target     BackupTarget   `db:"target" json:"target"`
+	Key        string         `db:"key" json:"key"`
+	SizeBytes  int64          `db:"size_bytes" json:"size_bytes"`
+	Status     BackupStatus   `db:"status" json:"status"`
+	Error      sql.NullString `db:"error" json:"error,omitempty"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+	FinishedAt sql.NullTime   `db:"finished_at" json:"finished_at,omitempty"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackupJob_Volumes(t *testing.T) {
+	job := &BackupJob{}
+	job.SetVolumes([]string{" app-data ", "", "db-data"})
+	if job.Volumes != "app-data,db-data" {
+		t.Errorf("Volumes = %q", job.Volumes)
+	}
+	if got := job.VolumeList(); len(got) != 2 || got[0] != "app-data" || got[1] != "db-data" {
+		t.Errorf("VolumeList() = %v", got)
+	}
+}
+
+func TestBackupJob_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		job     BackupJob
+		wantErr bool
+	}{
+		{"valid", BackupJob{Name: "nightly", Volumes: "data", Schedule: "0 3 * * *", KeepLast: 7, Target: BackupTargetLocal}, false},
+		{"manual only", BackupJob{Name: "adhoc", Volumes: "data", Target: BackupTargetS3}, false},
+		{"missing name", BackupJob{Volumes: "data", Target: BackupTargetLocal}, true},
+		{"no volumes", BackupJob{Name: "nightly", Volumes: " , ", Target: BackupTargetLocal}, true},
+		{"bad volume", BackupJob{Name: "nightly", Volumes: "../etc", Target: BackupTargetLocal}, true},
+		{"bad schedule", BackupJob{Name: "nightly", Volumes: "data", Schedule: "every day", Target: BackupTargetLocal}, true},
+		{"negative retention", BackupJob{Name: "nightly", Volumes: "data", KeepLast: -1, Target: BackupTargetLocal}, true},
+		{"unknown target", BackupJob{Name: "nightly", Volumes: "data", Target: "ftp"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.job.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBackupJob_NextRun(t *testing.T) {
+	after := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	job := &BackupJob{Schedule: "0 3 * * *"}
+	if got, want := job.NextRun(after), time.Date(2024, 5, 2, 3, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("NextRun() = %v, want %v", got, want)
+	}
+
+	job.Schedule = ""
+	if !job.NextRun(after).IsZero() {
+		t.Error("manual jobs should have no next run")
+	}
+}
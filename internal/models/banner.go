@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// BannerLevel controls how a maintenance banner is styled
+type BannerLevel string
+
+const (
+	BannerLevelInfo    BannerLevel = "info"
+	BannerLevelWarning BannerLevel = "warning"
+)
+
+// IsValid returns true if the level is a known banner level
+func (l BannerLevel) IsValid() bool {
+	return l == BannerLevelInfo || l == BannerLevelWarning
+}
+
+// Banner is a global announcement shown on every page
+type Banner struct {
+	Message   string      `json:"message"`
+	Level     BannerLevel `json:"level"`
+	ExpiresAt *time.Time  `json:"expires_at,omitempty"`
+}
+
+// IsActive returns true if the banner has a message and has not expired
+func (b *Banner) IsActive(now time.Time) bool {
+	if b == nil || b.Message == "" {
+		return false
+	}
+	if b.ExpiresAt != nil && !now.Before(*b.ExpiresAt) {
+		return false
+	}
+	return true
+}
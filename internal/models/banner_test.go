@@ -0,0 +1,52 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBanner_IsActive(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		banner   *Banner
+		expected bool
+	}{
+		{name: "nil banner", banner: nil, expected: false},
+		{name: "empty message", banner: &Banner{Level: BannerLevelInfo}, expected: false},
+		{name: "no expiry", banner: &Banner{Message: "maintenance", Level: BannerLevelInfo}, expected: true},
+		{name: "future expiry", banner: &Banner{Message: "maintenance", ExpiresAt: &future}, expected: true},
+		{name: "expired", banner: &Banner{Message: "maintenance", ExpiresAt: &past}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.banner.IsActive(now); got != tt.expected {
+				t.Errorf("IsActive() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBannerLevel_IsValid(t *testing.T) {
+	tests := []struct {
+		level    BannerLevel
+		expected bool
+	}{
+		{BannerLevelInfo, true},
+		{BannerLevelWarning, true},
+		{"error", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.level), func(t *testing.T) {
+			if got := tt.level.IsValid(); got != tt.expected {
+				t.Errorf("IsValid() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// GetAllowedBranches returns the patterns of branches the app's webhook
+// deploys may build, none if any branch may
+func (a *App) GetAllowedBranches() []string {
+	if !a.AllowedBranches.Valid {
+		return nil
+	}
+	var patterns []string
+	for _, pattern := range strings.Split(a.AllowedBranches.String, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// SetAllowedBranches checks and stores the patterns of branches the app's
+// webhook deploys may build
+func (a *App) SetAllowedBranches(patterns []string) error {
+	var cleaned []string
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if strings.Contains(pattern, ",") {
+			return fmt.Errorf("invalid branch pattern %q: commas aren't allowed", pattern)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid branch pattern %q: %w", pattern, err)
+		}
+		cleaned = append(cleaned, pattern)
+	}
+	joined := strings.Join(cleaned, ",")
+	a.AllowedBranches = sql.NullString{String: joined, Valid: joined != ""}
+	return nil
+}
+
+// AllowsBranch reports whether a webhook may deploy a push to branch. Apps
+// without allowed branches allow any.
+func (a *App) AllowsBranch(branch string) bool {
+	patterns := a.GetAllowedBranches()
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, branch); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetAllowedBranches(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+		wantErr  bool
+	}{
+		{name: "none", patterns: nil, want: nil},
+		{name: "trimmed", patterns: []string{" main ", "", "release/*"}, want: []string{"main", "release/*"}},
+		{name: "bad glob", patterns: []string{"release/[1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &App{}
+			err := app.SetAllowedBranches(tt.patterns)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetAllowedBranches() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := app.GetAllowedBranches(); !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetAllowedBranches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApp_AllowsBranch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		branch   string
+		want     bool
+	}{
+		{"no allowlist", nil, "anything", true},
+		{"exact", []string{"main"}, "main", true},
+		{"glob", []string{"main", "release/*"}, "release/1.2", true},
+		{"glob stops at slash", []string{"release/*"}, "release/1.2/hotfix", false},
+		{"not allowed", []string{"main"}, "feature-x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &App{}
+			if err := app.SetAllowedBranches(tt.patterns); err != nil {
+				t.Fatal(err)
+			}
+			if got := app.AllowsBranch(tt.branch); got != tt.want {
+				t.Errorf("AllowsBranch(%q) = %v, want %v", tt.branch, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,30 +2,41 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
+	"regexp"
+	"strings"
 	"time"
 )
 
+// dockerTagUnsafe matches characters Docker doesn't allow in image tags
+var dockerTagUnsafe = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
 // BuildStatus represents the current state of a build
 type BuildStatus string
 
 const (
-	BuildStatusPending   BuildStatus = "pending"
-	BuildStatusCloning   BuildStatus = "cloning"
-	BuildStatusBuilding  BuildStatus = "building"
-	BuildStatusPushing   BuildStatus = "pushing"
-	BuildStatusDeploying BuildStatus = "deploying"
-	BuildStatusSuccess   BuildStatus = "success"
-	BuildStatusFailed    BuildStatus = "failed"
-	BuildStatusCancelled BuildStatus = "cancelled"
+	BuildStatusPending         BuildStatus = "pending"
+	BuildStatusCloning         BuildStatus = "cloning"
+	BuildStatusBuilding        BuildStatus = "building"
+	BuildStatusPushing         BuildStatus = "pushing"
+	BuildStatusDeploying       BuildStatus = "deploying"
+	BuildStatusWaitingApproval BuildStatus = "waiting_approval"
+	BuildStatusScheduled       BuildStatus = "scheduled"
+	BuildStatusSuccess         BuildStatus = "success"
+	BuildStatusFailed          BuildStatus = "failed"
+	BuildStatusCancelled       BuildStatus = "cancelled"
 )
 
 // BuildTrigger indicates what initiated the build
 type BuildTrigger string
 
 const (
-	TriggerWebhook  BuildTrigger = "webhook"
-	TriggerManual   BuildTrigger = "manual"
-	TriggerRollback BuildTrigger = "rollback"
+	TriggerWebhook   BuildTrigger = "webhook"
+	TriggerManual    BuildTrigger = "manual"
+	TriggerRollback  BuildTrigger = "rollback"
+	TriggerConfig    BuildTrigger = "config"    // Redeploy applying a configuration change
+	TriggerReconcile BuildTrigger = "reconcile" // Redeploy recreating a container that went missing
+	TriggerRefresh   BuildTrigger = "refresh"   // Redeploy running images a scheduled pull updated
 )
 
 // Build represents a build execution
@@ -34,11 +45,25 @@ type Build struct {
 	AppID         string         `db:"app_id" json:"app_id"`
 	Status        BuildStatus    `db:"status" json:"status"`
 	Trigger       BuildTrigger   `db:"trigger" json:"trigger"`
+	TriggeredBy   sql.NullString `db:"triggered_by" json:"triggered_by,omitempty"` // GitHub user who pushed, or the user or API token that started the build
 	CommitSHA     sql.NullString `db:"commit_sha" json:"commit_sha"`
 	CommitMessage sql.NullString `db:"commit_message" json:"commit_message"`
 	CommitAuthor  sql.NullString `db:"commit_author" json:"commit_author"`
 	Branch        sql.NullString `db:"branch" json:"branch"`
 	ImageTag      sql.NullString `db:"image_tag" json:"image_tag"`
+	Tag           sql.NullString `db:"tag" json:"tag"`                                   // Git tag built, for apps building release tags
+	ReleaseName   sql.NullString `db:"release_name" json:"release_name,omitempty"`       // Title of the GitHub release built
+	ReleaseNotes  sql.NullString `db:"release_notes" json:"release_notes,omitempty"`     // Body of the GitHub release built
+	ReleaseURL    sql.NullString `db:"release_url" json:"release_url,omitempty"`         // Page of the GitHub release built
+	ImageDigests  sql.NullString `db:"image_digests" json:"image_digests,omitempty"`     // JSON object of compose service to pinned image
+	ImageID       sql.NullString `db:"image_id" json:"image_id,omitempty"`               // Content ID of the image built, sha256:...
+	ImageDigest   sql.NullString `db:"image_digest" json:"image_digest,omitempty"`       // Registry digest of the image, for images pushed
+	BaseImage     sql.NullString `db:"base_image" json:"base_image,omitempty"`           // Image the final stage of the Dockerfile is built FROM
+	Builder       sql.NullString `db:"builder" json:"builder,omitempty"`                 // Schooner and Docker versions and the strategy that built the image
+	BuildSeconds  int            `db:"build_seconds" json:"build_seconds"`               // How long building the image took
+	PinnedBuildID sql.NullString `db:"pinned_build_id" json:"pinned_build_id,omitempty"` // Build whose commit and images a redeploy uses
+	ApprovedBy    sql.NullString `db:"approved_by" json:"approved_by,omitempty"`         // Who approved the deploy of a build waiting for approval
+	ApprovedAt    sql.NullTime   `db:"approved_at" json:"approved_at,omitempty"`
 	ErrorMessage  sql.NullString `db:"error_message" json:"error_message,omitempty"`
 	StartedAt     sql.NullTime   `db:"started_at" json:"started_at,omitempty"`
 	FinishedAt    sql.NullTime   `db:"finished_at" json:"finished_at,omitempty"`
@@ -74,6 +99,31 @@ func (b *Build) GetCommitMessage() string {
 	return ""
 }
 
+// GetCommitAuthor returns commit author or empty string
+func (b *Build) GetCommitAuthor() string {
+	if b.CommitAuthor.Valid {
+		return b.CommitAuthor.String
+	}
+	return ""
+}
+
+// GetTriggeredBy returns who triggered the build or empty string
+func (b *Build) GetTriggeredBy() string {
+	if b.TriggeredBy.Valid {
+		return b.TriggeredBy.String
+	}
+	return ""
+}
+
+// DescribeTrigger says what started the build and who, e.g. "webhook by
+// alice"
+func (b *Build) DescribeTrigger() string {
+	if by := b.GetTriggeredBy(); by != "" {
+		return string(b.Trigger) + " by " + by
+	}
+	return string(b.Trigger)
+}
+
 // GetBranch returns branch or empty string
 func (b *Build) GetBranch() string {
 	if b.Branch.Valid {
@@ -82,6 +132,72 @@ func (b *Build) GetBranch() string {
 	return ""
 }
 
+// GetTag returns the git tag built or empty string
+func (b *Build) GetTag() string {
+	if b.Tag.Valid {
+		return b.Tag.String
+	}
+	return ""
+}
+
+// ReleaseEnv returns the env vars describing the release a build deploys:
+// its tag, and the title, notes and page of a published GitHub release
+func (b *Build) ReleaseEnv() map[string]string {
+	env := make(map[string]string)
+	for name, value := range map[string]sql.NullString{
+		"RELEASE_TAG":   b.Tag,
+		"RELEASE_NAME":  b.ReleaseName,
+		"RELEASE_NOTES": b.ReleaseNotes,
+		"RELEASE_URL":   b.ReleaseURL,
+	} {
+		if value.Valid && value.String != "" {
+			env[name] = value.String
+		}
+	}
+	return env
+}
+
+// GetImageDigests returns the image each compose service ran, pinned by
+// digest, or nil if none were recorded
+func (b *Build) GetImageDigests() map[string]string {
+	if !b.ImageDigests.Valid || b.ImageDigests.String == "" {
+		return nil
+	}
+	var digests map[string]string
+	if err := json.Unmarshal([]byte(b.ImageDigests.String), &digests); err != nil {
+		return nil
+	}
+	return digests
+}
+
+// SetImageDigests records the pinned image of each compose service
+func (b *Build) SetImageDigests(digests map[string]string) {
+	if len(digests) == 0 {
+		b.ImageDigests = sql.NullString{}
+		return
+	}
+	data, _ := json.Marshal(digests)
+	b.ImageDigests = sql.NullString{String: string(data), Valid: true}
+}
+
+// GetPinnedBuildID returns the build a redeploy pins its images to, or an
+// empty string
+func (b *Build) GetPinnedBuildID() string {
+	if b.PinnedBuildID.Valid {
+		return b.PinnedBuildID.String
+	}
+	return ""
+}
+
+// VersionTag returns the tag the build's image gets: its git tag made safe
+// for Docker, or the start of the build ID
+func (b *Build) VersionTag() string {
+	if tag := dockerTag(b.GetTag()); tag != "" {
+		return tag
+	}
+	return b.ID[:8]
+}
+
 // GetImageTag returns image tag or empty string
 func (b *Build) GetImageTag() string {
 	if b.ImageTag.Valid {
@@ -90,6 +206,26 @@ func (b *Build) GetImageTag() string {
 	return ""
 }
 
+// GetImageID returns the content ID of the image or empty string
+func (b *Build) GetImageID() string {
+	if b.ImageID.Valid {
+		return b.ImageID.String
+	}
+	return ""
+}
+
+// Digest returns what identifies the exact image the build deployed: its
+// registry digest if it was pushed, otherwise its content ID
+func (b *Build) Digest() string {
+	if b.ImageDigest.Valid && b.ImageDigest.String != "" {
+		if _, digest, ok := strings.Cut(b.ImageDigest.String, "@"); ok {
+			return digest
+		}
+		return b.ImageDigest.String
+	}
+	return b.GetImageID()
+}
+
 // GetErrorMessage returns error message or empty string
 func (b *Build) GetErrorMessage() string {
 	if b.ErrorMessage.Valid {
@@ -119,6 +255,17 @@ func (b *Build) IsRunning() bool {
 	return false
 }
 
+// IsWaiting returns true if the build is built and waiting to deploy, for
+// approval or for its deploy window
+func (b *Build) IsWaiting() bool {
+	return b.Status == BuildStatusWaitingApproval || b.Status == BuildStatusScheduled
+}
+
+// IsApproved returns true if the deploy of the build was approved
+func (b *Build) IsApproved() bool {
+	return b.ApprovedAt.Valid
+}
+
 // IsComplete returns true if build has finished
 func (b *Build) IsComplete() bool {
 	switch b.Status {
@@ -299,3 +299,115 @@ func TestBuildTriggerConstants(t *testing.T) {
 		t.Errorf("TriggerRollback = %v, want rollback", TriggerRollback)
 	}
 }
+
+func TestBuild_VersionTag(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		want string
+	}{
+		{"no tag", "", "abcdef12"},
+		{"semver", "v1.2.3", "v1.2.3"},
+		{"slashes", "release/1.2", "release-1.2"},
+		{"leading dot", ".hidden", "hidden"},
+		{"only unsafe", "+++", "abcdef12"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Build{ID: "abcdef12-3456", Tag: sql.NullString{String: tt.tag, Valid: tt.tag != ""}}
+			if got := b.VersionTag(); got != tt.want {
+				t.Errorf("VersionTag() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuild_ReleaseEnv(t *testing.T) {
+	b := &Build{
+		Tag:          sql.NullString{String: "v1.2.0", Valid: true},
+		ReleaseName:  sql.NullString{String: "Spring release", Valid: true},
+		ReleaseNotes: sql.NullString{String: "", Valid: true},
+		ReleaseURL:   sql.NullString{String: "https://github.com/acme/web/releases/tag/v1.2.0", Valid: true},
+	}
+	env := b.ReleaseEnv()
+	want := map[string]string{
+		"RELEASE_TAG":  "v1.2.0",
+		"RELEASE_NAME": "Spring release",
+		"RELEASE_URL":  "https://github.com/acme/web/releases/tag/v1.2.0",
+	}
+	if len(env) != len(want) {
+		t.Fatalf("ReleaseEnv() = %v, want %v", env, want)
+	}
+	for k, v := range want {
+		if env[k] != v {
+			t.Errorf("ReleaseEnv()[%q] = %q, want %q", k, env[k], v)
+		}
+	}
+
+	if env := (&Build{}).ReleaseEnv(); len(env) != 0 {
+		t.Errorf("ReleaseEnv() of a branch build = %v, want none", env)
+	}
+}
+
+func TestBuild_Digest(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     string
+		digest string
+		want   string
+	}{
+		{"not recorded", "", "", ""},
+		{"local image", "sha256:aaa", "", "sha256:aaa"},
+		{"pushed image", "sha256:aaa", "ghcr.io/acme/web@sha256:bbb", "sha256:bbb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Build{
+				ImageID:     sql.NullString{String: tt.id, Valid: tt.id != ""},
+				ImageDigest: sql.NullString{String: tt.digest, Valid: tt.digest != ""},
+			}
+			if got := b.Digest(); got != tt.want {
+				t.Errorf("Digest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuild_ImageDigests(t *testing.T) {
+	b := &Build{}
+	if got := b.GetImageDigests(); got != nil {
+		t.Errorf("GetImageDigests() = %v, want nil", got)
+	}
+
+	b.SetImageDigests(map[string]string{"db": "postgres@sha256:aaa"})
+	if got := b.GetImageDigests()["db"]; got != "postgres@sha256:aaa" {
+		t.Errorf("GetImageDigests()[db] = %q, want postgres@sha256:aaa", got)
+	}
+
+	b.SetImageDigests(nil)
+	if b.ImageDigests.Valid {
+		t.Error("SetImageDigests(nil) left ImageDigests set")
+	}
+}
+
+func TestBuild_DescribeTrigger(t *testing.T) {
+	tests := []struct {
+		name  string
+		build Build
+		want  string
+	}{
+		{"pushed", Build{Trigger: TriggerWebhook, TriggeredBy: sql.NullString{String: "alice", Valid: true}}, "webhook by alice"},
+		{"clicked", Build{Trigger: TriggerManual, TriggeredBy: sql.NullString{String: "bob", Valid: true}}, "manual by bob"},
+		{"unknown", Build{Trigger: TriggerReconcile}, "reconcile"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.build.DescribeTrigger(); got != tt.want {
+				t.Errorf("DescribeTrigger() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
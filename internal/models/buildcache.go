@@ -0,0 +1,88 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// BuildCache is a directory package managers fill during builds, kept
+// between an app's builds in a named volume
+type BuildCache struct {
+	Volume string // Suffix of the volume's name
+	Path   string // Where builds find it
+}
+
+// buildCacheKinds are the caches an app can keep, by the tool that fills
+// them. Paths are where the official images of each language put them.
+var buildCacheKinds = map[string][]BuildCache{
+	"go":     {{"go-mod", "/go/pkg/mod"}, {"go-build", "/root/.cache/go-build"}},
+	"npm":    {{"npm", "/root/.npm"}},
+	"yarn":   {{"yarn", "/usr/local/share/.cache/yarn"}},
+	"pnpm":   {{"pnpm", "/root/.local/share/pnpm/store"}},
+	"pip":    {{"pip", "/root/.cache/pip"}},
+	"maven":  {{"maven", "/root/.m2/repository"}},
+	"gradle": {{"gradle", "/root/.gradle/caches"}},
+	"cargo":  {{"cargo", "/usr/local/cargo/registry"}},
+}
+
+// BuildCacheKinds lists the caches an app can keep, sorted
+func BuildCacheKinds() []string {
+	return slices.Sorted(maps.Keys(buildCacheKinds))
+}
+
+// GetBuildCaches returns the kinds of cache the app keeps between builds
+func (a *App) GetBuildCaches() []string {
+	if !a.BuildCaches.Valid {
+		return nil
+	}
+	var kinds []string
+	for _, kind := range strings.Split(a.BuildCaches.String, ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+// SetBuildCaches checks and stores the kinds of cache the app keeps
+func (a *App) SetBuildCaches(kinds []string) error {
+	var names []string
+	for _, kind := range kinds {
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		if kind == "" || slices.Contains(names, kind) {
+			continue
+		}
+		if _, ok := buildCacheKinds[kind]; !ok {
+			return fmt.Errorf("unknown build cache %q: must be one of %s", kind, strings.Join(BuildCacheKinds(), ", "))
+		}
+		names = append(names, kind)
+	}
+	slices.Sort(names)
+	joined := strings.Join(names, ",")
+	a.BuildCaches = sql.NullString{String: joined, Valid: joined != ""}
+	return nil
+}
+
+// BuildCacheVolumePrefix starts the names of the app's cache volumes
+func (a *App) BuildCacheVolumePrefix() string {
+	return "schooner-cache-" + a.ID + "-"
+}
+
+// BuildCacheVolumes returns the named volumes of the app's build caches
+// and the paths builds mount them at
+func (a *App) BuildCacheVolumes() map[string]string {
+	kinds := a.GetBuildCaches()
+	if len(kinds) == 0 {
+		return nil
+	}
+	volumes := make(map[string]string)
+	for _, kind := range kinds {
+		for _, cache := range buildCacheKinds[kind] {
+			volumes[a.BuildCacheVolumePrefix()+cache.Volume] = cache.Path
+		}
+	}
+	return volumes
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetBuildCaches(t *testing.T) {
+	tests := []struct {
+		name    string
+		kinds   []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "none", kinds: nil, want: nil},
+		{name: "sorted and deduplicated", kinds: []string{"npm", " Go ", "npm", ""}, want: []string{"go", "npm"}},
+		{name: "unknown", kinds: []string{"bundler"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &App{}
+			err := app.SetBuildCaches(tt.kinds)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetBuildCaches() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := app.GetBuildCaches(); !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetBuildCaches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildCacheVolumes(t *testing.T) {
+	app := &App{ID: "a1"}
+	if volumes := app.BuildCacheVolumes(); volumes != nil {
+		t.Errorf("BuildCacheVolumes() without caches = %v, want none", volumes)
+	}
+
+	if err := app.SetBuildCaches([]string{"go", "pip"}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"schooner-cache-a1-go-mod":   "/go/pkg/mod",
+		"schooner-cache-a1-go-build": "/root/.cache/go-build",
+		"schooner-cache-a1-pip":      "/root/.cache/pip",
+	}
+	if got := app.BuildCacheVolumes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildCacheVolumes() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,183 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BuildStats aggregates the builds of a period
+type BuildStats struct {
+	Since          time.Time        `json:"since"`
+	Days           int              `json:"days"`
+	Total          int              `json:"total"`
+	Succeeded      int              `json:"succeeded"`
+	Failed         int              `json:"failed"`
+	Cancelled      int              `json:"cancelled"`
+	SuccessRate    float64          `json:"success_rate"` // Percentage of succeeded builds among succeeded and failed ones
+	Apps           []*AppBuildStats `json:"apps"`
+	Daily          []*DailyBuilds   `json:"daily"`
+	FailureReasons []*FailureReason `json:"failure_reasons"`
+}
+
+// AppBuildStats aggregates the builds of one app. Durations are in seconds
+// and only count builds that ran to success or failure.
+type AppBuildStats struct {
+	AppID       string  `json:"app_id"`
+	AppName     string  `json:"app_name"`
+	Total       int     `json:"total"`
+	Succeeded   int     `json:"succeeded"`
+	Failed      int     `json:"failed"`
+	SuccessRate float64 `json:"success_rate"`
+	AvgDuration float64 `json:"avg_duration"`
+	P50Duration float64 `json:"p50_duration"`
+	P90Duration float64 `json:"p90_duration"`
+	P95Duration float64 `json:"p95_duration"`
+
+	durations []float64
+}
+
+// DailyBuilds counts the builds created on a day
+type DailyBuilds struct {
+	Date      string `json:"date"` // 2006-01-02
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	Cancelled int    `json:"cancelled"`
+}
+
+// FailureReason counts the failed builds that stopped for the same reason
+type FailureReason struct {
+	Reason string `json:"reason"`
+	Count  int    `json:"count"`
+}
+
+// maxFailureReasons caps how many reasons the breakdown lists
+const maxFailureReasons = 10
+
+// ComputeBuildStats aggregates builds created in the days up to now. Builds
+// still running or waiting are left out.
+func ComputeBuildStats(builds []*Build, days int, now time.Time) *BuildStats {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	since := today.AddDate(0, 0, -(days - 1))
+	stats := &BuildStats{
+		Since:          since,
+		Days:           days,
+		Apps:           []*AppBuildStats{},
+		FailureReasons: []*FailureReason{},
+	}
+
+	daily := make(map[string]*DailyBuilds, days)
+	for d := 0; d < days; d++ {
+		day := &DailyBuilds{Date: since.AddDate(0, 0, d).Format("2006-01-02")}
+		daily[day.Date] = day
+		stats.Daily = append(stats.Daily, day)
+	}
+
+	apps := make(map[string]*AppBuildStats)
+	reasons := make(map[string]*FailureReason)
+	for _, b := range builds {
+		if b.CreatedAt.Before(since) || !b.IsComplete() {
+			continue
+		}
+
+		app, ok := apps[b.AppID]
+		if !ok {
+			app = &AppBuildStats{AppID: b.AppID, AppName: b.AppName}
+			apps[b.AppID] = app
+			stats.Apps = append(stats.Apps, app)
+		}
+		day := daily[b.CreatedAt.In(now.Location()).Format("2006-01-02")]
+
+		stats.Total++
+		app.Total++
+		switch b.Status {
+		case BuildStatusSuccess:
+			stats.Succeeded++
+			app.Succeeded++
+			if day != nil {
+				day.Succeeded++
+			}
+		case BuildStatusFailed:
+			stats.Failed++
+			app.Failed++
+			if day != nil {
+				day.Failed++
+			}
+			reason := failureReason(b.GetErrorMessage())
+			if reasons[reason] == nil {
+				reasons[reason] = &FailureReason{Reason: reason}
+				stats.FailureReasons = append(stats.FailureReasons, reasons[reason])
+			}
+			reasons[reason].Count++
+		default:
+			stats.Cancelled++
+			if day != nil {
+				day.Cancelled++
+			}
+		}
+
+		if b.Status != BuildStatusCancelled && b.StartedAt.Valid && b.FinishedAt.Valid {
+			app.durations = append(app.durations, b.FinishedAt.Time.Sub(b.StartedAt.Time).Seconds())
+		}
+	}
+
+	stats.SuccessRate = successRate(stats.Succeeded, stats.Failed)
+	for _, app := range stats.Apps {
+		app.SuccessRate = successRate(app.Succeeded, app.Failed)
+		if len(app.durations) == 0 {
+			continue
+		}
+		sort.Float64s(app.durations)
+		var sum float64
+		for _, d := range app.durations {
+			sum += d
+		}
+		app.AvgDuration = math.Round(sum / float64(len(app.durations)))
+		app.P50Duration = percentile(app.durations, 50)
+		app.P90Duration = percentile(app.durations, 90)
+		app.P95Duration = percentile(app.durations, 95)
+	}
+	sort.Slice(stats.Apps, func(i, j int) bool { return stats.Apps[i].AppName < stats.Apps[j].AppName })
+
+	sort.SliceStable(stats.FailureReasons, func(i, j int) bool {
+		return stats.FailureReasons[i].Count > stats.FailureReasons[j].Count
+	})
+	if len(stats.FailureReasons) > maxFailureReasons {
+		stats.FailureReasons = stats.FailureReasons[:maxFailureReasons]
+	}
+
+	return stats
+}
+
+// failureReason groups a build error by what failed, e.g. "clone failed"
+// for "clone failed: exit status 128"
+func failureReason(message string) string {
+	message, _, _ = strings.Cut(message, "\n")
+	message, _, _ = strings.Cut(message, ": ")
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return "unknown"
+	}
+	if len(message) > 100 {
+		message = message[:100]
+	}
+	return message
+}
+
+func successRate(succeeded, failed int) float64 {
+	if succeeded+failed == 0 {
+		return 0
+	}
+	return math.Round(float64(succeeded)/float64(succeeded+failed)*1000) / 10
+}
+
+// percentile returns the nearest-rank percentile of sorted values, rounded to
+// whole seconds
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return math.Round(sorted[rank-1])
+}
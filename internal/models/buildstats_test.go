@@ -0,0 +1,76 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestComputeBuildStats(t *testing.T) {
+	now := time.Date(2024, 6, 10, 15, 0, 0, 0, time.UTC)
+	build := func(app string, status BuildStatus, daysAgo int, seconds int, message string) *Build {
+		created := now.AddDate(0, 0, -daysAgo)
+		return &Build{
+			AppID:        app,
+			AppName:      app,
+			Status:       status,
+			CreatedAt:    created,
+			StartedAt:    sql.NullTime{Time: created, Valid: true},
+			FinishedAt:   sql.NullTime{Time: created.Add(time.Duration(seconds) * time.Second), Valid: true},
+			ErrorMessage: sql.NullString{String: message, Valid: message != ""},
+		}
+	}
+
+	builds := []*Build{
+		build("web", BuildStatusSuccess, 0, 60, ""),
+		build("web", BuildStatusSuccess, 0, 120, ""),
+		build("web", BuildStatusSuccess, 1, 90, ""),
+		build("web", BuildStatusFailed, 1, 30, "clone failed: exit status 128"),
+		build("api", BuildStatusFailed, 2, 10, "clone failed: authentication required"),
+		build("api", BuildStatusFailed, 2, 20, "tests failed"),
+		build("api", BuildStatusCancelled, 2, 5, "Superseded by a newer push (abcd1234)"),
+		build("api", BuildStatusSuccess, 10, 45, ""), // Before the period
+		build("api", BuildStatusBuilding, 0, 0, ""),  // Still running
+	}
+
+	stats := ComputeBuildStats(builds, 7, now)
+
+	if stats.Total != 7 || stats.Succeeded != 3 || stats.Failed != 3 || stats.Cancelled != 1 {
+		t.Errorf("totals = %d/%d/%d/%d, want 7/3/3/1", stats.Total, stats.Succeeded, stats.Failed, stats.Cancelled)
+	}
+	if stats.SuccessRate != 50 {
+		t.Errorf("SuccessRate = %v, want 50", stats.SuccessRate)
+	}
+
+	if len(stats.Daily) != 7 || stats.Daily[0].Date != "2024-06-04" || stats.Daily[6].Date != "2024-06-10" {
+		t.Fatalf("Daily = %+v, want 2024-06-04 to 2024-06-10", stats.Daily)
+	}
+	if d := stats.Daily[6]; d.Succeeded != 2 || d.Failed != 0 {
+		t.Errorf("today = %+v, want 2 succeeded", d)
+	}
+	if d := stats.Daily[4]; d.Failed != 2 || d.Cancelled != 1 {
+		t.Errorf("two days ago = %+v, want 2 failed and 1 cancelled", d)
+	}
+
+	if len(stats.Apps) != 2 || stats.Apps[0].AppName != "api" || stats.Apps[1].AppName != "web" {
+		t.Fatalf("Apps = %+v, want api and web", stats.Apps)
+	}
+	web := stats.Apps[1]
+	if web.Total != 4 || web.SuccessRate != 75 {
+		t.Errorf("web = %d builds at %v%%, want 4 at 75%%", web.Total, web.SuccessRate)
+	}
+	if web.AvgDuration != 75 || web.P50Duration != 60 || web.P90Duration != 120 || web.P95Duration != 120 {
+		t.Errorf("web durations = avg %v p50 %v p90 %v p95 %v, want 75/60/120/120",
+			web.AvgDuration, web.P50Duration, web.P90Duration, web.P95Duration)
+	}
+	if api := stats.Apps[0]; api.P50Duration != 10 || api.SuccessRate != 0 {
+		t.Errorf("api = p50 %v at %v%%, want p50 10 at 0%%", api.P50Duration, api.SuccessRate)
+	}
+
+	if len(stats.FailureReasons) != 2 {
+		t.Fatalf("FailureReasons = %+v, want 2", stats.FailureReasons)
+	}
+	if f := stats.FailureReasons[0]; f.Reason != "clone failed" || f.Count != 2 {
+		t.Errorf("top failure = %+v, want clone failed x2", f)
+	}
+}
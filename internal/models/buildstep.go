@@ -0,0 +1,58 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// StepStatus represents the state of a pipeline step
+type StepStatus string
+
+const (
+	StepStatusPending StepStatus = "pending"
+	StepStatusRunning StepStatus = "running"
+	StepStatusSuccess StepStatus = "success"
+	StepStatusFailed  StepStatus = "failed"
+	StepStatusSkipped StepStatus = "skipped"
+)
+
+// BuildStep is one step of a build's pipeline
+type BuildStep struct {
+	ID           int64          `db:"id" json:"id"`
+	BuildID      string         `db:"build_id" json:"build_id"`
+	Position     int            `db:"position" json:"position"`
+	Name         string         `db:"name" json:"name"`
+	Command      sql.NullString `db:"command" json:"command,omitempty"` // Shell command of a run step
+	Status       StepStatus     `db:"status" json:"status"`
+	ErrorMessage sql.NullString `db:"error_message" json:"error_message,omitempty"`
+	StartedAt    sql.NullTime   `db:"started_at" json:"started_at,omitempty"`
+	FinishedAt   sql.NullTime   `db:"finished_at" json:"finished_at,omitempty"`
+}
+
+// GetCommand returns the step's command or empty string
+func (s *BuildStep) GetCommand() string {
+	if s.Command.Valid {
+		return s.Command.String
+	}
+	return ""
+}
+
+// GetErrorMessage returns error message or empty string
+func (s *BuildStep) GetErrorMessage() string {
+	if s.ErrorMessage.Valid {
+		return s.ErrorMessage.String
+	}
+	return ""
+}
+
+// Duration returns how long the step ran, up to now if it's still running
+func (s *BuildStep) Duration() time.Duration {
+	if !s.StartedAt.Valid {
+		return 0
+	}
+	end := time.Now()
+	if s.FinishedAt.Valid {
+		end = s.FinishedAt.Time
+	}
+	return end.Sub(s.StartedAt.Time)
+}
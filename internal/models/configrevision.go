@@ -0,0 +1,154 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maskedValue replaces the value of secret env vars in config diffs
+const maskedValue = "••••••••"
+
+// ConfigRevision is a version of an app's configuration, saved each time
+// it's changed
+type ConfigRevision struct {
+	ID        string         `db:"id" json:"id"`
+	AppID     string         `db:"app_id" json:"app_id"`
+	Revision  int            `db:"revision" json:"revision"` // Counts up from 1 for each app
+	Snapshot  string         `db:"snapshot" json:"-"`        // JSON of the app's ConfigSnapshot
+	ChangedBy sql.NullString `db:"changed_by" json:"changed_by"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+}
+
+// GetSnapshot returns the settings the revision saved
+func (r *ConfigRevision) GetSnapshot() (map[string]string, error) {
+	snapshot := make(map[string]string)
+	if r.Snapshot == "" {
+		return snapshot, nil
+	}
+	if err := json.Unmarshal([]byte(r.Snapshot), &snapshot); err != nil {
+		return nil, fmt.Errorf("invalid config snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// SetSnapshot stores the settings of a revision
+func (r *ConfigRevision) SetSnapshot(snapshot map[string]string) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	r.Snapshot = string(data)
+	return nil
+}
+
+// ConfigChange is a setting that differs between two config revisions
+type ConfigChange struct {
+	Key    string `json:"key"`    // e.g. branch, env.DATABASE_URL or deploy.memory_limit
+	Action string `json:"action"` // added, removed or changed
+	Old    string `json:"old,omitempty"`
+	New    string `json:"new,omitempty"`
+}
+
+// ConfigSnapshot flattens the settings of an app a deploy applies into
+// key/value pairs that revisions save and diff. Env vars are keyed env.NAME
+// and deploy settings deploy.name; unset settings are left out.
+func (a *App) ConfigSnapshot() map[string]string {
+	snapshot := make(map[string]string)
+	set := func(key, value string) {
+		if value != "" {
+			snapshot[key] = value
+		}
+	}
+
+	set("branch", a.Branch)
+	set("allowed_branches", a.AllowedBranches.String)
+	set("image_refresh", a.ImageRefresh.String)
+	set("tag_pattern", a.TagPattern.String)
+	if a.ReleaseEvents {
+		set("release_events", "true")
+	}
+	set("build_strategy", string(a.BuildStrategy))
+	set("dockerfile_path", a.DockerfilePath)
+	set("compose_file", a.ComposeFile)
+	set("compose_profiles", a.ComposeProfiles.String)
+	set("build_context", a.BuildContext)
+	set("test_command", a.TestCommand.String)
+	set("artifact_paths", a.ArtifactPaths.String)
+	set("build_caches", a.BuildCaches.String)
+	set("container_name", a.ContainerName.String)
+	set("image_name", a.ImageName.String)
+	set("docker_host", a.DockerHost.String)
+	set("agent", a.Agent.String)
+	if port := a.GetPublicPort(); port > 0 {
+		set("public_port", fmt.Sprint(port))
+	}
+
+	for name, value := range a.EnvVars {
+		snapshot["env."+name] = value
+	}
+
+	var deploy map[string]json.RawMessage
+	if len(a.DeployConfig) > 0 && json.Unmarshal(a.DeployConfig, &deploy) == nil {
+		for name, raw := range deploy {
+			var s string
+			if json.Unmarshal(raw, &s) == nil {
+				set("deploy."+name, s)
+			} else {
+				set("deploy."+name, string(raw))
+			}
+		}
+	}
+
+	return snapshot
+}
+
+// DiffConfig lists the settings that differ between two snapshots, sorted
+// by key. Values of env vars that look like secrets are masked.
+func DiffConfig(old, new map[string]string) []ConfigChange {
+	keys := make(map[string]bool)
+	for k := range old {
+		keys[k] = true
+	}
+	for k := range new {
+		keys[k] = true
+	}
+
+	var changes []ConfigChange
+	for _, key := range slices.Sorted(maps.Keys(keys)) {
+		oldValue, hadOld := old[key]
+		newValue, hasNew := new[key]
+		change := ConfigChange{Key: key, Old: oldValue, New: newValue}
+		switch {
+		case !hadOld:
+			change.Action = "added"
+		case !hasNew:
+			change.Action = "removed"
+		case oldValue != newValue:
+			change.Action = "changed"
+		default:
+			continue
+		}
+
+		if name, ok := strings.CutPrefix(key, "env."); ok && IsSecretEnvVar(name) {
+			if hadOld {
+				change.Old = maskedValue
+			}
+			if hasNew {
+				change.New = maskedValue
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// ConfigChanged reports whether the app's configuration changed since it
+// was last deployed. Apps never deployed have nothing to be behind.
+func (a *App) ConfigChanged() bool {
+	return a.DeployedConfigRevision > 0 && a.ConfigRevision > a.DeployedConfigRevision
+}
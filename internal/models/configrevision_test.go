@@ -0,0 +1,96 @@
+package models
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestApp_ConfigSnapshot(t *testing.T) {
+	app := &App{
+		Branch:        "main",
+		BuildStrategy: BuildStrategyDockerfile,
+		ContainerName: sql.NullString{String: "api", Valid: true},
+		PublicPort:    sql.NullInt64{Int64: 8080, Valid: true},
+		EnvVars:       map[string]string{"PORT": "8080"},
+		DeployConfig:  NullRawMessage(`{"memory_limit":"512m","replicas":2}`),
+	}
+
+	want := map[string]string{
+		"branch":              "main",
+		"build_strategy":      "dockerfile",
+		"container_name":      "api",
+		"public_port":         "8080",
+		"env.PORT":            "8080",
+		"deploy.memory_limit": "512m",
+		"deploy.replicas":     "2",
+	}
+	if got := app.ConfigSnapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ConfigSnapshot() = %v, want %v", got, want)
+	}
+}
+
+func TestDiffConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		old  map[string]string
+		new  map[string]string
+		want []ConfigChange
+	}{
+		{
+			name: "unchanged",
+			old:  map[string]string{"branch": "main"},
+			new:  map[string]string{"branch": "main"},
+		},
+		{
+			name: "added, changed and removed",
+			old:  map[string]string{"branch": "main", "env.PORT": "8080"},
+			new:  map[string]string{"branch": "release", "env.DEBUG": "true"},
+			want: []ConfigChange{
+				{Key: "branch", Action: "changed", Old: "main", New: "release"},
+				{Key: "env.DEBUG", Action: "added", New: "true"},
+				{Key: "env.PORT", Action: "removed", Old: "8080"},
+			},
+		},
+		{
+			name: "secrets masked",
+			old:  map[string]string{"env.DB_PASSWORD": "hunter2"},
+			new:  map[string]string{"env.DB_PASSWORD": "correct-horse", "env.API_KEY": "abc"},
+			want: []ConfigChange{
+				{Key: "env.API_KEY", Action: "added", New: maskedValue},
+				{Key: "env.DB_PASSWORD", Action: "changed", Old: maskedValue, New: maskedValue},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffConfig(tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApp_ConfigChanged(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  int
+		deployed int
+		want     bool
+	}{
+		{name: "no history", want: false},
+		{name: "never deployed", current: 2, want: false},
+		{name: "deployed", current: 2, deployed: 2, want: false},
+		{name: "changed since deploy", current: 3, deployed: 2, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &App{ConfigRevision: tt.current, DeployedConfigRevision: tt.deployed}
+			if got := app.ConfigChanged(); got != tt.want {
+				t.Errorf("ConfigChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
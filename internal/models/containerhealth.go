@@ -0,0 +1,18 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ContainerHealth is how the health check of an app's container has
+// behaved, as seen by the container monitor
+type ContainerHealth struct {
+	AppID         string       `db:"app_id" json:"app_id"`
+	Health        string       `db:"health" json:"health"`     // starting, healthy or unhealthy
+	Flaps         int          `db:"flaps" json:"flaps"`       // Times it went from healthy to unhealthy
+	Restarts      int          `db:"restarts" json:"restarts"` // Restarts by the watchdog while unhealthy
+	LastFlapAt    sql.NullTime `db:"last_flap_at" json:"last_flap_at,omitempty"`
+	LastRestartAt sql.NullTime `db:"last_restart_at" json:"last_restart_at,omitempty"`
+	UpdatedAt     time.Time    `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// AppDependency says an app needs another app running before it starts
+type AppDependency struct {
+	AppID       string `db:"app_id" json:"app_id"`
+	DependsOnID string `db:"depends_on_id" json:"depends_on_id"`
+}
+
+// DependencyCycleError means apps depend on each other in a loop, so there
+// is no order to start them in
+type DependencyCycleError struct {
+	Path []string // IDs of the apps in the loop, starting and ending with the same app
+}
+
+func (e *DependencyCycleError) Error() string {
+	return "dependency cycle: " + strings.Join(e.Path, " -> ")
+}
+
+// NameCycle replaces the app IDs in a cycle with their names, for messages
+func (e *DependencyCycleError) NameCycle(names map[string]string) *DependencyCycleError {
+	named := make([]string, len(e.Path))
+	for i, id := range e.Path {
+		named[i] = id
+		if name, ok := names[id]; ok {
+			named[i] = name
+		}
+	}
+	return &DependencyCycleError{Path: named}
+}
+
+// DependencyGraph maps the ID of each app to the IDs of the apps it
+// depends on
+type DependencyGraph map[string][]string
+
+// NewDependencyGraph builds the graph of a list of dependencies
+func NewDependencyGraph(deps []AppDependency) DependencyGraph {
+	g := make(DependencyGraph)
+	for _, dep := range deps {
+		g[dep.AppID] = append(g[dep.AppID], dep.DependsOnID)
+	}
+	return g
+}
+
+// With returns a copy of the graph with the dependencies of appID replaced
+func (g DependencyGraph) With(appID string, dependsOn []string) DependencyGraph {
+	copied := make(DependencyGraph, len(g)+1)
+	for id, deps := range g {
+		copied[id] = deps
+	}
+	if len(dependsOn) == 0 {
+		delete(copied, appID)
+	} else {
+		copied[appID] = dependsOn
+	}
+	return copied
+}
+
+// Dependents returns the IDs of the apps that depend on appID directly,
+// sorted
+func (g DependencyGraph) Dependents(appID string) []string {
+	var dependents []string
+	for id, deps := range g {
+		for _, dep := range deps {
+			if dep == appID {
+				dependents = append(dependents, id)
+				break
+			}
+		}
+	}
+	sort.Strings(dependents)
+	return dependents
+}
+
+// Requires returns the IDs of every app appID depends on, directly or
+// through other apps
+func (g DependencyGraph) Requires(appID string) map[string]bool {
+	required := make(map[string]bool)
+	pending := append([]string(nil), g[appID]...)
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if required[id] {
+			continue
+		}
+		required[id] = true
+		pending = append(pending, g[id]...)
+	}
+	return required
+}
+
+// FindCycle returns a loop of dependencies in the graph, or nil if it has
+// none
+func (g DependencyGraph) FindCycle() *DependencyCycleError {
+	_, err := g.depths()
+	return err
+}
+
+// Order splits apps into waves to start them in: every app comes in a later
+// wave than the apps it depends on, directly or through apps that aren't in
+// the list. Apps within a wave keep their order in the list.
+func (g DependencyGraph) Order(appIDs []string) ([][]string, error) {
+	depths, err := g.depths()
+	if err != nil {
+		return nil, err
+	}
+
+	byDepth := make(map[int][]string)
+	var levels []int
+	for _, id := range appIDs {
+		depth := depths[id]
+		if _, ok := byDepth[depth]; !ok {
+			levels = append(levels, depth)
+		}
+		byDepth[depth] = append(byDepth[depth], id)
+	}
+	sort.Ints(levels)
+
+	waves := make([][]string, 0, len(levels))
+	for _, depth := range levels {
+		waves = append(waves, byDepth[depth])
+	}
+	return waves, nil
+}
+
+// depths returns how long the longest chain of dependencies below each app
+// is. Apps without dependencies are at depth 0.
+func (g DependencyGraph) depths() (map[string]int, *DependencyCycleError) {
+	depths := make(map[string]int)
+	visiting := make(map[string]bool)
+
+	var visit func(id string, path []string) *DependencyCycleError
+	visit = func(id string, path []string) *DependencyCycleError {
+		if _, done := depths[id]; done {
+			return nil
+		}
+		path = append(path, id)
+		if visiting[id] {
+			start := 0
+			for i, p := range path {
+				if p == id {
+					start = i
+					break
+				}
+			}
+			return &DependencyCycleError{Path: append([]string(nil), path[start:]...)}
+		}
+		visiting[id] = true
+
+		depth := 0
+		for _, dep := range g[id] {
+			if err := visit(dep, path); err != nil {
+				return err
+			}
+			depth = max(depth, depths[dep]+1)
+		}
+		visiting[id] = false
+		depths[id] = depth
+		return nil
+	}
+
+	ids := make([]string, 0, len(g))
+	for id := range g {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids) // Report the same cycle every time
+	for _, id := range ids {
+		if err := visit(id, nil); err != nil {
+			return nil, err
+		}
+	}
+	return depths, nil
+}
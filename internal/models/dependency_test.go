@@ -0,0 +1,120 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDependencyGraph_Order(t *testing.T) {
+	// web needs api, which needs db and cache; worker needs db
+	g := NewDependencyGraph([]AppDependency{
+		{AppID: "web", DependsOnID: "api"},
+		{AppID: "api", DependsOnID: "db"},
+		{AppID: "api", DependsOnID: "cache"},
+		{AppID: "worker", DependsOnID: "db"},
+	})
+
+	tests := []struct {
+		name string
+		apps []string
+		want [][]string
+	}{
+		{
+			name: "all apps",
+			apps: []string{"web", "worker", "api", "db", "cache", "docs"},
+			want: [][]string{{"db", "cache", "docs"}, {"worker", "api"}, {"web"}},
+		},
+		{
+			name: "through an app left out",
+			apps: []string{"web", "db"},
+			want: [][]string{{"db"}, {"web"}},
+		},
+		{
+			name: "independent apps",
+			apps: []string{"docs", "worker"},
+			want: [][]string{{"docs"}, {"worker"}},
+		},
+		{
+			name: "no apps",
+			want: [][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := g.Order(tt.apps)
+			if err != nil {
+				t.Fatalf("Order() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Order() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDependencyGraph_FindCycle(t *testing.T) {
+	g := NewDependencyGraph([]AppDependency{
+		{AppID: "web", DependsOnID: "api"},
+		{AppID: "api", DependsOnID: "db"},
+	})
+	if cycle := g.FindCycle(); cycle != nil {
+		t.Fatalf("FindCycle() = %v, want none", cycle)
+	}
+
+	looped := g.With("db", []string{"web"})
+	cycle := looped.FindCycle()
+	if cycle == nil {
+		t.Fatal("FindCycle() = nil, want the loop through db")
+	}
+	if want := []string{"api", "db", "web", "api"}; !reflect.DeepEqual(cycle.Path, want) {
+		t.Errorf("FindCycle().Path = %v, want %v", cycle.Path, want)
+	}
+	if _, err := looped.Order([]string{"web"}); err == nil {
+		t.Error("Order() error = nil, want the cycle")
+	}
+
+	named := cycle.NameCycle(map[string]string{"api": "API", "db": "Postgres"})
+	if got, want := named.Error(), "dependency cycle: API -> Postgres -> web -> API"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	if self := g.With("db", []string{"db"}).FindCycle(); self == nil || len(self.Path) != 2 {
+		t.Errorf("FindCycle() = %v, want db depending on itself", self)
+	}
+
+	// The original graph is unchanged
+	if cycle := g.FindCycle(); cycle != nil {
+		t.Errorf("FindCycle() after With = %v, want none", cycle)
+	}
+}
+
+func TestDependencyGraph_Dependents(t *testing.T) {
+	g := NewDependencyGraph([]AppDependency{
+		{AppID: "web", DependsOnID: "db"},
+		{AppID: "api", DependsOnID: "db"},
+		{AppID: "api", DependsOnID: "cache"},
+	})
+	if got, want := g.Dependents("db"), []string{"api", "web"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependents(db) = %v, want %v", got, want)
+	}
+	if got := g.Dependents("web"); got != nil {
+		t.Errorf("Dependents(web) = %v, want none", got)
+	}
+}
+
+func TestDependencyGraph_Requires(t *testing.T) {
+	g := NewDependencyGraph([]AppDependency{
+		{AppID: "web", DependsOnID: "api"},
+		{AppID: "api", DependsOnID: "db"},
+		{AppID: "api", DependsOnID: "cache"},
+		{AppID: "worker", DependsOnID: "db"},
+	})
+	want := map[string]bool{"api": true, "db": true, "cache": true}
+	if got := g.Requires("web"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Requires(web) = %v, want %v", got, want)
+	}
+	if got := g.Requires("db"); len(got) != 0 {
+		t.Errorf("Requires(db) = %v, want none", got)
+	}
+}
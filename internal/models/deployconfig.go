@@ -0,0 +1,223 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+)
+
+// DeployMode selects how a Dockerfile app's image is run
+type DeployMode string
+
+const (
+	// DeployModeContainer runs a single container. It is the default.
+	DeployModeContainer DeployMode = "container"
+	// DeployModeSwarm creates or updates a Swarm service on a Swarm manager
+	DeployModeSwarm DeployMode = "swarm"
+)
+
+// Swarm rolling update orders
+const (
+	UpdateOrderStopFirst  = "stop-first"
+	UpdateOrderStartFirst = "start-first"
+)
+
+// DeployConfig holds optional settings for running an app, stored as JSON
+// in the app's deploy_config column
+type DeployConfig struct {
+	Mode              DeployMode `json:"mode,omitempty"`
+	Replicas          int        `json:"replicas,omitempty"`           // Swarm only, defaults to 1
+	UpdateParallelism int        `json:"update_parallelism,omitempty"` // Swarm tasks updated at once, defaults to 1
+	UpdateDelay       string     `json:"update_delay,omitempty"`       // Pause between batches, e.g. "10s"
+	UpdateOrder       string     `json:"update_order,omitempty"`       // stop-first (default) or start-first
+
+	// Advanced settings of the app's container
+	Devices []string          `json:"devices,omitempty"`  // Host devices, "/dev/dri" or "/dev/dri:/dev/dri:rwm"
+	CapAdd  []string          `json:"cap_add,omitempty"`  // Linux capabilities added, e.g. NET_ADMIN
+	CapDrop []string          `json:"cap_drop,omitempty"` // Linux capabilities dropped
+	Sysctls map[string]string `json:"sysctls,omitempty"`  // Namespaced kernel parameters, e.g. net.ipv4.ip_forward
+	ShmSize string            `json:"shm_size,omitempty"` // Size of /dev/shm, e.g. 256m
+	GPUs    string            `json:"gpus,omitempty"`     // GPUs given to the container like docker run --gpus: all, a count or device=0,1
+	Runtime string            `json:"runtime,omitempty"`  // Container runtime, e.g. nvidia
+}
+
+var (
+	capabilityPattern = regexp.MustCompile(`^(?i)(CAP_)?[A-Z][A-Z0-9_]*$`)
+	sysctlPattern     = regexp.MustCompile(`^[a-z][a-z0-9_]*(\.[A-Za-z0-9_-]+)+$`)
+	gpuDevicePattern  = regexp.MustCompile(`^[A-Za-z0-9-]+(,[A-Za-z0-9-]+)*$`)
+	runtimePattern    = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+)
+
+// IsSwarm reports whether the app deploys as a Swarm service
+func (c *DeployConfig) IsSwarm() bool {
+	return c.Mode == DeployModeSwarm
+}
+
+// GetReplicas returns the number of Swarm tasks to run
+func (c *DeployConfig) GetReplicas() uint64 {
+	if c.Replicas < 1 {
+		return 1
+	}
+	return uint64(c.Replicas)
+}
+
+// GetUpdateParallelism returns how many Swarm tasks are updated at once
+func (c *DeployConfig) GetUpdateParallelism() uint64 {
+	if c.UpdateParallelism < 1 {
+		return 1
+	}
+	return uint64(c.UpdateParallelism)
+}
+
+// GetUpdateDelay returns the pause between Swarm update batches
+func (c *DeployConfig) GetUpdateDelay() time.Duration {
+	d, _ := time.ParseDuration(c.UpdateDelay)
+	return d
+}
+
+// GetUpdateOrder returns whether old Swarm tasks stop before new ones start
+func (c *DeployConfig) GetUpdateOrder() string {
+	if c.UpdateOrder == "" {
+		return UpdateOrderStopFirst
+	}
+	return c.UpdateOrder
+}
+
+// Validate checks the mode and Swarm settings
+func (c *DeployConfig) Validate() error {
+	switch c.Mode {
+	case "", DeployModeContainer, DeployModeSwarm:
+	default:
+		return fmt.Errorf("unknown deploy mode %q", c.Mode)
+	}
+	if c.Replicas < 0 || c.Replicas > 100 {
+		return fmt.Errorf("replicas must be between 0 and 100")
+	}
+	if c.UpdateParallelism < 0 {
+		return fmt.Errorf("update parallelism must not be negative")
+	}
+	if c.UpdateDelay != "" {
+		if d, err := time.ParseDuration(c.UpdateDelay); err != nil || d < 0 {
+			return fmt.Errorf("invalid update delay %q, use e.g. 10s", c.UpdateDelay)
+		}
+	}
+	switch c.UpdateOrder {
+	case "", UpdateOrderStopFirst, UpdateOrderStartFirst:
+	default:
+		return fmt.Errorf("update order must be %s or %s", UpdateOrderStopFirst, UpdateOrderStartFirst)
+	}
+	return c.validateAdvanced()
+}
+
+// validateAdvanced checks the devices, capabilities, sysctls, shm size, GPUs
+// and runtime
+func (c *DeployConfig) validateAdvanced() error {
+	for _, device := range c.Devices {
+		parts := strings.Split(device, ":")
+		if len(parts) > 3 || !strings.HasPrefix(parts[0], "/") || (len(parts) > 1 && !strings.HasPrefix(parts[1], "/")) {
+			return fmt.Errorf("invalid device %q, use a host path such as /dev/dri or /dev/dri:/dev/dri:rwm", device)
+		}
+		if len(parts) == 3 && strings.Trim(parts[2], "rwm") != "" {
+			return fmt.Errorf("invalid device %q: permissions must be made of r, w and m", device)
+		}
+	}
+	for _, capability := range append(append([]string{}, c.CapAdd...), c.CapDrop...) {
+		if !capabilityPattern.MatchString(capability) {
+			return fmt.Errorf("invalid capability %q, use e.g. NET_ADMIN", capability)
+		}
+	}
+	for key, value := range c.Sysctls {
+		if !sysctlPattern.MatchString(key) {
+			return fmt.Errorf("invalid sysctl %q, use e.g. net.ipv4.ip_forward", key)
+		}
+		if value == "" {
+			return fmt.Errorf("sysctl %s needs a value", key)
+		}
+	}
+	if c.ShmSize != "" {
+		if size, err := units.RAMInBytes(c.ShmSize); err != nil || size <= 0 {
+			return fmt.Errorf("invalid shm size %q, use e.g. 256m", c.ShmSize)
+		}
+	}
+	if _, _, err := c.GPURequest(); err != nil {
+		return err
+	}
+	if c.Runtime != "" && !runtimePattern.MatchString(c.Runtime) {
+		return fmt.Errorf("invalid runtime %q, use e.g. nvidia", c.Runtime)
+	}
+	return nil
+}
+
+// GPURequest returns how many GPUs the container gets, -1 for all of them,
+// or which ones by ID. Both are empty when it gets none.
+func (c *DeployConfig) GPURequest() (int, []string, error) {
+	switch {
+	case c.GPUs == "":
+		return 0, nil, nil
+	case c.GPUs == "all":
+		return -1, nil, nil
+	case strings.HasPrefix(c.GPUs, "device="):
+		ids := strings.TrimPrefix(c.GPUs, "device=")
+		if !gpuDevicePattern.MatchString(ids) {
+			return 0, nil, fmt.Errorf("invalid GPU devices %q, use e.g. device=0,1", c.GPUs)
+		}
+		return 0, strings.Split(ids, ","), nil
+	}
+	count, err := strconv.Atoi(c.GPUs)
+	if err != nil || count < 1 {
+		return 0, nil, fmt.Errorf("invalid GPUs %q, use all, a count or device=0,1", c.GPUs)
+	}
+	return count, nil, nil
+}
+
+// HasAdvanced reports whether any devices, capabilities, sysctls, shm size,
+// GPUs or runtime are set
+func (c *DeployConfig) HasAdvanced() bool {
+	return len(c.Devices) > 0 || len(c.CapAdd) > 0 || len(c.CapDrop) > 0 || len(c.Sysctls) > 0 || c.ShmSize != "" ||
+		c.GPUs != "" || c.Runtime != ""
+}
+
+// GetShmSize returns the size of /dev/shm in bytes, or 0 for Docker's
+// default
+func (c *DeployConfig) GetShmSize() int64 {
+	size, _ := units.RAMInBytes(c.ShmSize)
+	return size
+}
+
+// isZero reports whether nothing is set, so the app runs with the defaults
+func (c *DeployConfig) isZero() bool {
+	return c.Mode == "" && c.Replicas == 0 && c.UpdateParallelism == 0 && c.UpdateDelay == "" &&
+		c.UpdateOrder == "" && !c.HasAdvanced()
+}
+
+// GetDeployConfig parses the app's deploy settings. Apps without any run as
+// a single container.
+func (a *App) GetDeployConfig() (*DeployConfig, error) {
+	cfg := &DeployConfig{}
+	if len(a.DeployConfig) == 0 {
+		return cfg, nil
+	}
+	if err := json.Unmarshal(a.DeployConfig, cfg); err != nil {
+		return nil, fmt.Errorf("invalid deploy config: %w", err)
+	}
+	return cfg, nil
+}
+
+// SetDeployConfig stores the app's deploy settings
+func (a *App) SetDeployConfig(cfg *DeployConfig) error {
+	if cfg == nil || cfg.isZero() {
+		a.DeployConfig = nil
+		return nil
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	a.DeployConfig = NullRawMessage(data)
+	return nil
+}
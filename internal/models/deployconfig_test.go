@@ -0,0 +1,120 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeployConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     DeployConfig
+		wantErr bool
+	}{
+		{"empty", DeployConfig{}, false},
+		{"container", DeployConfig{Mode: DeployModeContainer}, false},
+		{"swarm", DeployConfig{Mode: DeployModeSwarm, Replicas: 3, UpdateParallelism: 1, UpdateDelay: "10s", UpdateOrder: UpdateOrderStartFirst}, false},
+		{"unknown mode", DeployConfig{Mode: "kubernetes"}, true},
+		{"negative replicas", DeployConfig{Mode: DeployModeSwarm, Replicas: -1}, true},
+		{"too many replicas", DeployConfig{Mode: DeployModeSwarm, Replicas: 500}, true},
+		{"bad delay", DeployConfig{Mode: DeployModeSwarm, UpdateDelay: "soon"}, true},
+		{"negative delay", DeployConfig{Mode: DeployModeSwarm, UpdateDelay: "-5s"}, true},
+		{"bad order", DeployConfig{Mode: DeployModeSwarm, UpdateOrder: "random"}, true},
+		{"advanced", DeployConfig{Devices: []string{"/dev/dri", "/dev/ttyUSB0:/dev/zigbee:rw"}, CapAdd: []string{"NET_ADMIN", "cap_sys_time"}, CapDrop: []string{"ALL"}, Sysctls: map[string]string{"net.ipv4.ip_forward": "1"}, ShmSize: "256m"}, false},
+		{"relative device", DeployConfig{Devices: []string{"dri"}}, true},
+		{"bad device permissions", DeployConfig{Devices: []string{"/dev/dri:/dev/dri:rx"}}, true},
+		{"bad capability", DeployConfig{CapAdd: []string{"NET ADMIN"}}, true},
+		{"bad sysctl", DeployConfig{Sysctls: map[string]string{"forward": "1"}}, true},
+		{"sysctl without value", DeployConfig{Sysctls: map[string]string{"net.ipv4.ip_forward": ""}}, true},
+		{"bad shm size", DeployConfig{ShmSize: "lots"}, true},
+		{"all gpus", DeployConfig{GPUs: "all", Runtime: "nvidia"}, false},
+		{"gpu devices", DeployConfig{GPUs: "device=0,GPU-3a5b"}, false},
+		{"zero gpus", DeployConfig{GPUs: "0"}, true},
+		{"bad gpu devices", DeployConfig{GPUs: "device="}, true},
+		{"bad runtime", DeployConfig{Runtime: "Nvidia Container"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeployConfig_Defaults(t *testing.T) {
+	cfg := &DeployConfig{Mode: DeployModeSwarm}
+	if cfg.GetReplicas() != 1 || cfg.GetUpdateParallelism() != 1 {
+		t.Errorf("replicas, parallelism = %d, %d, want 1, 1", cfg.GetReplicas(), cfg.GetUpdateParallelism())
+	}
+	if cfg.GetUpdateOrder() != UpdateOrderStopFirst || cfg.GetUpdateDelay() != 0 {
+		t.Errorf("order, delay = %q, %s, want stop-first, 0", cfg.GetUpdateOrder(), cfg.GetUpdateDelay())
+	}
+
+	cfg.UpdateDelay = "1m30s"
+	if cfg.GetUpdateDelay() != 90*time.Second {
+		t.Errorf("GetUpdateDelay() = %s, want 1m30s", cfg.GetUpdateDelay())
+	}
+}
+
+func TestApp_DeployConfig(t *testing.T) {
+	app := &App{}
+	cfg, err := app.GetDeployConfig()
+	if err != nil || cfg.IsSwarm() {
+		t.Fatalf("GetDeployConfig() of a new app = %+v, %v, want container mode", cfg, err)
+	}
+
+	if err := app.SetDeployConfig(&DeployConfig{Mode: DeployModeSwarm, Replicas: 3}); err != nil {
+		t.Fatalf("SetDeployConfig() error = %v", err)
+	}
+	cfg, err = app.GetDeployConfig()
+	if err != nil || !cfg.IsSwarm() || cfg.Replicas != 3 {
+		t.Errorf("GetDeployConfig() = %+v, %v, want swarm with 3 replicas", cfg, err)
+	}
+
+	if err := app.SetDeployConfig(&DeployConfig{ShmSize: "1g", Devices: []string{"/dev/dri"}}); err != nil {
+		t.Fatalf("SetDeployConfig() error = %v", err)
+	}
+	cfg, err = app.GetDeployConfig()
+	if err != nil || cfg.IsSwarm() || cfg.GetShmSize() != 1<<30 || len(cfg.Devices) != 1 {
+		t.Errorf("GetDeployConfig() = %+v, %v, want a container with /dev/dri and 1g of shm", cfg, err)
+	}
+
+	if err := app.SetDeployConfig(&DeployConfig{Sysctls: map[string]string{}}); err != nil || app.DeployConfig != nil {
+		t.Errorf("SetDeployConfig(empty sysctls) left %s, %v, want NULL", app.DeployConfig, err)
+	}
+	if err := app.SetDeployConfig(&DeployConfig{}); err != nil || app.DeployConfig != nil {
+		t.Errorf("SetDeployConfig(empty) left %s, %v, want NULL", app.DeployConfig, err)
+	}
+
+	app.DeployConfig = NullRawMessage(`{"mode":`)
+	if _, err := app.GetDeployConfig(); err == nil {
+		t.Error("GetDeployConfig() with invalid JSON error = nil")
+	}
+}
+
+func TestDeployConfig_GPURequest(t *testing.T) {
+	tests := []struct {
+		gpus      string
+		wantCount int
+		wantIDs   []string
+	}{
+		{"", 0, nil},
+		{"all", -1, nil},
+		{"2", 2, nil},
+		{"device=0,2", 0, []string{"0", "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.gpus, func(t *testing.T) {
+			cfg := &DeployConfig{GPUs: tt.gpus}
+			count, ids, err := cfg.GPURequest()
+			if err != nil || count != tt.wantCount || strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Errorf("GPURequest() = %d, %v, %v, want %d, %v", count, ids, err, tt.wantCount, tt.wantIDs)
+			}
+		})
+	}
+}
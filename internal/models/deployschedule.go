@@ -0,0 +1,366 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// freezeTimeLayout is how the start and end of a freeze period are written,
+// in the schedule's timezone
+const freezeTimeLayout = "2006-01-02 15:04"
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// DeployWindow is a recurring time of the week deploys may go out in,
+// written like "mon-fri 09:00-17:00". A window ending before it starts runs
+// past midnight into the next day.
+type DeployWindow struct {
+	Days  [7]bool // Indexed by time.Weekday
+	Start int     // Minutes after midnight
+	End   int     // Minutes after midnight, up to 24:00
+}
+
+// ParseDeployWindow parses a window like "09:00-17:00" (every day),
+// "mon-fri 09:00-17:00", "sat,sun 10:00-12:00", "weekdays 22:00-02:00" or
+// "daily 06:00-08:00"
+func ParseDeployWindow(s string) (DeployWindow, error) {
+	var w DeployWindow
+	fields := strings.Fields(s)
+	days, times := "daily", ""
+	switch len(fields) {
+	case 1:
+		times = fields[0]
+	case 2:
+		days, times = strings.ToLower(fields[0]), fields[1]
+	default:
+		return w, fmt.Errorf("invalid deploy window %q, use e.g. mon-fri 09:00-17:00", s)
+	}
+
+	if err := w.parseDays(days); err != nil {
+		return w, fmt.Errorf("invalid deploy window %q: %w", s, err)
+	}
+
+	start, end, ok := strings.Cut(times, "-")
+	if !ok {
+		return w, fmt.Errorf("invalid deploy window %q, times must look like 09:00-17:00", s)
+	}
+	var err error
+	if w.Start, err = parseClock(start); err != nil || w.Start == 24*60 {
+		return w, fmt.Errorf("invalid deploy window %q: bad start time %q", s, start)
+	}
+	if w.End, err = parseClock(end); err != nil {
+		return w, fmt.Errorf("invalid deploy window %q: bad end time %q", s, end)
+	}
+	if w.Start == w.End {
+		return w, fmt.Errorf("invalid deploy window %q: start and end are the same", s)
+	}
+	return w, nil
+}
+
+func (w *DeployWindow) parseDays(days string) error {
+	switch days {
+	case "daily":
+		days = "sun-sat"
+	case "weekdays":
+		days = "mon-fri"
+	case "weekends":
+		days = "sat,sun"
+	}
+	for _, part := range strings.Split(days, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, last := weekdayIndex(from), weekdayIndex(to)
+		if !isRange {
+			last = first
+		}
+		if first < 0 || last < 0 {
+			return fmt.Errorf("unknown days %q", part)
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			w.Days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func weekdayIndex(name string) int {
+	for i, n := range weekdayNames {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseClock parses a time of day like 09:30 into minutes after midnight
+func parseClock(s string) (int, error) {
+	hours, minutes, ok := strings.Cut(s, ":")
+	h, herr := strconv.Atoi(hours)
+	m, merr := strconv.Atoi(minutes)
+	if !ok || herr != nil || merr != nil || len(minutes) != 2 || h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return h*60 + m, nil
+}
+
+// String writes the window the way ParseDeployWindow reads it
+func (w DeployWindow) String() string {
+	clock := func(m int) string { return fmt.Sprintf("%02d:%02d", m/60, m%60) }
+	times := clock(w.Start) + "-" + clock(w.End)
+	if w.Days == [7]bool{true, true, true, true, true, true, true} {
+		return "daily " + times
+	}
+
+	// Write runs of days as ranges, starting the week on Monday
+	var parts []string
+	for i := 0; i < 7; {
+		d := (i + 1) % 7
+		if !w.Days[d] {
+			i++
+			continue
+		}
+		j := i
+		for j+1 < 7 && w.Days[(j+2)%7] {
+			j++
+		}
+		if j > i {
+			parts = append(parts, weekdayNames[d]+"-"+weekdayNames[(j+1)%7])
+		} else {
+			parts = append(parts, weekdayNames[d])
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",") + " " + times
+}
+
+// MarshalText writes the window as a string in JSON
+func (w DeployWindow) MarshalText() ([]byte, error) {
+	return []byte(w.String()), nil
+}
+
+// UnmarshalText parses a window from a string in JSON
+func (w *DeployWindow) UnmarshalText(text []byte) error {
+	parsed, err := ParseDeployWindow(string(text))
+	if err != nil {
+		return err
+	}
+	*w = parsed
+	return nil
+}
+
+// contains reports whether a local time falls in the window
+func (w DeployWindow) contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	day := int(t.Weekday())
+	if w.Start < w.End {
+		return w.Days[day] && m >= w.Start && m < w.End
+	}
+	return (w.Days[day] && m >= w.Start) || (w.Days[(day+6)%7] && m < w.End)
+}
+
+// FreezePeriod is a stretch of time no deploys go out in, such as a
+// holiday freeze
+type FreezePeriod struct {
+	Start  string `json:"start"` // 2006-01-02 15:04, in the schedule's timezone
+	End    string `json:"end"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// DeploySchedule limits when builds triggered by a push deploy: only in its
+// windows, if it has any, and never during a freeze
+type DeploySchedule struct {
+	Timezone string         `json:"timezone,omitempty"` // IANA name, empty for the server's
+	Windows  []DeployWindow `json:"windows,omitempty"`
+	Freezes  []FreezePeriod `json:"freezes,omitempty"`
+}
+
+// IsEmpty reports whether the schedule allows deploys at any time
+func (s *DeploySchedule) IsEmpty() bool {
+	return s == nil || (len(s.Windows) == 0 && len(s.Freezes) == 0)
+}
+
+// Validate checks the timezone and freeze periods. Windows are checked as
+// they are parsed.
+func (s *DeploySchedule) Validate() error {
+	loc, err := s.location()
+	if err != nil {
+		return err
+	}
+	for _, f := range s.Freezes {
+		start, end, err := f.times(loc)
+		if err != nil {
+			return err
+		}
+		if !end.After(start) {
+			return fmt.Errorf("freeze %s must end after it starts", f.Start)
+		}
+	}
+	return nil
+}
+
+func (s *DeploySchedule) location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", s.Timezone)
+	}
+	return loc, nil
+}
+
+func (f FreezePeriod) times(loc *time.Location) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation(freezeTimeLayout, f.Start, loc)
+	if err != nil {
+		return start, start, fmt.Errorf("invalid freeze start %q, use e.g. 2024-12-20 18:00", f.Start)
+	}
+	end, err := time.ParseInLocation(freezeTimeLayout, f.End, loc)
+	if err != nil {
+		return start, end, fmt.Errorf("invalid freeze end %q, use e.g. 2025-01-02 09:00", f.End)
+	}
+	return start, end, nil
+}
+
+// frozen returns the freeze period t falls in, if any
+func (s *DeploySchedule) frozen(t time.Time) (FreezePeriod, time.Time, bool) {
+	loc, err := s.location()
+	if err != nil {
+		return FreezePeriod{}, time.Time{}, false
+	}
+	for _, f := range s.Freezes {
+		start, end, err := f.times(loc)
+		if err == nil && !t.Before(start) && t.Before(end) {
+			return f, end, true
+		}
+	}
+	return FreezePeriod{}, time.Time{}, false
+}
+
+// inWindow reports whether t falls in one of the schedule's windows
+func (s *DeploySchedule) inWindow(t time.Time) bool {
+	loc, err := s.location()
+	if err != nil {
+		return true
+	}
+	t = t.In(loc)
+	for _, w := range s.Windows {
+		if w.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeployHold returns why a deploy can't go out at now under the global
+// schedule and the app's own, or an empty string if it can, along with the
+// next time it can (zero if there's none in sight). Freezes of both apply;
+// the app's windows replace the global ones.
+func DeployHold(now time.Time, global, app *DeploySchedule) (string, time.Time) {
+	schedules := []*DeploySchedule{}
+	for _, s := range []*DeploySchedule{global, app} {
+		if !s.IsEmpty() {
+			schedules = append(schedules, s)
+		}
+	}
+	windows := global
+	if app != nil && len(app.Windows) > 0 {
+		windows = app
+	}
+
+	blocked := func(t time.Time) string {
+		for _, s := range schedules {
+			if f, _, ok := s.frozen(t); ok {
+				if f.Reason != "" {
+					return "deploy freeze: " + f.Reason
+				}
+				return "deploy freeze until " + f.End
+			}
+		}
+		if windows != nil && len(windows.Windows) > 0 && !windows.inWindow(t) {
+			return "outside the deploy windows"
+		}
+		return ""
+	}
+
+	reason := blocked(now)
+	if reason == "" {
+		return "", now
+	}
+
+	// Deploys open up again when a freeze ends or a window starts, so only
+	// those moments need checking
+	bases := []time.Time{now}
+	for _, s := range schedules {
+		loc, err := s.location()
+		if err != nil {
+			continue
+		}
+		for _, f := range s.Freezes {
+			if _, end, err := f.times(loc); err == nil && end.After(now) {
+				bases = append(bases, end)
+			}
+		}
+	}
+	candidates := append([]time.Time{}, bases[1:]...)
+	if windows != nil && len(windows.Windows) > 0 {
+		loc, _ := windows.location()
+		for _, base := range bases {
+			local := base.In(loc)
+			for day := -1; day <= 8; day++ {
+				date := local.AddDate(0, 0, day)
+				for _, w := range windows.Windows {
+					if !w.Days[date.Weekday()] {
+						continue
+					}
+					start := time.Date(date.Year(), date.Month(), date.Day(), w.Start/60, w.Start%60, 0, 0, loc)
+					if start.After(now) {
+						candidates = append(candidates, start)
+					}
+				}
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+	for _, c := range candidates {
+		if blocked(c) == "" {
+			return reason, c
+		}
+	}
+	return reason, time.Time{}
+}
+
+// GetDeploySchedule parses the app's own deploy windows and freezes, or
+// returns nil if it has none
+func (a *App) GetDeploySchedule() (*DeploySchedule, error) {
+	if len(a.DeploySchedule) == 0 {
+		return nil, nil
+	}
+	schedule := &DeploySchedule{}
+	if err := json.Unmarshal(a.DeploySchedule, schedule); err != nil {
+		return nil, fmt.Errorf("invalid deploy schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+// SetDeploySchedule stores the app's deploy windows and freezes
+func (a *App) SetDeploySchedule(schedule *DeploySchedule) error {
+	if schedule.IsEmpty() {
+		a.DeploySchedule = nil
+		return nil
+	}
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return err
+	}
+	a.DeploySchedule = NullRawMessage(data)
+	return nil
+}
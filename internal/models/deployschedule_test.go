@@ -0,0 +1,124 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseDeployWindow(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string // String() of the parsed window
+		wantErr bool
+	}{
+		{input: "09:00-17:00", want: "daily 09:00-17:00"},
+		{input: "mon-fri 09:00-17:00", want: "mon-fri 09:00-17:00"},
+		{input: "weekdays 22:00-02:00", want: "mon-fri 22:00-02:00"},
+		{input: "Sat,SUN 10:00-12:30", want: "sat-sun 10:00-12:30"},
+		{input: "fri-mon 00:00-24:00", want: "mon,fri-sun 00:00-24:00"},
+		{input: "mon,wed,fri 08:00-09:00", want: "mon,wed,fri 08:00-09:00"},
+		{input: "mon-fri", wantErr: true},
+		{input: "someday 09:00-17:00", wantErr: true},
+		{input: "mon 9-17", wantErr: true},
+		{input: "mon 09:00-25:00", wantErr: true},
+		{input: "mon 24:00-01:00", wantErr: true},
+		{input: "mon 09:00-09:00", wantErr: true},
+		{input: "mon tue 09:00-17:00", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			w, err := ParseDeployWindow(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDeployWindow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && w.String() != tt.want {
+				t.Errorf("ParseDeployWindow().String() = %q, want %q", w.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDeployScheduleJSON(t *testing.T) {
+	var s DeploySchedule
+	input := `{"timezone":"Europe/Amsterdam","windows":["mon-fri 09:00-17:00"],"freezes":[{"start":"2024-12-20 18:00","end":"2025-01-02 09:00","reason":"Holidays"}]}`
+	if err := json.Unmarshal([]byte(input), &s); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	out, _ := json.Marshal(s)
+	if string(out) != input {
+		t.Errorf("Marshal() = %s, want %s", out, input)
+	}
+
+	if err := json.Unmarshal([]byte(`{"windows":["whenever"]}`), &s); err == nil {
+		t.Error("Unmarshal() of an invalid window succeeded")
+	}
+	bad := DeploySchedule{Freezes: []FreezePeriod{{Start: "2025-01-02 09:00", End: "2024-12-20 18:00"}}}
+	if err := bad.Validate(); err == nil {
+		t.Error("Validate() of a freeze ending before it starts succeeded")
+	}
+	bad = DeploySchedule{Timezone: "Mars/Olympus"}
+	if err := bad.Validate(); err == nil {
+		t.Error("Validate() of an unknown timezone succeeded")
+	}
+}
+
+func TestDeployHold(t *testing.T) {
+	windows := func(specs ...string) []DeployWindow {
+		var out []DeployWindow
+		for _, spec := range specs {
+			w, err := ParseDeployWindow(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, w)
+		}
+		return out
+	}
+	at := func(s string) time.Time {
+		parsed, err := time.Parse(freezeTimeLayout, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	office := &DeploySchedule{Timezone: "UTC", Windows: windows("mon-fri 09:00-17:00")}
+	nightly := &DeploySchedule{Timezone: "UTC", Windows: windows("22:00-02:00")}
+	holidays := &DeploySchedule{Timezone: "UTC", Freezes: []FreezePeriod{{Start: "2024-12-20 18:00", End: "2025-01-06 09:00", Reason: "Holidays"}}}
+
+	tests := []struct {
+		name       string
+		now        string // 2024-12-16 is a Monday
+		global     *DeploySchedule
+		app        *DeploySchedule
+		wantReason string
+		wantNext   string
+	}{
+		{name: "no schedule", now: "2024-12-16 03:00", wantNext: "2024-12-16 03:00"},
+		{name: "in window", now: "2024-12-16 10:00", global: office, wantNext: "2024-12-16 10:00"},
+		{name: "before window", now: "2024-12-16 07:30", global: office, wantReason: "outside the deploy windows", wantNext: "2024-12-16 09:00"},
+		{name: "friday evening", now: "2024-12-13 17:00", global: office, wantReason: "outside the deploy windows", wantNext: "2024-12-16 09:00"},
+		{name: "app windows replace global", now: "2024-12-16 23:00", global: office, app: nightly, wantNext: "2024-12-16 23:00"},
+		{name: "overnight window after midnight", now: "2024-12-17 01:00", app: nightly, wantNext: "2024-12-17 01:00"},
+		{name: "freeze", now: "2024-12-23 10:00", global: holidays, wantReason: "deploy freeze: Holidays", wantNext: "2025-01-06 09:00"},
+		{name: "freeze then window", now: "2024-12-23 10:00", global: holidays, app: nightly, wantReason: "deploy freeze: Holidays", wantNext: "2025-01-06 22:00"},
+		{name: "app freeze with global windows", now: "2024-12-23 10:00", global: office, app: holidays, wantReason: "deploy freeze: Holidays", wantNext: "2025-01-06 09:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, next := DeployHold(at(tt.now), tt.global, tt.app)
+			if reason != tt.wantReason {
+				t.Errorf("DeployHold() reason = %q, want %q", reason, tt.wantReason)
+			}
+			if !next.Equal(at(tt.wantNext)) {
+				t.Errorf("DeployHold() next = %s, want %s", next, tt.wantNext)
+			}
+		})
+	}
+}
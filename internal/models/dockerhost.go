@@ -0,0 +1,60 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// dockerHostNamePattern keeps host names short and readable in the UI
+var dockerHostNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// DockerHost is a remote Docker daemon apps can be deployed to instead of
+// the one Schooner runs next to
+type DockerHost struct {
+	ID          string    `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	URL         string    `db:"url" json:"url"`                     // tcp://host:2376 or ssh://user@host[:port]
+	TLSCertPath string    `db:"tls_cert_path" json:"tls_cert_path"` // Directory with ca.pem, cert.pem and key.pem, for tcp
+	SSHKeyPath  string    `db:"ssh_key_path" json:"ssh_key_path"`   // Private key file, for ssh
+	SSHHostKey  string    `db:"ssh_host_key" json:"ssh_host_key"`   // Pinned host key, empty to check known_hosts
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+// IsSSH reports whether the daemon is reached over SSH
+func (h *DockerHost) IsSSH() bool {
+	u, err := url.Parse(h.URL)
+	return err == nil && u.Scheme == "ssh"
+}
+
+// Validate checks the host's name and that its URL comes with the
+// credentials its scheme needs. Plain tcp without TLS is refused, it would
+// hand the daemon to anyone on the network.
+func (h *DockerHost) Validate() error {
+	if !dockerHostNamePattern.MatchString(h.Name) {
+		return fmt.Errorf("name must be lowercase letters, digits and dashes (max 32)")
+	}
+
+	u, err := url.Parse(h.URL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid Docker host URL %q", h.URL)
+	}
+
+	switch u.Scheme {
+	case "tcp":
+		if h.TLSCertPath == "" {
+			return fmt.Errorf("tcp hosts need a TLS certificate directory")
+		}
+	case "ssh":
+		if u.User == nil || u.User.Username() == "" {
+			return fmt.Errorf("ssh hosts need a user, e.g. ssh://deploy@example.com")
+		}
+		if h.SSHKeyPath == "" {
+			return fmt.Errorf("ssh hosts need a private key path")
+		}
+	default:
+		return fmt.Errorf("Docker host URL must start with tcp:// or ssh://")
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package models
+
+import "testing"
+
+func TestDockerHost_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    DockerHost
+		wantErr bool
+	}{
+		{"tcp with tls", DockerHost{Name: "builder", URL: "tcp://10.0.0.5:2376", TLSCertPath: "/certs/builder"}, false},
+		{"ssh", DockerHost{Name: "vps-1", URL: "ssh://deploy@vps.example.com", SSHKeyPath: "/keys/id_ed25519"}, false},
+		{"ssh with port", DockerHost{Name: "vps", URL: "ssh://deploy@vps.example.com:2222", SSHKeyPath: "/keys/id"}, false},
+		{"tcp without tls", DockerHost{Name: "builder", URL: "tcp://10.0.0.5:2375"}, true},
+		{"ssh without user", DockerHost{Name: "vps", URL: "ssh://vps.example.com", SSHKeyPath: "/keys/id"}, true},
+		{"ssh without key", DockerHost{Name: "vps", URL: "ssh://deploy@vps.example.com"}, true},
+		{"unix", DockerHost{Name: "local", URL: "unix:///var/run/docker.sock"}, true},
+		{"bad name", DockerHost{Name: "Big Box", URL: "tcp://10.0.0.5:2376", TLSCertPath: "/certs"}, true},
+		{"no host", DockerHost{Name: "x", URL: "tcp://", TLSCertPath: "/certs"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.host.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDockerHost_IsSSH(t *testing.T) {
+	if !(&DockerHost{URL: "ssh://deploy@vps"}).IsSSH() {
+		t.Error("ssh URL not detected")
+	}
+	if (&DockerHost{URL: "tcp://vps:2376"}).IsSSH() {
+		t.Error("tcp URL detected as ssh")
+	}
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// GetImageRefresh returns the cron schedule the app's images are refreshed
+// on, or empty string if they aren't
+func (a *App) GetImageRefresh() string {
+	if a.ImageRefresh.Valid {
+		return a.ImageRefresh.String
+	}
+	return ""
+}
+
+// SetImageRefresh checks and stores the schedule the app's images are
+// refreshed on. Only compose apps, and apps from templates or adopted
+// containers, run images that can be refreshed.
+func (a *App) SetImageRefresh(schedule string) error {
+	schedule = strings.TrimSpace(schedule)
+	if schedule == "" {
+		a.ImageRefresh = sql.NullString{}
+		return nil
+	}
+	if a.HasRepo() && a.BuildStrategy != BuildStrategyCompose && a.BuildStrategy != BuildStrategyAutodetect {
+		return fmt.Errorf("image refresh needs a compose app, %s apps build their own image", a.BuildStrategy)
+	}
+	if _, err := cron.ParseStandard(schedule); err != nil {
+		return fmt.Errorf("invalid image refresh schedule: %w", err)
+	}
+	a.ImageRefresh = sql.NullString{String: schedule, Valid: true}
+	return nil
+}
+
+// NextImageRefresh returns when the app's images are next refreshed after
+// the given time, or the zero time if they aren't
+func (a *App) NextImageRefresh(after time.Time) time.Time {
+	if a.GetImageRefresh() == "" {
+		return time.Time{}
+	}
+	schedule, err := cron.ParseStandard(a.GetImageRefresh())
+	if err != nil {
+		return time.Time{}
+	}
+	return schedule.Next(after)
+}
+
+// TracksLatest reports whether an image reference follows the latest tag,
+// explicitly or by having no tag, rather than a version or a digest
+func TracksLatest(ref string) bool {
+	if strings.Contains(ref, "@") {
+		return false
+	}
+	name := ref
+	if slash := strings.LastIndex(ref, "/"); slash >= 0 {
+		name = ref[slash+1:]
+	}
+	_, tag, tagged := strings.Cut(name, ":")
+	return !tagged || tag == "latest"
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestSetImageRefresh(t *testing.T) {
+	tests := []struct {
+		name     string
+		app      App
+		schedule string
+		want     string
+		wantErr  bool
+	}{
+		{"nightly", App{BuildStrategy: BuildStrategyCompose}, " 0 4 * * * ", "0 4 * * *", false},
+		{"descriptor", App{BuildStrategy: BuildStrategyCompose}, "@daily", "@daily", false},
+		{"off", App{BuildStrategy: BuildStrategyCompose, ImageRefresh: sql.NullString{String: "@daily", Valid: true}}, "", "", false},
+		{"template", App{BuildStrategy: BuildStrategyDockerfile, Template: sql.NullString{String: "uptime-kuma", Valid: true}}, "@daily", "@daily", false},
+		{"invalid", App{BuildStrategy: BuildStrategyCompose}, "nightly", "", true},
+		{"dockerfile", App{BuildStrategy: BuildStrategyDockerfile}, "@daily", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := tt.app
+			err := app.SetImageRefresh(tt.schedule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetImageRefresh() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && app.GetImageRefresh() != tt.want {
+				t.Errorf("GetImageRefresh() = %q, want %q", app.GetImageRefresh(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNextImageRefresh(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	app := &App{BuildStrategy: BuildStrategyCompose}
+	if next := app.NextImageRefresh(now); !next.IsZero() {
+		t.Errorf("NextImageRefresh() without a schedule = %v, want zero", next)
+	}
+
+	app.SetImageRefresh("0 4 * * *")
+	if next, want := app.NextImageRefresh(now), time.Date(2026, 3, 2, 4, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Errorf("NextImageRefresh() = %v, want %v", next, want)
+	}
+}
+
+func TestTracksLatest(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want bool
+	}{
+		{"nginx", true},
+		{"nginx:latest", true},
+		{"ghcr.io/acme/api:latest", true},
+		{"registry.local:5000/api", true},
+		{"postgres:16", false},
+		{"registry.local:5000/api:1.2", false},
+		{"nginx@sha256:abc", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			if got := TracksLatest(tt.ref); got != tt.want {
+				t.Errorf("TracksLatest(%q) = %v, want %v", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
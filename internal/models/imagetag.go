@@ -0,0 +1,91 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ImageTagStrategy decides the tag an app's images get
+type ImageTagStrategy string
+
+const (
+	ImageTagDefault ImageTagStrategy = ""       // The git tag built, otherwise the start of the build ID
+	ImageTagCommit  ImageTagStrategy = "commit" // The short commit SHA
+	ImageTagBranch  ImageTagStrategy = "branch" // <branch>-<short SHA>, with <branch>-latest moving along
+	ImageTagSemver  ImageTagStrategy = "semver" // The version of a vX.Y.Z git tag, with X.Y moving along
+	ImageTagDate    ImageTagStrategy = "date"   // When the build started, as YYYYMMDD-HHMMSS in UTC
+)
+
+// ImageTagStrategies lists the strategies an app can choose, in the order
+// they're offered
+var ImageTagStrategies = []ImageTagStrategy{ImageTagDefault, ImageTagCommit, ImageTagBranch, ImageTagSemver, ImageTagDate}
+
+// semverTag matches git tags that are semantic versions, optionally
+// prefixed with v
+var semverTag = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+// ParseImageTagStrategy validates the name of an image tag strategy
+func ParseImageTagStrategy(name string) (ImageTagStrategy, error) {
+	for _, s := range ImageTagStrategies {
+		if string(s) == name {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("invalid image tag strategy %q: must be commit, branch, semver, date or blank", name)
+}
+
+// Label returns how the strategy is described in the app form
+func (s ImageTagStrategy) Label() string {
+	switch s {
+	case ImageTagCommit:
+		return "Commit SHA"
+	case ImageTagBranch:
+		return "Branch and commit, plus branch-latest"
+	case ImageTagSemver:
+		return "Semver from git tag, plus major.minor"
+	case ImageTagDate:
+		return "Build date"
+	}
+	return "Git tag or build ID"
+}
+
+// Tags returns the tag the build's image gets under the strategy, and a
+// moving tag that points at the latest image too, empty if the strategy has
+// none. A strategy that can't tag the build, such as semver for a build of
+// a branch, falls back to the default.
+func (s ImageTagStrategy) Tags(b *Build) (tag, alias string) {
+	sha := b.GetShortSHA()
+	switch s {
+	case ImageTagCommit:
+		if sha != "" {
+			return sha, ""
+		}
+	case ImageTagBranch:
+		if branch := dockerTag(b.GetBranch()); branch != "" && sha != "" {
+			return dockerTag(branch + "-" + sha), dockerTag(branch + "-latest")
+		}
+	case ImageTagSemver:
+		if m := semverTag.FindStringSubmatch(b.GetTag()); m != nil {
+			version := dockerTag(strings.TrimPrefix(b.GetTag(), "v"))
+			if m[4] != "" {
+				return version, "" // Pre-releases don't move X.Y
+			}
+			return version, m[1] + "." + m[2]
+		}
+	case ImageTagDate:
+		return b.CreatedAt.UTC().Format("20060102-150405"), ""
+	}
+	return b.VersionTag(), ""
+}
+
+// dockerTag makes s usable as an image tag, or returns an empty string if
+// nothing of it is
+func dockerTag(s string) string {
+	tag := dockerTagUnsafe.ReplaceAllString(s, "-")
+	tag = strings.TrimLeft(tag, ".-")
+	if len(tag) > 128 {
+		tag = tag[:128]
+	}
+	return tag
+}
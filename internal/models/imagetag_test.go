@@ -0,0 +1,61 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestImageTagStrategy_Tags(t *testing.T) {
+	created := time.Date(2026, 3, 14, 9, 26, 53, 0, time.FixedZone("CET", 3600))
+	build := func(branch, tag string) *Build {
+		return &Build{
+			ID:        "abcdef12-3456",
+			CommitSHA: sql.NullString{String: "0123456789abcdef", Valid: true},
+			Branch:    sql.NullString{String: branch, Valid: branch != ""},
+			Tag:       sql.NullString{String: tag, Valid: tag != ""},
+			CreatedAt: created,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		strategy  ImageTagStrategy
+		build     *Build
+		wantTag   string
+		wantAlias string
+	}{
+		{"default", ImageTagDefault, build("main", ""), "abcdef12", ""},
+		{"default git tag", ImageTagDefault, build("", "v1.2.3"), "v1.2.3", ""},
+		{"commit", ImageTagCommit, build("main", ""), "01234567", ""},
+		{"commit without sha", ImageTagCommit, &Build{ID: "abcdef12-3456"}, "abcdef12", ""},
+		{"branch", ImageTagBranch, build("feature/login", ""), "feature-login-01234567", "feature-login-latest"},
+		{"branch of tag build", ImageTagBranch, build("", "v1.2.3"), "v1.2.3", ""},
+		{"semver", ImageTagSemver, build("", "v1.2.3"), "1.2.3", "1.2"},
+		{"semver pre-release", ImageTagSemver, build("", "v2.0.0-rc.1"), "2.0.0-rc.1", ""},
+		{"semver build metadata", ImageTagSemver, build("", "1.4.0+linux"), "1.4.0-linux", "1.4"},
+		{"semver of branch build", ImageTagSemver, build("main", ""), "abcdef12", ""},
+		{"semver of other tag", ImageTagSemver, build("", "release-7"), "release-7", ""},
+		{"date", ImageTagDate, build("main", ""), "20260314-082653", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag, alias := tt.strategy.Tags(tt.build)
+			if tag != tt.wantTag || alias != tt.wantAlias {
+				t.Errorf("Tags() = %q, %q, want %q, %q", tag, alias, tt.wantTag, tt.wantAlias)
+			}
+		})
+	}
+}
+
+func TestParseImageTagStrategy(t *testing.T) {
+	for _, name := range []string{"", "commit", "branch", "semver", "date"} {
+		if _, err := ParseImageTagStrategy(name); err != nil {
+			t.Errorf("ParseImageTagStrategy(%q) error = %v", name, err)
+		}
+	}
+	if _, err := ParseImageTagStrategy("latest"); err == nil {
+		t.Error("ParseImageTagStrategy(latest) should fail")
+	}
+}
@@ -22,6 +22,14 @@ const (
 	LogSourceSystem LogSource = "system"
 )
 
+// LogStream is the output stream a line of build output came from
+type LogStream string
+
+const (
+	LogStreamStdout LogStream = "stdout"
+	LogStreamStderr LogStream = "stderr"
+)
+
 // BuildLog represents a single log entry for a build
 type BuildLog struct {
 	ID        int64     `db:"id" json:"id"`
@@ -30,19 +38,22 @@ type BuildLog struct {
 	Level     LogLevel  `db:"level" json:"level"`
 	Message   string    `db:"message" json:"message"`
 	Source    LogSource `db:"source" json:"source,omitempty"`
+	Stream    LogStream `db:"stream" json:"stream,omitempty"`     // Empty for Schooner's own messages
+	Styles    LogStyles `db:"styles" json:"styles,omitempty"`     // Colors of the original output
+	Progress  string    `db:"progress" json:"progress,omitempty"` // See LogProgressKey
 }
 
 // Deployment represents a container deployment
 type Deployment struct {
-	ID            string    `db:"id" json:"id"`
-	AppID         string    `db:"app_id" json:"app_id"`
-	BuildID       string    `db:"build_id" json:"build_id,omitempty"`
-	ContainerID   string    `db:"container_id" json:"container_id,omitempty"`
-	ContainerName string    `db:"container_name" json:"container_name"`
-	ImageTag      string    `db:"image_tag" json:"image_tag"`
-	Status        string    `db:"status" json:"status"`
-	Ports         string    `db:"ports" json:"ports,omitempty"`
-	DeployedAt    time.Time `db:"deployed_at" json:"deployed_at"`
+	ID            string     `db:"id" json:"id"`
+	AppID         string     `db:"app_id" json:"app_id"`
+	BuildID       string     `db:"build_id" json:"build_id,omitempty"`
+	ContainerID   string     `db:"container_id" json:"container_id,omitempty"`
+	ContainerName string     `db:"container_name" json:"container_name"`
+	ImageTag      string     `db:"image_tag" json:"image_tag"`
+	Status        string     `db:"status" json:"status"`
+	Ports         string     `db:"ports" json:"ports,omitempty"`
+	DeployedAt    time.Time  `db:"deployed_at" json:"deployed_at"`
 	StoppedAt     *time.Time `db:"stopped_at" json:"stopped_at,omitempty"`
 }
 
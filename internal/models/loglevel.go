@@ -0,0 +1,43 @@
+package models
+
+import "regexp"
+
+var (
+	// buildkitLinePrefix is the step number and timestamp BuildKit puts in
+	// front of the output of a RUN instruction
+	buildkitLinePrefix = regexp.MustCompile(`^#\d+ \d+\.\d+ `)
+
+	// errorLinePatterns match lines that report an error
+	errorLinePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)^\s*(error|fatal|panic)\b`),
+		regexp.MustCompile(`\bERROR\b`),
+		regexp.MustCompile(`^--- FAIL\b|^FAIL\b`),
+		regexp.MustCompile(`npm ERR!|^E: `),
+		regexp.MustCompile(`Error response from daemon|did not complete successfully|exit code: [1-9]`),
+	}
+
+	// warnLinePatterns match lines that report a warning
+	warnLinePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)^\s*warn(ing)?\b`),
+		regexp.MustCompile(`\bWARN(ING)?\b`),
+		regexp.MustCompile(`(?i)\bdeprecated\b`),
+	}
+)
+
+// DetectLogLevel guesses the level of a line of build output from what it
+// says, since tools write errors, warnings and progress alike to stderr
+func DetectLogLevel(message string) LogLevel {
+	message = buildkitLinePrefix.ReplaceAllString(message, "")
+
+	for _, pattern := range errorLinePatterns {
+		if pattern.MatchString(message) {
+			return LogLevelError
+		}
+	}
+	for _, pattern := range warnLinePatterns {
+		if pattern.MatchString(message) {
+			return LogLevelWarn
+		}
+	}
+	return LogLevelInfo
+}
@@ -0,0 +1,32 @@
+package models
+
+import "testing"
+
+func TestDetectLogLevel(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected LogLevel
+	}{
+		{message: "Step 1/4 : FROM golang:1.24", expected: LogLevelInfo},
+		{message: "ERROR: Step build failed: exit status 1", expected: LogLevelError},
+		{message: "error: failed to solve: process did not complete successfully", expected: LogLevelError},
+		{message: "#12 3.412 main.go:10:2: undefined: foo", expected: LogLevelInfo},
+		{message: "#12 3.412 Error: Cannot find module 'express'", expected: LogLevelError},
+		{message: "fatal: repository not found", expected: LogLevelError},
+		{message: "--- FAIL: TestHandler (0.01s)", expected: LogLevelError},
+		{message: "npm ERR! code ELIFECYCLE", expected: LogLevelError},
+		{message: "Error response from daemon: pull access denied", expected: LogLevelError},
+		{message: "npm WARN deprecated request@2.88.2", expected: LogLevelWarn},
+		{message: "WARNING: The requested image's platform does not match", expected: LogLevelWarn},
+		{message: "ok  	example.com/app	0.012s", expected: LogLevelInfo},
+		{message: "0 errors, 0 failed", expected: LogLevelInfo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			if level := DetectLogLevel(tt.message); level != tt.expected {
+				t.Errorf("DetectLogLevel() = %s, want %s", level, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// MaintenanceTrigger is what started a Docker housekeeping run
+type MaintenanceTrigger string
+
+const (
+	MaintenanceScheduled    MaintenanceTrigger = "scheduled"
+	MaintenanceManual       MaintenanceTrigger = "manual"
+	MaintenanceDiskPressure MaintenanceTrigger = "disk_pressure"
+)
+
+// MaintenanceStatus is the state of a housekeeping run
+type MaintenanceStatus string
+
+const (
+	MaintenanceRunning MaintenanceStatus = "running"
+	MaintenanceSuccess MaintenanceStatus = "success"
+	MaintenanceFailed  MaintenanceStatus = "failed"
+)
+
+// MaintenanceRun records what a housekeeping run removed from the Docker
+// daemon and how much space it freed
+type MaintenanceRun struct {
+	ID              string             `db:"id" json:"id"`
+	Trigger         MaintenanceTrigger `db:"triggered_by" json:"trigger"`
+	Status          MaintenanceStatus  `db:"status" json:"status"`
+	ImagesRemoved   int                `db:"images_removed" json:"images_removed"`
+	VolumesRemoved  int                `db:"volumes_removed" json:"volumes_removed"`
+	NetworksRemoved int                `db:"networks_removed" json:"networks_removed"`
+	SpaceReclaimed  int64              `db:"space_reclaimed" json:"space_reclaimed"` // Bytes
+	Error           sql.NullString     `db:"error" json:"error,omitempty"`
+	StartedAt       time.Time          `db:"started_at" json:"started_at"`
+	FinishedAt      sql.NullTime       `db:"finished_at" json:"finished_at,omitempty"`
+}
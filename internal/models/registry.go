@@ -0,0 +1,55 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// RegistryCredential is the login images are pushed to a registry with
+type RegistryCredential struct {
+	Registry string `json:"registry"` // Host, with its port if it isn't 443
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+}
+
+// Validate checks the credential names a registry host and a login
+func (c *RegistryCredential) Validate() error {
+	c.Registry = strings.TrimSpace(c.Registry)
+	if c.Registry == "" || strings.ContainsAny(c.Registry, "/ ") {
+		return fmt.Errorf("registry must be a host such as registry.example.com")
+	}
+	if c.Username == "" || c.Password == "" {
+		return fmt.Errorf("username and password are required")
+	}
+	return nil
+}
+
+// ImageRegistry returns the registry host an image reference pushes to,
+// empty for Docker Hub images such as "myapp" or "library/nginx". As with
+// docker, the first part of the name is a host if it has a dot or a port or
+// is localhost.
+func ImageRegistry(image string) string {
+	host, _, ok := strings.Cut(image, "/")
+	if !ok {
+		return ""
+	}
+	if strings.ContainsAny(host, ".:") || host == "localhost" {
+		return host
+	}
+	return ""
+}
+
+// DockerConfig renders credentials as the config.json the docker CLI and
+// kaniko read logins from
+func DockerConfig(credentials []RegistryCredential) ([]byte, error) {
+	type auth struct {
+		Auth string `json:"auth"`
+	}
+	auths := make(map[string]auth, len(credentials))
+	for _, c := range credentials {
+		auths[c.Registry] = auth{Auth: base64.StdEncoding.EncodeToString([]byte(c.Username + ":" + c.Password))}
+	}
+	return json.Marshal(map[string]any{"auths": auths})
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestImageRegistry(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{image: "myapp", want: ""},
+		{image: "library/nginx", want: ""},
+		{image: "ghcr.io/example/web", want: "ghcr.io"},
+		{image: "registry.example.com:5000/web", want: "registry.example.com:5000"},
+		{image: "localhost/web", want: "localhost"},
+		{image: "localhost:5000/team/web", want: "localhost:5000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			if got := ImageRegistry(tt.image); got != tt.want {
+				t.Errorf("ImageRegistry(%q) = %q, want %q", tt.image, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryCredentialValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cred    RegistryCredential
+		wantErr bool
+	}{
+		{name: "valid", cred: RegistryCredential{Registry: " ghcr.io ", Username: "bot", Password: "secret"}},
+		{name: "no registry", cred: RegistryCredential{Username: "bot", Password: "secret"}, wantErr: true},
+		{name: "path in registry", cred: RegistryCredential{Registry: "ghcr.io/example", Username: "bot", Password: "secret"}, wantErr: true},
+		{name: "no password", cred: RegistryCredential{Registry: "ghcr.io", Username: "bot"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cred.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDockerConfig(t *testing.T) {
+	data, err := DockerConfig([]RegistryCredential{{Registry: "ghcr.io", Username: "bot", Password: "secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+	if got := config.Auths["ghcr.io"].Auth; got != "Ym90OnNlY3JldA==" {
+		t.Errorf("auth = %q, want base64 of bot:secret", got)
+	}
+}
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// ResourceResolution is how much time a resource sample covers
+type ResourceResolution string
+
+const (
+	ResourceResolutionMinute ResourceResolution = "minute"
+	ResourceResolutionHour   ResourceResolution = "hour"
+)
+
+// ResourceSample is the CPU and memory an app's containers used at one
+// point, or on average over an hour once samples are rolled up
+type ResourceSample struct {
+	ID           int64              `db:"id" json:"-"`
+	AppID        string             `db:"app_id" json:"-"`
+	Resolution   ResourceResolution `db:"resolution" json:"-"`
+	CPUPercent   float64            `db:"cpu_percent" json:"cpu_percent"`
+	MemoryUsage  uint64             `db:"memory_usage" json:"memory_usage"`
+	MemoryLimit  uint64             `db:"memory_limit" json:"memory_limit"`
+	RestartCount int                `db:"restart_count" json:"restart_count"`
+	SampledAt    time.Time          `db:"sampled_at" json:"time"`
+}
+
+// DownsampleResources averages samples ordered oldest first into one per
+// app and step. CPU and memory use are averaged, while the memory limit and
+// restart count are the highest seen. Each result is stamped with the start
+// of its step.
+func DownsampleResources(samples []*ResourceSample, step time.Duration) []*ResourceSample {
+	type bucket struct {
+		sample *ResourceSample
+		cpu    float64
+		memory uint64
+		count  int
+	}
+
+	var order []*bucket
+	buckets := make(map[string]*bucket)
+	for _, s := range samples {
+		start := s.SampledAt.Truncate(step)
+		key := s.AppID + "|" + start.Format(time.RFC3339)
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucket{sample: &ResourceSample{AppID: s.AppID, Resolution: s.Resolution, SampledAt: start}}
+			buckets[key] = b
+			order = append(order, b)
+		}
+		b.cpu += s.CPUPercent
+		b.memory += s.MemoryUsage
+		b.count++
+		b.sample.MemoryLimit = max(b.sample.MemoryLimit, s.MemoryLimit)
+		b.sample.RestartCount = max(b.sample.RestartCount, s.RestartCount)
+	}
+
+	downsampled := make([]*ResourceSample, 0, len(order))
+	for _, b := range order {
+		b.sample.CPUPercent = b.cpu / float64(b.count)
+		b.sample.MemoryUsage = b.memory / uint64(b.count)
+		downsampled = append(downsampled, b.sample)
+	}
+	return downsampled
+}
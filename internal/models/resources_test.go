@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDownsampleResources(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	samples := []*ResourceSample{
+		{AppID: "web", CPUPercent: 10, MemoryUsage: 100, MemoryLimit: 1000, SampledAt: start},
+		{AppID: "api", CPUPercent: 50, MemoryUsage: 500, SampledAt: start},
+		{AppID: "web", CPUPercent: 30, MemoryUsage: 300, MemoryLimit: 2000, RestartCount: 1, SampledAt: start.Add(30 * time.Minute)},
+		{AppID: "web", CPUPercent: 5, MemoryUsage: 50, SampledAt: start.Add(61 * time.Minute)},
+	}
+
+	got := DownsampleResources(samples, time.Hour)
+	if len(got) != 3 {
+		t.Fatalf("DownsampleResources() returned %d samples, want 3", len(got))
+	}
+
+	web := got[0]
+	if web.AppID != "web" || !web.SampledAt.Equal(start) {
+		t.Errorf("first sample = %s at %v, want web at %v", web.AppID, web.SampledAt, start)
+	}
+	if web.CPUPercent != 20 || web.MemoryUsage != 200 {
+		t.Errorf("averaged cpu %v and memory %d, want 20 and 200", web.CPUPercent, web.MemoryUsage)
+	}
+	if web.MemoryLimit != 2000 || web.RestartCount != 1 {
+		t.Errorf("memory limit %d and restarts %d, want the highest of 2000 and 1", web.MemoryLimit, web.RestartCount)
+	}
+
+	if got[1].AppID != "api" || got[1].CPUPercent != 50 {
+		t.Errorf("second sample = %+v, want api unchanged", got[1])
+	}
+	if next := got[2]; next.AppID != "web" || !next.SampledAt.Equal(start.Add(time.Hour)) {
+		t.Errorf("third sample = %s at %v, want web in the next hour", next.AppID, next.SampledAt)
+	}
+}
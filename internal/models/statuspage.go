@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// DefaultStatusPagePath is where the public status page is served by default
+const DefaultStatusPagePath = "/status"
+
+// reservedStatusPagePrefixes are routes the status page must not shadow
+var reservedStatusPagePrefixes = []string{
+	"/api", "/static", "/oauth", "/webhook", "/apps", "/builds",
+	"/settings", "/sessions", "/login", "/logs", "/health", "/metrics", "/logout",
+}
+
+var subdomainPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// StatusPageConfig controls the public, unauthenticated status page
+type StatusPageConfig struct {
+	Enabled   bool   `json:"enabled"`
+	Title     string `json:"title"`
+	Path      string `json:"path"`
+	Subdomain string `json:"subdomain"` // optional, served at / on this subdomain
+}
+
+// GetTitle returns the page title or a default
+func (c *StatusPageConfig) GetTitle() string {
+	if c.Title != "" {
+		return c.Title
+	}
+	return "Service Status"
+}
+
+// GetPath returns the page path or the default
+func (c *StatusPageConfig) GetPath() string {
+	if c.Path != "" {
+		return c.Path
+	}
+	return DefaultStatusPagePath
+}
+
+// Validate checks the path and subdomain are usable
+func (c *StatusPageConfig) Validate() error {
+	path := c.GetPath()
+	if !strings.HasPrefix(path, "/") || path == "/" {
+		return fmt.Errorf("path must start with / and cannot be the root")
+	}
+	if strings.ContainsAny(path, " ?#") || strings.HasSuffix(path, "/") {
+		return fmt.Errorf("path must not contain spaces, query strings or a trailing slash")
+	}
+	for _, prefix := range reservedStatusPagePrefixes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return fmt.Errorf("path %s conflicts with a built-in route", path)
+		}
+	}
+
+	if c.Subdomain != "" && !subdomainPattern.MatchString(c.Subdomain) {
+		return fmt.Errorf("subdomain must contain only lowercase letters, numbers and hyphens")
+	}
+
+	return nil
+}
+
+// MatchesHost reports whether a request host is the status page subdomain
+func (c *StatusPageConfig) MatchesHost(host string) bool {
+	if c.Subdomain == "" {
+		return false
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	label, _, _ := strings.Cut(strings.ToLower(host), ".")
+	return label == c.Subdomain
+}
@@ -0,0 +1,59 @@
+package models
+
+import "testing"
+
+func TestStatusPageConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  StatusPageConfig
+		wantErr bool
+	}{
+		{name: "defaults", config: StatusPageConfig{}, wantErr: false},
+		{name: "custom path", config: StatusPageConfig{Path: "/uptime"}, wantErr: false},
+		{name: "nested path", config: StatusPageConfig{Path: "/public/status"}, wantErr: false},
+		{name: "root", config: StatusPageConfig{Path: "/"}, wantErr: true},
+		{name: "relative", config: StatusPageConfig{Path: "status"}, wantErr: true},
+		{name: "trailing slash", config: StatusPageConfig{Path: "/status/"}, wantErr: true},
+		{name: "shadows api", config: StatusPageConfig{Path: "/api/status"}, wantErr: true},
+		{name: "shadows settings", config: StatusPageConfig{Path: "/settings"}, wantErr: true},
+		{name: "prefix of reserved is fine", config: StatusPageConfig{Path: "/apps-status"}, wantErr: false},
+		{name: "valid subdomain", config: StatusPageConfig{Subdomain: "status"}, wantErr: false},
+		{name: "invalid subdomain", config: StatusPageConfig{Subdomain: "Status.Page"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStatusPageConfig_MatchesHost(t *testing.T) {
+	cfg := StatusPageConfig{Subdomain: "status"}
+
+	tests := []struct {
+		host     string
+		expected bool
+	}{
+		{host: "status.example.com", expected: true},
+		{host: "STATUS.example.com", expected: true},
+		{host: "status.example.com:8080", expected: true},
+		{host: "schooner.example.com", expected: false},
+		{host: "mystatus.example.com", expected: false},
+		{host: "localhost:8080", expected: false},
+	}
+
+	for _, tt := range tests {
+		if got := cfg.MatchesHost(tt.host); got != tt.expected {
+			t.Errorf("MatchesHost(%q) = %v, want %v", tt.host, got, tt.expected)
+		}
+	}
+
+	empty := StatusPageConfig{}
+	if empty.MatchesHost("status.example.com") {
+		t.Error("MatchesHost() should be false without a subdomain")
+	}
+}
@@ -0,0 +1,109 @@
+package models
+
+import "time"
+
+// diskTrendMinSpan is how much history a disk trend needs before it's
+// worth extrapolating, and diskTrendMaxAhead how far ahead it's trusted
+const (
+	diskTrendMinSpan  = 6 * time.Hour
+	diskTrendMaxAhead = 365 * 24 * time.Hour
+)
+
+// SystemSample is the host's CPU, memory and disk use at one point, or on
+// average over an hour once samples are rolled up
+type SystemSample struct {
+	ID          int64              `db:"id" json:"-"`
+	Resolution  ResourceResolution `db:"resolution" json:"-"`
+	CPUPercent  float64            `db:"cpu_percent" json:"cpu_percent"`
+	LoadAvg1    float64            `db:"load_avg_1" json:"load_avg_1"`
+	MemoryUsed  uint64             `db:"memory_used" json:"memory_used"`
+	MemoryTotal uint64             `db:"memory_total" json:"memory_total"`
+	DiskUsed    uint64             `db:"disk_used" json:"disk_used"`
+	DiskTotal   uint64             `db:"disk_total" json:"disk_total"`
+	SampledAt   time.Time          `db:"sampled_at" json:"time"`
+}
+
+// DownsampleSystem averages samples ordered oldest first into one per step.
+// Use is averaged and totals are the highest seen. Each result is stamped
+// with the start of its step.
+func DownsampleSystem(samples []*SystemSample, step time.Duration) []*SystemSample {
+	var downsampled []*SystemSample
+	var cpu, load float64
+	var memory, disk uint64
+	count := 0
+
+	flush := func() {
+		if count == 0 {
+			return
+		}
+		last := downsampled[len(downsampled)-1]
+		last.CPUPercent = cpu / float64(count)
+		last.LoadAvg1 = load / float64(count)
+		last.MemoryUsed = memory / uint64(count)
+		last.DiskUsed = disk / uint64(count)
+		cpu, load, memory, disk, count = 0, 0, 0, 0, 0
+	}
+
+	for _, s := range samples {
+		start := s.SampledAt.Truncate(step)
+		if len(downsampled) == 0 || !downsampled[len(downsampled)-1].SampledAt.Equal(start) {
+			flush()
+			downsampled = append(downsampled, &SystemSample{Resolution: s.Resolution, SampledAt: start})
+		}
+		b := downsampled[len(downsampled)-1]
+		cpu += s.CPUPercent
+		load += s.LoadAvg1
+		memory += s.MemoryUsed
+		disk += s.DiskUsed
+		count++
+		b.MemoryTotal = max(b.MemoryTotal, s.MemoryTotal)
+		b.DiskTotal = max(b.DiskTotal, s.DiskTotal)
+	}
+	flush()
+
+	return downsampled
+}
+
+// PredictDiskFull fits a line through disk use in samples ordered oldest
+// first and returns how long after the last sample the disk fills up. It
+// returns false when disk use isn't growing, there's too little history,
+// or the disk won't fill within a year.
+func PredictDiskFull(samples []*SystemSample) (time.Duration, bool) {
+	if len(samples) < 2 {
+		return 0, false
+	}
+	first, last := samples[0], samples[len(samples)-1]
+	if last.SampledAt.Sub(first.SampledAt) < diskTrendMinSpan || last.DiskTotal == 0 {
+		return 0, false
+	}
+
+	// Least squares of bytes used against hours since the first sample
+	var sumX, sumY, sumXY, sumXX float64
+	n := float64(len(samples))
+	for _, s := range samples {
+		x := s.SampledAt.Sub(first.SampledAt).Hours()
+		y := float64(s.DiskUsed)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, false
+	}
+	bytesPerHour := (n*sumXY - sumX*sumY) / denominator
+	if bytesPerHour <= 0 {
+		return 0, false
+	}
+
+	// Start from the fitted value at the last sample so one noisy reading
+	// doesn't swing the estimate
+	intercept := (sumY - bytesPerHour*sumX) / n
+	used := intercept + bytesPerHour*last.SampledAt.Sub(first.SampledAt).Hours()
+	hours := (float64(last.DiskTotal) - used) / bytesPerHour
+	if hours > diskTrendMaxAhead.Hours() {
+		return 0, false
+	}
+	return time.Duration(max(hours, 0) * float64(time.Hour)), true
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDownsampleSystem(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	samples := []*SystemSample{
+		{CPUPercent: 10, MemoryUsed: 100, MemoryTotal: 1000, DiskUsed: 50, DiskTotal: 500, SampledAt: start},
+		{CPUPercent: 30, MemoryUsed: 300, MemoryTotal: 1000, DiskUsed: 70, DiskTotal: 600, SampledAt: start.Add(59 * time.Minute)},
+		{CPUPercent: 5, MemoryUsed: 50, SampledAt: start.Add(time.Hour)},
+	}
+
+	got := DownsampleSystem(samples, time.Hour)
+	if len(got) != 2 {
+		t.Fatalf("DownsampleSystem() returned %d samples, want 2", len(got))
+	}
+	if s := got[0]; s.CPUPercent != 20 || s.MemoryUsed != 200 || s.DiskUsed != 60 || s.DiskTotal != 600 || !s.SampledAt.Equal(start) {
+		t.Errorf("first hour = %+v, want averaged use and the highest total", s)
+	}
+	if s := got[1]; s.CPUPercent != 5 || !s.SampledAt.Equal(start.Add(time.Hour)) {
+		t.Errorf("second hour = %+v, want the one sample in it", s)
+	}
+}
+
+func TestPredictDiskFull(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	const gb = 1 << 30
+
+	// growing at perHour from used, sampled hourly for hours
+	series := func(used, perHour float64, hours int) []*SystemSample {
+		var samples []*SystemSample
+		for i := 0; i <= hours; i++ {
+			samples = append(samples, &SystemSample{
+				DiskUsed:  uint64(used + perHour*float64(i)),
+				DiskTotal: 100 * gb,
+				SampledAt: start.Add(time.Duration(i) * time.Hour),
+			})
+		}
+		return samples
+	}
+
+	tests := []struct {
+		name    string
+		samples []*SystemSample
+		want    time.Duration
+		wantOK  bool
+	}{
+		{name: "one GB a day with ten left", samples: series(80*gb, gb/24.0, 10*24), want: 10 * 24 * time.Hour, wantOK: true},
+		{name: "shrinking", samples: series(80*gb, -gb/24.0, 48), wantOK: false},
+		{name: "flat", samples: series(80*gb, 0, 48), wantOK: false},
+		{name: "too little history", samples: series(80*gb, gb, 2), wantOK: false},
+		{name: "more than a year out", samples: series(10*gb, 1024, 48), wantOK: false},
+		{name: "no samples", samples: nil, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := PredictDiskFull(tt.samples)
+			if ok != tt.wantOK {
+				t.Fatalf("PredictDiskFull() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && (got < tt.want-time.Hour || got > tt.want+time.Hour) {
+				t.Errorf("PredictDiskFull() = %v, want about %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package models
+
+// TwoFactorConfig holds the owner's TOTP enrollment. Secrets and recovery
+// code hashes are never serialized to API responses.
+type TwoFactorConfig struct {
+	Enabled            bool     `json:"enabled"`
+	Required           bool     `json:"required"` // login forces enrollment and 2FA can't be turned off
+	Secret             string   `json:"-"`
+	RecoveryCodeHashes []string `json:"-"`
+	LastCounter        int64    `json:"-"` // last accepted TOTP time step, to block replay
+}
+
+// RecoveryCodesRemaining returns how many unused recovery codes are left
+func (c *TwoFactorConfig) RecoveryCodesRemaining() int {
+	return len(c.RecoveryCodeHashes)
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// UptimeStatus is the last known availability of an app
+type UptimeStatus string
+
+const (
+	UptimeUnknown UptimeStatus = "unknown"
+	UptimeUp      UptimeStatus = "up"
+	UptimeDown    UptimeStatus = "down"
+)
+
+// UptimeCheck is an HTTP probe configured for an app
+type UptimeCheck struct {
+	ID              string         `db:"id" json:"id"`
+	AppID           string         `db:"app_id" json:"app_id"`
+	URL             sql.NullString `db:"url" json:"url"` // empty = app's public tunnel URL
+	IntervalSeconds int            `db:"interval_seconds" json:"interval_seconds"`
+	TimeoutSeconds  int            `db:"timeout_seconds" json:"timeout_seconds"`
+	ExpectedStatus  int            `db:"expected_status" json:"expected_status"` // 0 = any 2xx/3xx
+	Enabled         bool           `db:"enabled" json:"enabled"`
+	LastStatus      UptimeStatus   `db:"last_status" json:"last_status"`
+	LastCheckedAt   sql.NullTime   `db:"last_checked_at" json:"last_checked_at,omitempty"`
+	LastResponseMs  sql.NullInt64  `db:"last_response_ms" json:"last_response_ms,omitempty"`
+	CreatedAt       time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time      `db:"updated_at" json:"updated_at"`
+
+	// Joined fields (not in DB)
+	AppName string `db:"app_name" json:"app_name,omitempty"`
+}
+
+// GetURL returns the configured URL or empty string
+func (c *UptimeCheck) GetURL() string {
+	if c.URL.Valid {
+		return c.URL.String
+	}
+	return ""
+}
+
+// Interval returns the time between probes
+func (c *UptimeCheck) Interval() time.Duration {
+	return time.Duration(c.IntervalSeconds) * time.Second
+}
+
+// Timeout returns the probe request timeout
+func (c *UptimeCheck) Timeout() time.Duration {
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+// IsExpectedStatus reports whether an HTTP status code counts as up
+func (c *UptimeCheck) IsExpectedStatus(code int) bool {
+	if c.ExpectedStatus == 0 {
+		return code >= 200 && code < 400
+	}
+	return code == c.ExpectedStatus
+}
+
+// Validate checks the probe settings are within sane bounds
+func (c *UptimeCheck) Validate() error {
+	if c.IntervalSeconds < 10 {
+		return fmt.Errorf("interval must be at least 10 seconds")
+	}
+	if c.TimeoutSeconds < 1 || c.TimeoutSeconds > 60 {
+		return fmt.Errorf("timeout must be between 1 and 60 seconds")
+	}
+	if c.TimeoutSeconds >= c.IntervalSeconds {
+		return fmt.Errorf("timeout must be shorter than the interval")
+	}
+	if c.ExpectedStatus != 0 && (c.ExpectedStatus < 100 || c.ExpectedStatus > 599) {
+		return fmt.Errorf("expected status must be a valid HTTP status code")
+	}
+	return nil
+}
+
+// UptimeResult is a single probe outcome
+type UptimeResult struct {
+	ID         int64          `db:"id" json:"id"`
+	CheckID    string         `db:"check_id" json:"check_id"`
+	Up         bool           `db:"up" json:"up"`
+	StatusCode sql.NullInt64  `db:"status_code" json:"status_code,omitempty"`
+	ResponseMs int64          `db:"response_ms" json:"response_ms"`
+	Error      sql.NullString `db:"error" json:"error,omitempty"`
+	CheckedAt  time.Time      `db:"checked_at" json:"checked_at"`
+}
+
+// UptimeStats summarizes probe results over a window
+type UptimeStats struct {
+	Checks        int     `db:"checks" json:"checks"`
+	UpChecks      int     `db:"up_checks" json:"up_checks"`
+	AvgResponseMs float64 `db:"avg_response_ms" json:"avg_response_ms"`
+}
+
+// UptimePercent returns the share of successful probes, or 100 with no data
+func (s UptimeStats) UptimePercent() float64 {
+	if s.Checks == 0 {
+		return 100
+	}
+	return float64(s.UpChecks) / float64(s.Checks) * 100
+}
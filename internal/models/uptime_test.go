@@ -0,0 +1,60 @@
+package models
+
+import "testing"
+
+func TestUptimeCheck_IsExpectedStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected int
+		code     int
+		want     bool
+	}{
+		{name: "default accepts 200", expected: 0, code: 200, want: true},
+		{name: "default accepts 301", expected: 0, code: 301, want: true},
+		{name: "default rejects 404", expected: 0, code: 404, want: false},
+		{name: "default rejects 500", expected: 0, code: 500, want: false},
+		{name: "exact match", expected: 204, code: 204, want: true},
+		{name: "exact mismatch", expected: 204, code: 200, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &UptimeCheck{ExpectedStatus: tt.expected}
+			if got := c.IsExpectedStatus(tt.code); got != tt.want {
+				t.Errorf("IsExpectedStatus(%d) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUptimeCheck_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		check   UptimeCheck
+		wantErr bool
+	}{
+		{name: "valid", check: UptimeCheck{IntervalSeconds: 60, TimeoutSeconds: 10}, wantErr: false},
+		{name: "interval too short", check: UptimeCheck{IntervalSeconds: 5, TimeoutSeconds: 2}, wantErr: true},
+		{name: "timeout not below interval", check: UptimeCheck{IntervalSeconds: 10, TimeoutSeconds: 10}, wantErr: true},
+		{name: "timeout too long", check: UptimeCheck{IntervalSeconds: 300, TimeoutSeconds: 90}, wantErr: true},
+		{name: "bad expected status", check: UptimeCheck{IntervalSeconds: 60, TimeoutSeconds: 10, ExpectedStatus: 42}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.check.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUptimeStats_UptimePercent(t *testing.T) {
+	if got := (UptimeStats{}).UptimePercent(); got != 100 {
+		t.Errorf("UptimePercent() with no data = %v, want 100", got)
+	}
+	if got := (UptimeStats{Checks: 4, UpChecks: 3}).UptimePercent(); got != 75 {
+		t.Errorf("UptimePercent() = %v, want 75", got)
+	}
+}
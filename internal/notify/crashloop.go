@@ -0,0 +1,213 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"schooner/internal/docker"
+	"schooner/internal/events"
+	"schooner/internal/models"
+)
+
+// An app whose containers die crashLoopThreshold times within
+// crashLoopWindow is crash looping. It stops once they've stayed up for the
+// window.
+const (
+	crashLoopThreshold = 5
+	crashLoopWindow    = 10 * time.Minute
+	// A container that dies this soon after being killed was stopped, e.g.
+	// by a deploy, rather than crashing
+	stopGrace = time.Minute
+	// eventsRetry is how long to wait before resubscribing to Docker events
+	eventsRetry = 5 * time.Second
+)
+
+// ContainerEventSource streams lifecycle events of app containers
+type ContainerEventSource interface {
+	ContainerEvents(ctx context.Context) (<-chan docker.ContainerEvent, <-chan error)
+}
+
+// CrashLoop is an app whose containers keep dying
+type CrashLoop struct {
+	AppID        string    `json:"app_id"`
+	Deaths       int       `json:"deaths"` // Times its containers died within the window
+	LastExitCode int       `json:"last_exit_code"`
+	Since        time.Time `json:"since"` // When it was found crash looping
+	LastDiedAt   time.Time `json:"last_died_at"`
+}
+
+// CrashLoopDetector watches Docker events for app containers that keep
+// dying and notifies when an app starts crash looping
+type CrashLoopDetector struct {
+	dispatcher *Dispatcher
+	source     ContainerEventSource
+	events     *events.Bus
+	logger     *slog.Logger
+
+	mu     sync.Mutex
+	deaths map[string][]time.Time // By app ID, within the window
+	killed map[string]time.Time   // By container ID
+	loops  map[string]*CrashLoop  // By app ID
+}
+
+// NewCrashLoopDetector creates a detector reading events from source
+func NewCrashLoopDetector(dispatcher *Dispatcher, source ContainerEventSource) *CrashLoopDetector {
+	return &CrashLoopDetector{
+		dispatcher: dispatcher,
+		source:     source,
+		logger:     slog.Default(),
+		deaths:     make(map[string][]time.Time),
+		killed:     make(map[string]time.Time),
+		loops:      make(map[string]*CrashLoop),
+	}
+}
+
+// SetEvents publishes apps starting to crash loop, so their dashboard card
+// shows it
+func (d *CrashLoopDetector) SetEvents(bus *events.Bus) {
+	d.events = bus
+}
+
+// Start watches Docker events until the context is cancelled, resubscribing
+// when the stream breaks
+func (d *CrashLoopDetector) Start(ctx context.Context) {
+	go func() {
+		for {
+			d.watch(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(eventsRetry):
+			}
+		}
+	}()
+}
+
+// watch handles events until the stream breaks
+func (d *CrashLoopDetector) watch(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, errs := d.source.ContainerEvents(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-errs:
+			if err != nil && ctx.Err() == nil {
+				d.logger.Debug("Docker event stream broke", "error", err)
+			}
+			return
+		case event, ok := <-stream:
+			if !ok {
+				return
+			}
+			if loop := d.observe(event); loop != nil {
+				d.notify(ctx, event, loop)
+			}
+		}
+	}
+}
+
+// observe records a container event and returns the app's crash loop if
+// this event started one
+func (d *CrashLoopDetector) observe(event docker.ContainerEvent) *CrashLoop {
+	if event.AppID == "" {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch event.Action {
+	case "kill":
+		d.killed[event.ContainerID] = event.Time
+		return nil
+	case "die":
+	default:
+		return nil
+	}
+
+	killedAt, wasKilled := d.killed[event.ContainerID]
+	delete(d.killed, event.ContainerID)
+	if wasKilled && event.Time.Sub(killedAt) < stopGrace {
+		return nil
+	}
+
+	deaths := []time.Time{}
+	for _, t := range d.deaths[event.AppID] {
+		if event.Time.Sub(t) < crashLoopWindow {
+			deaths = append(deaths, t)
+		}
+	}
+	deaths = append(deaths, event.Time)
+	d.deaths[event.AppID] = deaths
+
+	loop, looping := d.loops[event.AppID]
+	if looping && event.Time.Sub(loop.LastDiedAt) >= crashLoopWindow {
+		looping = false
+	}
+	if looping {
+		loop.Deaths = len(deaths)
+		loop.LastExitCode = event.ExitCode
+		loop.LastDiedAt = event.Time
+		return nil
+	}
+	if len(deaths) < crashLoopThreshold {
+		delete(d.loops, event.AppID)
+		return nil
+	}
+
+	loop = &CrashLoop{
+		AppID:        event.AppID,
+		Deaths:       len(deaths),
+		LastExitCode: event.ExitCode,
+		Since:        event.Time,
+		LastDiedAt:   event.Time,
+	}
+	d.loops[event.AppID] = loop
+	copied := *loop
+	return &copied
+}
+
+// CrashLoops returns the apps crash looping now, by app ID
+func (d *CrashLoopDetector) CrashLoops() map[string]CrashLoop {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	loops := make(map[string]CrashLoop, len(d.loops))
+	for appID, loop := range d.loops {
+		if time.Since(loop.LastDiedAt) >= crashLoopWindow {
+			delete(d.loops, appID)
+			continue
+		}
+		loops[appID] = *loop
+	}
+	return loops
+}
+
+// notify sends the notification for an app that started crash looping
+func (d *CrashLoopDetector) notify(ctx context.Context, event docker.ContainerEvent, loop *CrashLoop) {
+	name := event.AppName
+	if name == "" {
+		name = event.Name
+	}
+	d.logger.Warn("app is crash looping", "app", name, "deaths", loop.Deaths, "exit_code", loop.LastExitCode)
+
+	d.events.Publish(events.Event{Kind: events.KindContainer, AppID: loop.AppID, Status: "crash_loop"})
+	d.events.Record(ctx, models.ActivityContainer, loop.AppID, "",
+		"%s is crash looping, last exit code %d", name, loop.LastExitCode)
+	d.dispatcher.Notify(ctx, Event{
+		Type:  EventCrashLoop,
+		Title: fmt.Sprintf("%s is crash looping", name),
+		Message: fmt.Sprintf("Containers of %s died %d times in %d minutes, last with exit code %d",
+			name, loop.Deaths, int(crashLoopWindow.Minutes()), loop.LastExitCode),
+		AppName:  name,
+		URL:      d.dispatcher.BaseURL() + "/apps/" + loop.AppID,
+		Priority: PriorityHigh,
+	})
+}
@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"schooner/internal/docker"
+)
+
+func TestCrashLoopDetector_Observe(t *testing.T) {
+	d := NewCrashLoopDetector(nil, nil)
+	start := time.Now().Add(-time.Hour)
+
+	steps := []struct {
+		after     time.Duration
+		container string
+		action    string
+		wantLoop  bool
+	}{
+		{after: 0, container: "c1", action: "die"},
+		{after: time.Minute, container: "c1", action: "die"},
+		{after: 2 * time.Minute, container: "c1", action: "kill"}, // stopped by a deploy
+		{after: 2*time.Minute + time.Second, container: "c1", action: "die"},
+		{after: 3 * time.Minute, container: "c2", action: "die"},
+		{after: 4 * time.Minute, container: "c2", action: "die"},
+		{after: 5 * time.Minute, container: "c2", action: "die", wantLoop: true}, // 5th crash in 10 minutes
+		{after: 6 * time.Minute, container: "c2", action: "die"},                 // already looping
+		{after: 30 * time.Minute, container: "c3", action: "die"},                // stayed up, loop over
+		{after: 31 * time.Minute, container: "c3", action: "die"},
+	}
+
+	for i, step := range steps {
+		loop := d.observe(docker.ContainerEvent{
+			ContainerID: step.container,
+			AppID:       "app",
+			Action:      step.action,
+			ExitCode:    1,
+			Time:        start.Add(step.after),
+		})
+		if (loop != nil) != step.wantLoop {
+			t.Errorf("step %d: observe(%s %s) = %+v, want loop %v", i, step.container, step.action, loop, step.wantLoop)
+		}
+		if loop != nil && loop.Deaths != crashLoopThreshold {
+			t.Errorf("step %d: deaths = %d, want %d", i, loop.Deaths, crashLoopThreshold)
+		}
+	}
+
+	if loops := d.CrashLoops(); len(loops) != 0 {
+		t.Errorf("CrashLoops() = %v, want none after the containers stayed up", loops)
+	}
+}
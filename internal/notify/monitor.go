@@ -0,0 +1,220 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"schooner/internal/docker"
+	"schooner/internal/events"
+	"schooner/internal/models"
+)
+
+// downThreshold is the number of consecutive failed checks before a container
+// is reported as down. This avoids alerting during redeploys.
+const downThreshold = 2
+
+// AppLister interface for listing enabled apps
+type AppLister interface {
+	ListEnabled(ctx context.Context) ([]*models.App, error)
+}
+
+// ContainerStatusGetter interface for inspecting container state
+type ContainerStatusGetter interface {
+	GetContainerStatus(ctx context.Context, nameOrID string) (*docker.ContainerStatus, error)
+}
+
+// DockerHostResolver returns the Docker client for the host an app runs on
+type DockerHostResolver interface {
+	ForApp(ctx context.Context, app *models.App) (*docker.Client, error)
+}
+
+// AgentContainers reports the containers Schooner agents last saw
+type AgentContainers interface {
+	ContainerStatus(agentID, name string) (*docker.ContainerStatus, error)
+}
+
+// agentStatusGetter reads container status from one agent's reports
+type agentStatusGetter struct {
+	agents  AgentContainers
+	agentID string
+}
+
+func (g agentStatusGetter) GetContainerStatus(ctx context.Context, name string) (*docker.ContainerStatus, error) {
+	return g.agents.ContainerStatus(g.agentID, name)
+}
+
+// containerState tracks what the monitor last saw for an app
+type containerState struct {
+	wasRunning bool
+	downChecks int
+	notified   bool
+	lastState  string
+}
+
+// ContainerMonitor watches app containers and notifies when they go down
+type ContainerMonitor struct {
+	dispatcher   *Dispatcher
+	dockerClient ContainerStatusGetter
+	dockerHosts  DockerHostResolver
+	agents       AgentContainers
+	appQueries   AppLister
+	events       *events.Bus
+	health       HealthRecorder
+	interval     time.Duration
+	logger       *slog.Logger
+
+	mu           sync.Mutex
+	states       map[string]*containerState
+	healthStates map[string]*healthState
+}
+
+// NewContainerMonitor creates a new container monitor
+func NewContainerMonitor(dispatcher *Dispatcher, dockerClient ContainerStatusGetter, appQueries AppLister, interval time.Duration) *ContainerMonitor {
+	return &ContainerMonitor{
+		dispatcher:   dispatcher,
+		dockerClient: dockerClient,
+		appQueries:   appQueries,
+		interval:     interval,
+		logger:       slog.Default(),
+		states:       make(map[string]*containerState),
+		healthStates: make(map[string]*healthState),
+	}
+}
+
+// SetDockerHosts makes the monitor check apps on the Docker host they deploy
+// to rather than the local daemon
+func (m *ContainerMonitor) SetDockerHosts(hosts DockerHostResolver) {
+	m.dockerHosts = hosts
+}
+
+// SetAgents makes the monitor check apps on agents from the agents' reports
+func (m *ContainerMonitor) SetAgents(agents AgentContainers) {
+	m.agents = agents
+}
+
+// SetEvents publishes changes of container state the monitor sees, such as
+// a container that crashed
+func (m *ContainerMonitor) SetEvents(bus *events.Bus) {
+	m.events = bus
+}
+
+// statusGetter returns the Docker client for an app's host, or its agent
+func (m *ContainerMonitor) statusGetter(ctx context.Context, app *models.App) (ContainerStatusGetter, error) {
+	if app.GetAgent() != "" {
+		if m.agents == nil {
+			return nil, fmt.Errorf("agents are not available")
+		}
+		return agentStatusGetter{agents: m.agents, agentID: app.GetAgent()}, nil
+	}
+	if m.dockerHosts == nil || app.GetDockerHost() == "" {
+		return m.dockerClient, nil
+	}
+	return m.dockerHosts.ForApp(ctx, app)
+}
+
+// Start runs the monitor until the context is cancelled
+func (m *ContainerMonitor) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.check(ctx)
+			}
+		}
+	}()
+}
+
+// check inspects every enabled app's container once
+func (m *ContainerMonitor) check(ctx context.Context) {
+	apps, err := m.appQueries.ListEnabled(ctx)
+	if err != nil {
+		m.logger.Warn("container monitor failed to list apps", "error", err)
+		return
+	}
+
+	for _, app := range apps {
+		dockerClient, err := m.statusGetter(ctx, app)
+		if err != nil {
+			m.logger.Debug("container monitor failed to reach Docker host", "app", app.Name, "error", err)
+			continue
+		}
+
+		status, err := dockerClient.GetContainerStatus(ctx, app.GetContainerName())
+		if err != nil {
+			m.logger.Debug("container monitor failed to get status", "app", app.Name, "error", err)
+			continue
+		}
+
+		if m.stateChanged(app.ID, status.State) {
+			m.events.Publish(events.Event{Kind: events.KindContainer, AppID: app.ID, Status: status.State})
+		}
+
+		m.watchHealth(ctx, app, dockerClient, status)
+
+		if m.observe(app.ID, status.State == "running") {
+			m.dispatcher.Notify(ctx, Event{
+				Type:     EventContainerDown,
+				Title:    fmt.Sprintf("%s is down", app.Name),
+				Message:  fmt.Sprintf("Container for %s is %s", app.Name, status.State),
+				AppName:  app.Name,
+				URL:      m.dispatcher.BaseURL() + "/apps/" + app.ID,
+				Priority: PriorityHigh,
+			})
+		}
+	}
+}
+
+// stateChanged records the state an app's container is in and returns true
+// if it's changed since the last check
+func (m *ContainerMonitor) stateChanged(appID, state string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.states[appID]
+	if !ok {
+		current = &containerState{}
+		m.states[appID] = current
+	}
+	changed := current.lastState != "" && current.lastState != state
+	current.lastState = state
+	return changed
+}
+
+// observe records a check result and returns true if a down notification should fire
+func (m *ContainerMonitor) observe(appID string, running bool) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.states[appID]
+	if !ok {
+		state = &containerState{}
+		m.states[appID] = state
+	}
+
+	if running {
+		state.wasRunning = true
+		state.downChecks = 0
+		state.notified = false
+		return false
+	}
+
+	if !state.wasRunning || state.notified {
+		return false
+	}
+
+	state.downChecks++
+	if state.downChecks < downThreshold {
+		return false
+	}
+
+	state.notified = true
+	return true
+}
@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"testing"
+	"time"
+)
+
+func TestContainerMonitor_Observe(t *testing.T) {
+	m := NewContainerMonitor(nil, nil, nil, 0)
+
+	steps := []struct {
+		running bool
+		want    bool
+	}{
+		{running: false, want: false}, // never seen running
+		{running: true, want: false},
+		{running: false, want: false}, // first failed check
+		{running: false, want: true},  // threshold reached
+		{running: false, want: false}, // already notified
+		{running: true, want: false},  // recovered
+		{running: false, want: false},
+		{running: false, want: true}, // down again
+	}
+
+	for i, step := range steps {
+		if got := m.observe("app", step.running); got != step.want {
+			t.Errorf("step %d: observe(%v) = %v, want %v", i, step.running, got, step.want)
+		}
+	}
+}
+
+func TestContainerMonitor_StateChanged(t *testing.T) {
+	m := NewContainerMonitor(nil, nil, nil, 0)
+
+	steps := []struct {
+		state string
+		want  bool
+	}{
+		{state: "running", want: false}, // first check
+		{state: "running", want: false},
+		{state: "exited", want: true},
+		{state: "exited", want: false},
+		{state: "running", want: true},
+	}
+
+	for i, step := range steps {
+		if got := m.stateChanged("app", step.state); got != step.want {
+			t.Errorf("step %d: stateChanged(%q) = %v, want %v", i, step.state, got, step.want)
+		}
+	}
+}
+
+func TestContainerMonitor_ObserveHealth(t *testing.T) {
+	m := NewContainerMonitor(nil, nil, nil, 0)
+	start := time.Now()
+
+	steps := []struct {
+		after       time.Duration
+		health      string
+		wantChanged bool
+		wantFlapped bool
+		wantRestart bool
+	}{
+		{after: 0, health: "starting", wantChanged: true},
+		{after: 30 * time.Second, health: "healthy", wantChanged: true},
+		{after: time.Minute, health: "unhealthy", wantChanged: true, wantFlapped: true},
+		{after: 90 * time.Second, health: "unhealthy"},                    // within the grace period
+		{after: 2 * time.Minute, health: "unhealthy", wantRestart: true},  // unhealthy for a minute
+		{after: 150 * time.Second, health: "starting", wantChanged: true}, // restarted
+		{after: 4 * time.Minute, health: "unhealthy", wantChanged: true},  // not a flap, it never recovered
+		{after: 5 * time.Minute, health: "unhealthy", wantRestart: true},  // 2 minutes after the restart
+		{after: 8 * time.Minute, health: "unhealthy"},                     // backing off for 4 minutes
+		{after: 9 * time.Minute, health: "unhealthy", wantRestart: true},  // backoff over
+		{after: 10 * time.Minute, health: "healthy", wantChanged: true},   // recovered, backoff reset
+		{after: 11 * time.Minute, health: "unhealthy", wantChanged: true, wantFlapped: true},
+		{after: 12 * time.Minute, health: "unhealthy", wantRestart: true},
+	}
+
+	for i, step := range steps {
+		changed, flapped, restart := m.observeHealth("app", step.health, start.Add(step.after))
+		if changed != step.wantChanged || flapped != step.wantFlapped || restart != step.wantRestart {
+			t.Errorf("step %d: observeHealth(%q) = %v %v %v, want %v %v %v", i, step.health,
+				changed, flapped, restart, step.wantChanged, step.wantFlapped, step.wantRestart)
+		}
+	}
+}
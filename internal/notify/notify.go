@@ -0,0 +1,138 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// EventType identifies what triggered a notification
+type EventType string
+
+const (
+	EventBuildFailed        EventType = "build_failed"
+	EventApprovalRequired   EventType = "approval_required"
+	EventContainerDown      EventType = "container_down"
+	EventContainerUnhealthy EventType = "container_unhealthy"
+	EventCrashLoop          EventType = "crash_loop"
+	EventAlertFiring        EventType = "alert_firing"
+	EventAlertResolved      EventType = "alert_resolved"
+	EventUptimeDown         EventType = "uptime_down"
+	EventUptimeUp           EventType = "uptime_up"
+	EventBackupFailed       EventType = "backup_failed"
+	EventDiskCleanup        EventType = "disk_cleanup"
+	EventTest               EventType = "test"
+)
+
+// Priority levels for notifications
+const (
+	PriorityDefault = 3
+	PriorityHigh    = 4
+)
+
+// Event is a notification to be delivered to all configured channels
+type Event struct {
+	Type     EventType
+	Title    string
+	Message  string
+	AppName  string
+	URL      string
+	Priority int
+}
+
+// Channel delivers notifications to an external service
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, event Event) error
+}
+
+// SettingsGetter interface for getting settings from the database
+type SettingsGetter interface {
+	Get(ctx context.Context, key string) (string, error)
+}
+
+// Dispatcher fans out events to every configured channel
+type Dispatcher struct {
+	settingsQueries SettingsGetter
+	logger          *slog.Logger
+
+	mu      sync.RWMutex
+	baseURL string
+}
+
+// NewDispatcher creates a new notification dispatcher
+func NewDispatcher(settingsQueries SettingsGetter, baseURL string) *Dispatcher {
+	return &Dispatcher{
+		settingsQueries: settingsQueries,
+		baseURL:         baseURL,
+		logger:          slog.Default(),
+	}
+}
+
+// BaseURL returns the external URL used for click-through links
+func (d *Dispatcher) BaseURL() string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.baseURL
+}
+
+// SetBaseURL changes the external URL links point to, when the config is
+// reloaded
+func (d *Dispatcher) SetBaseURL(baseURL string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.baseURL = baseURL
+}
+
+// channels builds the list of configured channels from settings.
+// Channels are rebuilt on every send so settings changes apply immediately.
+func (d *Dispatcher) channels(ctx context.Context) []Channel {
+	var channels []Channel
+
+	if ntfy, err := loadNtfyChannel(ctx, d.settingsQueries); err != nil {
+		d.logger.Warn("failed to load ntfy settings", "error", err)
+	} else if ntfy != nil {
+		channels = append(channels, ntfy)
+	}
+
+	return channels
+}
+
+// Notify sends an event to all channels in the background
+func (d *Dispatcher) Notify(ctx context.Context, event Event) {
+	go func() {
+		sendCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+
+		for _, ch := range d.channels(sendCtx) {
+			if err := ch.Send(sendCtx, event); err != nil {
+				d.logger.Error("failed to send notification", "channel", ch.Name(), "event", event.Type, "error", err)
+			}
+		}
+	}()
+}
+
+// SendTest synchronously sends a test notification and reports the first error
+func (d *Dispatcher) SendTest(ctx context.Context) error {
+	channels := d.channels(ctx)
+	if len(channels) == 0 {
+		return fmt.Errorf("no notification channels configured")
+	}
+
+	event := Event{
+		Type:     EventTest,
+		Title:    "Schooner test notification",
+		Message:  "Notifications are working.",
+		Priority: PriorityDefault,
+	}
+
+	for _, ch := range channels {
+		if err := ch.Send(ctx, event); err != nil {
+			return fmt.Errorf("%s: %w", ch.Name(), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Settings keys for the ntfy channel
+const (
+	NtfyServerKey = "ntfy_server_url"
+	NtfyTopicKey  = "ntfy_topic"
+	NtfyTokenKey  = "ntfy_token"
+
+	DefaultNtfyServer = "https://ntfy.sh"
+)
+
+// NtfyChannel sends push notifications via an ntfy server
+type NtfyChannel struct {
+	serverURL  string
+	topic      string
+	token      string
+	httpClient *http.Client
+}
+
+// NewNtfyChannel creates a new ntfy channel
+func NewNtfyChannel(serverURL, topic, token string) *NtfyChannel {
+	if serverURL == "" {
+		serverURL = DefaultNtfyServer
+	}
+	return &NtfyChannel{
+		serverURL:  strings.TrimRight(serverURL, "/"),
+		topic:      topic,
+		token:      token,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// loadNtfyChannel returns the ntfy channel if a topic is configured, nil otherwise
+func loadNtfyChannel(ctx context.Context, settings SettingsGetter) (*NtfyChannel, error) {
+	if settings == nil {
+		return nil, nil
+	}
+
+	topic, err := settings.Get(ctx, NtfyTopicKey)
+	if err != nil || topic == "" {
+		return nil, err
+	}
+
+	server, err := settings.Get(ctx, NtfyServerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := settings.Get(ctx, NtfyTokenKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewNtfyChannel(server, topic, token), nil
+}
+
+// Name returns the channel name
+func (c *NtfyChannel) Name() string {
+	return "ntfy"
+}
+
+// Send publishes the event to the configured topic
+func (c *NtfyChannel) Send(ctx context.Context, event Event) error {
+	req, err := c.newRequest(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish to ntfy: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("ntfy returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// newRequest builds the ntfy publish request using header-based metadata
+func (c *NtfyChannel) newRequest(ctx context.Context, event Event) (*http.Request, error) {
+	url := c.serverURL + "/" + c.topic
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(event.Message))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ntfy request: %w", err)
+	}
+
+	if event.Title != "" {
+		req.Header.Set("Title", event.Title)
+	}
+	if event.Priority > 0 {
+		req.Header.Set("Priority", strconv.Itoa(event.Priority))
+	}
+	if tag := ntfyTag(event.Type); tag != "" {
+		req.Header.Set("Tags", tag)
+	}
+	if event.URL != "" {
+		req.Header.Set("Click", event.URL)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	return req, nil
+}
+
+// ntfyTag maps event types to ntfy emoji tags
+func ntfyTag(eventType EventType) string {
+	switch eventType {
+	case EventBuildFailed, EventBackupFailed:
+		return "x"
+	case EventContainerDown, EventContainerUnhealthy:
+		return "warning"
+	case EventCrashLoop:
+		return "repeat"
+	case EventAlertFiring:
+		return "rotating_light"
+	case EventUptimeDown:
+		return "red_circle"
+	case EventDiskCleanup:
+		return "broom"
+	case EventApprovalRequired:
+		return "hourglass"
+	case EventAlertResolved, EventUptimeUp, EventTest:
+		return "white_check_mark"
+	default:
+		return ""
+	}
+}
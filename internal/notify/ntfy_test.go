@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeSettings map[string]string
+
+func (f fakeSettings) Get(ctx context.Context, key string) (string, error) {
+	return f[key], nil
+}
+
+func TestNtfyChannel_Send(t *testing.T) {
+	var gotPath, gotBody, gotTitle, gotAuth, gotPriority string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotPath = r.URL.Path
+		gotBody = string(body)
+		gotTitle = r.Header.Get("Title")
+		gotAuth = r.Header.Get("Authorization")
+		gotPriority = r.Header.Get("Priority")
+	}))
+	defer server.Close()
+
+	ch := NewNtfyChannel(server.URL+"/", "alerts", "tk_secret")
+	err := ch.Send(context.Background(), Event{
+		Type:     EventBuildFailed,
+		Title:    "Build failed: blog",
+		Message:  "clone failed",
+		Priority: PriorityHigh,
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if gotPath != "/alerts" {
+		t.Errorf("path = %v, want /alerts", gotPath)
+	}
+	if gotBody != "clone failed" {
+		t.Errorf("body = %v, want clone failed", gotBody)
+	}
+	if gotTitle != "Build failed: blog" {
+		t.Errorf("Title = %v, want Build failed: blog", gotTitle)
+	}
+	if gotAuth != "Bearer tk_secret" {
+		t.Errorf("Authorization = %v, want Bearer tk_secret", gotAuth)
+	}
+	if gotPriority != "4" {
+		t.Errorf("Priority = %v, want 4", gotPriority)
+	}
+}
+
+func TestNtfyChannel_SendError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	ch := NewNtfyChannel(server.URL, "alerts", "")
+	if err := ch.Send(context.Background(), Event{Message: "hi"}); err == nil {
+		t.Error("Send() expected error for 403 response")
+	}
+}
+
+func TestLoadNtfyChannel(t *testing.T) {
+	ch, err := loadNtfyChannel(context.Background(), fakeSettings{})
+	if err != nil || ch != nil {
+		t.Errorf("loadNtfyChannel() with no topic = %v, %v, want nil, nil", ch, err)
+	}
+
+	ch, err = loadNtfyChannel(context.Background(), fakeSettings{NtfyTopicKey: "alerts"})
+	if err != nil {
+		t.Fatalf("loadNtfyChannel() error = %v", err)
+	}
+	if ch.serverURL != DefaultNtfyServer {
+		t.Errorf("serverURL = %v, want %v", ch.serverURL, DefaultNtfyServer)
+	}
+}
@@ -0,0 +1,137 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"schooner/internal/docker"
+	"schooner/internal/events"
+	"schooner/internal/models"
+)
+
+// A container unhealthy for unhealthyGrace is restarted, then again each
+// time it stays unhealthy, waiting twice as long after every restart up to
+// maxRestartBackoff
+const (
+	unhealthyGrace     = time.Minute
+	restartBackoff     = 2 * time.Minute
+	maxRestartBackoff  = 30 * time.Minute
+	restartStopTimeout = 30 * time.Second
+)
+
+// HealthRecorder records changes of container health and the watchdog's
+// restarts
+type HealthRecorder interface {
+	SaveHealth(ctx context.Context, appID, health string, flapped bool) error
+	RecordRestart(ctx context.Context, appID string) error
+}
+
+// ContainerRestarter restarts a container on the Docker host it runs on
+type ContainerRestarter interface {
+	RestartContainer(ctx context.Context, nameOrID string, timeout time.Duration) error
+}
+
+// healthState tracks the health check of an app's container
+type healthState struct {
+	health         string
+	unhealthySince time.Time
+	restarts       int // Restarts since it was last healthy
+	nextRestart    time.Time
+}
+
+// SetHealthRecorder records the health of containers with a health check
+// and restarts those stuck unhealthy
+func (m *ContainerMonitor) SetHealthRecorder(recorder HealthRecorder) {
+	m.health = recorder
+}
+
+// watchHealth records a running container's health and restarts it once it
+// has been unhealthy too long
+func (m *ContainerMonitor) watchHealth(ctx context.Context, app *models.App, dockerClient ContainerStatusGetter, status *docker.ContainerStatus) {
+	if m.health == nil || status.State != "running" || status.Health == "" {
+		return
+	}
+
+	changed, flapped, restart := m.observeHealth(app.ID, status.Health, time.Now())
+	if changed {
+		if err := m.health.SaveHealth(ctx, app.ID, status.Health, flapped); err != nil {
+			m.logger.Warn("failed to record container health", "app", app.Name, "error", err)
+		}
+		m.events.Publish(events.Event{Kind: events.KindContainer, AppID: app.ID, Status: status.Health})
+	}
+	if !restart {
+		return
+	}
+
+	// Agents report status but can't be asked to restart
+	restarter, ok := dockerClient.(ContainerRestarter)
+	if !ok {
+		return
+	}
+	m.logger.Warn("restarting unhealthy container", "app", app.Name, "container", app.GetContainerName())
+	if err := restarter.RestartContainer(ctx, app.GetContainerName(), restartStopTimeout); err != nil {
+		m.logger.Error("failed to restart unhealthy container", "app", app.Name, "error", err)
+		return
+	}
+	if err := m.health.RecordRestart(ctx, app.ID); err != nil {
+		m.logger.Warn("failed to record container restart", "app", app.Name, "error", err)
+	}
+	m.events.Record(ctx, models.ActivityContainer, app.ID, "", "Restarted %s after it stayed unhealthy", app.Name)
+
+	m.dispatcher.Notify(ctx, Event{
+		Type:     EventContainerUnhealthy,
+		Title:    fmt.Sprintf("%s restarted", app.Name),
+		Message:  fmt.Sprintf("Container for %s was unhealthy and has been restarted", app.Name),
+		AppName:  app.Name,
+		URL:      m.dispatcher.BaseURL() + "/apps/" + app.ID,
+		Priority: PriorityHigh,
+	})
+}
+
+// observeHealth records a health check result. It reports whether the
+// health changed, whether that was a flap from healthy to unhealthy and
+// whether the container should be restarted.
+func (m *ContainerMonitor) observeHealth(appID, health string, now time.Time) (changed, flapped, restart bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.healthStates[appID]
+	if !ok {
+		state = &healthState{}
+		m.healthStates[appID] = state
+	}
+
+	changed = state.health != health
+	flapped = state.health == "healthy" && health == "unhealthy"
+	state.health = health
+
+	switch health {
+	case "healthy":
+		state.unhealthySince = time.Time{}
+		state.restarts = 0
+		state.nextRestart = time.Time{}
+		return changed, flapped, false
+	case "unhealthy":
+		if state.unhealthySince.IsZero() {
+			state.unhealthySince = now
+		}
+	default:
+		// Starting, e.g. after a restart
+		state.unhealthySince = time.Time{}
+		return changed, flapped, false
+	}
+
+	if now.Sub(state.unhealthySince) < unhealthyGrace || now.Before(state.nextRestart) {
+		return changed, flapped, false
+	}
+
+	backoff := restartBackoff << state.restarts
+	if backoff > maxRestartBackoff || backoff <= 0 {
+		backoff = maxRestartBackoff
+	}
+	state.restarts++
+	state.nextRestart = now.Add(backoff)
+	state.unhealthySince = time.Time{}
+	return changed, flapped, true
+}
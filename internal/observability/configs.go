@@ -524,3 +524,75 @@ func getServicesDashboard() string {
   "version": 1
 }`
 }
+
+// getAppDashboard returns the per-app dashboard whose panels are embedded on
+// the app detail page. Panel IDs must match appPanels.
+func getAppDashboard() string {
+	return `{
+  "annotations": {"list": []},
+  "editable": false,
+  "fiscalYearStartMonth": 0,
+  "graphTooltip": 1,
+  "id": null,
+  "links": [],
+  "panels": [
+    {
+      "datasource": {"type": "loki", "uid": "loki"},
+      "fieldConfig": {
+        "defaults": {"color": {"mode": "palette-classic"}},
+        "overrides": []
+      },
+      "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0},
+      "id": 1,
+      "options": {"legend": {"displayMode": "hidden", "placement": "bottom"}, "tooltip": {"mode": "single"}},
+      "targets": [{
+        "datasource": {"type": "loki", "uid": "loki"},
+        "expr": "sum(count_over_time({app_id=\"${app_id}\"}[$__interval]))",
+        "legendFormat": "Lines",
+        "refId": "A"
+      }],
+      "title": "Log Volume",
+      "type": "timeseries"
+    },
+    {
+      "datasource": {"type": "loki", "uid": "loki"},
+      "fieldConfig": {
+        "defaults": {"color": {"fixedColor": "red", "mode": "fixed"}},
+        "overrides": []
+      },
+      "gridPos": {"h": 8, "w": 12, "x": 12, "y": 0},
+      "id": 2,
+      "options": {"legend": {"displayMode": "hidden", "placement": "bottom"}, "tooltip": {"mode": "single"}},
+      "targets": [{
+        "datasource": {"type": "loki", "uid": "loki"},
+        "expr": "sum(count_over_time({app_id=\"${app_id}\"} |~ \"(?i)(error|err|fail|fatal|panic|exception)\"[$__interval]))",
+        "legendFormat": "Errors",
+        "refId": "A"
+      }],
+      "title": "Error Rate",
+      "type": "timeseries"
+    }
+  ],
+  "refresh": "1m",
+  "schemaVersion": 38,
+  "tags": ["schooner", "app"],
+  "templating": {
+    "list": [
+      {
+        "datasource": {"type": "loki", "uid": "loki"},
+        "definition": "label_values(app_id)",
+        "hide": 2,
+        "label": "App ID",
+        "name": "app_id",
+        "query": "label_values(app_id)",
+        "refresh": 2,
+        "type": "query"
+      }
+    ]
+  },
+  "time": {"from": "now-6h", "to": "now"},
+  "title": "Schooner App",
+  "uid": "schooner-app",
+  "version": 1
+}`
+}
@@ -0,0 +1,212 @@
+package observability
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// EmbedModeAnonymous lets anyone who can reach Grafana view dashboards
+	EmbedModeAnonymous = "anonymous"
+
+	// EmbedModeSigned disables anonymous access; embedded panels carry a
+	// short-lived JWT signed by Schooner instead
+	EmbedModeSigned = "signed"
+
+	appDashboardUID   = "schooner-app"
+	embedKeySetting   = "observability_grafana_embed_key"
+	embedModeSetting  = "observability_grafana_embed_mode"
+	embedPublicKeyPEM = "grafana-jwt.pem"
+	embedTokenTTL     = time.Hour
+)
+
+// appPanels are the panels of the per-app dashboard embedded on the app page
+var appPanels = []struct {
+	id    int
+	title string
+}{
+	{id: 1, title: "Log Volume"},
+	{id: 2, title: "Error Rate"},
+}
+
+// EmbedPanel is a Grafana panel that can be rendered in an iframe
+type EmbedPanel struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// GetEmbedMode returns how Grafana panels are embedded
+func (m *Manager) GetEmbedMode(ctx context.Context) string {
+	if m.settingsQueries != nil {
+		if mode, err := m.settingsQueries.Get(ctx, embedModeSetting); err == nil && mode == EmbedModeSigned {
+			return EmbedModeSigned
+		}
+	}
+	return EmbedModeAnonymous
+}
+
+// AppPanels returns embeddable log volume and error rate panels for an app
+// covering the window from now-from to now
+func (m *Manager) AppPanels(ctx context.Context, appID string, from time.Duration) ([]EmbedPanel, error) {
+	var token string
+	if m.GetEmbedMode(ctx) == EmbedModeSigned {
+		key, err := m.embedSigningKey(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if token, err = signEmbedToken(key, "schooner-embed", embedTokenTTL, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	base := m.GetGrafanaURL(ctx)
+	panels := make([]EmbedPanel, len(appPanels))
+	for i, p := range appPanels {
+		panels[i] = EmbedPanel{
+			Title: p.title,
+			URL:   panelURL(base, appDashboardUID, p.id, appID, from, token),
+		}
+	}
+	return panels, nil
+}
+
+// panelURL builds a d-solo URL for a single dashboard panel
+func panelURL(base, dashboardUID string, panelID int, appID string, from time.Duration, token string) string {
+	params := url.Values{}
+	params.Set("orgId", "1")
+	params.Set("panelId", fmt.Sprintf("%d", panelID))
+	params.Set("var-app_id", appID)
+	params.Set("from", fmt.Sprintf("now-%dm", int(from.Minutes())))
+	params.Set("to", "now")
+	params.Set("refresh", "1m")
+	params.Set("theme", "light")
+	if token != "" {
+		params.Set("auth_token", token)
+	}
+	return fmt.Sprintf("%s/d-solo/%s/%s?%s", base, dashboardUID, dashboardUID, params.Encode())
+}
+
+// grafanaAuthEnv returns the Grafana auth and embedding settings for the
+// configured embed mode. Signed mode writes the verification key to configDir.
+func (m *Manager) grafanaAuthEnv(ctx context.Context, configDir string) ([]string, error) {
+	env := []string{"GF_SECURITY_ALLOW_EMBEDDING=true"}
+
+	if m.GetEmbedMode(ctx) != EmbedModeSigned {
+		return append(env,
+			"GF_AUTH_ANONYMOUS_ENABLED=true",
+			"GF_AUTH_ANONYMOUS_ORG_ROLE=Admin",
+			"GF_AUTH_DISABLE_LOGIN_FORM=true",
+		), nil
+	}
+
+	key, err := m.embedSigningKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeEmbedPublicKey(filepath.Join(configDir, embedPublicKeyPEM), &key.PublicKey); err != nil {
+		return nil, err
+	}
+
+	// Anonymous access is off, so keep the login form for the admin account
+	return append(env,
+		"GF_AUTH_ANONYMOUS_ENABLED=false",
+		"GF_AUTH_DISABLE_LOGIN_FORM=false",
+		"GF_AUTH_JWT_ENABLED=true",
+		"GF_AUTH_JWT_URL_LOGIN=true",
+		"GF_AUTH_JWT_HEADER_NAME=X-JWT-Assertion",
+		"GF_AUTH_JWT_USERNAME_CLAIM=sub",
+		"GF_AUTH_JWT_EMAIL_CLAIM=sub",
+		"GF_AUTH_JWT_AUTO_SIGN_UP=true",
+		"GF_AUTH_JWT_ROLE_ATTRIBUTE_PATH=role",
+		"GF_AUTH_JWT_KEY_FILE=/schooner-data/observability/"+embedPublicKeyPEM,
+	), nil
+}
+
+// embedSigningKey loads the embed signing key, generating and storing one on
+// first use
+func (m *Manager) embedSigningKey(ctx context.Context) (*ecdsa.PrivateKey, error) {
+	if m.settingsQueries == nil {
+		return nil, fmt.Errorf("settings are not available")
+	}
+
+	if encoded, err := m.settingsQueries.Get(ctx, embedKeySetting); err == nil && encoded != "" {
+		block, _ := pem.Decode([]byte(encoded))
+		if block == nil {
+			return nil, fmt.Errorf("invalid embed signing key")
+		}
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embed signing key: %w", err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode embed signing key: %w", err)
+	}
+	encoded := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	if err := m.settingsQueries.Set(ctx, embedKeySetting, string(encoded)); err != nil {
+		return nil, fmt.Errorf("failed to save embed signing key: %w", err)
+	}
+
+	return key, nil
+}
+
+// writeEmbedPublicKey writes the PEM public key Grafana uses to verify tokens
+func writeEmbedPublicKey(path string, key *ecdsa.PublicKey) error {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode embed public key: %w", err)
+	}
+	encoded := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	if err := os.WriteFile(path, encoded, 0644); err != nil {
+		return fmt.Errorf("failed to write embed public key: %w", err)
+	}
+	return nil
+}
+
+// signEmbedToken returns an ES256 JWT granting Viewer access until now+ttl
+func signEmbedToken(key *ecdsa.PrivateKey, subject string, ttl time.Duration, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "ES256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"sub":  subject,
+		"role": "Viewer",
+		"iat":  now.Unix(),
+		"exp":  now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign embed token: %w", err)
+	}
+
+	// JWS ES256 signatures are the fixed-width concatenation of r and s
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	return signingInput + "." + enc.EncodeToString(sig), nil
+}
@@ -0,0 +1,116 @@
+package observability
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"schooner/internal/config"
+)
+
+type fakeSettings map[string]string
+
+func (f fakeSettings) Get(ctx context.Context, key string) (string, error) {
+	return f[key], nil
+}
+
+func (f fakeSettings) Set(ctx context.Context, key, value string) error {
+	f[key] = value
+	return nil
+}
+
+func TestSignEmbedToken(t *testing.T) {
+	m := &Manager{cfg: &config.Config{}, settingsQueries: fakeSettings{}}
+	key, err := m.embedSigningKey(context.Background())
+	if err != nil {
+		t.Fatalf("embedSigningKey() error = %v", err)
+	}
+
+	// The key is persisted and reused
+	again, err := m.embedSigningKey(context.Background())
+	if err != nil {
+		t.Fatalf("embedSigningKey() error = %v", err)
+	}
+	if !key.Equal(again) {
+		t.Error("expected stored key to be reused")
+	}
+
+	now := time.Unix(1700000000, 0)
+	token, err := signEmbedToken(key, "schooner-embed", time.Hour, now)
+	if err != nil {
+		t.Fatalf("signEmbedToken() error = %v", err)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 token parts, got %d", len(parts))
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(sig) != 64 {
+		t.Fatalf("invalid signature encoding: %v", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if !ecdsa.Verify(&key.PublicKey, digest[:], r, s) {
+		t.Error("signature does not verify")
+	}
+
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatalf("invalid claims: %v", err)
+	}
+	if claims["role"] != "Viewer" || claims["exp"] != float64(now.Add(time.Hour).Unix()) {
+		t.Errorf("unexpected claims %v", claims)
+	}
+}
+
+func TestAppPanels(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		wantToken bool
+	}{
+		{name: "anonymous", mode: "", wantToken: false},
+		{name: "signed", mode: EmbedModeSigned, wantToken: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := fakeSettings{embedModeSetting: tt.mode}
+			m := &Manager{cfg: &config.Config{}, settingsQueries: settings}
+
+			panels, err := m.AppPanels(context.Background(), "app-1", 6*time.Hour)
+			if err != nil {
+				t.Fatalf("AppPanels() error = %v", err)
+			}
+			if len(panels) != len(appPanels) {
+				t.Fatalf("AppPanels() returned %d panels, want %d", len(panels), len(appPanels))
+			}
+
+			u, err := url.Parse(panels[0].URL)
+			if err != nil {
+				t.Fatalf("invalid panel URL: %v", err)
+			}
+			if u.Path != "/d-solo/schooner-app/schooner-app" {
+				t.Errorf("path = %s", u.Path)
+			}
+			q := u.Query()
+			if q.Get("var-app_id") != "app-1" || q.Get("from") != "now-360m" || q.Get("panelId") != "1" {
+				t.Errorf("unexpected query %s", u.RawQuery)
+			}
+			if (q.Get("auth_token") != "") != tt.wantToken {
+				t.Errorf("auth_token present = %v, want %v", q.Get("auth_token") != "", tt.wantToken)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package observability
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxLogSearchRange is the widest time window a search may cover
+	MaxLogSearchRange = 7 * 24 * time.Hour
+
+	// MaxLogSearchLimit caps the number of lines returned by a search
+	MaxLogSearchLimit = 5000
+
+	defaultLogSearchLimit = 500
+)
+
+// LogSearch is a validated log query against Loki. Callers supply structured
+// filters rather than raw LogQL so label selectors can't be widened.
+type LogSearch struct {
+	AppIDs     []string  // match app_id label
+	Containers []string  // match container label (infrastructure services)
+	Contains   string    // case-sensitive substring line filter
+	Regex      string    // RE2 line filter
+	Start      time.Time // inclusive
+	End        time.Time // exclusive
+	Limit      int
+	Forward    bool // oldest first when true
+}
+
+// LogEntry is a single log line returned from a search
+type LogEntry struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Line      string            `json:"line"`
+	Labels    map[string]string `json:"labels"`
+}
+
+// Validate checks the search bounds and fills in defaults
+func (s *LogSearch) Validate(now time.Time) error {
+	if len(s.AppIDs) > 0 && len(s.Containers) > 0 {
+		return fmt.Errorf("filter by apps or by services, not both")
+	}
+	if s.Contains != "" && s.Regex != "" {
+		return fmt.Errorf("use either a text or a regex filter, not both")
+	}
+	if s.Regex != "" {
+		if _, err := regexp.Compile(s.Regex); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	}
+
+	if s.End.IsZero() || s.End.After(now) {
+		s.End = now
+	}
+	if s.Start.IsZero() {
+		s.Start = s.End.Add(-time.Hour)
+	}
+	if !s.Start.Before(s.End) {
+		return fmt.Errorf("start must be before end")
+	}
+	if s.End.Sub(s.Start) > MaxLogSearchRange {
+		return fmt.Errorf("time range must not exceed %s", MaxLogSearchRange)
+	}
+
+	if s.Limit <= 0 {
+		s.Limit = defaultLogSearchLimit
+	}
+	if s.Limit > MaxLogSearchLimit {
+		s.Limit = MaxLogSearchLimit
+	}
+
+	return nil
+}
+
+// LogQL builds the LogQL query for the search
+func (s *LogSearch) LogQL() string {
+	var b strings.Builder
+
+	switch {
+	case len(s.AppIDs) > 0:
+		b.WriteString(`{app_id=~"` + labelAlternation(s.AppIDs) + `"}`)
+	case len(s.Containers) > 0:
+		b.WriteString(`{container=~"` + labelAlternation(s.Containers) + `"}`)
+	default:
+		b.WriteString(`{container=~".+"}`)
+	}
+
+	if s.Contains != "" {
+		b.WriteString(` |= ` + strconv.Quote(s.Contains))
+	}
+	if s.Regex != "" {
+		b.WriteString(` |~ ` + strconv.Quote(s.Regex))
+	}
+
+	return b.String()
+}
+
+// labelAlternation returns an escaped regex matching any of the values exactly
+func labelAlternation(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = regexp.QuoteMeta(v)
+	}
+	// The alternation is embedded in a LogQL string literal, so escape
+	// backslashes and quotes once more
+	alt := strings.Join(quoted, "|")
+	alt = strings.ReplaceAll(alt, `\`, `\\`)
+	return strings.ReplaceAll(alt, `"`, `\"`)
+}
+
+// SearchLogs runs a log search against Loki
+func (m *Manager) SearchLogs(ctx context.Context, search *LogSearch) ([]LogEntry, error) {
+	direction := "backward"
+	if search.Forward {
+		direction = "forward"
+	}
+
+	params := url.Values{}
+	params.Set("query", search.LogQL())
+	params.Set("start", strconv.FormatInt(search.Start.UnixNano(), 10))
+	params.Set("end", strconv.FormatInt(search.End.UnixNano(), 10))
+	params.Set("limit", strconv.Itoa(search.Limit))
+	params.Set("direction", direction)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.GetLokiURL()+"/loki/api/v1/query_range?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Loki request: %w", err)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Loki: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("loki returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var lokiResp struct {
+		Data struct {
+			Result []struct {
+				Stream map[string]string `json:"stream"`
+				Values [][]string        `json:"values"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&lokiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode Loki response: %w", err)
+	}
+
+	var entries []LogEntry
+	for _, stream := range lokiResp.Data.Result {
+		for _, value := range stream.Values {
+			if len(value) < 2 {
+				continue
+			}
+			ns, err := strconv.ParseInt(value[0], 10, 64)
+			if err != nil {
+				continue
+			}
+			entries = append(entries, LogEntry{
+				Timestamp: time.Unix(0, ns),
+				Line:      value[1],
+				Labels:    stream.Stream,
+			})
+		}
+	}
+
+	// Loki groups results by stream; interleave them chronologically
+	sort.SliceStable(entries, func(i, j int) bool {
+		if search.Forward {
+			return entries[i].Timestamp.Before(entries[j].Timestamp)
+		}
+		return entries[i].Timestamp.After(entries[j].Timestamp)
+	})
+	if len(entries) > search.Limit {
+		entries = entries[:search.Limit]
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,87 @@
+package observability
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLogSearch_Validate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		search  LogSearch
+		wantErr bool
+	}{
+		{name: "defaults", search: LogSearch{}},
+		{name: "apps and services", search: LogSearch{AppIDs: []string{"a"}, Containers: []string{"schooner-loki"}}, wantErr: true},
+		{name: "text and regex", search: LogSearch{Contains: "x", Regex: "y"}, wantErr: true},
+		{name: "bad regex", search: LogSearch{Regex: "("}, wantErr: true},
+		{name: "start after end", search: LogSearch{Start: now, End: now.Add(-time.Minute)}, wantErr: true},
+		{name: "range too wide", search: LogSearch{Start: now.Add(-8 * 24 * time.Hour)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.search.Validate(now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLogSearch_ValidateDefaults(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := LogSearch{End: now.Add(time.Hour), Limit: MaxLogSearchLimit + 1}
+
+	if err := s.Validate(now); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if !s.End.Equal(now) {
+		t.Errorf("End = %v, want clamped to %v", s.End, now)
+	}
+	if !s.Start.Equal(now.Add(-time.Hour)) {
+		t.Errorf("Start = %v, want one hour before end", s.Start)
+	}
+	if s.Limit != MaxLogSearchLimit {
+		t.Errorf("Limit = %d, want %d", s.Limit, MaxLogSearchLimit)
+	}
+}
+
+func TestLogSearch_LogQL(t *testing.T) {
+	tests := []struct {
+		name     string
+		search   LogSearch
+		expected string
+	}{
+		{
+			name:     "all containers",
+			search:   LogSearch{},
+			expected: `{container=~".+"}`,
+		},
+		{
+			name:     "apps with text",
+			search:   LogSearch{AppIDs: []string{"a1", "b2"}, Contains: `say "hi"`},
+			expected: `{app_id=~"a1|b2"} |= "say \"hi\""`,
+		},
+		{
+			name:     "services with regex",
+			search:   LogSearch{Containers: []string{"schooner-loki"}, Regex: `level=(warn|error)`},
+			expected: `{container=~"schooner-loki"} |~ "level=(warn|error)"`,
+		},
+		{
+			name:     "label values are escaped",
+			search:   LogSearch{AppIDs: []string{`a.b"c`}},
+			expected: `{app_id=~"a\\.b\"c"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.search.LogQL(); got != tt.expected {
+				t.Errorf("LogQL() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
@@ -40,6 +40,12 @@ type SettingsGetter interface {
 	Get(ctx context.Context, key string) (string, error)
 }
 
+// SettingsStore interface for reading and writing settings in the database
+type SettingsStore interface {
+	SettingsGetter
+	Set(ctx context.Context, key, value string) error
+}
+
 // StackStatus represents the status of the observability stack
 type StackStatus struct {
 	Enabled        bool                    `json:"enabled"`
@@ -47,13 +53,20 @@ type StackStatus struct {
 	PromtailStatus *docker.ContainerStatus `json:"promtail_status,omitempty"`
 	GrafanaStatus  *docker.ContainerStatus `json:"grafana_status,omitempty"`
 	GrafanaURL     string                  `json:"grafana_url,omitempty"`
+
+	// Optional metrics stack (Prometheus, node-exporter, cAdvisor)
+	MetricsEnabled     bool                    `json:"metrics_enabled"`
+	PrometheusStatus   *docker.ContainerStatus `json:"prometheus_status,omitempty"`
+	NodeExporterStatus *docker.ContainerStatus `json:"node_exporter_status,omitempty"`
+	CadvisorStatus     *docker.ContainerStatus `json:"cadvisor_status,omitempty"`
 }
 
-// Manager manages the observability stack (Loki, Promtail, Grafana)
+// Manager manages the observability stack (Loki, Promtail, Grafana) and the
+// optional metrics stack (Prometheus, node-exporter, cAdvisor)
 type Manager struct {
 	cfg             *config.Config
 	dockerClient    *docker.Client
-	settingsQueries SettingsGetter
+	settingsQueries SettingsStore
 	mu              sync.Mutex
 }
 
@@ -66,7 +79,7 @@ func NewManager(cfg *config.Config, dockerClient *docker.Client) *Manager {
 }
 
 // SetSettingsQueries sets the settings queries for database-driven config
-func (m *Manager) SetSettingsQueries(sq SettingsGetter) {
+func (m *Manager) SetSettingsQueries(sq SettingsStore) {
 	m.settingsQueries = sq
 }
 
@@ -118,6 +131,7 @@ func (m *Manager) IsEnabled(ctx context.Context) bool {
 // Start starts the observability stack (Loki, Promtail, Grafana)
 func (m *Manager) Start(ctx context.Context) error {
 	enabled, grafanaPort, lokiRetention, configDir := m.getConfig(ctx)
+	metricsEnabled, prometheusRetention := m.getMetricsConfig(ctx)
 
 	if !enabled {
 		return fmt.Errorf("observability is not enabled")
@@ -149,6 +163,9 @@ func (m *Manager) Start(ctx context.Context) error {
 	if err := m.writeConfigs(configDir, lokiRetention); err != nil {
 		return fmt.Errorf("failed to write configs: %w", err)
 	}
+	if err := m.writeMetricsConfigs(configDir, metricsEnabled); err != nil {
+		return fmt.Errorf("failed to write metrics configs: %w", err)
+	}
 
 	// Start Loki
 	if err := m.startLoki(ctx, configDir); err != nil {
@@ -165,6 +182,15 @@ func (m *Manager) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to start Promtail: %w", err)
 	}
 
+	// Start or remove the metrics stack before Grafana so its datasource is reachable
+	if metricsEnabled {
+		if err := m.startMetricsStack(ctx, prometheusRetention); err != nil {
+			return err
+		}
+	} else {
+		m.removeMetricsStack(ctx)
+	}
+
 	// Start Grafana
 	if err := m.startGrafana(ctx, configDir, grafanaPort); err != nil {
 		return fmt.Errorf("failed to start Grafana: %w", err)
@@ -269,6 +295,11 @@ func (m *Manager) startGrafana(ctx context.Context, configDir string, port int)
 		}
 	}
 
+	authEnv, err := m.grafanaAuthEnv(ctx, configDir)
+	if err != nil {
+		return fmt.Errorf("failed to configure Grafana auth: %w", err)
+	}
+
 	// Use the schooner-data volume for config files
 	containerConfig := docker.ContainerConfig{
 		Name:  grafanaContainer,
@@ -284,14 +315,11 @@ func (m *Manager) startGrafana(ctx context.Context, configDir string, port int)
 			grafanaVolumeData:  "/var/lib/grafana",
 			schoonerDataVolume: "/schooner-data",
 		},
-		Env: []string{
+		Env: append([]string{
 			"GF_SECURITY_ADMIN_PASSWORD=" + adminPassword,
-			"GF_AUTH_ANONYMOUS_ENABLED=true",
-			"GF_AUTH_ANONYMOUS_ORG_ROLE=Admin",
 			"GF_USERS_ALLOW_SIGN_UP=false",
-			"GF_AUTH_DISABLE_LOGIN_FORM=true",
 			"GF_PATHS_PROVISIONING=/schooner-data/observability/grafana-provisioning",
-		},
+		}, authEnv...),
 		Networks:      []string{observabilityNetwork},
 		RestartPolicy: "unless-stopped",
 	}
@@ -320,6 +348,10 @@ func (m *Manager) Stop(ctx context.Context) error {
 		errs = append(errs, err)
 	}
 
+	if m.IsMetricsEnabled(ctx) {
+		errs = append(errs, m.stopMetricsStack(ctx)...)
+	}
+
 	if err := m.dockerClient.StopContainer(ctx, promtailContainer, 10); err != nil {
 		slog.Warn("failed to stop Promtail", "error", err)
 		errs = append(errs, err)
@@ -359,6 +391,13 @@ func (m *Manager) GetStatus(ctx context.Context) (*StackStatus, error) {
 	status.PromtailStatus = promtailStatus
 	status.GrafanaStatus = grafanaStatus
 
+	status.MetricsEnabled = m.IsMetricsEnabled(ctx)
+	if status.MetricsEnabled {
+		status.PrometheusStatus, _ = m.dockerClient.GetContainerStatus(ctx, prometheusContainer)
+		status.NodeExporterStatus, _ = m.dockerClient.GetContainerStatus(ctx, nodeExporterContainer)
+		status.CadvisorStatus, _ = m.dockerClient.GetContainerStatus(ctx, cadvisorContainer)
+	}
+
 	if grafanaStatus != nil && grafanaStatus.State == "running" {
 		status.GrafanaURL = fmt.Sprintf("%s:%d", m.getExternalHost(), grafanaPort)
 	}
@@ -374,10 +413,10 @@ func (m *Manager) GetGrafanaURL(ctx context.Context) string {
 
 // getExternalHost returns the scheme and hostname from the base URL (without port)
 func (m *Manager) getExternalHost() string {
-	if m.cfg.Server.BaseURL == "" {
+	if m.cfg.BaseURL() == "" {
 		return "http://localhost"
 	}
-	parsed, err := url.Parse(m.cfg.Server.BaseURL)
+	parsed, err := url.Parse(m.cfg.BaseURL())
 	if err != nil {
 		return "http://localhost"
 	}
@@ -420,6 +459,7 @@ func (m *Manager) writeConfigs(configDir, lokiRetention string) error {
 		"schooner-logs.json":     getSchoonerDashboard(),
 		"schooner-errors.json":   getErrorsDashboard(),
 		"schooner-services.json": getServicesDashboard(),
+		"schooner-app.json":      getAppDashboard(),
 	}
 
 	for filename, content := range dashboards {
@@ -0,0 +1,230 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"schooner/internal/docker"
+)
+
+const (
+	prometheusImage   = "prom/prometheus:v2.48.0"
+	nodeExporterImage = "prom/node-exporter:v1.7.0"
+	cadvisorImage     = "gcr.io/cadvisor/cadvisor:v0.47.2"
+
+	prometheusContainer   = "schooner-prometheus"
+	nodeExporterContainer = "schooner-node-exporter"
+	cadvisorContainer     = "schooner-cadvisor"
+
+	prometheusVolumeData       = "schooner-prometheus-data"
+	defaultPrometheusRetention = "15d"
+)
+
+// metricsDashboards maps provisioned dashboard filenames to their content.
+// These are only written while the metrics stack is enabled.
+var metricsDashboards = map[string]func() string{
+	"schooner-host.json":       getHostDashboard,
+	"schooner-containers.json": getContainersDashboard,
+}
+
+// getMetricsConfig loads the metrics stack configuration from database or config file
+func (m *Manager) getMetricsConfig(ctx context.Context) (enabled bool, retention string) {
+	retention = defaultPrometheusRetention
+
+	if m.settingsQueries != nil {
+		if e, err := m.settingsQueries.Get(ctx, "observability_metrics_enabled"); err == nil && e == "true" {
+			enabled = true
+		}
+		if r, err := m.settingsQueries.Get(ctx, "observability_prometheus_retention"); err == nil && r != "" {
+			retention = r
+		}
+	}
+
+	if m.cfg.Observability.MetricsEnabled && !enabled {
+		enabled = true
+	}
+	if m.cfg.Observability.PrometheusRetention != "" && retention == defaultPrometheusRetention {
+		retention = m.cfg.Observability.PrometheusRetention
+	}
+
+	return
+}
+
+// IsMetricsEnabled returns true if the Prometheus metrics stack is enabled
+func (m *Manager) IsMetricsEnabled(ctx context.Context) bool {
+	enabled, _ := m.getMetricsConfig(ctx)
+	return enabled
+}
+
+// startMetricsStack starts node-exporter, cAdvisor and Prometheus
+func (m *Manager) startMetricsStack(ctx context.Context, retention string) error {
+	if err := m.startNodeExporter(ctx); err != nil {
+		return fmt.Errorf("failed to start node-exporter: %w", err)
+	}
+	if err := m.startCadvisor(ctx); err != nil {
+		return fmt.Errorf("failed to start cAdvisor: %w", err)
+	}
+	if err := m.startPrometheus(ctx, retention); err != nil {
+		return fmt.Errorf("failed to start Prometheus: %w", err)
+	}
+	return nil
+}
+
+// stopMetricsStack stops the metrics containers, returning any errors
+func (m *Manager) stopMetricsStack(ctx context.Context) []error {
+	var errs []error
+	for _, name := range []string{prometheusContainer, cadvisorContainer, nodeExporterContainer} {
+		if err := m.dockerClient.StopContainer(ctx, name, 10); err != nil {
+			slog.Warn("failed to stop metrics container", "container", name, "error", err)
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// removeMetricsStack stops and removes metrics containers left over from a previous run
+func (m *Manager) removeMetricsStack(ctx context.Context) {
+	for _, name := range []string{prometheusContainer, cadvisorContainer, nodeExporterContainer} {
+		_ = m.dockerClient.StopContainer(ctx, name, 10)
+		_ = m.dockerClient.RemoveContainer(ctx, name)
+	}
+}
+
+// startPrometheus starts the Prometheus container
+func (m *Manager) startPrometheus(ctx context.Context, retention string) error {
+	_ = m.dockerClient.StopContainer(ctx, prometheusContainer, 10)
+	_ = m.dockerClient.RemoveContainer(ctx, prometheusContainer)
+
+	containerConfig := docker.ContainerConfig{
+		Name:  prometheusContainer,
+		Image: prometheusImage,
+		Cmd: []string{
+			"--config.file=/schooner-data/observability/prometheus.yml",
+			"--storage.tsdb.path=/prometheus",
+			"--storage.tsdb.retention.time=" + retention,
+		},
+		Labels: map[string]string{
+			"schooner.managed": "true",
+			"schooner.service": "prometheus",
+		},
+		Volumes: map[string]string{
+			prometheusVolumeData: "/prometheus",
+			schoonerDataVolume:   "/schooner-data",
+		},
+		Networks:      []string{observabilityNetwork},
+		RestartPolicy: "unless-stopped",
+	}
+
+	containerID, err := m.dockerClient.CreateAndStartContainer(ctx, containerConfig)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Prometheus started", "container_id", containerID[:12], "retention", retention)
+	return nil
+}
+
+// startNodeExporter starts the node-exporter container with read-only access to the host
+func (m *Manager) startNodeExporter(ctx context.Context) error {
+	_ = m.dockerClient.StopContainer(ctx, nodeExporterContainer, 10)
+	_ = m.dockerClient.RemoveContainer(ctx, nodeExporterContainer)
+
+	containerConfig := docker.ContainerConfig{
+		Name:  nodeExporterContainer,
+		Image: nodeExporterImage,
+		Cmd:   []string{"--path.rootfs=/host"},
+		Labels: map[string]string{
+			"schooner.managed": "true",
+			"schooner.service": "node-exporter",
+		},
+		Volumes: map[string]string{
+			"/": "/host:ro,rslave",
+		},
+		PidMode:       "host",
+		Networks:      []string{observabilityNetwork},
+		RestartPolicy: "unless-stopped",
+	}
+
+	containerID, err := m.dockerClient.CreateAndStartContainer(ctx, containerConfig)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("node-exporter started", "container_id", containerID[:12])
+	return nil
+}
+
+// startCadvisor starts the cAdvisor container for per-container resource metrics
+func (m *Manager) startCadvisor(ctx context.Context) error {
+	_ = m.dockerClient.StopContainer(ctx, cadvisorContainer, 10)
+	_ = m.dockerClient.RemoveContainer(ctx, cadvisorContainer)
+
+	containerConfig := docker.ContainerConfig{
+		Name:  cadvisorContainer,
+		Image: cadvisorImage,
+		Cmd:   []string{"--docker_only=true", "--housekeeping_interval=15s"},
+		Labels: map[string]string{
+			"schooner.managed": "true",
+			"schooner.service": "cadvisor",
+		},
+		Volumes: map[string]string{
+			"/":                "/rootfs:ro",
+			"/var/run":         "/var/run:ro",
+			"/sys":             "/sys:ro",
+			"/var/lib/docker/": "/var/lib/docker:ro",
+			"/dev/disk/":       "/dev/disk:ro",
+		},
+		Devices:       []string{"/dev/kmsg"},
+		Privileged:    true,
+		Networks:      []string{observabilityNetwork},
+		RestartPolicy: "unless-stopped",
+	}
+
+	containerID, err := m.dockerClient.CreateAndStartContainer(ctx, containerConfig)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("cAdvisor started", "container_id", containerID[:12])
+	return nil
+}
+
+// writeMetricsConfigs writes or removes Prometheus config, datasource and dashboards
+func (m *Manager) writeMetricsConfigs(configDir string, enabled bool) error {
+	provisioning := filepath.Join(configDir, "grafana-provisioning")
+	datasourcePath := filepath.Join(provisioning, "datasources", "prometheus.yaml")
+
+	if !enabled {
+		// Remove provisioned files so Grafana doesn't show broken dashboards
+		_ = os.Remove(datasourcePath)
+		for filename := range metricsDashboards {
+			_ = os.Remove(filepath.Join(provisioning, "dashboards", filename))
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(filepath.Join(configDir, "prometheus.yml"), []byte(getPrometheusConfig()), 0644); err != nil {
+		return fmt.Errorf("failed to write Prometheus config: %w", err)
+	}
+
+	if err := os.WriteFile(datasourcePath, []byte(getPrometheusDatasourceConfig()), 0644); err != nil {
+		return fmt.Errorf("failed to write Prometheus datasource config: %w", err)
+	}
+
+	for filename, content := range metricsDashboards {
+		path := filepath.Join(provisioning, "dashboards", filename)
+		if err := os.WriteFile(path, []byte(content()), 0644); err != nil {
+			return fmt.Errorf("failed to write dashboard %s: %w", filename, err)
+		}
+	}
+
+	return nil
+}
+
+// GetPrometheusURL returns the internal Prometheus URL (for API queries)
+func (m *Manager) GetPrometheusURL() string {
+	return fmt.Sprintf("http://%s:9090", prometheusContainer)
+}
@@ -0,0 +1,49 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"schooner/internal/docker"
+)
+
+// Reconcile starts the stack again when it's enabled but one of its
+// containers isn't running. It returns what it fixed.
+func (m *Manager) Reconcile(ctx context.Context) ([]string, error) {
+	if !m.IsEnabled(ctx) {
+		return nil, nil
+	}
+	status, err := m.GetStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	containers := map[string]*docker.ContainerStatus{
+		"loki":     status.LokiStatus,
+		"promtail": status.PromtailStatus,
+		"grafana":  status.GrafanaStatus,
+	}
+	if status.MetricsEnabled {
+		containers["prometheus"] = status.PrometheusStatus
+		containers["node-exporter"] = status.NodeExporterStatus
+		containers["cadvisor"] = status.CadvisorStatus
+	}
+
+	var down []string
+	for name, c := range containers {
+		if c == nil || c.State != "running" {
+			down = append(down, name)
+		}
+	}
+	sort.Strings(down)
+	if len(down) == 0 {
+		return nil, nil
+	}
+
+	if err := m.Start(ctx); err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("restarted observability stack, %s wasn't running", strings.Join(down, ", "))}, nil
+}
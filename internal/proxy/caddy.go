@@ -0,0 +1,382 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"schooner/internal/config"
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+const (
+	caddyImage     = "caddy:2-alpine"
+	caddyContainer = "schooner-caddy"
+
+	// defaultCaddyConfigDir is where schooner writes the Caddyfile; it is
+	// inside the schooner data volume, mounted into Caddy at /schooner-data
+	defaultCaddyConfigDir = "/data/caddy"
+	caddyfileMountPath    = "/schooner-data/caddy/Caddyfile"
+
+	// Docker named volumes
+	caddyDataVolume    = "schooner-caddy-data" // certificates and ACME account
+	schoonerDataVolume = "schooner_schooner-data"
+
+	// Settings keys
+	caddyDomainKey = "caddy_domain"
+	caddyEmailKey  = "caddy_email"
+)
+
+// AppLister interface for loading the apps to route
+type AppLister interface {
+	ListEnabled(ctx context.Context) ([]*models.App, error)
+}
+
+// Site is a hostname Caddy serves and the upstream it proxies to
+type Site struct {
+	Hostname      string
+	Path          string // Optional path prefix, e.g. "/api"
+	Upstream      string
+	BasicAuthUser string // Optional credentials required before proxying
+	BasicAuthHash string // bcrypt hash of the password
+}
+
+// CaddyManager runs a Caddy container that terminates TLS with automatic
+// Let's Encrypt certificates and proxies each app's subdomain to its port
+type CaddyManager struct {
+	cfg             *config.Config
+	dockerClient    *docker.Client
+	settingsQueries SettingsGetter
+	appQueries      AppLister
+	mu              sync.Mutex
+	configDir       string
+	stopped         bool // Stopped on purpose, so Reconcile leaves it down
+}
+
+// NewCaddyManager creates a new Caddy manager
+func NewCaddyManager(cfg *config.Config, dockerClient *docker.Client) *CaddyManager {
+	return &CaddyManager{
+		cfg:          cfg,
+		dockerClient: dockerClient,
+		configDir:    defaultCaddyConfigDir,
+	}
+}
+
+// SetSettingsQueries sets the settings queries for database-driven config
+func (m *CaddyManager) SetSettingsQueries(sq SettingsGetter) {
+	m.settingsQueries = sq
+}
+
+// SetAppQueries sets the app queries for loading apps
+func (m *CaddyManager) SetAppQueries(aq AppLister) {
+	m.appQueries = aq
+}
+
+// getCaddyConfig loads Caddy settings from database or config file
+func (m *CaddyManager) getCaddyConfig(ctx context.Context) (domain, email string) {
+	if m.settingsQueries != nil {
+		if d, err := m.settingsQueries.Get(ctx, caddyDomainKey); err == nil && d != "" {
+			domain = d
+		}
+		if e, err := m.settingsQueries.Get(ctx, caddyEmailKey); err == nil && e != "" {
+			email = e
+		}
+	}
+
+	if domain == "" {
+		domain = m.cfg.Caddy.Domain
+	}
+	if email == "" {
+		email = m.cfg.Caddy.Email
+	}
+	return
+}
+
+// IsConfigured returns true if a domain is set for Caddy
+func (m *CaddyManager) IsConfigured() bool {
+	domain, _ := m.getCaddyConfig(context.Background())
+	return domain != ""
+}
+
+// Start writes the Caddyfile and starts the Caddy container
+func (m *CaddyManager) Start(ctx context.Context) error {
+	if !m.IsConfigured() {
+		return fmt.Errorf("caddy not configured: domain is required")
+	}
+
+	if err := m.Reload(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopped = false
+
+	status, _ := m.dockerClient.GetContainerStatus(ctx, caddyContainer)
+	if status != nil && status.State == "running" {
+		slog.Info("caddy already running")
+		return nil
+	}
+
+	_ = m.dockerClient.StopContainer(ctx, caddyContainer, 10)
+	_ = m.dockerClient.RemoveContainer(ctx, caddyContainer)
+
+	// --watch makes Caddy reload whenever the Caddyfile changes, so route
+	// updates don't need a restart
+	containerConfig := docker.ContainerConfig{
+		Name:  caddyContainer,
+		Image: caddyImage,
+		Cmd: []string{
+			"caddy", "run",
+			"--config", caddyfileMountPath,
+			"--adapter", "caddyfile",
+			"--watch",
+		},
+		Labels: map[string]string{
+			"schooner.managed": "true",
+			"schooner.service": "caddy",
+		},
+		Ports: map[string]string{
+			"80":  "80",
+			"443": "443",
+		},
+		Volumes: map[string]string{
+			caddyDataVolume:    "/data",
+			schoonerDataVolume: "/schooner-data",
+		},
+		RestartPolicy: "unless-stopped",
+	}
+
+	containerID, err := m.dockerClient.CreateAndStartContainer(ctx, containerConfig)
+	if err != nil {
+		return fmt.Errorf("failed to start caddy: %w", err)
+	}
+
+	slog.Info("caddy started", "container_id", containerID[:12])
+	return nil
+}
+
+// Stop stops the Caddy container
+func (m *CaddyManager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopped = true
+
+	if err := m.dockerClient.StopContainer(ctx, caddyContainer, 30); err != nil {
+		return fmt.Errorf("failed to stop caddy: %w", err)
+	}
+
+	slog.Info("caddy stopped")
+	return nil
+}
+
+// Reconcile starts Caddy again when it's configured but its container isn't
+// running, unless it was stopped from the settings. It returns what it
+// fixed.
+func (m *CaddyManager) Reconcile(ctx context.Context) ([]string, error) {
+	if !m.IsConfigured() {
+		return nil, nil
+	}
+	m.mu.Lock()
+	stopped := m.stopped
+	m.mu.Unlock()
+	if stopped {
+		return nil, nil
+	}
+
+	status, _ := m.dockerClient.GetContainerStatus(ctx, caddyContainer)
+	if status != nil && status.State == "running" {
+		return nil, nil
+	}
+	if err := m.Start(ctx); err != nil {
+		return nil, err
+	}
+	return []string{"started caddy"}, nil
+}
+
+// Reload rewrites the Caddyfile from the enabled apps. A running Caddy picks
+// up the change on its own.
+func (m *CaddyManager) Reload(ctx context.Context) error {
+	if !m.IsConfigured() {
+		return nil
+	}
+
+	if m.appQueries == nil {
+		return fmt.Errorf("app queries not configured")
+	}
+
+	apps, err := m.appQueries.ListEnabled(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list apps: %w", err)
+	}
+
+	domain, email := m.getCaddyConfig(ctx)
+	sites := append(m.schoonerSites(ctx, domain), appSites(apps, domain)...)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := os.MkdirAll(m.configDir, 0755); err != nil {
+		return fmt.Errorf("failed to create caddy config dir: %w", err)
+	}
+
+	path := filepath.Join(m.configDir, "Caddyfile")
+	if err := os.WriteFile(path, []byte(GenerateCaddyfile(email, sites)), 0644); err != nil {
+		return fmt.Errorf("failed to write Caddyfile: %w", err)
+	}
+
+	slog.Info("caddy routes updated", "count", len(sites))
+	return nil
+}
+
+// GetStatus returns the Caddy container status
+func (m *CaddyManager) GetStatus(ctx context.Context) (*docker.ContainerStatus, error) {
+	if !m.IsConfigured() {
+		return nil, nil
+	}
+	return m.dockerClient.GetContainerStatus(ctx, caddyContainer)
+}
+
+// PublicURL returns the URL an app is served at by Caddy, or empty if the
+// app has no subdomain or no domain is configured
+func (m *CaddyManager) PublicURL(ctx context.Context, app *models.App) string {
+	domain, _ := m.getCaddyConfig(ctx)
+	subdomain := app.GetSubdomain()
+	if domain == "" || subdomain == "" || app.GetPublicPort() == 0 {
+		return ""
+	}
+	return fmt.Sprintf("https://%s.%s%s", subdomain, domain, app.GetRoutePath())
+}
+
+// schoonerSites returns the sites served by schooner itself: the dashboard
+// host from base_url and the public status page subdomain, if set
+func (m *CaddyManager) schoonerSites(ctx context.Context, domain string) []Site {
+	upstream := fmt.Sprintf("host.docker.internal:%d", m.cfg.Server.Port)
+
+	var sites []Site
+	if m.cfg.BaseURL() != "" {
+		if parsed, err := url.Parse(m.cfg.BaseURL()); err == nil && parsed.Hostname() != "" {
+			sites = append(sites, Site{Hostname: parsed.Hostname(), Upstream: upstream})
+		}
+	}
+
+	if m.settingsQueries != nil {
+		if sub, err := m.settingsQueries.Get(ctx, "status_page_subdomain"); err == nil && sub != "" {
+			sites = append(sites, Site{Hostname: sub + "." + domain, Upstream: upstream})
+		}
+	}
+
+	return sites
+}
+
+// appSites returns a site for each enabled app with a subdomain and port.
+// Protected apps are guarded with basic auth, or left unrouted without it.
+func appSites(apps []*models.App, domain string) []Site {
+	var sites []Site
+	for _, app := range apps {
+		subdomain := app.GetSubdomain()
+		port := app.GetPublicPort()
+		if !app.Enabled || subdomain == "" || port == 0 {
+			continue
+		}
+		site := Site{
+			Hostname: subdomain + "." + domain,
+			Path:     app.GetRoutePath(),
+			Upstream: fmt.Sprintf("host.docker.internal:%d", port),
+		}
+		if app.Protected {
+			if !app.HasBasicAuth() {
+				slog.Warn("not routing protected app through caddy: basic auth credentials are required", "app", app.Name)
+				continue
+			}
+			site.BasicAuthUser, site.BasicAuthHash = app.BasicAuth()
+		}
+		sites = append(sites, site)
+	}
+
+	sort.Slice(sites, func(i, j int) bool { return sites[i].Hostname < sites[j].Hostname })
+	return sites
+}
+
+// GenerateCaddyfile renders a Caddyfile with one site block per hostname.
+// Caddy obtains and renews a Let's Encrypt certificate for each site; email
+// is used for the ACME account and expiry notices. Sites sharing a hostname
+// are split by path prefix, with the first site winning any duplicate.
+func GenerateCaddyfile(email string, sites []Site) string {
+	var b strings.Builder
+
+	b.WriteString("# Managed by Schooner - changes will be overwritten\n")
+	if email != "" {
+		fmt.Fprintf(&b, "{\n\temail %s\n}\n", email)
+	}
+
+	var hostnames []string
+	byHost := make(map[string][]Site)
+	for _, site := range sites {
+		if _, ok := byHost[site.Hostname]; !ok {
+			hostnames = append(hostnames, site.Hostname)
+		}
+		if !hasPath(byHost[site.Hostname], site.Path) {
+			byHost[site.Hostname] = append(byHost[site.Hostname], site)
+		}
+	}
+
+	for _, hostname := range hostnames {
+		writeSiteBlock(&b, hostname, byHost[hostname])
+	}
+
+	return b.String()
+}
+
+// writeSiteBlock writes the site block for one hostname. A single whole-host
+// site proxies directly; otherwise each path prefix gets a handle block,
+// longest first, with the whole-host site as the fallback.
+func writeSiteBlock(b *strings.Builder, hostname string, sites []Site) {
+	if len(sites) == 1 && sites[0].Path == "" {
+		fmt.Fprintf(b, "\n%s {\n", hostname)
+		writeProxy(b, "\t", sites[0])
+		b.WriteString("}\n")
+		return
+	}
+
+	sort.SliceStable(sites, func(i, j int) bool { return len(sites[i].Path) > len(sites[j].Path) })
+
+	fmt.Fprintf(b, "\n%s {\n", hostname)
+	for i, site := range sites {
+		if site.Path == "" {
+			b.WriteString("\thandle {\n")
+		} else {
+			fmt.Fprintf(b, "\t@route%d path %s %s/*\n", i, site.Path, site.Path)
+			fmt.Fprintf(b, "\thandle @route%d {\n", i)
+		}
+		writeProxy(b, "\t\t", site)
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("}\n")
+}
+
+// writeProxy writes the directives serving a site, requiring its basic auth
+// credentials first if it has them
+func writeProxy(b *strings.Builder, indent string, site Site) {
+	if site.BasicAuthUser != "" {
+		fmt.Fprintf(b, "%sbasic_auth {\n%s\t%s %s\n%s}\n", indent, indent, site.BasicAuthUser, site.BasicAuthHash, indent)
+	}
+	fmt.Fprintf(b, "%sreverse_proxy %s\n", indent, site.Upstream)
+}
+
+// hasPath reports whether sites already include one for path
+func hasPath(sites []Site, path string) bool {
+	for _, site := range sites {
+		if site.Path == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"schooner/internal/config"
+	"schooner/internal/models"
+)
+
+type fakeApps []*models.App
+
+func (f fakeApps) ListEnabled(ctx context.Context) ([]*models.App, error) {
+	return f, nil
+}
+
+func testApp(name, subdomain string, port int64, enabled bool) *models.App {
+	app := &models.App{Name: name, Enabled: enabled}
+	if subdomain != "" {
+		app.Subdomain = sql.NullString{String: subdomain, Valid: true}
+	}
+	if port != 0 {
+		app.PublicPort = sql.NullInt64{Int64: port, Valid: true}
+	}
+	return app
+}
+
+func protectedApp(app *models.App) *models.App {
+	app.Protected = true
+	return app
+}
+
+func basicAuthApp(app *models.App) *models.App {
+	app.Protected = true
+	app.BasicAuthUser = sql.NullString{String: "admin", Valid: true}
+	app.BasicAuthHash = sql.NullString{String: "$2a$10$hash", Valid: true}
+	return app
+}
+
+func TestGenerateCaddyfile(t *testing.T) {
+	got := GenerateCaddyfile("admin@example.com", []Site{
+		{Hostname: "api.example.com", Upstream: "host.docker.internal:8081"},
+		{Hostname: "web.example.com", Upstream: "host.docker.internal:8082"},
+		{Hostname: "api.example.com", Upstream: "host.docker.internal:9999"},
+	})
+
+	want := `# Managed by Schooner - changes will be overwritten
+{
+	email admin@example.com
+}
+
+api.example.com {
+	reverse_proxy host.docker.internal:8081
+}
+
+web.example.com {
+	reverse_proxy host.docker.internal:8082
+}
+`
+	if got != want {
+		t.Errorf("GenerateCaddyfile() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestGenerateCaddyfile_Paths(t *testing.T) {
+	got := GenerateCaddyfile("", []Site{
+		{Hostname: "example.com", Upstream: "host.docker.internal:8080"},
+		{Hostname: "example.com", Path: "/api", Upstream: "host.docker.internal:8081"},
+		{Hostname: "example.com", Path: "/api/admin", Upstream: "host.docker.internal:8082"},
+	})
+
+	want := `# Managed by Schooner - changes will be overwritten
+
+example.com {
+	@route0 path /api/admin /api/admin/*
+	handle @route0 {
+		reverse_proxy host.docker.internal:8082
+	}
+	@route1 path /api /api/*
+	handle @route1 {
+		reverse_proxy host.docker.internal:8081
+	}
+	handle {
+		reverse_proxy host.docker.internal:8080
+	}
+}
+`
+	if got != want {
+		t.Errorf("GenerateCaddyfile() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestGenerateCaddyfile_NoEmail(t *testing.T) {
+	got := GenerateCaddyfile("", nil)
+	if strings.Contains(got, "email") {
+		t.Errorf("expected no global options block, got:\n%s", got)
+	}
+}
+
+func TestAppSites(t *testing.T) {
+	apps := []*models.App{
+		testApp("web", "web", 8082, true),
+		testApp("api", "api", 8081, true),
+		testApp("no-subdomain", "", 8083, true),
+		testApp("no-port", "worker", 0, true),
+		testApp("disabled", "old", 8084, false),
+		protectedApp(testApp("internal", "internal", 8085, true)),
+		basicAuthApp(testApp("admin", "admin", 8086, true)),
+	}
+
+	sites := appSites(apps, "example.com")
+	if len(sites) != 3 {
+		t.Fatalf("got %d sites, want 3: %+v", len(sites), sites)
+	}
+	if sites[0].Hostname != "admin.example.com" || sites[0].BasicAuthUser != "admin" || sites[0].BasicAuthHash != "$2a$10$hash" {
+		t.Errorf("sites[0] = %+v, want basic auth credentials", sites[0])
+	}
+	if sites[1].Hostname != "api.example.com" || sites[1].Upstream != "host.docker.internal:8081" || sites[1].BasicAuthUser != "" {
+		t.Errorf("sites[1] = %+v", sites[1])
+	}
+	if sites[2].Hostname != "web.example.com" {
+		t.Errorf("sites[2] = %+v", sites[2])
+	}
+}
+
+func TestGenerateCaddyfile_BasicAuth(t *testing.T) {
+	got := GenerateCaddyfile("", []Site{
+		{Hostname: "admin.example.com", Upstream: "host.docker.internal:8086", BasicAuthUser: "admin", BasicAuthHash: "$2a$10$hash"},
+	})
+
+	want := "admin.example.com {\n\tbasic_auth {\n\t\tadmin $2a$10$hash\n\t}\n\treverse_proxy host.docker.internal:8086\n}\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("GenerateCaddyfile() =\n%s\nwant it to contain:\n%s", got, want)
+	}
+}
+
+func TestCaddyManager_ConfigFallback(t *testing.T) {
+	cfg := &config.Config{Caddy: config.CaddyConfig{Domain: "file.example.com"}}
+	m := NewCaddyManager(cfg, nil)
+	if !m.IsConfigured() {
+		t.Fatal("expected config file domain to configure caddy")
+	}
+
+	m.SetSettingsQueries(fakeSettings{caddyDomainKey: "db.example.com"})
+	domain, _ := m.getCaddyConfig(context.Background())
+	if domain != "db.example.com" {
+		t.Errorf("domain = %q, want settings to override config", domain)
+	}
+
+	if NewCaddyManager(&config.Config{}, nil).IsConfigured() {
+		t.Error("expected caddy without a domain to be unconfigured")
+	}
+}
+
+func TestCaddyManager_Reload(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Server.Port = 7123
+	cfg.Server.BaseURL = "https://schooner.example.com"
+
+	m := NewCaddyManager(cfg, nil)
+	m.configDir = t.TempDir()
+	m.SetSettingsQueries(fakeSettings{
+		caddyDomainKey:          "example.com",
+		"status_page_subdomain": "status",
+	})
+	m.SetAppQueries(fakeApps{testApp("web", "web", 8082, true)})
+
+	if err := m.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(m.configDir, "Caddyfile"))
+	if err != nil {
+		t.Fatalf("failed to read Caddyfile: %v", err)
+	}
+	for _, want := range []string{
+		"schooner.example.com {\n\treverse_proxy host.docker.internal:7123",
+		"status.example.com {\n\treverse_proxy host.docker.internal:7123",
+		"web.example.com {\n\treverse_proxy host.docker.internal:8082",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Caddyfile missing %q:\n%s", want, data)
+		}
+	}
+
+	if got := m.PublicURL(context.Background(), testApp("web", "web", 8082, true)); got != "https://web.example.com" {
+		t.Errorf("PublicURL() = %q", got)
+	}
+}
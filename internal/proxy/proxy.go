@@ -0,0 +1,106 @@
+// Package proxy publishes apps on public hostnames. The Cloudflare tunnel,
+// the Caddy reverse proxy and Traefik labels are interchangeable providers;
+// the settings choose which one receives route changes.
+package proxy
+
+import (
+	"context"
+
+	"schooner/internal/build"
+	"schooner/internal/models"
+)
+
+const (
+	// ProviderCloudflare routes traffic through a Cloudflare Tunnel
+	ProviderCloudflare = "cloudflare"
+	// ProviderCaddy routes traffic through a local Caddy container
+	ProviderCaddy = "caddy"
+	// ProviderTraefik labels containers for an existing Traefik instance
+	ProviderTraefik = "traefik"
+
+	// ProviderSettingKey selects the active provider
+	ProviderSettingKey = "proxy_provider"
+)
+
+// Provider routes public hostnames to app ports
+type Provider interface {
+	// IsConfigured reports whether the provider has enough settings to run
+	IsConfigured() bool
+	// Reload regenerates routes from the enabled apps
+	Reload(ctx context.Context) error
+	// PublicURL returns the URL an app is exposed at, or empty if none
+	PublicURL(ctx context.Context, app *models.App) string
+}
+
+// SettingsGetter interface for getting settings from the database
+type SettingsGetter interface {
+	Get(ctx context.Context, key string) (string, error)
+}
+
+// Router forwards route management to the provider selected in settings
+type Router struct {
+	settingsQueries SettingsGetter
+	providers       map[string]Provider
+}
+
+// NewRouter creates a new Router
+func NewRouter(settingsQueries SettingsGetter) *Router {
+	return &Router{
+		settingsQueries: settingsQueries,
+		providers:       make(map[string]Provider),
+	}
+}
+
+// Register makes a provider available under name
+func (r *Router) Register(name string, provider Provider) {
+	r.providers[name] = provider
+}
+
+// ActiveName returns the selected provider name, defaulting to Cloudflare
+func (r *Router) ActiveName(ctx context.Context) string {
+	if r.settingsQueries != nil {
+		if name, err := r.settingsQueries.Get(ctx, ProviderSettingKey); err == nil && name != "" {
+			return name
+		}
+	}
+	return ProviderCloudflare
+}
+
+// active returns the selected provider, or nil if it is not registered
+func (r *Router) active(ctx context.Context) Provider {
+	return r.providers[r.ActiveName(ctx)]
+}
+
+// IsConfigured reports whether the active provider is configured
+func (r *Router) IsConfigured() bool {
+	provider := r.active(context.Background())
+	return provider != nil && provider.IsConfigured()
+}
+
+// Reload regenerates the active provider's routes
+func (r *Router) Reload(ctx context.Context) error {
+	provider := r.active(ctx)
+	if provider == nil {
+		return nil
+	}
+	return provider.Reload(ctx)
+}
+
+// PublicURL returns the URL an app is exposed at by the active provider
+func (r *Router) PublicURL(ctx context.Context, app *models.App) string {
+	provider := r.active(ctx)
+	if provider == nil {
+		return ""
+	}
+	return provider.PublicURL(ctx, app)
+}
+
+// RouteOptions returns label-based route options for an app when the active
+// provider routes by container labels, or nil otherwise
+func (r *Router) RouteOptions(ctx context.Context, app *models.App) *build.RouteOptions {
+	labeler, ok := r.active(ctx).(build.RouteLabeler)
+	if !ok {
+		return nil
+	}
+	return labeler.RouteOptions(ctx, app)
+}
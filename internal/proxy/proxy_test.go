@@ -0,0 +1,81 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	"schooner/internal/models"
+)
+
+type fakeSettings map[string]string
+
+func (f fakeSettings) Get(ctx context.Context, key string) (string, error) {
+	return f[key], nil
+}
+
+type fakeProvider struct {
+	configured bool
+	url        string
+	reloads    int
+}
+
+func (p *fakeProvider) IsConfigured() bool { return p.configured }
+
+func (p *fakeProvider) Reload(ctx context.Context) error {
+	p.reloads++
+	return nil
+}
+
+func (p *fakeProvider) PublicURL(ctx context.Context, app *models.App) string { return p.url }
+
+func TestRouter_ActiveProvider(t *testing.T) {
+	tests := []struct {
+		name       string
+		setting    string
+		wantName   string
+		wantURL    string
+		configured bool
+	}{
+		{"defaults to cloudflare", "", ProviderCloudflare, "https://cf.example.com", true},
+		{"caddy selected", ProviderCaddy, ProviderCaddy, "https://caddy.example.com", false},
+		{"unknown provider", "nginx", "nginx", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cf := &fakeProvider{configured: true, url: "https://cf.example.com"}
+			caddy := &fakeProvider{url: "https://caddy.example.com"}
+
+			router := NewRouter(fakeSettings{ProviderSettingKey: tt.setting})
+			router.Register(ProviderCloudflare, cf)
+			router.Register(ProviderCaddy, caddy)
+
+			ctx := context.Background()
+			if got := router.ActiveName(ctx); got != tt.wantName {
+				t.Errorf("ActiveName() = %q, want %q", got, tt.wantName)
+			}
+			if got := router.PublicURL(ctx, &models.App{}); got != tt.wantURL {
+				t.Errorf("PublicURL() = %q, want %q", got, tt.wantURL)
+			}
+			if got := router.IsConfigured(); got != tt.configured {
+				t.Errorf("IsConfigured() = %v, want %v", got, tt.configured)
+			}
+			if err := router.Reload(ctx); err != nil {
+				t.Fatalf("Reload() error = %v", err)
+			}
+			if tt.wantName == ProviderCaddy && (caddy.reloads != 1 || cf.reloads != 0) {
+				t.Errorf("reloads cloudflare=%d caddy=%d, want only caddy", cf.reloads, caddy.reloads)
+			}
+		})
+	}
+}
+
+func TestRouter_NoSettings(t *testing.T) {
+	router := NewRouter(nil)
+	if got := router.ActiveName(context.Background()); got != ProviderCloudflare {
+		t.Errorf("ActiveName() = %q, want %q", got, ProviderCloudflare)
+	}
+	if router.IsConfigured() {
+		t.Error("Expected router without providers to be unconfigured")
+	}
+}
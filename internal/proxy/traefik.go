@@ -0,0 +1,153 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"schooner/internal/build"
+	"schooner/internal/config"
+	"schooner/internal/models"
+)
+
+// Settings keys
+const (
+	traefikDomainKey       = "traefik_domain"
+	traefikNetworkKey      = "traefik_network"
+	traefikEntryPointKey   = "traefik_entrypoint"
+	traefikCertResolverKey = "traefik_certresolver"
+)
+
+// routerNameInvalid matches characters not allowed in Traefik router names
+var routerNameInvalid = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// TraefikLabeler routes apps through an existing Traefik instance by
+// attaching router and service labels to the containers Schooner deploys.
+// Schooner runs no proxy of its own in this mode.
+type TraefikLabeler struct {
+	cfg             *config.Config
+	settingsQueries SettingsGetter
+}
+
+// NewTraefikLabeler creates a new Traefik labeler
+func NewTraefikLabeler(cfg *config.Config) *TraefikLabeler {
+	return &TraefikLabeler{cfg: cfg}
+}
+
+// SetSettingsQueries sets the settings queries for database-driven config
+func (t *TraefikLabeler) SetSettingsQueries(sq SettingsGetter) {
+	t.settingsQueries = sq
+}
+
+// getTraefikConfig loads Traefik settings from database or config file
+func (t *TraefikLabeler) getTraefikConfig(ctx context.Context) config.TraefikConfig {
+	tc := t.cfg.Traefik
+	if t.settingsQueries == nil {
+		return tc
+	}
+
+	for key, field := range map[string]*string{
+		traefikDomainKey:       &tc.Domain,
+		traefikNetworkKey:      &tc.Network,
+		traefikEntryPointKey:   &tc.EntryPoint,
+		traefikCertResolverKey: &tc.CertResolver,
+	} {
+		if v, err := t.settingsQueries.Get(ctx, key); err == nil && v != "" {
+			*field = v
+		}
+	}
+	return tc
+}
+
+// IsConfigured returns true if a domain is set for Traefik
+func (t *TraefikLabeler) IsConfigured() bool {
+	return t.getTraefikConfig(context.Background()).Domain != ""
+}
+
+// Reload is a no-op: labels are attached at deploy time, so route changes
+// take effect on the app's next deploy
+func (t *TraefikLabeler) Reload(ctx context.Context) error {
+	return nil
+}
+
+// PublicURL returns the URL Traefik serves an app at, or empty if the app has
+// no subdomain or no domain is configured
+func (t *TraefikLabeler) PublicURL(ctx context.Context, app *models.App) string {
+	tc := t.getTraefikConfig(ctx)
+	subdomain := app.GetSubdomain()
+	if tc.Domain == "" || subdomain == "" || app.GetPublicPort() == 0 {
+		return ""
+	}
+
+	scheme := "http"
+	if tc.CertResolver != "" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s.%s%s", scheme, subdomain, tc.Domain, app.GetRoutePath())
+}
+
+// RouteOptions returns the Traefik labels for an app, or nil if the app has
+// no subdomain and port or Traefik is not configured. Protected apps are
+// guarded by a basic auth middleware and get no labels without credentials.
+func (t *TraefikLabeler) RouteOptions(ctx context.Context, app *models.App) *build.RouteOptions {
+	tc := t.getTraefikConfig(ctx)
+	subdomain := app.GetSubdomain()
+	if tc.Domain == "" || subdomain == "" || app.GetPublicPort() == 0 {
+		return nil
+	}
+	if app.Protected && !app.HasBasicAuth() {
+		slog.Warn("not routing protected app through traefik: basic auth credentials are required", "app", app.Name)
+		return nil
+	}
+
+	name := TraefikRouterName(app.Name)
+	router := "traefik.http.routers." + name
+	labels := map[string]string{
+		"traefik.enable":    "true",
+		router + ".rule":    traefikRule(subdomain+"."+tc.Domain, app.GetRoutePath()),
+		router + ".service": name,
+	}
+	if tc.EntryPoint != "" {
+		labels[router+".entrypoints"] = tc.EntryPoint
+	}
+	if tc.CertResolver != "" {
+		labels[router+".tls"] = "true"
+		labels[router+".tls.certresolver"] = tc.CertResolver
+	}
+	if tc.Network != "" {
+		// Containers on several networks need to tell Traefik which to use
+		labels["traefik.docker.network"] = tc.Network
+	}
+	if app.Protected {
+		user, hash := app.BasicAuth()
+		middleware := name + "-auth"
+		labels["traefik.http.middlewares."+middleware+".basicauth.users"] = user + ":" + hash
+		labels[router+".middlewares"] = middleware
+	}
+
+	return &build.RouteOptions{
+		PublicPort: app.GetPublicPort(),
+		Network:    tc.Network,
+		Labels:     labels,
+		PortLabel:  "traefik.http.services." + name + ".loadbalancer.server.port",
+	}
+}
+
+// traefikRule returns the router rule for a hostname and optional path
+// prefix. Traefik prefers longer rules, so path routes win over the
+// whole-host route on the same hostname.
+func traefikRule(hostname, path string) string {
+	rule := fmt.Sprintf("Host(`%s`)", hostname)
+	if path != "" {
+		rule += fmt.Sprintf(" && PathPrefix(`%s`)", path)
+	}
+	return rule
+}
+
+// TraefikRouterName derives a Traefik router and service name from an app name
+func TraefikRouterName(appName string) string {
+	name := routerNameInvalid.ReplaceAllString(strings.ToLower(appName), "-")
+	return "schooner-" + strings.Trim(name, "-")
+}
@@ -0,0 +1,133 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	"schooner/internal/config"
+)
+
+func TestTraefikLabeler_RouteOptions(t *testing.T) {
+	cfg := &config.Config{Traefik: config.TraefikConfig{Domain: "example.com"}}
+	labeler := NewTraefikLabeler(cfg)
+	labeler.SetSettingsQueries(fakeSettings{
+		traefikNetworkKey:      "proxy",
+		traefikEntryPointKey:   "websecure",
+		traefikCertResolverKey: "le",
+	})
+
+	routing := labeler.RouteOptions(context.Background(), testApp("My App", "web", 8082, true))
+	if routing == nil {
+		t.Fatal("expected route options")
+	}
+	if routing.PublicPort != 8082 || routing.Network != "proxy" {
+		t.Errorf("routing = %+v", routing)
+	}
+
+	labels := routing.LabelsFor(3000)
+	want := map[string]string{
+		"traefik.enable": "true",
+		"traefik.http.routers.schooner-my-app.rule":                      "Host(`web.example.com`)",
+		"traefik.http.routers.schooner-my-app.service":                   "schooner-my-app",
+		"traefik.http.routers.schooner-my-app.entrypoints":               "websecure",
+		"traefik.http.routers.schooner-my-app.tls":                       "true",
+		"traefik.http.routers.schooner-my-app.tls.certresolver":          "le",
+		"traefik.http.services.schooner-my-app.loadbalancer.server.port": "3000",
+		"traefik.docker.network":                                         "proxy",
+	}
+	if len(labels) != len(want) {
+		t.Errorf("got %d labels, want %d: %v", len(labels), len(want), labels)
+	}
+	for k, v := range want {
+		if labels[k] != v {
+			t.Errorf("label %s = %q, want %q", k, labels[k], v)
+		}
+	}
+
+	if got := labeler.PublicURL(context.Background(), testApp("My App", "web", 8082, true)); got != "https://web.example.com" {
+		t.Errorf("PublicURL() = %q", got)
+	}
+}
+
+func TestTraefikLabeler_NoRoute(t *testing.T) {
+	tests := []struct {
+		name   string
+		domain string
+		sub    string
+		port   int64
+	}{
+		{"no domain", "", "web", 8082},
+		{"no subdomain", "example.com", "", 8082},
+		{"no port", "example.com", "web", 0},
+	}
+
+	labeler := NewTraefikLabeler(&config.Config{Traefik: config.TraefikConfig{Domain: "example.com"}})
+	if got := labeler.RouteOptions(context.Background(), protectedApp(testApp("web", "web", 8082, true))); got != nil {
+		t.Errorf("RouteOptions() for protected app without basic auth = %+v, want nil", got)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labeler := NewTraefikLabeler(&config.Config{Traefik: config.TraefikConfig{Domain: tt.domain}})
+			if got := labeler.RouteOptions(context.Background(), testApp("web", tt.sub, tt.port, true)); got != nil {
+				t.Errorf("RouteOptions() = %+v, want nil", got)
+			}
+		})
+	}
+}
+
+func TestTraefikLabeler_BasicAuth(t *testing.T) {
+	labeler := NewTraefikLabeler(&config.Config{Traefik: config.TraefikConfig{Domain: "example.com"}})
+
+	opts := labeler.RouteOptions(context.Background(), basicAuthApp(testApp("admin", "admin", 8086, true)))
+	if opts == nil {
+		t.Fatal("RouteOptions() = nil, want labels for a protected app with basic auth")
+	}
+	if got := opts.Labels["traefik.http.routers.schooner-admin.middlewares"]; got != "schooner-admin-auth" {
+		t.Errorf("router middlewares = %q", got)
+	}
+	if got := opts.Labels["traefik.http.middlewares.schooner-admin-auth.basicauth.users"]; got != "admin:$2a$10$hash" {
+		t.Errorf("basicauth users = %q", got)
+	}
+}
+
+func TestRouter_RouteOptions(t *testing.T) {
+	labeler := NewTraefikLabeler(&config.Config{Traefik: config.TraefikConfig{Domain: "example.com"}})
+	app := testApp("web", "web", 8082, true)
+
+	router := NewRouter(fakeSettings{ProviderSettingKey: ProviderTraefik})
+	router.Register(ProviderTraefik, labeler)
+	if router.RouteOptions(context.Background(), app) == nil {
+		t.Error("expected route options when traefik is active")
+	}
+
+	router = NewRouter(fakeSettings{ProviderSettingKey: ProviderCaddy})
+	router.Register(ProviderTraefik, labeler)
+	router.Register(ProviderCaddy, &fakeProvider{})
+	if router.RouteOptions(context.Background(), app) != nil {
+		t.Error("expected no route options when caddy is active")
+	}
+}
+
+func TestTraefikRule(t *testing.T) {
+	if got := traefikRule("web.example.com", ""); got != "Host(`web.example.com`)" {
+		t.Errorf("traefikRule() = %q", got)
+	}
+	if got := traefikRule("web.example.com", "/api"); got != "Host(`web.example.com`) && PathPrefix(`/api`)" {
+		t.Errorf("traefikRule() with path = %q", got)
+	}
+}
+
+func TestTraefikRouterName(t *testing.T) {
+	tests := map[string]string{
+		"web":        "schooner-web",
+		"My App":     "schooner-my-app",
+		"api_v2.svc": "schooner-api-v2-svc",
+		"--edge--":   "schooner-edge",
+	}
+	for in, want := range tests {
+		if got := TraefikRouterName(in); got != want {
+			t.Errorf("TraefikRouterName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+
+	"schooner/internal/build"
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+// recreateBackoff is how long the reconciler waits before redeploying an
+// app whose container went missing again, so a deploy that keeps failing
+// isn't retried every pass
+const recreateBackoff = time.Hour
+
+// startupDelay is how long after startup the first pass waits, leaving
+// tunnels and the proxy to their own auto-start and Docker to restart
+// containers first
+const startupDelay = time.Minute
+
+// AppLister lists the apps that should be running
+type AppLister interface {
+	ListEnabled(ctx context.Context) ([]*models.App, error)
+}
+
+// BuildLister reports the latest build of each app
+type BuildLister interface {
+	ListLatestPerApp(ctx context.Context) (map[string]*models.Build, error)
+}
+
+// Containers lists, inspects and starts app containers
+type Containers interface {
+	ListContainers(ctx context.Context, all bool, filterLabels map[string]string) ([]types.Container, error)
+	InspectContainer(ctx context.Context, nameOrID string) (types.ContainerJSON, error)
+	StartContainer(ctx context.Context, nameOrID string) error
+}
+
+// DockerHostResolver returns the Docker client for the host an app runs on
+type DockerHostResolver interface {
+	ForApp(ctx context.Context, app *models.App) (*docker.Client, error)
+}
+
+// Redeployer redeploys an app whose container went missing
+type Redeployer interface {
+	RecreateContainer(ctx context.Context, app *models.App) (*models.Build, error)
+}
+
+// AddonEnsurer keeps the add-on containers of an app running
+type AddonEnsurer interface {
+	Ensure(ctx context.Context, app *models.App) ([]string, error)
+}
+
+// Service is something Schooner runs besides apps, such as a Cloudflare
+// tunnel, that can bring itself back in line with its settings
+type Service interface {
+	Reconcile(ctx context.Context) ([]string, error)
+}
+
+// ServiceFunc adapts a function to a Service
+type ServiceFunc func(ctx context.Context) ([]string, error)
+
+// Reconcile calls f
+func (f ServiceFunc) Reconcile(ctx context.Context) ([]string, error) {
+	return f(ctx)
+}
+
+// Result is what one reconcile pass found and fixed
+type Result struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Fixed      []string  `json:"fixed"`
+	Errors     []string  `json:"errors"`
+}
+
+// namedService is a Service with the name its fixes and errors are
+// reported under
+type namedService struct {
+	name    string
+	service Service
+}
+
+// Reconciler makes sure what should be running is: the container of every
+// enabled app that wasn't stopped on purpose, its add-ons, and services like
+// tunnels and the proxy. It runs at startup and then periodically, starting
+// containers that didn't come back after a reboot and redeploying apps
+// whose container is gone.
+type Reconciler struct {
+	apps         AppLister
+	builds       BuildLister
+	dockerClient Containers
+	dockerHosts  DockerHostResolver
+	redeployer   Redeployer
+	addons       AddonEnsurer
+	services     []namedService
+	logger       *slog.Logger
+
+	mu        sync.Mutex
+	running   sync.Mutex // Held during a pass, so passes don't overlap
+	last      *Result
+	recreated map[string]time.Time // When each app was last redeployed
+}
+
+// NewReconciler creates a Reconciler
+func NewReconciler(apps AppLister, builds BuildLister, dockerClient Containers) *Reconciler {
+	return &Reconciler{
+		apps:         apps,
+		builds:       builds,
+		dockerClient: dockerClient,
+		logger:       slog.Default(),
+		recreated:    make(map[string]time.Time),
+	}
+}
+
+// SetDockerHosts makes apps on remote Docker hosts be checked on their host
+func (r *Reconciler) SetDockerHosts(hosts DockerHostResolver) {
+	r.dockerHosts = hosts
+}
+
+// SetRedeployer lets apps whose container is gone be redeployed
+func (r *Reconciler) SetRedeployer(redeployer Redeployer) {
+	r.redeployer = redeployer
+}
+
+// SetAddons keeps the add-ons of apps running too
+func (r *Reconciler) SetAddons(addons AddonEnsurer) {
+	r.addons = addons
+}
+
+// AddService keeps a service running, reporting under name
+func (r *Reconciler) AddService(name string, service Service) {
+	r.services = append(r.services, namedService{name: name, service: service})
+}
+
+// Start reconciles shortly after startup and then every interval until the
+// context is cancelled. An interval of 0 only reconciles at startup.
+func (r *Reconciler) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(startupDelay):
+		}
+		r.Run(ctx)
+		if interval <= 0 {
+			return
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.Run(ctx)
+			}
+		}
+	}()
+}
+
+// Last returns the result of the latest pass, nil before the first one
+// finished
+func (r *Reconciler) Last() *Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+// Run makes one pass over the apps and services and returns what it fixed
+func (r *Reconciler) Run(ctx context.Context) *Result {
+	r.running.Lock()
+	defer r.running.Unlock()
+
+	result := &Result{StartedAt: time.Now(), Fixed: []string{}, Errors: []string{}}
+	record := func(scope string, fixed []string, err error) {
+		for _, fix := range fixed {
+			r.logger.Info("reconciled drift", "scope", scope, "fix", fix)
+			result.Fixed = append(result.Fixed, scope+": "+fix)
+		}
+		if err != nil {
+			r.logger.Warn("failed to reconcile", "scope", scope, "error", err)
+			result.Errors = append(result.Errors, scope+": "+err.Error())
+		}
+	}
+
+	if err := r.reconcileApps(ctx, record); err != nil {
+		record("apps", nil, err)
+	}
+	for _, s := range r.services {
+		fixed, err := s.service.Reconcile(ctx)
+		record(s.name, fixed, err)
+	}
+
+	result.FinishedAt = time.Now()
+	r.mu.Lock()
+	r.last = result
+	r.mu.Unlock()
+	return result
+}
+
+// reconcileApps checks the containers and add-ons of every enabled app
+func (r *Reconciler) reconcileApps(ctx context.Context, record func(string, []string, error)) error {
+	apps, err := r.apps.ListEnabled(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list apps: %w", err)
+	}
+	latest, err := r.builds.ListLatestPerApp(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list builds: %w", err)
+	}
+	sort.Slice(apps, func(i, j int) bool { return apps[i].Name < apps[j].Name })
+
+	for _, app := range apps {
+		// Apps stopped on purpose stay down, and ones never deployed or
+		// deploying right now have nothing to converge to yet
+		build := latest[app.ID]
+		if app.Stopped || build == nil || !build.IsComplete() {
+			continue
+		}
+		// Agents and Swarm keep their own containers running
+		deployConfig, _ := app.GetDeployConfig()
+		if app.GetAgent() != "" || (deployConfig != nil && deployConfig.IsSwarm()) {
+			continue
+		}
+
+		fixed, err := r.reconcileApp(ctx, app)
+		record(app.Name, fixed, err)
+		if r.addons != nil {
+			fixed, err := r.addons.Ensure(ctx, app)
+			record(app.Name, fixed, err)
+		}
+	}
+	return nil
+}
+
+// reconcileApp starts the containers of an app that should be running but
+// aren't, or redeploys the app if it has none
+func (r *Reconciler) reconcileApp(ctx context.Context, app *models.App) ([]string, error) {
+	containers, err := r.containersFor(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	list, err := containers.ListContainers(ctx, true, map[string]string{"schooner.app-id": app.ID})
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return r.recreate(ctx, app)
+	}
+
+	var fixed []string
+	var errs []error
+	for _, c := range list {
+		if c.State != "exited" && c.State != "created" {
+			continue
+		}
+		info, err := containers.InspectContainer(ctx, c.ID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !shouldRun(info) {
+			continue
+		}
+		if err := containers.StartContainer(ctx, c.ID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to start container %s: %w", containerName(c), err))
+			continue
+		}
+		fixed = append(fixed, fmt.Sprintf("started container %s", containerName(c)))
+	}
+	return fixed, errors.Join(errs...)
+}
+
+// recreate redeploys an app whose containers are gone, at most once per
+// recreateBackoff
+func (r *Reconciler) recreate(ctx context.Context, app *models.App) ([]string, error) {
+	if r.redeployer == nil {
+		return nil, nil
+	}
+	r.mu.Lock()
+	last, ok := r.recreated[app.ID]
+	r.mu.Unlock()
+	if ok && time.Since(last) < recreateBackoff {
+		return nil, nil
+	}
+
+	b, err := r.redeployer.RecreateContainer(ctx, app)
+	if errors.Is(err, build.ErrNotDeployed) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeploy: %w", err)
+	}
+	r.mu.Lock()
+	r.recreated[app.ID] = time.Now()
+	r.mu.Unlock()
+	return []string{fmt.Sprintf("container missing, redeploying in build %s", b.ID[:8])}, nil
+}
+
+// containersFor returns the Docker client for an app's host
+func (r *Reconciler) containersFor(ctx context.Context, app *models.App) (Containers, error) {
+	if r.dockerHosts == nil || app.GetDockerHost() == "" {
+		return r.dockerClient, nil
+	}
+	return r.dockerHosts.ForApp(ctx, app)
+}
+
+// shouldRun reports whether Docker was meant to keep a container that isn't
+// running up: its restart policy restarts it, or restarts it after a failure
+// and it failed without Docker giving up on it. One-off containers, such as
+// migrations that exited, stay down.
+func shouldRun(info types.ContainerJSON) bool {
+	if info.ContainerJSONBase == nil || info.HostConfig == nil {
+		return false
+	}
+	policy := info.HostConfig.RestartPolicy
+	switch policy.Name {
+	case "always", "unless-stopped":
+		return true
+	case "on-failure":
+		gaveUp := policy.MaximumRetryCount > 0 && info.RestartCount >= policy.MaximumRetryCount
+		return info.State != nil && info.State.ExitCode != 0 && !gaveUp
+	}
+	return false
+}
+
+// containerName returns a container's name without the leading slash
+func containerName(c types.Container) string {
+	if len(c.Names) == 0 {
+		return c.ID[:12]
+	}
+	return c.Names[0][1:]
+}
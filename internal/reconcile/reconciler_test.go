@@ -0,0 +1,141 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+
+	"schooner/internal/build"
+	"schooner/internal/models"
+)
+
+type fakeApps []*models.App
+
+func (f fakeApps) ListEnabled(ctx context.Context) ([]*models.App, error) {
+	return f, nil
+}
+
+type fakeBuilds map[string]*models.Build
+
+func (f fakeBuilds) ListLatestPerApp(ctx context.Context) (map[string]*models.Build, error) {
+	return f, nil
+}
+
+// fakeContainer is a container with how it was configured to restart
+type fakeContainer struct {
+	state    string
+	policy   container.RestartPolicyMode
+	exitCode int
+}
+
+// fakeContainers serves containers by app ID and records starts
+type fakeContainers struct {
+	byApp   map[string]map[string]fakeContainer
+	started []string
+}
+
+func (f *fakeContainers) ListContainers(ctx context.Context, all bool, labels map[string]string) ([]types.Container, error) {
+	var list []types.Container
+	for id, c := range f.byApp[labels["schooner.app-id"]] {
+		list = append(list, types.Container{ID: id, Names: []string{"/" + id}, State: c.state})
+	}
+	return list, nil
+}
+
+func (f *fakeContainers) InspectContainer(ctx context.Context, id string) (types.ContainerJSON, error) {
+	for _, containers := range f.byApp {
+		if c, ok := containers[id]; ok {
+			return types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{
+				State:      &types.ContainerState{Status: c.state, ExitCode: c.exitCode},
+				HostConfig: &container.HostConfig{RestartPolicy: container.RestartPolicy{Name: c.policy}},
+			}}, nil
+		}
+	}
+	return types.ContainerJSON{}, errors.New("no such container")
+}
+
+func (f *fakeContainers) StartContainer(ctx context.Context, id string) error {
+	f.started = append(f.started, id)
+	return nil
+}
+
+// fakeRedeployer records the apps it redeployed, failing for ones never
+// deployed
+type fakeRedeployer struct {
+	deployed   map[string]bool
+	redeployed []string
+}
+
+func (f *fakeRedeployer) RecreateContainer(ctx context.Context, app *models.App) (*models.Build, error) {
+	if !f.deployed[app.ID] {
+		return nil, build.ErrNotDeployed
+	}
+	f.redeployed = append(f.redeployed, app.ID)
+	return &models.Build{ID: "build-" + app.ID}, nil
+}
+
+func TestReconciler_Run(t *testing.T) {
+	app := func(id string) *models.App {
+		return &models.App{ID: id, Name: id, BuildStrategy: models.BuildStrategyDockerfile}
+	}
+	stopped := app("stopped")
+	stopped.Stopped = true
+	apps := fakeApps{app("api"), app("migrate"), app("worker"), app("gone"), app("fresh"), app("building"), stopped}
+
+	success := &models.Build{Status: models.BuildStatusSuccess}
+	builds := fakeBuilds{
+		"api":      success,
+		"migrate":  success,
+		"worker":   success,
+		"gone":     success,
+		"stopped":  success,
+		"building": {Status: models.BuildStatusBuilding},
+	}
+	containers := &fakeContainers{byApp: map[string]map[string]fakeContainer{
+		"api":     {"api": {state: "exited", policy: "unless-stopped"}},
+		"migrate": {"migrate": {state: "exited", policy: "on-failure"}},
+		"worker":  {"worker": {state: "exited", policy: "on-failure", exitCode: 1}},
+		"stopped": {"stopped": {state: "exited", policy: "always"}},
+	}}
+	redeployer := &fakeRedeployer{deployed: map[string]bool{"gone": true, "building": true}}
+
+	r := NewReconciler(apps, builds, containers)
+	r.SetRedeployer(redeployer)
+	r.AddService("tunnel", ServiceFunc(func(ctx context.Context) ([]string, error) {
+		return []string{"started tunnel home"}, errors.New("token rejected")
+	}))
+
+	result := r.Run(context.Background())
+	if want := []string{"api", "worker"}; !reflect.DeepEqual(containers.started, want) {
+		t.Errorf("started %v, want %v", containers.started, want)
+	}
+	if want := []string{"gone"}; !reflect.DeepEqual(redeployer.redeployed, want) {
+		t.Errorf("redeployed %v, want %v", redeployer.redeployed, want)
+	}
+	wantFixed := []string{
+		"api: started container api",
+		"gone: container missing, redeploying in build build-go",
+		"worker: started container worker",
+		"tunnel: started tunnel home",
+	}
+	if !reflect.DeepEqual(result.Fixed, wantFixed) {
+		t.Errorf("fixed %v, want %v", result.Fixed, wantFixed)
+	}
+	if want := []string{"tunnel: token rejected"}; !reflect.DeepEqual(result.Errors, want) {
+		t.Errorf("errors %v, want %v", result.Errors, want)
+	}
+	if r.Last() != result {
+		t.Error("Last() should return the latest pass")
+	}
+
+	// An app whose redeploy didn't bring its container back isn't
+	// redeployed again right away
+	r.Run(context.Background())
+	if want := []string{"gone"}; !reflect.DeepEqual(redeployer.redeployed, want) {
+		t.Errorf("redeployed %v after second pass, want %v", redeployer.redeployed, want)
+	}
+}
@@ -0,0 +1,268 @@
+package refresh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+
+	"schooner/internal/build"
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+// tick is how often the refresher looks for apps whose schedule is due
+const tick = time.Minute
+
+// AppLister lists the apps that should be running
+type AppLister interface {
+	ListEnabled(ctx context.Context) ([]*models.App, error)
+}
+
+// BuildLister reports the latest build of each app
+type BuildLister interface {
+	ListLatestPerApp(ctx context.Context) (map[string]*models.Build, error)
+}
+
+// Images lists app containers and pulls and inspects their images
+type Images interface {
+	ListContainers(ctx context.Context, all bool, filterLabels map[string]string) ([]types.Container, error)
+	InspectContainer(ctx context.Context, nameOrID string) (types.ContainerJSON, error)
+	ImageRepoDigests(ctx context.Context, ref string) ([]string, error)
+	ImageID(ctx context.Context, ref string) (id, digest string, err error)
+	PullImage(ctx context.Context, ref string) (io.ReadCloser, error)
+}
+
+// DockerHostResolver returns the Docker client for the host an app runs on
+type DockerHostResolver interface {
+	ForApp(ctx context.Context, app *models.App) (*docker.Client, error)
+}
+
+// Redeployer redeploys an app so its containers run the images just pulled
+type Redeployer interface {
+	RefreshImages(ctx context.Context, app *models.App, images []string) (*models.Build, error)
+}
+
+// Refresher pulls the images of apps that track :latest on each app's
+// image refresh schedule, and redeploys the apps whose images changed.
+// Redeploys go through the build pipeline, so they're health checked,
+// rolled back and notified like any other deploy.
+type Refresher struct {
+	apps         AppLister
+	builds       BuildLister
+	dockerClient Images
+	dockerHosts  DockerHostResolver
+	redeployer   Redeployer
+	logger       *slog.Logger
+
+	mu        sync.Mutex
+	startedAt time.Time
+	checked   map[string]time.Time // When each app's images were last checked
+}
+
+// NewRefresher creates a Refresher
+func NewRefresher(apps AppLister, builds BuildLister, dockerClient Images, redeployer Redeployer) *Refresher {
+	return &Refresher{
+		apps:         apps,
+		builds:       builds,
+		dockerClient: dockerClient,
+		redeployer:   redeployer,
+		logger:       slog.Default(),
+		startedAt:    time.Now(),
+		checked:      make(map[string]time.Time),
+	}
+}
+
+// SetDockerHosts makes apps on remote Docker hosts be pulled on their host
+func (r *Refresher) SetDockerHosts(hosts DockerHostResolver) {
+	r.dockerHosts = hosts
+}
+
+// Start refreshes apps as their schedules come round until the context is
+// cancelled
+func (r *Refresher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				r.RunDue(ctx, now)
+			}
+		}
+	}()
+}
+
+// RunDue refreshes every app whose schedule has come round since its
+// images were last checked, or since startup
+func (r *Refresher) RunDue(ctx context.Context, now time.Time) {
+	apps, err := r.apps.ListEnabled(ctx)
+	if err != nil {
+		r.logger.Error("failed to list apps", "error", err)
+		return
+	}
+	latest, err := r.builds.ListLatestPerApp(ctx)
+	if err != nil {
+		r.logger.Error("failed to list builds", "error", err)
+		return
+	}
+	sort.Slice(apps, func(i, j int) bool { return apps[i].Name < apps[j].Name })
+
+	for _, app := range apps {
+		if !r.isDue(app, now) {
+			continue
+		}
+		r.mu.Lock()
+		r.checked[app.ID] = now
+		r.mu.Unlock()
+
+		// Apps stopped on purpose stay down, and ones deploying right now
+		// pick up their images themselves
+		build := latest[app.ID]
+		if app.Stopped || build == nil || !build.IsComplete() {
+			continue
+		}
+		// Agents and Swarm pull on their own nodes
+		deployConfig, _ := app.GetDeployConfig()
+		if app.GetAgent() != "" || (deployConfig != nil && deployConfig.IsSwarm()) {
+			continue
+		}
+
+		b, err := r.Refresh(ctx, app)
+		if err != nil {
+			r.logger.Warn("failed to refresh images", "app", app.Name, "error", err)
+			continue
+		}
+		if b != nil {
+			r.logger.Info("images updated, redeploying", "app", app.Name, "build", b.ID)
+		}
+	}
+}
+
+// isDue reports whether an app's refresh schedule has come round since its
+// images were last checked
+func (r *Refresher) isDue(app *models.App, now time.Time) bool {
+	r.mu.Lock()
+	last, ok := r.checked[app.ID]
+	r.mu.Unlock()
+	if !ok {
+		last = r.startedAt
+	}
+	next := app.NextImageRefresh(last)
+	return !next.IsZero() && !next.After(now)
+}
+
+// Refresh pulls the images an app's containers run that track :latest, and
+// redeploys the app if any of them changed. It returns the redeploy, or nil
+// if every image was current.
+func (r *Refresher) Refresh(ctx context.Context, app *models.App) (*models.Build, error) {
+	images, err := r.imagesFor(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	tracked, err := trackedImages(ctx, images, app)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated []string
+	var errs []error
+	for _, ref := range sortedKeys(tracked) {
+		changed, err := pull(ctx, images, ref, tracked[ref])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if changed {
+			updated = append(updated, ref)
+		}
+	}
+	if len(updated) == 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	b, err := r.redeployer.RefreshImages(ctx, app, updated)
+	if errors.Is(err, build.ErrNotDeployed) {
+		return nil, errors.Join(errs...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeploy: %w", err)
+	}
+	return b, errors.Join(errs...)
+}
+
+// trackedImages returns the registry images the app's compose services run
+// that track :latest, with the ID of the image each runs now. Images built
+// locally have no repo digests and can't be pulled.
+func trackedImages(ctx context.Context, images Images, app *models.App) (map[string]string, error) {
+	containers, err := images.ListContainers(ctx, false, map[string]string{"schooner.app-id": app.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	tracked := make(map[string]string)
+	for _, c := range containers {
+		if c.Labels["com.docker.compose.service"] == "" {
+			continue
+		}
+		info, err := images.InspectContainer(ctx, c.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect container: %w", err)
+		}
+		if info.Config == nil || !models.TracksLatest(info.Config.Image) {
+			continue
+		}
+		repoDigests, err := images.ImageRepoDigests(ctx, info.Image)
+		if err != nil || len(repoDigests) == 0 {
+			continue
+		}
+		tracked[info.Config.Image] = info.Image
+	}
+	return tracked, nil
+}
+
+// pull pulls an image, reading the progress to completion, and reports
+// whether it changed from the one running
+func pull(ctx context.Context, images Images, ref, running string) (bool, error) {
+	reader, err := images.PullImage(ctx, ref)
+	if err != nil {
+		return false, fmt.Errorf("failed to pull %s: %w", ref, err)
+	}
+	defer reader.Close()
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return false, fmt.Errorf("failed to pull %s: %w", ref, err)
+	}
+
+	id, _, err := images.ImageID(ctx, ref)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect %s: %w", ref, err)
+	}
+	return id != running, nil
+}
+
+// imagesFor returns the Docker client for an app's host
+func (r *Refresher) imagesFor(ctx context.Context, app *models.App) (Images, error) {
+	if r.dockerHosts == nil || app.GetDockerHost() == "" {
+		return r.dockerClient, nil
+	}
+	return r.dockerHosts.ForApp(ctx, app)
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,192 @@
+package refresh
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+
+	"schooner/internal/models"
+)
+
+type fakeApps []*models.App
+
+func (f fakeApps) ListEnabled(ctx context.Context) ([]*models.App, error) {
+	return f, nil
+}
+
+type fakeBuilds map[string]*models.Build
+
+func (f fakeBuilds) ListLatestPerApp(ctx context.Context) (map[string]*models.Build, error) {
+	return f, nil
+}
+
+// fakeContainer is a service container and the image it runs
+type fakeContainer struct {
+	service string
+	ref     string
+	imageID string
+}
+
+// fakeImages serves containers by app ID, and images by reference with the
+// ID a pull leaves them at
+type fakeImages struct {
+	byApp   map[string][]fakeContainer
+	local   map[string]bool   // Image IDs built locally, without repo digests
+	pulled  map[string]string // Image ID of each reference after a pull
+	pulls   []string
+	pullErr map[string]bool
+}
+
+func (f *fakeImages) ListContainers(ctx context.Context, all bool, labels map[string]string) ([]types.Container, error) {
+	var list []types.Container
+	for i, c := range f.byApp[labels["schooner.app-id"]] {
+		list = append(list, types.Container{
+			ID:     labels["schooner.app-id"] + "/" + string(rune('0'+i)),
+			Labels: map[string]string{"com.docker.compose.service": c.service},
+		})
+	}
+	return list, nil
+}
+
+func (f *fakeImages) InspectContainer(ctx context.Context, id string) (types.ContainerJSON, error) {
+	appID, index, _ := strings.Cut(id, "/")
+	c := f.byApp[appID][index[0]-'0']
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{Image: c.imageID},
+		Config:            &container.Config{Image: c.ref},
+	}, nil
+}
+
+func (f *fakeImages) ImageRepoDigests(ctx context.Context, ref string) ([]string, error) {
+	if f.local[ref] {
+		return nil, nil
+	}
+	return []string{"registry/" + ref + "@sha256:abc"}, nil
+}
+
+func (f *fakeImages) ImageID(ctx context.Context, ref string) (string, string, error) {
+	return f.pulled[ref], "", nil
+}
+
+func (f *fakeImages) PullImage(ctx context.Context, ref string) (io.ReadCloser, error) {
+	if f.pullErr[ref] {
+		return nil, errors.New("manifest unknown")
+	}
+	f.pulls = append(f.pulls, ref)
+	return io.NopCloser(strings.NewReader(`{"status":"Pull complete"}`)), nil
+}
+
+// fakeRedeployer records the images each app was redeployed for
+type fakeRedeployer map[string][]string
+
+func (f fakeRedeployer) RefreshImages(ctx context.Context, app *models.App, images []string) (*models.Build, error) {
+	f[app.ID] = images
+	return &models.Build{ID: "build-" + app.ID}, nil
+}
+
+func TestRefresher_Refresh(t *testing.T) {
+	images := &fakeImages{
+		byApp: map[string][]fakeContainer{
+			"web": {
+				{service: "app", ref: "ghcr.io/acme/web", imageID: "sha256:old-web"},
+				{service: "db", ref: "postgres:16", imageID: "sha256:pg"},
+				{service: "cache", ref: "redis:latest", imageID: "sha256:redis"},
+				{service: "worker", ref: "web-worker:latest", imageID: "sha256:worker"},
+			},
+			"current": {{service: "app", ref: "nginx", imageID: "sha256:nginx"}},
+			"broken":  {{service: "app", ref: "gone:latest", imageID: "sha256:gone"}},
+		},
+		local: map[string]bool{"sha256:worker": true},
+		pulled: map[string]string{
+			"ghcr.io/acme/web": "sha256:new-web",
+			"redis:latest":     "sha256:redis",
+			"nginx":            "sha256:nginx",
+		},
+		pullErr: map[string]bool{"gone:latest": true},
+	}
+
+	tests := []struct {
+		name      string
+		appID     string
+		wantBuild bool
+		wantErr   bool
+		want      []string
+	}{
+		{name: "updated", appID: "web", wantBuild: true, want: []string{"ghcr.io/acme/web"}},
+		{name: "current", appID: "current"},
+		{name: "pull fails", appID: "broken", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redeployer := fakeRedeployer{}
+			r := NewRefresher(fakeApps{}, fakeBuilds{}, images, redeployer)
+
+			b, err := r.Refresh(context.Background(), &models.App{ID: tt.appID, Name: tt.appID})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Refresh() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (b != nil) != tt.wantBuild {
+				t.Errorf("Refresh() build = %v, want one %v", b, tt.wantBuild)
+			}
+			if !reflect.DeepEqual(redeployer[tt.appID], tt.want) {
+				t.Errorf("redeployed for %v, want %v", redeployer[tt.appID], tt.want)
+			}
+		})
+	}
+
+	if want := []string{"ghcr.io/acme/web", "redis:latest", "nginx"}; !reflect.DeepEqual(images.pulls, want) {
+		t.Errorf("pulled %v, want %v", images.pulls, want)
+	}
+}
+
+func TestRefresher_RunDue(t *testing.T) {
+	app := func(id, schedule string) *models.App {
+		a := &models.App{ID: id, Name: id, BuildStrategy: models.BuildStrategyCompose}
+		a.SetImageRefresh(schedule)
+		return a
+	}
+	stopped := app("stopped", "0 4 * * *")
+	stopped.Stopped = true
+	apps := fakeApps{app("nightly", "0 4 * * *"), app("weekly", "0 4 * * 0"), app("manual", ""), app("building", "0 4 * * *"), stopped}
+
+	success := &models.Build{Status: models.BuildStatusSuccess}
+	builds := fakeBuilds{
+		"nightly":  success,
+		"weekly":   success,
+		"manual":   success,
+		"stopped":  success,
+		"building": {Status: models.BuildStatusBuilding},
+	}
+	containers := []fakeContainer{{service: "app", ref: "nginx:latest", imageID: "sha256:old"}}
+	images := &fakeImages{
+		byApp: map[string][]fakeContainer{
+			"nightly": containers, "weekly": containers, "manual": containers, "building": containers, "stopped": containers,
+		},
+		pulled: map[string]string{"nginx:latest": "sha256:new"},
+	}
+	redeployer := fakeRedeployer{}
+
+	r := NewRefresher(apps, builds, images, redeployer)
+	// A Wednesday afternoon, with the nightly schedule come round once
+	r.startedAt = time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	r.RunDue(context.Background(), now)
+	if _, ok := redeployer["nightly"]; !ok || len(redeployer) != 1 {
+		t.Errorf("redeployed %v, want only nightly", redeployer)
+	}
+
+	delete(redeployer, "nightly")
+	r.RunDue(context.Background(), now.Add(time.Minute))
+	if len(redeployer) != 0 {
+		t.Errorf("redeployed %v again before the schedule came round", redeployer)
+	}
+}
@@ -0,0 +1,274 @@
+// Package selfupdate checks GitHub for new releases of Schooner and updates
+// it by swapping its own container for one running the release's image,
+// the way a self-deploy does.
+package selfupdate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"schooner/internal/config"
+	"schooner/internal/docker"
+	"schooner/internal/github"
+)
+
+// Errors Update returns instead of starting an update
+var (
+	ErrNoUpdate       = errors.New("no update available")
+	ErrNoImage        = errors.New("no update image is configured")
+	ErrNotInContainer = errors.New("Schooner is not running in a container it can replace")
+	ErrBuildsRunning  = errors.New("builds are running, try again once they finish")
+	ErrUpdating       = errors.New("an update is already in progress")
+)
+
+// pullTimeout bounds pulling the release's image and starting the swap
+const pullTimeout = 10 * time.Minute
+
+// selfLabels are the labels of Schooner's container the swap would drop,
+// set on the new container again
+var selfLabels = []string{"schooner.managed", "schooner.app", "schooner.app-id"}
+
+// ReleaseSource looks up a repository's releases on GitHub
+type ReleaseSource interface {
+	GetLatestRelease(ctx context.Context, owner, repo string) (*github.Release, error)
+	CompareCommits(ctx context.Context, owner, repo, base, head string) (string, error)
+}
+
+// ContainerSwapper pulls the release's image and replaces Schooner's
+// container with it
+type ContainerSwapper interface {
+	SelfContainer(ctx context.Context) (*docker.ContainerStatus, error)
+	PullImage(ctx context.Context, ref string) (io.ReadCloser, error)
+	SwapContainer(ctx context.Context, containerName, image string, labels map[string]string) (string, error)
+}
+
+// BuildCounter reports how many builds are running, which an update would
+// cut short
+type BuildCounter interface {
+	BusyWorkers() int
+}
+
+// Status is the running build, the latest release and whether updating to
+// it is possible
+type Status struct {
+	Current     string          `json:"current"` // Commit Schooner was built from
+	Latest      *github.Release `json:"latest,omitempty"`
+	Available   bool            `json:"available"`       // The latest release has commits the running build lacks
+	Image       string          `json:"image,omitempty"` // Image the update runs, empty when none is configured
+	CheckedAt   *time.Time      `json:"checked_at,omitempty"`
+	Error       string          `json:"error,omitempty"` // Why the last check failed
+	Updating    bool            `json:"updating"`
+	UpdateError string          `json:"update_error,omitempty"` // Why the last update failed
+}
+
+// Manager checks for releases on an interval and applies them
+type Manager struct {
+	releases ReleaseSource
+	docker   ContainerSwapper
+	builds   BuildCounter
+	commit   string
+	repo     string
+	image    string
+	interval time.Duration
+
+	mu     sync.Mutex
+	status Status
+}
+
+// NewManager creates a manager comparing the running commit with the
+// releases of the configured repository
+func NewManager(cfg config.UpdateConfig, commit string, releases ReleaseSource) *Manager {
+	return &Manager{
+		releases: releases,
+		commit:   commit,
+		repo:     cfg.Repo,
+		image:    cfg.Image,
+		interval: cfg.CheckInterval,
+		status:   Status{Current: commit},
+	}
+}
+
+// SetDocker sets the Docker client that swaps Schooner's container. Without
+// one, updates are only reported.
+func (m *Manager) SetDocker(swapper ContainerSwapper) {
+	m.docker = swapper
+}
+
+// SetBuilds sets where running builds are counted, so an update doesn't
+// interrupt them
+func (m *Manager) SetBuilds(builds BuildCounter) {
+	m.builds = builds
+}
+
+// Start checks for a new release now and then on the configured interval
+func (m *Manager) Start(ctx context.Context) {
+	if m.interval <= 0 || m.repo == "" {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			if status, err := m.Check(ctx); err != nil {
+				slog.Warn("failed to check for Schooner updates", "error", err)
+			} else if status.Available {
+				slog.Info("Schooner update available", "release", status.Latest.TagName)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Status returns the outcome of the last check and update
+func (m *Manager) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status
+}
+
+// Check looks up the latest release and whether it is newer than the
+// running build
+func (m *Manager) Check(ctx context.Context) (Status, error) {
+	owner, repo, _ := strings.Cut(m.repo, "/")
+	release, err := m.releases.GetLatestRelease(ctx, owner, repo)
+	available := false
+	if err == nil && release != nil {
+		available, err = m.newer(ctx, owner, repo, release.TagName)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.status.CheckedAt = &now
+	if err != nil {
+		m.status.Error = err.Error()
+		return m.status, err
+	}
+	m.status.Error = ""
+	m.status.Latest = release
+	m.status.Available = available
+	m.status.Image = ""
+	if release != nil && m.image != "" {
+		m.status.Image = m.image + ":" + release.TagName
+	}
+	return m.status, nil
+}
+
+// newer reports whether a release tag has commits the running build lacks.
+// Builds from a later commit than the release aren't offered a downgrade.
+func (m *Manager) newer(ctx context.Context, owner, repo, tag string) (bool, error) {
+	if m.commit == "" || m.commit == "unknown" {
+		return false, fmt.Errorf("the running build has no commit to compare with %s", tag)
+	}
+	comparison, err := m.releases.CompareCommits(ctx, owner, repo, m.commit, tag)
+	if err != nil {
+		return false, err
+	}
+	return comparison == "ahead", nil
+}
+
+// Update starts updating to the latest release: its image is pulled in the
+// background, then a helper container swaps Schooner's container for one
+// running it. Schooner stops shortly after the swap starts.
+func (m *Manager) Update(ctx context.Context) error {
+	m.mu.Lock()
+	switch {
+	case m.status.Updating:
+		m.mu.Unlock()
+		return ErrUpdating
+	case !m.status.Available:
+		m.mu.Unlock()
+		return ErrNoUpdate
+	case m.status.Image == "":
+		m.mu.Unlock()
+		return ErrNoImage
+	}
+	image := m.status.Image
+	m.status.Updating = true
+	m.status.UpdateError = ""
+	m.mu.Unlock()
+
+	if m.builds != nil && m.builds.BusyWorkers() > 0 {
+		m.finishUpdate(nil)
+		return ErrBuildsRunning
+	}
+	var self *docker.ContainerStatus
+	if m.docker != nil {
+		var err error
+		if self, err = m.docker.SelfContainer(ctx); err != nil {
+			m.finishUpdate(nil)
+			return fmt.Errorf("failed to find Schooner's container: %w", err)
+		}
+	}
+	if self == nil {
+		m.finishUpdate(nil)
+		return ErrNotInContainer
+	}
+
+	go m.apply(self, image)
+	return nil
+}
+
+// apply pulls the image and starts the swap. Schooner keeps reporting the
+// update in progress until it's stopped.
+func (m *Manager) apply(self *docker.ContainerStatus, image string) {
+	ctx, cancel := context.WithTimeout(context.Background(), pullTimeout)
+	defer cancel()
+
+	slog.Info("updating Schooner", "image", image, "container", self.Name)
+	err := m.pull(ctx, image)
+	if err == nil {
+		labels := make(map[string]string)
+		for _, k := range selfLabels {
+			if v, ok := self.Labels[k]; ok {
+				labels[k] = v
+			}
+		}
+		_, err = m.docker.SwapContainer(ctx, self.Name, image, labels)
+	}
+	if err != nil {
+		slog.Error("failed to update Schooner", "image", image, "error", err)
+		m.finishUpdate(err)
+		return
+	}
+	slog.Info("Schooner update started, restarting shortly", "image", image)
+}
+
+// pull pulls an image, reading the progress to completion
+func (m *Manager) pull(ctx context.Context, image string) error {
+	reader, err := m.docker.PullImage(ctx, image)
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %w", image, err)
+	}
+	defer reader.Close()
+
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return fmt.Errorf("failed to pull %s: %w", image, err)
+	}
+	return nil
+}
+
+// finishUpdate records an update that stopped, with the error it failed with
+func (m *Manager) finishUpdate(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.status.Updating = false
+	if err != nil {
+		m.status.UpdateError = err.Error()
+	}
+}
@@ -0,0 +1,152 @@
+package selfupdate
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"schooner/internal/config"
+	"schooner/internal/docker"
+	"schooner/internal/github"
+)
+
+type fakeReleases struct {
+	release    *github.Release
+	comparison string
+	err        error
+}
+
+func (f fakeReleases) GetLatestRelease(ctx context.Context, owner, repo string) (*github.Release, error) {
+	return f.release, f.err
+}
+
+func (f fakeReleases) CompareCommits(ctx context.Context, owner, repo, base, head string) (string, error) {
+	return f.comparison, nil
+}
+
+type swap struct {
+	container, image string
+	labels           map[string]string
+}
+
+type fakeDocker struct {
+	self  *docker.ContainerStatus
+	swaps chan swap
+}
+
+func (f *fakeDocker) SelfContainer(ctx context.Context) (*docker.ContainerStatus, error) {
+	return f.self, nil
+}
+
+func (f *fakeDocker) PullImage(ctx context.Context, ref string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(`{"status":"Downloaded newer image"}`)), nil
+}
+
+func (f *fakeDocker) SwapContainer(ctx context.Context, containerName, image string, labels map[string]string) (string, error) {
+	f.swaps <- swap{containerName, image, labels}
+	return "helper", nil
+}
+
+type fakeBuilds int
+
+func (f fakeBuilds) BusyWorkers() int { return int(f) }
+
+func TestManager_Check(t *testing.T) {
+	release := &github.Release{TagName: "v1.4.0"}
+	tests := []struct {
+		name          string
+		commit        string
+		releases      fakeReleases
+		wantAvailable bool
+		wantErr       bool
+	}{
+		{name: "newer release", commit: "abc123", releases: fakeReleases{release: release, comparison: "ahead"}, wantAvailable: true},
+		{name: "up to date", commit: "abc123", releases: fakeReleases{release: release, comparison: "identical"}},
+		{name: "ahead of release", commit: "abc123", releases: fakeReleases{release: release, comparison: "behind"}},
+		{name: "no releases", commit: "abc123", releases: fakeReleases{}},
+		{name: "unknown commit", commit: "unknown", releases: fakeReleases{release: release, comparison: "ahead"}, wantErr: true},
+		{name: "GitHub down", commit: "abc123", releases: fakeReleases{err: errors.New("GitHub API error (status 503)")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(config.UpdateConfig{Repo: "bas-slats/schooner", Image: "ghcr.io/bas-slats/schooner"}, tt.commit, tt.releases)
+			status, err := m.Check(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if status.Available != tt.wantAvailable {
+				t.Errorf("Available = %v, want %v", status.Available, tt.wantAvailable)
+			}
+			if tt.wantErr && status.Error == "" {
+				t.Error("Error not reported in status")
+			}
+			if tt.wantAvailable && status.Image != "ghcr.io/bas-slats/schooner:v1.4.0" {
+				t.Errorf("Image = %q, want the release's tag", status.Image)
+			}
+		})
+	}
+}
+
+func TestManager_Update(t *testing.T) {
+	releases := fakeReleases{release: &github.Release{TagName: "v1.4.0"}, comparison: "ahead"}
+	self := &docker.ContainerStatus{Name: "schooner", Labels: map[string]string{"schooner.managed": "false", "com.example": "kept"}}
+
+	tests := []struct {
+		name    string
+		image   string
+		self    *docker.ContainerStatus
+		builds  fakeBuilds
+		wantErr error
+	}{
+		{name: "swaps the container", image: "ghcr.io/bas-slats/schooner", self: self},
+		{name: "no image", self: self, wantErr: ErrNoImage},
+		{name: "not in a container", image: "ghcr.io/bas-slats/schooner", wantErr: ErrNotInContainer},
+		{name: "builds running", image: "ghcr.io/bas-slats/schooner", self: self, builds: 1, wantErr: ErrBuildsRunning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(config.UpdateConfig{Repo: "bas-slats/schooner", Image: tt.image}, "abc123", releases)
+			d := &fakeDocker{self: tt.self, swaps: make(chan swap, 1)}
+			m.SetDocker(d)
+			m.SetBuilds(tt.builds)
+
+			if err := m.Update(context.Background()); !errors.Is(err, ErrNoUpdate) {
+				t.Fatalf("Update() before a check = %v, want %v", err, ErrNoUpdate)
+			}
+			if _, err := m.Check(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			err := m.Update(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Update() = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if m.Status().Updating {
+					t.Error("Updating after the update was refused")
+				}
+				return
+			}
+
+			if err := m.Update(context.Background()); !errors.Is(err, ErrUpdating) {
+				t.Errorf("second Update() = %v, want %v", err, ErrUpdating)
+			}
+			select {
+			case got := <-d.swaps:
+				if got.container != "schooner" || got.image != "ghcr.io/bas-slats/schooner:v1.4.0" {
+					t.Errorf("swapped %s to %s", got.container, got.image)
+				}
+				if len(got.labels) != 1 || got.labels["schooner.managed"] != "false" {
+					t.Errorf("labels = %v, want only Schooner's own", got.labels)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("container was never swapped")
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+// Package templates holds the catalog of one-click apps that can be deployed
+// without a git repository.
+//
+// Each template is a compose file plus the parameters it needs. Parameters
+// become the app's env vars, which Docker Compose interpolates into the file
+// from the .env Schooner writes next to it. The host port is the exception:
+// proxy routing reads published ports before compose runs, so ${PORT} is
+// replaced with the app's public port when the file is rendered.
+package templates
+
+import (
+	"crypto/rand"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"schooner/internal/models"
+)
+
+//go:embed catalog/*.yml
+var catalogFS embed.FS
+
+// portPlaceholder is replaced with the app's public port when rendering
+const portPlaceholder = "${PORT}"
+
+// secretBytes is the entropy of generated secret parameters
+const secretBytes = 24
+
+var (
+	idPattern    = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+	paramPattern = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+)
+
+// Param is a value the user fills in when deploying a template
+type Param struct {
+	Name        string `yaml:"name" json:"name"`
+	Label       string `yaml:"label" json:"label"`
+	Description string `yaml:"description" json:"description,omitempty"`
+	Default     string `yaml:"default" json:"default,omitempty"`
+	Required    bool   `yaml:"required" json:"required,omitempty"`
+	Secret      bool   `yaml:"secret" json:"secret,omitempty"` // Generated when left blank
+}
+
+// Template is a one-click app in the catalog
+type Template struct {
+	ID          string  `yaml:"id" json:"id"`
+	Name        string  `yaml:"name" json:"name"`
+	Description string  `yaml:"description" json:"description"`
+	Category    string  `yaml:"category" json:"category"`
+	Website     string  `yaml:"website" json:"website,omitempty"`
+	Port        int     `yaml:"port" json:"port"` // Suggested host port
+	Params      []Param `yaml:"params" json:"params"`
+	Compose     string  `yaml:"compose" json:"-"`
+}
+
+// Catalog is the set of templates available to deploy
+type Catalog struct {
+	templates []*Template
+	byID      map[string]*Template
+}
+
+// Load parses the templates embedded in the binary
+func Load() (*Catalog, error) {
+	return load(catalogFS, "catalog")
+}
+
+func load(fsys fs.FS, dir string) (*Catalog, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template catalog: %w", err)
+	}
+
+	c := &Catalog{byID: make(map[string]*Template)}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".yml" {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", entry.Name(), err)
+		}
+
+		var t Template
+		if err := yaml.Unmarshal(data, &t); err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", entry.Name(), err)
+		}
+		if err := t.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid template %s: %w", entry.Name(), err)
+		}
+		if _, ok := c.byID[t.ID]; ok {
+			return nil, fmt.Errorf("duplicate template id %q", t.ID)
+		}
+
+		c.templates = append(c.templates, &t)
+		c.byID[t.ID] = &t
+	}
+
+	sort.Slice(c.templates, func(i, j int) bool {
+		return strings.ToLower(c.templates[i].Name) < strings.ToLower(c.templates[j].Name)
+	})
+	return c, nil
+}
+
+// List returns every template sorted by name
+func (c *Catalog) List() []*Template {
+	return c.templates
+}
+
+// Get returns a template by ID, nil if there is none
+func (c *Catalog) Get(id string) *Template {
+	return c.byID[id]
+}
+
+// RenderCompose returns the compose file of an app deployed from a template
+func (c *Catalog) RenderCompose(app *models.App) (string, error) {
+	if !app.Template.Valid || app.Template.String == "" {
+		return "", fmt.Errorf("app %s was not deployed from a template", app.Name)
+	}
+	t := c.Get(app.Template.String)
+	if t == nil {
+		return "", fmt.Errorf("template %q not found", app.Template.String)
+	}
+	if !app.PublicPort.Valid || app.PublicPort.Int64 <= 0 {
+		return "", fmt.Errorf("app %s has no public port", app.Name)
+	}
+	return t.Render(app.Name, int(app.PublicPort.Int64)), nil
+}
+
+// Validate checks that a template is complete and its compose file parses
+func (t *Template) Validate() error {
+	if !idPattern.MatchString(t.ID) {
+		return fmt.Errorf("invalid id %q", t.ID)
+	}
+	if t.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if t.Port <= 0 || t.Port > 65535 {
+		return fmt.Errorf("invalid port %d", t.Port)
+	}
+	if !strings.Contains(t.Compose, portPlaceholder) {
+		return fmt.Errorf("compose file doesn't publish %s", portPlaceholder)
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range t.Params {
+		if !paramPattern.MatchString(p.Name) {
+			return fmt.Errorf("invalid parameter name %q", p.Name)
+		}
+		if p.Name == "PORT" {
+			return fmt.Errorf("PORT is reserved for the host port")
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("duplicate parameter %s", p.Name)
+		}
+		seen[p.Name] = true
+	}
+
+	var compose struct {
+		Services map[string]interface{} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal([]byte(t.Render(t.ID, t.Port)), &compose); err != nil {
+		return fmt.Errorf("failed to parse compose file: %w", err)
+	}
+	if len(compose.Services) == 0 {
+		return fmt.Errorf("compose file has no services")
+	}
+	return nil
+}
+
+// Values fills in defaults and generated secrets for the parameters the
+// user left blank, and drops any that the template doesn't declare
+func (t *Template) Values(input map[string]string) (map[string]string, error) {
+	values := make(map[string]string, len(t.Params))
+	for _, p := range t.Params {
+		v := strings.TrimSpace(input[p.Name])
+		if v == "" {
+			v = p.Default
+		}
+		if v == "" && p.Secret {
+			secret, err := generateSecret()
+			if err != nil {
+				return nil, err
+			}
+			v = secret
+		}
+		if v == "" && p.Required {
+			return nil, fmt.Errorf("%s is required", p.Label)
+		}
+		if strings.ContainsAny(v, "\r\n") {
+			return nil, fmt.Errorf("%s must be a single line", p.Label)
+		}
+		if v != "" {
+			values[p.Name] = v
+		}
+	}
+	return values, nil
+}
+
+// Render returns the compose file for a deployment of the template, named
+// after the app and publishing port on the host
+func (t *Template) Render(appName string, port int) string {
+	compose := strings.ReplaceAll(t.Compose, portPlaceholder, strconv.Itoa(port))
+	return fmt.Sprintf("name: %s\n%s", models.ComposeProjectName(appName), compose)
+}
+
+// generateSecret returns a random value for a secret parameter
+func generateSecret() (string, error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,136 @@
+package templates
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"gopkg.in/yaml.v3"
+
+	"schooner/internal/models"
+)
+
+func TestLoadCatalog(t *testing.T) {
+	c, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(c.List()) == 0 {
+		t.Fatal("catalog is empty")
+	}
+
+	for _, id := range []string{"umami", "uptime-kuma", "vaultwarden", "n8n"} {
+		if c.Get(id) == nil {
+			t.Errorf("template %q missing from catalog", id)
+		}
+	}
+
+	list := c.List()
+	for i := 1; i < len(list); i++ {
+		if strings.ToLower(list[i-1].Name) > strings.ToLower(list[i].Name) {
+			t.Errorf("templates not sorted: %q before %q", list[i-1].Name, list[i].Name)
+		}
+	}
+}
+
+func TestLoadRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"bad id", "id: Bad ID\nname: x\nport: 80\ncompose: |\n  services:\n    x:\n      ports: [\"${PORT}:80\"]\n"},
+		{"no port placeholder", "id: x\nname: x\nport: 80\ncompose: |\n  services:\n    x:\n      image: nginx\n"},
+		{"reserved param", "id: x\nname: x\nport: 80\nparams:\n  - name: PORT\ncompose: |\n  services:\n    x:\n      ports: [\"${PORT}:80\"]\n"},
+		{"no services", "id: x\nname: x\nport: 80\ncompose: |\n  volumes:\n    x: {}\n  # ${PORT}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{"catalog/x.yml": {Data: []byte(tt.data)}}
+			if _, err := load(fsys, "catalog"); err == nil {
+				t.Error("load() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestValues(t *testing.T) {
+	tmpl := &Template{
+		Params: []Param{
+			{Name: "URL", Label: "Public URL", Required: true},
+			{Name: "MODE", Label: "Mode", Default: "prod"},
+			{Name: "TOKEN", Label: "Token", Secret: true},
+			{Name: "EXTRA", Label: "Extra"},
+		},
+	}
+
+	values, err := tmpl.Values(map[string]string{"URL": " https://x.example.com ", "UNKNOWN": "y"})
+	if err != nil {
+		t.Fatalf("Values() error = %v", err)
+	}
+	if values["URL"] != "https://x.example.com" {
+		t.Errorf("URL = %q", values["URL"])
+	}
+	if values["MODE"] != "prod" {
+		t.Errorf("MODE = %q, want default", values["MODE"])
+	}
+	if len(values["TOKEN"]) != secretBytes*2 {
+		t.Errorf("TOKEN = %q, want generated secret", values["TOKEN"])
+	}
+	if _, ok := values["EXTRA"]; ok {
+		t.Error("blank optional param should be omitted")
+	}
+	if _, ok := values["UNKNOWN"]; ok {
+		t.Error("undeclared param should be dropped")
+	}
+
+	if _, err := tmpl.Values(nil); err == nil {
+		t.Error("Values() without required param error = nil")
+	}
+	if _, err := tmpl.Values(map[string]string{"URL": "a\nb"}); err == nil {
+		t.Error("Values() with multi-line value error = nil")
+	}
+}
+
+func TestRenderCompose(t *testing.T) {
+	c, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	app := &models.App{
+		Name:       "My Uptime!",
+		Template:   sql.NullString{String: "uptime-kuma", Valid: true},
+		PublicPort: sql.NullInt64{Int64: 4001, Valid: true},
+	}
+	out, err := c.RenderCompose(app)
+	if err != nil {
+		t.Fatalf("RenderCompose() error = %v", err)
+	}
+
+	var compose struct {
+		Name     string `yaml:"name"`
+		Services map[string]struct {
+			Ports []string `yaml:"ports"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal([]byte(out), &compose); err != nil {
+		t.Fatalf("rendered compose doesn't parse: %v", err)
+	}
+	if compose.Name != "my-uptime" {
+		t.Errorf("project name = %q, want my-uptime", compose.Name)
+	}
+	if ports := compose.Services["uptime-kuma"].Ports; len(ports) != 1 || ports[0] != "4001:3001" {
+		t.Errorf("ports = %v, want [4001:3001]", ports)
+	}
+
+	app.Template = sql.NullString{String: "missing", Valid: true}
+	if _, err := c.RenderCompose(app); err == nil {
+		t.Error("RenderCompose() with unknown template error = nil")
+	}
+	app.Template = sql.NullString{}
+	if _, err := c.RenderCompose(app); err == nil {
+		t.Error("RenderCompose() without template error = nil")
+	}
+}
@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"schooner/internal/config"
+)
+
+func TestEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		env      map[string]string
+		want     bool
+	}{
+		{name: "not configured", want: false},
+		{name: "endpoint", endpoint: "http://tempo:4318", want: true},
+		{name: "otlp env", env: map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318"}, want: true},
+		{name: "traces env", env: map[string]string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "http://collector:4318/v1/traces"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+			t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if got := Enabled(config.TracingConfig{Endpoint: tt.endpoint}); got != tt.want {
+				t.Errorf("Enabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartEnd(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	ctx, parent := Start(context.Background(), "build")
+	_, child := Start(ctx, "pipeline.deploy")
+	End(child, errors.New("container exited"))
+	End(parent, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	deploy, build := spans[0], spans[1]
+	if deploy.Parent.SpanID() != build.SpanContext.SpanID() {
+		t.Errorf("deploy span parent = %s, want the build span %s", deploy.Parent.SpanID(), build.SpanContext.SpanID())
+	}
+	if deploy.Status.Code != codes.Error || deploy.Status.Description != "container exited" {
+		t.Errorf("deploy span status = %+v, want the error", deploy.Status)
+	}
+	if build.Status.Code != codes.Unset {
+		t.Errorf("build span status = %+v, want unset", build.Status)
+	}
+}
@@ -0,0 +1,93 @@
+package uptime
+
+import (
+	"time"
+
+	"schooner/internal/models"
+)
+
+// Incident is a period during which an app failed consecutive checks
+type Incident struct {
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end,omitempty"` // nil while ongoing
+}
+
+// Duration returns how long the incident lasted, up to now if still ongoing
+func (i Incident) Duration(now time.Time) time.Duration {
+	if i.End != nil {
+		return i.End.Sub(i.Start)
+	}
+	return now.Sub(i.Start)
+}
+
+// DaySummary is the share of successful checks on one calendar day
+type DaySummary struct {
+	Date          string  `json:"date"`
+	Checks        int     `json:"checks"`
+	UptimePercent float64 `json:"uptime_percent"`
+}
+
+// Incidents derives outages from results ordered oldest first. Runs shorter
+// than downThreshold are ignored, matching when the prober marks an app down.
+// Incidents are returned newest first.
+func Incidents(results []*models.UptimeResult) []Incident {
+	var incidents []Incident
+	var start time.Time
+	failures := 0
+
+	for _, r := range results {
+		if !r.Up {
+			if failures == 0 {
+				start = r.CheckedAt
+			}
+			failures++
+			continue
+		}
+
+		if failures >= downThreshold {
+			end := r.CheckedAt
+			incidents = append(incidents, Incident{Start: start, End: &end})
+		}
+		failures = 0
+	}
+
+	if failures >= downThreshold {
+		incidents = append(incidents, Incident{Start: start})
+	}
+
+	for i, j := 0, len(incidents)-1; i < j; i, j = i+1, j-1 {
+		incidents[i], incidents[j] = incidents[j], incidents[i]
+	}
+	return incidents
+}
+
+// DailySummary buckets results ordered oldest first into the last n calendar
+// days ending at now. Days without checks report 0 checks and 100% uptime.
+func DailySummary(results []*models.UptimeResult, days int, now time.Time) []DaySummary {
+	summaries := make([]DaySummary, days)
+	index := make(map[string]int, days)
+	for i := 0; i < days; i++ {
+		date := now.AddDate(0, 0, i-days+1).Format("2006-01-02")
+		summaries[i] = DaySummary{Date: date, UptimePercent: 100}
+		index[date] = i
+	}
+
+	up := make([]int, days)
+	for _, r := range results {
+		i, ok := index[r.CheckedAt.In(now.Location()).Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		summaries[i].Checks++
+		if r.Up {
+			up[i]++
+		}
+	}
+
+	for i := range summaries {
+		if summaries[i].Checks > 0 {
+			summaries[i].UptimePercent = float64(up[i]) / float64(summaries[i].Checks) * 100
+		}
+	}
+	return summaries
+}
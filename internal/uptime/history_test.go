@@ -0,0 +1,86 @@
+package uptime
+
+import (
+	"testing"
+	"time"
+
+	"schooner/internal/models"
+)
+
+func results(start time.Time, step time.Duration, ups ...bool) []*models.UptimeResult {
+	out := make([]*models.UptimeResult, len(ups))
+	for i, up := range ups {
+		out[i] = &models.UptimeResult{Up: up, CheckedAt: start.Add(time.Duration(i) * step)}
+	}
+	return out
+}
+
+func TestIncidents(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		ups       []bool
+		wantCount int
+		ongoing   bool
+	}{
+		{name: "all up", ups: []bool{true, true, true}, wantCount: 0},
+		{name: "single blip ignored", ups: []bool{true, false, true}, wantCount: 0},
+		{name: "resolved outage", ups: []bool{true, false, false, true}, wantCount: 1},
+		{name: "two outages", ups: []bool{false, false, true, false, false, false, true}, wantCount: 2},
+		{name: "ongoing outage", ups: []bool{true, false, false}, wantCount: 1, ongoing: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Incidents(results(start, time.Minute, tt.ups...))
+			if len(got) != tt.wantCount {
+				t.Fatalf("Incidents() returned %d, want %d", len(got), tt.wantCount)
+			}
+			if tt.wantCount > 0 && (got[0].End == nil) != tt.ongoing {
+				t.Errorf("newest incident ongoing = %v, want %v", got[0].End == nil, tt.ongoing)
+			}
+		})
+	}
+}
+
+func TestIncidents_NewestFirst(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	got := Incidents(results(start, time.Minute, false, false, true, false, false, true))
+
+	if len(got) != 2 {
+		t.Fatalf("Incidents() returned %d, want 2", len(got))
+	}
+	if !got[0].Start.After(got[1].Start) {
+		t.Errorf("expected newest incident first")
+	}
+	if d := got[1].Duration(start); d != 2*time.Minute {
+		t.Errorf("Duration() = %v, want 2m", d)
+	}
+}
+
+func TestDailySummary(t *testing.T) {
+	now := time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC)
+	day1 := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	day3 := time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC)
+
+	var rs []*models.UptimeResult
+	rs = append(rs, results(day1, time.Minute, true, false, true, true)...)
+	rs = append(rs, results(day3, time.Minute, true, true)...)
+
+	got := DailySummary(rs, 3, now)
+	if len(got) != 3 {
+		t.Fatalf("DailySummary() returned %d days, want 3", len(got))
+	}
+
+	want := []DaySummary{
+		{Date: "2024-01-01", Checks: 4, UptimePercent: 75},
+		{Date: "2024-01-02", Checks: 0, UptimePercent: 100},
+		{Date: "2024-01-03", Checks: 2, UptimePercent: 100},
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("day %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
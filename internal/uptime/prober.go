@@ -0,0 +1,275 @@
+package uptime
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"schooner/internal/models"
+	"schooner/internal/notify"
+)
+
+const (
+	// downThreshold is the number of consecutive failed probes before an app
+	// is marked down. A single timeout during a redeploy shouldn't page anyone.
+	downThreshold = 2
+
+	// retention is how long probe results are kept
+	retention = 7 * 24 * time.Hour
+
+	// tick is how often the prober looks for checks that are due
+	tick = 5 * time.Second
+)
+
+// CheckStore interface for loading checks and recording results
+type CheckStore interface {
+	ListEnabled(ctx context.Context) ([]*models.UptimeCheck, error)
+	RecordResult(ctx context.Context, result *models.UptimeResult, status models.UptimeStatus) error
+	PruneResults(ctx context.Context, before time.Time) (int64, error)
+}
+
+// AppGetter interface for looking up apps
+type AppGetter interface {
+	GetByID(ctx context.Context, id string) (*models.App, error)
+}
+
+// URLResolver resolves the public URL of an app
+type URLResolver interface {
+	PublicURL(ctx context.Context, app *models.App) string
+}
+
+// probeState tracks what the prober knows about one check
+type probeState struct {
+	nextRun  time.Time
+	failures int
+	status   models.UptimeStatus
+	running  bool
+}
+
+// observe records a probe outcome and returns the new status and whether it
+// changed in a way worth notifying about (went down, or recovered from down)
+func (s *probeState) observe(up bool) (models.UptimeStatus, bool) {
+	if up {
+		s.failures = 0
+		wasDown := s.status == models.UptimeDown
+		s.status = models.UptimeUp
+		return s.status, wasDown
+	}
+
+	s.failures++
+	if s.failures < downThreshold || s.status == models.UptimeDown {
+		return s.status, false
+	}
+
+	s.status = models.UptimeDown
+	return s.status, true
+}
+
+// Prober runs HTTP uptime checks against deployed apps
+type Prober struct {
+	checkStore  CheckStore
+	appQueries  AppGetter
+	urlResolver URLResolver
+	dispatcher  *notify.Dispatcher
+	httpClient  *http.Client
+	logger      *slog.Logger
+
+	mu     sync.Mutex
+	states map[string]*probeState
+}
+
+// NewProber creates a new uptime prober
+func NewProber(checkStore CheckStore, appQueries AppGetter, dispatcher *notify.Dispatcher) *Prober {
+	return &Prober{
+		checkStore: checkStore,
+		appQueries: appQueries,
+		dispatcher: dispatcher,
+		httpClient: &http.Client{
+			// Report the app's own status rather than wherever it redirects to
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		logger: slog.Default(),
+		states: make(map[string]*probeState),
+	}
+}
+
+// SetURLResolver sets the resolver used for checks without an explicit URL
+func (p *Prober) SetURLResolver(r URLResolver) {
+	p.urlResolver = r
+}
+
+// Start runs the prober until the context is cancelled
+func (p *Prober) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+
+		lastPrune := time.Time{}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				p.runDue(ctx, now)
+
+				if now.Sub(lastPrune) >= time.Hour {
+					lastPrune = now
+					if n, err := p.checkStore.PruneResults(ctx, now.Add(-retention)); err != nil {
+						p.logger.Warn("failed to prune uptime results", "error", err)
+					} else if n > 0 {
+						p.logger.Debug("pruned uptime results", "count", n)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// runDue starts a probe for every check whose interval has elapsed
+func (p *Prober) runDue(ctx context.Context, now time.Time) {
+	checks, err := p.checkStore.ListEnabled(ctx)
+	if err != nil {
+		p.logger.Warn("uptime prober failed to list checks", "error", err)
+		return
+	}
+
+	for _, check := range checks {
+		if !p.claim(check, now) {
+			continue
+		}
+		go p.run(ctx, check)
+	}
+}
+
+// claim reserves a check for probing if it is due and not already running
+func (p *Prober) claim(check *models.UptimeCheck, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, ok := p.states[check.ID]
+	if !ok {
+		state = &probeState{status: check.LastStatus}
+		p.states[check.ID] = state
+	}
+
+	if state.running || now.Before(state.nextRun) {
+		return false
+	}
+
+	state.running = true
+	state.nextRun = now.Add(check.Interval())
+	return true
+}
+
+// run probes a check once, records the result and notifies on status changes
+func (p *Prober) run(ctx context.Context, check *models.UptimeCheck) {
+	defer func() {
+		p.mu.Lock()
+		p.states[check.ID].running = false
+		p.mu.Unlock()
+	}()
+
+	target := p.resolveURL(ctx, check)
+	if target == "" {
+		p.logger.Debug("uptime check has no URL", "app", check.AppName)
+		return
+	}
+
+	result := p.probe(ctx, check, target)
+
+	p.mu.Lock()
+	status, changed := p.states[check.ID].observe(result.Up)
+	p.mu.Unlock()
+
+	if err := p.checkStore.RecordResult(ctx, result, status); err != nil {
+		p.logger.Warn("failed to record uptime result", "app", check.AppName, "error", err)
+	}
+
+	if changed {
+		p.notify(ctx, check, target, status, result)
+	}
+}
+
+// resolveURL returns the check's explicit URL or the app's public tunnel URL
+func (p *Prober) resolveURL(ctx context.Context, check *models.UptimeCheck) string {
+	if u := check.GetURL(); u != "" {
+		return u
+	}
+	if p.urlResolver == nil {
+		return ""
+	}
+
+	app, err := p.appQueries.GetByID(ctx, check.AppID)
+	if err != nil || app == nil {
+		return ""
+	}
+	return p.urlResolver.PublicURL(ctx, app)
+}
+
+// probe performs a single HTTP GET against the target
+func (p *Prober) probe(ctx context.Context, check *models.UptimeCheck, target string) *models.UptimeResult {
+	result := &models.UptimeResult{
+		CheckID:   check.ID,
+		CheckedAt: time.Now(),
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, check.Timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, target, nil)
+	if err != nil {
+		result.Error = sql.NullString{String: err.Error(), Valid: true}
+		return result
+	}
+	req.Header.Set("User-Agent", "Schooner-Uptime/1.0")
+
+	start := time.Now()
+	resp, err := p.httpClient.Do(req)
+	result.ResponseMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = sql.NullString{String: err.Error(), Valid: true}
+		return result
+	}
+	resp.Body.Close()
+
+	result.StatusCode = sql.NullInt64{Int64: int64(resp.StatusCode), Valid: true}
+	result.Up = check.IsExpectedStatus(resp.StatusCode)
+	if !result.Up {
+		result.Error = sql.NullString{String: fmt.Sprintf("unexpected status %d", resp.StatusCode), Valid: true}
+	}
+
+	return result
+}
+
+func (p *Prober) notify(ctx context.Context, check *models.UptimeCheck, target string, status models.UptimeStatus, result *models.UptimeResult) {
+	if p.dispatcher == nil {
+		return
+	}
+
+	event := notify.Event{
+		AppName: check.AppName,
+		URL:     p.dispatcher.BaseURL() + "/apps/" + check.AppID,
+	}
+
+	if status == models.UptimeDown {
+		event.Type = notify.EventUptimeDown
+		event.Title = fmt.Sprintf("%s is unreachable", check.AppName)
+		event.Message = fmt.Sprintf("%s failed %d consecutive checks: %s", target, downThreshold, result.Error.String)
+		event.Priority = notify.PriorityHigh
+		p.logger.Warn("app is down", "app", check.AppName, "url", target, "error", result.Error.String)
+	} else {
+		event.Type = notify.EventUptimeUp
+		event.Title = fmt.Sprintf("%s is back up", check.AppName)
+		event.Message = fmt.Sprintf("%s responded in %dms", target, result.ResponseMs)
+		event.Priority = notify.PriorityDefault
+		p.logger.Info("app recovered", "app", check.AppName, "url", target)
+	}
+
+	p.dispatcher.Notify(ctx, event)
+}
@@ -0,0 +1,145 @@
+package uptime
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"schooner/internal/models"
+)
+
+func TestProbeState_Observe(t *testing.T) {
+	s := &probeState{status: models.UptimeUnknown}
+
+	steps := []struct {
+		up          bool
+		wantStatus  models.UptimeStatus
+		wantChanged bool
+	}{
+		{up: true, wantStatus: models.UptimeUp, wantChanged: false},  // unknown -> up is not news
+		{up: false, wantStatus: models.UptimeUp, wantChanged: false}, // first failure
+		{up: false, wantStatus: models.UptimeDown, wantChanged: true},
+		{up: false, wantStatus: models.UptimeDown, wantChanged: false}, // already down
+		{up: true, wantStatus: models.UptimeUp, wantChanged: true},     // recovered
+		{up: false, wantStatus: models.UptimeUp, wantChanged: false},
+		{up: true, wantStatus: models.UptimeUp, wantChanged: false}, // flap below threshold
+	}
+
+	for i, step := range steps {
+		status, changed := s.observe(step.up)
+		if status != step.wantStatus || changed != step.wantChanged {
+			t.Errorf("step %d: observe(%v) = (%s, %v), want (%s, %v)",
+				i, step.up, status, changed, step.wantStatus, step.wantChanged)
+		}
+	}
+}
+
+func TestProber_Probe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/redirect":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		expected int
+		wantUp   bool
+		wantCode int64
+	}{
+		{name: "any 2xx", path: "/ok", wantUp: true, wantCode: 200},
+		{name: "redirect counts as up", path: "/redirect", wantUp: true, wantCode: 302},
+		{name: "redirect not followed", path: "/redirect", expected: 200, wantUp: false, wantCode: 302},
+		{name: "server error", path: "/broken", wantUp: false, wantCode: 503},
+		{name: "explicit 503", path: "/broken", expected: 503, wantUp: true, wantCode: 503},
+	}
+
+	p := NewProber(nil, nil, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := &models.UptimeCheck{ID: "c1", TimeoutSeconds: 5, ExpectedStatus: tt.expected}
+			result := p.probe(context.Background(), check, server.URL+tt.path)
+			if result.Up != tt.wantUp {
+				t.Errorf("Up = %v, want %v (error: %s)", result.Up, tt.wantUp, result.Error.String)
+			}
+			if result.StatusCode.Int64 != tt.wantCode {
+				t.Errorf("StatusCode = %d, want %d", result.StatusCode.Int64, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestProber_ProbeConnectionError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	p := NewProber(nil, nil, nil)
+	result := p.probe(context.Background(), &models.UptimeCheck{ID: "c1", TimeoutSeconds: 1}, url)
+	if result.Up {
+		t.Error("expected probe to a closed server to be down")
+	}
+	if !result.Error.Valid {
+		t.Error("expected an error message")
+	}
+}
+
+type fakeResolver struct{ url string }
+
+func (f fakeResolver) PublicURL(ctx context.Context, app *models.App) string { return f.url }
+
+type fakeApps struct{}
+
+func (fakeApps) GetByID(ctx context.Context, id string) (*models.App, error) {
+	return &models.App{ID: id}, nil
+}
+
+func TestProber_ResolveURL(t *testing.T) {
+	p := NewProber(nil, fakeApps{}, nil)
+	check := &models.UptimeCheck{AppID: "a1"}
+
+	if got := p.resolveURL(context.Background(), check); got != "" {
+		t.Errorf("resolveURL() without resolver = %q, want empty", got)
+	}
+
+	p.SetURLResolver(fakeResolver{url: "https://app.example.com"})
+	if got := p.resolveURL(context.Background(), check); got != "https://app.example.com" {
+		t.Errorf("resolveURL() = %q, want tunnel URL", got)
+	}
+
+	check.URL = sql.NullString{String: "https://custom.example.com/health", Valid: true}
+	if got := p.resolveURL(context.Background(), check); got != "https://custom.example.com/health" {
+		t.Errorf("resolveURL() = %q, want explicit URL", got)
+	}
+}
+
+func TestProber_Claim(t *testing.T) {
+	p := NewProber(nil, nil, nil)
+	check := &models.UptimeCheck{ID: "c1", IntervalSeconds: 60}
+	now := time.Now()
+
+	if !p.claim(check, now) {
+		t.Fatal("expected first claim to succeed")
+	}
+	if p.claim(check, now.Add(time.Minute)) {
+		t.Error("expected claim to fail while a probe is running")
+	}
+
+	p.states[check.ID].running = false
+	if p.claim(check, now.Add(30*time.Second)) {
+		t.Error("expected claim to fail before the interval elapses")
+	}
+	if !p.claim(check, now.Add(time.Minute)) {
+		t.Error("expected claim to succeed once the interval elapses")
+	}
+}
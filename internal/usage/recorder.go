@@ -0,0 +1,156 @@
+package usage
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+const (
+	// interval is how often app resource usage is recorded
+	interval = time.Minute
+
+	// minuteRetention is how long minute samples are kept before they're
+	// rolled up into hourly ones
+	minuteRetention = 24 * time.Hour
+
+	// retention is how long hourly samples are kept
+	retention = 30 * 24 * time.Hour
+)
+
+// StatsReader returns the latest resource usage of running containers
+type StatsReader interface {
+	Latest() []docker.ContainerSample
+}
+
+// SampleStore interface for recording and compacting resource samples
+type SampleStore interface {
+	RecordSamples(ctx context.Context, samples []*models.ResourceSample) error
+	Downsample(ctx context.Context, before time.Time) (int64, error)
+	PruneSamples(ctx context.Context, before time.Time) (int64, error)
+}
+
+// AppLister interface for looking up apps
+type AppLister interface {
+	List(ctx context.Context) ([]*models.App, error)
+}
+
+// ContainerStatusGetter reads the restart count of an app's container
+type ContainerStatusGetter interface {
+	GetContainerStatus(ctx context.Context, nameOrID string) (*docker.ContainerStatus, error)
+}
+
+// Recorder keeps a history of the CPU and memory each app's containers use,
+// taken from the container stats collector
+type Recorder struct {
+	stats      StatsReader
+	store      SampleStore
+	appQueries AppLister
+	docker     ContainerStatusGetter
+	logger     *slog.Logger
+}
+
+// NewRecorder creates a new Recorder
+func NewRecorder(stats StatsReader, store SampleStore, appQueries AppLister, dockerClient ContainerStatusGetter) *Recorder {
+	return &Recorder{
+		stats:      stats,
+		store:      store,
+		appQueries: appQueries,
+		docker:     dockerClient,
+		logger:     slog.Default(),
+	}
+}
+
+// Start runs the recorder until the context is cancelled
+func (r *Recorder) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		lastCompact := time.Time{}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				r.record(ctx, now)
+
+				if now.Sub(lastCompact) >= time.Hour {
+					lastCompact = now
+					r.compact(ctx, now)
+				}
+			}
+		}
+	}()
+}
+
+// record stores one sample per app with running containers, adding up the
+// usage of apps that run several
+func (r *Recorder) record(ctx context.Context, now time.Time) {
+	apps, err := r.appQueries.List(ctx)
+	if err != nil {
+		r.logger.Warn("usage recorder failed to list apps", "error", err)
+		return
+	}
+	byID := make(map[string]*models.App, len(apps))
+	for _, app := range apps {
+		byID[app.ID] = app
+	}
+
+	sampledAt := now.UTC().Truncate(interval)
+	var samples []*models.ResourceSample
+	byApp := make(map[string]*models.ResourceSample)
+	for _, c := range r.stats.Latest() {
+		// Stale samples mean the collector can't reach Docker
+		if byID[c.AppID] == nil || now.Sub(c.SampledAt) > interval {
+			continue
+		}
+		sample, ok := byApp[c.AppID]
+		if !ok {
+			sample = &models.ResourceSample{
+				AppID:      c.AppID,
+				Resolution: models.ResourceResolutionMinute,
+				SampledAt:  sampledAt,
+			}
+			byApp[c.AppID] = sample
+			samples = append(samples, sample)
+		}
+		sample.CPUPercent += c.Stats.CPUPercent
+		sample.MemoryUsage += c.Stats.MemoryUsage
+		sample.MemoryLimit += c.Stats.MemoryLimit
+	}
+
+	if r.docker != nil {
+		for _, sample := range samples {
+			status, err := r.docker.GetContainerStatus(ctx, byID[sample.AppID].GetContainerName())
+			if err == nil && status != nil {
+				sample.RestartCount = status.Restarts
+			}
+		}
+	}
+
+	if err := r.store.RecordSamples(ctx, samples); err != nil {
+		r.logger.Warn("failed to record resource usage", "error", err)
+	}
+}
+
+// compact rolls samples older than a day up into hourly ones and drops
+// those past retention
+func (r *Recorder) compact(ctx context.Context, now time.Time) {
+	// Only whole hours are rolled up, so no hour is split across two rows
+	before := now.UTC().Add(-minuteRetention).Truncate(time.Hour)
+	if n, err := r.store.Downsample(ctx, before); err != nil {
+		r.logger.Warn("failed to downsample resource usage", "error", err)
+	} else if n > 0 {
+		r.logger.Debug("downsampled resource usage", "count", n)
+	}
+
+	if n, err := r.store.PruneSamples(ctx, now.Add(-retention)); err != nil {
+		r.logger.Warn("failed to prune resource usage", "error", err)
+	} else if n > 0 {
+		r.logger.Debug("pruned resource usage", "count", n)
+	}
+}
@@ -0,0 +1,115 @@
+package usage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"schooner/internal/database"
+	"schooner/internal/database/queries"
+	"schooner/internal/docker"
+	"schooner/internal/models"
+)
+
+type fakeStats []docker.ContainerSample
+
+func (s fakeStats) Latest() []docker.ContainerSample { return s }
+
+type fakeDocker map[string]int
+
+func (d fakeDocker) GetContainerStatus(ctx context.Context, name string) (*docker.ContainerStatus, error) {
+	return &docker.ContainerStatus{Name: name, State: "running", Restarts: d[name]}, nil
+}
+
+func TestRecorder(t *testing.T) {
+	db := newTestDB(t)
+
+	ctx := context.Background()
+	appQueries := queries.NewAppQueries(db.DB)
+	resourceQueries := queries.NewResourceQueries(db.DB)
+	for _, name := range []string{"web", "api"} {
+		app := &models.App{
+			ID: name, Name: name, RepoURL: "https://github.com/example/" + name + ".git", Branch: "main",
+			BuildStrategy: models.BuildStrategyDockerfile, CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		}
+		if err := appQueries.Create(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	stats := fakeStats{
+		{Name: "web-app-1", AppID: "web", Stats: docker.ContainerStats{CPUPercent: 10, MemoryUsage: 100, MemoryLimit: 1000}, SampledAt: now},
+		{Name: "web-db-1", AppID: "web", Stats: docker.ContainerStats{CPUPercent: 5, MemoryUsage: 50, MemoryLimit: 1000}, SampledAt: now},
+		{Name: "api", AppID: "api", Stats: docker.ContainerStats{CPUPercent: 1}, SampledAt: now.Add(-time.Hour)},
+		{Name: "caddy", Stats: docker.ContainerStats{CPUPercent: 3}, SampledAt: now},
+		{Name: "gone", AppID: "deleted", Stats: docker.ContainerStats{CPUPercent: 3}, SampledAt: now},
+	}
+	r := NewRecorder(stats, resourceQueries, appQueries, fakeDocker{"web": 2})
+
+	// Two days of minute samples, a few an hour
+	start := now.Add(-48 * time.Hour).Truncate(time.Hour)
+	for at := start; at.Before(now.Add(-10 * time.Minute)); at = at.Add(20 * time.Minute) {
+		r.record(ctx, at)
+	}
+	r.record(ctx, now)
+
+	samples, err := resourceQueries.ListSince(ctx, "web", now.Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 1 {
+		t.Fatalf("recorded %d samples for web just now, want 1", len(samples))
+	}
+	if s := samples[0]; s.CPUPercent != 15 || s.MemoryUsage != 150 || s.MemoryLimit != 2000 || s.RestartCount != 2 {
+		t.Errorf("sample = %+v, want the usage of both containers and 2 restarts", s)
+	}
+	if api, _ := resourceQueries.ListSince(ctx, "api", now.Add(-time.Minute)); len(api) != 0 {
+		t.Errorf("recorded %d samples from a stale reading, want 0", len(api))
+	}
+
+	// Compacting leaves minute samples for the last day and hourly ones before
+	r.compact(ctx, now)
+	samples, err = resourceQueries.ListSince(ctx, "web", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cutoff := now.UTC().Add(-minuteRetention).Truncate(time.Hour)
+	hours := 0
+	for _, s := range samples {
+		if s.SampledAt.Before(cutoff) {
+			if s.Resolution != models.ResourceResolutionHour {
+				t.Fatalf("sample at %v is %s, want hour", s.SampledAt, s.Resolution)
+			}
+			hours++
+		} else if s.Resolution != models.ResourceResolutionMinute {
+			t.Fatalf("sample at %v is %s, want minute", s.SampledAt, s.Resolution)
+		}
+	}
+	if want := int(cutoff.Sub(start.UTC()) / time.Hour); hours != want {
+		t.Errorf("rolled up into %d hourly samples, want %d", hours, want)
+	}
+
+	// Everything past retention is dropped
+	r.compact(ctx, now.Add(retention+48*time.Hour))
+	if samples, _ := resourceQueries.ListSince(ctx, "web", start); len(samples) != 0 {
+		t.Errorf("%d samples left after retention, want 0", len(samples))
+	}
+}
+
+// newTestDB returns a migrated database in a temporary directory, keeping
+// the encryption key it creates there too
+func newTestDB(t *testing.T) *database.DB {
+	t.Helper()
+	t.Setenv("SCHOONER_KEY_PATH", filepath.Join(t.TempDir(), ".encryption_key"))
+	db, err := database.New(filepath.Join(t.TempDir(), "schooner.db"))
+	if err != nil {
+		t.Fatalf("database.New() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	return db
+}
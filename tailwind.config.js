@@ -0,0 +1,7 @@
+// Tailwind looks for class names in the templates, scripts and the Go
+// handlers that render HTML. Run `make css` after adding new ones.
+module.exports = {
+  content: ['./ui/**/*.{html,js}', './internal/**/*.go'],
+  theme: { extend: {} },
+  plugins: [],
+};
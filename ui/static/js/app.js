@@ -0,0 +1,694 @@
+// Shared by every dashboard page: htmx feedback, toasts and the app,
+// import and GitHub token forms
+
+// Handle HTMX requests
+document.body.addEventListener('htmx:afterRequest', function(evt) {
+    if (evt.detail.successful) {
+        // Refresh page on successful form submission. Forms that only
+        // fetch, like the dashboard search, swap in their results instead.
+        if (evt.detail.elt.tagName === 'FORM' && evt.detail.requestConfig.verb !== 'get') {
+            window.location.reload();
+        }
+        // Handle deploy/start/stop/restart buttons. Pages with live updates
+        // redraw what changed once the change event arrives.
+        if (evt.detail.elt.tagName === 'BUTTON') {
+            const action = evt.detail.pathInfo.requestPath;
+            if (action.includes('/deploy')) {
+                showToast('Build queued successfully', 'success');
+                if (!liveUpdates) {
+                    setTimeout(() => window.location.reload(), 1500);
+                }
+            } else if (action.includes('/start') || action.includes('/stop') || action.includes('/restart')) {
+                showToast('Container action completed', 'success');
+                if (!liveUpdates) {
+                    setTimeout(() => window.location.reload(), 1000);
+                }
+            }
+        }
+    } else if (evt.detail.failed) {
+        showToast('Action failed: ' + (evt.detail.xhr.responseText || 'Unknown error'), 'error');
+    }
+});
+
+// Live updates: fragments marked with data-fragment reload themselves on a
+// refresh event. App fragments refresh when their app, its builds or its
+// container change, the recent builds and build queue when any build does,
+// and the activity feed as entries are added to it.
+let liveUpdates = false;
+if (window.EventSource && document.querySelector('[data-fragment]')) {
+    const pending = new Map();
+    const refresh = fragment => {
+        // A deploy sends a burst of changes, so they're coalesced
+        clearTimeout(pending.get(fragment));
+        pending.set(fragment, setTimeout(() => {
+            pending.delete(fragment);
+            htmx.trigger(fragment, 'refresh');
+        }, 250));
+    };
+
+    const source = new EventSource('/api/events');
+    source.addEventListener('open', () => { liveUpdates = true; });
+    source.addEventListener('error', () => { liveUpdates = false; });
+    source.addEventListener('change', event => {
+        const change = JSON.parse(event.data);
+        if (change.kind === 'activity') {
+            document.querySelectorAll('[data-fragment="activity"]').forEach(refresh);
+            return;
+        }
+        document.querySelectorAll('[data-app-id="' + CSS.escape(change.app_id) + '"]').forEach(fragment => {
+            if (change.kind === 'app' && change.status === 'deleted') {
+                fragment.remove();
+            } else {
+                refresh(fragment);
+            }
+        });
+        if (change.kind === 'build') {
+            document.querySelectorAll('[data-fragment="recent-builds"], [data-fragment="build-queue"]').forEach(refresh);
+        }
+    });
+}
+
+// Dashboard app groups the user collapsed stay collapsed
+const collapsedGroups = new Set(JSON.parse(localStorage.getItem('collapsedGroups') || '[]'));
+function restoreGroups(root) {
+    root.querySelectorAll('details[data-group]').forEach(group => {
+        if (collapsedGroups.has(group.dataset.group)) group.open = false;
+    });
+}
+restoreGroups(document);
+document.body.addEventListener('htmx:afterSwap', () => restoreGroups(document));
+document.addEventListener('toggle', evt => {
+    const group = evt.target;
+    if (!group.matches || !group.matches('details[data-group]')) return;
+    if (group.open) {
+        collapsedGroups.delete(group.dataset.group);
+    } else {
+        collapsedGroups.add(group.dataset.group);
+    }
+    localStorage.setItem('collapsedGroups', JSON.stringify([...collapsedGroups]));
+}, true);
+
+// Dashboard multi-select: checked apps can be deployed, started, stopped
+// or restarted together. The selection outlives cards redrawn by live
+// updates and searches.
+const selectedApps = new Set();
+function updateAppSelection() {
+    document.querySelectorAll('.app-select').forEach(box => {
+        box.checked = selectedApps.has(box.value);
+    });
+    const bar = document.getElementById('bulk-actions');
+    if (!bar) return;
+    bar.classList.toggle('hidden', selectedApps.size === 0);
+    document.getElementById('bulk-count').textContent =
+        selectedApps.size + (selectedApps.size === 1 ? ' app selected' : ' apps selected');
+}
+document.addEventListener('change', evt => {
+    if (!evt.target.matches('.app-select')) return;
+    if (evt.target.checked) {
+        selectedApps.add(evt.target.value);
+    } else {
+        selectedApps.delete(evt.target.value);
+    }
+    updateAppSelection();
+});
+document.body.addEventListener('htmx:afterSwap', updateAppSelection);
+
+function selectAllApps() {
+    document.querySelectorAll('.app-select').forEach(box => selectedApps.add(box.value));
+    updateAppSelection();
+}
+
+function clearAppSelection() {
+    selectedApps.clear();
+    updateAppSelection();
+}
+
+function bulkAction(action) {
+    const count = selectedApps.size;
+    if (count === 0) return;
+    if (action !== 'deploy' && !confirm(action.charAt(0).toUpperCase() + action.slice(1) + ' ' + count + (count === 1 ? ' app?' : ' apps?'))) return;
+
+    fetch('/api/apps/bulk/' + action, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ app_ids: [...selectedApps] })
+    })
+    .then(response => {
+        if (!response.ok) return response.text().then(text => { throw new Error(text); });
+        return response.json();
+    })
+    .then(data => {
+        if (data.failed === 0) {
+            showToast(data.succeeded + (data.succeeded === 1 ? ' app ' : ' apps ') + (action === 'deploy' ? 'queued' : data.results[0].status), 'success');
+            clearAppSelection();
+            if (!liveUpdates) {
+                setTimeout(() => window.location.reload(), 1500);
+            }
+        } else {
+            // Failed apps stay selected so they can be tried again
+            const failed = data.results.filter(r => r.error);
+            data.results.filter(r => !r.error).forEach(r => selectedApps.delete(r.app_id));
+            updateAppSelection();
+            showToast(failed.length + ' of ' + data.results.length + ' failed: ' +
+                failed.map(r => (r.name || r.app_id) + ' (' + r.error + ')').join(', '), 'error');
+        }
+    })
+    .catch(err => showToast('Bulk ' + action + ' failed: ' + err.message, 'error'));
+}
+
+// Toast notification
+function showToast(message, type) {
+    const toast = document.createElement('div');
+    toast.className = 'fixed bottom-4 right-4 px-4 py-2 rounded shadow-lg text-white z-50 ' +
+        (type === 'error' ? 'bg-red-600' : 'bg-green-600');
+    toast.textContent = message;
+    document.body.appendChild(toast);
+    setTimeout(() => toast.remove(), 3000);
+}
+
+// Confirm delete, optionally removing what the app leaves behind
+const deleteCleanupOptions = [
+    ['containers', 'Stop and remove its containers'],
+    ['images', 'Delete its built images'],
+    ['volumes', 'Remove its named volumes and their data'],
+    ['dns', 'Delete its Cloudflare DNS record'],
+    ['webhook', 'Delete its GitHub webhook']
+];
+
+function confirmDelete(appId, appName) {
+    const modal = document.createElement('div');
+    modal.className = 'fixed inset-0 bg-black bg-opacity-40 flex items-center justify-center z-50';
+    modal.innerHTML = '<form class="bg-white rounded-lg shadow-lg p-6 w-full max-w-md">' +
+        '<h2 class="text-lg font-semibold mb-2">Delete "' + escapeHtml(appName) + '"?</h2>' +
+        '<p class="text-sm text-gray-500 mb-4">Its builds, checkout and routes are deleted with it. Also:</p>' +
+        deleteCleanupOptions.map(([name, label]) =>
+            '<label class="flex items-center mb-2 text-sm text-gray-700">' +
+                '<input type="checkbox" name="' + name + '" class="mr-2">' + label +
+            '</label>').join('') +
+        '<div class="flex justify-end space-x-2 mt-4">' +
+            '<button type="button" data-cancel class="px-4 py-2 bg-gray-50 hover:bg-gray-100 rounded border border-gray-200 text-gray-700">Cancel</button>' +
+            '<button type="submit" class="px-4 py-2 bg-red-600 hover:bg-red-700 rounded text-white">Delete</button>' +
+        '</div>' +
+    '</form>';
+    document.body.appendChild(modal);
+
+    modal.querySelector('[data-cancel]').addEventListener('click', () => modal.remove());
+    modal.querySelector('form').addEventListener('submit', event => {
+        event.preventDefault();
+        const params = new URLSearchParams();
+        event.target.querySelectorAll('input:checked').forEach(box => params.set(box.name, 'true'));
+        fetch('/api/apps/' + appId + (params.size ? '?' + params : ''), { method: 'DELETE' })
+            .then(response => {
+                if (!response.ok) throw new Error('Failed to delete app');
+                return response.status === 204 ? null : response.json();
+            })
+            .then(data => {
+                const failed = data ? data.cleanup.filter(step => step.error) : [];
+                if (failed.length > 0) {
+                    alert('App deleted, but some cleanup failed:\n' + failed.map(step => step.step + ': ' + step.error).join('\n'));
+                }
+                window.location.reload();
+            })
+            .catch(err => {
+                modal.remove();
+                alert(err.message);
+            });
+    });
+}
+
+// Clone an app as a second environment of it. Env vars that look like
+// secrets are only copied when asked to.
+function cloneApp(appId, appName) {
+    const name = prompt('Name of the copy of "' + appName + '":', appName + '-staging');
+    if (!name) return;
+    const subdomain = prompt('Subdomain (optional):', name) || '';
+    const port = subdomain ? parseInt(prompt('Host port for ' + subdomain + ':', ''), 10) || 0 : 0;
+    const includeSecrets = confirm('Copy env vars that look like secrets, such as passwords and API keys?');
+
+    fetch('/api/apps/' + appId + '/clone', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ name: name, subdomain: subdomain, public_port: port, include_secrets: includeSecrets })
+    })
+    .then(response => {
+        if (!response.ok) return response.text().then(text => { throw new Error(text); });
+        return response.json();
+    })
+    .then(data => {
+        if (data.excluded_env_vars) {
+            alert('Left out ' + data.excluded_env_vars.join(', ') + '. Set them on the copy before deploying it.');
+        }
+        window.location.href = '/apps/' + data.app.id;
+    })
+    .catch(err => alert('Failed to clone app: ' + err.message));
+}
+
+// Clear the volumes that keep an app's package caches between builds
+function clearBuildCache(appId) {
+    if (!confirm('Clear the build caches? The next build downloads its packages again.')) return;
+
+    fetch('/api/apps/' + appId + '/build-cache', { method: 'DELETE' })
+    .then(response => {
+        if (!response.ok) return response.text().then(text => { throw new Error(text); });
+        return response.json();
+    })
+    .then(data => alert(data.volumes.length ? 'Removed ' + data.volumes.join(', ') : 'The app has no build caches yet'))
+    .catch(err => alert('Failed to clear build cache: ' + err.message));
+}
+
+// Configure webhook for app
+function configureWebhook(appId, appName) {
+    if (confirm('Configure GitHub webhook for "' + appName + '"?')) {
+        fetch('/api/apps/' + appId + '/webhook', { method: 'POST' })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    const msg = data.created ? 'Webhook created successfully!' : 'Webhook already configured.';
+                    showToast(msg, 'success');
+                } else {
+                    showToast('Failed to configure webhook: ' + (data.message || 'Unknown error'), 'error');
+                }
+            })
+            .catch(err => {
+                showToast('Failed to configure webhook: ' + err.message, 'error');
+            });
+    }
+}
+
+// GitHub import functions
+function showGitHubTokenForm() {
+    document.getElementById('github-token-form').classList.remove('hidden');
+}
+
+function hideGitHubTokenForm() {
+    document.getElementById('github-token-form').classList.add('hidden');
+}
+
+function submitGitHubToken(event) {
+    event.preventDefault();
+    const form = event.target;
+    const token = form.querySelector('input[name="github_token"]').value;
+
+    fetch('/api/settings/github-token', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ token: token })
+    })
+    .then(response => {
+        if (response.ok) {
+            window.location.reload();
+        } else {
+            response.text().then(text => alert('Failed to save token: ' + text));
+        }
+    });
+}
+
+function removeGitHubToken() {
+    if (confirm('Are you sure you want to remove the GitHub token?')) {
+        fetch('/api/settings/github-token', { method: 'DELETE' })
+            .then(response => {
+                if (response.ok) {
+                    window.location.reload();
+                } else {
+                    alert('Failed to remove token');
+                }
+            });
+    }
+}
+
+function showImportModal() {
+    document.getElementById('import-modal').classList.remove('hidden');
+    loadGitHubOrgs();
+    loadGitHubRepos();
+}
+
+function hideImportModal() {
+    document.getElementById('import-modal').classList.add('hidden');
+}
+
+// Repositories listed so far, and the page to load next (0 when
+// there are no more)
+let allRepos = [];
+let nextRepoPage = 0;
+let repoSearchTimer = null;
+
+function loadGitHubOrgs() {
+    fetch('/api/github/orgs')
+        .then(response => response.ok ? response.json() : [])
+        .then(orgs => {
+            const select = document.getElementById('repo-owner');
+            select.innerHTML = '<option value="">Your repositories</option>' +
+                orgs.map(org => '<option value="' + escapeHtml(org.login) + '">' + escapeHtml(org.login) + '</option>').join('');
+        });
+}
+
+function loadGitHubRepos(page = 1) {
+    const container = document.getElementById('github-repos-list');
+    if (page === 1) {
+        allRepos = [];
+        container.innerHTML = '<div class="text-center py-8 text-gray-500">Loading repositories...</div>';
+    }
+
+    const params = new URLSearchParams({
+        owner: document.getElementById('repo-owner').value,
+        q: document.getElementById('repo-search').value.trim(),
+        page: page,
+        per_page: 30
+    });
+    fetch('/api/github/repos?' + params)
+        .then(response => {
+            if (!response.ok) {
+                throw new Error('Failed to fetch repositories');
+            }
+            nextRepoPage = (response.headers.get('Link') || '').includes('rel="next"') ? page + 1 : 0;
+            return response.json();
+        })
+        .then(repos => {
+            allRepos = allRepos.concat(repos);
+            renderRepos(allRepos);
+        })
+        .catch(error => {
+            container.innerHTML = '<div class="text-center py-8 text-red-400">' + error.message + '</div>';
+        });
+}
+
+// Search on the server once typing pauses, so repos beyond the
+// first page are found too
+function filterRepos() {
+    clearTimeout(repoSearchTimer);
+    repoSearchTimer = setTimeout(() => loadGitHubRepos(1), 300);
+}
+
+function renderRepos(repos) {
+    const container = document.getElementById('github-repos-list');
+    if (repos.length === 0) {
+        container.innerHTML = '<div class="text-center py-8 text-gray-500">No repositories found</div>';
+        return;
+    }
+
+    let html = '';
+    repos.forEach((repo, index) => {
+        const disabled = repo.already_imported ? 'opacity-50 cursor-not-allowed' : 'hover:bg-gray-100 cursor-pointer';
+        const imported = repo.already_imported ? '<span class="text-xs text-green-600 ml-2">Already imported</span>' : '';
+        const badges = [];
+        if (repo.has_dockerfile) badges.push('<span class="text-xs bg-blue-100 text-blue-700 px-2 py-1 rounded">Dockerfile</span>');
+        if (repo.has_compose) badges.push('<span class="text-xs bg-purple-100 text-purple-700 px-2 py-1 rounded">Compose</span>');
+
+        html += '<div class="p-4 border-b border-gray-200 ' + disabled + '" ' +
+            (repo.already_imported ? '' : 'onclick="selectRepo(' + index + ')"') + '>' +
+            '<div class="flex items-center justify-between">' +
+            '<div>' +
+            '<div class="font-semibold">' + escapeHtml(repo.name) + imported + '</div>' +
+            '<div class="text-sm text-gray-500">' + escapeHtml(repo.description || 'No description') + '</div>' +
+            '</div>' +
+            '<div class="flex items-center space-x-2">' + badges.join('') + '</div>' +
+            '</div>' +
+            '</div>';
+    });
+
+    if (nextRepoPage) {
+        html += '<div class="p-4 text-center"><button type="button" onclick="loadGitHubRepos(nextRepoPage)" class="px-4 py-2 bg-gray-50 hover:bg-gray-100 rounded border border-gray-200 text-sm text-gray-700">Load more</button></div>';
+    }
+
+    container.innerHTML = html;
+}
+
+function selectRepo(index) {
+    const repo = allRepos[index];
+    document.getElementById('import-repo-name').textContent = repo.full_name;
+    document.getElementById('import-repo-fullname').value = repo.full_name;
+    document.getElementById('import-branch').value = repo.default_branch;
+
+    // Prefill from the Dockerfile's EXPOSE and the repo's .env.example
+    const ports = repo.exposed_ports || [];
+    document.getElementById('import-public-port').value = ports.length > 0 ? ports[0] : '';
+    document.getElementById('import-env-vars').value = (repo.env_keys || []).map(key => key + '=').join('\n');
+
+    // Auto-select build strategy
+    const strategySelect = document.getElementById('import-build-strategy');
+    if (repo.has_compose) {
+        strategySelect.value = 'compose';
+    } else {
+        strategySelect.value = 'dockerfile';
+    }
+
+    document.getElementById('repo-selection').classList.add('hidden');
+    document.getElementById('import-config').classList.remove('hidden');
+}
+
+function backToRepoList() {
+    document.getElementById('import-config').classList.add('hidden');
+    document.getElementById('repo-selection').classList.remove('hidden');
+}
+
+function submitImport(event) {
+    event.preventDefault();
+    const form = event.target;
+    const formData = new FormData(form);
+    const data = {
+        repo_full_name: formData.get('repo_full_name'),
+        branch: formData.get('branch'),
+        build_strategy: formData.get('build_strategy'),
+        public_port: parseInt(formData.get('public_port')) || 0,
+        env_vars: parseEnvVars(formData.get('env_vars')),
+        auto_deploy: formData.get('auto_deploy') === 'on'
+    };
+
+    const btn = form.querySelector('button[type="submit"]');
+    btn.disabled = true;
+    btn.textContent = 'Importing...';
+
+    fetch('/api/github/import', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(data)
+    })
+    .then(response => {
+        if (response.ok) {
+            window.location.reload();
+        } else {
+            response.text().then(text => {
+                alert('Failed to import: ' + text);
+                btn.disabled = false;
+                btn.textContent = 'Import & Deploy';
+            });
+        }
+    });
+}
+
+function escapeHtml(text) {
+    if (!text) return '';
+    const div = document.createElement('div');
+    div.textContent = text;
+    return div.innerHTML;
+}
+
+// Toggle edit form
+function toggleEditForm(appId) {
+    const form = document.getElementById('edit-form-' + appId);
+    form.classList.toggle('hidden');
+}
+
+// Show add app form
+function showAddForm() {
+    document.getElementById('add-app-form').classList.remove('hidden');
+    document.getElementById('add-app-btn').classList.add('hidden');
+}
+
+function hideAddForm() {
+    document.getElementById('add-app-form').classList.add('hidden');
+    document.getElementById('add-app-btn').classList.remove('hidden');
+}
+
+// Parse env vars string to object
+function parseEnvVars(str) {
+    const result = {};
+    if (!str) return result;
+    str.split('\n').forEach(line => {
+        line = line.trim();
+        if (!line || line.startsWith('#')) return;
+        const idx = line.indexOf('=');
+        if (idx > 0) {
+            const key = line.substring(0, idx).trim();
+            const value = line.substring(idx + 1);
+            result[key] = value;
+        }
+    });
+    return result;
+}
+
+// Read the deploy settings of an app form; container mode clears the
+// Swarm ones
+function deployConfigFromForm(formData) {
+    const lines = name => (formData.get(name) || '').split('\n').map(l => l.trim()).filter(l => l);
+    const words = name => (formData.get(name) || '').split(/[\s,]+/).filter(w => w);
+    const sysctls = {};
+    lines('sysctls').forEach(line => {
+        const i = line.indexOf('=');
+        if (i < 0) {
+            sysctls[line] = '';
+        } else {
+            sysctls[line.slice(0, i).trim()] = line.slice(i + 1).trim();
+        }
+    });
+    const cfg = {
+        devices: lines('devices'),
+        cap_add: words('cap_add'),
+        cap_drop: words('cap_drop'),
+        sysctls: sysctls,
+        shm_size: (formData.get('shm_size') || '').trim(),
+        gpus: (formData.get('gpus') || '').trim(),
+        runtime: (formData.get('runtime') || '').trim()
+    };
+    if (formData.get('deploy_mode') !== 'swarm') return cfg;
+    return Object.assign(cfg, {
+        mode: 'swarm',
+        replicas: parseInt(formData.get('replicas')) || 0,
+        update_parallelism: parseInt(formData.get('update_parallelism')) || 0,
+        update_delay: formData.get('update_delay') || '',
+        update_order: formData.get('update_order') || ''
+    });
+}
+
+// Read deploy windows and freezes, one per line; a freeze line is
+// its start, its end and an optional reason
+function scheduleFromForm(formData) {
+    const lines = name => (formData.get(name) || '').split('\n').map(l => l.trim()).filter(l => l);
+    return {
+        timezone: (formData.get('schedule_timezone') || '').trim(),
+        windows: lines('deploy_windows'),
+        freezes: lines('deploy_freezes').map(line => {
+            const parts = line.split(/\s+/);
+            return { start: parts.slice(0, 2).join(' '), end: parts.slice(2, 4).join(' '), reason: parts.slice(4).join(' ') };
+        })
+    };
+}
+
+// Submit add app form
+function submitAddApp(event) {
+    event.preventDefault();
+    const form = event.target;
+    const formData = new FormData(form);
+    const data = {
+        name: formData.get('name'),
+        description: formData.get('description'),
+        project: formData.get('project') || '',
+        tags: (formData.get('tags') || '').split(',').map(t => t.trim()).filter(t => t),
+        repo_url: formData.get('repo_url'),
+        branch: formData.get('branch') || 'main',
+        webhook_secret: formData.get('webhook_secret'),
+        build_strategy: formData.get('build_strategy') || 'dockerfile',
+        dockerfile_path: formData.get('dockerfile_path') || 'Dockerfile',
+        compose_file: formData.get('compose_file') || 'docker-compose.yaml',
+        build_context: formData.get('build_context') || '.',
+        container_name: formData.get('container_name'),
+        image_name: formData.get('image_name'),
+        env_vars: parseEnvVars(formData.get('env_vars')),
+        auto_deploy: formData.get('auto_deploy') === 'on',
+        enabled: formData.get('enabled') === 'on',
+        subdomain: formData.get('subdomain') || '',
+        public_port: parseInt(formData.get('public_port')) || 0,
+        auto_port: formData.get('auto_port') === 'on',
+        route_path: formData.get('route_path') || '',
+        protected: formData.get('protected') === 'on',
+        access_allow: formData.get('access_allow') || '',
+        tunnel: formData.get('tunnel') || '',
+        docker_host: formData.get('docker_host') || '',
+        agent_id: formData.get('agent_id') || '',
+        submodules: formData.get('submodules') === 'on',
+        lfs: formData.get('lfs') === 'on',
+        isolated_build: formData.get('isolated_build') === 'on',
+        require_approval: formData.get('require_approval') === 'on',
+        debounce_seconds: parseInt(formData.get('debounce_seconds'), 10) || 0,
+        tag_pattern: formData.get('tag_pattern') || '',
+        release_events: formData.get('release_events') === 'on',
+        allowed_branches: (formData.get('allowed_branches') || '').split(',').map(b => b.trim()).filter(b => b),
+        image_tag_strategy: formData.get('image_tag_strategy') || '',
+        compose_profiles: (formData.get('compose_profiles') || '').split(',').map(p => p.trim()).filter(p => p),
+        image_refresh: formData.get('image_refresh') || '',
+        test_command: formData.get('test_command') || '',
+        artifact_paths: (formData.get('artifact_paths') || '').split(',').map(p => p.trim()).filter(p => p),
+        build_caches: (formData.get('build_caches') || '').split(',').map(c => c.trim()).filter(c => c),
+        deploy_config: deployConfigFromForm(formData),
+        deploy_schedule: scheduleFromForm(formData),
+        basic_auth_user: formData.get('basic_auth_user') || '',
+        basic_auth_password: formData.get('basic_auth_password') || ''
+    };
+
+    fetch('/api/apps', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(data)
+    })
+    .then(response => {
+        if (response.ok) {
+            window.location.reload();
+        } else {
+            response.text().then(text => alert('Failed to add app: ' + text));
+        }
+    });
+}
+
+// Submit edit app form
+function submitEditApp(event, appId) {
+    event.preventDefault();
+    const form = event.target;
+    const formData = new FormData(form);
+    const data = {
+        name: formData.get('name'),
+        description: formData.get('description'),
+        project: formData.get('project') || '',
+        tags: (formData.get('tags') || '').split(',').map(t => t.trim()).filter(t => t),
+        repo_url: formData.get('repo_url'),
+        branch: formData.get('branch'),
+        webhook_secret: formData.get('webhook_secret'),
+        build_strategy: formData.get('build_strategy'),
+        dockerfile_path: formData.get('dockerfile_path'),
+        compose_file: formData.get('compose_file'),
+        build_context: formData.get('build_context'),
+        container_name: formData.get('container_name'),
+        image_name: formData.get('image_name'),
+        env_vars: parseEnvVars(formData.get('env_vars')),
+        auto_deploy: formData.get('auto_deploy') === 'on',
+        enabled: formData.get('enabled') === 'on',
+        subdomain: formData.get('subdomain') || '',
+        public_port: parseInt(formData.get('public_port')) || 0,
+        route_path: formData.get('route_path') || '',
+        protected: formData.get('protected') === 'on',
+        access_allow: formData.get('access_allow') || '',
+        tunnel: formData.get('tunnel') || '',
+        docker_host: formData.get('docker_host') || '',
+        agent_id: formData.get('agent_id') || '',
+        submodules: formData.get('submodules') === 'on',
+        lfs: formData.get('lfs') === 'on',
+        isolated_build: formData.get('isolated_build') === 'on',
+        require_approval: formData.get('require_approval') === 'on',
+        debounce_seconds: parseInt(formData.get('debounce_seconds'), 10) || 0,
+        tag_pattern: formData.get('tag_pattern') || '',
+        release_events: formData.get('release_events') === 'on',
+        allowed_branches: (formData.get('allowed_branches') || '').split(',').map(b => b.trim()).filter(b => b),
+        image_tag_strategy: formData.get('image_tag_strategy') || '',
+        compose_profiles: (formData.get('compose_profiles') || '').split(',').map(p => p.trim()).filter(p => p),
+        image_refresh: formData.get('image_refresh') || '',
+        test_command: formData.get('test_command') || '',
+        artifact_paths: (formData.get('artifact_paths') || '').split(',').map(p => p.trim()).filter(p => p),
+        build_caches: (formData.get('build_caches') || '').split(',').map(c => c.trim()).filter(c => c),
+        deploy_config: deployConfigFromForm(formData),
+        deploy_schedule: scheduleFromForm(formData),
+        basic_auth_user: formData.get('basic_auth_user') || '',
+        basic_auth_password: formData.get('basic_auth_password') || ''
+    };
+
+    fetch('/api/apps/' + appId, {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(data)
+    })
+    .then(response => {
+        if (response.ok) {
+            window.location.reload();
+        } else {
+            response.text().then(text => alert('Failed to update app: ' + text));
+        }
+    });
+}
@@ -0,0 +1,16 @@
+// The settings page's switch pausing auto-deploys of every app
+
+function setAutoDeployPaused(paused) {
+    fetch('/api/settings/auto-deploy', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ paused: paused })
+    })
+    .then(response => {
+        if (response.ok) {
+            window.location.reload();
+        } else {
+            response.text().then(text => alert('Failed to change auto-deploys: ' + text));
+        }
+    });
+}
@@ -0,0 +1,221 @@
+// The backups page: backup jobs, the backups taken and where they are stored
+
+function escapeBackupText(s) {
+    const div = document.createElement('div');
+    div.textContent = s == null ? '' : String(s);
+    return div.innerHTML;
+}
+
+function formatBytes(bytes) {
+    const units = ['B', 'KB', 'MB', 'GB', 'TB'];
+    let i = 0;
+    while (bytes >= 1024 && i < units.length - 1) {
+        bytes /= 1024;
+        i++;
+    }
+    return bytes.toFixed(i === 0 ? 0 : 1) + ' ' + units[i];
+}
+
+function loadVolumes() {
+    fetch('/api/backups/volumes')
+        .then(response => response.ok ? response.json() : [])
+        .then(volumes => {
+            document.getElementById('job-volumes').innerHTML = volumes.map(v =>
+                '<option value="' + escapeBackupText(v) + '">' + escapeBackupText(v) + '</option>').join('');
+        });
+}
+
+function loadBackupJobs() {
+    fetch('/api/backups/jobs')
+        .then(response => response.json())
+        .then(jobs => {
+            const body = document.getElementById('backup-jobs');
+            if (jobs.length === 0) {
+                body.innerHTML = '<tr><td colspan="7" class="py-2 text-gray-400">No backup jobs</td></tr>';
+                return;
+            }
+            body.innerHTML = jobs.map(job => {
+                let lastRun = job.last_run_at ? new Date(job.last_run_at).toLocaleString() : 'Never';
+                if (job.last_error) {
+                    lastRun = '<span class="text-red-600" title="' + escapeBackupText(job.last_error) + '">' + escapeBackupText(lastRun) + ' (failed)</span>';
+                } else {
+                    lastRun = escapeBackupText(lastRun);
+                }
+                let schedule = job.schedule ? escapeBackupText(job.schedule) : 'Manual';
+                if (!job.enabled) {
+                    schedule += ' <span class="text-gray-400">(paused)</span>';
+                }
+                return '<tr class="border-b border-gray-100">' +
+                    '<td class="py-2">' + escapeBackupText(job.name) + '</td>' +
+                    '<td class="py-2 font-mono text-xs">' + escapeBackupText(job.volumes) + '</td>' +
+                    '<td class="py-2 font-mono text-xs">' + schedule + '</td>' +
+                    '<td class="py-2">' + (job.keep_last || 'All') + '</td>' +
+                    '<td class="py-2">' + escapeBackupText(job.target) + '</td>' +
+                    '<td class="py-2 text-gray-500">' + lastRun + '</td>' +
+                    '<td class="py-2 text-right whitespace-nowrap">' +
+                        '<button onclick="runBackupJob(\'' + job.id + '\')" class="text-blue-600 hover:text-blue-700 mr-3">Run now</button>' +
+                        '<button onclick="deleteBackupJob(\'' + job.id + '\')" class="text-red-600 hover:text-red-700">Delete</button>' +
+                    '</td></tr>';
+            }).join('');
+        });
+}
+
+function loadBackups() {
+    fetch('/api/backups')
+        .then(response => response.json())
+        .then(backups => {
+            const body = document.getElementById('backups-list');
+            if (backups.length === 0) {
+                body.innerHTML = '<tr><td colspan="6" class="py-2 text-gray-400">No backups yet</td></tr>';
+                return;
+            }
+            body.innerHTML = backups.map(b => {
+                const colors = { success: 'text-green-600', failed: 'text-red-600', running: 'text-yellow-600' };
+                const restore = b.status === 'success'
+                    ? '<button onclick="restoreBackup(\'' + b.id + '\', \'' + escapeBackupText(b.volume) + '\')" class="text-blue-600 hover:text-blue-700 mr-3">Restore</button>'
+                    : '';
+                return '<tr class="border-b border-gray-100">' +
+                    '<td class="py-2 font-mono text-xs">' + escapeBackupText(b.volume) + '</td>' +
+                    '<td class="py-2 text-gray-500">' + escapeBackupText(new Date(b.created_at).toLocaleString()) + '</td>' +
+                    '<td class="py-2">' + (b.status === 'success' ? formatBytes(b.size_bytes) : '') + '</td>' +
+                    '<td class="py-2">' + escapeBackupText(b.target) + '</td>' +
+                    '<td class="py-2 ' + (colors[b.status] || '') + '" title="' + escapeBackupText(b.error || '') + '">' + escapeBackupText(b.status) + '</td>' +
+                    '<td class="py-2 text-right whitespace-nowrap">' + restore +
+                        '<button onclick="deleteBackup(\'' + b.id + '\')" class="text-red-600 hover:text-red-700">Delete</button>' +
+                    '</td></tr>';
+            }).join('');
+        });
+}
+
+function loadBackupStorage() {
+    fetch('/api/settings/backups')
+        .then(response => response.json())
+        .then(config => {
+            document.getElementById('storage-dir').value = config.dir || '';
+            document.getElementById('s3-endpoint').value = config.s3.endpoint || '';
+            document.getElementById('s3-region').value = config.s3.region || '';
+            document.getElementById('s3-bucket').value = config.s3.bucket || '';
+            document.getElementById('s3-prefix').value = config.s3.prefix || '';
+            document.getElementById('s3-access-key').value = config.s3.access_key || '';
+            if (config.s3_secret_set) {
+                document.getElementById('s3-secret-key').placeholder = 'Secret key (unchanged)';
+            }
+        });
+}
+
+function createBackupJob(event) {
+    event.preventDefault();
+    const volumes = Array.from(document.getElementById('job-volumes').selectedOptions).map(o => o.value);
+    const data = {
+        name: document.getElementById('job-name').value.trim(),
+        volumes: volumes,
+        schedule: document.getElementById('job-schedule').value.trim(),
+        keep_last: parseInt(document.getElementById('job-keep').value, 10) || 0,
+        target: document.getElementById('job-target').value,
+        enabled: true
+    };
+    fetch('/api/backups/jobs', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(data)
+    })
+    .then(response => {
+        if (response.ok) {
+            showToast('Backup job added', 'success');
+            document.getElementById('job-name').value = '';
+            loadBackupJobs();
+        } else {
+            response.text().then(text => alert('Failed to add job: ' + text));
+        }
+    });
+}
+
+function runBackupJob(id) {
+    fetch('/api/backups/jobs/' + id + '/run', { method: 'POST' })
+        .then(response => {
+            if (response.ok) {
+                showToast('Backup started', 'success');
+                setTimeout(loadBackups, 2000);
+            } else {
+                response.text().then(text => alert('Failed to start backup: ' + text));
+            }
+        });
+}
+
+function deleteBackupJob(id) {
+    if (!confirm('Delete this backup job? Its backups are kept.')) {
+        return;
+    }
+    fetch('/api/backups/jobs/' + id, { method: 'DELETE' })
+        .then(response => {
+            if (response.ok) {
+                showToast('Backup job deleted', 'success');
+                loadBackupJobs();
+            } else {
+                response.text().then(text => alert('Failed to delete job: ' + text));
+            }
+        });
+}
+
+function restoreBackup(id, volume) {
+    if (!confirm('Restore ' + volume + ' from this backup? Its current contents are replaced and containers using it are restarted.')) {
+        return;
+    }
+    fetch('/api/backups/' + id + '/restore', { method: 'POST' })
+        .then(response => {
+            if (response.ok) {
+                response.json().then(result => showToast(result.message, 'success'));
+            } else {
+                response.text().then(text => alert('Failed to restore: ' + text));
+            }
+        });
+}
+
+function deleteBackup(id) {
+    if (!confirm('Delete this backup? This cannot be undone.')) {
+        return;
+    }
+    fetch('/api/backups/' + id, { method: 'DELETE' })
+        .then(response => {
+            if (response.ok) {
+                showToast('Backup deleted', 'success');
+                loadBackups();
+            } else {
+                response.text().then(text => alert('Failed to delete backup: ' + text));
+            }
+        });
+}
+
+function saveBackupStorage(event) {
+    event.preventDefault();
+    const data = {
+        dir: document.getElementById('storage-dir').value.trim(),
+        s3: {
+            endpoint: document.getElementById('s3-endpoint').value.trim(),
+            region: document.getElementById('s3-region').value.trim(),
+            bucket: document.getElementById('s3-bucket').value.trim(),
+            prefix: document.getElementById('s3-prefix').value.trim(),
+            access_key: document.getElementById('s3-access-key').value.trim()
+        },
+        s3_secret_key: document.getElementById('s3-secret-key').value
+    };
+    fetch('/api/settings/backups', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(data)
+    })
+    .then(response => {
+        if (response.ok) {
+            showToast('Backup storage saved', 'success');
+            document.getElementById('s3-secret-key').value = '';
+            loadBackupStorage();
+        } else {
+            response.text().then(text => alert('Failed to save: ' + text));
+        }
+    });
+}
+
+loadVolumes();
+loadBackupJobs();
+loadBackups();
+loadBackupStorage();
@@ -0,0 +1,304 @@
+// A build's page: its duration, steps and live log, and approving or
+// redeploying it
+
+const logContent = document.getElementById('log-content');
+const durationBar = document.getElementById('duration-bar');
+const buildID = logContent.dataset.buildId;
+const startedAt = logContent.dataset.startedAt;
+const finishedAt = logContent.dataset.finishedAt;
+let isRunning = logContent.dataset.running === 'true';
+let durationInterval;
+
+function formatDuration(ms) {
+    const seconds = Math.floor(ms / 1000);
+    const minutes = Math.floor(seconds / 60);
+    const remainingSeconds = seconds % 60;
+    if (minutes > 0) {
+        return minutes + ' min, ' + remainingSeconds + ' sec';
+    }
+    return remainingSeconds + ' sec';
+}
+
+function updateDuration() {
+    if (!startedAt) {
+        durationBar.innerHTML = '<span class="text-gray-500">Waiting to start...</span>';
+        return;
+    }
+    const start = new Date(startedAt);
+    if (isRunning) {
+        const elapsed = Date.now() - start.getTime();
+        durationBar.innerHTML = '<span class="text-blue-600"><svg class="inline w-4 h-4 mr-1 animate-spin" fill="none" viewBox="0 0 24 24"><circle class="opacity-25" cx="12" cy="12" r="10" stroke="currentColor" stroke-width="4"></circle><path class="opacity-75" fill="currentColor" d="M4 12a8 8 0 018-8V0C5.373 0 0 5.373 0 12h4z"></path></svg>Running for ' + formatDuration(elapsed) + '</span>';
+    } else if (finishedAt) {
+        const end = new Date(finishedAt);
+        const duration = end.getTime() - start.getTime();
+        durationBar.innerHTML = '<span class="text-green-600">Completed in ' + formatDuration(duration) + '</span>';
+    }
+}
+
+function scrollToBottom() {
+    logContent.scrollTop = logContent.scrollHeight;
+}
+
+// stepTimeline draws the steps that ran as bars along the build's
+// duration
+let buildSteps = [];
+function stepTimeline(steps) {
+    const ran = steps.filter(step => step.started_at && step.started_at.Valid);
+    if (!ran.length) return '';
+    const times = step => {
+        const start = new Date(step.started_at.Time).getTime();
+        const end = step.finished_at && step.finished_at.Valid ? new Date(step.finished_at.Time).getTime() : Date.now();
+        return [start, Math.max(end, start)];
+    };
+    const first = Math.min(...ran.map(step => times(step)[0]));
+    const last = Math.max(...ran.map(step => times(step)[1]));
+    const total = Math.max(last - first, 1);
+    const colors = {
+        running: 'bg-blue-400 animate-pulse',
+        success: 'bg-green-400',
+        failed: 'bg-red-400'
+    };
+    const bars = ran.map(step => {
+        const [start, end] = times(step);
+        const left = (start - first) / total * 100;
+        const width = Math.max((end - start) / total * 100, 0.5);
+        return '<div class="absolute top-0 h-full rounded-sm ' + (colors[step.status] || 'bg-gray-300') + '"' +
+            ' style="left: ' + left + '%; width: ' + width + '%"' +
+            ' title="' + escapeHtml(step.name) + ': ' + formatDuration(end - start) + '"></div>';
+    }).join('');
+    return '<div class="px-6 py-4"><div class="flex justify-between text-xs text-gray-500 mb-2">' +
+        '<span>Timeline</span><span>' + formatDuration(last - first) + '</span></div>' +
+        '<div class="relative h-3 bg-gray-100 rounded">' + bars + '</div></div>';
+}
+
+// jumpToStep scrolls the log to the first line a step wrote
+function jumpToStep(i) {
+    const step = buildSteps[i];
+    if (!step || !step.started_at || !step.started_at.Valid) return;
+    const start = new Date(step.started_at.Time).getTime();
+    const line = Array.from(logContent.querySelectorAll('.log-line:not(.hidden)'))
+        .find(line => new Date(line.dataset.time).getTime() >= start);
+    if (line) {
+        logContent.scrollTop = line.offsetTop - logContent.offsetTop;
+        logContent.scrollIntoView({behavior: 'smooth', block: 'nearest'});
+    }
+}
+
+function loadSteps() {
+    fetch('/api/builds/' + buildID + '/steps')
+        .then(response => response.ok ? response.json() : [])
+        .then(steps => {
+            const panel = document.getElementById('build-steps');
+            if (!steps || steps.length === 0) {
+                return;
+            }
+            const colors = {
+                pending: 'text-gray-400',
+                running: 'text-blue-600',
+                success: 'text-green-600',
+                failed: 'text-red-600',
+                skipped: 'text-gray-400'
+            };
+            panel.classList.remove('hidden');
+            buildSteps = steps;
+            panel.innerHTML = stepTimeline(steps) + steps.map((step, i) => {
+                let duration = '';
+                if (step.started_at && step.started_at.Valid) {
+                    const end = step.finished_at && step.finished_at.Valid ? new Date(step.finished_at.Time) : new Date();
+                    duration = formatDuration(end.getTime() - new Date(step.started_at.Time).getTime());
+                }
+                const error = step.error_message && step.error_message.Valid ? step.error_message.String : '';
+                const command = step.command && step.command.Valid ? step.command.String : '';
+                const clickable = step.started_at && step.started_at.Valid;
+                return '<div class="flex items-center justify-between px-6 py-3 text-sm' + (clickable ? ' cursor-pointer hover:bg-gray-50' : '') + '"' +
+                    (clickable ? ' onclick="jumpToStep(' + i + ')" title="Show this step in the log"' : '') + '>' +
+                    '<div><span class="font-medium">' + escapeHtml(step.name) + '</span>' +
+                    (command ? '<span class="ml-3 font-mono text-xs text-gray-500">' + escapeHtml(command) + '</span>' : '') +
+                    (error ? '<div class="text-xs text-red-600 mt-1">' + escapeHtml(error) + '</div>' : '') + '</div>' +
+                    '<div class="text-right"><span class="' + colors[step.status] + '">' + step.status + '</span>' +
+                    (duration ? '<span class="ml-3 text-gray-500">' + duration + '</span>' : '') + '</div>' +
+                    '</div>';
+            }).join('');
+            if (isRunning) {
+                setTimeout(loadSteps, 2000);
+            }
+        });
+}
+
+// A dropped stream reconnects by itself, sending the ID of the last
+// line received so it carries on from there
+const eventSource = new EventSource('/api/builds/' + buildID + '/logs/stream');
+logContent.innerHTML = '';
+
+eventSource.addEventListener('log', function(e) {
+    const log = JSON.parse(e.data);
+    const timestamp = new Date(log.timestamp).toLocaleTimeString();
+
+    // Progress updates of the same item replace each other
+    const last = logContent.lastElementChild;
+    let line;
+    if (log.progress && last && last.dataset.progress === log.progress) {
+        line = last;
+        line.textContent = '';
+    } else {
+        line = document.createElement('div');
+        logContent.appendChild(line);
+    }
+    line.className = 'log-line ' + log.level;
+    line.dataset.level = log.level;
+    line.dataset.time = log.timestamp;
+    line.dataset.stream = log.stream || 'system';
+    if (log.progress) {
+        line.dataset.progress = log.progress;
+    }
+    if (!logVisible(line)) {
+        line.classList.add('hidden');
+    }
+    if (log.level === 'error') {
+        updateErrorCount();
+    }
+
+    const time = document.createElement('span');
+    time.className = 'text-gray-600';
+    time.textContent = timestamp;
+    const message = document.createElement('span');
+    message.className = 'ml-2';
+    renderLogMessage(message, log);
+    line.append(time, ' ', message);
+    scrollToBottom();
+});
+
+// logVisible reports whether a log line passes the level and output
+// filters
+function logVisible(line) {
+    const level = document.getElementById('log-level-filter').value;
+    const stream = document.getElementById('log-stream-filter').value;
+    if (level === 'error' && line.dataset.level !== 'error') return false;
+    if (level === 'warn' && line.dataset.level !== 'error' && line.dataset.level !== 'warn') return false;
+    return !stream || line.dataset.stream === stream;
+}
+
+function filterLogs() {
+    logContent.querySelectorAll('.log-line').forEach(line => {
+        line.classList.toggle('hidden', !logVisible(line));
+    });
+}
+
+// nextError scrolls to the error after the one last shown, wrapping
+// around at the end
+let errorIndex = -1;
+function nextError() {
+    const errors = logContent.querySelectorAll('.log-line.error');
+    if (!errors.length) return;
+    errorIndex = (errorIndex + 1) % errors.length;
+    const line = errors[errorIndex];
+    line.classList.remove('hidden');
+    logContent.scrollTop = line.offsetTop - logContent.offsetTop - logContent.clientHeight / 3;
+}
+
+function updateErrorCount() {
+    const count = logContent.querySelectorAll('.log-line.error').length;
+    const button = document.getElementById('next-error');
+    button.textContent = count + (count === 1 ? ' error' : ' errors') + ' ↓';
+    button.classList.remove('hidden');
+}
+
+// renderLogMessage fills el with a log message, colored the way the
+// build output was
+function renderLogMessage(el, log) {
+    if (!log.styles || !log.styles.length) {
+        el.textContent = log.message;
+        return;
+    }
+    const chars = Array.from(log.message);
+    let pos = 0;
+    log.styles.forEach(style => {
+        if (style.start > pos) {
+            el.append(chars.slice(pos, style.start).join(''));
+        }
+        const span = document.createElement('span');
+        span.textContent = chars.slice(style.start, style.end).join('');
+        ['fg', 'bg'].forEach(key => {
+            const color = style[key];
+            if (!color) return;
+            if (color.startsWith('#')) {
+                span.style[key === 'fg' ? 'color' : 'backgroundColor'] = color;
+            } else {
+                span.classList.add('ansi-' + key + '-' + color);
+            }
+        });
+        ['bold', 'dim', 'italic', 'underline'].forEach(attr => {
+            if (style[attr]) span.classList.add('ansi-' + attr);
+        });
+        el.appendChild(span);
+        pos = style.end;
+    });
+    if (pos < chars.length) {
+        el.append(chars.slice(pos).join(''));
+    }
+}
+
+eventSource.addEventListener('complete', function(e) {
+    const data = JSON.parse(e.data);
+    isRunning = false;
+    if (durationInterval) clearInterval(durationInterval);
+    // Update duration with final time
+    if (data.started_at && data.finished_at) {
+        const start = new Date(data.started_at);
+        const end = new Date(data.finished_at);
+        const duration = end.getTime() - start.getTime();
+        const statusColor = data.status === 'success' ? 'text-green-600' : 'text-red-600';
+        const statusText = data.status === 'success' ? 'Completed' : 'Failed';
+        durationBar.innerHTML = '<span class="' + statusColor + '">' + statusText + ' in ' + formatDuration(duration) + '</span>';
+    }
+    eventSource.close();
+    loadSteps();
+});
+
+
+function escapeHtml(text) {
+    const div = document.createElement('div');
+    div.textContent = text;
+    return div.innerHTML;
+}
+
+// Start duration updates
+loadSteps();
+updateDuration();
+if (isRunning) {
+    durationInterval = setInterval(updateDuration, 1000);
+}
+
+// decideBuild approves or rejects a build waiting for approval
+function decideBuild(decision) {
+    if (!confirm(decision === 'approve' ? 'Deploy this build?' : 'Cancel this build without deploying it?')) {
+        return;
+    }
+    fetch('/api/builds/' + buildID + '/' + decision, { method: 'POST' })
+        .then(response => {
+            if (!response.ok) {
+                return response.text().then(text => { throw new Error(text); });
+            }
+            window.location.reload();
+        })
+        .catch(err => alert('Failed to ' + decision + ' build: ' + err.message));
+}
+
+// redeployPinned redeploys the build's commit with exactly the images it
+// deployed
+function redeployPinned() {
+    if (!confirm('Redeploy this commit with exactly these images?')) {
+        return;
+    }
+    fetch('/api/builds/' + buildID + '/redeploy', { method: 'POST' })
+        .then(response => {
+            if (!response.ok) {
+                return response.text().then(text => { throw new Error(text); });
+            }
+            return response.json();
+        })
+        .then(build => {
+            window.location.href = '/builds/' + build.id;
+        })
+        .catch(err => alert('Redeploy failed: ' + err.message));
+}
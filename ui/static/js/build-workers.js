@@ -0,0 +1,17 @@
+// The settings page's control for how many builds run at once
+
+document.getElementById('build-workers-form').addEventListener('submit', event => {
+    event.preventDefault();
+    fetch('/api/settings/build-workers', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ workers: parseInt(document.getElementById('build-workers-count').value, 10) })
+    })
+    .then(response => {
+        if (response.ok) {
+            window.location.reload();
+        } else {
+            response.text().then(text => alert('Failed to change build workers: ' + text));
+        }
+    });
+});
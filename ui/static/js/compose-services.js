@@ -0,0 +1,97 @@
+// A compose app's services on its page: their state, actions and logs
+
+(function() {
+    const appID = document.getElementById('compose-services').dataset.appId;
+
+    function escapeServiceText(text) {
+        const div = document.createElement('div');
+        div.textContent = text || '';
+        return div.innerHTML;
+    }
+
+    function stateClass(state) {
+        if (state === 'running') return 'bg-green-100 text-green-700';
+        if (state === 'exited' || state === 'dead') return 'bg-red-100 text-red-700';
+        if (state === 'paused' || state === 'restarting') return 'bg-yellow-100 text-yellow-700';
+        return 'bg-gray-100 text-gray-700';
+    }
+
+    function serviceURL(name) {
+        return '/api/apps/' + appID + '/services/' + encodeURIComponent(name);
+    }
+
+    function loadServices() {
+        const body = document.getElementById('compose-services-list');
+        fetch('/api/apps/' + appID + '/services')
+            .then(response => {
+                if (!response.ok) {
+                    return response.text().then(text => { throw new Error(text); });
+                }
+                return response.json();
+            })
+            .then(services => {
+                if (services.length === 0) {
+                    body.innerHTML = '<tr><td colspan="5" class="px-4 py-2 text-gray-400">No services found, deploy the app first</td></tr>';
+                    return;
+                }
+                body.innerHTML = services.map(s => {
+                    const name = escapeServiceText(s.name);
+                    const actions = [
+                        s.state === 'running'
+                            ? '<button data-action="stop" data-service="' + name + '" class="text-gray-600 hover:text-gray-900">Stop</button>'
+                            : '<button data-action="start" data-service="' + name + '" class="text-green-600 hover:text-green-700">Start</button>'
+                    ];
+                    if (s.container_id) {
+                        actions.push('<button data-action="restart" data-service="' + name + '" class="text-blue-600 hover:text-blue-700">Restart</button>');
+                        actions.push('<button data-action="logs" data-service="' + name + '" class="text-purple-600 hover:text-purple-700">Logs</button>');
+                    }
+                    return '<tr class="border-t border-gray-100">' +
+                        '<td class="px-4 py-2 font-medium text-gray-900">' + name + '</td>' +
+                        '<td class="px-4 py-2 text-xs font-mono text-gray-500">' + (escapeServiceText(s.image) || '-') + '</td>' +
+                        '<td class="px-4 py-2"><span class="px-2 py-0.5 text-xs rounded-full ' + stateClass(s.state) + '" title="' + escapeServiceText(s.status) + '">' + escapeServiceText(s.state) + '</span></td>' +
+                        '<td class="px-4 py-2 text-xs font-mono text-gray-500">' + (escapeServiceText((s.ports || []).join(', ')) || '-') + '</td>' +
+                        '<td class="px-4 py-2 text-xs text-right space-x-2">' + actions.join(' ') + '</td>' +
+                        '</tr>';
+                }).join('');
+            })
+            .catch(err => {
+                body.innerHTML = '<tr><td colspan="5" class="px-4 py-2 text-red-600">' + escapeServiceText(err.message) + '</td></tr>';
+            });
+    }
+
+    function showLogs(name) {
+        document.getElementById('compose-service-logs').classList.remove('hidden');
+        document.getElementById('compose-service-logs-title').textContent = name + ' logs';
+        const output = document.getElementById('compose-service-logs-output');
+        output.textContent = 'Loading...';
+        fetch(serviceURL(name) + '/logs?tail=200')
+            .then(response => response.text().then(text => {
+                output.textContent = text || 'No output';
+                output.scrollTop = output.scrollHeight;
+            }));
+    }
+
+    document.getElementById('compose-services-list').addEventListener('click', event => {
+        const button = event.target.closest('button[data-action]');
+        if (!button) return;
+        const name = button.dataset.service;
+        const action = button.dataset.action;
+        if (action === 'logs') {
+            showLogs(name);
+            return;
+        }
+        button.disabled = true;
+        fetch(serviceURL(name) + '/' + action, { method: 'POST' })
+            .then(response => {
+                if (!response.ok) {
+                    return response.text().then(text => { throw new Error(text); });
+                }
+                showToast(name + ': ' + action + ' done', 'success');
+            })
+            .catch(err => alert('Failed to ' + action + ' ' + name + ': ' + err.message))
+            .finally(loadServices);
+    });
+
+    loadServices();
+    setInterval(loadServices, 10000);
+})();
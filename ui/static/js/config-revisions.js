@@ -0,0 +1,47 @@
+// The app page's configuration history and applying a changed configuration
+
+(function() {
+    const appID = document.getElementById('config-section').dataset.appId;
+    const escape = text => {
+        const div = document.createElement('div');
+        div.textContent = text || '';
+        return div.innerHTML;
+    };
+    const changeRows = changes => '<table class="mt-1 text-xs">' + changes.map(c =>
+        '<tr><td class="pr-4 py-0.5 font-mono text-gray-500">' + escape(c.key) + '</td>' +
+        '<td class="pr-4 py-0.5 font-mono text-red-600">' + (escape(c.old) || '-') + '</td>' +
+        '<td class="py-0.5 font-mono text-green-700">' + (escape(c.new) || '-') + '</td></tr>').join('') + '</table>';
+
+    fetch('/api/apps/' + appID + '/config/revisions')
+        .then(response => response.json())
+        .then(history => {
+            const pending = document.getElementById('config-pending');
+            if (pending && history.pending) {
+                pending.innerHTML = changeRows(history.pending);
+            }
+            document.getElementById('config-revisions').innerHTML = history.revisions.map(rev =>
+                '<div class="py-2 border-b border-gray-100 last:border-0">' +
+                '<span class="font-medium text-gray-900">Revision ' + rev.revision + '</span>' +
+                (rev.deployed ? ' <span class="px-2 py-0.5 text-xs rounded-full bg-green-100 text-green-700">deployed</span>' : '') +
+                '<span class="ml-2">' + new Date(rev.created_at).toLocaleString() + '</span>' +
+                (rev.changed_by ? '<span class="ml-2">by ' + escape(rev.changed_by) + '</span>' : '') +
+                (rev.changes ? changeRows(rev.changes) : '<div class="text-xs text-gray-400">Configuration before the first change</div>') +
+                '</div>').join('') || 'No changes yet';
+        });
+
+    const button = document.getElementById('apply-config-button');
+    if (!button) return;
+    button.addEventListener('click', () => {
+        if (!confirm('Redeploy the running commit with the current configuration? The app restarts.')) {
+            return;
+        }
+        button.disabled = true;
+        fetch('/api/apps/' + appID + '/config/apply', { method: 'POST' })
+            .then(response => response.ok ? response.json() : response.text().then(text => { throw new Error(text); }))
+            .then(result => { window.location.href = '/builds/' + result.build_id; })
+            .catch(err => {
+                button.disabled = false;
+                alert('Failed to apply the configuration: ' + err.message);
+            });
+    });
+})();
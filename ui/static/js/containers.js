@@ -0,0 +1,72 @@
+// The dashboard's Docker containers: live stats and adopting containers
+// Schooner doesn't manage yet
+
+function showContainerStats(stats) {
+    stats.forEach(stat => {
+        const cpuCell = document.querySelector('.cpu-stat[data-container="' + stat.name + '"]');
+        const memCell = document.querySelector('.mem-stat[data-container="' + stat.name + '"]');
+        if (cpuCell) {
+            cpuCell.textContent = stat.cpu_percent.toFixed(1) + '%';
+            if (stat.cpu_percent > 80) cpuCell.className = 'px-4 py-2 text-xs text-red-600 cpu-stat';
+            else if (stat.cpu_percent > 50) cpuCell.className = 'px-4 py-2 text-xs text-yellow-600 cpu-stat';
+            else cpuCell.className = 'px-4 py-2 text-xs text-gray-600 cpu-stat';
+            cpuCell.setAttribute('data-container', stat.name);
+        }
+        if (memCell) {
+            memCell.textContent = stat.memory_display;
+            if (stat.memory_percent > 80) memCell.className = 'px-4 py-2 text-xs text-red-600 mem-stat';
+            else if (stat.memory_percent > 60) memCell.className = 'px-4 py-2 text-xs text-yellow-600 mem-stat';
+            else memCell.className = 'px-4 py-2 text-xs text-gray-600 mem-stat';
+            memCell.setAttribute('data-container', stat.name);
+        }
+    });
+}
+function loadContainerStats() {
+    fetch('/api/containers/stats')
+        .then(response => response.json())
+        .then(showContainerStats)
+        .catch(err => console.error('Failed to load container stats:', err));
+}
+// Stats are pushed as the server samples them; browsers without
+// EventSource poll instead
+if (window.EventSource) {
+    const statsSource = new EventSource('/api/containers/stats/stream');
+    statsSource.addEventListener('stats', event => showContainerStats(JSON.parse(event.data)));
+} else {
+    loadContainerStats();
+    setInterval(loadContainerStats, 5000);
+}
+
+function adoptContainer(id) {
+    fetch('/api/containers/' + id + '/adopt')
+        .then(response => {
+            if (!response.ok) return response.text().then(text => { throw new Error(text); });
+            return response.json();
+        })
+        .then(plan => {
+            const what = plan.services.length > 1
+                ? 'compose project ' + plan.project + ' (' + plan.services.join(', ') + ')'
+                : plan.name;
+            const name = prompt('Adopt ' + what + ' as a Schooner app. Its containers are recreated on the first deploy, volumes are kept.\n\nApp name:', plan.name);
+            if (!name) return;
+            return fetch('/api/containers/' + id + '/adopt', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ name: name })
+            })
+            .then(response => {
+                if (!response.ok) return response.text().then(text => { throw new Error(text); });
+                return response.json();
+            })
+            .then(data => { window.location.href = '/apps/' + data.app.id; });
+        })
+        .catch(err => alert('Adopt failed: ' + err.message));
+}
+
+const containerTable = document.getElementById('docker-containers');
+if (containerTable) {
+    containerTable.addEventListener('click', event => {
+        const button = event.target.closest('button[data-adopt]');
+        if (button) adoptContainer(button.dataset.adopt);
+    });
+}
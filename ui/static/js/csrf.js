@@ -0,0 +1,17 @@
+// Makes every same-origin fetch() that changes state send the session's
+// CSRF token from the page's csrf-token meta tag, so page scripts don't
+// have to add it themselves
+(function() {
+    const token = document.querySelector('meta[name="csrf-token"]').content;
+    const originalFetch = window.fetch;
+    window.fetch = function(resource, options) {
+        options = options || {};
+        const method = (options.method || 'GET').toUpperCase();
+        const url = new URL(resource instanceof Request ? resource.url : resource, window.location.href);
+        if (url.origin === window.location.origin && !['GET', 'HEAD', 'OPTIONS'].includes(method)) {
+            options.headers = new Headers(options.headers || {});
+            options.headers.set('X-CSRF-Token', token);
+        }
+        return originalFetch(resource, options);
+    };
+})();
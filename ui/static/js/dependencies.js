@@ -0,0 +1,63 @@
+// The app page's dependency editor and the order every app starts in
+
+(function() {
+    const appID = document.getElementById('dependencies-section').dataset.appId;
+    const escape = text => {
+        const div = document.createElement('div');
+        div.textContent = text || '';
+        return div.innerHTML;
+    };
+
+    function loadDependencies() {
+        fetch('/api/apps/dependencies')
+            .then(response => response.json())
+            .then(graph => {
+                const names = {};
+                graph.apps.forEach(a => { names[a.id] = a.name; });
+                const self = graph.apps.find(a => a.id === appID) || { depends_on: [] };
+
+                const others = graph.apps.filter(a => a.id !== appID);
+                document.getElementById('dependency-options').innerHTML = others.map(a =>
+                    '<label class="flex items-center gap-2"><input type="checkbox" class="dependency-option" value="' + escape(a.id) + '"' +
+                    (self.depends_on.includes(a.id) ? ' checked' : '') + '> ' + escape(a.name) + '</label>').join('') ||
+                    '<span class="text-gray-500">No other apps</span>';
+
+                const neededBy = graph.apps.filter(a => a.depends_on.includes(appID)).map(a => escape(a.name));
+                document.getElementById('dependency-needed-by').innerHTML = neededBy.length ?
+                    'Needed by ' + neededBy.join(', ') : '';
+
+                const related = new Set([appID, ...self.depends_on]);
+                graph.apps.forEach(a => { if (a.depends_on.includes(appID)) related.add(a.id); });
+                document.getElementById('dependency-waves').innerHTML = graph.waves.map((wave, i) =>
+                    '<div class="px-3 py-2 rounded border border-gray-200 bg-gray-50">' +
+                    '<div class="text-xs text-gray-400 mb-1">' + (i + 1) + '</div>' +
+                    wave.map(a => {
+                        const node = graph.apps.find(n => n.id === a.id);
+                        const deps = node && node.depends_on.length ?
+                            ' title="Depends on ' + escape(node.depends_on.map(id => names[id]).join(', ')) + '"' : '';
+                        const style = a.id === appID ? 'font-bold text-blue-600' : related.has(a.id) ? 'text-gray-900' : 'text-gray-400';
+                        return '<div class="' + style + '"' + deps + '>' + escape(a.name) + '</div>';
+                    }).join('') + '</div>').join('<div class="self-center text-gray-400">&rarr;</div>');
+            });
+    }
+
+    function saveDependencies() {
+        const dependsOn = Array.from(document.querySelectorAll('.dependency-option:checked')).map(box => box.value);
+        fetch('/api/apps/' + appID + '/dependencies', {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ depends_on: dependsOn })
+        })
+        .then(response => {
+            if (response.ok) {
+                showToast('Dependencies saved', 'success');
+                loadDependencies();
+            } else {
+                response.text().then(text => alert('Failed to save: ' + text));
+            }
+        });
+    }
+
+    document.getElementById('save-dependencies').addEventListener('click', saveDependencies);
+    loadDependencies();
+})();
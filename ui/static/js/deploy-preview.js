@@ -0,0 +1,50 @@
+// Previews what deploying a compose app would change, service by service
+
+function previewDeploy() {
+    const panel = document.getElementById('deploy-preview');
+    const services = document.getElementById('deploy-preview-services');
+    const escape = text => {
+        const div = document.createElement('div');
+        div.textContent = text || '';
+        return div.innerHTML;
+    };
+    panel.classList.remove('hidden');
+    services.innerHTML = '<span class="text-gray-400">Updating the checkout and rendering the compose config...</span>';
+    document.getElementById('deploy-preview-commit').textContent = '';
+    document.getElementById('deploy-preview-config').textContent = '';
+
+    fetch('/api/apps/' + panel.dataset.appId + '/deploy/preview', { method: 'POST' })
+        .then(response => {
+            if (!response.ok) {
+                return response.text().then(text => { throw new Error(text); });
+            }
+            return response.json();
+        })
+        .then(preview => {
+            const badges = {
+                create: 'bg-green-100 text-green-700',
+                update: 'bg-yellow-100 text-yellow-700',
+                remove: 'bg-red-100 text-red-700',
+                unchanged: 'bg-gray-100 text-gray-700'
+            };
+            services.innerHTML = preview.services.map(s => {
+                const changes = (s.changes || []).map(c =>
+                    '<tr><td class="pr-4 py-1 font-mono text-gray-500">' + escape(c.field) + '</td>' +
+                    '<td class="pr-4 py-1 font-mono text-red-600">' + (escape(c.from) || '-') + '</td>' +
+                    '<td class="py-1 font-mono text-green-700">' + (escape(c.to) || '-') + '</td></tr>').join('');
+                return '<div class="mb-3">' +
+                    '<span class="font-medium">' + escape(s.name) + '</span> ' +
+                    '<span class="px-2 py-0.5 text-xs rounded-full ' + badges[s.action] + '">' + s.action + '</span>' +
+                    (s.rebuild ? ' <span class="text-xs text-gray-500">image rebuilt from source</span>' : '') +
+                    (changes ? '<table class="mt-1 text-xs">' + changes + '</table>' : '') +
+                    '</div>';
+            }).join('') || '<span class="text-gray-400">No services</span>';
+            if (preview.commit) {
+                document.getElementById('deploy-preview-commit').textContent = preview.commit.substring(0, 8);
+            }
+            document.getElementById('deploy-preview-config').textContent = preview.config;
+        })
+        .catch(err => {
+            services.innerHTML = '<span class="text-red-600">' + escape(err.message) + '</span>';
+        });
+}
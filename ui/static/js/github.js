@@ -0,0 +1,18 @@
+// Shows whether a GitHub account is connected and whether OAuth login is set up
+
+// Check GitHub status on page load
+Promise.all([
+    fetch('/api/settings/github-status').then(r => r.json()),
+    fetch('/oauth/github/status').then(r => r.json())
+]).then(([githubStatus, oauthStatus]) => {
+    if (githubStatus.configured) {
+        document.getElementById('github-connected').classList.remove('hidden');
+        document.getElementById('github-not-connected').classList.add('hidden');
+        document.getElementById('github-username').textContent = githubStatus.username;
+    } else {
+        if (oauthStatus.oauth_configured) {
+            document.getElementById('oauth-available').classList.remove('hidden');
+            document.getElementById('oauth-not-available').classList.add('hidden');
+        }
+    }
+});
@@ -0,0 +1,118 @@
+// The networks page: listing networks and attaching containers to them
+
+function escapeNetworkText(s) {
+    const div = document.createElement('div');
+    div.textContent = s == null ? '' : String(s);
+    return div.innerHTML;
+}
+
+function networkRequest(method, url, body, done) {
+    fetch(url, {
+        method: method,
+        headers: { 'Content-Type': 'application/json' },
+        body: body ? JSON.stringify(body) : undefined
+    }).then(response => {
+        if (response.ok) {
+            response.json().then(data => showToast(data.message, 'success'));
+            if (done) done();
+            loadNetworks();
+        } else {
+            response.text().then(text => alert(text));
+        }
+    });
+}
+
+function loadNetworks() {
+    fetch('/api/networks')
+        .then(response => response.json())
+        .then(networks => {
+            document.getElementById('networks-body').innerHTML = networks.map(network => {
+                const name = escapeNetworkText(network.name);
+                let badges = '';
+                if (network.app_id) {
+                    badges += ' <a href="/apps/' + encodeURIComponent(network.app_id) + '" class="text-xs text-blue-600 hover:text-blue-700">' + escapeNetworkText(network.app_name) + '</a>';
+                } else if (network.managed) {
+                    badges += ' <span class="text-xs text-gray-400">schooner</span>';
+                }
+                if (network.internal) {
+                    badges += ' <span class="text-xs text-yellow-600">internal</span>';
+                }
+                const containers = network.containers.length === 0
+                    ? '<span class="text-gray-400">None</span>'
+                    : network.containers.map(c =>
+                        '<div class="whitespace-nowrap">' + escapeNetworkText(c.name) +
+                        (c.address ? ' <span class="text-xs text-gray-400 font-mono">' + escapeNetworkText(c.address) + '</span>' : '') +
+                        (network.builtin && network.name !== 'bridge' ? '' :
+                            ' <button data-network="' + name + '" data-container="' + escapeNetworkText(c.name) + '" data-action="detach" class="text-xs text-red-600 hover:text-red-700">Detach</button>') +
+                        '</div>').join('');
+                const attach = network.builtin && network.name !== 'bridge' ? '' :
+                    '<form data-network="' + name + '" class="flex gap-2">' +
+                        '<input type="text" list="network-containers" required placeholder="container" class="w-36 bg-gray-50 border border-gray-200 rounded px-2 py-1 text-gray-900">' +
+                        '<button type="submit" class="text-blue-600 hover:text-blue-700">Attach</button>' +
+                    '</form>';
+                const remove = network.builtin ? '' :
+                    '<button data-network="' + name + '" data-action="remove" class="text-red-600 hover:text-red-700">Remove</button>';
+                return '<tr class="border-b border-gray-100 align-top">' +
+                    '<td class="py-2 pr-4 font-mono">' + name + badges + '</td>' +
+                    '<td class="py-2 pr-4 text-gray-500">' + escapeNetworkText(network.driver) + '</td>' +
+                    '<td class="py-2 pr-4">' + containers + '</td>' +
+                    '<td class="py-2 pr-4">' + attach + '</td>' +
+                    '<td class="py-2 text-right">' + remove + '</td>' +
+                    '</tr>';
+            }).join('');
+        });
+}
+
+function loadNetworkContainers() {
+    fetch('/api/containers/stats')
+        .then(response => response.json())
+        .then(stats => {
+            document.getElementById('network-containers').innerHTML =
+                stats.map(stat => '<option value="' + escapeNetworkText(stat.name) + '">').join('');
+        });
+}
+
+function createNetwork(event) {
+    event.preventDefault();
+    networkRequest('POST', '/api/networks', {
+        name: document.getElementById('network-name').value.trim(),
+        internal: document.getElementById('network-internal').checked
+    }, () => event.target.reset());
+}
+
+function removeNetwork(button) {
+    if (!confirm('Remove network ' + button.dataset.network + '?')) {
+        return;
+    }
+    networkRequest('DELETE', '/api/networks/' + encodeURIComponent(button.dataset.network));
+}
+
+function attachContainer(event) {
+    event.preventDefault();
+    const form = event.target;
+    networkRequest('POST', '/api/networks/' + encodeURIComponent(form.dataset.network) + '/attach', {
+        container: form.querySelector('input').value.trim()
+    });
+}
+
+function detachContainer(button) {
+    if (!confirm('Detach ' + button.dataset.container + ' from ' + button.dataset.network + '?')) {
+        return;
+    }
+    networkRequest('POST', '/api/networks/' + encodeURIComponent(button.dataset.network) + '/detach', {
+        container: button.dataset.container
+    });
+}
+
+document.getElementById('create-network').addEventListener('submit', createNetwork);
+const networksBody = document.getElementById('networks-body');
+networksBody.addEventListener('submit', attachContainer);
+networksBody.addEventListener('click', event => {
+    const button = event.target.closest('button[data-action]');
+    if (!button) return;
+    if (button.dataset.action === 'detach') detachContainer(button);
+    else if (button.dataset.action === 'remove') removeNetwork(button);
+});
+
+loadNetworks();
+loadNetworkContainers();
@@ -0,0 +1,113 @@
+// The log aggregation settings: the stack's status, config and start and stop buttons
+
+// Load observability status on page load
+function loadObservabilityStatus() {
+    fetch('/api/settings/observability-status')
+        .then(response => response.json())
+        .then(data => {
+            const statusDisplay = document.getElementById('observability-status-display');
+            const servicesDisplay = document.getElementById('observability-services');
+            const statusIndicator = document.getElementById('observability-status-indicator');
+            const statusText = document.getElementById('observability-status-text');
+            const startBtn = document.getElementById('observability-start-btn');
+            const stopBtn = document.getElementById('observability-stop-btn');
+            const grafanaLink = document.getElementById('grafana-link');
+
+            if (data.available) {
+                statusDisplay.classList.remove('hidden');
+                document.getElementById('metrics-enabled-input').checked = !!data.metrics_enabled;
+                document.getElementById('embed-mode-input').value = data.embed_mode || 'anonymous';
+
+                if (data.running) {
+                    statusIndicator.classList.add('bg-green-500');
+                    statusIndicator.classList.remove('bg-gray-300', 'bg-yellow-500');
+                    statusText.textContent = 'Stack running';
+                    startBtn.classList.add('hidden');
+                    stopBtn.classList.remove('hidden');
+
+                    if (data.grafana_url) {
+                        grafanaLink.href = data.grafana_url;
+                        grafanaLink.classList.remove('hidden');
+                    }
+
+                    servicesDisplay.classList.remove('hidden');
+                    document.getElementById('loki-status').textContent = data.loki_status || '-';
+                    document.getElementById('promtail-status').textContent = data.promtail_status || '-';
+                    document.getElementById('grafana-status').textContent = data.grafana_status || '-';
+                    if (data.metrics_enabled) {
+                        document.getElementById('metrics-services').classList.remove('hidden');
+                        document.getElementById('prometheus-status').textContent = data.prometheus_status || '-';
+                        document.getElementById('node-exporter-status').textContent = data.node_exporter_status || '-';
+                        document.getElementById('cadvisor-status').textContent = data.cadvisor_status || '-';
+                    }
+                } else if (data.enabled) {
+                    statusIndicator.classList.add('bg-yellow-500');
+                    statusIndicator.classList.remove('bg-gray-300', 'bg-green-500');
+                    statusText.textContent = 'Enabled but not running';
+                    startBtn.classList.remove('hidden');
+                    stopBtn.classList.add('hidden');
+                    grafanaLink.classList.add('hidden');
+                    servicesDisplay.classList.add('hidden');
+                } else {
+                    statusIndicator.classList.add('bg-gray-300');
+                    statusIndicator.classList.remove('bg-green-500', 'bg-yellow-500');
+                    statusText.textContent = 'Not configured';
+                    startBtn.classList.remove('hidden');
+                    stopBtn.classList.add('hidden');
+                    grafanaLink.classList.add('hidden');
+                    servicesDisplay.classList.add('hidden');
+                }
+            }
+        });
+}
+loadObservabilityStatus();
+
+function submitObservabilityConfig(event) {
+    event.preventDefault();
+    const port = document.getElementById('grafana-port-input').value;
+    const retention = document.getElementById('loki-retention-input').value;
+    const metricsEnabled = document.getElementById('metrics-enabled-input').checked;
+    const embedMode = document.getElementById('embed-mode-input').value;
+
+    fetch('/api/settings/observability', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({
+            enabled: true,
+            grafana_port: parseInt(port),
+            loki_retention: retention,
+            metrics_enabled: metricsEnabled,
+            embed_mode: embedMode
+        })
+    })
+    .then(response => {
+        if (response.ok) {
+            // Start the stack after saving config
+            startObservability();
+        } else {
+            response.text().then(text => alert('Failed to save: ' + text));
+        }
+    });
+}
+
+function startObservability() {
+    fetch('/api/settings/observability/start', { method: 'POST' })
+        .then(response => {
+            if (response.ok) {
+                window.location.reload();
+            } else {
+                response.text().then(text => alert('Failed to start: ' + text));
+            }
+        });
+}
+
+function stopObservability() {
+    fetch('/api/settings/observability/stop', { method: 'POST' })
+        .then(response => {
+            if (response.ok) {
+                window.location.reload();
+            } else {
+                response.text().then(text => alert('Failed to stop: ' + text));
+            }
+        });
+}
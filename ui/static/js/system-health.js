@@ -0,0 +1,107 @@
+// The dashboard's system health card and its history charts
+
+function loadSystemHealth() {
+    fetch('/api/health/system')
+        .then(response => response.json())
+        .then(data => {
+            // CPU
+            const cpuPercent = data.cpu.usage_percent.toFixed(0);
+            document.getElementById('cpu-usage').textContent = cpuPercent + '%';
+            document.getElementById('cpu-bar').style.width = cpuPercent + '%';
+            document.getElementById('cpu-cores').textContent = data.cpu.num_cores + ' cores';
+            document.getElementById('cpu-load').textContent =
+                data.cpu.load_avg_1.toFixed(2) + ' / ' +
+                data.cpu.load_avg_5.toFixed(2) + ' / ' +
+                data.cpu.load_avg_15.toFixed(2);
+
+            // Color CPU bar based on usage
+            const cpuBar = document.getElementById('cpu-bar');
+            if (cpuPercent > 80) cpuBar.className = 'h-full bg-red-500 rounded-full transition-all';
+            else if (cpuPercent > 60) cpuBar.className = 'h-full bg-yellow-500 rounded-full transition-all';
+            else cpuBar.className = 'h-full bg-blue-500 rounded-full transition-all';
+
+            // Memory
+            const memPercent = data.memory.used_percent.toFixed(0);
+            document.getElementById('mem-usage').textContent = memPercent + '%';
+            document.getElementById('mem-bar').style.width = memPercent + '%';
+            document.getElementById('mem-total').textContent = data.memory.total_display;
+            document.getElementById('mem-used').textContent = data.memory.used_display;
+            document.getElementById('mem-total-val').textContent = data.memory.total_display;
+
+            // Color memory bar
+            const memBar = document.getElementById('mem-bar');
+            if (memPercent > 85) memBar.className = 'h-full bg-red-500 rounded-full transition-all';
+            else if (memPercent > 70) memBar.className = 'h-full bg-yellow-500 rounded-full transition-all';
+            else memBar.className = 'h-full bg-purple-500 rounded-full transition-all';
+
+            // Disk
+            const diskPercent = data.disk.used_percent.toFixed(0);
+            document.getElementById('disk-usage').textContent = diskPercent + '%';
+            document.getElementById('disk-bar').style.width = diskPercent + '%';
+            document.getElementById('disk-path').textContent = data.disk.path;
+            document.getElementById('disk-used').textContent = data.disk.used_display;
+            document.getElementById('disk-total').textContent = data.disk.total_display;
+
+            // Color disk bar
+            const diskBar = document.getElementById('disk-bar');
+            if (diskPercent > 90) diskBar.className = 'h-full bg-red-500 rounded-full transition-all';
+            else if (diskPercent > 75) diskBar.className = 'h-full bg-yellow-500 rounded-full transition-all';
+            else diskBar.className = 'h-full bg-green-500 rounded-full transition-all';
+
+            // GPUs, shown only on hosts that have them
+            const gpu = data.gpu || { count: 0 };
+            document.getElementById('gpu-health').classList.toggle('hidden', gpu.count === 0);
+            if (gpu.count > 0) {
+                const models = gpu.models || [];
+                document.getElementById('gpu-models').textContent =
+                    gpu.count + ' × ' + (models.length ? [...new Set(models)].join(', ') : 'NVIDIA GPU');
+                const runtime = document.getElementById('gpu-runtime');
+                if (gpu.nvidia_runtime === undefined) {
+                    runtime.textContent = '';
+                } else {
+                    runtime.textContent = gpu.nvidia_runtime ? 'nvidia runtime available' : 'nvidia runtime missing';
+                    runtime.className = 'text-xs ' + (gpu.nvidia_runtime ? 'text-green-600' : 'text-yellow-600');
+                }
+            }
+        })
+        .catch(err => console.error('Failed to load system health:', err));
+}
+loadSystemHealth();
+// Refresh every 10 seconds
+setInterval(loadSystemHealth, 10000);
+
+// Charts use of each kind as a share of its total, 0-100
+function plotHealthTrend(id, values, color) {
+    const svg = document.getElementById(id);
+    if (values.length < 2) {
+        svg.innerHTML = '';
+        return;
+    }
+    const points = values.map((v, i) =>
+        (i / (values.length - 1) * 300).toFixed(1) + ',' + (100 - Math.min(v, 100) * 0.95).toFixed(1)).join(' ');
+    svg.innerHTML = '<polyline fill="none" stroke="' + color + '" stroke-width="1.5" vector-effect="non-scaling-stroke" points="' + points + '"/>';
+}
+function loadHealthHistory() {
+    const range = document.getElementById('health-range').value;
+    fetch('/api/health/system/history?range=' + range)
+        .then(response => response.json())
+        .then(data => {
+            const samples = data.samples || [];
+            const share = (used, total) => total ? used / total * 100 : 0;
+            plotHealthTrend('health-cpu-trend', samples.map(s => s.cpu_percent), '#3b82f6');
+            plotHealthTrend('health-mem-trend', samples.map(s => share(s.memory_used, s.memory_total)), '#a855f7');
+            plotHealthTrend('health-disk-trend', samples.map(s => share(s.disk_used, s.disk_total)), '#22c55e');
+
+            const forecast = document.getElementById('disk-forecast');
+            const days = data.disk_full_in_days;
+            forecast.classList.toggle('hidden', days === undefined);
+            if (days !== undefined) {
+                forecast.textContent = days < 1 ? 'Disk full in less than a day' : 'Disk full in ~' + Math.round(days) + ' days';
+                forecast.className = 'text-xs mt-1 ' + (days < 7 ? 'text-red-600' : days < 30 ? 'text-yellow-600' : 'text-gray-500');
+            }
+        })
+        .catch(err => console.error('Failed to load system health history:', err));
+}
+document.getElementById('health-range').addEventListener('change', loadHealthHistory);
+loadHealthHistory();
+setInterval(loadHealthHistory, 60000);
@@ -0,0 +1,77 @@
+// The Cloudflare Tunnel settings: its status, config and start and stop buttons
+
+// Load tunnel status on page load
+fetch('/api/settings/tunnel-status')
+    .then(response => response.json())
+    .then(data => {
+        const statusDisplay = document.getElementById('tunnel-status-display');
+        const indicator = document.getElementById('tunnel-status-indicator');
+        const statusText = document.getElementById('tunnel-status-text');
+        const startBtn = document.getElementById('tunnel-start-btn');
+        const stopBtn = document.getElementById('tunnel-stop-btn');
+        const domainInput = document.getElementById('tunnel-domain-input');
+
+        if (data.domain) {
+            domainInput.value = data.domain;
+        }
+
+        if (data.configured) {
+            statusDisplay.classList.remove('hidden');
+            if (data.running) {
+                indicator.className = 'w-3 h-3 rounded-full mr-3 bg-green-500';
+                statusText.textContent = 'Tunnel is running';
+                stopBtn.classList.remove('hidden');
+            } else {
+                indicator.className = 'w-3 h-3 rounded-full mr-3 bg-gray-400';
+                statusText.textContent = 'Tunnel is stopped';
+                startBtn.classList.remove('hidden');
+            }
+        }
+    });
+
+function submitTunnelConfig(event) {
+    event.preventDefault();
+    const form = event.target;
+    const data = {
+        tunnel_token: form.querySelector('input[name="tunnel_token"]').value,
+        domain: form.querySelector('input[name="domain"]').value,
+        tunnel_id: form.querySelector('input[name="tunnel_id"]').value,
+        api_token: form.querySelector('input[name="api_token"]').value
+    };
+
+    fetch('/api/settings/tunnel', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(data)
+    })
+    .then(response => {
+        if (response.ok) {
+            alert('Tunnel configuration saved');
+            window.location.reload();
+        } else {
+            response.text().then(text => alert('Failed to save: ' + text));
+        }
+    });
+}
+
+function startTunnel() {
+    fetch('/api/settings/tunnel/start', { method: 'POST' })
+        .then(response => {
+            if (response.ok) {
+                window.location.reload();
+            } else {
+                response.text().then(text => alert('Failed to start tunnel: ' + text));
+            }
+        });
+}
+
+function stopTunnel() {
+    fetch('/api/settings/tunnel/stop', { method: 'POST' })
+        .then(response => {
+            if (response.ok) {
+                window.location.reload();
+            } else {
+                response.text().then(text => alert('Failed to stop tunnel: ' + text));
+            }
+        });
+}
@@ -0,0 +1,23 @@
+// The update banner's button updating Schooner to the latest release
+
+(function() {
+    const button = document.getElementById('update-button');
+    if (!button) return;
+    button.addEventListener('click', () => {
+        if (!confirm('Update Schooner to ' + button.dataset.tag + '? It restarts, and running pages reconnect once it is back.')) {
+            return;
+        }
+        button.disabled = true;
+        button.textContent = 'Updating…';
+        fetch('/api/update', { method: 'POST' })
+        .then(response => {
+            if (response.ok) {
+                setTimeout(() => window.location.reload(), 15000);
+            } else {
+                button.disabled = false;
+                button.textContent = 'Update now';
+                response.text().then(text => alert('Failed to update Schooner: ' + text));
+            }
+        });
+    });
+})();
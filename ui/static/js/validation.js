@@ -0,0 +1,38 @@
+// Checks an app's compose file or Dockerfile and lists the problems found
+
+function validateApp() {
+    const panel = document.getElementById('validation');
+    const results = document.getElementById('validation-results');
+    const escape = text => {
+        const div = document.createElement('div');
+        div.textContent = text || '';
+        return div.innerHTML;
+    };
+    panel.classList.remove('hidden');
+    results.innerHTML = '<span class="text-gray-400">Updating the checkout and checking the build files...</span>';
+    document.getElementById('validation-commit').textContent = '';
+
+    fetch('/api/apps/' + panel.dataset.appId + '/validate', { method: 'POST' })
+        .then(response => {
+            if (!response.ok) {
+                return response.text().then(text => { throw new Error(text); });
+            }
+            return response.json();
+        })
+        .then(result => {
+            if (result.commit) {
+                document.getElementById('validation-commit').textContent = result.commit.substring(0, 8);
+            }
+            if (result.valid) {
+                results.innerHTML = '<span class="text-green-700">No problems found (' + escape(result.strategy) + ')</span>';
+                return;
+            }
+            results.innerHTML = '<ul class="space-y-1">' + result.errors.map(e => {
+                const location = e.file ? escape(e.file) + (e.line ? ':' + e.line : '') + ': ' : '';
+                return '<li class="font-mono text-red-600">' + location + escape(e.message) + '</li>';
+            }).join('') + '</ul>';
+        })
+        .catch(err => {
+            results.innerHTML = '<span class="text-red-600">' + escape(err.message) + '</span>';
+        });
+}
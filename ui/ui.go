@@ -0,0 +1,16 @@
+// Package ui holds the dashboard's page templates and static assets, built
+// into the binary so it runs from any directory
+package ui
+
+import "embed"
+
+// Templates are the page templates under pages/ and the components under
+// components/ they share
+//
+//go:embed pages components
+var Templates embed.FS
+
+// Static holds the stylesheets, scripts and images served under /static
+//
+//go:embed static
+var Static embed.FS